@@ -221,10 +221,29 @@ func (m *CCManager) HeartbeatLoop() {
 			return
 		case <-ticker.C:
 			m.sendHeartbeat()
+			// HTTP 短暂不可用但 C&C 未断开时不会触发重连，由心跳周期兜底重放
+			if spool := m.apiClient.Spool(); spool != nil && spool.HasPending(0) {
+				go m.ReplaySpool("heartbeat")
+			}
 		}
 	}
 }
 
+// ReplaySpool uploads items left in the local spool while the server was unreachable
+func (m *CCManager) ReplaySpool(reason string) {
+	if m.apiClient.Spool() == nil {
+		return
+	}
+	replayed, err := m.apiClient.ReplaySpool()
+	if err != nil {
+		log.Printf("[Spool] Replay (%s) stopped after %d items: %v", reason, replayed, err)
+		return
+	}
+	if replayed > 0 {
+		log.Printf("[Spool] Replay (%s) uploaded %d items", reason, replayed)
+	}
+}
+
 // sendHeartbeat sends heartbeat message
 func (m *CCManager) sendHeartbeat() {
 	// Get CPU and memory usage
@@ -413,8 +432,8 @@ func (m *CCManager) executeTask(taskID uint, workspaceID string, action string)
 	if task.TaskType == "plan_and_apply" {
 		// Plan+apply tasks use the apply slot
 		m.applyRunning = true
-	} else if task.TaskType == "plan" {
-		// Pure plan tasks use plan slots
+	} else if task.TaskType == "plan" || task.TaskType == "test" {
+		// Pure plan tasks and module test tasks use plan slots
 		m.planRunning++
 	}
 	m.currentTasks = append(m.currentTasks, taskID)
@@ -428,7 +447,7 @@ func (m *CCManager) executeTask(taskID uint, workspaceID string, action string)
 		m.statusMutex.Lock()
 		if task.TaskType == "plan_and_apply" {
 			m.applyRunning = false
-		} else if task.TaskType == "plan" {
+		} else if task.TaskType == "plan" || task.TaskType == "test" {
 			m.planRunning--
 		}
 		// Remove task from current tasks
@@ -470,6 +489,9 @@ func (m *CCManager) executeTask(taskID uint, workspaceID string, action string)
 	if action == "apply" {
 		log.Printf("[Agent] Executing apply for task %d", taskID)
 		execErr = taskExecutor.ExecuteApply(ctx, task)
+	} else if task.TaskType == "test" {
+		log.Printf("[Agent] Executing module test for task %d", taskID)
+		execErr = taskExecutor.ExecuteTest(ctx, task)
 	} else {
 		log.Printf("[Agent] Executing plan for task %d", taskID)
 		execErr = taskExecutor.ExecutePlan(ctx, task)
@@ -706,6 +728,8 @@ func (m *CCManager) reconnect() {
 				}
 			} else {
 				log.Printf("[Reconnect] Successfully reconnected on attempt #%d", attempt)
+				// 上传断线期间写入本地 spool 的 State/Plan/日志
+				go m.ReplaySpool("reconnect")
 				return
 			}
 		}
@@ -54,6 +54,19 @@ func main() {
 	apiClient := services.NewAgentAPIClient(fullAPIURL, agentToken)
 	log.Printf("API client created with base URL: %s", fullAPIURL)
 
+	// State/Plan/日志上传前先写入本地 spool，服务端不可用时不丢失，重连后重放
+	spoolDir := os.Getenv("IAC_AGENT_SPOOL_DIR")
+	if spoolDir == "" {
+		spoolDir = "/var/lib/iac-agent/spool"
+	}
+	if spool, err := services.NewAgentSpool(spoolDir); err != nil {
+		log.Printf("Warning: Failed to initialize upload spool at %s: %v", spoolDir, err)
+		log.Printf("Agent will upload without a local spool")
+	} else {
+		apiClient.SetSpool(spool)
+		log.Printf("Upload spool enabled at %s", spoolDir)
+	}
+
 	// 3. Register agent with retry logic
 	log.Printf("Registering agent (with exponential backoff: 2s, 4s, 8s, 16s, then 60s)...")
 	var agentID, poolID string
@@ -121,6 +134,9 @@ func main() {
 		log.Printf("Connected to C&C channel successfully")
 	}
 
+	// Upload items spooled before the last restart
+	go ccManager.ReplaySpool("startup")
+
 	// 9. Start heartbeat loop
 	log.Printf("Starting heartbeat loop...")
 	go ccManager.HeartbeatLoop()
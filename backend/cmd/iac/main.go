@@ -0,0 +1,29 @@
+// iac - 平台命令行工具
+//
+// Usage:
+//
+//	iac login --address https://iac.example.com            # 粘贴个人/团队 API Token
+//	iac login --address https://iac.example.com --sso okta # 浏览器 SSO 登录
+//	iac workspace list --output json
+//	iac run plan ws-xxxx --follow
+//	iac state pull ws-xxxx > terraform.tfstate
+//	iac var set ws-xxxx region=us-east-1
+//
+// CI 中可以不执行 login，直接设置 IAC_ADDRESS 和 IAC_TOKEN 环境变量。
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"iac-platform/internal/cli"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	code := cli.New().Run(ctx, os.Args[1:])
+	stop()
+	os.Exit(code)
+}
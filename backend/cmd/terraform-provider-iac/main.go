@@ -0,0 +1,38 @@
+// terraform-provider-iac - 平台的 Terraform Provider
+//
+// 构建后放入 Terraform 插件目录，或在 ~/.terraformrc 中用 dev_overrides 指向构建目录：
+//
+//	provider_installation {
+//	  dev_overrides { "registry.terraform.io/iac-platform/iac" = "/path/to/bin" }
+//	  direct {}
+//	}
+//
+// 使用 -debug 启动可配合 TF_REATTACH_PROVIDERS 调试。
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"iac-platform/internal/tfprovider"
+
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+)
+
+// version 发布时通过 -ldflags "-X main.version=..." 注入
+var version = "dev"
+
+func main() {
+	var debug bool
+	flag.BoolVar(&debug, "debug", false, "start the provider in debug mode")
+	flag.Parse()
+
+	err := providerserver.Serve(context.Background(), tfprovider.New(version), providerserver.ServeOpts{
+		Address: "registry.terraform.io/iac-platform/iac",
+		Debug:   debug,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 固定的测试Token
+const TEST_TOKEN = "test-vault-token-12345"
+
+// kvSecret 一个 KV 路径下的密文及其版本
+type kvSecret struct {
+	Data      map[string]interface{}
+	Version   int
+	UpdatedAt time.Time
+}
+
+// kvStore 内存中的 KV 存储，键为 "<mount>/<path>"
+type kvStore struct {
+	mu      sync.RWMutex
+	secrets map[string]*kvSecret
+}
+
+func (s *kvStore) get(key string) (*kvSecret, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	secret, ok := s.secrets[key]
+	return secret, ok
+}
+
+func (s *kvStore) put(key string, data map[string]interface{}) *kvSecret {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	secret, ok := s.secrets[key]
+	if !ok {
+		secret = &kvSecret{}
+		s.secrets[key] = secret
+	}
+	secret.Data = data
+	secret.Version++
+	secret.UpdatedAt = time.Now()
+	return secret
+}
+
+// splitKVPath 解析 /v1/<mount>/data/<path>（KV v2）或 /v1/<mount>/<path>（KV v1）
+func splitKVPath(raw string) (key string, v2 bool) {
+	raw = strings.Trim(raw, "/")
+	mount, rest, _ := strings.Cut(raw, "/")
+	if strings.HasPrefix(rest, "data/") {
+		return mount + "/" + strings.TrimPrefix(rest, "data/"), true
+	}
+	return mount + "/" + rest, false
+}
+
+// 预置的测试数据，与平台文档中的示例引用对应
+func seedData(store *kvStore) {
+	store.put("kv/team/db", map[string]interface{}{"username": "app", "password": "db-password-123"})
+	store.put("kv/team/aws", map[string]interface{}{"access_key": "AKIAEXAMPLE", "secret_key": "aws-secret-key-123"})
+	store.put("secret/legacy/api", map[string]interface{}{"token": "legacy-token-123"})
+}
+
+func main() {
+	port := flag.Int("port", 18200, "listen port")
+	flag.Parse()
+
+	store := &kvStore{secrets: map[string]*kvSecret{}}
+	seedData(store)
+
+	// 设置Gin为release模式
+	gin.SetMode(gin.ReleaseMode)
+
+	r := gin.Default()
+
+	// 认证：除健康检查外都需要 Token
+	r.Use(func(c *gin.Context) {
+		if c.Request.URL.Path == "/v1/sys/health" {
+			c.Next()
+			return
+		}
+		if c.GetHeader("X-Vault-Token") != TEST_TOKEN {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"errors": []string{"permission denied"}})
+			return
+		}
+		c.Next()
+	})
+
+	r.GET("/v1/*path", func(c *gin.Context) {
+		// 健康检查端点（无需认证），与 Vault 的 sys/health 路径一致
+		if c.Param("path") == "/sys/health" {
+			c.JSON(http.StatusOK, gin.H{
+				"initialized": true,
+				"sealed":      false,
+				"standby":     false,
+				"version":     "test-stand-in",
+			})
+			return
+		}
+
+		key, v2 := splitKVPath(c.Param("path"))
+		secret, ok := store.get(key)
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"errors": []string{}})
+			return
+		}
+		if !v2 {
+			c.JSON(http.StatusOK, gin.H{"data": secret.Data})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"data": gin.H{
+				"data": secret.Data,
+				"metadata": gin.H{
+					"created_time": secret.UpdatedAt.Format(time.RFC3339Nano),
+					"version":      secret.Version,
+				},
+			},
+		})
+	})
+
+	write := func(c *gin.Context) {
+		key, v2 := splitKVPath(c.Param("path"))
+		var body map[string]interface{}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"errors": []string{err.Error()}})
+			return
+		}
+		data := body
+		if v2 {
+			inner, ok := body["data"].(map[string]interface{})
+			if !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"errors": []string{"no data provided"}})
+				return
+			}
+			data = inner
+		}
+		secret := store.put(key, data)
+		if !v2 {
+			c.Status(http.StatusNoContent)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": gin.H{"version": secret.Version}})
+	}
+	r.POST("/v1/*path", write)
+	r.PUT("/v1/*path", write)
+
+	addr := fmt.Sprintf(":%d", *port)
+	log.Printf("Vault KV test stand-in listening on %s (token: %s)", addr, TEST_TOKEN)
+	log.Printf("Example reference: vault://kv/data/team/db#password")
+	if err := r.Run(addr); err != nil {
+		log.Fatalf("Failed to start server: %v", err)
+	}
+}
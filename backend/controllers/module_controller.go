@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
@@ -10,6 +11,7 @@ import (
 	"iac-platform/services"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type ModuleController struct {
@@ -119,28 +121,18 @@ func (mc *ModuleController) GetModule(c *gin.Context) {
 
 	module, err := mc.moduleService.GetModuleByID(uint(id))
 	if err != nil {
-		// 返回模拟数据
-		mockModule := models.Module{
-			ID:          uint(id),
-			Name:        "aws-vpc",
-			Provider:    "AWS",
-			Source:      "terraform-aws-modules/vpc/aws",
-			Version:     "1.0.0",
-			Description: "AWS VPC模块，用于创建虚拟私有云网络环境",
-			SyncStatus:  "synced",
-			CreatedAt:   time.Now(),
-			UpdatedAt:   time.Now(),
-		}
-		if id == 2 {
-			mockModule.Name = "azure-vm"
-			mockModule.Provider = "Azure"
-			mockModule.Source = "Azure/compute/azurerm"
-			mockModule.Version = "2.1.0"
-			mockModule.Description = "Azure虚拟机模块，用于创建和管理Azure云虚拟机"
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"code":      404,
+				"message":   "模块不存在",
+				"timestamp": time.Now().Format(time.RFC3339),
+			})
+			return
 		}
-		c.JSON(http.StatusOK, gin.H{
-			"code":      200,
-			"data":      mockModule,
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":      500,
+			"message":   "获取模块失败",
+			"error":     err.Error(),
 			"timestamp": time.Now().Format(time.RFC3339),
 		})
 		return
@@ -259,6 +251,8 @@ func (mc *ModuleController) UpdateModule(c *gin.Context) {
 		Branch       string            `json:"branch"`
 		Status       string            `json:"status"`
 		AIPrompts    []models.AIPrompt `json:"ai_prompts"`
+		// 设置默认版本前是否要求测试通过
+		RequirePassingTest *bool `json:"require_passing_test"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -311,6 +305,9 @@ func (mc *ModuleController) UpdateModule(c *gin.Context) {
 	if req.AIPrompts != nil {
 		module.AIPrompts = req.AIPrompts
 	}
+	if req.RequirePassingTest != nil {
+		module.RequirePassingTest = *req.RequirePassingTest
+	}
 
 	if err := mc.moduleService.UpdateModuleFields(module); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"iac-platform/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// WorkspaceExecutionChecker 检查当前调用方能否在指定 workspace 中执行任务
+type WorkspaceExecutionChecker func(ctx *gin.Context, workspaceID string) (bool, error)
+
+// ModuleTestController 模块版本测试控制器
+type ModuleTestController struct {
+	service        *services.ModuleTestService
+	queueManager   *services.TaskQueueManager
+	canExecuteInWS WorkspaceExecutionChecker
+}
+
+// NewModuleTestController 创建模块版本测试控制器
+// canExecuteInWS 用于校验调用方对执行 workspace 的执行权限（测试会使用该 workspace 的凭证与 Agent Pool）
+func NewModuleTestController(db *gorm.DB, queueManager *services.TaskQueueManager, canExecuteInWS WorkspaceExecutionChecker) *ModuleTestController {
+	return &ModuleTestController{
+		service:        services.NewModuleTestService(db),
+		queueManager:   queueManager,
+		canExecuteInWS: canExecuteInWS,
+	}
+}
+
+// CreateTestRun 为模块版本发起测试运行
+// @Summary 运行模块版本测试
+// @Description 在执行 workspace 中创建 test 任务，运行 terraform test / tofu test（同步的 .tftest.hcl 与 demo 生成的测试）
+// @Tags ModuleVersion
+// @Accept json
+// @Produce json
+// @Param id path int true "Module ID"
+// @Param version_id path string true "Version ID"
+// @Param body body services.CreateModuleTestRunRequest true "测试请求"
+// @Success 201 {object} models.ModuleTestRun
+// @Router /api/v1/modules/{id}/versions/{version_id}/test-runs [post]
+func (c *ModuleTestController) CreateTestRun(ctx *gin.Context) {
+	moduleID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid module ID"})
+		return
+	}
+
+	var req services.CreateModuleTestRunRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	// 测试任务使用执行 workspace 的云凭证、Provider 配置与 Agent Pool，需要该 workspace 的执行权限
+	allowed := false
+	if c.canExecuteInWS != nil {
+		allowed, err = c.canExecuteInWS(ctx, req.WorkspaceID)
+		if err != nil {
+			log.Printf("[ModuleTest] Failed to check execution permission on workspace %s: %v", req.WorkspaceID, err)
+			allowed = false
+		}
+	}
+	if !allowed {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "WORKSPACE_EXECUTION write permission on workspace " + req.WorkspaceID + " is required to run module tests in it"})
+		return
+	}
+
+	userID := ""
+	if uid, exists := ctx.Get("user_id"); exists {
+		if id, ok := uid.(string); ok {
+			userID = id
+		}
+	}
+
+	run, err := c.service.CreateRun(uint(moduleID), ctx.Param("version_id"), &req, userID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if c.queueManager != nil {
+		go func() {
+			if err := c.queueManager.TryExecuteNextTask(run.WorkspaceID); err != nil {
+				log.Printf("[ModuleTest] Failed to schedule test task for run %s: %v", run.RunID, err)
+			}
+		}()
+	}
+
+	ctx.JSON(http.StatusCreated, run)
+}
+
+// ListTestRuns 获取模块版本的测试运行列表
+// @Summary 获取模块版本测试运行列表
+// @Tags ModuleVersion
+// @Produce json
+// @Param id path int true "Module ID"
+// @Param version_id path string true "Version ID"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/modules/{id}/versions/{version_id}/test-runs [get]
+func (c *ModuleTestController) ListTestRuns(ctx *gin.Context) {
+	moduleID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid module ID"})
+		return
+	}
+
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(ctx.DefaultQuery("page_size", "20"))
+
+	runs, total, err := c.service.ListRuns(uint(moduleID), ctx.Param("version_id"), page, pageSize)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"items":     runs,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// GetTestRun 获取测试运行详情（含每个 run 块的结果）
+// @Summary 获取模块测试运行详情
+// @Tags ModuleVersion
+// @Produce json
+// @Param id path int true "Module ID"
+// @Param run_id path string true "Test Run ID"
+// @Success 200 {object} models.ModuleTestRun
+// @Router /api/v1/modules/{id}/test-runs/{run_id} [get]
+func (c *ModuleTestController) GetTestRun(ctx *gin.Context) {
+	moduleID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid module ID"})
+		return
+	}
+
+	run, err := c.service.GetRun(ctx.Param("run_id"))
+	if err != nil || run.ModuleID != uint(moduleID) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "test run not found"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, run)
+}
@@ -0,0 +1,34 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModuleTestController_CreateTestRunRequiresWorkspaceExecution(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var checked string
+	controller := NewModuleTestController(nil, nil, func(ctx *gin.Context, workspaceID string) (bool, error) {
+		checked = workspaceID
+		return false, nil
+	})
+	router := gin.New()
+	router.POST("/modules/:id/versions/:version_id/test-runs", controller.CreateTestRun)
+
+	req, _ := http.NewRequest("POST", "/modules/1/versions/modv-1/test-runs",
+		strings.NewReader(`{"workspace_id":"ws-prod","demo_test_command":"apply"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// 未授权时在创建任务前拒绝（service 未初始化数据库，走到创建会 panic）
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, "ws-prod", checked)
+	assert.Contains(t, w.Body.String(), "WORKSPACE_EXECUTION")
+}
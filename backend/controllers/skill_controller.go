@@ -5,6 +5,7 @@ import (
 	"iac-platform/services"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -15,6 +16,7 @@ import (
 type SkillController struct {
 	db             *gorm.DB
 	skillAssembler *services.SkillAssembler
+	evalService    *services.SkillEvalService
 }
 
 // NewSkillController 创建控制器实例
@@ -22,6 +24,7 @@ func NewSkillController(db *gorm.DB) *SkillController {
 	return &SkillController{
 		db:             db,
 		skillAssembler: services.NewSkillAssembler(db),
+		evalService:    services.NewSkillEvalService(db),
 	}
 }
 
@@ -213,15 +216,18 @@ func (c *SkillController) CreateSkill(ctx *gin.Context) {
 
 // UpdateSkill 更新 Skill
 // @Summary 更新 Skill
-// @Description 更新 Skill 信息
+// @Description 更新 Skill 信息。修改内容时检查开启了 gate_skill_edits 的评估套件：
+// @Description 候选内容的对比评估回归时返回 409（force=true 可强制保存），未评估或没有结论时在 eval_warnings 中提示
 // @Tags Skill
 // @Accept json
 // @Produce json
 // @Param id path string true "Skill ID"
+// @Param force query bool false "忽略评估回归强制保存"
 // @Param request body models.UpdateSkillRequest true "更新信息"
 // @Success 200 {object} models.Skill
 // @Failure 400 {object} map[string]string
 // @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]interface{}
 // @Router /api/v1/admin/skills/{id} [put]
 func (c *SkillController) UpdateSkill(ctx *gin.Context) {
 	skillID := ctx.Param("id")
@@ -249,6 +255,28 @@ func (c *SkillController) UpdateSkill(ctx *gin.Context) {
 		return
 	}
 
+	// 修改内容前检查评估门禁
+	var evalWarnings []string
+	if req.Content != nil && *req.Content != skill.Content {
+		gate, err := c.evalService.CheckSkillEdit(skill.Name, *req.Content)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "检查 Skill 评估失败",
+				"details": err.Error(),
+			})
+			return
+		}
+		if gate.Blocked && ctx.Query("force") != "true" {
+			ctx.JSON(http.StatusConflict, gin.H{
+				"error":    "修改后的内容在评估中出现回归: " + strings.Join(gate.Reasons, "; "),
+				"reasons":  gate.Reasons,
+				"warnings": gate.Warnings,
+			})
+			return
+		}
+		evalWarnings = append(gate.Reasons, gate.Warnings...)
+	}
+
 	// 更新字段
 	if req.DisplayName != nil {
 		skill.DisplayName = *req.DisplayName
@@ -287,6 +315,13 @@ func (c *SkillController) UpdateSkill(ctx *gin.Context) {
 	// 清除缓存
 	c.skillAssembler.ClearCache()
 
+	if len(evalWarnings) > 0 {
+		ctx.JSON(http.StatusOK, struct {
+			models.Skill
+			EvalWarnings []string `json:"eval_warnings"`
+		}{skill, evalWarnings})
+		return
+	}
 	ctx.JSON(http.StatusOK, skill)
 }
 
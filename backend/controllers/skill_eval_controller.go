@@ -0,0 +1,339 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"iac-platform/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SkillEvalController Skill 评估控制器
+type SkillEvalController struct {
+	service *services.SkillEvalService
+}
+
+// NewSkillEvalController 创建 Skill 评估控制器
+func NewSkillEvalController(db *gorm.DB) *SkillEvalController {
+	return &SkillEvalController{
+		service: services.NewSkillEvalService(db),
+	}
+}
+
+// ListSuites 获取评估套件列表
+// @Summary 获取 Skill 评估套件列表
+// @Tags Skill
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/admin/skill-evals/suites [get]
+func (c *SkillEvalController) ListSuites(ctx *gin.Context) {
+	suites, err := c.service.ListSuites()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"items": suites, "total": len(suites)})
+}
+
+// GetSuite 获取评估套件详情（含用例）
+// @Summary 获取 Skill 评估套件详情
+// @Tags Skill
+// @Produce json
+// @Param id path int true "Suite ID"
+// @Success 200 {object} models.SkillEvalSuite
+// @Router /api/v1/admin/skill-evals/suites/{id} [get]
+func (c *SkillEvalController) GetSuite(ctx *gin.Context) {
+	id, ok := parseSkillEvalID(ctx, "id")
+	if !ok {
+		return
+	}
+	suite, err := c.service.GetSuite(id)
+	if err != nil {
+		respondSkillEvalError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, suite)
+}
+
+// CreateSuite 创建评估套件
+// @Summary 创建 Skill 评估套件
+// @Description 套件包含一组用户意图 + Module + 期望约束的用例；开启 gate_skill_edits 后，修改套件用到的 Skill 需要先通过对比评估
+// @Tags Skill
+// @Accept json
+// @Produce json
+// @Param body body services.SkillEvalSuiteRequest true "套件"
+// @Success 201 {object} models.SkillEvalSuite
+// @Router /api/v1/admin/skill-evals/suites [post]
+func (c *SkillEvalController) CreateSuite(ctx *gin.Context) {
+	var req services.SkillEvalSuiteRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	suite, err := c.service.CreateSuite(&req, ctx.GetString("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, suite)
+}
+
+// UpdateSuite 更新评估套件
+// @Summary 更新 Skill 评估套件
+// @Tags Skill
+// @Accept json
+// @Produce json
+// @Param id path int true "Suite ID"
+// @Param body body services.SkillEvalSuiteRequest true "套件"
+// @Success 200 {object} models.SkillEvalSuite
+// @Router /api/v1/admin/skill-evals/suites/{id} [put]
+func (c *SkillEvalController) UpdateSuite(ctx *gin.Context) {
+	id, ok := parseSkillEvalID(ctx, "id")
+	if !ok {
+		return
+	}
+	var req services.SkillEvalSuiteRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	suite, err := c.service.UpdateSuite(id, &req)
+	if err != nil {
+		respondSkillEvalError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, suite)
+}
+
+// DeleteSuite 删除评估套件
+// @Summary 删除 Skill 评估套件
+// @Description 同时删除用例、运行记录和录制的 AI 响应
+// @Tags Skill
+// @Param id path int true "Suite ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/admin/skill-evals/suites/{id} [delete]
+func (c *SkillEvalController) DeleteSuite(ctx *gin.Context) {
+	id, ok := parseSkillEvalID(ctx, "id")
+	if !ok {
+		return
+	}
+	if err := c.service.DeleteSuite(id); err != nil {
+		respondSkillEvalError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "评估套件已删除"})
+}
+
+// CreateCase 添加评估用例
+// @Summary 添加 Skill 评估用例
+// @Tags Skill
+// @Accept json
+// @Produce json
+// @Param id path int true "Suite ID"
+// @Param body body services.SkillEvalCaseRequest true "用例"
+// @Success 201 {object} models.SkillEvalCase
+// @Router /api/v1/admin/skill-evals/suites/{id}/cases [post]
+func (c *SkillEvalController) CreateCase(ctx *gin.Context) {
+	suiteID, ok := parseSkillEvalID(ctx, "id")
+	if !ok {
+		return
+	}
+	var req services.SkillEvalCaseRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	evalCase, err := c.service.CreateCase(suiteID, &req)
+	if err != nil {
+		respondSkillEvalError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, evalCase)
+}
+
+// UpdateCase 更新评估用例
+// @Summary 更新 Skill 评估用例
+// @Description 用户意图、Module 或当前配置变化时，删除该用例已录制的 AI 响应
+// @Tags Skill
+// @Accept json
+// @Produce json
+// @Param id path int true "Suite ID"
+// @Param case_id path int true "Case ID"
+// @Param body body services.SkillEvalCaseRequest true "用例"
+// @Success 200 {object} models.SkillEvalCase
+// @Router /api/v1/admin/skill-evals/suites/{id}/cases/{case_id} [put]
+func (c *SkillEvalController) UpdateCase(ctx *gin.Context) {
+	suiteID, ok := parseSkillEvalID(ctx, "id")
+	if !ok {
+		return
+	}
+	caseID, ok := parseSkillEvalID(ctx, "case_id")
+	if !ok {
+		return
+	}
+	var req services.SkillEvalCaseRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	evalCase, err := c.service.UpdateCase(suiteID, caseID, &req)
+	if err != nil {
+		respondSkillEvalError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, evalCase)
+}
+
+// DeleteCase 删除评估用例
+// @Summary 删除 Skill 评估用例
+// @Tags Skill
+// @Param id path int true "Suite ID"
+// @Param case_id path int true "Case ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/admin/skill-evals/suites/{id}/cases/{case_id} [delete]
+func (c *SkillEvalController) DeleteCase(ctx *gin.Context) {
+	suiteID, ok := parseSkillEvalID(ctx, "id")
+	if !ok {
+		return
+	}
+	caseID, ok := parseSkillEvalID(ctx, "case_id")
+	if !ok {
+		return
+	}
+	if err := c.service.DeleteCase(suiteID, caseID); err != nil {
+		respondSkillEvalError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "评估用例已删除"})
+}
+
+// RunSuite 运行评估套件
+// @Summary 运行 Skill 评估套件
+// @Description live 模式调用模型并录制响应，replay 模式只回放录制的响应。运行在后台执行，通过运行详情查询结果
+// @Tags Skill
+// @Accept json
+// @Produce json
+// @Param id path int true "Suite ID"
+// @Param body body services.RunSkillEvalRequest false "运行参数"
+// @Success 202 {object} models.SkillEvalRun
+// @Router /api/v1/admin/skill-evals/suites/{id}/runs [post]
+func (c *SkillEvalController) RunSuite(ctx *gin.Context) {
+	suiteID, ok := parseSkillEvalID(ctx, "id")
+	if !ok {
+		return
+	}
+	var req services.RunSkillEvalRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+			return
+		}
+	}
+	run, err := c.service.CreateRun(suiteID, &req, ctx.GetString("user_id"))
+	if err != nil {
+		respondSkillEvalError(ctx, err)
+		return
+	}
+
+	go func() {
+		if err := c.service.ExecuteRun(run.RunID); err != nil {
+			log.Printf("[SkillEval] Failed to execute run %s: %v", run.RunID, err)
+		}
+	}()
+
+	ctx.JSON(http.StatusAccepted, run)
+}
+
+// CompareSkill 对比评估 Skill 的候选内容
+// @Summary 对比 Skill 内容的评估结果
+// @Description 以当前内容（或 baseline_content）为 baseline、候选内容为 candidate 各运行一次套件，结论记录在 candidate 运行上
+// @Tags Skill
+// @Accept json
+// @Produce json
+// @Param id path int true "Suite ID"
+// @Param body body services.CompareSkillEvalRequest true "对比参数"
+// @Success 202 {object} map[string]interface{}
+// @Router /api/v1/admin/skill-evals/suites/{id}/compare [post]
+func (c *SkillEvalController) CompareSkill(ctx *gin.Context) {
+	suiteID, ok := parseSkillEvalID(ctx, "id")
+	if !ok {
+		return
+	}
+	var req services.CompareSkillEvalRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	baseline, candidate, err := c.service.CreateComparison(suiteID, &req, ctx.GetString("user_id"))
+	if err != nil {
+		respondSkillEvalError(ctx, err)
+		return
+	}
+
+	go func() {
+		if err := c.service.ExecuteComparison(baseline.RunID, candidate.RunID); err != nil {
+			log.Printf("[SkillEval] Failed to execute comparison %s/%s: %v", baseline.RunID, candidate.RunID, err)
+		}
+	}()
+
+	ctx.JSON(http.StatusAccepted, gin.H{"baseline": baseline, "candidate": candidate})
+}
+
+// ListRuns 获取套件的运行记录
+// @Summary 获取 Skill 评估运行列表
+// @Tags Skill
+// @Produce json
+// @Param id path int true "Suite ID"
+// @Param limit query int false "数量" default(20)
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/admin/skill-evals/suites/{id}/runs [get]
+func (c *SkillEvalController) ListRuns(ctx *gin.Context) {
+	suiteID, ok := parseSkillEvalID(ctx, "id")
+	if !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	runs, err := c.service.ListRuns(suiteID, limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"items": runs})
+}
+
+// GetRun 获取运行详情（含用例结果，candidate 运行附带对比）
+// @Summary 获取 Skill 评估运行详情
+// @Tags Skill
+// @Produce json
+// @Param run_id path string true "Run ID"
+// @Success 200 {object} services.SkillEvalRunDetail
+// @Router /api/v1/admin/skill-evals/runs/{run_id} [get]
+func (c *SkillEvalController) GetRun(ctx *gin.Context) {
+	run, err := c.service.GetRun(ctx.Param("run_id"))
+	if err != nil {
+		respondSkillEvalError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, run)
+}
+
+func parseSkillEvalID(ctx *gin.Context, param string) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param(param), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + param})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func respondSkillEvalError(ctx *gin.Context, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+	ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
@@ -2,11 +2,14 @@ package controllers
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
+	"iac-platform/internal/middleware"
 	"iac-platform/internal/models"
+	"iac-platform/services"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -567,6 +570,18 @@ func (svc *StateVersionController) RollbackState(c *gin.Context) {
 		return
 	}
 
+	ev := middleware.AuditEventFromContext(c, models.AuditEventStateRolledBack)
+	ev.ResourceType = "state_version"
+	ev.ResourceID = fmt.Sprintf("%s/%d", workspace.WorkspaceID, version)
+	ev.WorkspaceID = workspace.WorkspaceID
+	ev.Message = fmt.Sprintf("State rolled back to version %d", version)
+	ev.Details = models.JSONB{
+		"target_version": version,
+		"checksum":       stateVersion.Checksum,
+		"serial":         stateVersion.Serial,
+	}
+	services.NewAuditEventService(svc.db).Emit(ev)
+
 	c.JSON(http.StatusOK, gin.H{
 		"code":    200,
 		"message": "回滚成功",
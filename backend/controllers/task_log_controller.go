@@ -2,10 +2,12 @@ package controllers
 
 import (
 	"fmt"
+	"log"
 	"strings"
 	"time"
 
 	"iac-platform/internal/models"
+	"iac-platform/services"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -13,13 +15,15 @@ import (
 
 // TaskLogController 任务日志控制器
 type TaskLogController struct {
-	db *gorm.DB
+	db        *gorm.DB
+	artifacts *services.TaskArtifactService
 }
 
 // NewTaskLogController 创建控制器
 func NewTaskLogController(db *gorm.DB) *TaskLogController {
 	return &TaskLogController{
-		db: db,
+		db:        db,
+		artifacts: services.NewTaskArtifactService(db),
 	}
 }
 
@@ -47,6 +51,11 @@ func (c *TaskLogController) GetTaskLogs(ctx *gin.Context) {
 		return
 	}
 
+	// 已归档的输出从对象存储加载
+	if err := c.artifacts.HydrateTask(ctx.Request.Context(), &task, models.TaskArtifactPlanOutput, models.TaskArtifactApplyOutput); err != nil {
+		log.Printf("[TaskLog] Failed to load archived output of task %d: %v", task.ID, err)
+	}
+
 	// TODO: 检查权限
 	// userID := ctx.GetUint("user_id")
 	// if !c.checkPermission(userID, task.WorkspaceID) {
@@ -70,6 +79,9 @@ func (c *TaskLogController) GetTaskLogs(ctx *gin.Context) {
 		"duration":     task.Duration,
 		"logs":         gin.H{},
 	}
+	if status := c.artifacts.ArtifactStatus(task.ID); status != "" {
+		response["artifacts"] = status
+	}
 
 	if logType == "plan" || logType == "all" {
 		if task.PlanOutput != "" {
@@ -149,6 +161,11 @@ func (c *TaskLogController) DownloadTaskLogs(ctx *gin.Context) {
 		return
 	}
 
+	// 已归档的输出从对象存储加载
+	if err := c.artifacts.HydrateTask(ctx.Request.Context(), &task, models.TaskArtifactPlanOutput, models.TaskArtifactApplyOutput); err != nil {
+		log.Printf("[TaskLog] Failed to load archived output of task %d: %v", task.ID, err)
+	}
+
 	// TODO: 检查权限
 
 	var output strings.Builder
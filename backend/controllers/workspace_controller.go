@@ -5,6 +5,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
@@ -165,26 +166,18 @@ func (wc *WorkspaceController) GetWorkspace(c *gin.Context) {
 
 	workspace, err := wc.workspaceService.GetWorkspaceByID(workspaceID)
 	if err != nil {
-		// 返回模拟数据
-		mockWorkspace := models.Workspace{
-			WorkspaceID:      workspaceID,
-			Name:             "production",
-			Description:      "生产环境工作空间，用于部署生产级别的基础设施",
-			StateBackend:     "S3",
-			TerraformVersion: "1.5.0",
-			ExecutionMode:    "server",
-			CreatedAt:        time.Now(),
-			UpdatedAt:        time.Now(),
-		}
-		if workspaceID == "ws-staging" {
-			mockWorkspace.Name = "staging"
-			mockWorkspace.Description = "测试环境工作空间，用于开发和测试阶段的基础设施部署"
-			mockWorkspace.StateBackend = "Local"
-			mockWorkspace.ExecutionMode = "agent"
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"code":      404,
+				"message":   "工作空间不存在",
+				"timestamp": time.Now().Format(time.RFC3339),
+			})
+			return
 		}
-		c.JSON(http.StatusOK, gin.H{
-			"code":      200,
-			"data":      mockWorkspace,
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":      500,
+			"message":   "获取工作空间失败",
+			"error":     err.Error(),
 			"timestamp": time.Now().Format(time.RFC3339),
 		})
 		return
@@ -433,6 +426,76 @@ func (wc *WorkspaceController) CreateWorkspace(c *gin.Context) {
 	})
 }
 
+// CreateWorkspaceFromTemplate 从模板创建工作空间
+// @Summary 从模板创建工作空间
+// @Description 在同一事务中创建工作空间及模板定义的Run Tasks、通知、变量和初始资源，并记录模板关联
+// @Tags Workspace
+// @Accept json
+// @Produce json
+// @Param request body models.CreateWorkspaceFromTemplateRequest true "创建请求"
+// @Success 201 {object} map[string]interface{} "成功创建工作空间"
+// @Failure 400 {object} map[string]interface{} "请求参数无效或缺少必填变量"
+// @Failure 404 {object} map[string]interface{} "模板不存在"
+// @Failure 500 {object} map[string]interface{} "创建失败"
+// @Router /workspaces/from-template [post]
+// @Security Bearer
+func (wc *WorkspaceController) CreateWorkspaceFromTemplate(c *gin.Context) {
+	var req models.CreateWorkspaceFromTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":      400,
+			"message":   "请求参数无效",
+			"error":     err.Error(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	userID := c.GetString("user_id")
+	templateService := services.NewWorkspaceTemplateService(wc.workspaceService.GetDB())
+	workspace, err := templateService.CreateWorkspaceFromTemplate(&req, userID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		message := "从模板创建工作空间失败"
+		switch {
+		case errors.Is(err, services.ErrWorkspaceTemplateNotFound):
+			status = http.StatusNotFound
+			message = "工作空间模板不存在"
+		case errors.Is(err, services.ErrWorkspaceTemplateInvalid):
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{
+			"code":      status,
+			"message":   message,
+			"error":     err.Error(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	// 自动为创建者授予 ADMIN 权限
+	if wc.permissionService != nil && userID != "" {
+		wc.grantCreatorPermissions(workspace.ID, userID)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"code": 201,
+		"data": gin.H{
+			"id":               workspace.WorkspaceID,
+			"workspace_id":     workspace.WorkspaceID,
+			"name":             workspace.Name,
+			"description":      workspace.Description,
+			"execution_mode":   workspace.ExecutionMode,
+			"template_id":      workspace.TemplateID,
+			"template_version": workspace.TemplateVersion,
+			"state":            workspace.State,
+			"created_at":       workspace.CreatedAt,
+		},
+		"message":   "工作空间创建成功",
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
+
 // UpdateWorkspace 更新工作空间
 // @Summary 更新工作空间
 // @Description 更新工作空间的配置信息
@@ -462,13 +525,14 @@ func (wc *WorkspaceController) UpdateWorkspace(c *gin.Context) {
 
 	var req struct {
 		Name                   string                 `json:"name"`
-		Description            string                 `json:"description"`
+		Description            *string                `json:"description"`
 		TerraformVersion       string                 `json:"terraform_version"`
 		ExecutionMode          string                 `json:"execution_mode"`
 		AgentPoolID            *uint                  `json:"agent_pool_id"`
 		K8sConfigID            *uint                  `json:"k8s_config_id"`
 		Workdir                string                 `json:"workdir"`
 		AutoApply              *bool                  `json:"auto_apply"`
+		PlanOnly               *bool                  `json:"plan_only"`
 		UIMode                 string                 `json:"ui_mode"`
 		ShowUnchangedResources *bool                  `json:"show_unchanged_resources"`
 		Tags                   map[string]interface{} `json:"tags"`
@@ -476,6 +540,8 @@ func (wc *WorkspaceController) UpdateWorkspace(c *gin.Context) {
 		ProviderTemplateIDs    []uint                 `json:"provider_template_ids"`
 		ProviderOverrides      map[string]interface{} `json:"provider_overrides"`
 		NotifySettings         map[string]interface{} `json:"notify_settings"`
+		StaticAnalysisConfig   map[string]interface{} `json:"static_analysis_config"`
+		CostEstimationConfig   map[string]interface{} `json:"cost_estimation_config"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -508,14 +574,40 @@ func (wc *WorkspaceController) UpdateWorkspace(c *gin.Context) {
 		log.Printf("Provider validation passed")
 	}
 
+	// 校验静态分析配置
+	if req.StaticAnalysisConfig != nil {
+		if _, err := services.ParseStaticAnalysisConfig(req.StaticAnalysisConfig); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":      400,
+				"message":   "静态分析配置无效",
+				"error":     err.Error(),
+				"timestamp": time.Now().Format(time.RFC3339),
+			})
+			return
+		}
+	}
+
+	// 校验成本估算配置
+	if req.CostEstimationConfig != nil {
+		if _, err := services.ParseCostEstimationConfig(req.CostEstimationConfig); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":      400,
+				"message":   "成本估算配置无效",
+				"error":     err.Error(),
+				"timestamp": time.Now().Format(time.RFC3339),
+			})
+			return
+		}
+	}
+
 	// 构建更新字段
 	updates := make(map[string]interface{})
 
 	if req.Name != "" {
 		updates["name"] = req.Name
 	}
-	if req.Description != "" {
-		updates["description"] = req.Description
+	if req.Description != nil {
+		updates["description"] = *req.Description
 	}
 	if req.TerraformVersion != "" {
 		updates["terraform_version"] = req.TerraformVersion
@@ -535,6 +627,9 @@ func (wc *WorkspaceController) UpdateWorkspace(c *gin.Context) {
 	if req.AutoApply != nil {
 		updates["auto_apply"] = *req.AutoApply
 	}
+	if req.PlanOnly != nil {
+		updates["plan_only"] = *req.PlanOnly
+	}
 	if req.UIMode != "" {
 		updates["ui_mode"] = req.UIMode
 	}
@@ -574,6 +669,14 @@ func (wc *WorkspaceController) UpdateWorkspace(c *gin.Context) {
 	if req.NotifySettings != nil {
 		updates["notify_settings"] = req.NotifySettings
 	}
+	if req.StaticAnalysisConfig != nil {
+		staticAnalysisJSON, _ := json.Marshal(req.StaticAnalysisConfig)
+		updates["static_analysis_config"] = gorm.Expr("?::jsonb", string(staticAnalysisJSON))
+	}
+	if req.CostEstimationConfig != nil {
+		costEstimationJSON, _ := json.Marshal(req.CostEstimationConfig)
+		updates["cost_estimation_config"] = gorm.Expr("?::jsonb", string(costEstimationJSON))
+	}
 
 	log.Printf("Calling UpdateWorkspaceFields with %d updates", len(updates))
 
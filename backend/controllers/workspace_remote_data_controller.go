@@ -3,6 +3,7 @@ package controllers
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -10,6 +11,7 @@ import (
 	"time"
 
 	"iac-platform/internal/models"
+	"iac-platform/services"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -840,25 +842,12 @@ func (c *WorkspaceRemoteDataController) GetOutputsSharing(ctx *gin.Context) {
 
 // canAccessOutputs 检查是否有权限访问目标workspace的outputs
 func (c *WorkspaceRemoteDataController) canAccessOutputs(requesterWorkspaceID, targetWorkspaceID string) bool {
-	var targetWorkspace models.Workspace
-	if err := c.db.Where("workspace_id = ?", targetWorkspaceID).First(&targetWorkspace).Error; err != nil {
-		return false
-	}
-
-	// 检查共享模式
-	switch targetWorkspace.OutputsSharing {
-	case "all":
-		return true
-	case "specific":
-		// 检查是否在允许列表中
-		var count int64
-		c.db.Model(&models.WorkspaceOutputsAccess{}).
-			Where("workspace_id = ? AND allowed_workspace_id = ?", targetWorkspaceID, requesterWorkspaceID).
-			Count(&count)
-		return count > 0
-	default:
+	allowed, err := services.NewRemoteStateService(c.db).CanReadOutputs(requesterWorkspaceID, targetWorkspaceID)
+	if err != nil {
+		log.Printf("Failed to check outputs sharing of %s for %s: %v", targetWorkspaceID, requesterWorkspaceID, err)
 		return false
 	}
+	return allowed
 }
 
 // GenerateRemoteDataToken 生成远程数据访问token（内部方法，供terraform执行时调用）
@@ -900,7 +889,9 @@ func (c *WorkspaceRemoteDataController) GenerateRemoteDataToken(
 // ValidateAndUseToken 验证并使用token（供state-outputs API调用）
 func (c *WorkspaceRemoteDataController) ValidateAndUseToken(tokenValue string) (*models.RemoteDataToken, error) {
 	var token models.RemoteDataToken
-	if err := c.db.Where("token = ?", tokenValue).First(&token).Error; err != nil {
+	// terraform_remote_state 凭证只能用于 remote-state 接口
+	if err := c.db.Where("token = ? AND scope <> ?", tokenValue, models.RemoteDataTokenScopeRemoteState).
+		First(&token).Error; err != nil {
 		return nil, fmt.Errorf("invalid token")
 	}
 
@@ -918,3 +909,61 @@ func (c *WorkspaceRemoteDataController) ValidateAndUseToken(tokenValue string) (
 
 	return &token, nil
 }
+
+// GetRemoteState 读取源workspace的remote state（terraform_remote_state 的 http backend 地址）
+// @Summary 获取Remote State
+// @Description 返回只包含outputs的State快照（State v4格式，保留output类型和sensitive标记），供terraform_remote_state通过http backend读取。使用HTTP Basic认证：用户名为请求方workspace ID，密码为任务执行时签发的短期凭证（TF_HTTP_USERNAME/TF_HTTP_PASSWORD），并按源workspace的outputs共享设置校验访问权限。
+// @Tags Workspace Remote Data
+// @Produce json
+// @Param id path string true "源工作空间ID"
+// @Success 200 {object} map[string]interface{} "State快照"
+// @Success 204 "源工作空间还没有State"
+// @Failure 401 {object} map[string]interface{} "凭证无效或已过期"
+// @Failure 403 {object} map[string]interface{} "源工作空间未共享outputs"
+// @Failure 500 {object} map[string]interface{} "服务器错误"
+// @Router /api/v1/workspaces/{id}/remote-state [get]
+func (c *WorkspaceRemoteDataController) GetRemoteState(ctx *gin.Context) {
+	sourceWorkspaceID := ctx.Param("id")
+	stateService := services.NewRemoteStateService(c.db)
+
+	username, password, _ := ctx.Request.BasicAuth()
+	if _, err := stateService.Authorize(username, password, sourceWorkspaceID); err != nil {
+		switch {
+		case errors.Is(err, services.ErrRemoteStateUnauthorized):
+			ctx.Header("WWW-Authenticate", `Basic realm="remote-state"`)
+			ctx.JSON(http.StatusUnauthorized, gin.H{
+				"code":    401,
+				"message": err.Error(),
+			})
+		case errors.Is(err, services.ErrRemoteStateForbidden):
+			ctx.JSON(http.StatusForbidden, gin.H{
+				"code":    403,
+				"message": err.Error(),
+			})
+		default:
+			log.Printf("Failed to authorize remote state access to %s: %v", sourceWorkspaceID, err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code":    500,
+				"message": "failed to authorize remote state access",
+			})
+		}
+		return
+	}
+
+	state, err := stateService.Snapshot(sourceWorkspaceID)
+	if err != nil {
+		log.Printf("Failed to load remote state of %s: %v", sourceWorkspaceID, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500,
+			"message": "failed to load state",
+		})
+		return
+	}
+	if state == nil {
+		// http backend 把 204 视为空 State
+		ctx.Status(http.StatusNoContent)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, state)
+}
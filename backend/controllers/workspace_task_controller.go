@@ -3,14 +3,17 @@ package controllers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 
+	"iac-platform/internal/middleware"
 	"iac-platform/internal/models"
 	"iac-platform/services"
 
@@ -303,6 +306,15 @@ func (c *WorkspaceTaskController) GetTask(ctx *gin.Context) {
 		}
 	}
 
+	// 已归档的输出从对象存储加载（plan_json 只在需要返回时加载）
+	hydrateKinds := []string{models.TaskArtifactPlanOutput, models.TaskArtifactApplyOutput}
+	if workspace.ShowUnchangedResources {
+		hydrateKinds = append(hydrateKinds, models.TaskArtifactPlanJSON)
+	}
+	if err := services.NewTaskArtifactService(c.db).HydrateTask(ctx.Request.Context(), &task, hydrateKinds...); err != nil {
+		log.Printf("[WARN] GetTask: failed to load archived output of task %d: %v", task.ID, err)
+	}
+
 	// 获取创建者用户名
 	var createdByUsername string
 	if task.CreatedBy != nil {
@@ -646,6 +658,18 @@ func (c *WorkspaceTaskController) GetTaskLogs(ctx *gin.Context) {
 		return
 	}
 
+	// 日志已归档时从对象存储读取
+	if len(logs) == 0 {
+		archived, err := services.NewTaskArtifactService(c.db).LoadTaskLogs(ctx.Request.Context(), uint(taskID))
+		if err != nil {
+			ctx.JSON(http.StatusBadGateway, gin.H{"error": "Failed to fetch archived logs", "details": err.Error()})
+			return
+		}
+		if archived != nil {
+			logs = archived
+		}
+	}
+
 	ctx.JSON(http.StatusOK, gin.H{
 		"logs":  logs,
 		"total": len(logs),
@@ -740,6 +764,31 @@ func (c *WorkspaceTaskController) ConfirmApply(ctx *gin.Context) {
 		return
 	}
 
+	// Stack 执行中的任务由 Stack 统一审核后按依赖顺序确认，不能单独确认
+	if stackRun, err := services.NewStackService(c.db).ActiveRunForTask(task.ID); err != nil {
+		log.Printf("[WARN] ConfirmApply: failed to check stack runs for task %d: %v", task.ID, err)
+	} else if stackRun != nil {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"error":        "Task is part of a stack run; approve the stack run to apply it in dependency order",
+			"stack_id":     stackRun.StackID,
+			"stack_run_id": stackRun.ID,
+		})
+		return
+	}
+
+	// 成本预算检查（mandatory 预算超出时禁止 apply）
+	if err := services.NewCostEstimationService(c.db).CheckApplyAllowed(task.ID); err != nil {
+		if errors.Is(err, services.ErrCostBudgetExceeded) {
+			ctx.JSON(http.StatusConflict, gin.H{
+				"error":   "Cost estimate exceeds workspace budget",
+				"details": err.Error(),
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	// 验证资源版本快照（使用新的快照验证方法）
 	// 创建一个简单的logger用于验证过程
 	stream := c.streamManager.GetOrCreate(task.ID)
@@ -779,6 +828,20 @@ func (c *WorkspaceTaskController) ConfirmApply(ctx *gin.Context) {
 		return
 	}
 
+	ev := middleware.AuditEventFromContext(ctx, models.AuditEventApplyConfirmed)
+	ev.ResourceType = "task"
+	ev.ResourceID = fmt.Sprintf("%d", task.ID)
+	ev.WorkspaceID = workspace.WorkspaceID
+	ev.TaskID = &task.ID
+	ev.Message = fmt.Sprintf("Apply confirmed for task #%d", task.ID)
+	ev.Details = models.JSONB{
+		"apply_description": req.ApplyDescription,
+		"changes_add":       task.ChangesAdd,
+		"changes_change":    task.ChangesChange,
+		"changes_destroy":   task.ChangesDestroy,
+	}
+	services.NewAuditEventService(c.db).Emit(ev)
+
 	// 使用专门的ExecuteConfirmedApply方法来执行已确认的apply任务
 	// 这个方法会验证任务已被确认，并直接执行，不依赖GetNextExecutableTask
 	go func() {
@@ -814,6 +877,20 @@ func (c *WorkspaceTaskController) ConfirmApply(ctx *gin.Context) {
 		}
 	}()
 
+	// 变更冻结期间仍允许确认：TaskQueueManager 会挂起 apply，直到冻结结束或例外被批准
+	changeFreezes, err := services.NewChangeFreezeService(c.db).ActiveFreezes(&workspace, time.Now())
+	if err != nil {
+		log.Printf("[WARN] ConfirmApply: failed to check change freezes for task %d: %v", task.ID, err)
+	}
+	if len(changeFreezes) > 0 {
+		ctx.JSON(http.StatusOK, gin.H{
+			"message":        "Apply confirmed but held by an active change freeze; it will run when the freeze ends or an exception is approved",
+			"task":           task,
+			"change_freezes": changeFreezes,
+		})
+		return
+	}
+
 	ctx.JSON(http.StatusOK, gin.H{
 		"message": "Apply queued for execution",
 		"task":    task,
@@ -1062,6 +1139,17 @@ func (c *WorkspaceTaskController) CancelTask(ctx *gin.Context) {
 		}
 	}
 
+	ev := middleware.AuditEventFromContext(ctx, models.AuditEventTaskCancelled)
+	ev.ResourceType = "task"
+	ev.ResourceID = fmt.Sprintf("%d", task.ID)
+	ev.WorkspaceID = task.WorkspaceID
+	ev.TaskID = &task.ID
+	ev.Message = fmt.Sprintf("Task #%d cancelled", task.ID)
+	ev.Details = models.JSONB{
+		"task_type": string(task.TaskType),
+	}
+	services.NewAuditEventService(c.db).Emit(ev)
+
 	// 发送任务取消通知
 	go func() {
 		if err := c.notificationSender.TriggerNotifications(
@@ -1154,8 +1242,9 @@ func (c *WorkspaceTaskController) RetryStateSave(ctx *gin.Context) {
 		return
 	}
 
-	// 检查备份文件是否存在
-	if _, err := os.Stat(backupPath); os.IsNotExist(err) {
+	// 读取备份文件（本地文件已归档时从对象存储读取）
+	stateData, err := c.readStateBackup(ctx.Request.Context(), task.ID, backupPath)
+	if errors.Is(err, services.ErrArtifactNotFound) {
 		ctx.JSON(http.StatusNotFound, gin.H{
 			"error":       "Backup file not found",
 			"backup_path": backupPath,
@@ -1163,9 +1252,6 @@ func (c *WorkspaceTaskController) RetryStateSave(ctx *gin.Context) {
 		})
 		return
 	}
-
-	// 读取备份文件
-	stateData, err := os.ReadFile(backupPath)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"error":       fmt.Sprintf("Failed to read backup file: %v", err),
@@ -1252,17 +1338,35 @@ func (c *WorkspaceTaskController) DownloadStateBackup(ctx *gin.Context) {
 		return
 	}
 
-	// 检查文件是否存在
-	if _, err := os.Stat(backupPath); os.IsNotExist(err) {
+	stateData, err := c.readStateBackup(ctx.Request.Context(), task.ID, backupPath)
+	if errors.Is(err, services.ErrArtifactNotFound) {
 		ctx.JSON(http.StatusNotFound, gin.H{
 			"error": "Backup file not found",
 		})
 		return
 	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("Failed to read backup file: %v", err),
+		})
+		return
+	}
 
 	// 返回文件
 	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=terraform_%d.tfstate", taskID))
-	ctx.File(backupPath)
+	ctx.Data(http.StatusOK, "application/octet-stream", stateData)
+}
+
+// readStateBackup 读取 State 备份：优先读本地文件，本地文件已被保留策略归档时从对象存储读取
+func (c *WorkspaceTaskController) readStateBackup(ctx context.Context, taskID uint, backupPath string) ([]byte, error) {
+	data, err := os.ReadFile(backupPath)
+	if err == nil {
+		return data, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+	return services.NewTaskArtifactService(c.db).LoadStateBackup(ctx, taskID, filepath.Base(backupPath))
 }
 
 // extractBackupPath 从错误信息中提取备份路径
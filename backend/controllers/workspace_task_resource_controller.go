@@ -2,6 +2,8 @@ package controllers
 
 import (
 	"iac-platform/internal/models"
+	"iac-platform/services"
+	"log"
 	"net/http"
 	"strconv"
 
@@ -64,6 +66,12 @@ func GetTaskResourceChangesWithDB(c *gin.Context, db *gorm.DB) {
 	var actionInvocations interface{}
 	var actions []interface{}
 	if err := db.Where("id = ?", taskID).First(&task).Error; err == nil {
+		if task.PlanJSON == nil {
+			// plan_json 已归档时从对象存储加载
+			if err := services.NewTaskArtifactService(db).HydrateTask(c.Request.Context(), &task, models.TaskArtifactPlanJSON); err != nil {
+				log.Printf("[WARN] Failed to load archived plan json of task %d: %v", task.ID, err)
+			}
+		}
 		if task.PlanJSON != nil {
 			if oc, ok := task.PlanJSON["output_changes"]; ok {
 				// 对 sensitive output 进行脱敏处理
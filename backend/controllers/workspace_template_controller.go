@@ -0,0 +1,246 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"iac-platform/internal/models"
+	"iac-platform/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// WorkspaceTemplateController 工作空间模板控制器
+type WorkspaceTemplateController struct {
+	service *services.WorkspaceTemplateService
+}
+
+// NewWorkspaceTemplateController 创建工作空间模板控制器
+func NewWorkspaceTemplateController(db *gorm.DB) *WorkspaceTemplateController {
+	return &WorkspaceTemplateController{
+		service: services.NewWorkspaceTemplateService(db),
+	}
+}
+
+// ListWorkspaceTemplates 获取工作空间模板列表
+// @Summary 获取工作空间模板列表
+// @Description 获取所有工作空间模板，支持按enabled过滤；敏感变量默认值不返回
+// @Tags Admin
+// @Produce json
+// @Param enabled query boolean false "是否启用"
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/global/settings/workspace-templates [get]
+func (c *WorkspaceTemplateController) ListWorkspaceTemplates(ctx *gin.Context) {
+	var enabled *bool
+	if enabledStr := ctx.Query("enabled"); enabledStr != "" {
+		val := enabledStr == "true"
+		enabled = &val
+	}
+
+	templates, err := c.service.List(enabled)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for i := range templates {
+		templates[i] = services.MaskWorkspaceTemplate(templates[i])
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"items": templates, "total": len(templates)})
+}
+
+// GetWorkspaceTemplate 获取工作空间模板详情
+// @Summary 获取工作空间模板详情
+// @Description 根据template_id获取模板详情及关联的workspace
+// @Tags Admin
+// @Produce json
+// @Param template_id path string true "模板ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/global/settings/workspace-templates/{template_id} [get]
+func (c *WorkspaceTemplateController) GetWorkspaceTemplate(ctx *gin.Context) {
+	templateID := ctx.Param("template_id")
+	template, err := c.service.Get(templateID)
+	if err != nil {
+		respondWorkspaceTemplateError(ctx, err)
+		return
+	}
+
+	workspaces, err := c.service.ListLinkedWorkspaces(templateID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	linked := make([]gin.H, 0, len(workspaces))
+	for _, ws := range workspaces {
+		linked = append(linked, gin.H{
+			"workspace_id":     ws.WorkspaceID,
+			"name":             ws.Name,
+			"template_version": ws.TemplateVersion,
+			"up_to_date":       ws.TemplateVersion == template.Version,
+		})
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"template":          services.MaskWorkspaceTemplate(*template),
+		"linked_workspaces": linked,
+	})
+}
+
+// CreateWorkspaceTemplate 创建工作空间模板
+// @Summary 创建工作空间模板
+// @Description 创建包含设置、Run Tasks、通知、变量定义和初始资源的工作空间模板
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param request body models.CreateWorkspaceTemplateRequest true "创建请求"
+// @Success 201 {object} models.WorkspaceTemplate
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/global/settings/workspace-templates [post]
+func (c *WorkspaceTemplateController) CreateWorkspaceTemplate(ctx *gin.Context) {
+	var req models.CreateWorkspaceTemplateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	template, err := c.service.Create(&req, ctx.GetString("user_id"))
+	if err != nil {
+		respondWorkspaceTemplateError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, services.MaskWorkspaceTemplate(*template))
+}
+
+// UpdateWorkspaceTemplate 更新工作空间模板
+// @Summary 更新工作空间模板
+// @Description 更新模板；修改spec会递增模板版本，关联的workspace可通过预览/同步接口更新
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param template_id path string true "模板ID"
+// @Param request body models.UpdateWorkspaceTemplateRequest true "更新请求"
+// @Success 200 {object} models.WorkspaceTemplate
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/global/settings/workspace-templates/{template_id} [put]
+func (c *WorkspaceTemplateController) UpdateWorkspaceTemplate(ctx *gin.Context) {
+	var req models.UpdateWorkspaceTemplateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	template, err := c.service.Update(ctx.Param("template_id"), &req)
+	if err != nil {
+		respondWorkspaceTemplateError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, services.MaskWorkspaceTemplate(*template))
+}
+
+// DeleteWorkspaceTemplate 删除工作空间模板
+// @Summary 删除工作空间模板
+// @Description 删除模板（仍有workspace关联时无法删除）
+// @Tags Admin
+// @Param template_id path string true "模板ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/global/settings/workspace-templates/{template_id} [delete]
+func (c *WorkspaceTemplateController) DeleteWorkspaceTemplate(ctx *gin.Context) {
+	if err := c.service.Delete(ctx.Param("template_id")); err != nil {
+		respondWorkspaceTemplateError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// PreviewWorkspaceTemplatePropagation 预览模板同步
+// @Summary 预览模板同步
+// @Description 对比模板当前版本与关联workspace，返回每个workspace将要新增或更新的配置
+// @Tags Admin
+// @Produce json
+// @Param template_id path string true "模板ID"
+// @Param workspace_ids query string false "逗号分隔的workspace ID，为空时预览全部关联workspace"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/global/settings/workspace-templates/{template_id}/preview [get]
+func (c *WorkspaceTemplateController) PreviewWorkspaceTemplatePropagation(ctx *gin.Context) {
+	var workspaceIDs []string
+	if raw := ctx.Query("workspace_ids"); raw != "" {
+		for _, id := range strings.Split(raw, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				workspaceIDs = append(workspaceIDs, id)
+			}
+		}
+	}
+
+	previews, err := c.service.PreviewPropagation(ctx.Param("template_id"), workspaceIDs)
+	if err != nil {
+		respondWorkspaceTemplateError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"items": previews, "total": len(previews)})
+}
+
+// PropagateWorkspaceTemplate 同步模板到关联的workspace
+// @Summary 同步模板
+// @Description 将模板当前版本同步到关联workspace（只新增或更新，不删除已有配置）
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param template_id path string true "模板ID"
+// @Param request body models.PropagateWorkspaceTemplateRequest false "同步请求"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/global/settings/workspace-templates/{template_id}/propagate [post]
+func (c *WorkspaceTemplateController) PropagateWorkspaceTemplate(ctx *gin.Context) {
+	var req models.PropagateWorkspaceTemplateRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	results, err := c.service.Propagate(ctx.Param("template_id"), &req, ctx.GetString("user_id"))
+	if err != nil {
+		respondWorkspaceTemplateError(ctx, err)
+		return
+	}
+
+	applied := 0
+	for _, r := range results {
+		if r.Applied {
+			applied++
+		}
+	}
+	ctx.JSON(http.StatusOK, gin.H{"items": results, "total": len(results), "applied": applied})
+}
+
+// respondWorkspaceTemplateError 按错误类型返回对应状态码
+func respondWorkspaceTemplateError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrWorkspaceTemplateNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrWorkspaceTemplateInvalid), errors.Is(err, services.ErrWorkspaceTemplateInUse):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -1,10 +1,12 @@
 package controllers
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
+	"iac-platform/internal/middleware"
 	"iac-platform/internal/models"
 	"iac-platform/services"
 
@@ -196,24 +198,23 @@ func (vc *WorkspaceVariableController) ListVariables(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path int true "工作空间ID"
-// @Param var_id path int true "变量ID"
+// @Param var_id path string true "变量ID（支持数字ID或variable_id）"
 // @Success 200 {object} map[string]interface{} "成功返回变量详情"
-// @Failure 400 {object} map[string]interface{} "无效的变量ID"
 // @Failure 404 {object} map[string]interface{} "变量不存在"
 // @Router /api/v1/workspaces/{id}/variables/{var_id} [get]
 // @Security Bearer
 func (vc *WorkspaceVariableController) GetVariable(c *gin.Context) {
-	varID, err := strconv.ParseUint(c.Param("var_id"), 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":      400,
-			"message":   "无效的变量ID",
-			"timestamp": time.Now().Format(time.RFC3339),
-		})
-		return
-	}
+	varIDParam := c.Param("var_id")
+
+	var variable *models.WorkspaceVariable
+	var err error
 
-	variable, err := vc.variableService.GetVariable(uint(varID))
+	// 判断是数字ID还是variable_id，variable_id 返回最新未删除版本
+	if varID, parseErr := strconv.ParseUint(varIDParam, 10, 32); parseErr == nil {
+		variable, err = vc.variableService.GetVariable(uint(varID))
+	} else {
+		variable, err = vc.variableService.GetVariableByVariableID(varIDParam)
+	}
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"code":      404,
@@ -223,6 +224,21 @@ func (vc *WorkspaceVariableController) GetVariable(c *gin.Context) {
 		return
 	}
 
+	// 仅敏感变量的读取需要进入审计链，普通变量读取量大且无审计价值
+	if variable.Sensitive {
+		ev := middleware.AuditEventFromContext(c, models.AuditEventVariableRead)
+		ev.ResourceType = "workspace_variable"
+		ev.ResourceID = variable.VariableID
+		ev.WorkspaceID = variable.WorkspaceID
+		ev.Message = fmt.Sprintf("Sensitive variable %s read", variable.Key)
+		ev.Details = models.JSONB{
+			"key":           variable.Key,
+			"variable_type": string(variable.VariableType),
+			"version":       variable.Version,
+		}
+		services.NewAuditEventService(vc.variableService.GetDB()).Emit(ev)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"code":      200,
 		"data":      variable.ToResponse(),
@@ -0,0 +1,66 @@
+package apiclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// AgentPool Agent 池
+type AgentPool struct {
+	PoolID      string  `json:"pool_id"`
+	Name        string  `json:"name"`
+	Description *string `json:"description,omitempty"`
+	PoolType    string  `json:"pool_type"`
+}
+
+// CreateAgentPoolRequest 创建 Agent 池参数，PoolType 为 static 或 k8s
+type CreateAgentPoolRequest struct {
+	Name        string  `json:"name"`
+	Description *string `json:"description,omitempty"`
+	PoolType    string  `json:"pool_type"`
+}
+
+// UpdateAgentPoolRequest 更新 Agent 池参数，nil 字段不修改
+type UpdateAgentPoolRequest struct {
+	Name        *string `json:"name,omitempty"`
+	Description *string `json:"description,omitempty"`
+}
+
+func agentPoolPath(poolID string) string {
+	return "/agent-pools/" + url.PathEscape(poolID)
+}
+
+// GetAgentPool 获取 Agent 池
+func (c *Client) GetAgentPool(ctx context.Context, poolID string) (*AgentPool, error) {
+	var resp struct {
+		Pool AgentPool `json:"pool"`
+	}
+	if err := c.do(ctx, http.MethodGet, agentPoolPath(poolID), nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Pool, nil
+}
+
+// CreateAgentPool 创建 Agent 池
+func (c *Client) CreateAgentPool(ctx context.Context, req CreateAgentPoolRequest) (*AgentPool, error) {
+	var out AgentPool
+	if err := c.do(ctx, http.MethodPost, "/agent-pools", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateAgentPool 更新 Agent 池
+func (c *Client) UpdateAgentPool(ctx context.Context, poolID string, req UpdateAgentPoolRequest) (*AgentPool, error) {
+	var out AgentPool
+	if err := c.do(ctx, http.MethodPut, agentPoolPath(poolID), nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteAgentPool 删除 Agent 池，池中仍有 Agent 时返回 HTTP 409
+func (c *Client) DeleteAgentPool(ctx context.Context, poolID string) error {
+	return c.do(ctx, http.MethodDelete, agentPoolPath(poolID), nil, nil, nil)
+}
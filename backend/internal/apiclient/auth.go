@@ -0,0 +1,60 @@
+package apiclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// User 当前登录用户
+type User struct {
+	ID            string `json:"id"`
+	Username      string `json:"username"`
+	Email         string `json:"email"`
+	IsSystemAdmin bool   `json:"is_system_admin"`
+}
+
+// Me 获取当前 token 对应的用户（团队 Token 没有用户身份，会返回 401）
+func (c *Client) Me(ctx context.Context) (*User, error) {
+	var resp struct {
+		Data User `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/auth/me", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Data, nil
+}
+
+// SSOLoginURL 获取 SSO Provider 的授权地址，redirectURL 为登录完成后的回跳地址
+func (c *Client) SSOLoginURL(ctx context.Context, provider, redirectURL string) (string, error) {
+	var resp struct {
+		Data struct {
+			AuthURL string `json:"auth_url"`
+		} `json:"data"`
+	}
+	query := url.Values{"redirect_url": {redirectURL}}
+	if err := c.do(ctx, http.MethodGet, "/auth/sso/"+url.PathEscape(provider)+"/login", query, nil, &resp); err != nil {
+		return "", err
+	}
+	return resp.Data.AuthURL, nil
+}
+
+// LoginToken 登录成功后签发的 JWT
+type LoginToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	User      User      `json:"user"`
+}
+
+// VerifyMFA 用登录流程中的 mfa_token 和验证码换取 JWT
+func (c *Client) VerifyMFA(ctx context.Context, mfaToken, code string) (*LoginToken, error) {
+	var resp struct {
+		Data LoginToken `json:"data"`
+	}
+	body := map[string]string{"mfa_token": mfaToken, "code": code}
+	if err := c.do(ctx, http.MethodPost, "/auth/mfa/verify", nil, body, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Data, nil
+}
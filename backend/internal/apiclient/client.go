@@ -0,0 +1,186 @@
+// Package apiclient 是平台 /api/v1 REST 接口的 Go 客户端，供 CLI 等工具复用
+package apiclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrNotFound 资源不存在（HTTP 404）
+var ErrNotFound = errors.New("not found")
+
+// APIError 平台返回的非 2xx 响应
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("api error: HTTP %d", e.StatusCode)
+	}
+	return fmt.Sprintf("api error: HTTP %d: %s", e.StatusCode, e.Message)
+}
+
+// Is 让 errors.Is(err, ErrNotFound) 对 404 生效
+func (e *APIError) Is(target error) bool {
+	return target == ErrNotFound && e.StatusCode == http.StatusNotFound
+}
+
+// Client 平台 API 客户端
+type Client struct {
+	baseURL    *url.URL
+	token      string
+	httpClient *http.Client
+	userAgent  string
+}
+
+// Option 客户端可选配置
+type Option func(*Client)
+
+// WithHTTPClient 使用自定义的 http.Client
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.httpClient = hc }
+}
+
+// WithUserAgent 设置 User-Agent
+func WithUserAgent(ua string) Option {
+	return func(c *Client) { c.userAgent = ua }
+}
+
+// New 创建客户端，address 为平台地址（如 https://iac.example.com），token 可为空（仅调用公开接口）
+func New(address, token string, opts ...Option) (*Client, error) {
+	address = strings.TrimRight(strings.TrimSpace(address), "/")
+	if address == "" {
+		return nil, errors.New("platform address is required")
+	}
+	u, err := url.Parse(address)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid platform address %q", address)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/api/v1")
+
+	c := &Client{
+		baseURL:    u,
+		token:      token,
+		httpClient: &http.Client{Timeout: 60 * time.Second},
+		userAgent:  "iac-platform-client",
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// Address 返回平台地址
+func (c *Client) Address() string {
+	return c.baseURL.String()
+}
+
+// endpoint 拼出 /api/v1 下的完整 URL
+func (c *Client) endpoint(path string, query url.Values) string {
+	u := *c.baseURL
+	u.Path = strings.TrimRight(u.Path, "/") + "/api/v1" + path
+	if len(query) > 0 {
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
+
+// newRequest 构造带认证头的请求，body 为 nil 时不发送请求体
+func (c *Client) newRequest(ctx context.Context, method, path string, query url.Values, body interface{}) (*http.Request, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.endpoint(path, query), reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", c.userAgent)
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	return req, nil
+}
+
+// doRaw 发送请求并返回原始响应体，非 2xx 转为 *APIError
+func (c *Client) doRaw(ctx context.Context, method, path string, query url.Values, body interface{}) ([]byte, error) {
+	req, err := c.newRequest(ctx, method, path, query, body)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, parseAPIError(resp.StatusCode, data)
+	}
+	return data, nil
+}
+
+// do 发送 JSON 请求并把响应解码到 out（out 为 nil 时忽略响应体）
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	data, err := c.doRaw(ctx, method, path, query, body)
+	if err != nil {
+		return err
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// parseAPIError 兼容平台的两种错误格式：{"error": "..."} 与 {"code": 400, "message": "..."}
+func parseAPIError(status int, data []byte) error {
+	var body struct {
+		Error   interface{} `json:"error"`
+		Message string      `json:"message"`
+		Details string      `json:"details"`
+	}
+	msg := strings.TrimSpace(string(data))
+	if json.Unmarshal(data, &body) == nil {
+		parts := []string{}
+		if body.Message != "" {
+			parts = append(parts, body.Message)
+		}
+		if s, ok := body.Error.(string); ok && s != "" {
+			parts = append(parts, s)
+		}
+		if body.Details != "" {
+			parts = append(parts, body.Details)
+		}
+		if len(parts) > 0 {
+			msg = strings.Join(parts, ": ")
+		}
+	}
+	return &APIError{StatusCode: status, Message: msg}
+}
@@ -0,0 +1,156 @@
+package apiclient
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+)
+
+// Module 模块
+type Module struct {
+	ID                 uint    `json:"id"`
+	Name               string  `json:"name"`
+	Provider           string  `json:"provider"`
+	Source             string  `json:"source"`
+	ModuleSource       string  `json:"module_source"`
+	Version            string  `json:"version"`
+	Description        string  `json:"description"`
+	Status             string  `json:"status"`
+	DefaultVersionID   *string `json:"default_version_id,omitempty"`
+	RepositoryURL      string  `json:"repository_url"`
+	Branch             string  `json:"branch"`
+	RequirePassingTest bool    `json:"require_passing_test"`
+}
+
+// CreateModuleRequest 创建模块参数，创建时会自动生成默认版本
+type CreateModuleRequest struct {
+	Name          string `json:"name"`
+	Provider      string `json:"provider"`
+	Source        string `json:"source,omitempty"`
+	ModuleSource  string `json:"module_source,omitempty"`
+	Version       string `json:"version,omitempty"`
+	Description   string `json:"description,omitempty"`
+	RepositoryURL string `json:"repository_url,omitempty"`
+	Branch        string `json:"branch,omitempty"`
+}
+
+// UpdateModuleRequest 更新模块参数，空字符串和 nil 字段不修改
+type UpdateModuleRequest struct {
+	ModuleSource       string `json:"module_source,omitempty"`
+	Description        string `json:"description,omitempty"`
+	Version            string `json:"version,omitempty"`
+	Branch             string `json:"branch,omitempty"`
+	Status             string `json:"status,omitempty"`
+	RequirePassingTest *bool  `json:"require_passing_test,omitempty"`
+}
+
+// ModuleVersion 模块版本
+type ModuleVersion struct {
+	ID           string `json:"id"`
+	ModuleID     uint   `json:"module_id"`
+	Version      string `json:"version"`
+	Source       string `json:"source"`
+	ModuleSource string `json:"module_source"`
+	IsDefault    bool   `json:"is_default"`
+	Status       string `json:"status"`
+}
+
+// CreateModuleVersionRequest 创建模块版本参数
+type CreateModuleVersionRequest struct {
+	Version           string `json:"version"`
+	Source            string `json:"source,omitempty"`
+	ModuleSource      string `json:"module_source,omitempty"`
+	InheritSchemaFrom string `json:"inherit_schema_from,omitempty"`
+	SetAsDefault      bool   `json:"set_as_default"`
+}
+
+// UpdateModuleVersionRequest 更新模块版本参数，空字符串字段不修改
+type UpdateModuleVersionRequest struct {
+	Source       string `json:"source,omitempty"`
+	ModuleSource string `json:"module_source,omitempty"`
+	Status       string `json:"status,omitempty"`
+}
+
+func modulePath(moduleID uint) string {
+	return "/modules/" + strconv.FormatUint(uint64(moduleID), 10)
+}
+
+// GetModule 获取模块
+func (c *Client) GetModule(ctx context.Context, moduleID uint) (*Module, error) {
+	var resp struct {
+		Data Module `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodGet, modulePath(moduleID), nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Data, nil
+}
+
+// CreateModule 创建模块
+func (c *Client) CreateModule(ctx context.Context, req CreateModuleRequest) (*Module, error) {
+	var resp struct {
+		Data Module `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/modules", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Data, nil
+}
+
+// UpdateModule 更新模块
+func (c *Client) UpdateModule(ctx context.Context, moduleID uint, req UpdateModuleRequest) (*Module, error) {
+	var resp struct {
+		Data Module `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodPut, modulePath(moduleID), nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Data, nil
+}
+
+// DeleteModule 删除模块及其全部版本（活跃状态的模块需先停用）
+func (c *Client) DeleteModule(ctx context.Context, moduleID uint) error {
+	return c.do(ctx, http.MethodDelete, modulePath(moduleID), nil, nil, nil)
+}
+
+func moduleVersionPath(moduleID uint, versionID string) string {
+	return modulePath(moduleID) + "/versions/" + versionID
+}
+
+// GetModuleVersion 获取模块版本
+func (c *Client) GetModuleVersion(ctx context.Context, moduleID uint, versionID string) (*ModuleVersion, error) {
+	var out ModuleVersion
+	if err := c.do(ctx, http.MethodGet, moduleVersionPath(moduleID, versionID), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateModuleVersion 创建模块版本
+func (c *Client) CreateModuleVersion(ctx context.Context, moduleID uint, req CreateModuleVersionRequest) (*ModuleVersion, error) {
+	var out ModuleVersion
+	if err := c.do(ctx, http.MethodPost, modulePath(moduleID)+"/versions", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateModuleVersion 更新模块版本
+func (c *Client) UpdateModuleVersion(ctx context.Context, moduleID uint, versionID string, req UpdateModuleVersionRequest) (*ModuleVersion, error) {
+	var out ModuleVersion
+	if err := c.do(ctx, http.MethodPut, moduleVersionPath(moduleID, versionID), nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteModuleVersion 删除模块版本（默认版本不可删除）
+func (c *Client) DeleteModuleVersion(ctx context.Context, moduleID uint, versionID string) error {
+	return c.do(ctx, http.MethodDelete, moduleVersionPath(moduleID, versionID), nil, nil, nil)
+}
+
+// SetDefaultModuleVersion 设置模块的默认版本
+func (c *Client) SetDefaultModuleVersion(ctx context.Context, moduleID uint, versionID string) error {
+	req := map[string]string{"version_id": versionID}
+	return c.do(ctx, http.MethodPut, modulePath(moduleID)+"/default-version", nil, req, nil)
+}
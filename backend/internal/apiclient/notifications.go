@@ -0,0 +1,163 @@
+package apiclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// Notification 通知配置（密钥不返回，只返回是否已设置）
+type Notification struct {
+	ID                   uint              `json:"id"`
+	NotificationID       string            `json:"notification_id"`
+	Name                 string            `json:"name"`
+	Description          string            `json:"description"`
+	NotificationType     string            `json:"notification_type"`
+	EndpointURL          string            `json:"endpoint_url"`
+	SecretSet            bool              `json:"secret_set"`
+	CustomHeaders        map[string]string `json:"custom_headers"`
+	Enabled              bool              `json:"enabled"`
+	IsGlobal             bool              `json:"is_global"`
+	GlobalEvents         string            `json:"global_events,omitempty"`
+	RetryCount           int               `json:"retry_count"`
+	RetryIntervalSeconds int               `json:"retry_interval_seconds"`
+	TimeoutSeconds       int               `json:"timeout_seconds"`
+	WorkspaceCount       int               `json:"workspace_count"`
+}
+
+// CreateNotificationRequest 创建通知配置参数
+type CreateNotificationRequest struct {
+	Name                 string            `json:"name"`
+	Description          string            `json:"description,omitempty"`
+	NotificationType     string            `json:"notification_type"`
+	EndpointURL          string            `json:"endpoint_url"`
+	Secret               string            `json:"secret,omitempty"`
+	CustomHeaders        map[string]string `json:"custom_headers,omitempty"`
+	IsGlobal             bool              `json:"is_global"`
+	GlobalEvents         string            `json:"global_events,omitempty"`
+	RetryCount           int               `json:"retry_count,omitempty"`
+	RetryIntervalSeconds int               `json:"retry_interval_seconds,omitempty"`
+	TimeoutSeconds       int               `json:"timeout_seconds,omitempty"`
+}
+
+// UpdateNotificationRequest 更新通知配置参数，nil 字段不修改；Secret 为空字符串表示清除密钥
+type UpdateNotificationRequest struct {
+	Name                 *string            `json:"name,omitempty"`
+	Description          *string            `json:"description,omitempty"`
+	EndpointURL          *string            `json:"endpoint_url,omitempty"`
+	Secret               *string            `json:"secret,omitempty"`
+	CustomHeaders        *map[string]string `json:"custom_headers,omitempty"`
+	Enabled              *bool              `json:"enabled,omitempty"`
+	IsGlobal             *bool              `json:"is_global,omitempty"`
+	GlobalEvents         *string            `json:"global_events,omitempty"`
+	RetryCount           *int               `json:"retry_count,omitempty"`
+	RetryIntervalSeconds *int               `json:"retry_interval_seconds,omitempty"`
+	TimeoutSeconds       *int               `json:"timeout_seconds,omitempty"`
+}
+
+func notificationPath(notificationID string) string {
+	return "/notifications/" + url.PathEscape(notificationID)
+}
+
+// GetNotification 获取通知配置
+func (c *Client) GetNotification(ctx context.Context, notificationID string) (*Notification, error) {
+	var out Notification
+	if err := c.do(ctx, http.MethodGet, notificationPath(notificationID), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateNotification 创建通知配置
+func (c *Client) CreateNotification(ctx context.Context, req CreateNotificationRequest) (*Notification, error) {
+	var out Notification
+	if err := c.do(ctx, http.MethodPost, "/notifications", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateNotification 更新通知配置
+func (c *Client) UpdateNotification(ctx context.Context, notificationID string, req UpdateNotificationRequest) (*Notification, error) {
+	var out Notification
+	if err := c.do(ctx, http.MethodPut, notificationPath(notificationID), nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteNotification 删除通知配置
+func (c *Client) DeleteNotification(ctx context.Context, notificationID string) error {
+	return c.do(ctx, http.MethodDelete, notificationPath(notificationID), nil, nil, nil)
+}
+
+// WorkspaceNotification 工作空间关联的通知
+type WorkspaceNotification struct {
+	ID                      uint   `json:"id"`
+	WorkspaceNotificationID string `json:"workspace_notification_id"`
+	WorkspaceID             string `json:"workspace_id"`
+	NotificationID          string `json:"notification_id"`
+	Events                  string `json:"events"`
+	Enabled                 bool   `json:"enabled"`
+}
+
+// UpdateWorkspaceNotificationRequest 更新关联参数，nil 字段不修改
+type UpdateWorkspaceNotificationRequest struct {
+	Events  *string `json:"events,omitempty"`
+	Enabled *bool   `json:"enabled,omitempty"`
+}
+
+func workspaceNotificationsPath(workspaceID string) string {
+	return "/workspaces/" + url.PathEscape(workspaceID) + "/notifications"
+}
+
+// ListWorkspaceNotifications 查询工作空间直接关联的通知（不含全局通知）
+func (c *Client) ListWorkspaceNotifications(ctx context.Context, workspaceID string) ([]WorkspaceNotification, error) {
+	var resp struct {
+		WorkspaceNotifications []WorkspaceNotification `json:"workspace_notifications"`
+	}
+	if err := c.do(ctx, http.MethodGet, workspaceNotificationsPath(workspaceID), nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.WorkspaceNotifications, nil
+}
+
+// GetWorkspaceNotification 按 workspace_notification_id 查找关联，不存在时返回 ErrNotFound
+func (c *Client) GetWorkspaceNotification(ctx context.Context, workspaceID, workspaceNotificationID string) (*WorkspaceNotification, error) {
+	items, err := c.ListWorkspaceNotifications(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		if items[i].WorkspaceNotificationID == workspaceNotificationID {
+			return &items[i], nil
+		}
+	}
+	return nil, &APIError{StatusCode: http.StatusNotFound, Message: "workspace notification not found"}
+}
+
+// CreateWorkspaceNotification 为工作空间关联通知，events 为逗号分隔的事件列表
+func (c *Client) CreateWorkspaceNotification(ctx context.Context, workspaceID, notificationID, events string) (*WorkspaceNotification, error) {
+	req := map[string]string{"notification_id": notificationID, "events": events}
+	var out WorkspaceNotification
+	if err := c.do(ctx, http.MethodPost, workspaceNotificationsPath(workspaceID), nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateWorkspaceNotification 更新关联
+func (c *Client) UpdateWorkspaceNotification(ctx context.Context, workspaceID, workspaceNotificationID string, req UpdateWorkspaceNotificationRequest) (*WorkspaceNotification, error) {
+	var out WorkspaceNotification
+	path := workspaceNotificationsPath(workspaceID) + "/" + url.PathEscape(workspaceNotificationID)
+	if err := c.do(ctx, http.MethodPut, path, nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteWorkspaceNotification 取消关联
+func (c *Client) DeleteWorkspaceNotification(ctx context.Context, workspaceID, workspaceNotificationID string) error {
+	path := workspaceNotificationsPath(workspaceID) + "/" + url.PathEscape(workspaceNotificationID)
+	return c.do(ctx, http.MethodDelete, path, nil, nil, nil)
+}
@@ -0,0 +1,32 @@
+package apiclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+)
+
+// StateOutput 当前 State 中的单个 Output，敏感 Output 的 Value 为空
+type StateOutput struct {
+	Value     json.RawMessage `json:"value"`
+	Type      json.RawMessage `json:"type,omitempty"`
+	Sensitive bool            `json:"sensitive"`
+}
+
+// StateOutputs 工作空间当前 State 的 Outputs
+type StateOutputs struct {
+	Serial           int                    `json:"serial"`
+	Lineage          string                 `json:"lineage"`
+	TerraformVersion string                 `json:"terraform_version"`
+	Outputs          map[string]StateOutput `json:"outputs"`
+}
+
+// GetStateOutputs 获取工作空间当前 State 的 Outputs（不含敏感值），还没有 State 时返回空列表
+func (c *Client) GetStateOutputs(ctx context.Context, workspaceID string) (*StateOutputs, error) {
+	var out StateOutputs
+	if err := c.do(ctx, http.MethodGet, "/workspaces/"+url.PathEscape(workspaceID)+"/state-outputs", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
@@ -0,0 +1,167 @@
+package apiclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// RunTask Run Task 配置（HMAC 密钥不返回，只返回是否已设置）
+type RunTask struct {
+	ID                     uint   `json:"id"`
+	RunTaskID              string `json:"run_task_id"`
+	Name                   string `json:"name"`
+	Description            string `json:"description"`
+	EndpointURL            string `json:"endpoint_url"`
+	HMACKeySet             bool   `json:"hmac_key_set"`
+	Enabled                bool   `json:"enabled"`
+	TimeoutSeconds         int    `json:"timeout_seconds"`
+	MaxRunSeconds          int    `json:"max_run_seconds"`
+	IsGlobal               bool   `json:"is_global"`
+	GlobalStages           string `json:"global_stages,omitempty"`
+	GlobalEnforcementLevel string `json:"global_enforcement_level,omitempty"`
+	WorkspaceCount         int    `json:"workspace_count"`
+}
+
+// CreateRunTaskRequest 创建 Run Task 参数
+type CreateRunTaskRequest struct {
+	Name                   string `json:"name"`
+	Description            string `json:"description,omitempty"`
+	EndpointURL            string `json:"endpoint_url"`
+	HMACKey                string `json:"hmac_key,omitempty"`
+	TimeoutSeconds         int    `json:"timeout_seconds,omitempty"`
+	MaxRunSeconds          int    `json:"max_run_seconds,omitempty"`
+	IsGlobal               bool   `json:"is_global"`
+	GlobalStages           string `json:"global_stages,omitempty"`
+	GlobalEnforcementLevel string `json:"global_enforcement_level,omitempty"`
+}
+
+// UpdateRunTaskRequest 更新 Run Task 参数，nil 字段不修改；HMACKey 为空字符串表示清除密钥
+type UpdateRunTaskRequest struct {
+	Name                   *string `json:"name,omitempty"`
+	Description            *string `json:"description,omitempty"`
+	EndpointURL            *string `json:"endpoint_url,omitempty"`
+	HMACKey                *string `json:"hmac_key,omitempty"`
+	TimeoutSeconds         *int    `json:"timeout_seconds,omitempty"`
+	MaxRunSeconds          *int    `json:"max_run_seconds,omitempty"`
+	IsGlobal               *bool   `json:"is_global,omitempty"`
+	GlobalStages           *string `json:"global_stages,omitempty"`
+	GlobalEnforcementLevel *string `json:"global_enforcement_level,omitempty"`
+	Enabled                *bool   `json:"enabled,omitempty"`
+}
+
+func runTaskPath(runTaskID string) string {
+	return "/run-tasks/" + url.PathEscape(runTaskID)
+}
+
+// GetRunTask 获取 Run Task
+func (c *Client) GetRunTask(ctx context.Context, runTaskID string) (*RunTask, error) {
+	var out RunTask
+	if err := c.do(ctx, http.MethodGet, runTaskPath(runTaskID), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateRunTask 创建 Run Task
+func (c *Client) CreateRunTask(ctx context.Context, req CreateRunTaskRequest) (*RunTask, error) {
+	var out RunTask
+	if err := c.do(ctx, http.MethodPost, "/run-tasks", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateRunTask 更新 Run Task
+func (c *Client) UpdateRunTask(ctx context.Context, runTaskID string, req UpdateRunTaskRequest) (*RunTask, error) {
+	var out RunTask
+	if err := c.do(ctx, http.MethodPut, runTaskPath(runTaskID), nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteRunTask 删除 Run Task，仍被工作空间引用时返回 HTTP 409
+func (c *Client) DeleteRunTask(ctx context.Context, runTaskID string) error {
+	return c.do(ctx, http.MethodDelete, runTaskPath(runTaskID), nil, nil, nil)
+}
+
+// WorkspaceRunTask 工作空间关联的 Run Task
+type WorkspaceRunTask struct {
+	ID                 uint   `json:"id"`
+	WorkspaceRunTaskID string `json:"workspace_run_task_id"`
+	WorkspaceID        string `json:"workspace_id"`
+	RunTaskID          string `json:"run_task_id"`
+	RunTaskName        string `json:"run_task_name"`
+	Stage              string `json:"stage"`
+	EnforcementLevel   string `json:"enforcement_level"`
+	Enabled            bool   `json:"enabled"`
+}
+
+// CreateWorkspaceRunTaskRequest 关联 Run Task 参数
+type CreateWorkspaceRunTaskRequest struct {
+	RunTaskID        string `json:"run_task_id"`
+	Stage            string `json:"stage"`
+	EnforcementLevel string `json:"enforcement_level,omitempty"`
+}
+
+// UpdateWorkspaceRunTaskRequest 更新关联参数，nil 字段不修改
+type UpdateWorkspaceRunTaskRequest struct {
+	Stage            *string `json:"stage,omitempty"`
+	EnforcementLevel *string `json:"enforcement_level,omitempty"`
+	Enabled          *bool   `json:"enabled,omitempty"`
+}
+
+func workspaceRunTasksPath(workspaceID string) string {
+	return "/workspaces/" + url.PathEscape(workspaceID) + "/run-tasks"
+}
+
+// ListWorkspaceRunTasks 查询工作空间直接关联的 Run Task（不含全局 Run Task）
+func (c *Client) ListWorkspaceRunTasks(ctx context.Context, workspaceID string) ([]WorkspaceRunTask, error) {
+	var resp struct {
+		WorkspaceRunTasks []WorkspaceRunTask `json:"workspace_run_tasks"`
+	}
+	if err := c.do(ctx, http.MethodGet, workspaceRunTasksPath(workspaceID), nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.WorkspaceRunTasks, nil
+}
+
+// GetWorkspaceRunTask 按 workspace_run_task_id 查找关联，不存在时返回 ErrNotFound
+func (c *Client) GetWorkspaceRunTask(ctx context.Context, workspaceID, workspaceRunTaskID string) (*WorkspaceRunTask, error) {
+	items, err := c.ListWorkspaceRunTasks(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		if items[i].WorkspaceRunTaskID == workspaceRunTaskID {
+			return &items[i], nil
+		}
+	}
+	return nil, &APIError{StatusCode: http.StatusNotFound, Message: "workspace run task not found"}
+}
+
+// CreateWorkspaceRunTask 为工作空间关联 Run Task，同一阶段重复关联返回 HTTP 409
+func (c *Client) CreateWorkspaceRunTask(ctx context.Context, workspaceID string, req CreateWorkspaceRunTaskRequest) (*WorkspaceRunTask, error) {
+	var out WorkspaceRunTask
+	if err := c.do(ctx, http.MethodPost, workspaceRunTasksPath(workspaceID), nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateWorkspaceRunTask 更新关联
+func (c *Client) UpdateWorkspaceRunTask(ctx context.Context, workspaceID, workspaceRunTaskID string, req UpdateWorkspaceRunTaskRequest) (*WorkspaceRunTask, error) {
+	var out WorkspaceRunTask
+	path := workspaceRunTasksPath(workspaceID) + "/" + url.PathEscape(workspaceRunTaskID)
+	if err := c.do(ctx, http.MethodPut, path, nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteWorkspaceRunTask 取消关联
+func (c *Client) DeleteWorkspaceRunTask(ctx context.Context, workspaceID, workspaceRunTaskID string) error {
+	path := workspaceRunTasksPath(workspaceID) + "/" + url.PathEscape(workspaceRunTaskID)
+	return c.do(ctx, http.MethodDelete, path, nil, nil, nil)
+}
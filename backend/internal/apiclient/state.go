@@ -0,0 +1,96 @@
+package apiclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// StateVersion State 版本元数据（不含内容）
+type StateVersion struct {
+	ID            uint      `json:"id"`
+	WorkspaceID   string    `json:"workspace_id"`
+	Version       int       `json:"version"`
+	Serial        int       `json:"serial"`
+	Lineage       string    `json:"lineage"`
+	Checksum      string    `json:"checksum"`
+	SizeBytes     int       `json:"size_bytes"`
+	IsImported    bool      `json:"is_imported"`
+	Description   string    `json:"description"`
+	CreatedBy     *string   `json:"created_by"`
+	CreatedByName string    `json:"created_by_name"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// StateVersionList 分页的 State 版本列表（按版本号倒序）
+type StateVersionList struct {
+	Versions       []StateVersion `json:"versions"`
+	Total          int64          `json:"total"`
+	CurrentVersion int            `json:"current_version"`
+	Limit          int            `json:"limit"`
+	Offset         int            `json:"offset"`
+}
+
+func statePath(workspaceID string) string {
+	return "/workspaces/" + url.PathEscape(workspaceID) + "/state"
+}
+
+// ListStateVersions 查询 State 版本历史
+func (c *Client) ListStateVersions(ctx context.Context, workspaceID string, limit, offset int) (*StateVersionList, error) {
+	query := url.Values{}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	if offset > 0 {
+		query.Set("offset", strconv.Itoa(offset))
+	}
+	var resp StateVersionList
+	if err := c.do(ctx, http.MethodGet, statePath(workspaceID)+"/versions", query, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// DownloadState 下载指定版本的 State 文件；version 为 0 时下载当前版本
+func (c *Client) DownloadState(ctx context.Context, workspaceID string, version int) ([]byte, error) {
+	if version <= 0 {
+		list, err := c.ListStateVersions(ctx, workspaceID, 1, 0)
+		if err != nil {
+			return nil, err
+		}
+		if list.CurrentVersion == 0 {
+			return nil, &APIError{StatusCode: http.StatusNotFound, Message: fmt.Sprintf("workspace %s has no state", workspaceID)}
+		}
+		version = list.CurrentVersion
+	}
+	return c.doRaw(ctx, http.MethodGet, statePath(workspaceID)+"/versions/"+strconv.Itoa(version)+"/download", nil, nil)
+}
+
+// UploadStateResult State 上传结果
+type UploadStateResult struct {
+	Version      int          `json:"version"`
+	Warnings     []string     `json:"warnings"`
+	StateVersion StateVersion `json:"state_version"`
+}
+
+// UploadState 上传 State 生成新版本；force 为 true 时跳过 lineage/serial 校验（上传后工作空间被锁定）
+func (c *Client) UploadState(ctx context.Context, workspaceID string, state []byte, force bool, description string) (*UploadStateResult, error) {
+	var parsed map[string]interface{}
+	if err := json.Unmarshal(state, &parsed); err != nil {
+		return nil, fmt.Errorf("state is not valid JSON: %w", err)
+	}
+	body := map[string]interface{}{
+		"state":       parsed,
+		"force":       force,
+		"description": description,
+	}
+	var resp UploadStateResult
+	if err := c.do(ctx, http.MethodPost, statePath(workspaceID)+"/upload", nil, body, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
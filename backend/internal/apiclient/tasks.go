@@ -0,0 +1,204 @@
+package apiclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Task 工作空间运行任务
+type Task struct {
+	ID             uint      `json:"id"`
+	WorkspaceID    string    `json:"workspace_id"`
+	TaskType       string    `json:"task_type"`
+	Status         string    `json:"status"`
+	Stage          string    `json:"stage"`
+	Description    string    `json:"description"`
+	ErrorMessage   string    `json:"error_message"`
+	ChangesAdd     int       `json:"changes_add"`
+	ChangesChange  int       `json:"changes_change"`
+	ChangesDestroy int       `json:"changes_destroy"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// 任务状态（与 models.TaskStatus 保持一致）
+const (
+	TaskStatusApplyPending       = "apply_pending"
+	TaskStatusPlannedAndFinished = "planned_and_finished"
+	TaskStatusSuccess            = "success"
+	TaskStatusApplied            = "applied"
+	TaskStatusFailed             = "failed"
+	TaskStatusCancelled          = "cancelled"
+)
+
+// IsFinal 任务是否已结束（apply_pending 需要人工确认，也视为本次运行结束）
+func (t *Task) IsFinal() bool {
+	switch t.Status {
+	case TaskStatusApplyPending, TaskStatusPlannedAndFinished, TaskStatusSuccess,
+		TaskStatusApplied, TaskStatusFailed, TaskStatusCancelled:
+		return true
+	}
+	return false
+}
+
+// IsFailed 任务是否失败或被取消
+func (t *Task) IsFailed() bool {
+	return t.Status == TaskStatusFailed || t.Status == TaskStatusCancelled
+}
+
+func taskPath(workspaceID string, taskID uint, action string) string {
+	p := "/workspaces/" + url.PathEscape(workspaceID) + "/tasks/" + strconv.FormatUint(uint64(taskID), 10)
+	if action != "" {
+		p += "/" + action
+	}
+	return p
+}
+
+// CreatePlan 排队一次 Plan；apply 为 true 时创建 plan_and_apply 任务
+func (c *Client) CreatePlan(ctx context.Context, workspaceID, description string, apply bool) (*Task, error) {
+	runType := "plan"
+	if apply {
+		runType = "plan_and_apply"
+	}
+	body := map[string]string{"description": description, "run_type": runType}
+
+	var resp struct {
+		Task Task `json:"task"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/workspaces/"+url.PathEscape(workspaceID)+"/tasks/plan", nil, body, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Task, nil
+}
+
+// GetTask 获取任务详情
+func (c *Client) GetTask(ctx context.Context, workspaceID string, taskID uint) (*Task, error) {
+	var resp struct {
+		Task Task `json:"task"`
+	}
+	if err := c.do(ctx, http.MethodGet, taskPath(workspaceID, taskID, ""), nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Task, nil
+}
+
+// ConfirmApply 确认执行处于 apply_pending 状态的任务
+func (c *Client) ConfirmApply(ctx context.Context, workspaceID string, taskID uint, description string) (*Task, error) {
+	body := map[string]string{"apply_description": description}
+	var resp struct {
+		Task Task `json:"task"`
+	}
+	if err := c.do(ctx, http.MethodPost, taskPath(workspaceID, taskID, "confirm-apply"), nil, body, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Task, nil
+}
+
+// CancelTask 取消任务
+func (c *Client) CancelTask(ctx context.Context, workspaceID string, taskID uint) (*Task, error) {
+	var resp struct {
+		Task Task `json:"task"`
+	}
+	if err := c.do(ctx, http.MethodPost, taskPath(workspaceID, taskID, "cancel"), nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Task, nil
+}
+
+// GetTaskLogs 获取已结束任务的完整文本日志（含已归档的输出）
+func (c *Client) GetTaskLogs(ctx context.Context, taskID uint) (string, error) {
+	query := url.Values{"format": {"text"}}
+	data, err := c.doRaw(ctx, http.MethodGet, "/tasks/"+strconv.FormatUint(uint64(taskID), 10)+"/logs", query, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// OutputMessage 实时输出流中的一条消息（与 services.OutputMessage 保持一致）
+type OutputMessage struct {
+	Type      string    `json:"type"` // connected, output, error, completed, stage_marker
+	Line      string    `json:"line"`
+	Timestamp time.Time `json:"timestamp"`
+	LineNum   int       `json:"line_num,omitempty"`
+	Stage     string    `json:"stage,omitempty"`
+	Status    string    `json:"status,omitempty"`
+}
+
+// StreamTaskOutput 通过 WebSocket 订阅任务实时输出，先回放历史再推送新行
+// 收到 completed 消息、服务端关闭连接或 ctx 取消时返回；fn 返回错误时中止订阅
+func (c *Client) StreamTaskOutput(ctx context.Context, taskID uint, fn func(OutputMessage) error) error {
+	wsURL, err := url.Parse(c.endpoint("/tasks/"+strconv.FormatUint(uint64(taskID), 10)+"/output/stream", nil))
+	if err != nil {
+		return err
+	}
+	switch wsURL.Scheme {
+	case "https":
+		wsURL.Scheme = "wss"
+	default:
+		wsURL.Scheme = "ws"
+	}
+
+	header := http.Header{}
+	header.Set("User-Agent", c.userAgent)
+	if c.token != "" {
+		header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	conn, resp, err := websocket.DefaultDialer.DialContext(ctx, wsURL.String(), header)
+	if err != nil {
+		if resp != nil {
+			return &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(resp.Status)}
+		}
+		return fmt.Errorf("failed to connect to output stream: %w", err)
+	}
+	defer conn.Close()
+
+	// ctx 取消时关闭连接，打断阻塞中的 ReadMessage
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			var closeErr *websocket.CloseError
+			if errors.As(err, &closeErr) {
+				return nil
+			}
+			return fmt.Errorf("output stream interrupted: %w", err)
+		}
+
+		var msg OutputMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			continue
+		}
+		if msg.Type == "connected" {
+			continue
+		}
+		if err := fn(msg); err != nil {
+			return err
+		}
+		if msg.Type == "completed" {
+			return nil
+		}
+	}
+}
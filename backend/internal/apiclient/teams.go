@@ -0,0 +1,188 @@
+package apiclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Team 团队
+type Team struct {
+	ID          string `json:"id"`
+	OrgID       uint   `json:"org_id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+	Description string `json:"description"`
+	IsSystem    bool   `json:"is_system"`
+}
+
+// CreateTeamRequest 创建团队参数
+type CreateTeamRequest struct {
+	OrgID       uint   `json:"org_id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// TeamMember 团队成员
+type TeamMember struct {
+	ID     uint   `json:"id"`
+	TeamID string `json:"team_id"`
+	UserID string `json:"user_id"`
+	Role   string `json:"role"`
+}
+
+// RoleAssignment 用户或团队的角色分配
+type RoleAssignment struct {
+	ID        uint       `json:"id"`
+	UserID    string     `json:"user_id,omitempty"`
+	TeamID    string     `json:"team_id,omitempty"`
+	RoleID    uint       `json:"role_id"`
+	RoleName  string     `json:"role_name,omitempty"`
+	ScopeType string     `json:"scope_type"`
+	ScopeID   uint       `json:"scope_id"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Reason    string     `json:"reason,omitempty"`
+}
+
+// AssignRoleRequest 分配角色参数，ExpiresAt 为 RFC3339 格式，空表示永不过期
+type AssignRoleRequest struct {
+	RoleID    uint   `json:"role_id"`
+	ScopeType string `json:"scope_type"`
+	ScopeID   uint   `json:"scope_id"`
+	ExpiresAt string `json:"expires_at,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+func teamPath(teamID string) string {
+	return "/iam/teams/" + url.PathEscape(teamID)
+}
+
+// GetTeam 获取团队
+func (c *Client) GetTeam(ctx context.Context, teamID string) (*Team, error) {
+	var out Team
+	if err := c.do(ctx, http.MethodGet, teamPath(teamID), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateTeam 创建团队
+func (c *Client) CreateTeam(ctx context.Context, req CreateTeamRequest) (*Team, error) {
+	var out Team
+	if err := c.do(ctx, http.MethodPost, "/iam/teams", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteTeam 删除团队（系统团队不可删除）
+func (c *Client) DeleteTeam(ctx context.Context, teamID string) error {
+	return c.do(ctx, http.MethodDelete, teamPath(teamID), nil, nil, nil)
+}
+
+// ListTeamMembers 查询团队成员
+func (c *Client) ListTeamMembers(ctx context.Context, teamID string) ([]TeamMember, error) {
+	var resp struct {
+		Members []TeamMember `json:"members"`
+	}
+	if err := c.do(ctx, http.MethodGet, teamPath(teamID)+"/members", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Members, nil
+}
+
+// GetTeamMember 查找团队中的成员，不存在时返回 ErrNotFound
+func (c *Client) GetTeamMember(ctx context.Context, teamID, userID string) (*TeamMember, error) {
+	members, err := c.ListTeamMembers(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range members {
+		if members[i].UserID == userID {
+			return &members[i], nil
+		}
+	}
+	return nil, &APIError{StatusCode: http.StatusNotFound, Message: "team member not found"}
+}
+
+// AddTeamMember 添加团队成员，role 为 MEMBER 或 MAINTAINER
+func (c *Client) AddTeamMember(ctx context.Context, teamID, userID, role string) error {
+	req := map[string]string{"user_id": userID, "role": role}
+	return c.do(ctx, http.MethodPost, teamPath(teamID)+"/members", nil, req, nil)
+}
+
+// RemoveTeamMember 移除团队成员
+func (c *Client) RemoveTeamMember(ctx context.Context, teamID, userID string) error {
+	return c.do(ctx, http.MethodDelete, teamPath(teamID)+"/members/"+url.PathEscape(userID), nil, nil, nil)
+}
+
+// ListTeamRoles 查询团队的有效角色分配
+func (c *Client) ListTeamRoles(ctx context.Context, teamID string) ([]RoleAssignment, error) {
+	var resp struct {
+		Data []RoleAssignment `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodGet, teamPath(teamID)+"/roles", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+// AssignTeamRole 为团队分配角色
+// 接口不返回分配 ID，分配后按角色和作用域从列表中查出
+func (c *Client) AssignTeamRole(ctx context.Context, teamID string, req AssignRoleRequest) (*RoleAssignment, error) {
+	if err := c.do(ctx, http.MethodPost, teamPath(teamID)+"/roles", nil, req, nil); err != nil {
+		return nil, err
+	}
+	assignments, err := c.ListTeamRoles(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range assignments {
+		a := assignments[i]
+		if a.RoleID == req.RoleID && a.ScopeType == req.ScopeType && a.ScopeID == req.ScopeID {
+			return &a, nil
+		}
+	}
+	return nil, &APIError{StatusCode: http.StatusNotFound, Message: "role assignment not found after creation"}
+}
+
+// RevokeTeamRole 撤销团队的角色分配
+func (c *Client) RevokeTeamRole(ctx context.Context, teamID string, assignmentID uint) error {
+	path := teamPath(teamID) + "/roles/" + strconv.FormatUint(uint64(assignmentID), 10)
+	return c.do(ctx, http.MethodDelete, path, nil, nil, nil)
+}
+
+func userRolesPath(userID string) string {
+	return "/iam/users/" + url.PathEscape(userID) + "/roles"
+}
+
+// ListUserRoles 查询用户的有效角色分配
+func (c *Client) ListUserRoles(ctx context.Context, userID string) ([]RoleAssignment, error) {
+	var resp struct {
+		Data []RoleAssignment `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodGet, userRolesPath(userID), nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+// AssignUserRole 为用户分配角色
+func (c *Client) AssignUserRole(ctx context.Context, userID string, req AssignRoleRequest) (*RoleAssignment, error) {
+	var resp struct {
+		Data RoleAssignment `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodPost, userRolesPath(userID), nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Data, nil
+}
+
+// RevokeUserRole 撤销用户的角色分配
+func (c *Client) RevokeUserRole(ctx context.Context, userID string, assignmentID uint) error {
+	path := userRolesPath(userID) + "/" + strconv.FormatUint(uint64(assignmentID), 10)
+	return c.do(ctx, http.MethodDelete, path, nil, nil, nil)
+}
@@ -0,0 +1,111 @@
+package apiclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// 变量类型与值格式（与 models.VariableType / models.ValueFormat 保持一致）
+const (
+	VariableTypeTerraform   = "terraform"
+	VariableTypeEnvironment = "environment"
+	ValueFormatString       = "string"
+	ValueFormatHCL          = "hcl"
+	ValueFormatSecretRef    = "secret_ref"
+)
+
+// Variable 工作空间变量（敏感变量不返回 value）
+type Variable struct {
+	ID           uint      `json:"id"`
+	VariableID   string    `json:"variable_id"`
+	WorkspaceID  string    `json:"workspace_id"`
+	Key          string    `json:"key"`
+	Version      int       `json:"version"`
+	Value        string    `json:"value,omitempty"`
+	VariableType string    `json:"variable_type"`
+	ValueFormat  string    `json:"value_format"`
+	Sensitive    bool      `json:"sensitive"`
+	Description  string    `json:"description"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// VariableInput 创建变量参数
+type VariableInput struct {
+	Key          string `json:"key"`
+	Value        string `json:"value"`
+	VariableType string `json:"variable_type"`
+	ValueFormat  string `json:"value_format,omitempty"`
+	Sensitive    bool   `json:"sensitive"`
+	Description  string `json:"description"`
+}
+
+// VariableUpdate 更新变量参数，Version 必须是当前版本号（乐观锁），nil 字段不修改
+type VariableUpdate struct {
+	Version      int     `json:"version"`
+	Key          *string `json:"key,omitempty"`
+	Value        *string `json:"value,omitempty"`
+	VariableType *string `json:"variable_type,omitempty"`
+	ValueFormat  *string `json:"value_format,omitempty"`
+	Sensitive    *bool   `json:"sensitive,omitempty"`
+	Description  *string `json:"description,omitempty"`
+}
+
+func variablesPath(workspaceID string) string {
+	return "/workspaces/" + url.PathEscape(workspaceID) + "/variables"
+}
+
+// ListVariables 查询工作空间变量，variableType 为空时返回全部类型
+func (c *Client) ListVariables(ctx context.Context, workspaceID, variableType string) ([]Variable, error) {
+	var query url.Values
+	if variableType != "" {
+		query = url.Values{"type": {variableType}}
+	}
+	var resp struct {
+		Data []Variable `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodGet, variablesPath(workspaceID), query, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+// GetVariable 获取变量（支持数字ID或 variable_id）
+func (c *Client) GetVariable(ctx context.Context, workspaceID, variableID string) (*Variable, error) {
+	var resp struct {
+		Data Variable `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodGet, variablesPath(workspaceID)+"/"+url.PathEscape(variableID), nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Data, nil
+}
+
+// CreateVariable 创建变量
+func (c *Client) CreateVariable(ctx context.Context, workspaceID string, in VariableInput) (*Variable, error) {
+	var resp struct {
+		Data Variable `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodPost, variablesPath(workspaceID), nil, in, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Data, nil
+}
+
+// UpdateVariable 更新变量，版本冲突时返回 HTTP 409
+func (c *Client) UpdateVariable(ctx context.Context, workspaceID, variableID string, in VariableUpdate) (*Variable, error) {
+	var resp struct {
+		Data Variable `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodPut, variablesPath(workspaceID)+"/"+url.PathEscape(variableID), nil, in, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Data, nil
+}
+
+// DeleteVariable 删除变量
+func (c *Client) DeleteVariable(ctx context.Context, workspaceID, variableID string) error {
+	return c.do(ctx, http.MethodDelete, variablesPath(workspaceID)+"/"+url.PathEscape(variableID), nil, nil, nil)
+}
@@ -0,0 +1,136 @@
+package apiclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Workspace 工作空间（ID 使用语义化的 workspace_id）
+type Workspace struct {
+	WorkspaceID      string                 `json:"workspace_id"`
+	Name             string                 `json:"name"`
+	Description      string                 `json:"description"`
+	ExecutionMode    string                 `json:"execution_mode"`
+	AgentPoolID      *uint                  `json:"agent_pool_id,omitempty"`
+	K8sConfigID      *uint                  `json:"k8s_config_id,omitempty"`
+	AutoApply        bool                   `json:"auto_apply"`
+	PlanOnly         bool                   `json:"plan_only"`
+	TerraformVersion string                 `json:"terraform_version"`
+	Workdir          string                 `json:"workdir"`
+	StateBackend     string                 `json:"state_backend"`
+	Tags             map[string]interface{} `json:"tags,omitempty"`
+	IsLocked         bool                   `json:"is_locked"`
+	CreatedAt        time.Time              `json:"created_at"`
+	UpdatedAt        time.Time              `json:"updated_at"`
+}
+
+// ListWorkspacesOptions 工作空间列表查询条件
+type ListWorkspacesOptions struct {
+	Search    string
+	ProjectID uint
+	Page      int
+	Size      int
+}
+
+// WorkspaceList 分页的工作空间列表
+type WorkspaceList struct {
+	Items []Workspace `json:"items"`
+	Total int64       `json:"total"`
+	Page  int         `json:"page"`
+	Size  int         `json:"size"`
+}
+
+// ListWorkspaces 分页查询工作空间
+func (c *Client) ListWorkspaces(ctx context.Context, opts ListWorkspacesOptions) (*WorkspaceList, error) {
+	query := url.Values{}
+	if opts.Search != "" {
+		query.Set("search", opts.Search)
+	}
+	if opts.ProjectID > 0 {
+		query.Set("project_id", strconv.FormatUint(uint64(opts.ProjectID), 10))
+	}
+	if opts.Page > 0 {
+		query.Set("page", strconv.Itoa(opts.Page))
+	}
+	if opts.Size > 0 {
+		query.Set("size", strconv.Itoa(opts.Size))
+	}
+
+	var resp struct {
+		Data WorkspaceList `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/workspaces", query, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Data, nil
+}
+
+// GetWorkspace 获取工作空间详情（支持语义化ID和数字ID）
+func (c *Client) GetWorkspace(ctx context.Context, workspaceID string) (*Workspace, error) {
+	var resp struct {
+		Data Workspace `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/workspaces/"+url.PathEscape(workspaceID), nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Data, nil
+}
+
+// CreateWorkspaceRequest 创建工作空间参数
+type CreateWorkspaceRequest struct {
+	Name             string                 `json:"name"`
+	Description      string                 `json:"description,omitempty"`
+	ExecutionMode    string                 `json:"execution_mode"`
+	AgentPoolID      *uint                  `json:"agent_pool_id,omitempty"`
+	K8sConfigID      *uint                  `json:"k8s_config_id,omitempty"`
+	AutoApply        bool                   `json:"auto_apply"`
+	PlanOnly         bool                   `json:"plan_only"`
+	TerraformVersion string                 `json:"terraform_version,omitempty"`
+	Workdir          string                 `json:"workdir,omitempty"`
+	StateBackend     string                 `json:"state_backend"`
+	Tags             map[string]interface{} `json:"tags,omitempty"`
+}
+
+// CreateWorkspace 创建工作空间
+func (c *Client) CreateWorkspace(ctx context.Context, req CreateWorkspaceRequest) (*Workspace, error) {
+	if req.StateBackend == "" {
+		req.StateBackend = "local"
+	}
+	var resp struct {
+		Data Workspace `json:"data"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/workspaces", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Data, nil
+}
+
+// UpdateWorkspaceRequest 更新工作空间参数，nil 字段不修改
+type UpdateWorkspaceRequest struct {
+	Name             *string                 `json:"name,omitempty"`
+	Description      *string                 `json:"description,omitempty"`
+	ExecutionMode    *string                 `json:"execution_mode,omitempty"`
+	AgentPoolID      *uint                   `json:"agent_pool_id,omitempty"`
+	K8sConfigID      *uint                   `json:"k8s_config_id,omitempty"`
+	AutoApply        *bool                   `json:"auto_apply,omitempty"`
+	PlanOnly         *bool                   `json:"plan_only,omitempty"`
+	TerraformVersion *string                 `json:"terraform_version,omitempty"`
+	Workdir          *string                 `json:"workdir,omitempty"`
+	Tags             *map[string]interface{} `json:"tags,omitempty"` // 指向空 map 时清空标签
+}
+
+// UpdateWorkspace 更新工作空间，接口只返回结果消息，更新后重新读取详情
+func (c *Client) UpdateWorkspace(ctx context.Context, workspaceID string, req UpdateWorkspaceRequest) (*Workspace, error) {
+	if err := c.do(ctx, http.MethodPut, "/workspaces/"+url.PathEscape(workspaceID), nil, req, nil); err != nil {
+		return nil, err
+	}
+	return c.GetWorkspace(ctx, workspaceID)
+}
+
+// DeleteWorkspace 删除工作空间
+func (c *Client) DeleteWorkspace(ctx context.Context, workspaceID string) error {
+	return c.do(ctx, http.MethodDelete, "/workspaces/"+url.PathEscape(workspaceID), nil, nil, nil)
+}
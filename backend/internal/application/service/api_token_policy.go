@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"iac-platform/internal/domain/valueobject"
+	"iac-platform/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// APITokenPolicyConfigKey API Token 策略在 system_configs 中的 key
+const APITokenPolicyConfigKey = "api_token_policy"
+
+// 默认最长有效期（天），与作用域功能上线前接口允许的上限一致
+const defaultMaxTokenLifetimeDays = 365
+
+var (
+	// ErrTokenExpiryRequired 未指定过期时间
+	ErrTokenExpiryRequired = errors.New("expires_in_days is required")
+	// ErrTokenExpiryTooLong 过期时间超过管理员配置的上限
+	ErrTokenExpiryTooLong = errors.New("expires_in_days exceeds the maximum token lifetime")
+	// ErrTokenScopeRequired 未指定作用域
+	ErrTokenScopeRequired = errors.New("scopes is required")
+	// ErrInvalidTokenScope 作用域配置无效
+	ErrInvalidTokenScope = errors.New("invalid token scopes")
+)
+
+// APITokenPolicy API Token 策略，保存在 system_configs（key = api_token_policy）
+type APITokenPolicy struct {
+	MaxUserTokenDays int `json:"max_user_token_days"`
+	MaxTeamTokenDays int `json:"max_team_token_days"`
+}
+
+// DefaultAPITokenPolicy 默认策略
+func DefaultAPITokenPolicy() *APITokenPolicy {
+	return &APITokenPolicy{
+		MaxUserTokenDays: defaultMaxTokenLifetimeDays,
+		MaxTeamTokenDays: defaultMaxTokenLifetimeDays,
+	}
+}
+
+// Validate 校验策略
+func (p *APITokenPolicy) Validate() error {
+	if p.MaxUserTokenDays < 1 || p.MaxUserTokenDays > 3650 {
+		return fmt.Errorf("max_user_token_days must be between 1 and 3650")
+	}
+	if p.MaxTeamTokenDays < 1 || p.MaxTeamTokenDays > 3650 {
+		return fmt.Errorf("max_team_token_days must be between 1 and 3650")
+	}
+	return nil
+}
+
+// LoadAPITokenPolicy 读取 API Token 策略，未配置时返回默认策略
+func LoadAPITokenPolicy(ctx context.Context, db *gorm.DB) (*APITokenPolicy, error) {
+	policy := DefaultAPITokenPolicy()
+
+	var sc models.SystemConfig
+	if err := db.WithContext(ctx).Where("key = ?", APITokenPolicyConfigKey).First(&sc).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return policy, nil
+		}
+		return nil, fmt.Errorf("failed to load api token policy: %w", err)
+	}
+	if err := json.Unmarshal([]byte(sc.Value), policy); err != nil {
+		return nil, fmt.Errorf("invalid api token policy: %w", err)
+	}
+	return policy, nil
+}
+
+// SaveAPITokenPolicy 保存 API Token 策略
+func SaveAPITokenPolicy(ctx context.Context, db *gorm.DB, policy *APITokenPolicy) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	value, err := json.Marshal(policy)
+	if err != nil {
+		return err
+	}
+
+	var existing models.SystemConfig
+	err = db.WithContext(ctx).Where("key = ?", APITokenPolicyConfigKey).First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return db.WithContext(ctx).Create(&models.SystemConfig{
+			Key:         APITokenPolicyConfigKey,
+			Value:       string(value),
+			Description: "API Token 最长有效期策略",
+			UpdatedAt:   time.Now(),
+		}).Error
+	}
+	if err != nil {
+		return err
+	}
+	existing.Value = string(value)
+	existing.UpdatedAt = time.Now()
+	return db.WithContext(ctx).Save(&existing).Error
+}
+
+// validateTokenRequest 校验创建 Token 时的有效期和作用域
+func validateTokenRequest(expiresInDays, maxDays int, scopes *valueobject.TokenScope) error {
+	if expiresInDays <= 0 {
+		return ErrTokenExpiryRequired
+	}
+	if expiresInDays > maxDays {
+		return fmt.Errorf("%w (%d days)", ErrTokenExpiryTooLong, maxDays)
+	}
+	if scopes == nil {
+		return ErrTokenScopeRequired
+	}
+	if err := scopes.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidTokenScope, err)
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"time"
@@ -9,6 +10,8 @@ import (
 	"iac-platform/internal/domain/entity"
 	"iac-platform/internal/domain/repository"
 	"iac-platform/internal/domain/valueobject"
+
+	"gorm.io/gorm"
 )
 
 // CheckPermissionRequest 权限检查请求
@@ -19,6 +22,13 @@ type CheckPermissionRequest struct {
 	ScopeID       uint                        `json:"scope_id"`     // 保留用于向后兼容
 	ScopeIDStr    string                      `json:"scope_id_str"` // 新增：支持语义化ID
 	RequiredLevel valueobject.PermissionLevel `json:"required_level"`
+
+	// TeamID 以团队身份检查（Team Token），此时 UserID 为空
+	TeamID string `json:"team_id,omitempty"`
+	// SystemAdmin 持有者为系统管理员，授权视为 ADMIN，仍受 TokenScope 限制
+	SystemAdmin bool `json:"system_admin,omitempty"`
+	// TokenScope 请求使用的 API Token 作用域，有效权限取授权与作用域的交集
+	TokenScope *valueobject.TokenScope `json:"token_scope,omitempty"`
 }
 
 // CheckPermissionResult 权限检查结果
@@ -102,15 +112,32 @@ func (c *PermissionCheckerImpl) CheckPermission(
 		}
 	}
 
+	// 限定了 workspace 的 Token 在无法确定具体 workspace 时不适用，按拒绝处理而不是报错
+	if req.ScopeType == valueobject.ScopeTypeWorkspace && req.ScopeID == 0 &&
+		req.TokenScope != nil && len(req.TokenScope.WorkspaceIDs) > 0 {
+		return &CheckPermissionResult{
+			IsAllowed:      false,
+			EffectiveLevel: valueobject.PermissionLevelNone,
+			DenyReason:     "token scope does not include this workspace",
+			Source:         "regular",
+		}, nil
+	}
+
 	// 1. 验证请求参数
 	if err := c.validateRequest(req); err != nil {
 		return nil, err
 	}
 
-	// 2. 获取用户所属团队
-	userTeams, err := c.GetUserTeams(ctx, req.UserID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get user teams: %w", err)
+	// 2. 获取用户所属团队（Team Token 直接以该团队作为主体）
+	var userTeams []string
+	if req.UserID == "" {
+		userTeams = []string{req.TeamID}
+	} else {
+		teams, err := c.GetUserTeams(ctx, req.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user teams: %w", err)
+		}
+		userTeams = teams
 	}
 
 	// 3. 获取作用域层级信息
@@ -127,12 +154,31 @@ func (c *PermissionCheckerImpl) CheckPermission(
 
 	// 5. 计算有效权限等级
 	effectiveLevel := c.calculateEffectiveLevel(allGrants)
+	if req.SystemAdmin {
+		effectiveLevel = valueobject.PermissionLevelAdmin
+	}
+
+	// 5.1 与 Token 作用域取交集
+	scopeDenyReason := ""
+	if req.TokenScope != nil {
+		scopeLevel, reason, err := c.tokenScopeLevel(ctx, req, scopeInfo)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate token scope: %w", err)
+		}
+		if scopeLevel < effectiveLevel {
+			effectiveLevel = scopeLevel
+			scopeDenyReason = reason
+		}
+	}
 
 	// 6. 判断是否允许访问
 	isAllowed := effectiveLevel >= req.RequiredLevel && effectiveLevel != valueobject.PermissionLevelNone
 	denyReason := ""
 	if !isAllowed {
 		denyReason = c.getDenyReason(effectiveLevel, req.RequiredLevel)
+		if scopeDenyReason != "" {
+			denyReason = scopeDenyReason
+		}
 	}
 
 	result := &CheckPermissionResult{
@@ -487,6 +533,39 @@ func (c *PermissionCheckerImpl) getScopeInfo(
 	return info, nil
 }
 
+// tokenScopeLevel 计算 Token 作用域对本次检查允许的最高权限
+func (c *PermissionCheckerImpl) tokenScopeLevel(
+	ctx context.Context,
+	req *CheckPermissionRequest,
+	scopeInfo *ScopeInfo,
+) (valueobject.PermissionLevel, string, error) {
+	target := valueobject.TokenScopeTarget{
+		ResourceType: req.ResourceType,
+		ScopeType:    req.ScopeType,
+		ProjectID:    scopeInfo.ProjectID,
+	}
+	if req.ScopeType == valueobject.ScopeTypeWorkspace && len(req.TokenScope.WorkspaceIDs) > 0 {
+		target.WorkspaceID = req.ScopeIDStr
+		if _, err := parseUint(target.WorkspaceID); target.WorkspaceID == "" || err == nil {
+			var workspace struct {
+				WorkspaceID string `gorm:"column:workspace_id"`
+			}
+			if err := c.projectRepo.GetDB().WithContext(ctx).Table("workspaces").
+				Select("workspace_id").
+				Where("id = ?", req.ScopeID).
+				First(&workspace).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return valueobject.PermissionLevelNone, "token scope does not include this workspace", nil
+				}
+				return valueobject.PermissionLevelNone, "", err
+			}
+			target.WorkspaceID = workspace.WorkspaceID
+		}
+	}
+	level, reason := req.TokenScope.MaxLevel(target)
+	return level, reason, nil
+}
+
 // filterByScope 按作用域过滤权限
 func (c *PermissionCheckerImpl) filterByScope(
 	grants []*entity.PermissionGrant,
@@ -566,7 +645,7 @@ func (c *PermissionCheckerImpl) mapResourceToPermType(resourceType valueobject.R
 
 // validateRequest 验证请求参数
 func (c *PermissionCheckerImpl) validateRequest(req *CheckPermissionRequest) error {
-	if req.UserID == "" {
+	if req.UserID == "" && req.TeamID == "" {
 		return fmt.Errorf("user_id is required")
 	}
 	if !req.ResourceType.IsValid() {
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"iac-platform/internal/config"
+	"iac-platform/internal/domain/valueobject"
 	"iac-platform/internal/models"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -43,7 +44,16 @@ type TeamTokenClaims struct {
 }
 
 // GenerateToken 生成团队Token
-func (s *TeamTokenService) GenerateToken(ctx context.Context, teamID string, tokenName string, userID string, expiresInDays int) (*models.TeamTokenCreateResponse, error) {
+// Token 必须设置过期时间（不超过 API Token 策略上限）和作用域
+func (s *TeamTokenService) GenerateToken(ctx context.Context, teamID string, tokenName string, userID string, expiresInDays int, scopes *valueobject.TokenScope) (*models.TeamTokenCreateResponse, error) {
+	policy, err := LoadAPITokenPolicy(ctx, s.db)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateTokenRequest(expiresInDays, policy.MaxTeamTokenDays, scopes); err != nil {
+		return nil, err
+	}
+
 	// 检查团队是否存在
 	var team struct {
 		TeamID string `gorm:"column:team_id"`
@@ -75,12 +85,8 @@ func (s *TeamTokenService) GenerateToken(ctx context.Context, teamID string, tok
 
 	// 创建token记录
 	now := time.Now()
-	var expiresAtPtr *time.Time
-	if expiresInDays > 0 {
-		expiresAt := now.Add(time.Duration(expiresInDays) * 24 * time.Hour)
-		expiresAtPtr = &expiresAt
-	}
-	// expiresInDays = 0 表示永不过期，expiresAtPtr = nil
+	expiresAt := now.Add(time.Duration(expiresInDays) * 24 * time.Hour)
+	expiresAtPtr := &expiresAt
 
 	// 计算token_id的hash
 	tokenIDHash := sha256.Sum256([]byte(tokenID))
@@ -96,6 +102,7 @@ func (s *TeamTokenService) GenerateToken(ctx context.Context, teamID string, tok
 		CreatedAt:   now,
 		CreatedBy:   &createdBy,
 		ExpiresAt:   expiresAtPtr,
+		Scopes:      scopes,
 	}
 
 	// 保存到数据库
@@ -107,11 +114,7 @@ func (s *TeamTokenService) GenerateToken(ctx context.Context, teamID string, tok
 	registeredClaims := jwt.RegisteredClaims{
 		IssuedAt:  jwt.NewNumericDate(now),
 		NotBefore: jwt.NewNumericDate(now),
-	}
-
-	// 如果有过期时间，设置到claims中
-	if expiresAtPtr != nil {
-		registeredClaims.ExpiresAt = jwt.NewNumericDate(*expiresAtPtr)
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
 	}
 
 	claims := TeamTokenClaims{
@@ -147,6 +150,7 @@ func (s *TeamTokenService) GenerateToken(ctx context.Context, teamID string, tok
 		Token:     tokenString,
 		CreatedAt: now,
 		ExpiresAt: expiresAtPtr,
+		Scopes:    scopes,
 	}, nil
 }
 
@@ -190,7 +194,9 @@ func (s *TeamTokenService) ListTeamTokens(ctx context.Context, teamID string) ([
 			RevokedAt:  token.RevokedAt,
 			RevokedBy:  token.RevokedBy,
 			LastUsedAt: token.LastUsedAt,
+			LastUsedIP: token.LastUsedIP,
 			ExpiresAt:  token.ExpiresAt,
+			Scopes:     token.Scopes,
 		}
 	}
 
@@ -299,6 +305,8 @@ func (s *TeamTokenService) GetTokenByID(ctx context.Context, tokenID uint) (*mod
 		RevokedAt:  token.RevokedAt,
 		RevokedBy:  token.RevokedBy,
 		LastUsedAt: token.LastUsedAt,
+		LastUsedIP: token.LastUsedIP,
 		ExpiresAt:  token.ExpiresAt,
+		Scopes:     token.Scopes,
 	}, nil
 }
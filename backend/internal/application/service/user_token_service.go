@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"iac-platform/internal/config"
+	"iac-platform/internal/domain/valueobject"
 	"iac-platform/internal/infrastructure"
 	"iac-platform/internal/models"
 
@@ -44,7 +45,16 @@ type UserTokenClaims struct {
 }
 
 // GenerateToken 生成用户Token
-func (s *UserTokenService) GenerateToken(ctx context.Context, userID string, tokenName string, expiresInDays int) (*models.UserTokenCreateResponse, error) {
+// Token 必须设置过期时间（不超过 API Token 策略上限）和作用域
+func (s *UserTokenService) GenerateToken(ctx context.Context, userID string, tokenName string, expiresInDays int, scopes *valueobject.TokenScope) (*models.UserTokenCreateResponse, error) {
+	policy, err := LoadAPITokenPolicy(ctx, s.db)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateTokenRequest(expiresInDays, policy.MaxUserTokenDays, scopes); err != nil {
+		return nil, err
+	}
+
 	// 检查用户是否存在
 	var user struct {
 		UserID   string `gorm:"column:user_id"`
@@ -76,12 +86,8 @@ func (s *UserTokenService) GenerateToken(ctx context.Context, userID string, tok
 
 	// 创建token记录（先不设置token_hash，需要先生成JWT）
 	now := time.Now()
-	var expiresAtPtr *time.Time
-	if expiresInDays > 0 {
-		expiresAt := now.Add(time.Duration(expiresInDays) * 24 * time.Hour)
-		expiresAtPtr = &expiresAt
-	}
-	// expiresInDays = 0 表示永不过期，expiresAtPtr = nil
+	expiresAt := now.Add(time.Duration(expiresInDays) * 24 * time.Hour)
+	expiresAtPtr := &expiresAt
 
 	// 计算token_id的hash
 	tokenIDHash := sha256.Sum256([]byte(tokenID))
@@ -95,17 +101,14 @@ func (s *UserTokenService) GenerateToken(ctx context.Context, userID string, tok
 		IsActive:    true,
 		CreatedAt:   now,
 		ExpiresAt:   expiresAtPtr,
+		Scopes:      scopes,
 	}
 
 	// 生成JWT token
 	registeredClaims := jwt.RegisteredClaims{
 		IssuedAt:  jwt.NewNumericDate(now),
 		NotBefore: jwt.NewNumericDate(now),
-	}
-
-	// 如果有过期时间，设置到claims中
-	if expiresAtPtr != nil {
-		registeredClaims.ExpiresAt = jwt.NewNumericDate(*expiresAtPtr)
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
 	}
 
 	claims := UserTokenClaims{
@@ -139,6 +142,7 @@ func (s *UserTokenService) GenerateToken(ctx context.Context, userID string, tok
 		Token:     tokenString,
 		CreatedAt: now,
 		ExpiresAt: expiresAtPtr,
+		Scopes:    scopes,
 	}, nil
 }
 
@@ -161,7 +165,9 @@ func (s *UserTokenService) ListUserTokens(ctx context.Context, userID string) ([
 			CreatedAt:  token.CreatedAt,
 			RevokedAt:  token.RevokedAt,
 			LastUsedAt: token.LastUsedAt,
+			LastUsedIP: token.LastUsedIP,
 			ExpiresAt:  token.ExpiresAt,
+			Scopes:     token.Scopes,
 		}
 	}
 
@@ -275,6 +281,8 @@ func (s *UserTokenService) GetTokenByID(ctx context.Context, tokenID string) (*m
 		CreatedAt:  token.CreatedAt,
 		RevokedAt:  token.RevokedAt,
 		LastUsedAt: token.LastUsedAt,
+		LastUsedIP: token.LastUsedIP,
 		ExpiresAt:  token.ExpiresAt,
+		Scopes:     token.Scopes,
 	}, nil
 }
@@ -0,0 +1,299 @@
+// Package cli 实现平台命令行工具 iac，所有操作都通过 /api/v1 REST 接口完成
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"iac-platform/internal/apiclient"
+)
+
+// 退出码
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+// 环境变量（CI 中通常用环境变量代替 login）
+const (
+	envAddress = "IAC_ADDRESS"
+	envToken   = "IAC_TOKEN"
+	envConfig  = "IAC_CONFIG"
+)
+
+const usageText = `Usage: iac <command> [subcommand] [flags] [args]
+
+Commands:
+  login       Log in with a user/team token or browser SSO
+  logout      Remove stored credentials
+  workspace   List, show and create workspaces
+  run         Queue plans, stream logs, confirm or cancel applies
+  state       List, pull and push state versions
+  var         List, set and delete workspace variables
+
+Global flags (accepted by every command):
+  --address URL        Platform address (env IAC_ADDRESS)
+  --token TOKEN        API token (env IAC_TOKEN)
+  --output table|json  Output format (default table)
+  --config PATH        Credentials file (env IAC_CONFIG)
+
+Run "iac <command> -h" for details.
+`
+
+// errUsage 参数错误，退出码为 2
+var errUsage = errors.New("usage error")
+
+// usageError 返回带说明的参数错误
+func usageError(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", errUsage, fmt.Sprintf(format, args...))
+}
+
+// App 一次 CLI 调用的运行环境，测试中替换 IO、环境变量和浏览器
+type App struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// Getenv 读取环境变量
+	Getenv func(string) string
+	// OpenBrowser 打开浏览器访问 url（SSO 登录）
+	OpenBrowser func(url string) error
+	// HTTPClient 访问平台使用的 http.Client，nil 时使用默认客户端
+	HTTPClient *http.Client
+	// PollInterval 跟踪任务状态的轮询间隔
+	PollInterval time.Duration
+	// LoginTimeout 等待浏览器完成 SSO 登录的超时时间
+	LoginTimeout time.Duration
+
+	stdin *bufio.Reader
+}
+
+// New 使用进程的标准输入输出创建 App
+func New() *App {
+	return &App{
+		Stdin:        os.Stdin,
+		Stdout:       os.Stdout,
+		Stderr:       os.Stderr,
+		Getenv:       os.Getenv,
+		OpenBrowser:  openBrowser,
+		PollInterval: 2 * time.Second,
+		LoginTimeout: 5 * time.Minute,
+	}
+}
+
+// globalOptions 每个子命令都接受的全局参数
+type globalOptions struct {
+	address string
+	token   string
+	output  string
+	config  string
+}
+
+// command 叶子命令
+type command struct {
+	name    string
+	summary string
+	run     func(a *App, ctx context.Context, opts *globalOptions, args []string) error
+}
+
+// commandGroups 命令树：顶层命令 -> 子命令（login/logout 没有子命令，用空字符串表示）
+var commandGroups = map[string][]command{
+	"login":  {{name: "", summary: "Log in and store credentials", run: (*App).runLogin}},
+	"logout": {{name: "", summary: "Remove stored credentials", run: (*App).runLogout}},
+	"workspace": {
+		{name: "list", summary: "List workspaces", run: (*App).runWorkspaceList},
+		{name: "show", summary: "Show a workspace", run: (*App).runWorkspaceShow},
+		{name: "create", summary: "Create a workspace", run: (*App).runWorkspaceCreate},
+	},
+	"run": {
+		{name: "plan", summary: "Queue a plan (or plan and apply)", run: (*App).runRunPlan},
+		{name: "show", summary: "Show a run", run: (*App).runRunShow},
+		{name: "logs", summary: "Print or stream run logs", run: (*App).runRunLogs},
+		{name: "confirm", summary: "Confirm a pending apply", run: (*App).runRunConfirm},
+		{name: "cancel", summary: "Cancel a run", run: (*App).runRunCancel},
+	},
+	"state": {
+		{name: "versions", summary: "List state versions", run: (*App).runStateVersions},
+		{name: "pull", summary: "Download a state version", run: (*App).runStatePull},
+		{name: "push", summary: "Upload a state file as a new version", run: (*App).runStatePush},
+	},
+	"var": {
+		{name: "list", summary: "List variables", run: (*App).runVarList},
+		{name: "set", summary: "Create or update a variable", run: (*App).runVarSet},
+		{name: "delete", summary: "Delete a variable", run: (*App).runVarDelete},
+	},
+}
+
+// Run 执行一次命令，返回进程退出码
+func (a *App) Run(ctx context.Context, args []string) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		fmt.Fprint(a.Stdout, usageText)
+		return exitOK
+	}
+
+	group, ok := commandGroups[args[0]]
+	if !ok {
+		fmt.Fprintf(a.Stderr, "unknown command %q\n\n%s", args[0], usageText)
+		return exitUsage
+	}
+
+	cmd, rest, ok := findCommand(group, args[1:])
+	if !ok {
+		fmt.Fprintf(a.Stderr, "Usage: iac %s <subcommand>\n\nSubcommands:\n", args[0])
+		for _, c := range group {
+			fmt.Fprintf(a.Stderr, "  %-10s %s\n", c.name, c.summary)
+		}
+		return exitUsage
+	}
+
+	opts := &globalOptions{}
+	err := cmd.run(a, ctx, opts, rest)
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.Is(err, errUsage):
+		fmt.Fprintf(a.Stderr, "Error: %s\n", strings.TrimPrefix(err.Error(), errUsage.Error()+": "))
+		return exitUsage
+	default:
+		fmt.Fprintf(a.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+}
+
+func findCommand(group []command, args []string) (command, []string, bool) {
+	if len(group) == 1 && group[0].name == "" {
+		return group[0], args, true
+	}
+	if len(args) == 0 {
+		return command{}, nil, false
+	}
+	for _, c := range group {
+		if c.name == args[0] {
+			return c, args[1:], true
+		}
+	}
+	return command{}, nil, false
+}
+
+// flagSet 创建子命令的 FlagSet 并注册全局参数
+func (a *App) flagSet(name, usage string, opts *globalOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(a.Stderr)
+	fs.StringVar(&opts.address, "address", "", "platform address (env "+envAddress+")")
+	fs.StringVar(&opts.token, "token", "", "API token (env "+envToken+")")
+	fs.StringVar(&opts.output, "output", "table", "output format: table or json")
+	fs.StringVar(&opts.config, "config", "", "credentials file (env "+envConfig+")")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: iac %s\n\nFlags:\n", usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseArgs 解析参数，允许参数和位置参数交错（如 iac var set ws-1 KEY=V --sensitive）
+func parseArgs(fs *flag.FlagSet, args []string, opts *globalOptions) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, fmt.Errorf("%w: %s", errUsage, err.Error())
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+	if opts.output != "table" && opts.output != "json" {
+		return nil, usageError("--output must be table or json")
+	}
+	return positional, nil
+}
+
+// requireArgs 校验位置参数个数
+func requireArgs(fs *flag.FlagSet, args []string, names ...string) error {
+	if len(args) != len(names) {
+		return usageError("expected arguments: %s (run \"iac %s -h\")", strings.Join(names, " "), fs.Name())
+	}
+	return nil
+}
+
+// client 按 参数 > 环境变量 > 凭据文件 的优先级创建 API 客户端
+func (a *App) client(opts *globalOptions) (*apiclient.Client, error) {
+	creds, err := loadCredentials(a.configPath(opts))
+	if err != nil {
+		return nil, err
+	}
+
+	address := firstNonEmpty(opts.address, a.Getenv(envAddress), creds.Address)
+	token := firstNonEmpty(opts.token, a.Getenv(envToken), creds.Token)
+	if address == "" {
+		return nil, errors.New("no platform address: run \"iac login --address URL\" or set " + envAddress)
+	}
+	if token == "" {
+		return nil, errors.New("not logged in: run \"iac login\" or set " + envToken)
+	}
+	return a.newClient(address, token)
+}
+
+func (a *App) newClient(address, token string) (*apiclient.Client, error) {
+	clientOpts := []apiclient.Option{apiclient.WithUserAgent("iac-cli")}
+	if a.HTTPClient != nil {
+		clientOpts = append(clientOpts, apiclient.WithHTTPClient(a.HTTPClient))
+	}
+	return apiclient.New(address, token, clientOpts...)
+}
+
+// readLine 从标准输入读取一行（提示信息输出到 stderr，不污染 json 输出）
+func (a *App) readLine(prompt string) (string, error) {
+	if a.stdin == nil {
+		a.stdin = bufio.NewReader(a.Stdin)
+	}
+	if prompt != "" {
+		fmt.Fprint(a.Stderr, prompt)
+	}
+	line, err := a.stdin.ReadString('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+		return "", fmt.Errorf("failed to read input: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// openBrowser 用系统默认浏览器打开 url
+func openBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	return cmd.Start()
+}
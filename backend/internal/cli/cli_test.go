@@ -0,0 +1,822 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"iac-platform/internal/apiclient"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// makeJWT 构造测试用的 JWT（签名不校验）
+func makeJWT(claims map[string]interface{}) string {
+	enc := base64.RawURLEncoding
+	header := enc.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	payload, _ := json.Marshal(claims)
+	return header + "." + enc.EncodeToString(payload) + ".sig"
+}
+
+var (
+	userToken = makeJWT(map[string]interface{}{"type": "login_token", "username": "alice", "exp": time.Now().Add(time.Hour).Unix()})
+	teamToken = makeJWT(map[string]interface{}{"type": "team_token", "team_id": "team-ci", "team_name": "ci"})
+)
+
+// fakePlatform 进程内的平台 API 替身，只实现 CLI 用到的接口
+type fakePlatform struct {
+	mu         sync.Mutex
+	workspaces []apiclient.Workspace
+	tasks      map[uint]*apiclient.Task
+	states     map[string][]byte // "ws/version" -> 内容
+	current    map[string]int
+	vars       []apiclient.Variable
+	nextID     uint
+	output     []apiclient.OutputMessage
+	finalState string // 输出流结束后任务进入的状态
+	lastBody   map[string]interface{}
+}
+
+func newFakePlatform(t *testing.T) (*fakePlatform, *httptest.Server) {
+	gin.SetMode(gin.TestMode)
+	fp := &fakePlatform{
+		workspaces: []apiclient.Workspace{
+			{WorkspaceID: "ws-prod", Name: "prod", ExecutionMode: "agent", TerraformVersion: "1.6.0", IsLocked: true},
+			{WorkspaceID: "ws-dev", Name: "dev", ExecutionMode: "local", TerraformVersion: "1.6.0", AutoApply: true},
+		},
+		tasks:   map[uint]*apiclient.Task{},
+		states:  map[string][]byte{"ws-prod/1": []byte(`{"version":4,"serial":1}`), "ws-prod/2": []byte(`{"version":4,"serial":2}`)},
+		current: map[string]int{"ws-prod": 2},
+		vars: []apiclient.Variable{
+			{VariableID: "var-1", WorkspaceID: "ws-prod", Key: "region", Value: "us-east-1", VariableType: "terraform", ValueFormat: "string", Version: 1},
+			{VariableID: "var-2", WorkspaceID: "ws-prod", Key: "AWS_SECRET", VariableType: "environment", ValueFormat: "string", Sensitive: true, Version: 3},
+		},
+		nextID: 100,
+		output: []apiclient.OutputMessage{
+			{Type: "stage_marker", Stage: "plan", Status: "begin"},
+			{Type: "output", Line: "Plan: 1 to add, 0 to change, 0 to destroy.", LineNum: 1},
+			{Type: "completed"},
+		},
+		finalState: apiclient.TaskStatusApplyPending,
+	}
+
+	r := gin.New()
+	api := r.Group("/api/v1")
+	api.GET("/auth/sso/:provider/login", fp.ssoLogin)
+	api.POST("/auth/mfa/verify", fp.mfaVerify)
+	r.GET("/idp/authorize", fp.idpAuthorize)
+
+	authed := api.Group("", func(c *gin.Context) {
+		h := c.GetHeader("Authorization")
+		if h != "Bearer "+userToken && h != "Bearer "+teamToken {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"code": 401, "message": "Invalid token"})
+			return
+		}
+		c.Set("team", h == "Bearer "+teamToken)
+	})
+	authed.GET("/auth/me", func(c *gin.Context) {
+		if c.GetBool("team") {
+			c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "message": "Unauthorized"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"code": 200, "data": gin.H{"id": "user-1", "username": "alice"}})
+	})
+	authed.GET("/workspaces", fp.listWorkspaces)
+	authed.POST("/workspaces", fp.createWorkspace)
+	authed.GET("/workspaces/:id", fp.getWorkspace)
+	authed.POST("/workspaces/:id/tasks/plan", fp.createPlan)
+	authed.GET("/workspaces/:id/tasks/:task_id", fp.taskAction(""))
+	authed.POST("/workspaces/:id/tasks/:task_id/confirm-apply", fp.taskAction("confirm"))
+	authed.POST("/workspaces/:id/tasks/:task_id/cancel", fp.taskAction("cancel"))
+	authed.GET("/tasks/:task_id/output/stream", fp.stream)
+	authed.GET("/tasks/:task_id/logs", func(c *gin.Context) {
+		c.String(http.StatusOK, "=== PLAN OUTPUT ===\nNo changes.\n")
+	})
+	authed.GET("/workspaces/:id/state/versions", fp.listStateVersions)
+	authed.GET("/workspaces/:id/state/versions/:version/download", fp.downloadState)
+	authed.POST("/workspaces/:id/state/upload", fp.uploadState)
+	authed.GET("/workspaces/:id/variables", fp.listVariables)
+	authed.POST("/workspaces/:id/variables", fp.createVariable)
+	authed.PUT("/workspaces/:id/variables/:var_id", fp.updateVariable)
+	authed.DELETE("/workspaces/:id/variables/:var_id", fp.deleteVariable)
+
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return fp, srv
+}
+
+func (fp *fakePlatform) bind(c *gin.Context, out interface{}) bool {
+	if err := c.ShouldBindJSON(out); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	raw, _ := json.Marshal(out)
+	fp.lastBody = map[string]interface{}{}
+	_ = json.Unmarshal(raw, &fp.lastBody)
+	return true
+}
+
+// ssoLogin 授权地址指向假的 IdP，由它直接回跳到 CLI 的回环地址
+func (fp *fakePlatform) ssoLogin(c *gin.Context) {
+	authURL := fmt.Sprintf("http://%s/idp/authorize?provider=%s&redirect=%s",
+		c.Request.Host, c.Param("provider"), url.QueryEscape(c.Query("redirect_url")))
+	c.JSON(http.StatusOK, gin.H{"code": 200, "data": gin.H{"auth_url": authURL}})
+}
+
+// idpAuthorize 与平台一致：在回跳地址上追加结果参数，保留 CLI 带来的 state
+func (fp *fakePlatform) idpAuthorize(c *gin.Context) {
+	redirect, err := url.Parse(c.Query("redirect"))
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+	query := redirect.Query()
+	switch c.Query("provider") {
+	case "okta":
+		query.Set("token", userToken)
+	case "okta-mfa":
+		query.Set("mfa_required", "true")
+		query.Set("mfa_token", "mfa-1")
+	case "okta-forged":
+		// 伪造的回跳：state 与 CLI 生成的不一致
+		query.Set("state", "forged")
+		query.Set("token", userToken)
+	default:
+		query.Set("error", "sso_failed")
+		query.Set("error_description", "unknown provider")
+	}
+	redirect.RawQuery = query.Encode()
+	c.Redirect(http.StatusFound, redirect.String())
+}
+
+func (fp *fakePlatform) mfaVerify(c *gin.Context) {
+	var req struct {
+		MFAToken string `json:"mfa_token"`
+		Code     string `json:"code"`
+	}
+	if !fp.bind(c, &req) {
+		return
+	}
+	if req.MFAToken != "mfa-1" || req.Code != "123456" {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "message": "验证码错误"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "data": gin.H{"token": userToken}})
+}
+
+func (fp *fakePlatform) listWorkspaces(c *gin.Context) {
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+	items := []apiclient.Workspace{}
+	for _, ws := range fp.workspaces {
+		if strings.Contains(ws.Name, c.Query("search")) {
+			items = append(items, ws)
+		}
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	c.JSON(http.StatusOK, gin.H{"code": 200, "data": gin.H{"items": items, "total": len(items), "page": page, "size": 20}})
+}
+
+func (fp *fakePlatform) createWorkspace(c *gin.Context) {
+	var req struct {
+		Name          string `json:"name" binding:"required"`
+		ExecutionMode string `json:"execution_mode" binding:"required"`
+		StateBackend  string `json:"state_backend" binding:"required"`
+		AgentPoolID   *uint  `json:"agent_pool_id"`
+		AutoApply     bool   `json:"auto_apply"`
+	}
+	if !fp.bind(c, &req) {
+		return
+	}
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+	ws := apiclient.Workspace{WorkspaceID: "ws-" + req.Name, Name: req.Name, ExecutionMode: req.ExecutionMode,
+		StateBackend: req.StateBackend, AgentPoolID: req.AgentPoolID, AutoApply: req.AutoApply}
+	fp.workspaces = append(fp.workspaces, ws)
+	c.JSON(http.StatusCreated, gin.H{"code": 201, "data": ws})
+}
+
+func (fp *fakePlatform) getWorkspace(c *gin.Context) {
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+	for _, ws := range fp.workspaces {
+		if ws.WorkspaceID == c.Param("id") {
+			c.JSON(http.StatusOK, gin.H{"code": 200, "data": ws})
+			return
+		}
+	}
+	c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "工作空间不存在"})
+}
+
+func (fp *fakePlatform) createPlan(c *gin.Context) {
+	var req struct {
+		Description string `json:"description"`
+		RunType     string `json:"run_type"`
+	}
+	if !fp.bind(c, &req) {
+		return
+	}
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+	if c.Param("id") == "ws-prod" {
+		c.JSON(http.StatusLocked, gin.H{"error": "workspace is locked"})
+		return
+	}
+	fp.nextID++
+	taskType := "plan"
+	if req.RunType == "plan_and_apply" {
+		taskType = "plan_and_apply"
+	}
+	task := &apiclient.Task{ID: fp.nextID, WorkspaceID: c.Param("id"), TaskType: taskType, Status: "pending", Description: req.Description}
+	fp.tasks[task.ID] = task
+	c.JSON(http.StatusCreated, gin.H{"message": "Plan task created", "task": task})
+}
+
+func (fp *fakePlatform) taskAction(action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, _ := strconv.Atoi(c.Param("task_id"))
+		if action == "confirm" {
+			var req struct {
+				ApplyDescription string `json:"apply_description"`
+			}
+			if !fp.bind(c, &req) {
+				return
+			}
+		}
+		fp.mu.Lock()
+		defer fp.mu.Unlock()
+		task, ok := fp.tasks[uint(id)]
+		if !ok || task.WorkspaceID != c.Param("id") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+			return
+		}
+		switch action {
+		case "confirm":
+			if task.Status != apiclient.TaskStatusApplyPending {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Task is not in apply_pending status"})
+				return
+			}
+			task.Status = "apply_queued"
+		case "cancel":
+			task.Status = apiclient.TaskStatusCancelled
+		}
+		c.JSON(http.StatusOK, gin.H{"task": task})
+	}
+}
+
+var upgrader = websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+
+// stream 推送预置输出后把任务置为最终状态并关闭连接
+func (fp *fakePlatform) stream(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("task_id"))
+	ws, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return
+	}
+	defer ws.Close()
+	_ = ws.WriteJSON(gin.H{"type": "connected", "task_id": id})
+	for _, msg := range fp.output {
+		_ = ws.WriteJSON(msg)
+	}
+	fp.mu.Lock()
+	if task, ok := fp.tasks[uint(id)]; ok {
+		task.Status = fp.finalState
+		task.ChangesAdd = 1
+	}
+	fp.mu.Unlock()
+}
+
+func (fp *fakePlatform) listStateVersions(c *gin.Context) {
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+	cur := fp.current[c.Param("id")]
+	versions := []apiclient.StateVersion{}
+	for v := cur; v >= 1; v-- {
+		versions = append(versions, apiclient.StateVersion{WorkspaceID: c.Param("id"), Version: v, Serial: v, CreatedByName: "alice"})
+	}
+	c.JSON(http.StatusOK, gin.H{"versions": versions, "total": len(versions), "current_version": cur, "limit": 50, "offset": 0})
+}
+
+func (fp *fakePlatform) downloadState(c *gin.Context) {
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+	data, ok := fp.states[c.Param("id")+"/"+c.Param("version")]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "State version not found"})
+		return
+	}
+	c.Data(http.StatusOK, "application/json", data)
+}
+
+func (fp *fakePlatform) uploadState(c *gin.Context) {
+	var req struct {
+		State       map[string]interface{} `json:"state" binding:"required"`
+		Force       bool                   `json:"force"`
+		Description string                 `json:"description"`
+	}
+	if !fp.bind(c, &req) {
+		return
+	}
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+	ws := c.Param("id")
+	if serial, _ := req.State["serial"].(float64); !req.Force && int(serial) <= fp.current[ws] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "serial must be greater than current serial", "suggestion": "Use force=true to bypass validation"})
+		return
+	}
+	fp.current[ws]++
+	raw, _ := json.Marshal(req.State)
+	fp.states[fmt.Sprintf("%s/%d", ws, fp.current[ws])] = raw
+	warnings := []string{}
+	if req.Force {
+		warnings = append(warnings, "State uploaded with force=true, validation was bypassed")
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "State uploaded successfully", "version": fp.current[ws], "warnings": warnings})
+}
+
+func (fp *fakePlatform) listVariables(c *gin.Context) {
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+	out := []apiclient.Variable{}
+	for _, v := range fp.vars {
+		if v.WorkspaceID != c.Param("id") {
+			continue
+		}
+		if t := c.Query("type"); t != "" && t != "all" && t != v.VariableType {
+			continue
+		}
+		if v.Sensitive {
+			v.Value = ""
+		}
+		out = append(out, v)
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "data": out})
+}
+
+func (fp *fakePlatform) createVariable(c *gin.Context) {
+	var req apiclient.VariableInput
+	if !fp.bind(c, &req) {
+		return
+	}
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+	fp.nextID++
+	v := apiclient.Variable{VariableID: fmt.Sprintf("var-%d", fp.nextID), WorkspaceID: c.Param("id"), Key: req.Key, Value: req.Value,
+		VariableType: req.VariableType, ValueFormat: req.ValueFormat, Sensitive: req.Sensitive, Description: req.Description, Version: 1}
+	fp.vars = append(fp.vars, v)
+	c.JSON(http.StatusCreated, gin.H{"code": 201, "data": v})
+}
+
+func (fp *fakePlatform) updateVariable(c *gin.Context) {
+	var req apiclient.VariableUpdate
+	if !fp.bind(c, &req) {
+		return
+	}
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+	for i := range fp.vars {
+		v := &fp.vars[i]
+		if v.VariableID != c.Param("var_id") {
+			continue
+		}
+		if v.Version != req.Version {
+			c.JSON(http.StatusConflict, gin.H{"code": 409, "message": "版本冲突"})
+			return
+		}
+		if req.Value != nil {
+			v.Value = *req.Value
+		}
+		if req.Sensitive != nil {
+			v.Sensitive = *req.Sensitive
+		}
+		v.Version++
+		c.JSON(http.StatusOK, gin.H{"code": 200, "data": v})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "变量不存在"})
+}
+
+func (fp *fakePlatform) deleteVariable(c *gin.Context) {
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+	for i, v := range fp.vars {
+		if v.VariableID == c.Param("var_id") {
+			fp.vars = append(fp.vars[:i], fp.vars[i+1:]...)
+			c.JSON(http.StatusOK, gin.H{"code": 200, "message": "变量删除成功"})
+			return
+		}
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "变量不存在"})
+}
+
+// cliResult 一次 CLI 调用的结果
+type cliResult struct {
+	code   int
+	stdout string
+	stderr string
+}
+
+// runCLI 在临时配置目录下执行 CLI，env 覆盖环境变量
+func runCLI(t *testing.T, configPath string, env map[string]string, stdin string, args ...string) cliResult {
+	var stdout, stderr bytes.Buffer
+	app := &App{
+		Stdin:  strings.NewReader(stdin),
+		Stdout: &stdout,
+		Stderr: &stderr,
+		Getenv: func(key string) string {
+			if key == envConfig {
+				return configPath
+			}
+			return env[key]
+		},
+		// 测试中用 HTTP 客户端代替浏览器访问授权地址（自动跟随重定向回到 CLI）
+		OpenBrowser: func(u string) error {
+			resp, err := http.Get(u)
+			if err != nil {
+				return err
+			}
+			return resp.Body.Close()
+		},
+		PollInterval: 10 * time.Millisecond,
+		LoginTimeout: 5 * time.Second,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	code := app.Run(ctx, args)
+	return cliResult{code: code, stdout: stdout.String(), stderr: stderr.String()}
+}
+
+func TestCLI_Commands(t *testing.T) {
+	type testCase struct {
+		name     string
+		args     []string
+		stdin    string
+		loggedIn bool // 通过 IAC_ADDRESS / IAC_TOKEN 登录
+		setup    func(fp *fakePlatform)
+		wantCode int
+		wantOut  []string
+		wantErr  string
+		check    func(t *testing.T, fp *fakePlatform, res cliResult)
+	}
+
+	cases := []testCase{
+		{
+			name:     "not logged in",
+			args:     []string{"workspace", "list"},
+			wantCode: exitError,
+			wantErr:  "no platform address",
+		},
+		{
+			name:     "unknown command",
+			args:     []string{"deploy"},
+			wantCode: exitUsage,
+			wantErr:  `unknown command "deploy"`,
+		},
+		{
+			name:     "invalid output format",
+			args:     []string{"workspace", "list", "--output", "yaml"},
+			loggedIn: true,
+			wantCode: exitUsage,
+			wantErr:  "--output must be table or json",
+		},
+		{
+			name:     "list workspaces as table",
+			args:     []string{"workspace", "list"},
+			loggedIn: true,
+			wantOut:  []string{"ID", "ws-prod", "prod", "agent", "ws-dev", "Showing 2 of 2 workspaces"},
+		},
+		{
+			name:     "list workspaces as json with search",
+			args:     []string{"workspace", "list", "--search", "dev", "--output", "json"},
+			loggedIn: true,
+			check: func(t *testing.T, fp *fakePlatform, res cliResult) {
+				var list apiclient.WorkspaceList
+				require.NoError(t, json.Unmarshal([]byte(res.stdout), &list))
+				require.Len(t, list.Items, 1)
+				assert.Equal(t, "ws-dev", list.Items[0].WorkspaceID)
+			},
+		},
+		{
+			name:     "show missing workspace",
+			args:     []string{"workspace", "show", "ws-nope"},
+			loggedIn: true,
+			wantCode: exitError,
+			wantErr:  "HTTP 404",
+		},
+		{
+			name:     "create workspace",
+			args:     []string{"workspace", "create", "--name", "staging", "--execution-mode", "agent", "--agent-pool-id", "3", "--auto-apply"},
+			loggedIn: true,
+			wantOut:  []string{"Created workspace ws-staging"},
+			check: func(t *testing.T, fp *fakePlatform, res cliResult) {
+				assert.Equal(t, "local", fp.lastBody["state_backend"])
+				assert.Equal(t, float64(3), fp.lastBody["agent_pool_id"])
+				assert.Equal(t, true, fp.lastBody["auto_apply"])
+			},
+		},
+		{
+			name:     "create workspace requires name",
+			args:     []string{"workspace", "create"},
+			loggedIn: true,
+			wantCode: exitUsage,
+			wantErr:  "--name is required",
+		},
+		{
+			name:     "queue plan without following",
+			args:     []string{"run", "plan", "ws-dev", "--message", "nightly"},
+			loggedIn: true,
+			wantOut:  []string{"Queued run 101 (plan) in workspace ws-dev", "iac run logs ws-dev 101"},
+			check: func(t *testing.T, fp *fakePlatform, res cliResult) {
+				assert.Equal(t, "nightly", fp.lastBody["description"])
+				assert.Equal(t, "plan", fp.lastBody["run_type"])
+			},
+		},
+		{
+			name:     "queue plan on locked workspace",
+			args:     []string{"run", "plan", "ws-prod"},
+			loggedIn: true,
+			wantCode: exitError,
+			wantErr:  "HTTP 423: workspace is locked",
+		},
+		{
+			name:     "plan and apply streams logs until apply is pending",
+			args:     []string{"run", "plan", "ws-dev", "--apply", "--follow"},
+			loggedIn: true,
+			wantOut:  []string{"==> plan", "Plan: 1 to add", "apply_pending", "+1 ~0 -0", "iac run confirm ws-dev 101"},
+			check: func(t *testing.T, fp *fakePlatform, res cliResult) {
+				assert.Equal(t, "plan_and_apply", fp.lastBody["run_type"])
+			},
+		},
+		{
+			name:     "followed run that fails exits non-zero",
+			args:     []string{"run", "plan", "ws-dev", "--follow", "--output", "json"},
+			loggedIn: true,
+			setup:    func(fp *fakePlatform) { fp.finalState = apiclient.TaskStatusFailed },
+			wantCode: exitError,
+			wantErr:  "run 101 is failed",
+			check: func(t *testing.T, fp *fakePlatform, res cliResult) {
+				lines := strings.Split(strings.TrimSpace(res.stdout), "\n")
+				var first apiclient.OutputMessage
+				require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+				assert.Equal(t, "stage_marker", first.Type)
+				assert.Contains(t, lines[len(lines)-1], `"type":"result"`)
+			},
+		},
+		{
+			name:     "logs of a finished run",
+			args:     []string{"run", "logs", "ws-dev", "7"},
+			loggedIn: true,
+			setup: func(fp *fakePlatform) {
+				fp.tasks[7] = &apiclient.Task{ID: 7, WorkspaceID: "ws-dev", Status: apiclient.TaskStatusSuccess}
+			},
+			wantOut: []string{"=== PLAN OUTPUT ===", "No changes."},
+		},
+		{
+			name:     "confirm pending apply",
+			args:     []string{"run", "confirm", "ws-dev", "7", "--message", "ship it"},
+			loggedIn: true,
+			setup: func(fp *fakePlatform) {
+				fp.tasks[7] = &apiclient.Task{ID: 7, WorkspaceID: "ws-dev", Status: apiclient.TaskStatusApplyPending}
+			},
+			wantOut: []string{"Apply of run 7 confirmed (status: apply_queued)"},
+			check: func(t *testing.T, fp *fakePlatform, res cliResult) {
+				assert.Equal(t, "ship it", fp.lastBody["apply_description"])
+			},
+		},
+		{
+			name:     "confirm run that is not pending",
+			args:     []string{"run", "confirm", "ws-dev", "7"},
+			loggedIn: true,
+			setup: func(fp *fakePlatform) {
+				fp.tasks[7] = &apiclient.Task{ID: 7, WorkspaceID: "ws-dev", Status: "running"}
+			},
+			wantCode: exitError,
+			wantErr:  "not in apply_pending status",
+		},
+		{
+			name:     "cancel run",
+			args:     []string{"run", "cancel", "ws-dev", "7", "--output", "json"},
+			loggedIn: true,
+			setup: func(fp *fakePlatform) {
+				fp.tasks[7] = &apiclient.Task{ID: 7, WorkspaceID: "ws-dev", Status: "running"}
+			},
+			wantOut: []string{`"status": "cancelled"`},
+		},
+		{
+			name:     "invalid run id",
+			args:     []string{"run", "show", "ws-dev", "abc"},
+			loggedIn: true,
+			wantCode: exitUsage,
+			wantErr:  `invalid run id "abc"`,
+		},
+		{
+			name:     "list state versions",
+			args:     []string{"state", "versions", "ws-prod"},
+			loggedIn: true,
+			wantOut:  []string{"VERSION", "2 (current)", "alice"},
+		},
+		{
+			name:     "pull current state to stdout",
+			args:     []string{"state", "pull", "ws-prod"},
+			loggedIn: true,
+			wantOut:  []string{`{"version":4,"serial":2}`},
+		},
+		{
+			name:     "pull specific state version",
+			args:     []string{"state", "pull", "ws-prod", "--version", "1"},
+			loggedIn: true,
+			wantOut:  []string{`{"version":4,"serial":1}`},
+		},
+		{
+			name:     "pull state of workspace without state",
+			args:     []string{"state", "pull", "ws-dev"},
+			loggedIn: true,
+			wantCode: exitError,
+			wantErr:  "has no state",
+		},
+		{
+			name:     "push state from stdin",
+			args:     []string{"state", "push", "ws-prod", "-", "--message", "import"},
+			stdin:    `{"version":4,"serial":3}`,
+			loggedIn: true,
+			wantOut:  []string{"Uploaded state version 3 to workspace ws-prod"},
+			check: func(t *testing.T, fp *fakePlatform, res cliResult) {
+				assert.Equal(t, "import", fp.lastBody["description"])
+				assert.Equal(t, 3, fp.current["ws-prod"])
+			},
+		},
+		{
+			name:     "push stale state is rejected unless forced",
+			args:     []string{"state", "push", "ws-prod", "-"},
+			stdin:    `{"version":4,"serial":1}`,
+			loggedIn: true,
+			wantCode: exitError,
+			wantErr:  "serial must be greater than current serial",
+		},
+		{
+			name:     "force push stale state",
+			args:     []string{"state", "push", "ws-prod", "-", "--force"},
+			stdin:    `{"version":4,"serial":1}`,
+			loggedIn: true,
+			wantOut:  []string{"Uploaded state version 3", "Warning: State uploaded with force=true"},
+		},
+		{
+			name:     "push invalid json",
+			args:     []string{"state", "push", "ws-prod", "-"},
+			stdin:    `not json`,
+			loggedIn: true,
+			wantCode: exitError,
+			wantErr:  "state is not valid JSON",
+		},
+		{
+			name:     "list variables hides sensitive values",
+			args:     []string{"var", "list", "ws-prod"},
+			loggedIn: true,
+			wantOut:  []string{"region", "us-east-1", "AWS_SECRET", "(sensitive)"},
+		},
+		{
+			name:     "create variable",
+			args:     []string{"var", "set", "ws-prod", "instance_type=t3.large", "--description", "size"},
+			loggedIn: true,
+			wantOut:  []string{"Created terraform variable instance_type (version 1)"},
+			check: func(t *testing.T, fp *fakePlatform, res cliResult) {
+				assert.Equal(t, "terraform", fp.lastBody["variable_type"])
+				assert.Equal(t, "string", fp.lastBody["value_format"])
+				assert.Equal(t, "size", fp.lastBody["description"])
+			},
+		},
+		{
+			name:     "update existing variable keeps sensitivity",
+			args:     []string{"var", "set", "ws-prod", "AWS_SECRET", "--env"},
+			stdin:    "s3cr3t\n",
+			loggedIn: true,
+			wantOut:  []string{"Updated environment variable AWS_SECRET (version 4)"},
+			check: func(t *testing.T, fp *fakePlatform, res cliResult) {
+				assert.Equal(t, float64(3), fp.lastBody["version"])
+				assert.Equal(t, "s3cr3t", fp.lastBody["value"])
+				assert.NotContains(t, fp.lastBody, "sensitive")
+				assert.True(t, fp.vars[1].Sensitive)
+			},
+		},
+		{
+			name:     "hcl environment variable is rejected",
+			args:     []string{"var", "set", "ws-prod", "A=1", "--env", "--hcl"},
+			loggedIn: true,
+			wantCode: exitUsage,
+			wantErr:  "environment variables cannot use --hcl",
+		},
+		{
+			name:     "delete variable",
+			args:     []string{"var", "delete", "ws-prod", "region"},
+			loggedIn: true,
+			wantOut:  []string{"Deleted terraform variable region"},
+			check: func(t *testing.T, fp *fakePlatform, res cliResult) {
+				assert.Len(t, fp.vars, 1)
+			},
+		},
+		{
+			name:     "delete missing variable",
+			args:     []string{"var", "delete", "ws-prod", "region", "--env"},
+			loggedIn: true,
+			wantCode: exitError,
+			wantErr:  `environment variable "region" not found`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fp, srv := newFakePlatform(t)
+			if tc.setup != nil {
+				tc.setup(fp)
+			}
+			env := map[string]string{}
+			if tc.loggedIn {
+				env[envAddress] = srv.URL
+				env[envToken] = userToken
+			}
+
+			res := runCLI(t, filepath.Join(t.TempDir(), "credentials.json"), env, tc.stdin, tc.args...)
+			assert.Equal(t, tc.wantCode, res.code, "stdout: %s\nstderr: %s", res.stdout, res.stderr)
+			for _, want := range tc.wantOut {
+				assert.Contains(t, res.stdout, want)
+			}
+			if tc.wantErr != "" {
+				assert.Contains(t, res.stderr, tc.wantErr)
+			}
+			if tc.check != nil {
+				tc.check(t, fp, res)
+			}
+		})
+	}
+}
+
+func TestCLI_Login(t *testing.T) {
+	cases := []struct {
+		name          string
+		args          []string
+		stdin         string
+		wantCode      int
+		wantPrincipal string
+		wantErr       string
+	}{
+		{name: "user token from flag", args: []string{"login", "--token", userToken}, wantPrincipal: "alice"},
+		{name: "team token from stdin", args: []string{"login"}, stdin: teamToken + "\n", wantPrincipal: "team ci"},
+		{name: "rejected token", args: []string{"login", "--token", makeJWT(map[string]interface{}{"type": "user_token"})}, wantCode: exitError, wantErr: "token rejected"},
+		{name: "browser sso", args: []string{"login", "--sso", "okta"}, wantPrincipal: "alice"},
+		{name: "browser sso with mfa", args: []string{"login", "--sso", "okta-mfa"}, stdin: "123456\n", wantPrincipal: "alice"},
+		{name: "browser sso with wrong mfa code", args: []string{"login", "--sso", "okta-mfa"}, stdin: "000000\n", wantCode: exitError, wantErr: "MFA verification failed"},
+		{name: "browser sso error", args: []string{"login", "--sso", "github"}, wantCode: exitError, wantErr: "unknown provider"},
+		{name: "browser sso with forged state", args: []string{"login", "--sso", "okta-forged"}, wantCode: exitError, wantErr: "timed out"},
+		{name: "token and sso are exclusive", args: []string{"login", "--sso", "okta", "--token", userToken}, wantCode: exitUsage, wantErr: "mutually exclusive"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, srv := newFakePlatform(t)
+			configPath := filepath.Join(t.TempDir(), "iac", "credentials.json")
+
+			args := append(append([]string{}, tc.args...), "--address", srv.URL)
+			res := runCLI(t, configPath, nil, tc.stdin, args...)
+			require.Equal(t, tc.wantCode, res.code, "stdout: %s\nstderr: %s", res.stdout, res.stderr)
+			if tc.wantErr != "" {
+				assert.Contains(t, res.stderr, tc.wantErr)
+				_, err := os.Stat(configPath)
+				assert.True(t, os.IsNotExist(err), "credentials must not be saved on failure")
+				return
+			}
+
+			creds, err := loadCredentials(configPath)
+			require.NoError(t, err)
+			assert.Equal(t, srv.URL, creds.Address)
+			assert.Equal(t, tc.wantPrincipal, creds.Principal)
+			info, err := os.Stat(configPath)
+			require.NoError(t, err)
+			assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+			// 登录后不再需要 --address / --token
+			res = runCLI(t, configPath, nil, "", "workspace", "list", "--output", "json")
+			assert.Equal(t, exitOK, res.code, res.stderr)
+
+			res = runCLI(t, configPath, nil, "", "logout")
+			assert.Equal(t, exitOK, res.code)
+			res = runCLI(t, configPath, nil, "", "workspace", "list")
+			assert.Equal(t, exitError, res.code)
+			assert.Contains(t, res.stderr, "no platform address")
+		})
+	}
+}
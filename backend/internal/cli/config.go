@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// credentials 保存在本机的登录信息
+type credentials struct {
+	Address   string     `json:"address"`
+	Token     string     `json:"token"`
+	Principal string     `json:"principal,omitempty"` // 用户名或团队名，仅用于展示
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// configPath 凭据文件路径：--config > IAC_CONFIG > <用户配置目录>/iac-platform/credentials.json
+func (a *App) configPath(opts *globalOptions) string {
+	if p := firstNonEmpty(opts.config, a.Getenv(envConfig)); p != "" {
+		return p
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = "."
+	}
+	return filepath.Join(dir, "iac-platform", "credentials.json")
+}
+
+// loadCredentials 读取凭据文件，文件不存在时返回空凭据
+func loadCredentials(path string) (*credentials, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &credentials{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credentials: %w", err)
+	}
+	var creds credentials
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return nil, fmt.Errorf("invalid credentials file %s: %w", path, err)
+	}
+	return &creds, nil
+}
+
+// saveCredentials 写入凭据文件（仅当前用户可读）
+func saveCredentials(path string, creds *credentials) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	data, err := json.MarshalIndent(creds, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write credentials: %w", err)
+	}
+	return nil
+}
+
+// tokenClaims 解析 JWT 载荷（不校验签名，只用于判断 token 类型和过期时间，校验由服务端完成）
+type tokenClaims struct {
+	Type     string `json:"type"`
+	Username string `json:"username"`
+	TeamID   string `json:"team_id"`
+	TeamName string `json:"team_name"`
+	Exp      int64  `json:"exp"`
+}
+
+func parseTokenClaims(token string) (*tokenClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("token is not a JWT")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid token payload: %w", err)
+	}
+	var claims tokenClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf("invalid token payload: %w", err)
+	}
+	return &claims, nil
+}
+
+func (c *tokenClaims) expiresAt() *time.Time {
+	if c.Exp == 0 {
+		return nil
+	}
+	t := time.Unix(c.Exp, 0)
+	return &t
+}
@@ -0,0 +1,217 @@
+package cli
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// runLogin iac login [--token TOKEN | --sso PROVIDER]
+// 不带参数时从标准输入读取 token；--sso 在浏览器中完成 SSO 登录
+func (a *App) runLogin(ctx context.Context, opts *globalOptions, args []string) error {
+	fs := a.flagSet("login", "login [--address URL] [--token TOKEN | --sso PROVIDER]", opts)
+	sso := fs.String("sso", "", "log in through browser SSO with the given provider key")
+	pos, err := parseArgs(fs, args, opts)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs(fs, pos); err != nil {
+		return err
+	}
+	if *sso != "" && opts.token != "" {
+		return usageError("--token and --sso are mutually exclusive")
+	}
+
+	path := a.configPath(opts)
+	creds, err := loadCredentials(path)
+	if err != nil {
+		return err
+	}
+	address := firstNonEmpty(opts.address, a.Getenv(envAddress), creds.Address)
+	if address == "" {
+		return usageError("--address is required for the first login")
+	}
+
+	token := opts.token
+	if *sso != "" {
+		token, err = a.ssoLogin(ctx, address, *sso)
+		if err != nil {
+			return err
+		}
+	} else if token == "" {
+		token, err = a.readLine("API token: ")
+		if err != nil {
+			return err
+		}
+		token = strings.TrimSpace(token)
+		if token == "" {
+			return usageError("token is required")
+		}
+	}
+
+	principal, expiresAt, err := a.verifyToken(ctx, address, token)
+	if err != nil {
+		return err
+	}
+
+	client, err := a.newClient(address, token)
+	if err != nil {
+		return err
+	}
+	saved := &credentials{Address: client.Address(), Token: token, Principal: principal, ExpiresAt: expiresAt}
+	if err := saveCredentials(path, saved); err != nil {
+		return err
+	}
+
+	result := map[string]interface{}{"address": saved.Address, "principal": principal, "expires_at": expiresAt}
+	return a.render(opts, result, func(w io.Writer) {
+		fmt.Fprintf(w, "Logged in to %s as %s\n", saved.Address, principal)
+		if expiresAt != nil {
+			fmt.Fprintf(w, "Token expires at %s\n", formatTime(*expiresAt))
+		}
+	})
+}
+
+// verifyToken 校验 token 可用，返回展示用的身份
+// 团队 Token 没有用户身份（/auth/me 不可用），以 token 中的团队信息为准，首次调用接口时再由服务端校验
+func (a *App) verifyToken(ctx context.Context, address, token string) (string, *time.Time, error) {
+	claims, err := parseTokenClaims(token)
+	if err != nil {
+		return "", nil, err
+	}
+	if claims.Type == "team_token" {
+		return "team " + firstNonEmpty(claims.TeamName, claims.TeamID), claims.expiresAt(), nil
+	}
+
+	client, err := a.newClient(address, token)
+	if err != nil {
+		return "", nil, err
+	}
+	me, err := client.Me(ctx)
+	if err != nil {
+		return "", nil, fmt.Errorf("token rejected: %w", err)
+	}
+	return me.Username, claims.expiresAt(), nil
+}
+
+// ssoLogin 浏览器 SSO 登录：在 127.0.0.1 临时监听端口，平台登录完成后把 token（或 MFA 令牌）回跳到该端口
+// 回跳地址带有随机 state，平台原样带回；state 不匹配的请求（如其他网页伪造的回跳）直接拒绝
+func (a *App) ssoLogin(ctx context.Context, address, provider string) (string, error) {
+	state, err := newSSOState()
+	if err != nil {
+		return "", err
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", fmt.Errorf("failed to listen for SSO callback: %w", err)
+	}
+	redirectURL := fmt.Sprintf("http://%s/callback?%s", ln.Addr().String(), url.Values{"state": {state}}.Encode())
+
+	results := make(chan url.Values, 1)
+	srv := &http.Server{
+		ReadHeaderTimeout: 10 * time.Second,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/callback" {
+				http.NotFound(w, r)
+				return
+			}
+			if subtle.ConstantTimeCompare([]byte(r.URL.Query().Get("state")), []byte(state)) != 1 {
+				http.Error(w, "invalid login state", http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			fmt.Fprintln(w, "Login finished. You can close this window and return to the terminal.")
+			select {
+			case results <- r.URL.Query():
+			default:
+			}
+		}),
+	}
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	client, err := a.newClient(address, "")
+	if err != nil {
+		return "", err
+	}
+	authURL, err := client.SSOLoginURL(ctx, provider, redirectURL)
+	if err != nil {
+		return "", err
+	}
+
+	fmt.Fprintf(a.Stderr, "Opening the browser to log in. If it does not open, visit:\n\n  %s\n\n", authURL)
+	if err := a.OpenBrowser(authURL); err != nil {
+		fmt.Fprintf(a.Stderr, "Failed to open the browser: %v\n", err)
+	}
+
+	timer := time.NewTimer(a.LoginTimeout)
+	defer timer.Stop()
+	var query url.Values
+	select {
+	case query = <-results:
+	case <-timer.C:
+		return "", errors.New("timed out waiting for the SSO login to finish")
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+
+	switch {
+	case query.Get("error") != "":
+		return "", fmt.Errorf("SSO login failed: %s %s", query.Get("error"), query.Get("error_description"))
+	case query.Get("token") != "":
+		return query.Get("token"), nil
+	case query.Get("mfa_required") == "true":
+		code, err := a.readLine("MFA code: ")
+		if err != nil {
+			return "", err
+		}
+		login, err := client.VerifyMFA(ctx, query.Get("mfa_token"), strings.TrimSpace(code))
+		if err != nil {
+			return "", fmt.Errorf("MFA verification failed: %w", err)
+		}
+		return login.Token, nil
+	case query.Get("mfa_setup_required") == "true":
+		return "", errors.New("MFA must be set up first: log in to the web UI to set it up, then run \"iac login --sso\" again")
+	default:
+		return "", errors.New("SSO login returned no token")
+	}
+}
+
+// newSSOState 生成浏览器登录回跳校验用的随机 state
+func newSSOState() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate login state: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// runLogout iac logout：删除本地凭据
+func (a *App) runLogout(ctx context.Context, opts *globalOptions, args []string) error {
+	fs := a.flagSet("logout", "logout", opts)
+	pos, err := parseArgs(fs, args, opts)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs(fs, pos); err != nil {
+		return err
+	}
+
+	path := a.configPath(opts)
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove credentials: %w", err)
+	}
+	return a.render(opts, map[string]bool{"logged_out": true}, func(w io.Writer) {
+		fmt.Fprintln(w, "Credentials removed")
+	})
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// render 按 --output 输出：json 输出完整对象，table 调用 table 渲染
+func (a *App) render(opts *globalOptions, v interface{}, table func(w io.Writer)) error {
+	if opts.output == "json" {
+		enc := json.NewEncoder(a.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+	tw := newFieldWriter(a.Stdout)
+	table(tw)
+	return tw.Flush()
+}
+
+// newFieldWriter 创建按列对齐的输出
+func newFieldWriter(w io.Writer) *tabwriter.Writer {
+	return tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+}
+
+// writeRow 输出一行以 tab 分隔的表格
+func writeRow(w io.Writer, cols ...interface{}) {
+	parts := make([]string, len(cols))
+	for i, c := range cols {
+		parts[i] = fmt.Sprint(c)
+	}
+	fmt.Fprintln(w, strings.Join(parts, "\t"))
+}
+
+// writeField 输出 "名称: 值" 形式的详情行
+func writeField(w io.Writer, name string, value interface{}) {
+	fmt.Fprintf(w, "%s:\t%v\n", name, value)
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
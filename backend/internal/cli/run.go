@@ -0,0 +1,315 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"iac-platform/internal/apiclient"
+)
+
+// errRunFailed 跟踪的任务以失败或取消结束，退出码非 0 便于 CI 判断
+var errRunFailed = errors.New("run did not succeed")
+
+func parseTaskID(s string) (uint, error) {
+	id, err := strconv.ParseUint(s, 10, 32)
+	if err != nil || id == 0 {
+		return 0, usageError("invalid run id %q", s)
+	}
+	return uint(id), nil
+}
+
+// runRunPlan iac run plan WORKSPACE [--apply] [--message TEXT] [--follow]
+func (a *App) runRunPlan(ctx context.Context, opts *globalOptions, args []string) error {
+	fs := a.flagSet("run plan", "run plan WORKSPACE [--apply] [--message TEXT] [--follow]", opts)
+	apply := fs.Bool("apply", false, "plan and apply (the apply still waits for confirmation unless the workspace auto-applies)")
+	message := fs.String("message", "Queued from CLI", "run description")
+	follow := fs.Bool("follow", false, "stream logs until the run finishes; exit non-zero if it fails")
+	pos, err := parseArgs(fs, args, opts)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs(fs, pos, "WORKSPACE"); err != nil {
+		return err
+	}
+
+	client, err := a.client(opts)
+	if err != nil {
+		return err
+	}
+	task, err := client.CreatePlan(ctx, pos[0], *message, *apply)
+	if err != nil {
+		return err
+	}
+
+	if !*follow {
+		return a.render(opts, task, func(w io.Writer) {
+			fmt.Fprintf(w, "Queued run %d (%s) in workspace %s\n", task.ID, task.TaskType, pos[0])
+			fmt.Fprintf(w, "Follow it with: iac run logs %s %d\n", pos[0], task.ID)
+		})
+	}
+
+	fmt.Fprintf(a.Stderr, "Queued run %d in workspace %s\n", task.ID, pos[0])
+	return a.followAndReport(ctx, client, opts, pos[0], task.ID)
+}
+
+// runRunShow iac run show WORKSPACE RUN
+func (a *App) runRunShow(ctx context.Context, opts *globalOptions, args []string) error {
+	fs := a.flagSet("run show", "run show WORKSPACE RUN", opts)
+	pos, err := parseArgs(fs, args, opts)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs(fs, pos, "WORKSPACE", "RUN"); err != nil {
+		return err
+	}
+	taskID, err := parseTaskID(pos[1])
+	if err != nil {
+		return err
+	}
+
+	client, err := a.client(opts)
+	if err != nil {
+		return err
+	}
+	task, err := client.GetTask(ctx, pos[0], taskID)
+	if err != nil {
+		return err
+	}
+	return a.render(opts, task, func(w io.Writer) { writeTask(w, task) })
+}
+
+// runRunLogs iac run logs WORKSPACE RUN：已结束的任务输出完整日志，运行中的任务实时跟踪
+func (a *App) runRunLogs(ctx context.Context, opts *globalOptions, args []string) error {
+	fs := a.flagSet("run logs", "run logs WORKSPACE RUN", opts)
+	pos, err := parseArgs(fs, args, opts)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs(fs, pos, "WORKSPACE", "RUN"); err != nil {
+		return err
+	}
+	taskID, err := parseTaskID(pos[1])
+	if err != nil {
+		return err
+	}
+
+	client, err := a.client(opts)
+	if err != nil {
+		return err
+	}
+	task, err := client.GetTask(ctx, pos[0], taskID)
+	if err != nil {
+		return err
+	}
+	if !task.IsFinal() {
+		return a.followAndReport(ctx, client, opts, pos[0], taskID)
+	}
+
+	logs, err := client.GetTaskLogs(ctx, taskID)
+	if err != nil {
+		return err
+	}
+	if opts.output == "json" {
+		return a.render(opts, map[string]interface{}{"task": task, "logs": logs}, nil)
+	}
+	_, err = io.WriteString(a.Stdout, logs)
+	return err
+}
+
+// runRunConfirm iac run confirm WORKSPACE RUN [--message TEXT]
+func (a *App) runRunConfirm(ctx context.Context, opts *globalOptions, args []string) error {
+	fs := a.flagSet("run confirm", "run confirm WORKSPACE RUN [--message TEXT] [--follow]", opts)
+	message := fs.String("message", "Confirmed from CLI", "apply description")
+	follow := fs.Bool("follow", false, "stream apply logs until the run finishes")
+	pos, err := parseArgs(fs, args, opts)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs(fs, pos, "WORKSPACE", "RUN"); err != nil {
+		return err
+	}
+	taskID, err := parseTaskID(pos[1])
+	if err != nil {
+		return err
+	}
+
+	client, err := a.client(opts)
+	if err != nil {
+		return err
+	}
+	task, err := client.ConfirmApply(ctx, pos[0], taskID, *message)
+	if err != nil {
+		return err
+	}
+	if *follow {
+		fmt.Fprintf(a.Stderr, "Apply of run %d confirmed\n", taskID)
+		return a.followAndReport(ctx, client, opts, pos[0], taskID)
+	}
+	return a.render(opts, task, func(w io.Writer) {
+		fmt.Fprintf(w, "Apply of run %d confirmed (status: %s)\n", task.ID, task.Status)
+	})
+}
+
+// runRunCancel iac run cancel WORKSPACE RUN
+func (a *App) runRunCancel(ctx context.Context, opts *globalOptions, args []string) error {
+	fs := a.flagSet("run cancel", "run cancel WORKSPACE RUN", opts)
+	pos, err := parseArgs(fs, args, opts)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs(fs, pos, "WORKSPACE", "RUN"); err != nil {
+		return err
+	}
+	taskID, err := parseTaskID(pos[1])
+	if err != nil {
+		return err
+	}
+
+	client, err := a.client(opts)
+	if err != nil {
+		return err
+	}
+	task, err := client.CancelTask(ctx, pos[0], taskID)
+	if err != nil {
+		return err
+	}
+	return a.render(opts, task, func(w io.Writer) {
+		fmt.Fprintf(w, "Run %d cancelled\n", task.ID)
+	})
+}
+
+// followAndReport 跟踪任务输出直到结束，输出结果摘要；任务失败时返回 errRunFailed
+func (a *App) followAndReport(ctx context.Context, client *apiclient.Client, opts *globalOptions, workspaceID string, taskID uint) error {
+	task, err := a.followTask(ctx, client, opts, workspaceID, taskID)
+	if err != nil {
+		return err
+	}
+
+	if opts.output == "json" {
+		if err := json.NewEncoder(a.Stdout).Encode(map[string]interface{}{"type": "result", "task": task}); err != nil {
+			return err
+		}
+	} else {
+		fmt.Fprintln(a.Stdout)
+		tw := newFieldWriter(a.Stdout)
+		writeTask(tw, task)
+		tw.Flush()
+		if task.Status == apiclient.TaskStatusApplyPending {
+			fmt.Fprintf(a.Stdout, "\nPlan finished, waiting for confirmation: iac run confirm %s %d\n", workspaceID, taskID)
+		}
+	}
+
+	if task.IsFailed() {
+		return fmt.Errorf("%w: run %d is %s", errRunFailed, taskID, task.Status)
+	}
+	return nil
+}
+
+// followTask 订阅实时输出并轮询任务状态，直到任务结束
+// 输出流在任务结束后可能不会关闭（例如订阅时任务刚好结束），所以由轮询负责结束订阅
+func (a *App) followTask(ctx context.Context, client *apiclient.Client, opts *globalOptions, workspaceID string, taskID uint) (*apiclient.Task, error) {
+	seen := make(map[string]bool)
+	emit := func(msg apiclient.OutputMessage) error {
+		// 重新订阅时服务端会回放历史，按行号去重
+		if msg.LineNum > 0 {
+			key := fmt.Sprintf("%s/%d/%s", msg.Type, msg.LineNum, msg.Stage)
+			if seen[key] {
+				return nil
+			}
+			seen[key] = true
+		}
+		return a.printOutput(opts, msg)
+	}
+
+	for {
+		streamCtx, cancel := context.WithCancel(ctx)
+		finished := make(chan *apiclient.Task, 1)
+		go func() {
+			defer cancel()
+			ticker := time.NewTicker(a.PollInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-streamCtx.Done():
+					return
+				case <-ticker.C:
+				}
+				task, err := client.GetTask(streamCtx, workspaceID, taskID)
+				if err == nil && task.IsFinal() {
+					// 留一个轮询周期让剩余输出到达
+					select {
+					case <-streamCtx.Done():
+					case <-time.After(a.PollInterval):
+					}
+					finished <- task
+					return
+				}
+			}
+		}()
+
+		err := client.StreamTaskOutput(streamCtx, taskID, emit)
+		cancel()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err != nil && !errors.Is(err, context.Canceled) {
+			return nil, err
+		}
+
+		select {
+		case task := <-finished:
+			return task, nil
+		default:
+		}
+		task, err := client.GetTask(ctx, workspaceID, taskID)
+		if err != nil {
+			return nil, err
+		}
+		if task.IsFinal() {
+			return task, nil
+		}
+		// 阶段切换（如 plan 结束开始 apply）时输出流会关闭，重新订阅
+	}
+}
+
+// printOutput 输出一条实时日志：table 模式输出原始行，json 模式每条消息一行 JSON
+func (a *App) printOutput(opts *globalOptions, msg apiclient.OutputMessage) error {
+	if opts.output == "json" {
+		return json.NewEncoder(a.Stdout).Encode(msg)
+	}
+	switch msg.Type {
+	case "stage_marker":
+		if msg.Status == "begin" {
+			fmt.Fprintf(a.Stdout, "==> %s\n", msg.Stage)
+		}
+	case "completed":
+	case "error":
+		fmt.Fprintf(a.Stdout, "Error: %s\n", msg.Line)
+	default:
+		fmt.Fprintln(a.Stdout, msg.Line)
+	}
+	return nil
+}
+
+func writeTask(w io.Writer, task *apiclient.Task) {
+	writeField(w, "Run", task.ID)
+	writeField(w, "Workspace", task.WorkspaceID)
+	writeField(w, "Type", task.TaskType)
+	writeField(w, "Status", task.Status)
+	if task.Stage != "" {
+		writeField(w, "Stage", task.Stage)
+	}
+	writeField(w, "Changes", fmt.Sprintf("+%d ~%d -%d", task.ChangesAdd, task.ChangesChange, task.ChangesDestroy))
+	if task.Description != "" {
+		writeField(w, "Description", task.Description)
+	}
+	if task.ErrorMessage != "" {
+		writeField(w, "Error", task.ErrorMessage)
+	}
+	writeField(w, "Created", formatTime(task.CreatedAt))
+}
@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"iac-platform/internal/apiclient"
+)
+
+// runStateVersions iac state versions WORKSPACE [--limit N] [--offset N]
+func (a *App) runStateVersions(ctx context.Context, opts *globalOptions, args []string) error {
+	fs := a.flagSet("state versions", "state versions WORKSPACE [--limit N] [--offset N]", opts)
+	limit := fs.Int("limit", 20, "number of versions (max 100)")
+	offset := fs.Int("offset", 0, "number of versions to skip")
+	pos, err := parseArgs(fs, args, opts)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs(fs, pos, "WORKSPACE"); err != nil {
+		return err
+	}
+
+	client, err := a.client(opts)
+	if err != nil {
+		return err
+	}
+	list, err := client.ListStateVersions(ctx, pos[0], *limit, *offset)
+	if err != nil {
+		return err
+	}
+	return a.render(opts, list, func(w io.Writer) {
+		writeRow(w, "VERSION", "SERIAL", "SIZE", "CREATED BY", "CREATED", "DESCRIPTION")
+		for _, v := range list.Versions {
+			current := ""
+			if v.Version == list.CurrentVersion {
+				current = " (current)"
+			}
+			writeRow(w, fmt.Sprintf("%d%s", v.Version, current), v.Serial, v.SizeBytes,
+				firstNonEmpty(v.CreatedByName, "-"), formatTime(v.CreatedAt), v.Description)
+		}
+	})
+}
+
+// runStatePull iac state pull WORKSPACE [--version N] [--file PATH]
+// 默认下载当前版本并输出到标准输出（可直接重定向为 terraform.tfstate）
+func (a *App) runStatePull(ctx context.Context, opts *globalOptions, args []string) error {
+	fs := a.flagSet("state pull", "state pull WORKSPACE [--version N] [--file PATH]", opts)
+	version := fs.Int("version", 0, "state version (default: current)")
+	file := fs.String("file", "", "write the state to this file instead of stdout")
+	pos, err := parseArgs(fs, args, opts)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs(fs, pos, "WORKSPACE"); err != nil {
+		return err
+	}
+
+	client, err := a.client(opts)
+	if err != nil {
+		return err
+	}
+	state, err := client.DownloadState(ctx, pos[0], *version)
+	if err != nil {
+		return err
+	}
+
+	if *file == "" {
+		_, err = a.Stdout.Write(state)
+		return err
+	}
+	// State 可能包含敏感数据，仅当前用户可读
+	if err := os.WriteFile(*file, state, 0o600); err != nil {
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+	result := map[string]interface{}{"workspace_id": pos[0], "file": *file, "size_bytes": len(state)}
+	return a.render(opts, result, func(w io.Writer) {
+		fmt.Fprintf(w, "Wrote %d bytes to %s\n", len(state), *file)
+	})
+}
+
+// runStatePush iac state push WORKSPACE FILE [--force] [--message TEXT]
+// FILE 为 - 时从标准输入读取
+func (a *App) runStatePush(ctx context.Context, opts *globalOptions, args []string) error {
+	fs := a.flagSet("state push", "state push WORKSPACE FILE [--force] [--message TEXT]", opts)
+	force := fs.Bool("force", false, "skip lineage/serial checks (the workspace is locked afterwards)")
+	message := fs.String("message", "Pushed from CLI", "version description")
+	pos, err := parseArgs(fs, args, opts)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs(fs, pos, "WORKSPACE", "FILE"); err != nil {
+		return err
+	}
+
+	var state []byte
+	if pos[1] == "-" {
+		state, err = io.ReadAll(a.Stdin)
+	} else {
+		state, err = os.ReadFile(pos[1])
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read state: %w", err)
+	}
+
+	client, err := a.client(opts)
+	if err != nil {
+		return err
+	}
+	result, err := client.UploadState(ctx, pos[0], state, *force, *message)
+	if err != nil {
+		return err
+	}
+	return a.render(opts, result, func(w io.Writer) { writeUploadResult(w, pos[0], result) })
+}
+
+func writeUploadResult(w io.Writer, workspaceID string, result *apiclient.UploadStateResult) {
+	fmt.Fprintf(w, "Uploaded state version %d to workspace %s\n", result.Version, workspaceID)
+	for _, warning := range result.Warnings {
+		fmt.Fprintf(w, "Warning: %s\n", warning)
+	}
+}
@@ -0,0 +1,201 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"iac-platform/internal/apiclient"
+)
+
+// variableTypeFlag --env 表示环境变量，否则为 Terraform 变量
+func variableTypeFlag(env bool) string {
+	if env {
+		return apiclient.VariableTypeEnvironment
+	}
+	return apiclient.VariableTypeTerraform
+}
+
+// runVarList iac var list WORKSPACE [--env | --terraform]
+func (a *App) runVarList(ctx context.Context, opts *globalOptions, args []string) error {
+	fs := a.flagSet("var list", "var list WORKSPACE [--env | --terraform]", opts)
+	env := fs.Bool("env", false, "only environment variables")
+	terraform := fs.Bool("terraform", false, "only Terraform variables")
+	pos, err := parseArgs(fs, args, opts)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs(fs, pos, "WORKSPACE"); err != nil {
+		return err
+	}
+	if *env && *terraform {
+		return usageError("--env and --terraform are mutually exclusive")
+	}
+	variableType := ""
+	if *env || *terraform {
+		variableType = variableTypeFlag(*env)
+	}
+
+	client, err := a.client(opts)
+	if err != nil {
+		return err
+	}
+	vars, err := client.ListVariables(ctx, pos[0], variableType)
+	if err != nil {
+		return err
+	}
+	return a.render(opts, vars, func(w io.Writer) {
+		writeRow(w, "KEY", "TYPE", "FORMAT", "SENSITIVE", "VERSION", "VALUE")
+		for _, v := range vars {
+			value := v.Value
+			if v.Sensitive && v.ValueFormat != apiclient.ValueFormatSecretRef {
+				value = "(sensitive)"
+			}
+			writeRow(w, v.Key, v.VariableType, v.ValueFormat, yesNo(v.Sensitive), v.Version, value)
+		}
+	})
+}
+
+// runVarSet iac var set WORKSPACE KEY=VALUE [--env] [--hcl|--secret-ref] [--sensitive] [--description TEXT]
+// 只给 KEY 时从标准输入读取值，避免敏感值出现在命令行历史里；已存在同名变量时更新
+func (a *App) runVarSet(ctx context.Context, opts *globalOptions, args []string) error {
+	fs := a.flagSet("var set", "var set WORKSPACE KEY=VALUE|KEY [--env] [--hcl|--secret-ref] [--sensitive] [--description TEXT]", opts)
+	env := fs.Bool("env", false, "environment variable instead of Terraform variable")
+	hcl := fs.Bool("hcl", false, "parse the value as HCL")
+	secretRef := fs.Bool("secret-ref", false, "value is a vault://, env:// or file:// reference resolved by the agent at run time")
+	sensitive := fs.Bool("sensitive", false, "mark the variable as sensitive")
+	description := fs.String("description", "", "description")
+	pos, err := parseArgs(fs, args, opts)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs(fs, pos, "WORKSPACE", "KEY=VALUE"); err != nil {
+		return err
+	}
+	if *env && *hcl {
+		return usageError("environment variables cannot use --hcl")
+	}
+	if *hcl && *secretRef {
+		return usageError("--hcl and --secret-ref are mutually exclusive")
+	}
+
+	key, value, hasValue := strings.Cut(pos[1], "=")
+	if key == "" {
+		return usageError("variable key is required")
+	}
+	if !hasValue {
+		if value, err = a.readLine(fmt.Sprintf("Value for %s: ", key)); err != nil {
+			return err
+		}
+	}
+	variableType := variableTypeFlag(*env)
+	valueFormat := apiclient.ValueFormatString
+	if *hcl {
+		valueFormat = apiclient.ValueFormatHCL
+	}
+	if *secretRef {
+		valueFormat = apiclient.ValueFormatSecretRef
+	}
+
+	client, err := a.client(opts)
+	if err != nil {
+		return err
+	}
+	existing, err := findVariable(ctx, client, pos[0], key, variableType)
+	if err != nil {
+		return err
+	}
+
+	var v *apiclient.Variable
+	action := "Created"
+	if existing == nil {
+		v, err = client.CreateVariable(ctx, pos[0], apiclient.VariableInput{
+			Key:          key,
+			Value:        value,
+			VariableType: variableType,
+			ValueFormat:  valueFormat,
+			Sensitive:    *sensitive,
+			Description:  *description,
+		})
+	} else {
+		action = "Updated"
+		update := apiclient.VariableUpdate{
+			Version:     existing.Version,
+			Value:       &value,
+			ValueFormat: &valueFormat,
+		}
+		// 未显式指定时保留原有的敏感标记和描述，避免误把敏感变量改为明文
+		if flagWasSet(fs, "sensitive") {
+			update.Sensitive = sensitive
+		}
+		if flagWasSet(fs, "description") {
+			update.Description = description
+		}
+		v, err = client.UpdateVariable(ctx, pos[0], existing.VariableID, update)
+	}
+	if err != nil {
+		return err
+	}
+	return a.render(opts, v, func(w io.Writer) {
+		fmt.Fprintf(w, "%s %s variable %s (version %d)\n", action, v.VariableType, v.Key, v.Version)
+	})
+}
+
+// runVarDelete iac var delete WORKSPACE KEY [--env]
+func (a *App) runVarDelete(ctx context.Context, opts *globalOptions, args []string) error {
+	fs := a.flagSet("var delete", "var delete WORKSPACE KEY [--env]", opts)
+	env := fs.Bool("env", false, "environment variable instead of Terraform variable")
+	pos, err := parseArgs(fs, args, opts)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs(fs, pos, "WORKSPACE", "KEY"); err != nil {
+		return err
+	}
+
+	client, err := a.client(opts)
+	if err != nil {
+		return err
+	}
+	variableType := variableTypeFlag(*env)
+	existing, err := findVariable(ctx, client, pos[0], pos[1], variableType)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return fmt.Errorf("%s variable %q not found in workspace %s", variableType, pos[1], pos[0])
+	}
+	if err := client.DeleteVariable(ctx, pos[0], existing.VariableID); err != nil {
+		return err
+	}
+	return a.render(opts, existing, func(w io.Writer) {
+		fmt.Fprintf(w, "Deleted %s variable %s\n", variableType, existing.Key)
+	})
+}
+
+// findVariable 按 key 和类型查找变量，不存在时返回 nil
+func findVariable(ctx context.Context, client *apiclient.Client, workspaceID, key, variableType string) (*apiclient.Variable, error) {
+	vars, err := client.ListVariables(ctx, workspaceID, variableType)
+	if err != nil {
+		return nil, err
+	}
+	for i := range vars {
+		if vars[i].Key == key && vars[i].VariableType == variableType {
+			return &vars[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// flagWasSet 判断命令行是否显式指定了某个参数
+func flagWasSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"iac-platform/internal/apiclient"
+)
+
+// runWorkspaceList iac workspace list [--search S] [--project-id N] [--page N] [--size N]
+func (a *App) runWorkspaceList(ctx context.Context, opts *globalOptions, args []string) error {
+	fs := a.flagSet("workspace list", "workspace list [--search TEXT] [--project-id ID] [--page N] [--size N]", opts)
+	var listOpts apiclient.ListWorkspacesOptions
+	fs.StringVar(&listOpts.Search, "search", "", "filter by name")
+	fs.UintVar(&listOpts.ProjectID, "project-id", 0, "only workspaces in this project")
+	fs.IntVar(&listOpts.Page, "page", 1, "page number")
+	fs.IntVar(&listOpts.Size, "size", 20, "page size")
+	pos, err := parseArgs(fs, args, opts)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs(fs, pos); err != nil {
+		return err
+	}
+
+	client, err := a.client(opts)
+	if err != nil {
+		return err
+	}
+	list, err := client.ListWorkspaces(ctx, listOpts)
+	if err != nil {
+		return err
+	}
+
+	return a.render(opts, list, func(w io.Writer) {
+		writeRow(w, "ID", "NAME", "MODE", "TERRAFORM", "AUTO APPLY", "LOCKED", "UPDATED")
+		for _, ws := range list.Items {
+			writeRow(w, ws.WorkspaceID, ws.Name, ws.ExecutionMode, ws.TerraformVersion,
+				yesNo(ws.AutoApply), yesNo(ws.IsLocked), formatTime(ws.UpdatedAt))
+		}
+		fmt.Fprintf(w, "\nShowing %d of %d workspaces (page %d)\n", len(list.Items), list.Total, list.Page)
+	})
+}
+
+// runWorkspaceShow iac workspace show WORKSPACE
+func (a *App) runWorkspaceShow(ctx context.Context, opts *globalOptions, args []string) error {
+	fs := a.flagSet("workspace show", "workspace show WORKSPACE", opts)
+	pos, err := parseArgs(fs, args, opts)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs(fs, pos, "WORKSPACE"); err != nil {
+		return err
+	}
+
+	client, err := a.client(opts)
+	if err != nil {
+		return err
+	}
+	ws, err := client.GetWorkspace(ctx, pos[0])
+	if err != nil {
+		return err
+	}
+	return a.render(opts, ws, func(w io.Writer) { writeWorkspace(w, ws) })
+}
+
+// runWorkspaceCreate iac workspace create --name NAME [...]
+func (a *App) runWorkspaceCreate(ctx context.Context, opts *globalOptions, args []string) error {
+	fs := a.flagSet("workspace create", "workspace create --name NAME [flags]", opts)
+	var req apiclient.CreateWorkspaceRequest
+	var agentPoolID uint
+	fs.StringVar(&req.Name, "name", "", "workspace name (required)")
+	fs.StringVar(&req.Description, "description", "", "description")
+	fs.StringVar(&req.ExecutionMode, "execution-mode", "local", "execution mode: local, agent or k8s")
+	fs.UintVar(&agentPoolID, "agent-pool-id", 0, "agent pool for agent execution mode")
+	fs.StringVar(&req.TerraformVersion, "terraform-version", "", "Terraform version (default: platform default)")
+	fs.StringVar(&req.Workdir, "workdir", "", "working directory")
+	fs.BoolVar(&req.AutoApply, "auto-apply", false, "apply automatically after a successful plan")
+	fs.BoolVar(&req.PlanOnly, "plan-only", false, "only allow plans")
+	pos, err := parseArgs(fs, args, opts)
+	if err != nil {
+		return err
+	}
+	if err := requireArgs(fs, pos); err != nil {
+		return err
+	}
+	if req.Name == "" {
+		return usageError("--name is required")
+	}
+	if agentPoolID > 0 {
+		req.AgentPoolID = &agentPoolID
+	}
+
+	client, err := a.client(opts)
+	if err != nil {
+		return err
+	}
+	ws, err := client.CreateWorkspace(ctx, req)
+	if err != nil {
+		return err
+	}
+	return a.render(opts, ws, func(w io.Writer) {
+		fmt.Fprintf(w, "Created workspace %s\n\n", ws.WorkspaceID)
+		writeWorkspace(w, ws)
+	})
+}
+
+func writeWorkspace(w io.Writer, ws *apiclient.Workspace) {
+	writeField(w, "ID", ws.WorkspaceID)
+	writeField(w, "Name", ws.Name)
+	writeField(w, "Description", ws.Description)
+	writeField(w, "Execution mode", ws.ExecutionMode)
+	writeField(w, "Terraform version", ws.TerraformVersion)
+	writeField(w, "Auto apply", yesNo(ws.AutoApply))
+	writeField(w, "Plan only", yesNo(ws.PlanOnly))
+	writeField(w, "Locked", yesNo(ws.IsLocked))
+	writeField(w, "Created", formatTime(ws.CreatedAt))
+	writeField(w, "Updated", formatTime(ws.UpdatedAt))
+}
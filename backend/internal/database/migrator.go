@@ -0,0 +1,415 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"iac-platform/internal/pglock"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// migrationLockKey 迁移执行的 session 级 advisory lock key，保证只有一个副本执行迁移
+const migrationLockKey int64 = 0x4d49475241544531 // "MIGRATE1"
+
+// migrationLockPollInterval 等待其他副本完成迁移时的轮询间隔
+const migrationLockPollInterval = 2 * time.Second
+
+var (
+	// ErrUnknownSchemaVersion 数据库中存在当前二进制不认识的迁移（数据库比二进制新）
+	ErrUnknownSchemaVersion = errors.New("database schema version is unknown to this binary")
+	// ErrMigrationChecksumMismatch 已执行的迁移文件内容被修改
+	ErrMigrationChecksumMismatch = errors.New("applied migration checksum mismatch")
+	// ErrPendingMigrations 存在未执行的迁移
+	ErrPendingMigrations = errors.New("database schema has pending migrations")
+	// ErrIrreversibleMigration 迁移没有 down 脚本
+	ErrIrreversibleMigration = errors.New("migration cannot be reverted")
+)
+
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration 一个版本化迁移
+type Migration struct {
+	Version  int64
+	Name     string
+	UpSQL    string
+	DownSQL  string
+	Checksum string // up 脚本的 SHA256
+}
+
+// Reversible 是否可回滚
+func (m Migration) Reversible() bool {
+	return m.DownSQL != ""
+}
+
+// SchemaMigration schema_migrations 表记录
+type SchemaMigration struct {
+	Version     int64     `gorm:"primaryKey;autoIncrement:false"`
+	Name        string    `gorm:"size:255;not null"`
+	Checksum    string    `gorm:"size:64;not null"`
+	AppliedAt   time.Time `gorm:"not null"`
+	ExecutionMs int64
+}
+
+// TableName 指定表名
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// MigrationStatus 单个迁移的状态
+type MigrationStatus struct {
+	Version          int64      `json:"version"`
+	Name             string     `json:"name"`
+	Applied          bool       `json:"applied"`
+	AppliedAt        *time.Time `json:"applied_at,omitempty"`
+	Reversible       bool       `json:"reversible"`
+	ChecksumMismatch bool       `json:"checksum_mismatch,omitempty"`
+	Unknown          bool       `json:"unknown,omitempty"` // 数据库中有记录但二进制中不存在
+}
+
+// LoadMigrations 从文件系统加载迁移，按版本号排序
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration version in %s", entry.Name())
+		}
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d is used by both %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.UpSQL = string(content)
+			sum := sha256.Sum256(content)
+			m.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			m.DownSQL = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.UpSQL == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Migrator 执行内嵌的版本化迁移
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// NewMigrator 创建迁移执行器
+func NewMigrator(db *gorm.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := LoadMigrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Migrations 返回已加载的迁移
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// LatestVersion 二进制中最新的迁移版本
+func (m *Migrator) LatestVersion() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Status 返回所有迁移的执行状态（包括数据库中存在但二进制不认识的版本）
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	if err := m.ensureTable(ctx, m.db); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied(ctx, m.db)
+	if err != nil {
+		return nil, err
+	}
+	return m.status(applied), nil
+}
+
+// Verify 检查数据库 schema 与二进制一致：无未知版本、无校验和不一致、无待执行迁移
+func (m *Migrator) Verify(ctx context.Context) error {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	if err := checkApplied(statuses); err != nil {
+		return err
+	}
+	var pending []int64
+	for _, s := range statuses {
+		if !s.Applied {
+			pending = append(pending, s.Version)
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: %v", ErrPendingMigrations, pending)
+	}
+	return nil
+}
+
+// Up 执行所有待执行的迁移，返回本次执行的迁移
+// 多副本同时启动时只有持有 advisory lock 的副本执行，其余副本等待锁释放后发现已无待执行迁移
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(db *gorm.DB) error {
+		if err := m.ensureTable(ctx, db); err != nil {
+			return err
+		}
+		applied, err := m.applied(ctx, db)
+		if err != nil {
+			return err
+		}
+		if err := checkApplied(m.status(applied)); err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			start := time.Now()
+			err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+				if err := tx.Exec(migration.UpSQL).Error; err != nil {
+					return err
+				}
+				return tx.Create(&SchemaMigration{
+					Version:     migration.Version,
+					Name:        migration.Name,
+					Checksum:    migration.Checksum,
+					AppliedAt:   time.Now(),
+					ExecutionMs: time.Since(start).Milliseconds(),
+				}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migration %d_%s failed: %w", migration.Version, migration.Name, err)
+			}
+			log.Printf("[Migrate] Applied %d_%s (%s)", migration.Version, migration.Name, time.Since(start).Round(time.Millisecond))
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down 回滚最近执行的 steps 个迁移，返回本次回滚的迁移
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	if steps <= 0 {
+		return nil, fmt.Errorf("steps must be positive")
+	}
+
+	var done []Migration
+	err := m.withLock(ctx, func(db *gorm.DB) error {
+		if err := m.ensureTable(ctx, db); err != nil {
+			return err
+		}
+		applied, err := m.applied(ctx, db)
+		if err != nil {
+			return err
+		}
+		if err := checkApplied(m.status(applied)); err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+			if !migration.Reversible() {
+				return fmt.Errorf("%w: %d_%s", ErrIrreversibleMigration, migration.Version, migration.Name)
+			}
+			start := time.Now()
+			err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+				if err := tx.Exec(migration.DownSQL).Error; err != nil {
+					return err
+				}
+				return tx.Delete(&SchemaMigration{}, migration.Version).Error
+			})
+			if err != nil {
+				return fmt.Errorf("reverting migration %d_%s failed: %w", migration.Version, migration.Name, err)
+			}
+			log.Printf("[Migrate] Reverted %d_%s (%s)", migration.Version, migration.Name, time.Since(start).Round(time.Millisecond))
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// EnsureSchema 服务启动时检查数据库 schema
+// autoMigrate 为 true 时执行待执行的迁移，否则仅校验；数据库版本未知或迁移被修改时返回错误
+func EnsureSchema(ctx context.Context, db *gorm.DB, fsys fs.FS, autoMigrate bool) error {
+	migrator, err := NewMigrator(db, fsys)
+	if err != nil {
+		return err
+	}
+	if !autoMigrate {
+		return migrator.Verify(ctx)
+	}
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		return err
+	}
+	log.Printf("[Migrate] Schema at version %d (%d migration(s) applied)", migrator.LatestVersion(), len(applied))
+	return nil
+}
+
+func (m *Migrator) ensureTable(ctx context.Context, db *gorm.DB) error {
+	return db.WithContext(ctx).Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version bigint PRIMARY KEY,
+		name character varying(255) NOT NULL,
+		checksum character varying(64) NOT NULL,
+		applied_at timestamp NOT NULL,
+		execution_ms bigint
+	)`).Error
+}
+
+func (m *Migrator) applied(ctx context.Context, db *gorm.DB) (map[int64]SchemaMigration, error) {
+	var rows []SchemaMigration
+	if err := db.WithContext(ctx).Order("version").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	applied := make(map[int64]SchemaMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+func (m *Migrator) status(applied map[int64]SchemaMigration) []MigrationStatus {
+	known := make(map[int64]bool, len(m.migrations))
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		known[migration.Version] = true
+		s := MigrationStatus{
+			Version:    migration.Version,
+			Name:       migration.Name,
+			Reversible: migration.Reversible(),
+		}
+		if row, ok := applied[migration.Version]; ok {
+			appliedAt := row.AppliedAt
+			s.Applied = true
+			s.AppliedAt = &appliedAt
+			s.ChecksumMismatch = row.Checksum != migration.Checksum
+		}
+		statuses = append(statuses, s)
+	}
+	for version, row := range applied {
+		if known[version] {
+			continue
+		}
+		appliedAt := row.AppliedAt
+		statuses = append(statuses, MigrationStatus{
+			Version:   version,
+			Name:      row.Name,
+			Applied:   true,
+			AppliedAt: &appliedAt,
+			Unknown:   true,
+		})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses
+}
+
+// checkApplied 检查已执行的迁移是否都被二进制认识且未被修改
+func checkApplied(statuses []MigrationStatus) error {
+	for _, s := range statuses {
+		if s.Unknown {
+			return fmt.Errorf("%w: %d_%s (binary knows up to the latest embedded migration only)", ErrUnknownSchemaVersion, s.Version, s.Name)
+		}
+		if s.ChecksumMismatch {
+			return fmt.Errorf("%w: %d_%s", ErrMigrationChecksumMismatch, s.Version, s.Name)
+		}
+	}
+	return nil
+}
+
+// withLock 在持有迁移 advisory lock 的独占连接上执行 fn
+// advisory lock 是 session 级的，必须固定在同一个连接上加锁、执行和释放
+func (m *Migrator) withLock(ctx context.Context, fn func(db *gorm.DB) error) error {
+	if m.db.Dialector.Name() != "postgres" {
+		return fn(m.db)
+	}
+
+	sqlDB, err := m.db.DB()
+	if err != nil {
+		return err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire migration connection: %w", err)
+	}
+	defer conn.Close()
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: conn}), &gorm.Config{Logger: m.db.Logger})
+	if err != nil {
+		return err
+	}
+
+	locker := pglock.New(db)
+	for {
+		acquired, err := locker.TryLock(migrationLockKey)
+		if err != nil {
+			return err
+		}
+		if acquired {
+			break
+		}
+		log.Printf("[Migrate] Another replica is migrating, waiting for the lock...")
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(migrationLockPollInterval):
+		}
+	}
+	defer func() {
+		if _, err := locker.Unlock(migrationLockKey); err != nil {
+			log.Printf("[Migrate] Failed to release migration lock: %v", err)
+		}
+	}()
+
+	return fn(db)
+}
@@ -0,0 +1,132 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"iac-platform/migrations"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newMigratorTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	return db
+}
+
+func testMigrations() fstest.MapFS {
+	return fstest.MapFS{
+		"0001_create_widgets.up.sql":   {Data: []byte("CREATE TABLE widgets (id INTEGER PRIMARY KEY);\nCREATE INDEX idx_widgets_id ON widgets (id);")},
+		"0001_create_widgets.down.sql": {Data: []byte("DROP TABLE widgets;")},
+		"0002_seed_widgets.up.sql":     {Data: []byte("INSERT INTO widgets (id) VALUES (1);")},
+		"0003_add_color.up.sql":        {Data: []byte("ALTER TABLE widgets ADD COLUMN color TEXT;")},
+		"0003_add_color.down.sql":      {Data: []byte("ALTER TABLE widgets DROP COLUMN color;")},
+		"README.md":                    {Data: []byte("ignored")},
+	}
+}
+
+func TestMigrator_UpStatusDown(t *testing.T) {
+	db := newMigratorTestDB(t)
+	ctx := context.Background()
+	fsys := testMigrations()
+
+	m, err := NewMigrator(db, fsys)
+	require.NoError(t, err)
+	require.Len(t, m.Migrations(), 3)
+	assert.Equal(t, int64(3), m.LatestVersion())
+
+	// 全新数据库：全部待执行，Verify 报告 pending
+	assert.True(t, errors.Is(m.Verify(ctx), ErrPendingMigrations))
+
+	applied, err := m.Up(ctx)
+	require.NoError(t, err)
+	assert.Len(t, applied, 3)
+	require.NoError(t, m.Verify(ctx))
+
+	// 再次执行无操作
+	applied, err = m.Up(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+
+	statuses, err := m.Status(ctx)
+	require.NoError(t, err)
+	for _, s := range statuses {
+		assert.True(t, s.Applied, "version %d", s.Version)
+	}
+	assert.False(t, statuses[1].Reversible)
+
+	// 回滚一步
+	reverted, err := m.Down(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, reverted, 1)
+	assert.Equal(t, int64(3), reverted[0].Version)
+	assert.False(t, db.Migrator().HasColumn("widgets", "color"))
+
+	// 0002 没有 down 脚本，不可回滚
+	_, err = m.Down(ctx, 1)
+	assert.True(t, errors.Is(err, ErrIrreversibleMigration))
+
+	// 重新执行 0003
+	applied, err = m.Up(ctx)
+	require.NoError(t, err)
+	require.Len(t, applied, 1)
+	assert.Equal(t, int64(3), applied[0].Version)
+}
+
+func TestMigrator_RefusesUnknownVersionAndModifiedMigration(t *testing.T) {
+	db := newMigratorTestDB(t)
+	ctx := context.Background()
+
+	m, err := NewMigrator(db, testMigrations())
+	require.NoError(t, err)
+	_, err = m.Up(ctx)
+	require.NoError(t, err)
+
+	// 数据库由更新的版本迁移过：旧二进制拒绝启动
+	require.NoError(t, db.Create(&SchemaMigration{Version: 4, Name: "from_the_future", Checksum: "x"}).Error)
+	err = EnsureSchema(ctx, db, testMigrations(), true)
+	assert.True(t, errors.Is(err, ErrUnknownSchemaVersion))
+	err = EnsureSchema(ctx, db, testMigrations(), false)
+	assert.True(t, errors.Is(err, ErrUnknownSchemaVersion))
+	require.NoError(t, db.Delete(&SchemaMigration{}, 4).Error)
+
+	// 已执行的迁移被修改
+	modified := testMigrations()
+	modified["0002_seed_widgets.up.sql"] = &fstest.MapFile{Data: []byte("INSERT INTO widgets (id) VALUES (2);")}
+	err = EnsureSchema(ctx, db, modified, true)
+	assert.True(t, errors.Is(err, ErrMigrationChecksumMismatch))
+
+	require.NoError(t, EnsureSchema(ctx, db, testMigrations(), false))
+}
+
+func TestLoadMigrations_Embedded(t *testing.T) {
+	loaded, err := LoadMigrations(migrations.FS)
+	require.NoError(t, err)
+	require.NotEmpty(t, loaded)
+	for i, m := range loaded {
+		assert.Equal(t, int64(i+1), m.Version, "embedded migration versions must be contiguous")
+		assert.NotContains(t, m.UpSQL, "\\connect", "%d_%s", m.Version, m.Name)
+	}
+}
+
+func TestLoadMigrations_RejectsDuplicateVersion(t *testing.T) {
+	_, err := LoadMigrations(fstest.MapFS{
+		"0001_a.up.sql": {Data: []byte("SELECT 1;")},
+		"0001_b.up.sql": {Data: []byte("SELECT 1;")},
+	})
+	assert.Error(t, err)
+
+	_, err = LoadMigrations(fstest.MapFS{
+		"0001_a.down.sql": {Data: []byte("SELECT 1;")},
+	})
+	assert.Error(t, err)
+}
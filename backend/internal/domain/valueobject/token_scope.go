@@ -0,0 +1,148 @@
+package valueobject
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// TokenScope API Token 的作用域限制
+// Token 的实际权限 = 持有者（用户或团队）的授权 ∩ Token 作用域
+// 所有字段为空时不做限制（仅用于兼容作用域功能上线前创建的 Token）
+type TokenScope struct {
+	// ReadOnly 只读 Token，权限上限为 READ
+	ReadOnly bool `json:"read_only"`
+	// WorkspaceIDs 允许访问的工作空间（语义化ID，如 ws-xxx）
+	WorkspaceIDs []string `json:"workspace_ids,omitempty"`
+	// ProjectIDs 允许访问的项目，项目下的所有工作空间均可访问
+	ProjectIDs []uint `json:"project_ids,omitempty"`
+	// ResourceTypes 允许访问的资源类型，为空表示不限制
+	ResourceTypes []string `json:"resource_types,omitempty"`
+	// IPAllowlist 允许使用 Token 的来源地址（IP 或 CIDR），为空表示不限制
+	IPAllowlist []string `json:"ip_allowlist,omitempty"`
+}
+
+// Validate 校验作用域配置
+func (s *TokenScope) Validate() error {
+	for _, rt := range s.ResourceTypes {
+		if _, err := ParseResourceType(rt); err != nil {
+			return err
+		}
+	}
+	for _, ws := range s.WorkspaceIDs {
+		if strings.TrimSpace(ws) == "" {
+			return fmt.Errorf("workspace_ids must not contain empty values")
+		}
+	}
+	for _, p := range s.ProjectIDs {
+		if p == 0 {
+			return fmt.Errorf("project_ids must not contain 0")
+		}
+	}
+	for _, entry := range s.IPAllowlist {
+		if _, _, err := net.ParseCIDR(entry); err == nil {
+			continue
+		}
+		if net.ParseIP(entry) == nil {
+			return fmt.Errorf("invalid ip_allowlist entry: %s", entry)
+		}
+	}
+	return nil
+}
+
+// LimitsPermissions 作用域是否限制了权限（IP 白名单在认证阶段校验，不影响权限计算）
+func (s *TokenScope) LimitsPermissions() bool {
+	return s != nil && (s.ReadOnly || s.RestrictsScope() || len(s.ResourceTypes) > 0)
+}
+
+// RestrictsScope 是否限定了工作空间或项目
+func (s *TokenScope) RestrictsScope() bool {
+	return s != nil && (len(s.WorkspaceIDs) > 0 || len(s.ProjectIDs) > 0)
+}
+
+// AllowsIP 检查来源地址是否在允许列表中
+func (s *TokenScope) AllowsIP(ip string) bool {
+	if s == nil || len(s.IPAllowlist) == 0 {
+		return true
+	}
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	for _, entry := range s.IPAllowlist {
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			if network.Contains(addr) {
+				return true
+			}
+			continue
+		}
+		if allowed := net.ParseIP(entry); allowed != nil && allowed.Equal(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// TokenScopeTarget 权限检查目标，用于计算作用域上限
+type TokenScopeTarget struct {
+	ResourceType ResourceType
+	ScopeType    ScopeType
+	ProjectID    uint   // 目标所属项目（工作空间或项目级检查时）
+	WorkspaceID  string // 目标工作空间的语义化ID（工作空间级检查时）
+}
+
+// MaxLevel 返回作用域允许的最高权限等级及受限原因
+func (s *TokenScope) MaxLevel(target TokenScopeTarget) (PermissionLevel, string) {
+	if s == nil {
+		return PermissionLevelAdmin, ""
+	}
+
+	if len(s.ResourceTypes) > 0 && !containsString(s.ResourceTypes, string(target.ResourceType)) {
+		return PermissionLevelNone, fmt.Sprintf("token scope does not include resource type %s", target.ResourceType)
+	}
+
+	if s.RestrictsScope() {
+		switch target.ScopeType {
+		case ScopeTypeWorkspace:
+			if !containsString(s.WorkspaceIDs, target.WorkspaceID) && !containsUint(s.ProjectIDs, target.ProjectID) {
+				return PermissionLevelNone, "token scope does not include this workspace"
+			}
+		case ScopeTypeProject:
+			if !containsUint(s.ProjectIDs, target.ProjectID) {
+				return PermissionLevelNone, "token scope does not include this project"
+			}
+		default:
+			// 限定了工作空间/项目的 Token 不能访问组织级资源
+			return PermissionLevelNone, "token scope does not include organization-level access"
+		}
+	}
+
+	if s.ReadOnly {
+		return PermissionLevelRead, "token scope is read-only"
+	}
+	return PermissionLevelAdmin, ""
+}
+
+func containsString(values []string, target string) bool {
+	if target == "" {
+		return false
+	}
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
+
+func containsUint(values []uint, target uint) bool {
+	if target == 0 {
+		return false
+	}
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
@@ -887,6 +887,7 @@ func (h *RawAgentCCHandler) SendTaskToAgent(agentID string, taskID uint, workspa
 // Agent capacity: 3 plan tasks + 1 plan_and_apply task
 // Plan tasks are completely independent and can run concurrently with plan_and_apply
 // drift_check tasks use plan slots (they only run terraform plan)
+// test tasks (module terraform test) also use plan slots
 func (h *RawAgentCCHandler) IsAgentAvailable(agentID string, taskType models.TaskType) bool {
 	h.mu.RLock()
 	agentConn, ok := h.agents[agentID]
@@ -903,7 +904,7 @@ func (h *RawAgentCCHandler) IsAgentAvailable(agentID string, taskType models.Tas
 	// Plan tasks and drift_check tasks: check if plan slots are available (up to 3)
 	// These tasks can run even when apply is running
 	// drift_check is essentially a plan operation (terraform plan only)
-	if taskType == models.TaskTypePlan || taskType == models.TaskTypeDriftCheck {
+	if taskType == models.TaskTypePlan || taskType == models.TaskTypeDriftCheck || taskType == models.TaskTypeTest {
 		available := agentConn.Status.PlanRunning < agentConn.Status.PlanLimit
 		log.Printf("[Raw] IsAgentAvailable: agent %s, task_type=%s, plan_running=%d, plan_limit=%d, apply_running=%v, available=%v",
 			agentID, taskType, agentConn.Status.PlanRunning, agentConn.Status.PlanLimit, agentConn.Status.ApplyRunning, available)
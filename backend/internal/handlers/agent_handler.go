@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
 	"encoding/json"
@@ -502,22 +503,18 @@ func (h *AgentHandler) GetTaskData(c *gin.Context) {
 	var remoteDataList []models.WorkspaceRemoteData
 	h.db.Where("workspace_id = ?", workspace.WorkspaceID).Find(&remoteDataList)
 
-	// Generate remote data config with tokens for agent
+	// Remote data config for agent: terraform_remote_state reads the platform's remote-state API
+	// with short-lived credentials that the agent passes to terraform through TF_HTTP_* env vars
 	var remoteDataConfig []gin.H
+	var remoteStateCredentials *services.RemoteStateCredentials
 	if len(remoteDataList) > 0 {
-		// Get platform base URL
-		platformConfigService := services.NewPlatformConfigService(h.db)
-		baseURL := platformConfigService.GetBaseURL()
-
-		// Create RemoteDataTFGenerator to generate tokens
-		generator := services.NewRemoteDataTFGenerator(h.db, baseURL)
+		stateService := services.NewRemoteStateService(h.db)
 
 		for _, rd := range remoteDataList {
-			// Generate token for each remote data reference
-			token, err := generator.GenerateTokenForAgent(workspace.WorkspaceID, rd.SourceWorkspaceID, &taskID)
-			if err != nil {
-				// Log error but continue
-				log.Printf("[Agent] Failed to generate token for remote data %s: %v", rd.RemoteDataID, err)
+			allowed, err := stateService.CanReadOutputs(workspace.WorkspaceID, rd.SourceWorkspaceID)
+			if err != nil || !allowed {
+				// Log and skip sources that do not share outputs with this workspace
+				log.Printf("[Agent] Skipping remote data %s: outputs of %s not readable (err=%v)", rd.RemoteDataID, rd.SourceWorkspaceID, err)
 				continue
 			}
 
@@ -525,10 +522,18 @@ func (h *AgentHandler) GetTaskData(c *gin.Context) {
 				"remote_data_id":      rd.RemoteDataID,
 				"source_workspace_id": rd.SourceWorkspaceID,
 				"data_name":           rd.DataName,
-				"token":               token,
-				"url":                 fmt.Sprintf("%s/api/v1/workspaces/%s/state-outputs/full", baseURL, rd.SourceWorkspaceID),
 			})
 		}
+
+		if len(remoteDataConfig) > 0 {
+			creds, err := stateService.IssueCredentials(workspace.WorkspaceID, &taskID, len(remoteDataConfig))
+			if err != nil {
+				log.Printf("[Agent] Failed to issue remote state credentials for task %d: %v", taskID, err)
+				remoteDataConfig = nil
+			} else {
+				remoteStateCredentials = creds
+			}
+		}
 	}
 
 	// Get latest state version
@@ -582,6 +587,8 @@ func (h *AgentHandler) GetTaskData(c *gin.Context) {
 			"tf_code":            workspace.TFCode,
 			"system_variables":   workspace.SystemVariables,
 			"terraform_lock_hcl": workspace.TerraformLockHCL, // 用于恢复 .terraform.lock.hcl 文件
+
+			"static_analysis_config": workspace.StaticAnalysisConfig, // plan 前静态分析配置
 		},
 		"resources":       resources,
 		"variables":       variables,
@@ -590,6 +597,11 @@ func (h *AgentHandler) GetTaskData(c *gin.Context) {
 		"module_versions": moduleVersions,   // 【新增】添加 module_versions，用于 Agent 模式下补充 tf_code 中缺失的 version 字段
 	}
 
+	// terraform_remote_state 短期凭证，Agent 只通过环境变量传给 terraform
+	if remoteStateCredentials != nil {
+		response["remote_state_credentials"] = remoteStateCredentials
+	}
+
 	// Add state version ONLY if it actually exists in database
 	if hasStateVersion {
 		response["state_version"] = gin.H{
@@ -666,12 +678,32 @@ func (h *AgentHandler) UploadTaskLogChunk(c *gin.Context) {
 		return
 	}
 
+	// Idempotent re-upload: chunks replayed from the agent spool carry the same offset and checksum,
+	// identical content at a different offset is a new chunk
+	if req.Checksum != "" {
+		var existing int64
+		h.db.Model(&models.TaskLog{}).
+			Where("task_id = ? AND phase = ? AND log_offset = ? AND checksum = ?", taskID, req.Phase, req.Offset, req.Checksum).
+			Count(&existing)
+		if existing > 0 {
+			c.JSON(http.StatusOK, gin.H{
+				"status":      "ok",
+				"duplicate":   true,
+				"next_offset": req.Offset + int64(len(req.Content)),
+				"saved_bytes": 0,
+			})
+			return
+		}
+	}
+
 	// Save log chunk to task_logs table
 	taskLog := &models.TaskLog{
-		TaskID:  taskID,
-		Phase:   req.Phase,
-		Content: req.Content,
-		Level:   "info",
+		TaskID:    taskID,
+		Phase:     req.Phase,
+		Content:   req.Content,
+		Level:     "info",
+		Checksum:  req.Checksum,
+		LogOffset: &req.Offset,
 	}
 
 	if err := h.db.Create(taskLog).Error; err != nil {
@@ -801,6 +833,13 @@ func (h *AgentHandler) UpdateTaskStatus(c *gin.Context) {
 		return
 	}
 
+	// The state replayed from the agent spool may arrive before this status update
+	if req.Status == models.TaskStatusStatePendingUpload && h.taskHasStateVersion(taskID) {
+		req.Status = models.TaskStatusApplied
+		req.Stage = "applied"
+		req.ErrorMessage = ""
+	}
+
 	// Build updates map to avoid overwriting other fields (like plan_data, plan_json)
 	updates := map[string]interface{}{
 		"status": req.Status,
@@ -857,6 +896,10 @@ func (h *AgentHandler) UpdateTaskStatus(c *gin.Context) {
 		now := time.Now()
 		updates["completed_at"] = &now
 	}
+	if req.Status == models.TaskStatusApplied && task.Status != models.TaskStatusApplied {
+		// Clear the "state pending upload" message of a promoted task
+		updates["error_message"] = req.ErrorMessage
+	}
 
 	// Use Updates() instead of Save() to avoid overwriting other fields
 	if err := h.db.Model(&task).Updates(updates).Error; err != nil {
@@ -866,6 +909,19 @@ func (h *AgentHandler) UpdateTaskStatus(c *gin.Context) {
 		return
 	}
 
+	h.afterTaskStatusUpdate(task, req.Status)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "task status updated",
+		"task_id": taskID,
+		"status":  req.Status,
+	})
+}
+
+// afterTaskStatusUpdate runs the server-side hooks for a task status reported by an agent
+func (h *AgentHandler) afterTaskStatusUpdate(task models.WorkspaceTask, status models.TaskStatus) {
+	taskID := task.ID
+
 	// 注意：post_plan Run Tasks 在 UploadPlanData 中执行（Plan 完成后）
 	// apply_pending 状态是 pre_apply 的时机，但 pre_apply 需要在 Agent 开始 Apply 前执行
 	// 目前 pre_apply 在 terraform_executor.go 中处理（Local 模式）
@@ -875,7 +931,7 @@ func (h *AgentHandler) UpdateTaskStatus(c *gin.Context) {
 	// 注意：使用 ExecuteTriggersCreateOnly 只创建任务，不调用 TryExecuteNextTask
 	// 这样可以避免在没有完整初始化的 TaskQueueManager 中执行任务导致崩溃
 	// 创建的任务会被现有的任务队列机制自动执行
-	if req.Status == models.TaskStatusApplied {
+	if status == models.TaskStatusApplied {
 		// 执行 Run Triggers
 		go func() {
 			log.Printf("[RunTrigger] Task %d completed with status applied, executing run triggers", taskID)
@@ -893,56 +949,54 @@ func (h *AgentHandler) UpdateTaskStatus(c *gin.Context) {
 	// 失败的 apply 中可能有部分资源已创建，也需要同步
 	if h.taskQueueManager != nil &&
 		task.TaskType == models.TaskTypePlanAndApply &&
-		(req.Status == models.TaskStatusApplied || req.Status == models.TaskStatusFailed) {
-		// 使用 req.Status 而非 task.Status，因为 DB 已更新但内存对象未刷新
+		(status == models.TaskStatusApplied || status == models.TaskStatusFailed) {
+		// 使用 status 而非 task.Status，因为 DB 已更新但内存对象未刷新
 		taskCopy := task
-		taskCopy.Status = req.Status
+		taskCopy.Status = status
 		go h.taskQueueManager.SyncCMDBAfterApply(&taskCopy)
 	}
 
 	// Drift Check 结果处理（Agent 模式补齐）
 	if task.TaskType == models.TaskTypeDriftCheck &&
-		(req.Status == models.TaskStatusSuccess ||
-			req.Status == models.TaskStatusFailed ||
-			req.Status == models.TaskStatusApplied ||
-			req.Status == models.TaskStatusPlannedAndFinished ||
-			req.Status == models.TaskStatusCancelled) {
+		(status == models.TaskStatusSuccess ||
+			status == models.TaskStatusFailed ||
+			status == models.TaskStatusApplied ||
+			status == models.TaskStatusPlannedAndFinished ||
+			status == models.TaskStatusCancelled) {
 		taskCopy := task
-		taskCopy.Status = req.Status
+		taskCopy.Status = status
 		go services.NewDriftCheckService(h.db).ProcessDriftCheckResult(&taskCopy)
 	}
 
 	// 任务到达终态后，立即触发下个任务执行（Agent 模式补齐）
 	// 此前依赖 checkAndRetryPendingTasks 定时轮询，延迟可达 30-60s
 	if h.taskQueueManager != nil &&
-		(req.Status == models.TaskStatusSuccess ||
-			req.Status == models.TaskStatusApplied ||
-			req.Status == models.TaskStatusPlannedAndFinished ||
-			req.Status == models.TaskStatusFailed ||
-			req.Status == models.TaskStatusCancelled) {
+		(status == models.TaskStatusSuccess ||
+			status == models.TaskStatusApplied ||
+			status == models.TaskStatusPlannedAndFinished ||
+			status == models.TaskStatusFailed ||
+			status == models.TaskStatusCancelled) {
 		go h.taskQueueManager.TryExecuteNextTask(task.WorkspaceID)
 	}
 
 	// K8s Slot 释放 — 任务到达终态后释放 pod slot（Agent 模式补齐）
 	if h.taskQueueManager != nil &&
-		(req.Status == models.TaskStatusSuccess ||
-			req.Status == models.TaskStatusApplied ||
-			req.Status == models.TaskStatusPlannedAndFinished ||
-			req.Status == models.TaskStatusFailed ||
-			req.Status == models.TaskStatusCancelled) {
+		(status == models.TaskStatusSuccess ||
+			status == models.TaskStatusApplied ||
+			status == models.TaskStatusPlannedAndFinished ||
+			status == models.TaskStatusFailed ||
+			status == models.TaskStatusCancelled) {
 		go h.taskQueueManager.ReleaseTaskSlot(taskID)
 	}
 
 	// K8s Slot 预留 — 转入 apply_pending 时预留 slot 防 scale-down（Agent 模式补齐）
-	if h.taskQueueManager != nil && req.Status == models.TaskStatusApplyPending {
+	// state_pending_upload 时 State 唯一副本在 pod 本地 spool 中，同样预留，
+	// 待 State 上传后 promoteStatePendingTask 转 applied 时再释放
+	if h.taskQueueManager != nil &&
+		(status == models.TaskStatusApplyPending ||
+			status == models.TaskStatusStatePendingUpload) {
 		go h.taskQueueManager.ReserveSlotForApplyPending(taskID)
 	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"message": "task status updated",
-		"task_id": taskID,
-		"status":  req.Status,
-	})
 }
 
 // SaveTaskState saves task state version
@@ -1017,6 +1071,19 @@ func (h *AgentHandler) SaveTaskState(c *gin.Context) {
 		return
 	}
 
+	// Idempotent re-upload: the agent replays spooled states after reconnect
+	var existing models.WorkspaceStateVersion
+	if err := h.db.Where("task_id = ? AND checksum = ?", taskID, req.Checksum).
+		First(&existing).Error; err == nil {
+		h.promoteStatePendingTask(&task)
+		c.JSON(http.StatusOK, gin.H{
+			"message":   "state already saved",
+			"version":   existing.Version,
+			"duplicate": true,
+		})
+		return
+	}
+
 	// Get max version
 	var maxVersion int
 	h.db.Model(&models.WorkspaceStateVersion{}).
@@ -1070,12 +1137,48 @@ func (h *AgentHandler) SaveTaskState(c *gin.Context) {
 		}
 	}()
 
+	h.promoteStatePendingTask(&task)
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "state saved successfully",
 		"version": newVersion,
 	})
 }
 
+// taskHasStateVersion reports whether a state version produced by the task has been saved
+func (h *AgentHandler) taskHasStateVersion(taskID uint) bool {
+	var count int64
+	h.db.Model(&models.WorkspaceStateVersion{}).Where("task_id = ?", taskID).Count(&count)
+	return count > 0
+}
+
+// promoteStatePendingTask completes a task that was waiting for its state to be uploaded from the agent spool
+func (h *AgentHandler) promoteStatePendingTask(task *models.WorkspaceTask) {
+	if task.Status != models.TaskStatusStatePendingUpload {
+		return
+	}
+
+	now := time.Now()
+	result := h.db.Model(&models.WorkspaceTask{}).
+		Where("id = ? AND status = ?", task.ID, models.TaskStatusStatePendingUpload).
+		Updates(map[string]interface{}{
+			"status":        models.TaskStatusApplied,
+			"stage":         "applied",
+			"error_message": "",
+			"completed_at":  &now,
+		})
+	if result.Error != nil {
+		log.Printf("[SaveTaskState] Failed to promote task %d to applied: %v", task.ID, result.Error)
+		return
+	}
+	if result.RowsAffected == 0 {
+		return
+	}
+
+	log.Printf("[SaveTaskState] State of task %d arrived from agent spool, task promoted to applied", task.ID)
+	h.afterTaskStatusUpdate(*task, models.TaskStatusApplied)
+}
+
 // ============================================================================
 // New handlers for Agent Mode refactoring
 // ============================================================================
@@ -1190,6 +1293,10 @@ func (h *AgentHandler) GetPlanTask(c *gin.Context) {
 	}
 	if task.PlanHash != "" {
 		taskResponse["plan_hash"] = task.PlanHash
+		// plan 文件未上传时 Agent 需重新 plan，用变更数核对
+		taskResponse["changes_add"] = task.ChangesAdd
+		taskResponse["changes_change"] = task.ChangesChange
+		taskResponse["changes_destroy"] = task.ChangesDestroy
 	}
 	if task.SnapshotCreatedAt != nil {
 		taskResponse["snapshot_created_at"] = task.SnapshotCreatedAt
@@ -1344,6 +1451,16 @@ func (h *AgentHandler) UploadPlanData(c *gin.Context) {
 		return
 	}
 
+	// Idempotent re-upload: identical plan data replayed from the agent spool must not re-run post_plan Run Tasks
+	if len(decodedData) > 0 && bytes.Equal(task.PlanData, decodedData) {
+		c.JSON(http.StatusOK, gin.H{
+			"message":   "plan_data already uploaded",
+			"size":      len(decodedData),
+			"duplicate": true,
+		})
+		return
+	}
+
 	// Store the decoded binary data (same as Local mode)
 	if err := h.db.Model(&task).Update("plan_data", decodedData).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -1463,6 +1580,136 @@ func (h *AgentHandler) UploadPlanJSON(c *gin.Context) {
 	})
 }
 
+// GetModuleTestBundle returns module files and generated tests for a test task
+// @Summary Get module test bundle
+// @Description Get module files (including synced .tftest.hcl) and demo-generated tests for a module test task
+// @Tags Agent
+// @Produce json
+// @Param task_id path string true "Task ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/v1/agents/tasks/{task_id}/module-test-bundle [get]
+func (h *AgentHandler) GetModuleTestBundle(c *gin.Context) {
+	var taskID uint
+	if _, err := fmt.Sscanf(c.Param("task_id"), "%d", &taskID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task_id format"})
+		return
+	}
+
+	var task models.WorkspaceTask
+	if err := h.db.Select("id, task_type").First(&task, taskID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		return
+	}
+	if task.TaskType != models.TaskTypeTest {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "task is not a module test task"})
+		return
+	}
+
+	bundle, err := services.NewModuleTestService(h.db).BuildBundleForTask(taskID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"bundle": bundle})
+}
+
+// UploadModuleTestResult stores parsed terraform test results reported by agent
+// @Summary Upload module test result
+// @Description Upload parsed terraform test / tofu test results for a module test task
+// @Tags Agent
+// @Accept json
+// @Produce json
+// @Param task_id path string true "Task ID"
+// @Param request body services.ModuleTestReport true "Test report"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/agents/tasks/{task_id}/module-test-result [post]
+func (h *AgentHandler) UploadModuleTestResult(c *gin.Context) {
+	var taskID uint
+	if _, err := fmt.Sscanf(c.Param("task_id"), "%d", &taskID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task_id format"})
+		return
+	}
+
+	var report services.ModuleTestReport
+	if err := c.ShouldBindJSON(&report); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+
+	if err := services.NewModuleTestService(h.db).CompleteRun(taskID, &report); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "module test result saved"})
+}
+
+// UploadStaticAnalysisResults stores pre-plan static analysis results reported by agent
+// @Summary Upload static analysis results
+// @Description Upload SARIF-like static analysis results produced before terraform plan
+// @Tags Agent
+// @Accept json
+// @Produce json
+// @Param task_id path string true "Task ID"
+// @Param request body map[string]interface{} true "Static analysis results"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/agents/tasks/{task_id}/static-analysis-results [post]
+func (h *AgentHandler) UploadStaticAnalysisResults(c *gin.Context) {
+	var taskID uint
+	if _, err := fmt.Sscanf(c.Param("task_id"), "%d", &taskID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task_id format"})
+		return
+	}
+
+	var req struct {
+		Results []models.TaskStaticAnalysisResult `json:"results"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body: " + err.Error()})
+		return
+	}
+
+	if err := services.NewStaticAnalysisService(h.db).SaveResults(taskID, req.Results); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "static analysis results saved"})
+}
+
+// EstimateTaskCost estimates the cost of the plan JSON uploaded by agent
+// @Summary Estimate task cost
+// @Description Estimate monthly cost of the task's uploaded plan JSON and evaluate workspace budget thresholds
+// @Tags Agent
+// @Produce json
+// @Param task_id path string true "Task ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/agents/tasks/{task_id}/cost-estimate [post]
+func (h *AgentHandler) EstimateTaskCost(c *gin.Context) {
+	var taskID uint
+	if _, err := fmt.Sscanf(c.Param("task_id"), "%d", &taskID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task_id format"})
+		return
+	}
+
+	estimate, err := services.NewCostEstimationService(h.db).EnsureEstimate(c.Request.Context(), taskID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"cost_estimate": services.CostEstimateSummary(estimate)})
+}
+
 // LockWorkspace locks a workspace
 // @Summary Lock workspace
 // @Description Lock a workspace for exclusive access
@@ -599,6 +599,14 @@ func (h *AgentPoolHandler) UpdateK8sConfig(c *gin.Context) {
 		return
 	}
 
+	// Validate pod template customization before saving, so that idle pods are never rebuilt from an invalid template
+	if err := services.ValidateK8sPodTemplate(&req.K8sConfig); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("invalid pod template: %v", err),
+		})
+		return
+	}
+
 	// Get user ID from context
 	userID, exists := c.Get("user_id")
 	if !exists {
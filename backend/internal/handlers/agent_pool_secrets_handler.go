@@ -2,11 +2,13 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
 
 	"iac-platform/internal/crypto"
 	"iac-platform/internal/models"
+	"iac-platform/services"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -100,6 +102,24 @@ func (h *AgentPoolSecretsHandler) GetPoolSecrets(c *gin.Context) {
 		log.Printf("[Agent Pool Secrets] Added credential for key: %s", metadata.Key)
 	}
 
+	// Record secret access (agents authenticate with the pool token, so the pool is the actor)
+	for _, secret := range secrets {
+		services.NewAuditEventService(h.db).Emit(&models.AuditEvent{
+			EventType:    models.AuditEventSecretAccessed,
+			ActorType:    models.AuditActorAgent,
+			ActorID:      poolIDStr,
+			ActorName:    "pool-token",
+			SourceIP:     c.ClientIP(),
+			ResourceType: "secret",
+			ResourceID:   secret.SecretID,
+			Message:      fmt.Sprintf("Pool secret %s decrypted for agent", secret.SecretID),
+			Details: models.JSONB{
+				"pool_id":   poolIDStr,
+				"decrypted": true,
+			},
+		})
+	}
+
 	// Return credentials in Terraform format
 	c.JSON(http.StatusOK, gin.H{
 		"credentials": credentials,
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"net/http"
+
+	"iac-platform/internal/application/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// APITokenPolicyHandler handles the platform-wide API token policy
+type APITokenPolicyHandler struct {
+	db *gorm.DB
+}
+
+// NewAPITokenPolicyHandler creates a new API token policy handler
+func NewAPITokenPolicyHandler(db *gorm.DB) *APITokenPolicyHandler {
+	return &APITokenPolicyHandler{db: db}
+}
+
+// GetAPITokenPolicy gets the API token policy
+// @Summary Get API token policy
+// @Description Get the maximum lifetime allowed for user and team tokens
+// @Tags API Token Policy
+// @Produce json
+// @Success 200 {object} service.APITokenPolicy
+// @Router /api/v1/global/settings/api-token-policy [get]
+// @Security Bearer
+func (h *APITokenPolicyHandler) GetAPITokenPolicy(c *gin.Context) {
+	policy, err := service.LoadAPITokenPolicy(c.Request.Context(), h.db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, policy)
+}
+
+// UpdateAPITokenPolicy updates the API token policy
+// @Summary Update API token policy
+// @Description Set the maximum lifetime for newly created user and team tokens. Existing tokens keep their expiry.
+// @Tags API Token Policy
+// @Accept json
+// @Produce json
+// @Param request body service.APITokenPolicy true "API token policy"
+// @Success 200 {object} service.APITokenPolicy
+// @Failure 400 {object} map[string]interface{}
+// @Router /api/v1/global/settings/api-token-policy [put]
+// @Security Bearer
+func (h *APITokenPolicyHandler) UpdateAPITokenPolicy(c *gin.Context) {
+	var policy service.APITokenPolicy
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := policy.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := service.SaveAPITokenPolicy(c.Request.Context(), h.db, &policy); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, policy)
+}
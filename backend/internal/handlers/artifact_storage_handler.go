@@ -0,0 +1,318 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"iac-platform/internal/crypto"
+	"iac-platform/internal/models"
+	"iac-platform/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ArtifactStorageHandler handles task artifact storage settings and retention policies
+type ArtifactStorageHandler struct {
+	db *gorm.DB
+}
+
+// NewArtifactStorageHandler creates a new artifact storage handler
+func NewArtifactStorageHandler(db *gorm.DB) *ArtifactStorageHandler {
+	return &ArtifactStorageHandler{db: db}
+}
+
+// artifactStorageResponse is the storage config returned by the API; the secret is never returned
+type artifactStorageResponse struct {
+	Type               string `json:"type"`
+	LocalPath          string `json:"local_path,omitempty"`
+	Endpoint           string `json:"endpoint,omitempty"`
+	Region             string `json:"region,omitempty"`
+	Bucket             string `json:"bucket,omitempty"`
+	Prefix             string `json:"prefix,omitempty"`
+	UsePathStyle       bool   `json:"use_path_style"`
+	AccessKeyID        string `json:"access_key_id,omitempty"`
+	SecretAccessKeySet bool   `json:"secret_access_key_set"`
+}
+
+func toArtifactStorageResponse(cfg *models.ArtifactStorageConfig) artifactStorageResponse {
+	return artifactStorageResponse{
+		Type:               cfg.Type,
+		LocalPath:          cfg.LocalPath,
+		Endpoint:           cfg.Endpoint,
+		Region:             cfg.Region,
+		Bucket:             cfg.Bucket,
+		Prefix:             cfg.Prefix,
+		UsePathStyle:       cfg.UsePathStyle,
+		AccessKeyID:        cfg.AccessKeyID,
+		SecretAccessKeySet: cfg.SecretAccessKeyEncrypted != "",
+	}
+}
+
+// artifactStorageRequest is the request body for updating the storage config
+type artifactStorageRequest struct {
+	Type         string `json:"type" binding:"required,oneof=local s3"`
+	LocalPath    string `json:"local_path"`
+	Endpoint     string `json:"endpoint"`
+	Region       string `json:"region"`
+	Bucket       string `json:"bucket"`
+	Prefix       string `json:"prefix"`
+	UsePathStyle bool   `json:"use_path_style"`
+	AccessKeyID  string `json:"access_key_id"`
+	// SecretAccessKey nil keeps the stored secret, "" clears it
+	SecretAccessKey *string `json:"secret_access_key"`
+}
+
+// GetArtifactStorage gets the artifact storage configuration
+// @Summary Get artifact storage configuration
+// @Description Get where archived task logs, plan files and state backups are stored
+// @Tags Artifact Storage
+// @Produce json
+// @Success 200 {object} artifactStorageResponse
+// @Router /api/v1/global/settings/artifact-storage [get]
+// @Security Bearer
+func (h *ArtifactStorageHandler) GetArtifactStorage(c *gin.Context) {
+	cfg, err := services.LoadArtifactStorageConfig(h.db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, toArtifactStorageResponse(cfg))
+}
+
+// UpdateArtifactStorage updates the artifact storage configuration
+// @Summary Update artifact storage configuration
+// @Description Switch between local filesystem and S3-compatible storage. Existing artifacts stay in the storage they were written to.
+// @Tags Artifact Storage
+// @Accept json
+// @Produce json
+// @Param request body artifactStorageRequest true "Storage configuration"
+// @Success 200 {object} artifactStorageResponse
+// @Failure 400 {object} map[string]interface{}
+// @Router /api/v1/global/settings/artifact-storage [put]
+// @Security Bearer
+func (h *ArtifactStorageHandler) UpdateArtifactStorage(c *gin.Context) {
+	var req artifactStorageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	current, err := services.LoadArtifactStorageConfig(h.db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	cfg := &models.ArtifactStorageConfig{
+		Type:                     req.Type,
+		LocalPath:                req.LocalPath,
+		Endpoint:                 req.Endpoint,
+		Region:                   req.Region,
+		Bucket:                   req.Bucket,
+		Prefix:                   req.Prefix,
+		UsePathStyle:             req.UsePathStyle,
+		AccessKeyID:              req.AccessKeyID,
+		SecretAccessKeyEncrypted: current.SecretAccessKeyEncrypted,
+	}
+	if req.SecretAccessKey != nil {
+		cfg.SecretAccessKeyEncrypted = ""
+		if *req.SecretAccessKey != "" {
+			encrypted, err := crypto.EncryptValue(*req.SecretAccessKey)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to encrypt secret"})
+				return
+			}
+			cfg.SecretAccessKeyEncrypted = encrypted
+		}
+	}
+
+	// Validate the configuration can build a storage before saving
+	if _, err := services.NewArtifactStorage(cfg); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := services.SaveArtifactStorageConfig(h.db, cfg); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save artifact storage config", "message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, toArtifactStorageResponse(cfg))
+}
+
+// TestArtifactStorage writes, reads back and deletes a probe object
+// @Summary Test artifact storage
+// @Description Verify the configured storage is reachable and writable
+// @Tags Artifact Storage
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 502 {object} map[string]interface{}
+// @Router /api/v1/global/settings/artifact-storage/test [post]
+// @Security Bearer
+func (h *ArtifactStorageHandler) TestArtifactStorage(c *gin.Context) {
+	cfg, err := services.LoadArtifactStorageConfig(h.db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	storage, err := services.NewArtifactStorage(cfg)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	key := fmt.Sprintf("probe/%d", time.Now().UnixNano())
+	payload := []byte("iac-platform artifact storage probe")
+	if err := storage.Put(ctx, key, payload); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error(), "step": "put"})
+		return
+	}
+	if _, err := storage.Get(ctx, key); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error(), "step": "get"})
+		return
+	}
+	if err := storage.Delete(ctx, key); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error(), "step": "delete"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "artifact storage is reachable", "type": storage.Type()})
+}
+
+// ListRetentionPolicies lists artifact retention policies
+// @Summary List artifact retention policies
+// @Description organization_id 0 is the platform default used by organizations without their own policy
+// @Tags Artifact Storage
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/global/settings/artifact-retention-policies [get]
+// @Security Bearer
+func (h *ArtifactStorageHandler) ListRetentionPolicies(c *gin.Context) {
+	var policies []models.ArtifactRetentionPolicy
+	if err := h.db.Order("organization_id ASC").Find(&policies).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list retention policies"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"policies": policies})
+}
+
+// retentionPolicyRequest is the request body for creating or updating a retention policy
+type retentionPolicyRequest struct {
+	Enabled          *bool `json:"enabled"`
+	ArchiveAfterDays int   `json:"archive_after_days" binding:"min=0"`
+	PurgeAfterDays   int   `json:"purge_after_days" binding:"min=0"`
+}
+
+// PutRetentionPolicy creates or updates the retention policy of an organization
+// @Summary Set artifact retention policy
+// @Description Set when finished task artifacts are archived to object storage and when they are purged. Use org_id 0 for the platform default.
+// @Tags Artifact Storage
+// @Accept json
+// @Produce json
+// @Param org_id path int true "Organization ID (0 = platform default)"
+// @Param request body retentionPolicyRequest true "Retention policy"
+// @Success 200 {object} models.ArtifactRetentionPolicy
+// @Failure 400 {object} map[string]interface{}
+// @Router /api/v1/global/settings/artifact-retention-policies/{org_id} [put]
+// @Security Bearer
+func (h *ArtifactStorageHandler) PutRetentionPolicy(c *gin.Context) {
+	orgID, err := strconv.ParseUint(c.Param("org_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid org_id"})
+		return
+	}
+
+	var req retentionPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.PurgeAfterDays > 0 && req.ArchiveAfterDays > 0 && req.PurgeAfterDays <= req.ArchiveAfterDays {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "purge_after_days must be greater than archive_after_days"})
+		return
+	}
+
+	if orgID != 0 {
+		var count int64
+		h.db.Table("organizations").Where("id = ?", orgID).Count(&count)
+		if count == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "organization not found"})
+			return
+		}
+	}
+
+	var policy models.ArtifactRetentionPolicy
+	err = h.db.Where("organization_id = ?", orgID).First(&policy).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	policy.OrganizationID = uint(orgID)
+	policy.Enabled = req.Enabled == nil || *req.Enabled
+	policy.ArchiveAfterDays = req.ArchiveAfterDays
+	policy.PurgeAfterDays = req.PurgeAfterDays
+	if uid, ok := c.Get("user_id"); ok {
+		policy.UpdatedBy = fmt.Sprintf("%v", uid)
+	}
+
+	// enabled=false must be written explicitly, gorm skips zero values in favour of the column default
+	if policy.ID == 0 {
+		err = h.db.Create(&policy).Error
+		if err == nil && !policy.Enabled {
+			err = h.db.Model(&policy).Update("enabled", false).Error
+		}
+	} else {
+		err = h.db.Select("*").Save(&policy).Error
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save retention policy", "message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, policy)
+}
+
+// DeleteRetentionPolicy deletes the retention policy of an organization
+// @Summary Delete artifact retention policy
+// @Description The organization falls back to the platform default policy
+// @Tags Artifact Storage
+// @Produce json
+// @Param org_id path int true "Organization ID (0 = platform default)"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/global/settings/artifact-retention-policies/{org_id} [delete]
+// @Security Bearer
+func (h *ArtifactStorageHandler) DeleteRetentionPolicy(c *gin.Context) {
+	orgID, err := strconv.ParseUint(c.Param("org_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid org_id"})
+		return
+	}
+	result := h.db.Where("organization_id = ?", orgID).Delete(&models.ArtifactRetentionPolicy{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "retention policy not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "retention policy deleted"})
+}
+
+// RunRetention applies retention policies immediately instead of waiting for the next worker run
+// @Summary Run artifact retention now
+// @Tags Artifact Storage
+// @Produce json
+// @Success 200 {object} services.RetentionRunResult
+// @Router /api/v1/global/settings/artifact-retention-policies/run [post]
+// @Security Bearer
+func (h *ArtifactStorageHandler) RunRetention(c *gin.Context) {
+	result, err := services.NewTaskArtifactService(h.db).ApplyRetention(c.Request.Context(), time.Now(), 500)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,377 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"iac-platform/internal/crypto"
+	"iac-platform/internal/middleware"
+	"iac-platform/internal/models"
+	"iac-platform/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// AuditEventHandler 领域审计事件与 SIEM 导出处理器
+type AuditEventHandler struct {
+	db           *gorm.DB
+	eventService *services.AuditEventService
+}
+
+// NewAuditEventHandler 创建审计事件处理器
+func NewAuditEventHandler(db *gorm.DB) *AuditEventHandler {
+	return &AuditEventHandler{
+		db:           db,
+		eventService: services.NewAuditEventService(db),
+	}
+}
+
+// ListEvents 查询审计事件
+// @Summary 查询领域审计事件
+// @Tags IAM-Audit
+// @Produce json
+// @Param event_type query string false "事件类型（逗号分隔）"
+// @Param actor_id query string false "操作人ID"
+// @Param resource_type query string false "资源类型"
+// @Param resource_id query string false "资源ID"
+// @Param workspace_id query string false "Workspace ID"
+// @Param task_id query int false "任务ID"
+// @Param start_time query string false "开始时间 (RFC3339)"
+// @Param end_time query string false "结束时间 (RFC3339)"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(50)
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/iam/audit/events [get]
+func (h *AuditEventHandler) ListEvents(c *gin.Context) {
+	filter := services.AuditEventFilter{
+		ActorID:      c.Query("actor_id"),
+		ResourceType: c.Query("resource_type"),
+		ResourceID:   c.Query("resource_id"),
+		WorkspaceID:  c.Query("workspace_id"),
+	}
+	filter.Page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+	filter.PageSize, _ = strconv.Atoi(c.DefaultQuery("page_size", "50"))
+
+	if types := c.Query("event_type"); types != "" {
+		for _, t := range strings.Split(types, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				filter.EventTypes = append(filter.EventTypes, t)
+			}
+		}
+	}
+	if taskIDStr := c.Query("task_id"); taskIDStr != "" {
+		taskID, err := strconv.ParseUint(taskIDStr, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task_id"})
+			return
+		}
+		id := uint(taskID)
+		filter.TaskID = &id
+	}
+	if s := c.Query("start_time"); s != "" {
+		if t, err := time.Parse(time.RFC3339, s); err == nil {
+			filter.StartTime = t
+		}
+	}
+	if s := c.Query("end_time"); s != "" {
+		if t, err := time.Parse(time.RFC3339, s); err == nil {
+			filter.EndTime = t
+		}
+	}
+
+	events, total, err := h.eventService.Query(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to query audit events"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"events": events,
+		"pagination": gin.H{
+			"page":      filter.Page,
+			"page_size": filter.PageSize,
+			"total":     total,
+		},
+	})
+}
+
+// GetEvent 获取单条审计事件
+// @Summary 获取审计事件详情
+// @Tags IAM-Audit
+// @Produce json
+// @Param event_id path string true "事件ID"
+// @Success 200 {object} models.AuditEvent
+// @Router /api/v1/iam/audit/events/{event_id} [get]
+func (h *AuditEventHandler) GetEvent(c *gin.Context) {
+	ev, err := h.eventService.GetEvent(c.Param("event_id"))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Audit event not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get audit event"})
+		return
+	}
+	c.JSON(http.StatusOK, ev)
+}
+
+// VerifyChain 校验审计事件哈希链
+// @Summary 校验审计哈希链完整性
+// @Tags IAM-Audit
+// @Produce json
+// @Param from_id query int false "起始事件ID（默认链首）"
+// @Param limit query int false "最多校验条数" default(10000)
+// @Success 200 {object} services.AuditChainVerifyResult
+// @Router /api/v1/iam/audit/events/verify [get]
+func (h *AuditEventHandler) VerifyChain(c *gin.Context) {
+	fromID, _ := strconv.ParseUint(c.DefaultQuery("from_id", "0"), 10, 64)
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10000"))
+
+	result, err := h.eventService.VerifyChain(fromID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify audit chain"})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// auditExporterRequest 创建/更新导出器请求
+type auditExporterRequest struct {
+	Name                 string                   `json:"name"`
+	Description          *string                  `json:"description"`
+	ExporterType         models.AuditExporterType `json:"exporter_type"`
+	Config               models.JSONB             `json:"config"`
+	Secret               *string                  `json:"secret"`
+	EventTypes           *string                  `json:"event_types"`
+	Enabled              *bool                    `json:"enabled"`
+	BatchSize            *int                     `json:"batch_size"`
+	RetryCount           *int                     `json:"retry_count"`
+	RetryIntervalSeconds *int                     `json:"retry_interval_seconds"`
+	// StartFromLatest 为 true 时新导出器只投递创建之后的事件，不回放历史
+	StartFromLatest bool `json:"start_from_latest"`
+}
+
+// applyTo 将请求字段应用到导出器（nil 字段保持不变）
+func (r *auditExporterRequest) applyTo(e *models.AuditExporter) error {
+	if r.Name != "" {
+		e.Name = r.Name
+	}
+	if r.Description != nil {
+		e.Description = *r.Description
+	}
+	if r.ExporterType != "" {
+		e.ExporterType = r.ExporterType
+	}
+	if r.Config != nil {
+		e.Config = r.Config
+	}
+	if r.Secret != nil {
+		if *r.Secret == "" {
+			e.SecretEncrypted = ""
+		} else {
+			encrypted, err := crypto.EncryptValue(*r.Secret)
+			if err != nil {
+				return fmt.Errorf("failed to encrypt secret")
+			}
+			e.SecretEncrypted = encrypted
+		}
+	}
+	if r.EventTypes != nil {
+		e.EventTypes = *r.EventTypes
+	}
+	if r.Enabled != nil {
+		e.Enabled = *r.Enabled
+	}
+	if r.BatchSize != nil {
+		e.BatchSize = *r.BatchSize
+	}
+	if r.RetryCount != nil {
+		e.RetryCount = *r.RetryCount
+	}
+	if r.RetryIntervalSeconds != nil {
+		e.RetryIntervalSeconds = *r.RetryIntervalSeconds
+	}
+
+	if e.BatchSize <= 0 || e.BatchSize > 1000 {
+		e.BatchSize = 100
+	}
+	if e.RetryCount < 0 || e.RetryCount > 10 {
+		e.RetryCount = 3
+	}
+	if e.RetryIntervalSeconds <= 0 {
+		e.RetryIntervalSeconds = 5
+	}
+	return nil
+}
+
+// ListExporters 获取审计导出器列表
+// @Summary 获取审计导出器列表
+// @Tags IAM-Audit
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/iam/audit/exporters [get]
+func (h *AuditEventHandler) ListExporters(c *gin.Context) {
+	var exporters []models.AuditExporter
+	if err := h.db.Order("created_at DESC").Find(&exporters).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list exporters"})
+		return
+	}
+
+	responses := make([]models.AuditExporterResponse, len(exporters))
+	for i := range exporters {
+		responses[i] = exporters[i].ToResponse()
+	}
+	c.JSON(http.StatusOK, gin.H{"exporters": responses})
+}
+
+// CreateExporter 创建审计导出器
+// @Summary 创建审计导出器
+// @Tags IAM-Audit
+// @Accept json
+// @Produce json
+// @Success 201 {object} models.AuditExporterResponse
+// @Router /api/v1/iam/audit/exporters [post]
+func (h *AuditEventHandler) CreateExporter(c *gin.Context) {
+	var req auditExporterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Name == "" || req.ExporterType == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name and exporter_type are required"})
+		return
+	}
+
+	exporter := &models.AuditExporter{
+		ExporterID: fmt.Sprintf("aexp-%s", generateRandomID()),
+		Enabled:    true,
+	}
+	if err := req.applyTo(exporter); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 配置校验：能成功构造导出器即视为配置合法
+	if _, err := services.NewAuditEventExporter(exporter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.StartFromLatest {
+		var maxID uint64
+		h.db.Model(&models.AuditEvent{}).Select("COALESCE(MAX(id), 0)").Scan(&maxID)
+		exporter.LastExportedID = maxID
+	}
+	if uid, ok := c.Get("user_id"); ok {
+		s := fmt.Sprintf("%v", uid)
+		exporter.CreatedBy = &s
+	}
+
+	if err := h.db.Create(exporter).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create exporter"})
+		return
+	}
+	c.JSON(http.StatusCreated, exporter.ToResponse())
+}
+
+// UpdateExporter 更新审计导出器
+// @Summary 更新审计导出器
+// @Tags IAM-Audit
+// @Accept json
+// @Produce json
+// @Param exporter_id path string true "导出器ID"
+// @Success 200 {object} models.AuditExporterResponse
+// @Router /api/v1/iam/audit/exporters/{exporter_id} [put]
+func (h *AuditEventHandler) UpdateExporter(c *gin.Context) {
+	var exporter models.AuditExporter
+	if err := h.db.Where("exporter_id = ?", c.Param("exporter_id")).First(&exporter).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Exporter not found"})
+		return
+	}
+
+	var req auditExporterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := req.applyTo(&exporter); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if _, err := services.NewAuditEventExporter(&exporter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.db.Save(&exporter).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update exporter"})
+		return
+	}
+	c.JSON(http.StatusOK, exporter.ToResponse())
+}
+
+// DeleteExporter 删除审计导出器
+// @Summary 删除审计导出器
+// @Tags IAM-Audit
+// @Param exporter_id path string true "导出器ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/iam/audit/exporters/{exporter_id} [delete]
+func (h *AuditEventHandler) DeleteExporter(c *gin.Context) {
+	result := h.db.Where("exporter_id = ?", c.Param("exporter_id")).Delete(&models.AuditExporter{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete exporter"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Exporter not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Exporter deleted"})
+}
+
+// TestExporter 发送一条测试事件到导出器（不写入审计链）
+// @Summary 测试审计导出器
+// @Tags IAM-Audit
+// @Param exporter_id path string true "导出器ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/iam/audit/exporters/{exporter_id}/test [post]
+func (h *AuditEventHandler) TestExporter(c *gin.Context) {
+	var cfg models.AuditExporter
+	if err := h.db.Where("exporter_id = ?", c.Param("exporter_id")).First(&cfg).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Exporter not found"})
+		return
+	}
+
+	exporter, err := services.NewAuditEventExporter(&cfg)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	defer exporter.Close()
+
+	ev := middleware.AuditEventFromContext(c, "exporter_test")
+	ev.EventID = "aevt-test"
+	ev.OccurredAt = time.Now()
+	ev.Outcome = models.AuditOutcomeSuccess
+	ev.ResourceType = "audit_exporter"
+	ev.ResourceID = cfg.ExporterID
+	ev.Message = "Audit exporter connectivity test"
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	if err := exporter.Export(ctx, []models.AuditEvent{*ev}); err != nil {
+		c.JSON(http.StatusOK, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success":          true,
+		"response_time_ms": time.Since(start).Milliseconds(),
+	})
+}
@@ -0,0 +1,351 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"iac-platform/internal/middleware"
+	"iac-platform/internal/models"
+	"iac-platform/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ChangeFreezeHandler handles the change freeze calendar and per-run exceptions
+type ChangeFreezeHandler struct {
+	db      *gorm.DB
+	service *services.ChangeFreezeService
+}
+
+// NewChangeFreezeHandler creates a new change freeze handler
+func NewChangeFreezeHandler(db *gorm.DB) *ChangeFreezeHandler {
+	return &ChangeFreezeHandler{
+		db:      db,
+		service: services.NewChangeFreezeService(db),
+	}
+}
+
+// ListChangeFreezeWindows lists all change freeze windows
+// @Summary List change freeze windows
+// @Tags Change Freeze
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/global/settings/change-freezes [get]
+func (h *ChangeFreezeHandler) ListChangeFreezeWindows(c *gin.Context) {
+	windows, err := h.service.ListWindows()
+	if err != nil {
+		respondChangeFreezeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": windows, "total": len(windows)})
+}
+
+// GetChangeFreezeWindow gets a change freeze window
+// @Summary Get change freeze window
+// @Tags Change Freeze
+// @Produce json
+// @Param window_id path string true "Window ID"
+// @Success 200 {object} models.ChangeFreezeWindow
+// @Router /api/v1/global/settings/change-freezes/{window_id} [get]
+func (h *ChangeFreezeHandler) GetChangeFreezeWindow(c *gin.Context) {
+	window, err := h.service.GetWindow(c.Param("window_id"))
+	if err != nil {
+		respondChangeFreezeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, window)
+}
+
+// CreateChangeFreezeWindow creates a change freeze window
+// @Summary Create change freeze window
+// @Description Scope is organization (all workspaces) or project (selector.project_ids); selector.tags further restricts by workspace tags. Schedule is either a once date range or a weekly rule, evaluated in the window timezone.
+// @Tags Change Freeze
+// @Accept json
+// @Produce json
+// @Param body body models.ChangeFreezeWindowRequest true "Window"
+// @Success 201 {object} models.ChangeFreezeWindow
+// @Router /api/v1/global/settings/change-freezes [post]
+func (h *ChangeFreezeHandler) CreateChangeFreezeWindow(c *gin.Context) {
+	var req models.ChangeFreezeWindowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request parameters: " + err.Error()})
+		return
+	}
+
+	window, err := h.service.CreateWindow(&req, c.GetString("user_id"))
+	if err != nil {
+		respondChangeFreezeError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, window)
+}
+
+// UpdateChangeFreezeWindow updates a change freeze window
+// @Summary Update change freeze window
+// @Tags Change Freeze
+// @Accept json
+// @Produce json
+// @Param window_id path string true "Window ID"
+// @Param body body models.ChangeFreezeWindowRequest true "Window"
+// @Success 200 {object} models.ChangeFreezeWindow
+// @Router /api/v1/global/settings/change-freezes/{window_id} [put]
+func (h *ChangeFreezeHandler) UpdateChangeFreezeWindow(c *gin.Context) {
+	var req models.ChangeFreezeWindowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request parameters: " + err.Error()})
+		return
+	}
+
+	window, err := h.service.UpdateWindow(c.Param("window_id"), &req, c.GetString("user_id"))
+	if err != nil {
+		respondChangeFreezeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, window)
+}
+
+// DeleteChangeFreezeWindow deletes a change freeze window
+// @Summary Delete change freeze window
+// @Tags Change Freeze
+// @Produce json
+// @Param window_id path string true "Window ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/global/settings/change-freezes/{window_id} [delete]
+func (h *ChangeFreezeHandler) DeleteChangeFreezeWindow(c *gin.Context) {
+	if err := h.service.DeleteWindow(c.Param("window_id")); err != nil {
+		respondChangeFreezeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Change freeze window deleted"})
+}
+
+// ListChangeFreezeExceptions lists per-run exception requests
+// @Summary List change freeze exceptions
+// @Tags Change Freeze
+// @Produce json
+// @Param status query string false "pending, approved or rejected"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/global/settings/change-freeze-exceptions [get]
+func (h *ChangeFreezeHandler) ListChangeFreezeExceptions(c *gin.Context) {
+	exceptions, err := h.service.ListExceptions(c.Query("status"))
+	if err != nil {
+		respondChangeFreezeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": exceptions, "total": len(exceptions)})
+}
+
+// ApproveChangeFreezeException approves a pending exception; the held apply resumes on the next queue retry
+// @Summary Approve change freeze exception
+// @Tags Change Freeze
+// @Accept json
+// @Produce json
+// @Param exception_id path int true "Exception ID"
+// @Param body body models.ReviewChangeFreezeExceptionRequest false "Review comment"
+// @Success 200 {object} models.ChangeFreezeException
+// @Router /api/v1/global/settings/change-freeze-exceptions/{exception_id}/approve [post]
+func (h *ChangeFreezeHandler) ApproveChangeFreezeException(c *gin.Context) {
+	h.reviewException(c, true)
+}
+
+// RejectChangeFreezeException rejects a pending exception
+// @Summary Reject change freeze exception
+// @Tags Change Freeze
+// @Accept json
+// @Produce json
+// @Param exception_id path int true "Exception ID"
+// @Param body body models.ReviewChangeFreezeExceptionRequest false "Review comment"
+// @Success 200 {object} models.ChangeFreezeException
+// @Router /api/v1/global/settings/change-freeze-exceptions/{exception_id}/reject [post]
+func (h *ChangeFreezeHandler) RejectChangeFreezeException(c *gin.Context) {
+	h.reviewException(c, false)
+}
+
+func (h *ChangeFreezeHandler) reviewException(c *gin.Context, approve bool) {
+	id, err := strconv.ParseUint(c.Param("exception_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid exception_id"})
+		return
+	}
+	var req models.ReviewChangeFreezeExceptionRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request parameters: " + err.Error()})
+			return
+		}
+	}
+
+	exception, err := h.service.ReviewException(uint(id), approve, c.GetString("user_id"), req.Comment)
+	if err != nil {
+		respondChangeFreezeError(c, err)
+		return
+	}
+
+	eventType := models.AuditEventFreezeExceptionRejected
+	outcome := models.AuditOutcomeDenied
+	verb := "rejected"
+	if approve {
+		eventType = models.AuditEventFreezeExceptionApproved
+		outcome = models.AuditOutcomeSuccess
+		verb = "approved"
+	}
+	ev := middleware.AuditEventFromContext(c, eventType)
+	ev.ResourceType = "task"
+	ev.ResourceID = fmt.Sprintf("%d", exception.TaskID)
+	ev.WorkspaceID = exception.WorkspaceID
+	ev.TaskID = &exception.TaskID
+	ev.Outcome = outcome
+	ev.Message = fmt.Sprintf("Change freeze exception #%d for task #%d %s", exception.ID, exception.TaskID, verb)
+	ev.Details = models.JSONB{
+		"exception_id": exception.ID,
+		"requested_by": exception.RequestedBy,
+		"reason":       exception.Reason,
+		"window_ids":   exception.WindowIDs,
+		"comment":      req.Comment,
+	}
+	services.NewAuditEventService(h.db).Emit(ev)
+
+	c.JSON(http.StatusOK, exception)
+}
+
+// GetWorkspaceChangeFreezes lists the change freezes currently active for a workspace
+// @Summary Get active change freezes of a workspace
+// @Tags Change Freeze
+// @Produce json
+// @Param id path string true "Workspace ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/workspaces/{id}/change-freezes [get]
+func (h *ChangeFreezeHandler) GetWorkspaceChangeFreezes(c *gin.Context) {
+	var workspace models.Workspace
+	if err := h.db.Where("workspace_id = ?", c.Param("id")).First(&workspace).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "workspace not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get workspace"})
+		return
+	}
+
+	active, err := h.service.ActiveFreezes(&workspace, time.Now())
+	if err != nil {
+		respondChangeFreezeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"frozen": len(active) > 0, "active": active})
+}
+
+// GetTaskChangeFreeze gets the active freezes and the latest exception request of a task
+// @Summary Get task change freeze status
+// @Tags Change Freeze
+// @Produce json
+// @Param id path string true "Workspace ID"
+// @Param task_id path int true "Task ID"
+// @Success 200 {object} services.TaskFreezeStatus
+// @Router /api/v1/workspaces/{id}/tasks/{task_id}/change-freeze [get]
+func (h *ChangeFreezeHandler) GetTaskChangeFreeze(c *gin.Context) {
+	task, workspace, ok := h.loadTask(c)
+	if !ok {
+		return
+	}
+
+	status, err := h.service.GetTaskFreezeStatus(task, workspace)
+	if err != nil {
+		respondChangeFreezeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// RequestChangeFreezeException requests a one-off exception to apply this run during a change freeze
+// @Summary Request change freeze exception
+// @Tags Change Freeze
+// @Accept json
+// @Produce json
+// @Param id path string true "Workspace ID"
+// @Param task_id path int true "Task ID"
+// @Param body body models.ChangeFreezeExceptionRequest true "Reason"
+// @Success 201 {object} models.ChangeFreezeException
+// @Router /api/v1/workspaces/{id}/tasks/{task_id}/change-freeze/exception [post]
+func (h *ChangeFreezeHandler) RequestChangeFreezeException(c *gin.Context) {
+	var req models.ChangeFreezeExceptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reason is required"})
+		return
+	}
+	task, workspace, ok := h.loadTask(c)
+	if !ok {
+		return
+	}
+
+	exception, active, err := h.service.RequestException(task, workspace, req.Reason, c.GetString("user_id"))
+	if err != nil {
+		respondChangeFreezeError(c, err)
+		return
+	}
+
+	ev := middleware.AuditEventFromContext(c, models.AuditEventFreezeExceptionRequested)
+	ev.ResourceType = "task"
+	ev.ResourceID = fmt.Sprintf("%d", task.ID)
+	ev.WorkspaceID = workspace.WorkspaceID
+	ev.TaskID = &task.ID
+	ev.Message = fmt.Sprintf("Change freeze exception requested for task #%d", task.ID)
+	ev.Details = models.JSONB{
+		"exception_id": exception.ID,
+		"reason":       exception.Reason,
+		"window_ids":   exception.WindowIDs,
+		"active_count": len(active),
+	}
+	services.NewAuditEventService(h.db).Emit(ev)
+
+	c.JSON(http.StatusCreated, exception)
+}
+
+func (h *ChangeFreezeHandler) loadTask(c *gin.Context) (*models.WorkspaceTask, *models.Workspace, bool) {
+	taskID, err := strconv.ParseUint(c.Param("task_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task_id format"})
+		return nil, nil, false
+	}
+
+	var workspace models.Workspace
+	if err := h.db.Where("workspace_id = ?", c.Param("id")).First(&workspace).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "workspace not found"})
+			return nil, nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get workspace"})
+		return nil, nil, false
+	}
+
+	var task models.WorkspaceTask
+	if err := h.db.Where("id = ? AND workspace_id = ?", taskID, workspace.WorkspaceID).First(&task).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+			return nil, nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check task"})
+		return nil, nil, false
+	}
+	return &task, &workspace, true
+}
+
+func respondChangeFreezeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrChangeFreezeWindowNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Change freeze window not found"})
+	case errors.Is(err, services.ErrChangeFreezeExceptionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Change freeze exception not found"})
+	case errors.Is(err, services.ErrChangeFreezeSelfReview):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrChangeFreezeExceptionState):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrChangeFreezeInvalid):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Change freeze operation failed: " + err.Error()})
+	}
+}
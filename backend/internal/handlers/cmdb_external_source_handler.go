@@ -4,6 +4,7 @@ import (
 	"context"
 	"iac-platform/internal/models"
 	"iac-platform/services"
+	"io"
 	"net/http"
 	"strconv"
 
@@ -256,6 +257,71 @@ func (h *CMDBExternalSourceHandler) TestConnection(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 }
 
+// maxSourceFileSize 文件类型数据源上传文件的大小上限
+const maxSourceFileSize = 100 << 20
+
+// UploadSourceFile 上传文件数据源的导入文件
+// @Summary 上传外部数据源导入文件
+// @Description 为文件类型的外部CMDB数据源上传CSV/JSON/JSONL文件，替换之前上传的文件，之后的同步都使用该文件
+// @Tags CMDB External Sources
+// @Accept multipart/form-data
+// @Produce json
+// @Param source_id path string true "数据源ID"
+// @Param file formData file true "导入文件"
+// @Success 200 {object} models.ExternalSourceResponse
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/cmdb/external-sources/{source_id}/upload [post]
+func (h *CMDBExternalSourceHandler) UploadSourceFile(c *gin.Context) {
+	sourceID := c.Param("source_id")
+	if sourceID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "source_id is required"})
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+		return
+	}
+	if file.Size > maxSourceFileSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file exceeds 100MB limit"})
+		return
+	}
+
+	fileContent, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open file"})
+		return
+	}
+	defer fileContent.Close()
+
+	data, err := io.ReadAll(fileContent)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read file"})
+		return
+	}
+
+	userID := c.GetString("user_id")
+	if userID == "" {
+		userID = "system"
+	}
+
+	source, err := h.service.UploadSourceFile(c.Request.Context(), sourceID, file.Filename, data, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := source.ToResponse()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
 // GetSyncLogs 获取同步日志
 // @Summary 获取外部数据源同步日志
 // @Description 获取指定外部CMDB数据源的同步历史日志
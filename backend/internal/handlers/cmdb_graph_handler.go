@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"iac-platform/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CMDBGraphHandler 资源关系图处理器
+type CMDBGraphHandler struct {
+	service *services.CMDBGraphService
+	// canReadWorkspace 检查调用方能否读取指定 workspace；设置后计划影响分析只返回可读 workspace 中的明细
+	canReadWorkspace func(c *gin.Context, workspaceID string) (bool, error)
+}
+
+// NewCMDBGraphHandler 创建资源关系图处理器
+func NewCMDBGraphHandler(db *gorm.DB) *CMDBGraphHandler {
+	return &CMDBGraphHandler{
+		service: services.NewCMDBGraphService(db),
+	}
+}
+
+// SetWorkspaceAccessChecker 设置 workspace 读取权限检查
+func (h *CMDBGraphHandler) SetWorkspaceAccessChecker(fn func(c *gin.Context, workspaceID string) (bool, error)) {
+	h.canReadWorkspace = fn
+}
+
+// GetResourceGraph 获取资源关系图
+// @Summary 获取跨workspace资源关系图
+// @Description 由资源属性中引用的ID/ARN推导资源依赖边，并包含远程数据和Run Trigger形成的workspace依赖；指定workspace_id时只返回与其相关的部分
+// @Tags CMDB
+// @Produce json
+// @Param workspace_id query string false "Workspace ID"
+// @Success 200 {object} services.ResourceGraph
+// @Router /api/v1/cmdb/graph [get]
+func (h *CMDBGraphHandler) GetResourceGraph(c *gin.Context) {
+	graph, err := h.service.BuildGraph(c.Query("workspace_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, graph)
+}
+
+// AnalyzeImpact 资源影响分析
+// @Summary 资源影响分析
+// @Description 替换或删除指定资源时，哪些资源和workspace依赖它
+// @Tags CMDB
+// @Produce json
+// @Param workspace_id query string false "Workspace ID（按address查询时必填）"
+// @Param address query string false "Terraform地址"
+// @Param cloud_resource_id query string false "云资源ID或ARN"
+// @Param depth query int false "最大传播深度，默认10"
+// @Success 200 {object} services.ImpactReport
+// @Router /api/v1/cmdb/impact [get]
+func (h *CMDBGraphHandler) AnalyzeImpact(c *gin.Context) {
+	var target services.ImpactTarget
+	if err := c.ShouldBindQuery(&target); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if target.CloudResourceID == "" && (target.TerraformAddress == "" || target.WorkspaceID == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "需要workspace_id+address或cloud_resource_id"})
+		return
+	}
+
+	depth, _ := strconv.Atoi(c.Query("depth"))
+	report, err := h.service.AnalyzeImpact([]services.ImpactTarget{target}, depth)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetTaskImpact 计划影响分析
+// @Summary 计划影响分析
+// @Description 对任务计划中删除或替换的资源做影响分析，用于计划审核；调用方无权读取的 workspace 只返回数量（hidden_resources / hidden_workspaces）
+// @Tags Workspace Task
+// @Produce json
+// @Param id path string true "工作空间ID"
+// @Param task_id path int true "任务ID"
+// @Success 200 {object} services.TaskImpactReport
+// @Router /api/v1/workspaces/{id}/tasks/{task_id}/impact [get]
+// @Security Bearer
+func (h *CMDBGraphHandler) GetTaskImpact(c *gin.Context) {
+	taskID, err := strconv.ParseUint(c.Param("task_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	report, err := h.service.AnalyzeTaskImpact(c.Param("id"), uint(taskID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 影响可能跨越调用方无权访问的 workspace，只返回其数量；权限检查出错时按无权限处理
+	if h.canReadWorkspace != nil {
+		report.FilterWorkspaces(func(workspaceID string) bool {
+			if workspaceID == c.Param("id") {
+				return true
+			}
+			allowed, err := h.canReadWorkspace(c, workspaceID)
+			if err != nil {
+				log.Printf("[CMDBGraph] Failed to check access to workspace %s: %v", workspaceID, err)
+			}
+			return err == nil && allowed
+		})
+	}
+
+	c.JSON(http.StatusOK, report)
+}
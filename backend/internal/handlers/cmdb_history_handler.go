@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"iac-platform/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CMDBHistoryHandler CMDB 资源历史处理器
+type CMDBHistoryHandler struct {
+	service *services.CMDBHistoryService
+}
+
+// NewCMDBHistoryHandler 创建 CMDB 资源历史处理器
+func NewCMDBHistoryHandler(db *gorm.DB) *CMDBHistoryHandler {
+	return &CMDBHistoryHandler{
+		service: services.NewCMDBHistoryService(db),
+	}
+}
+
+// GetResourceTimeline 获取资源属性变更时间线
+// @Summary 获取资源变更时间线
+// @Description 按 terraform address 或云资源ID查询资源在各次 State 版本中的字段级变化；指定 field 时只返回修改过该字段的变更（即哪次 apply 改了它）
+// @Tags CMDB
+// @Produce json
+// @Param workspace_id path string true "Workspace ID"
+// @Param address query string false "Terraform地址"
+// @Param cloud_resource_id query string false "云资源ID"
+// @Param field query string false "属性路径，如 tags.Name"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/cmdb/workspaces/{workspace_id}/resources/history [get]
+func (h *CMDBHistoryHandler) GetResourceTimeline(c *gin.Context) {
+	workspaceID := c.Param("workspace_id")
+	address := c.Query("address")
+	cloudResourceID := c.Query("cloud_resource_id")
+
+	if address == "" && cloudResourceID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "address和cloud_resource_id不能同时为空"})
+		return
+	}
+
+	entries, err := h.service.GetResourceTimeline(workspaceID, address, cloudResourceID, c.Query("field"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"entries": entries,
+		"total":   len(entries),
+	})
+}
+
+// GetResourceAsOf 获取资源在某个时间点的属性
+// @Summary 获取资源历史版本
+// @Description 返回资源在指定时间点（at，RFC3339）或指定 State 版本（state_version_id）时的完整属性，两者都不传时返回当前版本
+// @Tags CMDB
+// @Produce json
+// @Param workspace_id path string true "Workspace ID"
+// @Param address query string false "Terraform地址"
+// @Param cloud_resource_id query string false "云资源ID"
+// @Param at query string false "时间点（RFC3339）"
+// @Param state_version_id query int false "State版本ID"
+// @Success 200 {object} services.ResourceVersionView
+// @Router /api/v1/cmdb/workspaces/{workspace_id}/resources/as-of [get]
+func (h *CMDBHistoryHandler) GetResourceAsOf(c *gin.Context) {
+	workspaceID := c.Param("workspace_id")
+	address := c.Query("address")
+	cloudResourceID := c.Query("cloud_resource_id")
+
+	if address == "" && cloudResourceID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "address和cloud_resource_id不能同时为空"})
+		return
+	}
+
+	var at *time.Time
+	if atStr := c.Query("at"); atStr != "" {
+		parsed, err := time.Parse(time.RFC3339, atStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "at必须是RFC3339格式的时间"})
+			return
+		}
+		at = &parsed
+	}
+
+	var stateVersionID uint
+	if svStr := c.Query("state_version_id"); svStr != "" {
+		parsed, err := strconv.ParseUint(svStr, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的state_version_id"})
+			return
+		}
+		stateVersionID = uint(parsed)
+	}
+
+	version, err := h.service.GetResourceAsOf(workspaceID, address, cloudResourceID, at, stateVersionID)
+	if err != nil {
+		if errors.Is(err, services.ErrResourceVersionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "该时间点资源不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, version)
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"net/http"
+
+	"iac-platform/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CMDBReconciliationHandler CMDB 对账处理器
+type CMDBReconciliationHandler struct {
+	service *services.CMDBReconciliationService
+}
+
+// NewCMDBReconciliationHandler 创建 CMDB 对账处理器
+func NewCMDBReconciliationHandler(db *gorm.DB) *CMDBReconciliationHandler {
+	return &CMDBReconciliationHandler{
+		service: services.NewCMDBReconciliationService(db),
+	}
+}
+
+// GetReconciliationReport 获取对账报告
+// @Summary 获取CMDB对账报告
+// @Description 对比外部数据源清单与Terraform state：未被任何workspace管理的资源、被多个workspace管理的资源、外部清单中不存在的Terraform资源，按账户/区域/资源类型汇总
+// @Tags CMDB
+// @Produce json
+// @Param cloud_provider query string false "云提供商"
+// @Param account_id query string false "云账户ID"
+// @Param region query string false "区域"
+// @Param resource_type query string false "资源类型"
+// @Success 200 {object} services.ReconciliationReport
+// @Router /api/v1/cmdb/reconciliation [get]
+func (h *CMDBReconciliationHandler) GetReconciliationReport(c *gin.Context) {
+	var filter services.ReconciliationFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := h.service.GenerateReport(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// ImportUnmanagedResources 将未管理的外部资源导入workspace
+// @Summary 导入未管理的资源
+// @Description 为对账报告中未管理的外部资源生成 import 块和资源骨架并添加到workspace，dry_run=true 时只返回TF代码。资源在下一次 apply 时导入state
+// @Tags CMDB
+// @Accept json
+// @Produce json
+// @Param request body services.ReconciliationImportRequest true "导入请求"
+// @Success 200 {object} services.ReconciliationImportResult
+// @Router /api/v1/cmdb/reconciliation/import [post]
+func (h *CMDBReconciliationHandler) ImportUnmanagedResources(c *gin.Context) {
+	var req services.ReconciliationImportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.service.ImportUnmanaged(&req, c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"iac-platform/internal/models"
+	"iac-platform/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CostEstimateHandler handles task cost estimate HTTP requests
+type CostEstimateHandler struct {
+	db      *gorm.DB
+	service *services.CostEstimationService
+}
+
+// NewCostEstimateHandler creates a new cost estimate handler
+func NewCostEstimateHandler(db *gorm.DB) *CostEstimateHandler {
+	return &CostEstimateHandler{
+		db:      db,
+		service: services.NewCostEstimationService(db),
+	}
+}
+
+// GetTaskCostEstimate gets the cost estimate of a task's plan
+// @Summary Get task cost estimate
+// @Description Get monthly before/after/delta cost per resource and per task, and the budget evaluation
+// @Tags Workspace
+// @Produce json
+// @Param workspace_id path string true "Workspace ID"
+// @Param task_id path string true "Task ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/workspaces/{workspace_id}/tasks/{task_id}/cost-estimate [get]
+func (h *CostEstimateHandler) GetTaskCostEstimate(c *gin.Context) {
+	workspaceID := c.Param("id")
+
+	var taskID uint
+	if _, err := fmt.Sscanf(c.Param("task_id"), "%d", &taskID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task_id format"})
+		return
+	}
+
+	// Verify task exists and belongs to workspace
+	var task models.WorkspaceTask
+	if err := h.db.Select("id").Where("id = ? AND workspace_id = ?", taskID, workspaceID).First(&task).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check task"})
+		return
+	}
+
+	estimate, err := h.service.GetEstimate(taskID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve cost estimate"})
+		return
+	}
+	if estimate == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "cost estimate not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"task_id":  taskID,
+		"estimate": estimate,
+		"summary":  services.CostEstimateSummary(estimate),
+	})
+}
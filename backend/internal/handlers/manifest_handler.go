@@ -3,18 +3,16 @@ package handlers
 import (
 	"archive/zip"
 	"bytes"
-	"crypto/sha256"
-	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
-	"regexp"
 	"strconv"
-	"strings"
 	"time"
 
 	"iac-platform/internal/models"
+	"iac-platform/internal/parsers"
 	"iac-platform/services"
 
 	"github.com/gin-gonic/gin"
@@ -524,6 +522,8 @@ func (h *ManifestHandler) SaveManifestDraft(c *gin.Context) {
 			Nodes:      req.Nodes,
 			Edges:      req.Edges,
 			Variables:  req.Variables,
+			Locals:     req.Locals,
+			Outputs:    req.Outputs,
 			IsDraft:    true,
 			CreatedBy:  userID,
 		}
@@ -540,6 +540,13 @@ func (h *ManifestHandler) SaveManifestDraft(c *gin.Context) {
 		draftVersion.Nodes = req.Nodes
 		draftVersion.Edges = req.Edges
 		draftVersion.Variables = req.Variables
+		// Canvas clients that don't edit locals/outputs omit them; keep the imported values
+		if req.Locals != nil {
+			draftVersion.Locals = req.Locals
+		}
+		if req.Outputs != nil {
+			draftVersion.Outputs = req.Outputs
+		}
 		if err := h.db.Save(&draftVersion).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save draft: " + err.Error()})
 			return
@@ -609,6 +616,8 @@ func (h *ManifestHandler) PublishManifestVersion(c *gin.Context) {
 		Nodes:      draftVersion.Nodes,
 		Edges:      draftVersion.Edges,
 		Variables:  draftVersion.Variables,
+		Locals:     draftVersion.Locals,
+		Outputs:    draftVersion.Outputs,
 		HCLContent: draftVersion.HCLContent,
 		IsDraft:    false,
 		CreatedBy:  userID,
@@ -617,9 +626,9 @@ func (h *ManifestHandler) PublishManifestVersion(c *gin.Context) {
 	err := h.db.Transaction(func(tx *gorm.DB) error {
 		// Use raw SQL to insert, ensuring is_draft is correctly set to false
 		if err := tx.Exec(`
-			INSERT INTO manifest_versions (id, manifest_id, version, canvas_data, nodes, edges, variables, hcl_content, is_draft, created_by, created_at)
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?, false, ?, NOW())
-		`, newVersion.ID, newVersion.ManifestID, newVersion.Version, newVersion.CanvasData, newVersion.Nodes, newVersion.Edges, newVersion.Variables, newVersion.HCLContent, newVersion.CreatedBy).Error; err != nil {
+			INSERT INTO manifest_versions (id, manifest_id, version, canvas_data, nodes, edges, variables, locals, outputs, hcl_content, is_draft, created_by, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, false, ?, NOW())
+		`, newVersion.ID, newVersion.ManifestID, newVersion.Version, newVersion.CanvasData, newVersion.Nodes, newVersion.Edges, newVersion.Variables, newVersion.Locals, newVersion.Outputs, newVersion.HCLContent, newVersion.CreatedBy).Error; err != nil {
 			return err
 		}
 
@@ -1004,18 +1013,16 @@ func (h *ManifestHandler) UpdateManifestDeployment(c *gin.Context) {
 		return
 	}
 
-	// Update version
+	// Version changes go through the upgrade flow (diff, conflict check, single plan+apply task)
 	if req.VersionID != "" && req.VersionID != deployment.VersionID {
-		var version models.ManifestVersion
-		if err := h.db.Where("id = ? AND manifest_id = ?", req.VersionID, manifestID).First(&version).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Version not found"})
+		if req.VariableOverrides != nil {
+			if err := h.db.Model(&deployment).Update("variable_overrides", req.VariableOverrides).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update deployment: " + err.Error()})
 				return
 			}
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Query failed: " + err.Error()})
-			return
 		}
-		deployment.VersionID = req.VersionID
+		h.runDeploymentUpgrade(c, deployment, req.VersionID, models.UpgradeStrategyFail, userID, "Manifest upgrade")
+		return
 	}
 
 	// Update variable overrides
@@ -1033,8 +1040,6 @@ func (h *ManifestHandler) UpdateManifestDeployment(c *gin.Context) {
 		return
 	}
 
-	// TODO: Trigger actual deployment update
-
 	c.JSON(http.StatusOK, deployment)
 }
 
@@ -1192,6 +1197,187 @@ func (h *ManifestHandler) UninstallManifestDeployment(c *gin.Context) {
 	h.DeleteManifestDeployment(c)
 }
 
+// ========== Deployment Upgrade ==========
+
+// PreviewManifestDeploymentUpgrade previews upgrading a deployment to another version
+// @Summary Preview deployment upgrade
+// @Description Three-way diff between the deployed version, the target version and local edits in the workspace. Without version_id the previous version (rollback target) is used.
+// @Tags Manifest
+// @Produce json
+// @Param org_id path string true "Organization ID"
+// @Param id path string true "Manifest ID"
+// @Param deployment_id path string true "Deployment ID"
+// @Param version_id query string false "Target version ID (default previous version)"
+// @Success 200 {object} models.ManifestUpgradePlan
+// @Router /api/v1/organizations/{org_id}/manifests/{id}/deployments/{deployment_id}/upgrade-preview [get]
+func (h *ManifestHandler) PreviewManifestDeploymentUpgrade(c *gin.Context) {
+	deployment, ok := h.getOrgDeployment(c)
+	if !ok {
+		return
+	}
+
+	upgradeService := services.NewManifestUpgradeService(h.db)
+	versionID := c.Query("version_id")
+	if versionID == "" {
+		previousID, err := upgradeService.RollbackVersionID(deployment.ID)
+		if err != nil {
+			respondManifestUpgradeError(c, nil, err)
+			return
+		}
+		versionID = previousID
+	}
+
+	plan, err := upgradeService.PlanUpgrade(deployment.ID, versionID)
+	if err != nil {
+		respondManifestUpgradeError(c, nil, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+// UpgradeManifestDeployment upgrades a deployment to another published version
+// @Summary Upgrade deployment
+// @Description Applies the three-way diff and creates a single plan+apply task. conflict_strategy: fail (default), overwrite, keep_local
+// @Tags Manifest
+// @Accept json
+// @Produce json
+// @Param org_id path string true "Organization ID"
+// @Param id path string true "Manifest ID"
+// @Param deployment_id path string true "Deployment ID"
+// @Param body body models.UpgradeManifestDeploymentRequest true "Upgrade request"
+// @Success 200 {object} models.ManifestUpgradePlan
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/organizations/{org_id}/manifests/{id}/deployments/{deployment_id}/upgrade [post]
+func (h *ManifestHandler) UpgradeManifestDeployment(c *gin.Context) {
+	deployment, ok := h.getOrgDeployment(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpgradeManifestDeploymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request parameters: " + err.Error()})
+		return
+	}
+
+	h.runDeploymentUpgrade(c, *deployment, req.VersionID, req.ConflictStrategy, c.GetString("user_id"), "Manifest upgrade")
+}
+
+// RollbackManifestDeployment rolls a deployment back to its previous version
+// @Summary Rollback deployment
+// @Description Rolls back to the version deployed before the last upgrade, using the same diff and conflict handling as upgrade
+// @Tags Manifest
+// @Accept json
+// @Produce json
+// @Param org_id path string true "Organization ID"
+// @Param id path string true "Manifest ID"
+// @Param deployment_id path string true "Deployment ID"
+// @Param body body models.RollbackManifestDeploymentRequest false "Rollback request"
+// @Success 200 {object} models.ManifestUpgradePlan
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/organizations/{org_id}/manifests/{id}/deployments/{deployment_id}/rollback [post]
+func (h *ManifestHandler) RollbackManifestDeployment(c *gin.Context) {
+	deployment, ok := h.getOrgDeployment(c)
+	if !ok {
+		return
+	}
+
+	var req models.RollbackManifestDeploymentRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request parameters: " + err.Error()})
+			return
+		}
+	}
+
+	previousID, err := services.NewManifestUpgradeService(h.db).RollbackVersionID(deployment.ID)
+	if err != nil {
+		respondManifestUpgradeError(c, nil, err)
+		return
+	}
+
+	h.runDeploymentUpgrade(c, *deployment, previousID, req.ConflictStrategy, c.GetString("user_id"), "Manifest rollback")
+}
+
+// runDeploymentUpgrade applies the upgrade and creates the plan+apply task
+func (h *ManifestHandler) runDeploymentUpgrade(c *gin.Context, deployment models.ManifestDeployment, versionID, strategy, userID, action string) {
+	plan, err := services.NewManifestUpgradeService(h.db).ApplyUpgrade(deployment.ID, versionID, strategy, userID)
+	if err != nil {
+		respondManifestUpgradeError(c, plan, err)
+		return
+	}
+
+	var workspace models.Workspace
+	if err := h.db.Where("id = ?", deployment.WorkspaceID).First(&workspace).Error; err != nil {
+		h.db.Model(&deployment).Update("status", models.DeploymentStatusFailed)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get Workspace: " + err.Error()})
+		return
+	}
+
+	taskID, err := h.createPlanAndApplyTask(workspace, userID, fmt.Sprintf("%s: %s (%s -> %s)", action, deployment.ID, plan.FromVersion, plan.ToVersion))
+	if err != nil {
+		log.Printf("[Manifest] Failed to create plan+apply task for deployment %s: %v", deployment.ID, err)
+		h.db.Model(&deployment).Update("status", models.DeploymentStatusFailed)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create task: " + err.Error(), "plan": plan})
+		return
+	}
+
+	// 部署保持 deploying，任务结束后由 ManifestRolloutWorker 同步为 deployed/failed
+	h.db.Model(&deployment).Updates(map[string]interface{}{
+		"status":       models.DeploymentStatusDeploying,
+		"last_task_id": taskID,
+	})
+	plan.TaskID = &taskID
+
+	log.Printf("[Manifest] %s of deployment %s (%s -> %s) created task %d", action, deployment.ID, plan.FromVersion, plan.ToVersion, taskID)
+	c.JSON(http.StatusOK, plan)
+}
+
+// getOrgDeployment loads the deployment from path parameters, verifying it belongs to the organization's manifest
+func (h *ManifestHandler) getOrgDeployment(c *gin.Context) (*models.ManifestDeployment, bool) {
+	var manifest models.Manifest
+	if err := h.db.Where("id = ? AND organization_id = ?", c.Param("id"), c.Param("org_id")).First(&manifest).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Manifest not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Query failed: " + err.Error()})
+		return nil, false
+	}
+
+	var deployment models.ManifestDeployment
+	if err := h.db.Where("id = ? AND manifest_id = ?", c.Param("deployment_id"), manifest.ID).First(&deployment).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Deployment not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Query failed: " + err.Error()})
+		return nil, false
+	}
+	return &deployment, true
+}
+
+// respondManifestUpgradeError maps upgrade errors to status codes; conflicts return the plan
+func respondManifestUpgradeError(c *gin.Context, plan *models.ManifestUpgradePlan, err error) {
+	switch {
+	case errors.Is(err, services.ErrManifestUpgradeConflict):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   err.Error(),
+			"plan":    plan,
+			"message": "Local edits conflict with the target version. Retry with conflict_strategy overwrite or keep_local.",
+		})
+	case errors.Is(err, services.ErrManifestDeploymentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Deployment not found"})
+	case errors.Is(err, services.ErrManifestVersionNotFound):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Version not found"})
+	case errors.Is(err, services.ErrManifestUpgradeInvalid):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Upgrade failed: " + err.Error()})
+	}
+}
+
 // ========== Workspace Perspective ==========
 
 // GetWorkspaceManifestDeployment retrieves Workspace's Manifest deployment
@@ -1297,6 +1483,8 @@ func (h *ManifestHandler) ExportManifestZip(c *gin.Context) {
 		"nodes":       version.Nodes,
 		"edges":       version.Edges,
 		"variables":   version.Variables,
+		"locals":      version.Locals,
+		"outputs":     version.Outputs,
 		"hcl_content": hclContent,
 		"exported_at": time.Now().Format(time.RFC3339),
 		"platform":    "iac-platform",
@@ -1372,6 +1560,8 @@ func (h *ManifestHandler) ImportManifestJSON(c *gin.Context) {
 		Nodes       json.RawMessage `json:"nodes"`
 		Edges       json.RawMessage `json:"edges"`
 		Variables   json.RawMessage `json:"variables"`
+		Locals      json.RawMessage `json:"locals"`
+		Outputs     json.RawMessage `json:"outputs"`
 		HCLContent  string          `json:"hcl_content"`
 	}
 	if err := json.Unmarshal(req.ManifestJSON, &manifestData); err != nil {
@@ -1417,6 +1607,8 @@ func (h *ManifestHandler) ImportManifestJSON(c *gin.Context) {
 			Nodes:      manifestData.Nodes,
 			Edges:      manifestData.Edges,
 			Variables:  manifestData.Variables,
+			Locals:     manifestData.Locals,
+			Outputs:    manifestData.Outputs,
 			HCLContent: manifestData.HCLContent,
 			IsDraft:    true,
 			CreatedBy:  userID,
@@ -1491,7 +1683,6 @@ func (h *ManifestHandler) ExportManifestHCL(c *gin.Context) {
 		return
 	}
 
-	// TODO: Generate HCL based on nodes and edges
 	hcl := generateHCL(manifest.Name, version)
 
 	c.Header("Content-Type", "text/plain")
@@ -1500,118 +1691,25 @@ func (h *ManifestHandler) ExportManifestHCL(c *gin.Context) {
 }
 
 // generateHCL generates HCL content
+// Variables, locals, modules and outputs are emitted with the same value conventions
+// used by ImportManifestHCL, so an imported manifest exports without losing information.
 func generateHCL(manifestName string, version models.ManifestVersion) string {
-	// Parse nodes and edges
 	var nodes []models.ManifestNode
 	var variables []models.ManifestVariable
+	var locals []models.ManifestLocal
+	var outputs []models.ManifestOutput
 
 	_ = json.Unmarshal(version.Nodes, &nodes)
 	_ = json.Unmarshal(version.Variables, &variables)
-
-	hcl := fmt.Sprintf("# Generated by IaC Platform Manifest Builder\n# Manifest: %s\n# Version: %s\n\n", manifestName, version.Version)
-
-	// Generate variable definitions
-	for _, v := range variables {
-		hcl += fmt.Sprintf("variable \"%s\" {\n", v.Name)
-		hcl += fmt.Sprintf("  type = %s\n", v.Type)
-		if v.Description != "" {
-			hcl += fmt.Sprintf("  description = \"%s\"\n", v.Description)
-		}
-		if v.Default != nil {
-			hcl += fmt.Sprintf("  default = %v\n", v.Default)
-		}
-		hcl += "}\n\n"
+	if len(version.Locals) > 0 {
+		_ = json.Unmarshal(version.Locals, &locals)
 	}
-
-	// Generate module blocks
-	for _, node := range nodes {
-		if node.Type != models.NodeTypeModule {
-			continue
-		}
-
-		hcl += fmt.Sprintf("module \"%s\" {\n", node.InstanceName)
-		if node.ModuleSource != "" {
-			hcl += fmt.Sprintf("  source  = \"%s\"\n", node.ModuleSource)
-		}
-		if node.ModuleVersion != "" {
-			hcl += fmt.Sprintf("  version = \"%s\"\n", node.ModuleVersion)
-		}
-
-		// Add config parameters
-		for key, value := range node.Config {
-			hcl += fmt.Sprintf("  %s = %v\n", key, formatHCLValue(value))
-		}
-
-		hcl += "}\n\n"
+	if len(version.Outputs) > 0 {
+		_ = json.Unmarshal(version.Outputs, &outputs)
 	}
 
-	return hcl
-}
-
-// formatHCLValue formats HCL value
-func formatHCLValue(value interface{}) string {
-	switch v := value.(type) {
-	case string:
-		// Check if it's a Terraform reference (var.xxx, local.xxx, module.xxx, data.xxx)
-		// Use "${...}" interpolation syntax for references
-		if strings.HasPrefix(v, "var.") || strings.HasPrefix(v, "local.") ||
-			strings.HasPrefix(v, "module.") || strings.HasPrefix(v, "data.") {
-			return fmt.Sprintf("\"${%s}\"", v) // Use interpolation syntax
-		}
-		return fmt.Sprintf("\"%s\"", v)
-	case bool:
-		return fmt.Sprintf("%t", v)
-	case float64:
-		return fmt.Sprintf("%v", v)
-	case int:
-		return fmt.Sprintf("%d", v)
-	case []interface{}:
-		// Array type
-		if len(v) == 0 {
-			return "[]"
-		}
-		items := make([]string, len(v))
-		for i, item := range v {
-			items[i] = formatHCLValue(item)
-		}
-		return fmt.Sprintf("[%s]", strings.Join(items, ", "))
-	case []string:
-		// String array
-		if len(v) == 0 {
-			return "[]"
-		}
-		items := make([]string, len(v))
-		for i, item := range v {
-			items[i] = fmt.Sprintf("\"%s\"", item)
-		}
-		return fmt.Sprintf("[%s]", strings.Join(items, ", "))
-	case map[string]interface{}:
-		// map/object type - format as multi-line
-		if len(v) == 0 {
-			return "{}"
-		}
-		items := make([]string, 0, len(v))
-		for key, val := range v {
-			items = append(items, fmt.Sprintf("    %s = %s", key, formatHCLValue(val)))
-		}
-		return fmt.Sprintf("{\n%s\n  }", strings.Join(items, "\n"))
-	default:
-		// Other types try JSON serialization
-		data, err := json.Marshal(v)
-		if err != nil {
-			return fmt.Sprintf("\"%v\"", v)
-		}
-		// Try to parse as map or array
-		var mapVal map[string]interface{}
-		if json.Unmarshal(data, &mapVal) == nil {
-			return formatHCLValue(mapVal)
-		}
-		var arrVal []interface{}
-		if json.Unmarshal(data, &arrVal) == nil {
-			return formatHCLValue(arrVal)
-		}
-		return string(data)
-	}
+	header := fmt.Sprintf("# Generated by IaC Platform Manifest Builder\n# Manifest: %s\n# Version: %s\n\n", manifestName, version.Version)
+	return parsers.GenerateManifestHCL(header, nodes, variables, locals, outputs)
 }
 
 // ImportManifestHCL imports HCL
@@ -1649,12 +1747,16 @@ func (h *ManifestHandler) ImportManifestHCL(c *gin.Context) {
 		return
 	}
 
-	// Parse HCL content to extract modules, variables, and edges
-	nodes, variables, edges, err := h.parseHCLContent(req.HCLContent)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse HCL: " + err.Error()})
+	// Parse HCL content to extract modules, variables, locals, outputs, and edges
+	parsed, diags := h.parseHCLContent(req.HCLContent)
+	if parsed == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse HCL", "diagnostics": diags})
 		return
 	}
+	for _, d := range diags {
+		log.Printf("[Manifest Import] %s at line %d: %s: %s", d.Severity, d.Line, d.Summary, d.Detail)
+	}
+	nodes, variables, edges := parsed.Nodes, parsed.Variables, parsed.Edges
 
 	// Create Manifest
 	manifest := models.Manifest{
@@ -1670,6 +1772,8 @@ func (h *ManifestHandler) ImportManifestHCL(c *gin.Context) {
 	nodesJSON, _ := json.Marshal(nodes)
 	variablesJSON, _ := json.Marshal(variables)
 	edgesJSON, _ := json.Marshal(edges)
+	localsJSON, _ := json.Marshal(parsed.Locals)
+	outputsJSON, _ := json.Marshal(parsed.Outputs)
 
 	err = h.db.Transaction(func(tx *gorm.DB) error {
 		if err := tx.Create(&manifest).Error; err != nil {
@@ -1685,6 +1789,8 @@ func (h *ManifestHandler) ImportManifestHCL(c *gin.Context) {
 			Nodes:      nodesJSON,
 			Edges:      edgesJSON,
 			Variables:  variablesJSON,
+			Locals:     localsJSON,
+			Outputs:    outputsJSON,
 			HCLContent: req.HCLContent,
 			IsDraft:    true,
 			CreatedBy:  userID,
@@ -1700,96 +1806,62 @@ func (h *ManifestHandler) ImportManifestHCL(c *gin.Context) {
 	c.JSON(http.StatusCreated, manifest)
 }
 
-// parseHCLContent parses HCL content and extracts modules, variables, and edges
-func (h *ManifestHandler) parseHCLContent(hclContent string) ([]models.ManifestNode, []models.ManifestVariable, []models.ManifestEdge, error) {
-	nodes := []models.ManifestNode{}
-	variables := []models.ManifestVariable{}
-	edges := []models.ManifestEdge{}
-
-	lines := strings.Split(hclContent, "\n")
-
-	var currentBlock string
-	var currentName string
-	var blockContent strings.Builder
-	var braceCount int
-	var inBlock bool
-
-	for _, line := range lines {
-		trimmedLine := strings.TrimSpace(line)
-
-		// Skip comments and empty lines when not in a block
-		if !inBlock {
-			if trimmedLine == "" || strings.HasPrefix(trimmedLine, "#") || strings.HasPrefix(trimmedLine, "//") {
-				continue
-			}
-		}
-
-		// Detect block start
-		if !inBlock {
-			// Match: module "name" {
-			if strings.HasPrefix(trimmedLine, "module ") {
-				parts := strings.SplitN(trimmedLine, "\"", 3)
-				if len(parts) >= 2 {
-					currentBlock = "module"
-					currentName = parts[1]
-					inBlock = true
-					braceCount = strings.Count(trimmedLine, "{") - strings.Count(trimmedLine, "}")
-					blockContent.Reset()
-					blockContent.WriteString(line + "\n")
-					continue
-				}
-			}
-			// Match: variable "name" {
-			if strings.HasPrefix(trimmedLine, "variable ") {
-				parts := strings.SplitN(trimmedLine, "\"", 3)
-				if len(parts) >= 2 {
-					currentBlock = "variable"
-					currentName = parts[1]
-					inBlock = true
-					braceCount = strings.Count(trimmedLine, "{") - strings.Count(trimmedLine, "}")
-					blockContent.Reset()
-					blockContent.WriteString(line + "\n")
-					continue
-				}
-			}
-		}
-
-		// Inside a block
-		if inBlock {
-			blockContent.WriteString(line + "\n")
-			braceCount += strings.Count(line, "{") - strings.Count(line, "}")
-
-			// Block ended
-			if braceCount <= 0 {
-				inBlock = false
-				content := blockContent.String()
-
-				switch currentBlock {
-				case "module":
-					node := h.parseModuleBlock(currentName, content, len(nodes))
-					nodes = append(nodes, node)
-				case "variable":
-					variable := h.parseVariableBlock(currentName, content)
-					variables = append(variables, variable)
-				}
-
-				currentBlock = ""
-				currentName = ""
-			}
-		}
-	}
-
-	// Try to link modules to existing Module records in database
-	for i := range nodes {
-		if nodes[i].Type == models.NodeTypeModule && nodes[i].ModuleSource != "" {
-			h.tryLinkModule(&nodes[i])
-		}
-	}
-
-	// Extract module references and create edges
-	edges = h.extractModuleReferences(nodes)
+// parseHCLContent parses HCL content and extracts modules, variables, locals, outputs, and edges
+func (h *ManifestHandler) parseHCLContent(hclContent string) (*parsedManifestHCL, []parsers.ManifestHCLDiagnostic) {
+	parsed, diags := parsers.ParseManifestHCL([]byte(hclContent), "manifest.tf")
+	if parsers.HasManifestHCLErrors(diags) {
+		return nil, diags
+	}
+
+	result := &parsedManifestHCL{
+		Nodes:     make([]models.ManifestNode, 0, len(parsed.Modules)),
+		Variables: parsed.Variables,
+		Locals:    parsed.Locals,
+		Outputs:   parsed.Outputs,
+	}
+	if result.Variables == nil {
+		result.Variables = []models.ManifestVariable{}
+	}
+
+	for index, module := range parsed.Modules {
+		node := models.ManifestNode{
+			ID:            fmt.Sprintf("node-%s-%d", module.Name, index),
+			Type:          models.NodeTypeModule,
+			InstanceName:  module.Name,
+			ResourceName:  module.Name,
+			ModuleSource:  module.Source,
+			RawSource:     module.Source,
+			ModuleVersion: module.Version,
+			RawVersion:    module.Version,
+			Position: models.ManifestNodePosition{
+				X: float64(100 + (index%4)*300),
+				Y: float64(100 + (index/4)*200),
+			},
+			Config:         module.Config,
+			RawConfig:      module.Config,
+			ConfigComplete: false,
+			Ports:          []models.ManifestPort{},
+			IsLinked:       false,
+			LinkStatus:     "unlinked",
+		}
+		// Try to link modules to existing Module records in database
+		h.tryLinkModule(&node)
+		result.Nodes = append(result.Nodes, node)
+	}
+
+	// Create edges from the module references found by the parser
+	result.Edges = h.extractModuleReferences(result.Nodes, parsed.Modules)
+
+	return result, diags
+}
 
-	return nodes, variables, edges, nil
+// parsedManifestHCL is the manifest content extracted from imported HCL
+type parsedManifestHCL struct {
+	Nodes     []models.ManifestNode
+	Variables []models.ManifestVariable
+	Locals    []models.ManifestLocal
+	Outputs   []models.ManifestOutput
+	Edges     []models.ManifestEdge
 }
 
 // Binding represents a single parameter mapping in an edge
@@ -1799,103 +1871,81 @@ type Binding struct {
 	Expression   string `json:"expression"`
 }
 
-// extractModuleReferences scans node configs for module.xxx.xxx references and creates edges
-// Two nodes can only have one edge between them, but the edge can contain multiple bindings
-func (h *ManifestHandler) extractModuleReferences(nodes []models.ManifestNode) []models.ManifestEdge {
+// extractModuleReferences creates edges from the module references collected while parsing
+// Two nodes can only have one edge between them, but the edge can contain multiple bindings.
+// References without an output (depends_on = [module.xxx]) produce a dependency edge.
+func (h *ManifestHandler) extractModuleReferences(nodes []models.ManifestNode, modules []parsers.ManifestHCLModule) []models.ManifestEdge {
 	// Build maps for node lookup
-	instanceToNodeID := make(map[string]string)
-	nodeIDToNode := make(map[string]*models.ManifestNode)
+	instanceToNode := make(map[string]*models.ManifestNode)
 	for i := range nodes {
-		node := &nodes[i]
-		if node.Type == models.NodeTypeModule && node.InstanceName != "" {
-			instanceToNodeID[node.InstanceName] = node.ID
-			nodeIDToNode[node.ID] = node
-		}
+		instanceToNode[nodes[i].InstanceName] = &nodes[i]
 	}
 
-	// Map to track edges between node pairs: "sourceNodeID->targetNodeID" -> edge
+	// Edges between node pairs in creation order: "sourceNodeID->targetNodeID" -> edge
+	var edgeKeys []string
 	edgeMap := make(map[string]*models.ManifestEdge)
-	// Map to track bindings for each edge
 	bindingsMap := make(map[string][]Binding)
 
-	// Regex to match module.xxx.xxx references (with or without ${...} interpolation)
-	// Matches: module.xxx.yyy or ${module.xxx.yyy}
-	moduleRefRegex := regexp.MustCompile(`\$?\{?module\.([a-zA-Z_][a-zA-Z0-9_-]*)\.([a-zA-Z_][a-zA-Z0-9_]*)\}?`)
-
-	for _, node := range nodes {
-		if node.Type != models.NodeTypeModule {
+	for _, module := range modules {
+		target := instanceToNode[module.Name]
+		if target == nil {
 			continue
 		}
+		for _, ref := range module.References {
+			source := instanceToNode[ref.Module]
+			if source == nil {
+				log.Printf("[Manifest Import] Reference to unknown module: %s", ref.Module)
+				continue
+			}
+			if source.ID == target.ID {
+				continue
+			}
 
-		// Scan all config values for module references
-		for paramName, value := range node.Config {
-			valueStr := fmt.Sprintf("%v", value)
-			matches := moduleRefRegex.FindAllStringSubmatch(valueStr, -1)
-
-			for _, match := range matches {
-				if len(match) >= 3 {
-					sourceInstanceName := match[1]
-					outputName := match[2]
-
-					// Find source node ID
-					sourceNodeID, exists := instanceToNodeID[sourceInstanceName]
-					if !exists {
-						log.Printf("[Manifest Import] Reference to unknown module: %s", sourceInstanceName)
-						continue
-					}
-
-					// Create edge key for this node pair
-					edgeKey := fmt.Sprintf("%s->%s", sourceNodeID, node.ID)
-
-					// Create binding
-					binding := Binding{
-						SourceOutput: outputName,
-						TargetInput:  paramName,
-						Expression:   match[0], // e.g., "module.xxx.yyy"
-					}
-
-					// Check if edge already exists for this node pair
-					if _, exists := edgeMap[edgeKey]; !exists {
-						// Calculate best connection ports based on node positions
-						sourcePort, targetPort := h.calculateBestPorts(nodeIDToNode[sourceNodeID], &node)
-
-						// Create new edge with smart port selection
-						edge := &models.ManifestEdge{
-							ID:   fmt.Sprintf("edge-ref-%s-%s", sourceNodeID, node.ID),
-							Type: "variable_binding",
-							Source: models.ManifestEdgePoint{
-								NodeID: sourceNodeID,
-								PortID: sourcePort,
-							},
-							Target: models.ManifestEdgePoint{
-								NodeID: node.ID,
-								PortID: targetPort,
-							},
-						}
-						edgeMap[edgeKey] = edge
-						bindingsMap[edgeKey] = []Binding{}
-					}
-
-					// Add binding to this edge
-					bindingsMap[edgeKey] = append(bindingsMap[edgeKey], binding)
-					log.Printf("[Manifest Import] Added binding to edge %s: %s.%s -> %s",
-						edgeKey, sourceInstanceName, outputName, paramName)
+			edgeKey := fmt.Sprintf("%s->%s", source.ID, target.ID)
+			edge, exists := edgeMap[edgeKey]
+			if !exists {
+				// Calculate best connection ports based on node positions
+				sourcePort, targetPort := h.calculateBestPorts(source, target)
+				edge = &models.ManifestEdge{
+					ID:   fmt.Sprintf("edge-ref-%s-%s", source.ID, target.ID),
+					Type: models.EdgeTypeDependency,
+					Source: models.ManifestEdgePoint{
+						NodeID: source.ID,
+						PortID: sourcePort,
+					},
+					Target: models.ManifestEdgePoint{
+						NodeID: target.ID,
+						PortID: targetPort,
+					},
 				}
+				edgeMap[edgeKey] = edge
+				edgeKeys = append(edgeKeys, edgeKey)
+			}
+
+			if ref.Output == "" || ref.Attribute == "depends_on" {
+				continue
 			}
+			// Any output reference turns the edge into a variable binding
+			edge.Type = models.EdgeTypeVariableBinding
+			bindingsMap[edgeKey] = append(bindingsMap[edgeKey], Binding{
+				SourceOutput: ref.Output,
+				TargetInput:  ref.Attribute,
+				Expression:   ref.Expression,
+			})
+			log.Printf("[Manifest Import] Added binding to edge %s: %s -> %s", edgeKey, ref.Expression, ref.Attribute)
 		}
 	}
 
-	// Convert map to slice and serialize bindings
-	edges := make([]models.ManifestEdge, 0, len(edgeMap))
-	for edgeKey, edge := range edgeMap {
+	edges := make([]models.ManifestEdge, 0, len(edgeKeys))
+	for _, edgeKey := range edgeKeys {
+		edge := edgeMap[edgeKey]
 		// Serialize bindings to JSON and store in Expression field
-		bindings := bindingsMap[edgeKey]
-		if len(bindings) > 0 {
+		if bindings := bindingsMap[edgeKey]; len(bindings) > 0 {
 			bindingsJSON, _ := json.Marshal(bindings)
 			edge.Expression = string(bindingsJSON)
 		}
 		edges = append(edges, *edge)
-		log.Printf("[Manifest Import] Created edge: %s with %d bindings", edge.ID, len(bindings))
+		log.Printf("[Manifest Import] Created %s edge: %s with %d bindings", edge.Type, edge.ID, len(bindingsMap[edgeKey]))
 	}
 
 	return edges
@@ -1969,330 +2019,15 @@ func (h *ManifestHandler) tryLinkModule(node *models.ManifestNode) {
 	log.Printf("[Manifest Import] No matching module found for source: %s", node.ModuleSource)
 }
 
-// parseModuleBlock parses a module block and returns a ManifestNode
-func (h *ManifestHandler) parseModuleBlock(name string, content string, index int) models.ManifestNode {
-	node := models.ManifestNode{
-		ID:           fmt.Sprintf("node-%s-%d", name, index),
-		Type:         models.NodeTypeModule,
-		InstanceName: name,
-		ResourceName: name,
-		Position: models.ManifestNodePosition{
-			X: float64(100 + (index%4)*300),
-			Y: float64(100 + (index/4)*200),
-		},
-		Config:         make(map[string]interface{}),
-		ConfigComplete: false,
-		Ports:          []models.ManifestPort{},
-		IsLinked:       false,
-		LinkStatus:     "unlinked",
-	}
-
-	lines := strings.Split(content, "\n")
-	var currentKey string
-	var valueBuilder strings.Builder
-	var braceDepth int
-	var bracketDepth int
-	var inMultiLineValue bool
-
-	for _, line := range lines {
-		trimmedLine := strings.TrimSpace(line)
-
-		// If we're collecting a multi-line value
-		if inMultiLineValue {
-			valueBuilder.WriteString(line + "\n")
-			braceDepth += strings.Count(line, "{") - strings.Count(line, "}")
-			bracketDepth += strings.Count(line, "[") - strings.Count(line, "]")
-
-			// Check if multi-line value is complete
-			if braceDepth <= 0 && bracketDepth <= 0 {
-				inMultiLineValue = false
-				value := parseHCLValueToInterface(strings.TrimSpace(valueBuilder.String()))
-				node.Config[currentKey] = value
-				currentKey = ""
-				valueBuilder.Reset()
-			}
-			continue
-		}
-
-		// Parse source
-		if strings.HasPrefix(trimmedLine, "source") && !strings.HasPrefix(trimmedLine, "source_") {
-			value := extractHCLValue(trimmedLine)
-			node.ModuleSource = value
-			node.RawSource = value
-			continue
-		}
-		// Parse version
-		if strings.HasPrefix(trimmedLine, "version") {
-			value := extractHCLValue(trimmedLine)
-			node.ModuleVersion = value
-			node.RawVersion = value
-			continue
-		}
-		// Skip module declaration line
-		if strings.HasPrefix(trimmedLine, "module") || trimmedLine == "}" {
-			continue
-		}
-		// Parse other config values
-		if strings.Contains(trimmedLine, "=") {
-			parts := strings.SplitN(trimmedLine, "=", 2)
-			if len(parts) == 2 {
-				key := strings.TrimSpace(parts[0])
-				valueStr := strings.TrimSpace(parts[1])
-
-				// Check if this is a multi-line value (starts with { or [)
-				braceDepth = strings.Count(valueStr, "{") - strings.Count(valueStr, "}")
-				bracketDepth = strings.Count(valueStr, "[") - strings.Count(valueStr, "]")
-
-				if braceDepth > 0 || bracketDepth > 0 {
-					// Multi-line value, start collecting
-					inMultiLineValue = true
-					currentKey = key
-					valueBuilder.Reset()
-					valueBuilder.WriteString(valueStr + "\n")
-				} else {
-					// Single-line value
-					value := parseHCLValueToInterface(valueStr)
-					node.Config[key] = value
-				}
-			}
-		}
-	}
-
-	// Store raw config
-	node.RawConfig = node.Config
-
-	return node
-}
-
-// parseVariableBlock parses a variable block and returns a ManifestVariable
-func (h *ManifestHandler) parseVariableBlock(name string, content string) models.ManifestVariable {
-	variable := models.ManifestVariable{
-		Name:     name,
-		Type:     "string", // Default type
-		Required: true,
-	}
-
-	lines := strings.Split(content, "\n")
-	for _, line := range lines {
-		trimmedLine := strings.TrimSpace(line)
-
-		// Parse type
-		if strings.HasPrefix(trimmedLine, "type") {
-			value := extractHCLValue(trimmedLine)
-			variable.Type = value
-		}
-		// Parse description
-		if strings.HasPrefix(trimmedLine, "description") {
-			value := extractHCLValue(trimmedLine)
-			variable.Description = value
-		}
-		// Parse default
-		if strings.HasPrefix(trimmedLine, "default") {
-			value := parseHCLValueToInterface(strings.TrimPrefix(strings.TrimSpace(strings.TrimPrefix(trimmedLine, "default")), "="))
-			variable.Default = value
-			variable.Required = false
-		}
-		// Parse sensitive
-		if strings.HasPrefix(trimmedLine, "sensitive") {
-			value := extractHCLValue(trimmedLine)
-			variable.Sensitive = value == "true"
-		}
-	}
-
-	return variable
-}
-
-// extractHCLValue extracts the value from an HCL assignment line
-func extractHCLValue(line string) string {
-	parts := strings.SplitN(line, "=", 2)
-	if len(parts) != 2 {
-		return ""
-	}
-	value := strings.TrimSpace(parts[1])
-	// Remove quotes
-	value = strings.Trim(value, "\"")
-	return value
-}
-
-// parseHCLValueToInterface parses an HCL value string to an interface{}
-func parseHCLValueToInterface(value string) interface{} {
-	value = strings.TrimSpace(value)
-
-	// Boolean
-	if value == "true" {
-		return true
-	}
-	if value == "false" {
-		return false
-	}
-
-	// Number
-	if num, err := strconv.ParseFloat(value, 64); err == nil {
-		return num
-	}
-
-	// String (quoted)
-	if strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"") {
-		return strings.Trim(value, "\"")
-	}
-
-	// Array
-	if strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]") {
-		// Simple array parsing
-		inner := strings.Trim(value, "[]")
-		if inner == "" {
-			return []interface{}{}
-		}
-		items := strings.Split(inner, ",")
-		result := make([]interface{}, 0, len(items))
-		for _, item := range items {
-			result = append(result, parseHCLValueToInterface(strings.TrimSpace(item)))
-		}
-		return result
-	}
-
-	// Map/Object - parse HCL map syntax
-	if strings.HasPrefix(value, "{") && strings.HasSuffix(value, "}") {
-		return parseHCLMap(value)
-	}
-
-	// Variable reference (e.g., var.xxx, local.xxx, module.xxx)
-	if strings.HasPrefix(value, "var.") || strings.HasPrefix(value, "local.") || strings.HasPrefix(value, "module.") {
-		return value
-	}
-
-	// Default: return as string
-	return value
-}
-
-// parseHCLMap parses an HCL map/object value like { key1 = "value1" key2 = "value2" }
-func parseHCLMap(value string) map[string]interface{} {
-	result := make(map[string]interface{})
-
-	// Remove outer braces
-	inner := strings.TrimSpace(value[1 : len(value)-1])
-	if inner == "" {
-		return result
-	}
-
-	// Parse key-value pairs
-	// HCL format: key = value (can be on same line or multiple lines)
-	// We need to handle nested structures and quoted strings
-
-	var currentKey string
-	var currentValue strings.Builder
-	var inQuote bool
-	var braceDepth int
-	var bracketDepth int
-	var expectingValue bool
-
-	for i := 0; i < len(inner); i++ {
-		c := inner[i]
-
-		// Handle quotes
-		if c == '"' && (i == 0 || inner[i-1] != '\\') {
-			inQuote = !inQuote
-			if expectingValue {
-				currentValue.WriteByte(c)
-			}
-			continue
-		}
-
-		// Inside quotes, just append
-		if inQuote {
-			if expectingValue {
-				currentValue.WriteByte(c)
-			} else {
-				currentKey += string(c)
-			}
-			continue
-		}
-
-		// Track brace/bracket depth
-		if c == '{' {
-			braceDepth++
-			if expectingValue {
-				currentValue.WriteByte(c)
-			}
-			continue
-		}
-		if c == '}' {
-			braceDepth--
-			if expectingValue && braceDepth >= 0 {
-				currentValue.WriteByte(c)
-			}
-			continue
-		}
-		if c == '[' {
-			bracketDepth++
-			if expectingValue {
-				currentValue.WriteByte(c)
-			}
-			continue
-		}
-		if c == ']' {
-			bracketDepth--
-			if expectingValue {
-				currentValue.WriteByte(c)
-			}
-			continue
-		}
-
-		// Inside nested structure, just append
-		if braceDepth > 0 || bracketDepth > 0 {
-			if expectingValue {
-				currentValue.WriteByte(c)
-			}
-			continue
-		}
-
-		// Handle equals sign
-		if c == '=' && !expectingValue {
-			currentKey = strings.TrimSpace(currentKey)
-			expectingValue = true
-			continue
-		}
-
-		// Handle newline or end of value
-		if (c == '\n' || c == '\r') && expectingValue {
-			// Save current key-value pair
-			if currentKey != "" {
-				valueStr := strings.TrimSpace(currentValue.String())
-				if valueStr != "" {
-					result[currentKey] = parseHCLValueToInterface(valueStr)
-				}
-			}
-			currentKey = ""
-			currentValue.Reset()
-			expectingValue = false
-			continue
-		}
-
-		// Append to current key or value
-		if expectingValue {
-			currentValue.WriteByte(c)
-		} else if c != ' ' && c != '\t' && c != '\n' && c != '\r' {
-			currentKey += string(c)
-		}
-	}
-
-	// Handle last key-value pair
-	if currentKey != "" && expectingValue {
-		valueStr := strings.TrimSpace(currentValue.String())
-		if valueStr != "" {
-			result[currentKey] = parseHCLValueToInterface(valueStr)
-		}
-	}
-
-	return result
-}
-
 // ========== Deployment Execution ==========
 
 // executeDeployment executes deployment: create resources based on Manifest version
 func (h *ManifestHandler) executeDeployment(deployment models.ManifestDeployment, version models.ManifestVersion, workspace models.Workspace, userID string) {
-	// Update deployment status to deploying
-	h.db.Model(&deployment).Update("status", models.DeploymentStatusDeploying)
+	// Update deployment status to deploying; last_task_id is set once the new task exists
+	h.db.Model(&deployment).Updates(map[string]interface{}{
+		"status":       models.DeploymentStatusDeploying,
+		"last_task_id": nil,
+	})
 
 	// Get Manifest information
 	var manifest models.Manifest
@@ -2398,13 +2133,13 @@ func (h *ManifestHandler) executeDeployment(deployment models.ManifestDeployment
 		return
 	}
 
-	// Update deployment status to deployed, and record task ID
+	// Record task ID; the deployment stays deploying until the task finishes
 	h.db.Model(&deployment).Updates(map[string]interface{}{
-		"status":       models.DeploymentStatusDeployed,
+		"status":       models.DeploymentStatusDeploying,
 		"last_task_id": taskID,
 	})
 
-	log.Printf("[Manifest] Deployment %s completed, created task %d", deployment.ID, taskID)
+	log.Printf("[Manifest] Deployment %s started, created task %d", deployment.ID, taskID)
 }
 
 // createPlanAndApplyTask creates a Plan+Apply task
@@ -2539,34 +2274,8 @@ func (h *ManifestHandler) createTaskSnapshot(task *models.WorkspaceTask, workspa
 
 // extractResourceType extracts resource type from node info
 // Format: {cloudProvider}_{moduleName}
-// Examples:
-//   - terraform-aws-modules/ec2-instance/aws + resourceName="ec2-ff" → AWS_ec2-ff
 func (h *ManifestHandler) extractResourceType(moduleSource string, resourceName string) string {
-	// Prefer resourceName (module name)
-	moduleName := resourceName
-	if moduleName == "" {
-		moduleName = "module"
-	}
-
-	// Extract cloud provider from moduleSource
-	cloudProvider := "AWS" // Default AWS
-
-	if moduleSource != "" {
-		parts := strings.Split(moduleSource, "/")
-		if len(parts) >= 3 {
-			if strings.Contains(parts[0], ".") {
-				// Private Registry format: hostname/namespace/name/provider
-				// Default to AWS
-				cloudProvider = "AWS"
-			} else {
-				// Terraform Registry format: namespace/name/provider
-				provider := parts[2] // provider (e.g., aws, google, azure)
-				cloudProvider = strings.ToUpper(provider)
-			}
-		}
-	}
-
-	return fmt.Sprintf("%s_%s", cloudProvider, moduleName)
+	return services.ManifestResourceType(moduleSource, resourceName)
 }
 
 // calculateResourceConfigHash calculates the hash of resource config
@@ -2581,63 +2290,10 @@ func (h *ManifestHandler) calculateResourceConfigHash(resource *models.Workspace
 		return ""
 	}
 
-	// Calculate SHA256 hash of TF code
-	tfCodeJSON, _ := json.Marshal(codeVersion.TFCode)
-	hash := sha256.Sum256(tfCodeJSON)
-	return hex.EncodeToString(hash[:])
+	return services.ManifestTFCodeHash(codeVersion.TFCode)
 }
 
 // generateModuleTFCode generates module TF code (Terraform JSON format)
 func (h *ManifestHandler) generateModuleTFCode(node models.ManifestNode) models.JSONB {
-	// Build module config
-	moduleConfig := make(map[string]interface{})
-	if node.ModuleSource != "" {
-		moduleConfig["source"] = node.ModuleSource
-	}
-	if node.ModuleVersion != "" {
-		moduleConfig["version"] = node.ModuleVersion
-	}
-
-	// Add config parameters (with reference conversion)
-	for key, value := range node.Config {
-		moduleConfig[key] = convertTerraformReferences(value)
-	}
-
-	// Return Terraform JSON format
-	// Format: { "module": { "instance_name": [{ "source": "...", ... }] } }
-	return models.JSONB{
-		"module": map[string]interface{}{
-			node.InstanceName: []interface{}{moduleConfig},
-		},
-	}
-}
-
-// convertTerraformReferences converts Terraform references to interpolation syntax
-// e.g., "module.xxx.yyy" -> "${module.xxx.yyy}"
-func convertTerraformReferences(value interface{}) interface{} {
-	switch v := value.(type) {
-	case string:
-		// Check if it's a Terraform reference
-		if strings.HasPrefix(v, "var.") || strings.HasPrefix(v, "local.") ||
-			strings.HasPrefix(v, "module.") || strings.HasPrefix(v, "data.") {
-			return fmt.Sprintf("${%s}", v)
-		}
-		return v
-	case map[string]interface{}:
-		// Recursively process map values
-		result := make(map[string]interface{})
-		for key, val := range v {
-			result[key] = convertTerraformReferences(val)
-		}
-		return result
-	case []interface{}:
-		// Recursively process array values
-		result := make([]interface{}, len(v))
-		for i, val := range v {
-			result[i] = convertTerraformReferences(val)
-		}
-		return result
-	default:
-		return v
-	}
+	return services.BuildManifestModuleTFCode(node)
 }
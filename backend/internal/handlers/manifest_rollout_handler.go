@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"iac-platform/internal/models"
+	"iac-platform/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ========== Bulk Rollout ==========
+
+// PreviewManifestRollout previews the workspaces selected by a rollout and their waves
+// @Summary Preview manifest rollout
+// @Tags Manifest
+// @Accept json
+// @Produce json
+// @Param org_id path string true "Organization ID"
+// @Param id path string true "Manifest ID"
+// @Param body body models.CreateManifestRolloutRequest true "Rollout request"
+// @Success 200 {object} models.ManifestRolloutPreview
+// @Router /api/v1/organizations/{org_id}/manifests/{id}/rollouts/preview [post]
+func (h *ManifestHandler) PreviewManifestRollout(c *gin.Context) {
+	manifest, ok := h.getOrgManifest(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreateManifestRolloutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request parameters: " + err.Error()})
+		return
+	}
+
+	preview, err := services.NewManifestRolloutService(h.db).Preview(manifest.ID, &req)
+	if err != nil {
+		respondManifestRolloutError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, preview)
+}
+
+// CreateManifestRollout deploys or upgrades a version to many workspaces in waves
+// @Summary Create manifest rollout
+// @Description Selects workspaces by project_ids, tags and/or workspace_ids (intersection). Waves: canary_count workspaces first, then cumulative percentages. The rollout pauses or stops when failures in a wave exceed failure_threshold percent, and waits for approval between waves when require_approval is set.
+// @Tags Manifest
+// @Accept json
+// @Produce json
+// @Param org_id path string true "Organization ID"
+// @Param id path string true "Manifest ID"
+// @Param body body models.CreateManifestRolloutRequest true "Rollout request"
+// @Success 201 {object} models.ManifestRollout
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/organizations/{org_id}/manifests/{id}/rollouts [post]
+func (h *ManifestHandler) CreateManifestRollout(c *gin.Context) {
+	manifest, ok := h.getOrgManifest(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreateManifestRolloutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request parameters: " + err.Error()})
+		return
+	}
+
+	rollout, err := services.NewManifestRolloutService(h.db).Create(manifest.ID, &req, c.GetString("user_id"))
+	if err != nil {
+		respondManifestRolloutError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, rollout)
+}
+
+// ListManifestRollouts lists rollouts of a manifest
+// @Summary List manifest rollouts
+// @Tags Manifest
+// @Produce json
+// @Param org_id path string true "Organization ID"
+// @Param id path string true "Manifest ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/organizations/{org_id}/manifests/{id}/rollouts [get]
+func (h *ManifestHandler) ListManifestRollouts(c *gin.Context) {
+	manifest, ok := h.getOrgManifest(c)
+	if !ok {
+		return
+	}
+
+	rollouts, err := services.NewManifestRolloutService(h.db).List(manifest.ID)
+	if err != nil {
+		respondManifestRolloutError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": rollouts, "total": len(rollouts)})
+}
+
+// GetManifestRollout gets a rollout with per-workspace status and task links
+// @Summary Get manifest rollout
+// @Tags Manifest
+// @Produce json
+// @Param org_id path string true "Organization ID"
+// @Param id path string true "Manifest ID"
+// @Param rollout_id path string true "Rollout ID"
+// @Success 200 {object} models.ManifestRollout
+// @Router /api/v1/organizations/{org_id}/manifests/{id}/rollouts/{rollout_id} [get]
+func (h *ManifestHandler) GetManifestRollout(c *gin.Context) {
+	manifest, ok := h.getOrgManifest(c)
+	if !ok {
+		return
+	}
+
+	rollout, err := services.NewManifestRolloutService(h.db).Get(manifest.ID, c.Param("rollout_id"))
+	if err != nil {
+		respondManifestRolloutError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rollout)
+}
+
+// ApproveManifestRollout approves the next wave of a rollout awaiting approval
+// @Summary Approve next rollout wave
+// @Tags Manifest
+// @Produce json
+// @Param org_id path string true "Organization ID"
+// @Param id path string true "Manifest ID"
+// @Param rollout_id path string true "Rollout ID"
+// @Success 200 {object} models.ManifestRollout
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/organizations/{org_id}/manifests/{id}/rollouts/{rollout_id}/approve [post]
+func (h *ManifestHandler) ApproveManifestRollout(c *gin.Context) {
+	manifest, ok := h.getOrgManifest(c)
+	if !ok {
+		return
+	}
+
+	rollout, err := services.NewManifestRolloutService(h.db).Approve(manifest.ID, c.Param("rollout_id"), c.GetString("user_id"))
+	if err != nil {
+		respondManifestRolloutError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rollout)
+}
+
+// PauseManifestRollout pauses a running rollout
+// @Summary Pause manifest rollout
+// @Description Started tasks keep running and are tracked; no new workspaces are started until resumed
+// @Tags Manifest
+// @Produce json
+// @Param org_id path string true "Organization ID"
+// @Param id path string true "Manifest ID"
+// @Param rollout_id path string true "Rollout ID"
+// @Success 200 {object} models.ManifestRollout
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/organizations/{org_id}/manifests/{id}/rollouts/{rollout_id}/pause [post]
+func (h *ManifestHandler) PauseManifestRollout(c *gin.Context) {
+	manifest, ok := h.getOrgManifest(c)
+	if !ok {
+		return
+	}
+
+	rollout, err := services.NewManifestRolloutService(h.db).Pause(manifest.ID, c.Param("rollout_id"), c.GetString("user_id"))
+	if err != nil {
+		respondManifestRolloutError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rollout)
+}
+
+// ResumeManifestRollout resumes a paused rollout
+// @Summary Resume manifest rollout
+// @Description With retry_failed the failed workspaces of the current wave are retried, otherwise their failures are accepted and no longer count toward the threshold
+// @Tags Manifest
+// @Accept json
+// @Produce json
+// @Param org_id path string true "Organization ID"
+// @Param id path string true "Manifest ID"
+// @Param rollout_id path string true "Rollout ID"
+// @Param body body models.ResumeManifestRolloutRequest false "Resume request"
+// @Success 200 {object} models.ManifestRollout
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/organizations/{org_id}/manifests/{id}/rollouts/{rollout_id}/resume [post]
+func (h *ManifestHandler) ResumeManifestRollout(c *gin.Context) {
+	manifest, ok := h.getOrgManifest(c)
+	if !ok {
+		return
+	}
+
+	var req models.ResumeManifestRolloutRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request parameters: " + err.Error()})
+			return
+		}
+	}
+
+	rollout, err := services.NewManifestRolloutService(h.db).Resume(manifest.ID, c.Param("rollout_id"), req.RetryFailed)
+	if err != nil {
+		respondManifestRolloutError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rollout)
+}
+
+// CancelManifestRollout cancels a rollout; workspaces not yet started are skipped
+// @Summary Cancel manifest rollout
+// @Tags Manifest
+// @Produce json
+// @Param org_id path string true "Organization ID"
+// @Param id path string true "Manifest ID"
+// @Param rollout_id path string true "Rollout ID"
+// @Success 200 {object} models.ManifestRollout
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/organizations/{org_id}/manifests/{id}/rollouts/{rollout_id}/cancel [post]
+func (h *ManifestHandler) CancelManifestRollout(c *gin.Context) {
+	manifest, ok := h.getOrgManifest(c)
+	if !ok {
+		return
+	}
+
+	rollout, err := services.NewManifestRolloutService(h.db).Cancel(manifest.ID, c.Param("rollout_id"), c.GetString("user_id"))
+	if err != nil {
+		respondManifestRolloutError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rollout)
+}
+
+// getOrgManifest loads the manifest from path parameters, verifying it belongs to the organization
+func (h *ManifestHandler) getOrgManifest(c *gin.Context) (*models.Manifest, bool) {
+	var manifest models.Manifest
+	if err := h.db.Where("id = ? AND organization_id = ?", c.Param("id"), c.Param("org_id")).First(&manifest).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Manifest not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Query failed: " + err.Error()})
+		return nil, false
+	}
+	return &manifest, true
+}
+
+// respondManifestRolloutError maps rollout errors to status codes
+func respondManifestRolloutError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrManifestRolloutNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Rollout not found"})
+	case errors.Is(err, services.ErrManifestRolloutState):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrManifestVersionNotFound):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Version not found"})
+	case errors.Is(err, services.ErrManifestRolloutInvalid), errors.Is(err, services.ErrManifestUpgradeInvalid):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Rollout operation failed: " + err.Error()})
+	}
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"fmt"
+	"log"
 	"net/http"
 	"strings"
 
@@ -216,6 +217,15 @@ func (h *RunTaskCallbackHandler) GetPlanJSON(c *gin.Context) {
 		return
 	}
 
+	// plan_json may have been archived to object storage by the retention policy
+	if task.PlanJSON == nil {
+		if err := services.NewTaskArtifactService(h.db).HydrateTask(c.Request.Context(), &task, models.TaskArtifactPlanJSON); err != nil {
+			log.Printf("[RunTask] Failed to load archived plan json of task %d: %v", task.ID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load plan json"})
+			return
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{"plan_json": task.PlanJSON})
 }
 
@@ -137,8 +137,10 @@ func (h *RunTriggerHandler) CreateInboundTrigger(c *gin.Context) {
 	targetWorkspaceID := c.Param("id")
 
 	var req struct {
-		SourceWorkspaceID string `json:"source_workspace_id" binding:"required"`
-		Enabled           *bool  `json:"enabled"`
+		SourceWorkspaceID string   `json:"source_workspace_id" binding:"required"`
+		Enabled           *bool    `json:"enabled"`
+		TriggerCondition  string   `json:"trigger_condition"` // apply_success（默认）或 outputs_changed
+		OutputNames       []string `json:"output_names"`      // outputs_changed 关注的 output，为空时关注目标通过 Remote Data 读取的 output
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -172,11 +174,18 @@ func (h *RunTriggerHandler) CreateInboundTrigger(c *gin.Context) {
 		enabled = *req.Enabled
 	}
 
+	condition, outputNames, err := services.NormalizeTriggerCondition(req.TriggerCondition, req.OutputNames)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	trigger := &models.RunTrigger{
 		SourceWorkspaceID: req.SourceWorkspaceID,
 		TargetWorkspaceID: targetWorkspaceID,
 		Enabled:           enabled,
-		TriggerCondition:  models.TriggerConditionApplySuccess,
+		TriggerCondition:  condition,
+		OutputNames:       outputNames,
 		CreatedBy:         createdBy,
 		CreatedAt:         time.Now(),
 		UpdatedAt:         time.Now(),
@@ -214,8 +223,10 @@ func (h *RunTriggerHandler) CreateRunTrigger(c *gin.Context) {
 	sourceWorkspaceID := c.Param("id")
 
 	var req struct {
-		TargetWorkspaceID string `json:"target_workspace_id" binding:"required"`
-		Enabled           *bool  `json:"enabled"`
+		TargetWorkspaceID string   `json:"target_workspace_id" binding:"required"`
+		Enabled           *bool    `json:"enabled"`
+		TriggerCondition  string   `json:"trigger_condition"` // apply_success（默认）或 outputs_changed
+		OutputNames       []string `json:"output_names"`      // outputs_changed 关注的 output，为空时关注目标通过 Remote Data 读取的 output
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -242,11 +253,18 @@ func (h *RunTriggerHandler) CreateRunTrigger(c *gin.Context) {
 		enabled = *req.Enabled
 	}
 
+	condition, outputNames, err := services.NormalizeTriggerCondition(req.TriggerCondition, req.OutputNames)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	trigger := &models.RunTrigger{
 		SourceWorkspaceID: sourceWorkspaceID,
 		TargetWorkspaceID: req.TargetWorkspaceID,
 		Enabled:           enabled,
-		TriggerCondition:  models.TriggerConditionApplySuccess,
+		TriggerCondition:  condition,
+		OutputNames:       outputNames,
 		CreatedBy:         createdBy,
 		CreatedAt:         time.Now(),
 		UpdatedAt:         time.Now(),
@@ -289,7 +307,9 @@ func (h *RunTriggerHandler) UpdateRunTrigger(c *gin.Context) {
 	}
 
 	var req struct {
-		Enabled *bool `json:"enabled"`
+		Enabled          *bool    `json:"enabled"`
+		TriggerCondition *string  `json:"trigger_condition"`
+		OutputNames      []string `json:"output_names"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -305,6 +325,16 @@ func (h *RunTriggerHandler) UpdateRunTrigger(c *gin.Context) {
 		updates["enabled"] = *req.Enabled
 	}
 
+	if req.TriggerCondition != nil {
+		condition, outputNames, err := services.NormalizeTriggerCondition(*req.TriggerCondition, req.OutputNames)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		updates["trigger_condition"] = condition
+		updates["output_names"] = outputNames
+	}
+
 	if err := h.service.UpdateRunTrigger(uint(triggerID), updates); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update run trigger"})
 		return
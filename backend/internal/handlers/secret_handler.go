@@ -9,6 +9,7 @@ import (
 
 	"iac-platform/internal/crypto"
 	"iac-platform/internal/infrastructure"
+	"iac-platform/internal/middleware"
 	"iac-platform/internal/models"
 	"iac-platform/services"
 
@@ -212,6 +213,20 @@ func (h *SecretHandler) GetSecret(c *gin.Context) {
 	now := time.Now()
 	h.db.Model(&secret).Update("last_used_at", now)
 
+	ev := middleware.AuditEventFromContext(c, models.AuditEventSecretAccessed)
+	ev.ResourceType = "secret"
+	ev.ResourceID = secret.SecretID
+	ev.Message = fmt.Sprintf("Secret %s metadata read", secret.SecretID)
+	ev.Details = models.JSONB{
+		"owner_type": resourceType,
+		"owner_id":   resourceId,
+		"decrypted":  false,
+	}
+	if resourceType == string(models.ResourceTypeWorkspace) {
+		ev.WorkspaceID = resourceId
+	}
+	services.NewAuditEventService(h.db).Emit(ev)
+
 	c.JSON(http.StatusOK, secret.ToResponse())
 }
 
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"iac-platform/internal/models"
@@ -171,6 +172,7 @@ func (h *SSOHandler) Callback(c *gin.Context) {
 					"mfa_setup_required": true,
 					"mfa_token":          mfaToken.Token,
 					"expires_in":         300,
+					"cli_redirect_url":   cliRedirectURL(result),
 					"is_new_user":        true,
 					"user": gin.H{
 						"username": result.User.Username,
@@ -207,6 +209,7 @@ func (h *SSOHandler) Callback(c *gin.Context) {
 				"mfa_token":             mfaToken.Token,
 				"expires_in":            300,
 				"required_backup_codes": requiredBackupCodes,
+				"cli_redirect_url":      cliRedirectURL(result),
 				"is_new_user":           result.IsNewUser,
 				"user": gin.H{
 					"username": result.User.Username,
@@ -236,6 +239,7 @@ func (h *SSOHandler) Callback(c *gin.Context) {
 				"mfa_setup_required": true,
 				"mfa_token":          mfaToken.Token,
 				"expires_in":         300,
+				"cli_redirect_url":   cliRedirectURL(result),
 				"is_new_user":        result.IsNewUser,
 				"user": gin.H{
 					"username": result.User.Username,
@@ -290,19 +294,37 @@ func (h *SSOHandler) Callback(c *gin.Context) {
 		"code":    200,
 		"message": "SSO login successful",
 		"data": gin.H{
-			"token":       token,
-			"expires_at":  expiresAt,
-			"is_new_user": result.IsNewUser,
+			"token":            token,
+			"expires_at":       expiresAt,
+			"cli_redirect_url": cliRedirectURL(result),
+			"is_new_user":      result.IsNewUser,
 			"user": gin.H{
-				"id":             result.User.ID,
-				"username":       result.User.Username,
-				"email":          result.User.Email,
+				"id":              result.User.ID,
+				"username":        result.User.Username,
+				"email":           result.User.Email,
 				"is_system_admin": result.User.IsSystemAdmin,
 			},
 		},
 	})
 }
 
+// withQuery 在回跳地址后追加查询参数；CLI 回环地址自带 state 参数（由 CLI 校验），需要原样保留
+func withQuery(target, query string) string {
+	if strings.Contains(target, "?") {
+		return target + "&" + query
+	}
+	return target + "?" + query
+}
+
+// cliRedirectURL 返回 CLI 浏览器登录的本机回跳地址，普通网页登录返回空字符串
+// 前端回调页拿到该地址后把 token 或 MFA 令牌转交给 CLI
+func cliRedirectURL(result *sso.LoginResult) string {
+	if sso.IsLoopbackRedirect(result.RedirectURL) {
+		return result.RedirectURL
+	}
+	return ""
+}
+
 // CallbackRedirect 处理 SSO 回调（重定向模式，Provider 直接重定向到此端点）
 // 处理完成后重定向到前端页面，通过 URL 参数传递临时 code（非 JWT token）
 func (h *SSOHandler) CallbackRedirect(c *gin.Context) {
@@ -316,8 +338,8 @@ func (h *SSOHandler) CallbackRedirect(c *gin.Context) {
 	if code == "" {
 		errMsg := c.Query("error")
 		errDesc := c.Query("error_description")
-		c.Redirect(http.StatusFound, fmt.Sprintf("%s?error=%s&error_description=%s",
-			frontendCallbackURL, url.QueryEscape(errMsg), url.QueryEscape(errDesc)))
+		c.Redirect(http.StatusFound, withQuery(frontendCallbackURL, fmt.Sprintf("error=%s&error_description=%s",
+			url.QueryEscape(errMsg), url.QueryEscape(errDesc))))
 		return
 	}
 
@@ -330,11 +352,16 @@ func (h *SSOHandler) CallbackRedirect(c *gin.Context) {
 		c.Request.UserAgent(),
 	)
 	if err != nil {
-		c.Redirect(http.StatusFound, fmt.Sprintf("%s?error=sso_failed&error_description=%s",
-			frontendCallbackURL, url.QueryEscape(err.Error())))
+		c.Redirect(http.StatusFound, withQuery(frontendCallbackURL, fmt.Sprintf("error=sso_failed&error_description=%s",
+			url.QueryEscape(err.Error()))))
 		return
 	}
 
+	// CLI 浏览器登录：把结果交回 CLI 在本机监听的回环地址，由 CLI 完成后续步骤（如 MFA 验证）
+	if sso.IsLoopbackRedirect(result.RedirectURL) {
+		frontendCallbackURL = result.RedirectURL
+	}
+
 	// 检查 MFA（与 Callback 端点保持一致的安全策略）
 
 	// 新用户第一次登录：生成 mfa_token（非 JWT），用户必须完成 MFA 设置后才能获得 JWT
@@ -343,12 +370,12 @@ func (h *SSOHandler) CallbackRedirect(c *gin.Context) {
 		if mfaConfig != nil && mfaConfig.Enabled {
 			mfaToken, err := h.mfaService.CreateMFAToken(result.User.ID, c.ClientIP())
 			if err != nil {
-				c.Redirect(http.StatusFound, fmt.Sprintf("%s?error=mfa_error&error_description=%s",
-					frontendCallbackURL, url.QueryEscape("Failed to create MFA token")))
+				c.Redirect(http.StatusFound, withQuery(frontendCallbackURL, fmt.Sprintf("error=mfa_error&error_description=%s",
+					url.QueryEscape("Failed to create MFA token"))))
 				return
 			}
-			c.Redirect(http.StatusFound, fmt.Sprintf("%s?mfa_setup_required=true&mfa_token=%s&is_new_user=true",
-				frontendCallbackURL, url.QueryEscape(mfaToken.Token)))
+			c.Redirect(http.StatusFound, withQuery(frontendCallbackURL, fmt.Sprintf("mfa_setup_required=true&mfa_token=%s&is_new_user=true",
+				url.QueryEscape(mfaToken.Token))))
 			return
 		}
 	}
@@ -357,12 +384,12 @@ func (h *SSOHandler) CallbackRedirect(c *gin.Context) {
 	if result.User.MFAEnabled {
 		mfaToken, err := h.mfaService.CreateMFAToken(result.User.ID, c.ClientIP())
 		if err != nil {
-			c.Redirect(http.StatusFound, fmt.Sprintf("%s?error=mfa_error&error_description=%s",
-				frontendCallbackURL, url.QueryEscape("Failed to create MFA token")))
+			c.Redirect(http.StatusFound, withQuery(frontendCallbackURL, fmt.Sprintf("error=mfa_error&error_description=%s",
+				url.QueryEscape("Failed to create MFA token"))))
 			return
 		}
-		c.Redirect(http.StatusFound, fmt.Sprintf("%s?mfa_required=true&mfa_token=%s&is_new_user=%v",
-			frontendCallbackURL, url.QueryEscape(mfaToken.Token), result.IsNewUser))
+		c.Redirect(http.StatusFound, withQuery(frontendCallbackURL, fmt.Sprintf("mfa_required=true&mfa_token=%s&is_new_user=%v",
+			url.QueryEscape(mfaToken.Token), result.IsNewUser)))
 		return
 	}
 
@@ -371,20 +398,20 @@ func (h *SSOHandler) CallbackRedirect(c *gin.Context) {
 	if err == nil && mfaStatus.IsRequired && !result.User.MFAEnabled {
 		mfaToken, err := h.mfaService.CreateMFAToken(result.User.ID, c.ClientIP())
 		if err != nil {
-			c.Redirect(http.StatusFound, fmt.Sprintf("%s?error=mfa_error&error_description=%s",
-				frontendCallbackURL, url.QueryEscape("Failed to create MFA token")))
+			c.Redirect(http.StatusFound, withQuery(frontendCallbackURL, fmt.Sprintf("error=mfa_error&error_description=%s",
+				url.QueryEscape("Failed to create MFA token"))))
 			return
 		}
-		c.Redirect(http.StatusFound, fmt.Sprintf("%s?mfa_setup_required=true&mfa_token=%s&is_new_user=%v",
-			frontendCallbackURL, url.QueryEscape(mfaToken.Token), result.IsNewUser))
+		c.Redirect(http.StatusFound, withQuery(frontendCallbackURL, fmt.Sprintf("mfa_setup_required=true&mfa_token=%s&is_new_user=%v",
+			url.QueryEscape(mfaToken.Token), result.IsNewUser)))
 		return
 	}
 
 	// 无需 MFA，生成 JWT
 	sessionID, err := generateSessionID()
 	if err != nil {
-		c.Redirect(http.StatusFound, fmt.Sprintf("%s?error=session_error&error_description=%s",
-			frontendCallbackURL, url.QueryEscape("Failed to generate session")))
+		c.Redirect(http.StatusFound, withQuery(frontendCallbackURL, fmt.Sprintf("error=session_error&error_description=%s",
+			url.QueryEscape("Failed to generate session"))))
 		return
 	}
 
@@ -400,21 +427,21 @@ func (h *SSOHandler) CallbackRedirect(c *gin.Context) {
 		IsActive:  true,
 	}
 	if err := h.db.Create(&session).Error; err != nil {
-		c.Redirect(http.StatusFound, fmt.Sprintf("%s?error=session_error&error_description=%s",
-			frontendCallbackURL, url.QueryEscape("Failed to create session")))
+		c.Redirect(http.StatusFound, withQuery(frontendCallbackURL, fmt.Sprintf("error=session_error&error_description=%s",
+			url.QueryEscape("Failed to create session"))))
 		return
 	}
 
 	token, err := generateJWTWithSession(result.User.ID, result.User.Username, sessionID)
 	if err != nil {
-		c.Redirect(http.StatusFound, fmt.Sprintf("%s?error=token_error&error_description=%s",
-			frontendCallbackURL, url.QueryEscape("Failed to generate token")))
+		c.Redirect(http.StatusFound, withQuery(frontendCallbackURL, fmt.Sprintf("error=token_error&error_description=%s",
+			url.QueryEscape("Failed to generate token"))))
 		return
 	}
 
 	// 重定向到前端，携带 token
-	c.Redirect(http.StatusFound, fmt.Sprintf("%s?token=%s&is_new_user=%v",
-		frontendCallbackURL, url.QueryEscape(token), result.IsNewUser))
+	c.Redirect(http.StatusFound, withQuery(frontendCallbackURL, fmt.Sprintf("token=%s&is_new_user=%v",
+		url.QueryEscape(token), result.IsNewUser)))
 }
 
 // ============================================
@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"iac-platform/internal/middleware"
+	"iac-platform/internal/models"
+	"iac-platform/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// StackHandler handles stacks and their coordinated runs
+type StackHandler struct {
+	db      *gorm.DB
+	service *services.StackService
+}
+
+// NewStackHandler creates a new stack handler
+func NewStackHandler(db *gorm.DB) *StackHandler {
+	return &StackHandler{
+		db:      db,
+		service: services.NewStackService(db),
+	}
+}
+
+// ========== Stacks ==========
+
+// ListStacks lists all stacks with their members
+// @Summary List stacks
+// @Tags Stack
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/stacks [get]
+func (h *StackHandler) ListStacks(c *gin.Context) {
+	stacks, err := h.service.List()
+	if err != nil {
+		respondStackError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": stacks, "total": len(stacks)})
+}
+
+// GetStack gets a stack with its members and dependency graph
+// @Summary Get stack
+// @Description The graph merges explicit depends_on with dependencies inferred from remote data and run triggers between members. graph_error is set when the inferred graph has a cycle.
+// @Tags Stack
+// @Produce json
+// @Param stack_id path string true "Stack ID"
+// @Success 200 {object} models.Stack
+// @Router /api/v1/stacks/{stack_id} [get]
+func (h *StackHandler) GetStack(c *gin.Context) {
+	stack, err := h.service.Get(c.Param("stack_id"))
+	if err != nil {
+		respondStackError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, stack)
+}
+
+// CreateStack creates a stack
+// @Summary Create stack
+// @Tags Stack
+// @Accept json
+// @Produce json
+// @Param body body models.StackRequest true "Stack"
+// @Success 201 {object} models.Stack
+// @Failure 400 {object} map[string]interface{}
+// @Router /api/v1/stacks [post]
+func (h *StackHandler) CreateStack(c *gin.Context) {
+	var req models.StackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request parameters: " + err.Error()})
+		return
+	}
+
+	stack, err := h.service.Create(&req, c.GetString("user_id"))
+	if err != nil {
+		respondStackError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, stack)
+}
+
+// UpdateStack replaces the name, description and members of a stack
+// @Summary Update stack
+// @Description Active runs keep the members and dependencies they were created with
+// @Tags Stack
+// @Accept json
+// @Produce json
+// @Param stack_id path string true "Stack ID"
+// @Param body body models.StackRequest true "Stack"
+// @Success 200 {object} models.Stack
+// @Failure 400 {object} map[string]interface{}
+// @Router /api/v1/stacks/{stack_id} [put]
+func (h *StackHandler) UpdateStack(c *gin.Context) {
+	var req models.StackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request parameters: " + err.Error()})
+		return
+	}
+
+	stack, err := h.service.Update(c.Param("stack_id"), &req, c.GetString("user_id"))
+	if err != nil {
+		respondStackError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, stack)
+}
+
+// DeleteStack deletes a stack and its run history
+// @Summary Delete stack
+// @Tags Stack
+// @Produce json
+// @Param stack_id path string true "Stack ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/stacks/{stack_id} [delete]
+func (h *StackHandler) DeleteStack(c *gin.Context) {
+	if err := h.service.Delete(c.Param("stack_id")); err != nil {
+		respondStackError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Stack deleted"})
+}
+
+// ========== Runs ==========
+
+// CreateStackRun starts a coordinated plan of all members in dependency order
+// @Summary Start stack run
+// @Description Members are planned layer by layer; when all plans finish the run waits for one approval, then applies members in dependency order and stops on the first failure
+// @Tags Stack
+// @Accept json
+// @Produce json
+// @Param stack_id path string true "Stack ID"
+// @Param body body models.CreateStackRunRequest false "Run"
+// @Success 201 {object} models.StackRun
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/stacks/{stack_id}/runs [post]
+func (h *StackHandler) CreateStackRun(c *gin.Context) {
+	var req models.CreateStackRunRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request parameters: " + err.Error()})
+			return
+		}
+	}
+
+	run, err := h.service.CreateRun(c.Param("stack_id"), &req, c.GetString("user_id"))
+	if err != nil {
+		respondStackError(c, err)
+		return
+	}
+	h.emitRunEvent(c, models.AuditEventStackRunStarted, run, fmt.Sprintf("Stack run %s started", run.ID))
+	c.JSON(http.StatusCreated, run)
+}
+
+// ListStackRuns lists runs of a stack
+// @Summary List stack runs
+// @Tags Stack
+// @Produce json
+// @Param stack_id path string true "Stack ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/stacks/{stack_id}/runs [get]
+func (h *StackHandler) ListStackRuns(c *gin.Context) {
+	runs, err := h.service.ListRuns(c.Param("stack_id"))
+	if err != nil {
+		respondStackError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": runs, "total": len(runs)})
+}
+
+// GetStackRun gets a run with per-workspace status and task links
+// @Summary Get stack run
+// @Tags Stack
+// @Produce json
+// @Param stack_id path string true "Stack ID"
+// @Param run_id path string true "Run ID"
+// @Success 200 {object} models.StackRun
+// @Router /api/v1/stacks/{stack_id}/runs/{run_id} [get]
+func (h *StackHandler) GetStackRun(c *gin.Context) {
+	run, err := h.service.GetRun(c.Param("stack_id"), c.Param("run_id"))
+	if err != nil {
+		respondStackError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, run)
+}
+
+// GetStackRunReview gets the combined review of a run: every member with its planned resource changes
+// @Summary Get stack run review
+// @Tags Stack
+// @Produce json
+// @Param stack_id path string true "Stack ID"
+// @Param run_id path string true "Run ID"
+// @Success 200 {object} models.StackRun
+// @Router /api/v1/stacks/{stack_id}/runs/{run_id}/review [get]
+func (h *StackHandler) GetStackRunReview(c *gin.Context) {
+	run, err := h.service.GetRunReview(c.Param("stack_id"), c.Param("run_id"))
+	if err != nil {
+		respondStackError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, run)
+}
+
+// ApproveStackRun approves all plans of a run awaiting approval
+// @Summary Approve stack run
+// @Description Applies are confirmed on behalf of the approver in dependency order, each once its upstream members have applied
+// @Tags Stack
+// @Produce json
+// @Param stack_id path string true "Stack ID"
+// @Param run_id path string true "Run ID"
+// @Success 200 {object} models.StackRun
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/stacks/{stack_id}/runs/{run_id}/approve [post]
+func (h *StackHandler) ApproveStackRun(c *gin.Context) {
+	run, err := h.service.ApproveRun(c.Param("stack_id"), c.Param("run_id"), c.GetString("user_id"))
+	if err != nil {
+		respondStackError(c, err)
+		return
+	}
+	h.emitRunEvent(c, models.AuditEventStackRunApproved, run, fmt.Sprintf("Stack run %s approved", run.ID))
+	c.JSON(http.StatusOK, run)
+}
+
+// CancelStackRun cancels a run; pending plans are discarded and their workspaces unlocked
+// @Summary Cancel stack run
+// @Description Members not yet started are skipped and plans waiting for apply are discarded. Plans or applies already running are not interrupted.
+// @Tags Stack
+// @Produce json
+// @Param stack_id path string true "Stack ID"
+// @Param run_id path string true "Run ID"
+// @Success 200 {object} models.StackRun
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/v1/stacks/{stack_id}/runs/{run_id}/cancel [post]
+func (h *StackHandler) CancelStackRun(c *gin.Context) {
+	run, err := h.service.CancelRun(c.Param("stack_id"), c.Param("run_id"), c.GetString("user_id"))
+	if err != nil {
+		respondStackError(c, err)
+		return
+	}
+	h.emitRunEvent(c, models.AuditEventStackRunCancelled, run, fmt.Sprintf("Stack run %s cancelled", run.ID))
+	c.JSON(http.StatusOK, run)
+}
+
+// emitRunEvent records a stack run audit event with the member summary
+func (h *StackHandler) emitRunEvent(c *gin.Context, eventType models.AuditEventType, run *models.StackRun, message string) {
+	ev := middleware.AuditEventFromContext(c, eventType)
+	ev.ResourceType = "stack_run"
+	ev.ResourceID = run.ID
+	ev.Message = message
+	ev.Details = models.JSONB{
+		"stack_id":    run.StackID,
+		"status":      run.Status,
+		"description": run.Description,
+		"summary":     run.Summary,
+	}
+	services.NewAuditEventService(h.db).Emit(ev)
+}
+
+// respondStackError maps stack errors to status codes
+func respondStackError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrStackNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Stack not found"})
+	case errors.Is(err, services.ErrStackRunNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Stack run not found"})
+	case errors.Is(err, services.ErrStackRunState):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrStackInvalid):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Stack operation failed: " + err.Error()})
+	}
+}
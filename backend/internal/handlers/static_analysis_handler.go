@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"iac-platform/internal/models"
+	"iac-platform/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// StaticAnalysisHandler handles static analysis result HTTP requests
+type StaticAnalysisHandler struct {
+	db      *gorm.DB
+	service *services.StaticAnalysisService
+}
+
+// NewStaticAnalysisHandler creates a new static analysis handler
+func NewStaticAnalysisHandler(db *gorm.DB) *StaticAnalysisHandler {
+	return &StaticAnalysisHandler{
+		db:      db,
+		service: services.NewStaticAnalysisService(db),
+	}
+}
+
+// GetTaskStaticAnalysisResults gets the pre-plan static analysis results of a task
+// @Summary Get task static analysis results
+// @Description Get SARIF-like static analysis results (one per analyzer) produced before terraform plan
+// @Tags Workspace
+// @Produce json
+// @Param workspace_id path string true "Workspace ID"
+// @Param task_id path string true "Task ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/workspaces/{workspace_id}/tasks/{task_id}/static-analysis-results [get]
+func (h *StaticAnalysisHandler) GetTaskStaticAnalysisResults(c *gin.Context) {
+	workspaceID := c.Param("id")
+
+	var taskID uint
+	if _, err := fmt.Sscanf(c.Param("task_id"), "%d", &taskID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task_id format"})
+		return
+	}
+
+	// Verify task exists and belongs to workspace
+	var task models.WorkspaceTask
+	if err := h.db.Select("id").Where("id = ? AND workspace_id = ?", taskID, workspaceID).First(&task).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check task"})
+		return
+	}
+
+	results, err := h.service.GetResultsForTask(taskID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve static analysis results"})
+		return
+	}
+
+	blocked := false
+	errorCount, warningCount, noteCount := 0, 0, 0
+	for _, result := range results {
+		blocked = blocked || result.Blocking
+		errorCount += result.ErrorCount
+		warningCount += result.WarningCount
+		noteCount += result.NoteCount
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"task_id": taskID,
+		"results": results,
+		"summary": gin.H{
+			"blocked":       blocked,
+			"error_count":   errorCount,
+			"warning_count": warningCount,
+			"note_count":    noteCount,
+		},
+	})
+}
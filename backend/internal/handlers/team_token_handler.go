@@ -5,6 +5,7 @@ import (
 	"strconv"
 
 	"iac-platform/internal/application/service"
+	"iac-platform/internal/domain/valueobject"
 
 	"github.com/gin-gonic/gin"
 )
@@ -24,7 +25,9 @@ func NewTeamTokenHandler(service *service.TeamTokenService) *TeamTokenHandler {
 // CreateTeamTokenRequest 创建团队Token请求
 type CreateTeamTokenRequest struct {
 	TokenName     string `json:"token_name" binding:"required"`
-	ExpiresInDays int    `json:"expires_in_days"` // 0表示永不过期
+	ExpiresInDays int    `json:"expires_in_days"` // 必填，不超过 API Token 策略的最长有效期
+	// Scopes 必填；传 {} 表示不额外限制（仍受团队权限约束）
+	Scopes *valueobject.TokenScope `json:"scopes"`
 }
 
 // CreateTeamToken 创建团队Token
@@ -55,7 +58,7 @@ func (h *TeamTokenHandler) CreateTeamToken(c *gin.Context) {
 	}
 
 	// 创建token
-	token, err := h.service.GenerateToken(c.Request.Context(), teamID, req.TokenName, userID.(string), req.ExpiresInDays)
+	token, err := h.service.GenerateToken(c.Request.Context(), teamID, req.TokenName, userID.(string), req.ExpiresInDays, req.Scopes)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
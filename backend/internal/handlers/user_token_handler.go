@@ -1,9 +1,11 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"iac-platform/internal/application/service"
+	"iac-platform/internal/domain/valueobject"
 	"iac-platform/internal/models"
 
 	"github.com/gin-gonic/gin"
@@ -28,7 +30,9 @@ func NewUserTokenHandler(service *service.UserTokenService, db *gorm.DB) *UserTo
 // CreateUserTokenRequest 创建用户Token请求
 type CreateUserTokenRequest struct {
 	TokenName     string `json:"token_name" binding:"required"`
-	ExpiresInDays int    `json:"expires_in_days"` // 0表示永不过期
+	ExpiresInDays int    `json:"expires_in_days"` // 必填，不超过 API Token 策略的最长有效期
+	// Scopes 必填；传 {} 表示不额外限制（仍受持有者权限约束）
+	Scopes *valueobject.TokenScope `json:"scopes"`
 }
 
 // CreateUserToken 创建用户Token
@@ -57,16 +61,10 @@ func (h *UserTokenHandler) CreateUserToken(c *gin.Context) {
 		return
 	}
 
-	// 验证过期天数
-	if req.ExpiresInDays < 0 || req.ExpiresInDays > 365 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "expires_in_days must be between 0 and 365"})
-		return
-	}
-
-	// 生成token
-	tokenResp, err := h.service.GenerateToken(c.Request.Context(), userID.(string), req.TokenName, req.ExpiresInDays)
+	// 生成token（过期时间和作用域在服务中校验）
+	tokenResp, err := h.service.GenerateToken(c.Request.Context(), userID.(string), req.TokenName, req.ExpiresInDays, req.Scopes)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(tokenErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -213,3 +211,16 @@ func (h *UserTokenHandler) ChangePassword(c *gin.Context) {
 		"message": "Password changed successfully",
 	})
 }
+
+// tokenErrorStatus 将Token创建错误映射为HTTP状态码
+func tokenErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrTokenExpiryRequired),
+		errors.Is(err, service.ErrTokenExpiryTooLong),
+		errors.Is(err, service.ErrTokenScopeRequired),
+		errors.Is(err, service.ErrInvalidTokenScope):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -7,7 +7,9 @@ import (
 	"net/http"
 	"time"
 
+	"iac-platform/internal/middleware"
 	"iac-platform/internal/models"
+	"iac-platform/services"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -426,6 +428,18 @@ func (h *WorkspaceRunTaskHandler) OverrideRunTasks(c *gin.Context) {
 		})
 	}
 
+	ev := middleware.AuditEventFromContext(c, models.AuditEventRunTaskOverridden)
+	ev.ResourceType = "task"
+	ev.ResourceID = fmt.Sprintf("%d", taskID)
+	ev.WorkspaceID = task.WorkspaceID
+	ev.TaskID = &taskID
+	ev.Message = fmt.Sprintf("%d advisory run task result(s) overridden", overriddenCount)
+	ev.Details = models.JSONB{
+		"overridden_count": overriddenCount,
+		"comment":          req.Comment,
+	}
+	services.NewAuditEventService(h.db).Emit(ev)
+
 	c.JSON(http.StatusOK, gin.H{
 		"message":       "run tasks overridden successfully",
 		"overridden":    true,
@@ -128,6 +128,54 @@ func GenerateVariableID() (string, error) {
 	return generateRandomID("var", 16)
 }
 
+// GenerateWorkspaceTemplateID 生成工作空间模板ID
+// 格式: wstpl-{16位随机小写字母+数字}
+func GenerateWorkspaceTemplateID() (string, error) {
+	return generateRandomID("wstpl", 16)
+}
+
+// GenerateWorkspaceRunTaskID 生成 Workspace Run Task 关联ID
+// 格式: wrt-{16位随机小写字母+数字}
+func GenerateWorkspaceRunTaskID() (string, error) {
+	return generateRandomID("wrt", 16)
+}
+
+// GenerateManifestDeploymentResourceID 生成 Manifest 部署资源关联ID
+// 格式: mdr-{16位随机小写字母+数字}
+func GenerateManifestDeploymentResourceID() (string, error) {
+	return generateRandomID("mdr", 16)
+}
+
+// GenerateManifestDeploymentID 生成 Manifest 部署ID
+// 格式: mfd-{16位随机小写字母+数字}
+func GenerateManifestDeploymentID() (string, error) {
+	return generateRandomID("mfd", 16)
+}
+
+// GenerateManifestRolloutID 生成 Manifest 批量发布ID
+// 格式: mfr-{16位随机小写字母+数字}
+func GenerateManifestRolloutID() (string, error) {
+	return generateRandomID("mfr", 16)
+}
+
+// GenerateChangeFreezeWindowID 生成变更冻结窗口ID
+// 格式: cfz-{16位随机小写字母+数字}
+func GenerateChangeFreezeWindowID() (string, error) {
+	return generateRandomID("cfz", 16)
+}
+
+// GenerateStackID 生成 Stack ID
+// 格式: stk-{16位随机小写字母+数字}
+func GenerateStackID() (string, error) {
+	return generateRandomID("stk", 16)
+}
+
+// GenerateStackRunID 生成 Stack 执行ID
+// 格式: skr-{16位随机小写字母+数字}
+func GenerateStackRunID() (string, error) {
+	return generateRandomID("skr", 16)
+}
+
 // generateRandomID 生成指定前缀和长度的随机ID
 func generateRandomID(prefix string, length int) (string, error) {
 	const charset = "abcdefghijklmnopqrstuvwxyz0123456789"
@@ -0,0 +1,244 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"iac-platform/internal/application/service"
+	"iac-platform/internal/domain/valueobject"
+	"iac-platform/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupTokenTestDB(t *testing.T) *gorm.DB {
+	t.Setenv("JWT_SECRET", "api-token-test-secret")
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, db.Exec(`CREATE TABLE teams (team_id TEXT PRIMARY KEY, name TEXT)`).Error)
+	require.NoError(t, db.Exec(`CREATE TABLE team_tokens (
+		token_id_hash TEXT PRIMARY KEY,
+		team_id TEXT NOT NULL,
+		token_name TEXT NOT NULL,
+		token_hash TEXT,
+		is_active BOOLEAN NOT NULL DEFAULT 1,
+		created_at DATETIME,
+		created_by TEXT,
+		revoked_at DATETIME,
+		revoked_by TEXT,
+		last_used_at DATETIME,
+		last_used_ip TEXT,
+		expires_at DATETIME,
+		scopes TEXT
+	)`).Error)
+	require.NoError(t, db.Exec(`CREATE TABLE system_configs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		key TEXT UNIQUE NOT NULL,
+		value TEXT NOT NULL,
+		description TEXT,
+		updated_by INTEGER,
+		updated_at DATETIME,
+		deleted_at DATETIME
+	)`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO teams (team_id, name) VALUES ('team-ci', 'ci')`).Error)
+
+	prev := globalDB
+	SetGlobalDB(db)
+	t.Cleanup(func() { SetGlobalDB(prev) })
+	return db
+}
+
+func serveWithToken(token, remoteAddr string) (*httptest.ResponseRecorder, *gin.Context) {
+	var captured *gin.Context
+	r := gin.New()
+	r.GET("/probe", JWTAuth(), func(c *gin.Context) {
+		captured = c
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/probe", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.RemoteAddr = remoteAddr
+	r.ServeHTTP(w, req)
+	return w, captured
+}
+
+func TestTeamToken_ScopeExpiryAndIPAllowlist(t *testing.T) {
+	db := setupTokenTestDB(t)
+	ctx := context.Background()
+	svc := service.NewTeamTokenService(db, "")
+
+	// 必须指定有效期和作用域
+	_, err := svc.GenerateToken(ctx, "team-ci", "no-expiry", "user-admin", 0, &valueobject.TokenScope{})
+	assert.True(t, errors.Is(err, service.ErrTokenExpiryRequired))
+	_, err = svc.GenerateToken(ctx, "team-ci", "too-long", "user-admin", 400, &valueobject.TokenScope{})
+	assert.True(t, errors.Is(err, service.ErrTokenExpiryTooLong))
+	_, err = svc.GenerateToken(ctx, "team-ci", "no-scope", "user-admin", 30, nil)
+	assert.True(t, errors.Is(err, service.ErrTokenScopeRequired))
+	_, err = svc.GenerateToken(ctx, "team-ci", "bad-type", "user-admin", 30, &valueobject.TokenScope{ResourceTypes: []string{"NOPE"}})
+	assert.True(t, errors.Is(err, service.ErrInvalidTokenScope))
+
+	// 管理员调低上限后按新上限校验
+	require.NoError(t, service.SaveAPITokenPolicy(ctx, db, &service.APITokenPolicy{MaxUserTokenDays: 30, MaxTeamTokenDays: 30}))
+	_, err = svc.GenerateToken(ctx, "team-ci", "over-policy", "user-admin", 31, &valueobject.TokenScope{})
+	assert.True(t, errors.Is(err, service.ErrTokenExpiryTooLong))
+
+	scope := &valueobject.TokenScope{
+		ReadOnly:     true,
+		WorkspaceIDs: []string{"ws-ci"},
+		IPAllowlist:  []string{"10.0.0.0/8"},
+	}
+	created, err := svc.GenerateToken(ctx, "team-ci", "ci", "user-admin", 30, scope)
+	require.NoError(t, err)
+	require.NotNil(t, created.ExpiresAt)
+
+	// 白名单内的地址：团队身份 + 作用域写入context，记录最后使用信息
+	w, c := serveWithToken(created.Token, "10.1.2.3:5000")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "team-ci", c.GetString("team_id"))
+	_, hasUser := c.Get("user_id")
+	assert.False(t, hasUser)
+	got := GetTokenScope(c)
+	require.NotNil(t, got)
+	assert.True(t, got.ReadOnly)
+	assert.Equal(t, []string{"ws-ci"}, got.WorkspaceIDs)
+
+	var stored models.TeamToken
+	require.NoError(t, db.Where("token_name = ?", "ci").First(&stored).Error)
+	require.NotNil(t, stored.LastUsedAt)
+	assert.Equal(t, "10.1.2.3", stored.LastUsedIP)
+
+	// 白名单外的地址被拒绝
+	w, _ = serveWithToken(created.Token, "192.168.1.10:5000")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// 数据库中的过期时间生效（即使 JWT 本身尚未过期）
+	require.NoError(t, db.Model(&models.TeamToken{}).Where("token_name = ?", "ci").
+		Update("expires_at", time.Now().Add(-time.Minute)).Error)
+	w, _ = serveWithToken(created.Token, "10.1.2.3:5000")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestTokenScope_MaxLevel(t *testing.T) {
+	scope := &valueobject.TokenScope{
+		ReadOnly:      true,
+		WorkspaceIDs:  []string{"ws-a"},
+		ProjectIDs:    []uint{7},
+		ResourceTypes: []string{string(valueobject.ResourceTypeWorkspaceState)},
+	}
+
+	cases := []struct {
+		name   string
+		target valueobject.TokenScopeTarget
+		want   valueobject.PermissionLevel
+	}{
+		{"listed workspace is capped at read", valueobject.TokenScopeTarget{
+			ResourceType: valueobject.ResourceTypeWorkspaceState, ScopeType: valueobject.ScopeTypeWorkspace, WorkspaceID: "ws-a", ProjectID: 1,
+		}, valueobject.PermissionLevelRead},
+		{"workspace in listed project", valueobject.TokenScopeTarget{
+			ResourceType: valueobject.ResourceTypeWorkspaceState, ScopeType: valueobject.ScopeTypeWorkspace, WorkspaceID: "ws-b", ProjectID: 7,
+		}, valueobject.PermissionLevelRead},
+		{"other workspace", valueobject.TokenScopeTarget{
+			ResourceType: valueobject.ResourceTypeWorkspaceState, ScopeType: valueobject.ScopeTypeWorkspace, WorkspaceID: "ws-b", ProjectID: 1,
+		}, valueobject.PermissionLevelNone},
+		{"resource type not allowed", valueobject.TokenScopeTarget{
+			ResourceType: valueobject.ResourceTypeWorkspaceManagement, ScopeType: valueobject.ScopeTypeWorkspace, WorkspaceID: "ws-a", ProjectID: 1,
+		}, valueobject.PermissionLevelNone},
+		{"organization level denied for scoped token", valueobject.TokenScopeTarget{
+			ResourceType: valueobject.ResourceTypeWorkspaceState, ScopeType: valueobject.ScopeTypeOrganization,
+		}, valueobject.PermissionLevelNone},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, _ := scope.MaxLevel(tc.target)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+
+	var legacy *valueobject.TokenScope
+	got, _ := legacy.MaxLevel(valueobject.TokenScopeTarget{ScopeType: valueobject.ScopeTypeOrganization})
+	assert.Equal(t, valueobject.PermissionLevelAdmin, got)
+	assert.False(t, legacy.LimitsPermissions())
+}
+
+func TestPermissionChecker_WorkspaceScopedTokenWithoutWorkspaceIsDenied(t *testing.T) {
+	checker := service.NewPermissionChecker(nil, nil, nil, nil, nil)
+	result, err := checker.CheckPermission(context.Background(), &service.CheckPermissionRequest{
+		UserID:        "user-1",
+		ResourceType:  valueobject.ResourceTypeWorkspaceState,
+		ScopeType:     valueobject.ScopeTypeWorkspace,
+		RequiredLevel: valueobject.PermissionLevelRead,
+		TokenScope:    &valueobject.TokenScope{WorkspaceIDs: []string{"ws-a"}},
+	})
+	require.NoError(t, err)
+	assert.False(t, result.IsAllowed)
+	assert.Equal(t, "token scope does not include this workspace", result.DenyReason)
+}
+
+func TestRequireLoginToken_ScopedTokenCannotMintUnscopedToken(t *testing.T) {
+	db := setupTokenTestDB(t)
+	ctx := context.Background()
+	require.NoError(t, db.Exec(`CREATE TABLE users (user_id TEXT PRIMARY KEY, username TEXT, is_active BOOLEAN DEFAULT 1, is_system_admin BOOLEAN DEFAULT 0)`).Error)
+	require.NoError(t, db.Exec(`CREATE TABLE user_tokens (
+		token_id_hash TEXT PRIMARY KEY, user_id TEXT NOT NULL, token_name TEXT NOT NULL, token_hash TEXT,
+		is_active BOOLEAN NOT NULL DEFAULT 1, created_at DATETIME, revoked_at DATETIME, last_used_at DATETIME,
+		expires_at DATETIME, last_used_ip TEXT, scopes TEXT)`).Error)
+	require.NoError(t, db.Exec(`CREATE TABLE login_sessions (
+		session_id TEXT PRIMARY KEY, user_id TEXT, is_active BOOLEAN, expires_at DATETIME, last_used_at DATETIME)`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO users (user_id, username) VALUES ('user-dev', 'dev')`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO login_sessions (session_id, user_id, is_active, expires_at) VALUES ('sess-1', 'user-dev', 1, ?)`,
+		time.Now().Add(time.Hour)).Error)
+
+	svc := service.NewUserTokenService(db, "")
+	readOnly, err := svc.GenerateToken(ctx, "user-dev", "read-only", 30,
+		&valueobject.TokenScope{ReadOnly: true, WorkspaceIDs: []string{"ws-dev"}})
+	require.NoError(t, err)
+
+	// 与路由一致：JWTAuth + RequireLoginToken 后才签发
+	minted := false
+	r := gin.New()
+	r.POST("/user/tokens", JWTAuth(), RequireLoginToken(), func(c *gin.Context) {
+		_, err := svc.GenerateToken(c.Request.Context(), c.GetString("user_id"), "escalated", 30, &valueobject.TokenScope{})
+		require.NoError(t, err)
+		minted = true
+		c.Status(http.StatusCreated)
+	})
+	post := func(token string) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/user/tokens", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusForbidden, post(readOnly.Token))
+	assert.False(t, minted)
+	var count int64
+	require.NoError(t, db.Model(&models.UserToken{}).Where("token_name = ?", "escalated").Count(&count).Error)
+	assert.Zero(t, count)
+
+	// 登录会话仍可签发
+	login := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": "user-dev", "username": "dev", "type": "login_token", "session_id": "sess-1",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	loginToken, err := login.SignedString([]byte("api-token-test-secret"))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, post(loginToken))
+	assert.True(t, minted)
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"fmt"
+
+	"iac-platform/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditEventFromContext 基于请求上下文构造领域审计事件（填充操作人和来源IP）
+func AuditEventFromContext(c *gin.Context, eventType models.AuditEventType) *models.AuditEvent {
+	ev := &models.AuditEvent{
+		EventType: eventType,
+		ActorType: models.AuditActorUser,
+		SourceIP:  c.ClientIP(),
+	}
+	if uid, ok := c.Get("user_id"); ok {
+		ev.ActorID = fmt.Sprintf("%v", uid)
+	}
+	if username, ok := c.Get("username"); ok {
+		ev.ActorName = fmt.Sprintf("%v", username)
+	}
+	return ev
+}
@@ -36,13 +36,21 @@ func (m *IAMPermissionMiddleware) RequirePermission(
 ) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 0. 系统管理员直接通过（is_system_admin 仅在系统初始化时设置）
-		if isSystemAdmin, _ := c.Get("is_system_admin"); isSystemAdmin == true {
+		// 使用带作用域限制的 Token 时仍需按作用域检查
+		isSystemAdmin := c.GetBool("is_system_admin")
+		tokenScope := GetTokenScope(c)
+		if isSystemAdmin && !tokenScope.LimitsPermissions() {
 			c.Next()
 			return
 		}
 
 		// 1. 获取用户ID
+		// Team token 没有 user_id，以 team_id 作为主体
 		userID, exists := c.Get("user_id")
+		teamID := c.GetString("team_id")
+		if !exists && teamID != "" {
+			userID, exists = "", true
+		}
 		if !exists {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"code":      401,
@@ -136,6 +144,9 @@ func (m *IAMPermissionMiddleware) RequirePermission(
 			ScopeID:       scopeIDUint,
 			ScopeIDStr:    scopeIDStr, // 支持语义化ID
 			RequiredLevel: rl,
+			TeamID:        teamID,
+			SystemAdmin:   isSystemAdmin,
+			TokenScope:    tokenScope,
 		}
 
 		result, err := m.permissionChecker.CheckPermission(c.Request.Context(), req)
@@ -181,12 +192,20 @@ func (m *IAMPermissionMiddleware) RequireAnyPermission(
 ) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 0. 系统管理员直接通过（is_system_admin 仅在系统初始化时设置）
-		if isSystemAdmin, _ := c.Get("is_system_admin"); isSystemAdmin == true {
+		// 使用带作用域限制的 Token 时仍需按作用域检查
+		isSystemAdmin := c.GetBool("is_system_admin")
+		tokenScope := GetTokenScope(c)
+		if isSystemAdmin && !tokenScope.LimitsPermissions() {
 			c.Next()
 			return
 		}
 
+		// Team token 没有 user_id，以 team_id 作为主体
 		userID, exists := c.Get("user_id")
+		teamID := c.GetString("team_id")
+		if !exists && teamID != "" {
+			userID, exists = "", true
+		}
 		if !exists {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"code":      401,
@@ -241,6 +260,9 @@ func (m *IAMPermissionMiddleware) RequireAnyPermission(
 				ScopeID:       scopeIDUint,
 				ScopeIDStr:    scopeIDStr,
 				RequiredLevel: rl,
+				TeamID:        teamID,
+				SystemAdmin:   isSystemAdmin,
+				TokenScope:    tokenScope,
 			}
 
 			result, err := m.permissionChecker.CheckPermission(c.Request.Context(), req)
@@ -265,6 +287,61 @@ func (m *IAMPermissionMiddleware) RequireAnyPermission(
 	}
 }
 
+// CheckScopePermission 在 handler 中检查当前主体对指定作用域的权限
+// 用于作用域 ID 不在路径中（如来自请求体）的场景；系统管理员与 Token 作用域的处理与 RequirePermission 一致
+func (m *IAMPermissionMiddleware) CheckScopePermission(
+	c *gin.Context,
+	resourceType string,
+	scopeType string,
+	scopeID string,
+	requiredLevel string,
+) (bool, error) {
+	isSystemAdmin := c.GetBool("is_system_admin")
+	tokenScope := GetTokenScope(c)
+	if isSystemAdmin && !tokenScope.LimitsPermissions() {
+		return true, nil
+	}
+
+	userID := c.GetString("user_id")
+	teamID := c.GetString("team_id")
+	if userID == "" && teamID == "" {
+		return false, nil
+	}
+
+	rt, err := valueobject.ParseResourceType(resourceType)
+	if err != nil {
+		return false, err
+	}
+	st, err := valueobject.ParseScopeType(scopeType)
+	if err != nil {
+		return false, err
+	}
+	rl, err := valueobject.ParsePermissionLevel(requiredLevel)
+	if err != nil {
+		return false, err
+	}
+
+	req := &service.CheckPermissionRequest{
+		UserID:        userID,
+		ResourceType:  rt,
+		ScopeType:     st,
+		RequiredLevel: rl,
+		TeamID:        teamID,
+		SystemAdmin:   isSystemAdmin,
+		TokenScope:    tokenScope,
+	}
+	if _, err := fmt.Sscanf(scopeID, "%d", &req.ScopeID); err != nil || req.ScopeID == 0 {
+		req.ScopeID = 0
+		req.ScopeIDStr = scopeID
+	}
+
+	result, err := m.permissionChecker.CheckPermission(c.Request.Context(), req)
+	if err != nil {
+		return false, err
+	}
+	return result.IsAllowed, nil
+}
+
 // PermissionRequirement 权限要求
 type PermissionRequirement struct {
 	ResourceType  string
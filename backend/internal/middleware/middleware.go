@@ -10,6 +10,8 @@ import (
 	"time"
 
 	"iac-platform/internal/config"
+	"iac-platform/internal/domain/valueobject"
+	"iac-platform/internal/models"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
@@ -130,6 +132,9 @@ func JWTAuth() gin.HandlerFunc {
 			return
 		}
 
+		// 检查token类型并验证
+		tokenType, _ := claims["type"].(string)
+
 		// 兼容新旧格式的user_id
 		userIDSet := false
 		userIDValue := claims["user_id"]
@@ -149,7 +154,8 @@ func JWTAuth() gin.HandlerFunc {
 			userIDSet = true
 		}
 
-		if !userIDSet {
+		// Team token 以团队身份访问，不携带 user_id
+		if !userIDSet && tokenType != "team_token" {
 			log.Printf("[JWT] Invalid user_id type in token: %T", userIDValue)
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"code":      401,
@@ -161,9 +167,8 @@ func JWTAuth() gin.HandlerFunc {
 		}
 
 		c.Set("username", claims["username"])
+		c.Set("token_type", tokenType)
 
-		// 检查token类型并验证
-		tokenType, _ := claims["type"].(string)
 		if tokenType == "login_token" {
 			// Login token: 必须验证session_id在数据库中存在且有效
 			sessionID, _ := claims["session_id"].(string)
@@ -259,13 +264,9 @@ func JWTAuth() gin.HandlerFunc {
 			tokenIDHashStr := base64.StdEncoding.EncodeToString(tokenIDHash[:])
 
 			// 验证token在数据库中存在且有效（使用hash）
-			var dbToken struct {
-				UserID   string
-				IsActive bool
-			}
+			var dbToken models.UserToken
 			userID := c.GetString("user_id")
-			err := globalDB.Table("user_tokens").
-				Select("user_id, is_active").
+			err := globalDB.
 				Where("token_id_hash = ? AND user_id = ? AND is_active = ?", tokenIDHashStr, userID, true).
 				First(&dbToken).Error
 
@@ -279,6 +280,11 @@ func JWTAuth() gin.HandlerFunc {
 				return
 			}
 
+			// 检查有效期、来源地址，记录最后使用信息
+			if !checkAPITokenUsage(c, "user_tokens", tokenIDHashStr, dbToken.ExpiresAt, dbToken.LastUsedAt, dbToken.Scopes) {
+				return
+			}
+
 			// 检查用户是否有活跃的login session（增强安全：user token需要登录状态）
 			var activeSessionCount int64
 			globalDB.Table("login_sessions").
@@ -341,12 +347,8 @@ func JWTAuth() gin.HandlerFunc {
 			tokenIDHashStr := base64.StdEncoding.EncodeToString(tokenIDHash[:])
 
 			// 验证token在数据库中存在且有效（使用hash）
-			var dbToken struct {
-				TeamID   string
-				IsActive bool
-			}
-			err := globalDB.Table("team_tokens").
-				Select("team_id, is_active").
+			var dbToken models.TeamToken
+			err := globalDB.
 				Where("token_id_hash = ? AND is_active = ?", tokenIDHashStr, true).
 				First(&dbToken).Error
 
@@ -360,7 +362,12 @@ func JWTAuth() gin.HandlerFunc {
 				return
 			}
 
-			// Team token不需要login session检查，可以长期使用
+			// 检查有效期、来源地址，记录最后使用信息
+			if !checkAPITokenUsage(c, "team_tokens", tokenIDHashStr, dbToken.ExpiresAt, dbToken.LastUsedAt, dbToken.Scopes) {
+				return
+			}
+
+			// Team token不需要login session检查
 			// 设置team_id到context
 			c.Set("team_id", dbToken.TeamID)
 
@@ -382,11 +389,77 @@ func JWTAuth() gin.HandlerFunc {
 	}
 }
 
+// tokenLastUsedInterval 最后使用时间的最小更新间隔，避免每个请求都写库
+const tokenLastUsedInterval = time.Minute
+
+// checkAPITokenUsage 校验 API Token 的有效期和 IP 白名单，记录最后使用信息，并将作用域写入context
+// 校验失败时已写入响应并中止请求，返回 false
+func checkAPITokenUsage(c *gin.Context, table, tokenIDHash string, expiresAt, lastUsedAt *time.Time, scope *valueobject.TokenScope) bool {
+	now := time.Now()
+	if expiresAt != nil && expiresAt.Before(now) {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":      401,
+			"message":   "Token has expired",
+			"timestamp": now,
+		})
+		c.Abort()
+		return false
+	}
+
+	clientIP := c.ClientIP()
+	if !scope.AllowsIP(clientIP) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"code":      403,
+			"message":   "Token is not allowed from this IP address",
+			"timestamp": now,
+		})
+		c.Abort()
+		return false
+	}
+
+	if lastUsedAt == nil || now.Sub(*lastUsedAt) >= tokenLastUsedInterval {
+		globalDB.Table(table).Where("token_id_hash = ?", tokenIDHash).
+			Updates(map[string]interface{}{"last_used_at": now, "last_used_ip": clientIP})
+	}
+
+	if scope != nil {
+		c.Set("token_scope", scope)
+	}
+	return true
+}
+
+// RequireLoginToken 要求请求使用登录会话（login_token）认证
+// 用于签发 API Token：API Token 不能再签发新 Token，否则可以绕过自身的作用域和有效期限制
+func RequireLoginToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("token_type") != "login_token" {
+			c.JSON(http.StatusForbidden, gin.H{
+				"code":      403,
+				"message":   "API tokens cannot create tokens, please sign in to create one",
+				"timestamp": time.Now(),
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// GetTokenScope 获取当前请求使用的 API Token 作用域，非 API Token 请求返回 nil
+func GetTokenScope(c *gin.Context) *valueobject.TokenScope {
+	if v, ok := c.Get("token_scope"); ok {
+		if scope, ok := v.(*valueobject.TokenScope); ok {
+			return scope
+		}
+	}
+	return nil
+}
+
 // RequireSystemAdmin 要求当前用户是系统管理员（is_system_admin=true）
-// 用于系统级管理操作（如SSO配置），不走IAM权限体系
+// 用于系统级管理操作（如SSO配置），不走IAM权限体系；带作用域限制的 Token 不能访问
 func RequireSystemAdmin() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if isSystemAdmin, _ := c.Get("is_system_admin"); isSystemAdmin == true {
+		if isSystemAdmin, _ := c.Get("is_system_admin"); isSystemAdmin == true && !GetTokenScope(c).LimitsPermissions() {
 			c.Next()
 			return
 		}
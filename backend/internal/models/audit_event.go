@@ -0,0 +1,138 @@
+package models
+
+import (
+	"time"
+)
+
+// AuditEventType 审计事件类型
+type AuditEventType string
+
+const (
+	AuditEventApplyConfirmed    AuditEventType = "apply_confirmed"     // 用户确认 Apply
+	AuditEventTaskCancelled     AuditEventType = "task_cancelled"      // 任务被取消
+	AuditEventStateRolledBack   AuditEventType = "state_rolled_back"   // State 回滚
+	AuditEventVariableRead      AuditEventType = "variable_read"       // 读取敏感变量
+	AuditEventRunTaskOverridden AuditEventType = "run_task_overridden" // Run Task 失败被覆盖
+	AuditEventSecretAccessed    AuditEventType = "secret_accessed"     // 密文被读取或解密
+
+	AuditEventApplyBlockedByFreeze     AuditEventType = "apply_blocked_by_freeze"    // Apply 因变更冻结被挂起
+	AuditEventFreezeExceptionRequested AuditEventType = "freeze_exception_requested" // 申请冻结例外
+	AuditEventFreezeExceptionApproved  AuditEventType = "freeze_exception_approved"  // 冻结例外被批准
+	AuditEventFreezeExceptionRejected  AuditEventType = "freeze_exception_rejected"  // 冻结例外被拒绝
+
+	AuditEventStackRunStarted   AuditEventType = "stack_run_started"   // 发起 Stack 执行
+	AuditEventStackRunApproved  AuditEventType = "stack_run_approved"  // Stack 执行审核通过，开始按顺序 Apply
+	AuditEventStackRunCancelled AuditEventType = "stack_run_cancelled" // Stack 执行被取消
+	AuditEventStackRunFinished  AuditEventType = "stack_run_finished"  // Stack 执行结束（完成或失败）
+)
+
+// AuditActorType 审计事件发起者类型
+type AuditActorType string
+
+const (
+	AuditActorUser   AuditActorType = "user"
+	AuditActorAgent  AuditActorType = "agent"
+	AuditActorSystem AuditActorType = "system"
+)
+
+// AuditOutcome 审计事件结果
+type AuditOutcome string
+
+const (
+	AuditOutcomeSuccess AuditOutcome = "success"
+	AuditOutcomeFailure AuditOutcome = "failure"
+	AuditOutcomeDenied  AuditOutcome = "denied"
+)
+
+// AuditEvent 领域审计事件（只追加，哈希链防篡改）
+// 每条记录的 Hash = SHA256(PrevHash + 规范化事件内容)，任何修改或删除都会使后续链条校验失败
+type AuditEvent struct {
+	ID           uint64         `json:"id" gorm:"primaryKey;autoIncrement"`
+	EventID      string         `json:"event_id" gorm:"column:event_id;type:varchar(50);uniqueIndex;not null"`
+	EventType    AuditEventType `json:"event_type" gorm:"type:varchar(50);not null;index"`
+	OccurredAt   time.Time      `json:"occurred_at" gorm:"not null;index"`
+	ActorType    AuditActorType `json:"actor_type" gorm:"type:varchar(20);not null"`
+	ActorID      string         `json:"actor_id" gorm:"type:varchar(100);index"`
+	ActorName    string         `json:"actor_name" gorm:"type:varchar(255)"`
+	SourceIP     string         `json:"source_ip" gorm:"type:varchar(64)"`
+	ResourceType string         `json:"resource_type" gorm:"type:varchar(50);index:idx_audit_events_resource"`
+	ResourceID   string         `json:"resource_id" gorm:"type:varchar(255);index:idx_audit_events_resource"`
+	WorkspaceID  string         `json:"workspace_id,omitempty" gorm:"type:varchar(50);index"`
+	TaskID       *uint          `json:"task_id,omitempty" gorm:"index"`
+	Outcome      AuditOutcome   `json:"outcome" gorm:"type:varchar(20);not null;default:success"`
+	Message      string         `json:"message" gorm:"type:text"`
+	Details      JSONB          `json:"details" gorm:"type:jsonb"`
+
+	// 哈希链
+	PrevHash string `json:"prev_hash" gorm:"type:varchar(64);not null"`
+	Hash     string `json:"hash" gorm:"type:varchar(64);not null;uniqueIndex"`
+}
+
+// TableName 指定表名
+func (AuditEvent) TableName() string {
+	return "audit_events"
+}
+
+// AuditExporterType 审计事件导出器类型
+type AuditExporterType string
+
+const (
+	AuditExporterSyslog    AuditExporterType = "syslog"     // Syslog (RFC 5424) + CEF 载荷
+	AuditExporterJSONLines AuditExporterType = "json_lines" // JSON Lines 文件
+	AuditExporterHTTP      AuditExporterType = "http"       // 通用 HTTP 批量推送
+)
+
+// AuditExporter 审计事件导出配置
+// 每个导出器独立维护游标（LastExportedID），保证至少一次投递；失败时游标不前进，下次重试
+type AuditExporter struct {
+	ID           uint              `json:"id" gorm:"primaryKey"`
+	ExporterID   string            `json:"exporter_id" gorm:"column:exporter_id;type:varchar(50);uniqueIndex;not null"`
+	Name         string            `json:"name" gorm:"type:varchar(100);not null"`
+	Description  string            `json:"description" gorm:"type:text"`
+	ExporterType AuditExporterType `json:"exporter_type" gorm:"type:varchar(20);not null"`
+
+	// 类型相关配置:
+	//   syslog:     {"network": "udp|tcp", "address": "host:514", "app_name": "iac-platform", "facility": 13}
+	//   json_lines: {"path": "/var/log/iac/audit.jsonl"}
+	//   http:       {"url": "https://siem/ingest", "headers": {...}, "timeout_seconds": 10}
+	Config JSONB `json:"config" gorm:"type:jsonb"`
+
+	// 认证密钥（HTTP Bearer 等，加密存储，不返回给前端）
+	SecretEncrypted string `json:"-" gorm:"column:secret_encrypted;type:text"`
+
+	// 事件过滤（逗号分隔，空表示全部）
+	EventTypes string `json:"event_types" gorm:"type:varchar(500)"`
+
+	Enabled              bool `json:"enabled" gorm:"default:true"`
+	BatchSize            int  `json:"batch_size" gorm:"default:100"`
+	RetryCount           int  `json:"retry_count" gorm:"default:3"`
+	RetryIntervalSeconds int  `json:"retry_interval_seconds" gorm:"default:5"`
+
+	// 投递进度
+	LastExportedID uint64     `json:"last_exported_id" gorm:"default:0"`
+	LastExportedAt *time.Time `json:"last_exported_at"`
+	LastError      string     `json:"last_error" gorm:"type:text"`
+
+	CreatedBy *string   `json:"created_by" gorm:"type:varchar(50)"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (AuditExporter) TableName() string {
+	return "audit_exporters"
+}
+
+// AuditExporterResponse API 响应结构（隐藏密钥）
+type AuditExporterResponse struct {
+	AuditExporter
+	SecretSet bool `json:"secret_set"`
+}
+
+// ToResponse 转换为 API 响应结构
+func (e *AuditExporter) ToResponse() AuditExporterResponse {
+	return AuditExporterResponse{
+		AuditExporter: *e,
+		SecretSet:     e.SecretEncrypted != "",
+	}
+}
@@ -0,0 +1,162 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// 冻结窗口作用范围
+const (
+	ChangeFreezeScopeOrganization = "organization" // 所有 Workspace
+	ChangeFreezeScopeProject      = "project"      // 指定项目下的 Workspace
+)
+
+// 冻结窗口类型
+const (
+	ChangeFreezeTypeOnce   = "once"   // 一次性日期范围（节假日封网）
+	ChangeFreezeTypeWeekly = "weekly" // 每周重复的时间段
+)
+
+// 冻结例外申请状态
+const (
+	ChangeFreezeExceptionPending  = "pending"
+	ChangeFreezeExceptionApproved = "approved"
+	ChangeFreezeExceptionRejected = "rejected"
+)
+
+// ChangeFreezeTimeLayout 冻结窗口的日期时间格式，按窗口时区解释（与 HTML datetime-local 一致）
+const ChangeFreezeTimeLayout = "2006-01-02T15:04"
+
+// ChangeFreezeWindow 组织/项目级变更冻结窗口
+// 窗口生效期间，匹配范围内的 Workspace 仍可执行 plan，但 apply 会被 TaskQueueManager 挂起在
+// apply_pending（stage = change_freeze），直到窗口结束或该次运行的例外申请被批准
+type ChangeFreezeWindow struct {
+	ID          string               `json:"id" gorm:"primaryKey;size:36"`                 // 格式: cfz-{16位随机字符}
+	Name        string               `json:"name" gorm:"size:100;not null"`                // 窗口名称
+	Description string               `json:"description" gorm:"type:text"`                 // 冻结原因，阻塞时展示给用户
+	Scope       string               `json:"scope" gorm:"size:20;not null"`                // organization, project
+	Selector    ChangeFreezeSelector `json:"selector" gorm:"type:jsonb"`                   // 项目和 Workspace tags 过滤条件
+	Timezone    string               `json:"timezone" gorm:"size:64;not null;default:UTC"` // IANA 时区，如 Asia/Shanghai
+	Schedule    ChangeFreezeSchedule `json:"schedule" gorm:"type:jsonb"`                   // 日期范围或每周规则
+	Enabled     bool                 `json:"enabled" gorm:"index"`
+	CreatedBy   string               `json:"created_by" gorm:"size:20"`
+	UpdatedBy   string               `json:"updated_by" gorm:"size:20"`
+	CreatedAt   time.Time            `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time            `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (ChangeFreezeWindow) TableName() string {
+	return "change_freeze_windows"
+}
+
+// ChangeFreezeSelector 冻结范围过滤条件，多个条件取交集
+type ChangeFreezeSelector struct {
+	ProjectIDs []uint            `json:"project_ids,omitempty"` // scope = project 时必填，属于任一项目
+	Tags       map[string]string `json:"tags,omitempty"`        // 所有 tag 都匹配，为空表示不按 tag 过滤
+}
+
+// Value 实现 driver.Valuer 接口
+func (s ChangeFreezeSelector) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+// Scan 实现 sql.Scanner 接口
+func (s *ChangeFreezeSelector) Scan(value interface{}) error {
+	*s = ChangeFreezeSelector{}
+	return scanJSONValue(value, s)
+}
+
+// ChangeFreezeSchedule 冻结时间规则，时间均按窗口时区解释
+//   - once:   StartsAt ~ EndsAt 内全部冻结
+//   - weekly: Weekdays 中每天的 FromTime ~ ToTime 冻结，ToTime <= FromTime 表示跨越午夜；
+//     StartsAt / EndsAt 可选，用于限定规则的生效期间
+type ChangeFreezeSchedule struct {
+	Type     string `json:"type"`                // once, weekly
+	StartsAt string `json:"starts_at,omitempty"` // 格式: 2006-01-02T15:04
+	EndsAt   string `json:"ends_at,omitempty"`   // 格式: 2006-01-02T15:04（不含）
+	Weekdays []int  `json:"weekdays,omitempty"`  // 1=周一 ... 7=周日
+	FromTime string `json:"from_time,omitempty"` // HH:MM
+	ToTime   string `json:"to_time,omitempty"`   // HH:MM（不含）
+}
+
+// Value 实现 driver.Valuer 接口
+func (s ChangeFreezeSchedule) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+// Scan 实现 sql.Scanner 接口
+func (s *ChangeFreezeSchedule) Scan(value interface{}) error {
+	*s = ChangeFreezeSchedule{}
+	return scanJSONValue(value, s)
+}
+
+// scanJSONValue 解析 jsonb 列（PostgreSQL 驱动返回 []byte，SQLite 可能返回 string）
+func scanJSONValue(value interface{}, dest interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		if len(v) == 0 {
+			return nil
+		}
+		return json.Unmarshal(v, dest)
+	case string:
+		if v == "" {
+			return nil
+		}
+		return json.Unmarshal([]byte(v), dest)
+	default:
+		return nil
+	}
+}
+
+// ChangeFreezeWindowRequest 创建/更新冻结窗口请求
+type ChangeFreezeWindowRequest struct {
+	Name        string               `json:"name" binding:"required,max=100"`
+	Description string               `json:"description"`
+	Scope       string               `json:"scope" binding:"required,oneof=organization project"`
+	Selector    ChangeFreezeSelector `json:"selector"`
+	Timezone    string               `json:"timezone"`
+	Schedule    ChangeFreezeSchedule `json:"schedule"`
+	Enabled     *bool                `json:"enabled"`
+}
+
+// ActiveChangeFreeze 当前对某个 Workspace 生效的冻结窗口
+type ActiveChangeFreeze struct {
+	WindowID    string    `json:"window_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Scope       string    `json:"scope"`
+	Timezone    string    `json:"timezone"`
+	Until       time.Time `json:"until"` // 本次冻结结束时间
+}
+
+// ChangeFreezeException 单次运行的冻结例外申请
+// 批准后该任务的 apply 不再受任何冻结窗口限制；申请和审批均记录到审计事件链
+type ChangeFreezeException struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	TaskID        uint       `json:"task_id" gorm:"not null;index"`
+	WorkspaceID   string     `json:"workspace_id" gorm:"size:50;not null;index"`
+	WindowIDs     string     `json:"window_ids" gorm:"type:text"`                 // 申请时生效的冻结窗口（逗号分隔）
+	Reason        string     `json:"reason" gorm:"type:text;not null"`            // 申请理由
+	Status        string     `json:"status" gorm:"size:20;default:pending;index"` // pending, approved, rejected
+	RequestedBy   string     `json:"requested_by" gorm:"size:20;not null"`
+	ReviewedBy    *string    `json:"reviewed_by" gorm:"size:20"`
+	ReviewComment string     `json:"review_comment" gorm:"type:text"`
+	ReviewedAt    *time.Time `json:"reviewed_at"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+func (ChangeFreezeException) TableName() string {
+	return "change_freeze_exceptions"
+}
+
+// ChangeFreezeExceptionRequest 申请冻结例外
+type ChangeFreezeExceptionRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// ReviewChangeFreezeExceptionRequest 审批冻结例外
+type ReviewChangeFreezeExceptionRequest struct {
+	Comment string `json:"comment"`
+}
@@ -14,8 +14,14 @@ type CMDBExternalSource struct {
 	Name        string `gorm:"column:name;type:varchar(100);not null" json:"name"`
 	Description string `gorm:"column:description;type:text" json:"description,omitempty"`
 
+	// 数据源类型：http / file / sql
+	SourceType string `gorm:"column:source_type;type:varchar(20);default:'http'" json:"source_type"`
+
+	// 类型相关配置（分页、限流、文件、SQL）
+	SourceConfig datatypes.JSON `gorm:"column:source_config;type:jsonb" json:"source_config,omitempty"`
+
 	// API配置
-	APIEndpoint string `gorm:"column:api_endpoint;type:varchar(500);not null" json:"api_endpoint"`
+	APIEndpoint string `gorm:"column:api_endpoint;type:varchar(500)" json:"api_endpoint"`
 	HTTPMethod  string `gorm:"column:http_method;type:varchar(10);default:'GET'" json:"http_method"`
 	RequestBody string `gorm:"column:request_body;type:text" json:"request_body,omitempty"`
 
@@ -59,6 +65,97 @@ func (CMDBExternalSource) TableName() string {
 	return "cmdb_external_sources"
 }
 
+// 外部数据源类型
+const (
+	ExternalSourceTypeHTTP = "http"
+	ExternalSourceTypeFile = "file"
+	ExternalSourceTypeSQL  = "sql"
+)
+
+// HTTP 分页策略
+const (
+	PaginationNone       = "none"
+	PaginationOffset     = "offset"      // offset + limit 参数
+	PaginationPage       = "page"        // 页码 + 每页数量参数
+	PaginationCursor     = "cursor"      // 响应中返回下一页游标
+	PaginationLinkHeader = "link_header" // 响应头 Link: <url>; rel="next"
+)
+
+// ExternalSourceConfig 数据源类型相关配置
+type ExternalSourceConfig struct {
+	// HTTP
+	Pagination         *PaginationConfig `json:"pagination,omitempty"`
+	RateLimitPerSecond float64           `json:"rate_limit_per_second,omitempty"` // 每秒最多请求数，0 表示不限制
+
+	// 文件
+	File *FileSourceConfig `json:"file,omitempty"`
+
+	// SQL
+	SQL *SQLSourceConfig `json:"sql,omitempty"`
+}
+
+// PaginationConfig HTTP 分页配置
+type PaginationConfig struct {
+	Strategy       string `json:"strategy"`
+	PageSize       int    `json:"page_size,omitempty"`
+	LimitParam     string `json:"limit_param,omitempty"`      // offset/page 策略中每页数量参数名
+	OffsetParam    string `json:"offset_param,omitempty"`     // offset 策略的偏移参数名
+	PageParam      string `json:"page_param,omitempty"`       // page 策略的页码参数名
+	StartPage      int    `json:"start_page,omitempty"`       // page 策略的起始页码，默认 1
+	CursorParam    string `json:"cursor_param,omitempty"`     // cursor 策略中传递游标的参数名
+	NextCursorPath string `json:"next_cursor_path,omitempty"` // cursor 策略中下一页游标在响应中的路径，如 $.meta.next
+	MaxPages       int    `json:"max_pages,omitempty"`        // 最多请求的页数，防止死循环
+}
+
+// FileSourceConfig 文件导入配置
+type FileSourceConfig struct {
+	Format    string `json:"format"`               // csv / json / jsonl
+	Path      string `json:"path,omitempty"`       // 挂载目录中的文件路径（相对于导入根目录）
+	ObjectKey string `json:"object_key,omitempty"` // 上传文件在存储中的 key（上传后由服务端设置）
+	FileName  string `json:"file_name,omitempty"`  // 上传的原始文件名
+	Delimiter string `json:"delimiter,omitempty"`  // CSV 分隔符，默认逗号
+}
+
+// SQLSourceConfig SQL 查询配置
+type SQLSourceConfig struct {
+	Driver      string `json:"driver"` // postgres
+	Query       string `json:"query"`
+	MaxRows     int    `json:"max_rows,omitempty"`
+	DSNSecretID string `json:"dsn_secret_id,omitempty"` // 存储时使用
+	DSN         string `json:"dsn,omitempty"`           // 创建/更新时使用，不存储
+	HasDSN      bool   `json:"has_dsn,omitempty"`       // 响应时使用
+}
+
+// GetSourceConfig 获取类型相关配置
+func (s *CMDBExternalSource) GetSourceConfig() (*ExternalSourceConfig, error) {
+	if len(s.SourceConfig) == 0 {
+		return &ExternalSourceConfig{}, nil
+	}
+	var config ExternalSourceConfig
+	if err := json.Unmarshal(s.SourceConfig, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// SetSourceConfig 设置类型相关配置
+func (s *CMDBExternalSource) SetSourceConfig(config *ExternalSourceConfig) error {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	s.SourceConfig = data
+	return nil
+}
+
+// GetSourceType 获取数据源类型，历史数据为空时视为 http
+func (s *CMDBExternalSource) GetSourceType() string {
+	if s.SourceType == "" {
+		return ExternalSourceTypeHTTP
+	}
+	return s.SourceType
+}
+
 // AuthHeader 认证Header配置
 type AuthHeader struct {
 	Key      string `json:"key"`
@@ -153,21 +250,23 @@ const (
 
 // CreateExternalSourceRequest 创建外部数据源请求
 type CreateExternalSourceRequest struct {
-	Name                string            `json:"name" binding:"required,max=100"`
-	Description         string            `json:"description"`
-	APIEndpoint         string            `json:"api_endpoint" binding:"required,url"`
-	HTTPMethod          string            `json:"http_method" binding:"omitempty,oneof=GET POST"`
-	RequestBody         string            `json:"request_body"`
-	AuthHeaders         []AuthHeaderInput `json:"auth_headers"`
-	ResponsePath        string            `json:"response_path"`
-	FieldMapping        map[string]string `json:"field_mapping" binding:"required"`
-	PrimaryKeyField     string            `json:"primary_key_field" binding:"required"`
-	CloudProvider       string            `json:"cloud_provider"`
-	AccountID           string            `json:"account_id"`
-	AccountName         string            `json:"account_name"`
-	Region              string            `json:"region"`
-	SyncIntervalMinutes int               `json:"sync_interval_minutes"`
-	ResourceTypeFilter  string            `json:"resource_type_filter"`
+	Name                string                `json:"name" binding:"required,max=100"`
+	Description         string                `json:"description"`
+	SourceType          string                `json:"source_type" binding:"omitempty,oneof=http file sql"`
+	SourceConfig        *ExternalSourceConfig `json:"source_config"`
+	APIEndpoint         string                `json:"api_endpoint" binding:"omitempty,url"` // http 类型必填
+	HTTPMethod          string                `json:"http_method" binding:"omitempty,oneof=GET POST"`
+	RequestBody         string                `json:"request_body"`
+	AuthHeaders         []AuthHeaderInput     `json:"auth_headers"`
+	ResponsePath        string                `json:"response_path"`
+	FieldMapping        map[string]string     `json:"field_mapping" binding:"required"`
+	PrimaryKeyField     string                `json:"primary_key_field" binding:"required"`
+	CloudProvider       string                `json:"cloud_provider"`
+	AccountID           string                `json:"account_id"`
+	AccountName         string                `json:"account_name"`
+	Region              string                `json:"region"`
+	SyncIntervalMinutes int                   `json:"sync_interval_minutes"`
+	ResourceTypeFilter  string                `json:"resource_type_filter"`
 }
 
 // AuthHeaderInput 认证Header输入
@@ -178,22 +277,24 @@ type AuthHeaderInput struct {
 
 // UpdateExternalSourceRequest 更新外部数据源请求
 type UpdateExternalSourceRequest struct {
-	Name                *string           `json:"name"`
-	Description         *string           `json:"description"`
-	APIEndpoint         *string           `json:"api_endpoint"`
-	HTTPMethod          *string           `json:"http_method"`
-	RequestBody         *string           `json:"request_body"`
-	AuthHeaders         []AuthHeaderInput `json:"auth_headers"`
-	ResponsePath        *string           `json:"response_path"`
-	FieldMapping        map[string]string `json:"field_mapping"`
-	PrimaryKeyField     *string           `json:"primary_key_field"`
-	CloudProvider       *string           `json:"cloud_provider"`
-	AccountID           *string           `json:"account_id"`
-	AccountName         *string           `json:"account_name"`
-	Region              *string           `json:"region"`
-	SyncIntervalMinutes *int              `json:"sync_interval_minutes"`
-	IsEnabled           *bool             `json:"is_enabled"`
-	ResourceTypeFilter  *string           `json:"resource_type_filter"`
+	Name                *string               `json:"name"`
+	Description         *string               `json:"description"`
+	SourceType          *string               `json:"source_type" binding:"omitempty,oneof=http file sql"`
+	SourceConfig        *ExternalSourceConfig `json:"source_config"`
+	APIEndpoint         *string               `json:"api_endpoint"`
+	HTTPMethod          *string               `json:"http_method"`
+	RequestBody         *string               `json:"request_body"`
+	AuthHeaders         []AuthHeaderInput     `json:"auth_headers"`
+	ResponsePath        *string               `json:"response_path"`
+	FieldMapping        map[string]string     `json:"field_mapping"`
+	PrimaryKeyField     *string               `json:"primary_key_field"`
+	CloudProvider       *string               `json:"cloud_provider"`
+	AccountID           *string               `json:"account_id"`
+	AccountName         *string               `json:"account_name"`
+	Region              *string               `json:"region"`
+	SyncIntervalMinutes *int                  `json:"sync_interval_minutes"`
+	IsEnabled           *bool                 `json:"is_enabled"`
+	ResourceTypeFilter  *string               `json:"resource_type_filter"`
 }
 
 // ExternalSourceResponse 外部数据源响应
@@ -201,6 +302,8 @@ type ExternalSourceResponse struct {
 	SourceID            string                 `json:"source_id"`
 	Name                string                 `json:"name"`
 	Description         string                 `json:"description,omitempty"`
+	SourceType          string                 `json:"source_type"`
+	SourceConfig        *ExternalSourceConfig  `json:"source_config,omitempty"`
 	APIEndpoint         string                 `json:"api_endpoint"`
 	HTTPMethod          string                 `json:"http_method"`
 	RequestBody         string                 `json:"request_body,omitempty"`
@@ -287,10 +390,27 @@ func (s *CMDBExternalSource) ToResponse() (*ExternalSourceResponse, error) {
 		json.Unmarshal(s.FieldMapping, &fieldMapping)
 	}
 
+	// 解析source_config，不返回DSN等敏感信息
+	var sourceConfig *ExternalSourceConfig
+	if len(s.SourceConfig) > 0 {
+		if config, err := s.GetSourceConfig(); err == nil {
+			if config.SQL != nil {
+				sqlConfig := *config.SQL
+				sqlConfig.HasDSN = sqlConfig.DSNSecretID != ""
+				sqlConfig.DSNSecretID = ""
+				sqlConfig.DSN = ""
+				config.SQL = &sqlConfig
+			}
+			sourceConfig = config
+		}
+	}
+
 	return &ExternalSourceResponse{
 		SourceID:            s.SourceID,
 		Name:                s.Name,
 		Description:         s.Description,
+		SourceType:          s.GetSourceType(),
+		SourceConfig:        sourceConfig,
 		APIEndpoint:         s.APIEndpoint,
 		HTTPMethod:          s.HTTPMethod,
 		RequestBody:         s.RequestBody,
@@ -0,0 +1,110 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// CostBudgetStatus 预算检查状态
+type CostBudgetStatus string
+
+const (
+	CostBudgetStatusNone     CostBudgetStatus = "none"     // 未配置预算阈值
+	CostBudgetStatusWithin   CostBudgetStatus = "within"   // 在预算内
+	CostBudgetStatusExceeded CostBudgetStatus = "exceeded" // 超出预算
+)
+
+// TaskCostEstimate 任务的月度成本估算（基于 plan JSON 的 resource_changes）
+type TaskCostEstimate struct {
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	TaskID      uint   `json:"task_id" gorm:"not null;uniqueIndex"`
+	WorkspaceID string `json:"workspace_id" gorm:"type:varchar(50);not null;index"`
+
+	// 汇总（月度）
+	Currency      string  `json:"currency" gorm:"type:varchar(10);default:USD"`
+	MonthlyBefore float64 `json:"monthly_before"`
+	MonthlyAfter  float64 `json:"monthly_after"`
+	MonthlyDelta  float64 `json:"monthly_delta"`
+
+	// 覆盖率
+	PricedCount      int `json:"priced_count" gorm:"default:0"`      // 已定价资源数
+	FreeCount        int `json:"free_count" gorm:"default:0"`        // 免费/按用量计费资源数（计为 0）
+	UnsupportedCount int `json:"unsupported_count" gorm:"default:0"` // 没有价格映射的资源数
+
+	// 价格来源
+	PricingSource string `json:"pricing_source" gorm:"type:varchar(50)"` // price_list / infracost
+	PricingRegion string `json:"pricing_region" gorm:"type:varchar(50)"`
+
+	// 预算
+	BudgetStatus     CostBudgetStatus        `json:"budget_status" gorm:"type:varchar(20);default:none"`
+	BudgetMessage    string                  `json:"budget_message" gorm:"type:text"`
+	EnforcementLevel RunTaskEnforcementLevel `json:"enforcement_level" gorm:"type:varchar(20);default:advisory"`
+	Blocking         bool                    `json:"blocking" gorm:"default:false"` // mandatory 且超出预算，禁止 apply
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Resources []TaskCostEstimateResource `json:"resources,omitempty" gorm:"foreignKey:TaskID;references:TaskID"`
+}
+
+// TableName 指定表名
+func (TaskCostEstimate) TableName() string {
+	return "task_cost_estimates"
+}
+
+// TaskCostEstimateResource 单个资源的月度成本估算
+type TaskCostEstimateResource struct {
+	ID            uint           `json:"id" gorm:"primaryKey"`
+	TaskID        uint           `json:"task_id" gorm:"not null;index"`
+	Address       string         `json:"address" gorm:"type:varchar(500);not null"`
+	ResourceType  string         `json:"resource_type" gorm:"type:varchar(100)"`
+	Action        string         `json:"action" gorm:"type:varchar(20)"` // create, update, delete, replace, no-op
+	MonthlyBefore float64        `json:"monthly_before"`
+	MonthlyAfter  float64        `json:"monthly_after"`
+	MonthlyDelta  float64        `json:"monthly_delta"`
+	Priced        bool           `json:"priced" gorm:"default:false"`
+	Note          string         `json:"note" gorm:"type:text"` // 未定价原因、已知后才能确定的值等
+	Components    CostComponents `json:"components" gorm:"type:jsonb"`
+	CreatedAt     time.Time      `json:"created_at"`
+}
+
+// TableName 指定表名
+func (TaskCostEstimateResource) TableName() string {
+	return "task_cost_estimate_resources"
+}
+
+// CostComponent 成本组成项（如实例时长、存储容量）
+type CostComponent struct {
+	Name          string  `json:"name"`
+	Unit          string  `json:"unit"`
+	PriceKey      string  `json:"price_key,omitempty"` // 定价属性值，如 t3.micro
+	UnitPrice     float64 `json:"unit_price"`
+	Quantity      float64 `json:"quantity"`
+	MonthlyBefore float64 `json:"monthly_before"`
+	MonthlyAfter  float64 `json:"monthly_after"`
+}
+
+// CostComponents 成本组成项列表（JSONB 存储）
+type CostComponents []CostComponent
+
+// Value 实现 driver.Valuer 接口
+func (c CostComponents) Value() (driver.Value, error) {
+	if c == nil {
+		return json.Marshal([]CostComponent{})
+	}
+	return json.Marshal([]CostComponent(c))
+}
+
+// Scan 实现 sql.Scanner 接口
+func (c *CostComponents) Scan(value interface{}) error {
+	if value == nil {
+		*c = nil
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(bytes, c)
+}
@@ -39,6 +39,8 @@ type ManifestVersion struct {
 	Nodes      json.RawMessage `json:"nodes" gorm:"type:jsonb;not null"`          // 节点配置
 	Edges      json.RawMessage `json:"edges" gorm:"type:jsonb;not null"`          // 连接关系
 	Variables  json.RawMessage `json:"variables" gorm:"type:jsonb"`               // 可配置变量
+	Locals     json.RawMessage `json:"locals" gorm:"type:jsonb"`                  // locals 定义
+	Outputs    json.RawMessage `json:"outputs" gorm:"type:jsonb"`                 // output 定义
 	HCLContent string          `json:"hcl_content" gorm:"type:text"`              // 生成的 HCL
 	IsDraft    bool            `json:"is_draft" gorm:"default:true;index"`        // 是否为草稿
 	CreatedBy  string          `json:"created_by" gorm:"size:20;not null"`        // 创建者
@@ -57,6 +59,7 @@ type ManifestDeployment struct {
 	ID                string          `json:"id" gorm:"primaryKey;size:36"`                // 格式: mfd-{ulid}
 	ManifestID        string          `json:"manifest_id" gorm:"size:36;not null;index"`   // 所属 Manifest
 	VersionID         string          `json:"version_id" gorm:"size:36;not null"`          // 部署的版本
+	PreviousVersionID *string         `json:"previous_version_id" gorm:"size:36"`          // 升级/回滚前的版本，用于回滚
 	WorkspaceID       int             `json:"workspace_id" gorm:"not null;index"`          // 目标 Workspace
 	VariableOverrides json.RawMessage `json:"variable_overrides" gorm:"type:jsonb"`        // 变量覆盖
 	Status            string          `json:"status" gorm:"size:20;default:pending;index"` // pending, deploying, deployed, failed
@@ -155,6 +158,31 @@ type ManifestVariable struct {
 	Default     interface{} `json:"default,omitempty"`     // 默认值
 	Required    bool        `json:"required"`              // 是否必填
 	Sensitive   bool        `json:"sensitive,omitempty"`   // 是否敏感
+	Nullable    *bool       `json:"nullable,omitempty"`    // 是否允许 null（未设置时沿用 Terraform 默认）
+
+	Validations []ManifestVariableValidation `json:"validations,omitempty"` // validation 块
+}
+
+// ManifestVariableValidation 变量校验规则
+type ManifestVariableValidation struct {
+	Condition    string `json:"condition"`     // 条件表达式（HCL 源码）
+	ErrorMessage string `json:"error_message"` // 错误信息
+}
+
+// ManifestLocal locals 中的一个值
+// Value 与节点配置的取值约定一致：字面量保存为 JSON 值，表达式保存为字符串
+type ManifestLocal struct {
+	Name  string      `json:"name"`
+	Value interface{} `json:"value"`
+}
+
+// ManifestOutput output 定义
+type ManifestOutput struct {
+	Name        string      `json:"name"`
+	Value       interface{} `json:"value"`
+	Description string      `json:"description,omitempty"`
+	Sensitive   bool        `json:"sensitive,omitempty"`
+	DependsOn   []string    `json:"depends_on,omitempty"`
 }
 
 // ManifestCanvasData 画布数据
@@ -190,6 +218,8 @@ type SaveManifestVersionRequest struct {
 	Nodes      json.RawMessage `json:"nodes" binding:"required"`
 	Edges      json.RawMessage `json:"edges" binding:"required"`
 	Variables  json.RawMessage `json:"variables"`
+	Locals     json.RawMessage `json:"locals"`
+	Outputs    json.RawMessage `json:"outputs"`
 }
 
 // PublishManifestVersionRequest 发布版本请求
@@ -214,6 +244,55 @@ type UpdateManifestDeploymentRequest struct {
 	PlanOnly          bool            `json:"plan_only"`
 }
 
+// UpgradeManifestDeploymentRequest 升级部署请求
+type UpgradeManifestDeploymentRequest struct {
+	VersionID        string `json:"version_id" binding:"required"`
+	ConflictStrategy string `json:"conflict_strategy"` // fail（默认）, overwrite, keep_local
+}
+
+// RollbackManifestDeploymentRequest 回滚部署请求
+type RollbackManifestDeploymentRequest struct {
+	ConflictStrategy string `json:"conflict_strategy"` // fail（默认）, overwrite, keep_local
+}
+
+// ManifestUpgradePlan 部署升级计划
+// 三方对比：当前部署版本（base）、目标版本、workspace 中资源的当前配置（本地修改通过 ConfigHash 检测）
+type ManifestUpgradePlan struct {
+	DeploymentID    string                  `json:"deployment_id"`
+	FromVersionID   string                  `json:"from_version_id"`
+	FromVersion     string                  `json:"from_version"`
+	ToVersionID     string                  `json:"to_version_id"`
+	ToVersion       string                  `json:"to_version"`
+	Changes         []ManifestUpgradeChange `json:"changes"`
+	Summary         ManifestUpgradeSummary  `json:"summary"`
+	HasConflicts    bool                    `json:"has_conflicts"`
+	TaskID          *uint                   `json:"task_id,omitempty"`          // 应用后创建的 plan_and_apply 任务
+	AppliedStrategy string                  `json:"applied_strategy,omitempty"` // 应用时使用的冲突策略
+}
+
+// ManifestUpgradeChange 单个 module 节点的变更
+type ManifestUpgradeChange struct {
+	NodeID          string   `json:"node_id"`
+	InstanceName    string   `json:"instance_name"`
+	ResourceID      string   `json:"resource_id"`             // workspace_resources.resource_id，如 module.vpc
+	Action          string   `json:"action"`                  // add, change, remove, unchanged
+	ChangedKeys     []string `json:"changed_keys,omitempty"`  // 两个版本之间变化的参数
+	LocallyModified bool     `json:"locally_modified"`        // workspace 中的资源在部署后被修改过
+	LocalKeys       []string `json:"local_keys,omitempty"`    // 本地修改过的参数
+	Conflict        string   `json:"conflict,omitempty"`      // locally_modified, resource_missing, resource_exists
+	ConflictKeys    []string `json:"conflict_keys,omitempty"` // 版本变化与本地修改冲突的参数
+	Resolution      string   `json:"resolution,omitempty"`    // 应用时的处理方式: merge, overwrite, keep_local
+}
+
+// ManifestUpgradeSummary 变更统计
+type ManifestUpgradeSummary struct {
+	Add       int `json:"add"`
+	Change    int `json:"change"`
+	Remove    int `json:"remove"`
+	Unchanged int `json:"unchanged"`
+	Conflicts int `json:"conflicts"`
+}
+
 // ManifestListResponse 列表响应
 type ManifestListResponse struct {
 	Items      []Manifest `json:"items"`
@@ -256,6 +335,22 @@ const (
 	DeploymentStatusFailed    = "failed"
 	DeploymentStatusArchived  = "archived" // 已废弃
 
+	// 升级变更类型
+	UpgradeActionAdd       = "add"
+	UpgradeActionChange    = "change"
+	UpgradeActionRemove    = "remove"
+	UpgradeActionUnchanged = "unchanged"
+
+	// 升级冲突类型
+	UpgradeConflictLocallyModified = "locally_modified" // 本地修改与版本变化冲突
+	UpgradeConflictResourceMissing = "resource_missing" // 部署的资源已被删除
+	UpgradeConflictResourceExists  = "resource_exists"  // 新增的资源已存在且不属于该部署
+
+	// 升级冲突策略
+	UpgradeStrategyFail      = "fail"       // 有冲突时拒绝升级
+	UpgradeStrategyOverwrite = "overwrite"  // 以目标版本为准
+	UpgradeStrategyKeepLocal = "keep_local" // 保留本地修改
+
 	// 节点类型
 	NodeTypeModule   = "module"
 	NodeTypeVariable = "variable"
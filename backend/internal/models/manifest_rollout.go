@@ -0,0 +1,178 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// ManifestRollout 将一个 Manifest 版本批量部署/升级到多个 Workspace
+// 目标按波次执行：第一波为金丝雀，之后按累计百分比推进；每波结束后检查失败阈值，可选人工审批
+// 所有进度保存在数据库中，由 leader 上的 ManifestRolloutWorker 推进，服务重启后自动继续
+type ManifestRollout struct {
+	ID                string                  `json:"id" gorm:"primaryKey;size:36"`                // 格式: mfr-{16位随机字符}
+	ManifestID        string                  `json:"manifest_id" gorm:"size:36;not null;index"`   // 所属 Manifest
+	VersionID         string                  `json:"version_id" gorm:"size:36;not null"`          // 目标版本
+	Selector          ManifestRolloutSelector `json:"selector" gorm:"type:jsonb"`                  // 目标选择条件
+	Strategy          ManifestRolloutStrategy `json:"strategy" gorm:"type:jsonb"`                  // 波次、失败阈值、审批
+	ConflictStrategy  string                  `json:"conflict_strategy" gorm:"size:20"`            // fail, overwrite, keep_local
+	VariableOverrides json.RawMessage         `json:"variable_overrides" gorm:"type:jsonb"`        // 新建部署时的变量覆盖
+	Status            string                  `json:"status" gorm:"size:30;default:running;index"` // 见 RolloutStatus*
+	CurrentWave       int                     `json:"current_wave" gorm:"default:0"`               // 当前波次（从 0 开始）
+	TotalWaves        int                     `json:"total_waves" gorm:"default:0"`                // 波次总数
+	AcceptedFailures  int                     `json:"accepted_failures" gorm:"default:0"`          // 当前波次中恢复时已接受的失败数，不再计入阈值
+	Message           string                  `json:"message" gorm:"type:text"`                    // 暂停/停止原因
+	CreatedBy         string                  `json:"created_by" gorm:"size:20;not null"`          // 创建者
+	ApprovedBy        *string                 `json:"approved_by" gorm:"size:20"`                  // 最近一次审批人
+	StartedAt         *time.Time              `json:"started_at"`                                  // 开始时间
+	CompletedAt       *time.Time              `json:"completed_at"`                                // 结束时间（完成/停止/取消）
+	CreatedAt         time.Time               `json:"created_at" gorm:"autoCreateTime"`            // 创建时间
+	UpdatedAt         time.Time               `json:"updated_at" gorm:"autoUpdateTime"`            // 更新时间
+
+	// 非数据库字段
+	Summary *ManifestRolloutSummary `json:"summary,omitempty" gorm:"-"`
+	Targets []ManifestRolloutTarget `json:"targets,omitempty" gorm:"-"`
+}
+
+func (ManifestRollout) TableName() string {
+	return "manifest_rollouts"
+}
+
+// ManifestRolloutSelector 目标 Workspace 选择条件，多个条件取交集；全部为空时不允许创建
+type ManifestRolloutSelector struct {
+	ProjectIDs   []uint            `json:"project_ids,omitempty"`   // 属于任一项目
+	Tags         map[string]string `json:"tags,omitempty"`          // 所有 tag 都匹配
+	WorkspaceIDs []string          `json:"workspace_ids,omitempty"` // 显式列表（ws-xxx）
+}
+
+// Value 实现 driver.Valuer 接口
+func (s ManifestRolloutSelector) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+// Scan 实现 sql.Scanner 接口
+func (s *ManifestRolloutSelector) Scan(value interface{}) error {
+	if value == nil {
+		*s = ManifestRolloutSelector{}
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(bytes, s)
+}
+
+// ManifestRolloutStrategy 波次与失败处理策略
+type ManifestRolloutStrategy struct {
+	CanaryCount int   `json:"canary_count"` // 金丝雀波次的 Workspace 数量，0 表示不单独设置金丝雀
+	Percentages []int `json:"percentages"`  // 之后各波次的累计百分比，如 [25, 50, 100]；最后一波总是补齐到 100
+	// 单个波次内允许的失败百分比，超过后执行 FailureAction；0 表示任何失败都触发
+	FailureThreshold int    `json:"failure_threshold"`
+	FailureAction    string `json:"failure_action"`   // pause（默认）或 stop
+	RequireApproval  bool   `json:"require_approval"` // 每个波次完成后需要审批才进入下一波
+}
+
+// Value 实现 driver.Valuer 接口
+func (s ManifestRolloutStrategy) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+// Scan 实现 sql.Scanner 接口
+func (s *ManifestRolloutStrategy) Scan(value interface{}) error {
+	if value == nil {
+		*s = ManifestRolloutStrategy{}
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(bytes, s)
+}
+
+// ManifestRolloutTarget 发布中的单个 Workspace
+type ManifestRolloutTarget struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	RolloutID    string     `json:"rollout_id" gorm:"size:36;not null;index"`    // 所属发布
+	WorkspaceID  int        `json:"workspace_id" gorm:"not null"`                // Workspace 数据库 ID（与 ManifestDeployment 一致）
+	WorkspaceKey string     `json:"workspace_key" gorm:"size:50"`                // 语义化 ID（ws-xxx）
+	Wave         int        `json:"wave" gorm:"not null;index"`                  // 所属波次
+	Action       string     `json:"action" gorm:"size:20"`                       // install, upgrade, none
+	Status       string     `json:"status" gorm:"size:20;default:pending;index"` // 见 RolloutTargetStatus*
+	DeploymentID *string    `json:"deployment_id" gorm:"size:36"`                // 对应的部署记录
+	TaskID       *uint      `json:"task_id"`                                     // plan_and_apply 任务
+	Error        string     `json:"error" gorm:"type:text"`                      // 失败原因
+	StartedAt    *time.Time `json:"started_at"`
+	CompletedAt  *time.Time `json:"completed_at"`
+
+	// 非数据库字段
+	WorkspaceName string `json:"workspace_name,omitempty" gorm:"-"`
+}
+
+func (ManifestRolloutTarget) TableName() string {
+	return "manifest_rollout_targets"
+}
+
+// ManifestRolloutSummary 各状态的目标数量
+type ManifestRolloutSummary struct {
+	Total     int `json:"total"`
+	Pending   int `json:"pending"`
+	Running   int `json:"running"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+	Skipped   int `json:"skipped"`
+}
+
+// CreateManifestRolloutRequest 创建发布请求
+type CreateManifestRolloutRequest struct {
+	VersionID         string                  `json:"version_id" binding:"required"`
+	Selector          ManifestRolloutSelector `json:"selector"`
+	Strategy          ManifestRolloutStrategy `json:"strategy"`
+	ConflictStrategy  string                  `json:"conflict_strategy"`
+	VariableOverrides json.RawMessage         `json:"variable_overrides"`
+}
+
+// ResumeManifestRolloutRequest 恢复暂停的发布
+// retry_failed 为 true 时重新执行当前波次中失败的 Workspace，否则接受这些失败继续推进
+type ResumeManifestRolloutRequest struct {
+	RetryFailed bool `json:"retry_failed"`
+}
+
+// ManifestRolloutPreview 创建前预览选中的 Workspace 及波次划分
+type ManifestRolloutPreview struct {
+	Waves [][]ManifestRolloutTarget `json:"waves"`
+	Total int                       `json:"total"`
+}
+
+// 发布状态
+const (
+	RolloutStatusRunning          = "running"
+	RolloutStatusAwaitingApproval = "awaiting_approval" // 波次完成，等待审批
+	RolloutStatusPaused           = "paused"            // 失败超过阈值或手动暂停
+	RolloutStatusCompleted        = "completed"
+	RolloutStatusStopped          = "stopped" // 失败超过阈值且 failure_action=stop
+	RolloutStatusCancelled        = "cancelled"
+)
+
+// 发布失败处理方式
+const (
+	RolloutFailureActionPause = "pause"
+	RolloutFailureActionStop  = "stop"
+)
+
+// 发布目标状态
+const (
+	RolloutTargetStatusPending   = "pending"
+	RolloutTargetStatusRunning   = "running"
+	RolloutTargetStatusSucceeded = "succeeded"
+	RolloutTargetStatusFailed    = "failed"
+	RolloutTargetStatusSkipped   = "skipped" // 已是目标版本或发布停止/取消
+)
+
+// 发布目标动作
+const (
+	RolloutActionInstall = "install" // 新建部署
+	RolloutActionUpgrade = "upgrade" // 升级已有部署
+	RolloutActionNone    = "none"    // 已是目标版本
+)
@@ -29,10 +29,12 @@ type Module struct {
 	ModuleFiles      interface{} `json:"module_files" gorm:"type:jsonb"`
 	AIPrompts        []AIPrompt  `json:"ai_prompts" gorm:"column:ai_prompts;type:jsonb;serializer:json;default:'[]'"` // AI 助手提示词列表
 	SyncStatus       string      `json:"sync_status" gorm:"default:pending"`
-	LastSyncAt       *time.Time  `json:"last_sync_at"`
-	CreatedBy        *string     `gorm:"type:varchar(20)" json:"created_by"`
-	CreatedAt        time.Time   `json:"created_at"`
-	UpdatedAt        time.Time   `json:"updated_at"`
+	// RequirePassingTest 设置默认版本前要求该版本最近一次测试运行通过
+	RequirePassingTest bool       `json:"require_passing_test" gorm:"default:false"`
+	LastSyncAt         *time.Time `json:"last_sync_at"`
+	CreatedBy          *string    `gorm:"type:varchar(20)" json:"created_by"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
 
 	// 关联（非数据库字段）
 	DefaultVersion *ModuleVersion `json:"default_version,omitempty" gorm:"foreignKey:DefaultVersionID"`
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"time"
+)
+
+// ModuleTestRunStatus 模块测试运行状态
+type ModuleTestRunStatus string
+
+const (
+	ModuleTestRunStatusPending   ModuleTestRunStatus = "pending"
+	ModuleTestRunStatusRunning   ModuleTestRunStatus = "running"
+	ModuleTestRunStatusPassed    ModuleTestRunStatus = "passed"
+	ModuleTestRunStatusFailed    ModuleTestRunStatus = "failed"  // 有测试断言失败
+	ModuleTestRunStatusErrored   ModuleTestRunStatus = "errored" // init/执行出错，未得到完整结果
+	ModuleTestRunStatusCancelled ModuleTestRunStatus = "cancelled"
+)
+
+// IsFinal 是否为终态
+func (s ModuleTestRunStatus) IsFinal() bool {
+	switch s {
+	case ModuleTestRunStatusPassed, ModuleTestRunStatusFailed, ModuleTestRunStatusErrored, ModuleTestRunStatusCancelled:
+		return true
+	}
+	return false
+}
+
+// ModuleTestRun 模块版本的一次 terraform test / tofu test 运行
+// 执行载体是执行 workspace 中 task_type=test 的 WorkspaceTask，
+// workspace 提供 Agent Pool、IaC 引擎版本、Provider 凭证和环境变量
+type ModuleTestRun struct {
+	ID               uint                `json:"id" gorm:"primaryKey"`
+	RunID            string              `json:"run_id" gorm:"type:varchar(50);uniqueIndex;not null"` // mtr-xxx 语义化 ID
+	ModuleID         uint                `json:"module_id" gorm:"not null;index"`
+	ModuleVersionID  string              `json:"module_version_id" gorm:"type:varchar(30);not null;index"`
+	WorkspaceID      string              `json:"workspace_id" gorm:"type:varchar(50);not null"` // 执行 workspace
+	TaskID           *uint               `json:"task_id" gorm:"index"`
+	Engine           IaCEngineType       `json:"engine" gorm:"type:varchar(20)"` // terraform / opentofu
+	EngineVersion    string              `json:"engine_version" gorm:"type:varchar(50)"`
+	IncludeDemoTests bool                `json:"include_demo_tests" gorm:"default:true"`
+	DemoTestCommand  string              `json:"demo_test_command" gorm:"type:varchar(10);default:plan"` // 生成的 demo 测试使用 plan 还是 apply
+	TestFiles        JSONB               `json:"test_files" gorm:"type:jsonb"`                           // 本次运行的测试文件清单 {"files": [...]}
+	SourceRevision   string              `json:"source_revision" gorm:"type:varchar(64)"`                // 测试时模块已同步文件的内容摘要
+	Status           ModuleTestRunStatus `json:"status" gorm:"type:varchar(20);default:pending;index"`
+	TotalCount       int                 `json:"total_count" gorm:"default:0"`
+	PassedCount      int                 `json:"passed_count" gorm:"default:0"`
+	FailedCount      int                 `json:"failed_count" gorm:"default:0"`
+	ErroredCount     int                 `json:"errored_count" gorm:"default:0"`
+	SkippedCount     int                 `json:"skipped_count" gorm:"default:0"`
+	ErrorMessage     string              `json:"error_message" gorm:"type:text"`
+	CreatedBy        *string             `json:"created_by,omitempty" gorm:"type:varchar(20)"`
+	StartedAt        *time.Time          `json:"started_at"`
+	CompletedAt      *time.Time          `json:"completed_at"`
+	CreatedAt        time.Time           `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt        time.Time           `json:"updated_at" gorm:"autoUpdateTime"`
+
+	// 关联
+	Results []ModuleTestResult `json:"results,omitempty" gorm:"foreignKey:RunID;references:RunID"`
+}
+
+// TableName 指定表名
+func (ModuleTestRun) TableName() string {
+	return "module_test_runs"
+}
+
+// ModuleTestResult 单个 run 块的测试结果（对应 .tftest.hcl 中的一个 run）
+type ModuleTestResult struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	RunID       string    `json:"run_id" gorm:"type:varchar(50);not null;index"`
+	TestFile    string    `json:"test_file" gorm:"type:varchar(500);not null"`
+	RunName     string    `json:"run_name" gorm:"type:varchar(200)"`       // 为空表示文件级结果
+	Status      string    `json:"status" gorm:"type:varchar(20);not null"` // pass / fail / error / skip
+	Generated   bool      `json:"generated" gorm:"default:false"`          // 是否由 demo 自动生成
+	Diagnostics string    `json:"diagnostics" gorm:"type:text"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName 指定表名
+func (ModuleTestResult) TableName() string {
+	return "module_test_results"
+}
@@ -1,7 +1,10 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
+
+	corev1 "k8s.io/api/core/v1"
 )
 
 // PoolToken represents a token for agent pool authentication
@@ -89,6 +92,23 @@ type K8sJobTemplateConfig struct {
 	MinReplicas     int               `json:"min_replicas,omitempty"`
 	MaxReplicas     int               `json:"max_replicas,omitempty"`
 	FreezeSchedules []FreezeSchedule  `json:"freeze_schedules,omitempty"`
+
+	// Pod template customization. Nested objects use the native K8s (camelCase) schema
+	// so that snippets can be copied from existing manifests.
+	NodeSelector                 map[string]string          `json:"node_selector,omitempty"`
+	Tolerations                  []corev1.Toleration        `json:"tolerations,omitempty"`
+	Affinity                     *corev1.Affinity           `json:"affinity,omitempty"`
+	ServiceAccountName           string                     `json:"service_account_name,omitempty"`
+	AutomountServiceAccountToken *bool                      `json:"automount_service_account_token,omitempty"`
+	Volumes                      []corev1.Volume            `json:"volumes,omitempty"`
+	VolumeMounts                 []corev1.VolumeMount       `json:"volume_mounts,omitempty"`
+	PodSecurityContext           *corev1.PodSecurityContext `json:"pod_security_context,omitempty"`
+	SecurityContext              *corev1.SecurityContext    `json:"security_context,omitempty"` // agent container
+	PodAnnotations               map[string]string          `json:"pod_annotations,omitempty"`
+	PodLabels                    map[string]string          `json:"pod_labels,omitempty"`
+	PriorityClassName            string                     `json:"priority_class_name,omitempty"`
+	ImagePullSecrets             []string                   `json:"image_pull_secrets,omitempty"`
+	PodOverrides                 json.RawMessage            `json:"pod_overrides,omitempty" swaggertype:"object"` // strategic merge patch applied to the built Pod
 }
 
 // FreezeSchedule represents a time window when the pool should be frozen
@@ -40,6 +40,7 @@ type RemoteDataToken struct {
 	WorkspaceID          string `json:"workspace_id" gorm:"type:varchar(50);not null;index"`           // 被访问的workspace ID
 	RequesterWorkspaceID string `json:"requester_workspace_id" gorm:"type:varchar(50);not null;index"` // 请求方workspace ID
 	TaskID               *uint  `json:"task_id" gorm:"index"`                                          // 关联的任务ID（可选）
+	Scope                string `json:"scope" gorm:"type:varchar(20);default:outputs"`                 // 作用范围: outputs/remote_state
 
 	// 使用限制
 	MaxUses   int       `json:"max_uses" gorm:"default:5"`   // 最大使用次数
@@ -55,6 +56,15 @@ type RemoteDataToken struct {
 	RequesterWorkspace *Workspace `json:"requester_workspace,omitempty" gorm:"foreignKey:RequesterWorkspaceID;references:WorkspaceID"`
 }
 
+// RemoteDataToken 作用范围
+const (
+	// RemoteDataTokenScopeOutputs 只能读取 WorkspaceID 指定的 workspace 的 outputs（state-outputs/full）
+	RemoteDataTokenScopeOutputs = "outputs"
+	// RemoteDataTokenScopeRemoteState 请求方 workspace 的 terraform_remote_state 凭证，
+	// WorkspaceID 与 RequesterWorkspaceID 相同，可读取所有按 OutputsSharing 允许访问的 workspace
+	RemoteDataTokenScopeRemoteState = "remote_state"
+)
+
 // TableName 指定表名
 func (RemoteDataToken) TableName() string {
 	return "remote_data_tokens"
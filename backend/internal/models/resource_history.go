@@ -0,0 +1,57 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// 资源历史版本的变更类型
+const (
+	ResourceHistoryChangeCreate = "create"
+	ResourceHistoryChangeUpdate = "update"
+	ResourceHistoryChangeDelete = "delete"
+)
+
+// ResourceAttributeHistory 资源属性历史版本
+// 每一行代表资源在 [ValidFrom, ValidTo) 区间内的一个属性版本，区间边界与 State 版本绑定。
+// 为控制存储量，只有链首版本保存完整属性快照（Snapshot），其余版本只保存相对上一版本的差异（Diff）。
+type ResourceAttributeHistory struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	// 资源标识：按 terraform address 串联版本链，同时记录云资源ID便于按云资源查询
+	WorkspaceID      string `gorm:"column:workspace_id;type:varchar(50);not null;index:idx_resource_history_address" json:"workspace_id"`
+	TerraformAddress string `gorm:"column:terraform_address;type:text;not null;index:idx_resource_history_address" json:"terraform_address"`
+	CloudResourceID  string `gorm:"column:cloud_resource_id;type:varchar(255);index:idx_resource_history_cloud_id" json:"cloud_resource_id,omitempty"`
+	ResourceType     string `gorm:"column:resource_type;type:varchar(100);not null" json:"resource_type"`
+
+	// 有效区间
+	ValidFromStateVersionID uint       `gorm:"column:valid_from_state_version_id;not null" json:"valid_from_state_version_id"`
+	ValidToStateVersionID   *uint      `gorm:"column:valid_to_state_version_id" json:"valid_to_state_version_id,omitempty"`
+	ValidFrom               time.Time  `gorm:"column:valid_from;not null" json:"valid_from"`
+	ValidTo                 *time.Time `gorm:"column:valid_to" json:"valid_to,omitempty"`
+	TaskID                  *uint      `gorm:"column:task_id" json:"task_id,omitempty"` // 产生该版本的任务（通常是 apply）
+
+	// 内容
+	ChangeType string          `gorm:"column:change_type;type:varchar(20);not null" json:"change_type"` // create / update
+	IsSnapshot bool            `gorm:"column:is_snapshot;default:false" json:"is_snapshot"`
+	ChainDepth int             `gorm:"column:chain_depth;default:0" json:"chain_depth"` // 距最近快照的版本数
+	Snapshot   json.RawMessage `gorm:"column:snapshot;type:jsonb" json:"snapshot,omitempty"`
+	Diff       json.RawMessage `gorm:"column:diff;type:jsonb" json:"diff,omitempty"` // {"set": {...}, "unset": [...]}
+
+	CreatedAt time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+func (ResourceAttributeHistory) TableName() string {
+	return "resource_attribute_history"
+}
+
+// ResourceHistoryCursor 记录每个 workspace 已写入历史的最后一个 State 版本
+type ResourceHistoryCursor struct {
+	WorkspaceID        string    `gorm:"column:workspace_id;type:varchar(50);primaryKey" json:"workspace_id"`
+	LastStateVersionID uint      `gorm:"column:last_state_version_id;not null" json:"last_state_version_id"`
+	UpdatedAt          time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (ResourceHistoryCursor) TableName() string {
+	return "resource_history_cursors"
+}
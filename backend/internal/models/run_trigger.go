@@ -7,14 +7,15 @@ import (
 // RunTrigger 工作空间触发配置
 // 定义当源 workspace 的任务完成后，触发目标 workspace 的 plan+apply 任务
 type RunTrigger struct {
-	ID                uint      `json:"id" gorm:"primaryKey"`
-	SourceWorkspaceID string    `json:"source_workspace_id" gorm:"type:varchar(50);not null;index"`
-	TargetWorkspaceID string    `json:"target_workspace_id" gorm:"type:varchar(50);not null;index"`
-	Enabled           bool      `json:"enabled" gorm:"default:true"`
-	TriggerCondition  string    `json:"trigger_condition" gorm:"type:varchar(50);default:apply_success"` // apply_success
-	CreatedAt         time.Time `json:"created_at"`
-	UpdatedAt         time.Time `json:"updated_at"`
-	CreatedBy         *string   `json:"created_by" gorm:"type:varchar(50)"`
+	ID                uint        `json:"id" gorm:"primaryKey"`
+	SourceWorkspaceID string      `json:"source_workspace_id" gorm:"type:varchar(50);not null;index"`
+	TargetWorkspaceID string      `json:"target_workspace_id" gorm:"type:varchar(50);not null;index"`
+	Enabled           bool        `json:"enabled" gorm:"default:true"`
+	TriggerCondition  string      `json:"trigger_condition" gorm:"type:varchar(50);default:apply_success"` // apply_success, outputs_changed
+	OutputNames       StringArray `json:"output_names" gorm:"type:jsonb"`                                  // outputs_changed 关注的 output；为空时关注目标通过 Remote Data 读取的 output
+	CreatedAt         time.Time   `json:"created_at"`
+	UpdatedAt         time.Time   `json:"updated_at"`
+	CreatedBy         *string     `json:"created_by" gorm:"type:varchar(50)"`
 
 	// 关联
 	SourceWorkspace *Workspace `json:"source_workspace,omitempty" gorm:"foreignKey:SourceWorkspaceID;references:WorkspaceID"`
@@ -29,17 +30,18 @@ func (RunTrigger) TableName() string {
 // TaskTriggerExecution 任务触发执行记录
 // 记录每次任务触发的执行情况
 type TaskTriggerExecution struct {
-	ID                  uint       `json:"id" gorm:"primaryKey"`
-	SourceTaskID        uint       `json:"source_task_id" gorm:"not null;index"`
-	RunTriggerID        uint       `json:"run_trigger_id" gorm:"not null;index"`
-	TargetTaskID        *uint      `json:"target_task_id" gorm:"index"`
-	Status              string     `json:"status" gorm:"type:varchar(20);default:pending;index"` // pending, triggered, skipped, failed
-	TemporarilyDisabled bool       `json:"temporarily_disabled" gorm:"default:false"`
-	DisabledBy          *string    `json:"disabled_by" gorm:"type:varchar(50)"`
-	DisabledAt          *time.Time `json:"disabled_at"`
-	ErrorMessage        string     `json:"error_message" gorm:"type:text"`
-	CreatedAt           time.Time  `json:"created_at"`
-	UpdatedAt           time.Time  `json:"updated_at"`
+	ID                  uint        `json:"id" gorm:"primaryKey"`
+	SourceTaskID        uint        `json:"source_task_id" gorm:"not null;index"`
+	RunTriggerID        uint        `json:"run_trigger_id" gorm:"not null;index"`
+	TargetTaskID        *uint       `json:"target_task_id" gorm:"index"`
+	Status              string      `json:"status" gorm:"type:varchar(20);default:pending;index"` // pending, triggered, skipped, failed
+	TemporarilyDisabled bool        `json:"temporarily_disabled" gorm:"default:false"`
+	DisabledBy          *string     `json:"disabled_by" gorm:"type:varchar(50)"`
+	DisabledAt          *time.Time  `json:"disabled_at"`
+	ErrorMessage        string      `json:"error_message" gorm:"type:text"`    // 失败原因或跳过原因
+	ChangedOutputs      StringArray `json:"changed_outputs" gorm:"type:jsonb"` // 源任务 apply 后发生变化的关注 output
+	CreatedAt           time.Time   `json:"created_at"`
+	UpdatedAt           time.Time   `json:"updated_at"`
 
 	// 关联
 	SourceTask *WorkspaceTask `json:"source_task,omitempty" gorm:"foreignKey:SourceTaskID"`
@@ -56,11 +58,12 @@ func (TaskTriggerExecution) TableName() string {
 const (
 	TriggerStatusPending   = "pending"   // 等待触发
 	TriggerStatusTriggered = "triggered" // 已触发
-	TriggerStatusSkipped   = "skipped"   // 已跳过（被临时禁用）
+	TriggerStatusSkipped   = "skipped"   // 已跳过（被临时禁用、条件不满足等）
 	TriggerStatusFailed    = "failed"    // 触发失败
 )
 
 // TriggerCondition 触发条件常量
 const (
-	TriggerConditionApplySuccess = "apply_success" // Apply 成功后触发
+	TriggerConditionApplySuccess   = "apply_success"   // Apply 成功后触发
+	TriggerConditionOutputsChanged = "outputs_changed" // Apply 成功且关注的 output 相对上一个 State 版本发生变化时触发
 )
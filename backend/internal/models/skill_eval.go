@@ -0,0 +1,155 @@
+package models
+
+import (
+	"time"
+)
+
+// SkillEvalMode 评估运行模式
+type SkillEvalMode string
+
+const (
+	SkillEvalModeLive   SkillEvalMode = "live"   // 调用配置的模型，并录制响应供回放
+	SkillEvalModeReplay SkillEvalMode = "replay" // 只使用录制的响应，Prompt 变化后没有录制的用例记为 error
+)
+
+// SkillEvalRunStatus 评估运行状态
+type SkillEvalRunStatus string
+
+const (
+	SkillEvalRunStatusPending   SkillEvalRunStatus = "pending"
+	SkillEvalRunStatusRunning   SkillEvalRunStatus = "running"
+	SkillEvalRunStatusCompleted SkillEvalRunStatus = "completed"
+	SkillEvalRunStatusFailed    SkillEvalRunStatus = "failed" // 运行本身失败（如无法获取 AI 配置），没有得分
+)
+
+// SkillEvalSuite Skill 评估套件
+// 一组用户意图 + Module + 期望约束的用例，用于在修改 Skill 前后对比 AI 配置生成的质量
+type SkillEvalSuite struct {
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	Name        string `json:"name" gorm:"type:varchar(255);not null;uniqueIndex"`
+	Description string `json:"description" gorm:"type:text"`
+	// Composition 评估使用的 Skill 组合，为空时使用 AI 配置中的组合（再为空使用默认组合）
+	Composition *SkillComposition `json:"composition,omitempty" gorm:"type:jsonb;serializer:json"`
+	AIConfigID  *uint             `json:"ai_config_id,omitempty"`                               // live 模式使用的 AI 配置，为空时使用 form_generation 能力的配置
+	Mode        SkillEvalMode     `json:"mode" gorm:"type:varchar(20);not null;default:replay"` // 默认运行模式
+	// GateSkillEdits 为 true 时，修改该套件用到的 Skill 内容需要先通过对比评估
+	GateSkillEdits bool `json:"gate_skill_edits" gorm:"default:false"`
+	// Tolerance 对比时允许的得分下降（0-1），超过即视为回归
+	Tolerance float64   `json:"tolerance" gorm:"default:0"`
+	CreatedBy string    `json:"created_by,omitempty" gorm:"type:varchar(20)"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Cases []SkillEvalCase `json:"cases,omitempty" gorm:"foreignKey:SuiteID"`
+}
+
+// TableName 指定表名
+func (SkillEvalSuite) TableName() string {
+	return "skill_eval_suites"
+}
+
+// SkillEvalExpectations 用例的期望约束
+type SkillEvalExpectations struct {
+	RequiredFields  []string               `json:"required_fields,omitempty"`  // 生成结果中必须存在且非空的字段，支持 a.b 路径
+	ExpectedValues  map[string]interface{} `json:"expected_values,omitempty"`  // 字段路径 -> 期望值
+	ForbiddenFields []string               `json:"forbidden_fields,omitempty"` // 不能出现的字段
+}
+
+// SkillEvalCase 评估用例
+type SkillEvalCase struct {
+	ID            uint                   `json:"id" gorm:"primaryKey"`
+	SuiteID       uint                   `json:"suite_id" gorm:"not null;index"`
+	Name          string                 `json:"name" gorm:"type:varchar(255);not null"`
+	UserIntent    string                 `json:"user_intent" gorm:"type:text;not null"` // 用户描述
+	ModuleID      uint                   `json:"module_id" gorm:"not null"`
+	CurrentConfig map[string]interface{} `json:"current_config,omitempty" gorm:"type:jsonb;serializer:json"` // 可选，模拟编辑已有配置
+	Expectations  SkillEvalExpectations  `json:"expectations" gorm:"type:jsonb;serializer:json"`
+	CreatedAt     time.Time              `json:"created_at"`
+	UpdatedAt     time.Time              `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (SkillEvalCase) TableName() string {
+	return "skill_eval_cases"
+}
+
+// SkillEvalRun 评估套件的一次运行
+// 对比评估由一对运行组成：baseline（当前 Skill）和 candidate（覆盖了待评估的 Skill 内容），
+// candidate 通过 BaselineRunID 关联 baseline，对比结论记录在 candidate 上
+type SkillEvalRun struct {
+	ID      uint               `json:"id" gorm:"primaryKey"`
+	RunID   string             `json:"run_id" gorm:"type:varchar(50);uniqueIndex;not null"` // ser-xxx 语义化 ID
+	SuiteID uint               `json:"suite_id" gorm:"not null;index"`
+	Mode    SkillEvalMode      `json:"mode" gorm:"type:varchar(20);not null"`
+	Status  SkillEvalRunStatus `json:"status" gorm:"type:varchar(20);default:pending"`
+	// SkillOverrides Skill 名称 -> 评估用的内容，覆盖数据库中的内容
+	SkillOverrides map[string]string `json:"skill_overrides,omitempty" gorm:"type:jsonb;serializer:json"`
+	// SkillName / ContentHash 对比评估中被修改的 Skill 及其候选内容的 SHA256，用于修改 Skill 时查找评估结论
+	SkillName     string      `json:"skill_name,omitempty" gorm:"type:varchar(255);index"`
+	ContentHash   string      `json:"content_hash,omitempty" gorm:"type:varchar(64)"`
+	BaselineRunID string      `json:"baseline_run_id,omitempty" gorm:"type:varchar(50)"`
+	AIModel       string      `json:"ai_model,omitempty" gorm:"type:varchar(200)"`
+	UsedSkills    StringArray `json:"used_skills" gorm:"type:jsonb"` // 本次运行组装 Prompt 用到的 Skill 名称
+	Score         float64     `json:"score"`                         // 用例得分的平均值（0-1）
+	TotalCount    int         `json:"total_count"`
+	PassedCount   int         `json:"passed_count"`
+	FailedCount   int         `json:"failed_count"`
+	ErroredCount  int         `json:"errored_count"`
+	Regressed     *bool       `json:"regressed,omitempty"`   // 仅 candidate：相对 baseline 是否回归
+	ScoreDelta    *float64    `json:"score_delta,omitempty"` // 仅 candidate：candidate - baseline
+	ErrorMessage  string      `json:"error_message,omitempty" gorm:"type:text"`
+	CreatedBy     string      `json:"created_by,omitempty" gorm:"type:varchar(20)"`
+	StartedAt     *time.Time  `json:"started_at"`
+	CompletedAt   *time.Time  `json:"completed_at"`
+	CreatedAt     time.Time   `json:"created_at"`
+
+	Results []SkillEvalResult `json:"results,omitempty" gorm:"foreignKey:RunID;references:RunID"`
+}
+
+// TableName 指定表名
+func (SkillEvalRun) TableName() string {
+	return "skill_eval_runs"
+}
+
+// SkillEvalCheck 单项检查结果
+type SkillEvalCheck struct {
+	Name    string `json:"name"` // solver / required:<field> / value:<field> / forbidden:<field>
+	Passed  bool   `json:"passed"`
+	Message string `json:"message,omitempty"`
+}
+
+// SkillEvalResult 单个用例的评估结果
+type SkillEvalResult struct {
+	ID           uint                   `json:"id" gorm:"primaryKey"`
+	RunID        string                 `json:"run_id" gorm:"type:varchar(50);not null;index"`
+	CaseID       uint                   `json:"case_id" gorm:"not null"`
+	CaseName     string                 `json:"case_name" gorm:"type:varchar(255)"`
+	Status       string                 `json:"status" gorm:"type:varchar(20);not null"` // pass / fail / error
+	Score        float64                `json:"score"`                                   // 通过的检查项比例（0-1）
+	Checks       []SkillEvalCheck       `json:"checks" gorm:"type:jsonb;serializer:json"`
+	Params       map[string]interface{} `json:"params,omitempty" gorm:"type:jsonb;serializer:json"` // SchemaSolver 处理后的最终参数
+	SolverErrors int                    `json:"solver_errors"`                                      // 最终仍未解决的 Solver 错误数
+	Retries      int                    `json:"retries"`                                            // 反馈循环中 AI 修正的次数
+	ErrorMessage string                 `json:"error_message,omitempty" gorm:"type:text"`
+	CreatedAt    time.Time              `json:"created_at"`
+}
+
+// TableName 指定表名
+func (SkillEvalResult) TableName() string {
+	return "skill_eval_results"
+}
+
+// SkillEvalRecording 录制的 AI 响应，按用例和 Prompt 的 SHA256 回放
+type SkillEvalRecording struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	CaseID     uint      `json:"case_id" gorm:"not null;uniqueIndex:idx_skill_eval_recordings_case_prompt"`
+	PromptHash string    `json:"prompt_hash" gorm:"type:varchar(64);not null;uniqueIndex:idx_skill_eval_recordings_case_prompt"`
+	Response   string    `json:"response" gorm:"type:text;not null"`
+	AIModel    string    `json:"ai_model,omitempty" gorm:"type:varchar(200)"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// TableName 指定表名
+func (SkillEvalRecording) TableName() string {
+	return "skill_eval_recordings"
+}
@@ -0,0 +1,169 @@
+package models
+
+import (
+	"time"
+)
+
+// Stack 一组按依赖关系协同 plan/apply 的 Workspace
+// 成员之间的依赖由远程数据和 Run Trigger 推导，也可以在成员上显式声明；
+// 一次 StackRun 按拓扑顺序 plan 全部成员，统一审核后按顺序 apply，任一成员失败即停止
+type Stack struct {
+	ID          string    `json:"id" gorm:"primaryKey;size:36"`              // 格式: stk-{16位随机字符}
+	Name        string    `json:"name" gorm:"size:100;not null;uniqueIndex"` // 名称
+	Description string    `json:"description" gorm:"type:text"`              // 描述
+	CreatedBy   string    `json:"created_by" gorm:"size:20;not null"`        // 创建者
+	UpdatedBy   *string   `json:"updated_by" gorm:"size:20"`                 // 最近修改者
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`          // 创建时间
+	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`          // 更新时间
+
+	// 非数据库字段
+	Members    []StackMember `json:"members,omitempty" gorm:"-"`
+	Graph      *StackGraph   `json:"graph,omitempty" gorm:"-"`
+	GraphError string        `json:"graph_error,omitempty" gorm:"-"` // 推导出的依赖成环等，此时不能发起执行
+}
+
+func (Stack) TableName() string {
+	return "stacks"
+}
+
+// StackMember Stack 中的 Workspace
+type StackMember struct {
+	ID          uint        `json:"id" gorm:"primaryKey"`
+	StackID     string      `json:"stack_id" gorm:"size:36;not null;index"` // 所属 Stack
+	WorkspaceID string      `json:"workspace_id" gorm:"size:50;not null"`   // 语义化 ID（ws-xxx）
+	DependsOn   StringArray `json:"depends_on" gorm:"type:jsonb"`           // 显式声明的上游成员（ws-xxx），与推导出的依赖合并
+	CreatedAt   time.Time   `json:"created_at" gorm:"autoCreateTime"`
+
+	// 非数据库字段
+	WorkspaceName string `json:"workspace_name,omitempty" gorm:"-"`
+}
+
+func (StackMember) TableName() string {
+	return "stack_members"
+}
+
+// StackEdge 成员间的依赖边，From 依赖 To（To 先于 From 执行）
+type StackEdge struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Type   string `json:"type"`             // explicit, remote_data, run_trigger
+	Detail string `json:"detail,omitempty"` // 远程数据名称等
+}
+
+// StackGraph 成员依赖图；Layers 为拓扑分层，同一层的成员之间没有依赖
+type StackGraph struct {
+	Edges  []StackEdge `json:"edges"`
+	Layers [][]string  `json:"layers"`
+}
+
+// StackRun 一次协同执行：全部成员 plan → 统一审核 → 按顺序 apply
+// 所有进度保存在数据库中，由 leader 上的 StackRunWorker 推进，服务重启后自动继续
+type StackRun struct {
+	ID          string     `json:"id" gorm:"primaryKey;size:36"`                 // 格式: skr-{16位随机字符}
+	StackID     string     `json:"stack_id" gorm:"size:36;not null;index"`       // 所属 Stack
+	Status      string     `json:"status" gorm:"size:30;default:planning;index"` // 见 StackRunStatus*
+	Description string     `json:"description" gorm:"type:text"`                 // 发起时填写的说明，写入各成员任务
+	Message     string     `json:"message" gorm:"type:text"`                     // 失败/取消原因
+	CreatedBy   string     `json:"created_by" gorm:"size:20;not null"`           // 发起者
+	ApprovedBy  *string    `json:"approved_by" gorm:"size:20"`                   // 审核通过者
+	ApprovedAt  *time.Time `json:"approved_at"`                                  // 审核通过时间
+	CompletedAt *time.Time `json:"completed_at"`                                 // 结束时间
+	CreatedAt   time.Time  `json:"created_at" gorm:"autoCreateTime"`             // 创建时间
+	UpdatedAt   time.Time  `json:"updated_at" gorm:"autoUpdateTime"`             // 更新时间
+
+	// 非数据库字段
+	Summary *StackRunSummary `json:"summary,omitempty" gorm:"-"`
+	Members []StackRunMember `json:"members,omitempty" gorm:"-"`
+}
+
+func (StackRun) TableName() string {
+	return "stack_runs"
+}
+
+// StackRunMember 一次执行中的单个 Workspace；依赖在发起时固化，之后修改 Stack 不影响进行中的执行
+type StackRunMember struct {
+	ID             uint        `json:"id" gorm:"primaryKey"`
+	RunID          string      `json:"run_id" gorm:"size:36;not null;index"`        // 所属执行
+	WorkspaceID    string      `json:"workspace_id" gorm:"size:50;not null;index"`  // 语义化 ID（ws-xxx）
+	Layer          int         `json:"layer" gorm:"not null"`                       // 拓扑层（从 0 开始）
+	DependsOn      StringArray `json:"depends_on" gorm:"type:jsonb"`                // 上游成员（ws-xxx）
+	Status         string      `json:"status" gorm:"size:20;default:pending;index"` // 见 StackMemberStatus*
+	TaskID         *uint       `json:"task_id"`                                     // plan_and_apply 任务
+	ChangesAdd     int         `json:"changes_add"`
+	ChangesChange  int         `json:"changes_change"`
+	ChangesDestroy int         `json:"changes_destroy"`
+	Error          string      `json:"error" gorm:"type:text"` // 失败/跳过原因
+	StartedAt      *time.Time  `json:"started_at"`
+	CompletedAt    *time.Time  `json:"completed_at"`
+
+	// 非数据库字段
+	WorkspaceName string                        `json:"workspace_name,omitempty" gorm:"-"`
+	Resources     []WorkspaceTaskResourceChange `json:"resources,omitempty" gorm:"-"` // 审核视图中的资源变更
+}
+
+func (StackRunMember) TableName() string {
+	return "stack_run_members"
+}
+
+// StackRunSummary 各状态的成员数量及变更合计
+type StackRunSummary struct {
+	Total          int `json:"total"`
+	Pending        int `json:"pending"`
+	Planning       int `json:"planning"`
+	Planned        int `json:"planned"`
+	NoChanges      int `json:"no_changes"`
+	Applying       int `json:"applying"`
+	Applied        int `json:"applied"`
+	Failed         int `json:"failed"`
+	Skipped        int `json:"skipped"`
+	ChangesAdd     int `json:"changes_add"`
+	ChangesChange  int `json:"changes_change"`
+	ChangesDestroy int `json:"changes_destroy"`
+}
+
+// StackRequest 创建/更新 Stack 请求；members 为完整成员列表
+type StackRequest struct {
+	Name        string               `json:"name" binding:"required"`
+	Description string               `json:"description"`
+	Members     []StackMemberRequest `json:"members"`
+}
+
+// StackMemberRequest 成员及其显式依赖
+type StackMemberRequest struct {
+	WorkspaceID string   `json:"workspace_id" binding:"required"`
+	DependsOn   []string `json:"depends_on"`
+}
+
+// CreateStackRunRequest 发起执行请求
+type CreateStackRunRequest struct {
+	Description string `json:"description"`
+}
+
+// Stack 执行状态
+const (
+	StackRunStatusPlanning         = "planning"          // 按拓扑顺序 plan 成员
+	StackRunStatusAwaitingApproval = "awaiting_approval" // 全部 plan 完成，等待统一审核
+	StackRunStatusApplying         = "applying"          // 按拓扑顺序 apply 成员
+	StackRunStatusCompleted        = "completed"
+	StackRunStatusFailed           = "failed" // 某个成员 plan/apply 失败，其余成员已停止
+	StackRunStatusCancelled        = "cancelled"
+)
+
+// Stack 执行成员状态
+const (
+	StackMemberStatusPending   = "pending"    // 等待上游成员 plan 完成
+	StackMemberStatusPlanning  = "planning"   // plan 中
+	StackMemberStatusPlanned   = "planned"    // plan 有变更，等待审核/上游 apply
+	StackMemberStatusNoChanges = "no_changes" // plan 无变更，不需要 apply
+	StackMemberStatusApplying  = "applying"   // apply 已确认并投递
+	StackMemberStatusApplied   = "applied"
+	StackMemberStatusFailed    = "failed"
+	StackMemberStatusSkipped   = "skipped" // 执行停止或取消，未执行/已取消
+)
+
+// Stack 依赖边类型（远程数据和 Run Trigger 与 CMDB 关系图一致）
+const (
+	StackEdgeExplicit   = "explicit"
+	StackEdgeRemoteData = "remote_data"
+	StackEdgeRunTrigger = "run_trigger"
+)
@@ -0,0 +1,131 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// StaticAnalysisStatus 静态分析结果状态
+type StaticAnalysisStatus string
+
+const (
+	StaticAnalysisStatusPassed  StaticAnalysisStatus = "passed"  // 没有达到阻断级别的发现
+	StaticAnalysisStatusFailed  StaticAnalysisStatus = "failed"  // 存在达到阻断级别的发现
+	StaticAnalysisStatusErrored StaticAnalysisStatus = "errored" // 分析器本身执行失败（未安装、输出无法解析等）
+)
+
+// SARIF 结果级别（与 SARIF 2.1.0 的 result.level 保持一致）
+const (
+	SARIFLevelError   = "error"
+	SARIFLevelWarning = "warning"
+	SARIFLevelNote    = "note"
+)
+
+// SARIFRun SARIF-like 单次分析结果，对应 SARIF 2.1.0 中的 run 对象（只保留平台需要的字段）
+type SARIFRun struct {
+	Tool    SARIFTool     `json:"tool"`
+	Results []SARIFResult `json:"results"`
+}
+
+// SARIFTool 分析工具描述
+type SARIFTool struct {
+	Driver SARIFDriver `json:"driver"`
+}
+
+// SARIFDriver 分析工具信息
+type SARIFDriver struct {
+	Name           string `json:"name"`
+	Version        string `json:"version,omitempty"`
+	InformationURI string `json:"informationUri,omitempty"`
+}
+
+// SARIFResult 单条发现
+type SARIFResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   SARIFMessage    `json:"message"`
+	HelpURI   string          `json:"helpUri,omitempty"`
+	Locations []SARIFLocation `json:"locations,omitempty"`
+}
+
+// SARIFMessage 发现描述
+type SARIFMessage struct {
+	Text string `json:"text"`
+}
+
+// SARIFLocation 发现位置（文件位置和/或资源地址）
+type SARIFLocation struct {
+	PhysicalLocation *SARIFPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []SARIFLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+// SARIFPhysicalLocation 文件位置
+type SARIFPhysicalLocation struct {
+	ArtifactLocation SARIFArtifactLocation `json:"artifactLocation"`
+	Region           *SARIFRegion          `json:"region,omitempty"`
+}
+
+// SARIFArtifactLocation 文件路径（相对于工作目录）
+type SARIFArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// SARIFRegion 行号范围
+type SARIFRegion struct {
+	StartLine int `json:"startLine,omitempty"`
+	EndLine   int `json:"endLine,omitempty"`
+}
+
+// SARIFLogicalLocation 逻辑位置，如 module.vpc 或 aws_s3_bucket.logs
+type SARIFLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind,omitempty"`
+}
+
+// Value 实现 driver.Valuer 接口
+func (r SARIFRun) Value() (driver.Value, error) {
+	return json.Marshal(r)
+}
+
+// Scan 实现 sql.Scanner 接口
+func (r *SARIFRun) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(bytes, r)
+}
+
+// TaskStaticAnalysisResult 任务的静态分析结果
+// 每个任务每个分析器一条记录，在 plan 之前由执行器（Local 或 Agent）生成
+type TaskStaticAnalysisResult struct {
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	TaskID      uint   `json:"task_id" gorm:"not null;index"`
+	WorkspaceID string `json:"workspace_id" gorm:"type:varchar(50);not null;index"`
+
+	// 分析器
+	Analyzer         string                  `json:"analyzer" gorm:"type:varchar(50);not null"` // builtin, tflint, trivy, checkov
+	EnforcementLevel RunTaskEnforcementLevel `json:"enforcement_level" gorm:"type:varchar(20);default:advisory"`
+	BlockingLevel    string                  `json:"blocking_level" gorm:"type:varchar(20);default:error"` // 达到该级别的发现视为失败
+
+	// 结果
+	Status       StaticAnalysisStatus `json:"status" gorm:"type:varchar(20);not null"`
+	Blocking     bool                 `json:"blocking" gorm:"default:false"` // mandatory 且失败/出错，阻断了 plan
+	ErrorCount   int                  `json:"error_count" gorm:"default:0"`
+	WarningCount int                  `json:"warning_count" gorm:"default:0"`
+	NoteCount    int                  `json:"note_count" gorm:"default:0"`
+	SARIF        SARIFRun             `json:"sarif" gorm:"column:sarif;type:jsonb"`
+	Message      string               `json:"message" gorm:"type:text"`
+	DurationMs   int64                `json:"duration_ms"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName 指定表名
+func (TaskStaticAnalysisResult) TableName() string {
+	return "task_static_analysis_results"
+}
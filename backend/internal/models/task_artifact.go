@@ -0,0 +1,75 @@
+package models
+
+import "time"
+
+// 任务产物类型
+const (
+	TaskArtifactLogs        = "logs"         // task_logs 表中的日志行（JSON Lines）
+	TaskArtifactPlanOutput  = "plan_output"  // workspace_tasks.plan_output
+	TaskArtifactApplyOutput = "apply_output" // workspace_tasks.apply_output
+	TaskArtifactPlanData    = "plan_data"    // workspace_tasks.plan_data（plan.out 二进制）
+	TaskArtifactPlanJSON    = "plan_json"    // workspace_tasks.plan_json
+	TaskArtifactStateBackup = "state_backup" // /var/backup/states 下的 State 备份文件
+)
+
+// 产物存储类型
+const (
+	ArtifactStorageLocal = "local"
+	ArtifactStorageS3    = "s3"
+)
+
+// TaskArtifact 已归档到对象存储的任务产物
+// 归档后对应的数据库列被清空，读取时通过 TaskArtifactService 透明加载
+type TaskArtifact struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	TaskID      uint       `json:"task_id" gorm:"not null;index"`
+	WorkspaceID string     `json:"workspace_id" gorm:"type:varchar(50);not null;index"`
+	Kind        string     `json:"kind" gorm:"type:varchar(20);not null"`
+	Name        string     `json:"name" gorm:"type:varchar(255)"` // State 备份的原始文件名
+	StorageType string     `json:"storage_type" gorm:"type:varchar(20);not null"`
+	StorageKey  string     `json:"storage_key" gorm:"type:varchar(500)"`
+	Compression string     `json:"compression" gorm:"type:varchar(20)"`
+	SizeBytes   int64      `json:"size_bytes"`
+	StoredBytes int64      `json:"stored_bytes"`
+	Checksum    string     `json:"checksum" gorm:"type:varchar(64)"` // 原始内容的 SHA256
+	ArchivedAt  time.Time  `json:"archived_at"`
+	PurgedAt    *time.Time `json:"purged_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// TableName 指定表名
+func (TaskArtifact) TableName() string {
+	return "task_artifacts"
+}
+
+// ArtifactRetentionPolicy 任务产物保留策略
+// OrganizationID 为 0 表示平台默认策略；组织未配置策略时使用默认策略
+type ArtifactRetentionPolicy struct {
+	ID               uint      `json:"id" gorm:"primaryKey"`
+	OrganizationID   uint      `json:"organization_id" gorm:"not null;uniqueIndex"`
+	Enabled          bool      `json:"enabled" gorm:"default:true"`
+	ArchiveAfterDays int       `json:"archive_after_days"` // 任务结束多少天后归档到对象存储，0 表示不归档
+	PurgeAfterDays   int       `json:"purge_after_days"`   // 任务结束多少天后彻底删除，0 表示永久保留
+	UpdatedBy        string    `json:"updated_by" gorm:"type:varchar(50)"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (ArtifactRetentionPolicy) TableName() string {
+	return "artifact_retention_policies"
+}
+
+// ArtifactStorageConfig 产物存储配置，保存在 system_configs（key = artifact_storage）
+// SecretAccessKey 加密后存入 SecretAccessKeyEncrypted，接口中不返回
+type ArtifactStorageConfig struct {
+	Type                     string `json:"type"`                 // local 或 s3
+	LocalPath                string `json:"local_path,omitempty"` // local 存储根目录
+	Endpoint                 string `json:"endpoint,omitempty"`   // S3 兼容服务地址，为空时使用 AWS 区域地址
+	Region                   string `json:"region,omitempty"`
+	Bucket                   string `json:"bucket,omitempty"`
+	Prefix                   string `json:"prefix,omitempty"`
+	UsePathStyle             bool   `json:"use_path_style"` // MinIO 等通常需要 path-style
+	AccessKeyID              string `json:"access_key_id,omitempty"`
+	SecretAccessKeyEncrypted string `json:"secret_access_key_encrypted,omitempty"`
+}
@@ -10,7 +10,9 @@ type TaskLog struct {
 	TaskID    uint      `gorm:"not null;index:idx_task_logs_task_id" json:"task_id"`
 	Phase     string    `gorm:"type:varchar(20);not null" json:"phase"` // init, plan, apply
 	Content   string    `gorm:"type:text" json:"content"`
-	Level     string    `gorm:"type:varchar(10);not null" json:"level"` // info, error, warning
+	Level     string    `gorm:"type:varchar(10);not null" json:"level"`        // info, error, warning
+	Checksum  string    `gorm:"type:varchar(64);index" json:"checksum"`        // 日志块 SHA256（含偏移），Agent 重放上传时用于去重
+	LogOffset *int64    `gorm:"column:log_offset" json:"log_offset,omitempty"` // 日志块在该阶段 Agent 日志中的偏移
 	CreatedAt time.Time `gorm:"autoCreateTime;index:idx_task_logs_created_at" json:"created_at"`
 
 	// 关联
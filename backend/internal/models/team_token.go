@@ -2,6 +2,8 @@ package models
 
 import (
 	"time"
+
+	"iac-platform/internal/domain/valueobject"
 )
 
 // TeamToken 团队Token模型
@@ -18,6 +20,9 @@ type TeamToken struct {
 	RevokedBy   *string    `json:"revoked_by,omitempty" gorm:"column:revoked_by"`
 	LastUsedAt  *time.Time `json:"last_used_at,omitempty" gorm:"column:last_used_at"`
 	ExpiresAt   *time.Time `json:"expires_at,omitempty" gorm:"column:expires_at"`
+	LastUsedIP  string     `json:"last_used_ip,omitempty" gorm:"column:last_used_ip;size:64"`
+	// Scopes 为空表示作用域功能上线前创建的 Token，不做限制
+	Scopes *valueobject.TokenScope `json:"scopes,omitempty" gorm:"column:scopes;type:jsonb;serializer:json"`
 }
 
 // TableName 指定表名
@@ -36,7 +41,10 @@ type TeamTokenResponse struct {
 	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
 	RevokedBy  *string    `json:"revoked_by,omitempty"`
 	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	LastUsedIP string     `json:"last_used_ip,omitempty"`
 	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+
+	Scopes *valueobject.TokenScope `json:"scopes,omitempty"`
 }
 
 // TeamTokenCreateResponse 创建Token时的响应（包含明文token）
@@ -46,4 +54,6 @@ type TeamTokenCreateResponse struct {
 	Token     string     `json:"token"` // 明文token，仅在创建时返回一次
 	CreatedAt time.Time  `json:"created_at"`
 	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+
+	Scopes *valueobject.TokenScope `json:"scopes,omitempty"`
 }
@@ -2,6 +2,8 @@ package models
 
 import (
 	"time"
+
+	"iac-platform/internal/domain/valueobject"
 )
 
 // UserToken 用户个人Token模型
@@ -16,6 +18,9 @@ type UserToken struct {
 	RevokedAt   *time.Time `json:"revoked_at"`
 	LastUsedAt  *time.Time `json:"last_used_at"`
 	ExpiresAt   *time.Time `json:"expires_at"`
+	LastUsedIP  string     `json:"last_used_ip" gorm:"size:64"`
+	// Scopes 为空表示作用域功能上线前创建的 Token，不做限制
+	Scopes *valueobject.TokenScope `json:"scopes" gorm:"type:jsonb;serializer:json"`
 }
 
 // TableName 指定表名
@@ -32,7 +37,10 @@ type UserTokenResponse struct {
 	CreatedAt  time.Time  `json:"created_at"`
 	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
 	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	LastUsedIP string     `json:"last_used_ip,omitempty"`
 	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+
+	Scopes *valueobject.TokenScope `json:"scopes,omitempty"`
 }
 
 // UserTokenCreateResponse 创建Token时的响应（包含明文token）
@@ -43,4 +51,6 @@ type UserTokenCreateResponse struct {
 	Token     string     `json:"token"` // 明文token，仅在创建时返回一次
 	CreatedAt time.Time  `json:"created_at"`
 	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+
+	Scopes *valueobject.TokenScope `json:"scopes,omitempty"`
 }
@@ -24,6 +24,8 @@ type ValueFormat string
 const (
 	ValueFormatString ValueFormat = "string" // 字符串格式
 	ValueFormatHCL    ValueFormat = "hcl"    // HCL表达式格式
+	// ValueFormatSecretRef 外部密文引用（vault://、env://、file://），由执行者在运行前解析，平台只保存引用
+	ValueFormatSecretRef ValueFormat = "secret_ref"
 )
 
 // WorkspaceVariable Workspace变量模型
@@ -52,6 +54,11 @@ func (WorkspaceVariable) TableName() string {
 	return "workspace_variables"
 }
 
+// IsSecretRef 变量值是否为外部密文引用
+func (v *WorkspaceVariable) IsSecretRef() bool {
+	return v.ValueFormat == ValueFormatSecretRef
+}
+
 // BeforeCreate 创建前生成 variable_id 并加密敏感变量
 func (v *WorkspaceVariable) BeforeCreate(tx *gorm.DB) error {
 	// 只在 variable_id 为空时生成（创建新变量）
@@ -65,7 +72,7 @@ func (v *WorkspaceVariable) BeforeCreate(tx *gorm.DB) error {
 	}
 	
 	// 加密敏感变量
-	if v.Sensitive && v.Value != "" && !crypto.IsEncrypted(v.Value) {
+	if v.Sensitive && !v.IsSecretRef() && v.Value != "" && !crypto.IsEncrypted(v.Value) {
 		encrypted, err := crypto.EncryptValue(v.Value)
 		if err != nil {
 			return fmt.Errorf("failed to encrypt variable: %w", err)
@@ -77,7 +84,7 @@ func (v *WorkspaceVariable) BeforeCreate(tx *gorm.DB) error {
 
 // BeforeSave 保存前加密敏感变量
 func (v *WorkspaceVariable) BeforeSave(tx *gorm.DB) error {
-	if v.Sensitive && v.Value != "" && !crypto.IsEncrypted(v.Value) {
+	if v.Sensitive && !v.IsSecretRef() && v.Value != "" && !crypto.IsEncrypted(v.Value) {
 		encrypted, err := crypto.EncryptValue(v.Value)
 		if err != nil {
 			return fmt.Errorf("failed to encrypt variable: %w", err)
@@ -135,8 +142,8 @@ func (v *WorkspaceVariable) ToResponse() *WorkspaceVariableResponse {
 		CreatedBy:    v.CreatedBy,
 	}
 
-	// 敏感变量不返回值
-	if !v.Sensitive {
+	// 敏感变量不返回值；密文引用本身不是密文，始终返回
+	if !v.Sensitive || v.IsSecretRef() {
 		resp.Value = v.Value
 	}
 
@@ -274,6 +274,16 @@ type Workspace struct {
 	// 系统变量
 	SystemVariables JSONB `json:"system_variables" gorm:"type:jsonb"`
 
+	// Plan 前静态分析配置（analyzers: builtin/tflint/trivy/checkov）
+	StaticAnalysisConfig JSONB `json:"static_analysis_config" gorm:"type:jsonb"`
+
+	// Plan 后成本估算与预算配置（enabled, max_monthly_delta, max_monthly_total, enforcement_level）
+	CostEstimationConfig JSONB `json:"cost_estimation_config" gorm:"type:jsonb"`
+
+	// 工作空间模板关联（从模板创建或同步时记录）
+	TemplateID      *string `json:"template_id" gorm:"type:varchar(50);index"`
+	TemplateVersion int     `json:"template_version" gorm:"default:0"`
+
 	// Overview统计字段
 	ResourceCount  int        `json:"resource_count" gorm:"default:0"` // 当前管理的资源数量
 	LastPlanAt     *time.Time `json:"last_plan_at" gorm:"index"`       // 最后一次Plan执行时间
@@ -339,6 +349,7 @@ const (
 	TaskTypeApply        TaskType = "apply"
 	TaskTypePlanAndApply TaskType = "plan_and_apply" // Plan+Apply组合任务
 	TaskTypeDriftCheck   TaskType = "drift_check"    // Drift 检测任务
+	TaskTypeTest         TaskType = "test"           // Module 版本测试任务（terraform test / tofu test）
 )
 
 // TaskStatus 任务状态枚举
@@ -354,6 +365,8 @@ const (
 	TaskStatusApplied            TaskStatus = "applied"              // Apply任务成功完成
 	TaskStatusFailed             TaskStatus = "failed"
 	TaskStatusCancelled          TaskStatus = "cancelled"
+	// Apply完成但State尚未上传（服务端不可用，State保存在Agent本地spool中），State到达后转为applied
+	TaskStatusStatePendingUpload TaskStatus = "state_pending_upload"
 )
 
 // WorkspaceTask 工作空间任务模型
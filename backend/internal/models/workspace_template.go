@@ -0,0 +1,172 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// WorkspaceTemplate 工作空间模板（golden workspace blueprint）
+// 描述一个完整的 workspace 配置：基础设置、Run Tasks、通知、变量定义和初始资源
+type WorkspaceTemplate struct {
+	ID          uint                  `json:"id" gorm:"primaryKey"`
+	TemplateID  string                `json:"template_id" gorm:"column:template_id;type:varchar(50);uniqueIndex"` // 语义化ID，如 wstpl-xxx
+	Name        string                `json:"name" gorm:"type:varchar(100);not null;uniqueIndex"`
+	Description string                `json:"description" gorm:"type:text"`
+	Version     int                   `json:"version" gorm:"not null;default:1"` // 每次修改模板内容递增，workspace 记录创建/同步时的版本
+	Spec        WorkspaceTemplateSpec `json:"spec" gorm:"type:jsonb"`
+	Enabled     bool                  `json:"enabled" gorm:"default:true"`
+	CreatedBy   *string               `json:"created_by" gorm:"type:varchar(50)"`
+	CreatedAt   time.Time             `json:"created_at"`
+	UpdatedAt   time.Time             `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (WorkspaceTemplate) TableName() string {
+	return "workspace_templates"
+}
+
+// WorkspaceTemplateSpec 模板内容
+type WorkspaceTemplateSpec struct {
+	Settings      WorkspaceTemplateSettings       `json:"settings"`
+	RunTasks      []WorkspaceTemplateRunTask      `json:"run_tasks,omitempty"`
+	Notifications []WorkspaceTemplateNotification `json:"notifications,omitempty"`
+	Variables     []WorkspaceTemplateVariable     `json:"variables,omitempty"`
+	Resources     []WorkspaceTemplateResource     `json:"resources,omitempty"`
+}
+
+// Value 实现 driver.Valuer 接口
+func (s WorkspaceTemplateSpec) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+// Scan 实现 sql.Scanner 接口
+func (s *WorkspaceTemplateSpec) Scan(value interface{}) error {
+	if value == nil {
+		*s = WorkspaceTemplateSpec{}
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(bytes, s)
+}
+
+// WorkspaceTemplateSettings workspace 基础设置
+// 字段的 json 名称与 Workspace 字段（数据库列）一致；未设置（nil）的字段不由模板管理
+type WorkspaceTemplateSettings struct {
+	ExecutionMode          *string                `json:"execution_mode,omitempty"`
+	AgentPoolID            *uint                  `json:"agent_pool_id,omitempty"`
+	CurrentPoolID          *string                `json:"current_pool_id,omitempty"`
+	K8sConfigID            *uint                  `json:"k8s_config_id,omitempty"`
+	TerraformVersion       *string                `json:"terraform_version,omitempty"`
+	Workdir                *string                `json:"workdir,omitempty"`
+	StateBackend           *string                `json:"state_backend,omitempty"`
+	AutoApply              *bool                  `json:"auto_apply,omitempty"`
+	PlanOnly               *bool                  `json:"plan_only,omitempty"`
+	UIMode                 *string                `json:"ui_mode,omitempty"`
+	ShowUnchangedResources *bool                  `json:"show_unchanged_resources,omitempty"`
+	OutputsSharing         *string                `json:"outputs_sharing,omitempty"`
+	RetryEnabled           *bool                  `json:"retry_enabled,omitempty"`
+	MaxRetries             *int                   `json:"max_retries,omitempty"`
+	DriftCheckEnabled      *bool                  `json:"drift_check_enabled,omitempty"`
+	DriftCheckStartTime    *string                `json:"drift_check_start_time,omitempty"`
+	DriftCheckEndTime      *string                `json:"drift_check_end_time,omitempty"`
+	DriftCheckInterval     *int                   `json:"drift_check_interval,omitempty"`
+	ProviderTemplateIDs    []uint                 `json:"provider_template_ids,omitempty"`
+	ProviderOverrides      map[string]interface{} `json:"provider_overrides,omitempty"`
+	Tags                   map[string]interface{} `json:"tags,omitempty"`
+	NotifySettings         map[string]interface{} `json:"notify_settings,omitempty"`
+	StaticAnalysisConfig   map[string]interface{} `json:"static_analysis_config,omitempty"`
+	CostEstimationConfig   map[string]interface{} `json:"cost_estimation_config,omitempty"`
+}
+
+// WorkspaceTemplateRunTask 模板中的 Run Task 关联
+type WorkspaceTemplateRunTask struct {
+	RunTaskID        string                  `json:"run_task_id"`
+	Stage            RunTaskStage            `json:"stage"`
+	EnforcementLevel RunTaskEnforcementLevel `json:"enforcement_level"`
+	Enabled          *bool                   `json:"enabled,omitempty"` // 默认 true
+}
+
+// WorkspaceTemplateNotification 模板中的通知关联
+type WorkspaceTemplateNotification struct {
+	NotificationID string `json:"notification_id"`
+	Events         string `json:"events"`            // 逗号分隔，默认 task_completed,task_failed
+	Enabled        *bool  `json:"enabled,omitempty"` // 默认 true
+}
+
+// WorkspaceTemplateVariable 模板中的变量定义
+type WorkspaceTemplateVariable struct {
+	Key          string       `json:"key"`
+	Value        string       `json:"value,omitempty"` // 默认值；敏感变量保存为密文
+	VariableType VariableType `json:"variable_type"`
+	ValueFormat  ValueFormat  `json:"value_format"`
+	Sensitive    bool         `json:"sensitive"`
+	Description  string       `json:"description,omitempty"`
+	Required     bool         `json:"required"` // 从模板创建时必须填写
+}
+
+// WorkspaceTemplateResource 模板中的初始资源（基于 Module）
+type WorkspaceTemplateResource struct {
+	Name          string                 `json:"name"`
+	ModuleID      *uint                  `json:"module_id,omitempty"`
+	ModuleSource  string                 `json:"module_source,omitempty"`  // 不引用 Module 时必填
+	ModuleVersion string                 `json:"module_version,omitempty"` // 默认使用 Module 的版本
+	ResourceType  string                 `json:"resource_type,omitempty"`  // 默认 {provider}_{module_name}
+	Config        map[string]interface{} `json:"config,omitempty"`
+	Description   string                 `json:"description,omitempty"`
+}
+
+// CreateWorkspaceTemplateRequest 创建模板请求
+type CreateWorkspaceTemplateRequest struct {
+	Name        string                `json:"name" binding:"required"`
+	Description string                `json:"description"`
+	Spec        WorkspaceTemplateSpec `json:"spec"`
+	Enabled     *bool                 `json:"enabled"`
+}
+
+// UpdateWorkspaceTemplateRequest 更新模板请求
+type UpdateWorkspaceTemplateRequest struct {
+	Name        *string                `json:"name"`
+	Description *string                `json:"description"`
+	Spec        *WorkspaceTemplateSpec `json:"spec"`
+	Enabled     *bool                  `json:"enabled"`
+}
+
+// CreateWorkspaceFromTemplateRequest 从模板创建 workspace 请求
+type CreateWorkspaceFromTemplateRequest struct {
+	TemplateID  string                    `json:"template_id" binding:"required"`
+	Name        string                    `json:"name" binding:"required"`
+	Description string                    `json:"description"`
+	Variables   map[string]string         `json:"variables"` // 变量值（required 变量必须提供），按 key 覆盖默认值
+	Settings    WorkspaceTemplateSettings `json:"settings"`  // 覆盖模板设置，如 agent pool
+}
+
+// PropagateWorkspaceTemplateRequest 同步模板到 workspace 请求
+type PropagateWorkspaceTemplateRequest struct {
+	WorkspaceIDs []string          `json:"workspace_ids"` // 为空时同步所有关联的 workspace
+	Variables    map[string]string `json:"variables"`     // 新增 required 变量的值
+}
+
+// WorkspaceTemplateChange 模板与 workspace 之间的一项差异
+type WorkspaceTemplateChange struct {
+	Section string      `json:"section"` // settings, run_tasks, notifications, variables, resources
+	Key     string      `json:"key"`
+	Action  string      `json:"action"` // add, update, requires_input
+	Current interface{} `json:"current,omitempty"`
+	Desired interface{} `json:"desired,omitempty"`
+}
+
+// WorkspaceTemplatePreview 模板同步预览（单个 workspace）
+type WorkspaceTemplatePreview struct {
+	WorkspaceID   string                    `json:"workspace_id"`
+	WorkspaceName string                    `json:"workspace_name"`
+	FromVersion   int                       `json:"from_version"`
+	ToVersion     int                       `json:"to_version"`
+	UpToDate      bool                      `json:"up_to_date"`
+	Changes       []WorkspaceTemplateChange `json:"changes"`
+	Applied       bool                      `json:"applied,omitempty"`
+	Error         string                    `json:"error,omitempty"`
+}
@@ -0,0 +1,761 @@
+package parsers
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"iac-platform/internal/models"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Manifest HCL 取值约定（与画布节点配置一致）：
+//   - 字面量（字符串、数字、布尔、列表、对象）保存为对应的 JSON 值；
+//     字符串按 Terraform 模板语义保存，字面量中的 "${" 写作 "$${"
+//   - var./local./module./data. 开头的引用保存为裸字符串，如 "module.vpc.vpc_id"
+//   - 其他表达式（函数调用、条件、for 表达式、each/count 引用等）保存为 "${<表达式源码>}"
+// 生成 HCL 时按相同约定还原，保证 import -> export -> import 不丢失信息。
+
+// referenceRoots 以裸字符串保存的引用前缀
+var referenceRoots = map[string]bool{"var": true, "local": true, "module": true, "data": true}
+
+// moduleMetaArguments 生成 HCL 时排在普通参数之前的 meta-arguments（depends_on 排在最后）
+var moduleMetaArguments = []string{"count", "for_each", "providers"}
+
+// ManifestHCL 解析结果
+type ManifestHCL struct {
+	Modules   []ManifestHCLModule
+	Variables []models.ManifestVariable
+	Locals    []models.ManifestLocal
+	Outputs   []models.ManifestOutput
+}
+
+// ManifestHCLModule module 块
+type ManifestHCLModule struct {
+	Name       string
+	Source     string
+	Version    string
+	Config     map[string]interface{} // 包含 count/for_each/providers/depends_on 等 meta-arguments
+	References []ManifestHCLReference // 配置中对其他 module 的引用
+	Line       int
+}
+
+// ManifestHCLReference module 配置中对其他 module 的引用
+type ManifestHCLReference struct {
+	Module     string // 被引用的 module 实例名
+	Output     string // 被引用的 output，depends_on = [module.x] 时为空
+	Attribute  string // 引用所在的顶层参数名
+	Expression string // 引用源码，如 module.vpc.vpc_id
+}
+
+// ManifestHCLDiagnostic 解析诊断信息
+type ManifestHCLDiagnostic struct {
+	Severity string `json:"severity"` // error, warning
+	Summary  string `json:"summary"`
+	Detail   string `json:"detail,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+}
+
+// HasManifestHCLErrors 诊断中是否包含错误
+func HasManifestHCLErrors(diags []ManifestHCLDiagnostic) bool {
+	for _, d := range diags {
+		if d.Severity == "error" {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseManifestHCL 使用 hclsyntax 解析 manifest HCL
+// 支持 module、variable、output、locals 块，其他块会被忽略并给出 warning
+func ParseManifestHCL(content []byte, filename string) (*ManifestHCL, []ManifestHCLDiagnostic) {
+	p := &manifestHCLParser{src: content, result: &ManifestHCL{}}
+
+	file, diags := hclsyntax.ParseConfig(content, filename, hcl.InitialPos)
+	p.addDiags(diags)
+	if diags.HasErrors() {
+		return p.result, p.diags
+	}
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		p.errorf(nil, "Unsupported file", "HCL native syntax is required")
+		return p.result, p.diags
+	}
+
+	for _, attr := range sortedAttributes(body.Attributes) {
+		p.warnf(&attr.SrcRange, "Unsupported top-level argument", "Argument %q is ignored on import", attr.Name)
+	}
+
+	seen := map[string]map[string]bool{"module": {}, "variable": {}, "output": {}, "locals": {}}
+	for _, block := range body.Blocks {
+		switch block.Type {
+		case "module", "variable", "output":
+			if len(block.Labels) != 1 {
+				p.errorf(&block.TypeRange, "Invalid block", "A %s block requires exactly one label", block.Type)
+				continue
+			}
+			name := block.Labels[0]
+			if seen[block.Type][name] {
+				p.errorf(&block.TypeRange, "Duplicate block", "%s %q is defined more than once", block.Type, name)
+				continue
+			}
+			seen[block.Type][name] = true
+			switch block.Type {
+			case "module":
+				p.parseModule(block)
+			case "variable":
+				p.parseVariable(block)
+			case "output":
+				p.parseOutput(block)
+			}
+		case "locals":
+			for _, attr := range sortedAttributes(block.Body.Attributes) {
+				if seen["locals"][attr.Name] {
+					p.errorf(&attr.SrcRange, "Duplicate local value", "local.%s is defined more than once", attr.Name)
+					continue
+				}
+				seen["locals"][attr.Name] = true
+				p.result.Locals = append(p.result.Locals, models.ManifestLocal{Name: attr.Name, Value: p.exprValue(attr.Expr)})
+			}
+		default:
+			p.warnf(&block.TypeRange, "Unsupported block", "Block %q is not part of a manifest and is ignored on import", block.Type)
+		}
+	}
+
+	return p.result, p.diags
+}
+
+type manifestHCLParser struct {
+	src    []byte
+	result *ManifestHCL
+	diags  []ManifestHCLDiagnostic
+}
+
+func (p *manifestHCLParser) parseModule(block *hclsyntax.Block) {
+	module := ManifestHCLModule{
+		Name:   block.Labels[0],
+		Config: make(map[string]interface{}),
+		Line:   block.TypeRange.Start.Line,
+	}
+	for _, nested := range block.Body.Blocks {
+		p.warnf(&nested.TypeRange, "Unsupported nested block", "Block %q inside module %q is ignored on import", nested.Type, module.Name)
+	}
+
+	for _, attr := range sortedAttributes(block.Body.Attributes) {
+		switch attr.Name {
+		case "source", "version":
+			value, ok := p.literalString(attr.Expr)
+			if !ok {
+				p.errorf(&attr.SrcRange, "Invalid module "+attr.Name, "The %s argument of module %q must be a literal string", attr.Name, module.Name)
+				continue
+			}
+			if attr.Name == "source" {
+				module.Source = value
+			} else {
+				module.Version = value
+			}
+			continue
+		}
+
+		module.Config[attr.Name] = p.exprValue(attr.Expr)
+		for _, traversal := range attr.Expr.Variables() {
+			if ref, ok := p.moduleReference(traversal, attr.Name); ok {
+				module.References = append(module.References, ref)
+			}
+		}
+	}
+	if module.Source == "" {
+		p.errorf(&block.TypeRange, "Missing module source", "Module %q must set the source argument", module.Name)
+	}
+	p.result.Modules = append(p.result.Modules, module)
+}
+
+func (p *manifestHCLParser) parseVariable(block *hclsyntax.Block) {
+	variable := models.ManifestVariable{Name: block.Labels[0], Required: true}
+	for _, attr := range sortedAttributes(block.Body.Attributes) {
+		switch attr.Name {
+		case "type":
+			variable.Type = p.source(attr.Expr.Range())
+		case "description":
+			if value, ok := p.literalString(attr.Expr); ok {
+				variable.Description = value
+			} else {
+				p.errorf(&attr.SrcRange, "Invalid description", "The description of variable %q must be a literal string", variable.Name)
+			}
+		case "default":
+			variable.Default = p.exprValue(attr.Expr)
+			variable.Required = false
+		case "sensitive", "nullable":
+			value, ok := p.literalBool(attr.Expr)
+			if !ok {
+				p.errorf(&attr.SrcRange, "Invalid "+attr.Name, "The %s argument of variable %q must be true or false", attr.Name, variable.Name)
+				continue
+			}
+			if attr.Name == "sensitive" {
+				variable.Sensitive = value
+			} else {
+				variable.Nullable = &value
+			}
+		default:
+			p.warnf(&attr.SrcRange, "Unsupported argument", "Argument %q of variable %q is ignored on import", attr.Name, variable.Name)
+		}
+	}
+	for _, nested := range block.Body.Blocks {
+		if nested.Type != "validation" {
+			p.warnf(&nested.TypeRange, "Unsupported nested block", "Block %q inside variable %q is ignored on import", nested.Type, variable.Name)
+			continue
+		}
+		validation := models.ManifestVariableValidation{}
+		if attr, ok := nested.Body.Attributes["condition"]; ok {
+			validation.Condition = p.source(attr.Expr.Range())
+		}
+		if attr, ok := nested.Body.Attributes["error_message"]; ok {
+			if msg, ok := p.exprValue(attr.Expr).(string); ok {
+				validation.ErrorMessage = msg
+			}
+		}
+		if validation.Condition == "" {
+			p.errorf(&nested.TypeRange, "Missing validation condition", "A validation block of variable %q must set condition", variable.Name)
+			continue
+		}
+		variable.Validations = append(variable.Validations, validation)
+	}
+	p.result.Variables = append(p.result.Variables, variable)
+}
+
+func (p *manifestHCLParser) parseOutput(block *hclsyntax.Block) {
+	output := models.ManifestOutput{Name: block.Labels[0]}
+	hasValue := false
+	for _, attr := range sortedAttributes(block.Body.Attributes) {
+		switch attr.Name {
+		case "value":
+			output.Value = p.exprValue(attr.Expr)
+			hasValue = true
+		case "description":
+			if value, ok := p.literalString(attr.Expr); ok {
+				output.Description = value
+			} else {
+				p.errorf(&attr.SrcRange, "Invalid description", "The description of output %q must be a literal string", output.Name)
+			}
+		case "sensitive":
+			value, ok := p.literalBool(attr.Expr)
+			if !ok {
+				p.errorf(&attr.SrcRange, "Invalid sensitive", "The sensitive argument of output %q must be true or false", output.Name)
+				continue
+			}
+			output.Sensitive = value
+		case "depends_on":
+			for _, traversal := range attr.Expr.Variables() {
+				output.DependsOn = append(output.DependsOn, p.source(traversal.SourceRange()))
+			}
+		default:
+			p.warnf(&attr.SrcRange, "Unsupported argument", "Argument %q of output %q is ignored on import", attr.Name, output.Name)
+		}
+	}
+	for _, nested := range block.Body.Blocks {
+		p.warnf(&nested.TypeRange, "Unsupported nested block", "Block %q inside output %q is ignored on import", nested.Type, output.Name)
+	}
+	if !hasValue {
+		p.errorf(&block.TypeRange, "Missing output value", "Output %q must set the value argument", output.Name)
+	}
+	p.result.Outputs = append(p.result.Outputs, output)
+}
+
+// moduleReference 从 traversal 中识别 module.<name>[.<output>] 引用
+func (p *manifestHCLParser) moduleReference(traversal hcl.Traversal, attribute string) (ManifestHCLReference, bool) {
+	if traversal.RootName() != "module" || len(traversal) < 2 {
+		return ManifestHCLReference{}, false
+	}
+	name, ok := traversal[1].(hcl.TraverseAttr)
+	if !ok {
+		return ManifestHCLReference{}, false
+	}
+	ref := ManifestHCLReference{
+		Module:     name.Name,
+		Attribute:  attribute,
+		Expression: p.source(traversal.SourceRange()),
+	}
+	// module.x[0].id / module.x["key"].id：跳过 count/for_each 索引
+	for _, step := range traversal[2:] {
+		if attr, ok := step.(hcl.TraverseAttr); ok {
+			ref.Output = attr.Name
+			break
+		}
+	}
+	return ref, true
+}
+
+// exprValue 按取值约定把表达式转换为 JSON 值
+func (p *manifestHCLParser) exprValue(expr hclsyntax.Expression) interface{} {
+	switch e := expr.(type) {
+	case *hclsyntax.LiteralValueExpr:
+		if value, ok := ctyToInterface(e.Val); ok {
+			return value
+		}
+	case *hclsyntax.TemplateExpr:
+		return p.templateValue(e)
+	case *hclsyntax.TemplateWrapExpr:
+		return "${" + p.source(e.Wrapped.Range()) + "}"
+	case *hclsyntax.TupleConsExpr:
+		items := make([]interface{}, 0, len(e.Exprs))
+		for _, item := range e.Exprs {
+			items = append(items, p.exprValue(item))
+		}
+		return items
+	case *hclsyntax.ObjectConsExpr:
+		if object, ok := p.objectValue(e); ok {
+			return object
+		}
+	case *hclsyntax.ScopeTraversalExpr:
+		if referenceRoots[e.Traversal.RootName()] {
+			return p.source(e.Range())
+		}
+	case *hclsyntax.UnaryOpExpr:
+		// 负数字面量，如 -1
+		if len(e.Variables()) == 0 {
+			if value, diags := e.Value(nil); !diags.HasErrors() {
+				if converted, ok := ctyToInterface(value); ok {
+					return converted
+				}
+			}
+		}
+	}
+	return "${" + p.source(expr.Range()) + "}"
+}
+
+// templateValue 把字符串模板还原为模板源码形式（字面量部分转义 "${"、"%{"）
+func (p *manifestHCLParser) templateValue(e *hclsyntax.TemplateExpr) interface{} {
+	var builder strings.Builder
+	for _, part := range e.Parts {
+		if lit, ok := part.(*hclsyntax.LiteralValueExpr); ok && lit.Val.Type() == cty.String && lit.Val.IsKnown() && !lit.Val.IsNull() {
+			builder.WriteString(escapeTemplateLiteral(lit.Val.AsString()))
+			continue
+		}
+		src := p.source(part.Range())
+		if strings.HasPrefix(strings.TrimSpace(src), "%{") {
+			// 模板指令（%{if}/%{for}）无法按部分还原，保留整个模板表达式
+			return "${" + p.source(e.Range()) + "}"
+		}
+		builder.WriteString("${" + src + "}")
+	}
+	return builder.String()
+}
+
+func (p *manifestHCLParser) objectValue(e *hclsyntax.ObjectConsExpr) (map[string]interface{}, bool) {
+	object := make(map[string]interface{}, len(e.Items))
+	for _, item := range e.Items {
+		key := hcl.ExprAsKeyword(item.KeyExpr)
+		if key == "" {
+			value, diags := item.KeyExpr.Value(nil)
+			if diags.HasErrors() || !value.IsKnown() || value.IsNull() || value.Type() != cty.String {
+				// 计算得到的 key 无法用 JSON 对象表示
+				return nil, false
+			}
+			key = value.AsString()
+		}
+		object[key] = p.exprValue(item.ValueExpr)
+	}
+	return object, true
+}
+
+func (p *manifestHCLParser) literalString(expr hclsyntax.Expression) (string, bool) {
+	if len(expr.Variables()) > 0 {
+		return "", false
+	}
+	value, diags := expr.Value(nil)
+	if diags.HasErrors() || !value.IsKnown() || value.IsNull() || value.Type() != cty.String {
+		return "", false
+	}
+	return value.AsString(), true
+}
+
+func (p *manifestHCLParser) literalBool(expr hclsyntax.Expression) (bool, bool) {
+	if len(expr.Variables()) > 0 {
+		return false, false
+	}
+	value, diags := expr.Value(nil)
+	if diags.HasErrors() || !value.IsKnown() || value.IsNull() || value.Type() != cty.Bool {
+		return false, false
+	}
+	return value.True(), true
+}
+
+func (p *manifestHCLParser) source(rng hcl.Range) string {
+	return string(rng.SliceBytes(p.src))
+}
+
+func (p *manifestHCLParser) addDiags(diags hcl.Diagnostics) {
+	for _, d := range diags {
+		diag := ManifestHCLDiagnostic{Severity: "error", Summary: d.Summary, Detail: d.Detail}
+		if d.Severity == hcl.DiagWarning {
+			diag.Severity = "warning"
+		}
+		if d.Subject != nil {
+			diag.Line = d.Subject.Start.Line
+			diag.Column = d.Subject.Start.Column
+		}
+		p.diags = append(p.diags, diag)
+	}
+}
+
+func (p *manifestHCLParser) errorf(rng *hcl.Range, summary string, format string, args ...interface{}) {
+	p.addDiags(hcl.Diagnostics{{Severity: hcl.DiagError, Summary: summary, Detail: fmt.Sprintf(format, args...), Subject: rng}})
+}
+
+func (p *manifestHCLParser) warnf(rng *hcl.Range, summary string, format string, args ...interface{}) {
+	p.addDiags(hcl.Diagnostics{{Severity: hcl.DiagWarning, Summary: summary, Detail: fmt.Sprintf(format, args...), Subject: rng}})
+}
+
+// sortedAttributes 按源码顺序返回属性
+func sortedAttributes(attrs hclsyntax.Attributes) []*hclsyntax.Attribute {
+	result := make([]*hclsyntax.Attribute, 0, len(attrs))
+	for _, attr := range attrs {
+		result = append(result, attr)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].SrcRange.Start.Byte < result[j].SrcRange.Start.Byte
+	})
+	return result
+}
+
+func ctyToInterface(value cty.Value) (interface{}, bool) {
+	if value.IsNull() {
+		return nil, true
+	}
+	if !value.IsKnown() {
+		return nil, false
+	}
+	switch value.Type() {
+	case cty.String:
+		return escapeTemplateLiteral(value.AsString()), true
+	case cty.Number:
+		f, _ := value.AsBigFloat().Float64()
+		return f, true
+	case cty.Bool:
+		return value.True(), true
+	}
+	return nil, false
+}
+
+func escapeTemplateLiteral(s string) string {
+	s = strings.ReplaceAll(s, "${", "$${")
+	return strings.ReplaceAll(s, "%{", "%%{")
+}
+
+// ========== HCL 生成 ==========
+
+// GenerateManifestHCL 根据 manifest 版本内容生成 HCL
+// 顺序：variable、locals、module、output；同一块内的参数顺序固定，保证输出稳定
+func GenerateManifestHCL(header string, nodes []models.ManifestNode, variables []models.ManifestVariable, locals []models.ManifestLocal, outputs []models.ManifestOutput) string {
+	var b strings.Builder
+	b.WriteString(header)
+
+	for _, v := range variables {
+		fmt.Fprintf(&b, "variable %q {\n", v.Name)
+		if v.Type != "" {
+			fmt.Fprintf(&b, "  type = %s\n", v.Type)
+		}
+		if v.Description != "" {
+			fmt.Fprintf(&b, "  description = %s\n", quoteHCLString(escapeTemplateLiteral(v.Description)))
+		}
+		if v.Default != nil || !v.Required {
+			fmt.Fprintf(&b, "  default = %s\n", FormatHCLValue(v.Default, 1))
+		}
+		if v.Sensitive {
+			b.WriteString("  sensitive = true\n")
+		}
+		if v.Nullable != nil {
+			fmt.Fprintf(&b, "  nullable = %t\n", *v.Nullable)
+		}
+		for _, validation := range v.Validations {
+			b.WriteString("\n  validation {\n")
+			fmt.Fprintf(&b, "    condition     = %s\n", validation.Condition)
+			fmt.Fprintf(&b, "    error_message = %s\n", FormatHCLValue(validation.ErrorMessage, 2))
+			b.WriteString("  }\n")
+		}
+		b.WriteString("}\n\n")
+	}
+
+	if len(locals) > 0 {
+		b.WriteString("locals {\n")
+		for _, l := range locals {
+			fmt.Fprintf(&b, "  %s = %s\n", l.Name, FormatHCLValue(l.Value, 1))
+		}
+		b.WriteString("}\n\n")
+	}
+
+	for _, node := range nodes {
+		if node.Type != models.NodeTypeModule {
+			continue
+		}
+		fmt.Fprintf(&b, "module %q {\n", node.InstanceName)
+		if node.ModuleSource != "" {
+			fmt.Fprintf(&b, "  source  = %s\n", quoteHCLString(node.ModuleSource))
+		}
+		if node.ModuleVersion != "" {
+			fmt.Fprintf(&b, "  version = %s\n", quoteHCLString(node.ModuleVersion))
+		}
+		for _, key := range moduleConfigKeys(node.Config) {
+			fmt.Fprintf(&b, "  %s = %s\n", formatHCLKey(key), FormatHCLValue(node.Config[key], 1))
+		}
+		b.WriteString("}\n\n")
+	}
+
+	for _, o := range outputs {
+		fmt.Fprintf(&b, "output %q {\n", o.Name)
+		fmt.Fprintf(&b, "  value = %s\n", FormatHCLValue(o.Value, 1))
+		if o.Description != "" {
+			fmt.Fprintf(&b, "  description = %s\n", quoteHCLString(escapeTemplateLiteral(o.Description)))
+		}
+		if o.Sensitive {
+			b.WriteString("  sensitive = true\n")
+		}
+		if len(o.DependsOn) > 0 {
+			fmt.Fprintf(&b, "  depends_on = [%s]\n", strings.Join(o.DependsOn, ", "))
+		}
+		b.WriteString("}\n\n")
+	}
+
+	return b.String()
+}
+
+// moduleConfigKeys meta-arguments 在前、depends_on 在最后，其余按字母排序
+func moduleConfigKeys(config map[string]interface{}) []string {
+	keys := make([]string, 0, len(config))
+	for _, meta := range moduleMetaArguments {
+		if _, ok := config[meta]; ok {
+			keys = append(keys, meta)
+		}
+	}
+	var rest []string
+	for key := range config {
+		if key == "source" || key == "version" || key == "depends_on" || containsString(moduleMetaArguments, key) {
+			continue
+		}
+		rest = append(rest, key)
+	}
+	sort.Strings(rest)
+	keys = append(keys, rest...)
+	if _, ok := config["depends_on"]; ok {
+		keys = append(keys, "depends_on")
+	}
+	return keys
+}
+
+// FormatHCLValue 按取值约定把 JSON 值格式化为 HCL 表达式
+// indent 为当前所在块的缩进层级（每级两个空格），用于多行对象和列表
+func FormatHCLValue(value interface{}, indent int) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return formatHCLString(v)
+	case bool:
+		return fmt.Sprintf("%t", v)
+	case float64:
+		return formatHCLNumber(v)
+	case float32:
+		return formatHCLNumber(float64(v))
+	case int:
+		return fmt.Sprintf("%d", v)
+	case int64:
+		return fmt.Sprintf("%d", v)
+	case []string:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = item
+		}
+		return FormatHCLValue(items, indent)
+	case []interface{}:
+		return formatHCLList(v, indent)
+	case map[string]interface{}:
+		return formatHCLObject(v, indent)
+	case models.JSONB:
+		return formatHCLObject(map[string]interface{}(v), indent)
+	default:
+		return quoteHCLString(escapeTemplateLiteral(fmt.Sprintf("%v", v)))
+	}
+}
+
+func formatHCLList(items []interface{}, indent int) string {
+	if len(items) == 0 {
+		return "[]"
+	}
+	multiline := false
+	formatted := make([]string, len(items))
+	for i, item := range items {
+		switch item.(type) {
+		case map[string]interface{}, []interface{}, models.JSONB:
+			multiline = true
+		}
+		formatted[i] = FormatHCLValue(item, indent+1)
+		if strings.Contains(formatted[i], "\n") {
+			multiline = true
+		}
+	}
+	if !multiline {
+		return "[" + strings.Join(formatted, ", ") + "]"
+	}
+	pad := strings.Repeat("  ", indent+1)
+	return "[\n" + pad + strings.Join(formatted, ",\n"+pad) + ",\n" + strings.Repeat("  ", indent) + "]"
+}
+
+func formatHCLObject(object map[string]interface{}, indent int) string {
+	if len(object) == 0 {
+		return "{}"
+	}
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pad := strings.Repeat("  ", indent+1)
+	var b strings.Builder
+	b.WriteString("{\n")
+	for _, key := range keys {
+		fmt.Fprintf(&b, "%s%s = %s\n", pad, formatHCLKey(key), FormatHCLValue(object[key], indent+1))
+	}
+	b.WriteString(strings.Repeat("  ", indent) + "}")
+	return b.String()
+}
+
+func formatHCLKey(key string) string {
+	if hclsyntax.ValidIdentifier(key) {
+		return key
+	}
+	return quoteHCLString(escapeTemplateLiteral(key))
+}
+
+func formatHCLNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// formatHCLString 字符串按取值约定还原为引用、表达式或模板字符串
+func formatHCLString(s string) string {
+	if isBareReference(s) {
+		return s
+	}
+	if inner, ok := singleInterpolation(s); ok {
+		return inner
+	}
+	if strings.HasSuffix(s, "\n") && strings.Count(s, "\n") > 1 {
+		return heredoc(s)
+	}
+	return quoteHCLString(s)
+}
+
+// isBareReference 判断是否为 var./local./module./data. 开头的合法引用
+func isBareReference(s string) bool {
+	dot := strings.Index(s, ".")
+	if dot <= 0 || !referenceRoots[s[:dot]] {
+		return false
+	}
+	_, diags := hclsyntax.ParseTraversalAbs([]byte(s), "", hcl.InitialPos)
+	return !diags.HasErrors()
+}
+
+// singleInterpolation 判断字符串是否恰好是一个 "${...}"，是则返回内部表达式源码
+func singleInterpolation(s string) (string, bool) {
+	if !strings.HasPrefix(s, "${") || !strings.HasSuffix(s, "}") {
+		return "", false
+	}
+	expr, diags := hclsyntax.ParseTemplate([]byte(s), "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return "", false
+	}
+	wrap, ok := expr.(*hclsyntax.TemplateWrapExpr)
+	if !ok {
+		return "", false
+	}
+	return string(wrap.Wrapped.Range().SliceBytes([]byte(s))), true
+}
+
+// quoteHCLString 生成带引号的模板字符串：字面量部分转义，"${...}"/"%{...}" 插值部分原样保留
+func quoteHCLString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c == '$' || c == '%') && i+2 < len(s) && s[i+1] == c && s[i+2] == '{' {
+			// 已转义的 $${ / %%{
+			b.WriteString(s[i : i+3])
+			i += 2
+			continue
+		}
+		if (c == '$' || c == '%') && i+1 < len(s) && s[i+1] == '{' {
+			end := interpolationEnd(s, i+2)
+			if end > 0 {
+				b.WriteString(s[i : end+1])
+				i = end
+				continue
+			}
+		}
+		switch c {
+		case '\\':
+			b.WriteString(`\\`)
+		case '"':
+			b.WriteString(`\"`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// interpolationEnd 返回与 start 之前的 "{" 匹配的 "}" 位置（跳过内部字符串），找不到返回 -1
+func interpolationEnd(s string, start int) int {
+	depth := 1
+	inString := false
+	for i := start; i < len(s); i++ {
+		c := s[i]
+		if inString {
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func heredoc(s string) string {
+	marker := "EOT"
+	for i := 1; strings.Contains("\n"+s, "\n"+marker+"\n"); i++ {
+		marker = fmt.Sprintf("EOT%d", i)
+	}
+	return "<<" + marker + "\n" + s + marker
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
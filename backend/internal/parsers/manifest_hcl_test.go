@@ -0,0 +1,203 @@
+package parsers
+
+import (
+	"testing"
+
+	"iac-platform/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testManifestHCL = `# network stack
+variable "env" {
+  type        = string
+  description = "Deployment environment"
+  default     = "dev"
+
+  validation {
+    condition     = contains(["dev", "prod"], var.env)
+    error_message = "env must be dev or prod."
+  }
+}
+
+variable "cidrs" {
+  type      = list(string)
+  sensitive = true
+  nullable  = false
+}
+
+locals {
+  name_prefix = "app-${var.env}"
+  tags = {
+    Env         = var.env
+    "cost-center" = "42"
+  }
+}
+
+module "vpc" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "5.1.0"
+
+  name = local.name_prefix
+  cidr = "10.0.0.0/16"
+  azs  = ["a", "b"]
+  user_data = <<EOT
+#!/bin/bash
+echo "hello"
+EOT
+}
+
+// instances depend on the vpc
+module "ec2" {
+  source   = "terraform-aws-modules/ec2-instance/aws"
+  for_each = toset(["one", "two"])
+
+  name      = "web-${each.key}"
+  subnet_id = module.vpc.private_subnets[0]
+  vpc_security_group_ids = [
+    module.vpc.default_security_group_id,
+  ]
+  monitoring = var.env == "prod" ? true : false
+  count_max  = -1
+  literal    = "$${not_interpolated}"
+}
+
+module "dns" {
+  source     = "./modules/dns"
+  depends_on = [module.ec2]
+}
+
+output "vpc_id" {
+  value       = module.vpc.vpc_id
+  description = "VPC ID"
+  depends_on  = [module.vpc]
+}
+`
+
+func TestParseManifestHCL(t *testing.T) {
+	result, diags := ParseManifestHCL([]byte(testManifestHCL), "main.tf")
+	require.False(t, HasManifestHCLErrors(diags), "%v", diags)
+
+	require.Len(t, result.Variables, 2)
+	env := result.Variables[0]
+	assert.Equal(t, "string", env.Type)
+	assert.Equal(t, "dev", env.Default)
+	assert.False(t, env.Required)
+	require.Len(t, env.Validations, 1)
+	assert.Equal(t, `contains(["dev", "prod"], var.env)`, env.Validations[0].Condition)
+	cidrs := result.Variables[1]
+	assert.Equal(t, "list(string)", cidrs.Type)
+	assert.True(t, cidrs.Required)
+	assert.True(t, cidrs.Sensitive)
+	require.NotNil(t, cidrs.Nullable)
+	assert.False(t, *cidrs.Nullable)
+
+	require.Len(t, result.Locals, 2)
+	assert.Equal(t, "app-${var.env}", result.Locals[0].Value)
+	assert.Equal(t, map[string]interface{}{"Env": "var.env", "cost-center": "42"}, result.Locals[1].Value)
+
+	require.Len(t, result.Modules, 3)
+	vpc := result.Modules[0]
+	assert.Equal(t, "terraform-aws-modules/vpc/aws", vpc.Source)
+	assert.Equal(t, "5.1.0", vpc.Version)
+	assert.Equal(t, "local.name_prefix", vpc.Config["name"])
+	assert.Equal(t, []interface{}{"a", "b"}, vpc.Config["azs"])
+	assert.Equal(t, "#!/bin/bash\necho \"hello\"\n", vpc.Config["user_data"])
+	assert.Empty(t, vpc.References)
+
+	ec2 := result.Modules[1]
+	assert.Equal(t, `${toset(["one", "two"])}`, ec2.Config["for_each"])
+	assert.Equal(t, "web-${each.key}", ec2.Config["name"])
+	assert.Equal(t, "module.vpc.private_subnets[0]", ec2.Config["subnet_id"])
+	assert.Equal(t, `${var.env == "prod" ? true : false}`, ec2.Config["monitoring"])
+	assert.Equal(t, float64(-1), ec2.Config["count_max"])
+	assert.Equal(t, "$${not_interpolated}", ec2.Config["literal"])
+	assert.ElementsMatch(t, []ManifestHCLReference{
+		{Module: "vpc", Output: "private_subnets", Attribute: "subnet_id", Expression: "module.vpc.private_subnets[0]"},
+		{Module: "vpc", Output: "default_security_group_id", Attribute: "vpc_security_group_ids", Expression: "module.vpc.default_security_group_id"},
+	}, ec2.References)
+
+	dns := result.Modules[2]
+	assert.Equal(t, []ManifestHCLReference{{Module: "ec2", Attribute: "depends_on", Expression: "module.ec2"}}, dns.References)
+
+	require.Len(t, result.Outputs, 1)
+	assert.Equal(t, "module.vpc.vpc_id", result.Outputs[0].Value)
+	assert.Equal(t, []string{"module.vpc"}, result.Outputs[0].DependsOn)
+}
+
+func TestParseManifestHCLDiagnostics(t *testing.T) {
+	t.Run("syntax error", func(t *testing.T) {
+		_, diags := ParseManifestHCL([]byte("module \"a\" {\n  source = \"x\"\n  name = \n}\n"), "main.tf")
+		require.True(t, HasManifestHCLErrors(diags))
+		assert.Equal(t, 3, diags[0].Line)
+	})
+
+	t.Run("semantic errors and warnings", func(t *testing.T) {
+		content := `provider "aws" {}
+
+module "a" {
+  source = var.source
+}
+
+output "x" {
+  description = "no value"
+}
+`
+		_, diags := ParseManifestHCL([]byte(content), "main.tf")
+		require.True(t, HasManifestHCLErrors(diags))
+
+		lines := map[string]int{}
+		for _, d := range diags {
+			lines[d.Severity+":"+d.Summary] = d.Line
+		}
+		assert.Equal(t, 1, lines["warning:Unsupported block"])
+		assert.Equal(t, 4, lines["error:Invalid module source"])
+		assert.Equal(t, 3, lines["error:Missing module source"])
+		assert.Equal(t, 7, lines["error:Missing output value"])
+	})
+}
+
+func TestManifestHCLRoundTrip(t *testing.T) {
+	first, diags := ParseManifestHCL([]byte(testManifestHCL), "main.tf")
+	require.False(t, HasManifestHCLErrors(diags), "%v", diags)
+
+	generated := GenerateManifestHCL("# header\n\n", toNodes(first.Modules), first.Variables, first.Locals, first.Outputs)
+	second, diags := ParseManifestHCL([]byte(generated), "generated.tf")
+	require.False(t, HasManifestHCLErrors(diags), "%v\n%s", diags, generated)
+
+	assert.Equal(t, first.Variables, second.Variables)
+	assert.Equal(t, first.Locals, second.Locals)
+	assert.Equal(t, first.Outputs, second.Outputs)
+	require.Len(t, second.Modules, len(first.Modules))
+	for i := range first.Modules {
+		assert.Equal(t, first.Modules[i].Source, second.Modules[i].Source)
+		assert.Equal(t, first.Modules[i].Version, second.Modules[i].Version)
+		assert.Equal(t, first.Modules[i].Config, second.Modules[i].Config)
+		assert.ElementsMatch(t, first.Modules[i].References, second.Modules[i].References)
+	}
+}
+
+func TestFormatHCLValue(t *testing.T) {
+	assert.Equal(t, "var.env", FormatHCLValue("var.env", 1))
+	assert.Equal(t, "length(var.azs)", FormatHCLValue("${length(var.azs)}", 1))
+	assert.Equal(t, `"a-${var.env}-\"q\""`, FormatHCLValue(`a-${var.env}-"q"`, 1))
+	assert.Equal(t, `"var.env is not a traversal"`, FormatHCLValue("var.env is not a traversal", 1))
+	assert.Equal(t, "null", FormatHCLValue(nil, 1))
+	assert.Equal(t, "1.5", FormatHCLValue(1.5, 1))
+	assert.Equal(t, "{\n    \"a b\" = 1\n    c = true\n  }", FormatHCLValue(map[string]interface{}{"a b": float64(1), "c": true}, 1))
+}
+
+func toNodes(modules []ManifestHCLModule) []models.ManifestNode {
+	nodes := make([]models.ManifestNode, 0, len(modules))
+	for _, m := range modules {
+		nodes = append(nodes, models.ManifestNode{
+			Type:          models.NodeTypeModule,
+			InstanceName:  m.Name,
+			ModuleSource:  m.Source,
+			ModuleVersion: m.Version,
+			Config:        m.Config,
+		})
+	}
+	return nodes
+}
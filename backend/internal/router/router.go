@@ -171,9 +171,11 @@ func Setup(db *gorm.DB, streamManager *services.OutputStreamManager, wsHub *webs
 	// 工作空间管理 - 使用IAM权限控制
 	// 传入 permissionService 用于创建 workspace 时自动为创建者授权
 	setupWorkspaceRoutes(api, db, streamManager, iamMiddleware, wsHub, queueManager, rawCCHandler, iamFactory.GetPermissionService())
-	setupModuleRoutes(api, db, iamMiddleware)
+	setupModuleRoutes(api, db, iamMiddleware, queueManager)
 	// Project 管理 - 使用 Organization 权限控制
 	setupProjectRoutes(api, db, iamMiddleware)
+	// Stack 协同执行 - 使用 Organization 权限控制
+	setupStackRoutes(api, db, iamMiddleware)
 	// AI分析路由
 	setupAIRoutes(api, db, iamMiddleware)
 
@@ -79,6 +79,12 @@ func setupAgentAPIRoutes(api *gin.RouterGroup, db *gorm.DB, streamManager *servi
 		agentTasks.POST("/:task_id/plan-json", middleware.PoolTokenAuthWithTaskCheck(db), agentHandler.UploadPlanJSON)
 		agentTasks.POST("/:task_id/parse-plan-changes", middleware.PoolTokenAuthWithTaskCheck(db), agentHandler.ParsePlanChanges)
 		agentTasks.GET("/:task_id/logs", middleware.PoolTokenAuthWithTaskCheck(db), agentHandler.GetTaskLogs)
+
+		// Module test tasks (terraform test / tofu test)
+		agentTasks.GET("/:task_id/module-test-bundle", middleware.PoolTokenAuthWithTaskCheck(db), agentHandler.GetModuleTestBundle)
+		agentTasks.POST("/:task_id/module-test-result", middleware.PoolTokenAuthWithTaskCheck(db), agentHandler.UploadModuleTestResult)
+		agentTasks.POST("/:task_id/static-analysis-results", middleware.PoolTokenAuthWithTaskCheck(db), agentHandler.UploadStaticAnalysisResults)
+		agentTasks.POST("/:task_id/cost-estimate", middleware.PoolTokenAuthWithTaskCheck(db), agentHandler.EstimateTaskCost)
 	}
 
 	// ===== Agent Workspace API Routes (for Agent v3.2) =====
@@ -150,6 +150,24 @@ func setupAIRoutes(api *gin.RouterGroup, db *gorm.DB, iamMiddleware *middleware.
 			skills.GET("/:id/usage-stats", skillController.GetSkillUsageStats)
 		}
 
+		// ========== Skill 评估 API ==========
+		skillEvalController := controllers.NewSkillEvalController(db)
+		skillEvals := admin.Group("/skill-evals")
+		{
+			skillEvals.GET("/suites", skillEvalController.ListSuites)
+			skillEvals.POST("/suites", skillEvalController.CreateSuite)
+			skillEvals.GET("/suites/:id", skillEvalController.GetSuite)
+			skillEvals.PUT("/suites/:id", skillEvalController.UpdateSuite)
+			skillEvals.DELETE("/suites/:id", skillEvalController.DeleteSuite)
+			skillEvals.POST("/suites/:id/cases", skillEvalController.CreateCase)
+			skillEvals.PUT("/suites/:id/cases/:case_id", skillEvalController.UpdateCase)
+			skillEvals.DELETE("/suites/:id/cases/:case_id", skillEvalController.DeleteCase)
+			skillEvals.GET("/suites/:id/runs", skillEvalController.ListRuns)
+			skillEvals.POST("/suites/:id/runs", skillEvalController.RunSuite)
+			skillEvals.POST("/suites/:id/compare", skillEvalController.CompareSkill)
+			skillEvals.GET("/runs/:run_id", skillEvalController.GetRun)
+		}
+
 		// ========== Module Skill API ==========
 		moduleSkillController := controllers.NewModuleSkillController(db)
 		admin.GET("/modules/:module_id/skill", moduleSkillController.GetModuleSkill)
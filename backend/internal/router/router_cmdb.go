@@ -18,6 +18,15 @@ func SetupCMDBRoutes(r *gin.RouterGroup, db *gorm.DB) {
 	// 创建外部数据源处理器
 	externalSourceHandler := handlers.NewCMDBExternalSourceHandler(db)
 
+	// 创建对账处理器
+	reconciliationHandler := handlers.NewCMDBReconciliationHandler(db)
+
+	// 创建资源历史处理器
+	historyHandler := handlers.NewCMDBHistoryHandler(db)
+
+	// 创建资源关系图处理器
+	graphHandler := handlers.NewCMDBGraphHandler(db)
+
 	// 初始化IAM权限中间件
 	iamMiddleware := middleware.NewIAMPermissionMiddleware(db)
 
@@ -46,6 +55,28 @@ func SetupCMDBRoutes(r *gin.RouterGroup, db *gorm.DB) {
 		cmdb.GET("/workspaces/:workspace_id/resources",
 			iamMiddleware.RequirePermission("WORKSPACES", "ORGANIZATION", "READ"),
 			cmdbHandler.GetResourceDetail)
+		cmdb.GET("/workspaces/:workspace_id/resources/history",
+			iamMiddleware.RequirePermission("WORKSPACES", "ORGANIZATION", "READ"),
+			historyHandler.GetResourceTimeline)
+		cmdb.GET("/workspaces/:workspace_id/resources/as-of",
+			iamMiddleware.RequirePermission("WORKSPACES", "ORGANIZATION", "READ"),
+			historyHandler.GetResourceAsOf)
+
+		// 跨workspace资源关系图和影响分析
+		cmdb.GET("/graph",
+			iamMiddleware.RequirePermission("WORKSPACES", "ORGANIZATION", "READ"),
+			graphHandler.GetResourceGraph)
+		cmdb.GET("/impact",
+			iamMiddleware.RequirePermission("WORKSPACES", "ORGANIZATION", "READ"),
+			graphHandler.AnalyzeImpact)
+
+		// 对账：外部清单 vs Terraform state
+		cmdb.GET("/reconciliation",
+			iamMiddleware.RequirePermission("WORKSPACES", "ORGANIZATION", "READ"),
+			reconciliationHandler.GetReconciliationReport)
+		cmdb.POST("/reconciliation/import",
+			iamMiddleware.RequirePermission("WORKSPACES", "ORGANIZATION", "WRITE"),
+			reconciliationHandler.ImportUnmanagedResources)
 
 		// 同步操作（需要cmdb:ADMIN权限，通常只有admin有此权限）
 		cmdb.POST("/workspaces/:workspace_id/sync",
@@ -75,6 +106,8 @@ func SetupCMDBRoutes(r *gin.RouterGroup, db *gorm.DB) {
 			externalSources.POST("/:source_id/test", externalSourceHandler.TestConnection)
 			// 获取同步日志
 			externalSources.GET("/:source_id/sync-logs", externalSourceHandler.GetSyncLogs)
+			// 上传文件数据源的导入文件
+			externalSources.POST("/:source_id/upload", externalSourceHandler.UploadSourceFile)
 		}
 	}
 }
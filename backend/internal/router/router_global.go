@@ -84,6 +84,44 @@ func setupGlobalRoutes(protected *gin.RouterGroup, db *gorm.DB, iamMiddleware *m
 			ptController.DeleteProviderTemplate,
 		)
 
+		// 工作空间模板管理
+		wtController := controllers.NewWorkspaceTemplateController(db)
+
+		globalSettings.GET("/workspace-templates",
+			iamMiddleware.RequirePermission("WORKSPACES", "ORGANIZATION", "READ"),
+			wtController.ListWorkspaceTemplates,
+		)
+
+		globalSettings.GET("/workspace-templates/:template_id",
+			iamMiddleware.RequirePermission("WORKSPACES", "ORGANIZATION", "READ"),
+			wtController.GetWorkspaceTemplate,
+		)
+
+		globalSettings.POST("/workspace-templates",
+			iamMiddleware.RequirePermission("WORKSPACES", "ORGANIZATION", "ADMIN"),
+			wtController.CreateWorkspaceTemplate,
+		)
+
+		globalSettings.PUT("/workspace-templates/:template_id",
+			iamMiddleware.RequirePermission("WORKSPACES", "ORGANIZATION", "ADMIN"),
+			wtController.UpdateWorkspaceTemplate,
+		)
+
+		globalSettings.DELETE("/workspace-templates/:template_id",
+			iamMiddleware.RequirePermission("WORKSPACES", "ORGANIZATION", "ADMIN"),
+			wtController.DeleteWorkspaceTemplate,
+		)
+
+		globalSettings.GET("/workspace-templates/:template_id/preview",
+			iamMiddleware.RequirePermission("WORKSPACES", "ORGANIZATION", "READ"),
+			wtController.PreviewWorkspaceTemplatePropagation,
+		)
+
+		globalSettings.POST("/workspace-templates/:template_id/propagate",
+			iamMiddleware.RequirePermission("WORKSPACES", "ORGANIZATION", "ADMIN"),
+			wtController.PropagateWorkspaceTemplate,
+		)
+
 		// AI配置管理
 		aiController := controllers.NewAIController(db)
 
@@ -145,6 +183,100 @@ func setupGlobalRoutes(protected *gin.RouterGroup, db *gorm.DB, iamMiddleware *m
 			platformConfigHandler.UpdatePlatformConfig,
 		)
 
+		// 任务产物归档存储与保留策略
+		artifactStorageHandler := handlers.NewArtifactStorageHandler(db)
+
+		globalSettings.GET("/artifact-storage",
+			iamMiddleware.RequirePermission("SYSTEM_SETTINGS", "ORGANIZATION", "READ"),
+			artifactStorageHandler.GetArtifactStorage,
+		)
+
+		globalSettings.PUT("/artifact-storage",
+			iamMiddleware.RequirePermission("SYSTEM_SETTINGS", "ORGANIZATION", "ADMIN"),
+			artifactStorageHandler.UpdateArtifactStorage,
+		)
+
+		globalSettings.POST("/artifact-storage/test",
+			iamMiddleware.RequirePermission("SYSTEM_SETTINGS", "ORGANIZATION", "ADMIN"),
+			artifactStorageHandler.TestArtifactStorage,
+		)
+
+		globalSettings.GET("/artifact-retention-policies",
+			iamMiddleware.RequirePermission("SYSTEM_SETTINGS", "ORGANIZATION", "READ"),
+			artifactStorageHandler.ListRetentionPolicies,
+		)
+
+		globalSettings.POST("/artifact-retention-policies/run",
+			iamMiddleware.RequirePermission("SYSTEM_SETTINGS", "ORGANIZATION", "ADMIN"),
+			artifactStorageHandler.RunRetention,
+		)
+
+		globalSettings.PUT("/artifact-retention-policies/:org_id",
+			iamMiddleware.RequirePermission("SYSTEM_SETTINGS", "ORGANIZATION", "ADMIN"),
+			artifactStorageHandler.PutRetentionPolicy,
+		)
+
+		globalSettings.DELETE("/artifact-retention-policies/:org_id",
+			iamMiddleware.RequirePermission("SYSTEM_SETTINGS", "ORGANIZATION", "ADMIN"),
+			artifactStorageHandler.DeleteRetentionPolicy,
+		)
+
+		// API Token 策略（最长有效期）
+		apiTokenPolicyHandler := handlers.NewAPITokenPolicyHandler(db)
+
+		globalSettings.GET("/api-token-policy",
+			iamMiddleware.RequirePermission("SYSTEM_SETTINGS", "ORGANIZATION", "READ"),
+			apiTokenPolicyHandler.GetAPITokenPolicy,
+		)
+
+		globalSettings.PUT("/api-token-policy",
+			iamMiddleware.RequirePermission("SYSTEM_SETTINGS", "ORGANIZATION", "ADMIN"),
+			apiTokenPolicyHandler.UpdateAPITokenPolicy,
+		)
+
+		// 变更冻结日历与单次运行例外审批
+		changeFreezeHandler := handlers.NewChangeFreezeHandler(db)
+
+		globalSettings.GET("/change-freezes",
+			iamMiddleware.RequirePermission("SYSTEM_SETTINGS", "ORGANIZATION", "READ"),
+			changeFreezeHandler.ListChangeFreezeWindows,
+		)
+
+		globalSettings.GET("/change-freezes/:window_id",
+			iamMiddleware.RequirePermission("SYSTEM_SETTINGS", "ORGANIZATION", "READ"),
+			changeFreezeHandler.GetChangeFreezeWindow,
+		)
+
+		globalSettings.POST("/change-freezes",
+			iamMiddleware.RequirePermission("SYSTEM_SETTINGS", "ORGANIZATION", "ADMIN"),
+			changeFreezeHandler.CreateChangeFreezeWindow,
+		)
+
+		globalSettings.PUT("/change-freezes/:window_id",
+			iamMiddleware.RequirePermission("SYSTEM_SETTINGS", "ORGANIZATION", "ADMIN"),
+			changeFreezeHandler.UpdateChangeFreezeWindow,
+		)
+
+		globalSettings.DELETE("/change-freezes/:window_id",
+			iamMiddleware.RequirePermission("SYSTEM_SETTINGS", "ORGANIZATION", "ADMIN"),
+			changeFreezeHandler.DeleteChangeFreezeWindow,
+		)
+
+		globalSettings.GET("/change-freeze-exceptions",
+			iamMiddleware.RequirePermission("SYSTEM_SETTINGS", "ORGANIZATION", "READ"),
+			changeFreezeHandler.ListChangeFreezeExceptions,
+		)
+
+		globalSettings.POST("/change-freeze-exceptions/:exception_id/approve",
+			iamMiddleware.RequirePermission("SYSTEM_SETTINGS", "ORGANIZATION", "ADMIN"),
+			changeFreezeHandler.ApproveChangeFreezeException,
+		)
+
+		globalSettings.POST("/change-freeze-exceptions/:exception_id/reject",
+			iamMiddleware.RequirePermission("SYSTEM_SETTINGS", "ORGANIZATION", "ADMIN"),
+			changeFreezeHandler.RejectChangeFreezeException,
+		)
+
 		// MFA全局配置管理
 		mfaHandler := handlers.NewMFAHandler(db)
 
@@ -56,6 +56,7 @@ func setupIAMRoutes(adminProtected *gin.RouterGroup, db *gorm.DB, iamMiddleware
 		applicationHandler := handlers.NewApplicationHandler(iamFactory.GetApplicationService())
 		auditHandler := handlers.NewAuditHandler(iamFactory.GetAuditService())
 		auditConfigHandler := handlers.NewAuditConfigHandler(db)
+		auditEventHandler := handlers.NewAuditEventHandler(db)
 		userHandler := handlers.NewUserHandler(service.NewUserService(db))
 		roleHandler := handlers.NewRoleHandler(db)
 
@@ -141,6 +142,7 @@ func setupIAMRoutes(adminProtected *gin.RouterGroup, db *gorm.DB, iamMiddleware
 		teamTokenHandler := handlers.NewTeamTokenHandler(service.NewTeamTokenService(db, ""))
 
 		iamGroup.POST("/teams/:id/tokens",
+			middleware.RequireLoginToken(),
 			iamMiddleware.RequirePermission("IAM_TEAMS", "ORGANIZATION", "WRITE"),
 			teamTokenHandler.CreateTeamToken,
 		)
@@ -290,6 +292,47 @@ func setupIAMRoutes(adminProtected *gin.RouterGroup, db *gorm.DB, iamMiddleware
 			auditHandler.QueryPermissionChangesByPerformer,
 		)
 
+		// 领域审计事件（哈希链）与 SIEM 导出
+		iamGroup.GET("/audit/events",
+			iamMiddleware.RequirePermission("IAM_AUDIT", "ORGANIZATION", "READ"),
+			auditEventHandler.ListEvents,
+		)
+
+		iamGroup.GET("/audit/events/verify",
+			iamMiddleware.RequirePermission("IAM_AUDIT", "ORGANIZATION", "READ"),
+			auditEventHandler.VerifyChain,
+		)
+
+		iamGroup.GET("/audit/events/:event_id",
+			iamMiddleware.RequirePermission("IAM_AUDIT", "ORGANIZATION", "READ"),
+			auditEventHandler.GetEvent,
+		)
+
+		iamGroup.GET("/audit/exporters",
+			iamMiddleware.RequirePermission("IAM_AUDIT", "ORGANIZATION", "READ"),
+			auditEventHandler.ListExporters,
+		)
+
+		iamGroup.POST("/audit/exporters",
+			iamMiddleware.RequirePermission("IAM_AUDIT", "ORGANIZATION", "ADMIN"),
+			auditEventHandler.CreateExporter,
+		)
+
+		iamGroup.PUT("/audit/exporters/:exporter_id",
+			iamMiddleware.RequirePermission("IAM_AUDIT", "ORGANIZATION", "ADMIN"),
+			auditEventHandler.UpdateExporter,
+		)
+
+		iamGroup.DELETE("/audit/exporters/:exporter_id",
+			iamMiddleware.RequirePermission("IAM_AUDIT", "ORGANIZATION", "ADMIN"),
+			auditEventHandler.DeleteExporter,
+		)
+
+		iamGroup.POST("/audit/exporters/:exporter_id/test",
+			iamMiddleware.RequirePermission("IAM_AUDIT", "ORGANIZATION", "ADMIN"),
+			auditEventHandler.TestExporter,
+		)
+
 		// 用户管理 - 添加IAM权限检查
 		iamGroup.GET("/users/stats",
 			iamMiddleware.RequirePermission("IAM_USERS", "ORGANIZATION", "READ"),
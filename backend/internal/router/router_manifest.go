@@ -95,6 +95,52 @@ func RegisterManifestRoutes(r *gin.RouterGroup, db *gorm.DB, queueManager TaskQu
 			iamMiddleware.RequirePermission("SYSTEM_SETTINGS", "ORGANIZATION", "ADMIN"),
 			manifestHandler.UninstallManifestDeployment,
 		)
+		orgManifests.GET("/:id/deployments/:deployment_id/upgrade-preview",
+			iamMiddleware.RequirePermission("SYSTEM_SETTINGS", "ORGANIZATION", "READ"),
+			manifestHandler.PreviewManifestDeploymentUpgrade,
+		)
+		orgManifests.POST("/:id/deployments/:deployment_id/upgrade",
+			iamMiddleware.RequirePermission("SYSTEM_SETTINGS", "ORGANIZATION", "WRITE"),
+			manifestHandler.UpgradeManifestDeployment,
+		)
+		orgManifests.POST("/:id/deployments/:deployment_id/rollback",
+			iamMiddleware.RequirePermission("SYSTEM_SETTINGS", "ORGANIZATION", "WRITE"),
+			manifestHandler.RollbackManifestDeployment,
+		)
+
+		// 批量发布
+		orgManifests.GET("/:id/rollouts",
+			iamMiddleware.RequirePermission("SYSTEM_SETTINGS", "ORGANIZATION", "READ"),
+			manifestHandler.ListManifestRollouts,
+		)
+		orgManifests.POST("/:id/rollouts",
+			iamMiddleware.RequirePermission("SYSTEM_SETTINGS", "ORGANIZATION", "WRITE"),
+			manifestHandler.CreateManifestRollout,
+		)
+		orgManifests.POST("/:id/rollouts/preview",
+			iamMiddleware.RequirePermission("SYSTEM_SETTINGS", "ORGANIZATION", "READ"),
+			manifestHandler.PreviewManifestRollout,
+		)
+		orgManifests.GET("/:id/rollouts/:rollout_id",
+			iamMiddleware.RequirePermission("SYSTEM_SETTINGS", "ORGANIZATION", "READ"),
+			manifestHandler.GetManifestRollout,
+		)
+		orgManifests.POST("/:id/rollouts/:rollout_id/approve",
+			iamMiddleware.RequirePermission("SYSTEM_SETTINGS", "ORGANIZATION", "ADMIN"),
+			manifestHandler.ApproveManifestRollout,
+		)
+		orgManifests.POST("/:id/rollouts/:rollout_id/pause",
+			iamMiddleware.RequirePermission("SYSTEM_SETTINGS", "ORGANIZATION", "WRITE"),
+			manifestHandler.PauseManifestRollout,
+		)
+		orgManifests.POST("/:id/rollouts/:rollout_id/resume",
+			iamMiddleware.RequirePermission("SYSTEM_SETTINGS", "ORGANIZATION", "WRITE"),
+			manifestHandler.ResumeManifestRollout,
+		)
+		orgManifests.POST("/:id/rollouts/:rollout_id/cancel",
+			iamMiddleware.RequirePermission("SYSTEM_SETTINGS", "ORGANIZATION", "ADMIN"),
+			manifestHandler.CancelManifestRollout,
+		)
 
 		// 导入导出
 		orgManifests.GET("/:id/export",
@@ -12,7 +12,7 @@ import (
 
 // setupModuleRoutes sets up module routes
 // 包括: modules, schemas, demos
-func setupModuleRoutes(api *gin.RouterGroup, db *gorm.DB, iamMiddleware *middleware.IAMPermissionMiddleware) {
+func setupModuleRoutes(api *gin.RouterGroup, db *gorm.DB, iamMiddleware *middleware.IAMPermissionMiddleware, queueManager *services.TaskQueueManager) {
 	// TODO: 实现module路由
 	// 参考原router.go中的 modules := api.Group("/modules") 部分
 	// 模块管理 - 使用IAM权限控制
@@ -234,6 +234,25 @@ func setupModuleRoutes(api *gin.RouterGroup, db *gorm.DB, iamMiddleware *middlew
 			demoController.ImportDemos,
 		)
 
+		// 模块版本测试（terraform test / tofu test）
+		// 测试在调用方指定的 workspace 中执行，需要该 workspace 的执行权限
+		moduleTestController := controllers.NewModuleTestController(db, queueManager,
+			func(c *gin.Context, workspaceID string) (bool, error) {
+				return iamMiddleware.CheckScopePermission(c, "WORKSPACE_EXECUTION", "WORKSPACE", workspaceID, "WRITE")
+			})
+		modules.GET("/:id/versions/:version_id/test-runs",
+			iamMiddleware.RequirePermission("MODULES", "ORGANIZATION", "READ"),
+			moduleTestController.ListTestRuns,
+		)
+		modules.POST("/:id/versions/:version_id/test-runs",
+			iamMiddleware.RequirePermission("MODULES", "ORGANIZATION", "WRITE"),
+			moduleTestController.CreateTestRun,
+		)
+		modules.GET("/:id/test-runs/:run_id",
+			iamMiddleware.RequirePermission("MODULES", "ORGANIZATION", "READ"),
+			moduleTestController.GetTestRun,
+		)
+
 		// 删除版本
 		modules.DELETE("/:id/versions/:version_id",
 			iamMiddleware.RequirePermission("MODULES", "ORGANIZATION", "ADMIN"),
@@ -0,0 +1,67 @@
+package router
+
+import (
+	"iac-platform/internal/handlers"
+	"iac-platform/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// setupStackRoutes sets up stack routes
+// Stack 跨多个 workspace，使用 Organization 级别的 WORKSPACES 权限
+func setupStackRoutes(api *gin.RouterGroup, db *gorm.DB, iamMiddleware *middleware.IAMPermissionMiddleware) {
+	stacks := api.Group("/stacks")
+	stacks.Use(middleware.JWTAuth())
+	stacks.Use(middleware.AuditLogger(db))
+	{
+		stackHandler := handlers.NewStackHandler(db)
+
+		stacks.GET("",
+			iamMiddleware.RequirePermission("WORKSPACES", "ORGANIZATION", "READ"),
+			stackHandler.ListStacks,
+		)
+		stacks.POST("",
+			iamMiddleware.RequirePermission("WORKSPACES", "ORGANIZATION", "ADMIN"),
+			stackHandler.CreateStack,
+		)
+		stacks.GET("/:stack_id",
+			iamMiddleware.RequirePermission("WORKSPACES", "ORGANIZATION", "READ"),
+			stackHandler.GetStack,
+		)
+		stacks.PUT("/:stack_id",
+			iamMiddleware.RequirePermission("WORKSPACES", "ORGANIZATION", "ADMIN"),
+			stackHandler.UpdateStack,
+		)
+		stacks.DELETE("/:stack_id",
+			iamMiddleware.RequirePermission("WORKSPACES", "ORGANIZATION", "ADMIN"),
+			stackHandler.DeleteStack,
+		)
+
+		// 协同执行
+		stacks.GET("/:stack_id/runs",
+			iamMiddleware.RequirePermission("WORKSPACES", "ORGANIZATION", "READ"),
+			stackHandler.ListStackRuns,
+		)
+		stacks.POST("/:stack_id/runs",
+			iamMiddleware.RequirePermission("WORKSPACES", "ORGANIZATION", "WRITE"),
+			stackHandler.CreateStackRun,
+		)
+		stacks.GET("/:stack_id/runs/:run_id",
+			iamMiddleware.RequirePermission("WORKSPACES", "ORGANIZATION", "READ"),
+			stackHandler.GetStackRun,
+		)
+		stacks.GET("/:stack_id/runs/:run_id/review",
+			iamMiddleware.RequirePermission("WORKSPACES", "ORGANIZATION", "READ"),
+			stackHandler.GetStackRunReview,
+		)
+		stacks.POST("/:stack_id/runs/:run_id/approve",
+			iamMiddleware.RequirePermission("WORKSPACES", "ORGANIZATION", "ADMIN"),
+			stackHandler.ApproveStackRun,
+		)
+		stacks.POST("/:stack_id/runs/:run_id/cancel",
+			iamMiddleware.RequirePermission("WORKSPACES", "ORGANIZATION", "WRITE"),
+			stackHandler.CancelStackRun,
+		)
+	}
+}
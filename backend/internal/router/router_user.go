@@ -30,7 +30,8 @@ func setupUserRoutes(protected *gin.RouterGroup, db *gorm.DB, iamMiddleware *mid
 		user.POST("/change-password", userTokenHandler.ChangePassword)
 
 		// 用户Token管理
-		user.POST("/tokens", userTokenHandler.CreateUserToken)
+		// 只能在登录会话中签发，API Token 不能签发新 Token
+		user.POST("/tokens", middleware.RequireLoginToken(), userTokenHandler.CreateUserToken)
 		user.GET("/tokens", userTokenHandler.ListUserTokens)
 		user.DELETE("/tokens/:token_name", userTokenHandler.RevokeUserToken)
 
@@ -142,6 +142,10 @@ func setupWorkspaceRoutes(api *gin.RouterGroup, db *gorm.DB, streamManager *serv
 			iamMiddleware.RequirePermission("WORKSPACES", "ORGANIZATION", "WRITE"),
 			workspaceController.CreateWorkspace,
 		)
+		workspaces.POST("/from-template",
+			iamMiddleware.RequirePermission("WORKSPACES", "ORGANIZATION", "WRITE"),
+			workspaceController.CreateWorkspaceFromTemplate,
+		)
 		// Task operations - READ level (精细化权限优先)
 		workspaces.GET("/:id/tasks",
 			iamMiddleware.RequireAnyPermission([]middleware.PermissionRequirement{
@@ -195,6 +199,37 @@ func setupWorkspaceRoutes(api *gin.RouterGroup, db *gorm.DB, streamManager *serv
 			},
 		)
 
+		// 计划影响分析：删除/替换的资源对其他资源和workspace的影响
+		// 受影响的其他 workspace 按与本路由相同的权限逐个检查，无权读取的只返回数量
+		impactReadPermissions := []middleware.PermissionRequirement{
+			{ResourceType: "WORKSPACES", ScopeType: "ORGANIZATION", RequiredLevel: "READ"},
+			{ResourceType: "TASK_DATA_ACCESS", ScopeType: "WORKSPACE", RequiredLevel: "READ"},
+			{ResourceType: "WORKSPACE_EXECUTION", ScopeType: "WORKSPACE", RequiredLevel: "READ"},
+			{ResourceType: "WORKSPACE_MANAGEMENT", ScopeType: "WORKSPACE", RequiredLevel: "READ"},
+		}
+		impactHandler := handlers.NewCMDBGraphHandler(db)
+		impactHandler.SetWorkspaceAccessChecker(func(c *gin.Context, workspaceID string) (bool, error) {
+			for _, perm := range impactReadPermissions {
+				scopeID := workspaceID
+				if perm.ScopeType == "ORGANIZATION" {
+					// 与 RequireAnyPermission 一致：组织 ID 取 org_id 查询参数，默认 1
+					scopeID = c.DefaultQuery("org_id", "1")
+				}
+				allowed, err := iamMiddleware.CheckScopePermission(c, perm.ResourceType, perm.ScopeType, scopeID, perm.RequiredLevel)
+				if err != nil {
+					return false, err
+				}
+				if allowed {
+					return true, nil
+				}
+			}
+			return false, nil
+		})
+		workspaces.GET("/:id/tasks/:task_id/impact",
+			iamMiddleware.RequireAnyPermission(impactReadPermissions),
+			impactHandler.GetTaskImpact,
+		)
+
 		aiController := controllers.NewAIController(db)
 		workspaces.GET("/:id/tasks/:task_id/error-analysis",
 			iamMiddleware.RequireAnyPermission([]middleware.PermissionRequirement{
@@ -808,6 +843,15 @@ func setupWorkspaceRoutes(api *gin.RouterGroup, db *gorm.DB, streamManager *serv
 		// Setup workspace run task routes
 		setupWorkspaceRunTaskRoutes(workspaces, db, iamMiddleware)
 
+		// Setup task static analysis routes
+		setupWorkspaceStaticAnalysisRoutes(workspaces, db, iamMiddleware)
+
+		// Setup task cost estimate routes
+		setupWorkspaceCostEstimateRoutes(workspaces, db, iamMiddleware)
+
+		// Setup change freeze routes
+		setupWorkspaceChangeFreezeRoutes(workspaces, db, iamMiddleware)
+
 		// Setup workspace notification routes
 		setupWorkspaceNotificationRoutes(workspaces, db, iamMiddleware)
 
@@ -857,6 +901,74 @@ func setupWorkspaceProjectRoutes(workspaces *gin.RouterGroup, db *gorm.DB, iamMi
 	)
 }
 
+// setupWorkspaceStaticAnalysisRoutes sets up task static analysis result routes
+func setupWorkspaceStaticAnalysisRoutes(workspaces *gin.RouterGroup, db *gorm.DB, iamMiddleware *middleware.IAMPermissionMiddleware) {
+	staticAnalysisHandler := handlers.NewStaticAnalysisHandler(db)
+
+	// Get task static analysis results - READ level
+	workspaces.GET("/:id/tasks/:task_id/static-analysis-results",
+		iamMiddleware.RequireAnyPermission([]middleware.PermissionRequirement{
+			{ResourceType: "WORKSPACES", ScopeType: "ORGANIZATION", RequiredLevel: "READ"},
+			{ResourceType: "TASK_DATA_ACCESS", ScopeType: "WORKSPACE", RequiredLevel: "READ"},
+			{ResourceType: "WORKSPACE_EXECUTION", ScopeType: "WORKSPACE", RequiredLevel: "READ"},
+			{ResourceType: "WORKSPACE_MANAGEMENT", ScopeType: "WORKSPACE", RequiredLevel: "READ"},
+		}),
+		staticAnalysisHandler.GetTaskStaticAnalysisResults,
+	)
+}
+
+// setupWorkspaceCostEstimateRoutes sets up task cost estimate routes
+func setupWorkspaceCostEstimateRoutes(workspaces *gin.RouterGroup, db *gorm.DB, iamMiddleware *middleware.IAMPermissionMiddleware) {
+	costEstimateHandler := handlers.NewCostEstimateHandler(db)
+
+	// Get task cost estimate - READ level
+	workspaces.GET("/:id/tasks/:task_id/cost-estimate",
+		iamMiddleware.RequireAnyPermission([]middleware.PermissionRequirement{
+			{ResourceType: "WORKSPACES", ScopeType: "ORGANIZATION", RequiredLevel: "READ"},
+			{ResourceType: "TASK_DATA_ACCESS", ScopeType: "WORKSPACE", RequiredLevel: "READ"},
+			{ResourceType: "WORKSPACE_EXECUTION", ScopeType: "WORKSPACE", RequiredLevel: "READ"},
+			{ResourceType: "WORKSPACE_MANAGEMENT", ScopeType: "WORKSPACE", RequiredLevel: "READ"},
+		}),
+		costEstimateHandler.GetTaskCostEstimate,
+	)
+}
+
+// setupWorkspaceChangeFreezeRoutes sets up change freeze status and exception request routes
+func setupWorkspaceChangeFreezeRoutes(workspaces *gin.RouterGroup, db *gorm.DB, iamMiddleware *middleware.IAMPermissionMiddleware) {
+	changeFreezeHandler := handlers.NewChangeFreezeHandler(db)
+
+	// Get active change freezes - READ level
+	workspaces.GET("/:id/change-freezes",
+		iamMiddleware.RequireAnyPermission([]middleware.PermissionRequirement{
+			{ResourceType: "WORKSPACES", ScopeType: "ORGANIZATION", RequiredLevel: "READ"},
+			{ResourceType: "WORKSPACE_EXECUTION", ScopeType: "WORKSPACE", RequiredLevel: "READ"},
+			{ResourceType: "WORKSPACE_MANAGEMENT", ScopeType: "WORKSPACE", RequiredLevel: "READ"},
+		}),
+		changeFreezeHandler.GetWorkspaceChangeFreezes,
+	)
+
+	// Get task change freeze status - READ level
+	workspaces.GET("/:id/tasks/:task_id/change-freeze",
+		iamMiddleware.RequireAnyPermission([]middleware.PermissionRequirement{
+			{ResourceType: "WORKSPACES", ScopeType: "ORGANIZATION", RequiredLevel: "READ"},
+			{ResourceType: "TASK_DATA_ACCESS", ScopeType: "WORKSPACE", RequiredLevel: "READ"},
+			{ResourceType: "WORKSPACE_EXECUTION", ScopeType: "WORKSPACE", RequiredLevel: "READ"},
+			{ResourceType: "WORKSPACE_MANAGEMENT", ScopeType: "WORKSPACE", RequiredLevel: "READ"},
+		}),
+		changeFreezeHandler.GetTaskChangeFreeze,
+	)
+
+	// Request a change freeze exception - same level as confirming an apply
+	workspaces.POST("/:id/tasks/:task_id/change-freeze/exception",
+		iamMiddleware.RequireAnyPermission([]middleware.PermissionRequirement{
+			{ResourceType: "WORKSPACES", ScopeType: "ORGANIZATION", RequiredLevel: "ADMIN"},
+			{ResourceType: "WORKSPACE_EXECUTION", ScopeType: "WORKSPACE", RequiredLevel: "ADMIN"},
+			{ResourceType: "WORKSPACE_MANAGEMENT", ScopeType: "WORKSPACE", RequiredLevel: "ADMIN"},
+		}),
+		changeFreezeHandler.RequestChangeFreezeException,
+	)
+}
+
 // setupWorkspaceRunTaskRoutes sets up workspace run task routes
 func setupWorkspaceRunTaskRoutes(workspaces *gin.RouterGroup, db *gorm.DB, iamMiddleware *middleware.IAMPermissionMiddleware) {
 	wrtHandler := handlers.NewWorkspaceRunTaskHandler(db)
@@ -1117,6 +1229,10 @@ func setupRemoteDataPublicRoutes(api *gin.RouterGroup, db *gorm.DB) {
 		// Token validated, return full outputs
 		outputController.GetStateOutputsFull(c)
 	})
+
+	// terraform_remote_state 的 http backend 地址
+	// 凭证通过 TF_HTTP_USERNAME/TF_HTTP_PASSWORD 以 HTTP Basic 认证提供，并按源 workspace 的 outputs 共享设置校验
+	api.GET("/workspaces/:id/remote-state", remoteDataController.GetRemoteState)
 }
 
 // setupWorkspaceRunTriggerRoutes sets up workspace run trigger routes
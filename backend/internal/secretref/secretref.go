@@ -0,0 +1,338 @@
+// Package secretref 解析外部密文引用。
+//
+// 变量值或 Provider 配置字段可以写成外部密文引用，平台只保存引用本身，
+// 真实值由执行者（Agent 或本地执行器）在执行前按自身环境解析，用完即弃：
+//
+//	vault://kv/data/team/db#password   Vault KV（v1/v2）路径 + 字段
+//	env://IAC_SECRET_DB_PASSWORD       执行者进程的环境变量
+//	file://db/password                 执行者密文目录下的文件
+package secretref
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// 支持的引用协议
+const (
+	SchemeVault = "vault"
+	SchemeEnv   = "env"
+	SchemeFile  = "file"
+)
+
+// 执行者侧配置（环境变量）
+const (
+	EnvVaultAddr      = "VAULT_ADDR"
+	EnvVaultToken     = "VAULT_TOKEN"
+	EnvVaultNamespace = "VAULT_NAMESPACE"
+	// EnvAllowedEnvPrefix env:// 引用允许读取的环境变量前缀，避免引用到执行者自身的凭据
+	EnvAllowedEnvPrefix = "IAC_SECRET_ENV_PREFIX"
+	// EnvFileRoot file:// 引用的根目录，未配置时禁用 file:// 引用
+	EnvFileRoot = "IAC_SECRET_FILE_DIR"
+
+	DefaultAllowedEnvPrefix = "IAC_SECRET_"
+)
+
+// MaskedValue 日志中替代密文的占位符
+const MaskedValue = "***SENSITIVE***"
+
+var (
+	// ErrInvalidReference 引用格式错误
+	ErrInvalidReference = errors.New("invalid secret reference")
+	// ErrNotConfigured 执行者未配置对应的密文来源
+	ErrNotConfigured = errors.New("secret source not configured")
+	// ErrNotFound 密文或字段不存在
+	ErrNotFound = errors.New("secret not found")
+)
+
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Reference 解析后的密文引用
+type Reference struct {
+	Raw    string
+	Scheme string
+	Path   string
+	Field  string
+}
+
+// String 返回原始引用（引用本身不是密文，可以出现在日志中）
+func (r *Reference) String() string {
+	return r.Raw
+}
+
+// IsReference 判断字符串是否以受支持的引用协议开头
+func IsReference(value string) bool {
+	value = strings.TrimSpace(value)
+	for _, scheme := range []string{SchemeVault, SchemeEnv, SchemeFile} {
+		if strings.HasPrefix(value, scheme+"://") {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse 解析并校验引用格式
+func Parse(raw string) (*Reference, error) {
+	raw = strings.TrimSpace(raw)
+	idx := strings.Index(raw, "://")
+	if idx <= 0 {
+		return nil, fmt.Errorf("%w: %q must look like vault://path#field, env://NAME or file://path", ErrInvalidReference, raw)
+	}
+
+	ref := &Reference{Raw: raw, Scheme: raw[:idx]}
+	rest := raw[idx+3:]
+	if hash := strings.LastIndex(rest, "#"); hash >= 0 {
+		ref.Path, ref.Field = rest[:hash], rest[hash+1:]
+	} else {
+		ref.Path = rest
+	}
+
+	switch ref.Scheme {
+	case SchemeVault:
+		ref.Path = strings.Trim(ref.Path, "/")
+		if ref.Path == "" || ref.Field == "" {
+			return nil, fmt.Errorf("%w: %q requires a path and a #field", ErrInvalidReference, raw)
+		}
+	case SchemeEnv:
+		if ref.Field != "" || !envNamePattern.MatchString(ref.Path) {
+			return nil, fmt.Errorf("%w: %q must name a single environment variable", ErrInvalidReference, raw)
+		}
+	case SchemeFile:
+		clean := filepath.Clean("/" + ref.Path)
+		if ref.Path == "" || clean == "/" || strings.Contains(ref.Path, "..") {
+			return nil, fmt.Errorf("%w: %q must be a relative path inside the secret directory", ErrInvalidReference, raw)
+		}
+		ref.Path = strings.TrimPrefix(clean, "/")
+	default:
+		return nil, fmt.Errorf("%w: unsupported scheme %q", ErrInvalidReference, ref.Scheme)
+	}
+	return ref, nil
+}
+
+// Resolver 在执行者侧解析引用
+type Resolver struct {
+	VaultAddr        string
+	VaultToken       string
+	VaultNamespace   string
+	AllowedEnvPrefix string
+	FileRoot         string
+	HTTPClient       *http.Client
+	LookupEnv        func(string) (string, bool)
+}
+
+// NewResolverFromEnv 使用执行者进程的环境变量构建解析器
+func NewResolverFromEnv() *Resolver {
+	prefix, ok := os.LookupEnv(EnvAllowedEnvPrefix)
+	if !ok {
+		prefix = DefaultAllowedEnvPrefix
+	}
+	return &Resolver{
+		VaultAddr:        os.Getenv(EnvVaultAddr),
+		VaultToken:       os.Getenv(EnvVaultToken),
+		VaultNamespace:   os.Getenv(EnvVaultNamespace),
+		AllowedEnvPrefix: prefix,
+		FileRoot:         os.Getenv(EnvFileRoot),
+		HTTPClient:       &http.Client{Timeout: 15 * time.Second},
+		LookupEnv:        os.LookupEnv,
+	}
+}
+
+// Resolve 解析单个引用，返回明文值。错误信息只包含引用，不包含值。
+func (r *Resolver) Resolve(ctx context.Context, raw string) (string, error) {
+	ref, err := Parse(raw)
+	if err != nil {
+		return "", err
+	}
+
+	switch ref.Scheme {
+	case SchemeVault:
+		return r.resolveVault(ctx, ref)
+	case SchemeEnv:
+		return r.resolveEnv(ref)
+	default:
+		return r.resolveFile(ref)
+	}
+}
+
+// ResolveTree 递归替换结构中所有引用形式的字符串，返回新结构和解析出的明文（用于日志脱敏）
+func (r *Resolver) ResolveTree(ctx context.Context, value interface{}) (interface{}, []string, error) {
+	var secrets []string
+	var walk func(v interface{}) (interface{}, error)
+	walk = func(v interface{}) (interface{}, error) {
+		switch t := v.(type) {
+		case string:
+			if !IsReference(t) {
+				return t, nil
+			}
+			resolved, err := r.Resolve(ctx, t)
+			if err != nil {
+				return nil, err
+			}
+			secrets = append(secrets, resolved)
+			return resolved, nil
+		case map[string]interface{}:
+			out := make(map[string]interface{}, len(t))
+			for k, item := range t {
+				resolved, err := walk(item)
+				if err != nil {
+					return nil, err
+				}
+				out[k] = resolved
+			}
+			return out, nil
+		case []interface{}:
+			out := make([]interface{}, len(t))
+			for i, item := range t {
+				resolved, err := walk(item)
+				if err != nil {
+					return nil, err
+				}
+				out[i] = resolved
+			}
+			return out, nil
+		default:
+			return v, nil
+		}
+	}
+
+	resolved, err := walk(value)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resolved, secrets, nil
+}
+
+func (r *Resolver) resolveEnv(ref *Reference) (string, error) {
+	if !strings.HasPrefix(ref.Path, r.AllowedEnvPrefix) {
+		return "", fmt.Errorf("%w: %s is outside the allowed prefix %q", ErrInvalidReference, ref, r.AllowedEnvPrefix)
+	}
+	lookup := r.LookupEnv
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+	value, ok := lookup(ref.Path)
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, ref)
+	}
+	return value, nil
+}
+
+func (r *Resolver) resolveFile(ref *Reference) (string, error) {
+	if r.FileRoot == "" {
+		return "", fmt.Errorf("%w: %s requires %s on the executor", ErrNotConfigured, ref, EnvFileRoot)
+	}
+	data, err := os.ReadFile(filepath.Join(r.FileRoot, ref.Path))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("%w: %s", ErrNotFound, ref)
+		}
+		return "", fmt.Errorf("failed to read %s: %w", ref, err)
+	}
+	content := strings.TrimRight(string(data), "\r\n")
+	if ref.Field == "" {
+		return content, nil
+	}
+
+	// 带 #field 时按 JSON 对象读取字段
+	var obj map[string]interface{}
+	if err := json.Unmarshal([]byte(content), &obj); err != nil {
+		return "", fmt.Errorf("%w: %s is not a JSON object", ErrInvalidReference, ref)
+	}
+	return fieldValue(obj, ref)
+}
+
+// resolveVault 读取 Vault KV。v2 的路径包含 data/，响应为 data.data；v1 响应为 data。
+func (r *Resolver) resolveVault(ctx context.Context, ref *Reference) (string, error) {
+	if r.VaultAddr == "" || r.VaultToken == "" {
+		return "", fmt.Errorf("%w: %s requires %s and %s on the executor", ErrNotConfigured, ref, EnvVaultAddr, EnvVaultToken)
+	}
+
+	endpoint := strings.TrimRight(r.VaultAddr, "/") + "/v1/" + escapePath(ref.Path)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to build request for %s: %w", ref, err)
+	}
+	req.Header.Set("X-Vault-Token", r.VaultToken)
+	if r.VaultNamespace != "" {
+		req.Header.Set("X-Vault-Namespace", r.VaultNamespace)
+	}
+
+	client := r.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", ref, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", ref, err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return "", fmt.Errorf("%w: %s", ErrNotFound, ref)
+	case resp.StatusCode != http.StatusOK:
+		return "", fmt.Errorf("failed to read %s: vault returned HTTP %d", ref, resp.StatusCode)
+	}
+
+	var payload struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return "", fmt.Errorf("failed to decode vault response for %s: %w", ref, err)
+	}
+	data := payload.Data
+	if inner, ok := data["data"].(map[string]interface{}); ok {
+		if _, hasMeta := data["metadata"]; hasMeta {
+			data = inner
+		}
+	}
+	return fieldValue(data, ref)
+}
+
+func fieldValue(obj map[string]interface{}, ref *Reference) (string, error) {
+	value, ok := obj[ref.Field]
+	if !ok || value == nil {
+		return "", fmt.Errorf("%w: field %q missing in %s", ErrNotFound, ref.Field, ref)
+	}
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode field %q of %s: %w", ref.Field, ref, err)
+	}
+	return string(encoded), nil
+}
+
+func escapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// Mask 将内容中出现的明文替换为占位符
+func Mask(content string, secrets []string) string {
+	for _, secret := range secrets {
+		if secret == "" {
+			continue
+		}
+		content = strings.ReplaceAll(content, secret, MaskedValue)
+	}
+	return content
+}
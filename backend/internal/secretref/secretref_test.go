@@ -0,0 +1,190 @@
+package secretref
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newKVStandIn 最小的 Vault KV 兼容服务：kv 为 v2 挂载，legacy 为 v1 挂载
+func newKVStandIn(t *testing.T, token string) *httptest.Server {
+	t.Helper()
+	secrets := map[string]map[string]interface{}{
+		"team/db":  {"password": "s3cr3t", "port": 5432},
+		"team/api": {"key": "abc"},
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != token {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		path := strings.TrimPrefix(r.URL.Path, "/v1/")
+		var body interface{}
+		switch {
+		case strings.HasPrefix(path, "kv/data/"):
+			data, ok := secrets[strings.TrimPrefix(path, "kv/data/")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			body = map[string]interface{}{"data": map[string]interface{}{"data": data, "metadata": map[string]interface{}{"version": 3}}}
+		case strings.HasPrefix(path, "legacy/"):
+			data, ok := secrets[strings.TrimPrefix(path, "legacy/")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			body = map[string]interface{}{"data": data}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(body)
+	}))
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		raw     string
+		scheme  string
+		path    string
+		field   string
+		wantErr bool
+	}{
+		{raw: "vault://kv/data/team/db#password", scheme: SchemeVault, path: "kv/data/team/db", field: "password"},
+		{raw: " vault:///kv/data/team/db/#password ", scheme: SchemeVault, path: "kv/data/team/db", field: "password"},
+		{raw: "env://IAC_SECRET_DB", scheme: SchemeEnv, path: "IAC_SECRET_DB"},
+		{raw: "file://db/creds.json#password", scheme: SchemeFile, path: "db/creds.json", field: "password"},
+		{raw: "vault://kv/data/team/db", wantErr: true},
+		{raw: "env://BAD-NAME", wantErr: true},
+		{raw: "env://A#b", wantErr: true},
+		{raw: "file://../etc/passwd", wantErr: true},
+		{raw: "s3://bucket/key", wantErr: true},
+		{raw: "plain", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		ref, err := Parse(tt.raw)
+		if tt.wantErr {
+			assert.ErrorIs(t, err, ErrInvalidReference, tt.raw)
+			continue
+		}
+		require.NoError(t, err, tt.raw)
+		assert.Equal(t, tt.scheme, ref.Scheme, tt.raw)
+		assert.Equal(t, tt.path, ref.Path, tt.raw)
+		assert.Equal(t, tt.field, ref.Field, tt.raw)
+	}
+
+	assert.True(t, IsReference("vault://kv/data/x#y"))
+	assert.False(t, IsReference("https://example.com"))
+}
+
+func TestResolver_Vault(t *testing.T) {
+	server := newKVStandIn(t, "root-token")
+	defer server.Close()
+
+	r := &Resolver{VaultAddr: server.URL, VaultToken: "root-token"}
+	ctx := context.Background()
+
+	value, err := r.Resolve(ctx, "vault://kv/data/team/db#password")
+	require.NoError(t, err)
+	assert.Equal(t, "s3cr3t", value)
+
+	value, err = r.Resolve(ctx, "vault://kv/data/team/db#port")
+	require.NoError(t, err)
+	assert.Equal(t, "5432", value)
+
+	value, err = r.Resolve(ctx, "vault://legacy/team/api#key")
+	require.NoError(t, err)
+	assert.Equal(t, "abc", value)
+
+	_, err = r.Resolve(ctx, "vault://kv/data/team/db#missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = r.Resolve(ctx, "vault://kv/data/team/none#password")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = (&Resolver{VaultAddr: server.URL, VaultToken: "wrong"}).Resolve(ctx, "vault://kv/data/team/db#password")
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "s3cr3t")
+
+	_, err = (&Resolver{}).Resolve(ctx, "vault://kv/data/team/db#password")
+	assert.ErrorIs(t, err, ErrNotConfigured)
+}
+
+func TestResolver_EnvAndFile(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "db"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "db", "password"), []byte("from-file\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "db", "creds.json"), []byte(`{"user":"app"}`), 0600))
+
+	env := map[string]string{"IAC_SECRET_TOKEN": "from-env", "AGENT_TOKEN": "agent"}
+	r := &Resolver{
+		AllowedEnvPrefix: DefaultAllowedEnvPrefix,
+		FileRoot:         dir,
+		LookupEnv: func(k string) (string, bool) {
+			v, ok := env[k]
+			return v, ok
+		},
+	}
+	ctx := context.Background()
+
+	value, err := r.Resolve(ctx, "env://IAC_SECRET_TOKEN")
+	require.NoError(t, err)
+	assert.Equal(t, "from-env", value)
+
+	_, err = r.Resolve(ctx, "env://AGENT_TOKEN")
+	assert.ErrorIs(t, err, ErrInvalidReference)
+	_, err = r.Resolve(ctx, "env://IAC_SECRET_MISSING")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	value, err = r.Resolve(ctx, "file://db/password")
+	require.NoError(t, err)
+	assert.Equal(t, "from-file", value)
+
+	value, err = r.Resolve(ctx, "file://db/creds.json#user")
+	require.NoError(t, err)
+	assert.Equal(t, "app", value)
+
+	_, err = (&Resolver{}).Resolve(ctx, "file://db/password")
+	assert.True(t, errors.Is(err, ErrNotConfigured))
+}
+
+func TestResolver_ResolveTreeAndMask(t *testing.T) {
+	server := newKVStandIn(t, "root-token")
+	defer server.Close()
+	r := &Resolver{VaultAddr: server.URL, VaultToken: "root-token"}
+
+	config := map[string]interface{}{
+		"provider": map[string]interface{}{
+			"aws": []interface{}{
+				map[string]interface{}{
+					"region":     "us-east-1",
+					"secret_key": "vault://kv/data/team/api#key",
+				},
+			},
+		},
+	}
+
+	resolved, secrets, err := r.ResolveTree(context.Background(), config)
+	require.NoError(t, err)
+	aws := resolved.(map[string]interface{})["provider"].(map[string]interface{})["aws"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "abc", aws["secret_key"])
+	assert.Equal(t, "us-east-1", aws["region"])
+	assert.Equal(t, []string{"abc"}, secrets)
+
+	// 原结构保持引用不变
+	original := config["provider"].(map[string]interface{})["aws"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "vault://kv/data/team/api#key", original["secret_key"])
+
+	assert.Equal(t, `secret_key = "`+MaskedValue+`"`, Mask(`secret_key = "abc"`, secrets))
+}
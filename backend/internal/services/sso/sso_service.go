@@ -6,6 +6,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"strings"
 	"time"
 
@@ -35,6 +36,8 @@ type LoginResult struct {
 	User      *models.User `json:"user"`
 	IsNewUser bool         `json:"is_new_user"`
 	IsLinked  bool         `json:"is_linked"` // 是否为已有用户关联新身份
+	// RedirectURL 发起登录时指定的回跳地址（CLI 浏览器登录时为本机回环地址）
+	RedirectURL string `json:"redirect_url,omitempty"`
 }
 
 // NewSSOService 创建 SSO 服务
@@ -204,7 +207,12 @@ func (s *SSOService) HandleCallback(ctx context.Context, providerKey string, cod
 	}
 
 	// 登录操作
-	return s.handleLoginCallback(ctx, providerKey, providerCfg, userInfo, token, ipAddress, userAgent)
+	result, err := s.handleLoginCallback(ctx, providerKey, providerCfg, userInfo, token, ipAddress, userAgent)
+	if err != nil {
+		return nil, err
+	}
+	result.RedirectURL = stateData.RedirectURL
+	return result, nil
 }
 
 // handleLoginCallback 处理登录回调
@@ -702,3 +710,17 @@ func isEmailDomainAllowed(email string, allowedDomains []string) bool {
 	}
 	return false
 }
+
+// IsLoopbackRedirect 判断回跳地址是否为本机回环地址
+// CLI 浏览器登录时在本机临时监听端口接收 token，只允许 http://127.0.0.1、localhost、[::1] 且必须带端口
+func IsLoopbackRedirect(redirectURL string) bool {
+	u, err := url.Parse(redirectURL)
+	if err != nil || u.Scheme != "http" || u.Port() == "" || u.User != nil {
+		return false
+	}
+	switch u.Hostname() {
+	case "127.0.0.1", "localhost", "::1":
+		return true
+	}
+	return false
+}
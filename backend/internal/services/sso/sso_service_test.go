@@ -0,0 +1,28 @@
+package sso
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsLoopbackRedirect(t *testing.T) {
+	cases := []struct {
+		url  string
+		want bool
+	}{
+		{"http://127.0.0.1:53124/callback", true},
+		{"http://localhost:8085/callback", true},
+		{"http://[::1]:8085/callback", true},
+		{"http://127.0.0.1/callback", false},
+		{"https://127.0.0.1:53124/callback", false},
+		{"http://evil.example.com:53124/callback", false},
+		{"http://127.0.0.1.evil.example.com:80/callback", false},
+		{"http://user@127.0.0.1:53124/callback", false},
+		{"/workspaces", false},
+		{"", false},
+	}
+	for _, tc := range cases {
+		assert.Equal(t, tc.want, IsLoopbackRedirect(tc.url), tc.url)
+	}
+}
@@ -0,0 +1,358 @@
+package tfprovider
+
+import (
+	"testing"
+
+	"iac-platform/internal/models"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	"github.com/stretchr/testify/require"
+)
+
+// 验收测试需要 terraform 可执行文件，只在设置 TF_ACC=1 时运行：
+//
+//	TF_ACC=1 go test ./internal/tfprovider/ -run TestAcc
+
+// importIDFromAttrs 组合导入 ID，如 <workspace_id>/<id>
+func importIDFromAttrs(name string, attrs ...string) resource.ImportStateIdFunc {
+	return func(s *terraform.State) (string, error) {
+		rs := s.RootModule().Resources[name]
+		id := ""
+		for i, attr := range attrs {
+			if i > 0 {
+				id += "/"
+			}
+			id += rs.Primary.Attributes[attr]
+		}
+		return id, nil
+	}
+}
+
+func TestAccWorkspace(t *testing.T) {
+	platform := newTestPlatform(t)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+resource "iac_workspace" "app" {
+  name        = "app"
+  description = "first"
+  tags        = { team = "platform" }
+}
+
+resource "iac_workspace_variable" "region" {
+  workspace_id = iac_workspace.app.id
+  key          = "region"
+  value        = "us-east-1"
+}
+
+resource "iac_workspace_variable" "secret" {
+  workspace_id  = iac_workspace.app.id
+  key           = "TOKEN"
+  value         = "s3cr3t"
+  variable_type = "environment"
+  sensitive     = true
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrSet("iac_workspace.app", "id"),
+					resource.TestCheckResourceAttr("iac_workspace.app", "execution_mode", "local"),
+					resource.TestCheckResourceAttr("iac_workspace.app", "tags.team", "platform"),
+					resource.TestCheckResourceAttr("iac_workspace_variable.region", "value", "us-east-1"),
+					resource.TestCheckResourceAttr("iac_workspace_variable.secret", "value", "s3cr3t"),
+				),
+			},
+			{
+				// 写入一个 State 版本，供 Outputs 数据源读取
+				PreConfig: func() {
+					var ws models.Workspace
+					require.NoError(t, platform.db.Where("name = ?", "app").First(&ws).Error)
+					require.NoError(t, platform.db.Create(&models.WorkspaceStateVersion{
+						WorkspaceID: ws.WorkspaceID,
+						Version:     1,
+						Checksum:    "test",
+						Serial:      3,
+						Lineage:     "lineage-1",
+						Content: models.JSONB{
+							"version": 4, "serial": 3, "lineage": "lineage-1",
+							"outputs": map[string]interface{}{
+								"vpc_id":   map[string]interface{}{"value": "vpc-123", "type": "string"},
+								"password": map[string]interface{}{"value": "hunter2", "type": "string", "sensitive": true},
+							},
+						},
+					}).Error)
+				},
+				Config: `
+resource "iac_workspace" "app" {
+  name        = "app"
+  description = "second"
+  auto_apply  = true
+  tags        = { team = "platform" }
+}
+
+resource "iac_workspace_variable" "region" {
+  workspace_id = iac_workspace.app.id
+  key          = "region"
+  value        = "eu-west-1"
+}
+
+resource "iac_workspace_variable" "secret" {
+  workspace_id  = iac_workspace.app.id
+  key           = "TOKEN"
+  value         = "s3cr3t"
+  variable_type = "environment"
+  sensitive     = true
+}
+
+data "iac_workspace_outputs" "app" {
+  workspace_id = iac_workspace.app.id
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("iac_workspace.app", "description", "second"),
+					resource.TestCheckResourceAttr("iac_workspace.app", "auto_apply", "true"),
+					resource.TestCheckResourceAttr("iac_workspace_variable.region", "value", "eu-west-1"),
+					resource.TestCheckResourceAttr("data.iac_workspace_outputs.app", "serial", "3"),
+					resource.TestCheckResourceAttr("data.iac_workspace_outputs.app", "outputs.vpc_id", `"vpc-123"`),
+					resource.TestCheckNoResourceAttr("data.iac_workspace_outputs.app", "outputs.password"),
+					resource.TestCheckTypeSetElemAttr("data.iac_workspace_outputs.app", "sensitive_outputs.*", "password"),
+				),
+			},
+			{
+				ResourceName:      "iac_workspace.app",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			{
+				ResourceName:      "iac_workspace_variable.region",
+				ImportState:       true,
+				ImportStateIdFunc: importIDFromAttrs("iac_workspace_variable.region", "workspace_id", "id"),
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestAccRunTaskAndNotification(t *testing.T) {
+	newTestPlatform(t)
+
+	config := func(enforcement, events string, enabled bool) string {
+		return `
+resource "iac_workspace" "app" {
+  name = "notify"
+}
+
+resource "iac_run_task" "scan" {
+  name         = "scan"
+  endpoint_url = "https://scanner.example.com/hook"
+  hmac_key     = "key"
+}
+
+resource "iac_workspace_run_task" "scan" {
+  workspace_id      = iac_workspace.app.id
+  run_task_id       = iac_run_task.scan.id
+  stage             = "post_plan"
+  enforcement_level = "` + enforcement + `"
+}
+
+resource "iac_notification" "ops" {
+  name              = "ops"
+  notification_type = "webhook"
+  endpoint_url      = "https://hooks.example.com/ops"
+  enabled           = ` + map[bool]string{true: "true", false: "false"}[enabled] + `
+}
+
+resource "iac_workspace_notification" "ops" {
+  workspace_id    = iac_workspace.app.id
+  notification_id = iac_notification.ops.id
+  events          = [` + events + `]
+}
+`
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: config("advisory", `"task_failed"`, false),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("iac_run_task.scan", "timeout_seconds", "600"),
+					resource.TestCheckResourceAttr("iac_workspace_run_task.scan", "enforcement_level", "advisory"),
+					resource.TestCheckResourceAttr("iac_notification.ops", "enabled", "false"),
+					resource.TestCheckResourceAttr("iac_notification.ops", "custom_headers.Content-Type", "application/json"),
+					resource.TestCheckResourceAttr("iac_workspace_notification.ops", "events.#", "1"),
+				),
+			},
+			{
+				Config: config("mandatory", `"task_failed", "task_completed"`, true),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("iac_workspace_run_task.scan", "enforcement_level", "mandatory"),
+					resource.TestCheckResourceAttr("iac_notification.ops", "enabled", "true"),
+					resource.TestCheckResourceAttr("iac_workspace_notification.ops", "events.#", "2"),
+				),
+			},
+			{
+				ResourceName:            "iac_run_task.scan",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"hmac_key"},
+			},
+			{
+				ResourceName:      "iac_workspace_run_task.scan",
+				ImportState:       true,
+				ImportStateIdFunc: importIDFromAttrs("iac_workspace_run_task.scan", "workspace_id", "id"),
+				ImportStateVerify: true,
+			},
+			{
+				ResourceName:      "iac_workspace_notification.ops",
+				ImportState:       true,
+				ImportStateIdFunc: importIDFromAttrs("iac_workspace_notification.ops", "workspace_id", "id"),
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestAccTeamAndRoles(t *testing.T) {
+	newTestPlatform(t)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+resource "iac_team" "ops" {
+  name         = "ops"
+  display_name = "Operations"
+}
+
+resource "iac_team_member" "bob" {
+  team_id = iac_team.ops.id
+  user_id = "user-bob"
+}
+
+resource "iac_role_assignment" "team" {
+  team_id    = iac_team.ops.id
+  role_id    = 1
+  scope_type = "ORGANIZATION"
+  scope_id   = 1
+}
+
+resource "iac_role_assignment" "user" {
+  user_id    = "user-bob"
+  role_id    = 1
+  scope_type = "ORGANIZATION"
+  scope_id   = 1
+  reason     = "on-call"
+}
+`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("iac_team.ops", "org_id", "1"),
+					resource.TestCheckResourceAttr("iac_team_member.bob", "role", "MEMBER"),
+					resource.TestCheckResourceAttr("iac_role_assignment.team", "role_name", "viewer"),
+					resource.TestCheckResourceAttr("iac_role_assignment.user", "role_name", "viewer"),
+				),
+			},
+			{
+				// 变更成员角色会重新加入
+				Config: `
+resource "iac_team" "ops" {
+  name         = "ops"
+  display_name = "Operations"
+}
+
+resource "iac_team_member" "bob" {
+  team_id = iac_team.ops.id
+  user_id = "user-bob"
+  role    = "MAINTAINER"
+}
+`,
+				Check: resource.TestCheckResourceAttr("iac_team_member.bob", "role", "MAINTAINER"),
+			},
+			{
+				ResourceName:      "iac_team.ops",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			{
+				ResourceName:      "iac_team_member.bob",
+				ImportState:       true,
+				ImportStateIdFunc: importIDFromAttrs("iac_team_member.bob", "team_id", "user_id"),
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestAccAgentPoolAndModule(t *testing.T) {
+	newTestPlatform(t)
+
+	config := func(description, status string, defaultVersion bool) string {
+		return `
+resource "iac_agent_pool" "build" {
+  name        = "build"
+  description = "` + description + `"
+}
+
+resource "iac_module" "vpc" {
+  name           = "vpc"
+  provider_name  = "aws"
+  repository_url = "https://git.example.com/modules/vpc"
+  description    = "` + description + `"
+}
+
+resource "iac_module_version" "v2" {
+  module_id = iac_module.vpc.id
+  version   = "2.0.0"
+  status    = "` + status + `"
+  default   = ` + map[bool]string{true: "true", false: "false"}[defaultVersion] + `
+}
+`
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: config("first", "active", false),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("iac_agent_pool.build", "pool_type", "static"),
+					resource.TestCheckResourceAttr("iac_module.vpc", "source", "https://git.example.com/modules/vpc"),
+					resource.TestCheckResourceAttr("iac_module.vpc", "version", "1.0.0"),
+					resource.TestCheckResourceAttrSet("iac_module.vpc", "default_version_id"),
+					resource.TestCheckResourceAttr("iac_module_version.v2", "default", "false"),
+					resource.TestCheckResourceAttr("iac_module_version.v2", "source", "https://git.example.com/modules/vpc"),
+				),
+			},
+			{
+				Config: config("second", "deprecated", true),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("iac_agent_pool.build", "description", "second"),
+					resource.TestCheckResourceAttr("iac_module.vpc", "description", "second"),
+					resource.TestCheckResourceAttr("iac_module_version.v2", "status", "deprecated"),
+					resource.TestCheckResourceAttr("iac_module_version.v2", "default", "true"),
+				),
+			},
+			{
+				ResourceName:      "iac_agent_pool.build",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			{
+				ResourceName:            "iac_module.vpc",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"version", "default_version_id"},
+			},
+			{
+				ResourceName:      "iac_module_version.v2",
+				ImportState:       true,
+				ImportStateIdFunc: importIDFromAttrs("iac_module_version.v2", "module_id", "id"),
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
@@ -0,0 +1,106 @@
+package tfprovider
+
+import (
+	"context"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ datasource.DataSourceWithConfigure = &workspaceOutputsDataSource{}
+
+// workspaceOutputsDataSource 读取工作空间当前 State 的 Outputs
+// 接口不返回敏感值，敏感 Output 只列出名称
+type workspaceOutputsDataSource struct {
+	clientHolder
+}
+
+type workspaceOutputsModel struct {
+	WorkspaceID      types.String `tfsdk:"workspace_id"`
+	Serial           types.Int64  `tfsdk:"serial"`
+	Lineage          types.String `tfsdk:"lineage"`
+	Outputs          types.Map    `tfsdk:"outputs"`
+	SensitiveOutputs types.Set    `tfsdk:"sensitive_outputs"`
+}
+
+func newWorkspaceOutputsDataSource() datasource.DataSource {
+	return &workspaceOutputsDataSource{}
+}
+
+func (d *workspaceOutputsDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_workspace_outputs"
+}
+
+func (d *workspaceOutputsDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	d.configureDataSource(req, resp)
+}
+
+func (d *workspaceOutputsDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Outputs of a workspace's current state. Sensitive values are never returned.",
+		Attributes: map[string]schema.Attribute{
+			"workspace_id": schema.StringAttribute{
+				Required: true,
+			},
+			"serial": schema.Int64Attribute{
+				Description: "Serial of the state the outputs were read from.",
+				Computed:    true,
+			},
+			"lineage": schema.StringAttribute{
+				Computed: true,
+			},
+			"outputs": schema.MapAttribute{
+				Description: "Non-sensitive output values, JSON encoded. Use jsondecode() to read them.",
+				ElementType: types.StringType,
+				Computed:    true,
+			},
+			"sensitive_outputs": schema.SetAttribute{
+				Description: "Names of sensitive outputs, whose values are not exposed.",
+				ElementType: types.StringType,
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func (d *workspaceOutputsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var config workspaceOutputsModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	out, err := d.client.GetStateOutputs(ctx, config.WorkspaceID.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read workspace outputs", err.Error())
+		return
+	}
+
+	values := map[string]attr.Value{}
+	sensitive := []string{}
+	for name, o := range out.Outputs {
+		if o.Sensitive {
+			sensitive = append(sensitive, name)
+			continue
+		}
+		value := "null"
+		if len(o.Value) > 0 {
+			value = string(o.Value)
+		}
+		values[name] = types.StringValue(value)
+	}
+	sort.Strings(sensitive)
+	names := make([]attr.Value, 0, len(sensitive))
+	for _, name := range sensitive {
+		names = append(names, types.StringValue(name))
+	}
+
+	config.Serial = types.Int64Value(int64(out.Serial))
+	config.Lineage = types.StringValue(out.Lineage)
+	config.Outputs = types.MapValueMust(types.StringType, values)
+	config.SensitiveOutputs = types.SetValueMust(types.StringType, names)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &config)...)
+}
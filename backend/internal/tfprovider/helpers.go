@@ -0,0 +1,150 @@
+package tfprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"iac-platform/internal/apiclient"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// namePattern Run Task、通知等配置名称的格式（与接口校验一致）
+var namePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// clientHolder 资源和数据源共用的 Configure：从 Provider 取得 API 客户端
+type clientHolder struct {
+	client *apiclient.Client
+}
+
+func (h *clientHolder) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	h.configure(req.ProviderData, &resp.Diagnostics)
+}
+
+func (h *clientHolder) configureDataSource(req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	h.configure(req.ProviderData, &resp.Diagnostics)
+}
+
+func (h *clientHolder) configure(data interface{}, diags *diag.Diagnostics) {
+	if data == nil {
+		// ValidateConfig 等阶段 Provider 尚未配置
+		return
+	}
+	client, ok := data.(*apiclient.Client)
+	if !ok {
+		diags.AddError("Unexpected provider data", fmt.Sprintf("expected *apiclient.Client, got %T", data))
+		return
+	}
+	h.client = client
+}
+
+// isNotFound 资源已在平台侧被删除，Read 时应从 state 中移除
+func isNotFound(err error) bool {
+	return errors.Is(err, apiclient.ErrNotFound)
+}
+
+// splitImportID 拆分 "a/b" 形式的复合导入 ID
+func splitImportID(id string, parts int, format string, diags *diag.Diagnostics) []string {
+	fields := strings.SplitN(id, "/", parts)
+	if len(fields) != parts {
+		diags.AddError("Invalid import ID", fmt.Sprintf("expected %s, got %q", format, id))
+		return nil
+	}
+	for _, f := range fields {
+		if f == "" {
+			diags.AddError("Invalid import ID", fmt.Sprintf("expected %s, got %q", format, id))
+			return nil
+		}
+	}
+	return fields
+}
+
+func parseUintID(s string) (uint, error) {
+	n, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid numeric ID %q", s)
+	}
+	return uint(n), nil
+}
+
+func formatUintID(n uint) string {
+	return strconv.FormatUint(uint64(n), 10)
+}
+
+// stringPtr 未配置（null）时返回 nil，用于可选参数
+func stringPtr(v types.String) *string {
+	if v.IsNull() || v.IsUnknown() {
+		return nil
+	}
+	s := v.ValueString()
+	return &s
+}
+
+// uintPtr 未配置（null）时返回 nil
+func uintPtr(v types.Int64) *uint {
+	if v.IsNull() || v.IsUnknown() {
+		return nil
+	}
+	n := uint(v.ValueInt64())
+	return &n
+}
+
+func uintValue(n *uint) types.Int64 {
+	if n == nil {
+		return types.Int64Null()
+	}
+	return types.Int64Value(int64(*n))
+}
+
+// changed 更新时只提交计划值与当前 state 不同的字段
+func changed(plan, state attr.Value) bool {
+	return !plan.Equal(state)
+}
+
+// eventSet 把逗号分隔的事件列表转为集合，顺序无关
+func eventSet(events string) types.Set {
+	values := []attr.Value{}
+	for _, e := range strings.Split(events, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			values = append(values, types.StringValue(e))
+		}
+	}
+	return types.SetValueMust(types.StringType, values)
+}
+
+// joinEvents 把事件集合转为接口使用的逗号分隔字符串（排序后保证稳定）
+func joinEvents(ctx context.Context, set types.Set, diags *diag.Diagnostics) string {
+	if set.IsNull() || set.IsUnknown() {
+		return ""
+	}
+	var events []string
+	diags.Append(set.ElementsAs(ctx, &events, false)...)
+	sort.Strings(events)
+	return strings.Join(events, ",")
+}
+
+func stringMap(m map[string]string) types.Map {
+	values := make(map[string]attr.Value, len(m))
+	for k, v := range m {
+		values[k] = types.StringValue(v)
+	}
+	return types.MapValueMust(types.StringType, values)
+}
+
+func mapToStrings(ctx context.Context, m types.Map, diags *diag.Diagnostics) map[string]string {
+	if m.IsNull() || m.IsUnknown() {
+		return nil
+	}
+	out := map[string]string{}
+	diags.Append(m.ElementsAs(ctx, &out, false)...)
+	return out
+}
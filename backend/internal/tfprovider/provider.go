@@ -0,0 +1,120 @@
+// Package tfprovider 是平台的 Terraform Provider（terraform-plugin-framework），
+// 把工作空间、变量、Run Task、通知、团队与权限、Agent 池、模块等平台配置以代码方式管理。
+// 资源一一对应已有的 /api/v1 接口，通过 internal/apiclient 调用。
+package tfprovider
+
+import (
+	"context"
+	"os"
+
+	"iac-platform/internal/apiclient"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/provider"
+	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// 未在 provider 块中配置时读取的环境变量（与 iac CLI 一致）
+const (
+	envAddress = "IAC_ADDRESS"
+	envToken   = "IAC_TOKEN"
+)
+
+var _ provider.Provider = &iacProvider{}
+
+type iacProvider struct {
+	version string
+}
+
+type providerModel struct {
+	Address types.String `tfsdk:"address"`
+	Token   types.String `tfsdk:"token"`
+}
+
+// New 返回 Provider 工厂，version 会写入 User-Agent
+func New(version string) func() provider.Provider {
+	return func() provider.Provider {
+		return &iacProvider{version: version}
+	}
+}
+
+func (p *iacProvider) Metadata(_ context.Context, _ provider.MetadataRequest, resp *provider.MetadataResponse) {
+	resp.TypeName = "iac"
+	resp.Version = p.version
+}
+
+func (p *iacProvider) Schema(_ context.Context, _ provider.SchemaRequest, resp *provider.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Manages IaC Platform configuration: workspaces, variables, run tasks, notifications, teams, permissions, agent pools and modules.",
+		Attributes: map[string]schema.Attribute{
+			"address": schema.StringAttribute{
+				Description: "Platform address, e.g. https://iac.example.com. Defaults to the " + envAddress + " environment variable.",
+				Optional:    true,
+			},
+			"token": schema.StringAttribute{
+				Description: "API token (a user token or a team token). Defaults to the " + envToken + " environment variable.",
+				Optional:    true,
+				Sensitive:   true,
+			},
+		},
+	}
+}
+
+func (p *iacProvider) Configure(ctx context.Context, req provider.ConfigureRequest, resp *provider.ConfigureResponse) {
+	var config providerModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if config.Address.IsUnknown() || config.Token.IsUnknown() {
+		// 依赖其他资源输出的配置要到 apply 时才能确定
+		return
+	}
+
+	address := os.Getenv(envAddress)
+	if !config.Address.IsNull() {
+		address = config.Address.ValueString()
+	}
+	token := os.Getenv(envToken)
+	if !config.Token.IsNull() {
+		token = config.Token.ValueString()
+	}
+	if token == "" {
+		resp.Diagnostics.AddError("Missing API token",
+			"Set the token attribute in the provider block or the "+envToken+" environment variable.")
+		return
+	}
+
+	client, err := apiclient.New(address, token, apiclient.WithUserAgent("terraform-provider-iac/"+p.version))
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid provider configuration", err.Error())
+		return
+	}
+	resp.ResourceData = client
+	resp.DataSourceData = client
+}
+
+func (p *iacProvider) Resources(_ context.Context) []func() resource.Resource {
+	return []func() resource.Resource{
+		newWorkspaceResource,
+		newWorkspaceVariableResource,
+		newRunTaskResource,
+		newWorkspaceRunTaskResource,
+		newNotificationResource,
+		newWorkspaceNotificationResource,
+		newTeamResource,
+		newTeamMemberResource,
+		newRoleAssignmentResource,
+		newAgentPoolResource,
+		newModuleResource,
+		newModuleVersionResource,
+	}
+}
+
+func (p *iacProvider) DataSources(_ context.Context) []func() datasource.DataSource {
+	return []func() datasource.DataSource{
+		newWorkspaceOutputsDataSource,
+	}
+}
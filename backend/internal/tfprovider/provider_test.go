@@ -0,0 +1,207 @@
+package tfprovider
+
+import (
+	"context"
+	"database/sql"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"iac-platform/internal/domain/entity"
+	"iac-platform/internal/models"
+	"iac-platform/internal/router"
+	"iac-platform/internal/websocket"
+	"iac-platform/services"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const testJWTSecret = "tfprovider-test-secret-tfprovider-test-secret"
+
+// testProviderFactories 验收测试使用的进程内 Provider
+var testProviderFactories = map[string]func() (tfprotov6.ProviderServer, error){
+	"iac": providerserver.NewProtocol6WithError(New("test")()),
+}
+
+// testPlatform 进程内启动的平台 API（sqlite 内存库），供验收测试使用
+// 路由会注册全局的 Prometheus 指标，一个测试进程只能启动一次，各测试共用并使用不同的资源名称
+type testPlatform struct {
+	db    *gorm.DB
+	url   string
+	token string
+}
+
+var (
+	platformOnce sync.Once
+	platform     *testPlatform
+	platformErr  error
+)
+
+// newTestPlatform 返回共用的平台 API，并通过 IAC_ADDRESS/IAC_TOKEN 指向它，Provider 块可以留空
+func newTestPlatform(t *testing.T) *testPlatform {
+	t.Helper()
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("acceptance tests require TF_ACC=1")
+	}
+	t.Setenv("JWT_SECRET", testJWTSecret)
+
+	platformOnce.Do(func() { platform, platformErr = startTestPlatform() })
+	require.NoError(t, platformErr)
+
+	t.Setenv(envAddress, platform.url)
+	t.Setenv(envToken, platform.token)
+	return platform
+}
+
+// 部分服务的 SQL 使用 PostgreSQL 的 NOW()，为 sqlite 注册同名函数
+func init() {
+	sql.Register("sqlite3_tfprovider", &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			return conn.RegisterFunc("now", func() string {
+				return time.Now().UTC().Format("2006-01-02 15:04:05.999999999-07:00")
+			}, false)
+		},
+	})
+}
+
+func startTestPlatform() (*testPlatform, error) {
+	dialector := sqlite.Dialector{DriverName: "sqlite3_tfprovider", DSN: "file:tfprovider?mode=memory&cache=shared"}
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(1)
+
+	err = db.AutoMigrate(
+		&models.User{}, &models.LoginSession{},
+		&models.Workspace{}, &models.WorkspaceVariable{}, &models.WorkspaceStateVersion{}, &models.WorkspaceOutput{},
+		&models.RunTask{}, &models.WorkspaceRunTask{},
+		&models.NotificationConfig{}, &models.WorkspaceNotification{},
+		&models.AgentPool{}, &models.Module{}, &models.ModuleVersion{},
+		&entity.Organization{}, &entity.Team{}, &entity.TeamMember{}, &entity.Role{}, &entity.UserRole{},
+		&entity.AccessLog{},
+	)
+	if err != nil {
+		return nil, err
+	}
+	// 团队角色没有对应的实体，接口直接读写该表
+	seeds := []func() error{
+		// 变量按版本保存多行，与线上一致使用 (variable_id, version) 唯一索引
+		func() error {
+			return db.Migrator().DropIndex(&models.WorkspaceVariable{}, "idx_workspace_variables_variable_id")
+		},
+		func() error {
+			return db.Exec("CREATE UNIQUE INDEX idx_variable_id_version ON workspace_variables (variable_id, version)").Error
+		},
+		func() error {
+			return db.Exec(`CREATE TABLE iam_team_roles (
+				id integer PRIMARY KEY AUTOINCREMENT, team_id text, role_id integer, scope_type text, scope_id integer,
+				assigned_by text, assigned_at datetime, expires_at datetime, reason text)`).Error
+		},
+		func() error {
+			return db.Create(&entity.Organization{ID: 1, Name: "default", DisplayName: "Default", IsActive: true}).Error
+		},
+		func() error {
+			return db.Create(&entity.Role{Name: "viewer", DisplayName: "Viewer", IsActive: true}).Error
+		},
+		func() error {
+			return db.Create(&models.User{ID: "user-admin", Username: "admin", Email: "admin@example.com", IsActive: true, IsSystemAdmin: true}).Error
+		},
+		func() error {
+			return db.Create(&models.User{ID: "user-bob", Username: "bob", Email: "bob@example.com", IsActive: true}).Error
+		},
+		func() error {
+			return db.Create(&models.LoginSession{SessionID: "session-admin", UserID: "user-admin", IsActive: true, ExpiresAt: time.Now().Add(24 * time.Hour)}).Error
+		},
+	}
+	for _, seed := range seeds {
+		if err := seed(); err != nil {
+			return nil, err
+		}
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id":    "user-admin",
+		"username":   "admin",
+		"type":       "login_token",
+		"session_id": "session-admin",
+		"exp":        time.Now().Add(24 * time.Hour).Unix(),
+	}).SignedString([]byte(testJWTSecret))
+	if err != nil {
+		return nil, err
+	}
+
+	streams := services.NewOutputStreamManager()
+	queue := services.NewTaskQueueManager(db, services.NewTerraformExecutor(db, streams))
+	r := router.Setup(db, streams, websocket.NewHub(), websocket.NewAgentMetricsHub(), queue, nil,
+		services.NewRunTaskExecutor(db, "http://localhost"))
+	srv := httptest.NewServer(r)
+	return &testPlatform{db: db, url: srv.URL, token: token}, nil
+}
+
+func TestProvider_Schemas(t *testing.T) {
+	server, err := testProviderFactories["iac"]()
+	require.NoError(t, err)
+
+	// 框架在此校验全部资源和数据源的 Schema 定义
+	resp, err := server.GetProviderSchema(context.Background(), &tfprotov6.GetProviderSchemaRequest{})
+	require.NoError(t, err)
+	for _, d := range resp.Diagnostics {
+		t.Errorf("%s: %s", d.Summary, d.Detail)
+	}
+
+	var names []string
+	for name := range resp.ResourceSchemas {
+		names = append(names, name)
+	}
+	assert.ElementsMatch(t, []string{
+		"iac_workspace", "iac_workspace_variable",
+		"iac_run_task", "iac_workspace_run_task",
+		"iac_notification", "iac_workspace_notification",
+		"iac_team", "iac_team_member", "iac_role_assignment",
+		"iac_agent_pool", "iac_module", "iac_module_version",
+	}, names)
+	assert.Contains(t, resp.DataSourceSchemas, "iac_workspace_outputs")
+}
+
+func TestSplitImportID(t *testing.T) {
+	var diags diag.Diagnostics
+	assert.Equal(t, []string{"ws-1", "var-2"}, splitImportID("ws-1/var-2", 2, "<a>/<b>", &diags))
+	assert.False(t, diags.HasError())
+
+	for _, id := range []string{"ws-1", "ws-1/", "/var-2"} {
+		var diags diag.Diagnostics
+		assert.Nil(t, splitImportID(id, 2, "<a>/<b>", &diags), id)
+		assert.True(t, diags.HasError(), id)
+	}
+
+	// 最后一段可以包含分隔符
+	assert.Equal(t, []string{"team", "t-1", "a/b"}, splitImportID("team/t-1/a/b", 3, "", &diags))
+}
+
+func TestEvents(t *testing.T) {
+	ctx := context.Background()
+	set := eventSet("task_failed, task_completed,,")
+	assert.Len(t, set.Elements(), 2)
+
+	var diags diag.Diagnostics
+	assert.Equal(t, "task_completed,task_failed", joinEvents(ctx, set, &diags))
+	assert.Equal(t, "", joinEvents(ctx, types.SetNull(types.StringType), &diags))
+	assert.False(t, diags.HasError())
+}
@@ -0,0 +1,161 @@
+package tfprovider
+
+import (
+	"context"
+
+	"iac-platform/internal/apiclient"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ resource.Resource                = &agentPoolResource{}
+	_ resource.ResourceWithImportState = &agentPoolResource{}
+)
+
+type agentPoolResource struct {
+	clientHolder
+}
+
+type agentPoolModel struct {
+	ID          types.String `tfsdk:"id"`
+	Name        types.String `tfsdk:"name"`
+	Description types.String `tfsdk:"description"`
+	PoolType    types.String `tfsdk:"pool_type"`
+}
+
+func newAgentPoolResource() resource.Resource {
+	return &agentPoolResource{}
+}
+
+func (r *agentPoolResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_agent_pool"
+}
+
+func (r *agentPoolResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "An agent pool. Pools that still have registered agents cannot be deleted.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description:   "Pool ID (pool-...).",
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"name": schema.StringAttribute{
+				Required: true,
+			},
+			"description": schema.StringAttribute{
+				Optional: true,
+				Computed: true,
+				Default:  stringdefault.StaticString(""),
+			},
+			"pool_type": schema.StringAttribute{
+				Description:   "static or k8s. Changing it forces a new pool.",
+				Optional:      true,
+				Computed:      true,
+				Default:       stringdefault.StaticString("static"),
+				Validators:    []validator.String{stringvalidator.OneOf("static", "k8s")},
+				PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+		},
+	}
+}
+
+func (r *agentPoolResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan agentPoolModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	pool, err := r.client.CreateAgentPool(ctx, apiclient.CreateAgentPoolRequest{
+		Name:        plan.Name.ValueString(),
+		Description: stringPtr(plan.Description),
+		PoolType:    plan.PoolType.ValueString(),
+	})
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to create agent pool", err.Error())
+		return
+	}
+	setAgentPoolState(&plan, pool)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *agentPoolResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state agentPoolModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	pool, err := r.client.GetAgentPool(ctx, state.ID.ValueString())
+	if isNotFound(err) {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read agent pool", err.Error())
+		return
+	}
+	setAgentPoolState(&state, pool)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+func (r *agentPoolResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state agentPoolModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	update := apiclient.UpdateAgentPoolRequest{}
+	if changed(plan.Name, state.Name) {
+		update.Name = stringPtr(plan.Name)
+	}
+	if changed(plan.Description, state.Description) {
+		update.Description = stringPtr(plan.Description)
+	}
+
+	pool, err := r.client.UpdateAgentPool(ctx, state.ID.ValueString(), update)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to update agent pool", err.Error())
+		return
+	}
+	setAgentPoolState(&plan, pool)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *agentPoolResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state agentPoolModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if err := r.client.DeleteAgentPool(ctx, state.ID.ValueString()); err != nil && !isNotFound(err) {
+		resp.Diagnostics.AddError("Failed to delete agent pool", err.Error())
+	}
+}
+
+func (r *agentPoolResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+}
+
+func setAgentPoolState(m *agentPoolModel, pool *apiclient.AgentPool) {
+	m.ID = types.StringValue(pool.PoolID)
+	m.Name = types.StringValue(pool.Name)
+	description := ""
+	if pool.Description != nil {
+		description = *pool.Description
+	}
+	m.Description = types.StringValue(description)
+	m.PoolType = types.StringValue(pool.PoolType)
+}
@@ -0,0 +1,282 @@
+package tfprovider
+
+import (
+	"context"
+
+	"iac-platform/internal/apiclient"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/boolplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ resource.Resource                = &moduleResource{}
+	_ resource.ResourceWithImportState = &moduleResource{}
+)
+
+// moduleResource 模块更新接口忽略空字符串，可选字符串参数不能清空，未配置时沿用平台上的值
+type moduleResource struct {
+	clientHolder
+}
+
+type moduleModel struct {
+	ID                 types.String `tfsdk:"id"`
+	Name               types.String `tfsdk:"name"`
+	Provider           types.String `tfsdk:"provider_name"`
+	Source             types.String `tfsdk:"source"`
+	RepositoryURL      types.String `tfsdk:"repository_url"`
+	ModuleSource       types.String `tfsdk:"module_source"`
+	InitialVersion     types.String `tfsdk:"initial_version"`
+	Version            types.String `tfsdk:"version"`
+	Description        types.String `tfsdk:"description"`
+	Branch             types.String `tfsdk:"branch"`
+	Status             types.String `tfsdk:"status"`
+	RequirePassingTest types.Bool   `tfsdk:"require_passing_test"`
+	DefaultVersionID   types.String `tfsdk:"default_version_id"`
+}
+
+func newModuleResource() resource.Resource {
+	return &moduleResource{}
+}
+
+func (r *moduleResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_module"
+}
+
+func (r *moduleResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	// 平台不能清空的可选字符串：未配置时取平台上的值
+	optionalComputed := func(description string) schema.StringAttribute {
+		return schema.StringAttribute{
+			Description:   description,
+			Optional:      true,
+			Computed:      true,
+			Validators:    []validator.String{stringvalidator.LengthAtLeast(1)},
+			PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+		}
+	}
+	replaced := func(description string) schema.StringAttribute {
+		return schema.StringAttribute{
+			Description:   description,
+			Optional:      true,
+			Computed:      true,
+			Validators:    []validator.String{stringvalidator.LengthAtLeast(1)},
+			PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplaceIfConfigured(), stringplanmodifier.UseStateForUnknown()},
+		}
+	}
+
+	status := optionalComputed("active or inactive.")
+	status.Validators = []validator.String{stringvalidator.OneOf("active", "inactive")}
+
+	resp.Schema = schema.Schema{
+		Description: "A module in the private module registry. Creating a module also creates its first version.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description:   "Numeric module ID.",
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"name": schema.StringAttribute{
+				Required:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"provider_name": schema.StringAttribute{
+				Description:   "Terraform provider the module targets, e.g. aws.",
+				Required:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"source":         replaced("Module source. Defaults to repository_url."),
+			"repository_url": replaced("Git repository of the module."),
+			"module_source":  optionalComputed("Source address used in generated module blocks."),
+			"initial_version": schema.StringAttribute{
+				Description: "Version of the module version created together with the module. Defaults to 1.0.0. Only used on create.",
+				Optional:    true,
+				Validators:  []validator.String{stringvalidator.LengthAtLeast(1)},
+			},
+			"version": schema.StringAttribute{
+				Description:   "Version of the current default module version.",
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"description": optionalComputed(""),
+			"branch":      optionalComputed(""),
+			"status":      status,
+			"require_passing_test": schema.BoolAttribute{
+				Description:   "Require a passing module test before a version can become the default.",
+				Optional:      true,
+				Computed:      true,
+				PlanModifiers: []planmodifier.Bool{boolplanmodifier.UseStateForUnknown()},
+			},
+			"default_version_id": schema.StringAttribute{
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+		},
+	}
+}
+
+func (r *moduleResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan moduleModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	m, err := r.client.CreateModule(ctx, apiclient.CreateModuleRequest{
+		Name:          plan.Name.ValueString(),
+		Provider:      plan.Provider.ValueString(),
+		Source:        plan.Source.ValueString(),
+		ModuleSource:  plan.ModuleSource.ValueString(),
+		Version:       plan.InitialVersion.ValueString(),
+		Description:   plan.Description.ValueString(),
+		RepositoryURL: plan.RepositoryURL.ValueString(),
+		Branch:        plan.Branch.ValueString(),
+	})
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to create module", err.Error())
+		return
+	}
+	// status 和 require_passing_test 只能通过更新接口设置
+	update := apiclient.UpdateModuleRequest{Status: plan.Status.ValueString()}
+	if !plan.RequirePassingTest.IsUnknown() {
+		v := plan.RequirePassingTest.ValueBool()
+		update.RequirePassingTest = &v
+	}
+	if update.Status != "" || update.RequirePassingTest != nil {
+		if _, err := r.client.UpdateModule(ctx, m.ID, update); err != nil {
+			resp.Diagnostics.AddError("Failed to update module", err.Error())
+			return
+		}
+	}
+	// 创建接口返回的数据不含自动创建的默认版本，重新读取
+	m, err = r.client.GetModule(ctx, m.ID)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read module", err.Error())
+		return
+	}
+	setModuleState(&plan, m)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *moduleResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state moduleModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	id, err := parseUintID(state.ID.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid module ID", err.Error())
+		return
+	}
+	m, err := r.client.GetModule(ctx, id)
+	if isNotFound(err) {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read module", err.Error())
+		return
+	}
+	setModuleState(&state, m)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+func (r *moduleResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state moduleModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	id, err := parseUintID(state.ID.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid module ID", err.Error())
+		return
+	}
+	update := apiclient.UpdateModuleRequest{}
+	if changed(plan.ModuleSource, state.ModuleSource) {
+		update.ModuleSource = plan.ModuleSource.ValueString()
+	}
+	if changed(plan.Description, state.Description) {
+		update.Description = plan.Description.ValueString()
+	}
+	if changed(plan.Branch, state.Branch) {
+		update.Branch = plan.Branch.ValueString()
+	}
+	if changed(plan.Status, state.Status) {
+		update.Status = plan.Status.ValueString()
+	}
+	if !plan.RequirePassingTest.IsUnknown() && changed(plan.RequirePassingTest, state.RequirePassingTest) {
+		v := plan.RequirePassingTest.ValueBool()
+		update.RequirePassingTest = &v
+	}
+
+	if _, err := r.client.UpdateModule(ctx, id, update); err != nil {
+		resp.Diagnostics.AddError("Failed to update module", err.Error())
+		return
+	}
+	m, err := r.client.GetModule(ctx, id)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read module", err.Error())
+		return
+	}
+	setModuleState(&plan, m)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+// Delete 活跃状态的模块不能删除，先停用再删除；模块的全部版本一并删除
+func (r *moduleResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state moduleModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	id, err := parseUintID(state.ID.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid module ID", err.Error())
+		return
+	}
+	if _, err := r.client.UpdateModule(ctx, id, apiclient.UpdateModuleRequest{Status: "inactive"}); err != nil {
+		if isNotFound(err) {
+			return
+		}
+		resp.Diagnostics.AddError("Failed to deactivate module", err.Error())
+		return
+	}
+	if err := r.client.DeleteModule(ctx, id); err != nil && !isNotFound(err) {
+		resp.Diagnostics.AddError("Failed to delete module", err.Error())
+	}
+}
+
+func (r *moduleResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+}
+
+func setModuleState(m *moduleModel, mod *apiclient.Module) {
+	m.ID = types.StringValue(formatUintID(mod.ID))
+	m.Name = types.StringValue(mod.Name)
+	m.Provider = types.StringValue(mod.Provider)
+	m.Source = types.StringValue(mod.Source)
+	m.RepositoryURL = types.StringValue(mod.RepositoryURL)
+	m.ModuleSource = types.StringValue(mod.ModuleSource)
+	m.Version = types.StringValue(mod.Version)
+	m.Description = types.StringValue(mod.Description)
+	m.Branch = types.StringValue(mod.Branch)
+	m.Status = types.StringValue(mod.Status)
+	m.RequirePassingTest = types.BoolValue(mod.RequirePassingTest)
+	if mod.DefaultVersionID != nil {
+		m.DefaultVersionID = types.StringValue(*mod.DefaultVersionID)
+	} else {
+		m.DefaultVersionID = types.StringValue("")
+	}
+}
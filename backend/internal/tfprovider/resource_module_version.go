@@ -0,0 +1,254 @@
+package tfprovider
+
+import (
+	"context"
+
+	"iac-platform/internal/apiclient"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/boolplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ resource.Resource                = &moduleVersionResource{}
+	_ resource.ResourceWithImportState = &moduleVersionResource{}
+)
+
+type moduleVersionResource struct {
+	clientHolder
+}
+
+type moduleVersionModel struct {
+	ID                types.String `tfsdk:"id"`
+	ModuleID          types.String `tfsdk:"module_id"`
+	Version           types.String `tfsdk:"version"`
+	Source            types.String `tfsdk:"source"`
+	ModuleSource      types.String `tfsdk:"module_source"`
+	InheritSchemaFrom types.String `tfsdk:"inherit_schema_from"`
+	Status            types.String `tfsdk:"status"`
+	Default           types.Bool   `tfsdk:"default"`
+}
+
+func newModuleVersionResource() resource.Resource {
+	return &moduleVersionResource{}
+}
+
+func (r *moduleVersionResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_module_version"
+}
+
+func (r *moduleVersionResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "A version of a registry module.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description:   "Version ID (modv-...).",
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"module_id": schema.StringAttribute{
+				Required:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"version": schema.StringAttribute{
+				Required:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"source": schema.StringAttribute{
+				Description:   "Defaults to the module source.",
+				Optional:      true,
+				Computed:      true,
+				Validators:    []validator.String{stringvalidator.LengthAtLeast(1)},
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"module_source": schema.StringAttribute{
+				Optional:      true,
+				Computed:      true,
+				Validators:    []validator.String{stringvalidator.LengthAtLeast(1)},
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"inherit_schema_from": schema.StringAttribute{
+				Description:   "Version ID whose schema is copied when the version is created.",
+				Optional:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"status": schema.StringAttribute{
+				Description:   "active, deprecated or archived.",
+				Optional:      true,
+				Computed:      true,
+				Validators:    []validator.String{stringvalidator.OneOf("active", "deprecated", "archived")},
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"default": schema.BoolAttribute{
+				Description: "Make this the module's default version. Setting it to false does not unset the default; " +
+					"make another version the default instead.",
+				Optional:      true,
+				Computed:      true,
+				PlanModifiers: []planmodifier.Bool{boolplanmodifier.UseStateForUnknown()},
+			},
+		},
+	}
+}
+
+func (r *moduleVersionResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan moduleVersionModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	moduleID, err := parseUintID(plan.ModuleID.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("module_id"), "Invalid module ID", err.Error())
+		return
+	}
+	v, err := r.client.CreateModuleVersion(ctx, moduleID, apiclient.CreateModuleVersionRequest{
+		Version:           plan.Version.ValueString(),
+		Source:            plan.Source.ValueString(),
+		ModuleSource:      plan.ModuleSource.ValueString(),
+		InheritSchemaFrom: plan.InheritSchemaFrom.ValueString(),
+		SetAsDefault:      plan.Default.ValueBool(),
+	})
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to create module version", err.Error())
+		return
+	}
+	if status := plan.Status.ValueString(); status != "" && status != v.Status {
+		v, err = r.client.UpdateModuleVersion(ctx, moduleID, v.ID, apiclient.UpdateModuleVersionRequest{Status: status})
+		if err != nil {
+			resp.Diagnostics.AddError("Failed to update module version", err.Error())
+			return
+		}
+	}
+	setModuleVersionState(&plan, v)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *moduleVersionResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state moduleVersionModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	moduleID, err := parseUintID(state.ModuleID.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid module ID", err.Error())
+		return
+	}
+	v, err := r.client.GetModuleVersion(ctx, moduleID, state.ID.ValueString())
+	if isNotFound(err) {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read module version", err.Error())
+		return
+	}
+	setModuleVersionState(&state, v)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+func (r *moduleVersionResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state moduleVersionModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	moduleID, err := parseUintID(state.ModuleID.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid module ID", err.Error())
+		return
+	}
+	versionID := state.ID.ValueString()
+	update := apiclient.UpdateModuleVersionRequest{}
+	if changed(plan.Source, state.Source) {
+		update.Source = plan.Source.ValueString()
+	}
+	if changed(plan.ModuleSource, state.ModuleSource) {
+		update.ModuleSource = plan.ModuleSource.ValueString()
+	}
+	if changed(plan.Status, state.Status) {
+		update.Status = plan.Status.ValueString()
+	}
+	if _, err := r.client.UpdateModuleVersion(ctx, moduleID, versionID, update); err != nil {
+		resp.Diagnostics.AddError("Failed to update module version", err.Error())
+		return
+	}
+	if plan.Default.ValueBool() && !state.Default.ValueBool() {
+		if err := r.client.SetDefaultModuleVersion(ctx, moduleID, versionID); err != nil {
+			resp.Diagnostics.AddError("Failed to set default module version", err.Error())
+			return
+		}
+	}
+
+	v, err := r.client.GetModuleVersion(ctx, moduleID, versionID)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read module version", err.Error())
+		return
+	}
+	setModuleVersionState(&plan, v)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+// Delete 默认版本不能单独删除，它会随模块一起删除，这里只从 state 中移除
+func (r *moduleVersionResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state moduleVersionModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	moduleID, err := parseUintID(state.ModuleID.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid module ID", err.Error())
+		return
+	}
+	versionID := state.ID.ValueString()
+	v, err := r.client.GetModuleVersion(ctx, moduleID, versionID)
+	if isNotFound(err) {
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read module version", err.Error())
+		return
+	}
+	if v.IsDefault {
+		resp.Diagnostics.AddWarning("Default module version not deleted",
+			"Version "+v.Version+" is the module's default version. It is removed together with the module, "+
+				"or can be deleted after another version becomes the default.")
+		return
+	}
+	if err := r.client.DeleteModuleVersion(ctx, moduleID, versionID); err != nil && !isNotFound(err) {
+		resp.Diagnostics.AddError("Failed to delete module version", err.Error())
+	}
+}
+
+// ImportState 导入 ID 格式为 <module_id>/<version_id>
+func (r *moduleVersionResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	parts := splitImportID(req.ID, 2, "<module_id>/<version_id>", &resp.Diagnostics)
+	if parts == nil {
+		return
+	}
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("module_id"), parts[0])...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), parts[1])...)
+}
+
+func setModuleVersionState(m *moduleVersionModel, v *apiclient.ModuleVersion) {
+	m.ID = types.StringValue(v.ID)
+	m.ModuleID = types.StringValue(formatUintID(v.ModuleID))
+	m.Version = types.StringValue(v.Version)
+	m.Source = types.StringValue(v.Source)
+	m.ModuleSource = types.StringValue(v.ModuleSource)
+	m.Status = types.StringValue(v.Status)
+	m.Default = types.BoolValue(v.IsDefault)
+}
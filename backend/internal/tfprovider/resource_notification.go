@@ -0,0 +1,294 @@
+package tfprovider
+
+import (
+	"context"
+
+	"iac-platform/internal/apiclient"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64default"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/mapplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/setplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ resource.Resource                = &notificationResource{}
+	_ resource.ResourceWithImportState = &notificationResource{}
+)
+
+type notificationResource struct {
+	clientHolder
+}
+
+type notificationModel struct {
+	ID                   types.String `tfsdk:"id"`
+	Name                 types.String `tfsdk:"name"`
+	Description          types.String `tfsdk:"description"`
+	NotificationType     types.String `tfsdk:"notification_type"`
+	EndpointURL          types.String `tfsdk:"endpoint_url"`
+	Secret               types.String `tfsdk:"secret"`
+	CustomHeaders        types.Map    `tfsdk:"custom_headers"`
+	Enabled              types.Bool   `tfsdk:"enabled"`
+	IsGlobal             types.Bool   `tfsdk:"is_global"`
+	GlobalEvents         types.Set    `tfsdk:"global_events"`
+	RetryCount           types.Int64  `tfsdk:"retry_count"`
+	RetryIntervalSeconds types.Int64  `tfsdk:"retry_interval_seconds"`
+	TimeoutSeconds       types.Int64  `tfsdk:"timeout_seconds"`
+}
+
+func newNotificationResource() resource.Resource {
+	return &notificationResource{}
+}
+
+func (r *notificationResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_notification"
+}
+
+func (r *notificationResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "A notification configuration (webhook or Lark robot).",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description:   "Notification ID (notif-...).",
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"name": schema.StringAttribute{
+				Description: "Letters, digits, dashes and underscores.",
+				Required:    true,
+				Validators:  []validator.String{stringvalidator.RegexMatches(namePattern, "must contain only letters, digits, dashes and underscores")},
+			},
+			"description": schema.StringAttribute{
+				Optional: true,
+				Computed: true,
+				Default:  stringdefault.StaticString(""),
+			},
+			"notification_type": schema.StringAttribute{
+				Description:   "webhook or lark_robot. Changing it forces a new notification.",
+				Required:      true,
+				Validators:    []validator.String{stringvalidator.OneOf("webhook", "lark_robot")},
+				PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"endpoint_url": schema.StringAttribute{
+				Required: true,
+			},
+			"secret": schema.StringAttribute{
+				Description: "Signing secret. The platform never returns it, so drift cannot be detected.",
+				Optional:    true,
+				Sensitive:   true,
+			},
+			"custom_headers": schema.MapAttribute{
+				Description:   "Extra HTTP headers. Defaults to Content-Type: application/json.",
+				ElementType:   types.StringType,
+				Optional:      true,
+				Computed:      true,
+				PlanModifiers: []planmodifier.Map{mapplanmodifier.UseStateForUnknown()},
+			},
+			"enabled": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				Default:  booldefault.StaticBool(true),
+			},
+			"is_global": schema.BoolAttribute{
+				Description: "Send for every workspace without an explicit association.",
+				Optional:    true,
+				Computed:    true,
+				Default:     booldefault.StaticBool(false),
+			},
+			"global_events": schema.SetAttribute{
+				Description:   "Events sent by a global notification. Defaults to task_completed and task_failed.",
+				ElementType:   types.StringType,
+				Optional:      true,
+				Computed:      true,
+				PlanModifiers: []planmodifier.Set{setplanmodifier.UseStateForUnknown()},
+			},
+			"retry_count": schema.Int64Attribute{
+				Optional:   true,
+				Computed:   true,
+				Default:    int64default.StaticInt64(3),
+				Validators: []validator.Int64{int64validator.Between(1, 10)},
+			},
+			"retry_interval_seconds": schema.Int64Attribute{
+				Optional:   true,
+				Computed:   true,
+				Default:    int64default.StaticInt64(30),
+				Validators: []validator.Int64{int64validator.AtLeast(1)},
+			},
+			"timeout_seconds": schema.Int64Attribute{
+				Optional:   true,
+				Computed:   true,
+				Default:    int64default.StaticInt64(30),
+				Validators: []validator.Int64{int64validator.Between(5, 120)},
+			},
+		},
+	}
+}
+
+func (r *notificationResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan notificationModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	create := apiclient.CreateNotificationRequest{
+		Name:                 plan.Name.ValueString(),
+		Description:          plan.Description.ValueString(),
+		NotificationType:     plan.NotificationType.ValueString(),
+		EndpointURL:          plan.EndpointURL.ValueString(),
+		Secret:               plan.Secret.ValueString(),
+		CustomHeaders:        mapToStrings(ctx, plan.CustomHeaders, &resp.Diagnostics),
+		IsGlobal:             plan.IsGlobal.ValueBool(),
+		GlobalEvents:         joinEvents(ctx, plan.GlobalEvents, &resp.Diagnostics),
+		RetryCount:           int(plan.RetryCount.ValueInt64()),
+		RetryIntervalSeconds: int(plan.RetryIntervalSeconds.ValueInt64()),
+		TimeoutSeconds:       int(plan.TimeoutSeconds.ValueInt64()),
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	n, err := r.client.CreateNotification(ctx, create)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to create notification", err.Error())
+		return
+	}
+	// 新建的通知总是启用的
+	if !plan.Enabled.ValueBool() {
+		enabled := false
+		n, err = r.client.UpdateNotification(ctx, n.NotificationID, apiclient.UpdateNotificationRequest{Enabled: &enabled})
+		if err != nil {
+			resp.Diagnostics.AddError("Failed to disable notification", err.Error())
+			return
+		}
+	}
+	setNotificationState(&plan, n)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *notificationResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state notificationModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	n, err := r.client.GetNotification(ctx, state.ID.ValueString())
+	if isNotFound(err) {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read notification", err.Error())
+		return
+	}
+	setNotificationState(&state, n)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+func (r *notificationResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state notificationModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	update := apiclient.UpdateNotificationRequest{}
+	if changed(plan.Name, state.Name) {
+		update.Name = stringPtr(plan.Name)
+	}
+	if changed(plan.Description, state.Description) {
+		update.Description = stringPtr(plan.Description)
+	}
+	if changed(plan.EndpointURL, state.EndpointURL) {
+		update.EndpointURL = stringPtr(plan.EndpointURL)
+	}
+	if changed(plan.Secret, state.Secret) {
+		// 从配置中移除时清除密钥
+		secret := plan.Secret.ValueString()
+		update.Secret = &secret
+	}
+	if !plan.CustomHeaders.IsUnknown() && changed(plan.CustomHeaders, state.CustomHeaders) {
+		headers := mapToStrings(ctx, plan.CustomHeaders, &resp.Diagnostics)
+		if headers == nil {
+			headers = map[string]string{}
+		}
+		update.CustomHeaders = &headers
+	}
+	if changed(plan.Enabled, state.Enabled) {
+		v := plan.Enabled.ValueBool()
+		update.Enabled = &v
+	}
+	if changed(plan.IsGlobal, state.IsGlobal) {
+		v := plan.IsGlobal.ValueBool()
+		update.IsGlobal = &v
+	}
+	if !plan.GlobalEvents.IsUnknown() && changed(plan.GlobalEvents, state.GlobalEvents) {
+		events := joinEvents(ctx, plan.GlobalEvents, &resp.Diagnostics)
+		update.GlobalEvents = &events
+	}
+	if changed(plan.RetryCount, state.RetryCount) {
+		v := int(plan.RetryCount.ValueInt64())
+		update.RetryCount = &v
+	}
+	if changed(plan.RetryIntervalSeconds, state.RetryIntervalSeconds) {
+		v := int(plan.RetryIntervalSeconds.ValueInt64())
+		update.RetryIntervalSeconds = &v
+	}
+	if changed(plan.TimeoutSeconds, state.TimeoutSeconds) {
+		v := int(plan.TimeoutSeconds.ValueInt64())
+		update.TimeoutSeconds = &v
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	n, err := r.client.UpdateNotification(ctx, state.ID.ValueString(), update)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to update notification", err.Error())
+		return
+	}
+	setNotificationState(&plan, n)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *notificationResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state notificationModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if err := r.client.DeleteNotification(ctx, state.ID.ValueString()); err != nil && !isNotFound(err) {
+		resp.Diagnostics.AddError("Failed to delete notification", err.Error())
+	}
+}
+
+func (r *notificationResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+}
+
+// setNotificationState secret 不会被返回，保留 state/配置中的值
+func setNotificationState(m *notificationModel, n *apiclient.Notification) {
+	m.ID = types.StringValue(n.NotificationID)
+	m.Name = types.StringValue(n.Name)
+	m.Description = types.StringValue(n.Description)
+	m.NotificationType = types.StringValue(n.NotificationType)
+	m.EndpointURL = types.StringValue(n.EndpointURL)
+	m.CustomHeaders = stringMap(n.CustomHeaders)
+	m.Enabled = types.BoolValue(n.Enabled)
+	m.IsGlobal = types.BoolValue(n.IsGlobal)
+	m.GlobalEvents = eventSet(n.GlobalEvents)
+	m.RetryCount = types.Int64Value(int64(n.RetryCount))
+	m.RetryIntervalSeconds = types.Int64Value(int64(n.RetryIntervalSeconds))
+	m.TimeoutSeconds = types.Int64Value(int64(n.TimeoutSeconds))
+}
@@ -0,0 +1,263 @@
+package tfprovider
+
+import (
+	"context"
+
+	"iac-platform/internal/apiclient"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64default"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ resource.Resource                = &runTaskResource{}
+	_ resource.ResourceWithImportState = &runTaskResource{}
+)
+
+// 与 models.RunTaskStage / models.RunTaskEnforcementLevel 保持一致
+var (
+	runTaskStages            = []string{"pre_plan", "post_plan", "pre_apply", "post_apply"}
+	runTaskEnforcementLevels = []string{"advisory", "mandatory"}
+)
+
+type runTaskResource struct {
+	clientHolder
+}
+
+type runTaskModel struct {
+	ID                     types.String `tfsdk:"id"`
+	Name                   types.String `tfsdk:"name"`
+	Description            types.String `tfsdk:"description"`
+	EndpointURL            types.String `tfsdk:"endpoint_url"`
+	HMACKey                types.String `tfsdk:"hmac_key"`
+	Enabled                types.Bool   `tfsdk:"enabled"`
+	TimeoutSeconds         types.Int64  `tfsdk:"timeout_seconds"`
+	MaxRunSeconds          types.Int64  `tfsdk:"max_run_seconds"`
+	IsGlobal               types.Bool   `tfsdk:"is_global"`
+	GlobalStages           types.String `tfsdk:"global_stages"`
+	GlobalEnforcementLevel types.String `tfsdk:"global_enforcement_level"`
+}
+
+func newRunTaskResource() resource.Resource {
+	return &runTaskResource{}
+}
+
+func (r *runTaskResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_run_task"
+}
+
+func (r *runTaskResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "A run task: an external HTTP service called at a stage of every run.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description:   "Run task ID (rt-...).",
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"name": schema.StringAttribute{
+				Description: "Letters, digits, dashes and underscores.",
+				Required:    true,
+				Validators:  []validator.String{stringvalidator.RegexMatches(namePattern, "must contain only letters, digits, dashes and underscores")},
+			},
+			"description": schema.StringAttribute{
+				Optional: true,
+				Computed: true,
+				Default:  stringdefault.StaticString(""),
+			},
+			"endpoint_url": schema.StringAttribute{
+				Required: true,
+			},
+			"hmac_key": schema.StringAttribute{
+				Description: "Key used to sign requests. The platform never returns it, so drift cannot be detected.",
+				Optional:    true,
+				Sensitive:   true,
+			},
+			"enabled": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				Default:  booldefault.StaticBool(true),
+			},
+			"timeout_seconds": schema.Int64Attribute{
+				Optional:   true,
+				Computed:   true,
+				Default:    int64default.StaticInt64(600),
+				Validators: []validator.Int64{int64validator.Between(60, 600)},
+			},
+			"max_run_seconds": schema.Int64Attribute{
+				Optional:   true,
+				Computed:   true,
+				Default:    int64default.StaticInt64(3600),
+				Validators: []validator.Int64{int64validator.Between(60, 3600)},
+			},
+			"is_global": schema.BoolAttribute{
+				Description: "Run for every workspace without an explicit association.",
+				Optional:    true,
+				Computed:    true,
+				Default:     booldefault.StaticBool(false),
+			},
+			"global_stages": schema.StringAttribute{
+				Description:   "Comma separated stages for a global run task. Defaults to post_plan.",
+				Optional:      true,
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"global_enforcement_level": schema.StringAttribute{
+				Description:   "advisory or mandatory for a global run task. Defaults to advisory.",
+				Optional:      true,
+				Computed:      true,
+				Validators:    []validator.String{stringvalidator.OneOf(runTaskEnforcementLevels...)},
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+		},
+	}
+}
+
+func (r *runTaskResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan runTaskModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	rt, err := r.client.CreateRunTask(ctx, apiclient.CreateRunTaskRequest{
+		Name:                   plan.Name.ValueString(),
+		Description:            plan.Description.ValueString(),
+		EndpointURL:            plan.EndpointURL.ValueString(),
+		HMACKey:                plan.HMACKey.ValueString(),
+		TimeoutSeconds:         int(plan.TimeoutSeconds.ValueInt64()),
+		MaxRunSeconds:          int(plan.MaxRunSeconds.ValueInt64()),
+		IsGlobal:               plan.IsGlobal.ValueBool(),
+		GlobalStages:           plan.GlobalStages.ValueString(),
+		GlobalEnforcementLevel: plan.GlobalEnforcementLevel.ValueString(),
+	})
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to create run task", err.Error())
+		return
+	}
+	// 新建的 Run Task 总是启用的
+	if !plan.Enabled.ValueBool() {
+		enabled := false
+		rt, err = r.client.UpdateRunTask(ctx, rt.RunTaskID, apiclient.UpdateRunTaskRequest{Enabled: &enabled})
+		if err != nil {
+			resp.Diagnostics.AddError("Failed to disable run task", err.Error())
+			return
+		}
+	}
+	setRunTaskState(&plan, rt)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *runTaskResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state runTaskModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	rt, err := r.client.GetRunTask(ctx, state.ID.ValueString())
+	if isNotFound(err) {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read run task", err.Error())
+		return
+	}
+	setRunTaskState(&state, rt)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+func (r *runTaskResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state runTaskModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	update := apiclient.UpdateRunTaskRequest{}
+	if changed(plan.Name, state.Name) {
+		update.Name = stringPtr(plan.Name)
+	}
+	if changed(plan.Description, state.Description) {
+		update.Description = stringPtr(plan.Description)
+	}
+	if changed(plan.EndpointURL, state.EndpointURL) {
+		update.EndpointURL = stringPtr(plan.EndpointURL)
+	}
+	if changed(plan.HMACKey, state.HMACKey) {
+		// 从配置中移除时清除密钥
+		key := plan.HMACKey.ValueString()
+		update.HMACKey = &key
+	}
+	if changed(plan.Enabled, state.Enabled) {
+		v := plan.Enabled.ValueBool()
+		update.Enabled = &v
+	}
+	if changed(plan.TimeoutSeconds, state.TimeoutSeconds) {
+		v := int(plan.TimeoutSeconds.ValueInt64())
+		update.TimeoutSeconds = &v
+	}
+	if changed(plan.MaxRunSeconds, state.MaxRunSeconds) {
+		v := int(plan.MaxRunSeconds.ValueInt64())
+		update.MaxRunSeconds = &v
+	}
+	if changed(plan.IsGlobal, state.IsGlobal) {
+		v := plan.IsGlobal.ValueBool()
+		update.IsGlobal = &v
+	}
+	if !plan.GlobalStages.IsUnknown() && changed(plan.GlobalStages, state.GlobalStages) {
+		update.GlobalStages = stringPtr(plan.GlobalStages)
+	}
+	if !plan.GlobalEnforcementLevel.IsUnknown() && changed(plan.GlobalEnforcementLevel, state.GlobalEnforcementLevel) {
+		update.GlobalEnforcementLevel = stringPtr(plan.GlobalEnforcementLevel)
+	}
+
+	rt, err := r.client.UpdateRunTask(ctx, state.ID.ValueString(), update)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to update run task", err.Error())
+		return
+	}
+	setRunTaskState(&plan, rt)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *runTaskResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state runTaskModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if err := r.client.DeleteRunTask(ctx, state.ID.ValueString()); err != nil && !isNotFound(err) {
+		resp.Diagnostics.AddError("Failed to delete run task", err.Error())
+	}
+}
+
+func (r *runTaskResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+}
+
+// setRunTaskState hmac_key 不会被返回，保留 state/配置中的值
+func setRunTaskState(m *runTaskModel, rt *apiclient.RunTask) {
+	m.ID = types.StringValue(rt.RunTaskID)
+	m.Name = types.StringValue(rt.Name)
+	m.Description = types.StringValue(rt.Description)
+	m.EndpointURL = types.StringValue(rt.EndpointURL)
+	m.Enabled = types.BoolValue(rt.Enabled)
+	m.TimeoutSeconds = types.Int64Value(int64(rt.TimeoutSeconds))
+	m.MaxRunSeconds = types.Int64Value(int64(rt.MaxRunSeconds))
+	m.IsGlobal = types.BoolValue(rt.IsGlobal)
+	m.GlobalStages = types.StringValue(rt.GlobalStages)
+	m.GlobalEnforcementLevel = types.StringValue(rt.GlobalEnforcementLevel)
+}
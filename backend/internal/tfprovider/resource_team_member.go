@@ -0,0 +1,146 @@
+package tfprovider
+
+import (
+	"context"
+
+	"iac-platform/internal/apiclient"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ resource.Resource                = &teamMemberResource{}
+	_ resource.ResourceWithImportState = &teamMemberResource{}
+)
+
+// teamMemberResource 成员角色没有更新接口，变更角色会先移除再重新加入
+type teamMemberResource struct {
+	clientHolder
+}
+
+type teamMemberModel struct {
+	ID     types.String `tfsdk:"id"`
+	TeamID types.String `tfsdk:"team_id"`
+	UserID types.String `tfsdk:"user_id"`
+	Role   types.String `tfsdk:"role"`
+}
+
+func newTeamMemberResource() resource.Resource {
+	return &teamMemberResource{}
+}
+
+func (r *teamMemberResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_team_member"
+}
+
+func (r *teamMemberResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Membership of a user in a team.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description:   "<team_id>/<user_id>.",
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"team_id": schema.StringAttribute{
+				Required:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"user_id": schema.StringAttribute{
+				Required:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"role": schema.StringAttribute{
+				Description:   "MEMBER or MAINTAINER. Changing it re-adds the member.",
+				Optional:      true,
+				Computed:      true,
+				Default:       stringdefault.StaticString("MEMBER"),
+				Validators:    []validator.String{stringvalidator.OneOf("MEMBER", "MAINTAINER")},
+				PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+		},
+	}
+}
+
+func (r *teamMemberResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan teamMemberModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	teamID, userID := plan.TeamID.ValueString(), plan.UserID.ValueString()
+	if err := r.client.AddTeamMember(ctx, teamID, userID, plan.Role.ValueString()); err != nil {
+		resp.Diagnostics.AddError("Failed to add team member", err.Error())
+		return
+	}
+	member, err := r.client.GetTeamMember(ctx, teamID, userID)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read team member", err.Error())
+		return
+	}
+	setTeamMemberState(&plan, member)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *teamMemberResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state teamMemberModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	member, err := r.client.GetTeamMember(ctx, state.TeamID.ValueString(), state.UserID.ValueString())
+	if isNotFound(err) {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read team member", err.Error())
+		return
+	}
+	setTeamMemberState(&state, member)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+// Update 所有参数都会触发重建，不会被调用
+func (r *teamMemberResource) Update(_ context.Context, _ resource.UpdateRequest, resp *resource.UpdateResponse) {
+	resp.Diagnostics.AddError("Team members cannot be updated", "every team member attribute forces replacement")
+}
+
+func (r *teamMemberResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state teamMemberModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	err := r.client.RemoveTeamMember(ctx, state.TeamID.ValueString(), state.UserID.ValueString())
+	if err != nil && !isNotFound(err) {
+		resp.Diagnostics.AddError("Failed to remove team member", err.Error())
+	}
+}
+
+// ImportState 导入 ID 格式为 <team_id>/<user_id>
+func (r *teamMemberResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	parts := splitImportID(req.ID, 2, "<team_id>/<user_id>", &resp.Diagnostics)
+	if parts == nil {
+		return
+	}
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("team_id"), parts[0])...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("user_id"), parts[1])...)
+}
+
+func setTeamMemberState(m *teamMemberModel, member *apiclient.TeamMember) {
+	// 成员列表不一定带 team_id，沿用 state 中的值
+	m.ID = types.StringValue(m.TeamID.ValueString() + "/" + member.UserID)
+	m.UserID = types.StringValue(member.UserID)
+	m.Role = types.StringValue(member.Role)
+}
@@ -0,0 +1,291 @@
+package tfprovider
+
+import (
+	"context"
+	"fmt"
+
+	"iac-platform/internal/apiclient"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/mapvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ resource.Resource                = &workspaceResource{}
+	_ resource.ResourceWithImportState = &workspaceResource{}
+)
+
+type workspaceResource struct {
+	clientHolder
+}
+
+type workspaceModel struct {
+	ID               types.String `tfsdk:"id"`
+	Name             types.String `tfsdk:"name"`
+	Description      types.String `tfsdk:"description"`
+	ExecutionMode    types.String `tfsdk:"execution_mode"`
+	AgentPoolID      types.Int64  `tfsdk:"agent_pool_id"`
+	K8sConfigID      types.Int64  `tfsdk:"k8s_config_id"`
+	AutoApply        types.Bool   `tfsdk:"auto_apply"`
+	PlanOnly         types.Bool   `tfsdk:"plan_only"`
+	TerraformVersion types.String `tfsdk:"terraform_version"`
+	Workdir          types.String `tfsdk:"workdir"`
+	StateBackend     types.String `tfsdk:"state_backend"`
+	Tags             types.Map    `tfsdk:"tags"`
+}
+
+func newWorkspaceResource() resource.Resource {
+	return &workspaceResource{}
+}
+
+func (r *workspaceResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_workspace"
+}
+
+func (r *workspaceResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "A workspace.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description:   "Workspace ID (ws-...).",
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"name": schema.StringAttribute{
+				Required:   true,
+				Validators: []validator.String{stringvalidator.LengthAtLeast(1)},
+			},
+			"description": schema.StringAttribute{
+				Optional: true,
+				Computed: true,
+				Default:  stringdefault.StaticString(""),
+			},
+			"execution_mode": schema.StringAttribute{
+				Description: "local, agent (requires agent_pool_id) or k8s (requires k8s_config_id).",
+				Optional:    true,
+				Computed:    true,
+				Default:     stringdefault.StaticString("local"),
+				Validators:  []validator.String{stringvalidator.OneOf("local", "agent", "k8s")},
+			},
+			// 接口无法清空这两个字段，从配置中移除时沿用已有值
+			"agent_pool_id": schema.Int64Attribute{
+				Optional:      true,
+				Computed:      true,
+				PlanModifiers: []planmodifier.Int64{int64planmodifier.UseStateForUnknown()},
+			},
+			"k8s_config_id": schema.Int64Attribute{
+				Optional:      true,
+				Computed:      true,
+				PlanModifiers: []planmodifier.Int64{int64planmodifier.UseStateForUnknown()},
+			},
+			"auto_apply": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				Default:  booldefault.StaticBool(false),
+			},
+			"plan_only": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				Default:  booldefault.StaticBool(false),
+			},
+			"terraform_version": schema.StringAttribute{
+				Optional: true,
+				Computed: true,
+				Default:  stringdefault.StaticString("latest"),
+			},
+			"workdir": schema.StringAttribute{
+				Optional: true,
+				Computed: true,
+				Default:  stringdefault.StaticString("/workspace"),
+			},
+			"state_backend": schema.StringAttribute{
+				Description:   "State backend. Changing it forces a new workspace.",
+				Optional:      true,
+				Computed:      true,
+				Default:       stringdefault.StaticString("local"),
+				PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"tags": schema.MapAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				Validators:  []validator.Map{mapvalidator.SizeAtLeast(1)},
+			},
+		},
+	}
+}
+
+func (r *workspaceResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan workspaceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ws, err := r.client.CreateWorkspace(ctx, apiclient.CreateWorkspaceRequest{
+		Name:             plan.Name.ValueString(),
+		Description:      plan.Description.ValueString(),
+		ExecutionMode:    plan.ExecutionMode.ValueString(),
+		AgentPoolID:      uintPtr(plan.AgentPoolID),
+		K8sConfigID:      uintPtr(plan.K8sConfigID),
+		AutoApply:        plan.AutoApply.ValueBool(),
+		PlanOnly:         plan.PlanOnly.ValueBool(),
+		TerraformVersion: plan.TerraformVersion.ValueString(),
+		Workdir:          plan.Workdir.ValueString(),
+		StateBackend:     plan.StateBackend.ValueString(),
+		Tags:             tagsToAPI(ctx, plan.Tags, &resp.Diagnostics),
+	})
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to create workspace", err.Error())
+		return
+	}
+
+	// 创建接口返回的字段不全，重新读取详情
+	ws, err = r.client.GetWorkspace(ctx, ws.WorkspaceID)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read workspace", err.Error())
+		return
+	}
+	setWorkspaceState(&plan, ws)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *workspaceResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state workspaceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ws, err := r.client.GetWorkspace(ctx, state.ID.ValueString())
+	if isNotFound(err) {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read workspace", err.Error())
+		return
+	}
+	setWorkspaceState(&state, ws)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+func (r *workspaceResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state workspaceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	update := apiclient.UpdateWorkspaceRequest{}
+	if changed(plan.Name, state.Name) {
+		update.Name = stringPtr(plan.Name)
+	}
+	if changed(plan.Description, state.Description) {
+		update.Description = stringPtr(plan.Description)
+	}
+	if changed(plan.ExecutionMode, state.ExecutionMode) {
+		update.ExecutionMode = stringPtr(plan.ExecutionMode)
+	}
+	if !plan.AgentPoolID.IsUnknown() && changed(plan.AgentPoolID, state.AgentPoolID) {
+		update.AgentPoolID = uintPtr(plan.AgentPoolID)
+	}
+	if !plan.K8sConfigID.IsUnknown() && changed(plan.K8sConfigID, state.K8sConfigID) {
+		update.K8sConfigID = uintPtr(plan.K8sConfigID)
+	}
+	if changed(plan.AutoApply, state.AutoApply) {
+		v := plan.AutoApply.ValueBool()
+		update.AutoApply = &v
+	}
+	if changed(plan.PlanOnly, state.PlanOnly) {
+		v := plan.PlanOnly.ValueBool()
+		update.PlanOnly = &v
+	}
+	if changed(plan.TerraformVersion, state.TerraformVersion) {
+		update.TerraformVersion = stringPtr(plan.TerraformVersion)
+	}
+	if changed(plan.Workdir, state.Workdir) {
+		update.Workdir = stringPtr(plan.Workdir)
+	}
+	if changed(plan.Tags, state.Tags) {
+		// 删除全部标签时提交空对象
+		tags := tagsToAPI(ctx, plan.Tags, &resp.Diagnostics)
+		if tags == nil {
+			tags = map[string]interface{}{}
+		}
+		update.Tags = &tags
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ws, err := r.client.UpdateWorkspace(ctx, state.ID.ValueString(), update)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to update workspace", err.Error())
+		return
+	}
+	plan.ID = state.ID
+	setWorkspaceState(&plan, ws)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *workspaceResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state workspaceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if err := r.client.DeleteWorkspace(ctx, state.ID.ValueString()); err != nil && !isNotFound(err) {
+		resp.Diagnostics.AddError("Failed to delete workspace", err.Error())
+	}
+}
+
+func (r *workspaceResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+}
+
+func setWorkspaceState(m *workspaceModel, ws *apiclient.Workspace) {
+	m.ID = types.StringValue(ws.WorkspaceID)
+	m.Name = types.StringValue(ws.Name)
+	m.Description = types.StringValue(ws.Description)
+	m.ExecutionMode = types.StringValue(ws.ExecutionMode)
+	m.AgentPoolID = uintValue(ws.AgentPoolID)
+	m.K8sConfigID = uintValue(ws.K8sConfigID)
+	m.AutoApply = types.BoolValue(ws.AutoApply)
+	m.PlanOnly = types.BoolValue(ws.PlanOnly)
+	m.TerraformVersion = types.StringValue(ws.TerraformVersion)
+	m.Workdir = types.StringValue(ws.Workdir)
+	m.StateBackend = types.StringValue(ws.StateBackend)
+	if len(ws.Tags) == 0 {
+		m.Tags = types.MapNull(types.StringType)
+	} else {
+		tags := make(map[string]string, len(ws.Tags))
+		for k, v := range ws.Tags {
+			tags[k] = fmt.Sprint(v)
+		}
+		m.Tags = stringMap(tags)
+	}
+}
+
+func tagsToAPI(ctx context.Context, m types.Map, diags *diag.Diagnostics) map[string]interface{} {
+	tags := mapToStrings(ctx, m, diags)
+	if tags == nil {
+		return nil
+	}
+	out := make(map[string]interface{}, len(tags))
+	for k, v := range tags {
+		out[k] = v
+	}
+	return out
+}
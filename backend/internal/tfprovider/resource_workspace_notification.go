@@ -0,0 +1,186 @@
+package tfprovider
+
+import (
+	"context"
+
+	"iac-platform/internal/apiclient"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/setvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/setplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ resource.Resource                = &workspaceNotificationResource{}
+	_ resource.ResourceWithImportState = &workspaceNotificationResource{}
+)
+
+type workspaceNotificationResource struct {
+	clientHolder
+}
+
+type workspaceNotificationModel struct {
+	ID             types.String `tfsdk:"id"`
+	WorkspaceID    types.String `tfsdk:"workspace_id"`
+	NotificationID types.String `tfsdk:"notification_id"`
+	Events         types.Set    `tfsdk:"events"`
+	Enabled        types.Bool   `tfsdk:"enabled"`
+}
+
+func newWorkspaceNotificationResource() resource.Resource {
+	return &workspaceNotificationResource{}
+}
+
+func (r *workspaceNotificationResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_workspace_notification"
+}
+
+func (r *workspaceNotificationResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Associates a notification with a workspace.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description:   "Association ID (wn-...).",
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"workspace_id": schema.StringAttribute{
+				Required:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"notification_id": schema.StringAttribute{
+				Required:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"events": schema.SetAttribute{
+				Description:   "Events that trigger the notification. Defaults to task_completed and task_failed.",
+				ElementType:   types.StringType,
+				Optional:      true,
+				Computed:      true,
+				Validators:    []validator.Set{setvalidator.SizeAtLeast(1)},
+				PlanModifiers: []planmodifier.Set{setplanmodifier.UseStateForUnknown()},
+			},
+			"enabled": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				Default:  booldefault.StaticBool(true),
+			},
+		},
+	}
+}
+
+func (r *workspaceNotificationResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan workspaceNotificationModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	workspaceID := plan.WorkspaceID.ValueString()
+	events := joinEvents(ctx, plan.Events, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	wn, err := r.client.CreateWorkspaceNotification(ctx, workspaceID, plan.NotificationID.ValueString(), events)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to associate notification", err.Error())
+		return
+	}
+	if !plan.Enabled.ValueBool() {
+		enabled := false
+		wn, err = r.client.UpdateWorkspaceNotification(ctx, workspaceID, wn.WorkspaceNotificationID,
+			apiclient.UpdateWorkspaceNotificationRequest{Enabled: &enabled})
+		if err != nil {
+			resp.Diagnostics.AddError("Failed to disable notification association", err.Error())
+			return
+		}
+	}
+	setWorkspaceNotificationState(&plan, wn)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *workspaceNotificationResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state workspaceNotificationModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	wn, err := r.client.GetWorkspaceNotification(ctx, state.WorkspaceID.ValueString(), state.ID.ValueString())
+	if isNotFound(err) {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read notification association", err.Error())
+		return
+	}
+	setWorkspaceNotificationState(&state, wn)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+func (r *workspaceNotificationResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state workspaceNotificationModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	update := apiclient.UpdateWorkspaceNotificationRequest{}
+	if !plan.Events.IsUnknown() && changed(plan.Events, state.Events) {
+		events := joinEvents(ctx, plan.Events, &resp.Diagnostics)
+		update.Events = &events
+	}
+	if changed(plan.Enabled, state.Enabled) {
+		v := plan.Enabled.ValueBool()
+		update.Enabled = &v
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	wn, err := r.client.UpdateWorkspaceNotification(ctx, state.WorkspaceID.ValueString(), state.ID.ValueString(), update)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to update notification association", err.Error())
+		return
+	}
+	setWorkspaceNotificationState(&plan, wn)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *workspaceNotificationResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state workspaceNotificationModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	err := r.client.DeleteWorkspaceNotification(ctx, state.WorkspaceID.ValueString(), state.ID.ValueString())
+	if err != nil && !isNotFound(err) {
+		resp.Diagnostics.AddError("Failed to remove notification association", err.Error())
+	}
+}
+
+// ImportState 导入 ID 格式为 <workspace_id>/<workspace_notification_id>
+func (r *workspaceNotificationResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	parts := splitImportID(req.ID, 2, "<workspace_id>/<workspace_notification_id>", &resp.Diagnostics)
+	if parts == nil {
+		return
+	}
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("workspace_id"), parts[0])...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), parts[1])...)
+}
+
+func setWorkspaceNotificationState(m *workspaceNotificationModel, wn *apiclient.WorkspaceNotification) {
+	m.ID = types.StringValue(wn.WorkspaceNotificationID)
+	m.NotificationID = types.StringValue(wn.NotificationID)
+	m.Events = eventSet(wn.Events)
+	m.Enabled = types.BoolValue(wn.Enabled)
+}
@@ -0,0 +1,191 @@
+package tfprovider
+
+import (
+	"context"
+
+	"iac-platform/internal/apiclient"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ resource.Resource                = &workspaceRunTaskResource{}
+	_ resource.ResourceWithImportState = &workspaceRunTaskResource{}
+)
+
+type workspaceRunTaskResource struct {
+	clientHolder
+}
+
+type workspaceRunTaskModel struct {
+	ID               types.String `tfsdk:"id"`
+	WorkspaceID      types.String `tfsdk:"workspace_id"`
+	RunTaskID        types.String `tfsdk:"run_task_id"`
+	Stage            types.String `tfsdk:"stage"`
+	EnforcementLevel types.String `tfsdk:"enforcement_level"`
+	Enabled          types.Bool   `tfsdk:"enabled"`
+}
+
+func newWorkspaceRunTaskResource() resource.Resource {
+	return &workspaceRunTaskResource{}
+}
+
+func (r *workspaceRunTaskResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_workspace_run_task"
+}
+
+func (r *workspaceRunTaskResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Associates a run task with a workspace at one stage.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description:   "Association ID (wrt-...).",
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"workspace_id": schema.StringAttribute{
+				Required:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"run_task_id": schema.StringAttribute{
+				Required:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"stage": schema.StringAttribute{
+				Description: "pre_plan, post_plan, pre_apply or post_apply.",
+				Required:    true,
+				Validators:  []validator.String{stringvalidator.OneOf(runTaskStages...)},
+			},
+			"enforcement_level": schema.StringAttribute{
+				Description: "advisory or mandatory.",
+				Optional:    true,
+				Computed:    true,
+				Default:     stringdefault.StaticString("advisory"),
+				Validators:  []validator.String{stringvalidator.OneOf(runTaskEnforcementLevels...)},
+			},
+			"enabled": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				Default:  booldefault.StaticBool(true),
+			},
+		},
+	}
+}
+
+func (r *workspaceRunTaskResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan workspaceRunTaskModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	workspaceID := plan.WorkspaceID.ValueString()
+	wrt, err := r.client.CreateWorkspaceRunTask(ctx, workspaceID, apiclient.CreateWorkspaceRunTaskRequest{
+		RunTaskID:        plan.RunTaskID.ValueString(),
+		Stage:            plan.Stage.ValueString(),
+		EnforcementLevel: plan.EnforcementLevel.ValueString(),
+	})
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to associate run task", err.Error())
+		return
+	}
+	if !plan.Enabled.ValueBool() {
+		enabled := false
+		wrt, err = r.client.UpdateWorkspaceRunTask(ctx, workspaceID, wrt.WorkspaceRunTaskID,
+			apiclient.UpdateWorkspaceRunTaskRequest{Enabled: &enabled})
+		if err != nil {
+			resp.Diagnostics.AddError("Failed to disable run task association", err.Error())
+			return
+		}
+	}
+	setWorkspaceRunTaskState(&plan, wrt)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *workspaceRunTaskResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state workspaceRunTaskModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	wrt, err := r.client.GetWorkspaceRunTask(ctx, state.WorkspaceID.ValueString(), state.ID.ValueString())
+	if isNotFound(err) {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read run task association", err.Error())
+		return
+	}
+	setWorkspaceRunTaskState(&state, wrt)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+func (r *workspaceRunTaskResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state workspaceRunTaskModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	update := apiclient.UpdateWorkspaceRunTaskRequest{}
+	if changed(plan.Stage, state.Stage) {
+		update.Stage = stringPtr(plan.Stage)
+	}
+	if changed(plan.EnforcementLevel, state.EnforcementLevel) {
+		update.EnforcementLevel = stringPtr(plan.EnforcementLevel)
+	}
+	if changed(plan.Enabled, state.Enabled) {
+		v := plan.Enabled.ValueBool()
+		update.Enabled = &v
+	}
+
+	wrt, err := r.client.UpdateWorkspaceRunTask(ctx, state.WorkspaceID.ValueString(), state.ID.ValueString(), update)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to update run task association", err.Error())
+		return
+	}
+	setWorkspaceRunTaskState(&plan, wrt)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *workspaceRunTaskResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state workspaceRunTaskModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	err := r.client.DeleteWorkspaceRunTask(ctx, state.WorkspaceID.ValueString(), state.ID.ValueString())
+	if err != nil && !isNotFound(err) {
+		resp.Diagnostics.AddError("Failed to remove run task association", err.Error())
+	}
+}
+
+// ImportState 导入 ID 格式为 <workspace_id>/<workspace_run_task_id>
+func (r *workspaceRunTaskResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	parts := splitImportID(req.ID, 2, "<workspace_id>/<workspace_run_task_id>", &resp.Diagnostics)
+	if parts == nil {
+		return
+	}
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("workspace_id"), parts[0])...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), parts[1])...)
+}
+
+func setWorkspaceRunTaskState(m *workspaceRunTaskModel, wrt *apiclient.WorkspaceRunTask) {
+	m.ID = types.StringValue(wrt.WorkspaceRunTaskID)
+	m.RunTaskID = types.StringValue(wrt.RunTaskID)
+	m.Stage = types.StringValue(wrt.Stage)
+	m.EnforcementLevel = types.StringValue(wrt.EnforcementLevel)
+	m.Enabled = types.BoolValue(wrt.Enabled)
+}
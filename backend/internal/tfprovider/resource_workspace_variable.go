@@ -0,0 +1,219 @@
+package tfprovider
+
+import (
+	"context"
+
+	"iac-platform/internal/apiclient"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ resource.Resource                = &workspaceVariableResource{}
+	_ resource.ResourceWithImportState = &workspaceVariableResource{}
+)
+
+type workspaceVariableResource struct {
+	clientHolder
+}
+
+type workspaceVariableModel struct {
+	ID           types.String `tfsdk:"id"`
+	WorkspaceID  types.String `tfsdk:"workspace_id"`
+	Key          types.String `tfsdk:"key"`
+	Value        types.String `tfsdk:"value"`
+	VariableType types.String `tfsdk:"variable_type"`
+	ValueFormat  types.String `tfsdk:"value_format"`
+	Sensitive    types.Bool   `tfsdk:"sensitive"`
+	Description  types.String `tfsdk:"description"`
+	Version      types.Int64  `tfsdk:"version"`
+}
+
+func newWorkspaceVariableResource() resource.Resource {
+	return &workspaceVariableResource{}
+}
+
+func (r *workspaceVariableResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_workspace_variable"
+}
+
+func (r *workspaceVariableResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "A Terraform or environment variable of a workspace.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description:   "Variable ID (var-...).",
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"workspace_id": schema.StringAttribute{
+				Required:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"key": schema.StringAttribute{
+				Required:   true,
+				Validators: []validator.String{stringvalidator.LengthAtLeast(1)},
+			},
+			"value": schema.StringAttribute{
+				Description: "Variable value. The platform never returns sensitive values, so they are kept from the configuration.",
+				Required:    true,
+				Sensitive:   true,
+			},
+			"variable_type": schema.StringAttribute{
+				Description: "terraform or environment.",
+				Optional:    true,
+				Computed:    true,
+				Default:     stringdefault.StaticString(apiclient.VariableTypeTerraform),
+				Validators:  []validator.String{stringvalidator.OneOf(apiclient.VariableTypeTerraform, apiclient.VariableTypeEnvironment)},
+			},
+			"value_format": schema.StringAttribute{
+				Description: "string, hcl (Terraform variables only) or secret_ref (value is a vault://, env:// or file:// reference resolved by the agent at run time).",
+				Optional:    true,
+				Computed:    true,
+				Default:     stringdefault.StaticString(apiclient.ValueFormatString),
+				Validators:  []validator.String{stringvalidator.OneOf(apiclient.ValueFormatString, apiclient.ValueFormatHCL, apiclient.ValueFormatSecretRef)},
+			},
+			"sensitive": schema.BoolAttribute{
+				Optional: true,
+				Computed: true,
+				Default:  booldefault.StaticBool(false),
+			},
+			"description": schema.StringAttribute{
+				Optional: true,
+				Computed: true,
+				Default:  stringdefault.StaticString(""),
+			},
+			"version": schema.Int64Attribute{
+				Description: "Current version of the variable, used for optimistic locking on update.",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func (r *workspaceVariableResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan workspaceVariableModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	v, err := r.client.CreateVariable(ctx, plan.WorkspaceID.ValueString(), apiclient.VariableInput{
+		Key:          plan.Key.ValueString(),
+		Value:        plan.Value.ValueString(),
+		VariableType: plan.VariableType.ValueString(),
+		ValueFormat:  plan.ValueFormat.ValueString(),
+		Sensitive:    plan.Sensitive.ValueBool(),
+		Description:  plan.Description.ValueString(),
+	})
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to create workspace variable", err.Error())
+		return
+	}
+	setWorkspaceVariableState(&plan, v)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *workspaceVariableResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state workspaceVariableModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	v, err := r.client.GetVariable(ctx, state.WorkspaceID.ValueString(), state.ID.ValueString())
+	if isNotFound(err) {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read workspace variable", err.Error())
+		return
+	}
+	setWorkspaceVariableState(&state, v)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+func (r *workspaceVariableResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state workspaceVariableModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// 以 state 中的版本号提交，平台侧被他人修改过时返回 409，需要先 refresh
+	update := apiclient.VariableUpdate{Version: int(state.Version.ValueInt64())}
+	if changed(plan.Key, state.Key) {
+		update.Key = stringPtr(plan.Key)
+	}
+	if changed(plan.Value, state.Value) {
+		update.Value = stringPtr(plan.Value)
+	}
+	if changed(plan.VariableType, state.VariableType) {
+		update.VariableType = stringPtr(plan.VariableType)
+	}
+	if changed(plan.ValueFormat, state.ValueFormat) {
+		update.ValueFormat = stringPtr(plan.ValueFormat)
+	}
+	if changed(plan.Sensitive, state.Sensitive) {
+		v := plan.Sensitive.ValueBool()
+		update.Sensitive = &v
+	}
+	if changed(plan.Description, state.Description) {
+		update.Description = stringPtr(plan.Description)
+	}
+
+	v, err := r.client.UpdateVariable(ctx, state.WorkspaceID.ValueString(), state.ID.ValueString(), update)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to update workspace variable", err.Error())
+		return
+	}
+	setWorkspaceVariableState(&plan, v)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *workspaceVariableResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state workspaceVariableModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	err := r.client.DeleteVariable(ctx, state.WorkspaceID.ValueString(), state.ID.ValueString())
+	if err != nil && !isNotFound(err) {
+		resp.Diagnostics.AddError("Failed to delete workspace variable", err.Error())
+	}
+}
+
+// ImportState 导入 ID 格式为 <workspace_id>/<variable_id>；敏感变量导入后需在配置中提供 value
+func (r *workspaceVariableResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	parts := splitImportID(req.ID, 2, "<workspace_id>/<variable_id>", &resp.Diagnostics)
+	if parts == nil {
+		return
+	}
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("workspace_id"), parts[0])...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), parts[1])...)
+}
+
+func setWorkspaceVariableState(m *workspaceVariableModel, v *apiclient.Variable) {
+	m.ID = types.StringValue(v.VariableID)
+	m.Key = types.StringValue(v.Key)
+	m.VariableType = types.StringValue(v.VariableType)
+	m.ValueFormat = types.StringValue(v.ValueFormat)
+	m.Sensitive = types.BoolValue(v.Sensitive)
+	m.Description = types.StringValue(v.Description)
+	m.Version = types.Int64Value(int64(v.Version))
+	// 敏感变量不返回值，保留配置中的值
+	if !v.Sensitive {
+		m.Value = types.StringValue(v.Value)
+	}
+}
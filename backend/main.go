@@ -22,6 +22,7 @@ import (
 	"iac-platform/internal/websocket"
 	"iac-platform/internal/leaderelection"
 	"iac-platform/internal/pgpubsub"
+	"iac-platform/migrations"
 	"iac-platform/services"
 
 	"github.com/gin-gonic/gin"
@@ -60,12 +61,23 @@ func main() {
 	// 加载配置
 	cfg := config.Load()
 
+	// migrate 子命令：iac-platform migrate status|up|down [n]
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrateCommand(cfg, os.Args[2:]))
+	}
+
 	// 初始化数据库
 	db, err := database.Initialize(cfg.Database)
 	if err != nil {
 		log.Fatal("Failed to initialize database:", err)
 	}
 
+	// 检查数据库 schema 版本：默认执行待执行的迁移（多副本通过 advisory lock 串行），
+	// MIGRATE_ON_START=false 时只校验；数据库版本比二进制新或迁移被修改时拒绝启动
+	if err := database.EnsureSchema(context.Background(), db, migrations.FS, os.Getenv("MIGRATE_ON_START") != "false"); err != nil {
+		log.Fatal("Database schema check failed: ", err)
+	}
+
 	// 创建可被 shutdown 信号取消的顶层 context
 	shutdownCtx, shutdownCancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer shutdownCancel()
@@ -190,6 +202,18 @@ func main() {
 	// 初始化 Drift 检测调度器
 	driftScheduler := services.NewDriftCheckScheduler(db, queueManager)
 
+	// 初始化审计事件导出 worker（SIEM 投递）
+	auditExportWorker := services.NewAuditExportWorker(db)
+
+	// 初始化 Manifest 批量发布 worker（发布进度保存在数据库中，leader 切换后继续推进）
+	manifestRolloutWorker := services.NewManifestRolloutWorker(db, queueManager)
+
+	// 初始化 Stack 执行 worker（按依赖顺序协同 plan/apply 多个 workspace，进度保存在数据库中）
+	stackRunWorker := services.NewStackRunWorker(db, queueManager)
+
+	// 初始化任务产物保留策略 worker（按组织策略归档/清除已结束任务的日志和 Plan 数据）
+	artifactRetentionWorker := services.NewArtifactRetentionWorker(db)
+
 	// 设置Gin模式
 	if cfg.Server.Env == "production" {
 		gin.SetMode(gin.ReleaseMode)
@@ -362,6 +386,22 @@ func main() {
 				log.Println("[Leader] Embedding worker started for CMDB vector search")
 			}
 
+			// 7.1 Audit event export worker (syslog/CEF, JSON lines, HTTP)
+			go auditExportWorker.Start(leaderCtx, 10*time.Second)
+			log.Println("[Leader] Audit event export worker started (10 second interval)")
+
+			// 7.2 Manifest rollout worker (waves, failure thresholds, approvals)
+			go manifestRolloutWorker.Start(leaderCtx, 15*time.Second)
+			log.Println("[Leader] Manifest rollout worker started (15 second interval)")
+
+			// 7.3 Stack run worker (ordered multi-workspace plan/apply)
+			go stackRunWorker.Start(leaderCtx, 10*time.Second)
+			log.Println("[Leader] Stack run worker started (10 second interval)")
+
+			// 7.4 Task artifact retention worker (archive to object storage, purge)
+			go artifactRetentionWorker.Start(leaderCtx, 1*time.Hour)
+			log.Println("[Leader] Artifact retention worker started (1 hour interval)")
+
 			// 8. Background cleanup goroutine (lock/draft cleanup)
 			go func() {
 				ticker := time.NewTicker(1 * time.Minute)
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_org_permissions_principal;
+DROP INDEX IF EXISTS idx_org_permissions_scope;
+DROP INDEX IF EXISTS idx_project_permissions_principal;
+DROP INDEX IF EXISTS idx_project_permissions_scope;
+DROP INDEX IF EXISTS idx_workspace_permissions_principal;
+DROP INDEX IF EXISTS idx_workspace_permissions_scope;
+DROP INDEX IF EXISTS idx_org_permissions_principal_scope;
+DROP INDEX IF EXISTS idx_project_permissions_principal_scope;
+DROP INDEX IF EXISTS idx_workspace_permissions_principal_scope;
@@ -3,12 +3,9 @@
 -- 创建日期: 2026-02-16
 -- 目的: 补齐 11 种未被任何系统角色覆盖的资源类型，修复角色权限不足问题
 -- 幂等: 所有 INSERT 使用 WHERE NOT EXISTS，可重复执行
+-- 由 migrate 在事务中执行
 -- ============================================================
 
-\connect iac_platform
-
-BEGIN;
-
 -- ============================================================
 -- 第一层: permission_definitions 补注册
 -- SYSTEM_SETTINGS 在路由中使用但 DB 未注册
@@ -368,14 +365,12 @@ SELECT 30, 'READ', 'WORKSPACE', NOW(), 'wspm-000000000026'
 WHERE NOT EXISTS (SELECT 1 FROM iam_role_policies WHERE role_id = 30 AND permission_id = 'wspm-000000000026' AND scope_type = 'WORKSPACE');
 
 -- ============================================================
--- 验证：检查各角色策略数量
+-- 验证：检查各角色策略数量（手动执行）
 -- ============================================================
 
-SELECT r.name AS role_name, r.id AS role_id, COUNT(p.id) AS policy_count
-FROM iam_roles r
-LEFT JOIN iam_role_policies p ON r.id = p.role_id
-WHERE r.id IN (1, 2, 3, 4, 5, 6, 26, 27, 28, 30)
-GROUP BY r.id, r.name
-ORDER BY r.id;
-
-COMMIT;
+-- SELECT r.name AS role_name, r.id AS role_id, COUNT(p.id) AS policy_count
+-- FROM iam_roles r
+-- LEFT JOIN iam_role_policies p ON r.id = p.role_id
+-- WHERE r.id IN (1, 2, 3, 4, 5, 6, 26, 27, 28, 30)
+-- GROUP BY r.id, r.name
+-- ORDER BY r.id;
@@ -0,0 +1,5 @@
+ALTER TABLE public.workspaces
+    DROP COLUMN IF EXISTS provider_template_ids,
+    DROP COLUMN IF EXISTS provider_overrides;
+
+DROP TABLE IF EXISTS public.provider_templates;
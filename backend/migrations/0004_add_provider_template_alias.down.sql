@@ -0,0 +1 @@
+ALTER TABLE public.provider_templates DROP COLUMN IF EXISTS alias;
@@ -0,0 +1,5 @@
+ALTER TABLE public.workspaces
+    DROP COLUMN IF EXISTS cmdb_sync_status,
+    DROP COLUMN IF EXISTS cmdb_sync_triggered_by,
+    DROP COLUMN IF EXISTS cmdb_sync_started_at,
+    DROP COLUMN IF EXISTS cmdb_sync_completed_at;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS public.audit_exporters;
+DROP TRIGGER IF EXISTS trg_audit_events_append_only ON public.audit_events;
+DROP FUNCTION IF EXISTS public.audit_events_append_only();
+DROP TABLE IF EXISTS public.audit_events;
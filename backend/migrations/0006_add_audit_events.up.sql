@@ -0,0 +1,72 @@
+-- Structured domain audit events (append-only, hash-chained) and SIEM exporters
+
+CREATE TABLE IF NOT EXISTS public.audit_events (
+    id BIGSERIAL PRIMARY KEY,
+    event_id character varying(50) NOT NULL,
+    event_type character varying(50) NOT NULL,
+    occurred_at timestamp without time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    actor_type character varying(20) NOT NULL,
+    actor_id character varying(100),
+    actor_name character varying(255),
+    source_ip character varying(64),
+    resource_type character varying(50),
+    resource_id character varying(255),
+    workspace_id character varying(50),
+    task_id integer,
+    outcome character varying(20) NOT NULL DEFAULT 'success',
+    message text,
+    details jsonb,
+    prev_hash character varying(64) NOT NULL,
+    hash character varying(64) NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_audit_events_event_id ON public.audit_events (event_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_audit_events_hash ON public.audit_events (hash);
+CREATE INDEX IF NOT EXISTS idx_audit_events_event_type ON public.audit_events (event_type);
+CREATE INDEX IF NOT EXISTS idx_audit_events_occurred_at ON public.audit_events (occurred_at);
+CREATE INDEX IF NOT EXISTS idx_audit_events_actor_id ON public.audit_events (actor_id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_resource ON public.audit_events (resource_type, resource_id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_workspace_id ON public.audit_events (workspace_id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_task_id ON public.audit_events (task_id);
+
+-- Reject UPDATE/DELETE so the table stays append-only at the database level
+CREATE OR REPLACE FUNCTION public.audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_audit_events_append_only ON public.audit_events;
+CREATE TRIGGER trg_audit_events_append_only
+    BEFORE UPDATE OR DELETE ON public.audit_events
+    FOR EACH ROW EXECUTE FUNCTION public.audit_events_append_only();
+
+COMMENT ON TABLE public.audit_events IS 'Typed domain audit events, hash-chained for tamper evidence';
+COMMENT ON COLUMN public.audit_events.prev_hash IS 'Hash of the previous event in the chain (64 zeros for the first event)';
+COMMENT ON COLUMN public.audit_events.hash IS 'SHA256(prev_hash || canonical event payload)';
+
+CREATE TABLE IF NOT EXISTS public.audit_exporters (
+    id SERIAL PRIMARY KEY,
+    exporter_id character varying(50) NOT NULL,
+    name character varying(100) NOT NULL,
+    description text,
+    exporter_type character varying(20) NOT NULL,
+    config jsonb NOT NULL DEFAULT '{}',
+    secret_encrypted text,
+    event_types character varying(500),
+    enabled boolean DEFAULT true,
+    batch_size integer DEFAULT 100,
+    retry_count integer DEFAULT 3,
+    retry_interval_seconds integer DEFAULT 5,
+    last_exported_id bigint DEFAULT 0,
+    last_exported_at timestamp without time zone,
+    last_error text,
+    created_by character varying(50),
+    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_audit_exporters_exporter_id ON public.audit_exporters (exporter_id);
+
+COMMENT ON TABLE public.audit_exporters IS 'SIEM export targets for audit_events (syslog/CEF, JSON lines, HTTP)';
+COMMENT ON COLUMN public.audit_exporters.last_exported_id IS 'Cursor: highest audit_events.id successfully delivered';
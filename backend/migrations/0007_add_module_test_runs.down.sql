@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS public.module_test_results;
+DROP TABLE IF EXISTS public.module_test_runs;
+ALTER TABLE public.modules DROP COLUMN IF EXISTS require_passing_test;
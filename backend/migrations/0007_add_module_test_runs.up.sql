@@ -0,0 +1,56 @@
+-- Module version test runs (terraform test / tofu test) and per-run-block results
+
+ALTER TABLE public.modules ADD COLUMN IF NOT EXISTS require_passing_test boolean DEFAULT false;
+
+COMMENT ON COLUMN public.modules.require_passing_test IS 'Require the latest test run of a version to pass before it can become the default version';
+
+CREATE TABLE IF NOT EXISTS public.module_test_runs (
+    id SERIAL PRIMARY KEY,
+    run_id character varying(50) NOT NULL,
+    module_id integer NOT NULL,
+    module_version_id character varying(30) NOT NULL,
+    workspace_id character varying(50) NOT NULL,
+    task_id integer,
+    engine character varying(20),
+    engine_version character varying(50),
+    include_demo_tests boolean DEFAULT true,
+    demo_test_command character varying(10) DEFAULT 'plan',
+    test_files jsonb,
+    status character varying(20) DEFAULT 'pending',
+    total_count integer DEFAULT 0,
+    passed_count integer DEFAULT 0,
+    failed_count integer DEFAULT 0,
+    errored_count integer DEFAULT 0,
+    skipped_count integer DEFAULT 0,
+    error_message text,
+    created_by character varying(20),
+    started_at timestamp without time zone,
+    completed_at timestamp without time zone,
+    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_module_test_runs_run_id ON public.module_test_runs (run_id);
+CREATE INDEX IF NOT EXISTS idx_module_test_runs_module_id ON public.module_test_runs (module_id);
+CREATE INDEX IF NOT EXISTS idx_module_test_runs_version ON public.module_test_runs (module_version_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_module_test_runs_task_id ON public.module_test_runs (task_id);
+CREATE INDEX IF NOT EXISTS idx_module_test_runs_status ON public.module_test_runs (status);
+
+COMMENT ON TABLE public.module_test_runs IS 'terraform test / tofu test runs for a module version, executed as task_type=test workspace tasks';
+COMMENT ON COLUMN public.module_test_runs.workspace_id IS 'Execution workspace providing agent pool, engine version, credentials and env vars';
+
+CREATE TABLE IF NOT EXISTS public.module_test_results (
+    id SERIAL PRIMARY KEY,
+    run_id character varying(50) NOT NULL,
+    test_file character varying(500) NOT NULL,
+    run_name character varying(200),
+    status character varying(20) NOT NULL,
+    generated boolean DEFAULT false,
+    diagnostics text,
+    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_module_test_results_run_id ON public.module_test_results (run_id);
+
+COMMENT ON TABLE public.module_test_results IS 'Per run-block results of a module test run';
+COMMENT ON COLUMN public.module_test_results.generated IS 'True when the test file was generated from a module demo';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS public.task_static_analysis_results;
+ALTER TABLE public.workspaces DROP COLUMN IF EXISTS static_analysis_config;
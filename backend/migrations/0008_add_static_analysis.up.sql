@@ -0,0 +1,30 @@
+-- Pre-plan static analysis (builtin rules, tflint, trivy, checkov) for generated configuration
+
+ALTER TABLE public.workspaces ADD COLUMN IF NOT EXISTS static_analysis_config jsonb;
+
+COMMENT ON COLUMN public.workspaces.static_analysis_config IS 'Static analysis settings: {"enabled": bool, "analyzers": [{"name", "enforcement_level", "blocking_level", "args", "disabled_rules", "timeout_seconds"}]}';
+
+CREATE TABLE IF NOT EXISTS public.task_static_analysis_results (
+    id SERIAL PRIMARY KEY,
+    task_id integer NOT NULL,
+    workspace_id character varying(50) NOT NULL,
+    analyzer character varying(50) NOT NULL,
+    enforcement_level character varying(20) DEFAULT 'advisory',
+    blocking_level character varying(20) DEFAULT 'error',
+    status character varying(20) NOT NULL,
+    blocking boolean DEFAULT false,
+    error_count integer DEFAULT 0,
+    warning_count integer DEFAULT 0,
+    note_count integer DEFAULT 0,
+    sarif jsonb,
+    message text,
+    duration_ms bigint DEFAULT 0,
+    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_static_analysis_results_task_id ON public.task_static_analysis_results (task_id);
+CREATE INDEX IF NOT EXISTS idx_task_static_analysis_results_workspace_id ON public.task_static_analysis_results (workspace_id);
+
+COMMENT ON TABLE public.task_static_analysis_results IS 'Per-analyzer pre-plan static analysis results of a workspace task';
+COMMENT ON COLUMN public.task_static_analysis_results.sarif IS 'SARIF-like run object: {"tool": {"driver": {...}}, "results": [...]}';
+COMMENT ON COLUMN public.task_static_analysis_results.blocking IS 'True when a mandatory analyzer failed or errored and blocked the plan';
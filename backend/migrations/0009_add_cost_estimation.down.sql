@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS public.task_cost_estimate_resources;
+DROP TABLE IF EXISTS public.task_cost_estimates;
+ALTER TABLE public.workspaces DROP COLUMN IF EXISTS cost_estimation_config;
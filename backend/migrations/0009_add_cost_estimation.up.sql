@@ -0,0 +1,55 @@
+-- Post-plan cost estimation with workspace budget thresholds
+
+ALTER TABLE public.workspaces ADD COLUMN IF NOT EXISTS cost_estimation_config jsonb;
+
+COMMENT ON COLUMN public.workspaces.cost_estimation_config IS 'Cost estimation settings: {"enabled": bool, "max_monthly_delta": number, "max_monthly_total": number, "enforcement_level": "advisory|mandatory"}';
+
+CREATE TABLE IF NOT EXISTS public.task_cost_estimates (
+    id SERIAL PRIMARY KEY,
+    task_id integer NOT NULL,
+    workspace_id character varying(50) NOT NULL,
+    currency character varying(10) DEFAULT 'USD',
+    monthly_before numeric(14,2) DEFAULT 0,
+    monthly_after numeric(14,2) DEFAULT 0,
+    monthly_delta numeric(14,2) DEFAULT 0,
+    priced_count integer DEFAULT 0,
+    free_count integer DEFAULT 0,
+    unsupported_count integer DEFAULT 0,
+    pricing_source character varying(50),
+    pricing_region character varying(50),
+    budget_status character varying(20) DEFAULT 'none',
+    budget_message text,
+    enforcement_level character varying(20) DEFAULT 'advisory',
+    blocking boolean DEFAULT false,
+    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_task_cost_estimates_task_id ON public.task_cost_estimates (task_id);
+CREATE INDEX IF NOT EXISTS idx_task_cost_estimates_workspace_id ON public.task_cost_estimates (workspace_id);
+
+COMMENT ON TABLE public.task_cost_estimates IS 'Monthly cost estimate (before/after/delta) of a task plan';
+COMMENT ON COLUMN public.task_cost_estimates.blocking IS 'True when a mandatory budget threshold is exceeded; apply is refused';
+
+CREATE TABLE IF NOT EXISTS public.task_cost_estimate_resources (
+    id SERIAL PRIMARY KEY,
+    task_id integer NOT NULL,
+    address character varying(500) NOT NULL,
+    resource_type character varying(100),
+    action character varying(20),
+    monthly_before numeric(14,2) DEFAULT 0,
+    monthly_after numeric(14,2) DEFAULT 0,
+    monthly_delta numeric(14,2) DEFAULT 0,
+    priced boolean DEFAULT false,
+    note text,
+    components jsonb,
+    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_cost_estimate_resources_task_id ON public.task_cost_estimate_resources (task_id);
+
+COMMENT ON TABLE public.task_cost_estimate_resources IS 'Per-resource monthly cost estimate of a task plan';
+COMMENT ON COLUMN public.task_cost_estimate_resources.components IS 'Cost components: [{"name", "unit", "price_key", "unit_price", "quantity", "monthly_before", "monthly_after"}]';
+
+-- Pricing source (optional, defaults to the built-in price list)
+-- {"type": "price_list|infracost", "price_list_file": "/path/prices.json", "endpoint": "https://pricing.api.infracost.io/graphql", "api_key": "..."}
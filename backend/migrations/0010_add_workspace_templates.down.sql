@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_workspaces_template_id;
+ALTER TABLE public.workspaces
+    DROP COLUMN IF EXISTS template_id,
+    DROP COLUMN IF EXISTS template_version;
+DROP TABLE IF EXISTS public.workspace_templates;
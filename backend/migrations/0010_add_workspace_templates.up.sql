@@ -0,0 +1,29 @@
+-- Workspace templates (golden workspace blueprints)
+
+CREATE TABLE IF NOT EXISTS public.workspace_templates (
+    id SERIAL PRIMARY KEY,
+    template_id character varying(50) NOT NULL,
+    name character varying(100) NOT NULL,
+    description text,
+    version integer NOT NULL DEFAULT 1,
+    spec jsonb NOT NULL DEFAULT '{}'::jsonb,
+    enabled boolean DEFAULT true,
+    created_by character varying(50),
+    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_workspace_templates_template_id ON public.workspace_templates (template_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_workspace_templates_name ON public.workspace_templates (name);
+
+COMMENT ON TABLE public.workspace_templates IS 'Workspace templates: settings, run tasks, notifications, variable definitions and initial resources';
+COMMENT ON COLUMN public.workspace_templates.version IS 'Incremented whenever spec changes; workspaces record the version they were created from or last synced to';
+COMMENT ON COLUMN public.workspace_templates.spec IS 'Template spec: {"settings": {...}, "run_tasks": [...], "notifications": [...], "variables": [...], "resources": [...]}';
+
+ALTER TABLE public.workspaces ADD COLUMN IF NOT EXISTS template_id character varying(50);
+ALTER TABLE public.workspaces ADD COLUMN IF NOT EXISTS template_version integer DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_workspaces_template_id ON public.workspaces (template_id);
+
+COMMENT ON COLUMN public.workspaces.template_id IS 'Workspace template this workspace was created from';
+COMMENT ON COLUMN public.workspaces.template_version IS 'Template version last applied to this workspace';
@@ -0,0 +1,3 @@
+ALTER TABLE IF EXISTS public.manifest_versions
+    DROP COLUMN IF EXISTS locals,
+    DROP COLUMN IF EXISTS outputs;
@@ -0,0 +1,8 @@
+-- Manifest versions keep locals and outputs parsed from imported HCL
+-- so that exporting an imported manifest does not lose them
+
+ALTER TABLE IF EXISTS public.manifest_versions ADD COLUMN IF NOT EXISTS locals jsonb;
+ALTER TABLE IF EXISTS public.manifest_versions ADD COLUMN IF NOT EXISTS outputs jsonb;
+
+COMMENT ON COLUMN public.manifest_versions.locals IS 'Local values: [{"name": "...", "value": ...}]';
+COMMENT ON COLUMN public.manifest_versions.outputs IS 'Outputs: [{"name": "...", "value": ..., "description": "...", "sensitive": false, "depends_on": [...]}]';
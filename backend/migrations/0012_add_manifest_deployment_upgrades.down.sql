@@ -0,0 +1 @@
+ALTER TABLE IF EXISTS public.manifest_deployments DROP COLUMN IF EXISTS previous_version_id;
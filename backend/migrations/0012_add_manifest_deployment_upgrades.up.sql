@@ -0,0 +1,5 @@
+-- Manifest deployment upgrades and rollback
+
+ALTER TABLE IF EXISTS public.manifest_deployments ADD COLUMN IF NOT EXISTS previous_version_id character varying(36);
+
+COMMENT ON COLUMN public.manifest_deployments.previous_version_id IS 'Version deployed before the last upgrade or rollback; rollback target';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS public.manifest_rollout_targets;
+DROP TABLE IF EXISTS public.manifest_rollouts;
@@ -0,0 +1,53 @@
+-- Bulk manifest rollouts across many workspaces in waves
+
+CREATE TABLE IF NOT EXISTS public.manifest_rollouts (
+    id character varying(36) PRIMARY KEY,
+    manifest_id character varying(36) NOT NULL,
+    version_id character varying(36) NOT NULL,
+    selector jsonb NOT NULL DEFAULT '{}'::jsonb,
+    strategy jsonb NOT NULL DEFAULT '{}'::jsonb,
+    conflict_strategy character varying(20),
+    variable_overrides jsonb,
+    status character varying(30) NOT NULL DEFAULT 'running',
+    current_wave integer NOT NULL DEFAULT 0,
+    total_waves integer NOT NULL DEFAULT 0,
+    accepted_failures integer NOT NULL DEFAULT 0,
+    message text,
+    created_by character varying(20) NOT NULL,
+    approved_by character varying(20),
+    started_at timestamp without time zone,
+    completed_at timestamp without time zone,
+    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_manifest_rollouts_manifest_id ON public.manifest_rollouts (manifest_id);
+CREATE INDEX IF NOT EXISTS idx_manifest_rollouts_status ON public.manifest_rollouts (status);
+
+COMMENT ON TABLE public.manifest_rollouts IS 'Rollout of one manifest version to many workspaces in waves; advanced by the leader-only rollout worker';
+COMMENT ON COLUMN public.manifest_rollouts.selector IS 'Target selector: {"project_ids": [...], "tags": {...}, "workspace_ids": [...]} (intersection)';
+COMMENT ON COLUMN public.manifest_rollouts.strategy IS 'Waves and failure handling: {"canary_count", "percentages", "failure_threshold", "failure_action", "require_approval"}';
+COMMENT ON COLUMN public.manifest_rollouts.status IS 'running, awaiting_approval, paused, completed, stopped, cancelled';
+COMMENT ON COLUMN public.manifest_rollouts.accepted_failures IS 'Failures in the current wave accepted on resume; excluded from the failure threshold';
+
+CREATE TABLE IF NOT EXISTS public.manifest_rollout_targets (
+    id SERIAL PRIMARY KEY,
+    rollout_id character varying(36) NOT NULL,
+    workspace_id integer NOT NULL,
+    workspace_key character varying(50),
+    wave integer NOT NULL,
+    action character varying(20),
+    status character varying(20) NOT NULL DEFAULT 'pending',
+    deployment_id character varying(36),
+    task_id integer,
+    error text,
+    started_at timestamp without time zone,
+    completed_at timestamp without time zone
+);
+
+CREATE INDEX IF NOT EXISTS idx_manifest_rollout_targets_rollout_wave ON public.manifest_rollout_targets (rollout_id, wave);
+CREATE INDEX IF NOT EXISTS idx_manifest_rollout_targets_status ON public.manifest_rollout_targets (status);
+
+COMMENT ON TABLE public.manifest_rollout_targets IS 'Per-workspace status of a manifest rollout with deployment and plan+apply task links';
+COMMENT ON COLUMN public.manifest_rollout_targets.action IS 'install (new deployment), upgrade (existing deployment) or none (already on the target version)';
+COMMENT ON COLUMN public.manifest_rollout_targets.status IS 'pending, running, succeeded, failed, skipped';
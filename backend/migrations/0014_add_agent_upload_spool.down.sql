@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_workspace_state_versions_task_checksum;
+DROP INDEX IF EXISTS idx_task_logs_checksum;
+ALTER TABLE IF EXISTS public.task_logs DROP COLUMN IF EXISTS checksum;
@@ -0,0 +1,10 @@
+-- Agent-side durable spool: idempotent re-uploads of log chunks and the state_pending_upload task status
+
+ALTER TABLE IF EXISTS public.task_logs ADD COLUMN IF NOT EXISTS checksum character varying(64);
+
+CREATE INDEX IF NOT EXISTS idx_task_logs_checksum ON public.task_logs (task_id, checksum);
+
+CREATE INDEX IF NOT EXISTS idx_workspace_state_versions_task_checksum ON public.workspace_state_versions (task_id, checksum);
+
+COMMENT ON COLUMN public.task_logs.checksum IS 'SHA256 of the uploaded log chunk; duplicate chunks replayed from the agent spool are ignored';
+COMMENT ON COLUMN public.workspace_tasks.status IS 'Task status; state_pending_upload = apply succeeded but the state is still in the agent spool, promoted to applied when the state arrives';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS public.artifact_retention_policies;
+DROP TABLE IF EXISTS public.task_artifacts;
@@ -0,0 +1,45 @@
+-- Task artifact archival to object storage with per-organization retention policies
+
+CREATE TABLE IF NOT EXISTS public.task_artifacts (
+    id SERIAL PRIMARY KEY,
+    task_id integer NOT NULL,
+    workspace_id character varying(50) NOT NULL,
+    kind character varying(20) NOT NULL,
+    name character varying(255),
+    storage_type character varying(20) NOT NULL,
+    storage_key character varying(500),
+    compression character varying(20),
+    size_bytes bigint NOT NULL DEFAULT 0,
+    stored_bytes bigint NOT NULL DEFAULT 0,
+    checksum character varying(64),
+    archived_at timestamp without time zone,
+    purged_at timestamp without time zone,
+    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_artifacts_task_id ON public.task_artifacts (task_id);
+CREATE INDEX IF NOT EXISTS idx_task_artifacts_workspace_id ON public.task_artifacts (workspace_id);
+CREATE INDEX IF NOT EXISTS idx_task_artifacts_kind_purged ON public.task_artifacts (kind, purged_at);
+
+COMMENT ON TABLE public.task_artifacts IS 'Task logs, plan/apply output, plan files, plan JSON and state backups moved out of the database into artifact storage';
+COMMENT ON COLUMN public.task_artifacts.kind IS 'logs, plan_output, apply_output, plan_data, plan_json, state_backup; every archived or purged task has a logs row';
+COMMENT ON COLUMN public.task_artifacts.storage_type IS 'local or s3; the storage the object was written to';
+COMMENT ON COLUMN public.task_artifacts.checksum IS 'SHA256 of the uncompressed content, verified on read';
+COMMENT ON COLUMN public.task_artifacts.purged_at IS 'Set when the retention policy deleted the object; the row is kept so reads can report the purge';
+
+CREATE TABLE IF NOT EXISTS public.artifact_retention_policies (
+    id SERIAL PRIMARY KEY,
+    organization_id integer NOT NULL,
+    enabled boolean NOT NULL DEFAULT true,
+    archive_after_days integer NOT NULL DEFAULT 0,
+    purge_after_days integer NOT NULL DEFAULT 0,
+    updated_by character varying(50),
+    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_artifact_retention_policies_org ON public.artifact_retention_policies (organization_id);
+
+COMMENT ON TABLE public.artifact_retention_policies IS 'Per-organization retention for finished task artifacts; organization_id 0 is the platform default';
+COMMENT ON COLUMN public.artifact_retention_policies.archive_after_days IS 'Days after task completion before artifacts move to artifact storage; 0 disables archival';
+COMMENT ON COLUMN public.artifact_retention_policies.purge_after_days IS 'Days after task completion before artifacts are deleted everywhere; 0 keeps them forever';
@@ -0,0 +1,6 @@
+ALTER TABLE public.user_tokens
+    DROP COLUMN IF EXISTS scopes,
+    DROP COLUMN IF EXISTS last_used_ip;
+ALTER TABLE public.team_tokens
+    DROP COLUMN IF EXISTS scopes,
+    DROP COLUMN IF EXISTS last_used_ip;
@@ -0,0 +1,11 @@
+-- Scoped and expiring API tokens: token scopes, last-used tracking and the lifetime policy
+
+ALTER TABLE public.user_tokens ADD COLUMN IF NOT EXISTS scopes jsonb;
+ALTER TABLE public.user_tokens ADD COLUMN IF NOT EXISTS last_used_ip character varying(64);
+ALTER TABLE public.team_tokens ADD COLUMN IF NOT EXISTS scopes jsonb;
+ALTER TABLE public.team_tokens ADD COLUMN IF NOT EXISTS last_used_ip character varying(64);
+
+COMMENT ON COLUMN public.user_tokens.scopes IS 'Token scope (read_only, workspace_ids, project_ids, resource_types, ip_allowlist); NULL for tokens created before scopes existed';
+COMMENT ON COLUMN public.team_tokens.scopes IS 'Token scope (read_only, workspace_ids, project_ids, resource_types, ip_allowlist); NULL for tokens created before scopes existed';
+
+-- The lifetime policy lives in system_configs (key = api_token_policy); 365 days for both token kinds until an admin sets it
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS public.skill_eval_recordings;
+DROP TABLE IF EXISTS public.skill_eval_results;
+DROP TABLE IF EXISTS public.skill_eval_runs;
+DROP TABLE IF EXISTS public.skill_eval_cases;
+DROP TABLE IF EXISTS public.skill_eval_suites;
@@ -0,0 +1,101 @@
+-- Skill evaluation suites: stored cases, runs, per-case results and recorded AI responses for replay
+
+CREATE TABLE IF NOT EXISTS public.skill_eval_suites (
+    id SERIAL PRIMARY KEY,
+    name character varying(255) NOT NULL,
+    description text,
+    composition jsonb,
+    ai_config_id integer,
+    mode character varying(20) NOT NULL DEFAULT 'replay',
+    gate_skill_edits boolean DEFAULT false,
+    tolerance double precision DEFAULT 0,
+    created_by character varying(20),
+    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_skill_eval_suites_name ON public.skill_eval_suites (name);
+
+COMMENT ON TABLE public.skill_eval_suites IS 'Evaluation suites for AI config generation; runs exercise skill assembly, generation and SchemaSolver';
+COMMENT ON COLUMN public.skill_eval_suites.composition IS 'Skill composition used by the suite; null uses the form_generation AI config composition';
+COMMENT ON COLUMN public.skill_eval_suites.gate_skill_edits IS 'Skill content edits used by this suite are blocked when their comparison regressed and warned when not evaluated';
+COMMENT ON COLUMN public.skill_eval_suites.tolerance IS 'Allowed score drop (0-1) before a candidate counts as a regression';
+
+CREATE TABLE IF NOT EXISTS public.skill_eval_cases (
+    id SERIAL PRIMARY KEY,
+    suite_id integer NOT NULL,
+    name character varying(255) NOT NULL,
+    user_intent text NOT NULL,
+    module_id integer NOT NULL,
+    current_config jsonb,
+    expectations jsonb,
+    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_skill_eval_cases_suite_id ON public.skill_eval_cases (suite_id);
+
+COMMENT ON COLUMN public.skill_eval_cases.expectations IS 'required_fields, expected_values and forbidden_fields checked against the solver output';
+
+CREATE TABLE IF NOT EXISTS public.skill_eval_runs (
+    id SERIAL PRIMARY KEY,
+    run_id character varying(50) NOT NULL,
+    suite_id integer NOT NULL,
+    mode character varying(20) NOT NULL,
+    status character varying(20) DEFAULT 'pending',
+    skill_overrides jsonb,
+    skill_name character varying(255),
+    content_hash character varying(64),
+    baseline_run_id character varying(50),
+    ai_model character varying(200),
+    used_skills jsonb DEFAULT '[]',
+    score double precision DEFAULT 0,
+    total_count integer DEFAULT 0,
+    passed_count integer DEFAULT 0,
+    failed_count integer DEFAULT 0,
+    errored_count integer DEFAULT 0,
+    regressed boolean,
+    score_delta double precision,
+    error_message text,
+    created_by character varying(20),
+    started_at timestamp without time zone,
+    completed_at timestamp without time zone,
+    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_skill_eval_runs_run_id ON public.skill_eval_runs (run_id);
+CREATE INDEX IF NOT EXISTS idx_skill_eval_runs_suite_id ON public.skill_eval_runs (suite_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_skill_eval_runs_skill_name ON public.skill_eval_runs (skill_name, content_hash);
+
+COMMENT ON COLUMN public.skill_eval_runs.skill_overrides IS 'Skill name to content used instead of the stored skill content';
+COMMENT ON COLUMN public.skill_eval_runs.baseline_run_id IS 'Set on the candidate run of a comparison; regressed and score_delta are relative to it';
+
+CREATE TABLE IF NOT EXISTS public.skill_eval_results (
+    id SERIAL PRIMARY KEY,
+    run_id character varying(50) NOT NULL,
+    case_id integer NOT NULL,
+    case_name character varying(255),
+    status character varying(20) NOT NULL,
+    score double precision DEFAULT 0,
+    checks jsonb,
+    params jsonb,
+    solver_errors integer DEFAULT 0,
+    retries integer DEFAULT 0,
+    error_message text,
+    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_skill_eval_results_run_id ON public.skill_eval_results (run_id);
+
+CREATE TABLE IF NOT EXISTS public.skill_eval_recordings (
+    id SERIAL PRIMARY KEY,
+    case_id integer NOT NULL,
+    prompt_hash character varying(64) NOT NULL,
+    response text NOT NULL,
+    ai_model character varying(200),
+    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_skill_eval_recordings_case_prompt ON public.skill_eval_recordings (case_id, prompt_hash);
+
+COMMENT ON TABLE public.skill_eval_recordings IS 'AI responses recorded by live runs, replayed by prompt SHA256';
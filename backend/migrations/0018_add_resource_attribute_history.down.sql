@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS public.resource_history_cursors;
+DROP TABLE IF EXISTS public.resource_attribute_history;
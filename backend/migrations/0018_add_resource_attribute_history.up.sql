@@ -0,0 +1,37 @@
+-- CMDB time-travel: per-resource attribute history tied to state versions
+
+CREATE TABLE IF NOT EXISTS public.resource_attribute_history (
+    id SERIAL PRIMARY KEY,
+    workspace_id character varying(50) NOT NULL,
+    terraform_address text NOT NULL,
+    cloud_resource_id character varying(255),
+    resource_type character varying(100) NOT NULL,
+    valid_from_state_version_id integer NOT NULL,
+    valid_to_state_version_id integer,
+    valid_from timestamp without time zone NOT NULL,
+    valid_to timestamp without time zone,
+    task_id integer,
+    change_type character varying(20) NOT NULL,
+    is_snapshot boolean DEFAULT false,
+    chain_depth integer DEFAULT 0,
+    snapshot jsonb,
+    diff jsonb,
+    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_resource_history_address ON public.resource_attribute_history (workspace_id, terraform_address, valid_from_state_version_id);
+CREATE INDEX IF NOT EXISTS idx_resource_history_cloud_id ON public.resource_attribute_history (cloud_resource_id);
+CREATE INDEX IF NOT EXISTS idx_resource_history_open ON public.resource_attribute_history (workspace_id) WHERE valid_to IS NULL;
+
+COMMENT ON TABLE public.resource_attribute_history IS 'Attribute versions of Terraform-managed resources, valid between two state versions';
+COMMENT ON COLUMN public.resource_attribute_history.snapshot IS 'Full attributes; set only on chain heads (is_snapshot)';
+COMMENT ON COLUMN public.resource_attribute_history.diff IS 'Top-level attribute changes relative to the previous version: {"set": {...}, "unset": [...]}';
+COMMENT ON COLUMN public.resource_attribute_history.chain_depth IS 'Number of diff versions since the last snapshot';
+
+CREATE TABLE IF NOT EXISTS public.resource_history_cursors (
+    workspace_id character varying(50) PRIMARY KEY,
+    last_state_version_id integer NOT NULL,
+    updated_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE public.resource_history_cursors IS 'Last state version of each workspace already recorded into resource_attribute_history';
@@ -0,0 +1,2 @@
+ALTER TABLE public.cmdb_external_sources DROP COLUMN IF EXISTS source_config;
+ALTER TABLE public.cmdb_external_sources DROP COLUMN IF EXISTS source_type;
@@ -0,0 +1,10 @@
+-- CMDB external sources: pluggable source types (paginated HTTP, file import, SQL query)
+
+ALTER TABLE public.cmdb_external_sources ADD COLUMN IF NOT EXISTS source_type character varying(20) DEFAULT 'http';
+ALTER TABLE public.cmdb_external_sources ADD COLUMN IF NOT EXISTS source_config jsonb;
+ALTER TABLE public.cmdb_external_sources ALTER COLUMN api_endpoint DROP NOT NULL;
+
+UPDATE public.cmdb_external_sources SET source_type = 'http' WHERE source_type IS NULL;
+
+COMMENT ON COLUMN public.cmdb_external_sources.source_type IS 'Source type: http, file or sql';
+COMMENT ON COLUMN public.cmdb_external_sources.source_config IS 'Type-specific settings: HTTP pagination/rate limit, file location/format, SQL driver/query (DSN stored in secrets)';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS public.change_freeze_exceptions;
+DROP TABLE IF EXISTS public.change_freeze_windows;
@@ -0,0 +1,47 @@
+-- Organization/project change freeze calendar and per-run exceptions
+
+CREATE TABLE IF NOT EXISTS public.change_freeze_windows (
+    id character varying(36) PRIMARY KEY,
+    name character varying(100) NOT NULL,
+    description text,
+    scope character varying(20) NOT NULL,
+    selector jsonb NOT NULL DEFAULT '{}'::jsonb,
+    timezone character varying(64) NOT NULL DEFAULT 'UTC',
+    schedule jsonb NOT NULL DEFAULT '{}'::jsonb,
+    enabled boolean NOT NULL DEFAULT true,
+    created_by character varying(20),
+    updated_by character varying(20),
+    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_change_freeze_windows_enabled ON public.change_freeze_windows (enabled);
+
+COMMENT ON TABLE public.change_freeze_windows IS 'Change freeze calendar; applies (not plans) in matching workspaces are held by the task queue while a window is active';
+COMMENT ON COLUMN public.change_freeze_windows.scope IS 'organization (all workspaces) or project (workspaces in selector.project_ids)';
+COMMENT ON COLUMN public.change_freeze_windows.selector IS 'Filters: {"project_ids": [...], "tags": {...}} (intersection)';
+COMMENT ON COLUMN public.change_freeze_windows.timezone IS 'IANA timezone the schedule is evaluated in';
+COMMENT ON COLUMN public.change_freeze_windows.schedule IS 'once: {"starts_at", "ends_at"}; weekly: {"weekdays", "from_time", "to_time"} with optional starts_at/ends_at bounds';
+
+CREATE TABLE IF NOT EXISTS public.change_freeze_exceptions (
+    id SERIAL PRIMARY KEY,
+    task_id integer NOT NULL,
+    workspace_id character varying(50) NOT NULL,
+    window_ids text,
+    reason text NOT NULL,
+    status character varying(20) NOT NULL DEFAULT 'pending',
+    requested_by character varying(20) NOT NULL,
+    reviewed_by character varying(20),
+    review_comment text,
+    reviewed_at timestamp without time zone,
+    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_change_freeze_exceptions_task_id ON public.change_freeze_exceptions (task_id);
+CREATE INDEX IF NOT EXISTS idx_change_freeze_exceptions_workspace_id ON public.change_freeze_exceptions (workspace_id);
+CREATE INDEX IF NOT EXISTS idx_change_freeze_exceptions_status ON public.change_freeze_exceptions (status);
+
+COMMENT ON TABLE public.change_freeze_exceptions IS 'Per-run exception requests to apply during a change freeze; requests and reviews are also recorded in audit_events';
+COMMENT ON COLUMN public.change_freeze_exceptions.window_ids IS 'Freeze windows active when the exception was requested (comma separated)';
+COMMENT ON COLUMN public.change_freeze_exceptions.status IS 'pending, approved, rejected';
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS public.stack_run_members;
+DROP TABLE IF EXISTS public.stack_runs;
+DROP TABLE IF EXISTS public.stack_members;
+DROP TABLE IF EXISTS public.stacks;
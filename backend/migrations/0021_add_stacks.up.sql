@@ -0,0 +1,72 @@
+-- Stacks: ordered multi-workspace plan and apply orchestration
+
+CREATE TABLE IF NOT EXISTS public.stacks (
+    id character varying(36) PRIMARY KEY,
+    name character varying(100) NOT NULL,
+    description text,
+    created_by character varying(20) NOT NULL,
+    updated_by character varying(20),
+    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stacks_name ON public.stacks (name);
+
+COMMENT ON TABLE public.stacks IS 'Group of workspaces planned and applied together in dependency order';
+
+CREATE TABLE IF NOT EXISTS public.stack_members (
+    id SERIAL PRIMARY KEY,
+    stack_id character varying(36) NOT NULL,
+    workspace_id character varying(50) NOT NULL,
+    depends_on jsonb NOT NULL DEFAULT '[]'::jsonb,
+    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_stack_members_stack_id ON public.stack_members (stack_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stack_members_stack_workspace ON public.stack_members (stack_id, workspace_id);
+
+COMMENT ON COLUMN public.stack_members.depends_on IS 'Explicitly declared upstream members (ws-xxx); merged with dependencies inferred from remote data and run triggers';
+
+CREATE TABLE IF NOT EXISTS public.stack_runs (
+    id character varying(36) PRIMARY KEY,
+    stack_id character varying(36) NOT NULL,
+    status character varying(30) NOT NULL DEFAULT 'planning',
+    description text,
+    message text,
+    created_by character varying(20) NOT NULL,
+    approved_by character varying(20),
+    approved_at timestamp without time zone,
+    completed_at timestamp without time zone,
+    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_stack_runs_stack_id ON public.stack_runs (stack_id);
+CREATE INDEX IF NOT EXISTS idx_stack_runs_status ON public.stack_runs (status);
+
+COMMENT ON TABLE public.stack_runs IS 'Coordinated stack execution: plan all members in topological order, one review, apply in order with stop-on-failure';
+COMMENT ON COLUMN public.stack_runs.status IS 'planning, awaiting_approval, applying, completed, failed, cancelled';
+
+CREATE TABLE IF NOT EXISTS public.stack_run_members (
+    id SERIAL PRIMARY KEY,
+    run_id character varying(36) NOT NULL,
+    workspace_id character varying(50) NOT NULL,
+    layer integer NOT NULL DEFAULT 0,
+    depends_on jsonb NOT NULL DEFAULT '[]'::jsonb,
+    status character varying(20) NOT NULL DEFAULT 'pending',
+    task_id integer,
+    changes_add integer NOT NULL DEFAULT 0,
+    changes_change integer NOT NULL DEFAULT 0,
+    changes_destroy integer NOT NULL DEFAULT 0,
+    error text,
+    started_at timestamp without time zone,
+    completed_at timestamp without time zone
+);
+
+CREATE INDEX IF NOT EXISTS idx_stack_run_members_run_id ON public.stack_run_members (run_id);
+CREATE INDEX IF NOT EXISTS idx_stack_run_members_workspace_id ON public.stack_run_members (workspace_id);
+CREATE INDEX IF NOT EXISTS idx_stack_run_members_status ON public.stack_run_members (status);
+CREATE INDEX IF NOT EXISTS idx_stack_run_members_task_id ON public.stack_run_members (task_id);
+
+COMMENT ON COLUMN public.stack_run_members.depends_on IS 'Upstream members frozen when the run was created';
+COMMENT ON COLUMN public.stack_run_members.status IS 'pending, planning, planned, no_changes, applying, applied, failed, skipped';
@@ -0,0 +1,2 @@
+ALTER TABLE public.task_trigger_executions DROP COLUMN IF EXISTS changed_outputs;
+ALTER TABLE public.run_triggers DROP COLUMN IF EXISTS output_names;
@@ -0,0 +1,11 @@
+-- Output-change-aware run triggers: fire only when watched upstream outputs changed
+
+ALTER TABLE public.run_triggers
+    ADD COLUMN IF NOT EXISTS output_names jsonb;
+
+ALTER TABLE public.task_trigger_executions
+    ADD COLUMN IF NOT EXISTS changed_outputs jsonb;
+
+COMMENT ON COLUMN public.run_triggers.trigger_condition IS 'apply_success: every successful apply; outputs_changed: only when watched outputs changed between state versions';
+COMMENT ON COLUMN public.run_triggers.output_names IS 'Outputs watched by outputs_changed; empty means the outputs the target reads through remote data';
+COMMENT ON COLUMN public.task_trigger_executions.changed_outputs IS 'Watched upstream outputs that changed in the source apply';
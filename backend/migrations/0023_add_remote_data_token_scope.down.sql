@@ -0,0 +1 @@
+ALTER TABLE public.remote_data_tokens DROP COLUMN IF EXISTS scope;
@@ -0,0 +1,6 @@
+-- Remote data via terraform_remote_state: requester-scoped short-lived credentials
+
+ALTER TABLE public.remote_data_tokens
+    ADD COLUMN IF NOT EXISTS scope varchar(20) NOT NULL DEFAULT 'outputs';
+
+COMMENT ON COLUMN public.remote_data_tokens.scope IS 'outputs: reads state-outputs/full of workspace_id only; remote_state: terraform_remote_state credential of the requester, valid for every workspace whose outputs_sharing allows it';
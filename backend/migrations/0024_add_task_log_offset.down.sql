@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_task_logs_offset;
+ALTER TABLE IF EXISTS public.task_logs DROP COLUMN IF EXISTS log_offset;
@@ -0,0 +1,7 @@
+-- Agent log chunks are deduplicated by their offset in the phase log, so identical lines at different offsets are kept
+
+ALTER TABLE IF EXISTS public.task_logs ADD COLUMN IF NOT EXISTS log_offset bigint;
+
+CREATE INDEX IF NOT EXISTS idx_task_logs_offset ON public.task_logs (task_id, phase, log_offset);
+
+COMMENT ON COLUMN public.task_logs.log_offset IS 'Byte offset of the uploaded chunk in the agent phase log; replayed chunks with the same task, phase, offset and checksum are ignored';
@@ -0,0 +1,15 @@
+-- Tokens created before the lifetime policy have no expiry; give them the policy's maximum lifetime from now
+
+UPDATE public.user_tokens
+SET expires_at = now() + make_interval(days => COALESCE(
+    (SELECT (value->>'max_user_token_days')::int FROM public.system_configs
+     WHERE key = 'api_token_policy'),
+    365))
+WHERE expires_at IS NULL;
+
+UPDATE public.team_tokens
+SET expires_at = now() + make_interval(days => COALESCE(
+    (SELECT (value->>'max_team_token_days')::int FROM public.system_configs
+     WHERE key = 'api_token_policy'),
+    365))
+WHERE expires_at IS NULL;
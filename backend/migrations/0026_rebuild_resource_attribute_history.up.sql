@@ -0,0 +1,6 @@
+-- Resource history recorded before sensitive attributes were redacted may contain secrets;
+-- drop it and reset the cursors so the next CMDB sync rebuilds it (redacted) from the retained state versions
+
+DELETE FROM public.resource_attribute_history;
+
+DELETE FROM public.resource_history_cursors;
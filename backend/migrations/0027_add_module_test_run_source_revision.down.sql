@@ -0,0 +1 @@
+ALTER TABLE IF EXISTS public.module_test_runs DROP COLUMN IF EXISTS source_revision;
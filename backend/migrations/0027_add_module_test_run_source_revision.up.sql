@@ -0,0 +1,5 @@
+-- Module test runs record which synced module files they tested, so a pass is not reused after the module is re-synced
+
+ALTER TABLE IF EXISTS public.module_test_runs ADD COLUMN IF NOT EXISTS source_revision character varying(64);
+
+COMMENT ON COLUMN public.module_test_runs.source_revision IS 'SHA-256 of the synced module files used by the run; the default-version gate ignores passing runs whose revision differs from the current files';
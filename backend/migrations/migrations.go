@@ -0,0 +1,14 @@
+// Package migrations 内嵌到服务二进制中的版本化数据库迁移
+//
+// 文件命名：<版本号>_<名称>.up.sql，可选的 <版本号>_<名称>.down.sql 用于回滚。
+// 版本号递增且不可复用；已发布的迁移不能再修改（校验和不一致时服务拒绝启动），需要变更时新增迁移。
+// 没有 down 文件的迁移（如数据修复）不可回滚。每个迁移在单个事务中执行，不要在文件中写 BEGIN/COMMIT。
+// 基线 schema 来自数据库初始化镜像（manifests/db/init_seed_data.sql），迁移均需可在其上重复执行。
+package migrations
+
+import "embed"
+
+// FS 内嵌的迁移文件
+//
+//go:embed *.sql
+var FS embed.FS
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"iac-platform/internal/models"
 	"io"
@@ -37,6 +38,17 @@ type AgentAPIClient struct {
 	token       string
 	httpClient  *http.Client
 	retryConfig RetryConfig
+	spool       *AgentSpool // 本地持久化上传队列，为 nil 时直接上传
+}
+
+// APIStatusError 服务端返回非 2xx 状态码
+type APIStatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIStatusError) Error() string {
+	return fmt.Sprintf("request failed with status %d: %s", e.StatusCode, e.Body)
 }
 
 // NewAgentAPIClient creates a new API client
@@ -91,6 +103,12 @@ func (c *AgentAPIClient) GetTaskData(taskID uint) (map[string]interface{}, error
 func (c *AgentAPIClient) UploadLogChunk(taskID uint, phase, content string, offset int64, checksum string) (int64, error) {
 	path := fmt.Sprintf("/api/v1/agents/tasks/%d/logs/chunk", taskID)
 
+	// checksum 同时作为服务端去重的幂等键，spool 重放时不会重复追加日志；
+	// 包含偏移，内容相同但位置不同的日志块（如重复输出的同一行）不会被当作重放丢弃
+	if checksum == "" {
+		checksum = spoolChecksum([]byte(fmt.Sprintf("%s\n%d\n%s", phase, offset, content)))
+	}
+
 	reqBody := map[string]interface{}{
 		"phase":    phase,
 		"content":  content,
@@ -98,7 +116,8 @@ func (c *AgentAPIClient) UploadLogChunk(taskID uint, phase, content string, offs
 		"checksum": checksum,
 	}
 
-	respBody, err := c.doRequest("POST", path, reqBody)
+	key := fmt.Sprintf("%s-%d-%s", phase, offset, checksum[:16])
+	respBody, err := c.doSpooledRequest(SpoolKindLog, taskID, key, "POST", path, reqBody, 0)
 	if err != nil {
 		return 0, fmt.Errorf("failed to upload log chunk: %w", err)
 	}
@@ -187,7 +206,7 @@ func (c *AgentAPIClient) doRequest(method, path string, body interface{}) (map[s
 
 	// Check status code
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(respData))
+		return nil, &APIStatusError{StatusCode: resp.StatusCode, Body: string(respData)}
 	}
 
 	// Parse response
@@ -547,6 +566,67 @@ func (c *AgentAPIClient) GetTerraformVersion(version string) (*models.TerraformV
 	return tfVersion, nil
 }
 
+// GetModuleTestBundle retrieves module files and generated tests for a test task
+func (c *AgentAPIClient) GetModuleTestBundle(taskID uint) (*ModuleTestBundle, error) {
+	path := fmt.Sprintf("/api/v1/agents/tasks/%d/module-test-bundle", taskID)
+
+	respBody, err := c.doRequestWithRetry("GET", path, nil, 3)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get module test bundle: %w", err)
+	}
+
+	data, err := json.Marshal(respBody["bundle"])
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode module test bundle: %w", err)
+	}
+	bundle := &ModuleTestBundle{}
+	if err := json.Unmarshal(data, bundle); err != nil {
+		return nil, fmt.Errorf("failed to parse module test bundle: %w", err)
+	}
+
+	return bundle, nil
+}
+
+// UploadModuleTestReport uploads parsed terraform test results for a test task
+func (c *AgentAPIClient) UploadModuleTestReport(taskID uint, report *ModuleTestReport) error {
+	path := fmt.Sprintf("/api/v1/agents/tasks/%d/module-test-result", taskID)
+
+	if _, err := c.doRequestWithRetry("POST", path, report, 3); err != nil {
+		return fmt.Errorf("failed to upload module test result: %w", err)
+	}
+
+	return nil
+}
+
+// UploadStaticAnalysisResults uploads pre-plan static analysis results for a task
+func (c *AgentAPIClient) UploadStaticAnalysisResults(taskID uint, results []models.TaskStaticAnalysisResult) error {
+	path := fmt.Sprintf("/api/v1/agents/tasks/%d/static-analysis-results", taskID)
+
+	payload := map[string]interface{}{
+		"results": results,
+	}
+
+	if _, err := c.doRequestWithRetry("POST", path, payload, 3); err != nil {
+		return fmt.Errorf("failed to upload static analysis results: %w", err)
+	}
+
+	return nil
+}
+
+// RequestCostEstimate asks the server to estimate the cost of the task's uploaded plan JSON
+// Returns the estimate summary, or nil when cost estimation is disabled for the workspace
+func (c *AgentAPIClient) RequestCostEstimate(taskID uint) (map[string]interface{}, error) {
+	path := fmt.Sprintf("/api/v1/agents/tasks/%d/cost-estimate", taskID)
+
+	respBody, err := c.doRequestWithRetry("POST", path, map[string]interface{}{}, 3)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request cost estimate: %w", err)
+	}
+
+	summary, _ := respBody["cost_estimate"].(map[string]interface{})
+	return summary, nil
+}
+
 // GetPoolSecrets retrieves HCP secrets for the agent's pool
 func (c *AgentAPIClient) GetPoolSecrets() (map[string]interface{}, error) {
 	path := "/api/v1/agents/pool/secrets"
@@ -717,7 +797,7 @@ func (c *AgentAPIClient) doRequestWithRetry(method, path string, body interface{
 
 		// Check status code
 		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-			lastErr = fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(respData))
+			lastErr = &APIStatusError{StatusCode: resp.StatusCode, Body: string(respData)}
 
 			// 判断是否可以重试（5xx 错误）
 			if attempt < maxRetries && c.isRetryableError(nil, resp.StatusCode) {
@@ -812,6 +892,9 @@ func (c *AgentAPIClient) parsePlanTaskResponse(respBody map[string]interface{})
 		if planHash, ok := taskData["plan_hash"].(string); ok && planHash != "" {
 			task.PlanHash = planHash
 		}
+		task.ChangesAdd = getInt(taskData, "changes_add")
+		task.ChangesChange = getInt(taskData, "changes_change")
+		task.ChangesDestroy = getInt(taskData, "changes_destroy")
 
 		// 解析快照字段
 		if snapshotCreatedAt, ok := taskData["snapshot_created_at"].(string); ok && snapshotCreatedAt != "" {
@@ -906,7 +989,7 @@ func (c *AgentAPIClient) SaveTaskStateWithRetry(taskID uint, content map[string]
 		"size":     size,
 	}
 
-	_, err := c.doRequestWithRetry("POST", path, reqBody, c.retryConfig.MaxRetries)
+	_, err := c.doSpooledRequest(SpoolKindState, taskID, "", "POST", path, reqBody, c.retryConfig.MaxRetries)
 	if err != nil {
 		return fmt.Errorf("failed to save state: %w", err)
 	}
@@ -926,7 +1009,7 @@ func (c *AgentAPIClient) UpdateTaskStatusWithRetry(taskID uint, status string, u
 		reqBody[k] = v
 	}
 
-	_, err := c.doRequestWithRetry("PUT", path, reqBody, c.retryConfig.MaxRetries)
+	_, err := c.doSpooledRequest(SpoolKindStatus, taskID, "", "PUT", path, reqBody, c.retryConfig.MaxRetries)
 	if err != nil {
 		return fmt.Errorf("failed to update task status: %w", err)
 	}
@@ -942,7 +1025,7 @@ func (c *AgentAPIClient) UploadPlanDataWithRetry(taskID uint, encodedData string
 		"plan_data": encodedData,
 	}
 
-	_, err := c.doRequestWithRetry("POST", path, reqBody, c.retryConfig.MaxRetries)
+	_, err := c.doSpooledRequest(SpoolKindPlanData, taskID, "", "POST", path, reqBody, c.retryConfig.MaxRetries)
 	if err != nil {
 		return fmt.Errorf("failed to upload plan data: %w", err)
 	}
@@ -958,10 +1041,111 @@ func (c *AgentAPIClient) UploadPlanJSONWithRetry(taskID uint, planJSON map[strin
 		"plan_json": planJSON,
 	}
 
-	_, err := c.doRequestWithRetry("POST", path, reqBody, c.retryConfig.MaxRetries)
+	_, err := c.doSpooledRequest(SpoolKindPlanJSON, taskID, "", "POST", path, reqBody, c.retryConfig.MaxRetries)
 	if err != nil {
 		return fmt.Errorf("failed to upload plan json: %w", err)
 	}
 
 	return nil
 }
+
+// ============================================================================
+// 本地 spool：State/Plan/日志/状态在上传前先持久化，服务端不可用时不丢失
+// ============================================================================
+
+// SetSpool 启用本地持久化上传队列
+func (c *AgentAPIClient) SetSpool(spool *AgentSpool) {
+	c.spool = spool
+}
+
+// Spool 返回本地持久化上传队列（未启用时为 nil）
+func (c *AgentAPIClient) Spool() *AgentSpool {
+	return c.spool
+}
+
+// doSpooledRequest 先将请求写入 spool 再上传
+// 上传成功后确认删除；服务端不可达或 5xx 时保留条目并返回 ErrUploadPending；服务端明确拒绝时移入 rejected/
+func (c *AgentAPIClient) doSpooledRequest(kind string, taskID uint, key, method, path string, body interface{}, maxRetries int) (map[string]interface{}, error) {
+	if c.spool == nil {
+		return c.doRequestWithRetry(method, path, body, maxRetries)
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request body: %w", err)
+	}
+
+	item, spoolErr := c.spool.Put(kind, taskID, key, method, path, data)
+	if spoolErr != nil {
+		// spool 不可用（如磁盘满）时退化为直接上传
+		log.Printf("[Spool] Failed to spool %s for task %d, uploading directly: %v", kind, taskID, spoolErr)
+		return c.doRequestWithRetry(method, path, json.RawMessage(data), maxRetries)
+	}
+
+	result, err := c.doRequestWithRetry(method, path, json.RawMessage(data), maxRetries)
+	if err != nil {
+		if isSpoolRejection(err) {
+			c.spool.reject(item, err.Error())
+			return nil, err
+		}
+		c.spool.recordFailure(item, err.Error())
+		log.Printf("[Spool] %s for task %d kept in spool (%s) until the server is reachable: %v", kind, taskID, item.ID, err)
+		return nil, fmt.Errorf("%w: %v", ErrUploadPending, err)
+	}
+
+	if err := c.spool.Ack(item); err != nil {
+		log.Printf("[Spool] Failed to ack %s: %v", item.ID, err)
+	}
+	return result, nil
+}
+
+// ReplaySpool 按写入顺序重放 spool 中的待上传条目，返回成功上传的条数
+// 遇到连接错误或 5xx 立即停止（服务端仍不可用），被服务端明确拒绝的条目移入 rejected/
+func (c *AgentAPIClient) ReplaySpool() (int, error) {
+	if c.spool == nil {
+		return 0, nil
+	}
+	// 同一时间只允许一个重放
+	if !c.spool.replayMu.TryLock() {
+		return 0, nil
+	}
+	defer c.spool.replayMu.Unlock()
+
+	items, err := c.spool.Pending()
+	if err != nil {
+		return 0, err
+	}
+
+	replayed := 0
+	for _, item := range items {
+		if _, err := c.doRequestWithRetry(item.Method, item.Path, item.Body, 0); err != nil {
+			if isSpoolRejection(err) {
+				log.Printf("[Spool] Server rejected %s, moved to rejected: %v", item.ID, err)
+				c.spool.reject(item, err.Error())
+				continue
+			}
+			c.spool.recordFailure(item, err.Error())
+			return replayed, fmt.Errorf("%w: %v", ErrUploadPending, err)
+		}
+
+		if err := c.spool.Ack(item); err != nil {
+			log.Printf("[Spool] Failed to ack %s: %v", item.ID, err)
+		}
+		replayed++
+		log.Printf("[Spool] Replayed %s for task %d", item.Kind, item.TaskID)
+	}
+	return replayed, nil
+}
+
+// isSpoolRejection 服务端明确拒绝（重放也不会成功）的错误；认证、限流、超时类 4xx 仍保留重试
+func isSpoolRejection(err error) bool {
+	var statusErr *APIStatusError
+	if !errors.As(err, &statusErr) {
+		return false
+	}
+	switch statusErr.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return false
+	}
+	return statusErr.StatusCode >= 400 && statusErr.StatusCode < 500
+}
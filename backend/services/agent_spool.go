@@ -0,0 +1,279 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrUploadPending 上传失败，但数据已写入 Agent 本地 spool，将在与服务端恢复连接后重放
+var ErrUploadPending = errors.New("upload pending in agent spool")
+
+// Spool 条目类型
+const (
+	SpoolKindState    = "state"     // State 快照（最关键）
+	SpoolKindLog      = "log"       // 日志块
+	SpoolKindPlanData = "plan_data" // Plan 二进制
+	SpoolKindPlanJSON = "plan_json" // Plan JSON
+	SpoolKindStatus   = "status"    // 任务状态更新
+)
+
+// spoolSupersedes 后写覆盖前写的条目类型：直接上传成功后，同一任务更早的待重放条目不再需要
+var spoolSupersedes = map[string]bool{
+	SpoolKindPlanData: true,
+	SpoolKindPlanJSON: true,
+	SpoolKindStatus:   true,
+}
+
+// SpoolItem 一个待上传的请求
+// Body 为原始请求体，Checksum 为 Body 的 SHA256，重放前校验，防止磁盘损坏的数据被上传
+type SpoolItem struct {
+	ID        string          `json:"id"`
+	Kind      string          `json:"kind"`
+	TaskID    uint            `json:"task_id"`
+	Method    string          `json:"method"`
+	Path      string          `json:"path"`
+	Body      json.RawMessage `json:"body"`
+	Checksum  string          `json:"checksum"`
+	Seq       int64           `json:"seq"` // 写入顺序，重放按此排序
+	CreatedAt time.Time       `json:"created_at"`
+	Attempts  int             `json:"attempts"`
+	LastError string          `json:"last_error,omitempty"`
+}
+
+// AgentSpool Agent 本地的持久化上传队列
+// 每个条目是目录下的一个 JSON 文件（临时文件 + fsync + rename 原子写入），Agent 进程崩溃或重启后仍可重放
+// 服务端拒绝（4xx）的条目移入 rejected/ 子目录保留，供人工恢复，不会被删除
+type AgentSpool struct {
+	dir      string
+	mu       sync.Mutex
+	lastSeq  int64
+	replayMu sync.Mutex
+}
+
+// NewAgentSpool 创建 spool，目录不存在时自动创建，并清理上次崩溃遗留的临时文件
+func NewAgentSpool(dir string) (*AgentSpool, error) {
+	if err := os.MkdirAll(filepath.Join(dir, "rejected"), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create spool dir: %w", err)
+	}
+
+	tmpFiles, _ := filepath.Glob(filepath.Join(dir, "*.tmp"))
+	for _, f := range tmpFiles {
+		os.Remove(f)
+	}
+
+	return &AgentSpool{dir: dir}, nil
+}
+
+// Dir 返回 spool 目录
+func (s *AgentSpool) Dir() string {
+	return s.dir
+}
+
+// Put 持久化一个请求；同类型、同任务、同 key 的条目已存在时直接返回已有条目（幂等）
+// key 为请求的幂等键（如日志块的阶段和偏移），为空时使用请求体的 SHA256
+func (s *AgentSpool) Put(kind string, taskID uint, key, method, path string, body []byte) (*SpoolItem, error) {
+	checksum := spoolChecksum(body)
+	if key == "" {
+		key = checksum[:16]
+	}
+	id := fmt.Sprintf("%s-%d-%s", kind, taskID, key)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, err := s.load(s.itemPath(id)); err == nil {
+		return existing, nil
+	}
+
+	seq := time.Now().UnixNano()
+	if seq <= s.lastSeq {
+		seq = s.lastSeq + 1
+	}
+	s.lastSeq = seq
+
+	item := &SpoolItem{
+		ID:        id,
+		Kind:      kind,
+		TaskID:    taskID,
+		Method:    method,
+		Path:      path,
+		Body:      json.RawMessage(body),
+		Checksum:  checksum,
+		Seq:       seq,
+		CreatedAt: time.Now(),
+	}
+	if err := s.write(item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// Ack 上传成功，删除条目；对覆盖型条目同时删除同任务更早的条目
+func (s *AgentSpool) Ack(item *SpoolItem) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if spoolSupersedes[item.Kind] {
+		items, err := s.list()
+		if err != nil {
+			return err
+		}
+		for _, other := range items {
+			if other.Kind == item.Kind && other.TaskID == item.TaskID && other.Seq < item.Seq {
+				os.Remove(s.itemPath(other.ID))
+			}
+		}
+	}
+
+	if err := os.Remove(s.itemPath(item.ID)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove spool item: %w", err)
+	}
+	return nil
+}
+
+// Pending 按写入顺序返回所有待上传条目
+func (s *AgentSpool) Pending() ([]*SpoolItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.list()
+}
+
+// HasPending 是否有待上传的条目；taskID 为 0 时检查所有任务
+func (s *AgentSpool) HasPending(taskID uint) bool {
+	items, err := s.Pending()
+	if err != nil {
+		return false
+	}
+	for _, item := range items {
+		if taskID == 0 || item.TaskID == taskID {
+			return true
+		}
+	}
+	return false
+}
+
+// reject 服务端明确拒绝的条目移入 rejected/，保留数据
+func (s *AgentSpool) reject(item *SpoolItem, reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item.LastError = reason
+	if data, err := json.Marshal(item); err == nil {
+		os.WriteFile(filepath.Join(s.dir, "rejected", item.ID+".json"), data, 0600)
+	}
+	os.Remove(s.itemPath(item.ID))
+}
+
+// recordFailure 记录一次重放失败（保留条目）
+func (s *AgentSpool) recordFailure(item *SpoolItem, reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 条目可能已被并发的直接上传确认删除
+	if _, err := os.Stat(s.itemPath(item.ID)); err != nil {
+		return
+	}
+	item.Attempts++
+	item.LastError = reason
+	if err := s.write(item); err != nil {
+		log.Printf("[Spool] Failed to record replay failure for %s: %v", item.ID, err)
+	}
+}
+
+func (s *AgentSpool) itemPath(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+// write 原子写入：临时文件 + fsync + rename
+func (s *AgentSpool) write(item *SpoolItem) error {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return fmt.Errorf("failed to marshal spool item: %w", err)
+	}
+
+	tmpPath := s.itemPath(item.ID) + ".tmp"
+	f, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create spool file: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write spool file: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to sync spool file: %w", err)
+	}
+	f.Close()
+
+	if err := os.Rename(tmpPath, s.itemPath(item.ID)); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to commit spool file: %w", err)
+	}
+
+	// 目录 fsync，确保 rename 本身落盘
+	if d, err := os.Open(s.dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
+
+func (s *AgentSpool) load(path string) (*SpoolItem, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var item SpoolItem
+	if err := json.Unmarshal(data, &item); err != nil {
+		return nil, fmt.Errorf("corrupted spool file %s: %w", filepath.Base(path), err)
+	}
+	if spoolChecksum(item.Body) != item.Checksum {
+		return nil, fmt.Errorf("checksum mismatch in spool file %s", filepath.Base(path))
+	}
+	return &item, nil
+}
+
+// list 读取所有条目；损坏的文件移入 rejected/，不阻塞其他条目
+func (s *AgentSpool) list() ([]*SpoolItem, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spool dir: %w", err)
+	}
+
+	items := make([]*SpoolItem, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(s.dir, entry.Name())
+		item, err := s.load(path)
+		if err != nil {
+			log.Printf("[Spool] %v, moving to rejected", err)
+			os.Rename(path, filepath.Join(s.dir, "rejected", entry.Name()))
+			continue
+		}
+		items = append(items, item)
+	}
+
+	sort.Slice(items, func(i, j int) bool { return items[i].Seq < items[j].Seq })
+	return items, nil
+}
+
+// spoolChecksum 计算请求体的 SHA256
+func spoolChecksum(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
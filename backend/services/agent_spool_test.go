@@ -0,0 +1,328 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"iac-platform/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// standInServer 模拟服务端的 Agent API，记录收到的上传
+type standInServer struct {
+	mu       sync.Mutex
+	requests []string
+	states   map[string]int // checksum -> 收到次数
+	status   int            // 非 0 时所有上传返回该状态码
+}
+
+func newStandInServer() *standInServer {
+	return &standInServer{states: make(map[string]int)}
+}
+
+func (s *standInServer) handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		body, _ := io.ReadAll(r.Body)
+		s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+		if s.status != 0 {
+			w.WriteHeader(s.status)
+			w.Write([]byte(`{"error":"rejected"}`))
+			return
+		}
+
+		switch r.URL.Path {
+		case "/api/v1/agents/workspaces/ws-spool/state/max-version":
+			w.Write([]byte(`{"max_version": 3}`))
+		case "/api/v1/agents/tasks/42/state":
+			var req struct {
+				Checksum string `json:"checksum"`
+			}
+			json.Unmarshal(body, &req)
+			s.states[req.Checksum]++
+			w.Write([]byte(`{"version": 4}`))
+		case "/api/v1/agents/tasks/42/logs/chunk":
+			w.Write([]byte(`{"next_offset": 10}`))
+		default:
+			w.Write([]byte(`{}`))
+		}
+	})
+}
+
+func (s *standInServer) stateCount(checksum string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.states[checksum]
+}
+
+func newSpoolTestClient(t *testing.T, baseURL string) (*AgentAPIClient, *AgentSpool) {
+	spool, err := NewAgentSpool(t.TempDir())
+	require.NoError(t, err)
+
+	client := NewAgentAPIClient(baseURL, "test-token")
+	client.retryConfig.MaxRetries = 1
+	client.retryConfig.BaseDelay = time.Millisecond
+	client.retryConfig.MaxDelay = time.Millisecond
+	client.SetSpool(spool)
+	return client, spool
+}
+
+func TestAgentSpool_PutIsIdempotentAndOrdered(t *testing.T) {
+	spool, err := NewAgentSpool(t.TempDir())
+	require.NoError(t, err)
+
+	first, err := spool.Put(SpoolKindLog, 1, "", "POST", "/logs", []byte(`{"content":"a"}`))
+	require.NoError(t, err)
+	second, err := spool.Put(SpoolKindState, 1, "", "POST", "/state", []byte(`{"content":"b"}`))
+	require.NoError(t, err)
+	again, err := spool.Put(SpoolKindLog, 1, "", "POST", "/logs", []byte(`{"content":"a"}`))
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, again.ID)
+	assert.Equal(t, first.Seq, again.Seq)
+
+	items, err := spool.Pending()
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.Equal(t, first.ID, items[0].ID)
+	assert.Equal(t, second.ID, items[1].ID)
+	assert.True(t, spool.HasPending(1))
+	assert.False(t, spool.HasPending(2))
+
+	require.NoError(t, spool.Ack(first))
+	items, err = spool.Pending()
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, second.ID, items[0].ID)
+}
+
+func TestAgentSpool_AckSupersedesOlderStatusUpdates(t *testing.T) {
+	spool, err := NewAgentSpool(t.TempDir())
+	require.NoError(t, err)
+
+	running, err := spool.Put(SpoolKindStatus, 7, "", "PUT", "/status", []byte(`{"status":"running"}`))
+	require.NoError(t, err)
+	state, err := spool.Put(SpoolKindState, 7, "", "POST", "/state", []byte(`{"content":{}}`))
+	require.NoError(t, err)
+	final, err := spool.Put(SpoolKindStatus, 7, "", "PUT", "/status", []byte(`{"status":"state_pending_upload"}`))
+	require.NoError(t, err)
+
+	// 最终状态直接上传成功后，更早的状态更新不应再被重放（避免把任务改回 running）
+	require.NoError(t, spool.Ack(final))
+
+	items, err := spool.Pending()
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, state.ID, items[0].ID)
+	assert.NotEqual(t, running.ID, items[0].ID)
+}
+
+func TestAgentSpool_CorruptedItemIsQuarantined(t *testing.T) {
+	dir := t.TempDir()
+	spool, err := NewAgentSpool(dir)
+	require.NoError(t, err)
+
+	item, err := spool.Put(SpoolKindState, 3, "", "POST", "/state", []byte(`{"content":{"serial":1}}`))
+	require.NoError(t, err)
+
+	// 篡改请求体，使 checksum 不匹配
+	path := filepath.Join(dir, item.ID+".json")
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var raw map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &raw))
+	raw["body"] = map[string]interface{}{"content": map[string]interface{}{"serial": 2}}
+	data, _ = json.Marshal(raw)
+	require.NoError(t, os.WriteFile(path, data, 0600))
+
+	items, err := spool.Pending()
+	require.NoError(t, err)
+	assert.Empty(t, items)
+	_, err = os.Stat(filepath.Join(dir, "rejected", item.ID+".json"))
+	assert.NoError(t, err)
+}
+
+func TestAgentAPIClient_StateSurvivesServerOutage(t *testing.T) {
+	server := newStandInServer()
+	srv := httptest.NewServer(server.handler())
+	client, spool := newSpoolTestClient(t, srv.URL)
+
+	content := map[string]interface{}{"serial": float64(5), "resources": []interface{}{}}
+
+	// 服务端在 Apply 过程中被杀掉
+	srv.Close()
+	err := client.SaveTaskStateWithRetry(42, content, "sum-1", 64)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrUploadPending))
+	assert.True(t, spool.HasPending(42))
+
+	// 同一 State 的重复保存不会产生新的 spool 条目
+	err = client.SaveTaskStateWithRetry(42, content, "sum-1", 64)
+	assert.True(t, errors.Is(err, ErrUploadPending))
+	items, err := spool.Pending()
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, SpoolKindState, items[0].Kind)
+	assert.GreaterOrEqual(t, items[0].Attempts, 1)
+
+	// 服务端恢复后重放
+	restarted := httptest.NewServer(server.handler())
+	defer restarted.Close()
+	client.baseURL = restarted.URL
+
+	replayed, err := client.ReplaySpool()
+	require.NoError(t, err)
+	assert.Equal(t, 1, replayed)
+	assert.Equal(t, 1, server.stateCount("sum-1"))
+	assert.False(t, spool.HasPending(0))
+
+	// 再次重放无事可做
+	replayed, err = client.ReplaySpool()
+	require.NoError(t, err)
+	assert.Equal(t, 0, replayed)
+}
+
+func TestAgentAPIClient_UploadAcksSpoolOnSuccess(t *testing.T) {
+	server := newStandInServer()
+	srv := httptest.NewServer(server.handler())
+	defer srv.Close()
+	client, spool := newSpoolTestClient(t, srv.URL)
+
+	nextOffset, err := client.UploadLogChunk(42, "apply", "apply done", 0, "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), nextOffset)
+
+	require.NoError(t, client.SaveTaskStateWithRetry(42, map[string]interface{}{"serial": float64(1)}, "sum-ok", 10))
+	assert.Equal(t, 1, server.stateCount("sum-ok"))
+	assert.False(t, spool.HasPending(0))
+}
+
+func TestAgentAPIClient_ReplayStopsWhileServerDown(t *testing.T) {
+	server := newStandInServer()
+	srv := httptest.NewServer(server.handler())
+	client, spool := newSpoolTestClient(t, srv.URL)
+	srv.Close()
+
+	_, err := client.UploadLogChunk(42, "apply", "line 1", 0, "")
+	assert.True(t, errors.Is(err, ErrUploadPending))
+	err = client.SaveTaskStateWithRetry(42, map[string]interface{}{"serial": float64(2)}, "sum-2", 10)
+	assert.True(t, errors.Is(err, ErrUploadPending))
+
+	replayed, err := client.ReplaySpool()
+	assert.True(t, errors.Is(err, ErrUploadPending))
+	assert.Equal(t, 0, replayed)
+
+	items, err := spool.Pending()
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.Equal(t, SpoolKindLog, items[0].Kind)
+	assert.Equal(t, SpoolKindState, items[1].Kind)
+}
+
+func TestAgentAPIClient_ReplayQuarantinesRejectedItems(t *testing.T) {
+	server := newStandInServer()
+	srv := httptest.NewServer(server.handler())
+	client, spool := newSpoolTestClient(t, srv.URL)
+	srv.Close()
+
+	err := client.SaveTaskStateWithRetry(42, map[string]interface{}{"serial": float64(3)}, "sum-3", 10)
+	require.True(t, errors.Is(err, ErrUploadPending))
+
+	// 任务在服务端已不存在：条目移入 rejected/ 保留，不再阻塞后续重放
+	server.status = http.StatusNotFound
+	restarted := httptest.NewServer(server.handler())
+	defer restarted.Close()
+	client.baseURL = restarted.URL
+
+	replayed, err := client.ReplaySpool()
+	require.NoError(t, err)
+	assert.Equal(t, 0, replayed)
+	assert.False(t, spool.HasPending(0))
+
+	rejected, err := filepath.Glob(filepath.Join(spool.Dir(), "rejected", "*.json"))
+	require.NoError(t, err)
+	assert.Len(t, rejected, 1)
+}
+
+func TestTerraformExecutor_SaveStateKeepsStateInSpoolWhenServerDown(t *testing.T) {
+	server := newStandInServer()
+	srv := httptest.NewServer(server.handler())
+	client, spool := newSpoolTestClient(t, srv.URL)
+
+	accessor := NewRemoteDataAccessor(client)
+	accessor.taskData = map[string]interface{}{
+		"task": map[string]interface{}{"id": float64(42), "workspace_id": "ws-spool"},
+	}
+	streamManager := NewOutputStreamManager()
+	executor := NewTerraformExecutorWithAccessor(accessor, streamManager)
+
+	workDir := t.TempDir()
+	stateData := []byte(`{"version":4,"serial":7,"lineage":"l-1","resources":[]}`)
+	require.NoError(t, os.WriteFile(filepath.Join(workDir, "terraform.tfstate"), stateData, 0600))
+
+	createdBy := "user-1"
+	workspace := &models.Workspace{WorkspaceID: "ws-spool"}
+	task := &models.WorkspaceTask{ID: 42, WorkspaceID: "ws-spool", CreatedBy: &createdBy}
+	logger := NewTerraformLoggerWithLevelAndMode(streamManager.GetOrCreate(42), "info", true)
+
+	// Apply 结束前服务端宕机
+	srv.Close()
+	err := executor.SaveNewStateVersionWithLogging(workspace, task, workDir, logger)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrUploadPending))
+	assert.True(t, spool.HasPending(42))
+
+	restarted := httptest.NewServer(server.handler())
+	defer restarted.Close()
+	client.baseURL = restarted.URL
+
+	replayed, err := client.ReplaySpool()
+	require.NoError(t, err)
+	assert.Equal(t, 1, replayed)
+	assert.Equal(t, 1, server.stateCount(executor.calculateChecksum(stateData)))
+}
+
+func TestRemoteDataAccessor_IdenticalLogChunksAreNotDropped(t *testing.T) {
+	server := newStandInServer()
+	srv := httptest.NewServer(server.handler())
+	client, spool := newSpoolTestClient(t, srv.URL)
+	srv.Close()
+
+	// 同一行输出两次，离线时都应进入 spool
+	accessor := NewRemoteDataAccessor(client)
+	for i := 0; i < 2; i++ {
+		err := accessor.SaveTaskLog(42, "apply", "Still creating...\n", "info")
+		assert.True(t, errors.Is(err, ErrUploadPending))
+	}
+
+	items, err := spool.Pending()
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.NotEqual(t, items[0].ID, items[1].ID)
+
+	var first, second struct {
+		Offset   int64  `json:"offset"`
+		Checksum string `json:"checksum"`
+	}
+	require.NoError(t, json.Unmarshal(items[0].Body, &first))
+	require.NoError(t, json.Unmarshal(items[1].Body, &second))
+	assert.Equal(t, int64(0), first.Offset)
+	assert.Equal(t, int64(len("Still creating...\n")), second.Offset)
+	assert.NotEqual(t, first.Checksum, second.Checksum)
+
+	// 重放同一日志块仍是幂等的
+	again, err := spool.Put(SpoolKindLog, 42, items[0].ID[len("log-42-"):], items[0].Method, items[0].Path, items[0].Body)
+	require.NoError(t, err)
+	assert.Equal(t, items[0].ID, again.ID)
+}
@@ -0,0 +1,370 @@
+package services
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"iac-platform/internal/crypto"
+	"iac-platform/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"gorm.io/gorm"
+)
+
+// ArtifactStorageConfigKey 产物存储配置在 system_configs 中的 key
+const ArtifactStorageConfigKey = "artifact_storage"
+
+// DefaultArtifactLocalPath 未配置时使用的本地存储目录
+const DefaultArtifactLocalPath = "/var/lib/iac-platform/artifacts"
+
+// ErrArtifactNotFound 对象不存在
+var ErrArtifactNotFound = errors.New("artifact not found")
+
+// ArtifactStorage 任务产物存储接口（日志、Plan 文件、Plan JSON、State 备份）
+// 存取的都是已压缩的字节，压缩由调用方通过 compressArtifact/decompressArtifact 处理
+type ArtifactStorage interface {
+	Put(ctx context.Context, key string, data []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+	Type() string
+}
+
+// NewArtifactStorage 根据配置创建存储
+func NewArtifactStorage(cfg *models.ArtifactStorageConfig) (ArtifactStorage, error) {
+	switch cfg.Type {
+	case "", models.ArtifactStorageLocal:
+		root := cfg.LocalPath
+		if root == "" {
+			root = DefaultArtifactLocalPath
+		}
+		return NewLocalArtifactStorage(root), nil
+	case models.ArtifactStorageS3:
+		return newS3ArtifactStorage(cfg)
+	default:
+		return nil, fmt.Errorf("unsupported artifact storage type: %s", cfg.Type)
+	}
+}
+
+// LoadArtifactStorageConfig 读取产物存储配置；未配置时返回默认的本地存储配置
+func LoadArtifactStorageConfig(db *gorm.DB) (*models.ArtifactStorageConfig, error) {
+	cfg := &models.ArtifactStorageConfig{Type: models.ArtifactStorageLocal}
+
+	var sc models.SystemConfig
+	if err := db.Where("key = ?", ArtifactStorageConfigKey).First(&sc).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return cfg, nil
+		}
+		return nil, fmt.Errorf("failed to load artifact storage config: %w", err)
+	}
+	if err := json.Unmarshal([]byte(sc.Value), cfg); err != nil {
+		return nil, fmt.Errorf("invalid artifact storage config: %w", err)
+	}
+	return cfg, nil
+}
+
+// SaveArtifactStorageConfig 保存产物存储配置
+func SaveArtifactStorageConfig(db *gorm.DB, cfg *models.ArtifactStorageConfig) error {
+	value, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+
+	var existing models.SystemConfig
+	err = db.Where("key = ?", ArtifactStorageConfigKey).First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return db.Create(&models.SystemConfig{
+			Key:         ArtifactStorageConfigKey,
+			Value:       string(value),
+			Description: "任务产物归档存储配置",
+			UpdatedAt:   time.Now(),
+		}).Error
+	}
+	if err != nil {
+		return err
+	}
+	existing.Value = string(value)
+	existing.UpdatedAt = time.Now()
+	return db.Save(&existing).Error
+}
+
+// ============================================================================
+// 压缩
+// ============================================================================
+
+// compressArtifact gzip 压缩
+func compressArtifact(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decompressArtifact gzip 解压
+func decompressArtifact(data []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open gzip artifact: %w", err)
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
+
+// artifactChecksum 原始内容的 SHA256
+func artifactChecksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// ============================================================================
+// 本地文件系统
+// ============================================================================
+
+// LocalArtifactStorage 本地文件系统存储，适合单实例部署或挂载的共享卷
+type LocalArtifactStorage struct {
+	root string
+}
+
+// NewLocalArtifactStorage 创建本地存储
+func NewLocalArtifactStorage(root string) *LocalArtifactStorage {
+	return &LocalArtifactStorage{root: root}
+}
+
+func (s *LocalArtifactStorage) path(key string) (string, error) {
+	clean := filepath.Clean("/" + key)
+	if clean == "/" {
+		return "", fmt.Errorf("invalid artifact key: %q", key)
+	}
+	return filepath.Join(s.root, clean), nil
+}
+
+// Put 写入对象（临时文件 + rename）
+func (s *LocalArtifactStorage) Put(ctx context.Context, key string, data []byte) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create artifact dir: %w", err)
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write artifact: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to commit artifact: %w", err)
+	}
+	return nil
+}
+
+// Get 读取对象
+func (s *LocalArtifactStorage) Get(ctx context.Context, key string) ([]byte, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrArtifactNotFound
+	}
+	return data, err
+}
+
+// Delete 删除对象，不存在时不报错
+func (s *LocalArtifactStorage) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Type 存储类型
+func (s *LocalArtifactStorage) Type() string {
+	return models.ArtifactStorageLocal
+}
+
+// ============================================================================
+// S3 兼容存储
+// ============================================================================
+
+// S3ArtifactStorage S3 兼容对象存储（AWS S3、MinIO、Ceph RGW 等）
+// 只用到 PUT/GET/DELETE Object，直接发送 SigV4 签名的 HTTP 请求
+type S3ArtifactStorage struct {
+	endpoint     *url.URL
+	bucket       string
+	prefix       string
+	region       string
+	usePathStyle bool
+	credentials  aws.CredentialsProvider
+	signer       *v4.Signer
+	client       *http.Client
+}
+
+func newS3ArtifactStorage(cfg *models.ArtifactStorageConfig) (*S3ArtifactStorage, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("s3 artifact storage requires a bucket")
+	}
+	region := cfg.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", region)
+	}
+	u, err := url.Parse(strings.TrimRight(endpoint, "/"))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid s3 endpoint: %s", endpoint)
+	}
+
+	var provider aws.CredentialsProvider
+	if cfg.AccessKeyID != "" {
+		secret := ""
+		if cfg.SecretAccessKeyEncrypted != "" {
+			secret, err = crypto.DecryptValue(cfg.SecretAccessKeyEncrypted)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decrypt s3 secret: %w", err)
+			}
+		}
+		provider = aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
+			return aws.Credentials{AccessKeyID: cfg.AccessKeyID, SecretAccessKey: secret}, nil
+		})
+	} else {
+		// 未配置密钥时使用默认凭证链（环境变量、IRSA、实例角色）
+		awsCfg, err := awsconfig.LoadDefaultConfig(context.Background(), awsconfig.WithRegion(region))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load default aws credentials: %w", err)
+		}
+		provider = awsCfg.Credentials
+	}
+
+	return &S3ArtifactStorage{
+		endpoint:     u,
+		bucket:       cfg.Bucket,
+		prefix:       strings.Trim(cfg.Prefix, "/"),
+		region:       region,
+		usePathStyle: cfg.UsePathStyle,
+		credentials:  provider,
+		// S3 的规范请求路径不做二次编码
+		signer: v4.NewSigner(func(o *v4.SignerOptions) { o.DisableURIPathEscaping = true }),
+		client: &http.Client{Timeout: 60 * time.Second},
+	}, nil
+}
+
+// objectURL 构造对象地址（path-style 或 virtual-hosted-style）
+func (s *S3ArtifactStorage) objectURL(key string) string {
+	if s.prefix != "" {
+		key = s.prefix + "/" + key
+	}
+
+	u := *s.endpoint
+	if s.usePathStyle {
+		u.Path = strings.TrimRight(u.Path, "/") + "/" + s.bucket + "/" + key
+	} else {
+		u.Host = s.bucket + "." + u.Host
+		u.Path = strings.TrimRight(u.Path, "/") + "/" + key
+	}
+	return u.String()
+}
+
+func (s *S3ArtifactStorage) do(ctx context.Context, method, key string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.objectURL(key), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.ContentLength = int64(len(body))
+		req.Header.Set("Content-Type", "application/gzip")
+	}
+
+	sum := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(sum[:])
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	creds, err := s.credentials.Retrieve(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve s3 credentials: %w", err)
+	}
+	if err := s.signer.SignHTTP(ctx, creds, req, payloadHash, "s3", s.region, time.Now()); err != nil {
+		return nil, fmt.Errorf("failed to sign s3 request: %w", err)
+	}
+	return s.client.Do(req)
+}
+
+// s3Error 读取错误响应
+func s3Error(op, key string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("s3 %s %s failed: HTTP %d: %s", op, key, resp.StatusCode, strings.TrimSpace(string(body)))
+}
+
+// Put 上传对象
+func (s *S3ArtifactStorage) Put(ctx context.Context, key string, data []byte) error {
+	resp, err := s.do(ctx, http.MethodPut, key, data)
+	if err != nil {
+		return fmt.Errorf("s3 put %s failed: %w", key, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return s3Error("put", key, resp)
+	}
+	return nil
+}
+
+// Get 下载对象
+func (s *S3ArtifactStorage) Get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, fmt.Errorf("s3 get %s failed: %w", key, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrArtifactNotFound
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, s3Error("get", key, resp)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// Delete 删除对象（S3 对不存在的对象同样返回 204）
+func (s *S3ArtifactStorage) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return fmt.Errorf("s3 delete %s failed: %w", key, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 && resp.StatusCode != http.StatusNotFound {
+		return s3Error("delete", key, resp)
+	}
+	return nil
+}
+
+// Type 存储类型
+func (s *S3ArtifactStorage) Type() string {
+	return models.ArtifactStorageS3
+}
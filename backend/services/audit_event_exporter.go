@@ -0,0 +1,553 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"iac-platform/internal/crypto"
+	"iac-platform/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// AuditEventExporter 审计事件导出器接口
+// Export 要么整批成功，要么返回错误（调用方不会推进游标，下一轮重投）
+type AuditEventExporter interface {
+	Export(ctx context.Context, events []models.AuditEvent) error
+	Close() error
+}
+
+// NewAuditEventExporter 根据配置创建导出器
+func NewAuditEventExporter(cfg *models.AuditExporter) (AuditEventExporter, error) {
+	switch cfg.ExporterType {
+	case models.AuditExporterSyslog:
+		return newSyslogCEFExporter(cfg)
+	case models.AuditExporterJSONLines:
+		return newJSONLinesAuditExporter(cfg)
+	case models.AuditExporterHTTP:
+		return newHTTPAuditExporter(cfg)
+	default:
+		return nil, fmt.Errorf("unsupported audit exporter type: %s", cfg.ExporterType)
+	}
+}
+
+// auditConfigString 读取导出器配置中的字符串字段
+func auditConfigString(cfg models.JSONB, key, def string) string {
+	if cfg == nil {
+		return def
+	}
+	if v, ok := cfg[key].(string); ok && v != "" {
+		return v
+	}
+	return def
+}
+
+// auditConfigInt 读取导出器配置中的整数字段
+func auditConfigInt(cfg models.JSONB, key string, def int) int {
+	if cfg == nil {
+		return def
+	}
+	switch v := cfg[key].(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	}
+	return def
+}
+
+// ============================================================================
+// Syslog / CEF
+// ============================================================================
+
+// auditEventSeverity 将事件映射为 CEF 严重级别（0-10）
+func auditEventSeverity(ev *models.AuditEvent) int {
+	if ev.Outcome == models.AuditOutcomeDenied || ev.Outcome == models.AuditOutcomeFailure {
+		return 7
+	}
+	switch ev.EventType {
+	case models.AuditEventStateRolledBack, models.AuditEventRunTaskOverridden:
+		return 6
+	case models.AuditEventSecretAccessed, models.AuditEventVariableRead:
+		return 5
+	default:
+		return 3
+	}
+}
+
+// cefEscapeHeader 转义 CEF 头部字段（| 和 \）
+func cefEscapeHeader(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+// cefEscapeExtension 转义 CEF 扩展字段值（\、= 和换行）
+func cefEscapeExtension(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "=", `\=`)
+	s = strings.ReplaceAll(s, "\r", `\r`)
+	return strings.ReplaceAll(s, "\n", `\n`)
+}
+
+// FormatAuditEventCEF 将审计事件格式化为 CEF:0 记录
+func FormatAuditEventCEF(ev *models.AuditEvent) string {
+	name := ev.Message
+	if name == "" {
+		name = string(ev.EventType)
+	}
+
+	header := fmt.Sprintf("CEF:0|IaC Platform|iac-platform|1.0|%s|%s|%d|",
+		cefEscapeHeader(string(ev.EventType)),
+		cefEscapeHeader(name),
+		auditEventSeverity(ev),
+	)
+
+	ext := []string{
+		"rt=" + fmt.Sprintf("%d", ev.OccurredAt.UnixMilli()),
+		"externalId=" + cefEscapeExtension(ev.EventID),
+		"outcome=" + cefEscapeExtension(string(ev.Outcome)),
+	}
+	if ev.ActorID != "" {
+		ext = append(ext, "suid="+cefEscapeExtension(ev.ActorID))
+	}
+	if ev.ActorName != "" {
+		ext = append(ext, "suser="+cefEscapeExtension(ev.ActorName))
+	}
+	if ev.SourceIP != "" {
+		ext = append(ext, "src="+cefEscapeExtension(ev.SourceIP))
+	}
+	if ev.ResourceType != "" {
+		ext = append(ext, "cs1Label=resourceType", "cs1="+cefEscapeExtension(ev.ResourceType))
+	}
+	if ev.ResourceID != "" {
+		ext = append(ext, "cs2Label=resourceId", "cs2="+cefEscapeExtension(ev.ResourceID))
+	}
+	if ev.WorkspaceID != "" {
+		ext = append(ext, "cs3Label=workspaceId", "cs3="+cefEscapeExtension(ev.WorkspaceID))
+	}
+	if ev.TaskID != nil {
+		ext = append(ext, "cn1Label=taskId", fmt.Sprintf("cn1=%d", *ev.TaskID))
+	}
+	ext = append(ext, "cs4Label=hash", "cs4="+ev.Hash)
+	ext = append(ext, "cs5Label=actorType", "cs5="+cefEscapeExtension(string(ev.ActorType)))
+
+	return header + strings.Join(ext, " ")
+}
+
+// syslogCEFExporter 通过 syslog（RFC 5424）发送 CEF 记录
+type syslogCEFExporter struct {
+	network  string
+	address  string
+	appName  string
+	facility int
+	hostname string
+	timeout  time.Duration
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+func newSyslogCEFExporter(cfg *models.AuditExporter) (*syslogCEFExporter, error) {
+	address := auditConfigString(cfg.Config, "address", "")
+	if address == "" {
+		return nil, fmt.Errorf("syslog exporter requires config.address")
+	}
+	network := auditConfigString(cfg.Config, "network", "udp")
+	if network != "udp" && network != "tcp" {
+		return nil, fmt.Errorf("syslog exporter network must be udp or tcp")
+	}
+	hostname, _ := os.Hostname()
+	return &syslogCEFExporter{
+		network:  network,
+		address:  address,
+		appName:  auditConfigString(cfg.Config, "app_name", "iac-platform"),
+		facility: auditConfigInt(cfg.Config, "facility", 13), // log audit
+		hostname: hostname,
+		timeout:  time.Duration(auditConfigInt(cfg.Config, "timeout_seconds", 10)) * time.Second,
+	}, nil
+}
+
+// formatSyslogLine 构造 RFC 5424 syslog 行
+func (e *syslogCEFExporter) formatSyslogLine(ev *models.AuditEvent) string {
+	// severity: notice(5)，失败/拒绝为 warning(4)
+	severity := 5
+	if ev.Outcome != models.AuditOutcomeSuccess {
+		severity = 4
+	}
+	pri := e.facility*8 + severity
+	return fmt.Sprintf("<%d>1 %s %s %s - %s - %s",
+		pri,
+		ev.OccurredAt.UTC().Format(time.RFC3339Nano),
+		e.hostname,
+		e.appName,
+		string(ev.EventType),
+		FormatAuditEventCEF(ev),
+	)
+}
+
+func (e *syslogCEFExporter) dial() (net.Conn, error) {
+	if e.conn != nil {
+		return e.conn, nil
+	}
+	conn, err := net.DialTimeout(e.network, e.address, e.timeout)
+	if err != nil {
+		return nil, err
+	}
+	e.conn = conn
+	return conn, nil
+}
+
+// Export 逐条发送；TCP 使用 octet-counting 分帧（RFC 6587）
+func (e *syslogCEFExporter) Export(ctx context.Context, events []models.AuditEvent) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	conn, err := e.dial()
+	if err != nil {
+		return fmt.Errorf("failed to connect syslog %s://%s: %w", e.network, e.address, err)
+	}
+
+	for i := range events {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		line := e.formatSyslogLine(&events[i])
+		var frame string
+		if e.network == "tcp" {
+			frame = fmt.Sprintf("%d %s", len(line), line)
+		} else {
+			frame = line
+		}
+		_ = conn.SetWriteDeadline(time.Now().Add(e.timeout))
+		if _, err := conn.Write([]byte(frame)); err != nil {
+			conn.Close()
+			e.conn = nil
+			return fmt.Errorf("failed to write syslog message: %w", err)
+		}
+	}
+	return nil
+}
+
+func (e *syslogCEFExporter) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.conn != nil {
+		err := e.conn.Close()
+		e.conn = nil
+		return err
+	}
+	return nil
+}
+
+// ============================================================================
+// JSON Lines
+// ============================================================================
+
+// jsonLinesAuditExporter 以 JSON Lines 格式追加写入文件
+type jsonLinesAuditExporter struct {
+	path string
+	mu   sync.Mutex
+}
+
+func newJSONLinesAuditExporter(cfg *models.AuditExporter) (*jsonLinesAuditExporter, error) {
+	path := auditConfigString(cfg.Config, "path", "")
+	if path == "" {
+		return nil, fmt.Errorf("json_lines exporter requires config.path")
+	}
+	return &jsonLinesAuditExporter{path: path}, nil
+}
+
+// Export 整批写入后 fsync，保证游标推进前数据已落盘
+func (e *jsonLinesAuditExporter) Export(ctx context.Context, events []models.AuditEvent) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(e.path), 0o750); err != nil {
+		return fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	f, err := os.OpenFile(e.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o640)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log file: %w", err)
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for i := range events {
+		if err := enc.Encode(&events[i]); err != nil {
+			return fmt.Errorf("failed to encode audit event: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write audit log file: %w", err)
+	}
+	return f.Sync()
+}
+
+func (e *jsonLinesAuditExporter) Close() error {
+	return nil
+}
+
+// ============================================================================
+// HTTP
+// ============================================================================
+
+// httpAuditExporter 以 JSON 数组批量 POST 到 HTTP 端点，失败按配置重试
+type httpAuditExporter struct {
+	url           string
+	headers       map[string]string
+	bearerToken   string
+	retryCount    int
+	retryInterval time.Duration
+	client        *http.Client
+}
+
+func newHTTPAuditExporter(cfg *models.AuditExporter) (*httpAuditExporter, error) {
+	url := auditConfigString(cfg.Config, "url", "")
+	if url == "" {
+		return nil, fmt.Errorf("http exporter requires config.url")
+	}
+
+	headers := make(map[string]string)
+	if raw, ok := cfg.Config["headers"].(map[string]interface{}); ok {
+		for k, v := range raw {
+			if s, ok := v.(string); ok {
+				headers[k] = s
+			}
+		}
+	}
+
+	var token string
+	if cfg.SecretEncrypted != "" {
+		decrypted, err := crypto.DecryptValue(cfg.SecretEncrypted)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt exporter secret: %w", err)
+		}
+		token = decrypted
+	}
+
+	retryInterval := time.Duration(cfg.RetryIntervalSeconds) * time.Second
+	if retryInterval <= 0 {
+		retryInterval = 5 * time.Second
+	}
+
+	return &httpAuditExporter{
+		url:           url,
+		headers:       headers,
+		bearerToken:   token,
+		retryCount:    cfg.RetryCount,
+		retryInterval: retryInterval,
+		client: &http.Client{
+			Timeout: time.Duration(auditConfigInt(cfg.Config, "timeout_seconds", 10)) * time.Second,
+		},
+	}, nil
+}
+
+// Export 发送一批事件，5xx / 429 / 网络错误按指数退避重试
+func (e *httpAuditExporter) Export(ctx context.Context, events []models.AuditEvent) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"source": "iac-platform",
+		"count":  len(events),
+		"events": events,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit events: %w", err)
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= e.retryCount; attempt++ {
+		if attempt > 0 {
+			wait := e.retryInterval * time.Duration(1<<uint(attempt-1))
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(wait):
+			}
+		}
+
+		retryable, err := e.post(ctx, body)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !retryable {
+			break
+		}
+	}
+	return lastErr
+}
+
+func (e *httpAuditExporter) post(ctx context.Context, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-IaC-Event", "audit_events")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+	if e.bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+e.bearerToken)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("audit export request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1000))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retryable := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+	return retryable, fmt.Errorf("audit export HTTP %d: %s", resp.StatusCode, string(respBody))
+}
+
+func (e *httpAuditExporter) Close() error {
+	return nil
+}
+
+// ============================================================================
+// Export worker
+// ============================================================================
+
+// AuditExportWorker 后台投递审计事件到各导出器（仅 leader 运行）
+type AuditExportWorker struct {
+	db           *gorm.DB
+	eventService *AuditEventService
+}
+
+// NewAuditExportWorker 创建审计导出 worker
+func NewAuditExportWorker(db *gorm.DB) *AuditExportWorker {
+	return &AuditExportWorker{
+		db:           db,
+		eventService: NewAuditEventService(db),
+	}
+}
+
+// Start 按 interval 周期投递，直到 ctx 取消
+func (w *AuditExportWorker) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Printf("[AuditExport] Worker started (interval %v)", interval)
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("[AuditExport] Worker stopped")
+			return
+		case <-ticker.C:
+			w.runOnce(ctx)
+		}
+	}
+}
+
+// runOnce 对每个启用的导出器投递积压事件
+func (w *AuditExportWorker) runOnce(ctx context.Context) {
+	var exporters []models.AuditExporter
+	if err := w.db.Where("enabled = ?", true).Find(&exporters).Error; err != nil {
+		log.Printf("[AuditExport] Failed to load exporters: %v", err)
+		return
+	}
+	for i := range exporters {
+		if ctx.Err() != nil {
+			return
+		}
+		if _, err := w.Drain(ctx, &exporters[i], 10); err != nil {
+			log.Printf("[AuditExport] Exporter %s failed: %v", exporters[i].ExporterID, err)
+		}
+	}
+}
+
+// Drain 对单个导出器最多投递 maxBatches 批事件，返回投递的事件数
+func (w *AuditExportWorker) Drain(ctx context.Context, cfg *models.AuditExporter, maxBatches int) (int, error) {
+	exporter, err := NewAuditEventExporter(cfg)
+	if err != nil {
+		w.recordError(cfg, err)
+		return 0, err
+	}
+	defer exporter.Close()
+
+	batchSize := cfg.BatchSize
+	if batchSize <= 0 || batchSize > 1000 {
+		batchSize = 100
+	}
+	filter := parseAuditEventTypes(cfg.EventTypes)
+
+	delivered := 0
+	for i := 0; i < maxBatches; i++ {
+		events, err := w.eventService.ListAfter(cfg.LastExportedID, batchSize)
+		if err != nil {
+			return delivered, err
+		}
+		if len(events) == 0 {
+			return delivered, nil
+		}
+
+		selected := events
+		if len(filter) > 0 {
+			selected = make([]models.AuditEvent, 0, len(events))
+			for _, ev := range events {
+				if filter[string(ev.EventType)] {
+					selected = append(selected, ev)
+				}
+			}
+		}
+
+		if len(selected) > 0 {
+			if err := exporter.Export(ctx, selected); err != nil {
+				w.recordError(cfg, err)
+				return delivered, err
+			}
+		}
+
+		now := time.Now()
+		cfg.LastExportedID = events[len(events)-1].ID
+		cfg.LastExportedAt = &now
+		cfg.LastError = ""
+		if err := w.db.Model(&models.AuditExporter{}).Where("id = ?", cfg.ID).Updates(map[string]interface{}{
+			"last_exported_id": cfg.LastExportedID,
+			"last_exported_at": now,
+			"last_error":       "",
+		}).Error; err != nil {
+			return delivered, fmt.Errorf("failed to advance exporter cursor: %w", err)
+		}
+		delivered += len(selected)
+
+		if len(events) < batchSize {
+			return delivered, nil
+		}
+	}
+	return delivered, nil
+}
+
+func (w *AuditExportWorker) recordError(cfg *models.AuditExporter, err error) {
+	cfg.LastError = err.Error()
+	w.db.Model(&models.AuditExporter{}).Where("id = ?", cfg.ID).Update("last_error", cfg.LastError)
+}
+
+// parseAuditEventTypes 解析逗号分隔的事件类型过滤
+func parseAuditEventTypes(s string) map[string]bool {
+	result := make(map[string]bool)
+	for _, t := range strings.Split(s, ",") {
+		t = strings.TrimSpace(t)
+		if t != "" {
+			result[t] = true
+		}
+	}
+	return result
+}
@@ -0,0 +1,321 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"time"
+
+	"iac-platform/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// auditChainLockKey 审计哈希链写入的事务级 advisory lock key
+// 所有副本串行追加，保证链条不分叉
+const auditChainLockKey int64 = 0x4155444954 // "AUDIT"
+
+// AuditGenesisHash 链条首条事件的 PrevHash
+var AuditGenesisHash = strings.Repeat("0", 64)
+
+// AuditEventService 领域审计事件服务：追加写入、查询、链条校验
+type AuditEventService struct {
+	db *gorm.DB
+}
+
+// NewAuditEventService 创建审计事件服务
+func NewAuditEventService(db *gorm.DB) *AuditEventService {
+	return &AuditEventService{db: db}
+}
+
+// generateAuditEventID 生成审计事件ID
+func generateAuditEventID() string {
+	const charset = "abcdefghijklmnopqrstuvwxyz0123456789"
+	const length = 16
+	b := make([]byte, length)
+	charsetLen := big.NewInt(int64(len(charset)))
+	for i := range b {
+		num, _ := rand.Int(rand.Reader, charsetLen)
+		b[i] = charset[num.Int64()]
+	}
+	return fmt.Sprintf("aevt-%s", string(b))
+}
+
+// auditHashPayload 参与哈希计算的规范化字段（不含 ID / PrevHash / Hash）
+type auditHashPayload struct {
+	EventID      string                 `json:"event_id"`
+	EventType    string                 `json:"event_type"`
+	OccurredAt   string                 `json:"occurred_at"`
+	ActorType    string                 `json:"actor_type"`
+	ActorID      string                 `json:"actor_id"`
+	ActorName    string                 `json:"actor_name"`
+	SourceIP     string                 `json:"source_ip"`
+	ResourceType string                 `json:"resource_type"`
+	ResourceID   string                 `json:"resource_id"`
+	WorkspaceID  string                 `json:"workspace_id"`
+	TaskID       *uint                  `json:"task_id"`
+	Outcome      string                 `json:"outcome"`
+	Message      string                 `json:"message"`
+	Details      map[string]interface{} `json:"details"`
+}
+
+// ComputeAuditEventHash 计算事件哈希: SHA256(prevHash || canonical JSON)
+// encoding/json 对 map 按 key 排序输出，因此 Details 的序列化是确定的
+func ComputeAuditEventHash(prevHash string, ev *models.AuditEvent) (string, error) {
+	payload := auditHashPayload{
+		EventID:      ev.EventID,
+		EventType:    string(ev.EventType),
+		OccurredAt:   ev.OccurredAt.UTC().Format(time.RFC3339Nano),
+		ActorType:    string(ev.ActorType),
+		ActorID:      ev.ActorID,
+		ActorName:    ev.ActorName,
+		SourceIP:     ev.SourceIP,
+		ResourceType: ev.ResourceType,
+		ResourceID:   ev.ResourceID,
+		WorkspaceID:  ev.WorkspaceID,
+		TaskID:       ev.TaskID,
+		Outcome:      string(ev.Outcome),
+		Message:      ev.Message,
+		Details:      map[string]interface{}(ev.Details),
+	}
+	if len(payload.Details) == 0 {
+		payload.Details = nil
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal audit event: %w", err)
+	}
+
+	h := sha256.New()
+	h.Write([]byte(prevHash))
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Record 追加一条审计事件并挂到哈希链末尾
+func (s *AuditEventService) Record(ev *models.AuditEvent) error {
+	if ev.EventType == "" {
+		return fmt.Errorf("audit event type is required")
+	}
+	if ev.EventID == "" {
+		ev.EventID = generateAuditEventID()
+	}
+	if ev.OccurredAt.IsZero() {
+		ev.OccurredAt = time.Now()
+	}
+	// occurred_at 为 timestamp without time zone，精度为微秒；统一按 UTC 存储并截断，
+	// 否则本地时区的墙钟时间读回后会被当作 UTC，哈希无法复现
+	ev.OccurredAt = ev.OccurredAt.UTC().Truncate(time.Microsecond)
+	if ev.ActorType == "" {
+		ev.ActorType = models.AuditActorSystem
+	}
+	if ev.Outcome == "" {
+		ev.Outcome = models.AuditOutcomeSuccess
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if tx.Dialector.Name() == "postgres" {
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", auditChainLockKey).Error; err != nil {
+				return fmt.Errorf("failed to lock audit chain: %w", err)
+			}
+		}
+
+		prevHash := AuditGenesisHash
+		var last models.AuditEvent
+		err := tx.Select("id, hash").Order("id DESC").Limit(1).Take(&last).Error
+		if err == nil {
+			prevHash = last.Hash
+		} else if err != gorm.ErrRecordNotFound {
+			return fmt.Errorf("failed to read audit chain head: %w", err)
+		}
+
+		hash, err := ComputeAuditEventHash(prevHash, ev)
+		if err != nil {
+			return err
+		}
+		ev.PrevHash = prevHash
+		ev.Hash = hash
+
+		return tx.Create(ev).Error
+	})
+}
+
+// Emit 记录审计事件，失败只打日志，不影响业务流程
+func (s *AuditEventService) Emit(ev *models.AuditEvent) {
+	if err := s.Record(ev); err != nil {
+		log.Printf("[AuditEvent] Failed to record %s event for %s/%s: %v", ev.EventType, ev.ResourceType, ev.ResourceID, err)
+	}
+}
+
+// AuditEventFilter 审计事件查询条件
+type AuditEventFilter struct {
+	EventTypes   []string
+	ActorID      string
+	ResourceType string
+	ResourceID   string
+	WorkspaceID  string
+	TaskID       *uint
+	StartTime    time.Time
+	EndTime      time.Time
+	AfterID      uint64
+	Page         int
+	PageSize     int
+}
+
+// Query 分页查询审计事件（按 id 倒序）
+func (s *AuditEventService) Query(filter AuditEventFilter) ([]models.AuditEvent, int64, error) {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PageSize < 1 || filter.PageSize > 500 {
+		filter.PageSize = 50
+	}
+
+	query := s.db.Model(&models.AuditEvent{})
+	if len(filter.EventTypes) > 0 {
+		query = query.Where("event_type IN ?", filter.EventTypes)
+	}
+	if filter.ActorID != "" {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.ResourceType != "" {
+		query = query.Where("resource_type = ?", filter.ResourceType)
+	}
+	if filter.ResourceID != "" {
+		query = query.Where("resource_id = ?", filter.ResourceID)
+	}
+	if filter.WorkspaceID != "" {
+		query = query.Where("workspace_id = ?", filter.WorkspaceID)
+	}
+	if filter.TaskID != nil {
+		query = query.Where("task_id = ?", *filter.TaskID)
+	}
+	if !filter.StartTime.IsZero() {
+		query = query.Where("occurred_at >= ?", filter.StartTime.UTC())
+	}
+	if !filter.EndTime.IsZero() {
+		query = query.Where("occurred_at <= ?", filter.EndTime.UTC())
+	}
+	if filter.AfterID > 0 {
+		query = query.Where("id > ?", filter.AfterID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var events []models.AuditEvent
+	offset := (filter.Page - 1) * filter.PageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(filter.PageSize).Find(&events).Error; err != nil {
+		return nil, 0, err
+	}
+	return events, total, nil
+}
+
+// GetEvent 获取单条审计事件
+func (s *AuditEventService) GetEvent(eventID string) (*models.AuditEvent, error) {
+	var ev models.AuditEvent
+	if err := s.db.Where("event_id = ?", eventID).First(&ev).Error; err != nil {
+		return nil, err
+	}
+	return &ev, nil
+}
+
+// ListAfter 按 id 升序读取游标之后的事件（导出器使用）
+func (s *AuditEventService) ListAfter(afterID uint64, limit int) ([]models.AuditEvent, error) {
+	var events []models.AuditEvent
+	err := s.db.Where("id > ?", afterID).Order("id ASC").Limit(limit).Find(&events).Error
+	return events, err
+}
+
+// AuditChainVerifyResult 链条校验结果
+type AuditChainVerifyResult struct {
+	Valid        bool   `json:"valid"`
+	Checked      int    `json:"checked"`
+	FirstID      uint64 `json:"first_id"`
+	LastID       uint64 `json:"last_id"`
+	BrokenAtID   uint64 `json:"broken_at_id,omitempty"`
+	BrokenReason string `json:"broken_reason,omitempty"`
+}
+
+// VerifyChain 从 fromID 开始校验最多 limit 条事件的哈希链
+// fromID 为 0 时从链首开始，链首的 PrevHash 必须为创世哈希
+func (s *AuditEventService) VerifyChain(fromID uint64, limit int) (*AuditChainVerifyResult, error) {
+	if limit <= 0 || limit > 100000 {
+		limit = 10000
+	}
+
+	result := &AuditChainVerifyResult{Valid: true}
+
+	expectedPrev := ""
+	if fromID > 0 {
+		var prev models.AuditEvent
+		err := s.db.Select("id, hash").Where("id < ?", fromID).Order("id DESC").Limit(1).Take(&prev).Error
+		if err == nil {
+			expectedPrev = prev.Hash
+		} else if err == gorm.ErrRecordNotFound {
+			expectedPrev = AuditGenesisHash
+		} else {
+			return nil, err
+		}
+	} else {
+		expectedPrev = AuditGenesisHash
+	}
+
+	const batchSize = 500
+	cursor := uint64(0)
+	if fromID > 0 {
+		cursor = fromID - 1
+	}
+
+	for result.Checked < limit {
+		size := batchSize
+		if remaining := limit - result.Checked; remaining < size {
+			size = remaining
+		}
+		batch, err := s.ListAfter(cursor, size)
+		if err != nil {
+			return nil, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		for i := range batch {
+			ev := &batch[i]
+			if result.Checked == 0 {
+				result.FirstID = ev.ID
+			}
+			result.Checked++
+			result.LastID = ev.ID
+
+			if ev.PrevHash != expectedPrev {
+				result.Valid = false
+				result.BrokenAtID = ev.ID
+				result.BrokenReason = "prev_hash does not match previous event hash (event removed or reordered)"
+				return result, nil
+			}
+			hash, err := ComputeAuditEventHash(ev.PrevHash, ev)
+			if err != nil {
+				return nil, err
+			}
+			if hash != ev.Hash {
+				result.Valid = false
+				result.BrokenAtID = ev.ID
+				result.BrokenReason = "hash mismatch (event content modified)"
+				return result, nil
+			}
+			expectedPrev = ev.Hash
+			cursor = ev.ID
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,317 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"iac-platform/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupAuditTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+
+	// details 使用 BLOB，保证读回为 []byte（JSONB.Scan 需要）
+	_, err = sqlDB.Exec(`CREATE TABLE audit_events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		event_id TEXT UNIQUE NOT NULL,
+		event_type TEXT NOT NULL,
+		occurred_at DATETIME NOT NULL,
+		actor_type TEXT NOT NULL,
+		actor_id TEXT,
+		actor_name TEXT,
+		source_ip TEXT,
+		resource_type TEXT,
+		resource_id TEXT,
+		workspace_id TEXT,
+		task_id INTEGER,
+		outcome TEXT NOT NULL DEFAULT 'success',
+		message TEXT,
+		details BLOB,
+		prev_hash TEXT NOT NULL,
+		hash TEXT UNIQUE NOT NULL
+	)`)
+	require.NoError(t, err)
+
+	_, err = sqlDB.Exec(`CREATE TABLE audit_exporters (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		exporter_id TEXT UNIQUE NOT NULL,
+		name TEXT NOT NULL,
+		description TEXT,
+		exporter_type TEXT NOT NULL,
+		config BLOB,
+		secret_encrypted TEXT,
+		event_types TEXT,
+		enabled INTEGER DEFAULT 1,
+		batch_size INTEGER DEFAULT 100,
+		retry_count INTEGER DEFAULT 3,
+		retry_interval_seconds INTEGER DEFAULT 5,
+		last_exported_id INTEGER DEFAULT 0,
+		last_exported_at DATETIME,
+		last_error TEXT,
+		created_by TEXT,
+		created_at DATETIME,
+		updated_at DATETIME
+	)`)
+	require.NoError(t, err)
+	return db
+}
+
+func recordTestEvents(t *testing.T, svc *AuditEventService, n int) {
+	t.Helper()
+	types := []models.AuditEventType{
+		models.AuditEventApplyConfirmed,
+		models.AuditEventStateRolledBack,
+		models.AuditEventSecretAccessed,
+	}
+	for i := 0; i < n; i++ {
+		taskID := uint(i + 1)
+		require.NoError(t, svc.Record(&models.AuditEvent{
+			EventType:    types[i%len(types)],
+			ActorType:    models.AuditActorUser,
+			ActorID:      "user-abc",
+			ResourceType: "task",
+			ResourceID:   "ws-1",
+			WorkspaceID:  "ws-1",
+			TaskID:       &taskID,
+			Details:      models.JSONB{"index": i, "note": "a=b|c"},
+		}))
+	}
+}
+
+func TestAuditEventService_HashChain(t *testing.T) {
+	db := setupAuditTestDB(t)
+	svc := NewAuditEventService(db)
+	recordTestEvents(t, svc, 5)
+
+	events, err := svc.ListAfter(0, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 5)
+
+	assert.Equal(t, AuditGenesisHash, events[0].PrevHash)
+	for i := 1; i < len(events); i++ {
+		assert.Equal(t, events[i-1].Hash, events[i].PrevHash, "event %d should chain to previous", i)
+	}
+
+	result, err := svc.VerifyChain(0, 0)
+	require.NoError(t, err)
+	assert.True(t, result.Valid)
+	assert.Equal(t, 5, result.Checked)
+
+	// 从中间开始校验
+	result, err = svc.VerifyChain(events[2].ID, 0)
+	require.NoError(t, err)
+	assert.True(t, result.Valid)
+	assert.Equal(t, 3, result.Checked)
+}
+
+func TestAuditEventService_HashChainWithLocalTimeZone(t *testing.T) {
+	origLocal := time.Local
+	time.Local = time.FixedZone("Asia/Singapore", 8*60*60)
+	defer func() { time.Local = origLocal }()
+
+	db := setupAuditTestDB(t)
+	svc := NewAuditEventService(db)
+	recordTestEvents(t, svc, 3)
+
+	// 模拟 PostgreSQL timestamp without time zone：丢弃时区偏移，只保留墙钟时间
+	require.NoError(t, db.Exec("UPDATE audit_events SET occurred_at = substr(occurred_at, 1, length(occurred_at) - 6)").Error)
+
+	result, err := svc.VerifyChain(0, 0)
+	require.NoError(t, err)
+	assert.True(t, result.Valid, result.BrokenReason)
+	assert.Equal(t, 3, result.Checked)
+
+	events, err := svc.ListAfter(0, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	assert.WithinDuration(t, time.Now(), events[0].OccurredAt, time.Minute)
+}
+
+func TestAuditEventService_DetectsTampering(t *testing.T) {
+	db := setupAuditTestDB(t)
+	svc := NewAuditEventService(db)
+	recordTestEvents(t, svc, 4)
+
+	events, err := svc.ListAfter(0, 10)
+	require.NoError(t, err)
+
+	// 修改内容
+	require.NoError(t, db.Exec("UPDATE audit_events SET actor_id = ? WHERE id = ?", "user-evil", events[1].ID).Error)
+	result, err := svc.VerifyChain(0, 0)
+	require.NoError(t, err)
+	assert.False(t, result.Valid)
+	assert.Equal(t, events[1].ID, result.BrokenAtID)
+	assert.Contains(t, result.BrokenReason, "modified")
+
+	// 删除事件
+	db2 := setupAuditTestDB(t)
+	svc2 := NewAuditEventService(db2)
+	recordTestEvents(t, svc2, 4)
+	events, err = svc2.ListAfter(0, 10)
+	require.NoError(t, err)
+	require.NoError(t, db2.Exec("DELETE FROM audit_events WHERE id = ?", events[2].ID).Error)
+	result, err = svc2.VerifyChain(0, 0)
+	require.NoError(t, err)
+	assert.False(t, result.Valid)
+	assert.Equal(t, events[3].ID, result.BrokenAtID)
+	assert.Contains(t, result.BrokenReason, "removed")
+}
+
+func TestFormatAuditEventCEF(t *testing.T) {
+	taskID := uint(42)
+	ev := &models.AuditEvent{
+		EventID:      "aevt-1",
+		EventType:    models.AuditEventApplyConfirmed,
+		ActorType:    models.AuditActorUser,
+		ActorID:      "user-1",
+		ActorName:    "alice",
+		SourceIP:     "10.0.0.1",
+		ResourceType: "task",
+		ResourceID:   "42",
+		WorkspaceID:  "ws-1",
+		TaskID:       &taskID,
+		Outcome:      models.AuditOutcomeSuccess,
+		Message:      "Apply confirmed | a=b",
+		Hash:         "abc",
+	}
+
+	line := FormatAuditEventCEF(ev)
+	assert.True(t, strings.HasPrefix(line, "CEF:0|IaC Platform|iac-platform|1.0|apply_confirmed|Apply confirmed \\| a=b|3|"))
+	assert.Contains(t, line, "suser=alice")
+	assert.Contains(t, line, "src=10.0.0.1")
+	assert.Contains(t, line, "cn1=42")
+	assert.Contains(t, line, "cs3=ws-1")
+	assert.Equal(t, `x\=y\\z\n`, cefEscapeExtension("x=y\\z\n"))
+}
+
+func TestAuditExportWorker_JSONLinesAndFilter(t *testing.T) {
+	db := setupAuditTestDB(t)
+	svc := NewAuditEventService(db)
+	recordTestEvents(t, svc, 6)
+
+	path := filepath.Join(t.TempDir(), "audit", "events.jsonl")
+	cfg := &models.AuditExporter{
+		ExporterID:   "aexp-file",
+		Name:         "file",
+		ExporterType: models.AuditExporterJSONLines,
+		Config:       models.JSONB{"path": path},
+		EventTypes:   string(models.AuditEventApplyConfirmed),
+		Enabled:      true,
+		BatchSize:    4,
+	}
+	require.NoError(t, db.Create(cfg).Error)
+
+	worker := NewAuditExportWorker(db)
+	delivered, err := worker.Drain(context.Background(), cfg, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 2, delivered)
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	var lines int
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var ev models.AuditEvent
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &ev))
+		assert.Equal(t, models.AuditEventApplyConfirmed, ev.EventType)
+		lines++
+	}
+	assert.Equal(t, 2, lines)
+
+	var stored models.AuditExporter
+	require.NoError(t, db.First(&stored, cfg.ID).Error)
+	assert.Equal(t, uint64(6), stored.LastExportedID)
+
+	// 游标已推进，重复投递不会产生新数据
+	delivered, err = worker.Drain(context.Background(), &stored, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 0, delivered)
+}
+
+func TestAuditExportWorker_HTTPRetryKeepsCursorOnFailure(t *testing.T) {
+	db := setupAuditTestDB(t)
+	svc := NewAuditEventService(db)
+	recordTestEvents(t, svc, 3)
+
+	var calls int32
+	var fail atomic.Bool
+	fail.Store(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		body, _ := io.ReadAll(r.Body)
+		if fail.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var payload struct {
+			Count  int                 `json:"count"`
+			Events []models.AuditEvent `json:"events"`
+		}
+		if err := json.Unmarshal(body, &payload); err != nil || payload.Count != len(payload.Events) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	cfg := &models.AuditExporter{
+		ExporterID:           "aexp-http",
+		Name:                 "http",
+		ExporterType:         models.AuditExporterHTTP,
+		Config:               models.JSONB{"url": server.URL},
+		Enabled:              true,
+		BatchSize:            100,
+		RetryCount:           1,
+		RetryIntervalSeconds: 1,
+	}
+	require.NoError(t, db.Create(cfg).Error)
+
+	worker := NewAuditExportWorker(db)
+	exp, err := newHTTPAuditExporter(cfg)
+	require.NoError(t, err)
+	exp.retryInterval = time.Millisecond
+	events, err := svc.ListAfter(0, 10)
+	require.NoError(t, err)
+	require.Error(t, exp.Export(context.Background(), events))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls), "one attempt plus one retry")
+
+	// 投递失败时游标不前进，并记录错误
+	cfg.RetryCount = 0
+	_, err = worker.Drain(context.Background(), cfg, 1)
+	require.Error(t, err)
+	var stored models.AuditExporter
+	require.NoError(t, db.First(&stored, cfg.ID).Error)
+	assert.Equal(t, uint64(0), stored.LastExportedID)
+	assert.Contains(t, stored.LastError, "HTTP 503")
+
+	fail.Store(false)
+	delivered, err := worker.Drain(context.Background(), cfg, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 3, delivered)
+	assert.Equal(t, uint64(3), cfg.LastExportedID)
+}
@@ -0,0 +1,501 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"iac-platform/internal/infrastructure"
+	"iac-platform/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrChangeFreezeWindowNotFound    = errors.New("change freeze window not found")
+	ErrChangeFreezeInvalid           = errors.New("invalid change freeze")
+	ErrChangeFreezeActive            = errors.New("apply is blocked by an active change freeze")
+	ErrChangeFreezeExceptionNotFound = errors.New("change freeze exception not found")
+	ErrChangeFreezeExceptionState    = errors.New("change freeze exception is not in a valid state for this operation")
+	ErrChangeFreezeSelfReview        = errors.New("an exception cannot be reviewed by its requester")
+)
+
+// ChangeFreezeStage 被冻结挂起的已确认 apply 任务的 stage（status 保持 apply_pending）
+const ChangeFreezeStage = "change_freeze"
+
+// ChangeFreezeService 组织/项目级变更冻结日历
+// 冻结窗口只阻塞 apply：TaskQueueManager 在执行已确认的 apply 前调用 CheckApplyAllowed，
+// 冻结期间任务挂起，冻结结束或该次运行的例外被批准后由 pending tasks monitor 重新投递
+type ChangeFreezeService struct {
+	db *gorm.DB
+}
+
+// NewChangeFreezeService 创建变更冻结服务
+func NewChangeFreezeService(db *gorm.DB) *ChangeFreezeService {
+	return &ChangeFreezeService{db: db}
+}
+
+// ListWindows 列出所有冻结窗口
+func (s *ChangeFreezeService) ListWindows() ([]models.ChangeFreezeWindow, error) {
+	var windows []models.ChangeFreezeWindow
+	if err := s.db.Order("created_at DESC").Find(&windows).Error; err != nil {
+		return nil, err
+	}
+	return windows, nil
+}
+
+// GetWindow 获取冻结窗口
+func (s *ChangeFreezeService) GetWindow(id string) (*models.ChangeFreezeWindow, error) {
+	var window models.ChangeFreezeWindow
+	if err := s.db.Where("id = ?", id).First(&window).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrChangeFreezeWindowNotFound
+		}
+		return nil, err
+	}
+	return &window, nil
+}
+
+// CreateWindow 创建冻结窗口
+func (s *ChangeFreezeService) CreateWindow(req *models.ChangeFreezeWindowRequest, userID string) (*models.ChangeFreezeWindow, error) {
+	if err := validateChangeFreezeWindow(req); err != nil {
+		return nil, err
+	}
+	id, err := infrastructure.GenerateChangeFreezeWindowID()
+	if err != nil {
+		return nil, err
+	}
+
+	window := &models.ChangeFreezeWindow{ID: id, CreatedBy: userID}
+	applyChangeFreezeWindowRequest(window, req, userID)
+	if err := s.db.Create(window).Error; err != nil {
+		return nil, err
+	}
+	return window, nil
+}
+
+// UpdateWindow 更新冻结窗口
+func (s *ChangeFreezeService) UpdateWindow(id string, req *models.ChangeFreezeWindowRequest, userID string) (*models.ChangeFreezeWindow, error) {
+	window, err := s.GetWindow(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateChangeFreezeWindow(req); err != nil {
+		return nil, err
+	}
+
+	applyChangeFreezeWindowRequest(window, req, userID)
+	if err := s.db.Save(window).Error; err != nil {
+		return nil, err
+	}
+	return window, nil
+}
+
+// DeleteWindow 删除冻结窗口，被其挂起的 apply 会在下次重试时继续执行
+func (s *ChangeFreezeService) DeleteWindow(id string) error {
+	result := s.db.Where("id = ?", id).Delete(&models.ChangeFreezeWindow{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrChangeFreezeWindowNotFound
+	}
+	return nil
+}
+
+func applyChangeFreezeWindowRequest(window *models.ChangeFreezeWindow, req *models.ChangeFreezeWindowRequest, userID string) {
+	window.Name = req.Name
+	window.Description = req.Description
+	window.Scope = req.Scope
+	window.Selector = req.Selector
+	if window.Scope == models.ChangeFreezeScopeOrganization {
+		window.Selector.ProjectIDs = nil
+	}
+	window.Timezone = req.Timezone
+	if window.Timezone == "" {
+		window.Timezone = "UTC"
+	}
+	window.Schedule = req.Schedule
+	window.Enabled = req.Enabled == nil || *req.Enabled
+	window.UpdatedBy = userID
+}
+
+// ActiveFreezes 返回当前（at 时刻）对 workspace 生效的冻结窗口，按结束时间排序
+func (s *ChangeFreezeService) ActiveFreezes(workspace *models.Workspace, at time.Time) ([]models.ActiveChangeFreeze, error) {
+	var windows []models.ChangeFreezeWindow
+	if err := s.db.Where("enabled = ?", true).Find(&windows).Error; err != nil {
+		return nil, fmt.Errorf("failed to load change freeze windows: %w", err)
+	}
+
+	var projectIDs map[uint]bool
+	active := make([]models.ActiveChangeFreeze, 0)
+	for _, window := range windows {
+		until, ok := changeFreezeActiveUntil(&window, at)
+		if !ok || !workspaceTagsMatch(workspace.Tags, window.Selector.Tags) {
+			continue
+		}
+		if window.Scope == models.ChangeFreezeScopeProject {
+			if projectIDs == nil {
+				ids, err := s.workspaceProjectIDs(workspace.WorkspaceID)
+				if err != nil {
+					return nil, err
+				}
+				projectIDs = ids
+			}
+			if !selectorHasProject(window.Selector.ProjectIDs, projectIDs) {
+				continue
+			}
+		}
+		active = append(active, models.ActiveChangeFreeze{
+			WindowID:    window.ID,
+			Name:        window.Name,
+			Description: window.Description,
+			Scope:       window.Scope,
+			Timezone:    window.Timezone,
+			Until:       until,
+		})
+	}
+	sort.Slice(active, func(i, j int) bool { return active[i].Until.Before(active[j].Until) })
+	return active, nil
+}
+
+func (s *ChangeFreezeService) workspaceProjectIDs(workspaceID string) (map[uint]bool, error) {
+	var ids []uint
+	if err := s.db.Table("workspace_project_relations").
+		Where("workspace_id = ?", workspaceID).
+		Pluck("project_id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("failed to load workspace projects: %w", err)
+	}
+	result := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		result[id] = true
+	}
+	return result, nil
+}
+
+func selectorHasProject(selected []uint, projectIDs map[uint]bool) bool {
+	for _, id := range selected {
+		if projectIDs[id] {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckApplyAllowed 检查任务的 apply 是否被冻结窗口阻塞
+// 已批准的例外放行该任务；被阻塞时返回包装了 ErrChangeFreezeActive 的错误，说明冻结窗口和结束时间
+func (s *ChangeFreezeService) CheckApplyAllowed(taskID uint, workspace *models.Workspace) error {
+	active, err := s.ActiveFreezes(workspace, time.Now())
+	if err != nil {
+		return err
+	}
+	if len(active) == 0 {
+		return nil
+	}
+
+	var approved int64
+	if err := s.db.Model(&models.ChangeFreezeException{}).
+		Where("task_id = ? AND status = ?", taskID, models.ChangeFreezeExceptionApproved).
+		Count(&approved).Error; err != nil {
+		return fmt.Errorf("failed to check change freeze exceptions: %w", err)
+	}
+	if approved > 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrChangeFreezeActive, describeActiveFreezes(active))
+}
+
+// describeActiveFreezes 生成阻塞原因，如 "Year-end freeze until 2026-01-02 09:00 Asia/Shanghai"
+func describeActiveFreezes(active []models.ActiveChangeFreeze) string {
+	parts := make([]string, 0, len(active))
+	for _, freeze := range active {
+		until := freeze.Until
+		if loc, err := time.LoadLocation(freeze.Timezone); err == nil {
+			until = until.In(loc)
+		}
+		parts = append(parts, fmt.Sprintf("%s until %s %s", freeze.Name, until.Format("2006-01-02 15:04"), freeze.Timezone))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// TaskFreezeStatus 任务的冻结状态：当前生效的冻结窗口和最近一次例外申请
+type TaskFreezeStatus struct {
+	Active    []models.ActiveChangeFreeze   `json:"active"`
+	Exception *models.ChangeFreezeException `json:"exception"`
+}
+
+// GetTaskFreezeStatus 获取任务的冻结状态
+func (s *ChangeFreezeService) GetTaskFreezeStatus(task *models.WorkspaceTask, workspace *models.Workspace) (*TaskFreezeStatus, error) {
+	active, err := s.ActiveFreezes(workspace, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	status := &TaskFreezeStatus{Active: active}
+
+	var exception models.ChangeFreezeException
+	err = s.db.Where("task_id = ?", task.ID).Order("id DESC").First(&exception).Error
+	if err == nil {
+		status.Exception = &exception
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	return status, nil
+}
+
+// RequestException 为被冻结阻塞的 plan_and_apply 任务申请单次例外
+// 同一任务同时只能有一个待审批或已批准的申请
+func (s *ChangeFreezeService) RequestException(task *models.WorkspaceTask, workspace *models.Workspace, reason, userID string) (*models.ChangeFreezeException, []models.ActiveChangeFreeze, error) {
+	if task.TaskType != models.TaskTypePlanAndApply {
+		return nil, nil, fmt.Errorf("%w: only plan_and_apply tasks can request a freeze exception", ErrChangeFreezeInvalid)
+	}
+	if task.Status != models.TaskStatusApplyPending && task.Status != models.TaskStatusPending && task.Status != models.TaskStatusRunning {
+		return nil, nil, fmt.Errorf("%w: task is already %s", ErrChangeFreezeExceptionState, task.Status)
+	}
+	if strings.TrimSpace(reason) == "" {
+		return nil, nil, fmt.Errorf("%w: reason is required", ErrChangeFreezeInvalid)
+	}
+
+	active, err := s.ActiveFreezes(workspace, time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(active) == 0 {
+		return nil, nil, fmt.Errorf("%w: no change freeze is active for this workspace", ErrChangeFreezeInvalid)
+	}
+
+	var existing int64
+	if err := s.db.Model(&models.ChangeFreezeException{}).
+		Where("task_id = ? AND status IN ?", task.ID,
+			[]string{models.ChangeFreezeExceptionPending, models.ChangeFreezeExceptionApproved}).
+		Count(&existing).Error; err != nil {
+		return nil, nil, err
+	}
+	if existing > 0 {
+		return nil, nil, fmt.Errorf("%w: task already has a pending or approved exception", ErrChangeFreezeExceptionState)
+	}
+
+	windowIDs := make([]string, 0, len(active))
+	for _, freeze := range active {
+		windowIDs = append(windowIDs, freeze.WindowID)
+	}
+	exception := &models.ChangeFreezeException{
+		TaskID:      task.ID,
+		WorkspaceID: task.WorkspaceID,
+		WindowIDs:   strings.Join(windowIDs, ","),
+		Reason:      reason,
+		Status:      models.ChangeFreezeExceptionPending,
+		RequestedBy: userID,
+	}
+	if err := s.db.Create(exception).Error; err != nil {
+		return nil, nil, err
+	}
+	return exception, active, nil
+}
+
+// ListExceptions 列出例外申请，status 为空时返回全部
+func (s *ChangeFreezeService) ListExceptions(status string) ([]models.ChangeFreezeException, error) {
+	query := s.db.Order("id DESC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var exceptions []models.ChangeFreezeException
+	if err := query.Find(&exceptions).Error; err != nil {
+		return nil, err
+	}
+	return exceptions, nil
+}
+
+// ReviewException 批准或拒绝待审批的例外申请，申请人不能审批自己的申请
+func (s *ChangeFreezeService) ReviewException(id uint, approve bool, reviewerID, comment string) (*models.ChangeFreezeException, error) {
+	var exception models.ChangeFreezeException
+	if err := s.db.First(&exception, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrChangeFreezeExceptionNotFound
+		}
+		return nil, err
+	}
+	if exception.Status != models.ChangeFreezeExceptionPending {
+		return nil, fmt.Errorf("%w: exception is already %s", ErrChangeFreezeExceptionState, exception.Status)
+	}
+	if exception.RequestedBy == reviewerID {
+		return nil, ErrChangeFreezeSelfReview
+	}
+
+	status := models.ChangeFreezeExceptionRejected
+	if approve {
+		status = models.ChangeFreezeExceptionApproved
+	}
+	now := time.Now()
+	result := s.db.Model(&models.ChangeFreezeException{}).
+		Where("id = ? AND status = ?", id, models.ChangeFreezeExceptionPending).
+		Updates(map[string]interface{}{
+			"status":         status,
+			"reviewed_by":    reviewerID,
+			"review_comment": comment,
+			"reviewed_at":    now,
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, fmt.Errorf("%w: exception was reviewed concurrently", ErrChangeFreezeExceptionState)
+	}
+
+	exception.Status = status
+	exception.ReviewedBy = &reviewerID
+	exception.ReviewComment = comment
+	exception.ReviewedAt = &now
+	return &exception, nil
+}
+
+// changeFreezeActiveUntil 判断窗口在 at 时刻是否生效，生效时返回本次冻结的结束时间
+func changeFreezeActiveUntil(window *models.ChangeFreezeWindow, at time.Time) (time.Time, bool) {
+	loc, err := time.LoadLocation(window.Timezone)
+	if err != nil {
+		return time.Time{}, false
+	}
+	schedule := window.Schedule
+	local := at.In(loc)
+
+	startsAt, hasStart := parseChangeFreezeTime(schedule.StartsAt, loc)
+	endsAt, hasEnd := parseChangeFreezeTime(schedule.EndsAt, loc)
+	if hasStart && local.Before(startsAt) {
+		return time.Time{}, false
+	}
+	if hasEnd && !local.Before(endsAt) {
+		return time.Time{}, false
+	}
+
+	switch schedule.Type {
+	case models.ChangeFreezeTypeOnce:
+		if !hasStart || !hasEnd {
+			return time.Time{}, false
+		}
+		return endsAt, true
+
+	case models.ChangeFreezeTypeWeekly:
+		from, okFrom := parseClockMinutes(schedule.FromTime)
+		to, okTo := parseClockMinutes(schedule.ToTime)
+		if !okFrom || !okTo {
+			return time.Time{}, false
+		}
+		midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+		minutes := local.Hour()*60 + local.Minute()
+		today := isoWeekday(local.Weekday())
+		yesterday := today - 1
+		if yesterday == 0 {
+			yesterday = 7
+		}
+
+		var until time.Time
+		switch {
+		case from < to:
+			if !containsInt(schedule.Weekdays, today) || minutes < from || minutes >= to {
+				return time.Time{}, false
+			}
+			until = midnight.Add(time.Duration(to) * time.Minute)
+		case containsInt(schedule.Weekdays, today) && minutes >= from:
+			// 跨越午夜（to <= from），当天开始的冻结在次日 to 结束
+			until = midnight.AddDate(0, 0, 1).Add(time.Duration(to) * time.Minute)
+		case containsInt(schedule.Weekdays, yesterday) && minutes < to:
+			// 跨越午夜，前一天开始的冻结在今天 to 结束
+			until = midnight.Add(time.Duration(to) * time.Minute)
+		default:
+			return time.Time{}, false
+		}
+		if hasEnd && until.After(endsAt) {
+			until = endsAt
+		}
+		return until, true
+	}
+	return time.Time{}, false
+}
+
+func parseChangeFreezeTime(value string, loc *time.Location) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation(models.ChangeFreezeTimeLayout, value, loc)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// parseClockMinutes 解析 HH:MM，返回午夜起的分钟数
+func parseClockMinutes(value string) (int, bool) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
+
+// isoWeekday 将 time.Weekday 转换为 1=周一 ... 7=周日
+func isoWeekday(day time.Weekday) int {
+	if day == time.Sunday {
+		return 7
+	}
+	return int(day)
+}
+
+func validateChangeFreezeWindow(req *models.ChangeFreezeWindowRequest) error {
+	switch req.Scope {
+	case models.ChangeFreezeScopeOrganization:
+	case models.ChangeFreezeScopeProject:
+		if len(req.Selector.ProjectIDs) == 0 {
+			return fmt.Errorf("%w: project scope requires selector.project_ids", ErrChangeFreezeInvalid)
+		}
+	default:
+		return fmt.Errorf("%w: scope must be organization or project", ErrChangeFreezeInvalid)
+	}
+
+	timezone := req.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return fmt.Errorf("%w: unknown timezone %q", ErrChangeFreezeInvalid, req.Timezone)
+	}
+
+	schedule := req.Schedule
+	startsAt, hasStart := parseChangeFreezeTime(schedule.StartsAt, loc)
+	if schedule.StartsAt != "" && !hasStart {
+		return fmt.Errorf("%w: starts_at must use format YYYY-MM-DDTHH:MM", ErrChangeFreezeInvalid)
+	}
+	endsAt, hasEnd := parseChangeFreezeTime(schedule.EndsAt, loc)
+	if schedule.EndsAt != "" && !hasEnd {
+		return fmt.Errorf("%w: ends_at must use format YYYY-MM-DDTHH:MM", ErrChangeFreezeInvalid)
+	}
+	if hasStart && hasEnd && !endsAt.After(startsAt) {
+		return fmt.Errorf("%w: ends_at must be after starts_at", ErrChangeFreezeInvalid)
+	}
+
+	switch schedule.Type {
+	case models.ChangeFreezeTypeOnce:
+		if !hasStart || !hasEnd {
+			return fmt.Errorf("%w: once schedule requires starts_at and ends_at", ErrChangeFreezeInvalid)
+		}
+	case models.ChangeFreezeTypeWeekly:
+		if len(schedule.Weekdays) == 0 {
+			return fmt.Errorf("%w: weekly schedule requires weekdays", ErrChangeFreezeInvalid)
+		}
+		for _, day := range schedule.Weekdays {
+			if day < 1 || day > 7 {
+				return fmt.Errorf("%w: weekday %d must be between 1 (Monday) and 7 (Sunday)", ErrChangeFreezeInvalid, day)
+			}
+		}
+		if _, ok := parseClockMinutes(schedule.FromTime); !ok {
+			return fmt.Errorf("%w: from_time must use format HH:MM", ErrChangeFreezeInvalid)
+		}
+		if _, ok := parseClockMinutes(schedule.ToTime); !ok {
+			return fmt.Errorf("%w: to_time must use format HH:MM", ErrChangeFreezeInvalid)
+		}
+	default:
+		return fmt.Errorf("%w: schedule.type must be once or weekly", ErrChangeFreezeInvalid)
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"iac-platform/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangeFreezeActiveUntil(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	require.NoError(t, err)
+
+	holiday := models.ChangeFreezeSchedule{Type: "once", StartsAt: "2026-12-24T00:00", EndsAt: "2027-01-02T09:00"}
+	// 周五 18:00 到周一 06:00 之外的工作日夜间冻结（跨越午夜）
+	nightly := models.ChangeFreezeSchedule{Type: "weekly", Weekdays: []int{1, 2, 3, 4, 5}, FromTime: "22:00", ToTime: "06:00"}
+	business := models.ChangeFreezeSchedule{Type: "weekly", Weekdays: []int{5}, FromTime: "12:00", ToTime: "18:00",
+		StartsAt: "2026-10-01T00:00", EndsAt: "2026-10-31T00:00"}
+
+	tests := []struct {
+		name     string
+		schedule models.ChangeFreezeSchedule
+		tz       string
+		at       time.Time
+		active   bool
+		until    time.Time
+	}{
+		{"holiday before", holiday, "Asia/Shanghai", time.Date(2026, 12, 23, 23, 59, 0, 0, shanghai), false, time.Time{}},
+		{"holiday inside", holiday, "Asia/Shanghai", time.Date(2026, 12, 31, 12, 0, 0, 0, shanghai), true, time.Date(2027, 1, 2, 9, 0, 0, 0, shanghai)},
+		{"holiday evaluated in window timezone", holiday, "Asia/Shanghai", time.Date(2026, 12, 23, 16, 30, 0, 0, time.UTC), true, time.Date(2027, 1, 2, 9, 0, 0, 0, shanghai)},
+		{"holiday end is exclusive", holiday, "Asia/Shanghai", time.Date(2027, 1, 2, 9, 0, 0, 0, shanghai), false, time.Time{}},
+		// 2026-10-19 是周一
+		{"nightly start day", nightly, "UTC", time.Date(2026, 10, 19, 23, 0, 0, 0, time.UTC), true, time.Date(2026, 10, 20, 6, 0, 0, 0, time.UTC)},
+		{"nightly after midnight", nightly, "UTC", time.Date(2026, 10, 20, 5, 59, 0, 0, time.UTC), true, time.Date(2026, 10, 20, 6, 0, 0, 0, time.UTC)},
+		{"nightly daytime", nightly, "UTC", time.Date(2026, 10, 20, 12, 0, 0, 0, time.UTC), false, time.Time{}},
+		{"nightly sunday night not listed", nightly, "UTC", time.Date(2026, 10, 18, 23, 0, 0, 0, time.UTC), false, time.Time{}},
+		{"nightly saturday morning from friday", nightly, "UTC", time.Date(2026, 10, 24, 3, 0, 0, 0, time.UTC), true, time.Date(2026, 10, 24, 6, 0, 0, 0, time.UTC)},
+		{"business friday inside bounds", business, "UTC", time.Date(2026, 10, 23, 13, 0, 0, 0, time.UTC), true, time.Date(2026, 10, 23, 18, 0, 0, 0, time.UTC)},
+		{"business friday after bounds", business, "UTC", time.Date(2026, 11, 6, 13, 0, 0, 0, time.UTC), false, time.Time{}},
+		{"business thursday", business, "UTC", time.Date(2026, 10, 22, 13, 0, 0, 0, time.UTC), false, time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			window := &models.ChangeFreezeWindow{Timezone: tt.tz, Schedule: tt.schedule}
+			until, active := changeFreezeActiveUntil(window, tt.at)
+			assert.Equal(t, tt.active, active)
+			if tt.active {
+				assert.True(t, tt.until.Equal(until), "until = %s, want %s", until, tt.until)
+			}
+		})
+	}
+}
+
+func TestValidateChangeFreezeWindow(t *testing.T) {
+	once := models.ChangeFreezeSchedule{Type: "once", StartsAt: "2026-12-24T00:00", EndsAt: "2027-01-02T00:00"}
+	tests := []struct {
+		name   string
+		req    models.ChangeFreezeWindowRequest
+		errMsg string
+	}{
+		{"valid once", models.ChangeFreezeWindowRequest{Scope: "organization", Timezone: "Europe/Berlin", Schedule: once}, ""},
+		{"valid weekly", models.ChangeFreezeWindowRequest{Scope: "project", Selector: models.ChangeFreezeSelector{ProjectIDs: []uint{1}},
+			Schedule: models.ChangeFreezeSchedule{Type: "weekly", Weekdays: []int{5}, FromTime: "18:00", ToTime: "23:59"}}, ""},
+		{"project without ids", models.ChangeFreezeWindowRequest{Scope: "project", Schedule: once}, "project_ids"},
+		{"unknown timezone", models.ChangeFreezeWindowRequest{Scope: "organization", Timezone: "Mars/Base", Schedule: once}, "timezone"},
+		{"once without end", models.ChangeFreezeWindowRequest{Scope: "organization",
+			Schedule: models.ChangeFreezeSchedule{Type: "once", StartsAt: "2026-12-24T00:00"}}, "requires starts_at and ends_at"},
+		{"end before start", models.ChangeFreezeWindowRequest{Scope: "organization",
+			Schedule: models.ChangeFreezeSchedule{Type: "once", StartsAt: "2026-12-24T00:00", EndsAt: "2026-12-23T00:00"}}, "after starts_at"},
+		{"bad date format", models.ChangeFreezeWindowRequest{Scope: "organization",
+			Schedule: models.ChangeFreezeSchedule{Type: "once", StartsAt: "2026-12-24", EndsAt: "2026-12-25T00:00"}}, "starts_at"},
+		{"weekly bad weekday", models.ChangeFreezeWindowRequest{Scope: "organization",
+			Schedule: models.ChangeFreezeSchedule{Type: "weekly", Weekdays: []int{0}, FromTime: "18:00", ToTime: "20:00"}}, "weekday"},
+		{"weekly bad time", models.ChangeFreezeWindowRequest{Scope: "organization",
+			Schedule: models.ChangeFreezeSchedule{Type: "weekly", Weekdays: []int{1}, FromTime: "25:00", ToTime: "20:00"}}, "from_time"},
+		{"unknown type", models.ChangeFreezeWindowRequest{Scope: "organization", Schedule: models.ChangeFreezeSchedule{Type: "daily"}}, "schedule.type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateChangeFreezeWindow(&tt.req)
+			if tt.errMsg == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrChangeFreezeInvalid)
+				assert.ErrorContains(t, err, tt.errMsg)
+			}
+		})
+	}
+}
+
+// activeOnceSchedule 返回覆盖当前时间的一次性冻结
+func activeOnceSchedule() models.ChangeFreezeSchedule {
+	now := time.Now().UTC()
+	return models.ChangeFreezeSchedule{
+		Type:     "once",
+		StartsAt: now.Add(-time.Hour).Format(models.ChangeFreezeTimeLayout),
+		EndsAt:   now.Add(2 * time.Hour).Format(models.ChangeFreezeTimeLayout),
+	}
+}
+
+func TestChangeFreezeActiveFreezes_Scope(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.Exec(`CREATE TABLE workspace_project_relations (id INTEGER PRIMARY KEY AUTOINCREMENT, workspace_id TEXT, project_id INTEGER, created_at DATETIME)`).Error)
+	createTestWorkspace(t, db, "ws-cf-prod")
+	createTestWorkspace(t, db, "ws-cf-dev")
+	require.NoError(t, db.Exec(`UPDATE workspaces SET tags = ? WHERE workspace_id = ?`, []byte(`{"env":"prod"}`), "ws-cf-prod").Error)
+	require.NoError(t, db.Exec(`UPDATE workspaces SET tags = ? WHERE workspace_id = ?`, []byte(`{"env":"dev"}`), "ws-cf-dev").Error)
+	require.NoError(t, db.Exec(`INSERT INTO workspace_project_relations (workspace_id, project_id) VALUES ('ws-cf-dev', 7)`).Error)
+
+	svc := NewChangeFreezeService(db)
+	disabled := false
+	_, err := svc.CreateWindow(&models.ChangeFreezeWindowRequest{Name: "prod only", Scope: "organization",
+		Selector: models.ChangeFreezeSelector{Tags: map[string]string{"env": "prod"}}, Schedule: activeOnceSchedule()}, "admin")
+	require.NoError(t, err)
+	_, err = svc.CreateWindow(&models.ChangeFreezeWindowRequest{Name: "project 7", Scope: "project",
+		Selector: models.ChangeFreezeSelector{ProjectIDs: []uint{7}}, Schedule: activeOnceSchedule()}, "admin")
+	require.NoError(t, err)
+	_, err = svc.CreateWindow(&models.ChangeFreezeWindowRequest{Name: "disabled", Scope: "organization",
+		Schedule: activeOnceSchedule(), Enabled: &disabled}, "admin")
+	require.NoError(t, err)
+
+	names := func(wsID string) []string {
+		var ws models.Workspace
+		require.NoError(t, db.Where("workspace_id = ?", wsID).First(&ws).Error)
+		active, err := svc.ActiveFreezes(&ws, time.Now())
+		require.NoError(t, err)
+		result := []string{}
+		for _, freeze := range active {
+			result = append(result, freeze.Name)
+		}
+		return result
+	}
+	assert.Equal(t, []string{"prod only"}, names("ws-cf-prod"))
+	assert.Equal(t, []string{"project 7"}, names("ws-cf-dev"))
+}
+
+func TestExecuteConfirmedApply_HeldByChangeFreeze(t *testing.T) {
+	db := setupTestDB(t)
+	poolID := "pool-cf-001"
+	createTestWorkspace(t, db, "ws-cf-001", func(ws *testWorkspace) {
+		ws.ExecutionMode = models.ExecutionModeAgent
+		ws.CurrentPoolID = &poolID
+	})
+	confirmedAt := time.Now()
+	task := createTestTask(t, db, "ws-cf-001", models.TaskTypePlanAndApply, models.TaskStatusApplyPending, func(task *testWorkspaceTask) {
+		task.Stage = "apply_pending"
+		task.ApplyConfirmedBy = strPtr("user-requester")
+		task.ApplyConfirmedAt = &confirmedAt
+	})
+	createTestAgent(t, db, "agent-cf-001", poolID)
+
+	svc := NewChangeFreezeService(db)
+	_, err := svc.CreateWindow(&models.ChangeFreezeWindowRequest{Name: "Year-end freeze", Scope: "organization",
+		Timezone: "UTC", Schedule: activeOnceSchedule()}, "admin")
+	require.NoError(t, err)
+
+	mockHandler := &mockAgentCCHandler{connectedAgents: []string{"agent-cf-001"}}
+	mgr := newTestManager(db, mockHandler, nil)
+
+	// 冻结期间 apply 被挂起，任务保持 apply_pending 并标记 stage
+	err = mgr.ExecuteConfirmedApply("ws-cf-001", task.ID)
+	require.ErrorIs(t, err, ErrChangeFreezeActive)
+	assert.Contains(t, err.Error(), "Year-end freeze until")
+	assert.Empty(t, mockHandler.getSentTasks())
+
+	var held models.WorkspaceTask
+	require.NoError(t, db.First(&held, task.ID).Error)
+	assert.Equal(t, models.TaskStatusApplyPending, held.Status)
+	assert.Equal(t, ChangeFreezeStage, held.Stage)
+
+	// 例外申请：申请人不能自己审批，批准后 apply 继续
+	exception, active, err := svc.RequestException(&held, &models.Workspace{WorkspaceID: "ws-cf-001"}, "hotfix for outage", "user-requester")
+	require.NoError(t, err)
+	assert.Len(t, active, 1)
+	assert.Equal(t, active[0].WindowID, exception.WindowIDs)
+
+	_, _, err = svc.RequestException(&held, &models.Workspace{WorkspaceID: "ws-cf-001"}, "again", "user-requester")
+	assert.ErrorIs(t, err, ErrChangeFreezeExceptionState)
+
+	_, err = svc.ReviewException(exception.ID, true, "user-requester", "")
+	assert.ErrorIs(t, err, ErrChangeFreezeSelfReview)
+
+	err = mgr.ExecuteConfirmedApply("ws-cf-001", task.ID)
+	require.ErrorIs(t, err, ErrChangeFreezeActive, "pending exception does not release the apply")
+
+	approved, err := svc.ReviewException(exception.ID, true, "user-approver", "approved by CAB")
+	require.NoError(t, err)
+	assert.Equal(t, models.ChangeFreezeExceptionApproved, approved.Status)
+
+	_, err = svc.ReviewException(exception.ID, false, "user-approver", "")
+	assert.ErrorIs(t, err, ErrChangeFreezeExceptionState)
+
+	require.NoError(t, mgr.ExecuteConfirmedApply("ws-cf-001", task.ID))
+	sent := mockHandler.getSentTasks()
+	require.Len(t, sent, 1)
+	assert.Equal(t, "apply", sent[0].Action)
+}
+
+func TestChangeFreezeRequestException_NoActiveFreeze(t *testing.T) {
+	db := setupTestDB(t)
+	createTestWorkspace(t, db, "ws-cf-002")
+	task := createTestTask(t, db, "ws-cf-002", models.TaskTypePlanAndApply, models.TaskStatusApplyPending)
+
+	_, _, err := NewChangeFreezeService(db).RequestException(task, &models.Workspace{WorkspaceID: "ws-cf-002"}, "hotfix", "user-1")
+	assert.ErrorIs(t, err, ErrChangeFreezeInvalid)
+	assert.ErrorContains(t, err, "no change freeze is active")
+}
@@ -10,7 +10,10 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"iac-platform/internal/crypto"
@@ -24,18 +27,47 @@ import (
 type CMDBExternalSourceService struct {
 	db         *gorm.DB
 	httpClient *http.Client
+	importDir  string // 文件类型数据源的挂载导入根目录
+
+	mu      sync.Mutex
+	storage ArtifactStorage // 上传文件的存储，首次使用时按产物存储配置初始化
 }
 
 // NewCMDBExternalSourceService 创建外部数据源服务
 func NewCMDBExternalSourceService(db *gorm.DB) *CMDBExternalSourceService {
+	importDir := os.Getenv("CMDB_IMPORT_DIR")
+	if importDir == "" {
+		importDir = DefaultCMDBImportDir
+	}
 	return &CMDBExternalSourceService{
 		db: db,
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		importDir: importDir,
 	}
 }
 
+// getStorage 获取上传文件使用的存储
+func (s *CMDBExternalSourceService) getStorage() (ArtifactStorage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.storage != nil {
+		return s.storage, nil
+	}
+	cfg, err := LoadArtifactStorageConfig(s.db)
+	if err != nil {
+		return nil, err
+	}
+	storage, err := NewArtifactStorage(cfg)
+	if err != nil {
+		return nil, err
+	}
+	s.storage = storage
+	return storage, nil
+}
+
 // generateSecretID 生成密钥ID
 func generateSecretID() string {
 	b := make([]byte, 8)
@@ -52,9 +84,25 @@ func GenerateSourceID() string {
 
 // CreateExternalSource 创建外部数据源
 func (s *CMDBExternalSourceService) CreateExternalSource(ctx context.Context, req *models.CreateExternalSourceRequest, userID string) (*models.CMDBExternalSource, error) {
-	// 1. 生成source_id
+	// 1. 生成source_id，校验类型相关配置
 	sourceID := GenerateSourceID()
 
+	sourceType := req.SourceType
+	if sourceType == "" {
+		sourceType = models.ExternalSourceTypeHTTP
+	}
+	sourceConfig := req.SourceConfig
+	if sourceConfig == nil {
+		sourceConfig = &models.ExternalSourceConfig{}
+	}
+	if err := validateSourceConfig(sourceType, req.APIEndpoint, sourceConfig); err != nil {
+		return nil, err
+	}
+
+	if sourceType == models.ExternalSourceTypeSQL && sourceConfig.SQL.DSN == "" {
+		return nil, fmt.Errorf("sql source requires a DSN")
+	}
+
 	// 2. 处理auth_headers - 将value加密存储到secrets表
 	var authHeaders []models.AuthHeader
 	for _, h := range req.AuthHeaders {
@@ -86,11 +134,27 @@ func (s *CMDBExternalSourceService) CreateExternalSource(ctx context.Context, re
 		return nil, fmt.Errorf("failed to marshal field_mapping: %w", err)
 	}
 
-	// 5. 创建数据源记录
+	// 5. SQL DSN 加密存储到secrets表，source_config中只保留secret_id
+	if sourceConfig.SQL != nil && sourceConfig.SQL.DSN != "" {
+		secretID, err := s.storeSourceSecret(sourceID, "sql_dsn", sourceConfig.SQL.DSN, "CMDB External Source SQL DSN", userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to store DSN secret: %w", err)
+		}
+		sourceConfig.SQL.DSNSecretID = secretID
+		sourceConfig.SQL.DSN = ""
+	}
+	if sourceConfig.File != nil {
+		// 上传文件只能通过上传接口设置
+		sourceConfig.File.ObjectKey = ""
+		sourceConfig.File.FileName = ""
+	}
+
+	// 6. 创建数据源记录
 	source := &models.CMDBExternalSource{
 		SourceID:            sourceID,
 		Name:                req.Name,
 		Description:         req.Description,
+		SourceType:          sourceType,
 		APIEndpoint:         req.APIEndpoint,
 		HTTPMethod:          req.HTTPMethod,
 		RequestBody:         req.RequestBody,
@@ -112,6 +176,9 @@ func (s *CMDBExternalSourceService) CreateExternalSource(ctx context.Context, re
 	if source.HTTPMethod == "" {
 		source.HTTPMethod = "GET"
 	}
+	if err := source.SetSourceConfig(sourceConfig); err != nil {
+		return nil, fmt.Errorf("failed to marshal source_config: %w", err)
+	}
 
 	if err := s.db.Create(source).Error; err != nil {
 		return nil, fmt.Errorf("failed to create external source: %w", err)
@@ -120,14 +187,70 @@ func (s *CMDBExternalSourceService) CreateExternalSource(ctx context.Context, re
 	return source, nil
 }
 
+// validateSourceConfig 校验数据源类型及其配置
+func validateSourceConfig(sourceType, apiEndpoint string, config *models.ExternalSourceConfig) error {
+	switch sourceType {
+	case models.ExternalSourceTypeHTTP:
+		if apiEndpoint == "" {
+			return fmt.Errorf("api_endpoint is required for http sources")
+		}
+		if config.RateLimitPerSecond < 0 {
+			return fmt.Errorf("rate_limit_per_second must not be negative")
+		}
+		if p := config.Pagination; p != nil {
+			switch p.Strategy {
+			case "", models.PaginationNone, models.PaginationOffset, models.PaginationPage, models.PaginationLinkHeader:
+			case models.PaginationCursor:
+				if p.NextCursorPath == "" {
+					return fmt.Errorf("cursor pagination requires next_cursor_path")
+				}
+			default:
+				return fmt.Errorf("unsupported pagination strategy: %s", p.Strategy)
+			}
+		}
+	case models.ExternalSourceTypeFile:
+		if config.File == nil {
+			return fmt.Errorf("file source requires source_config.file")
+		}
+		switch config.File.Format {
+		case "", "csv", "json", "jsonl":
+		default:
+			return fmt.Errorf("unsupported file format: %s", config.File.Format)
+		}
+		if config.File.Path != "" {
+			if _, err := resolveImportPath(DefaultCMDBImportDir, config.File.Path); err != nil {
+				return err
+			}
+		}
+	case models.ExternalSourceTypeSQL:
+		if config.SQL == nil {
+			return fmt.Errorf("sql source requires source_config.sql")
+		}
+		if _, ok := sqlSourceDrivers[config.SQL.Driver]; !ok {
+			return fmt.Errorf("unsupported SQL driver: %s", config.SQL.Driver)
+		}
+		if err := validateReadOnlyQuery(config.SQL.Query); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported source type: %s", sourceType)
+	}
+	return nil
+}
+
 // storeHeaderSecret 存储Header密钥到secrets表
 func (s *CMDBExternalSourceService) storeHeaderSecret(sourceID, key, value, userID string) (string, error) {
+	return s.storeSourceSecret(sourceID, key, value, fmt.Sprintf("CMDB External Source Header: %s", key), userID)
+}
+
+// storeSourceSecret 存储数据源密钥（Header值、SQL DSN）到secrets表
+func (s *CMDBExternalSourceService) storeSourceSecret(sourceID, key, value, description, userID string) (string, error) {
 	secretID := generateSecretID()
 
 	// 使用crypto包加密存储
 	metadata := map[string]interface{}{
 		"key":         key,
-		"description": fmt.Sprintf("CMDB External Source Header: %s", key),
+		"description": description,
 	}
 	metadataJSON, _ := json.Marshal(metadata)
 
@@ -213,6 +336,67 @@ func (s *CMDBExternalSourceService) UpdateExternalSource(ctx context.Context, so
 		updates["field_mapping"] = fieldMappingJSON
 	}
 
+	// 类型相关配置：校验更新后的完整配置，保留已存储的DSN和上传文件
+	sourceType := source.GetSourceType()
+	if req.SourceType != nil {
+		sourceType = *req.SourceType
+		updates["source_type"] = sourceType
+	}
+	apiEndpoint := source.APIEndpoint
+	if req.APIEndpoint != nil {
+		apiEndpoint = *req.APIEndpoint
+	}
+	existingConfig, err := source.GetSourceConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse source_config: %w", err)
+	}
+	sourceConfig := existingConfig
+	if req.SourceConfig != nil {
+		sourceConfig = req.SourceConfig
+		if sourceConfig.File != nil {
+			sourceConfig.File.ObjectKey = ""
+			sourceConfig.File.FileName = ""
+			if existingConfig.File != nil {
+				sourceConfig.File.ObjectKey = existingConfig.File.ObjectKey
+				sourceConfig.File.FileName = existingConfig.File.FileName
+			}
+		}
+		if sourceConfig.SQL != nil {
+			sourceConfig.SQL.DSNSecretID = ""
+			if existingConfig.SQL != nil {
+				sourceConfig.SQL.DSNSecretID = existingConfig.SQL.DSNSecretID
+			}
+		}
+	}
+	if sourceType == models.ExternalSourceTypeSQL && sourceConfig.SQL != nil &&
+		sourceConfig.SQL.DSN == "" && sourceConfig.SQL.DSNSecretID == "" {
+		return nil, fmt.Errorf("sql source requires a DSN")
+	}
+	if err := validateSourceConfig(sourceType, apiEndpoint, sourceConfig); err != nil {
+		return nil, err
+	}
+	if req.SourceConfig != nil {
+		if sourceConfig.SQL != nil && sourceConfig.SQL.DSN != "" {
+			if sourceConfig.SQL.DSNSecretID != "" {
+				if err := s.updateHeaderSecret(sourceConfig.SQL.DSNSecretID, sourceConfig.SQL.DSN, userID); err != nil {
+					return nil, fmt.Errorf("failed to update DSN secret: %w", err)
+				}
+			} else {
+				secretID, err := s.storeSourceSecret(sourceID, "sql_dsn", sourceConfig.SQL.DSN, "CMDB External Source SQL DSN", userID)
+				if err != nil {
+					return nil, fmt.Errorf("failed to store DSN secret: %w", err)
+				}
+				sourceConfig.SQL.DSNSecretID = secretID
+			}
+			sourceConfig.SQL.DSN = ""
+		}
+		if sourceConfig.SQL == nil && existingConfig.SQL != nil && existingConfig.SQL.DSNSecretID != "" {
+			s.db.Where("secret_id = ?", existingConfig.SQL.DSNSecretID).Delete(&models.Secret{})
+		}
+		sourceConfigJSON, _ := json.Marshal(sourceConfig)
+		updates["source_config"] = sourceConfigJSON
+	}
+
 	// 3. 处理auth_headers更新
 	if req.AuthHeaders != nil {
 		// 获取现有的headers
@@ -305,7 +489,14 @@ func (s *CMDBExternalSourceService) updateHeaderSecret(secretID, value, userID s
 
 // DeleteExternalSource 删除外部数据源
 func (s *CMDBExternalSourceService) DeleteExternalSource(ctx context.Context, sourceID string) error {
-	return s.db.Transaction(func(tx *gorm.DB) error {
+	var uploadKey string
+	if source, err := s.GetExternalSource(ctx, sourceID); err == nil {
+		if config, err := source.GetSourceConfig(); err == nil && config.File != nil {
+			uploadKey = config.File.ObjectKey
+		}
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
 		// 1. 删除关联的secrets
 		if err := tx.Where("resource_type = ? AND resource_id = ?", "cmdb_external_source", sourceID).
 			Delete(&models.Secret{}).Error; err != nil {
@@ -327,6 +518,80 @@ func (s *CMDBExternalSourceService) DeleteExternalSource(ctx context.Context, so
 
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	// 5. 删除上传的导入文件
+	if uploadKey != "" {
+		if storage, err := s.getStorage(); err == nil {
+			if err := storage.Delete(ctx, uploadKey); err != nil {
+				log.Printf("[CMDBExternalSource] Failed to delete uploaded file %s: %v", uploadKey, err)
+			}
+		}
+	}
+	return nil
+}
+
+// UploadSourceFile 上传文件类型数据源的导入文件，替换之前上传的文件
+func (s *CMDBExternalSourceService) UploadSourceFile(ctx context.Context, sourceID, fileName string, data []byte, userID string) (*models.CMDBExternalSource, error) {
+	source, err := s.GetExternalSource(ctx, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	if source.GetSourceType() != models.ExternalSourceTypeFile {
+		return nil, fmt.Errorf("source %s is not a file source", sourceID)
+	}
+	config, err := source.GetSourceConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse source_config: %w", err)
+	}
+	if config.File == nil {
+		config.File = &models.FileSourceConfig{}
+	}
+
+	name := filepath.Base(filepath.Clean("/" + fileName))
+	if name == "/" || name == "." {
+		return nil, fmt.Errorf("invalid file name")
+	}
+	format := config.File.Format
+	if format == "" {
+		format = fileFormatFromName(name)
+	}
+	if format == "" {
+		return nil, fmt.Errorf("cannot determine file format from %q, set source_config.file.format", name)
+	}
+
+	storage, err := s.getStorage()
+	if err != nil {
+		return nil, err
+	}
+	compressed, err := compressArtifact(data)
+	if err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("cmdb-sources/%s/%s", sourceID, name)
+	if err := storage.Put(ctx, key, compressed); err != nil {
+		return nil, fmt.Errorf("failed to store file: %w", err)
+	}
+	if previous := config.File.ObjectKey; previous != "" && previous != key {
+		if err := storage.Delete(ctx, previous); err != nil {
+			log.Printf("[CMDBExternalSource] Failed to delete previous upload %s: %v", previous, err)
+		}
+	}
+
+	config.File.ObjectKey = key
+	config.File.FileName = name
+	configJSON, _ := json.Marshal(config)
+	if err := s.db.Model(source).Updates(map[string]interface{}{
+		"source_config": configJSON,
+		"updated_by":    userID,
+		"updated_at":    time.Now(),
+	}).Error; err != nil {
+		return nil, fmt.Errorf("failed to update external source: %w", err)
+	}
+
+	return s.GetExternalSource(ctx, sourceID)
 }
 
 // GetExternalSource 获取外部数据源详情
@@ -347,6 +612,9 @@ func (s *CMDBExternalSourceService) ListExternalSources(ctx context.Context) ([]
 	return sources, nil
 }
 
+// testConnectionSampleSize 测试连接返回的样本数量
+const testConnectionSampleSize = 5
+
 // TestConnection 测试连接
 func (s *CMDBExternalSourceService) TestConnection(ctx context.Context, sourceID string) (*models.TestConnectionResponse, error) {
 	// 1. 获取数据源配置
@@ -355,75 +623,30 @@ func (s *CMDBExternalSourceService) TestConnection(ctx context.Context, sourceID
 		return nil, err
 	}
 
-	// 2. 构建HTTP请求
-	req, err := s.buildHTTPRequest(ctx, source)
+	// 2. 拉取样本数据（HTTP只请求到足够样本的页）
+	fetcher, err := s.newExternalSourceFetcher(source)
 	if err != nil {
 		return &models.TestConnectionResponse{
 			Success: false,
-			Message: fmt.Sprintf("Failed to build request: %v", err),
+			Message: err.Error(),
 		}, nil
 	}
-
-	// 3. 发送请求
-	resp, err := s.httpClient.Do(req)
+	records, err := fetcher.Fetch(ctx, source, testConnectionSampleSize)
 	if err != nil {
 		return &models.TestConnectionResponse{
 			Success: false,
 			Message: fmt.Sprintf("Connection failed: %v", err),
 		}, nil
 	}
-	defer resp.Body.Close()
-
-	// 4. 检查响应状态
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return &models.TestConnectionResponse{
-			Success: false,
-			Message: fmt.Sprintf("HTTP %d: %s", resp.StatusCode, string(body)),
-		}, nil
-	}
-
-	// 5. 解析响应
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return &models.TestConnectionResponse{
-			Success: false,
-			Message: fmt.Sprintf("Failed to read response: %v", err),
-		}, nil
-	}
-
-	var responseData interface{}
-	if err := json.Unmarshal(body, &responseData); err != nil {
-		return &models.TestConnectionResponse{
-			Success: false,
-			Message: fmt.Sprintf("Failed to parse JSON response: %v", err),
-		}, nil
-	}
 
-	// 6. 提取数据
-	data, err := s.extractDataFromResponse(responseData, source.ResponsePath)
-	if err != nil {
-		return &models.TestConnectionResponse{
-			Success: false,
-			Message: fmt.Sprintf("Failed to extract data: %v", err),
-		}, nil
-	}
-
-	// 7. 返回样本数据
-	dataArray, ok := data.([]interface{})
-	if !ok {
-		return &models.TestConnectionResponse{
-			Success:     true,
-			Message:     "Connection successful, but response is not an array",
-			SampleCount: 1,
-			SampleData:  []interface{}{data},
-		}, nil
-	}
-
-	sampleCount := len(dataArray)
-	sampleData := dataArray
-	if sampleCount > 5 {
-		sampleData = dataArray[:5]
+	// 3. 返回样本数据
+	sampleCount := len(records)
+	sampleData := make([]interface{}, 0, testConnectionSampleSize)
+	for _, record := range records {
+		if len(sampleData) == testConnectionSampleSize {
+			break
+		}
+		sampleData = append(sampleData, record)
 	}
 
 	return &models.TestConnectionResponse{
@@ -615,57 +838,17 @@ func (s *CMDBExternalSourceService) createEmbeddingTasksForExternalSource(source
 
 // doSync 执行同步
 func (s *CMDBExternalSourceService) doSync(ctx context.Context, source *models.CMDBExternalSource) (added, updated, deleted int, err error) {
-	// 1. 构建HTTP请求
-	req, err := s.buildHTTPRequest(ctx, source)
+	// 1-4. 按数据源类型拉取全部记录
+	fetcher, err := s.newExternalSourceFetcher(source)
 	if err != nil {
-		return 0, 0, 0, fmt.Errorf("failed to build request: %w", err)
-	}
-
-	// 2. 发送请求
-	resp, err := s.httpClient.Do(req)
-	if err != nil {
-		return 0, 0, 0, fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return 0, 0, 0, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
-	}
-
-	// 3. 解析响应
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return 0, 0, 0, fmt.Errorf("failed to read response: %w", err)
-	}
-
-	var responseData interface{}
-	if err := json.Unmarshal(body, &responseData); err != nil {
-		return 0, 0, 0, fmt.Errorf("failed to parse JSON: %w", err)
+		return 0, 0, 0, err
 	}
-
-	// 4. 提取数据
-	data, err := s.extractDataFromResponse(responseData, source.ResponsePath)
+	records, err := fetcher.Fetch(ctx, source, 0)
 	if err != nil {
 		return 0, 0, 0, err
 	}
 
-	// 确保data是数组类型
-	var dataArray []interface{}
-	switch v := data.(type) {
-	case []interface{}:
-		dataArray = v
-	case []map[string]interface{}:
-		// 转换为[]interface{}
-		for _, item := range v {
-			dataArray = append(dataArray, item)
-		}
-	default:
-		// 如果不是数组，包装成单元素数组
-		dataArray = []interface{}{data}
-	}
-
-	log.Printf("[CMDBSync] Extracted data: type=%T, dataArray length=%d", data, len(dataArray))
+	log.Printf("[CMDBSync] Fetched %d records from %s source %s", len(records), source.GetSourceType(), source.SourceID)
 
 	// 5. 获取字段映射
 	var fieldMapping map[string]string
@@ -683,12 +866,7 @@ func (s *CMDBExternalSourceService) doSync(ctx context.Context, source *models.C
 
 	// 7. 处理每个资源
 	processedKeys := make(map[string]bool)
-	for _, item := range dataArray {
-		itemMap, ok := item.(map[string]interface{})
-		if !ok {
-			continue
-		}
-
+	for _, itemMap := range records {
 		// 提取主键值
 		primaryKeyValue := s.extractFieldValue(itemMap, source.PrimaryKeyField)
 		if primaryKeyValue == "" {
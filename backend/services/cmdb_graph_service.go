@@ -0,0 +1,609 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"iac-platform/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// 图中边的类型
+const (
+	GraphEdgeReference  = "reference"   // 资源属性引用了另一个资源的 ID/ARN
+	GraphEdgeRemoteData = "remote_data" // workspace 通过远程数据读取另一个 workspace 的输出
+	GraphEdgeRunTrigger = "run_trigger" // 源 workspace apply 成功后触发目标 workspace
+)
+
+// defaultImpactDepth 影响分析默认的最大传播深度
+const defaultImpactDepth = 10
+
+// CMDBGraphService 跨 workspace 资源关系图服务
+// 关系图由 resource_index 推导：资源属性值中引用了其他资源的 ID/ARN 即视为依赖（如 subnet → VPC、instance → SG），
+// workspace 之间的依赖来自远程数据和 Run Trigger
+type CMDBGraphService struct {
+	db *gorm.DB
+
+	// 资源节点和引用边的缓存：解析全部资源属性代价较高，resource_index 未变化时复用
+	cacheMu  sync.Mutex
+	cacheKey string
+	cached   *resourceGraphCache
+}
+
+// resourceGraphCache 由 resource_index 推导出的节点和边，只读共享
+type resourceGraphCache struct {
+	nodes      map[string]GraphNode
+	edges      []GraphEdge
+	dependents map[string][]GraphEdge
+}
+
+// NewCMDBGraphService 创建资源关系图服务
+func NewCMDBGraphService(db *gorm.DB) *CMDBGraphService {
+	return &CMDBGraphService{db: db}
+}
+
+// GraphNode 关系图中的资源节点
+type GraphNode struct {
+	ID               string `json:"id"` // workspace_id/terraform_address
+	WorkspaceID      string `json:"workspace_id"`
+	WorkspaceName    string `json:"workspace_name,omitempty"`
+	TerraformAddress string `json:"terraform_address"`
+	ResourceType     string `json:"resource_type"`
+	CloudResourceID  string `json:"cloud_resource_id,omitempty"`
+	CloudResourceARN string `json:"cloud_resource_arn,omitempty"`
+}
+
+// GraphEdge 资源依赖边，From 依赖 To
+type GraphEdge struct {
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Type      string `json:"type"`
+	Attribute string `json:"attribute,omitempty"` // 引用所在的属性路径，如 vpc_id、vpc_security_group_ids.0
+}
+
+// WorkspaceEdge workspace 依赖边，From 依赖 To
+type WorkspaceEdge struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Type   string `json:"type"`             // reference / remote_data / run_trigger
+	Detail string `json:"detail,omitempty"` // 远程数据名称等
+}
+
+// ResourceGraph 资源关系图
+type ResourceGraph struct {
+	Nodes          []GraphNode       `json:"nodes"`
+	Edges          []GraphEdge       `json:"edges"`
+	WorkspaceEdges []WorkspaceEdge   `json:"workspace_edges"`
+	Workspaces     map[string]string `json:"workspaces"` // workspace_id -> name
+}
+
+// ImpactTarget 影响分析的起点资源，address 或云资源ID二选一
+type ImpactTarget struct {
+	WorkspaceID      string `json:"workspace_id" form:"workspace_id"`
+	TerraformAddress string `json:"address" form:"address"`
+	CloudResourceID  string `json:"cloud_resource_id" form:"cloud_resource_id"`
+}
+
+// ImpactedResource 受影响的资源
+type ImpactedResource struct {
+	GraphNode
+	Depth     int    `json:"depth"`      // 距起点的依赖层数
+	DependsOn string `json:"depends_on"` // 直接依赖的节点
+	Attribute string `json:"attribute,omitempty"`
+}
+
+// ImpactedWorkspace 受影响的 workspace
+type ImpactedWorkspace struct {
+	WorkspaceID   string   `json:"workspace_id"`
+	WorkspaceName string   `json:"workspace_name,omitempty"`
+	Depth         int      `json:"depth"`
+	Reasons       []string `json:"reasons"` // reference / remote_data / run_trigger
+}
+
+// ImpactReport 影响分析结果
+type ImpactReport struct {
+	Roots      []GraphNode         `json:"roots"`
+	Resources  []ImpactedResource  `json:"resources"`
+	Workspaces []ImpactedWorkspace `json:"workspaces"`
+}
+
+// TaskImpactReport 计划中删除/替换的资源对其他资源和 workspace 的影响
+type TaskImpactReport struct {
+	TaskID  uint             `json:"task_id"`
+	Changes []TaskImpactRoot `json:"changes"`
+	Impact  *ImpactReport    `json:"impact"`
+	Missing []string         `json:"missing,omitempty"` // 计划中涉及但尚未出现在 CMDB 中的资源
+	// 调用方无权读取的 workspace 中受影响的资源和 workspace 数量，明细已移除
+	HiddenResources  int `json:"hidden_resources,omitempty"`
+	HiddenWorkspaces int `json:"hidden_workspaces,omitempty"`
+}
+
+// TaskImpactRoot 计划中作为影响起点的资源变更
+type TaskImpactRoot struct {
+	ResourceAddress string `json:"resource_address"`
+	Action          string `json:"action"`
+}
+
+// graphIndex 构建好的关系图及查询索引
+type graphIndex struct {
+	nodes      map[string]*GraphNode
+	edges      []GraphEdge
+	dependents map[string][]GraphEdge // to -> 依赖它的边
+	wsEdges    []WorkspaceEdge
+	wsNames    map[string]string
+}
+
+// BuildGraph 构建资源关系图；workspaceID 非空时只返回与该 workspace 相关的节点和边
+func (s *CMDBGraphService) BuildGraph(workspaceID string) (*ResourceGraph, error) {
+	index, err := s.buildIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	graph := &ResourceGraph{
+		Nodes:          []GraphNode{},
+		Edges:          []GraphEdge{},
+		WorkspaceEdges: []WorkspaceEdge{},
+		Workspaces:     map[string]string{},
+	}
+	included := make(map[string]bool)
+	for _, edge := range index.edges {
+		if workspaceID != "" && index.nodes[edge.From].WorkspaceID != workspaceID && index.nodes[edge.To].WorkspaceID != workspaceID {
+			continue
+		}
+		graph.Edges = append(graph.Edges, edge)
+		included[edge.From] = true
+		included[edge.To] = true
+	}
+	for id, node := range index.nodes {
+		if workspaceID == "" || node.WorkspaceID == workspaceID {
+			included[id] = true
+		}
+	}
+	for _, id := range sortedKeys(included) {
+		node := *index.nodes[id]
+		graph.Nodes = append(graph.Nodes, node)
+		graph.Workspaces[node.WorkspaceID] = index.wsNames[node.WorkspaceID]
+	}
+	for _, edge := range index.wsEdges {
+		if workspaceID != "" && edge.From != workspaceID && edge.To != workspaceID {
+			continue
+		}
+		graph.WorkspaceEdges = append(graph.WorkspaceEdges, edge)
+		graph.Workspaces[edge.From] = index.wsNames[edge.From]
+		graph.Workspaces[edge.To] = index.wsNames[edge.To]
+	}
+	return graph, nil
+}
+
+// AnalyzeImpact 分析替换/删除目标资源时受影响的资源和 workspace
+func (s *CMDBGraphService) AnalyzeImpact(targets []ImpactTarget, maxDepth int) (*ImpactReport, error) {
+	index, err := s.buildIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	var roots []string
+	for _, target := range targets {
+		matched := index.match(target)
+		if len(matched) == 0 {
+			return nil, fmt.Errorf("资源不存在: %s", target.describe())
+		}
+		roots = append(roots, matched...)
+	}
+	return index.impact(roots, maxDepth), nil
+}
+
+// AnalyzeTaskImpact 对任务计划中删除或替换的资源做影响分析，供计划审核页面使用
+func (s *CMDBGraphService) AnalyzeTaskImpact(workspaceID string, taskID uint) (*TaskImpactReport, error) {
+	var changes []models.WorkspaceTaskResourceChange
+	if err := s.db.Where("workspace_id = ? AND task_id = ? AND action IN ?", workspaceID, taskID, []string{"delete", "replace"}).
+		Order("id ASC").
+		Find(&changes).Error; err != nil {
+		return nil, fmt.Errorf("加载资源变更失败: %w", err)
+	}
+
+	index, err := s.buildIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &TaskImpactReport{TaskID: taskID, Changes: []TaskImpactRoot{}}
+	var roots []string
+	for _, change := range changes {
+		report.Changes = append(report.Changes, TaskImpactRoot{ResourceAddress: change.ResourceAddress, Action: change.Action})
+		matched := index.match(ImpactTarget{WorkspaceID: workspaceID, TerraformAddress: change.ResourceAddress})
+		if len(matched) == 0 {
+			report.Missing = append(report.Missing, change.ResourceAddress)
+			continue
+		}
+		roots = append(roots, matched...)
+	}
+	report.Impact = index.impact(roots, defaultImpactDepth)
+	return report, nil
+}
+
+// FilterWorkspaces 移除调用方无权读取的 workspace 中的受影响资源和 workspace，只保留数量
+func (r *TaskImpactReport) FilterWorkspaces(canRead func(workspaceID string) bool) {
+	if r.Impact == nil {
+		return
+	}
+	hidden := make(map[string]bool)
+	visible := func(workspaceID string) bool {
+		if v, ok := hidden[workspaceID]; ok {
+			return !v
+		}
+		hidden[workspaceID] = !canRead(workspaceID)
+		return !hidden[workspaceID]
+	}
+
+	resources := r.Impact.Resources[:0]
+	for _, res := range r.Impact.Resources {
+		if !visible(res.WorkspaceID) {
+			r.HiddenResources++
+			continue
+		}
+		// 直接依赖的节点在不可读的 workspace 中时不暴露其地址
+		if dependsOn, _, ok := strings.Cut(res.DependsOn, "/"); ok && !visible(dependsOn) {
+			res.DependsOn = ""
+			res.Attribute = ""
+		}
+		resources = append(resources, res)
+	}
+	r.Impact.Resources = resources
+
+	workspaces := r.Impact.Workspaces[:0]
+	for _, ws := range r.Impact.Workspaces {
+		if !visible(ws.WorkspaceID) {
+			r.HiddenWorkspaces++
+			continue
+		}
+		workspaces = append(workspaces, ws)
+	}
+	r.Impact.Workspaces = workspaces
+}
+
+// buildIndex 加载资源和 workspace 关系并推导依赖边
+func (s *CMDBGraphService) buildIndex() (*graphIndex, error) {
+	graph, err := s.resourceGraph()
+	if err != nil {
+		return nil, err
+	}
+
+	// 节点会被补充 workspace 名称，复制一份避免修改缓存
+	index := &graphIndex{
+		nodes:      make(map[string]*GraphNode, len(graph.nodes)),
+		edges:      graph.edges,
+		dependents: graph.dependents,
+	}
+	for id, node := range graph.nodes {
+		index.nodes[id] = &node
+	}
+
+	if err := s.loadWorkspaceEdges(index); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+// resourceGraph 返回资源节点和引用边；resource_index 的行数、最大 ID 和最近同步时间均未变化时使用缓存
+func (s *CMDBGraphService) resourceGraph() (*resourceGraphCache, error) {
+	var fingerprint struct {
+		Count    int64
+		MaxID    uint
+		LastSync string
+	}
+	if err := s.graphRows().
+		Select("COUNT(*) AS count, COALESCE(MAX(id), 0) AS max_id, CAST(MAX(last_synced_at) AS TEXT) AS last_sync").
+		Scan(&fingerprint).Error; err != nil {
+		return nil, fmt.Errorf("加载资源索引失败: %w", err)
+	}
+	key := fmt.Sprintf("%d/%d/%s", fingerprint.Count, fingerprint.MaxID, fingerprint.LastSync)
+
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+	if s.cached != nil && s.cacheKey == key {
+		return s.cached, nil
+	}
+	graph, err := s.loadResourceGraph()
+	if err != nil {
+		return nil, err
+	}
+	s.cached, s.cacheKey = graph, key
+	return graph, nil
+}
+
+// graphRows 参与关系图的资源：Terraform 管理的资源（不含 data source 和外部数据源）
+func (s *CMDBGraphService) graphRows() *gorm.DB {
+	return s.db.Model(&models.ResourceIndex{}).
+		Where("resource_mode = ? AND (source_type = ? OR source_type IS NULL OR source_type = '')", "managed", "terraform")
+}
+
+// loadResourceGraph 加载资源并从属性值中推导引用边
+func (s *CMDBGraphService) loadResourceGraph() (*resourceGraphCache, error) {
+	var rows []reconciliationRow
+	if err := s.graphRows().
+		Select("workspace_id, terraform_address, resource_type, cloud_resource_id, cloud_resource_arn, attributes").
+		Order("workspace_id, terraform_address").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("加载资源索引失败: %w", err)
+	}
+
+	index := &resourceGraphCache{
+		nodes:      make(map[string]GraphNode, len(rows)),
+		dependents: make(map[string][]GraphEdge),
+	}
+
+	// 资源标识（ID/ARN）-> 节点
+	identifiers := make(map[string][]string)
+	for _, row := range rows {
+		node := GraphNode{
+			ID:               row.WorkspaceID + "/" + row.TerraformAddress,
+			WorkspaceID:      row.WorkspaceID,
+			TerraformAddress: row.TerraformAddress,
+			ResourceType:     row.ResourceType,
+			CloudResourceID:  row.CloudResourceID,
+			CloudResourceARN: row.CloudResourceARN,
+		}
+		index.nodes[node.ID] = node
+		for _, identifier := range nonEmptyKeys(row.CloudResourceID, row.CloudResourceARN) {
+			if referenceableIdentifier(identifier) {
+				identifiers[identifier] = append(identifiers[identifier], node.ID)
+			}
+		}
+	}
+
+	// 扫描属性值中的引用
+	for _, row := range rows {
+		if len(row.Attributes) == 0 {
+			continue
+		}
+		var attrs map[string]interface{}
+		if err := json.Unmarshal(row.Attributes, &attrs); err != nil {
+			continue
+		}
+		from := row.WorkspaceID + "/" + row.TerraformAddress
+		seen := make(map[string]bool)
+		for _, key := range sortedKeys(attrs) {
+			// 自身标识和标签不构成依赖
+			if key == "id" || key == "arn" || key == "tags" || key == "tags_all" {
+				continue
+			}
+			walkAttributeStrings(key, attrs[key], func(path, value string) {
+				for _, to := range identifiers[value] {
+					if to == from || seen[to] {
+						continue
+					}
+					seen[to] = true
+					edge := GraphEdge{From: from, To: to, Type: GraphEdgeReference, Attribute: path}
+					index.edges = append(index.edges, edge)
+					index.dependents[to] = append(index.dependents[to], edge)
+				}
+			})
+		}
+	}
+	return index, nil
+}
+
+// loadWorkspaceEdges 加载 workspace 间的依赖：跨 workspace 的资源引用、远程数据和 Run Trigger
+func (s *CMDBGraphService) loadWorkspaceEdges(index *graphIndex) error {
+	seen := make(map[string]bool)
+	addEdge := func(edge WorkspaceEdge) {
+		key := edge.From + "|" + edge.To + "|" + edge.Type + "|" + edge.Detail
+		if edge.From == edge.To || seen[key] {
+			return
+		}
+		seen[key] = true
+		index.wsEdges = append(index.wsEdges, edge)
+	}
+
+	for _, edge := range index.edges {
+		from, to := index.nodes[edge.From].WorkspaceID, index.nodes[edge.To].WorkspaceID
+		addEdge(WorkspaceEdge{From: from, To: to, Type: GraphEdgeReference})
+	}
+
+	var remoteData []models.WorkspaceRemoteData
+	if err := s.db.Order("id").Find(&remoteData).Error; err != nil {
+		return fmt.Errorf("加载远程数据配置失败: %w", err)
+	}
+	for _, rd := range remoteData {
+		addEdge(WorkspaceEdge{From: rd.WorkspaceID, To: rd.SourceWorkspaceID, Type: GraphEdgeRemoteData, Detail: rd.DataName})
+	}
+
+	var triggers []models.RunTrigger
+	if err := s.db.Where("enabled = ?", true).Order("id").Find(&triggers).Error; err != nil {
+		return fmt.Errorf("加载Run Trigger失败: %w", err)
+	}
+	for _, trigger := range triggers {
+		addEdge(WorkspaceEdge{From: trigger.TargetWorkspaceID, To: trigger.SourceWorkspaceID, Type: GraphEdgeRunTrigger})
+	}
+
+	ids := make(map[string]bool)
+	for _, node := range index.nodes {
+		ids[node.WorkspaceID] = true
+	}
+	for _, edge := range index.wsEdges {
+		ids[edge.From] = true
+		ids[edge.To] = true
+	}
+	index.wsNames = make(map[string]string, len(ids))
+	if len(ids) == 0 {
+		return nil
+	}
+	var workspaces []struct {
+		WorkspaceID string
+		Name        string
+	}
+	if err := s.db.Model(&models.Workspace{}).Select("workspace_id, name").
+		Where("workspace_id IN ?", sortedKeys(ids)).Scan(&workspaces).Error; err != nil {
+		return fmt.Errorf("加载workspace失败: %w", err)
+	}
+	for _, ws := range workspaces {
+		index.wsNames[ws.WorkspaceID] = ws.Name
+	}
+	for _, node := range index.nodes {
+		node.WorkspaceName = index.wsNames[node.WorkspaceID]
+	}
+	return nil
+}
+
+// match 查找与目标匹配的节点
+func (g *graphIndex) match(target ImpactTarget) []string {
+	if target.TerraformAddress != "" {
+		id := target.WorkspaceID + "/" + target.TerraformAddress
+		if _, ok := g.nodes[id]; ok {
+			return []string{id}
+		}
+		return nil
+	}
+	if target.CloudResourceID == "" {
+		return nil
+	}
+	var matched []string
+	for id, node := range g.nodes {
+		if (target.WorkspaceID == "" || node.WorkspaceID == target.WorkspaceID) &&
+			(node.CloudResourceID == target.CloudResourceID || node.CloudResourceARN == target.CloudResourceID) {
+			matched = append(matched, id)
+		}
+	}
+	sort.Strings(matched)
+	return matched
+}
+
+// impact 从起点沿反向依赖边做广度优先遍历，再沿 workspace 依赖边传播
+func (g *graphIndex) impact(roots []string, maxDepth int) *ImpactReport {
+	if maxDepth <= 0 {
+		maxDepth = defaultImpactDepth
+	}
+	report := &ImpactReport{Roots: []GraphNode{}, Resources: []ImpactedResource{}, Workspaces: []ImpactedWorkspace{}}
+
+	visited := make(map[string]bool)
+	rootWorkspaces := make(map[string]bool)
+	queue := make([]string, 0, len(roots))
+	for _, root := range roots {
+		if visited[root] {
+			continue
+		}
+		visited[root] = true
+		queue = append(queue, root)
+		report.Roots = append(report.Roots, *g.nodes[root])
+		rootWorkspaces[g.nodes[root].WorkspaceID] = true
+	}
+
+	for depth := 1; depth <= maxDepth && len(queue) > 0; depth++ {
+		var next []string
+		for _, id := range queue {
+			for _, edge := range g.dependents[id] {
+				if visited[edge.From] {
+					continue
+				}
+				visited[edge.From] = true
+				next = append(next, edge.From)
+				report.Resources = append(report.Resources, ImpactedResource{
+					GraphNode: *g.nodes[edge.From],
+					Depth:     depth,
+					DependsOn: edge.To,
+					Attribute: edge.Attribute,
+				})
+			}
+		}
+		queue = next
+	}
+
+	// 受影响的 workspace：受影响资源所在的其他 workspace，以及通过远程数据/Run Trigger 依赖它们的 workspace
+	workspaces := make(map[string]*ImpactedWorkspace)
+	addReason := func(workspaceID string, depth int, reason string) bool {
+		if rootWorkspaces[workspaceID] {
+			return false
+		}
+		ws, ok := workspaces[workspaceID]
+		if !ok {
+			ws = &ImpactedWorkspace{WorkspaceID: workspaceID, WorkspaceName: g.wsNames[workspaceID], Depth: depth}
+			workspaces[workspaceID] = ws
+		}
+		for _, existing := range ws.Reasons {
+			if existing == reason {
+				return !ok
+			}
+		}
+		ws.Reasons = append(ws.Reasons, reason)
+		return !ok
+	}
+
+	var wsQueue []string
+	for id := range rootWorkspaces {
+		wsQueue = append(wsQueue, id)
+	}
+	for _, res := range report.Resources {
+		if addReason(res.WorkspaceID, res.Depth, GraphEdgeReference) {
+			wsQueue = append(wsQueue, res.WorkspaceID)
+		}
+	}
+	sort.Strings(wsQueue)
+
+	wsVisited := make(map[string]bool)
+	for len(wsQueue) > 0 {
+		current := wsQueue[0]
+		wsQueue = wsQueue[1:]
+		if wsVisited[current] {
+			continue
+		}
+		wsVisited[current] = true
+		depth := 0
+		if ws, ok := workspaces[current]; ok {
+			depth = ws.Depth
+		}
+		for _, edge := range g.wsEdges {
+			// 资源级引用已经在上面按资源传播过
+			if edge.To != current || edge.Type == GraphEdgeReference {
+				continue
+			}
+			addReason(edge.From, depth+1, edge.Type)
+			if !wsVisited[edge.From] {
+				wsQueue = append(wsQueue, edge.From)
+			}
+		}
+	}
+
+	for _, id := range sortedKeys(workspaces) {
+		report.Workspaces = append(report.Workspaces, *workspaces[id])
+	}
+	sort.SliceStable(report.Workspaces, func(i, j int) bool {
+		return report.Workspaces[i].Depth < report.Workspaces[j].Depth
+	})
+	return report
+}
+
+// describe 用于错误信息
+func (t ImpactTarget) describe() string {
+	if t.TerraformAddress != "" {
+		return t.WorkspaceID + "/" + t.TerraformAddress
+	}
+	return t.CloudResourceID
+}
+
+// referenceableIdentifier 过滤掉过短或过于通用的标识，避免误判引用（如 "default"、"main"）
+func referenceableIdentifier(value string) bool {
+	return len(value) >= 6 && strings.ContainsAny(value, "-:/0123456789")
+}
+
+// walkAttributeStrings 遍历属性中的所有字符串值
+func walkAttributeStrings(path string, value interface{}, fn func(path, value string)) {
+	switch v := value.(type) {
+	case string:
+		fn(path, v)
+	case map[string]interface{}:
+		for _, key := range sortedKeys(v) {
+			walkAttributeStrings(path+"."+key, v[key], fn)
+		}
+	case []interface{}:
+		for i, child := range v {
+			walkAttributeStrings(path+"."+strconv.Itoa(i), child, fn)
+		}
+	}
+}
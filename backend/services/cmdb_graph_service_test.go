@@ -0,0 +1,190 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+
+	"iac-platform/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func setupGraphTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := setupReconciliationTestDB(t)
+	statements := []string{
+		`INSERT INTO workspaces (workspace_id, name) VALUES ('ws-data', 'data'), ('ws-deploy', 'deploy')`,
+		`CREATE TABLE workspace_remote_data (
+			id INTEGER PRIMARY KEY AUTOINCREMENT, workspace_id TEXT NOT NULL, remote_data_id TEXT,
+			source_workspace_id TEXT NOT NULL, data_name TEXT NOT NULL, description TEXT,
+			created_by TEXT, created_at DATETIME, updated_at DATETIME)`,
+		`CREATE TABLE run_triggers (
+			id INTEGER PRIMARY KEY AUTOINCREMENT, source_workspace_id TEXT NOT NULL, target_workspace_id TEXT NOT NULL,
+			enabled INTEGER DEFAULT 1, trigger_condition TEXT, created_at DATETIME, updated_at DATETIME, created_by TEXT)`,
+		`CREATE TABLE workspace_task_resource_changes (
+			id INTEGER PRIMARY KEY AUTOINCREMENT, task_id INTEGER NOT NULL, workspace_id TEXT NOT NULL,
+			created_at DATETIME, updated_at DATETIME, resource_address TEXT NOT NULL, resource_type TEXT NOT NULL,
+			resource_name TEXT NOT NULL, module_address TEXT, action TEXT NOT NULL, changes_before BLOB, changes_after BLOB,
+			apply_status TEXT, apply_started_at DATETIME, apply_completed_at DATETIME, apply_error TEXT,
+			resource_id TEXT, resource_attributes BLOB)`,
+		// ws-data 读取 ws-app 的输出；ws-app apply 后触发 ws-deploy
+		`INSERT INTO workspace_remote_data (workspace_id, remote_data_id, source_workspace_id, data_name) VALUES ('ws-data', 'rd-1', 'ws-app', 'app')`,
+		`INSERT INTO run_triggers (source_workspace_id, target_workspace_id, enabled) VALUES ('ws-app', 'ws-deploy', 1), ('ws-network', 'ws-deploy', 0)`,
+		`INSERT INTO workspace_task_resource_changes (task_id, workspace_id, resource_address, resource_type, resource_name, action)
+			VALUES (7, 'ws-network', 'aws_vpc.main', 'aws_vpc', 'main', 'replace'),
+			       (7, 'ws-network', 'aws_subnet.private', 'aws_subnet', 'private', 'update'),
+			       (7, 'ws-network', 'aws_nat_gateway.new', 'aws_nat_gateway', 'new', 'delete')`,
+	}
+	for _, stmt := range statements {
+		require.NoError(t, db.Exec(stmt).Error)
+	}
+
+	attrs := func(m map[string]interface{}) json.RawMessage {
+		raw, err := json.Marshal(m)
+		require.NoError(t, err)
+		return raw
+	}
+	resources := []models.ResourceIndex{
+		{WorkspaceID: "ws-network", TerraformAddress: "aws_vpc.main", ResourceType: "aws_vpc", ResourceName: "main",
+			CloudResourceID: "vpc-0a1", CloudResourceARN: "arn:aws:ec2:us-east-1:111111111111:vpc/vpc-0a1",
+			Attributes: attrs(map[string]interface{}{"id": "vpc-0a1", "cidr_block": "10.0.0.0/16"})},
+		{WorkspaceID: "ws-network", TerraformAddress: "aws_subnet.private", ResourceType: "aws_subnet", ResourceName: "private",
+			CloudResourceID: "subnet-0b2",
+			Attributes:      attrs(map[string]interface{}{"id": "subnet-0b2", "vpc_id": "vpc-0a1"})},
+		{WorkspaceID: "ws-app", TerraformAddress: "aws_security_group.web", ResourceType: "aws_security_group", ResourceName: "web",
+			CloudResourceID: "sg-0c3",
+			Attributes:      attrs(map[string]interface{}{"id": "sg-0c3", "vpc_id": "vpc-0a1", "tags": map[string]interface{}{"Vpc": "vpc-0a1"}})},
+		{WorkspaceID: "ws-app", TerraformAddress: "aws_instance.web", ResourceType: "aws_instance", ResourceName: "web",
+			CloudResourceID: "i-0d4",
+			Attributes: attrs(map[string]interface{}{"id": "i-0d4", "subnet_id": "subnet-0b2",
+				"vpc_security_group_ids": []interface{}{"sg-0c3"}, "name": "main"})},
+		// 通用短名称不应被当作引用
+		{WorkspaceID: "ws-app", TerraformAddress: "aws_iam_role.main", ResourceType: "aws_iam_role", ResourceName: "main",
+			CloudResourceID: "main", Attributes: attrs(map[string]interface{}{"id": "main"})},
+	}
+	for i := range resources {
+		require.NoError(t, db.Create(&resources[i]).Error)
+	}
+	return db
+}
+
+func TestCMDBGraph_BuildGraph(t *testing.T) {
+	db := setupGraphTestDB(t)
+	svc := NewCMDBGraphService(db)
+
+	graph, err := svc.BuildGraph("")
+	require.NoError(t, err)
+	assert.Len(t, graph.Nodes, 5)
+	assert.ElementsMatch(t, []GraphEdge{
+		{From: "ws-network/aws_subnet.private", To: "ws-network/aws_vpc.main", Type: GraphEdgeReference, Attribute: "vpc_id"},
+		{From: "ws-app/aws_security_group.web", To: "ws-network/aws_vpc.main", Type: GraphEdgeReference, Attribute: "vpc_id"},
+		{From: "ws-app/aws_instance.web", To: "ws-network/aws_subnet.private", Type: GraphEdgeReference, Attribute: "subnet_id"},
+		{From: "ws-app/aws_instance.web", To: "ws-app/aws_security_group.web", Type: GraphEdgeReference, Attribute: "vpc_security_group_ids.0"},
+	}, graph.Edges)
+	assert.ElementsMatch(t, []WorkspaceEdge{
+		{From: "ws-app", To: "ws-network", Type: GraphEdgeReference},
+		{From: "ws-data", To: "ws-app", Type: GraphEdgeRemoteData, Detail: "app"},
+		{From: "ws-deploy", To: "ws-app", Type: GraphEdgeRunTrigger},
+	}, graph.WorkspaceEdges)
+	assert.Equal(t, "network", graph.Workspaces["ws-network"])
+
+	scoped, err := svc.BuildGraph("ws-network")
+	require.NoError(t, err)
+	assert.Len(t, scoped.Edges, 3, "edges into the workspace from other workspaces are included")
+	assert.Len(t, scoped.WorkspaceEdges, 1)
+}
+
+func TestCMDBGraph_AnalyzeImpact(t *testing.T) {
+	db := setupGraphTestDB(t)
+	svc := NewCMDBGraphService(db)
+
+	report, err := svc.AnalyzeImpact([]ImpactTarget{{WorkspaceID: "ws-network", TerraformAddress: "aws_vpc.main"}}, 0)
+	require.NoError(t, err)
+	require.Len(t, report.Roots, 1)
+
+	depths := map[string]int{}
+	for _, res := range report.Resources {
+		depths[res.ID] = res.Depth
+	}
+	assert.Equal(t, map[string]int{
+		"ws-network/aws_subnet.private": 1,
+		"ws-app/aws_security_group.web": 1,
+		"ws-app/aws_instance.web":       2,
+	}, depths)
+
+	require.Len(t, report.Workspaces, 3)
+	assert.Equal(t, "ws-app", report.Workspaces[0].WorkspaceID)
+	assert.Equal(t, []string{GraphEdgeReference}, report.Workspaces[0].Reasons)
+	assert.Equal(t, "ws-data", report.Workspaces[1].WorkspaceID)
+	assert.Equal(t, []string{GraphEdgeRemoteData}, report.Workspaces[1].Reasons)
+	assert.Equal(t, "ws-deploy", report.Workspaces[2].WorkspaceID)
+
+	limited, err := svc.AnalyzeImpact([]ImpactTarget{{CloudResourceID: "vpc-0a1"}}, 1)
+	require.NoError(t, err)
+	assert.Len(t, limited.Resources, 2)
+
+	_, err = svc.AnalyzeImpact([]ImpactTarget{{CloudResourceID: "vpc-missing"}}, 0)
+	assert.Error(t, err)
+}
+
+func TestCMDBGraph_AnalyzeTaskImpact(t *testing.T) {
+	db := setupGraphTestDB(t)
+	svc := NewCMDBGraphService(db)
+
+	report, err := svc.AnalyzeTaskImpact("ws-network", 7)
+	require.NoError(t, err)
+	assert.Equal(t, []TaskImpactRoot{
+		{ResourceAddress: "aws_vpc.main", Action: "replace"},
+		{ResourceAddress: "aws_nat_gateway.new", Action: "delete"},
+	}, report.Changes)
+	assert.Equal(t, []string{"aws_nat_gateway.new"}, report.Missing)
+	assert.Len(t, report.Impact.Resources, 3)
+}
+
+func TestCMDBGraph_TaskImpactHidesUnreadableWorkspaces(t *testing.T) {
+	db := setupGraphTestDB(t)
+	svc := NewCMDBGraphService(db)
+
+	report, err := svc.AnalyzeTaskImpact("ws-network", 7)
+	require.NoError(t, err)
+	report.FilterWorkspaces(func(workspaceID string) bool {
+		return workspaceID == "ws-network" || workspaceID == "ws-deploy"
+	})
+
+	require.Len(t, report.Impact.Resources, 1)
+	assert.Equal(t, "ws-network/aws_subnet.private", report.Impact.Resources[0].ID)
+	assert.Equal(t, 2, report.HiddenResources)
+	require.Len(t, report.Impact.Workspaces, 1)
+	assert.Equal(t, "ws-deploy", report.Impact.Workspaces[0].WorkspaceID)
+	assert.Equal(t, 2, report.HiddenWorkspaces)
+}
+
+func TestCMDBGraph_ResourceGraphCacheFollowsIndexChanges(t *testing.T) {
+	db := setupGraphTestDB(t)
+	svc := NewCMDBGraphService(db)
+
+	report, err := svc.AnalyzeTaskImpact("ws-network", 7)
+	require.NoError(t, err)
+	assert.Len(t, report.Impact.Resources, 3)
+
+	// 缓存命中时仍补充 workspace 名称
+	report, err = svc.AnalyzeTaskImpact("ws-network", 7)
+	require.NoError(t, err)
+	assert.Equal(t, "ws-network", report.Impact.Roots[0].WorkspaceID)
+	for _, res := range report.Impact.Resources {
+		if res.WorkspaceID == "ws-app" {
+			assert.Equal(t, "app", res.WorkspaceName)
+		}
+	}
+
+	// 新同步的资源会使缓存失效
+	require.NoError(t, db.Create(&models.ResourceIndex{
+		WorkspaceID: "ws-deploy", TerraformAddress: "aws_lb.public", ResourceType: "aws_lb", ResourceName: "public",
+		CloudResourceID: "lb-0e5", Attributes: json.RawMessage(`{"id":"lb-0e5","vpc_id":"vpc-0a1"}`),
+	}).Error)
+	report, err = svc.AnalyzeTaskImpact("ws-network", 7)
+	require.NoError(t, err)
+	assert.Len(t, report.Impact.Resources, 4)
+}
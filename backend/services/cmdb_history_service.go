@@ -0,0 +1,692 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"iac-platform/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// resourceHistorySnapshotInterval 每隔多少个差异版本写入一次完整快照，限制重建版本时需要回放的差异数量
+	resourceHistorySnapshotInterval = 20
+
+	// DefaultResourceHistoryRetention 已失效的历史版本保留时长，超过后会被压缩掉
+	DefaultResourceHistoryRetention = 180 * 24 * time.Hour
+
+	// sensitiveAttributeValue State 中标记为 sensitive 的属性在历史中保存的值，与 State 资源视图的显示一致
+	sensitiveAttributeValue = "(sensitive value)"
+)
+
+// ErrResourceVersionNotFound 指定时间点/State版本下资源不存在
+var ErrResourceVersionNotFound = errors.New("resource version not found")
+
+// CMDBHistoryService CMDB 资源属性历史（time-travel）服务
+// 以 State 版本为时间轴记录每个 Terraform 资源的属性变化，支持按时间点回看和字段级变更时间线
+type CMDBHistoryService struct {
+	db   *gorm.DB
+	cmdb *CMDBService
+}
+
+// NewCMDBHistoryService 创建资源历史服务
+func NewCMDBHistoryService(db *gorm.DB) *CMDBHistoryService {
+	return &CMDBHistoryService{
+		db:   db,
+		cmdb: NewCMDBService(db),
+	}
+}
+
+// attributeDiff 相对上一版本的顶层属性差异
+type attributeDiff struct {
+	Set   map[string]interface{} `json:"set,omitempty"`
+	Unset []string               `json:"unset,omitempty"`
+}
+
+// AttributeChange 单个属性路径上的变化，路径使用点号分隔（如 tags.Name、ingress.0.from_port）
+type AttributeChange struct {
+	Path string      `json:"path"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+// ResourceHistoryEntry 时间线中的一次变更
+type ResourceHistoryEntry struct {
+	WorkspaceID      string            `json:"workspace_id"`
+	TerraformAddress string            `json:"terraform_address"`
+	CloudResourceID  string            `json:"cloud_resource_id,omitempty"`
+	ResourceType     string            `json:"resource_type"`
+	StateVersionID   uint              `json:"state_version_id"`
+	TaskID           *uint             `json:"task_id,omitempty"`
+	ChangedAt        time.Time         `json:"changed_at"`
+	ChangeType       string            `json:"change_type"` // create / update / delete
+	Changes          []AttributeChange `json:"changes,omitempty"`
+}
+
+// ResourceVersionView 资源在某个时间点的属性版本
+type ResourceVersionView struct {
+	WorkspaceID             string                 `json:"workspace_id"`
+	TerraformAddress        string                 `json:"terraform_address"`
+	CloudResourceID         string                 `json:"cloud_resource_id,omitempty"`
+	ResourceType            string                 `json:"resource_type"`
+	ValidFromStateVersionID uint                   `json:"valid_from_state_version_id"`
+	ValidToStateVersionID   *uint                  `json:"valid_to_state_version_id,omitempty"`
+	ValidFrom               time.Time              `json:"valid_from"`
+	ValidTo                 *time.Time             `json:"valid_to,omitempty"`
+	TaskID                  *uint                  `json:"task_id,omitempty"`
+	Attributes              map[string]interface{} `json:"attributes"`
+}
+
+// historyChain 同一 terraform address 的版本链及每个版本重建后的属性
+type historyChain struct {
+	rows  []models.ResourceAttributeHistory
+	attrs []map[string]interface{}
+}
+
+// currentVersion 资源当前有效的版本
+type currentVersion struct {
+	row   *models.ResourceAttributeHistory
+	attrs map[string]interface{}
+}
+
+// SyncWorkspace 记录 workspace 新增 State 版本的资源历史，并压缩超出保留期的版本
+func (s *CMDBHistoryService) SyncWorkspace(workspaceID string) error {
+	if err := s.RecordWorkspaceHistory(workspaceID); err != nil {
+		return err
+	}
+	_, err := s.CompactWorkspaceHistory(workspaceID, time.Now().Add(-DefaultResourceHistoryRetention))
+	return err
+}
+
+// RecordWorkspaceHistory 按顺序处理游标之后的所有 State 版本，为发生变化的资源写入新版本
+func (s *CMDBHistoryService) RecordWorkspaceHistory(workspaceID string) error {
+	var lastID uint
+	var cursor models.ResourceHistoryCursor
+	if err := s.db.Where("workspace_id = ?", workspaceID).First(&cursor).Error; err == nil {
+		lastID = cursor.LastStateVersionID
+	} else if err != gorm.ErrRecordNotFound {
+		return fmt.Errorf("failed to load history cursor: %w", err)
+	}
+
+	var versionIDs []uint
+	if err := s.db.Model(&models.WorkspaceStateVersion{}).
+		Where("workspace_id = ? AND id > ?", workspaceID, lastID).
+		Order("id ASC").
+		Pluck("id", &versionIDs).Error; err != nil {
+		return fmt.Errorf("failed to list state versions: %w", err)
+	}
+	if len(versionIDs) == 0 {
+		return nil
+	}
+
+	current, err := s.loadCurrentVersions(workspaceID)
+	if err != nil {
+		return err
+	}
+
+	// State 内容可能很大，逐个加载
+	for _, id := range versionIDs {
+		var stateVersion models.WorkspaceStateVersion
+		if err := s.db.First(&stateVersion, id).Error; err != nil {
+			return fmt.Errorf("failed to load state version %d: %w", id, err)
+		}
+		if err := s.db.Transaction(func(tx *gorm.DB) error {
+			return s.recordStateVersion(tx, &stateVersion, current)
+		}); err != nil {
+			return fmt.Errorf("failed to record history for state version %d: %w", id, err)
+		}
+	}
+
+	return nil
+}
+
+// recordStateVersion 将单个 State 版本与当前版本比较并写入差异，current 会被原地更新
+func (s *CMDBHistoryService) recordStateVersion(tx *gorm.DB, stateVersion *models.WorkspaceStateVersion, current map[string]*currentVersion) error {
+	validFrom := stateVersion.CreatedAt
+	if validFrom.IsZero() {
+		validFrom = time.Now()
+	}
+
+	seen := make(map[string]bool)
+	resources, _ := map[string]interface{}(stateVersion.Content)["resources"].([]interface{})
+	for _, res := range resources {
+		resMap, ok := res.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		sensitive := s.sensitiveAttributes(resMap)
+		for _, record := range s.cmdb.parseResource(stateVersion.WorkspaceID, resMap, stateVersion.ID) {
+			// data source 只是读取结果，不记录历史
+			if record.ResourceMode == "data" {
+				continue
+			}
+			address := record.TerraformAddress
+			if seen[address] {
+				continue
+			}
+			seen[address] = true
+
+			attrs := map[string]interface{}{}
+			if len(record.Attributes) > 0 {
+				if err := json.Unmarshal(record.Attributes, &attrs); err != nil {
+					return fmt.Errorf("failed to decode attributes of %s: %w", address, err)
+				}
+			}
+			for key := range sensitive[address] {
+				if _, ok := attrs[key]; ok {
+					attrs[key] = sensitiveAttributeValue
+				}
+			}
+
+			row := &models.ResourceAttributeHistory{
+				WorkspaceID:             stateVersion.WorkspaceID,
+				TerraformAddress:        address,
+				CloudResourceID:         record.CloudResourceID,
+				ResourceType:            record.ResourceType,
+				ValidFromStateVersionID: stateVersion.ID,
+				ValidFrom:               validFrom,
+				TaskID:                  stateVersion.TaskID,
+			}
+
+			cur := current[address]
+			if cur == nil {
+				row.ChangeType = models.ResourceHistoryChangeCreate
+			} else {
+				diff := diffAttributes(cur.attrs, attrs)
+				if diff == nil {
+					continue
+				}
+				if err := closeHistoryVersion(tx, cur.row, stateVersion.ID, validFrom); err != nil {
+					return err
+				}
+				row.ChangeType = models.ResourceHistoryChangeUpdate
+				row.ChainDepth = cur.row.ChainDepth + 1
+				if row.ChainDepth < resourceHistorySnapshotInterval {
+					row.Diff, _ = json.Marshal(diff)
+				}
+			}
+			if row.Diff == nil {
+				row.IsSnapshot = true
+				row.ChainDepth = 0
+				row.Snapshot, _ = json.Marshal(attrs)
+			}
+
+			if err := tx.Create(row).Error; err != nil {
+				return err
+			}
+			current[address] = &currentVersion{row: row, attrs: attrs}
+		}
+	}
+
+	// 不再出现在 State 中的资源：关闭其当前版本
+	for address, cur := range current {
+		if seen[address] {
+			continue
+		}
+		if err := closeHistoryVersion(tx, cur.row, stateVersion.ID, validFrom); err != nil {
+			return err
+		}
+		delete(current, address)
+	}
+
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "workspace_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"last_state_version_id", "updated_at"}),
+	}).Create(&models.ResourceHistoryCursor{
+		WorkspaceID:        stateVersion.WorkspaceID,
+		LastStateVersionID: stateVersion.ID,
+		UpdatedAt:          time.Now(),
+	}).Error
+}
+
+// closeHistoryVersion 设置版本的失效边界
+func closeHistoryVersion(tx *gorm.DB, row *models.ResourceAttributeHistory, stateVersionID uint, validTo time.Time) error {
+	row.ValidToStateVersionID = &stateVersionID
+	row.ValidTo = &validTo
+	return tx.Model(&models.ResourceAttributeHistory{}).
+		Where("id = ?", row.ID).
+		Updates(map[string]interface{}{
+			"valid_to_state_version_id": stateVersionID,
+			"valid_to":                  validTo,
+		}).Error
+}
+
+// sensitiveAttributes 返回资源每个实例（按 terraform address）在 State 中标记为 sensitive 的顶层属性
+// 与 State 资源视图相同，嵌套路径按其顶层属性整体脱敏
+func (s *CMDBHistoryService) sensitiveAttributes(resMap map[string]interface{}) map[string]map[string]bool {
+	instances, _ := resMap["instances"].([]interface{})
+	result := make(map[string]map[string]bool)
+	for _, inst := range instances {
+		instMap, ok := inst.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		paths, _ := instMap["sensitive_attributes"].([]interface{})
+		if len(paths) == 0 {
+			continue
+		}
+		address := s.cmdb.buildTerraformAddress(cmdbGetString(resMap, "module"), cmdbGetString(resMap, "type"),
+			cmdbGetString(resMap, "name"), s.cmdb.getIndexKey(instMap))
+		keys := make(map[string]bool)
+		for _, path := range paths {
+			if key := sensitivePathRoot(path); key != "" {
+				keys[key] = true
+			}
+		}
+		result[address] = keys
+	}
+	return result
+}
+
+// sensitivePathRoot 返回 sensitive 路径的顶层属性名
+// 支持 State 中的路径步骤格式 [{"type":"get_attr","value":"password"}]，以及 ["password"] 或 "password"
+func sensitivePathRoot(path interface{}) string {
+	switch p := path.(type) {
+	case string:
+		var steps []interface{}
+		if err := json.Unmarshal([]byte(p), &steps); err == nil {
+			return sensitivePathRoot(steps)
+		}
+		return p
+	case []interface{}:
+		if len(p) == 0 {
+			return ""
+		}
+		return sensitivePathRoot(p[0])
+	case map[string]interface{}:
+		value, _ := p["value"].(string)
+		return value
+	}
+	return ""
+}
+
+// loadCurrentVersions 加载 workspace 中所有仍然有效的版本及其属性
+func (s *CMDBHistoryService) loadCurrentVersions(workspaceID string) (map[string]*currentVersion, error) {
+	chains, err := s.loadChains(s.db.Where("workspace_id = ?", workspaceID))
+	if err != nil {
+		return nil, err
+	}
+
+	current := make(map[string]*currentVersion)
+	for _, chain := range chains {
+		last := len(chain.rows) - 1
+		if chain.rows[last].ValidTo == nil {
+			current[chain.rows[last].TerraformAddress] = &currentVersion{
+				row:   &chain.rows[last],
+				attrs: chain.attrs[last],
+			}
+		}
+	}
+	return current, nil
+}
+
+// loadChains 按 (workspace, address) 加载版本链并重建每个版本的属性
+func (s *CMDBHistoryService) loadChains(query *gorm.DB) ([]*historyChain, error) {
+	var rows []models.ResourceAttributeHistory
+	if err := query.Order("workspace_id, terraform_address, valid_from_state_version_id, id").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to load resource history: %w", err)
+	}
+
+	var chains []*historyChain
+	var chain *historyChain
+	for _, row := range rows {
+		if chain == nil || chain.rows[0].WorkspaceID != row.WorkspaceID || chain.rows[0].TerraformAddress != row.TerraformAddress {
+			chain = &historyChain{}
+			chains = append(chains, chain)
+		}
+
+		var attrs map[string]interface{}
+		if row.IsSnapshot {
+			attrs = map[string]interface{}{}
+			if len(row.Snapshot) > 0 {
+				if err := json.Unmarshal(row.Snapshot, &attrs); err != nil {
+					return nil, fmt.Errorf("invalid snapshot in history %d: %w", row.ID, err)
+				}
+			}
+		} else {
+			if len(chain.attrs) == 0 {
+				return nil, fmt.Errorf("history %d of %s has no base snapshot", row.ID, row.TerraformAddress)
+			}
+			var diff attributeDiff
+			if err := json.Unmarshal(row.Diff, &diff); err != nil {
+				return nil, fmt.Errorf("invalid diff in history %d: %w", row.ID, err)
+			}
+			attrs = diff.apply(chain.attrs[len(chain.attrs)-1])
+		}
+
+		chain.rows = append(chain.rows, row)
+		chain.attrs = append(chain.attrs, attrs)
+	}
+	return chains, nil
+}
+
+// loadResourceChains 按 terraform address 或云资源ID定位资源的版本链
+// 使用云资源ID时，返回曾经持有该ID的所有地址的完整版本链
+func (s *CMDBHistoryService) loadResourceChains(workspaceID, address, cloudResourceID string) ([]*historyChain, error) {
+	if address != "" {
+		if workspaceID == "" {
+			return nil, fmt.Errorf("workspace_id is required when querying by address")
+		}
+		return s.loadChains(s.db.Where("workspace_id = ? AND terraform_address = ?", workspaceID, address))
+	}
+	if cloudResourceID == "" {
+		return nil, fmt.Errorf("address or cloud_resource_id is required")
+	}
+
+	type resourceKey struct {
+		WorkspaceID      string
+		TerraformAddress string
+	}
+	var keys []resourceKey
+	keyQuery := s.db.Model(&models.ResourceAttributeHistory{}).
+		Distinct("workspace_id", "terraform_address").
+		Where("cloud_resource_id = ?", cloudResourceID)
+	if workspaceID != "" {
+		keyQuery = keyQuery.Where("workspace_id = ?", workspaceID)
+	}
+	if err := keyQuery.Find(&keys).Error; err != nil {
+		return nil, fmt.Errorf("failed to locate resource history: %w", err)
+	}
+
+	var chains []*historyChain
+	for _, key := range keys {
+		keyChains, err := s.loadChains(s.db.Where("workspace_id = ? AND terraform_address = ?", key.WorkspaceID, key.TerraformAddress))
+		if err != nil {
+			return nil, err
+		}
+		chains = append(chains, keyChains...)
+	}
+	return chains, nil
+}
+
+// GetResourceTimeline 获取资源的变更时间线
+// field 非空时只返回修改了该字段（或其子字段）的变更，用于回答"哪次 apply 改了这个字段"
+func (s *CMDBHistoryService) GetResourceTimeline(workspaceID, address, cloudResourceID, field string) ([]ResourceHistoryEntry, error) {
+	chains, err := s.loadResourceChains(workspaceID, address, cloudResourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []ResourceHistoryEntry{}
+	deleteVersionIDs := make(map[uint]bool)
+	for _, chain := range chains {
+		for i, row := range chain.rows {
+			entry := ResourceHistoryEntry{
+				WorkspaceID:      row.WorkspaceID,
+				TerraformAddress: row.TerraformAddress,
+				CloudResourceID:  row.CloudResourceID,
+				ResourceType:     row.ResourceType,
+				StateVersionID:   row.ValidFromStateVersionID,
+				TaskID:           row.TaskID,
+				ChangedAt:        row.ValidFrom,
+				ChangeType:       row.ChangeType,
+			}
+			switch {
+			case row.ChangeType == models.ResourceHistoryChangeUpdate && i > 0:
+				entry.Changes = filterAttributeChanges(attributeChanges(chain.attrs[i-1], chain.attrs[i]), field)
+			case row.ChangeType == models.ResourceHistoryChangeCreate && field != "":
+				// 新建时所有属性都是变化，只在按字段过滤时展开
+				entry.Changes = filterAttributeChanges(attributeChanges(nil, chain.attrs[i]), field)
+			}
+			if field == "" || len(entry.Changes) > 0 {
+				entries = append(entries, entry)
+			}
+
+			// 版本关闭后没有后继版本：资源在该 State 版本中被删除
+			if row.ValidToStateVersionID != nil &&
+				(i == len(chain.rows)-1 || chain.rows[i+1].ChangeType == models.ResourceHistoryChangeCreate) {
+				entry := ResourceHistoryEntry{
+					WorkspaceID:      row.WorkspaceID,
+					TerraformAddress: row.TerraformAddress,
+					CloudResourceID:  row.CloudResourceID,
+					ResourceType:     row.ResourceType,
+					StateVersionID:   *row.ValidToStateVersionID,
+					ChangedAt:        *row.ValidTo,
+					ChangeType:       models.ResourceHistoryChangeDelete,
+				}
+				if field != "" {
+					entry.Changes = filterAttributeChanges(attributeChanges(chain.attrs[i], nil), field)
+				}
+				if field == "" || len(entry.Changes) > 0 {
+					entries = append(entries, entry)
+					deleteVersionIDs[entry.StateVersionID] = true
+				}
+			}
+		}
+	}
+
+	// 删除事件的任务来自关闭版本的 State 版本
+	if len(deleteVersionIDs) > 0 {
+		ids := make([]uint, 0, len(deleteVersionIDs))
+		for id := range deleteVersionIDs {
+			ids = append(ids, id)
+		}
+		var versions []models.WorkspaceStateVersion
+		if err := s.db.Select("id", "task_id").Where("id IN ?", ids).Find(&versions).Error; err != nil {
+			return nil, fmt.Errorf("failed to load state versions: %w", err)
+		}
+		taskIDs := make(map[uint]*uint, len(versions))
+		for _, v := range versions {
+			taskIDs[v.ID] = v.TaskID
+		}
+		for i := range entries {
+			if entries[i].ChangeType == models.ResourceHistoryChangeDelete {
+				entries[i].TaskID = taskIDs[entries[i].StateVersionID]
+			}
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].StateVersionID != entries[j].StateVersionID {
+			return entries[i].StateVersionID < entries[j].StateVersionID
+		}
+		return entries[i].TerraformAddress < entries[j].TerraformAddress
+	})
+	return entries, nil
+}
+
+// GetResourceAsOf 获取资源在某个时间点（或某个 State 版本）的属性
+// stateVersionID 优先；两者都为空时返回当前版本
+func (s *CMDBHistoryService) GetResourceAsOf(workspaceID, address, cloudResourceID string, at *time.Time, stateVersionID uint) (*ResourceVersionView, error) {
+	chains, err := s.loadResourceChains(workspaceID, address, cloudResourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, chain := range chains {
+		for i := range chain.rows {
+			row := &chain.rows[i]
+			if !historyRowValidAt(row, at, stateVersionID) {
+				continue
+			}
+			if cloudResourceID != "" && address == "" && row.CloudResourceID != cloudResourceID {
+				continue
+			}
+			return &ResourceVersionView{
+				WorkspaceID:             row.WorkspaceID,
+				TerraformAddress:        row.TerraformAddress,
+				CloudResourceID:         row.CloudResourceID,
+				ResourceType:            row.ResourceType,
+				ValidFromStateVersionID: row.ValidFromStateVersionID,
+				ValidToStateVersionID:   row.ValidToStateVersionID,
+				ValidFrom:               row.ValidFrom,
+				ValidTo:                 row.ValidTo,
+				TaskID:                  row.TaskID,
+				Attributes:              chain.attrs[i],
+			}, nil
+		}
+	}
+	return nil, ErrResourceVersionNotFound
+}
+
+// historyRowValidAt 判断版本在给定时间点/State 版本是否有效（区间左闭右开）
+func historyRowValidAt(row *models.ResourceAttributeHistory, at *time.Time, stateVersionID uint) bool {
+	switch {
+	case stateVersionID > 0:
+		return row.ValidFromStateVersionID <= stateVersionID &&
+			(row.ValidToStateVersionID == nil || *row.ValidToStateVersionID > stateVersionID)
+	case at != nil:
+		return !row.ValidFrom.After(*at) && (row.ValidTo == nil || row.ValidTo.After(*at))
+	default:
+		return row.ValidTo == nil
+	}
+}
+
+// CompactWorkspaceHistory 压缩历史：删除在 before 之前已经失效的版本，
+// 并把每条链上保留下来的第一个版本物化为快照，保证剩余版本仍可重建。返回删除的版本数
+func (s *CMDBHistoryService) CompactWorkspaceHistory(workspaceID string, before time.Time) (int, error) {
+	chains, err := s.loadChains(s.db.Where("workspace_id = ?", workspaceID))
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		for _, chain := range chains {
+			expired := 0
+			for expired < len(chain.rows) && chain.rows[expired].ValidTo != nil && chain.rows[expired].ValidTo.Before(before) {
+				expired++
+			}
+			if expired == 0 {
+				continue
+			}
+
+			ids := make([]uint, expired)
+			for i := 0; i < expired; i++ {
+				ids[i] = chain.rows[i].ID
+			}
+			if err := tx.Where("id IN ?", ids).Delete(&models.ResourceAttributeHistory{}).Error; err != nil {
+				return err
+			}
+			removed += expired
+
+			if expired < len(chain.rows) && !chain.rows[expired].IsSnapshot {
+				snapshot, _ := json.Marshal(chain.attrs[expired])
+				if err := tx.Model(&models.ResourceAttributeHistory{}).
+					Where("id = ?", chain.rows[expired].ID).
+					Updates(map[string]interface{}{
+						"is_snapshot": true,
+						"snapshot":    snapshot,
+						"diff":        nil,
+						"chain_depth": 0,
+					}).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	return removed, err
+}
+
+// diffAttributes 计算顶层属性差异，没有变化时返回 nil
+func diffAttributes(prev, next map[string]interface{}) *attributeDiff {
+	diff := &attributeDiff{Set: map[string]interface{}{}}
+	for key, value := range next {
+		if old, ok := prev[key]; !ok || !reflect.DeepEqual(old, value) {
+			diff.Set[key] = value
+		}
+	}
+	for key := range prev {
+		if _, ok := next[key]; !ok {
+			diff.Unset = append(diff.Unset, key)
+		}
+	}
+	if len(diff.Set) == 0 && len(diff.Unset) == 0 {
+		return nil
+	}
+	sort.Strings(diff.Unset)
+	return diff
+}
+
+// apply 在 base 的副本上应用差异
+func (d *attributeDiff) apply(base map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(base)+len(d.Set))
+	for key, value := range base {
+		result[key] = value
+	}
+	for key, value := range d.Set {
+		result[key] = value
+	}
+	for _, key := range d.Unset {
+		delete(result, key)
+	}
+	return result
+}
+
+// attributeChanges 展开为叶子路径后逐一比较，得到字段级变化
+func attributeChanges(prev, next map[string]interface{}) []AttributeChange {
+	oldFlat := make(map[string]interface{})
+	newFlat := make(map[string]interface{})
+	for key, value := range prev {
+		flattenAttribute(key, value, oldFlat)
+	}
+	for key, value := range next {
+		flattenAttribute(key, value, newFlat)
+	}
+
+	paths := make(map[string]bool, len(oldFlat)+len(newFlat))
+	for path := range oldFlat {
+		paths[path] = true
+	}
+	for path := range newFlat {
+		paths[path] = true
+	}
+
+	var changes []AttributeChange
+	for _, path := range sortedKeys(paths) {
+		oldValue, hadOld := oldFlat[path]
+		newValue, hasNew := newFlat[path]
+		if hadOld && hasNew && reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		changes = append(changes, AttributeChange{Path: path, Old: oldValue, New: newValue})
+	}
+	return changes
+}
+
+// flattenAttribute 将嵌套的 map/list 展开为点号路径；空 map/list 作为叶子保留
+func flattenAttribute(path string, value interface{}, out map[string]interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			out[path] = v
+			return
+		}
+		for key, child := range v {
+			flattenAttribute(path+"."+key, child, out)
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			out[path] = v
+			return
+		}
+		for i, child := range v {
+			flattenAttribute(path+"."+strconv.Itoa(i), child, out)
+		}
+	default:
+		out[path] = v
+	}
+}
+
+// filterAttributeChanges 只保留指定字段及其子字段的变化
+func filterAttributeChanges(changes []AttributeChange, field string) []AttributeChange {
+	if field == "" {
+		return changes
+	}
+	var filtered []AttributeChange
+	for _, change := range changes {
+		if change.Path == field || strings.HasPrefix(change.Path, field+".") {
+			filtered = append(filtered, change)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,247 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"iac-platform/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupHistoryTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+
+	statements := []string{
+		`CREATE TABLE workspace_state_versions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT, workspace_id TEXT NOT NULL, created_by TEXT, created_at DATETIME,
+			content BLOB NOT NULL, version INTEGER NOT NULL, checksum TEXT NOT NULL DEFAULT '', size_bytes INTEGER,
+			lineage TEXT, serial INTEGER, is_imported INTEGER DEFAULT 0, import_source TEXT, is_rollback INTEGER DEFAULT 0,
+			rollback_from_version INTEGER, description TEXT, task_id INTEGER, resource_count INTEGER DEFAULT 0)`,
+		`CREATE TABLE resource_attribute_history (
+			id INTEGER PRIMARY KEY AUTOINCREMENT, workspace_id TEXT NOT NULL, terraform_address TEXT NOT NULL,
+			cloud_resource_id TEXT, resource_type TEXT NOT NULL, valid_from_state_version_id INTEGER NOT NULL,
+			valid_to_state_version_id INTEGER, valid_from DATETIME NOT NULL, valid_to DATETIME, task_id INTEGER,
+			change_type TEXT NOT NULL, is_snapshot INTEGER DEFAULT 0, chain_depth INTEGER DEFAULT 0,
+			snapshot BLOB, diff BLOB, created_at DATETIME DEFAULT CURRENT_TIMESTAMP)`,
+		`CREATE TABLE resource_history_cursors (
+			workspace_id TEXT PRIMARY KEY, last_state_version_id INTEGER NOT NULL, updated_at DATETIME)`,
+	}
+	for _, stmt := range statements {
+		require.NoError(t, db.Exec(stmt).Error)
+	}
+	return db
+}
+
+// addStateVersion 写入一个只包含给定资源的 State 版本
+func addStateVersion(t *testing.T, db *gorm.DB, workspaceID string, version int, createdAt time.Time, taskID uint, resources ...map[string]interface{}) uint {
+	t.Helper()
+	items := make([]interface{}, 0, len(resources))
+	for _, r := range resources {
+		items = append(items, r)
+	}
+	content, err := json.Marshal(map[string]interface{}{"version": 4, "resources": items})
+	require.NoError(t, err)
+	require.NoError(t, db.Exec(
+		`INSERT INTO workspace_state_versions (workspace_id, created_at, content, version, task_id) VALUES (?, ?, ?, ?, ?)`,
+		workspaceID, createdAt, content, version, taskID).Error)
+
+	var id uint
+	require.NoError(t, db.Raw(`SELECT id FROM workspace_state_versions WHERE workspace_id = ? AND version = ?`, workspaceID, version).Scan(&id).Error)
+	return id
+}
+
+func stateResource(resourceType, name string, attributes map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"mode":      "managed",
+		"type":      resourceType,
+		"name":      name,
+		"provider":  `provider["registry.terraform.io/hashicorp/aws"]`,
+		"instances": []interface{}{map[string]interface{}{"attributes": attributes}},
+	}
+}
+
+func TestCMDBHistory_RecordAndQuery(t *testing.T) {
+	db := setupHistoryTestDB(t)
+	svc := NewCMDBHistoryService(db)
+
+	base := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	sgV1 := map[string]interface{}{"id": "sg-1", "name": "web", "ingress": []interface{}{
+		map[string]interface{}{"from_port": 80.0, "to_port": 80.0},
+	}, "tags": map[string]interface{}{"Name": "web"}}
+	sgV2 := map[string]interface{}{"id": "sg-1", "name": "web", "ingress": []interface{}{
+		map[string]interface{}{"from_port": 443.0, "to_port": 443.0},
+	}, "tags": map[string]interface{}{"Name": "web"}}
+	sgV3 := map[string]interface{}{"id": "sg-1", "name": "web", "ingress": []interface{}{
+		map[string]interface{}{"from_port": 443.0, "to_port": 443.0},
+	}, "tags": map[string]interface{}{"Name": "web-public"}}
+	bucket := map[string]interface{}{"id": "logs", "bucket": "logs"}
+
+	sv1 := addStateVersion(t, db, "ws-1", 1, base, 11,
+		stateResource("aws_security_group", "web", sgV1), stateResource("aws_s3_bucket", "logs", bucket))
+	// S3 未变化，不应产生新版本
+	sv2 := addStateVersion(t, db, "ws-1", 2, base.Add(24*time.Hour), 12,
+		stateResource("aws_security_group", "web", sgV2), stateResource("aws_s3_bucket", "logs", bucket))
+	require.NoError(t, svc.RecordWorkspaceHistory("ws-1"))
+
+	// 增量处理：只处理游标之后的版本，S3 被删除
+	sv3 := addStateVersion(t, db, "ws-1", 3, base.Add(48*time.Hour), 13,
+		stateResource("aws_security_group", "web", sgV3))
+	require.NoError(t, svc.RecordWorkspaceHistory("ws-1"))
+	require.NoError(t, svc.RecordWorkspaceHistory("ws-1"))
+
+	var rows []models.ResourceAttributeHistory
+	require.NoError(t, db.Order("id").Find(&rows).Error)
+	require.Len(t, rows, 4)
+	assert.True(t, rows[0].IsSnapshot)
+	assert.False(t, rows[2].IsSnapshot, "unchanged top-level attributes are stored as diffs")
+	assert.NotContains(t, string(rows[2].Diff), "tags")
+
+	t.Run("timeline", func(t *testing.T) {
+		entries, err := svc.GetResourceTimeline("ws-1", "aws_security_group.web", "", "")
+		require.NoError(t, err)
+		require.Len(t, entries, 3)
+		assert.Equal(t, models.ResourceHistoryChangeCreate, entries[0].ChangeType)
+		assert.Empty(t, entries[0].Changes)
+		assert.Equal(t, sv2, entries[1].StateVersionID)
+		require.Len(t, entries[1].Changes, 2)
+		assert.Equal(t, "ingress.0.from_port", entries[1].Changes[0].Path)
+		assert.Equal(t, 80.0, entries[1].Changes[0].Old)
+		assert.Equal(t, 443.0, entries[1].Changes[0].New)
+	})
+
+	t.Run("which apply changed a field", func(t *testing.T) {
+		entries, err := svc.GetResourceTimeline("ws-1", "", "sg-1", "tags")
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		assert.Equal(t, sv1, entries[0].StateVersionID)
+		assert.Equal(t, sv3, entries[1].StateVersionID)
+		require.NotNil(t, entries[1].TaskID)
+		assert.Equal(t, uint(13), *entries[1].TaskID)
+		assert.Equal(t, []AttributeChange{{Path: "tags.Name", Old: "web", New: "web-public"}}, entries[1].Changes)
+	})
+
+	t.Run("deletion", func(t *testing.T) {
+		entries, err := svc.GetResourceTimeline("ws-1", "aws_s3_bucket.logs", "", "")
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		assert.Equal(t, models.ResourceHistoryChangeDelete, entries[1].ChangeType)
+		assert.Equal(t, sv3, entries[1].StateVersionID)
+		require.NotNil(t, entries[1].TaskID)
+		assert.Equal(t, uint(13), *entries[1].TaskID)
+	})
+
+	t.Run("as of", func(t *testing.T) {
+		at := base.Add(30 * time.Hour)
+		version, err := svc.GetResourceAsOf("ws-1", "aws_security_group.web", "", &at, 0)
+		require.NoError(t, err)
+		assert.Equal(t, sv2, version.ValidFromStateVersionID)
+		assert.Equal(t, sgV2, version.Attributes)
+
+		version, err = svc.GetResourceAsOf("ws-1", "", "sg-1", nil, sv1)
+		require.NoError(t, err)
+		assert.Equal(t, sgV1, version.Attributes)
+
+		version, err = svc.GetResourceAsOf("ws-1", "aws_security_group.web", "", nil, 0)
+		require.NoError(t, err)
+		assert.Equal(t, sgV3, version.Attributes)
+
+		_, err = svc.GetResourceAsOf("ws-1", "aws_s3_bucket.logs", "", nil, sv3)
+		assert.ErrorIs(t, err, ErrResourceVersionNotFound)
+	})
+
+	t.Run("compaction keeps later versions reconstructable", func(t *testing.T) {
+		removed, err := svc.CompactWorkspaceHistory("ws-1", base.Add(36*time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, 1, removed)
+
+		_, err = svc.GetResourceAsOf("ws-1", "aws_security_group.web", "", nil, sv1)
+		assert.ErrorIs(t, err, ErrResourceVersionNotFound)
+		version, err := svc.GetResourceAsOf("ws-1", "aws_security_group.web", "", nil, sv2)
+		require.NoError(t, err)
+		assert.Equal(t, sgV2, version.Attributes)
+
+		// 压缩后继续记录，仍基于正确的当前版本计算差异
+		addStateVersion(t, db, "ws-1", 4, base.Add(72*time.Hour), 14,
+			stateResource("aws_security_group", "web", sgV1))
+		require.NoError(t, svc.RecordWorkspaceHistory("ws-1"))
+		entries, err := svc.GetResourceTimeline("ws-1", "aws_security_group.web", "", "tags.Name")
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		assert.Equal(t, "web", entries[1].Changes[0].New)
+	})
+}
+
+func TestCMDBHistory_SnapshotInterval(t *testing.T) {
+	db := setupHistoryTestDB(t)
+	svc := NewCMDBHistoryService(db)
+
+	base := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i <= resourceHistorySnapshotInterval; i++ {
+		addStateVersion(t, db, "ws-1", i+1, base.Add(time.Duration(i)*time.Hour), uint(i+1),
+			stateResource("aws_instance", "web", map[string]interface{}{"id": "i-1", "instance_type": float64(i)}))
+	}
+	require.NoError(t, svc.RecordWorkspaceHistory("ws-1"))
+
+	var snapshots int64
+	require.NoError(t, db.Model(&models.ResourceAttributeHistory{}).Where("is_snapshot = ?", true).Count(&snapshots).Error)
+	assert.Equal(t, int64(2), snapshots)
+
+	version, err := svc.GetResourceAsOf("ws-1", "aws_instance.web", "", nil, 0)
+	require.NoError(t, err)
+	assert.Equal(t, float64(resourceHistorySnapshotInterval), version.Attributes["instance_type"])
+}
+
+func TestCMDBHistory_RedactsSensitiveAttributes(t *testing.T) {
+	db := setupHistoryTestDB(t)
+	svc := NewCMDBHistoryService(db)
+
+	dbInstance := func(password, size string) map[string]interface{} {
+		res := stateResource("aws_db_instance", "main", map[string]interface{}{
+			"id": "db-1", "password": password, "instance_class": size,
+			"connection": map[string]interface{}{"token": "tok-" + password},
+		})
+		instance := res["instances"].([]interface{})[0].(map[string]interface{})
+		instance["sensitive_attributes"] = []interface{}{
+			[]interface{}{map[string]interface{}{"type": "get_attr", "value": "password"}},
+			[]interface{}{
+				map[string]interface{}{"type": "get_attr", "value": "connection"},
+				map[string]interface{}{"type": "get_attr", "value": "token"},
+			},
+		}
+		return res
+	}
+
+	base := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	addStateVersion(t, db, "ws-1", 1, base, 1, dbInstance("hunter2", "db.t3.micro"))
+	addStateVersion(t, db, "ws-1", 2, base.Add(time.Hour), 2, dbInstance("rotated", "db.t3.micro"))
+	addStateVersion(t, db, "ws-1", 3, base.Add(2*time.Hour), 3, dbInstance("rotated", "db.t3.large"))
+	require.NoError(t, svc.RecordWorkspaceHistory("ws-1"))
+
+	var rows []models.ResourceAttributeHistory
+	require.NoError(t, db.Order("id").Find(&rows).Error)
+	// 只修改 sensitive 属性不产生新版本
+	require.Len(t, rows, 2)
+	for _, row := range rows {
+		assert.NotContains(t, string(row.Snapshot)+string(row.Diff), "hunter2")
+		assert.NotContains(t, string(row.Snapshot)+string(row.Diff), "rotated")
+	}
+
+	version, err := svc.GetResourceAsOf("ws-1", "aws_db_instance.main", "", nil, 0)
+	require.NoError(t, err)
+	assert.Equal(t, sensitiveAttributeValue, version.Attributes["password"])
+	assert.Equal(t, sensitiveAttributeValue, version.Attributes["connection"])
+	assert.Equal(t, "db.t3.large", version.Attributes["instance_class"])
+}
@@ -0,0 +1,685 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"iac-platform/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// CMDBReconciliationService CMDB 对账服务
+// 对比 resource_index 中 Terraform state 解析出的资源（source_type=terraform）与外部数据源同步的资源（source_type=external），
+// 找出未被任何 workspace 管理的云资源、被多个 workspace 同时管理的资源，以及外部清单中已不存在的 Terraform 资源
+type CMDBReconciliationService struct {
+	db *gorm.DB
+}
+
+// NewCMDBReconciliationService 创建 CMDB 对账服务
+func NewCMDBReconciliationService(db *gorm.DB) *CMDBReconciliationService {
+	return &CMDBReconciliationService{db: db}
+}
+
+// ReconciliationFilter 对账范围过滤，字段为空表示不过滤
+type ReconciliationFilter struct {
+	CloudProvider string `form:"cloud_provider"`
+	AccountID     string `form:"account_id"`
+	Region        string `form:"region"`
+	ResourceType  string `form:"resource_type"`
+}
+
+// ReconciliationResource 对账报告中的资源
+type ReconciliationResource struct {
+	ResourceIndexID   uint   `json:"resource_index_id"`
+	SourceType        string `json:"source_type"` // terraform / external
+	WorkspaceID       string `json:"workspace_id,omitempty"`
+	WorkspaceName     string `json:"workspace_name,omitempty"`
+	TerraformAddress  string `json:"terraform_address,omitempty"`
+	ExternalSourceID  string `json:"external_source_id,omitempty"`
+	ResourceType      string `json:"resource_type"`
+	CloudResourceID   string `json:"cloud_resource_id,omitempty"`
+	CloudResourceName string `json:"cloud_resource_name,omitempty"`
+	CloudResourceARN  string `json:"cloud_resource_arn,omitempty"`
+	CloudProvider     string `json:"cloud_provider,omitempty"`
+	AccountID         string `json:"account_id,omitempty"`
+	Region            string `json:"region,omitempty"`
+	// Importable 外部资源的类型是 Terraform 资源类型且有可用的导入 ID，可直接交给导入流程
+	Importable bool `json:"importable,omitempty"`
+}
+
+// MultiManagedResource 被多个 workspace 同时管理的云资源
+type MultiManagedResource struct {
+	CloudResourceID  string                   `json:"cloud_resource_id,omitempty"`
+	CloudResourceARN string                   `json:"cloud_resource_arn,omitempty"`
+	ResourceType     string                   `json:"resource_type"`
+	AccountID        string                   `json:"account_id,omitempty"`
+	Region           string                   `json:"region,omitempty"`
+	Workspaces       []string                 `json:"workspaces"`
+	Instances        []ReconciliationResource `json:"instances"`
+}
+
+// ReconciliationGroup 按账户/区域/资源类型汇总
+type ReconciliationGroup struct {
+	AccountID         string `json:"account_id"`
+	Region            string `json:"region"`
+	ResourceType      string `json:"resource_type"`
+	Unmanaged         int    `json:"unmanaged"`
+	MultiManaged      int    `json:"multi_managed"`
+	MissingExternally int    `json:"missing_externally"`
+}
+
+// ReconciliationSummary 对账汇总
+type ReconciliationSummary struct {
+	ExternalResources  int `json:"external_resources"`
+	TerraformResources int `json:"terraform_resources"`
+	Managed            int `json:"managed"` // 外部资源中被 workspace 管理的数量
+	Unmanaged          int `json:"unmanaged"`
+	MultiManaged       int `json:"multi_managed"`
+	MissingExternally  int `json:"missing_externally"`
+}
+
+// ReconciliationReport 对账报告
+type ReconciliationReport struct {
+	GeneratedAt       time.Time                `json:"generated_at"`
+	Filter            ReconciliationFilter     `json:"filter"`
+	Summary           ReconciliationSummary    `json:"summary"`
+	Groups            []ReconciliationGroup    `json:"groups"`
+	Unmanaged         []ReconciliationResource `json:"unmanaged"`
+	MultiManaged      []MultiManagedResource   `json:"multi_managed"`
+	MissingExternally []ReconciliationResource `json:"missing_externally"`
+}
+
+// reconciliationRow resource_index 中参与对账的字段
+type reconciliationRow struct {
+	ID                uint            `gorm:"column:id"`
+	WorkspaceID       string          `gorm:"column:workspace_id"`
+	TerraformAddress  string          `gorm:"column:terraform_address"`
+	ResourceType      string          `gorm:"column:resource_type"`
+	ResourceName      string          `gorm:"column:resource_name"`
+	CloudResourceID   string          `gorm:"column:cloud_resource_id"`
+	CloudResourceName string          `gorm:"column:cloud_resource_name"`
+	CloudResourceARN  string          `gorm:"column:cloud_resource_arn"`
+	Attributes        json.RawMessage `gorm:"column:attributes"`
+	SourceType        string          `gorm:"column:source_type"`
+	ExternalSourceID  string          `gorm:"column:external_source_id"`
+	CloudProvider     string          `gorm:"column:cloud_provider"`
+	CloudAccountID    string          `gorm:"column:cloud_account_id"`
+	CloudRegion       string          `gorm:"column:cloud_region"`
+	PrimaryKeyValue   string          `gorm:"column:primary_key_value"`
+}
+
+// terraformResourceTypePattern Terraform 资源类型名称（provider 前缀 + 下划线）
+var terraformResourceTypePattern = regexp.MustCompile(`^[a-z][a-z0-9]*_[a-z0-9_]+$`)
+
+// GenerateReport 生成对账报告
+func (s *CMDBReconciliationService) GenerateReport(filter ReconciliationFilter) (*ReconciliationReport, error) {
+	externalRows, terraformRows, err := s.loadRows()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ReconciliationReport{
+		GeneratedAt:       time.Now(),
+		Filter:            filter,
+		Unmanaged:         []ReconciliationResource{},
+		MultiManaged:      []MultiManagedResource{},
+		MissingExternally: []ReconciliationResource{},
+	}
+
+	// Terraform 资源按云 ID / ARN 建立索引
+	managedKeys := make(map[string]bool)
+	for i := range terraformRows {
+		for _, key := range terraformMatchKeys(&terraformRows[i]) {
+			managedKeys[key] = true
+		}
+	}
+
+	// 外部清单覆盖的资源类型及账户，只有在覆盖范围内的 Terraform 资源才判断是否缺失
+	externalKeys := make(map[string]bool)
+	coveredAccounts := make(map[string]map[string]bool) // resource_type -> accounts
+	for i := range externalRows {
+		row := &externalRows[i]
+		for _, key := range externalMatchKeys(row) {
+			externalKeys[key] = true
+		}
+		if coveredAccounts[row.ResourceType] == nil {
+			coveredAccounts[row.ResourceType] = make(map[string]bool)
+		}
+		if row.CloudAccountID != "" {
+			coveredAccounts[row.ResourceType][row.CloudAccountID] = true
+		}
+	}
+
+	workspaceNames := s.loadWorkspaceNames(terraformRows)
+	groups := make(map[string]*ReconciliationGroup)
+	group := func(r *ReconciliationResource) *ReconciliationGroup {
+		key := r.AccountID + "|" + r.Region + "|" + r.ResourceType
+		g, ok := groups[key]
+		if !ok {
+			g = &ReconciliationGroup{AccountID: r.AccountID, Region: r.Region, ResourceType: r.ResourceType}
+			groups[key] = g
+		}
+		return g
+	}
+
+	// 1. 外部存在但没有 workspace 管理的资源
+	for i := range externalRows {
+		row := &externalRows[i]
+		resource := externalReconciliationResource(row)
+		if !filter.matches(&resource) {
+			continue
+		}
+		report.Summary.ExternalResources++
+		if matchesAny(externalMatchKeys(row), managedKeys) {
+			report.Summary.Managed++
+			continue
+		}
+		report.Unmanaged = append(report.Unmanaged, resource)
+		group(&resource).Unmanaged++
+	}
+
+	// 2. 同一云资源出现在多个 workspace 的 state 中
+	type multiEntry struct {
+		instances  []ReconciliationResource
+		workspaces map[string]bool
+	}
+	multi := make(map[string]*multiEntry)
+	var multiOrder []string
+	for i := range terraformRows {
+		row := &terraformRows[i]
+		resource := terraformReconciliationResource(row, workspaceNames)
+		if !filter.matches(&resource) {
+			continue
+		}
+		report.Summary.TerraformResources++
+
+		if key := terraformIdentityKey(row); key != "" {
+			entry, ok := multi[key]
+			if !ok {
+				entry = &multiEntry{workspaces: make(map[string]bool)}
+				multi[key] = entry
+				multiOrder = append(multiOrder, key)
+			}
+			entry.instances = append(entry.instances, resource)
+			entry.workspaces[row.WorkspaceID] = true
+		}
+
+		// 3. Terraform 管理但外部清单中不存在的资源
+		accounts, covered := coveredAccounts[row.ResourceType]
+		if !covered {
+			continue
+		}
+		if resource.AccountID != "" && len(accounts) > 0 && !accounts[resource.AccountID] {
+			continue
+		}
+		if len(terraformMatchKeys(row)) == 0 || matchesAny(terraformMatchKeys(row), externalKeys) {
+			continue
+		}
+		report.MissingExternally = append(report.MissingExternally, resource)
+		group(&resource).MissingExternally++
+	}
+
+	for _, key := range multiOrder {
+		entry := multi[key]
+		if len(entry.workspaces) < 2 {
+			continue
+		}
+		first := entry.instances[0]
+		item := MultiManagedResource{
+			CloudResourceID:  first.CloudResourceID,
+			CloudResourceARN: first.CloudResourceARN,
+			ResourceType:     first.ResourceType,
+			AccountID:        first.AccountID,
+			Region:           first.Region,
+			Workspaces:       sortedKeys(entry.workspaces),
+			Instances:        entry.instances,
+		}
+		report.MultiManaged = append(report.MultiManaged, item)
+		group(&first).MultiManaged++
+	}
+
+	report.Summary.Unmanaged = len(report.Unmanaged)
+	report.Summary.MultiManaged = len(report.MultiManaged)
+	report.Summary.MissingExternally = len(report.MissingExternally)
+
+	report.Groups = make([]ReconciliationGroup, 0, len(groups))
+	for _, g := range groups {
+		report.Groups = append(report.Groups, *g)
+	}
+	sort.Slice(report.Groups, func(i, j int) bool {
+		a, b := report.Groups[i], report.Groups[j]
+		if a.AccountID != b.AccountID {
+			return a.AccountID < b.AccountID
+		}
+		if a.Region != b.Region {
+			return a.Region < b.Region
+		}
+		return a.ResourceType < b.ResourceType
+	})
+	sortReconciliationResources(report.Unmanaged)
+	sortReconciliationResources(report.MissingExternally)
+	return report, nil
+}
+
+func (s *CMDBReconciliationService) loadRows() (external, terraform []reconciliationRow, err error) {
+	var rows []reconciliationRow
+	err = s.db.Model(&models.ResourceIndex{}).
+		Select("id, workspace_id, terraform_address, resource_type, resource_name, cloud_resource_id, cloud_resource_name, "+
+			"cloud_resource_arn, attributes, source_type, external_source_id, cloud_provider, cloud_account_id, cloud_region, primary_key_value").
+		Where("resource_mode = ?", "managed").
+		Order("id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, nil, fmt.Errorf("加载资源索引失败: %w", err)
+	}
+	for _, row := range rows {
+		if row.SourceType == "external" || row.WorkspaceID == ExternalWorkspaceID {
+			external = append(external, row)
+		} else {
+			terraform = append(terraform, row)
+		}
+	}
+	return external, terraform, nil
+}
+
+func (s *CMDBReconciliationService) loadWorkspaceNames(rows []reconciliationRow) map[string]string {
+	ids := make(map[string]bool)
+	for _, row := range rows {
+		ids[row.WorkspaceID] = true
+	}
+	names := make(map[string]string, len(ids))
+	if len(ids) == 0 {
+		return names
+	}
+	var workspaces []struct {
+		WorkspaceID string
+		Name        string
+	}
+	s.db.Model(&models.Workspace{}).Select("workspace_id, name").
+		Where("workspace_id IN ?", sortedKeys(ids)).Scan(&workspaces)
+	for _, ws := range workspaces {
+		names[ws.WorkspaceID] = ws.Name
+	}
+	return names
+}
+
+func (f *ReconciliationFilter) matches(r *ReconciliationResource) bool {
+	if f.CloudProvider != "" && r.CloudProvider != f.CloudProvider {
+		return false
+	}
+	if f.AccountID != "" && r.AccountID != f.AccountID {
+		return false
+	}
+	if f.Region != "" && r.Region != f.Region {
+		return false
+	}
+	if f.ResourceType != "" && r.ResourceType != f.ResourceType {
+		return false
+	}
+	return true
+}
+
+// terraformMatchKeys Terraform 资源用于与外部资源匹配的标识
+func terraformMatchKeys(row *reconciliationRow) []string {
+	return nonEmptyKeys(row.CloudResourceID, row.CloudResourceARN)
+}
+
+// externalMatchKeys 外部资源用于与 Terraform 资源匹配的标识（主键值通常就是云资源 ID）
+func externalMatchKeys(row *reconciliationRow) []string {
+	return nonEmptyKeys(row.CloudResourceID, row.CloudResourceARN, row.PrimaryKeyValue)
+}
+
+// terraformIdentityKey 判断多 workspace 管理时使用的标识
+// 优先使用 ARN；没有 ARN 时用资源类型 + ID，避免不同类型的资源恰好 ID 相同
+func terraformIdentityKey(row *reconciliationRow) string {
+	if arn := strings.TrimSpace(row.CloudResourceARN); arn != "" {
+		return "arn|" + arn
+	}
+	if id := strings.TrimSpace(row.CloudResourceID); id != "" {
+		return row.ResourceType + "|" + id
+	}
+	return ""
+}
+
+func nonEmptyKeys(values ...string) []string {
+	var keys []string
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			keys = append(keys, v)
+		}
+	}
+	return keys
+}
+
+func matchesAny(keys []string, set map[string]bool) bool {
+	for _, key := range keys {
+		if set[key] {
+			return true
+		}
+	}
+	return false
+}
+
+func externalReconciliationResource(row *reconciliationRow) ReconciliationResource {
+	resource := ReconciliationResource{
+		ResourceIndexID:   row.ID,
+		SourceType:        "external",
+		ExternalSourceID:  row.ExternalSourceID,
+		ResourceType:      row.ResourceType,
+		CloudResourceID:   row.CloudResourceID,
+		CloudResourceName: row.CloudResourceName,
+		CloudResourceARN:  row.CloudResourceARN,
+		CloudProvider:     row.CloudProvider,
+		AccountID:         row.CloudAccountID,
+		Region:            row.CloudRegion,
+	}
+	if resource.CloudResourceName == "" {
+		resource.CloudResourceName = row.ResourceName
+	}
+	resource.Importable = terraformResourceTypePattern.MatchString(row.ResourceType) && importIDForRow(row) != ""
+	return resource
+}
+
+func terraformReconciliationResource(row *reconciliationRow, workspaceNames map[string]string) ReconciliationResource {
+	resource := ReconciliationResource{
+		ResourceIndexID:   row.ID,
+		SourceType:        "terraform",
+		WorkspaceID:       row.WorkspaceID,
+		WorkspaceName:     workspaceNames[row.WorkspaceID],
+		TerraformAddress:  row.TerraformAddress,
+		ResourceType:      row.ResourceType,
+		CloudResourceID:   row.CloudResourceID,
+		CloudResourceName: row.CloudResourceName,
+		CloudResourceARN:  row.CloudResourceARN,
+		CloudProvider:     cloudProviderForResourceType(row.ResourceType),
+		AccountID:         row.CloudAccountID,
+		Region:            row.CloudRegion,
+	}
+	// state 中的资源没有账户/区域列，从 ARN 或属性中推断
+	if resource.AccountID == "" || resource.Region == "" {
+		arnRegion, arnAccount := parseARNLocation(row.CloudResourceARN)
+		if resource.AccountID == "" {
+			resource.AccountID = arnAccount
+		}
+		if resource.Region == "" {
+			resource.Region = arnRegion
+		}
+	}
+	if resource.Region == "" && len(row.Attributes) > 0 {
+		var attrs map[string]interface{}
+		if json.Unmarshal(row.Attributes, &attrs) == nil {
+			resource.Region = cmdbGetString(attrs, "region")
+		}
+	}
+	return resource
+}
+
+// parseARNLocation 从 arn:partition:service:region:account:resource 中解析区域和账户
+func parseARNLocation(arn string) (region, account string) {
+	parts := strings.SplitN(arn, ":", 6)
+	if len(parts) < 6 || parts[0] != "arn" {
+		return "", ""
+	}
+	return parts[3], parts[4]
+}
+
+// cloudProviderForResourceType 根据 Terraform 资源类型前缀推断云提供商，与外部数据源的 cloud_provider 取值一致
+func cloudProviderForResourceType(resourceType string) string {
+	switch {
+	case strings.HasPrefix(resourceType, "aws_"):
+		return "aws"
+	case strings.HasPrefix(resourceType, "azurerm_"), strings.HasPrefix(resourceType, "azuread_"):
+		return "azure"
+	case strings.HasPrefix(resourceType, "google_"):
+		return "gcp"
+	case strings.HasPrefix(resourceType, "alicloud_"):
+		return "aliyun"
+	}
+	return ""
+}
+
+func sortReconciliationResources(resources []ReconciliationResource) {
+	sort.SliceStable(resources, func(i, j int) bool {
+		a, b := resources[i], resources[j]
+		if a.AccountID != b.AccountID {
+			return a.AccountID < b.AccountID
+		}
+		if a.Region != b.Region {
+			return a.Region < b.Region
+		}
+		if a.ResourceType != b.ResourceType {
+			return a.ResourceType < b.ResourceType
+		}
+		return a.ResourceIndexID < b.ResourceIndexID
+	})
+}
+
+// ========== 导入未管理的资源 ==========
+
+// ReconciliationImportItem 要导入的外部资源
+type ReconciliationImportItem struct {
+	ResourceIndexID uint   `json:"resource_index_id" binding:"required"`
+	ResourceType    string `json:"resource_type"` // 可选，外部资源类型不是 Terraform 资源类型时必填
+	ResourceName    string `json:"resource_name"` // 可选，默认由云资源名称生成
+	ImportID        string `json:"import_id"`     // 可选，默认使用云资源 ID
+}
+
+// ReconciliationImportRequest 将未管理的外部资源导入 workspace
+type ReconciliationImportRequest struct {
+	WorkspaceID string                     `json:"workspace_id" binding:"required"`
+	Items       []ReconciliationImportItem `json:"items" binding:"required,min=1,dive"`
+	DryRun      bool                       `json:"dry_run"` // 只生成 TF 代码，不创建资源
+}
+
+// ReconciliationImportError 单个资源的导入错误
+type ReconciliationImportError struct {
+	ResourceIndexID uint   `json:"resource_index_id"`
+	Error           string `json:"error"`
+}
+
+// ReconciliationImportResult 导入结果
+// TFCode 包含 resource 骨架和 import 块，可直接提交给 workspace 的资源导入接口；
+// 资源参数需要根据 plan 输出补全，apply 后资源进入 state
+type ReconciliationImportResult struct {
+	WorkspaceID string                      `json:"workspace_id"`
+	TFCode      map[string]interface{}      `json:"tf_code"`
+	Created     []string                    `json:"created"`
+	Errors      []ReconciliationImportError `json:"errors,omitempty"`
+}
+
+// ImportUnmanaged 为未管理的外部资源生成 import 块和资源骨架，并（非 dry_run 时）添加到 workspace
+func (s *CMDBReconciliationService) ImportUnmanaged(req *ReconciliationImportRequest, userID string) (*ReconciliationImportResult, error) {
+	if req.WorkspaceID == ExternalWorkspaceID {
+		return nil, fmt.Errorf("invalid workspace: %s", req.WorkspaceID)
+	}
+	var workspaceCount int64
+	if err := s.db.Model(&models.Workspace{}).Where("workspace_id = ?", req.WorkspaceID).Count(&workspaceCount).Error; err != nil {
+		return nil, err
+	}
+	if workspaceCount == 0 {
+		return nil, fmt.Errorf("workspace %s not found", req.WorkspaceID)
+	}
+
+	_, terraformRows, err := s.loadRows()
+	if err != nil {
+		return nil, err
+	}
+	managedBy := make(map[string]string)
+	for i := range terraformRows {
+		for _, key := range terraformMatchKeys(&terraformRows[i]) {
+			managedBy[key] = terraformRows[i].WorkspaceID
+		}
+	}
+
+	result := &ReconciliationImportResult{
+		WorkspaceID: req.WorkspaceID,
+		TFCode:      map[string]interface{}{},
+		Created:     []string{},
+	}
+	resourceBlocks := map[string]interface{}{}
+	var importBlocks []interface{}
+	usedNames := make(map[string]bool)
+	resourceService := &ResourceService{db: s.db}
+
+	for _, item := range req.Items {
+		candidate, err := s.buildImportCandidate(item, managedBy, usedNames)
+		if err != nil {
+			result.Errors = append(result.Errors, ReconciliationImportError{ResourceIndexID: item.ResourceIndexID, Error: err.Error()})
+			continue
+		}
+
+		if !req.DryRun {
+			_, err := resourceService.AddResource(req.WorkspaceID, candidate.resourceType, candidate.resourceName, candidate.tfCode(), nil,
+				fmt.Sprintf("Imported from CMDB reconciliation (resource index %d)", item.ResourceIndexID), userID)
+			if err != nil {
+				result.Errors = append(result.Errors, ReconciliationImportError{ResourceIndexID: item.ResourceIndexID, Error: err.Error()})
+				continue
+			}
+			result.Created = append(result.Created, candidate.address())
+		}
+
+		blocks, ok := resourceBlocks[candidate.resourceType].(map[string]interface{})
+		if !ok {
+			blocks = map[string]interface{}{}
+			resourceBlocks[candidate.resourceType] = blocks
+		}
+		blocks[candidate.resourceName] = candidate.body()
+		importBlocks = append(importBlocks, candidate.importBlock())
+	}
+
+	if len(resourceBlocks) > 0 {
+		result.TFCode["resource"] = resourceBlocks
+		result.TFCode["import"] = importBlocks
+	}
+	return result, nil
+}
+
+// importCandidate 待导入的资源
+type importCandidate struct {
+	resourceType     string
+	resourceName     string
+	importID         string
+	externalSourceID string
+}
+
+func (c *importCandidate) address() string {
+	return c.resourceType + "." + c.resourceName
+}
+
+// body resource 骨架，Terraform JSON 语法中 "//" 属性为注释
+func (c *importCandidate) body() map[string]interface{} {
+	return map[string]interface{}{
+		"//": fmt.Sprintf("Imported from CMDB external source %s (%s); complete the arguments from the plan output",
+			c.externalSourceID, c.importID),
+	}
+}
+
+func (c *importCandidate) importBlock() map[string]interface{} {
+	return map[string]interface{}{"to": c.address(), "id": c.importID}
+}
+
+// tfCode 单个资源的 TF 代码：resource 骨架 + import 块
+func (c *importCandidate) tfCode() map[string]interface{} {
+	return map[string]interface{}{
+		"resource": map[string]interface{}{
+			c.resourceType: map[string]interface{}{c.resourceName: c.body()},
+		},
+		"import": []interface{}{c.importBlock()},
+	}
+}
+
+// buildImportCandidate 校验要导入的外部资源并确定资源地址和导入 ID
+func (s *CMDBReconciliationService) buildImportCandidate(
+	item ReconciliationImportItem,
+	managedBy map[string]string,
+	usedNames map[string]bool,
+) (*importCandidate, error) {
+	var row reconciliationRow
+	err := s.db.Model(&models.ResourceIndex{}).
+		Select("id, workspace_id, resource_type, resource_name, cloud_resource_id, cloud_resource_name, cloud_resource_arn, "+
+			"source_type, external_source_id, primary_key_value").
+		Where("id = ?", item.ResourceIndexID).
+		Take(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("resource index %d not found", item.ResourceIndexID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if row.SourceType != "external" {
+		return nil, fmt.Errorf("resource index %d is not an external resource", item.ResourceIndexID)
+	}
+	for _, key := range externalMatchKeys(&row) {
+		if ws, ok := managedBy[key]; ok {
+			return nil, fmt.Errorf("resource %s is already managed by workspace %s", key, ws)
+		}
+	}
+
+	resourceType := item.ResourceType
+	if resourceType == "" {
+		resourceType = row.ResourceType
+	}
+	if !terraformResourceTypePattern.MatchString(resourceType) {
+		return nil, fmt.Errorf("resource type %q is not a Terraform resource type, specify resource_type", resourceType)
+	}
+
+	importID := item.ImportID
+	if importID == "" {
+		importID = importIDForRow(&row)
+	}
+	if importID == "" {
+		return nil, fmt.Errorf("resource index %d has no cloud resource ID, specify import_id", item.ResourceIndexID)
+	}
+
+	resourceName := item.ResourceName
+	if resourceName == "" {
+		resourceName = terraformResourceName(row.CloudResourceName, row.ResourceName, importID)
+	}
+	if !terraformNamePattern.MatchString(resourceName) {
+		return nil, fmt.Errorf("invalid resource name %q", resourceName)
+	}
+	candidate := &importCandidate{
+		resourceType:     resourceType,
+		resourceName:     resourceName,
+		importID:         importID,
+		externalSourceID: row.ExternalSourceID,
+	}
+	if usedNames[candidate.address()] {
+		return nil, fmt.Errorf("duplicate resource address %s", candidate.address())
+	}
+	usedNames[candidate.address()] = true
+	return candidate, nil
+}
+
+func importIDForRow(row *reconciliationRow) string {
+	if row.CloudResourceID != "" {
+		return row.CloudResourceID
+	}
+	return row.PrimaryKeyValue
+}
+
+var (
+	terraformNamePattern    = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_-]*$`)
+	terraformNameInvalidRun = regexp.MustCompile(`[^a-z0-9_]+`)
+)
+
+// terraformResourceName 由云资源名称（或 ID）生成合法的 Terraform 资源名
+func terraformResourceName(candidates ...string) string {
+	for _, candidate := range candidates {
+		name := strings.Trim(terraformNameInvalidRun.ReplaceAllString(strings.ToLower(candidate), "_"), "_")
+		if name == "" {
+			continue
+		}
+		if name[0] >= '0' && name[0] <= '9' {
+			name = "r_" + name
+		}
+		return name
+	}
+	return "imported"
+}
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"testing"
+
+	"iac-platform/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupReconciliationTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+
+	require.NoError(t, db.AutoMigrate(&models.ResourceIndex{}))
+	statements := []string{
+		`CREATE TABLE workspaces (id INTEGER PRIMARY KEY AUTOINCREMENT, workspace_id TEXT UNIQUE NOT NULL, name TEXT)`,
+		`CREATE TABLE workspace_resources (
+			id INTEGER PRIMARY KEY AUTOINCREMENT, workspace_id TEXT NOT NULL, resource_id TEXT NOT NULL,
+			resource_type TEXT NOT NULL, resource_name TEXT NOT NULL, current_version_id INTEGER,
+			is_active INTEGER DEFAULT 1, description TEXT, tags BLOB, created_by TEXT,
+			created_at DATETIME, updated_at DATETIME, last_applied_at DATETIME, manifest_deployment_id TEXT)`,
+		`CREATE TABLE resource_code_versions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT, resource_id INTEGER NOT NULL, version INTEGER NOT NULL,
+			is_latest INTEGER DEFAULT 0, tf_code BLOB NOT NULL, variables BLOB, change_summary TEXT,
+			change_type TEXT, diff_from_previous TEXT, state_version_id INTEGER, task_id INTEGER,
+			created_by TEXT, created_at DATETIME)`,
+		`INSERT INTO workspaces (workspace_id, name) VALUES ('ws-network', 'network'), ('ws-app', 'app')`,
+	}
+	for _, stmt := range statements {
+		require.NoError(t, db.Exec(stmt).Error)
+	}
+	// SQLite 驱动无法绑定 WorkspaceResource.Tags（map），写入资源时忽略该字段
+	omitTags := func(tx *gorm.DB) {
+		if tx.Statement.Table == "workspace_resources" {
+			tx.Statement.Omits = append(tx.Statement.Omits, "Tags")
+		}
+	}
+	require.NoError(t, db.Callback().Create().Before("gorm:create").Register("test:omit_resource_tags", omitTags))
+	require.NoError(t, db.Callback().Update().Before("gorm:update").Register("test:omit_resource_tags", omitTags))
+	return db
+}
+
+func seedReconciliationResources(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	resources := []models.ResourceIndex{
+		// Terraform state
+		{WorkspaceID: "ws-network", TerraformAddress: "aws_vpc.main", ResourceType: "aws_vpc", ResourceName: "main",
+			CloudResourceID: "vpc-1", CloudResourceARN: "arn:aws:ec2:us-east-1:111111111111:vpc/vpc-1"},
+		{WorkspaceID: "ws-app", TerraformAddress: "aws_vpc.shared", ResourceType: "aws_vpc", ResourceName: "shared",
+			CloudResourceID: "vpc-1", CloudResourceARN: "arn:aws:ec2:us-east-1:111111111111:vpc/vpc-1"},
+		{WorkspaceID: "ws-app", TerraformAddress: "aws_instance.web", ResourceType: "aws_instance", ResourceName: "web",
+			CloudResourceID: "i-web", CloudResourceARN: "arn:aws:ec2:us-east-1:111111111111:instance/i-web"},
+		{WorkspaceID: "ws-app", TerraformAddress: "aws_instance.gone", ResourceType: "aws_instance", ResourceName: "gone",
+			CloudResourceID: "i-gone", CloudResourceARN: "arn:aws:ec2:us-east-1:111111111111:instance/i-gone"},
+		// 外部清单未覆盖的类型，不判断缺失
+		{WorkspaceID: "ws-app", TerraformAddress: "aws_iam_role.app", ResourceType: "aws_iam_role", ResourceName: "app",
+			CloudResourceID: "app-role"},
+		// data source 不参与对账
+		{WorkspaceID: "ws-app", TerraformAddress: "data.aws_instance.lookup", ResourceType: "aws_instance", ResourceName: "lookup",
+			ResourceMode: "data", CloudResourceID: "i-shadow"},
+
+		// 外部清单
+		{WorkspaceID: ExternalWorkspaceID, TerraformAddress: "external.src-1.i-web", SourceType: "external",
+			ExternalSourceID: "src-1", ResourceType: "aws_instance", CloudResourceID: "i-web", PrimaryKeyValue: "i-web",
+			CloudProvider: "aws", CloudAccountID: "111111111111", CloudRegion: "us-east-1"},
+		{WorkspaceID: ExternalWorkspaceID, TerraformAddress: "external.src-1.i-shadow", SourceType: "external",
+			ExternalSourceID: "src-1", ResourceType: "aws_instance", CloudResourceID: "i-shadow", CloudResourceName: "Shadow Box",
+			PrimaryKeyValue: "i-shadow", CloudProvider: "aws", CloudAccountID: "111111111111", CloudRegion: "us-east-1"},
+		{WorkspaceID: ExternalWorkspaceID, TerraformAddress: "external.src-2.db-1", SourceType: "external",
+			ExternalSourceID: "src-2", ResourceType: "rds", CloudResourceID: "db-1", PrimaryKeyValue: "db-1",
+			CloudProvider: "aws", CloudAccountID: "222222222222", CloudRegion: "eu-west-1"},
+	}
+	for i := range resources {
+		if resources[i].ResourceMode == "" {
+			resources[i].ResourceMode = "managed"
+		}
+		if resources[i].SourceType == "" {
+			resources[i].SourceType = "terraform"
+		}
+		require.NoError(t, db.Create(&resources[i]).Error)
+	}
+}
+
+func TestCMDBReconciliation_GenerateReport(t *testing.T) {
+	db := setupReconciliationTestDB(t)
+	seedReconciliationResources(t, db)
+
+	report, err := NewCMDBReconciliationService(db).GenerateReport(ReconciliationFilter{})
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, report.Summary.ExternalResources)
+	assert.Equal(t, 5, report.Summary.TerraformResources)
+	assert.Equal(t, 1, report.Summary.Managed)
+
+	require.Len(t, report.Unmanaged, 2)
+	assert.Equal(t, "db-1", report.Unmanaged[1].CloudResourceID)
+	assert.False(t, report.Unmanaged[1].Importable, "rds is not a Terraform resource type")
+	shadow := report.Unmanaged[0]
+	assert.Equal(t, "i-shadow", shadow.CloudResourceID)
+	assert.True(t, shadow.Importable)
+
+	require.Len(t, report.MultiManaged, 1)
+	assert.Equal(t, []string{"ws-app", "ws-network"}, report.MultiManaged[0].Workspaces)
+	assert.Equal(t, "111111111111", report.MultiManaged[0].AccountID)
+
+	require.Len(t, report.MissingExternally, 1)
+	missing := report.MissingExternally[0]
+	assert.Equal(t, "aws_instance.gone", missing.TerraformAddress)
+	assert.Equal(t, "app", missing.WorkspaceName)
+	assert.Equal(t, "us-east-1", missing.Region)
+
+	assert.Contains(t, report.Groups, ReconciliationGroup{
+		AccountID: "111111111111", Region: "us-east-1", ResourceType: "aws_instance", Unmanaged: 1, MissingExternally: 1,
+	})
+	assert.Contains(t, report.Groups, ReconciliationGroup{
+		AccountID: "111111111111", Region: "us-east-1", ResourceType: "aws_vpc", MultiManaged: 1,
+	})
+
+	filtered, err := NewCMDBReconciliationService(db).GenerateReport(ReconciliationFilter{AccountID: "222222222222"})
+	require.NoError(t, err)
+	require.Len(t, filtered.Unmanaged, 1)
+	assert.Empty(t, filtered.MultiManaged)
+	assert.Empty(t, filtered.MissingExternally)
+}
+
+func TestCMDBReconciliation_ImportUnmanaged(t *testing.T) {
+	db := setupReconciliationTestDB(t)
+	seedReconciliationResources(t, db)
+	service := NewCMDBReconciliationService(db)
+
+	report, err := service.GenerateReport(ReconciliationFilter{})
+	require.NoError(t, err)
+	shadowID := report.Unmanaged[0].ResourceIndexID
+	rdsID := report.Unmanaged[1].ResourceIndexID
+
+	var managedID uint
+	require.NoError(t, db.Model(&models.ResourceIndex{}).Select("id").
+		Where("terraform_address = ?", "external.src-1.i-web").Scan(&managedID).Error)
+
+	req := &ReconciliationImportRequest{
+		WorkspaceID: "ws-app",
+		Items: []ReconciliationImportItem{
+			{ResourceIndexID: shadowID},
+			{ResourceIndexID: rdsID},
+			{ResourceIndexID: rdsID, ResourceType: "aws_db_instance"},
+			{ResourceIndexID: managedID},
+		},
+		DryRun: true,
+	}
+	result, err := service.ImportUnmanaged(req, "u-1")
+	require.NoError(t, err)
+	require.Len(t, result.Errors, 2)
+	assert.Contains(t, result.Errors[0].Error, "not a Terraform resource type")
+	assert.Contains(t, result.Errors[1].Error, "already managed by workspace ws-app")
+	assert.Empty(t, result.Created)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"to": "aws_instance.shadow_box", "id": "i-shadow"},
+		map[string]interface{}{"to": "aws_db_instance.db_1", "id": "db-1"},
+	}, result.TFCode["import"])
+
+	var count int64
+	db.Table("workspace_resources").Count(&count)
+	assert.Zero(t, count, "dry run must not create resources")
+
+	req.DryRun = false
+	req.Items = req.Items[:1]
+	result, err = service.ImportUnmanaged(req, "u-1")
+	require.NoError(t, err)
+	require.Empty(t, result.Errors)
+	assert.Equal(t, []string{"aws_instance.shadow_box"}, result.Created)
+
+	var version models.ResourceCodeVersion
+	require.NoError(t, db.Table("resource_code_versions").First(&version).Error)
+	assert.Equal(t, []interface{}{map[string]interface{}{"to": "aws_instance.shadow_box", "id": "i-shadow"}},
+		version.TFCode["import"])
+
+	_, err = service.ImportUnmanaged(&ReconciliationImportRequest{WorkspaceID: "ws-missing", Items: req.Items}, "u-1")
+	assert.Error(t, err)
+}
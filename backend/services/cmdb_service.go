@@ -152,7 +152,12 @@ func (s *CMDBService) SyncWorkspaceResources(workspaceID string) error {
 		return err
 	}
 
-	// 4. 触发 embedding 生成（异步，不阻塞主流程）
+	// 4. 记录资源属性历史（失败不影响索引同步）
+	if err := NewCMDBHistoryService(s.db).SyncWorkspace(workspaceID); err != nil {
+		log.Printf("[CMDB] Resource history sync failed for workspace %s: %v", workspaceID, err)
+	}
+
+	// 5. 触发 embedding 生成（异步，不阻塞主流程）
 	go func() {
 		log.Printf("[CMDB] Starting embedding sync for workspace %s", workspaceID)
 		embeddingWorker := NewEmbeddingWorker(s.db)
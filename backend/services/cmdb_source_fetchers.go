@@ -0,0 +1,626 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"iac-platform/internal/models"
+)
+
+// DefaultCMDBImportDir 文件类型数据源的挂载导入根目录（可通过 CMDB_IMPORT_DIR 覆盖）
+const DefaultCMDBImportDir = "/var/lib/iac-platform/cmdb-imports"
+
+const (
+	defaultPaginationPageSize = 100
+	defaultPaginationMaxPages = 1000
+	defaultSQLSourceMaxRows   = 100000
+	sqlSourceQueryTimeout     = 5 * time.Minute
+	maxRateLimitRetries       = 3
+	maxRetryAfter             = 60 * time.Second
+)
+
+// sqlSourceDrivers 允许的 SQL 数据源驱动（配置名 -> database/sql 驱动名）
+var sqlSourceDrivers = map[string]string{
+	"postgres": "postgres",
+}
+
+// externalSourceFetcher 从外部数据源拉取原始记录，记录随后统一按 field_mapping 写入 ResourceIndex
+// limit > 0 时拉取到足够的记录即可停止（用于测试连接）
+type externalSourceFetcher interface {
+	Fetch(ctx context.Context, source *models.CMDBExternalSource, limit int) ([]map[string]interface{}, error)
+}
+
+// newExternalSourceFetcher 根据数据源类型创建拉取器
+func (s *CMDBExternalSourceService) newExternalSourceFetcher(source *models.CMDBExternalSource) (externalSourceFetcher, error) {
+	config, err := source.GetSourceConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse source_config: %w", err)
+	}
+
+	switch source.GetSourceType() {
+	case models.ExternalSourceTypeHTTP:
+		return &httpSourceFetcher{service: s, config: config}, nil
+	case models.ExternalSourceTypeFile:
+		if config.File == nil {
+			return nil, fmt.Errorf("file source requires source_config.file")
+		}
+		return &fileSourceFetcher{service: s, config: config.File}, nil
+	case models.ExternalSourceTypeSQL:
+		if config.SQL == nil {
+			return nil, fmt.Errorf("sql source requires source_config.sql")
+		}
+		return &sqlSourceFetcher{service: s, config: config.SQL}, nil
+	default:
+		return nil, fmt.Errorf("unsupported source type: %s", source.SourceType)
+	}
+}
+
+// toRecords 将提取出的数据转换为记录列表，非数组时包装为单元素数组，非对象元素被忽略
+func toRecords(data interface{}) []map[string]interface{} {
+	var items []interface{}
+	switch v := data.(type) {
+	case []interface{}:
+		items = v
+	case nil:
+		return nil
+	default:
+		items = []interface{}{v}
+	}
+
+	records := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		if m, ok := item.(map[string]interface{}); ok {
+			records = append(records, m)
+		}
+	}
+	return records
+}
+
+// ===== HTTP =====
+
+// httpSourceFetcher HTTP 数据源，支持 offset/page/cursor/Link 头分页与限流
+type httpSourceFetcher struct {
+	service *CMDBExternalSourceService
+	config  *models.ExternalSourceConfig
+}
+
+func (f *httpSourceFetcher) Fetch(ctx context.Context, source *models.CMDBExternalSource, limit int) ([]map[string]interface{}, error) {
+	pagination := f.config.Pagination
+	if pagination == nil {
+		pagination = &models.PaginationConfig{Strategy: models.PaginationNone}
+	}
+	pageSize := pagination.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultPaginationPageSize
+	}
+	maxPages := pagination.MaxPages
+	if maxPages <= 0 {
+		maxPages = defaultPaginationMaxPages
+	}
+	limiter := newRequestRateLimiter(f.config.RateLimitPerSecond)
+
+	var records []map[string]interface{}
+	var nextURL, cursor string
+	for page := 0; ; page++ {
+		if page >= maxPages {
+			// 未拉取完整时不能继续同步，否则未拉取到的资源会被当作已删除
+			return nil, fmt.Errorf("pagination exceeded max_pages (%d)", maxPages)
+		}
+
+		req, err := f.service.buildHTTPRequest(ctx, source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build request: %w", err)
+		}
+		if nextURL != "" {
+			next, err := req.URL.Parse(nextURL)
+			if err != nil {
+				return nil, fmt.Errorf("invalid next page link %q: %w", nextURL, err)
+			}
+			req.URL = next
+		} else {
+			applyPaginationParams(req.URL, pagination, page, pageSize, cursor)
+		}
+
+		body, header, err := f.do(ctx, req, limiter)
+		if err != nil {
+			return nil, err
+		}
+
+		var responseData interface{}
+		if err := json.Unmarshal(body, &responseData); err != nil {
+			return nil, fmt.Errorf("failed to parse JSON: %w", err)
+		}
+		data, err := f.service.extractDataFromResponse(responseData, source.ResponsePath)
+		if err != nil {
+			return nil, err
+		}
+		pageRecords := toRecords(data)
+		records = append(records, pageRecords...)
+
+		if limit > 0 && len(records) >= limit {
+			return records, nil
+		}
+
+		switch pagination.Strategy {
+		case models.PaginationOffset, models.PaginationPage:
+			if len(pageRecords) < pageSize {
+				return records, nil
+			}
+		case models.PaginationCursor:
+			next, _ := f.service.extractDataFromResponse(responseData, pagination.NextCursorPath)
+			cursor = cursorString(next)
+			if cursor == "" || len(pageRecords) == 0 {
+				return records, nil
+			}
+		case models.PaginationLinkHeader:
+			nextURL = nextLink(header.Get("Link"))
+			if nextURL == "" {
+				return records, nil
+			}
+		default:
+			return records, nil
+		}
+	}
+}
+
+// do 发送请求，遇到 429 时按 Retry-After 重试
+func (f *httpSourceFetcher) do(ctx context.Context, req *http.Request, limiter *requestRateLimiter) ([]byte, http.Header, error) {
+	var bodyBytes []byte
+	if req.Body != nil {
+		bodyBytes, _ = io.ReadAll(req.Body)
+		req.Body.Close()
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := limiter.Wait(ctx); err != nil {
+			return nil, nil, err
+		}
+		if bodyBytes != nil {
+			req.Body = io.NopCloser(bytes.NewReader(bodyBytes))
+		}
+
+		resp, err := f.service.httpClient.Do(req)
+		if err != nil {
+			return nil, nil, fmt.Errorf("request failed: %w", err)
+		}
+		body, readErr := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode == http.StatusTooManyRequests && attempt < maxRateLimitRetries {
+			wait := retryAfter(resp.Header.Get("Retry-After"), attempt)
+			log.Printf("[CMDBSync] Rate limited by %s, retrying in %s", req.URL.Host, wait)
+			select {
+			case <-ctx.Done():
+				return nil, nil, ctx.Err()
+			case <-time.After(wait):
+			}
+			continue
+		}
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return nil, nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+		}
+		if readErr != nil {
+			return nil, nil, fmt.Errorf("failed to read response: %w", readErr)
+		}
+		return body, resp.Header, nil
+	}
+}
+
+// applyPaginationParams 按分页策略设置查询参数，page 从 0 开始计数
+func applyPaginationParams(u *url.URL, p *models.PaginationConfig, page, pageSize int, cursor string) {
+	query := u.Query()
+	switch p.Strategy {
+	case models.PaginationOffset:
+		query.Set(defaultString(p.OffsetParam, "offset"), strconv.Itoa(page*pageSize))
+		query.Set(defaultString(p.LimitParam, "limit"), strconv.Itoa(pageSize))
+	case models.PaginationPage:
+		startPage := p.StartPage
+		if startPage <= 0 {
+			startPage = 1
+		}
+		query.Set(defaultString(p.PageParam, "page"), strconv.Itoa(startPage+page))
+		query.Set(defaultString(p.LimitParam, "per_page"), strconv.Itoa(pageSize))
+	case models.PaginationCursor:
+		if p.LimitParam != "" {
+			query.Set(p.LimitParam, strconv.Itoa(pageSize))
+		}
+		if cursor != "" {
+			query.Set(defaultString(p.CursorParam, "cursor"), cursor)
+		}
+	default:
+		return
+	}
+	u.RawQuery = query.Encode()
+}
+
+var linkNextPattern = regexp.MustCompile(`<([^>]+)>\s*;[^,]*rel="?next"?`)
+
+// nextLink 解析 RFC 8288 Link 头中 rel="next" 的地址
+func nextLink(header string) string {
+	if m := linkNextPattern.FindStringSubmatch(header); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// cursorString 将响应中的游标值转换为字符串
+func cursorString(v interface{}) string {
+	switch c := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return c
+	case float64:
+		return strconv.FormatFloat(c, 'f', -1, 64)
+	case bool:
+		return ""
+	default:
+		return fmt.Sprintf("%v", c)
+	}
+}
+
+// retryAfter 解析 Retry-After（秒数或 HTTP 时间），缺省时指数退避
+func retryAfter(value string, attempt int) time.Duration {
+	wait := time.Duration(1<<attempt) * time.Second
+	if value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil {
+			wait = time.Duration(seconds) * time.Second
+		} else if at, err := http.ParseTime(value); err == nil {
+			wait = time.Until(at)
+		}
+	}
+	if wait < 0 {
+		wait = 0
+	}
+	if wait > maxRetryAfter {
+		wait = maxRetryAfter
+	}
+	return wait
+}
+
+// requestRateLimiter 按固定间隔限制请求速率
+type requestRateLimiter struct {
+	interval time.Duration
+	last     time.Time
+}
+
+func newRequestRateLimiter(perSecond float64) *requestRateLimiter {
+	if perSecond <= 0 {
+		return &requestRateLimiter{}
+	}
+	return &requestRateLimiter{interval: time.Duration(float64(time.Second) / perSecond)}
+}
+
+// Wait 等待到允许发送下一个请求
+func (l *requestRateLimiter) Wait(ctx context.Context) error {
+	if l.interval > 0 && !l.last.IsZero() {
+		if wait := time.Until(l.last.Add(l.interval)); wait > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(wait):
+			}
+		}
+	}
+	l.last = time.Now()
+	return nil
+}
+
+func defaultString(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// ===== 文件 =====
+
+// fileSourceFetcher 文件数据源：读取上传到产物存储的文件或挂载目录中的文件
+type fileSourceFetcher struct {
+	service *CMDBExternalSourceService
+	config  *models.FileSourceConfig
+}
+
+func (f *fileSourceFetcher) Fetch(ctx context.Context, source *models.CMDBExternalSource, limit int) ([]map[string]interface{}, error) {
+	data, name, err := f.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	format := f.config.Format
+	if format == "" {
+		format = fileFormatFromName(name)
+	}
+
+	switch format {
+	case "csv":
+		return parseCSVRecords(data, f.config.Delimiter)
+	case "jsonl":
+		return parseJSONLRecords(data)
+	case "json":
+		var parsed interface{}
+		if err := json.Unmarshal(data, &parsed); err != nil {
+			return nil, fmt.Errorf("failed to parse JSON: %w", err)
+		}
+		extracted, err := f.service.extractDataFromResponse(parsed, source.ResponsePath)
+		if err != nil {
+			return nil, err
+		}
+		return toRecords(extracted), nil
+	default:
+		return nil, fmt.Errorf("unsupported file format: %q", format)
+	}
+}
+
+// read 读取文件内容，上传文件优先于挂载路径
+func (f *fileSourceFetcher) read(ctx context.Context) ([]byte, string, error) {
+	if f.config.ObjectKey != "" {
+		storage, err := f.service.getStorage()
+		if err != nil {
+			return nil, "", err
+		}
+		compressed, err := storage.Get(ctx, f.config.ObjectKey)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read uploaded file: %w", err)
+		}
+		data, err := decompressArtifact(compressed)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to decompress uploaded file: %w", err)
+		}
+		return data, f.config.FileName, nil
+	}
+
+	if f.config.Path == "" {
+		return nil, "", fmt.Errorf("no file uploaded and no path configured")
+	}
+	path, err := resolveImportPath(f.service.importDir, f.config.Path)
+	if err != nil {
+		return nil, "", err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read %s: %w", f.config.Path, err)
+	}
+	return data, path, nil
+}
+
+// resolveImportPath 将配置的路径限制在导入根目录内
+func resolveImportPath(root, path string) (string, error) {
+	cleaned := filepath.Join(root, filepath.Clean("/"+path))
+	rel, err := filepath.Rel(root, cleaned)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("path %q is outside the import directory", path)
+	}
+	return cleaned, nil
+}
+
+// fileFormatFromName 根据扩展名推断文件格式
+func fileFormatFromName(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		return "csv"
+	case ".jsonl", ".ndjson":
+		return "jsonl"
+	case ".json":
+		return "json"
+	default:
+		return ""
+	}
+}
+
+// parseCSVRecords 解析带表头的 CSV，每行转换为以表头为 key 的记录
+func parseCSVRecords(data []byte, delimiter string) ([]map[string]interface{}, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	if delimiter != "" {
+		if delimiter == `\t` {
+			delimiter = "\t"
+		}
+		reader.Comma = []rune(delimiter)[0]
+	}
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+	}
+
+	var records []map[string]interface{}
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV line %d: %w", line, err)
+		}
+		record := make(map[string]interface{}, len(header))
+		for i, column := range header {
+			if i < len(row) && column != "" {
+				record[column] = row[i]
+			}
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// parseJSONLRecords 解析每行一个 JSON 对象的文件
+func parseJSONLRecords(data []byte) ([]map[string]interface{}, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+
+	var records []map[string]interface{}
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(text), &record); err != nil {
+			return nil, fmt.Errorf("invalid JSON on line %d: %w", line, err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// ===== SQL =====
+
+// sqlSourceFetcher SQL 数据源：在只读事务中执行查询（通常是专门提供的只读视图）
+type sqlSourceFetcher struct {
+	service *CMDBExternalSourceService
+	config  *models.SQLSourceConfig
+}
+
+func (f *sqlSourceFetcher) Fetch(ctx context.Context, source *models.CMDBExternalSource, limit int) ([]map[string]interface{}, error) {
+	driverName, ok := sqlSourceDrivers[f.config.Driver]
+	if !ok {
+		return nil, fmt.Errorf("unsupported SQL driver: %s", f.config.Driver)
+	}
+	if err := validateReadOnlyQuery(f.config.Query); err != nil {
+		return nil, err
+	}
+	if f.config.DSNSecretID == "" {
+		return nil, fmt.Errorf("SQL source has no DSN configured")
+	}
+	dsn, err := f.service.getHeaderSecretValue(f.config.DSNSecretID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get DSN: %w", err)
+	}
+
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, sqlSourceQueryTimeout)
+	defer cancel()
+
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, f.config.Query)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	maxRows := f.config.MaxRows
+	if maxRows <= 0 {
+		maxRows = defaultSQLSourceMaxRows
+	}
+
+	var records []map[string]interface{}
+	for rows.Next() {
+		if len(records) >= maxRows {
+			return nil, fmt.Errorf("query returned more than max_rows (%d) rows", maxRows)
+		}
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		record := make(map[string]interface{}, len(columns))
+		for i, column := range columns {
+			record[column] = sqlRecordValue(values[i])
+		}
+		records = append(records, record)
+
+		if limit > 0 && len(records) >= limit {
+			break
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	return records, nil
+}
+
+// sqlRecordValue 将扫描出的列值转换为与 JSON 记录一致的类型，JSON 文本列会被解析为对象
+func sqlRecordValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case []byte:
+		return sqlTextValue(string(value))
+	case string:
+		return sqlTextValue(value)
+	case time.Time:
+		return value.UTC().Format(time.RFC3339)
+	case int64:
+		return float64(value)
+	case int32:
+		return float64(value)
+	case float32:
+		return float64(value)
+	default:
+		return value
+	}
+}
+
+// sqlTextValue 文本列中的 JSON 对象/数组被解析，其余原样返回
+func sqlTextValue(text string) interface{} {
+	if trimmed := strings.TrimSpace(text); strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		var parsed interface{}
+		if json.Unmarshal([]byte(trimmed), &parsed) == nil {
+			return parsed
+		}
+	}
+	return text
+}
+
+var sqlWriteKeywordPattern = regexp.MustCompile(`(?i)\b(insert|update|delete|merge|drop|alter|create|truncate|grant|revoke|copy|call|execute|lock|vacuum)\b`)
+
+// validateReadOnlyQuery 只允许单条 SELECT/WITH 查询；只读事务是实际的保护，这里尽早给出明确错误
+func validateReadOnlyQuery(query string) error {
+	trimmed := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(query), ";"))
+	if trimmed == "" {
+		return fmt.Errorf("query is required")
+	}
+	if strings.Contains(trimmed, ";") {
+		return fmt.Errorf("only a single statement is allowed")
+	}
+	lower := strings.ToLower(trimmed)
+	if !strings.HasPrefix(lower, "select") && !strings.HasPrefix(lower, "with") {
+		return fmt.Errorf("only SELECT queries are allowed")
+	}
+	if sqlWriteKeywordPattern.MatchString(trimmed) {
+		return fmt.Errorf("query must be read-only")
+	}
+	return nil
+}
@@ -0,0 +1,312 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"iac-platform/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupExternalSourceTestService(t *testing.T) (*CMDBExternalSourceService, *gorm.DB) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, db.AutoMigrate(&models.ResourceIndex{}, &models.CMDBExternalSource{}, &models.CMDBSyncLog{}, &models.Secret{}))
+
+	// 密钥加密使用由 JWT_SECRET 派生的密钥
+	t.Setenv("JWT_SECRET", "cmdb-source-test-secret")
+
+	svc := NewCMDBExternalSourceService(db)
+	svc.importDir = t.TempDir()
+	svc.storage = NewLocalArtifactStorage(t.TempDir())
+	return svc, db
+}
+
+// inventoryItems 模拟外部 CMDB 的资源列表
+func inventoryItems(n int) []map[string]interface{} {
+	items := make([]map[string]interface{}, 0, n)
+	for i := 1; i <= n; i++ {
+		items = append(items, map[string]interface{}{"id": fmt.Sprintf("host-%d", i), "type": "server"})
+	}
+	return items
+}
+
+func recordIDs(records []map[string]interface{}) []string {
+	ids := make([]string, 0, len(records))
+	for _, r := range records {
+		ids = append(ids, fmt.Sprint(r["id"]))
+	}
+	return ids
+}
+
+func TestHTTPSourceFetcher_Pagination(t *testing.T) {
+	items := inventoryItems(5)
+	page := func(from, to int) []map[string]interface{} {
+		if from > len(items) {
+			return []map[string]interface{}{}
+		}
+		if to > len(items) {
+			to = len(items)
+		}
+		return items[from:to]
+	}
+
+	var srvURL string
+	rateLimited := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		var body interface{}
+		switch r.URL.Path {
+		case "/offset":
+			offset, _ := strconv.Atoi(q.Get("offset"))
+			limit, _ := strconv.Atoi(q.Get("limit"))
+			body = map[string]interface{}{"data": page(offset, offset+limit)}
+		case "/page":
+			p, _ := strconv.Atoi(q.Get("pageNo"))
+			size, _ := strconv.Atoi(q.Get("per_page"))
+			body = map[string]interface{}{"data": page((p-1)*size, p*size)}
+		case "/cursor":
+			// 第一次请求返回 429，验证按 Retry-After 重试
+			if !rateLimited {
+				rateLimited = true
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			from, _ := strconv.Atoi(q.Get("after"))
+			next := ""
+			if from+2 < len(items) {
+				next = strconv.Itoa(from + 2)
+			}
+			body = map[string]interface{}{"data": page(from, from+2), "meta": map[string]interface{}{"next": next}}
+		case "/link":
+			p, _ := strconv.Atoi(q.Get("p"))
+			if (p+1)*2 < len(items) {
+				w.Header().Set("Link", fmt.Sprintf(`<%s/link?p=%d>; rel="next", <%s/link?p=0>; rel="first"`, srvURL, p+1, srvURL))
+			}
+			body = map[string]interface{}{"data": page(p*2, (p+1)*2)}
+		}
+		json.NewEncoder(w).Encode(body)
+	}))
+	defer srv.Close()
+	srvURL = srv.URL
+
+	tests := []struct {
+		name       string
+		path       string
+		pagination *models.PaginationConfig
+	}{
+		{"offset", "/offset", &models.PaginationConfig{Strategy: models.PaginationOffset, PageSize: 2}},
+		{"page", "/page", &models.PaginationConfig{Strategy: models.PaginationPage, PageSize: 2, PageParam: "pageNo"}},
+		{"cursor", "/cursor", &models.PaginationConfig{Strategy: models.PaginationCursor, CursorParam: "after", NextCursorPath: "$.meta.next"}},
+		{"link header", "/link", &models.PaginationConfig{Strategy: models.PaginationLinkHeader}},
+	}
+
+	svc, _ := setupExternalSourceTestService(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := &models.CMDBExternalSource{SourceID: "src-1", APIEndpoint: srv.URL + tt.path, HTTPMethod: "GET", ResponsePath: "$.data"}
+			require.NoError(t, source.SetSourceConfig(&models.ExternalSourceConfig{Pagination: tt.pagination, RateLimitPerSecond: 100}))
+
+			fetcher, err := svc.newExternalSourceFetcher(source)
+			require.NoError(t, err)
+			records, err := fetcher.Fetch(context.Background(), source, 0)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"host-1", "host-2", "host-3", "host-4", "host-5"}, recordIDs(records))
+		})
+	}
+
+	t.Run("max pages", func(t *testing.T) {
+		source := &models.CMDBExternalSource{SourceID: "src-1", APIEndpoint: srv.URL + "/offset", HTTPMethod: "GET", ResponsePath: "$.data"}
+		require.NoError(t, source.SetSourceConfig(&models.ExternalSourceConfig{
+			Pagination: &models.PaginationConfig{Strategy: models.PaginationOffset, PageSize: 1, MaxPages: 3},
+		}))
+		fetcher, err := svc.newExternalSourceFetcher(source)
+		require.NoError(t, err)
+
+		_, err = fetcher.Fetch(context.Background(), source, 0)
+		assert.ErrorContains(t, err, "max_pages")
+		records, err := fetcher.Fetch(context.Background(), source, 2)
+		require.NoError(t, err)
+		assert.Len(t, records, 2, "test connection stops once enough samples are fetched")
+	})
+}
+
+func TestFileSource_UploadAndSync(t *testing.T) {
+	svc, db := setupExternalSourceTestService(t)
+	ctx := context.Background()
+
+	source, err := svc.CreateExternalSource(ctx, &models.CreateExternalSourceRequest{
+		Name:            "inventory",
+		SourceType:      models.ExternalSourceTypeFile,
+		SourceConfig:    &models.ExternalSourceConfig{File: &models.FileSourceConfig{}},
+		FieldMapping:    map[string]string{"resource_type": "$.type", "resource_name": "$.name"},
+		PrimaryKeyField: "$.id",
+	}, "admin")
+	require.NoError(t, err)
+
+	// 未上传文件时同步失败并记录日志
+	require.Error(t, svc.SyncExternalSource(ctx, source.SourceID))
+
+	csvData := "\xef\xbb\xbfid,name,type\nhost-1,web,server\nhost-2,db,server\n"
+	_, err = svc.UploadSourceFile(ctx, source.SourceID, "../hosts.csv", []byte(csvData), "admin")
+	require.NoError(t, err)
+	require.NoError(t, svc.SyncExternalSource(ctx, source.SourceID))
+
+	var resources []models.ResourceIndex
+	require.NoError(t, db.Where("external_source_id = ?", source.SourceID).Order("primary_key_value").Find(&resources).Error)
+	require.Len(t, resources, 2)
+	assert.Equal(t, "host-1", resources[0].PrimaryKeyValue)
+	assert.Equal(t, "web", resources[0].ResourceName)
+
+	// 替换为 JSONL 文件：host-2 被删除
+	_, err = svc.UploadSourceFile(ctx, source.SourceID, "hosts.jsonl", []byte(`{"id":"host-1","name":"web-1","type":"server"}`+"\n"), "admin")
+	require.NoError(t, err)
+	require.NoError(t, svc.SyncExternalSource(ctx, source.SourceID))
+
+	var logs []models.CMDBSyncLog
+	require.NoError(t, db.Where("source_id = ?", source.SourceID).Order("id").Find(&logs).Error)
+	require.Len(t, logs, 3)
+	assert.Equal(t, models.SyncStatusFailed, logs[0].Status)
+	assert.Equal(t, 1, logs[2].ResourcesUpdated)
+	assert.Equal(t, 1, logs[2].ResourcesDeleted)
+
+	stored, err := svc.GetExternalSource(ctx, source.SourceID)
+	require.NoError(t, err)
+	config, err := stored.GetSourceConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "cmdb-sources/"+source.SourceID+"/hosts.jsonl", config.File.ObjectKey)
+	_, err = svc.storage.Get(ctx, "cmdb-sources/"+source.SourceID+"/hosts.csv")
+	assert.ErrorIs(t, err, ErrArtifactNotFound, "previous upload is removed")
+}
+
+func TestFileSource_MountedPath(t *testing.T) {
+	svc, _ := setupExternalSourceTestService(t)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(svc.importDir, "exports"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(svc.importDir, "exports", "hosts.json"),
+		[]byte(`{"result": {"items": [{"id": "a"}, {"id": "b"}, "skipped"]}}`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(svc.importDir, "exports", "hosts.tsv"),
+		[]byte("id\tname\na\tweb\n"), 0o644))
+
+	fetch := func(file *models.FileSourceConfig, responsePath string) ([]map[string]interface{}, error) {
+		source := &models.CMDBExternalSource{SourceType: models.ExternalSourceTypeFile, ResponsePath: responsePath}
+		require.NoError(t, source.SetSourceConfig(&models.ExternalSourceConfig{File: file}))
+		fetcher, err := svc.newExternalSourceFetcher(source)
+		require.NoError(t, err)
+		return fetcher.Fetch(context.Background(), source, 0)
+	}
+
+	records, err := fetch(&models.FileSourceConfig{Path: "exports/hosts.json"}, "$.result.items")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, recordIDs(records))
+
+	records, err = fetch(&models.FileSourceConfig{Path: "/exports/hosts.tsv", Format: "csv", Delimiter: `\t`}, "")
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{{"id": "a", "name": "web"}}, records)
+
+	// 路径被限制在导入根目录内
+	resolved, err := resolveImportPath(svc.importDir, "../../etc/passwd")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(svc.importDir, "etc", "passwd"), resolved)
+	_, err = fetch(&models.FileSourceConfig{Path: "../../etc/passwd", Format: "csv"}, "")
+	assert.Error(t, err)
+	_, err = resolveImportPath(svc.importDir, "..")
+	assert.Error(t, err)
+}
+
+func TestSQLSource(t *testing.T) {
+	svc, db := setupExternalSourceTestService(t)
+	ctx := context.Background()
+
+	sqlSourceDrivers["sqlite3"] = "sqlite3"
+	t.Cleanup(func() { delete(sqlSourceDrivers, "sqlite3") })
+
+	dsn := filepath.Join(t.TempDir(), "inventory.db")
+	inventory, err := sql.Open("sqlite3", dsn)
+	require.NoError(t, err)
+	for _, stmt := range []string{
+		`CREATE TABLE hosts (id TEXT, hostname TEXT, attrs TEXT, retired INTEGER)`,
+		`INSERT INTO hosts VALUES ('h-1', 'web', '{"env":"prod"}', 0), ('h-2', 'db', '{"env":"prod"}', 0), ('h-3', 'old', '{}', 1)`,
+		`CREATE VIEW cmdb_hosts AS SELECT id, hostname, attrs FROM hosts WHERE retired = 0`,
+	} {
+		_, err := inventory.Exec(stmt)
+		require.NoError(t, err)
+	}
+	require.NoError(t, inventory.Close())
+
+	req := &models.CreateExternalSourceRequest{
+		Name:       "inventory-db",
+		SourceType: models.ExternalSourceTypeSQL,
+		SourceConfig: &models.ExternalSourceConfig{SQL: &models.SQLSourceConfig{
+			Driver: "sqlite3", Query: "SELECT * FROM cmdb_hosts ORDER BY id", DSN: dsn,
+		}},
+		FieldMapping:    map[string]string{"resource_name": "$.hostname", "description": "$.attrs.env"},
+		PrimaryKeyField: "$.id",
+	}
+	source, err := svc.CreateExternalSource(ctx, req, "admin")
+	require.NoError(t, err)
+
+	// DSN 只保存在 secrets 表中，响应中不返回
+	assert.NotContains(t, string(source.SourceConfig), dsn)
+	response, err := source.ToResponse()
+	require.NoError(t, err)
+	assert.True(t, response.SourceConfig.SQL.HasDSN)
+	assert.Empty(t, response.SourceConfig.SQL.DSNSecretID)
+
+	result, err := svc.TestConnection(ctx, source.SourceID)
+	require.NoError(t, err)
+	require.True(t, result.Success, result.Message)
+	assert.Equal(t, 2, result.SampleCount)
+
+	require.NoError(t, svc.SyncExternalSource(ctx, source.SourceID))
+	var resources []models.ResourceIndex
+	require.NoError(t, db.Where("external_source_id = ?", source.SourceID).Order("primary_key_value").Find(&resources).Error)
+	require.Len(t, resources, 2)
+	assert.Equal(t, "web", resources[0].ResourceName)
+	assert.Equal(t, "prod", resources[0].Description, "JSON columns are mapped like nested fields")
+
+	// 更新查询时保留已存储的 DSN
+	query := "SELECT id, hostname FROM hosts"
+	_, err = svc.UpdateExternalSource(ctx, source.SourceID, &models.UpdateExternalSourceRequest{
+		SourceConfig: &models.ExternalSourceConfig{SQL: &models.SQLSourceConfig{Driver: "sqlite3", Query: query}},
+	}, "admin")
+	require.NoError(t, err)
+	result, err = svc.TestConnection(ctx, source.SourceID)
+	require.NoError(t, err)
+	require.True(t, result.Success, result.Message)
+	assert.Equal(t, 3, result.SampleCount)
+
+	_, err = svc.UpdateExternalSource(ctx, source.SourceID, &models.UpdateExternalSourceRequest{
+		SourceConfig: &models.ExternalSourceConfig{SQL: &models.SQLSourceConfig{Driver: "sqlite3", Query: "DELETE FROM hosts"}},
+	}, "admin")
+	assert.Error(t, err)
+}
+
+func TestValidateReadOnlyQuery(t *testing.T) {
+	assert.NoError(t, validateReadOnlyQuery("select id, created_at from v_hosts;"))
+	assert.NoError(t, validateReadOnlyQuery("WITH h AS (SELECT * FROM hosts) SELECT * FROM h"))
+	assert.Error(t, validateReadOnlyQuery(""))
+	assert.Error(t, validateReadOnlyQuery("UPDATE hosts SET name = 'x'"))
+	assert.Error(t, validateReadOnlyQuery("SELECT 1; DROP TABLE hosts"))
+	assert.Error(t, validateReadOnlyQuery("WITH d AS (DELETE FROM hosts RETURNING *) SELECT * FROM d"))
+}
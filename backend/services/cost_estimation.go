@@ -0,0 +1,444 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"iac-platform/internal/models"
+)
+
+// 成本估算在 plan 保存之后运行：遍历 plan JSON 的 resource_changes，
+// 用价格表中的组成项把 change.before / change.after 换算成月度成本，
+// 得到每个资源和整个任务的 before/after/delta。预算阈值超出且为 mandatory 时禁止 apply。
+
+// CostEstimationConfig workspace 的成本估算配置（存储在 workspaces.cost_estimation_config）
+type CostEstimationConfig struct {
+	Enabled          bool                           `json:"enabled"`
+	MaxMonthlyDelta  *float64                       `json:"max_monthly_delta,omitempty"` // 月度成本增量上限
+	MaxMonthlyTotal  *float64                       `json:"max_monthly_total,omitempty"` // 变更后月度总成本上限
+	EnforcementLevel models.RunTaskEnforcementLevel `json:"enforcement_level"`           // advisory（默认）或 mandatory
+}
+
+// ParseCostEstimationConfig 解析并校验 workspace 的成本估算配置
+// 配置为空时返回未启用的配置
+func ParseCostEstimationConfig(raw map[string]interface{}) (*CostEstimationConfig, error) {
+	cfg := &CostEstimationConfig{}
+	if len(raw) == 0 {
+		return cfg, nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cost estimation config: %w", err)
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("invalid cost estimation config: %w", err)
+	}
+
+	switch cfg.EnforcementLevel {
+	case "":
+		cfg.EnforcementLevel = models.RunTaskEnforcementAdvisory
+	case models.RunTaskEnforcementAdvisory, models.RunTaskEnforcementMandatory:
+	default:
+		return nil, fmt.Errorf("cost estimation: invalid enforcement_level %q", cfg.EnforcementLevel)
+	}
+	if cfg.MaxMonthlyDelta != nil && *cfg.MaxMonthlyDelta < 0 {
+		return nil, fmt.Errorf("cost estimation: max_monthly_delta must not be negative")
+	}
+	if cfg.MaxMonthlyTotal != nil && *cfg.MaxMonthlyTotal < 0 {
+		return nil, fmt.Errorf("cost estimation: max_monthly_total must not be negative")
+	}
+	return cfg, nil
+}
+
+// CostEstimator 根据价格表和价格来源估算 plan 的月度成本
+type CostEstimator struct {
+	PriceList *CostPriceList
+	Source    CostPriceSource
+}
+
+// Estimate 估算 plan JSON 的月度成本，返回任务汇总和每个资源的明细
+func (e *CostEstimator) Estimate(ctx context.Context, planJSON map[string]interface{}) (*models.TaskCostEstimate, []models.TaskCostEstimateResource, error) {
+	region := planAWSRegion(planJSON, e.PriceList.DefaultRegion)
+	estimate := &models.TaskCostEstimate{
+		Currency:      e.PriceList.Currency,
+		PricingSource: e.Source.Name(),
+		PricingRegion: region,
+		BudgetStatus:  models.CostBudgetStatusNone,
+	}
+
+	changes, _ := planJSON["resource_changes"].([]interface{})
+	resources := make([]models.TaskCostEstimateResource, 0, len(changes))
+	for _, raw := range changes {
+		rc, ok := raw.(map[string]interface{})
+		if !ok || rc["mode"] != "managed" {
+			continue
+		}
+		change, _ := rc["change"].(map[string]interface{})
+		action := costChangeAction(change["actions"])
+		if action == "" {
+			continue
+		}
+
+		resource, err := e.estimateResource(ctx, rc, change, action, region)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		switch {
+		case e.PriceList.IsFree(resource.ResourceType):
+			estimate.FreeCount++
+		case resource.Priced:
+			estimate.PricedCount++
+		default:
+			estimate.UnsupportedCount++
+		}
+		estimate.MonthlyBefore += resource.MonthlyBefore
+		estimate.MonthlyAfter += resource.MonthlyAfter
+		resources = append(resources, resource)
+	}
+
+	estimate.MonthlyBefore = roundCost(estimate.MonthlyBefore)
+	estimate.MonthlyAfter = roundCost(estimate.MonthlyAfter)
+	estimate.MonthlyDelta = roundCost(estimate.MonthlyAfter - estimate.MonthlyBefore)
+	return estimate, resources, nil
+}
+
+func (e *CostEstimator) estimateResource(ctx context.Context, rc, change map[string]interface{}, action, region string) (models.TaskCostEstimateResource, error) {
+	resourceType, _ := rc["type"].(string)
+	address, _ := rc["address"].(string)
+	resource := models.TaskCostEstimateResource{
+		Address:      address,
+		ResourceType: resourceType,
+		Action:       action,
+	}
+
+	if e.PriceList.IsFree(resourceType) {
+		resource.Priced = true
+		resource.Note = "free or usage-based, counted as 0"
+		return resource, nil
+	}
+	components, ok := e.PriceList.Resources[resourceType]
+	if !ok {
+		resource.Note = "no price mapping for resource type"
+		return resource, nil
+	}
+
+	resource.Priced = true
+	var notes []string
+	resource.Components = make(models.CostComponents, len(components))
+	for i, c := range components {
+		resource.Components[i] = models.CostComponent{Name: c.Name, Unit: c.Unit}
+	}
+
+	if action != "create" {
+		costs, note, err := e.priceComponents(ctx, components, change["before"], nil, region)
+		if err != nil {
+			return resource, err
+		}
+		if note != "" {
+			resource.Priced = false
+			notes = append(notes, "before: "+note)
+		}
+		for i, cc := range costs {
+			resource.Components[i].MonthlyBefore = cc.monthly
+			resource.MonthlyBefore += cc.monthly
+			if action == "delete" {
+				resource.Components[i].PriceKey = cc.priceKey
+				resource.Components[i].UnitPrice = cc.unitPrice
+				resource.Components[i].Quantity = cc.quantity
+			}
+		}
+	}
+	if action != "delete" {
+		costs, note, err := e.priceComponents(ctx, components, change["after"], change["after_unknown"], region)
+		if err != nil {
+			return resource, err
+		}
+		if note != "" {
+			resource.Priced = false
+			notes = append(notes, "after: "+note)
+		}
+		for i, cc := range costs {
+			resource.Components[i].MonthlyAfter = cc.monthly
+			resource.Components[i].PriceKey = cc.priceKey
+			resource.Components[i].UnitPrice = cc.unitPrice
+			resource.Components[i].Quantity = cc.quantity
+			resource.MonthlyAfter += cc.monthly
+		}
+	}
+
+	resource.MonthlyBefore = roundCost(resource.MonthlyBefore)
+	resource.MonthlyAfter = roundCost(resource.MonthlyAfter)
+	resource.MonthlyDelta = roundCost(resource.MonthlyAfter - resource.MonthlyBefore)
+	resource.Note = strings.Join(notes, "; ")
+	return resource, nil
+}
+
+type componentCost struct {
+	priceKey  string
+	unitPrice float64
+	quantity  float64
+	monthly   float64
+}
+
+// priceComponents 计算一组属性值下每个组成项的月度成本
+// 返回的 note 非空表示部分组成项无法定价（值在 apply 后才知道，或价格表中没有对应价格），这些组成项计为 0
+func (e *CostEstimator) priceComponents(ctx context.Context, components []CostPriceComponent, values, unknown interface{}, region string) ([]componentCost, string, error) {
+	costs := make([]componentCost, len(components))
+	var notes []string
+
+	for i, c := range components {
+		priceKey := ""
+		if c.PriceBy != "" {
+			if costAttributeUnknown(unknown, c.PriceBy) {
+				notes = append(notes, fmt.Sprintf("%s known after apply", c.PriceBy))
+				continue
+			}
+			if v, ok := lookupCostAttribute(values, c.PriceBy); ok {
+				priceKey = fmt.Sprint(v)
+			}
+			if priceKey == "" {
+				priceKey = c.Default
+			}
+			if priceKey == "" {
+				notes = append(notes, fmt.Sprintf("%s not set", c.PriceBy))
+				continue
+			}
+		}
+
+		quantity := 1.0
+		if c.DefaultQuantity != nil {
+			quantity = *c.DefaultQuantity
+		}
+		if c.QuantityAttribute != "" {
+			if costAttributeUnknown(unknown, c.QuantityAttribute) {
+				notes = append(notes, fmt.Sprintf("%s known after apply", c.QuantityAttribute))
+				continue
+			}
+			if v, ok := lookupCostAttribute(values, c.QuantityAttribute); ok {
+				if q, ok := costNumber(v); ok {
+					quantity = q
+				}
+			}
+		}
+
+		unitPrice, ok, err := e.Source.UnitPrice(ctx, c, priceKey, region)
+		if err != nil {
+			return nil, "", err
+		}
+		if !ok {
+			notes = append(notes, fmt.Sprintf("no price for %s %q", c.Name, priceKey))
+			continue
+		}
+
+		monthly := unitPrice * quantity
+		if c.Unit == "hours" {
+			monthly *= e.PriceList.HoursPerMonth
+		}
+		costs[i] = componentCost{priceKey: priceKey, unitPrice: unitPrice, quantity: quantity, monthly: monthly}
+	}
+
+	return costs, strings.Join(notes, ", "), nil
+}
+
+// EvaluateCostBudget 按 workspace 配置检查预算，设置估算结果的预算状态
+func EvaluateCostBudget(estimate *models.TaskCostEstimate, cfg *CostEstimationConfig) {
+	estimate.EnforcementLevel = cfg.EnforcementLevel
+	estimate.Blocking = false
+	if cfg.MaxMonthlyDelta == nil && cfg.MaxMonthlyTotal == nil {
+		estimate.BudgetStatus = models.CostBudgetStatusNone
+		estimate.BudgetMessage = ""
+		return
+	}
+
+	var violations []string
+	if cfg.MaxMonthlyDelta != nil && estimate.MonthlyDelta > *cfg.MaxMonthlyDelta {
+		violations = append(violations, fmt.Sprintf("monthly delta %s exceeds budget %s",
+			formatCost(estimate.MonthlyDelta, estimate.Currency), formatCost(*cfg.MaxMonthlyDelta, estimate.Currency)))
+	}
+	if cfg.MaxMonthlyTotal != nil && estimate.MonthlyAfter > *cfg.MaxMonthlyTotal {
+		violations = append(violations, fmt.Sprintf("monthly total %s exceeds budget %s",
+			formatCost(estimate.MonthlyAfter, estimate.Currency), formatCost(*cfg.MaxMonthlyTotal, estimate.Currency)))
+	}
+
+	if len(violations) == 0 {
+		estimate.BudgetStatus = models.CostBudgetStatusWithin
+		estimate.BudgetMessage = "within budget"
+		return
+	}
+	estimate.BudgetStatus = models.CostBudgetStatusExceeded
+	estimate.BudgetMessage = strings.Join(violations, "; ")
+	estimate.Blocking = cfg.EnforcementLevel == models.RunTaskEnforcementMandatory
+}
+
+// CostEstimateSummary 生成用于通知、Run Task payload 和 Agent 日志的估算摘要
+func CostEstimateSummary(estimate *models.TaskCostEstimate) map[string]interface{} {
+	if estimate == nil {
+		return nil
+	}
+	return map[string]interface{}{
+		"currency":          estimate.Currency,
+		"monthly_before":    estimate.MonthlyBefore,
+		"monthly_after":     estimate.MonthlyAfter,
+		"monthly_delta":     estimate.MonthlyDelta,
+		"priced_count":      estimate.PricedCount,
+		"free_count":        estimate.FreeCount,
+		"unsupported_count": estimate.UnsupportedCount,
+		"pricing_source":    estimate.PricingSource,
+		"budget_status":     estimate.BudgetStatus,
+		"budget_message":    estimate.BudgetMessage,
+		"enforcement_level": estimate.EnforcementLevel,
+		"blocking":          estimate.Blocking,
+	}
+}
+
+// FormatCostDelta 格式化月度成本变化，如 "+$12.34/mo ($100.00 → $112.34)"
+func FormatCostDelta(estimate *models.TaskCostEstimate) string {
+	sign := "+"
+	if estimate.MonthlyDelta < 0 {
+		sign = "-"
+	}
+	return fmt.Sprintf("%s%s/mo (%s → %s)", sign,
+		formatCost(math.Abs(estimate.MonthlyDelta), estimate.Currency),
+		formatCost(estimate.MonthlyBefore, estimate.Currency),
+		formatCost(estimate.MonthlyAfter, estimate.Currency))
+}
+
+func formatCost(amount float64, currency string) string {
+	if currency == "" || currency == "USD" {
+		return fmt.Sprintf("$%.2f", amount)
+	}
+	return fmt.Sprintf("%.2f %s", amount, currency)
+}
+
+func roundCost(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// costChangeAction 把 plan 的 actions 转换为估算动作，data source 读取返回空
+func costChangeAction(raw interface{}) string {
+	list, _ := raw.([]interface{})
+	actions := make([]string, 0, len(list))
+	for _, a := range list {
+		if s, ok := a.(string); ok {
+			actions = append(actions, s)
+		}
+	}
+	sort.Strings(actions)
+
+	switch strings.Join(actions, ",") {
+	case "create":
+		return "create"
+	case "delete":
+		return "delete"
+	case "update":
+		return "update"
+	case "no-op":
+		return "no-op"
+	case "create,delete":
+		return "replace"
+	default:
+		return ""
+	}
+}
+
+// planAWSRegion 从 plan 的 provider 配置中读取 AWS region（仅常量），读取不到时使用默认区域
+func planAWSRegion(planJSON map[string]interface{}, defaultRegion string) string {
+	configuration, _ := planJSON["configuration"].(map[string]interface{})
+	providers, _ := configuration["provider_config"].(map[string]interface{})
+
+	keys := make([]string, 0, len(providers))
+	for k := range providers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys) // "aws" 排在 "aws.xxx" 别名之前
+
+	for _, k := range keys {
+		provider, _ := providers[k].(map[string]interface{})
+		if provider["name"] != "aws" && k != "aws" {
+			continue
+		}
+		if v, ok := lookupCostAttribute(provider, "expressions.region.constant_value"); ok {
+			if region, ok := v.(string); ok && region != "" {
+				return region
+			}
+		}
+	}
+	return defaultRegion
+}
+
+// lookupCostAttribute 按点分路径读取属性，数字段表示列表下标，如 root_block_device.0.volume_size
+func lookupCostAttribute(values interface{}, path string) (interface{}, bool) {
+	current := values
+	for _, part := range strings.Split(path, ".") {
+		switch v := current.(type) {
+		case map[string]interface{}:
+			next, ok := v[part]
+			if !ok {
+				return nil, false
+			}
+			current = next
+		case []interface{}:
+			idx, err := strconv.Atoi(part)
+			if err != nil || idx < 0 || idx >= len(v) {
+				return nil, false
+			}
+			current = v[idx]
+		default:
+			return nil, false
+		}
+	}
+	if current == nil {
+		return nil, false
+	}
+	return current, true
+}
+
+// costAttributeUnknown 检查 after_unknown 中路径（或其任一父级）是否为 true
+func costAttributeUnknown(unknown interface{}, path string) bool {
+	current := unknown
+	for _, part := range strings.Split(path, ".") {
+		if b, ok := current.(bool); ok {
+			return b
+		}
+		switch v := current.(type) {
+		case map[string]interface{}:
+			current = v[part]
+		case []interface{}:
+			idx, err := strconv.Atoi(part)
+			if err != nil || idx < 0 || idx >= len(v) {
+				return false
+			}
+			current = v[idx]
+		default:
+			return false
+		}
+	}
+	b, _ := current.(bool)
+	return b
+}
+
+func costNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
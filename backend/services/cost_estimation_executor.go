@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"iac-platform/internal/models"
+)
+
+// runCostEstimationStage 在 plan 保存后估算成本并输出到任务日志
+// 估算只影响 apply 确认（mandatory 预算超出时阻断），不会让 plan 失败
+func (s *TerraformExecutor) runCostEstimationStage(
+	ctx context.Context,
+	task *models.WorkspaceTask,
+	planJSON map[string]interface{},
+	logger *TerraformLogger,
+) {
+	if task.TaskType == models.TaskTypeDriftCheck || planJSON == nil {
+		return
+	}
+
+	summary, err := s.estimateCost(ctx, task, planJSON)
+	if err != nil {
+		logger.StageBegin("cost_estimation")
+		logger.Warn("Cost estimation failed: %v", err)
+		logger.StageEnd("cost_estimation")
+		return
+	}
+	if summary == nil {
+		// workspace 未启用成本估算
+		return
+	}
+
+	logger.StageBegin("cost_estimation")
+	defer logger.StageEnd("cost_estimation")
+
+	estimate := costEstimateFromSummary(summary)
+	logger.Info("Estimated monthly cost: %s", FormatCostDelta(estimate))
+	logger.Info("  - Priced resources: %d, free/usage-based: %d, unsupported: %d (source: %s)",
+		estimate.PricedCount, estimate.FreeCount, estimate.UnsupportedCount, estimate.PricingSource)
+
+	switch {
+	case estimate.Blocking:
+		logger.Error("✗ Budget exceeded (mandatory): %s - apply will be blocked", estimate.BudgetMessage)
+	case estimate.BudgetStatus == models.CostBudgetStatusExceeded:
+		logger.Warn("⚠ Budget exceeded (advisory, not blocking): %s", estimate.BudgetMessage)
+	case estimate.BudgetStatus == models.CostBudgetStatusWithin:
+		logger.Info("✓ Within budget")
+	}
+}
+
+// estimateCost 估算成本（Local 模式直接估算，Agent 模式由服务端根据已上传的 plan_json 估算）
+func (s *TerraformExecutor) estimateCost(ctx context.Context, task *models.WorkspaceTask, planJSON map[string]interface{}) (map[string]interface{}, error) {
+	if s.db != nil {
+		estimate, err := NewCostEstimationService(s.db).EstimateTask(ctx, task, planJSON)
+		if err != nil || estimate == nil {
+			return nil, err
+		}
+		return CostEstimateSummary(estimate), nil
+	}
+	if remoteAccessor, ok := s.dataAccessor.(*RemoteDataAccessor); ok {
+		return remoteAccessor.apiClient.RequestCostEstimate(task.ID)
+	}
+	return nil, fmt.Errorf("no data accessor available to estimate cost")
+}
+
+// costEstimateFromSummary 从摘要还原估算汇总（Agent 模式下摘要来自 API 响应）
+func costEstimateFromSummary(summary map[string]interface{}) *models.TaskCostEstimate {
+	number := func(key string) float64 {
+		v, _ := costNumber(summary[key])
+		return v
+	}
+	text := func(key string) string {
+		return fmt.Sprint(summary[key])
+	}
+	blocking, _ := summary["blocking"].(bool)
+
+	return &models.TaskCostEstimate{
+		Currency:         text("currency"),
+		MonthlyBefore:    number("monthly_before"),
+		MonthlyAfter:     number("monthly_after"),
+		MonthlyDelta:     number("monthly_delta"),
+		PricedCount:      int(number("priced_count")),
+		FreeCount:        int(number("free_count")),
+		UnsupportedCount: int(number("unsupported_count")),
+		PricingSource:    text("pricing_source"),
+		BudgetStatus:     models.CostBudgetStatus(text("budget_status")),
+		BudgetMessage:    text("budget_message"),
+		EnforcementLevel: models.RunTaskEnforcementLevel(text("enforcement_level")),
+		Blocking:         blocking,
+	}
+}
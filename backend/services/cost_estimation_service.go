@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"iac-platform/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrCostBudgetExceeded mandatory 预算超出，禁止 apply
+var ErrCostBudgetExceeded = errors.New("cost estimate exceeds the workspace budget")
+
+// CostEstimationService 成本估算服务
+type CostEstimationService struct {
+	db *gorm.DB
+}
+
+// NewCostEstimationService 创建成本估算服务
+func NewCostEstimationService(db *gorm.DB) *CostEstimationService {
+	return &CostEstimationService{db: db}
+}
+
+// EstimateTask 估算任务 plan 的成本并保存（替换已有估算）
+// workspace 未启用成本估算时返回 nil, nil
+func (s *CostEstimationService) EstimateTask(ctx context.Context, task *models.WorkspaceTask, planJSON map[string]interface{}) (*models.TaskCostEstimate, error) {
+	var workspace models.Workspace
+	if err := s.db.Select("workspace_id", "cost_estimation_config").
+		Where("workspace_id = ?", task.WorkspaceID).First(&workspace).Error; err != nil {
+		return nil, fmt.Errorf("workspace not found: %w", err)
+	}
+
+	cfg, err := ParseCostEstimationConfig(workspace.CostEstimationConfig)
+	if err != nil {
+		return nil, err
+	}
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	if len(planJSON) == 0 {
+		return nil, fmt.Errorf("plan JSON is not available")
+	}
+
+	priceList, source, err := NewCostPriceSource(LoadCostPricingSourceConfig(s.db))
+	if err != nil {
+		return nil, err
+	}
+	estimator := &CostEstimator{PriceList: priceList, Source: source}
+	estimate, resources, err := estimator.Estimate(ctx, planJSON)
+	if err != nil {
+		return nil, err
+	}
+	EvaluateCostBudget(estimate, cfg)
+
+	estimate.TaskID = task.ID
+	estimate.WorkspaceID = task.WorkspaceID
+	if err := s.SaveEstimate(estimate, resources); err != nil {
+		return nil, err
+	}
+	estimate.Resources = resources
+	return estimate, nil
+}
+
+// EnsureEstimate 返回任务已有的估算，没有时根据已保存的 plan JSON 估算
+// Agent 模式下 plan_json 上传后由服务端调用；workspace 未启用或任务没有 plan JSON 时返回 nil, nil
+func (s *CostEstimationService) EnsureEstimate(ctx context.Context, taskID uint) (*models.TaskCostEstimate, error) {
+	existing, err := s.GetEstimate(taskID)
+	if err != nil || existing != nil {
+		return existing, err
+	}
+
+	var task models.WorkspaceTask
+	if err := s.db.Select("id", "workspace_id", "plan_json").First(&task, taskID).Error; err != nil {
+		return nil, fmt.Errorf("task not found: %w", err)
+	}
+	if len(task.PlanJSON) == 0 {
+		return nil, nil
+	}
+	return s.EstimateTask(ctx, &task, task.PlanJSON)
+}
+
+// SaveEstimate 保存估算汇总和资源明细
+func (s *CostEstimationService) SaveEstimate(estimate *models.TaskCostEstimate, resources []models.TaskCostEstimateResource) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("task_id = ?", estimate.TaskID).Delete(&models.TaskCostEstimateResource{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("task_id = ?", estimate.TaskID).Delete(&models.TaskCostEstimate{}).Error; err != nil {
+			return err
+		}
+
+		estimate.ID = 0
+		if err := tx.Omit("Resources").Create(estimate).Error; err != nil {
+			return fmt.Errorf("failed to save cost estimate: %w", err)
+		}
+		for i := range resources {
+			resources[i].ID = 0
+			resources[i].TaskID = estimate.TaskID
+		}
+		if len(resources) > 0 {
+			if err := tx.CreateInBatches(resources, 200).Error; err != nil {
+				return fmt.Errorf("failed to save cost estimate resources: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// GetEstimate 获取任务的成本估算（含资源明细），不存在时返回 nil, nil
+func (s *CostEstimationService) GetEstimate(taskID uint) (*models.TaskCostEstimate, error) {
+	var estimate models.TaskCostEstimate
+	err := s.db.Preload("Resources", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	}).Where("task_id = ?", taskID).First(&estimate).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &estimate, nil
+}
+
+// CheckApplyAllowed 检查任务的成本估算是否允许 apply
+// 估算为 mandatory 且超出预算时返回 ErrCostBudgetExceeded
+func (s *CostEstimationService) CheckApplyAllowed(taskID uint) error {
+	var estimate models.TaskCostEstimate
+	err := s.db.Select("id", "blocking", "budget_message").Where("task_id = ?", taskID).First(&estimate).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check cost estimate: %w", err)
+	}
+	if estimate.Blocking {
+		return fmt.Errorf("%w: %s", ErrCostBudgetExceeded, estimate.BudgetMessage)
+	}
+	return nil
+}
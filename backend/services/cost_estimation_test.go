@@ -0,0 +1,295 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"iac-platform/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const costTestPlanJSON = `{
+  "configuration": {
+    "provider_config": {
+      "aws": {"name": "aws", "expressions": {"region": {"constant_value": "eu-west-1"}}}
+    }
+  },
+  "resource_changes": [
+    {
+      "address": "module.app.aws_instance.web",
+      "mode": "managed",
+      "type": "aws_instance",
+      "change": {
+        "actions": ["update"],
+        "before": {"instance_type": "t3.micro", "root_block_device": [{"volume_size": 20, "volume_type": "gp3"}]},
+        "after": {"instance_type": "t3.large", "root_block_device": [{"volume_size": 20, "volume_type": "gp3"}]},
+        "after_unknown": {}
+      }
+    },
+    {
+      "address": "aws_nat_gateway.main",
+      "mode": "managed",
+      "type": "aws_nat_gateway",
+      "change": {"actions": ["create"], "before": null, "after": {}, "after_unknown": {"id": true}}
+    },
+    {
+      "address": "aws_ebs_volume.old",
+      "mode": "managed",
+      "type": "aws_ebs_volume",
+      "change": {"actions": ["delete"], "before": {"size": 100, "type": "gp2"}, "after": null}
+    },
+    {
+      "address": "aws_db_instance.db",
+      "mode": "managed",
+      "type": "aws_db_instance",
+      "change": {"actions": ["create"], "before": null, "after": {"allocated_storage": 50}, "after_unknown": {"instance_class": true}}
+    },
+    {
+      "address": "aws_iam_role.app",
+      "mode": "managed",
+      "type": "aws_iam_role",
+      "change": {"actions": ["create"], "before": null, "after": {"name": "app"}}
+    },
+    {
+      "address": "aws_glue_job.etl",
+      "mode": "managed",
+      "type": "aws_glue_job",
+      "change": {"actions": ["create"], "before": null, "after": {"name": "etl"}}
+    },
+    {
+      "address": "data.aws_ami.ubuntu",
+      "mode": "data",
+      "type": "aws_ami",
+      "change": {"actions": ["read"]}
+    }
+  ]
+}`
+
+func loadCostTestPlan(t *testing.T) map[string]interface{} {
+	var plan map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(costTestPlanJSON), &plan))
+	return plan
+}
+
+func TestCostEstimator_PriceList(t *testing.T) {
+	list, err := LoadCostPriceList("")
+	require.NoError(t, err)
+
+	estimator := &CostEstimator{PriceList: list, Source: NewPriceListSource(list)}
+	estimate, resources, err := estimator.Estimate(context.Background(), loadCostTestPlan(t))
+	require.NoError(t, err)
+
+	assert.Equal(t, "eu-west-1", estimate.PricingRegion)
+	assert.Equal(t, CostPricingSourcePriceList, estimate.PricingSource)
+	require.Len(t, resources, 6, "data sources are skipped")
+
+	byAddress := make(map[string]models.TaskCostEstimateResource)
+	for _, r := range resources {
+		byAddress[r.Address] = r
+	}
+
+	// eu-west-1 系数 1.1
+	web := byAddress["module.app.aws_instance.web"]
+	assert.True(t, web.Priced)
+	assert.Equal(t, "update", web.Action)
+	assert.InDelta(t, roundCost((0.0104*730+0.08*20)*1.1), web.MonthlyBefore, 0.001)
+	assert.InDelta(t, roundCost((0.0832*730+0.08*20)*1.1), web.MonthlyAfter, 0.001)
+	assert.Equal(t, "t3.large", web.Components[0].PriceKey)
+
+	nat := byAddress["aws_nat_gateway.main"]
+	assert.Equal(t, 0.0, nat.MonthlyBefore)
+	assert.InDelta(t, roundCost(0.045*730*1.1), nat.MonthlyAfter, 0.001)
+
+	ebs := byAddress["aws_ebs_volume.old"]
+	assert.InDelta(t, roundCost(0.10*100*1.1), ebs.MonthlyBefore, 0.001)
+	assert.InDelta(t, -ebs.MonthlyBefore, ebs.MonthlyDelta, 0.001)
+
+	// instance_class 在 apply 后才知道：实例部分不计价，存储部分照常计价
+	db := byAddress["aws_db_instance.db"]
+	assert.False(t, db.Priced)
+	assert.Contains(t, db.Note, "instance_class known after apply")
+	assert.InDelta(t, roundCost(0.115*50*1.1), db.MonthlyAfter, 0.001)
+
+	assert.True(t, byAddress["aws_iam_role.app"].Priced)
+	assert.False(t, byAddress["aws_glue_job.etl"].Priced)
+
+	assert.Equal(t, 3, estimate.PricedCount)
+	assert.Equal(t, 1, estimate.FreeCount)
+	assert.Equal(t, 2, estimate.UnsupportedCount)
+	assert.InDelta(t, estimate.MonthlyAfter-estimate.MonthlyBefore, estimate.MonthlyDelta, 0.011)
+}
+
+func TestEvaluateCostBudget(t *testing.T) {
+	estimate := &models.TaskCostEstimate{Currency: "USD", MonthlyBefore: 100, MonthlyAfter: 180, MonthlyDelta: 80}
+
+	cfg, err := ParseCostEstimationConfig(map[string]interface{}{"enabled": true})
+	require.NoError(t, err)
+	EvaluateCostBudget(estimate, cfg)
+	assert.Equal(t, models.CostBudgetStatusNone, estimate.BudgetStatus)
+	assert.Equal(t, models.RunTaskEnforcementAdvisory, estimate.EnforcementLevel)
+
+	cfg, err = ParseCostEstimationConfig(map[string]interface{}{"enabled": true, "max_monthly_delta": 50})
+	require.NoError(t, err)
+	EvaluateCostBudget(estimate, cfg)
+	assert.Equal(t, models.CostBudgetStatusExceeded, estimate.BudgetStatus)
+	assert.False(t, estimate.Blocking, "advisory budget does not block")
+	assert.Contains(t, estimate.BudgetMessage, "$80.00")
+
+	cfg, err = ParseCostEstimationConfig(map[string]interface{}{"enabled": true, "max_monthly_delta": 100, "max_monthly_total": 150, "enforcement_level": "mandatory"})
+	require.NoError(t, err)
+	EvaluateCostBudget(estimate, cfg)
+	assert.True(t, estimate.Blocking)
+	assert.Contains(t, estimate.BudgetMessage, "monthly total")
+	assert.NotContains(t, estimate.BudgetMessage, "monthly delta")
+
+	cfg.MaxMonthlyTotal = nil
+	EvaluateCostBudget(estimate, cfg)
+	assert.Equal(t, models.CostBudgetStatusWithin, estimate.BudgetStatus)
+	assert.False(t, estimate.Blocking)
+
+	for _, raw := range []map[string]interface{}{
+		{"enforcement_level": "strict"},
+		{"max_monthly_delta": -1},
+		{"max_monthly_total": "a lot"},
+	} {
+		_, err := ParseCostEstimationConfig(raw)
+		assert.Error(t, err, "%v", raw)
+	}
+}
+
+func TestInfracostPriceSource(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		assert.Equal(t, "test-key", r.Header.Get("X-Api-Key"))
+
+		body, _ := io.ReadAll(r.Body)
+		var req struct {
+			Query     string `json:"query"`
+			Variables struct {
+				Filter struct {
+					Service          string              `json:"service"`
+					Region           string              `json:"region"`
+					AttributeFilters []map[string]string `json:"attributeFilters"`
+				} `json:"filter"`
+			} `json:"variables"`
+		}
+		require.NoError(t, json.Unmarshal(body, &req))
+		assert.Contains(t, req.Query, "products(filter: $filter)")
+
+		instanceType := ""
+		for _, f := range req.Variables.Filter.AttributeFilters {
+			if f["key"] == "instanceType" {
+				instanceType = f["value"]
+			}
+		}
+		switch {
+		case req.Variables.Filter.Service == "AmazonEC2" && instanceType == "t3.large":
+			w.Write([]byte(`{"data":{"products":[{"prices":[{"USD":"0.0900"}]}]}}`))
+		case req.Variables.Filter.Service == "AmazonEKS":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.Write([]byte(`{"data":{"products":[]}}`))
+		}
+	}))
+	defer server.Close()
+
+	list, err := LoadCostPriceList("")
+	require.NoError(t, err)
+	source := NewInfracostPriceSource(server.URL, "test-key", NewPriceListSource(list))
+	instance := list.Resources["aws_instance"][0]
+
+	price, ok, err := source.UnitPrice(context.Background(), instance, "t3.large", "us-east-1")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 0.09, price)
+
+	// 相同查询命中缓存
+	_, _, _ = source.UnitPrice(context.Background(), instance, "t3.large", "us-east-1")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	// 没有产品时回退到价格表
+	price, ok, err = source.UnitPrice(context.Background(), instance, "t3.micro", "us-east-1")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 0.0104, price)
+
+	// 接口出错时回退到价格表
+	price, ok, err = source.UnitPrice(context.Background(), list.Resources["aws_eks_cluster"][0], "", "us-east-1")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 0.10, price)
+
+	_, _, err = NewCostPriceSource(CostPricingSourceConfig{Type: "azure-retail"})
+	assert.Error(t, err)
+	_, _, err = NewCostPriceSource(CostPricingSourceConfig{Type: CostPricingSourcePriceList, PriceListFile: "/nonexistent/prices.json"})
+	assert.Error(t, err)
+}
+
+func TestCostEstimationService_EnsureEstimateAndApplyGate(t *testing.T) {
+	db := setupTestDB(t)
+	for _, stmt := range []string{
+		`ALTER TABLE workspaces ADD COLUMN cost_estimation_config BLOB`,
+		`CREATE TABLE task_cost_estimate_resources (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			task_id INTEGER NOT NULL,
+			address TEXT NOT NULL,
+			resource_type TEXT,
+			action TEXT,
+			monthly_before REAL DEFAULT 0,
+			monthly_after REAL DEFAULT 0,
+			monthly_delta REAL DEFAULT 0,
+			priced INTEGER DEFAULT 0,
+			note TEXT,
+			components BLOB,
+			created_at DATETIME
+		)`,
+	} {
+		require.NoError(t, db.Exec(stmt).Error)
+	}
+
+	budget := `{"enabled": true, "max_monthly_delta": 10, "enforcement_level": "mandatory"}`
+	require.NoError(t, db.Exec(`INSERT INTO workspaces (workspace_id, name, cost_estimation_config) VALUES ('ws-1', 'ws-1', ?)`, []byte(budget)).Error)
+	require.NoError(t, db.Exec(`INSERT INTO workspace_tasks (id, workspace_id, task_type, status, plan_json) VALUES (1, 'ws-1', 'plan_and_apply', 'apply_pending', ?)`,
+		[]byte(costTestPlanJSON)).Error)
+	require.NoError(t, db.Exec(`INSERT INTO workspace_tasks (id, workspace_id, task_type, status) VALUES (2, 'ws-1', 'plan', 'running')`).Error)
+
+	svc := NewCostEstimationService(db)
+	estimate, err := svc.EnsureEstimate(context.Background(), 1)
+	require.NoError(t, err)
+	require.NotNil(t, estimate)
+	assert.Equal(t, models.CostBudgetStatusExceeded, estimate.BudgetStatus)
+	assert.True(t, estimate.Blocking)
+
+	stored, err := svc.GetEstimate(1)
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	assert.Equal(t, estimate.MonthlyDelta, stored.MonthlyDelta)
+	require.Len(t, stored.Resources, 6)
+	assert.Equal(t, "module.app.aws_instance.web", stored.Resources[0].Address)
+	require.Len(t, stored.Resources[0].Components, 2)
+
+	// 已有估算时不重新计算
+	again, err := svc.EnsureEstimate(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, stored.ID, again.ID)
+
+	err = svc.CheckApplyAllowed(1)
+	assert.True(t, errors.Is(err, ErrCostBudgetExceeded))
+	assert.True(t, strings.Contains(err.Error(), "monthly delta"))
+
+	// 没有 plan JSON 的任务没有估算，不阻断
+	estimate, err = svc.EnsureEstimate(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Nil(t, estimate)
+	assert.NoError(t, svc.CheckApplyAllowed(2))
+}
@@ -0,0 +1,396 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"iac-platform/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// 成本估算的价格来源可插拔：
+//   - price_list：平台维护的离线价格表（默认内置 pricing/default_price_list.json，可用文件覆盖）
+//   - infracost：兼容 Infracost Cloud Pricing API 的 GraphQL 服务（可以是自建的替身服务），
+//     查询失败或查不到价格时回退到价格表
+// 资源类型到计费组成项的映射始终来自价格表，infracost 来源只负责提供单价。
+
+//go:embed pricing/default_price_list.json
+var defaultCostPriceListJSON []byte
+
+const (
+	CostPricingSourcePriceList = "price_list"
+	CostPricingSourceInfracost = "infracost"
+
+	costPricingSourceConfigKey = "cost_pricing_source"
+	defaultInfracostEndpoint   = "https://pricing.api.infracost.io/graphql"
+)
+
+// CostPriceList 价格表
+type CostPriceList struct {
+	Version           string                          `json:"version"`
+	Currency          string                          `json:"currency"`
+	DefaultRegion     string                          `json:"default_region"`
+	HoursPerMonth     float64                         `json:"hours_per_month"`
+	RegionMultipliers map[string]float64              `json:"region_multipliers,omitempty"`
+	FreeResources     []string                        `json:"free_resources,omitempty"` // 免费或按用量计费的资源，计为 0
+	Resources         map[string][]CostPriceComponent `json:"resources"`
+
+	free map[string]bool
+}
+
+// CostPriceComponent 资源的一个计费组成项
+type CostPriceComponent struct {
+	Name              string                  `json:"name"`
+	Unit              string                  `json:"unit"`                         // hours, months, GB-months
+	PriceBy           string                  `json:"price_by,omitempty"`           // 决定单价的属性路径，如 instance_type、root_block_device.0.volume_type
+	Default           string                  `json:"default,omitempty"`            // 属性未设置时使用的值
+	Price             *float64                `json:"price,omitempty"`              // 固定单价（无 price_by 时）
+	Prices            map[string]float64      `json:"prices,omitempty"`             // 按属性值定价
+	QuantityAttribute string                  `json:"quantity_attribute,omitempty"` // 数量属性路径，如 size、num_cache_nodes
+	DefaultQuantity   *float64                `json:"default_quantity,omitempty"`
+	Infracost         *InfracostProductFilter `json:"infracost,omitempty"`
+}
+
+// InfracostProductFilter Infracost products 查询条件
+type InfracostProductFilter struct {
+	Service          string            `json:"service"`
+	ProductFamily    string            `json:"product_family"`
+	Attribute        string            `json:"attribute,omitempty"` // price_by 属性值对应的产品属性，如 instanceType
+	AttributeFilters map[string]string `json:"attribute_filters,omitempty"`
+	PurchaseOption   string            `json:"purchase_option,omitempty"`
+}
+
+// ParseCostPriceList 解析并校验价格表
+func ParseCostPriceList(data []byte) (*CostPriceList, error) {
+	list := &CostPriceList{}
+	if err := json.Unmarshal(data, list); err != nil {
+		return nil, fmt.Errorf("invalid price list: %w", err)
+	}
+	if list.Currency == "" {
+		list.Currency = "USD"
+	}
+	if list.HoursPerMonth <= 0 {
+		list.HoursPerMonth = 730
+	}
+	if list.DefaultRegion == "" {
+		list.DefaultRegion = "us-east-1"
+	}
+
+	for resourceType, components := range list.Resources {
+		for _, c := range components {
+			switch c.Unit {
+			case "hours", "months", "GB-months":
+			default:
+				return nil, fmt.Errorf("price list %s/%s: invalid unit %q", resourceType, c.Name, c.Unit)
+			}
+			if c.PriceBy == "" && c.Price == nil {
+				return nil, fmt.Errorf("price list %s/%s: either price or price_by is required", resourceType, c.Name)
+			}
+		}
+	}
+
+	list.free = make(map[string]bool, len(list.FreeResources))
+	for _, t := range list.FreeResources {
+		list.free[t] = true
+	}
+	return list, nil
+}
+
+// LoadCostPriceList 加载价格表，path 为空时使用内置价格表
+func LoadCostPriceList(path string) (*CostPriceList, error) {
+	if path == "" {
+		return ParseCostPriceList(defaultCostPriceListJSON)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read price list %s: %w", path, err)
+	}
+	return ParseCostPriceList(data)
+}
+
+// IsFree 资源类型是否计为免费
+func (l *CostPriceList) IsFree(resourceType string) bool {
+	return l.free[resourceType]
+}
+
+// RegionMultiplier 返回区域价格系数，未配置的区域按 1 计算
+func (l *CostPriceList) RegionMultiplier(region string) float64 {
+	if m, ok := l.RegionMultipliers[region]; ok && m > 0 {
+		return m
+	}
+	return 1
+}
+
+// CostPriceSource 单价来源
+type CostPriceSource interface {
+	// Name 来源名称，记录在估算结果中
+	Name() string
+	// UnitPrice 返回组成项在指定区域的单价，ok=false 表示没有价格
+	UnitPrice(ctx context.Context, component CostPriceComponent, priceKey, region string) (price float64, ok bool, err error)
+}
+
+// priceListSource 离线价格表来源
+type priceListSource struct {
+	list *CostPriceList
+}
+
+// NewPriceListSource 创建离线价格表来源
+func NewPriceListSource(list *CostPriceList) CostPriceSource {
+	return &priceListSource{list: list}
+}
+
+func (s *priceListSource) Name() string {
+	return CostPricingSourcePriceList
+}
+
+func (s *priceListSource) UnitPrice(ctx context.Context, component CostPriceComponent, priceKey, region string) (float64, bool, error) {
+	var price float64
+	if component.PriceBy == "" {
+		price = *component.Price
+	} else {
+		p, ok := component.Prices[priceKey]
+		if !ok {
+			return 0, false, nil
+		}
+		price = p
+	}
+	return price * s.list.RegionMultiplier(region), true, nil
+}
+
+// infracostPriceSource Infracost 兼容的 GraphQL 价格来源
+type infracostPriceSource struct {
+	endpoint string
+	apiKey   string
+	client   *http.Client
+	fallback CostPriceSource
+
+	mu    sync.Mutex
+	cache map[string]infracostCachedPrice
+}
+
+type infracostCachedPrice struct {
+	price float64
+	ok    bool
+}
+
+// NewInfracostPriceSource 创建 Infracost 兼容的价格来源，fallback 用于没有查询条件或查询失败的组成项
+func NewInfracostPriceSource(endpoint, apiKey string, fallback CostPriceSource) CostPriceSource {
+	if endpoint == "" {
+		endpoint = defaultInfracostEndpoint
+	}
+	return &infracostPriceSource{
+		endpoint: endpoint,
+		apiKey:   apiKey,
+		client:   &http.Client{Timeout: 15 * time.Second},
+		fallback: fallback,
+		cache:    make(map[string]infracostCachedPrice),
+	}
+}
+
+func (s *infracostPriceSource) Name() string {
+	return CostPricingSourceInfracost
+}
+
+const infracostPriceQuery = `query($filter: ProductFilter!, $priceFilter: PriceFilter) {
+  products(filter: $filter) {
+    prices(filter: $priceFilter) {
+      USD
+    }
+  }
+}`
+
+func (s *infracostPriceSource) UnitPrice(ctx context.Context, component CostPriceComponent, priceKey, region string) (float64, bool, error) {
+	f := component.Infracost
+	if f == nil || (f.Attribute != "" && priceKey == "") {
+		return s.fallback.UnitPrice(ctx, component, priceKey, region)
+	}
+
+	attributeFilters := make([]map[string]string, 0, len(f.AttributeFilters)+1)
+	keys := make([]string, 0, len(f.AttributeFilters))
+	for k := range f.AttributeFilters {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		attributeFilters = append(attributeFilters, map[string]string{"key": k, "value": f.AttributeFilters[k]})
+	}
+	if f.Attribute != "" {
+		attributeFilters = append(attributeFilters, map[string]string{"key": f.Attribute, "value": priceKey})
+	}
+
+	filter := map[string]interface{}{
+		"vendorName":       "aws",
+		"service":          f.Service,
+		"productFamily":    f.ProductFamily,
+		"region":           region,
+		"attributeFilters": attributeFilters,
+	}
+	purchaseOption := f.PurchaseOption
+	if purchaseOption == "" {
+		purchaseOption = "on_demand"
+	}
+	variables := map[string]interface{}{
+		"filter":      filter,
+		"priceFilter": map[string]string{"purchaseOption": purchaseOption},
+	}
+
+	cacheKeyBytes, _ := json.Marshal(variables)
+	cacheKey := string(cacheKeyBytes)
+	s.mu.Lock()
+	cached, hit := s.cache[cacheKey]
+	s.mu.Unlock()
+	if hit {
+		if !cached.ok {
+			return s.fallback.UnitPrice(ctx, component, priceKey, region)
+		}
+		return cached.price, true, nil
+	}
+
+	price, ok, err := s.query(ctx, variables)
+	if err != nil {
+		log.Printf("[CostEstimation] Infracost price lookup failed for %s/%s (%s), using price list: %v", f.Service, f.ProductFamily, priceKey, err)
+		return s.fallback.UnitPrice(ctx, component, priceKey, region)
+	}
+
+	s.mu.Lock()
+	s.cache[cacheKey] = infracostCachedPrice{price: price, ok: ok}
+	s.mu.Unlock()
+
+	if !ok {
+		return s.fallback.UnitPrice(ctx, component, priceKey, region)
+	}
+	return price, true, nil
+}
+
+// query 执行 GraphQL 查询，返回第一个产品的第一个价格
+func (s *infracostPriceSource) query(ctx context.Context, variables map[string]interface{}) (float64, bool, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"query":     infracostPriceQuery,
+		"variables": variables,
+	})
+	if err != nil {
+		return 0, false, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return 0, false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.apiKey != "" {
+		req.Header.Set("X-Api-Key", s.apiKey)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, false, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return 0, false, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, false, fmt.Errorf("pricing API returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	var result struct {
+		Data struct {
+			Products []struct {
+				Prices []struct {
+					USD string `json:"USD"`
+				} `json:"prices"`
+			} `json:"products"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return 0, false, fmt.Errorf("invalid pricing API response: %w", err)
+	}
+	if len(result.Errors) > 0 {
+		return 0, false, fmt.Errorf("pricing API error: %s", result.Errors[0].Message)
+	}
+
+	for _, product := range result.Data.Products {
+		for _, p := range product.Prices {
+			price, err := strconv.ParseFloat(p.USD, 64)
+			if err != nil {
+				continue
+			}
+			return price, true, nil
+		}
+	}
+	return 0, false, nil
+}
+
+// CostPricingSourceConfig 价格来源配置（system_configs.cost_pricing_source）
+type CostPricingSourceConfig struct {
+	Type          string `json:"type"`                      // price_list（默认）或 infracost
+	PriceListFile string `json:"price_list_file,omitempty"` // 覆盖内置价格表
+	Endpoint      string `json:"endpoint,omitempty"`        // infracost GraphQL 地址
+	APIKey        string `json:"api_key,omitempty"`
+}
+
+// LoadCostPricingSourceConfig 读取价格来源配置，数据库优先，环境变量兜底
+func LoadCostPricingSourceConfig(db *gorm.DB) CostPricingSourceConfig {
+	cfg := CostPricingSourceConfig{}
+	if db != nil {
+		var sc models.SystemConfig
+		if err := db.Where("key = ?", costPricingSourceConfigKey).First(&sc).Error; err == nil {
+			if err := json.Unmarshal([]byte(sc.Value), &cfg); err != nil {
+				log.Printf("[CostEstimation] Invalid %s config: %v", costPricingSourceConfigKey, err)
+			}
+		}
+	}
+
+	if cfg.Type == "" {
+		cfg.Type = os.Getenv("COST_PRICING_SOURCE")
+	}
+	if cfg.Type == "" {
+		cfg.Type = CostPricingSourcePriceList
+	}
+	if cfg.PriceListFile == "" {
+		cfg.PriceListFile = os.Getenv("COST_PRICE_LIST_FILE")
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = os.Getenv("INFRACOST_PRICING_API_ENDPOINT")
+	}
+	if cfg.APIKey == "" {
+		cfg.APIKey = os.Getenv("INFRACOST_API_KEY")
+	}
+	return cfg
+}
+
+// NewCostPriceSource 根据配置创建价格表和价格来源
+func NewCostPriceSource(cfg CostPricingSourceConfig) (*CostPriceList, CostPriceSource, error) {
+	list, err := LoadCostPriceList(cfg.PriceListFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	fallback := NewPriceListSource(list)
+
+	switch cfg.Type {
+	case CostPricingSourcePriceList:
+		return list, fallback, nil
+	case CostPricingSourceInfracost:
+		return list, NewInfracostPriceSource(cfg.Endpoint, cfg.APIKey, fallback), nil
+	default:
+		return nil, nil, fmt.Errorf("unknown cost pricing source: %q", cfg.Type)
+	}
+}
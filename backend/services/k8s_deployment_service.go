@@ -421,7 +421,10 @@ func (s *K8sDeploymentService) UpdateDeploymentConfig(ctx context.Context, pool
 	}
 
 	// Build new deployment spec with current replica count
-	newDeployment := s.buildDeployment(existingDeployment.Name, existingDeployment.Namespace, pool, &k8sConfig, currentReplicas, secretName)
+	newDeployment, err := s.buildDeployment(existingDeployment.Name, existingDeployment.Namespace, pool, &k8sConfig, currentReplicas, secretName)
+	if err != nil {
+		return err
+	}
 
 	// Preserve the existing deployment's metadata (like UID, ResourceVersion)
 	newDeployment.ObjectMeta.ResourceVersion = existingDeployment.ObjectMeta.ResourceVersion
@@ -577,7 +580,7 @@ func (s *K8sDeploymentService) DeleteDeployment(ctx context.Context, poolID stri
 }
 
 // buildDeployment constructs a K8s Deployment object
-func (s *K8sDeploymentService) buildDeployment(deploymentName, namespace string, pool *models.AgentPool, config *models.K8sJobTemplateConfig, replicas int32, secretName string) *appsv1.Deployment {
+func (s *K8sDeploymentService) buildDeployment(deploymentName, namespace string, pool *models.AgentPool, config *models.K8sJobTemplateConfig, replicas int32, secretName string) (*appsv1.Deployment, error) {
 	// Image pull policy
 	imagePullPolicy := corev1.PullIfNotPresent
 	if config.ImagePullPolicy != "" {
@@ -653,7 +656,7 @@ func (s *K8sDeploymentService) buildDeployment(deploymentName, namespace string,
 
 	// Build container
 	container := corev1.Container{
-		Name:            "agent",
+		Name:            agentContainerName,
 		Image:           config.Image,
 		ImagePullPolicy: imagePullPolicy,
 		Env:             envVars,
@@ -705,7 +708,12 @@ func (s *K8sDeploymentService) buildDeployment(deploymentName, namespace string,
 		},
 	}
 
-	return deployment
+	template := &deployment.Spec.Template
+	if err := applyPodTemplate(&template.ObjectMeta, &template.Spec, container.Name, config); err != nil {
+		return nil, fmt.Errorf("invalid pod template for pool %s: %w", pool.PoolID, err)
+	}
+
+	return deployment, nil
 }
 
 // CountPendingTasksForPool counts the number of active tasks for a specific pool
@@ -1073,8 +1081,9 @@ performScaling:
 		return currentPodCount, false, nil
 	}
 
-	// 6.5. Additional protection: check for apply_pending tasks before scale-down
+	// 6.5. Additional protection: check for apply_pending / state_pending_upload tasks before scale-down
 	// This prevents deleting Pods during server restart before slots are properly synced
+	// (state_pending_upload tasks keep the only copy of the state in the Pod's local spool)
 	if desiredPodCount < currentPodCount {
 		var applyPendingCount int64
 		err := s.db.WithContext(ctx).
@@ -1082,13 +1091,13 @@ performScaling:
 			Joins("JOIN workspaces ON workspaces.workspace_id = workspace_tasks.workspace_id").
 			Where("workspaces.current_pool_id = ?", pool.PoolID).
 			Where("workspaces.execution_mode = ?", models.ExecutionModeK8s).
-			Where("workspace_tasks.status = ?", models.TaskStatusApplyPending).
+			Where("workspace_tasks.status IN ?", []models.TaskStatus{models.TaskStatusApplyPending, models.TaskStatusStatePendingUpload}).
 			Count(&applyPendingCount).Error
 
 		if err != nil {
 			log.Printf("[K8sPodService] Warning: failed to check apply_pending tasks: %v", err)
 		} else if applyPendingCount > 0 {
-			log.Printf("[K8sPodService] Pool %s has %d apply_pending tasks, skipping scale-down to protect reserved slots",
+			log.Printf("[K8sPodService] Pool %s has %d apply_pending/state_pending_upload tasks, skipping scale-down to protect reserved slots",
 				pool.PoolID, applyPendingCount)
 			return currentPodCount, false, nil
 		}
@@ -131,7 +131,11 @@ func (s *K8sJobService) CreateJobForTask(ctx context.Context, task *models.Works
 	}
 
 	// 7. Create K8s Job
-	job := s.buildJob(jobName, namespace, task, &k8sConfig, token, pool.PoolID)
+	job, err := s.buildJob(jobName, namespace, task, &k8sConfig, token, pool.PoolID)
+	if err != nil {
+		s.db.Delete(poolToken)
+		return err
+	}
 
 	_, err = s.clientset.BatchV1().Jobs(namespace).Create(ctx, job, metav1.CreateOptions{})
 	if err != nil {
@@ -157,7 +161,7 @@ func (s *K8sJobService) CreateJobForTask(ctx context.Context, task *models.Works
 }
 
 // buildJob constructs a K8s Job object
-func (s *K8sJobService) buildJob(jobName, namespace string, task *models.WorkspaceTask, config *models.K8sJobTemplateConfig, token, poolID string) *batchv1.Job {
+func (s *K8sJobService) buildJob(jobName, namespace string, task *models.WorkspaceTask, config *models.K8sJobTemplateConfig, token, poolID string) (*batchv1.Job, error) {
 	// Default values
 	backoffLimit := int32(3)
 	if config.BackoffLimit != nil {
@@ -219,7 +223,7 @@ func (s *K8sJobService) buildJob(jobName, namespace string, task *models.Workspa
 
 	// Build container
 	container := corev1.Container{
-		Name:            "terraform-agent",
+		Name:            agentContainerName,
 		Image:           config.Image,
 		ImagePullPolicy: imagePullPolicy,
 		Env:             envVars,
@@ -267,7 +271,12 @@ func (s *K8sJobService) buildJob(jobName, namespace string, task *models.Workspa
 		},
 	}
 
-	return job
+	template := &job.Spec.Template
+	if err := applyPodTemplate(&template.ObjectMeta, &template.Spec, container.Name, config); err != nil {
+		return nil, fmt.Errorf("invalid pod template for pool %s: %w", poolID, err)
+	}
+
+	return job, nil
 }
 
 // generateToken generates a random token and its hash
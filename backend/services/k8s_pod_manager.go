@@ -85,7 +85,10 @@ func (m *K8sPodManager) CreatePod(ctx context.Context, poolID string, config *mo
 	namespace := "terraform"
 
 	// 构建Pod spec
-	pod := m.buildPodSpec(podName, namespace, poolID, config, secretName)
+	pod, err := m.buildPodSpec(podName, namespace, poolID, config, secretName)
+	if err != nil {
+		return nil, err
+	}
 
 	// 创建Pod
 	createdPod, err := m.clientset.CoreV1().Pods(namespace).Create(ctx, pod, metav1.CreateOptions{})
@@ -701,7 +704,7 @@ func (m *K8sPodManager) UpdateHeartbeat(podName string) error {
 // ============================================================================
 
 // buildPodSpec 构建Pod规格
-func (m *K8sPodManager) buildPodSpec(podName, namespace, poolID string, config *models.K8sJobTemplateConfig, secretName string) *corev1.Pod {
+func (m *K8sPodManager) buildPodSpec(podName, namespace, poolID string, config *models.K8sJobTemplateConfig, secretName string) (*corev1.Pod, error) {
 	// Image pull policy
 	imagePullPolicy := corev1.PullIfNotPresent
 	if config.ImagePullPolicy != "" {
@@ -813,7 +816,7 @@ func (m *K8sPodManager) buildPodSpec(podName, namespace, poolID string, config *
 
 	// Build container
 	container := corev1.Container{
-		Name:            "agent",
+		Name:            agentContainerName,
 		Image:           config.Image,
 		ImagePullPolicy: imagePullPolicy,
 		Env:             envVars,
@@ -874,7 +877,12 @@ func (m *K8sPodManager) buildPodSpec(podName, namespace, poolID string, config *
 		},
 	}
 
-	return pod
+	// 应用Pod模板定制（节点选择、容忍、ServiceAccount、卷、安全上下文、覆盖文档等）
+	if err := applyPodTemplate(&pod.ObjectMeta, &pod.Spec, container.Name, config); err != nil {
+		return nil, fmt.Errorf("invalid pod template for pool %s: %w", poolID, err)
+	}
+
+	return pod, nil
 }
 
 // ============================================================================
@@ -0,0 +1,250 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"iac-platform/internal/models"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// reservedPodLabels 平台用于选择和识别 agent Pod 的标签，不允许通过模板修改
+var reservedPodLabels = []string{"app", "component", "pool-id", "pool-name", "workspace-id", "task-id"}
+
+// reservedVolumeNames 平台自动挂载的卷
+var reservedVolumeNames = []string{"internal-ca"}
+
+// agentContainerName Pod、Deployment 和 Job 中 agent 容器的名称，pod_overrides 按此名称合并到 agent 容器
+const agentContainerName = "agent"
+
+// ValidateK8sPodTemplate 校验 agent pool 的 Pod 模板定制项，在保存配置时调用，
+// 避免无效模板导致空闲 Pod 重建失败
+func ValidateK8sPodTemplate(config *models.K8sJobTemplateConfig) error {
+	for key, value := range config.NodeSelector {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("node_selector key %q: %s", key, strings.Join(errs, "; "))
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return fmt.Errorf("node_selector value %q: %s", value, strings.Join(errs, "; "))
+		}
+	}
+	for key, value := range config.PodLabels {
+		if isReservedPodLabel(key) {
+			return fmt.Errorf("pod label %q is reserved", key)
+		}
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("pod label key %q: %s", key, strings.Join(errs, "; "))
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return fmt.Errorf("pod label value %q: %s", value, strings.Join(errs, "; "))
+		}
+	}
+	for key := range config.PodAnnotations {
+		if errs := validation.IsQualifiedName(strings.ToLower(key)); len(errs) > 0 {
+			return fmt.Errorf("pod annotation key %q: %s", key, strings.Join(errs, "; "))
+		}
+	}
+	for _, toleration := range config.Tolerations {
+		switch toleration.Operator {
+		case "", corev1.TolerationOpEqual:
+		case corev1.TolerationOpExists:
+			if toleration.Value != "" {
+				return fmt.Errorf("toleration %q: value must be empty when operator is Exists", toleration.Key)
+			}
+		default:
+			return fmt.Errorf("toleration %q: unsupported operator %q", toleration.Key, toleration.Operator)
+		}
+		switch toleration.Effect {
+		case "", corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute:
+		default:
+			return fmt.Errorf("toleration %q: unsupported effect %q", toleration.Key, toleration.Effect)
+		}
+	}
+
+	if config.ServiceAccountName != "" {
+		if errs := validation.IsDNS1123Subdomain(config.ServiceAccountName); len(errs) > 0 {
+			return fmt.Errorf("service_account_name: %s", strings.Join(errs, "; "))
+		}
+	}
+	if config.PriorityClassName != "" {
+		if errs := validation.IsDNS1123Subdomain(config.PriorityClassName); len(errs) > 0 {
+			return fmt.Errorf("priority_class_name: %s", strings.Join(errs, "; "))
+		}
+	}
+	for _, name := range config.ImagePullSecrets {
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+			return fmt.Errorf("image pull secret %q: %s", name, strings.Join(errs, "; "))
+		}
+	}
+
+	volumes := make(map[string]bool, len(config.Volumes))
+	for _, volume := range config.Volumes {
+		if errs := validation.IsDNS1123Label(volume.Name); len(errs) > 0 {
+			return fmt.Errorf("volume %q: %s", volume.Name, strings.Join(errs, "; "))
+		}
+		if isReservedVolume(volume.Name) {
+			return fmt.Errorf("volume name %q is reserved", volume.Name)
+		}
+		if volumes[volume.Name] {
+			return fmt.Errorf("duplicate volume %q", volume.Name)
+		}
+		volumes[volume.Name] = true
+	}
+	for _, mount := range config.VolumeMounts {
+		if !volumes[mount.Name] {
+			return fmt.Errorf("volume mount %q does not reference a configured volume", mount.Name)
+		}
+		if !path.IsAbs(mount.MountPath) {
+			return fmt.Errorf("volume mount %q: mountPath must be absolute", mount.Name)
+		}
+	}
+
+	// 在示例 Pod 上试应用，确保覆盖文档能解析并且不破坏平台依赖的字段
+	sample := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "iac-agent-validate",
+			Namespace: "terraform",
+			Labels:    map[string]string{"app": "iac-platform", "component": "agent", "pool-id": "validate"},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: agentContainerName, Image: config.Image}},
+		},
+	}
+	return applyPodTemplate(&sample.ObjectMeta, &sample.Spec, agentContainerName, config)
+}
+
+// applyPodTemplate 将 Pod 模板定制项应用到平台构建的 Pod 上，最后应用 pod_overrides（strategic merge patch）
+// containerName 为 agent 容器名称，volume mount 和容器安全上下文只作用于该容器
+func applyPodTemplate(meta *metav1.ObjectMeta, spec *corev1.PodSpec, containerName string, config *models.K8sJobTemplateConfig) error {
+	for key, value := range config.PodLabels {
+		if isReservedPodLabel(key) {
+			continue
+		}
+		if meta.Labels == nil {
+			meta.Labels = map[string]string{}
+		}
+		meta.Labels[key] = value
+	}
+	for key, value := range config.PodAnnotations {
+		if meta.Annotations == nil {
+			meta.Annotations = map[string]string{}
+		}
+		meta.Annotations[key] = value
+	}
+
+	if len(config.NodeSelector) > 0 {
+		spec.NodeSelector = config.NodeSelector
+	}
+	if len(config.Tolerations) > 0 {
+		spec.Tolerations = append(spec.Tolerations, config.Tolerations...)
+	}
+	if config.Affinity != nil {
+		spec.Affinity = config.Affinity
+	}
+	if config.ServiceAccountName != "" {
+		spec.ServiceAccountName = config.ServiceAccountName
+	}
+	if config.AutomountServiceAccountToken != nil {
+		spec.AutomountServiceAccountToken = config.AutomountServiceAccountToken
+	}
+	if config.PodSecurityContext != nil {
+		spec.SecurityContext = config.PodSecurityContext
+	}
+	if config.PriorityClassName != "" {
+		spec.PriorityClassName = config.PriorityClassName
+	}
+	for _, name := range config.ImagePullSecrets {
+		spec.ImagePullSecrets = append(spec.ImagePullSecrets, corev1.LocalObjectReference{Name: name})
+	}
+	spec.Volumes = append(spec.Volumes, config.Volumes...)
+
+	for i := range spec.Containers {
+		if spec.Containers[i].Name != containerName {
+			continue
+		}
+		spec.Containers[i].VolumeMounts = append(spec.Containers[i].VolumeMounts, config.VolumeMounts...)
+		if config.SecurityContext != nil {
+			spec.Containers[i].SecurityContext = config.SecurityContext
+		}
+	}
+
+	if len(config.PodOverrides) == 0 || string(config.PodOverrides) == "null" {
+		return nil
+	}
+	return applyPodOverrides(meta, spec, containerName, config.PodOverrides)
+}
+
+// applyPodOverrides 以 strategic merge patch 的方式应用覆盖文档（与 kubectl patch 的语义一致，
+// 容器按 name 合并），覆盖后 Pod 名称、命名空间、保留标签和 agent 容器必须保持不变
+func applyPodOverrides(meta *metav1.ObjectMeta, spec *corev1.PodSpec, containerName string, overrides json.RawMessage) error {
+	var patch map[string]interface{}
+	if err := json.Unmarshal(overrides, &patch); err != nil {
+		return fmt.Errorf("pod_overrides must be a JSON object: %w", err)
+	}
+
+	original, err := json.Marshal(&corev1.Pod{ObjectMeta: *meta, Spec: *spec})
+	if err != nil {
+		return err
+	}
+	patched, err := strategicpatch.StrategicMergePatch(original, overrides, corev1.Pod{})
+	if err != nil {
+		return fmt.Errorf("failed to apply pod_overrides: %w", err)
+	}
+
+	var result corev1.Pod
+	decoder := json.NewDecoder(strings.NewReader(string(patched)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&result); err != nil {
+		return fmt.Errorf("pod_overrides produced an invalid pod: %w", err)
+	}
+
+	if result.Name != meta.Name || result.Namespace != meta.Namespace {
+		return fmt.Errorf("pod_overrides must not change the pod name or namespace")
+	}
+	for _, key := range reservedPodLabels {
+		if result.Labels[key] != meta.Labels[key] {
+			return fmt.Errorf("pod_overrides must not change reserved label %q", key)
+		}
+	}
+	found := false
+	for _, container := range result.Spec.Containers {
+		if container.Name == containerName {
+			found = true
+		}
+		// 名称与已有容器不匹配时 strategic merge 会新增容器，没有镜像说明写错了容器名
+		if container.Name != containerName && container.Image == "" {
+			return fmt.Errorf("pod_overrides container %q has no image (the agent container is named %q)", container.Name, containerName)
+		}
+	}
+	if !found {
+		return fmt.Errorf("pod_overrides must keep the %q container", containerName)
+	}
+
+	*meta = result.ObjectMeta
+	*spec = result.Spec
+	return nil
+}
+
+func isReservedPodLabel(key string) bool {
+	for _, reserved := range reservedPodLabels {
+		if key == reserved {
+			return true
+		}
+	}
+	return false
+}
+
+func isReservedVolume(name string) bool {
+	for _, reserved := range reservedVolumeNames {
+		if name == reserved {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+
+	"iac-platform/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestBuildPodSpec_PodTemplate(t *testing.T) {
+	automount := false
+	nonRoot := true
+	config := &models.K8sJobTemplateConfig{
+		Image:        "iac-agent:1.0",
+		NodeSelector: map[string]string{"node-pool": "iac"},
+		Tolerations: []corev1.Toleration{
+			{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "iac", Effect: corev1.TaintEffectNoSchedule},
+		},
+		ServiceAccountName:           "iac-agent",
+		AutomountServiceAccountToken: &automount,
+		Volumes: []corev1.Volume{
+			{Name: "ca-bundle", VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "corp-ca"},
+			}}},
+			{Name: "plugin-cache", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: "ca-bundle", MountPath: "/etc/ssl/corp", ReadOnly: true},
+			{Name: "plugin-cache", MountPath: "/root/.terraform.d/plugin-cache"},
+		},
+		PodSecurityContext: &corev1.PodSecurityContext{RunAsNonRoot: &nonRoot},
+		SecurityContext:    &corev1.SecurityContext{AllowPrivilegeEscalation: &automount},
+		PodAnnotations:     map[string]string{"eks.amazonaws.com/role-arn": "arn:aws:iam::111111111111:role/iac"},
+		PodLabels:          map[string]string{"team": "platform", "app": "hijack"},
+		PriorityClassName:  "iac-high",
+		ImagePullSecrets:   []string{"registry-creds"},
+		PodOverrides: json.RawMessage(`{
+			"spec": {
+				"dnsPolicy": "None",
+				"dnsConfig": {"nameservers": ["10.0.0.10"]},
+				"containers": [{"name": "agent", "resources": {"limits": {"ephemeral-storage": "2Gi"}}}]
+			}
+		}`),
+	}
+	require.NoError(t, ValidateK8sPodTemplate(&models.K8sJobTemplateConfig{
+		Image: config.Image, Volumes: config.Volumes, VolumeMounts: config.VolumeMounts,
+		Tolerations: config.Tolerations, PodOverrides: config.PodOverrides,
+	}))
+
+	m := &K8sPodManager{}
+	pod, err := m.buildPodSpec("iac-agent-pool-1-1", "terraform", "pool-1", config, "iac-agent-token-pool-1")
+	require.NoError(t, err)
+
+	assert.Equal(t, "iac-platform", pod.Labels["app"], "reserved labels cannot be overridden")
+	assert.Equal(t, "platform", pod.Labels["team"])
+	assert.Equal(t, "arn:aws:iam::111111111111:role/iac", pod.Annotations["eks.amazonaws.com/role-arn"])
+	assert.Equal(t, map[string]string{"node-pool": "iac"}, pod.Spec.NodeSelector)
+	assert.Equal(t, config.Tolerations, pod.Spec.Tolerations)
+	assert.Equal(t, "iac-agent", pod.Spec.ServiceAccountName)
+	assert.Equal(t, &automount, pod.Spec.AutomountServiceAccountToken)
+	assert.Equal(t, "iac-high", pod.Spec.PriorityClassName)
+	assert.Equal(t, []corev1.LocalObjectReference{{Name: "registry-creds"}}, pod.Spec.ImagePullSecrets)
+	assert.Len(t, pod.Spec.Volumes, 2)
+	require.NotNil(t, pod.Spec.SecurityContext)
+	assert.True(t, *pod.Spec.SecurityContext.RunAsNonRoot)
+	assert.Equal(t, corev1.DNSNone, pod.Spec.DNSPolicy)
+
+	require.Len(t, pod.Spec.Containers, 1)
+	agent := pod.Spec.Containers[0]
+	assert.Equal(t, "iac-agent:1.0", agent.Image, "overrides merge into the agent container by name")
+	assert.Len(t, agent.VolumeMounts, 2)
+	assert.NotNil(t, agent.SecurityContext)
+	assert.Equal(t, "2Gi", agent.Resources.Limits.StorageEphemeral().String())
+	assert.NotEmpty(t, agent.Env, "platform env vars are kept")
+}
+
+func TestValidateK8sPodTemplate(t *testing.T) {
+	tests := []struct {
+		name   string
+		config models.K8sJobTemplateConfig
+		errMsg string
+	}{
+		{"empty", models.K8sJobTemplateConfig{Image: "iac-agent"}, ""},
+		{"reserved label", models.K8sJobTemplateConfig{PodLabels: map[string]string{"pool-id": "other"}}, "reserved"},
+		{"invalid node selector", models.K8sJobTemplateConfig{NodeSelector: map[string]string{"bad key!": "x"}}, "node_selector"},
+		{"invalid toleration", models.K8sJobTemplateConfig{Tolerations: []corev1.Toleration{{Key: "a", Operator: "Exists", Value: "b"}}}, "Exists"},
+		{"invalid service account", models.K8sJobTemplateConfig{ServiceAccountName: "IAC_Agent"}, "service_account_name"},
+		{"mount without volume", models.K8sJobTemplateConfig{VolumeMounts: []corev1.VolumeMount{{Name: "ca", MountPath: "/etc/ca"}}}, "does not reference"},
+		{"reserved volume", models.K8sJobTemplateConfig{Volumes: []corev1.Volume{{Name: "internal-ca"}}}, "reserved"},
+		{"relative mount path", models.K8sJobTemplateConfig{
+			Volumes:      []corev1.Volume{{Name: "ca"}},
+			VolumeMounts: []corev1.VolumeMount{{Name: "ca", MountPath: "etc/ca"}},
+		}, "absolute"},
+		{"overrides not an object", models.K8sJobTemplateConfig{PodOverrides: json.RawMessage(`["spec"]`)}, "JSON object"},
+		{"overrides unknown field", models.K8sJobTemplateConfig{PodOverrides: json.RawMessage(`{"spec": {"nodeSelectr": {"a": "b"}}}`)}, "invalid pod"},
+		{"overrides rename pod", models.K8sJobTemplateConfig{PodOverrides: json.RawMessage(`{"metadata": {"name": "other"}}`)}, "name or namespace"},
+		{"overrides change selector label", models.K8sJobTemplateConfig{PodOverrides: json.RawMessage(`{"metadata": {"labels": {"component": null}}}`)}, "reserved label"},
+		{"overrides remove agent container", models.K8sJobTemplateConfig{PodOverrides: json.RawMessage(
+			`{"spec": {"containers": [{"name": "agent", "$patch": "delete"}]}}`)}, "container"},
+		{"overrides add sidecar", models.K8sJobTemplateConfig{PodOverrides: json.RawMessage(
+			`{"spec": {"containers": [{"name": "proxy", "image": "envoy"}]}}`)}, ""},
+		{"overrides wrong container name", models.K8sJobTemplateConfig{Image: "iac-agent", PodOverrides: json.RawMessage(
+			`{"spec": {"containers": [{"name": "terraform-agent", "resources": {"limits": {"memory": "1Gi"}}}]}}`)}, "no image"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateK8sPodTemplate(&tt.config)
+			if tt.errMsg == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestBuildJob_AppliesValidatedPodTemplate(t *testing.T) {
+	config := &models.K8sJobTemplateConfig{
+		Image:        "iac-agent:1.0",
+		PodLabels:    map[string]string{"team": "platform"},
+		PodOverrides: json.RawMessage(`{"spec": {"containers": [{"name": "agent", "resources": {"limits": {"ephemeral-storage": "2Gi"}}}]}}`),
+	}
+	require.NoError(t, ValidateK8sPodTemplate(config))
+
+	s := &K8sJobService{}
+	job, err := s.buildJob("iac-job-1", "terraform", &models.WorkspaceTask{ID: 1, WorkspaceID: "ws-1"}, config, "token", "pool-1")
+	require.NoError(t, err)
+
+	spec := job.Spec.Template.Spec
+	require.Len(t, spec.Containers, 1, "overrides merge into the job's agent container instead of adding one")
+	assert.Equal(t, "iac-agent:1.0", spec.Containers[0].Image)
+	assert.Equal(t, "2Gi", spec.Containers[0].Resources.Limits.StorageEphemeral().String())
+	assert.Equal(t, "platform", job.Spec.Template.Labels["team"])
+}
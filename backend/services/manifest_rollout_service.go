@@ -0,0 +1,901 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"iac-platform/internal/infrastructure"
+	"iac-platform/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrManifestRolloutNotFound = errors.New("manifest rollout not found")
+	ErrManifestRolloutInvalid  = errors.New("invalid manifest rollout")
+	ErrManifestRolloutState    = errors.New("manifest rollout is not in a valid state for this operation")
+)
+
+// rolloutTaskTrigger 触发 workspace 任务执行（TaskQueueManager 实现）
+type rolloutTaskTrigger interface {
+	TryExecuteNextTask(workspaceID string) error
+}
+
+// ManifestRolloutService Manifest 批量发布服务
+// 创建时解析目标 Workspace 并划分波次；推进（Process）由 ManifestRolloutWorker 在 leader 上定期调用，
+// 每个目标复用 ManifestUpgradeService 的首次部署/升级逻辑，并创建一个 plan_and_apply 任务
+type ManifestRolloutService struct {
+	db           *gorm.DB
+	upgrades     *ManifestUpgradeService
+	queueManager rolloutTaskTrigger
+}
+
+// NewManifestRolloutService 创建 Manifest 批量发布服务
+func NewManifestRolloutService(db *gorm.DB) *ManifestRolloutService {
+	return &ManifestRolloutService{
+		db:       db,
+		upgrades: NewManifestUpgradeService(db),
+	}
+}
+
+// SetQueueManager 设置任务队列管理器，未设置时任务由 pending tasks monitor 拾取
+func (s *ManifestRolloutService) SetQueueManager(qm rolloutTaskTrigger) {
+	s.queueManager = qm
+}
+
+// Preview 解析目标 Workspace 并返回波次划分，不做任何修改
+func (s *ManifestRolloutService) Preview(manifestID string, req *models.CreateManifestRolloutRequest) (*models.ManifestRolloutPreview, error) {
+	targets, waves, err := s.planTargets(manifestID, req)
+	if err != nil {
+		return nil, err
+	}
+	preview := &models.ManifestRolloutPreview{Waves: make([][]models.ManifestRolloutTarget, waves), Total: len(targets)}
+	for _, target := range targets {
+		preview.Waves[target.Wave] = append(preview.Waves[target.Wave], target)
+	}
+	return preview, nil
+}
+
+// Create 创建发布并立即进入 running，由 worker 开始执行第一波
+func (s *ManifestRolloutService) Create(manifestID string, req *models.CreateManifestRolloutRequest, userID string) (*models.ManifestRollout, error) {
+	targets, waves, err := s.planTargets(manifestID, req)
+	if err != nil {
+		return nil, err
+	}
+	id, err := infrastructure.GenerateManifestRolloutID()
+	if err != nil {
+		return nil, err
+	}
+
+	strategy := req.Strategy
+	if strategy.FailureAction == "" {
+		strategy.FailureAction = models.RolloutFailureActionPause
+	}
+	conflictStrategy := req.ConflictStrategy
+	if conflictStrategy == "" {
+		conflictStrategy = models.UpgradeStrategyFail
+	}
+
+	now := time.Now()
+	rollout := models.ManifestRollout{
+		ID:                id,
+		ManifestID:        manifestID,
+		VersionID:         req.VersionID,
+		Selector:          req.Selector,
+		Strategy:          strategy,
+		ConflictStrategy:  conflictStrategy,
+		VariableOverrides: req.VariableOverrides,
+		Status:            models.RolloutStatusRunning,
+		TotalWaves:        waves,
+		CreatedBy:         userID,
+		StartedAt:         &now,
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// 同一 Manifest 同时只允许一个未结束的发布，避免对同一 workspace 并发升级
+		var active int64
+		if err := tx.Model(&models.ManifestRollout{}).
+			Where("manifest_id = ? AND status IN ?", manifestID, activeRolloutStatuses).
+			Count(&active).Error; err != nil {
+			return err
+		}
+		if active > 0 {
+			return fmt.Errorf("%w: manifest already has an active rollout", ErrManifestRolloutState)
+		}
+		if err := tx.Create(&rollout).Error; err != nil {
+			return err
+		}
+		for i := range targets {
+			targets[i].RolloutID = rollout.ID
+		}
+		return tx.Create(&targets).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rollout.Targets = targets
+	rollout.Summary = summarizeRolloutTargets(targets)
+	return &rollout, nil
+}
+
+// List 列出 Manifest 的发布（带目标统计）
+func (s *ManifestRolloutService) List(manifestID string) ([]models.ManifestRollout, error) {
+	var rollouts []models.ManifestRollout
+	if err := s.db.Where("manifest_id = ?", manifestID).Order("created_at DESC").Find(&rollouts).Error; err != nil {
+		return nil, err
+	}
+	for i := range rollouts {
+		var targets []models.ManifestRolloutTarget
+		if err := s.db.Select("status").Where("rollout_id = ?", rollouts[i].ID).Find(&targets).Error; err != nil {
+			return nil, err
+		}
+		rollouts[i].Summary = summarizeRolloutTargets(targets)
+	}
+	return rollouts, nil
+}
+
+// Get 获取发布详情（含全部目标）
+func (s *ManifestRolloutService) Get(manifestID, rolloutID string) (*models.ManifestRollout, error) {
+	rollout, err := s.load(s.db, manifestID, rolloutID)
+	if err != nil {
+		return nil, err
+	}
+	var targets []models.ManifestRolloutTarget
+	if err := s.db.Where("rollout_id = ?", rollout.ID).Order("wave, id").Find(&targets).Error; err != nil {
+		return nil, err
+	}
+
+	workspaceIDs := make([]int, 0, len(targets))
+	for _, target := range targets {
+		workspaceIDs = append(workspaceIDs, target.WorkspaceID)
+	}
+	var workspaces []models.Workspace
+	if len(workspaceIDs) > 0 {
+		if err := s.db.Select("id, name").Where("id IN ?", workspaceIDs).Find(&workspaces).Error; err != nil {
+			return nil, err
+		}
+	}
+	names := make(map[int]string, len(workspaces))
+	for _, ws := range workspaces {
+		names[int(ws.ID)] = ws.Name
+	}
+	for i := range targets {
+		targets[i].WorkspaceName = names[targets[i].WorkspaceID]
+	}
+
+	rollout.Targets = targets
+	rollout.Summary = summarizeRolloutTargets(targets)
+	return rollout, nil
+}
+
+// Approve 审批通过，进入下一波
+func (s *ManifestRolloutService) Approve(manifestID, rolloutID, userID string) (*models.ManifestRollout, error) {
+	rollout, err := s.load(s.db, manifestID, rolloutID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.transition(rollout, models.RolloutStatusAwaitingApproval, map[string]interface{}{
+		"status":            models.RolloutStatusRunning,
+		"current_wave":      rollout.CurrentWave + 1,
+		"accepted_failures": 0,
+		"approved_by":       userID,
+		"message":           "",
+	}); err != nil {
+		return nil, err
+	}
+	return s.Get(manifestID, rolloutID)
+}
+
+// Pause 手动暂停；已启动的任务继续执行并跟踪状态，但不再启动新的目标
+func (s *ManifestRolloutService) Pause(manifestID, rolloutID, userID string) (*models.ManifestRollout, error) {
+	rollout, err := s.load(s.db, manifestID, rolloutID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.transition(rollout, models.RolloutStatusRunning, map[string]interface{}{
+		"status":  models.RolloutStatusPaused,
+		"message": fmt.Sprintf("Paused by %s", userID),
+	}); err != nil {
+		return nil, err
+	}
+	return s.Get(manifestID, rolloutID)
+}
+
+// Resume 恢复暂停的发布
+func (s *ManifestRolloutService) Resume(manifestID, rolloutID string, retryFailed bool) (*models.ManifestRollout, error) {
+	rollout, err := s.load(s.db, manifestID, rolloutID)
+	if err != nil {
+		return nil, err
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{"status": models.RolloutStatusRunning, "message": ""}
+		failed := tx.Model(&models.ManifestRolloutTarget{}).
+			Where("rollout_id = ? AND wave = ? AND status = ?", rollout.ID, rollout.CurrentWave, models.RolloutTargetStatusFailed)
+		if retryFailed {
+			if err := failed.Updates(map[string]interface{}{
+				"status":       models.RolloutTargetStatusPending,
+				"error":        "",
+				"task_id":      nil,
+				"started_at":   nil,
+				"completed_at": nil,
+			}).Error; err != nil {
+				return err
+			}
+			updates["accepted_failures"] = 0
+		} else {
+			var count int64
+			if err := failed.Count(&count).Error; err != nil {
+				return err
+			}
+			updates["accepted_failures"] = int(count)
+		}
+		return s.transition(rollout, models.RolloutStatusPaused, updates, tx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.Get(manifestID, rolloutID)
+}
+
+// Cancel 取消发布，未开始的目标标记为 skipped；已启动的任务不会被取消
+func (s *ManifestRolloutService) Cancel(manifestID, rolloutID, userID string) (*models.ManifestRollout, error) {
+	rollout, err := s.load(s.db, manifestID, rolloutID)
+	if err != nil {
+		return nil, err
+	}
+	if !isActiveRolloutStatus(rollout.Status) {
+		return nil, fmt.Errorf("%w: rollout is %s", ErrManifestRolloutState, rollout.Status)
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.transition(rollout, rollout.Status, map[string]interface{}{
+			"status":       models.RolloutStatusCancelled,
+			"message":      fmt.Sprintf("Cancelled by %s", userID),
+			"completed_at": time.Now(),
+		}, tx); err != nil {
+			return err
+		}
+		return skipPendingRolloutTargets(tx, rollout.ID, "rollout cancelled")
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.Get(manifestID, rolloutID)
+}
+
+// ProcessAll 推进所有需要处理的发布：running 状态的发布，以及仍有任务在执行的发布
+func (s *ManifestRolloutService) ProcessAll(ctx context.Context) {
+	var ids []string
+	if err := s.db.Model(&models.ManifestRollout{}).
+		Where("status = ? OR id IN (?)", models.RolloutStatusRunning,
+			s.db.Model(&models.ManifestRolloutTarget{}).Select("rollout_id").Where("status = ?", models.RolloutTargetStatusRunning)).
+		Order("created_at").
+		Pluck("id", &ids).Error; err != nil {
+		log.Printf("[ManifestRollout] Failed to load rollouts: %v", err)
+		return
+	}
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := s.Process(id); err != nil {
+			log.Printf("[ManifestRollout] Failed to process rollout %s: %v", id, err)
+		}
+	}
+}
+
+// Process 推进单个发布：刷新执行中目标的任务状态，检查失败阈值，启动当前波次的目标，波次完成后进入下一波或等待审批
+// 所有状态都从数据库读取，可以在任意时刻（包括服务重启后）重复调用
+func (s *ManifestRolloutService) Process(rolloutID string) error {
+	var rollout models.ManifestRollout
+	if err := s.db.Where("id = ?", rolloutID).First(&rollout).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrManifestRolloutNotFound
+		}
+		return err
+	}
+
+	var running []models.ManifestRolloutTarget
+	if err := s.db.Where("rollout_id = ? AND status = ? AND task_id IS NOT NULL", rollout.ID, models.RolloutTargetStatusRunning).
+		Find(&running).Error; err != nil {
+		return err
+	}
+	for i := range running {
+		if err := s.refreshTarget(&running[i]); err != nil {
+			log.Printf("[ManifestRollout] Failed to refresh target %d of rollout %s: %v", running[i].ID, rollout.ID, err)
+		}
+	}
+
+	for rollout.Status == models.RolloutStatusRunning {
+		var targets []models.ManifestRolloutTarget
+		if err := s.db.Where("rollout_id = ? AND wave = ?", rollout.ID, rollout.CurrentWave).Order("id").Find(&targets).Error; err != nil {
+			return err
+		}
+
+		if tripped, err := s.checkFailureThreshold(&rollout, targets); err != nil || tripped {
+			return err
+		}
+
+		done := true
+		for i := range targets {
+			target := &targets[i]
+			// task_id 为空的 running 目标说明上次启动被中断（如服务重启），重新启动是幂等的
+			if target.Status == models.RolloutTargetStatusPending ||
+				(target.Status == models.RolloutTargetStatusRunning && target.TaskID == nil) {
+				s.startTarget(&rollout, target)
+			}
+			if target.Status == models.RolloutTargetStatusPending || target.Status == models.RolloutTargetStatusRunning {
+				done = false
+			}
+		}
+		if tripped, err := s.checkFailureThreshold(&rollout, targets); err != nil || tripped || !done {
+			return err
+		}
+
+		// 当前波次全部结束
+		switch {
+		case rollout.CurrentWave >= rollout.TotalWaves-1:
+			log.Printf("[ManifestRollout] Rollout %s completed", rollout.ID)
+			return s.advance(&rollout, map[string]interface{}{
+				"status":       models.RolloutStatusCompleted,
+				"completed_at": time.Now(),
+			})
+		case rollout.Strategy.RequireApproval:
+			log.Printf("[ManifestRollout] Rollout %s wave %d finished, awaiting approval", rollout.ID, rollout.CurrentWave+1)
+			return s.advance(&rollout, map[string]interface{}{
+				"status":  models.RolloutStatusAwaitingApproval,
+				"message": fmt.Sprintf("Wave %d of %d finished, approval required to continue", rollout.CurrentWave+1, rollout.TotalWaves),
+			})
+		default:
+			if err := s.advance(&rollout, map[string]interface{}{
+				"current_wave":      rollout.CurrentWave + 1,
+				"accepted_failures": 0,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkFailureThreshold 当前波次失败比例超过阈值时暂停或停止发布
+func (s *ManifestRolloutService) checkFailureThreshold(rollout *models.ManifestRollout, targets []models.ManifestRolloutTarget) (bool, error) {
+	failed := 0
+	for _, target := range targets {
+		if target.Status == models.RolloutTargetStatusFailed {
+			failed++
+		}
+	}
+	failed -= rollout.AcceptedFailures
+	if failed <= 0 || failed*100 <= rollout.Strategy.FailureThreshold*len(targets) {
+		return false, nil
+	}
+
+	message := fmt.Sprintf("%d of %d workspaces failed in wave %d (threshold %d%%)",
+		failed, len(targets), rollout.CurrentWave+1, rollout.Strategy.FailureThreshold)
+	log.Printf("[ManifestRollout] Rollout %s: %s, action %s", rollout.ID, message, rollout.Strategy.FailureAction)
+
+	if rollout.Strategy.FailureAction == models.RolloutFailureActionStop {
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			if err := s.transition(rollout, models.RolloutStatusRunning, map[string]interface{}{
+				"status":       models.RolloutStatusStopped,
+				"message":      message,
+				"completed_at": time.Now(),
+			}, tx); err != nil {
+				return err
+			}
+			return skipPendingRolloutTargets(tx, rollout.ID, "rollout stopped: "+message)
+		})
+		return true, ignoreRolloutStateError(err)
+	}
+	return true, ignoreRolloutStateError(s.advance(rollout, map[string]interface{}{
+		"status":  models.RolloutStatusPaused,
+		"message": message,
+	}))
+}
+
+// startTarget 为目标创建或升级部署并创建 plan_and_apply 任务，失败时目标标记为 failed
+func (s *ManifestRolloutService) startTarget(rollout *models.ManifestRollout, target *models.ManifestRolloutTarget) {
+	now := time.Now()
+	if target.StartedAt == nil {
+		target.StartedAt = &now
+	}
+	target.Status = models.RolloutTargetStatusRunning
+
+	taskID, err := s.deployTarget(rollout, target)
+	switch {
+	case err != nil:
+		target.Status = models.RolloutTargetStatusFailed
+		target.Error = err.Error()
+		target.CompletedAt = &now
+		log.Printf("[ManifestRollout] Rollout %s failed to start workspace %s: %v", rollout.ID, target.WorkspaceKey, err)
+	case target.Action == models.RolloutActionNone:
+		target.Status = models.RolloutTargetStatusSkipped
+		target.Error = "already on target version"
+		target.CompletedAt = &now
+	default:
+		target.TaskID = &taskID
+	}
+
+	if err := s.db.Model(&models.ManifestRolloutTarget{}).Where("id = ?", target.ID).Updates(map[string]interface{}{
+		"status":        target.Status,
+		"action":        target.Action,
+		"deployment_id": target.DeploymentID,
+		"task_id":       target.TaskID,
+		"error":         target.Error,
+		"started_at":    target.StartedAt,
+		"completed_at":  target.CompletedAt,
+	}).Error; err != nil {
+		log.Printf("[ManifestRollout] Failed to save target %d of rollout %s: %v", target.ID, rollout.ID, err)
+	}
+}
+
+// deployTarget 根据 workspace 当前的部署状态选择首次部署或升级
+// 部署 ID 在修改资源之前就写入目标，重启后可以识别由本次发布创建/升级但尚未创建任务的部署
+func (s *ManifestRolloutService) deployTarget(rollout *models.ManifestRollout, target *models.ManifestRolloutTarget) (uint, error) {
+	var workspace models.Workspace
+	if err := s.db.Select("id, workspace_id, execution_mode").Where("id = ?", target.WorkspaceID).First(&workspace).Error; err != nil {
+		return 0, fmt.Errorf("failed to get workspace: %w", err)
+	}
+
+	var deployment models.ManifestDeployment
+	err := s.db.Where("manifest_id = ? AND workspace_id = ? AND status != ?", rollout.ManifestID, target.WorkspaceID, models.DeploymentStatusArchived).
+		First(&deployment).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, err
+	}
+	ownDeployment := err == nil && target.DeploymentID != nil && *target.DeploymentID == deployment.ID
+
+	var plan *models.ManifestUpgradePlan
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		id, err := infrastructure.GenerateManifestDeploymentID()
+		if err != nil {
+			return 0, err
+		}
+		now := time.Now()
+		deployment = models.ManifestDeployment{
+			ID:                id,
+			ManifestID:        rollout.ManifestID,
+			VersionID:         rollout.VersionID,
+			WorkspaceID:       target.WorkspaceID,
+			VariableOverrides: rollout.VariableOverrides,
+			Status:            models.DeploymentStatusPending,
+			DeployedBy:        rollout.CreatedBy,
+			DeployedAt:        &now,
+		}
+		if err := s.db.Create(&deployment).Error; err != nil {
+			return 0, fmt.Errorf("failed to create deployment: %w", err)
+		}
+		if err := s.saveTargetDeployment(target, deployment.ID, models.RolloutActionInstall); err != nil {
+			return 0, err
+		}
+		plan, err = s.upgrades.InstallDeployment(deployment.ID, rollout.ConflictStrategy, rollout.CreatedBy)
+		if err != nil {
+			s.db.Model(&deployment).Update("status", models.DeploymentStatusFailed)
+			return 0, err
+		}
+	case ownDeployment && deployment.VersionID == rollout.VersionID && deployment.Status == models.DeploymentStatusDeploying:
+		// 上次已完成资源变更；任务已创建但未写入目标时直接沿用，否则补建任务
+		if deployment.LastTaskID != nil {
+			return uint(*deployment.LastTaskID), nil
+		}
+	case ownDeployment && deployment.VersionID == rollout.VersionID && deployment.Status == models.DeploymentStatusPending:
+		// 上次创建了部署记录但首次部署未完成
+		plan, err = s.upgrades.InstallDeployment(deployment.ID, rollout.ConflictStrategy, rollout.CreatedBy)
+		if err != nil {
+			s.db.Model(&deployment).Update("status", models.DeploymentStatusFailed)
+			return 0, err
+		}
+	case deployment.VersionID == rollout.VersionID && deployment.Status != models.DeploymentStatusFailed:
+		target.Action = models.RolloutActionNone
+		target.DeploymentID = &deployment.ID
+		return 0, nil
+	case deployment.Status == models.DeploymentStatusDeploying:
+		return 0, fmt.Errorf("deployment %s is in progress", deployment.ID)
+	default:
+		if err := s.saveTargetDeployment(target, deployment.ID, models.RolloutActionUpgrade); err != nil {
+			return 0, err
+		}
+		if deployment.VersionID == rollout.VersionID {
+			// 之前在该版本上部署失败，重新执行首次部署
+			plan, err = s.upgrades.InstallDeployment(deployment.ID, rollout.ConflictStrategy, rollout.CreatedBy)
+		} else {
+			plan, err = s.upgrades.ApplyUpgrade(deployment.ID, rollout.VersionID, rollout.ConflictStrategy, rollout.CreatedBy)
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	description := fmt.Sprintf("Manifest rollout %s: %s", rollout.ID, deployment.ID)
+	if plan != nil && plan.FromVersion != "" {
+		description = fmt.Sprintf("Manifest rollout %s: %s (%s -> %s)", rollout.ID, deployment.ID, plan.FromVersion, plan.ToVersion)
+	}
+	taskID, err := s.createTask(workspace, rollout.CreatedBy, description)
+	if err != nil {
+		s.db.Model(&models.ManifestDeployment{}).Where("id = ?", deployment.ID).Update("status", models.DeploymentStatusFailed)
+		return 0, err
+	}
+	// 部署保持 deploying，由 refreshTarget 根据任务最终状态更新
+	s.db.Model(&models.ManifestDeployment{}).Where("id = ?", deployment.ID).Updates(map[string]interface{}{
+		"status":       models.DeploymentStatusDeploying,
+		"last_task_id": taskID,
+	})
+	return taskID, nil
+}
+
+func (s *ManifestRolloutService) saveTargetDeployment(target *models.ManifestRolloutTarget, deploymentID, action string) error {
+	target.DeploymentID = &deploymentID
+	target.Action = action
+	return s.db.Model(&models.ManifestRolloutTarget{}).Where("id = ?", target.ID).Updates(map[string]interface{}{
+		"status":        models.RolloutTargetStatusRunning,
+		"deployment_id": deploymentID,
+		"action":        action,
+		"started_at":    target.StartedAt,
+	}).Error
+}
+
+// createTask 创建 plan_and_apply 任务，快照由执行器在 plan 阶段生成
+func (s *ManifestRolloutService) createTask(workspace models.Workspace, userID, description string) (uint, error) {
+	task := &models.WorkspaceTask{
+		WorkspaceID:   workspace.WorkspaceID,
+		TaskType:      models.TaskTypePlanAndApply,
+		Status:        models.TaskStatusPending,
+		ExecutionMode: workspace.ExecutionMode,
+		CreatedBy:     &userID,
+		Stage:         "pending",
+		Description:   description,
+	}
+	if err := s.db.Create(task).Error; err != nil {
+		return 0, fmt.Errorf("failed to create task: %w", err)
+	}
+
+	if s.queueManager != nil {
+		go func() {
+			if err := s.queueManager.TryExecuteNextTask(workspace.WorkspaceID); err != nil {
+				log.Printf("[ManifestRollout] Failed to trigger task execution for workspace %s: %v", workspace.WorkspaceID, err)
+			}
+		}()
+	}
+	return task.ID, nil
+}
+
+// refreshTarget 根据任务状态更新目标；任务等待确认 Apply 时目标保持 running
+func (s *ManifestRolloutService) refreshTarget(target *models.ManifestRolloutTarget) error {
+	var task models.WorkspaceTask
+	if err := s.db.Select("id, status, error_message").Where("id = ?", *target.TaskID).First(&task).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		task.Status = models.TaskStatusFailed
+		task.ErrorMessage = "task not found"
+	}
+
+	deploymentStatus := DeploymentStatusForTask(task.Status)
+	switch deploymentStatus {
+	case models.DeploymentStatusDeployed:
+		target.Status = models.RolloutTargetStatusSucceeded
+	case models.DeploymentStatusFailed:
+		target.Status = models.RolloutTargetStatusFailed
+		target.Error = fmt.Sprintf("task %d %s", *target.TaskID, task.Status)
+		if task.ErrorMessage != "" {
+			target.Error += ": " + task.ErrorMessage
+		}
+	default:
+		return nil
+	}
+	if target.DeploymentID != nil {
+		if err := s.db.Model(&models.ManifestDeployment{}).
+			Where("id = ? AND status = ? AND last_task_id = ?", *target.DeploymentID, models.DeploymentStatusDeploying, *target.TaskID).
+			Update("status", deploymentStatus).Error; err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	target.CompletedAt = &now
+	return s.db.Model(&models.ManifestRolloutTarget{}).Where("id = ?", target.ID).Updates(map[string]interface{}{
+		"status":       target.Status,
+		"error":        target.Error,
+		"completed_at": now,
+	}).Error
+}
+
+// advance 在发布仍为 running 时更新状态（API 的暂停/取消优先）
+func (s *ManifestRolloutService) advance(rollout *models.ManifestRollout, updates map[string]interface{}) error {
+	return ignoreRolloutStateError(s.transition(rollout, models.RolloutStatusRunning, updates))
+}
+
+// transition 仅当发布处于 from 状态时更新，成功后同步到 rollout
+func (s *ManifestRolloutService) transition(rollout *models.ManifestRollout, from string, updates map[string]interface{}, tx ...*gorm.DB) error {
+	db := s.db
+	if len(tx) > 0 {
+		db = tx[0]
+	}
+	if rollout.Status != from {
+		return fmt.Errorf("%w: rollout is %s", ErrManifestRolloutState, rollout.Status)
+	}
+	result := db.Model(&models.ManifestRollout{}).Where("id = ? AND status = ?", rollout.ID, from).Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: rollout status changed concurrently", ErrManifestRolloutState)
+	}
+	return db.Where("id = ?", rollout.ID).First(rollout).Error
+}
+
+func (s *ManifestRolloutService) load(db *gorm.DB, manifestID, rolloutID string) (*models.ManifestRollout, error) {
+	var rollout models.ManifestRollout
+	if err := db.Where("id = ? AND manifest_id = ?", rolloutID, manifestID).First(&rollout).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrManifestRolloutNotFound
+		}
+		return nil, err
+	}
+	return &rollout, nil
+}
+
+// planTargets 校验请求，解析目标 Workspace 并分配波次
+func (s *ManifestRolloutService) planTargets(manifestID string, req *models.CreateManifestRolloutRequest) ([]models.ManifestRolloutTarget, int, error) {
+	if err := validateRolloutStrategy(&req.Strategy); err != nil {
+		return nil, 0, err
+	}
+	conflictStrategy := req.ConflictStrategy
+	if err := validateUpgradeStrategy(&conflictStrategy); err != nil {
+		return nil, 0, err
+	}
+
+	var version models.ManifestVersion
+	if err := s.db.Select("id, is_draft").Where("id = ? AND manifest_id = ?", req.VersionID, manifestID).First(&version).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, 0, ErrManifestVersionNotFound
+		}
+		return nil, 0, err
+	}
+	if version.IsDraft {
+		return nil, 0, fmt.Errorf("%w: only published versions can be rolled out", ErrManifestRolloutInvalid)
+	}
+
+	workspaces, err := s.selectWorkspaces(req.Selector)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var deployments []models.ManifestDeployment
+	if err := s.db.Select("workspace_id, version_id, status").
+		Where("manifest_id = ? AND status != ?", manifestID, models.DeploymentStatusArchived).
+		Find(&deployments).Error; err != nil {
+		return nil, 0, err
+	}
+	deployed := make(map[int]models.ManifestDeployment, len(deployments))
+	for _, d := range deployments {
+		deployed[d.WorkspaceID] = d
+	}
+
+	bounds := rolloutWaveBounds(len(workspaces), req.Strategy)
+	targets := make([]models.ManifestRolloutTarget, 0, len(workspaces))
+	wave := 0
+	for i, ws := range workspaces {
+		for i >= bounds[wave] {
+			wave++
+		}
+		action := models.RolloutActionInstall
+		if d, ok := deployed[int(ws.ID)]; ok {
+			action = models.RolloutActionUpgrade
+			if d.VersionID == req.VersionID && d.Status != models.DeploymentStatusFailed {
+				action = models.RolloutActionNone
+			}
+		}
+		targets = append(targets, models.ManifestRolloutTarget{
+			WorkspaceID:   int(ws.ID),
+			WorkspaceKey:  ws.WorkspaceID,
+			WorkspaceName: ws.Name,
+			Wave:          wave,
+			Action:        action,
+			Status:        models.RolloutTargetStatusPending,
+		})
+	}
+	return targets, len(bounds), nil
+}
+
+// selectWorkspaces 按项目、显式列表和 tags 选择 Workspace（条件取交集），按 ID 排序保证波次划分稳定
+func (s *ManifestRolloutService) selectWorkspaces(selector models.ManifestRolloutSelector) ([]models.Workspace, error) {
+	if len(selector.ProjectIDs) == 0 && len(selector.Tags) == 0 && len(selector.WorkspaceIDs) == 0 {
+		return nil, fmt.Errorf("%w: selector must specify project_ids, tags or workspace_ids", ErrManifestRolloutInvalid)
+	}
+
+	query := s.db.Model(&models.Workspace{}).Select("id, workspace_id, name, tags")
+	if len(selector.WorkspaceIDs) > 0 {
+		query = query.Where("workspace_id IN ?", selector.WorkspaceIDs)
+	}
+	if len(selector.ProjectIDs) > 0 {
+		query = query.Where("workspace_id IN (SELECT workspace_id FROM workspace_project_relations WHERE project_id IN ?)", selector.ProjectIDs)
+	}
+	var candidates []models.Workspace
+	if err := query.Order("id").Find(&candidates).Error; err != nil {
+		return nil, err
+	}
+
+	if len(selector.WorkspaceIDs) > 0 && len(selector.ProjectIDs) == 0 {
+		found := make(map[string]bool, len(candidates))
+		for _, ws := range candidates {
+			found[ws.WorkspaceID] = true
+		}
+		var missing []string
+		for _, id := range selector.WorkspaceIDs {
+			if !found[id] {
+				missing = append(missing, id)
+			}
+		}
+		if len(missing) > 0 {
+			sort.Strings(missing)
+			return nil, fmt.Errorf("%w: workspaces not found: %s", ErrManifestRolloutInvalid, strings.Join(missing, ", "))
+		}
+	}
+
+	workspaces := make([]models.Workspace, 0, len(candidates))
+	for _, ws := range candidates {
+		if workspaceTagsMatch(ws.Tags, selector.Tags) {
+			workspaces = append(workspaces, ws)
+		}
+	}
+	if len(workspaces) == 0 {
+		return nil, fmt.Errorf("%w: no workspaces match the selector", ErrManifestRolloutInvalid)
+	}
+	return workspaces, nil
+}
+
+func workspaceTagsMatch(tags models.JSONB, want map[string]string) bool {
+	for key, value := range want {
+		actual, ok := tags[key]
+		if !ok || fmt.Sprint(actual) != value {
+			return false
+		}
+	}
+	return true
+}
+
+func validateRolloutStrategy(strategy *models.ManifestRolloutStrategy) error {
+	if strategy.CanaryCount < 0 {
+		return fmt.Errorf("%w: canary_count must not be negative", ErrManifestRolloutInvalid)
+	}
+	previous := 0
+	for _, p := range strategy.Percentages {
+		if p <= previous || p > 100 {
+			return fmt.Errorf("%w: percentages must be increasing values between 1 and 100", ErrManifestRolloutInvalid)
+		}
+		previous = p
+	}
+	if strategy.FailureThreshold < 0 || strategy.FailureThreshold > 100 {
+		return fmt.Errorf("%w: failure_threshold must be between 0 and 100", ErrManifestRolloutInvalid)
+	}
+	switch strategy.FailureAction {
+	case "", models.RolloutFailureActionPause, models.RolloutFailureActionStop:
+		return nil
+	}
+	return fmt.Errorf("%w: unknown failure_action %q", ErrManifestRolloutInvalid, strategy.FailureAction)
+}
+
+// rolloutWaveBounds 返回每个波次结束位置（累计数量）
+// 金丝雀波次取 canary_count 个，之后按累计百分比向上取整，最后一波补齐到全部
+func rolloutWaveBounds(total int, strategy models.ManifestRolloutStrategy) []int {
+	var bounds []int
+	last := 0
+	if strategy.CanaryCount > 0 {
+		last = strategy.CanaryCount
+		if last > total {
+			last = total
+		}
+		bounds = append(bounds, last)
+	}
+	for _, p := range strategy.Percentages {
+		end := (total*p + 99) / 100
+		if end > last {
+			bounds = append(bounds, end)
+			last = end
+		}
+	}
+	if last < total {
+		bounds = append(bounds, total)
+	}
+	return bounds
+}
+
+func summarizeRolloutTargets(targets []models.ManifestRolloutTarget) *models.ManifestRolloutSummary {
+	summary := &models.ManifestRolloutSummary{Total: len(targets)}
+	for _, target := range targets {
+		switch target.Status {
+		case models.RolloutTargetStatusPending:
+			summary.Pending++
+		case models.RolloutTargetStatusRunning:
+			summary.Running++
+		case models.RolloutTargetStatusSucceeded:
+			summary.Succeeded++
+		case models.RolloutTargetStatusFailed:
+			summary.Failed++
+		case models.RolloutTargetStatusSkipped:
+			summary.Skipped++
+		}
+	}
+	return summary
+}
+
+func skipPendingRolloutTargets(tx *gorm.DB, rolloutID, reason string) error {
+	return tx.Model(&models.ManifestRolloutTarget{}).
+		Where("rollout_id = ? AND status = ?", rolloutID, models.RolloutTargetStatusPending).
+		Updates(map[string]interface{}{
+			"status":       models.RolloutTargetStatusSkipped,
+			"error":        reason,
+			"completed_at": time.Now(),
+		}).Error
+}
+
+var activeRolloutStatuses = []string{
+	models.RolloutStatusRunning,
+	models.RolloutStatusAwaitingApproval,
+	models.RolloutStatusPaused,
+}
+
+func isActiveRolloutStatus(status string) bool {
+	for _, s := range activeRolloutStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// ignoreRolloutStateError worker 推进时发布状态被 API 并发修改属于正常情况
+func ignoreRolloutStateError(err error) error {
+	if errors.Is(err, ErrManifestRolloutState) {
+		return nil
+	}
+	return err
+}
+
+// ManifestRolloutWorker 后台推进 Manifest 批量发布（仅 leader 运行）
+type ManifestRolloutWorker struct {
+	service *ManifestRolloutService
+}
+
+// NewManifestRolloutWorker 创建 Manifest 发布 worker
+func NewManifestRolloutWorker(db *gorm.DB, queueManager rolloutTaskTrigger) *ManifestRolloutWorker {
+	service := NewManifestRolloutService(db)
+	if queueManager != nil {
+		service.SetQueueManager(queueManager)
+	}
+	return &ManifestRolloutWorker{service: service}
+}
+
+// Start 立即处理一次（接管上一个 leader 未完成的发布），之后按间隔推进
+func (w *ManifestRolloutWorker) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Printf("[ManifestRollout] Worker started (interval %v)", interval)
+	w.process(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("[ManifestRollout] Worker stopped")
+			return
+		case <-ticker.C:
+			w.process(ctx)
+		}
+	}
+}
+
+// process 推进批量发布，并同步单个部署/升级/回滚任务结束后的部署状态
+func (w *ManifestRolloutWorker) process(ctx context.Context) {
+	w.service.ProcessAll(ctx)
+	if err := w.service.upgrades.SyncDeploymentStatuses(); err != nil {
+		log.Printf("[ManifestRollout] Failed to sync deployment statuses: %v", err)
+	}
+}
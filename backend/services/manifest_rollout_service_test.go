@@ -0,0 +1,429 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+
+	"iac-platform/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func setupManifestRolloutTestDB(t *testing.T, workspaces int) *gorm.DB {
+	t.Helper()
+	db := setupTestDB(t)
+	createManifestTestTables(t, db)
+	statements := []string{
+		`CREATE TABLE workspace_project_relations (id INTEGER PRIMARY KEY AUTOINCREMENT, workspace_id TEXT, project_id INTEGER, created_at DATETIME)`,
+		`CREATE TABLE manifest_rollouts (
+			id TEXT PRIMARY KEY, manifest_id TEXT, version_id TEXT, selector BLOB, strategy BLOB, conflict_strategy TEXT,
+			variable_overrides BLOB, status TEXT DEFAULT 'running', current_wave INTEGER DEFAULT 0, total_waves INTEGER DEFAULT 0,
+			accepted_failures INTEGER DEFAULT 0, message TEXT, created_by TEXT, approved_by TEXT,
+			started_at DATETIME, completed_at DATETIME, created_at DATETIME, updated_at DATETIME)`,
+		`CREATE TABLE manifest_rollout_targets (
+			id INTEGER PRIMARY KEY AUTOINCREMENT, rollout_id TEXT, workspace_id INTEGER, workspace_key TEXT, wave INTEGER,
+			action TEXT, status TEXT DEFAULT 'pending', deployment_id TEXT, task_id INTEGER, error TEXT,
+			started_at DATETIME, completed_at DATETIME)`,
+	}
+	for _, stmt := range statements {
+		require.NoError(t, db.Exec(stmt).Error)
+	}
+
+	require.NoError(t, db.Create(&models.Manifest{ID: "mf-test", OrganizationID: 1, Name: "golden", CreatedBy: "u-1"}).Error)
+	createTestManifestVersion(t, db, "v1", testManifestNode("vpc", map[string]interface{}{"cidr": "10.0.0.0/16"}))
+	createTestManifestVersion(t, db, "v2", testManifestNode("vpc", map[string]interface{}{"cidr": "10.1.0.0/16"}))
+
+	for i := 1; i <= workspaces; i++ {
+		env := "prod"
+		if i%2 == 0 {
+			env = "dev"
+		}
+		require.NoError(t, db.Exec(`INSERT INTO workspaces (id, workspace_id, name, execution_mode, tags) VALUES (?, ?, ?, 'local', ?)`,
+			i, fmt.Sprintf("ws-%d", i), fmt.Sprintf("workspace-%d", i), []byte(`{"env":"`+env+`"}`)).Error)
+	}
+	return db
+}
+
+func loadRolloutTargets(t *testing.T, db *gorm.DB, rolloutID string) []models.ManifestRolloutTarget {
+	t.Helper()
+	var targets []models.ManifestRolloutTarget
+	require.NoError(t, db.Where("rollout_id = ?", rolloutID).Order("id").Find(&targets).Error)
+	return targets
+}
+
+func loadRollout(t *testing.T, db *gorm.DB, rolloutID string) models.ManifestRollout {
+	t.Helper()
+	var rollout models.ManifestRollout
+	require.NoError(t, db.Where("id = ?", rolloutID).First(&rollout).Error)
+	return rollout
+}
+
+// finishRolloutTasks 模拟当前已启动任务执行结束，failed 中的 workspace 失败
+func finishRolloutTasks(t *testing.T, db *gorm.DB, rolloutID string, failed ...string) {
+	t.Helper()
+	fail := make(map[string]bool, len(failed))
+	for _, key := range failed {
+		fail[key] = true
+	}
+	for _, target := range loadRolloutTargets(t, db, rolloutID) {
+		if target.Status != models.RolloutTargetStatusRunning || target.TaskID == nil {
+			continue
+		}
+		status := models.TaskStatusApplied
+		if fail[target.WorkspaceKey] {
+			status = models.TaskStatusFailed
+		}
+		require.NoError(t, db.Exec(`UPDATE workspace_tasks SET status = ?, error_message = ? WHERE id = ?`,
+			status, "", *target.TaskID).Error)
+	}
+}
+
+func workspaceTestConfig(t *testing.T, db *gorm.DB, workspaceID, instanceName string) map[string]interface{} {
+	t.Helper()
+	var resource models.WorkspaceResource
+	require.NoError(t, db.Omit("Tags").Where("workspace_id = ? AND resource_id = ?", workspaceID, "module."+instanceName).First(&resource).Error)
+	var version models.ResourceCodeVersion
+	require.NoError(t, db.First(&version, *resource.CurrentVersionID).Error)
+	return manifestModuleConfig(version.TFCode, instanceName)
+}
+
+func TestRolloutWaveBounds(t *testing.T) {
+	assert.Equal(t, []int{1, 3, 5, 10}, rolloutWaveBounds(10, models.ManifestRolloutStrategy{CanaryCount: 1, Percentages: []int{25, 50}}))
+	assert.Equal(t, []int{2, 3}, rolloutWaveBounds(3, models.ManifestRolloutStrategy{CanaryCount: 2, Percentages: []int{50, 100}}))
+	assert.Equal(t, []int{3}, rolloutWaveBounds(3, models.ManifestRolloutStrategy{CanaryCount: 5}))
+	assert.Equal(t, []int{4}, rolloutWaveBounds(4, models.ManifestRolloutStrategy{}))
+}
+
+func TestManifestRolloutPreviewSelection(t *testing.T) {
+	db := setupManifestRolloutTestDB(t, 5)
+	require.NoError(t, db.Exec(`INSERT INTO workspace_project_relations (workspace_id, project_id) VALUES ('ws-1', 7), ('ws-2', 7), ('ws-3', 7), ('ws-4', 8)`).Error)
+	svc := NewManifestRolloutService(db)
+
+	// 项目与 tags 取交集：项目 7 中 env=prod 的为 ws-1、ws-3
+	preview, err := svc.Preview("mf-test", &models.CreateManifestRolloutRequest{
+		VersionID: "v1",
+		Selector:  models.ManifestRolloutSelector{ProjectIDs: []uint{7}, Tags: map[string]string{"env": "prod"}},
+		Strategy:  models.ManifestRolloutStrategy{CanaryCount: 1},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, preview.Total)
+	require.Len(t, preview.Waves, 2)
+	assert.Equal(t, "ws-1", preview.Waves[0][0].WorkspaceKey)
+	assert.Equal(t, "workspace-3", preview.Waves[1][0].WorkspaceName)
+	assert.Equal(t, models.RolloutActionInstall, preview.Waves[1][0].Action)
+
+	_, err = svc.Preview("mf-test", &models.CreateManifestRolloutRequest{
+		VersionID: "v1", Selector: models.ManifestRolloutSelector{WorkspaceIDs: []string{"ws-1", "ws-9"}},
+	})
+	assert.ErrorIs(t, err, ErrManifestRolloutInvalid)
+	assert.Contains(t, err.Error(), "ws-9")
+
+	_, err = svc.Preview("mf-test", &models.CreateManifestRolloutRequest{VersionID: "v1"})
+	assert.ErrorIs(t, err, ErrManifestRolloutInvalid)
+
+	_, err = svc.Preview("mf-test", &models.CreateManifestRolloutRequest{
+		VersionID: "v1", Selector: models.ManifestRolloutSelector{Tags: map[string]string{"env": "prod"}},
+		Strategy: models.ManifestRolloutStrategy{Percentages: []int{50, 50}},
+	})
+	assert.ErrorIs(t, err, ErrManifestRolloutInvalid)
+
+	_, err = svc.Preview("mf-test", &models.CreateManifestRolloutRequest{
+		VersionID: "v404", Selector: models.ManifestRolloutSelector{Tags: map[string]string{"env": "prod"}},
+	})
+	assert.ErrorIs(t, err, ErrManifestVersionNotFound)
+}
+
+func TestManifestRolloutWavesWithApproval(t *testing.T) {
+	db := setupManifestRolloutTestDB(t, 4)
+	svc := NewManifestRolloutService(db)
+
+	// ws-4 已部署 v1，发布 v2 时走升级
+	require.NoError(t, db.Create(&models.ManifestDeployment{
+		ID: "mfd-ws4", ManifestID: "mf-test", VersionID: "v1", WorkspaceID: 4,
+		Status: models.DeploymentStatusDeployed, DeployedBy: "u-1",
+	}).Error)
+	_, err := svc.upgrades.InstallDeployment("mfd-ws4", "", "u-1")
+	require.NoError(t, err)
+	require.NoError(t, db.Model(&models.ManifestDeployment{}).Where("id = ?", "mfd-ws4").Update("status", models.DeploymentStatusDeployed).Error)
+
+	rollout, err := svc.Create("mf-test", &models.CreateManifestRolloutRequest{
+		VersionID: "v2",
+		Selector:  models.ManifestRolloutSelector{WorkspaceIDs: []string{"ws-1", "ws-2", "ws-3", "ws-4"}},
+		Strategy:  models.ManifestRolloutStrategy{CanaryCount: 1, Percentages: []int{50}, RequireApproval: true},
+	}, "u-2")
+	require.NoError(t, err)
+	assert.Equal(t, 3, rollout.TotalWaves)
+	assert.Equal(t, models.RolloutStatusRunning, rollout.Status)
+	assert.Equal(t, models.RolloutFailureActionPause, rollout.Strategy.FailureAction)
+
+	// 同一 Manifest 不允许并发发布
+	_, err = svc.Create("mf-test", &models.CreateManifestRolloutRequest{
+		VersionID: "v2", Selector: models.ManifestRolloutSelector{WorkspaceIDs: []string{"ws-1"}},
+	}, "u-2")
+	assert.ErrorIs(t, err, ErrManifestRolloutState)
+
+	// 第一波（金丝雀）：只启动 ws-1
+	require.NoError(t, svc.Process(rollout.ID))
+	targets := loadRolloutTargets(t, db, rollout.ID)
+	assert.Equal(t, models.RolloutTargetStatusRunning, targets[0].Status)
+	require.NotNil(t, targets[0].TaskID)
+	require.NotNil(t, targets[0].DeploymentID)
+	assert.Equal(t, models.RolloutTargetStatusPending, targets[1].Status)
+
+	var deployment models.ManifestDeployment
+	require.NoError(t, db.Where("id = ?", *targets[0].DeploymentID).First(&deployment).Error)
+	assert.Equal(t, "v2", deployment.VersionID)
+	assert.Equal(t, 1, int(*deployment.LastTaskID))
+	assert.Equal(t, "10.1.0.0/16", workspaceTestConfig(t, db, "ws-1", "vpc")["cidr"])
+
+	// 任务未完成时不推进
+	require.NoError(t, svc.Process(rollout.ID))
+	assert.Equal(t, 0, loadRollout(t, db, rollout.ID).CurrentWave)
+
+	finishRolloutTasks(t, db, rollout.ID)
+	require.NoError(t, svc.Process(rollout.ID))
+	stored := loadRollout(t, db, rollout.ID)
+	assert.Equal(t, models.RolloutStatusAwaitingApproval, stored.Status)
+	assert.Equal(t, models.RolloutTargetStatusSucceeded, loadRolloutTargets(t, db, rollout.ID)[0].Status)
+
+	// 等待审批时不启动下一波
+	require.NoError(t, svc.Process(rollout.ID))
+	assert.Equal(t, models.RolloutTargetStatusPending, loadRolloutTargets(t, db, rollout.ID)[1].Status)
+
+	_, err = svc.Pause("mf-test", rollout.ID, "u-2")
+	assert.ErrorIs(t, err, ErrManifestRolloutState)
+
+	approved, err := svc.Approve("mf-test", rollout.ID, "u-admin")
+	require.NoError(t, err)
+	assert.Equal(t, 1, approved.CurrentWave)
+	require.NotNil(t, approved.ApprovedBy)
+	assert.Equal(t, "u-admin", *approved.ApprovedBy)
+
+	// 第二波：累计 50% 即 ws-2
+	require.NoError(t, svc.Process(rollout.ID))
+	finishRolloutTasks(t, db, rollout.ID)
+	require.NoError(t, svc.Process(rollout.ID))
+	_, err = svc.Approve("mf-test", rollout.ID, "u-admin")
+	require.NoError(t, err)
+
+	// 最后一波：ws-3 新建，ws-4 从 v1 升级
+	require.NoError(t, svc.Process(rollout.ID))
+	targets = loadRolloutTargets(t, db, rollout.ID)
+	assert.Equal(t, models.RolloutActionInstall, targets[2].Action)
+	assert.Equal(t, models.RolloutActionUpgrade, targets[3].Action)
+	assert.Equal(t, "mfd-ws4", *targets[3].DeploymentID)
+	var upgraded models.ManifestDeployment
+	require.NoError(t, db.Where("id = ?", "mfd-ws4").First(&upgraded).Error)
+	assert.Equal(t, "v2", upgraded.VersionID)
+	require.NotNil(t, upgraded.PreviousVersionID)
+	assert.Equal(t, "v1", *upgraded.PreviousVersionID)
+
+	finishRolloutTasks(t, db, rollout.ID)
+	require.NoError(t, svc.Process(rollout.ID))
+
+	result, err := svc.Get("mf-test", rollout.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.RolloutStatusCompleted, result.Status)
+	assert.NotNil(t, result.CompletedAt)
+	assert.Equal(t, 4, result.Summary.Succeeded)
+	assert.Equal(t, "workspace-1", result.Targets[0].WorkspaceName)
+}
+
+func TestManifestRolloutFailureThresholdPause(t *testing.T) {
+	db := setupManifestRolloutTestDB(t, 4)
+	svc := NewManifestRolloutService(db)
+
+	rollout, err := svc.Create("mf-test", &models.CreateManifestRolloutRequest{
+		VersionID: "v1",
+		Selector:  models.ManifestRolloutSelector{WorkspaceIDs: []string{"ws-1", "ws-2", "ws-3", "ws-4"}},
+		Strategy:  models.ManifestRolloutStrategy{Percentages: []int{50}, FailureThreshold: 0},
+	}, "u-2")
+	require.NoError(t, err)
+
+	require.NoError(t, svc.Process(rollout.ID))
+	finishRolloutTasks(t, db, rollout.ID, "ws-2")
+	require.NoError(t, svc.Process(rollout.ID))
+
+	stored := loadRollout(t, db, rollout.ID)
+	assert.Equal(t, models.RolloutStatusPaused, stored.Status)
+	assert.Contains(t, stored.Message, "1 of 2 workspaces failed in wave 1")
+	targets := loadRolloutTargets(t, db, rollout.ID)
+	assert.Equal(t, models.RolloutTargetStatusFailed, targets[1].Status)
+	assert.Contains(t, targets[1].Error, "failed")
+	assert.Equal(t, models.RolloutTargetStatusPending, targets[2].Status)
+
+	var deployment models.ManifestDeployment
+	require.NoError(t, db.Where("id = ?", *targets[1].DeploymentID).First(&deployment).Error)
+	assert.Equal(t, models.DeploymentStatusFailed, deployment.Status)
+
+	// 重试失败的 workspace：重新部署（失败的部署在同一版本上重新执行）
+	resumed, err := svc.Resume("mf-test", rollout.ID, true)
+	require.NoError(t, err)
+	assert.Equal(t, models.RolloutStatusRunning, resumed.Status)
+	require.NoError(t, svc.Process(rollout.ID))
+	targets = loadRolloutTargets(t, db, rollout.ID)
+	assert.Equal(t, models.RolloutTargetStatusRunning, targets[1].Status)
+	assert.Equal(t, *targets[1].DeploymentID, deployment.ID)
+
+	// 再次失败后接受失败继续推进
+	finishRolloutTasks(t, db, rollout.ID, "ws-2")
+	require.NoError(t, svc.Process(rollout.ID))
+	assert.Equal(t, models.RolloutStatusPaused, loadRollout(t, db, rollout.ID).Status)
+	_, err = svc.Resume("mf-test", rollout.ID, false)
+	require.NoError(t, err)
+	require.NoError(t, svc.Process(rollout.ID))
+	stored = loadRollout(t, db, rollout.ID)
+	assert.Equal(t, 1, stored.CurrentWave)
+	assert.Equal(t, 0, stored.AcceptedFailures)
+
+	finishRolloutTasks(t, db, rollout.ID)
+	require.NoError(t, svc.Process(rollout.ID))
+	result, err := svc.Get("mf-test", rollout.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.RolloutStatusCompleted, result.Status)
+	assert.Equal(t, 3, result.Summary.Succeeded)
+	assert.Equal(t, 1, result.Summary.Failed)
+}
+
+func TestManifestRolloutFailureThresholdStop(t *testing.T) {
+	db := setupManifestRolloutTestDB(t, 4)
+	svc := NewManifestRolloutService(db)
+
+	// ws-2 中已存在配置不同的同名资源，fail 策略下首次部署冲突
+	require.NoError(t, db.Omit("Tags").Create(&models.WorkspaceResource{
+		WorkspaceID: "ws-2", ResourceID: "module.vpc", ResourceType: "AWS_vpc", ResourceName: "vpc", IsActive: true,
+	}).Error)
+	resource := loadTestResource(t, db, "vpc")
+	version := models.ResourceCodeVersion{ResourceID: resource.ID, Version: 1, IsLatest: true,
+		TFCode: models.JSONB{"module": map[string]interface{}{"vpc": []interface{}{map[string]interface{}{"source": "local"}}}}}
+	require.NoError(t, db.Create(&version).Error)
+	require.NoError(t, db.Model(&resource).Update("current_version_id", version.ID).Error)
+
+	rollout, err := svc.Create("mf-test", &models.CreateManifestRolloutRequest{
+		VersionID: "v1",
+		Selector:  models.ManifestRolloutSelector{WorkspaceIDs: []string{"ws-1", "ws-2", "ws-3", "ws-4"}},
+		Strategy: models.ManifestRolloutStrategy{
+			Percentages: []int{50}, FailureThreshold: 40, FailureAction: models.RolloutFailureActionStop,
+		},
+	}, "u-2")
+	require.NoError(t, err)
+
+	require.NoError(t, svc.Process(rollout.ID))
+	stored := loadRollout(t, db, rollout.ID)
+	assert.Equal(t, models.RolloutStatusStopped, stored.Status)
+	assert.NotNil(t, stored.CompletedAt)
+
+	targets := loadRolloutTargets(t, db, rollout.ID)
+	assert.Equal(t, models.RolloutTargetStatusRunning, targets[0].Status)
+	assert.Equal(t, models.RolloutTargetStatusFailed, targets[1].Status)
+	assert.Contains(t, targets[1].Error, ErrManifestUpgradeConflict.Error())
+	assert.Equal(t, models.RolloutTargetStatusSkipped, targets[2].Status)
+	assert.Equal(t, models.RolloutTargetStatusSkipped, targets[3].Status)
+
+	// 停止后已启动的任务仍被跟踪
+	finishRolloutTasks(t, db, rollout.ID)
+	NewManifestRolloutService(db).ProcessAll(t.Context())
+	assert.Equal(t, models.RolloutTargetStatusSucceeded, loadRolloutTargets(t, db, rollout.ID)[0].Status)
+	assert.Equal(t, models.RolloutStatusStopped, loadRollout(t, db, rollout.ID).Status)
+
+	_, err = svc.Cancel("mf-test", rollout.ID, "u-2")
+	assert.ErrorIs(t, err, ErrManifestRolloutState)
+}
+
+func TestManifestRolloutResumesInterruptedTarget(t *testing.T) {
+	db := setupManifestRolloutTestDB(t, 2)
+	svc := NewManifestRolloutService(db)
+
+	rollout, err := svc.Create("mf-test", &models.CreateManifestRolloutRequest{
+		VersionID: "v1",
+		Selector:  models.ManifestRolloutSelector{Tags: map[string]string{"env": "prod"}},
+	}, "u-2")
+	require.NoError(t, err)
+	target := loadRolloutTargets(t, db, rollout.ID)[0]
+	assert.Equal(t, "ws-1", target.WorkspaceKey)
+
+	// 模拟上一个 leader 创建部署并完成资源变更后、创建任务前退出
+	require.NoError(t, db.Create(&models.ManifestDeployment{
+		ID: "mfd-interrupted", ManifestID: "mf-test", VersionID: "v1", WorkspaceID: 1,
+		Status: models.DeploymentStatusPending, DeployedBy: "u-2",
+	}).Error)
+	_, err = svc.upgrades.InstallDeployment("mfd-interrupted", "", "u-2")
+	require.NoError(t, err)
+	require.NoError(t, db.Model(&models.ManifestRolloutTarget{}).Where("id = ?", target.ID).Updates(map[string]interface{}{
+		"status": models.RolloutTargetStatusRunning, "deployment_id": "mfd-interrupted", "action": models.RolloutActionInstall,
+	}).Error)
+
+	// 新 leader 接管：只补建任务，不重复部署
+	NewManifestRolloutService(db).ProcessAll(t.Context())
+	target = loadRolloutTargets(t, db, rollout.ID)[0]
+	assert.Equal(t, models.RolloutTargetStatusRunning, target.Status)
+	require.NotNil(t, target.TaskID)
+
+	var deployments int64
+	require.NoError(t, db.Model(&models.ManifestDeployment{}).Where("workspace_id = ?", 1).Count(&deployments).Error)
+	assert.Equal(t, int64(1), deployments)
+	var deployment models.ManifestDeployment
+	require.NoError(t, db.Where("id = ?", "mfd-interrupted").First(&deployment).Error)
+	// 任务结束前部署保持 deploying
+	assert.Equal(t, models.DeploymentStatusDeploying, deployment.Status)
+	assert.Equal(t, int(*target.TaskID), *deployment.LastTaskID)
+
+	// 任务已创建但未写入目标时再次接管：沿用该任务，不重复创建
+	taskID := *target.TaskID
+	require.NoError(t, db.Model(&models.ManifestRolloutTarget{}).Where("id = ?", target.ID).Update("task_id", nil).Error)
+	NewManifestRolloutService(db).ProcessAll(t.Context())
+	target = loadRolloutTargets(t, db, rollout.ID)[0]
+	require.NotNil(t, target.TaskID)
+	assert.Equal(t, taskID, *target.TaskID)
+	var tasks int64
+	require.NoError(t, db.Model(&models.WorkspaceTask{}).Where("workspace_id = ?", "ws-1").Count(&tasks).Error)
+	assert.Equal(t, int64(1), tasks)
+
+	// 取消后未开始的目标标记为 skipped
+	cancelled, err := svc.Cancel("mf-test", rollout.ID, "u-2")
+	require.NoError(t, err)
+	assert.Equal(t, models.RolloutStatusCancelled, cancelled.Status)
+	assert.Equal(t, 1, cancelled.Summary.Running)
+}
+
+func TestManifestRolloutDeploymentFollowsTaskResult(t *testing.T) {
+	db := setupManifestRolloutTestDB(t, 3)
+	svc := NewManifestRolloutService(db)
+
+	rollout, err := svc.Create("mf-test", &models.CreateManifestRolloutRequest{
+		VersionID: "v1",
+		Selector:  models.ManifestRolloutSelector{WorkspaceIDs: []string{"ws-1", "ws-2", "ws-3"}},
+		Strategy:  models.ManifestRolloutStrategy{FailureThreshold: 50},
+	}, "u-2")
+	require.NoError(t, err)
+	require.NoError(t, svc.Process(rollout.ID))
+
+	deploymentStatus := func(workspaceID int) string {
+		var deployment models.ManifestDeployment
+		require.NoError(t, db.Where("workspace_id = ?", workspaceID).First(&deployment).Error)
+		return deployment.Status
+	}
+	for i := 1; i <= 3; i++ {
+		assert.Equal(t, models.DeploymentStatusDeploying, deploymentStatus(i))
+	}
+
+	// ws-1 成功，ws-2 失败，ws-3 等待确认 Apply
+	targets := loadRolloutTargets(t, db, rollout.ID)
+	require.Len(t, targets, 3)
+	statuses := []models.TaskStatus{models.TaskStatusApplied, models.TaskStatusCancelled, models.TaskStatusApplyPending}
+	for i, target := range targets {
+		require.NotNil(t, target.TaskID)
+		require.NoError(t, db.Exec(`UPDATE workspace_tasks SET status = ? WHERE id = ?`, statuses[i], *target.TaskID).Error)
+	}
+	require.NoError(t, svc.Process(rollout.ID))
+
+	assert.Equal(t, models.DeploymentStatusDeployed, deploymentStatus(1))
+	assert.Equal(t, models.DeploymentStatusFailed, deploymentStatus(2))
+	assert.Equal(t, models.DeploymentStatusDeploying, deploymentStatus(3))
+	targets = loadRolloutTargets(t, db, rollout.ID)
+	assert.Equal(t, models.RolloutTargetStatusSucceeded, targets[0].Status)
+	assert.Equal(t, models.RolloutTargetStatusFailed, targets[1].Status)
+	assert.Equal(t, models.RolloutTargetStatusRunning, targets[2].Status)
+}
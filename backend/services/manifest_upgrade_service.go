@@ -0,0 +1,808 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"iac-platform/internal/infrastructure"
+	"iac-platform/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrManifestDeploymentNotFound = errors.New("manifest deployment not found")
+	ErrManifestVersionNotFound    = errors.New("manifest version not found")
+	ErrManifestUpgradeInvalid     = errors.New("invalid manifest upgrade")
+	ErrManifestUpgradeConflict    = errors.New("manifest upgrade has unresolved conflicts")
+)
+
+// ManifestUpgradeService Manifest 部署升级/回滚服务
+// 通过三方对比（当前部署版本、目标版本、workspace 中资源的当前配置）计算变更，
+// 在一个事务中更新资源代码版本，由调用方创建一个 plan_and_apply 任务执行
+type ManifestUpgradeService struct {
+	db *gorm.DB
+}
+
+// NewManifestUpgradeService 创建 Manifest 升级服务
+func NewManifestUpgradeService(db *gorm.DB) *ManifestUpgradeService {
+	return &ManifestUpgradeService{db: db}
+}
+
+// manifestUpgradeItem 单个节点的计划及应用时需要的数据
+type manifestUpgradeItem struct {
+	change   *models.ManifestUpgradeChange
+	node     *models.ManifestNode               // 目标版本节点，remove 时为 nil
+	target   models.JSONB                       // 目标版本生成的 TF 代码
+	local    map[string]interface{}             // 当前资源的 module 配置
+	merged   map[string]interface{}             // 三方合并结果，冲突参数取目标版本的值
+	resource *models.WorkspaceResource          // workspace 中的资源，不存在时为 nil
+	link     *models.ManifestDeploymentResource // 部署资源关联，不存在时为 nil
+}
+
+type manifestUpgrade struct {
+	plan         *models.ManifestUpgradePlan
+	deployment   models.ManifestDeployment
+	manifestName string
+	workspaceID  string
+	items        []*manifestUpgradeItem
+}
+
+// PlanUpgrade 计算部署升级到目标版本的变更（不做任何修改）
+func (s *ManifestUpgradeService) PlanUpgrade(deploymentID, targetVersionID string) (*models.ManifestUpgradePlan, error) {
+	upgrade, err := s.prepare(s.db, deploymentID, targetVersionID, false)
+	if err != nil {
+		return nil, err
+	}
+	return upgrade.plan, nil
+}
+
+// ApplyUpgrade 将部署升级到目标版本
+// strategy 为 fail 时存在冲突则返回 ErrManifestUpgradeConflict（同时返回计划）；
+// overwrite 以目标版本为准，keep_local 保留本地修改。
+// 成功后部署的 previous_version_id 指向升级前的版本，状态置为 deploying，调用方负责创建任务
+func (s *ManifestUpgradeService) ApplyUpgrade(deploymentID, targetVersionID, strategy, userID string) (*models.ManifestUpgradePlan, error) {
+	if err := validateUpgradeStrategy(&strategy); err != nil {
+		return nil, err
+	}
+
+	var plan *models.ManifestUpgradePlan
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		upgrade, err := s.prepare(tx, deploymentID, targetVersionID, false)
+		if err != nil {
+			return err
+		}
+		plan = upgrade.plan
+		summary := fmt.Sprintf("Updated by Manifest [%s] upgrade %s -> %s", upgrade.manifestName, plan.FromVersion, plan.ToVersion)
+		if err := s.apply(tx, upgrade, strategy, summary, userID); err != nil {
+			return err
+		}
+
+		return tx.Model(&models.ManifestDeployment{}).Where("id = ?", deploymentID).Updates(map[string]interface{}{
+			"previous_version_id": plan.FromVersionID,
+			"version_id":          plan.ToVersionID,
+			"status":              models.DeploymentStatusDeploying,
+			"last_task_id":        nil, // 新任务创建后写入，SyncDeploymentStatuses 不会按上一次的任务结果结束本次部署
+			"deployed_by":         userID,
+			"deployed_at":         time.Now(),
+		}).Error
+	})
+	return plan, err
+}
+
+// InstallDeployment 首次部署：以空版本为基准对比部署记录上的版本，创建全部资源
+// workspace 中已存在同名资源且配置不同时视为冲突，处理方式与升级相同
+func (s *ManifestUpgradeService) InstallDeployment(deploymentID, strategy, userID string) (*models.ManifestUpgradePlan, error) {
+	if err := validateUpgradeStrategy(&strategy); err != nil {
+		return nil, err
+	}
+
+	var plan *models.ManifestUpgradePlan
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var deployment models.ManifestDeployment
+		if err := tx.Select("version_id").Where("id = ?", deploymentID).First(&deployment).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrManifestDeploymentNotFound
+			}
+			return err
+		}
+		upgrade, err := s.prepare(tx, deploymentID, deployment.VersionID, true)
+		if err != nil {
+			return err
+		}
+		plan = upgrade.plan
+		summary := fmt.Sprintf("Created by Manifest [%s] version [%s] deployment", upgrade.manifestName, plan.ToVersion)
+		if err := s.apply(tx, upgrade, strategy, summary, userID); err != nil {
+			return err
+		}
+
+		return tx.Model(&models.ManifestDeployment{}).Where("id = ?", deploymentID).Updates(map[string]interface{}{
+			"status":       models.DeploymentStatusDeploying,
+			"last_task_id": nil,
+			"deployed_by":  userID,
+			"deployed_at":  time.Now(),
+		}).Error
+	})
+	return plan, err
+}
+
+// apply 校验冲突后逐个应用节点变更
+func (s *ManifestUpgradeService) apply(tx *gorm.DB, upgrade *manifestUpgrade, strategy, summary, userID string) error {
+	plan := upgrade.plan
+	if plan.HasConflicts && strategy == models.UpgradeStrategyFail {
+		return fmt.Errorf("%w: %d conflicting nodes", ErrManifestUpgradeConflict, plan.Summary.Conflicts)
+	}
+	plan.AppliedStrategy = strategy
+
+	for _, item := range upgrade.items {
+		if err := s.applyItem(tx, upgrade, item, strategy, summary, userID); err != nil {
+			return fmt.Errorf("failed to apply %s: %w", item.change.ResourceID, err)
+		}
+	}
+	return nil
+}
+
+func validateUpgradeStrategy(strategy *string) error {
+	if *strategy == "" {
+		*strategy = models.UpgradeStrategyFail
+	}
+	switch *strategy {
+	case models.UpgradeStrategyFail, models.UpgradeStrategyOverwrite, models.UpgradeStrategyKeepLocal:
+		return nil
+	}
+	return fmt.Errorf("%w: unknown conflict strategy %q", ErrManifestUpgradeInvalid, *strategy)
+}
+
+// RollbackVersionID 返回部署可回滚到的版本
+func (s *ManifestUpgradeService) RollbackVersionID(deploymentID string) (string, error) {
+	var deployment models.ManifestDeployment
+	if err := s.db.Where("id = ?", deploymentID).First(&deployment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", ErrManifestDeploymentNotFound
+		}
+		return "", err
+	}
+	if deployment.PreviousVersionID == nil || *deployment.PreviousVersionID == "" {
+		return "", fmt.Errorf("%w: deployment has no previous version to roll back to", ErrManifestUpgradeInvalid)
+	}
+	return *deployment.PreviousVersionID, nil
+}
+
+// DeploymentStatusForTask 根据部署任务的状态返回部署应处的状态，任务未结束时返回空字符串（保持 deploying）
+func DeploymentStatusForTask(taskStatus models.TaskStatus) string {
+	switch taskStatus {
+	case models.TaskStatusApplied, models.TaskStatusPlannedAndFinished, models.TaskStatusSuccess:
+		return models.DeploymentStatusDeployed
+	case models.TaskStatusFailed, models.TaskStatusCancelled:
+		return models.DeploymentStatusFailed
+	}
+	return ""
+}
+
+// SyncDeploymentStatuses 将处于 deploying 的部署按其最后一个任务的结果更新为 deployed 或 failed
+// 由 ManifestRolloutWorker 定期调用；任务记录不存在时视为失败
+func (s *ManifestUpgradeService) SyncDeploymentStatuses() error {
+	var deployments []models.ManifestDeployment
+	if err := s.db.Select("id, last_task_id").
+		Where("status = ? AND last_task_id IS NOT NULL", models.DeploymentStatusDeploying).
+		Find(&deployments).Error; err != nil {
+		return err
+	}
+
+	for _, deployment := range deployments {
+		var task models.WorkspaceTask
+		status := ""
+		if err := s.db.Select("id, status").Where("id = ?", *deployment.LastTaskID).First(&task).Error; err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			status = models.DeploymentStatusFailed
+		} else {
+			status = DeploymentStatusForTask(task.Status)
+		}
+		if status == "" {
+			continue
+		}
+		// 只在部署仍指向同一个任务时更新，避免覆盖期间发起的新部署
+		if err := s.db.Model(&models.ManifestDeployment{}).
+			Where("id = ? AND status = ? AND last_task_id = ?", deployment.ID, models.DeploymentStatusDeploying, *deployment.LastTaskID).
+			Update("status", status).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// prepare 加载数据并计算三方对比结果
+// install 为 true 时以空版本为基准（首次部署），部署记录上的版本即目标版本
+func (s *ManifestUpgradeService) prepare(db *gorm.DB, deploymentID, targetVersionID string, install bool) (*manifestUpgrade, error) {
+	var deployment models.ManifestDeployment
+	if err := db.Where("id = ?", deploymentID).First(&deployment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrManifestDeploymentNotFound
+		}
+		return nil, err
+	}
+	switch deployment.Status {
+	case models.DeploymentStatusArchived:
+		return nil, fmt.Errorf("%w: deployment is archived", ErrManifestUpgradeInvalid)
+	case models.DeploymentStatusDeploying:
+		return nil, fmt.Errorf("%w: deployment is in progress", ErrManifestUpgradeInvalid)
+	}
+	if !install && targetVersionID == deployment.VersionID {
+		return nil, fmt.Errorf("%w: deployment is already on this version", ErrManifestUpgradeInvalid)
+	}
+
+	var toVersion models.ManifestVersion
+	if err := db.Where("id = ? AND manifest_id = ?", targetVersionID, deployment.ManifestID).First(&toVersion).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrManifestVersionNotFound
+		}
+		return nil, err
+	}
+	if toVersion.IsDraft {
+		return nil, fmt.Errorf("%w: only published versions can be deployed", ErrManifestUpgradeInvalid)
+	}
+
+	// 当前部署版本缺失时按空版本处理，所有节点都视为新增
+	var fromVersion models.ManifestVersion
+	if install {
+		deployment.VersionID = ""
+	} else if err := db.Where("id = ?", deployment.VersionID).First(&fromVersion).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	var manifest models.Manifest
+	if err := db.Select("name").Where("id = ?", deployment.ManifestID).First(&manifest).Error; err != nil {
+		return nil, err
+	}
+	var workspace models.Workspace
+	if err := db.Select("workspace_id").Where("id = ?", deployment.WorkspaceID).First(&workspace).Error; err != nil {
+		return nil, fmt.Errorf("failed to get workspace: %w", err)
+	}
+
+	fromNodes, err := manifestModuleNodes(fromVersion.Nodes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid nodes in version %s: %w", fromVersion.Version, err)
+	}
+	toNodes, err := manifestModuleNodes(toVersion.Nodes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid nodes in version %s: %w", toVersion.Version, err)
+	}
+
+	var links []models.ManifestDeploymentResource
+	if err := db.Where("deployment_id = ?", deploymentID).Find(&links).Error; err != nil {
+		return nil, err
+	}
+	linkByResource := make(map[string]*models.ManifestDeploymentResource, len(links))
+	for i := range links {
+		linkByResource[links[i].ResourceID] = &links[i]
+	}
+
+	upgrade := &manifestUpgrade{
+		plan: &models.ManifestUpgradePlan{
+			DeploymentID:  deployment.ID,
+			FromVersionID: deployment.VersionID,
+			FromVersion:   fromVersion.Version,
+			ToVersionID:   toVersion.ID,
+			ToVersion:     toVersion.Version,
+			Changes:       []models.ManifestUpgradeChange{},
+		},
+		deployment:   deployment,
+		manifestName: manifest.Name,
+		workspaceID:  workspace.WorkspaceID,
+	}
+
+	baseByInstance := make(map[string]models.ManifestNode, len(fromNodes))
+	for _, node := range fromNodes {
+		baseByInstance[node.InstanceName] = node
+	}
+	inTarget := make(map[string]bool, len(toNodes))
+
+	for i := range toNodes {
+		node := &toNodes[i]
+		inTarget[node.InstanceName] = true
+		item, err := s.loadItem(db, upgrade, node.ID, node.InstanceName, linkByResource)
+		if err != nil {
+			return nil, err
+		}
+		item.node = node
+		item.target = BuildManifestModuleTFCode(*node)
+		target := manifestModuleConfig(item.target, node.InstanceName)
+
+		if baseNode, ok := baseByInstance[node.InstanceName]; ok {
+			base := manifestModuleConfig(BuildManifestModuleTFCode(baseNode), node.InstanceName)
+			item.change.ChangedKeys = diffConfigKeys(base, target)
+			item.change.Action = models.UpgradeActionUnchanged
+			if len(item.change.ChangedKeys) > 0 {
+				item.change.Action = models.UpgradeActionChange
+			}
+			s.detectLocalChanges(item, base)
+			switch {
+			case item.resource == nil || !item.resource.IsActive:
+				item.change.Conflict = models.UpgradeConflictResourceMissing
+			case item.change.LocallyModified:
+				item.merged, item.change.ConflictKeys = mergeModuleConfig(base, item.local, target)
+				if len(item.change.ConflictKeys) > 0 {
+					item.change.Conflict = models.UpgradeConflictLocallyModified
+				}
+			}
+		} else {
+			item.change.Action = models.UpgradeActionAdd
+			item.change.ChangedKeys = diffConfigKeys(nil, target)
+			ownResource := item.resource != nil && item.resource.ManifestDeploymentID != nil && *item.resource.ManifestDeploymentID == deployment.ID
+			if item.resource != nil && item.resource.IsActive && !ownResource {
+				if keys := diffConfigKeys(item.local, target); len(keys) > 0 {
+					item.change.Conflict = models.UpgradeConflictResourceExists
+					item.change.LocalKeys = keys
+					item.change.ConflictKeys = keys
+				}
+			}
+		}
+		upgrade.items = append(upgrade.items, item)
+	}
+
+	for _, baseNode := range fromNodes {
+		if inTarget[baseNode.InstanceName] {
+			continue
+		}
+		item, err := s.loadItem(db, upgrade, baseNode.ID, baseNode.InstanceName, linkByResource)
+		if err != nil {
+			return nil, err
+		}
+		item.change.Action = models.UpgradeActionRemove
+		if item.resource != nil && item.resource.IsActive {
+			base := manifestModuleConfig(BuildManifestModuleTFCode(baseNode), baseNode.InstanceName)
+			s.detectLocalChanges(item, base)
+			if item.change.LocallyModified {
+				item.change.Conflict = models.UpgradeConflictLocallyModified
+				item.change.ConflictKeys = item.change.LocalKeys
+			}
+		}
+		upgrade.items = append(upgrade.items, item)
+	}
+
+	for _, item := range upgrade.items {
+		switch item.change.Action {
+		case models.UpgradeActionAdd:
+			upgrade.plan.Summary.Add++
+		case models.UpgradeActionChange:
+			upgrade.plan.Summary.Change++
+		case models.UpgradeActionRemove:
+			upgrade.plan.Summary.Remove++
+		default:
+			upgrade.plan.Summary.Unchanged++
+		}
+		if item.change.Conflict != "" {
+			upgrade.plan.Summary.Conflicts++
+		}
+		upgrade.plan.Changes = append(upgrade.plan.Changes, *item.change)
+	}
+	upgrade.plan.HasConflicts = upgrade.plan.Summary.Conflicts > 0
+
+	// 计划中的 Changes 是副本，应用时回写处理方式
+	for i, item := range upgrade.items {
+		item.change = &upgrade.plan.Changes[i]
+	}
+	return upgrade, nil
+}
+
+// loadItem 加载节点对应的 workspace 资源及部署关联
+func (s *ManifestUpgradeService) loadItem(db *gorm.DB, upgrade *manifestUpgrade, nodeID, instanceName string, links map[string]*models.ManifestDeploymentResource) (*manifestUpgradeItem, error) {
+	resourceID := fmt.Sprintf("module.%s", instanceName)
+	item := &manifestUpgradeItem{
+		change: &models.ManifestUpgradeChange{
+			NodeID:       nodeID,
+			InstanceName: instanceName,
+			ResourceID:   resourceID,
+		},
+		link: links[resourceID],
+	}
+
+	var resource models.WorkspaceResource
+	err := db.Omit("Tags").Where("workspace_id = ? AND resource_id = ?", upgrade.workspaceID, resourceID).First(&resource).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if err == nil {
+		item.resource = &resource
+		if resource.CurrentVersionID != nil {
+			var codeVersion models.ResourceCodeVersion
+			if err := db.First(&codeVersion, *resource.CurrentVersionID).Error; err == nil {
+				item.local = manifestModuleConfig(codeVersion.TFCode, instanceName)
+				if item.link != nil && item.link.ConfigHash != "" {
+					item.change.LocallyModified = item.link.ConfigHash != ManifestTFCodeHash(codeVersion.TFCode)
+				}
+			}
+		}
+	}
+	return item, nil
+}
+
+// detectLocalChanges 对比资源当前配置与部署版本，标记本地修改
+// 有 ConfigHash 时以 hash 为准，旧部署没有记录 hash 时直接比较配置
+func (s *ManifestUpgradeService) detectLocalChanges(item *manifestUpgradeItem, base map[string]interface{}) {
+	if item.resource == nil || !item.resource.IsActive {
+		item.change.LocallyModified = item.resource != nil || item.link != nil
+		return
+	}
+	item.change.LocalKeys = diffConfigKeys(base, item.local)
+	if item.link == nil || item.link.ConfigHash == "" {
+		item.change.LocallyModified = len(item.change.LocalKeys) > 0
+	}
+	if !item.change.LocallyModified {
+		// hash 一致时本地配置就是部署版本
+		item.change.LocalKeys = nil
+		item.local = base
+	}
+}
+
+// applyItem 应用单个节点的变更
+func (s *ManifestUpgradeService) applyItem(tx *gorm.DB, upgrade *manifestUpgrade, item *manifestUpgradeItem, strategy, summary, userID string) error {
+	change := item.change
+	keepLocal := change.Conflict != "" && strategy == models.UpgradeStrategyKeepLocal
+
+	if change.Action == models.UpgradeActionRemove {
+		if keepLocal {
+			// 保留本地修改：资源脱离部署
+			change.Resolution = models.UpgradeStrategyKeepLocal
+			if err := tx.Model(&models.WorkspaceResource{}).Where("id = ?", item.resource.ID).
+				Update("manifest_deployment_id", nil).Error; err != nil {
+				return err
+			}
+			return s.deleteLink(tx, item)
+		}
+		if item.resource != nil && item.resource.IsActive {
+			if change.Conflict != "" {
+				change.Resolution = models.UpgradeStrategyOverwrite
+			}
+			// 软删除，保留代码版本以便回滚时恢复
+			if err := tx.Where("workspace_id = ? AND resource_name = ?", upgrade.workspaceID, item.resource.ResourceName).
+				Delete(&models.WorkspaceOutput{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.WorkspaceResource{}).Where("id = ?", item.resource.ID).
+				Update("is_active", false).Error; err != nil {
+				return err
+			}
+		}
+		return s.deleteLink(tx, item)
+	}
+
+	config := manifestModuleConfig(item.target, change.InstanceName)
+	switch {
+	case keepLocal && (item.resource == nil || !item.resource.IsActive):
+		// 本地删除了资源：保持删除并移除关联
+		change.Resolution = models.UpgradeStrategyKeepLocal
+		return s.deleteLink(tx, item)
+	case keepLocal && change.Action == models.UpgradeActionAdd:
+		// 已存在的资源保持原样，只纳入部署
+		change.Resolution = models.UpgradeStrategyKeepLocal
+		config = item.local
+	case keepLocal:
+		// 无冲突的参数仍然合并目标版本，冲突参数保留本地值
+		change.Resolution = models.UpgradeStrategyKeepLocal
+		config = make(map[string]interface{}, len(item.merged))
+		for key, value := range item.merged {
+			config[key] = value
+		}
+		for _, key := range change.ConflictKeys {
+			if value, ok := item.local[key]; ok {
+				config[key] = value
+			} else {
+				delete(config, key)
+			}
+		}
+	case change.Conflict != "":
+		change.Resolution = models.UpgradeStrategyOverwrite
+	case change.LocallyModified && item.merged != nil:
+		change.Resolution = "merge"
+		config = item.merged
+	}
+
+	if item.resource == nil {
+		if err := s.createResource(tx, upgrade, item, summary, userID); err != nil {
+			return err
+		}
+	} else if err := s.writeModuleConfig(tx, upgrade, item, config, summary, userID); err != nil {
+		return err
+	}
+	return s.upsertLink(tx, upgrade, item)
+}
+
+// writeModuleConfig 配置与资源当前版本不同时写入新的代码版本，并确保资源处于启用状态且归属该部署
+func (s *ManifestUpgradeService) writeModuleConfig(tx *gorm.DB, upgrade *manifestUpgrade, item *manifestUpgradeItem, config map[string]interface{}, summary, userID string) error {
+	resource := item.resource
+	updates := map[string]interface{}{"manifest_deployment_id": upgrade.deployment.ID}
+	if !resource.IsActive {
+		updates["is_active"] = true
+	}
+
+	if !resource.IsActive || !reflect.DeepEqual(normalizeJSON(config), normalizeJSON(item.local)) {
+		var maxVersion int
+		tx.Model(&models.ResourceCodeVersion{}).
+			Where("resource_id = ?", resource.ID).
+			Select("COALESCE(MAX(version), 0)").
+			Scan(&maxVersion)
+
+		codeVersion := models.ResourceCodeVersion{
+			ResourceID:    resource.ID,
+			Version:       maxVersion + 1,
+			IsLatest:      true,
+			TFCode:        models.JSONB{"module": map[string]interface{}{item.change.InstanceName: []interface{}{config}}},
+			Variables:     models.JSONB(item.node.Config),
+			ChangeSummary: summary,
+			ChangeType:    "update",
+			CreatedBy:     &userID,
+		}
+		if err := tx.Model(&models.ResourceCodeVersion{}).
+			Where("resource_id = ? AND is_latest = ?", resource.ID, true).
+			Update("is_latest", false).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&codeVersion).Error; err != nil {
+			return err
+		}
+		updates["current_version_id"] = codeVersion.ID
+		updates["description"] = summary
+	}
+
+	return tx.Model(&models.WorkspaceResource{}).Where("id = ?", resource.ID).Updates(updates).Error
+}
+
+// createResource 创建目标版本新增的资源
+func (s *ManifestUpgradeService) createResource(tx *gorm.DB, upgrade *manifestUpgrade, item *manifestUpgradeItem, summary, userID string) error {
+	deploymentID := upgrade.deployment.ID
+	resource := models.WorkspaceResource{
+		WorkspaceID:          upgrade.workspaceID,
+		ResourceID:           item.change.ResourceID,
+		ResourceType:         ManifestResourceType(item.node.ModuleSource, item.node.ResourceName),
+		ResourceName:         item.node.InstanceName,
+		IsActive:             true,
+		Description:          summary,
+		ManifestDeploymentID: &deploymentID,
+		CreatedBy:            &userID,
+	}
+	// 部署创建的资源不带 tags，交给数据库默认值
+	if err := tx.Omit("Tags").Create(&resource).Error; err != nil {
+		return err
+	}
+
+	codeVersion := models.ResourceCodeVersion{
+		ResourceID:    resource.ID,
+		Version:       1,
+		IsLatest:      true,
+		TFCode:        item.target,
+		Variables:     models.JSONB(item.node.Config),
+		ChangeSummary: summary,
+		ChangeType:    "create",
+		CreatedBy:     &userID,
+	}
+	if err := tx.Create(&codeVersion).Error; err != nil {
+		return err
+	}
+	return tx.Model(&resource).Update("current_version_id", codeVersion.ID).Error
+}
+
+// upsertLink 更新部署资源关联，ConfigHash 记录目标版本生成的代码，后续用于检测本地修改
+func (s *ManifestUpgradeService) upsertLink(tx *gorm.DB, upgrade *manifestUpgrade, item *manifestUpgradeItem) error {
+	hash := ManifestTFCodeHash(item.target)
+	if item.link != nil {
+		return tx.Model(&models.ManifestDeploymentResource{}).Where("id = ?", item.link.ID).Updates(map[string]interface{}{
+			"node_id":     item.change.NodeID,
+			"config_hash": hash,
+		}).Error
+	}
+	id, err := infrastructure.GenerateManifestDeploymentResourceID()
+	if err != nil {
+		return err
+	}
+	return tx.Create(&models.ManifestDeploymentResource{
+		ID:           id,
+		DeploymentID: upgrade.deployment.ID,
+		NodeID:       item.change.NodeID,
+		ResourceID:   item.change.ResourceID,
+		ConfigHash:   hash,
+	}).Error
+}
+
+func (s *ManifestUpgradeService) deleteLink(tx *gorm.DB, item *manifestUpgradeItem) error {
+	if item.link == nil {
+		return nil
+	}
+	return tx.Where("id = ?", item.link.ID).Delete(&models.ManifestDeploymentResource{}).Error
+}
+
+// ========== 共享的 Manifest 资源生成逻辑 ==========
+
+// BuildManifestModuleTFCode 根据 Manifest 节点生成 module 的 TF 代码（Terraform JSON 格式）
+// 格式: { "module": { "instance_name": [{ "source": "...", ... }] } }
+func BuildManifestModuleTFCode(node models.ManifestNode) models.JSONB {
+	moduleConfig := make(map[string]interface{})
+	if node.ModuleSource != "" {
+		moduleConfig["source"] = node.ModuleSource
+	}
+	if node.ModuleVersion != "" {
+		moduleConfig["version"] = node.ModuleVersion
+	}
+
+	// 引用转换为插值语法
+	for key, value := range node.Config {
+		moduleConfig[key] = convertManifestReferences(value)
+	}
+
+	return models.JSONB{
+		"module": map[string]interface{}{
+			node.InstanceName: []interface{}{moduleConfig},
+		},
+	}
+}
+
+// ManifestResourceType 根据 module source 生成资源类型
+// 格式: {cloudProvider}_{moduleName}，如 terraform-aws-modules/ec2-instance/aws + ec2-ff → AWS_ec2-ff
+func ManifestResourceType(moduleSource string, resourceName string) string {
+	moduleName := resourceName
+	if moduleName == "" {
+		moduleName = "module"
+	}
+
+	cloudProvider := "AWS"
+	if moduleSource != "" {
+		parts := strings.Split(moduleSource, "/")
+		// Terraform Registry 格式 namespace/name/provider；私有 Registry（hostname/...）默认 AWS
+		if len(parts) >= 3 && !strings.Contains(parts[0], ".") {
+			cloudProvider = strings.ToUpper(parts[2])
+		}
+	}
+
+	return fmt.Sprintf("%s_%s", cloudProvider, moduleName)
+}
+
+// ManifestTFCodeHash 计算 TF 代码的 hash，用于检测部署后的本地修改
+func ManifestTFCodeHash(tfCode models.JSONB) string {
+	tfCodeJSON, _ := json.Marshal(tfCode)
+	hash := sha256.Sum256(tfCodeJSON)
+	return hex.EncodeToString(hash[:])
+}
+
+// convertManifestReferences 将 var./local./module./data. 引用转换为插值语法
+// 如 "module.xxx.yyy" -> "${module.xxx.yyy}"
+func convertManifestReferences(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		if strings.HasPrefix(v, "var.") || strings.HasPrefix(v, "local.") ||
+			strings.HasPrefix(v, "module.") || strings.HasPrefix(v, "data.") {
+			return fmt.Sprintf("${%s}", v)
+		}
+		return v
+	case map[string]interface{}:
+		result := make(map[string]interface{})
+		for key, val := range v {
+			result[key] = convertManifestReferences(val)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, val := range v {
+			result[i] = convertManifestReferences(val)
+		}
+		return result
+	default:
+		return v
+	}
+}
+
+// manifestModuleNodes 解析版本中的 module 节点
+func manifestModuleNodes(raw json.RawMessage) ([]models.ManifestNode, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var nodes []models.ManifestNode
+	if err := json.Unmarshal(raw, &nodes); err != nil {
+		return nil, err
+	}
+	modules := make([]models.ManifestNode, 0, len(nodes))
+	for _, node := range nodes {
+		if node.Type == models.NodeTypeModule && node.InstanceName != "" {
+			modules = append(modules, node)
+		}
+	}
+	return modules, nil
+}
+
+// manifestModuleConfig 从 TF 代码中取出 module 块配置（JSON 规范化后的副本）
+func manifestModuleConfig(tfCode models.JSONB, instanceName string) map[string]interface{} {
+	normalized, _ := normalizeJSON(map[string]interface{}(tfCode)).(map[string]interface{})
+	modules, _ := normalized["module"].(map[string]interface{})
+	switch block := modules[instanceName].(type) {
+	case []interface{}:
+		if len(block) > 0 {
+			if config, ok := block[0].(map[string]interface{}); ok {
+				return config
+			}
+		}
+	case map[string]interface{}:
+		return block
+	}
+	return map[string]interface{}{}
+}
+
+// mergeModuleConfig 三方合并 module 配置
+// 本地未修改的参数取目标版本，版本未修改的参数保留本地值；两边都修改且不一致的参数为冲突，合并结果中取目标版本的值
+func mergeModuleConfig(base, local, target map[string]interface{}) (map[string]interface{}, []string) {
+	merged := make(map[string]interface{})
+	var conflicts []string
+	for _, key := range unionKeys(base, local, target) {
+		baseValue, inBase := base[key]
+		localValue, inLocal := local[key]
+		targetValue, inTarget := target[key]
+
+		localChanged := inBase != inLocal || !reflect.DeepEqual(baseValue, localValue)
+		targetChanged := inBase != inTarget || !reflect.DeepEqual(baseValue, targetValue)
+
+		switch {
+		case !localChanged || (inLocal == inTarget && reflect.DeepEqual(localValue, targetValue)):
+			if inTarget {
+				merged[key] = targetValue
+			}
+		case !targetChanged:
+			if inLocal {
+				merged[key] = localValue
+			}
+		default:
+			conflicts = append(conflicts, key)
+			if inTarget {
+				merged[key] = targetValue
+			}
+		}
+	}
+	return merged, conflicts
+}
+
+// diffConfigKeys 返回两个配置之间不同的参数名
+func diffConfigKeys(a, b map[string]interface{}) []string {
+	var keys []string
+	for _, key := range unionKeys(a, b) {
+		av, inA := a[key]
+		bv, inB := b[key]
+		if inA != inB || !reflect.DeepEqual(av, bv) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func unionKeys(maps ...map[string]interface{}) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, m := range maps {
+		for key := range m {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// normalizeJSON 通过 JSON 序列化统一数值和集合类型，便于比较
+func normalizeJSON(value interface{}) interface{} {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return value
+	}
+	return normalized
+}
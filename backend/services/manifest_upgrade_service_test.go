@@ -0,0 +1,339 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"iac-platform/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupManifestUpgradeTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+
+	require.NoError(t, db.Exec(`CREATE TABLE workspaces (id INTEGER PRIMARY KEY AUTOINCREMENT, workspace_id TEXT UNIQUE NOT NULL)`).Error)
+	createManifestTestTables(t, db)
+	return db
+}
+
+// createManifestTestTables 创建 Manifest 部署相关的表（不含 workspaces）
+func createManifestTestTables(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	// JSONB 字段使用 BLOB，保证读回为 []byte
+	statements := []string{
+		`CREATE TABLE manifests (
+			id TEXT PRIMARY KEY, organization_id INTEGER, name TEXT, description TEXT, status TEXT,
+			created_by TEXT, created_at DATETIME, updated_at DATETIME)`,
+		`CREATE TABLE manifest_versions (
+			id TEXT PRIMARY KEY, manifest_id TEXT, version TEXT, canvas_data BLOB, nodes BLOB, edges BLOB,
+			variables BLOB, locals BLOB, outputs BLOB, hcl_content TEXT, is_draft INTEGER DEFAULT 0,
+			created_by TEXT, created_at DATETIME)`,
+		`CREATE TABLE manifest_deployments (
+			id TEXT PRIMARY KEY, manifest_id TEXT, version_id TEXT, previous_version_id TEXT, workspace_id INTEGER,
+			variable_overrides BLOB, status TEXT, last_task_id INTEGER, deployed_by TEXT, deployed_at DATETIME,
+			created_at DATETIME, updated_at DATETIME)`,
+		`CREATE TABLE manifest_deployment_resources (
+			id TEXT PRIMARY KEY, deployment_id TEXT, node_id TEXT, resource_id TEXT, config_hash TEXT, created_at DATETIME)`,
+		`CREATE TABLE workspace_resources (
+			id INTEGER PRIMARY KEY AUTOINCREMENT, workspace_id TEXT NOT NULL, resource_id TEXT NOT NULL,
+			resource_type TEXT NOT NULL, resource_name TEXT NOT NULL, current_version_id INTEGER,
+			is_active INTEGER DEFAULT 1, description TEXT, tags BLOB, created_by TEXT,
+			created_at DATETIME, updated_at DATETIME, last_applied_at DATETIME, manifest_deployment_id TEXT)`,
+		`CREATE TABLE resource_code_versions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT, resource_id INTEGER NOT NULL, version INTEGER NOT NULL,
+			is_latest INTEGER DEFAULT 0, tf_code BLOB NOT NULL, variables BLOB, change_summary TEXT,
+			change_type TEXT, diff_from_previous TEXT, state_version_id INTEGER, task_id INTEGER,
+			created_by TEXT, created_at DATETIME)`,
+		`CREATE TABLE workspace_outputs (id INTEGER PRIMARY KEY AUTOINCREMENT, workspace_id TEXT, resource_name TEXT)`,
+	}
+	for _, stmt := range statements {
+		require.NoError(t, db.Exec(stmt).Error)
+	}
+}
+
+func testManifestNode(name string, config map[string]interface{}) models.ManifestNode {
+	return models.ManifestNode{
+		ID:           "node-" + name,
+		Type:         models.NodeTypeModule,
+		InstanceName: name,
+		ResourceName: name,
+		ModuleSource: "terraform-aws-modules/" + name + "/aws",
+		Config:       config,
+	}
+}
+
+func createTestManifestVersion(t *testing.T, db *gorm.DB, id string, nodes ...models.ManifestNode) {
+	t.Helper()
+	nodesJSON, err := json.Marshal(nodes)
+	require.NoError(t, err)
+	require.NoError(t, db.Create(&models.ManifestVersion{
+		ID:         id,
+		ManifestID: "mf-test",
+		Version:    id,
+		CanvasData: json.RawMessage(`{}`),
+		Nodes:      nodesJSON,
+		Edges:      json.RawMessage(`[]`),
+		CreatedBy:  "u-1",
+	}).Error)
+	// is_draft 带默认值 true，零值不会写入（与 PublishManifestVersion 使用原生 SQL 的原因相同）
+	require.NoError(t, db.Model(&models.ManifestVersion{}).Where("id = ?", id).Update("is_draft", false).Error)
+}
+
+// deployTestManifest 模拟 executeDeployment：为 v1 的每个节点创建资源和部署关联
+func deployTestManifest(t *testing.T, db *gorm.DB, nodes ...models.ManifestNode) {
+	t.Helper()
+	require.NoError(t, db.Create(&models.Manifest{ID: "mf-test", OrganizationID: 1, Name: "golden", CreatedBy: "u-1"}).Error)
+	require.NoError(t, db.Exec(`INSERT INTO workspaces (id, workspace_id) VALUES (1, 'ws-test')`).Error)
+	createTestManifestVersion(t, db, "v1", nodes...)
+	require.NoError(t, db.Create(&models.ManifestDeployment{
+		ID: "mfd-test", ManifestID: "mf-test", VersionID: "v1", WorkspaceID: 1,
+		Status: models.DeploymentStatusDeployed, DeployedBy: "u-1",
+	}).Error)
+
+	deploymentID := "mfd-test"
+	for _, node := range nodes {
+		resource := models.WorkspaceResource{
+			WorkspaceID: "ws-test", ResourceID: "module." + node.InstanceName, ResourceType: "AWS_" + node.InstanceName,
+			ResourceName: node.InstanceName, IsActive: true, ManifestDeploymentID: &deploymentID,
+		}
+		require.NoError(t, db.Omit("Tags").Create(&resource).Error)
+		code := BuildManifestModuleTFCode(node)
+		version := models.ResourceCodeVersion{ResourceID: resource.ID, Version: 1, IsLatest: true, TFCode: code}
+		require.NoError(t, db.Create(&version).Error)
+		require.NoError(t, db.Model(&resource).Update("current_version_id", version.ID).Error)
+		require.NoError(t, db.Create(&models.ManifestDeploymentResource{
+			ID: "mdr-" + node.InstanceName, DeploymentID: deploymentID, NodeID: node.ID,
+			ResourceID: resource.ResourceID, ConfigHash: ManifestTFCodeHash(code),
+		}).Error)
+	}
+}
+
+// editTestResource 模拟用户在 workspace 中修改资源
+func editTestResource(t *testing.T, db *gorm.DB, instanceName string, edits map[string]interface{}) {
+	t.Helper()
+	resource := loadTestResource(t, db, instanceName)
+	config := currentTestConfig(t, db, instanceName)
+	for key, value := range edits {
+		config[key] = value
+	}
+	version := models.ResourceCodeVersion{
+		ResourceID: resource.ID, Version: 2, IsLatest: true,
+		TFCode: models.JSONB{"module": map[string]interface{}{instanceName: []interface{}{config}}},
+	}
+	require.NoError(t, db.Create(&version).Error)
+	require.NoError(t, db.Model(&models.WorkspaceResource{}).Where("id = ?", resource.ID).Update("current_version_id", version.ID).Error)
+}
+
+func loadTestResource(t *testing.T, db *gorm.DB, instanceName string) models.WorkspaceResource {
+	t.Helper()
+	var resource models.WorkspaceResource
+	require.NoError(t, db.Omit("Tags").Where("resource_id = ?", "module."+instanceName).First(&resource).Error)
+	return resource
+}
+
+func currentTestConfig(t *testing.T, db *gorm.DB, instanceName string) map[string]interface{} {
+	t.Helper()
+	resource := loadTestResource(t, db, instanceName)
+	var version models.ResourceCodeVersion
+	require.NoError(t, db.First(&version, *resource.CurrentVersionID).Error)
+	return manifestModuleConfig(version.TFCode, instanceName)
+}
+
+func findUpgradeChange(plan *models.ManifestUpgradePlan, instanceName string) models.ManifestUpgradeChange {
+	for _, change := range plan.Changes {
+		if change.InstanceName == instanceName {
+			return change
+		}
+	}
+	return models.ManifestUpgradeChange{}
+}
+
+func setupManifestUpgradeFixture(t *testing.T) (*gorm.DB, *ManifestUpgradeService) {
+	db := setupManifestUpgradeTestDB(t)
+	deployTestManifest(t, db,
+		testManifestNode("vpc", map[string]interface{}{"cidr": "10.0.0.0/16", "name": "main"}),
+		testManifestNode("app", map[string]interface{}{"size": float64(1)}),
+	)
+	createTestManifestVersion(t, db, "v2",
+		testManifestNode("vpc", map[string]interface{}{"cidr": "10.1.0.0/16", "name": "main"}),
+		testManifestNode("db", map[string]interface{}{"engine": "postgres", "vpc_id": "module.vpc.vpc_id"}),
+	)
+	return db, NewManifestUpgradeService(db)
+}
+
+func TestManifestUpgradeService_UpgradeAndRollback(t *testing.T) {
+	db, svc := setupManifestUpgradeFixture(t)
+
+	plan, err := svc.PlanUpgrade("mfd-test", "v2")
+	require.NoError(t, err)
+	assert.False(t, plan.HasConflicts)
+	assert.Equal(t, models.ManifestUpgradeSummary{Add: 1, Change: 1, Remove: 1}, plan.Summary)
+	vpc := findUpgradeChange(plan, "vpc")
+	assert.Equal(t, models.UpgradeActionChange, vpc.Action)
+	assert.Equal(t, []string{"cidr"}, vpc.ChangedKeys)
+	assert.False(t, vpc.LocallyModified)
+	assert.Equal(t, models.UpgradeActionAdd, findUpgradeChange(plan, "db").Action)
+	assert.Equal(t, models.UpgradeActionRemove, findUpgradeChange(plan, "app").Action)
+
+	require.NoError(t, db.Model(&models.ManifestDeployment{}).Where("id = ?", "mfd-test").Update("last_task_id", 99).Error)
+	_, err = svc.ApplyUpgrade("mfd-test", "v2", "", "u-2")
+	require.NoError(t, err)
+
+	assert.Equal(t, "10.1.0.0/16", currentTestConfig(t, db, "vpc")["cidr"])
+	assert.Equal(t, "${module.vpc.vpc_id}", currentTestConfig(t, db, "db")["vpc_id"])
+	assert.False(t, loadTestResource(t, db, "app").IsActive)
+
+	var deployment models.ManifestDeployment
+	require.NoError(t, db.First(&deployment, "id = ?", "mfd-test").Error)
+	assert.Equal(t, "v2", deployment.VersionID)
+	require.NotNil(t, deployment.PreviousVersionID)
+	assert.Equal(t, "v1", *deployment.PreviousVersionID)
+	assert.Equal(t, models.DeploymentStatusDeploying, deployment.Status)
+	assert.Nil(t, deployment.LastTaskID, "the previous task must not finish the new upgrade")
+
+	// 回滚到 v1：app 恢复，db 移除，vpc 还原
+	require.NoError(t, db.Model(&deployment).Update("status", models.DeploymentStatusDeployed).Error)
+	rollbackTo, err := svc.RollbackVersionID("mfd-test")
+	require.NoError(t, err)
+	assert.Equal(t, "v1", rollbackTo)
+	plan, err = svc.ApplyUpgrade("mfd-test", rollbackTo, "", "u-2")
+	require.NoError(t, err)
+	assert.Equal(t, models.ManifestUpgradeSummary{Add: 1, Change: 1, Remove: 1}, plan.Summary)
+
+	assert.Equal(t, "10.0.0.0/16", currentTestConfig(t, db, "vpc")["cidr"])
+	assert.True(t, loadTestResource(t, db, "app").IsActive)
+	assert.False(t, loadTestResource(t, db, "db").IsActive)
+
+	var links []models.ManifestDeploymentResource
+	require.NoError(t, db.Where("deployment_id = ?", "mfd-test").Order("resource_id").Find(&links).Error)
+	require.Len(t, links, 2)
+	assert.Equal(t, "module.app", links[0].ResourceID)
+	assert.Equal(t, "module.vpc", links[1].ResourceID)
+}
+
+func TestManifestUpgradeService_MergesNonConflictingLocalEdits(t *testing.T) {
+	db, svc := setupManifestUpgradeFixture(t)
+	editTestResource(t, db, "vpc", map[string]interface{}{"name": "edited"})
+
+	plan, err := svc.PlanUpgrade("mfd-test", "v2")
+	require.NoError(t, err)
+	vpc := findUpgradeChange(plan, "vpc")
+	assert.True(t, vpc.LocallyModified)
+	assert.Equal(t, []string{"name"}, vpc.LocalKeys)
+	assert.Empty(t, vpc.Conflict)
+	assert.False(t, plan.HasConflicts)
+
+	plan, err = svc.ApplyUpgrade("mfd-test", "v2", models.UpgradeStrategyFail, "u-2")
+	require.NoError(t, err)
+	assert.Equal(t, "merge", findUpgradeChange(plan, "vpc").Resolution)
+
+	config := currentTestConfig(t, db, "vpc")
+	assert.Equal(t, "10.1.0.0/16", config["cidr"])
+	assert.Equal(t, "edited", config["name"])
+}
+
+func TestManifestUpgradeService_Conflicts(t *testing.T) {
+	t.Run("fail strategy leaves workspace untouched", func(t *testing.T) {
+		db, svc := setupManifestUpgradeFixture(t)
+		editTestResource(t, db, "vpc", map[string]interface{}{"cidr": "172.16.0.0/16"})
+
+		plan, err := svc.ApplyUpgrade("mfd-test", "v2", "", "u-2")
+		require.ErrorIs(t, err, ErrManifestUpgradeConflict)
+		require.NotNil(t, plan)
+		vpc := findUpgradeChange(plan, "vpc")
+		assert.Equal(t, models.UpgradeConflictLocallyModified, vpc.Conflict)
+		assert.Equal(t, []string{"cidr"}, vpc.ConflictKeys)
+
+		assert.Equal(t, "172.16.0.0/16", currentTestConfig(t, db, "vpc")["cidr"])
+		assert.True(t, loadTestResource(t, db, "app").IsActive)
+		var deployment models.ManifestDeployment
+		require.NoError(t, db.First(&deployment, "id = ?", "mfd-test").Error)
+		assert.Equal(t, "v1", deployment.VersionID)
+	})
+
+	t.Run("keep_local", func(t *testing.T) {
+		db, svc := setupManifestUpgradeFixture(t)
+		editTestResource(t, db, "vpc", map[string]interface{}{"cidr": "172.16.0.0/16"})
+		editTestResource(t, db, "app", map[string]interface{}{"size": float64(3)})
+
+		plan, err := svc.ApplyUpgrade("mfd-test", "v2", models.UpgradeStrategyKeepLocal, "u-2")
+		require.NoError(t, err)
+		assert.Equal(t, 2, plan.Summary.Conflicts)
+
+		assert.Equal(t, "172.16.0.0/16", currentTestConfig(t, db, "vpc")["cidr"])
+		// 本地修改过的待删除资源保留，但脱离部署
+		app := loadTestResource(t, db, "app")
+		assert.True(t, app.IsActive)
+		assert.Nil(t, app.ManifestDeploymentID)
+	})
+
+	t.Run("overwrite", func(t *testing.T) {
+		db, svc := setupManifestUpgradeFixture(t)
+		editTestResource(t, db, "vpc", map[string]interface{}{"cidr": "172.16.0.0/16"})
+
+		_, err := svc.ApplyUpgrade("mfd-test", "v2", models.UpgradeStrategyOverwrite, "u-2")
+		require.NoError(t, err)
+		assert.Equal(t, "10.1.0.0/16", currentTestConfig(t, db, "vpc")["cidr"])
+	})
+}
+
+func TestManifestUpgradeService_SyncDeploymentStatuses(t *testing.T) {
+	db := setupTestDB(t)
+	createManifestTestTables(t, db)
+	svc := NewManifestUpgradeService(db)
+
+	tasks := map[int]models.TaskStatus{
+		1: models.TaskStatusApplied,
+		2: models.TaskStatusCancelled,
+		3: models.TaskStatusApplyPending,
+	}
+	for id, status := range tasks {
+		require.NoError(t, db.Exec(`INSERT INTO workspace_tasks (id, workspace_id, task_type, status) VALUES (?, 'ws-1', 'plan_and_apply', ?)`,
+			id, status).Error)
+	}
+	// mfd-4 指向不存在的任务，视为失败
+	for i := 1; i <= 4; i++ {
+		taskID := i
+		require.NoError(t, db.Create(&models.ManifestDeployment{
+			ID: fmt.Sprintf("mfd-%d", i), ManifestID: "mf-test", VersionID: "v1", WorkspaceID: i,
+			Status: models.DeploymentStatusDeploying, LastTaskID: &taskID,
+		}).Error)
+	}
+
+	require.NoError(t, svc.SyncDeploymentStatuses())
+
+	expected := map[string]string{
+		"mfd-1": models.DeploymentStatusDeployed,
+		"mfd-2": models.DeploymentStatusFailed,
+		"mfd-3": models.DeploymentStatusDeploying,
+		"mfd-4": models.DeploymentStatusFailed,
+	}
+	for id, status := range expected {
+		var deployment models.ManifestDeployment
+		require.NoError(t, db.First(&deployment, "id = ?", id).Error)
+		assert.Equal(t, status, deployment.Status, id)
+	}
+}
+
+func TestMergeModuleConfig(t *testing.T) {
+	base := map[string]interface{}{"a": "1", "b": "1", "c": "1", "d": "1"}
+	local := map[string]interface{}{"a": "2", "b": "1", "c": "2", "d": "1"}
+	target := map[string]interface{}{"a": "1", "b": "3", "c": "3", "e": "3"}
+
+	merged, conflicts := mergeModuleConfig(base, local, target)
+	assert.Equal(t, map[string]interface{}{"a": "2", "b": "3", "c": "3", "e": "3"}, merged)
+	assert.Equal(t, []string{"c"}, conflicts)
+}
@@ -0,0 +1,315 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"iac-platform/internal/models"
+)
+
+// ExecuteTest 执行 Module 版本测试任务（terraform test / tofu test）
+// 工作目录即被测模块的根目录：写入同步的模块文件与 demo 生成的测试，
+// 使用执行 workspace 的引擎版本、环境变量与 Provider 配置运行测试
+func (s *TerraformExecutor) ExecuteTest(
+	ctx context.Context,
+	task *models.WorkspaceTask,
+) error {
+	executionMode := "LOCAL"
+	if s.db == nil {
+		executionMode = "AGENT"
+	}
+	log.Printf("[%s MODE] ExecuteTest started for task %d, workspace %s", executionMode, task.ID, task.WorkspaceID)
+
+	workspace, err := s.dataAccessor.GetWorkspace(task.WorkspaceID)
+	if err != nil {
+		task.Status = models.TaskStatusFailed
+		task.ErrorMessage = fmt.Sprintf("failed to get workspace: %v", err)
+		task.CompletedAt = timePtr(time.Now())
+		if updateErr := s.dataAccessor.UpdateTask(task); updateErr != nil {
+			log.Printf("[ERROR] Failed to update task %d status after GetWorkspace failure: %v", task.ID, updateErr)
+		}
+		return fmt.Errorf("failed to get workspace: %w", err)
+	}
+
+	stream := s.streamManager.GetOrCreate(task.ID)
+	defer s.streamManager.Close(task.ID)
+	logger := NewTerraformLoggerWithLevelAndMode(stream, "info", s.db == nil)
+
+	// failTest 上报 errored 结果并保存任务失败信息
+	failTest := func(stage string, err error) error {
+		logger.LogError(stage, err, map[string]interface{}{
+			"task_id":      task.ID,
+			"workspace_id": task.WorkspaceID,
+		}, nil)
+		logger.StageEnd(stage)
+		if ctx.Err() == context.Canceled {
+			s.saveTaskCancellation(task, logger, "plan")
+			return fmt.Errorf("task cancelled by user")
+		}
+		if reportErr := s.reportModuleTestResult(task.ID, &ModuleTestReport{
+			Status:       models.ModuleTestRunStatusErrored,
+			ErrorMessage: err.Error(),
+		}); reportErr != nil {
+			logger.Warn("Failed to report test result: %v", reportErr)
+		}
+		s.saveTaskFailure(task, logger, err, "plan")
+		return err
+	}
+
+	// ========== 阶段1: Fetching ==========
+	logger.StageBegin("fetching")
+
+	if s.downloader == nil {
+		return failTest("fetching", fmt.Errorf("terraform downloader not initialized"))
+	}
+	logger.Info("Ensuring IaC engine binary for version: %s", workspace.TerraformVersion)
+	binaryPath, err := s.downloader.EnsureTerraformBinary(workspace.TerraformVersion)
+	if err != nil {
+		return failTest("fetching", fmt.Errorf("failed to ensure binary for version %s: %w", workspace.TerraformVersion, err))
+	}
+	logger.Info("✓ Binary ready: %s", binaryPath)
+
+	workDir, err := s.PrepareWorkspace(task)
+	if err != nil {
+		return failTest("fetching", err)
+	}
+	defer func() {
+		if cleanupErr := s.CleanupWorkspace(workDir); cleanupErr != nil {
+			log.Printf("Warning: failed to cleanup test work directory %s: %v", workDir, cleanupErr)
+		}
+	}()
+
+	logger.Info("Fetching module test bundle...")
+	bundle, err := s.loadModuleTestBundle(task.ID)
+	if err != nil {
+		return failTest("fetching", fmt.Errorf("failed to load module test bundle: %w", err))
+	}
+
+	if err := writeModuleTestBundle(workDir, bundle); err != nil {
+		return failTest("fetching", err)
+	}
+	testFiles := ListModuleTestFiles(bundle.ModuleFiles)
+	logger.Info("✓ Module files written: %d file(s), %d synced test file(s), %d generated demo test(s)",
+		len(bundle.ModuleFiles), len(testFiles), len(bundle.GeneratedTests))
+	if len(testFiles)+len(bundle.GeneratedTests) == 0 {
+		return failTest("fetching", fmt.Errorf("no .tftest.hcl files found and no demos to generate tests from"))
+	}
+
+	// 模块未声明 provider 块时，使用执行 workspace 的 provider 配置
+	if !moduleDeclaresProvider(bundle.ModuleFiles) && len(workspace.ProviderConfig) > 0 {
+		providerConfig, err := s.resolveProviderConfig(s.cleanProviderConfig(workspace.ProviderConfig))
+		if err != nil {
+			return failTest("fetching", err)
+		}
+		if providers, ok := providerConfig["provider"]; ok {
+			if err := s.writeJSONFile(workDir, "zz_test_provider.tf.json", map[string]interface{}{"provider": providers}); err != nil {
+				return failTest("fetching", err)
+			}
+			logger.Info("✓ Provider configuration taken from workspace %s", workspace.WorkspaceID)
+		}
+	}
+	logger.StageEnd("fetching")
+
+	env := s.buildEnvironmentVariables(workspace)
+	pluginCacheDir := filepath.Join(workDir, ".terraform-plugin-cache")
+	if err := os.MkdirAll(pluginCacheDir, 0755); err == nil {
+		env = append(env, fmt.Sprintf("TF_PLUGIN_CACHE_DIR=%s", pluginCacheDir))
+	}
+
+	// ========== 阶段2: Init ==========
+	logger.StageBegin("init")
+	if _, err := s.runModuleTestCommand(ctx, workDir, env, binaryPath, []string{"init", "-no-color", "-input=false"}, logger, false); err != nil {
+		return failTest("init", fmt.Errorf("init failed: %w", err))
+	}
+	logger.StageEnd("init")
+
+	// ========== 阶段3: Testing ==========
+	logger.StageBegin("testing")
+	startTime := time.Now()
+	jsonOutput, cmdErr := s.runModuleTestCommand(ctx, workDir, env, binaryPath, []string{"test", "-no-color", "-json"}, logger, true)
+	if ctx.Err() == context.Canceled {
+		logger.StageEnd("testing")
+		s.saveTaskCancellation(task, logger, "plan")
+		return fmt.Errorf("task cancelled by user")
+	}
+
+	report := ParseTerraformTestJSON(jsonOutput)
+	if cmdErr != nil && report.Status == models.ModuleTestRunStatusPassed {
+		// 进程异常退出但摘要为 pass，按执行错误处理
+		report.Status = models.ModuleTestRunStatusErrored
+		report.ErrorMessage = cmdErr.Error()
+	}
+	logger.Info("Test summary: %d passed, %d failed, %d errored, %d skipped (%s)",
+		report.Passed, report.Failed, report.Errored, report.Skipped, report.Status)
+	logger.StageEnd("testing")
+
+	if err := s.reportModuleTestResult(task.ID, report); err != nil {
+		logger.Error("Failed to report test result: %v", err)
+		s.saveTaskFailure(task, logger, fmt.Errorf("failed to report test result: %w", err), "plan")
+		return err
+	}
+
+	if report.Status != models.ModuleTestRunStatusPassed {
+		message := report.ErrorMessage
+		if message == "" {
+			message = fmt.Sprintf("%d test(s) failed, %d errored", report.Failed, report.Errored)
+		}
+		s.saveTaskFailure(task, logger, fmt.Errorf("module test %s: %s", report.Status, message), "plan")
+		return fmt.Errorf("module test %s", report.Status)
+	}
+
+	stream.Broadcast(OutputMessage{
+		Type:      "completed",
+		Timestamp: time.Now(),
+	})
+
+	task.Status = models.TaskStatusSuccess
+	task.Stage = "completed"
+	task.PlanOutput = logger.GetFullOutput()
+	task.CompletedAt = timePtr(time.Now())
+	task.Duration = int(time.Since(startTime).Seconds())
+	if err := s.dataAccessor.UpdateTask(task); err != nil {
+		return fmt.Errorf("failed to update task: %w", err)
+	}
+	s.saveTaskLog(task.ID, "test", task.PlanOutput, "info")
+	return nil
+}
+
+// runModuleTestCommand 执行命令并实时输出日志
+// jsonOutput 为 true 时 stdout 是机器可读 JSON：日志中只展示 @message，原始内容返回用于解析
+func (s *TerraformExecutor) runModuleTestCommand(
+	ctx context.Context,
+	workDir string,
+	env []string,
+	binaryPath string,
+	args []string,
+	logger *TerraformLogger,
+	jsonOutput bool,
+) (string, error) {
+	cmd := exec.CommandContext(ctx, binaryPath, args...)
+	cmd.Dir = workDir
+	cmd.Env = env
+
+	stdoutPipe, err := cmd.StdoutPipe()
+	if err != nil {
+		return "", fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+	stderrPipe, err := cmd.StderrPipe()
+	if err != nil {
+		return "", fmt.Errorf("failed to create stderr pipe: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return "", fmt.Errorf("failed to start %s: %w", filepath.Base(binaryPath), err)
+	}
+
+	var stdout strings.Builder
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		scanner := bufio.NewScanner(stdoutPipe)
+		scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+		for scanner.Scan() {
+			line := scanner.Text()
+			stdout.WriteString(line)
+			stdout.WriteString("\n")
+			if jsonOutput {
+				var msg struct {
+					Message string `json:"@message"`
+				}
+				if json.Unmarshal([]byte(line), &msg) == nil && msg.Message != "" {
+					logger.RawOutput(msg.Message)
+					continue
+				}
+			}
+			logger.RawOutput(line)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		scanner := bufio.NewScanner(stderrPipe)
+		for scanner.Scan() {
+			logger.RawOutput(scanner.Text())
+		}
+	}()
+
+	wg.Wait()
+	return stdout.String(), cmd.Wait()
+}
+
+// loadModuleTestBundle 获取测试文件（Local 模式直接查库，Agent 模式通过 API）
+func (s *TerraformExecutor) loadModuleTestBundle(taskID uint) (*ModuleTestBundle, error) {
+	if s.db != nil {
+		return NewModuleTestService(s.db).BuildBundleForTask(taskID)
+	}
+	if remoteAccessor, ok := s.dataAccessor.(*RemoteDataAccessor); ok {
+		return remoteAccessor.apiClient.GetModuleTestBundle(taskID)
+	}
+	return nil, fmt.Errorf("no data source for module test bundle")
+}
+
+// reportModuleTestResult 上报测试结果（Local 模式直接写库，Agent 模式通过 API）
+func (s *TerraformExecutor) reportModuleTestResult(taskID uint, report *ModuleTestReport) error {
+	if s.db != nil {
+		return NewModuleTestService(s.db).CompleteRun(taskID, report)
+	}
+	if remoteAccessor, ok := s.dataAccessor.(*RemoteDataAccessor); ok {
+		return remoteAccessor.apiClient.UploadModuleTestReport(taskID, report)
+	}
+	return fmt.Errorf("no data source for module test report")
+}
+
+// writeModuleTestBundle 将模块文件与生成的测试写入工作目录（拒绝越界路径）
+func writeModuleTestBundle(workDir string, bundle *ModuleTestBundle) error {
+	write := func(name, content string) error {
+		clean := filepath.Clean(filepath.FromSlash(name))
+		if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("invalid module file path: %s", name)
+		}
+		target := filepath.Join(workDir, clean)
+		if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", name, err)
+		}
+		if err := os.WriteFile(target, []byte(content), 0600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+		return nil
+	}
+
+	for name, content := range bundle.ModuleFiles {
+		if err := write(name, content); err != nil {
+			return err
+		}
+	}
+	for name, content := range bundle.GeneratedTests {
+		if err := write(name, content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// moduleDeclaresProvider 粗略判断模块根目录是否已声明 provider 块
+func moduleDeclaresProvider(files map[string]string) bool {
+	for name, content := range files {
+		if filepath.Dir(filepath.Clean(name)) != "." {
+			continue
+		}
+		if strings.HasSuffix(name, ".tf") && strings.Contains(content, "provider \"") {
+			return true
+		}
+		if strings.HasSuffix(name, ".tf.json") && strings.Contains(content, "\"provider\"") {
+			return true
+		}
+	}
+	return false
+}
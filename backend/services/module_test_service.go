@@ -0,0 +1,722 @@
+package services
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"iac-platform/internal/models"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+	"gorm.io/gorm"
+)
+
+// ErrModuleVersionTestRequired 模块要求测试通过后才能设为默认版本
+var ErrModuleVersionTestRequired = errors.New("module requires a passing test run before a version can be set as default")
+
+// generatedDemoTestPrefix demo 生成的测试文件前缀（位于 tests/ 目录）
+const generatedDemoTestPrefix = "generated_demo_"
+
+// generateModuleTestRunID 生成模块测试运行 ID
+func generateModuleTestRunID() string {
+	bytes := make([]byte, 8)
+	rand.Read(bytes)
+	return "mtr-" + hex.EncodeToString(bytes)
+}
+
+// ModuleTestService 模块版本测试服务
+type ModuleTestService struct {
+	db *gorm.DB
+}
+
+// NewModuleTestService 创建模块测试服务
+func NewModuleTestService(db *gorm.DB) *ModuleTestService {
+	return &ModuleTestService{db: db}
+}
+
+// CreateModuleTestRunRequest 创建测试运行请求
+type CreateModuleTestRunRequest struct {
+	WorkspaceID      string `json:"workspace_id" binding:"required"` // 执行 workspace（提供 Agent Pool、引擎版本与凭证）
+	IncludeDemoTests *bool  `json:"include_demo_tests"`              // 是否根据 demo 生成测试，默认 true
+	DemoTestCommand  string `json:"demo_test_command"`               // plan（默认）或 apply
+}
+
+// ModuleTestBundle 执行测试所需的文件集合（Local 与 Agent 模式共用）
+type ModuleTestBundle struct {
+	RunID          string            `json:"run_id"`
+	ModuleFiles    map[string]string `json:"module_files"`    // 模块源码（含同步的 .tftest.hcl）
+	GeneratedTests map[string]string `json:"generated_tests"` // 由 demo 生成的测试文件
+}
+
+// ModuleTestReport terraform test -json 的解析结果
+type ModuleTestReport struct {
+	Status       models.ModuleTestRunStatus `json:"status"`
+	Total        int                        `json:"total"`
+	Passed       int                        `json:"passed"`
+	Failed       int                        `json:"failed"`
+	Errored      int                        `json:"errored"`
+	Skipped      int                        `json:"skipped"`
+	Results      []models.ModuleTestResult  `json:"results"`
+	ErrorMessage string                     `json:"error_message,omitempty"`
+}
+
+// CreateRun 为模块版本创建测试运行，并在执行 workspace 中创建 test 任务
+// 调用方负责在返回后触发任务调度
+func (s *ModuleTestService) CreateRun(moduleID uint, versionID string, req *CreateModuleTestRunRequest, userID string) (*models.ModuleTestRun, error) {
+	var version models.ModuleVersion
+	if err := s.db.Where("id = ? AND module_id = ?", versionID, moduleID).First(&version).Error; err != nil {
+		return nil, errors.New("version not found")
+	}
+
+	var module models.Module
+	if err := s.db.First(&module, moduleID).Error; err != nil {
+		return nil, errors.New("module not found")
+	}
+	if _, err := decodeModuleFiles(module.ModuleFiles); err != nil {
+		return nil, err
+	}
+
+	var workspace models.Workspace
+	if err := s.db.Where("workspace_id = ?", req.WorkspaceID).First(&workspace).Error; err != nil {
+		return nil, errors.New("workspace not found")
+	}
+
+	command := req.DemoTestCommand
+	if command == "" {
+		command = "plan"
+	}
+	if command != "plan" && command != "apply" {
+		return nil, fmt.Errorf("invalid demo_test_command %q: must be plan or apply", command)
+	}
+	includeDemos := true
+	if req.IncludeDemoTests != nil {
+		includeDemos = *req.IncludeDemoTests
+	}
+
+	engine := models.IaCEngineTerraform
+	var tfVersion models.TerraformVersion
+	if err := s.db.Where("version = ?", workspace.TerraformVersion).First(&tfVersion).Error; err == nil {
+		engine = tfVersion.GetEngineType()
+	}
+
+	run := &models.ModuleTestRun{
+		RunID:            generateModuleTestRunID(),
+		ModuleID:         moduleID,
+		ModuleVersionID:  versionID,
+		WorkspaceID:      workspace.WorkspaceID,
+		Engine:           engine,
+		EngineVersion:    workspace.TerraformVersion,
+		IncludeDemoTests: includeDemos,
+		DemoTestCommand:  command,
+		Status:           models.ModuleTestRunStatusPending,
+	}
+	if userID != "" {
+		run.CreatedBy = &userID
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		task := &models.WorkspaceTask{
+			WorkspaceID:   workspace.WorkspaceID,
+			TaskType:      models.TaskTypeTest,
+			Status:        models.TaskStatusPending,
+			ExecutionMode: workspace.ExecutionMode,
+			Description:   fmt.Sprintf("Module test: %s v%s", module.Name, version.Version),
+			Stage:         "pending",
+			Context: models.JSONB{
+				"module_test_run_id": run.RunID,
+				"module_id":          moduleID,
+				"module_version_id":  versionID,
+			},
+		}
+		if userID != "" {
+			task.CreatedBy = &userID
+		}
+		if err := tx.Create(task).Error; err != nil {
+			return fmt.Errorf("failed to create test task: %w", err)
+		}
+		run.TaskID = &task.ID
+		return tx.Create(run).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("[ModuleTest] Created test run %s for module %d version %s (task %d, workspace %s)",
+		run.RunID, moduleID, versionID, *run.TaskID, workspace.WorkspaceID)
+	return run, nil
+}
+
+// GetRun 获取测试运行（含每个 run 块的结果）
+func (s *ModuleTestService) GetRun(runID string) (*models.ModuleTestRun, error) {
+	var run models.ModuleTestRun
+	if err := s.db.Preload("Results", func(db *gorm.DB) *gorm.DB {
+		return db.Order("test_file ASC, id ASC")
+	}).Where("run_id = ?", runID).First(&run).Error; err != nil {
+		return nil, err
+	}
+	s.reconcileWithTask(&run)
+	return &run, nil
+}
+
+// ListRuns 分页列出模块版本的测试运行
+func (s *ModuleTestService) ListRuns(moduleID uint, versionID string, page, pageSize int) ([]models.ModuleTestRun, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	query := s.db.Model(&models.ModuleTestRun{}).Where("module_id = ?", moduleID)
+	if versionID != "" {
+		query = query.Where("module_version_id = ?", versionID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var runs []models.ModuleTestRun
+	if err := query.Order("created_at DESC, id DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&runs).Error; err != nil {
+		return nil, 0, err
+	}
+	for i := range runs {
+		s.reconcileWithTask(&runs[i])
+	}
+	return runs, total, nil
+}
+
+// GetRunByTaskID 根据 test 任务 ID 获取测试运行
+func (s *ModuleTestService) GetRunByTaskID(taskID uint) (*models.ModuleTestRun, error) {
+	var run models.ModuleTestRun
+	if err := s.db.Where("task_id = ?", taskID).First(&run).Error; err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// BuildBundleForTask 组装测试文件，并将运行标记为 running
+func (s *ModuleTestService) BuildBundleForTask(taskID uint) (*ModuleTestBundle, error) {
+	run, err := s.GetRunByTaskID(taskID)
+	if err != nil {
+		return nil, fmt.Errorf("module test run not found for task %d: %w", taskID, err)
+	}
+
+	var module models.Module
+	if err := s.db.First(&module, run.ModuleID).Error; err != nil {
+		return nil, fmt.Errorf("module not found: %w", err)
+	}
+	files, err := decodeModuleFiles(module.ModuleFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &ModuleTestBundle{
+		RunID:          run.RunID,
+		ModuleFiles:    files,
+		GeneratedTests: map[string]string{},
+	}
+
+	if run.IncludeDemoTests {
+		var demos []models.ModuleDemo
+		if err := s.db.Preload("CurrentVersion").
+			Where("module_id = ? AND module_version_id = ? AND is_active = ?", run.ModuleID, run.ModuleVersionID, true).
+			Order("id ASC").
+			Find(&demos).Error; err != nil {
+			return nil, fmt.Errorf("failed to load demos: %w", err)
+		}
+		for _, demo := range demos {
+			if demo.CurrentVersion == nil {
+				continue
+			}
+			name, content, err := GenerateDemoTestFile(&demo, run.DemoTestCommand)
+			if err != nil {
+				log.Printf("[ModuleTest] Skipping demo %d (%s) for run %s: %v", demo.ID, demo.Name, run.RunID, err)
+				continue
+			}
+			if _, exists := bundle.GeneratedTests[name]; exists {
+				name = strings.TrimSuffix(name, ".tftest.hcl") + fmt.Sprintf("_%d.tftest.hcl", demo.ID)
+			}
+			bundle.GeneratedTests[name] = content
+		}
+	}
+
+	testFiles := make([]interface{}, 0)
+	for _, name := range ListModuleTestFiles(bundle.ModuleFiles) {
+		testFiles = append(testFiles, name)
+	}
+	generatedNames := make([]string, 0, len(bundle.GeneratedTests))
+	for name := range bundle.GeneratedTests {
+		generatedNames = append(generatedNames, name)
+	}
+	sort.Strings(generatedNames)
+	for _, name := range generatedNames {
+		testFiles = append(testFiles, name)
+	}
+
+	now := time.Now()
+	if err := s.db.Model(&models.ModuleTestRun{}).Where("id = ?", run.ID).Updates(map[string]interface{}{
+		"status":          models.ModuleTestRunStatusRunning,
+		"started_at":      now,
+		"test_files":      models.JSONB{"files": testFiles},
+		"source_revision": moduleFilesRevision(files),
+	}).Error; err != nil {
+		return nil, fmt.Errorf("failed to mark test run running: %w", err)
+	}
+
+	return bundle, nil
+}
+
+// CompleteRun 保存测试结果并更新运行状态
+func (s *ModuleTestService) CompleteRun(taskID uint, report *ModuleTestReport) error {
+	run, err := s.GetRunByTaskID(taskID)
+	if err != nil {
+		return fmt.Errorf("module test run not found for task %d: %w", taskID, err)
+	}
+
+	generated := map[string]bool{}
+	if files, ok := run.TestFiles["files"].([]interface{}); ok {
+		for _, f := range files {
+			if name, ok := f.(string); ok && strings.HasPrefix(path.Base(name), generatedDemoTestPrefix) {
+				generated[name] = true
+			}
+		}
+	}
+
+	status := report.Status
+	if !status.IsFinal() {
+		status = models.ModuleTestRunStatusErrored
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		// 结果上报可能重试，先清理旧结果保证幂等
+		if err := tx.Where("run_id = ?", run.RunID).Delete(&models.ModuleTestResult{}).Error; err != nil {
+			return err
+		}
+		for i := range report.Results {
+			result := report.Results[i]
+			result.ID = 0
+			result.RunID = run.RunID
+			result.Generated = generated[result.TestFile] || strings.HasPrefix(path.Base(result.TestFile), generatedDemoTestPrefix)
+			if err := tx.Create(&result).Error; err != nil {
+				return fmt.Errorf("failed to save test result: %w", err)
+			}
+		}
+
+		now := time.Now()
+		return tx.Model(&models.ModuleTestRun{}).Where("id = ?", run.ID).Updates(map[string]interface{}{
+			"status":        status,
+			"total_count":   report.Total,
+			"passed_count":  report.Passed,
+			"failed_count":  report.Failed,
+			"errored_count": report.Errored,
+			"skipped_count": report.Skipped,
+			"error_message": report.ErrorMessage,
+			"completed_at":  now,
+		}).Error
+	})
+}
+
+// reconcileWithTask 任务已结束但未上报结果时（如 init 失败、被取消），以任务状态收敛运行状态
+func (s *ModuleTestService) reconcileWithTask(run *models.ModuleTestRun) {
+	if run.Status.IsFinal() || run.TaskID == nil {
+		return
+	}
+
+	var task models.WorkspaceTask
+	if err := s.db.Select("id, status, error_message, completed_at").First(&task, *run.TaskID).Error; err != nil {
+		return
+	}
+
+	var status models.ModuleTestRunStatus
+	switch task.Status {
+	case models.TaskStatusCancelled:
+		status = models.ModuleTestRunStatusCancelled
+	case models.TaskStatusFailed, models.TaskStatusSuccess:
+		status = models.ModuleTestRunStatusErrored
+	default:
+		return
+	}
+
+	message := task.ErrorMessage
+	if message == "" {
+		message = "test task finished without reporting results"
+	}
+	completedAt := task.CompletedAt
+	if completedAt == nil {
+		now := time.Now()
+		completedAt = &now
+	}
+
+	if err := s.db.Model(&models.ModuleTestRun{}).
+		Where("id = ? AND status IN ?", run.ID, []models.ModuleTestRunStatus{models.ModuleTestRunStatusPending, models.ModuleTestRunStatusRunning}).
+		Updates(map[string]interface{}{
+			"status":        status,
+			"error_message": message,
+			"completed_at":  completedAt,
+		}).Error; err != nil {
+		log.Printf("[ModuleTest] Failed to reconcile run %s with task %d: %v", run.RunID, task.ID, err)
+		return
+	}
+	run.Status = status
+	run.ErrorMessage = message
+	run.CompletedAt = completedAt
+}
+
+// LatestFinishedRun 返回模块版本最近一次已结束的测试运行
+func (s *ModuleTestService) LatestFinishedRun(versionID string) (*models.ModuleTestRun, error) {
+	var runs []models.ModuleTestRun
+	if err := s.db.Where("module_version_id = ?", versionID).
+		Order("created_at DESC, id DESC").
+		Limit(20).
+		Find(&runs).Error; err != nil {
+		return nil, err
+	}
+	for i := range runs {
+		s.reconcileWithTask(&runs[i])
+		if runs[i].Status.IsFinal() && runs[i].Status != models.ModuleTestRunStatusCancelled {
+			return &runs[i], nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// CheckDefaultVersionGate 模块开启 require_passing_test 时，要求版本最近一次测试运行通过
+// 测试使用的是模块已同步的文件，运行记录的 source_revision 与当前同步的文件不一致时（之后重新同步过）不能作为依据
+func (s *ModuleTestService) CheckDefaultVersionGate(module *models.Module, versionID string) error {
+	if !module.RequirePassingTest {
+		return nil
+	}
+	run, err := s.LatestFinishedRun(versionID)
+	if err == gorm.ErrRecordNotFound {
+		return ErrModuleVersionTestRequired
+	}
+	if err != nil {
+		return err
+	}
+	if run.Status != models.ModuleTestRunStatusPassed {
+		return fmt.Errorf("%w: latest test run %s is %s", ErrModuleVersionTestRequired, run.RunID, run.Status)
+	}
+	files, err := decodeModuleFiles(module.ModuleFiles)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrModuleVersionTestRequired, err)
+	}
+	if run.SourceRevision == "" || run.SourceRevision != moduleFilesRevision(files) {
+		return fmt.Errorf("%w: module files changed since test run %s, run the tests again", ErrModuleVersionTestRequired, run.RunID)
+	}
+	return nil
+}
+
+// moduleFilesRevision 计算模块文件的内容摘要（按文件名排序），用于确认测试运行与当前同步的文件一致
+func moduleFilesRevision(files map[string]string) string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	for _, name := range names {
+		h.Write([]byte(name))
+		h.Write([]byte{0})
+		h.Write([]byte(files[name]))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// decodeModuleFiles 解析 Module.ModuleFiles（jsonb）为文件名到内容的映射
+func decodeModuleFiles(raw interface{}) (map[string]string, error) {
+	files := make(map[string]string)
+	switch v := raw.(type) {
+	case nil:
+	case *interface{}:
+		if v == nil {
+			return decodeModuleFiles(nil)
+		}
+		return decodeModuleFiles(*v)
+	case map[string]interface{}:
+		for key, value := range v {
+			if str, ok := value.(string); ok {
+				files[key] = str
+			}
+		}
+	case map[string]string:
+		for key, value := range v {
+			files[key] = value
+		}
+	case []byte:
+		if err := json.Unmarshal(v, &files); err != nil {
+			return nil, fmt.Errorf("invalid module files: %w", err)
+		}
+	case string:
+		if err := json.Unmarshal([]byte(v), &files); err != nil {
+			return nil, fmt.Errorf("invalid module files: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported module files type %T", raw)
+	}
+
+	hasConfig := false
+	for name := range files {
+		if strings.HasSuffix(name, ".tf") || strings.HasSuffix(name, ".tf.json") {
+			hasConfig = true
+			break
+		}
+	}
+	if !hasConfig {
+		return nil, errors.New("module files not synced: no terraform configuration files found")
+	}
+	return files, nil
+}
+
+// ListModuleTestFiles 返回 terraform test 会发现的测试文件（模块根目录与 tests/ 目录）
+func ListModuleTestFiles(files map[string]string) []string {
+	var names []string
+	for name := range files {
+		if !strings.HasSuffix(name, ".tftest.hcl") && !strings.HasSuffix(name, ".tftest.json") {
+			continue
+		}
+		dir := path.Dir(path.Clean(name))
+		if dir == "." || dir == "tests" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+var nonIdentifierChars = regexp.MustCompile(`[^a-zA-Z0-9_]+`)
+
+// GenerateDemoTestFile 根据 demo 当前版本的配置生成 .tftest.hcl
+// demo 配置即模块输入变量，生成一个 run 块，用 plan（默认）或 apply 验证模块可用
+func GenerateDemoTestFile(demo *models.ModuleDemo, command string) (string, string, error) {
+	if demo.CurrentVersion == nil {
+		return "", "", errors.New("demo has no current version")
+	}
+	if command != "apply" {
+		command = "plan"
+	}
+
+	runName := strings.Trim(strings.ToLower(nonIdentifierChars.ReplaceAllString(demo.Name, "_")), "_")
+	if runName == "" || !hclsyntax.ValidIdentifier(runName) || (runName[0] >= '0' && runName[0] <= '9') {
+		runName = fmt.Sprintf("demo_%d", demo.ID)
+	} else {
+		runName = "demo_" + runName
+	}
+
+	f := hclwrite.NewEmptyFile()
+	body := f.Body()
+	body.AppendUnstructuredTokens(hclwrite.Tokens{{
+		Type:  hclsyntax.TokenComment,
+		Bytes: []byte(fmt.Sprintf("# Generated from module demo %q (demo %d, version %d). Do not edit.\n", demo.Name, demo.ID, demo.CurrentVersion.Version)),
+	}})
+
+	run := body.AppendNewBlock("run", []string{runName}).Body()
+	run.SetAttributeTraversal("command", hcl.Traversal{hcl.TraverseRoot{Name: command}})
+
+	keys := make([]string, 0, len(demo.CurrentVersion.ConfigData))
+	for key := range demo.CurrentVersion.ConfigData {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	vars := run.AppendNewBlock("variables", nil).Body()
+	for _, key := range keys {
+		value := demo.CurrentVersion.ConfigData[key]
+		if value == nil || !hclsyntax.ValidIdentifier(key) {
+			continue
+		}
+		ctyVal, err := jsonToCty(value)
+		if err != nil {
+			return "", "", fmt.Errorf("variable %s: %w", key, err)
+		}
+		vars.SetAttributeValue(key, ctyVal)
+	}
+
+	fileName := fmt.Sprintf("tests/%s%s.tftest.hcl", generatedDemoTestPrefix, strings.TrimPrefix(runName, "demo_"))
+	return fileName, string(f.Bytes()), nil
+}
+
+// jsonToCty 将任意 JSON 值转换为 cty.Value
+func jsonToCty(value interface{}) (cty.Value, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return cty.NilVal, err
+	}
+	ty, err := ctyjson.ImpliedType(data)
+	if err != nil {
+		return cty.NilVal, err
+	}
+	return ctyjson.Unmarshal(data, ty)
+}
+
+// ParseTerraformTestJSON 解析 `terraform test -json` / `tofu test -json` 的输出
+// 每行一个 JSON 消息；test_run 完成消息携带结果，diagnostic 消息按 @testfile/@testrun 归属
+func ParseTerraformTestJSON(output string) *ModuleTestReport {
+	type runKey struct{ file, run string }
+
+	report := &ModuleTestReport{}
+	statuses := map[runKey]string{}
+	diagnostics := map[runKey][]string{}
+	var order []runKey
+	seen := map[runKey]bool{}
+	track := func(k runKey) {
+		if !seen[k] {
+			seen[k] = true
+			order = append(order, k)
+		}
+	}
+	summaryFound := false
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+		var msg struct {
+			Type     string `json:"type"`
+			Message  string `json:"@message"`
+			TestFile string `json:"@testfile"`
+			TestRun  string `json:"@testrun"`
+			Run      *struct {
+				Path     string `json:"path"`
+				Run      string `json:"run"`
+				Progress string `json:"progress"`
+				Status   string `json:"status"`
+			} `json:"test_run"`
+			File *struct {
+				Path     string `json:"path"`
+				Progress string `json:"progress"`
+				Status   string `json:"status"`
+			} `json:"test_file"`
+			Summary *struct {
+				Status  string `json:"status"`
+				Passed  int    `json:"passed"`
+				Failed  int    `json:"failed"`
+				Errored int    `json:"errored"`
+				Skipped int    `json:"skipped"`
+			} `json:"test_summary"`
+			Diagnostic *struct {
+				Severity string `json:"severity"`
+				Summary  string `json:"summary"`
+				Detail   string `json:"detail"`
+			} `json:"diagnostic"`
+		}
+		if err := json.Unmarshal([]byte(line), &msg); err != nil {
+			continue
+		}
+
+		switch msg.Type {
+		case "test_run":
+			if msg.Run == nil || msg.Run.Status == "" {
+				continue
+			}
+			k := runKey{file: msg.Run.Path, run: msg.Run.Run}
+			track(k)
+			statuses[k] = msg.Run.Status
+		case "test_file":
+			// 文件级错误（如配置无效）时没有 run 结果，保留文件状态
+			if msg.File == nil || msg.File.Status == "" {
+				continue
+			}
+			k := runKey{file: msg.File.Path}
+			if msg.File.Status == "error" || msg.File.Status == "fail" {
+				track(k)
+				statuses[k] = msg.File.Status
+			}
+		case "diagnostic":
+			if msg.Diagnostic == nil {
+				continue
+			}
+			k := runKey{file: msg.TestFile, run: msg.TestRun}
+			text := fmt.Sprintf("[%s] %s", msg.Diagnostic.Severity, msg.Diagnostic.Summary)
+			if msg.Diagnostic.Detail != "" {
+				text += "\n" + msg.Diagnostic.Detail
+			}
+			diagnostics[k] = append(diagnostics[k], text)
+			if k.file == "" && msg.Diagnostic.Severity == "error" && report.ErrorMessage == "" {
+				report.ErrorMessage = msg.Diagnostic.Summary
+			}
+		case "test_summary":
+			if msg.Summary == nil {
+				continue
+			}
+			summaryFound = true
+			report.Passed = msg.Summary.Passed
+			report.Failed = msg.Summary.Failed
+			report.Errored = msg.Summary.Errored
+			report.Skipped = msg.Summary.Skipped
+			switch msg.Summary.Status {
+			case "pass":
+				report.Status = models.ModuleTestRunStatusPassed
+			case "fail":
+				report.Status = models.ModuleTestRunStatusFailed
+			default:
+				report.Status = models.ModuleTestRunStatusErrored
+			}
+		}
+	}
+
+	// 有 run 级结果的文件不再保留文件级记录
+	filesWithRuns := map[string]bool{}
+	for _, k := range order {
+		if k.run != "" {
+			filesWithRuns[k.file] = true
+		}
+	}
+
+	for _, k := range order {
+		if k.run == "" && filesWithRuns[k.file] {
+			continue
+		}
+		report.Results = append(report.Results, models.ModuleTestResult{
+			TestFile:    k.file,
+			RunName:     k.run,
+			Status:      statuses[k],
+			Diagnostics: strings.Join(diagnostics[k], "\n\n"),
+		})
+	}
+
+	if !summaryFound {
+		for _, r := range report.Results {
+			switch r.Status {
+			case "pass":
+				report.Passed++
+			case "fail":
+				report.Failed++
+			case "error":
+				report.Errored++
+			case "skip":
+				report.Skipped++
+			}
+		}
+		report.Status = models.ModuleTestRunStatusErrored
+		if report.ErrorMessage == "" {
+			report.ErrorMessage = "test output did not contain a summary"
+		}
+	}
+	report.Total = report.Passed + report.Failed + report.Errored + report.Skipped
+	if report.Total == 0 && report.Status == models.ModuleTestRunStatusPassed {
+		report.Status = models.ModuleTestRunStatusErrored
+		report.ErrorMessage = "no tests were executed"
+	}
+	return report
+}
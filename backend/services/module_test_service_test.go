@@ -0,0 +1,293 @@
+package services
+
+import (
+	"testing"
+
+	"iac-platform/internal/models"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func setupModuleTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := setupTestDB(t)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+
+	stmts := []string{
+		`CREATE TABLE modules (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			provider TEXT NOT NULL,
+			source TEXT NOT NULL,
+			module_source TEXT,
+			version TEXT NOT NULL,
+			description TEXT,
+			status TEXT DEFAULT 'active',
+			default_version_id TEXT,
+			vcs_provider_id INTEGER,
+			repository_url TEXT,
+			branch TEXT DEFAULT 'main',
+			path TEXT DEFAULT '/',
+			module_files BLOB,
+			ai_prompts TEXT DEFAULT '[]',
+			sync_status TEXT DEFAULT 'pending',
+			require_passing_test INTEGER DEFAULT 0,
+			last_sync_at DATETIME,
+			created_by TEXT,
+			created_at DATETIME,
+			updated_at DATETIME
+		)`,
+		`CREATE TABLE module_versions (
+			id TEXT PRIMARY KEY,
+			module_id INTEGER NOT NULL,
+			version TEXT NOT NULL,
+			source TEXT,
+			module_source TEXT,
+			is_default INTEGER DEFAULT 0,
+			status TEXT DEFAULT 'active',
+			active_schema_id INTEGER,
+			inherited_from_version_id TEXT,
+			created_by TEXT,
+			created_at DATETIME,
+			updated_at DATETIME
+		)`,
+		`CREATE TABLE module_demos (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			module_id INTEGER NOT NULL,
+			module_version_id TEXT,
+			name TEXT NOT NULL,
+			description TEXT,
+			current_version_id INTEGER,
+			is_active INTEGER DEFAULT 1,
+			usage_notes TEXT,
+			inherited_from_demo_id INTEGER,
+			created_by TEXT,
+			created_at DATETIME,
+			updated_at DATETIME
+		)`,
+		`CREATE TABLE module_demo_versions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			demo_id INTEGER NOT NULL,
+			version INTEGER NOT NULL,
+			is_latest INTEGER DEFAULT 0,
+			config_data BLOB NOT NULL,
+			change_summary TEXT,
+			change_type TEXT,
+			diff_from_previous TEXT,
+			created_by TEXT,
+			created_at DATETIME
+		)`,
+		`CREATE TABLE module_test_runs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			run_id TEXT UNIQUE NOT NULL,
+			module_id INTEGER NOT NULL,
+			module_version_id TEXT NOT NULL,
+			workspace_id TEXT NOT NULL,
+			task_id INTEGER,
+			engine TEXT,
+			engine_version TEXT,
+			include_demo_tests INTEGER DEFAULT 1,
+			demo_test_command TEXT DEFAULT 'plan',
+			test_files BLOB,
+			source_revision TEXT,
+			status TEXT DEFAULT 'pending',
+			total_count INTEGER DEFAULT 0,
+			passed_count INTEGER DEFAULT 0,
+			failed_count INTEGER DEFAULT 0,
+			errored_count INTEGER DEFAULT 0,
+			skipped_count INTEGER DEFAULT 0,
+			error_message TEXT,
+			created_by TEXT,
+			started_at DATETIME,
+			completed_at DATETIME,
+			created_at DATETIME,
+			updated_at DATETIME
+		)`,
+		`CREATE TABLE module_test_results (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			run_id TEXT NOT NULL,
+			test_file TEXT NOT NULL,
+			run_name TEXT,
+			status TEXT NOT NULL,
+			generated INTEGER DEFAULT 0,
+			diagnostics TEXT,
+			created_at DATETIME
+		)`,
+	}
+	for _, stmt := range stmts {
+		_, err := sqlDB.Exec(stmt)
+		require.NoError(t, err)
+	}
+
+	_, err = sqlDB.Exec(`INSERT INTO workspaces (workspace_id, name, execution_mode, terraform_version) VALUES ('ws-test', 'module-tests', 'local', '1.9.0')`)
+	require.NoError(t, err)
+	_, err = sqlDB.Exec(`INSERT INTO modules (id, name, provider, source, version, module_files, require_passing_test, sync_status)
+		VALUES (1, 's3', 'AWS', 'terraform-aws-modules/s3-bucket/aws', '4.0.0', ?, 1, 'synced')`,
+		[]byte(`{"main.tf":"variable \"bucket\" {}\n","tests/basic.tftest.hcl":"run \"basic\" {}\n","examples/x.tftest.hcl":"run \"ignored\" {}\n"}`))
+	require.NoError(t, err)
+	_, err = sqlDB.Exec(`INSERT INTO module_versions (id, module_id, version, is_default) VALUES ('modv-1', 1, '4.0.0', 1), ('modv-2', 1, '4.1.0', 0)`)
+	require.NoError(t, err)
+	_, err = sqlDB.Exec(`INSERT INTO module_demos (id, module_id, module_version_id, name, current_version_id, is_active) VALUES (1, 1, 'modv-2', 'Private Bucket', 1, 1)`)
+	require.NoError(t, err)
+	_, err = sqlDB.Exec(`INSERT INTO module_demo_versions (id, demo_id, version, is_latest, config_data) VALUES (1, 1, 3, 1, ?)`,
+		[]byte(`{"bucket":"demo-bucket","tags":{"env":"dev"},"versioning":true,"unset":null}`))
+	require.NoError(t, err)
+	return db
+}
+
+func TestParseTerraformTestJSON(t *testing.T) {
+	output := `{"@level":"info","@message":"Found 2 files and 3 run blocks","type":"test_abstract","test_abstract":{"main.tftest.hcl":["a","b"],"tests/other.tftest.hcl":["c"]}}
+{"@level":"info","@message":"main.tftest.hcl... in progress","@testfile":"main.tftest.hcl","type":"test_file","test_file":{"path":"main.tftest.hcl","progress":"starting"}}
+{"@level":"info","@message":"  \"a\"... pass","@testfile":"main.tftest.hcl","@testrun":"a","type":"test_run","test_run":{"path":"main.tftest.hcl","run":"a","progress":"complete","status":"pass"}}
+{"@level":"error","@message":"Error: Test assertion failed","@testfile":"main.tftest.hcl","@testrun":"b","type":"diagnostic","diagnostic":{"severity":"error","summary":"Test assertion failed","detail":"bucket name must be lowercase"}}
+{"@level":"info","@message":"  \"b\"... fail","@testfile":"main.tftest.hcl","@testrun":"b","type":"test_run","test_run":{"path":"main.tftest.hcl","run":"b","progress":"complete","status":"fail"}}
+{"@level":"info","@message":"main.tftest.hcl... fail","@testfile":"main.tftest.hcl","type":"test_file","test_file":{"path":"main.tftest.hcl","progress":"complete","status":"fail"}}
+{"@level":"info","@message":"  \"c\"... skip","@testfile":"tests/other.tftest.hcl","@testrun":"c","type":"test_run","test_run":{"path":"tests/other.tftest.hcl","run":"c","progress":"complete","status":"skip"}}
+{"@level":"info","@message":"Failure! 1 passed, 1 failed, 1 skipped.","type":"test_summary","test_summary":{"status":"fail","passed":1,"failed":1,"errored":0,"skipped":1}}`
+
+	report := ParseTerraformTestJSON(output)
+	assert.Equal(t, models.ModuleTestRunStatusFailed, report.Status)
+	assert.Equal(t, 3, report.Total)
+	assert.Equal(t, 1, report.Passed)
+	assert.Equal(t, 1, report.Failed)
+	assert.Equal(t, 1, report.Skipped)
+	require.Len(t, report.Results, 3, "file-level result is dropped when run results exist")
+	assert.Equal(t, "b", report.Results[1].RunName)
+	assert.Equal(t, "fail", report.Results[1].Status)
+	assert.Contains(t, report.Results[1].Diagnostics, "bucket name must be lowercase")
+
+	// 配置错误：没有 summary 时视为 errored
+	report = ParseTerraformTestJSON(`{"@level":"error","@message":"Error: Unsupported argument","type":"diagnostic","diagnostic":{"severity":"error","summary":"Unsupported argument"}}`)
+	assert.Equal(t, models.ModuleTestRunStatusErrored, report.Status)
+	assert.Equal(t, "Unsupported argument", report.ErrorMessage)
+
+	// 没有执行任何测试不算通过
+	report = ParseTerraformTestJSON(`{"type":"test_summary","test_summary":{"status":"pass","passed":0,"failed":0,"errored":0,"skipped":0}}`)
+	assert.Equal(t, models.ModuleTestRunStatusErrored, report.Status)
+}
+
+func TestGenerateDemoTestFile(t *testing.T) {
+	demo := &models.ModuleDemo{
+		ID:   7,
+		Name: "Private Bucket (v2)",
+		CurrentVersion: &models.ModuleDemoVersion{
+			Version:    2,
+			ConfigData: models.JSONB{"bucket": "demo", "tags": map[string]interface{}{"env": "dev"}, "count": 2, "bad key": "x"},
+		},
+	}
+
+	name, content, err := GenerateDemoTestFile(demo, "")
+	require.NoError(t, err)
+	assert.Equal(t, "tests/generated_demo_private_bucket_v2.tftest.hcl", name)
+
+	file, diags := hclsyntax.ParseConfig([]byte(content), name, hcl.InitialPos)
+	require.False(t, diags.HasErrors(), diags.Error())
+	blocks := file.Body.(*hclsyntax.Body).Blocks
+	require.Len(t, blocks, 1)
+	assert.Equal(t, "run", blocks[0].Type)
+	assert.Equal(t, []string{"demo_private_bucket_v2"}, blocks[0].Labels)
+	assert.Contains(t, content, "command = plan")
+	assert.Contains(t, content, `bucket = "demo"`)
+	assert.NotContains(t, content, "bad key")
+
+	_, content, err = GenerateDemoTestFile(demo, "apply")
+	require.NoError(t, err)
+	assert.Contains(t, content, "command = apply")
+}
+
+func TestModuleTestService_RunLifecycleAndDefaultGate(t *testing.T) {
+	db := setupModuleTestDB(t)
+	svc := NewModuleTestService(db)
+	versionSvc := NewModuleVersionService(db)
+
+	// 没有测试运行时不能设为默认版本
+	err := versionSvc.SetDefaultVersion(1, "modv-2")
+	require.ErrorIs(t, err, ErrModuleVersionTestRequired)
+
+	run, err := svc.CreateRun(1, "modv-2", &CreateModuleTestRunRequest{WorkspaceID: "ws-test"}, "user-1")
+	require.NoError(t, err)
+	require.NotNil(t, run.TaskID)
+	assert.Equal(t, models.ModuleTestRunStatusPending, run.Status)
+
+	var taskType string
+	require.NoError(t, db.Raw("SELECT task_type FROM workspace_tasks WHERE id = ?", *run.TaskID).Scan(&taskType).Error)
+	assert.Equal(t, string(models.TaskTypeTest), taskType)
+
+	bundle, err := svc.BuildBundleForTask(*run.TaskID)
+	require.NoError(t, err)
+	assert.Contains(t, bundle.ModuleFiles, "tests/basic.tftest.hcl")
+	require.Contains(t, bundle.GeneratedTests, "tests/generated_demo_private_bucket.tftest.hcl")
+	assert.Contains(t, bundle.GeneratedTests["tests/generated_demo_private_bucket.tftest.hcl"], `bucket = "demo-bucket"`)
+
+	stored, err := svc.GetRun(run.RunID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ModuleTestRunStatusRunning, stored.Status)
+	assert.Equal(t, []interface{}{"tests/basic.tftest.hcl", "tests/generated_demo_private_bucket.tftest.hcl"}, stored.TestFiles["files"])
+
+	// 失败的运行不能解除门禁
+	require.NoError(t, svc.CompleteRun(*run.TaskID, &ModuleTestReport{
+		Status: models.ModuleTestRunStatusFailed, Total: 2, Passed: 1, Failed: 1,
+		Results: []models.ModuleTestResult{
+			{TestFile: "tests/basic.tftest.hcl", RunName: "basic", Status: "pass"},
+			{TestFile: "tests/generated_demo_private_bucket.tftest.hcl", RunName: "demo_private_bucket", Status: "fail"},
+		},
+	}))
+	err = versionSvc.SetDefaultVersion(1, "modv-2")
+	require.ErrorIs(t, err, ErrModuleVersionTestRequired)
+
+	stored, err = svc.GetRun(run.RunID)
+	require.NoError(t, err)
+	require.Len(t, stored.Results, 2)
+	assert.False(t, stored.Results[0].Generated)
+	assert.True(t, stored.Results[1].Generated)
+
+	// 新的通过运行解除门禁；结果重复上报保持幂等
+	run2, err := svc.CreateRun(1, "modv-2", &CreateModuleTestRunRequest{WorkspaceID: "ws-test"}, "user-1")
+	require.NoError(t, err)
+	_, err = svc.BuildBundleForTask(*run2.TaskID)
+	require.NoError(t, err)
+	passed := &ModuleTestReport{
+		Status: models.ModuleTestRunStatusPassed, Total: 1, Passed: 1,
+		Results: []models.ModuleTestResult{{TestFile: "tests/basic.tftest.hcl", RunName: "basic", Status: "pass"}},
+	}
+	require.NoError(t, svc.CompleteRun(*run2.TaskID, passed))
+	require.NoError(t, svc.CompleteRun(*run2.TaskID, passed))
+	stored, err = svc.GetRun(run2.RunID)
+	require.NoError(t, err)
+	assert.Len(t, stored.Results, 1)
+	assert.NotEmpty(t, stored.SourceRevision)
+
+	require.NoError(t, versionSvc.SetDefaultVersion(1, "modv-2"))
+
+	// 模块重新同步后，之前的通过运行不再作为依据
+	require.NoError(t, db.Exec(`UPDATE modules SET module_files = ? WHERE id = 1`,
+		`{"main.tf": "resource \"aws_s3_bucket\" \"this\" { bucket = var.bucket_name }"}`).Error)
+	err = versionSvc.SetDefaultVersion(1, "modv-2")
+	require.ErrorIs(t, err, ErrModuleVersionTestRequired)
+	assert.Contains(t, err.Error(), "module files changed")
+}
+
+func TestModuleTestService_ReconcilesFailedTask(t *testing.T) {
+	db := setupModuleTestDB(t)
+	svc := NewModuleTestService(db)
+
+	run, err := svc.CreateRun(1, "modv-2", &CreateModuleTestRunRequest{WorkspaceID: "ws-test", DemoTestCommand: "apply"}, "")
+	require.NoError(t, err)
+
+	// 任务在 init 阶段失败，未上报结果
+	require.NoError(t, db.Exec("UPDATE workspace_tasks SET status = ?, error_message = ? WHERE id = ?",
+		models.TaskStatusFailed, "init failed", *run.TaskID).Error)
+
+	stored, err := svc.GetRun(run.RunID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ModuleTestRunStatusErrored, stored.Status)
+	assert.Equal(t, "init failed", stored.ErrorMessage)
+
+	_, err = svc.CreateRun(1, "modv-2", &CreateModuleTestRunRequest{WorkspaceID: "ws-test", DemoTestCommand: "destroy"}, "")
+	assert.Error(t, err)
+}
@@ -279,8 +279,11 @@ func (s *ModuleVersionService) CreateVersion(moduleID uint, req *CreateModuleVer
 			}
 		}
 
-		// 如果设为默认版本
+		// 如果设为默认版本（新版本还没有测试运行，开启 require_passing_test 的模块不允许）
 		if req.SetAsDefault {
+			if module.RequirePassingTest {
+				return ErrModuleVersionTestRequired
+			}
 			if err := s.setDefaultVersionTx(tx, moduleID, newVersion.ID); err != nil {
 				return err
 			}
@@ -394,6 +397,15 @@ func (s *ModuleVersionService) SetDefaultVersion(moduleID uint, versionID string
 		return errors.New("version not found")
 	}
 
+	// 模块开启 require_passing_test 时，要求该版本最近一次测试运行通过
+	var module models.Module
+	if err := s.db.First(&module, moduleID).Error; err != nil {
+		return errors.New("module not found")
+	}
+	if err := NewModuleTestService(s.db).CheckDefaultVersionGate(&module, versionID); err != nil {
+		return err
+	}
+
 	return s.db.Transaction(func(tx *gorm.DB) error {
 		return s.setDefaultVersionTx(tx, moduleID, versionID)
 	})
@@ -398,6 +398,9 @@ func (s *NotificationSender) buildWebhookPayload(
 		if task.Description != "" {
 			taskData["description"] = task.Description
 		}
+		if estimate := s.getCostEstimate(task); estimate != nil {
+			taskData["cost_estimate"] = CostEstimateSummary(estimate)
+		}
 		payload["task"] = taskData
 	}
 
@@ -413,6 +416,18 @@ func (s *NotificationSender) buildWebhookPayload(
 	return payload
 }
 
+// getCostEstimate 获取任务的成本估算汇总，没有估算时返回 nil
+func (s *NotificationSender) getCostEstimate(task *models.WorkspaceTask) *models.TaskCostEstimate {
+	if s.db == nil || task == nil || task.ID == 0 {
+		return nil
+	}
+	var estimate models.TaskCostEstimate
+	if err := s.db.Where("task_id = ?", task.ID).First(&estimate).Error; err != nil {
+		return nil
+	}
+	return &estimate
+}
+
 // buildTestWebhookPayload 构建测试 Webhook 请求体
 func (s *NotificationSender) buildTestWebhookPayload(event string, testMessage string) map[string]interface{} {
 	return map[string]interface{}{
@@ -482,6 +497,13 @@ func (s *NotificationSender) buildLarkCardPayload(
 			contentParts = append(contentParts, fmt.Sprintf("**Description:** %s", task.Description))
 		}
 		contentParts = append(contentParts, fmt.Sprintf("**Status:** %s", task.Status))
+		if estimate := s.getCostEstimate(task); estimate != nil {
+			costLine := fmt.Sprintf("**Cost:** %s", FormatCostDelta(estimate))
+			if estimate.BudgetStatus == models.CostBudgetStatusExceeded {
+				costLine += fmt.Sprintf(" ⚠️ %s", estimate.BudgetMessage)
+			}
+			contentParts = append(contentParts, costLine)
+		}
 		// 获取用户真实名字
 		createdByName := "Unknown"
 		if task.CreatedBy != nil && s.db != nil {
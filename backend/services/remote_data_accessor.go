@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"iac-platform/internal/models"
 	"log"
+	"sync"
 	"time"
 )
 
@@ -14,13 +15,17 @@ type RemoteDataAccessor struct {
 	apiClient     *AgentAPIClient
 	taskData      map[string]interface{} // Cached task data
 	streamManager *OutputStreamManager   // For WebSocket updates
+
+	logOffsetsMu sync.Mutex
+	logOffsets   map[string]int64 // "taskID/phase" -> 已上传日志的字节偏移
 }
 
 // NewRemoteDataAccessor creates a new remote data accessor
 func NewRemoteDataAccessor(apiClient *AgentAPIClient) *RemoteDataAccessor {
 	return &RemoteDataAccessor{
-		apiClient: apiClient,
-		taskData:  make(map[string]interface{}),
+		apiClient:  apiClient,
+		taskData:   make(map[string]interface{}),
+		logOffsets: make(map[string]int64),
 	}
 }
 
@@ -84,6 +89,8 @@ func (a *RemoteDataAccessor) GetWorkspace(workspaceID string) (*models.Workspace
 		ProviderConfig:   getMap(workspaceData, "provider_config"),
 		TFCode:           getMap(workspaceData, "tf_code"),
 		SystemVariables:  getMap(workspaceData, "system_variables"),
+
+		StaticAnalysisConfig: getMap(workspaceData, "static_analysis_config"),
 	}
 
 	return workspace, nil
@@ -284,9 +291,16 @@ func (a *RemoteDataAccessor) UpdateTask(task *models.WorkspaceTask) error {
 }
 
 // SaveTaskLog 保存任务日志
+// 每个日志块带上其在该阶段日志中的偏移，服务端按偏移去重，内容相同的不同日志块不会被丢弃
 func (a *RemoteDataAccessor) SaveTaskLog(taskID uint, phase, content, level string) error {
+	key := fmt.Sprintf("%d/%s", taskID, phase)
+	a.logOffsetsMu.Lock()
+	offset := a.logOffsets[key]
+	a.logOffsets[key] = offset + int64(len(content))
+	a.logOffsetsMu.Unlock()
+
 	// Upload log chunk
-	_, err := a.apiClient.UploadLogChunk(taskID, phase, content, 0, "")
+	_, err := a.apiClient.UploadLogChunk(taskID, phase, content, offset, "")
 	return err
 }
 
@@ -591,7 +605,7 @@ func (a *RemoteDataAccessor) CheckResourceVersionExistsByVersion(resourceID stri
 }
 
 // GetRemoteDataConfig 获取 Workspace 的 remote data 配置（Agent模式）
-// 返回服务端已经生成好token的remote data配置
+// 返回服务端已按 OutputsSharing 过滤过的 remote data 配置（data_name、source_workspace_id）
 func (a *RemoteDataAccessor) GetRemoteDataConfig() []map[string]interface{} {
 	remoteDataData, ok := a.taskData["remote_data"].([]interface{})
 	if !ok {
@@ -608,6 +622,26 @@ func (a *RemoteDataAccessor) GetRemoteDataConfig() []map[string]interface{} {
 	return result
 }
 
+// GetRemoteStateCredentials 获取服务端签发的 terraform_remote_state 凭证（Agent模式）
+func (a *RemoteDataAccessor) GetRemoteStateCredentials() *RemoteStateCredentials {
+	credsData, ok := a.taskData["remote_state_credentials"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	creds := &RemoteStateCredentials{
+		Username: getString(credsData, "username"),
+		Password: getString(credsData, "password"),
+	}
+	if creds.Username == "" || creds.Password == "" {
+		return nil
+	}
+	if expiresAt, err := time.Parse(time.RFC3339, getString(credsData, "expires_at")); err == nil {
+		creds.ExpiresAt = expiresAt
+	}
+	return creds
+}
+
 // GetModuleVersions 获取 module 版本映射（Agent模式）
 // 返回 map[string]string，key 是 {provider}_{name}，value 是 version
 func (a *RemoteDataAccessor) GetModuleVersions() map[string]string {
@@ -1,8 +1,6 @@
 package services
 
 import (
-	"crypto/rand"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -30,116 +28,97 @@ func NewRemoteDataTFGenerator(db *gorm.DB, baseURL string) *RemoteDataTFGenerato
 	}
 }
 
-// generateToken 生成临时访问token
-func (g *RemoteDataTFGenerator) generateToken(
-	workspaceID string,
-	sourceWorkspaceID string,
-	taskID *uint,
-) (*models.RemoteDataToken, error) {
-	// 生成随机token
-	tokenBytes := make([]byte, 32)
-	if _, err := rand.Read(tokenBytes); err != nil {
-		return nil, fmt.Errorf("failed to generate token: %w", err)
-	}
-	tokenStr := hex.EncodeToString(tokenBytes)
-
-	// 生成唯一的token_id
-	tokenIDBytes := make([]byte, 8)
-	if _, err := rand.Read(tokenIDBytes); err != nil {
-		return nil, fmt.Errorf("failed to generate token_id: %w", err)
-	}
-	tokenID := fmt.Sprintf("rdt-%s", hex.EncodeToString(tokenIDBytes))
-
-	// 创建token记录
-	// WorkspaceID = 被访问的workspace（source）
-	// RequesterWorkspaceID = 请求方workspace
-	token := &models.RemoteDataToken{
-		TokenID:              tokenID,
-		Token:                tokenStr,
-		WorkspaceID:          sourceWorkspaceID, // 被访问的workspace
-		RequesterWorkspaceID: workspaceID,       // 请求方workspace
-		TaskID:               taskID,
-		ExpiresAt:            time.Now().Add(30 * time.Minute), // 30分钟有效
-		MaxUses:              5,                                // 最多使用5次
-		UsedCount:            0,
-	}
-
-	if err := g.db.Create(token).Error; err != nil {
-		return nil, fmt.Errorf("failed to create token: %w", err)
-	}
-
-	return token, nil
+// RemoteStateRef 一个 Remote Data 引用：以 DataName 读取源 workspace 的 outputs
+type RemoteStateRef struct {
+	DataName          string
+	SourceWorkspaceID string
 }
 
-// GenerateRemoteDataTFWithLogging 生成remote_data.tf文件（带日志）
+// GenerateRemoteDataTFWithLogging 生成remote_data.tf.json（带日志）
+// 每个 Remote Data 生成一个 terraform_remote_state 数据源（http backend 指向平台的 remote-state 接口）。
+// 返回的凭证需要通过环境变量传给 terraform，不写入工作目录；没有可用的 Remote Data 时返回 nil
 func (g *RemoteDataTFGenerator) GenerateRemoteDataTFWithLogging(
 	workspaceID string,
 	workDir string,
 	taskID *uint,
 	logger *TerraformLogger,
-) error {
+) (*RemoteStateCredentials, error) {
 	// 查询workspace的remote data配置
 	var remoteDataList []models.WorkspaceRemoteData
 	if err := g.db.Where("workspace_id = ?", workspaceID).Find(&remoteDataList).Error; err != nil {
-		return fmt.Errorf("failed to get remote data list: %w", err)
+		return nil, fmt.Errorf("failed to get remote data list: %w", err)
 	}
 
 	// 如果没有配置remote data，不生成文件
 	if len(remoteDataList) == 0 {
 		logger.Debug("No remote data configured, skipping remote_data.tf generation")
-		return nil
+		return nil, nil
 	}
 
 	logger.Info("Generating remote_data.tf with %d remote data references...", len(remoteDataList))
 
-	// 构建TF配置
-	tfConfig := make(map[string]interface{})
-	dataBlocks := make(map[string]interface{})
-	localBlocks := make(map[string]interface{})
-
+	stateService := NewRemoteStateService(g.db)
+	refs := make([]RemoteStateRef, 0, len(remoteDataList))
 	for _, rd := range remoteDataList {
-		// 为每个remote data生成临时token
-		token, err := g.generateToken(workspaceID, rd.SourceWorkspaceID, taskID)
+		allowed, err := stateService.CanReadOutputs(workspaceID, rd.SourceWorkspaceID)
 		if err != nil {
-			logger.Warn("Failed to generate token for remote data %s: %v", rd.RemoteDataID, err)
+			logger.Warn("Failed to check outputs sharing for remote data %s: %v", rd.RemoteDataID, err)
 			continue
 		}
+		if !allowed {
+			logger.Warn("Workspace %s does not share its outputs with this workspace, skipping remote data %s",
+				rd.SourceWorkspaceID, rd.DataName)
+			continue
+		}
+		refs = append(refs, RemoteStateRef{DataName: rd.DataName, SourceWorkspaceID: rd.SourceWorkspaceID})
+	}
 
-		logger.Debug("Generated token for remote data %s (expires: %s, max_uses: %d)",
-			rd.DataName, token.ExpiresAt.Format(time.RFC3339), token.MaxUses)
+	// 只有当有有效的data blocks时才生成文件
+	if len(refs) == 0 {
+		logger.Warn("No valid remote data blocks generated")
+		return nil, nil
+	}
 
-		// 生成data "http" block
-		dataBlockName := fmt.Sprintf("remote_%s", sanitizeName(rd.DataName))
-		url := fmt.Sprintf("%s/api/v1/workspaces/%s/state-outputs/full", g.baseURL, rd.SourceWorkspaceID)
+	creds, err := stateService.IssueCredentials(workspaceID, taskID, len(refs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue remote state credentials: %w", err)
+	}
+	logger.Debug("Issued remote state credentials (expires: %s)", creds.ExpiresAt.Format(time.RFC3339))
 
+	if err := WriteRemoteDataTFJSON(workDir, g.baseURL, refs, logger); err != nil {
+		return nil, err
+	}
+	return creds, nil
+}
+
+// WriteRemoteDataTFJSON 写入remote_data.tf.json，Local 和 Agent 模式共用
+// local.<data_name> 保持 {<output> = {value = ...}} 的结构，已有的 local.<data_name>.<output>.value 引用不变，
+// value 直接来自 terraform_remote_state，类型与 sensitive 标记都会保留
+func WriteRemoteDataTFJSON(workDir, baseURL string, refs []RemoteStateRef, logger *TerraformLogger) error {
+	dataBlocks := make(map[string]interface{})
+	localBlocks := make(map[string]interface{})
+
+	for _, ref := range refs {
+		dataBlockName := fmt.Sprintf("remote_%s", sanitizeName(ref.DataName))
 		dataBlocks[dataBlockName] = []map[string]interface{}{
 			{
-				"url": url,
-				"request_headers": map[string]interface{}{
-					"Authorization": fmt.Sprintf("Bearer %s", token.Token),
+				"backend": "http",
+				"config": map[string]interface{}{
+					"address": RemoteStateAddress(baseURL, ref.SourceWorkspaceID),
 				},
 			},
 		}
+		localBlocks[ref.DataName] = fmt.Sprintf(
+			"${{for name, output in data.terraform_remote_state.%s.outputs : name => {value = output}}}", dataBlockName)
 
-		// 生成local block
-		localBlocks[rd.DataName] = fmt.Sprintf("${jsondecode(data.http.%s.response_body).outputs}", dataBlockName)
-
-		logger.Info("✓ Added remote data reference: %s -> %s", rd.DataName, rd.SourceWorkspaceID)
-	}
-
-	// 只有当有有效的data blocks时才生成文件
-	if len(dataBlocks) == 0 {
-		logger.Warn("No valid remote data blocks generated")
-		return nil
-	}
-
-	// 构建完整的TF配置
-	tfConfig["data"] = map[string]interface{}{
-		"http": dataBlocks,
+		logger.Info("✓ Added remote data reference: %s -> %s", ref.DataName, ref.SourceWorkspaceID)
 	}
 
-	if len(localBlocks) > 0 {
-		tfConfig["locals"] = localBlocks
+	tfConfig := map[string]interface{}{
+		"data": map[string]interface{}{
+			"terraform_remote_state": dataBlocks,
+		},
+		"locals": localBlocks,
 	}
 
 	// 写入文件
@@ -173,16 +152,6 @@ func sanitizeName(name string) string {
 	return result
 }
 
-// GenerateTokenForAgent 为Agent模式生成临时token
-// 这个方法在服务端调用，生成的token会通过API传递给Agent
-func (g *RemoteDataTFGenerator) GenerateTokenForAgent(workspaceID, sourceWorkspaceID string, taskID *uint) (string, error) {
-	token, err := g.generateToken(workspaceID, sourceWorkspaceID, taskID)
-	if err != nil {
-		return "", err
-	}
-	return token.Token, nil
-}
-
 // CleanupExpiredTokens 清理过期的token
 func (g *RemoteDataTFGenerator) CleanupExpiredTokens() error {
 	result := g.db.Where("expires_at < ? OR used_count >= max_uses", time.Now()).
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"iac-platform/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrRemoteStateUnauthorized 凭证无效、过期或已用完
+	ErrRemoteStateUnauthorized = errors.New("invalid remote state credentials")
+	// ErrRemoteStateForbidden 源 workspace 未向请求方共享 outputs
+	ErrRemoteStateForbidden = errors.New("workspace does not share its outputs with the requester")
+)
+
+const (
+	remoteStateTokenTTL = 30 * time.Minute
+	// 每个源 workspace 允许读取的次数（init/validate/plan 等命令会重复读取）
+	remoteStateUsesPerSource = 5
+)
+
+// RemoteStateCredentials terraform_remote_state（http backend）的短期凭证
+// 只通过环境变量传给 terraform，不写入工作目录
+type RemoteStateCredentials struct {
+	Username  string    `json:"username"` // 请求方 workspace ID
+	Password  string    `json:"password"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Env 返回 http backend 读取的凭证环境变量
+func (c *RemoteStateCredentials) Env() []string {
+	if c == nil || c.Password == "" {
+		return nil
+	}
+	return []string{
+		"TF_HTTP_USERNAME=" + c.Username,
+		"TF_HTTP_PASSWORD=" + c.Password,
+	}
+}
+
+// RemoteStateAddress 返回源 workspace 的 remote state 地址，用作 http backend 的 address
+func RemoteStateAddress(baseURL, sourceWorkspaceID string) string {
+	return fmt.Sprintf("%s/api/v1/workspaces/%s/remote-state", strings.TrimRight(baseURL, "/"), sourceWorkspaceID)
+}
+
+// RemoteStateService 为 terraform_remote_state 提供凭证签发、访问校验和只含 outputs 的 State 快照
+type RemoteStateService struct {
+	db *gorm.DB
+}
+
+// NewRemoteStateService 创建 RemoteStateService
+func NewRemoteStateService(db *gorm.DB) *RemoteStateService {
+	return &RemoteStateService{db: db}
+}
+
+// CanReadOutputs 按源 workspace 的 OutputsSharing 判断请求方能否读取其 outputs
+func (s *RemoteStateService) CanReadOutputs(requesterWorkspaceID, sourceWorkspaceID string) (bool, error) {
+	var source models.Workspace
+	if err := s.db.Select("workspace_id", "outputs_sharing").
+		Where("workspace_id = ?", sourceWorkspaceID).First(&source).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	switch models.OutputsSharingMode(source.OutputsSharing) {
+	case models.OutputsSharingAll:
+		return true, nil
+	case models.OutputsSharingSpecific:
+		var count int64
+		if err := s.db.Model(&models.WorkspaceOutputsAccess{}).
+			Where("workspace_id = ? AND allowed_workspace_id = ?", sourceWorkspaceID, requesterWorkspaceID).
+			Count(&count).Error; err != nil {
+			return false, err
+		}
+		return count > 0, nil
+	default:
+		return false, nil
+	}
+}
+
+// IssueCredentials 为请求方 workspace 签发短期凭证
+// 凭证不绑定源 workspace，每次读取时按 OutputsSharing 校验，sources 决定可用次数
+func (s *RemoteStateService) IssueCredentials(requesterWorkspaceID string, taskID *uint, sources int) (*RemoteStateCredentials, error) {
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+	tokenIDBytes := make([]byte, 8)
+	if _, err := rand.Read(tokenIDBytes); err != nil {
+		return nil, fmt.Errorf("failed to generate token_id: %w", err)
+	}
+	if sources < 1 {
+		sources = 1
+	}
+
+	token := &models.RemoteDataToken{
+		TokenID:              fmt.Sprintf("rdt-%s", hex.EncodeToString(tokenIDBytes)),
+		Token:                hex.EncodeToString(tokenBytes),
+		WorkspaceID:          requesterWorkspaceID,
+		RequesterWorkspaceID: requesterWorkspaceID,
+		TaskID:               taskID,
+		Scope:                models.RemoteDataTokenScopeRemoteState,
+		MaxUses:              sources * remoteStateUsesPerSource,
+		ExpiresAt:            time.Now().Add(remoteStateTokenTTL),
+	}
+	if err := s.db.Create(token).Error; err != nil {
+		return nil, fmt.Errorf("failed to create token: %w", err)
+	}
+
+	return &RemoteStateCredentials{
+		Username:  requesterWorkspaceID,
+		Password:  token.Token,
+		ExpiresAt: token.ExpiresAt,
+	}, nil
+}
+
+// Authorize 校验凭证能否读取源 workspace 的 remote state，校验通过后记一次使用
+func (s *RemoteStateService) Authorize(username, password, sourceWorkspaceID string) (*models.RemoteDataToken, error) {
+	if username == "" || password == "" {
+		return nil, ErrRemoteStateUnauthorized
+	}
+
+	var token models.RemoteDataToken
+	err := s.db.Where("token = ? AND scope = ?", password, models.RemoteDataTokenScopeRemoteState).First(&token).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRemoteStateUnauthorized
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !token.IsValid() || token.RequesterWorkspaceID != username {
+		return nil, ErrRemoteStateUnauthorized
+	}
+
+	if sourceWorkspaceID != token.RequesterWorkspaceID {
+		allowed, err := s.CanReadOutputs(token.RequesterWorkspaceID, sourceWorkspaceID)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return nil, ErrRemoteStateForbidden
+		}
+	}
+
+	now := time.Now()
+	if err := s.db.Model(&token).Updates(map[string]interface{}{
+		"used_count":   gorm.Expr("used_count + 1"),
+		"last_used_at": now,
+	}).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// Snapshot 返回源 workspace 最新 State 的快照（State v4 格式），只保留 outputs，resources 置空
+// output 的 value/type/sensitive 原样保留，terraform_remote_state 读取后类型不变；没有 State 时返回 nil
+func (s *RemoteStateService) Snapshot(sourceWorkspaceID string) (map[string]interface{}, error) {
+	var version models.WorkspaceStateVersion
+	err := s.db.Where("workspace_id = ?", sourceWorkspaceID).Order("version DESC").First(&version).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if version.Content == nil {
+		return nil, nil
+	}
+
+	outputs := make(map[string]interface{})
+	for name, raw := range stateOutputs(version.Content) {
+		output, ok := raw.(map[string]interface{})
+		if !ok || output["value"] == nil || output["type"] == nil {
+			// 已删除的 output，或缺少类型信息无法还原
+			continue
+		}
+		entry := map[string]interface{}{
+			"value": output["value"],
+			"type":  output["type"],
+		}
+		if sensitive, _ := output["sensitive"].(bool); sensitive {
+			entry["sensitive"] = true
+		}
+		outputs[name] = entry
+	}
+
+	terraformVersion, _ := version.Content["terraform_version"].(string)
+	if terraformVersion == "" {
+		terraformVersion = "1.0.0"
+	}
+	serial := version.Content["serial"]
+	if serial == nil {
+		serial = 0
+	}
+	lineage, _ := version.Content["lineage"].(string)
+
+	return map[string]interface{}{
+		"version":           4,
+		"terraform_version": terraformVersion,
+		"serial":            serial,
+		"lineage":           lineage,
+		"outputs":           outputs,
+		"resources":         []interface{}{},
+	}, nil
+}
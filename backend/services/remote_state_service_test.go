@@ -0,0 +1,182 @@
+package services
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"iac-platform/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func setupRemoteStateTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := setupTestDB(t)
+	statements := []string{
+		`CREATE TABLE workspace_outputs_access (
+			id INTEGER PRIMARY KEY AUTOINCREMENT, workspace_id TEXT NOT NULL, allowed_workspace_id TEXT NOT NULL,
+			created_by TEXT, created_at DATETIME)`,
+		`CREATE TABLE remote_data_tokens (
+			id INTEGER PRIMARY KEY AUTOINCREMENT, token_id TEXT, token TEXT, workspace_id TEXT NOT NULL,
+			requester_workspace_id TEXT NOT NULL, task_id INTEGER, scope TEXT DEFAULT 'outputs', max_uses INTEGER DEFAULT 5,
+			used_count INTEGER DEFAULT 0, expires_at DATETIME NOT NULL, created_at DATETIME, last_used_at DATETIME)`,
+		`CREATE TABLE workspace_state_versions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT, workspace_id TEXT NOT NULL, created_by TEXT, created_at DATETIME,
+			content BLOB NOT NULL, version INTEGER NOT NULL, checksum TEXT NOT NULL DEFAULT '', size_bytes INTEGER,
+			lineage TEXT, serial INTEGER, is_imported INTEGER DEFAULT 0, import_source TEXT, is_rollback INTEGER DEFAULT 0,
+			rollback_from_version INTEGER, description TEXT, task_id INTEGER, resource_count INTEGER DEFAULT 0)`,
+		`CREATE TABLE workspace_remote_data (
+			id INTEGER PRIMARY KEY AUTOINCREMENT, workspace_id TEXT NOT NULL, remote_data_id TEXT,
+			source_workspace_id TEXT NOT NULL, data_name TEXT NOT NULL, description TEXT,
+			created_by TEXT, created_at DATETIME, updated_at DATETIME)`,
+		// ws-app 读取 ws-net（共享给全部）、ws-db（只共享给 ws-app）和 ws-secret（不共享）
+		`INSERT INTO workspace_outputs_access (workspace_id, allowed_workspace_id) VALUES ('ws-db', 'ws-app')`,
+		`INSERT INTO workspace_remote_data (workspace_id, remote_data_id, source_workspace_id, data_name)
+			VALUES ('ws-app', 'rd-1', 'ws-net', 'net'), ('ws-app', 'rd-2', 'ws-db', 'db-main'), ('ws-app', 'rd-3', 'ws-secret', 'secret')`,
+	}
+	for _, stmt := range statements {
+		require.NoError(t, db.Exec(stmt).Error)
+	}
+
+	sharing := map[string]string{"ws-app": "none", "ws-net": "all", "ws-db": "specific", "ws-secret": "none"}
+	for id, mode := range sharing {
+		require.NoError(t, db.Exec(`INSERT INTO workspaces (workspace_id, name, execution_mode, outputs_sharing) VALUES (?, ?, 'local', ?)`,
+			id, id, mode).Error)
+	}
+	return db
+}
+
+func TestRemoteStateService_CanReadOutputs(t *testing.T) {
+	svc := NewRemoteStateService(setupRemoteStateTestDB(t))
+
+	cases := []struct {
+		requester, source string
+		allowed           bool
+	}{
+		{"ws-app", "ws-net", true},
+		{"ws-app", "ws-db", true},
+		{"ws-net", "ws-db", false},
+		{"ws-app", "ws-secret", false},
+		{"ws-app", "ws-missing", false},
+	}
+	for _, tc := range cases {
+		allowed, err := svc.CanReadOutputs(tc.requester, tc.source)
+		require.NoError(t, err)
+		assert.Equal(t, tc.allowed, allowed, "%s -> %s", tc.requester, tc.source)
+	}
+}
+
+func TestRemoteStateService_Authorize(t *testing.T) {
+	db := setupRemoteStateTestDB(t)
+	svc := NewRemoteStateService(db)
+
+	creds, err := svc.IssueCredentials("ws-app", nil, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "ws-app", creds.Username)
+	assert.Equal(t, []string{"TF_HTTP_USERNAME=ws-app", "TF_HTTP_PASSWORD=" + creds.Password}, creds.Env())
+
+	_, err = svc.Authorize("ws-net", creds.Password, "ws-net")
+	assert.ErrorIs(t, err, ErrRemoteStateUnauthorized, "username must match the requester")
+	_, err = svc.Authorize("ws-app", "wrong", "ws-net")
+	assert.ErrorIs(t, err, ErrRemoteStateUnauthorized)
+	_, err = svc.Authorize("ws-app", creds.Password, "ws-secret")
+	assert.ErrorIs(t, err, ErrRemoteStateForbidden)
+
+	token, err := svc.Authorize("ws-app", creds.Password, "ws-db")
+	require.NoError(t, err)
+	assert.Equal(t, models.RemoteDataTokenScopeRemoteState, token.Scope)
+
+	var used models.RemoteDataToken
+	require.NoError(t, db.First(&used, token.ID).Error)
+	assert.Equal(t, 1, used.UsedCount)
+
+	// 用完次数后失效
+	require.NoError(t, db.Model(&used).Update("used_count", used.MaxUses).Error)
+	_, err = svc.Authorize("ws-app", creds.Password, "ws-net")
+	assert.ErrorIs(t, err, ErrRemoteStateUnauthorized)
+
+	// outputs 范围的旧 token 不能用于 remote state
+	require.NoError(t, db.Create(&models.RemoteDataToken{
+		TokenID: "rdt-legacy", Token: "legacy", WorkspaceID: "ws-net", RequesterWorkspaceID: "ws-app",
+		Scope: models.RemoteDataTokenScopeOutputs, MaxUses: 5, ExpiresAt: time.Now().Add(time.Hour),
+	}).Error)
+	_, err = svc.Authorize("ws-app", "legacy", "ws-net")
+	assert.ErrorIs(t, err, ErrRemoteStateUnauthorized)
+}
+
+func TestRemoteStateService_SnapshotPreservesOutputTypes(t *testing.T) {
+	db := setupRemoteStateTestDB(t)
+	svc := NewRemoteStateService(db)
+
+	state, err := svc.Snapshot("ws-net")
+	require.NoError(t, err)
+	assert.Nil(t, state, "no state version yet")
+
+	content, err := json.Marshal(map[string]interface{}{
+		"version": 4, "terraform_version": "1.5.7", "serial": 12, "lineage": "net-lineage",
+		"outputs": map[string]interface{}{
+			"vpc_id":     map[string]interface{}{"value": "vpc-1", "type": "string"},
+			"subnet_ids": map[string]interface{}{"value": []string{"a", "b"}, "type": []interface{}{"list", "string"}},
+			"db_pass":    map[string]interface{}{"value": "s3cret", "type": "string", "sensitive": true},
+			"removed":    map[string]interface{}{"value": nil, "type": "string"},
+		},
+		"resources": []interface{}{map[string]interface{}{"type": "aws_vpc", "name": "main"}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.Exec(`INSERT INTO workspace_state_versions (workspace_id, content, version) VALUES ('ws-net', ?, 1)`, content).Error)
+
+	state, err = svc.Snapshot("ws-net")
+	require.NoError(t, err)
+	raw, err := json.Marshal(state)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"version": 4, "terraform_version": "1.5.7", "serial": 12, "lineage": "net-lineage", "resources": [],
+		"outputs": {
+			"vpc_id": {"value": "vpc-1", "type": "string"},
+			"subnet_ids": {"value": ["a", "b"], "type": ["list", "string"]},
+			"db_pass": {"value": "s3cret", "type": "string", "sensitive": true}
+		}
+	}`, string(raw))
+}
+
+func TestRemoteDataTFGenerator_UsesTerraformRemoteState(t *testing.T) {
+	db := setupRemoteStateTestDB(t)
+	workDir := t.TempDir()
+
+	generator := NewRemoteDataTFGenerator(db, "https://iac.example.com/")
+	creds, err := generator.GenerateRemoteDataTFWithLogging("ws-app", workDir, nil, NewTerraformLogger(nil))
+	require.NoError(t, err)
+	require.NotNil(t, creds)
+
+	content, err := os.ReadFile(filepath.Join(workDir, "remote_data.tf.json"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), creds.Password, "credentials must not be written to the working directory")
+
+	var config struct {
+		Data   map[string]map[string]interface{} `json:"data"`
+		Locals map[string]interface{}            `json:"locals"`
+	}
+	require.NoError(t, json.Unmarshal(content, &config))
+
+	// ws-secret 未共享 outputs，不生成数据源
+	dataBlocks := config.Data["terraform_remote_state"]
+	assert.Len(t, dataBlocks, 2)
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"backend": "http",
+		"config":  map[string]interface{}{"address": "https://iac.example.com/api/v1/workspaces/ws-db/remote-state"},
+	}}, dataBlocks["remote_db_main"])
+	assert.Equal(t, map[string]interface{}{
+		"net":     "${{for name, output in data.terraform_remote_state.remote_net.outputs : name => {value = output}}}",
+		"db-main": "${{for name, output in data.terraform_remote_state.remote_db_main.outputs : name => {value = output}}}",
+	}, config.Locals)
+
+	var token models.RemoteDataToken
+	require.NoError(t, db.Where("token = ?", creds.Password).First(&token).Error)
+	assert.Equal(t, "ws-app", token.RequesterWorkspaceID)
+	assert.Equal(t, 2*remoteStateUsesPerSource, token.MaxUses)
+}
@@ -777,6 +777,10 @@ func (s *ResourceService) ImportResourcesFromTF(
 							},
 						},
 					}
+					// 携带指向该资源的 import 块（如 CMDB 对账生成的导入代码）
+					if imports := importBlocksFor(tfCode, resourceType+"."+resourceName); len(imports) > 0 {
+						resourceTFCode["import"] = imports
+					}
 
 					_, err := s.AddResource(
 						workspaceID,
@@ -799,6 +803,21 @@ func (s *ResourceService) ImportResourcesFromTF(
 	return count, nil
 }
 
+// importBlocksFor 从TF代码中筛选 to 指向指定资源地址的 import 块
+func importBlocksFor(tfCode map[string]interface{}, address string) []interface{} {
+	blocks, ok := tfCode["import"].([]interface{})
+	if !ok {
+		return nil
+	}
+	var matched []interface{}
+	for _, block := range blocks {
+		if blockMap, ok := block.(map[string]interface{}); ok && blockMap["to"] == address {
+			matched = append(matched, blockMap)
+		}
+	}
+	return matched
+}
+
 // GetResourcesByIDs 根据ID列表获取资源
 func (s *ResourceService) GetResourcesByIDs(resourceIDs []uint, resources *[]models.WorkspaceResource) error {
 	return s.db.Where("id IN ? AND is_active = true", resourceIDs).
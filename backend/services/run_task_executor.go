@@ -508,6 +508,14 @@ func (e *RunTaskExecutor) buildWebhookPayload(
 		baseURL := e.getBaseURL()
 		payload["plan_json_api_url"] = fmt.Sprintf("%s/api/v1/run-task-results/%s/plan-json", baseURL, result.ResultID)
 		payload["resource_changes_api_url"] = fmt.Sprintf("%s/api/v1/run-task-results/%s/resource-changes", baseURL, result.ResultID)
+
+		// Include cost estimate summary when the workspace has cost estimation enabled
+		estimate, err := NewCostEstimationService(e.db).EnsureEstimate(context.Background(), task.ID)
+		if err != nil {
+			log.Printf("[RunTask] Failed to get cost estimate for task %d: %v", task.ID, err)
+		} else if estimate != nil {
+			payload["cost_estimate"] = CostEstimateSummary(estimate)
+		}
 	}
 
 	return payload
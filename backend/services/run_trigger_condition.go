@@ -0,0 +1,219 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"iac-platform/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidTriggerCondition 触发条件配置无效
+var ErrInvalidTriggerCondition = errors.New("invalid trigger condition")
+
+var outputNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// NormalizeTriggerCondition 校验触发条件，返回规范化后的条件和关注的 output 列表
+// 空条件视为 apply_success；apply_success 不使用 output 列表
+func NormalizeTriggerCondition(condition string, outputNames []string) (string, models.StringArray, error) {
+	switch condition {
+	case "", models.TriggerConditionApplySuccess:
+		return models.TriggerConditionApplySuccess, nil, nil
+	case models.TriggerConditionOutputsChanged:
+	default:
+		return "", nil, fmt.Errorf("%w: unknown condition %q", ErrInvalidTriggerCondition, condition)
+	}
+
+	names := models.StringArray{}
+	seen := make(map[string]bool)
+	for _, name := range outputNames {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		if !outputNamePattern.MatchString(name) {
+			return "", nil, fmt.Errorf("%w: invalid output name %q", ErrInvalidTriggerCondition, name)
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return condition, names, nil
+}
+
+// sourceOutputChanges 源任务 apply 前后 output 的变化
+// 通过对比源任务产生的 State 版本与上一个版本得到，按需计算一次，供同一任务的所有触发器共用
+type sourceOutputChanges struct {
+	db   *gorm.DB
+	task *models.WorkspaceTask
+
+	loaded  bool
+	changed map[string]bool
+	reason  string // 没有可对比的 State 版本时的说明
+	err     error
+}
+
+// get 返回发生变化（新增、修改、删除）的 output 名称
+func (c *sourceOutputChanges) get() (map[string]bool, string, error) {
+	if c.loaded {
+		return c.changed, c.reason, c.err
+	}
+	c.loaded = true
+	c.changed = make(map[string]bool)
+
+	var current models.WorkspaceStateVersion
+	err := c.db.Where("workspace_id = ? AND task_id = ?", c.task.WorkspaceID, c.task.ID).
+		Order("version DESC").First(&current).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// apply 没有产生新的 State 版本，output 不可能变化
+		c.reason = fmt.Sprintf("task #%d recorded no new state version", c.task.ID)
+		return c.changed, c.reason, nil
+	}
+	if err != nil {
+		c.err = err
+		return nil, "", err
+	}
+
+	var previous models.WorkspaceStateVersion
+	err = c.db.Where("workspace_id = ? AND version < ?", c.task.WorkspaceID, current.Version).
+		Order("version DESC").First(&previous).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.err = err
+		return nil, "", err
+	}
+
+	before := stateOutputs(previous.Content)
+	after := stateOutputs(current.Content)
+	for name, value := range after {
+		if old, ok := before[name]; !ok || !reflect.DeepEqual(old, value) {
+			c.changed[name] = true
+		}
+	}
+	for name := range before {
+		if _, ok := after[name]; !ok {
+			c.changed[name] = true
+		}
+	}
+	return c.changed, "", nil
+}
+
+// stateOutputs 提取 State 中的 outputs（name -> {value, type, sensitive}）
+func stateOutputs(content models.JSONB) map[string]interface{} {
+	if content == nil {
+		return nil
+	}
+	outputs, _ := content["outputs"].(map[string]interface{})
+	return outputs
+}
+
+// evaluateTriggerCondition 判断触发器是否满足触发条件
+// 返回是否触发、发生变化的关注 output，以及不触发时的原因
+func (s *RunTriggerService) evaluateTriggerCondition(trigger *models.RunTrigger, changes *sourceOutputChanges) (bool, []string, string) {
+	if trigger == nil || trigger.TriggerCondition != models.TriggerConditionOutputsChanged {
+		return true, nil, ""
+	}
+
+	changed, reason, err := changes.get()
+	if err != nil {
+		// 无法判断时按 apply_success 处理，避免漏触发
+		log.Printf("[RunTrigger] Failed to diff outputs of task %d, firing trigger %d: %v", changes.task.ID, trigger.ID, err)
+		return true, nil, ""
+	}
+
+	watched := []string(trigger.OutputNames)
+	if len(watched) == 0 {
+		watched, err = s.consumedOutputs(trigger.TargetWorkspaceID, trigger.SourceWorkspaceID)
+		if err != nil {
+			log.Printf("[RunTrigger] Failed to resolve outputs consumed by %s, firing trigger %d: %v",
+				trigger.TargetWorkspaceID, trigger.ID, err)
+			return true, nil, ""
+		}
+	}
+
+	var matched []string
+	if watched == nil {
+		// 关注全部 output
+		for name := range changed {
+			matched = append(matched, name)
+		}
+	} else {
+		for _, name := range watched {
+			if changed[name] {
+				matched = append(matched, name)
+			}
+		}
+	}
+	sort.Strings(matched)
+	if len(matched) > 0 {
+		return true, matched, ""
+	}
+
+	if reason == "" {
+		switch {
+		case watched == nil:
+			reason = "no outputs changed"
+		case len(watched) == 0:
+			reason = "target reads no outputs of the source through remote data"
+		default:
+			reason = fmt.Sprintf("none of the watched outputs changed (%s)", strings.Join(watched, ", "))
+		}
+	}
+	return false, nil, "trigger condition not met: " + reason
+}
+
+// consumedOutputs 返回目标 workspace 通过 Remote Data 读取的源 workspace output
+// 从目标资源代码中的 local.<data_name>.<output> 引用推导；
+// 返回 nil 表示关注全部 output（未配置 Remote Data，或存在整体引用 local.<data_name> 无法确定具体 output）
+func (s *RunTriggerService) consumedOutputs(targetWorkspaceID, sourceWorkspaceID string) ([]string, error) {
+	var remoteData []models.WorkspaceRemoteData
+	if err := s.db.Where("workspace_id = ? AND source_workspace_id = ?", targetWorkspaceID, sourceWorkspaceID).
+		Find(&remoteData).Error; err != nil {
+		return nil, err
+	}
+	if len(remoteData) == 0 {
+		return nil, nil
+	}
+
+	var resources []models.WorkspaceResource
+	if err := s.db.Where("workspace_id = ? AND is_active = ?", targetWorkspaceID, true).
+		Preload("CurrentVersion").Find(&resources).Error; err != nil {
+		return nil, err
+	}
+	var code strings.Builder
+	for _, r := range resources {
+		if r.CurrentVersion == nil {
+			continue
+		}
+		raw, err := json.Marshal(r.CurrentVersion.TFCode)
+		if err != nil {
+			return nil, err
+		}
+		code.Write(raw)
+		code.WriteByte('\n')
+	}
+
+	names := make(map[string]bool)
+	for _, rd := range remoteData {
+		pattern := regexp.MustCompile(`local\.` + regexp.QuoteMeta(rd.DataName) + `\b(\.[A-Za-z_][A-Za-z0-9_-]*)?`)
+		for _, match := range pattern.FindAllStringSubmatch(code.String(), -1) {
+			if match[1] == "" {
+				return nil, nil
+			}
+			names[strings.TrimPrefix(match[1], ".")] = true
+		}
+	}
+
+	consumed := make([]string, 0, len(names))
+	for name := range names {
+		consumed = append(consumed, name)
+	}
+	sort.Strings(consumed)
+	return consumed, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"iac-platform/internal/models"
@@ -173,6 +174,8 @@ func (s *RunTriggerService) ExecuteTriggersCreateOnly(ctx context.Context, task
 	}
 
 	log.Printf("[RunTrigger] Executing triggers for task %d (workspace %s)", task.ID, task.WorkspaceID)
+	managed := s.stackManagedTargets(task)
+	changes := &sourceOutputChanges{db: s.db, task: task}
 
 	// 首先尝试从 task_trigger_executions 表获取预先创建的执行记录
 	var executions []models.TaskTriggerExecution
@@ -211,8 +214,27 @@ func (s *RunTriggerService) ExecuteTriggersCreateOnly(ctx context.Context, task
 				Status:       models.TriggerStatusPending,
 			}
 
+			if managed[trigger.TargetWorkspaceID] {
+				execution.Status = models.TriggerStatusSkipped
+				execution.ErrorMessage = stackManagedTriggerMessage
+				s.db.Create(execution)
+				log.Printf("[RunTrigger] Trigger %d skipped (target orchestrated by the same stack run)", trigger.ID)
+				continue
+			}
+
+			// 检查触发条件
+			fire, changed, reason := s.evaluateTriggerCondition(&trigger, changes)
+			execution.ChangedOutputs = changed
+			if !fire {
+				execution.Status = models.TriggerStatusSkipped
+				execution.ErrorMessage = reason
+				s.db.Create(execution)
+				log.Printf("[RunTrigger] Trigger %d skipped (%s)", trigger.ID, reason)
+				continue
+			}
+
 			// 创建目标 workspace 的任务
-			targetTask, err := s.createTriggeredTask(trigger.TargetWorkspaceID, task)
+			targetTask, err := s.createTriggeredTask(trigger.TargetWorkspaceID, task, changed)
 			if err != nil {
 				execution.Status = models.TriggerStatusFailed
 				execution.ErrorMessage = err.Error()
@@ -255,8 +277,27 @@ func (s *RunTriggerService) ExecuteTriggersCreateOnly(ctx context.Context, task
 			continue
 		}
 
+		if managed[execution.RunTrigger.TargetWorkspaceID] {
+			execution.Status = models.TriggerStatusSkipped
+			execution.ErrorMessage = stackManagedTriggerMessage
+			s.db.Save(&execution)
+			log.Printf("[RunTrigger] Trigger execution %d skipped (target orchestrated by the same stack run)", execution.ID)
+			continue
+		}
+
+		// 检查触发条件
+		fire, changed, reason := s.evaluateTriggerCondition(execution.RunTrigger, changes)
+		execution.ChangedOutputs = changed
+		if !fire {
+			execution.Status = models.TriggerStatusSkipped
+			execution.ErrorMessage = reason
+			s.db.Save(&execution)
+			log.Printf("[RunTrigger] Trigger execution %d skipped (%s)", execution.ID, reason)
+			continue
+		}
+
 		// 创建目标 workspace 的任务
-		targetTask, err := s.createTriggeredTask(execution.RunTrigger.TargetWorkspaceID, task)
+		targetTask, err := s.createTriggeredTask(execution.RunTrigger.TargetWorkspaceID, task, changed)
 		if err != nil {
 			execution.Status = models.TriggerStatusFailed
 			execution.ErrorMessage = err.Error()
@@ -289,6 +330,8 @@ func (s *RunTriggerService) ExecuteTriggers(ctx context.Context, task *models.Wo
 	}
 
 	log.Printf("[RunTrigger] Executing triggers for task %d (workspace %s)", task.ID, task.WorkspaceID)
+	managed := s.stackManagedTargets(task)
+	changes := &sourceOutputChanges{db: s.db, task: task}
 
 	// 首先尝试从 task_trigger_executions 表获取预先创建的执行记录
 	var executions []models.TaskTriggerExecution
@@ -327,8 +370,27 @@ func (s *RunTriggerService) ExecuteTriggers(ctx context.Context, task *models.Wo
 				Status:       models.TriggerStatusPending,
 			}
 
+			if managed[trigger.TargetWorkspaceID] {
+				execution.Status = models.TriggerStatusSkipped
+				execution.ErrorMessage = stackManagedTriggerMessage
+				s.db.Create(execution)
+				log.Printf("[RunTrigger] Trigger %d skipped (target orchestrated by the same stack run)", trigger.ID)
+				continue
+			}
+
+			// 检查触发条件
+			fire, changed, reason := s.evaluateTriggerCondition(&trigger, changes)
+			execution.ChangedOutputs = changed
+			if !fire {
+				execution.Status = models.TriggerStatusSkipped
+				execution.ErrorMessage = reason
+				s.db.Create(execution)
+				log.Printf("[RunTrigger] Trigger %d skipped (%s)", trigger.ID, reason)
+				continue
+			}
+
 			// 创建目标 workspace 的任务
-			targetTask, err := s.createTriggeredTask(trigger.TargetWorkspaceID, task)
+			targetTask, err := s.createTriggeredTask(trigger.TargetWorkspaceID, task, changed)
 			if err != nil {
 				execution.Status = models.TriggerStatusFailed
 				execution.ErrorMessage = err.Error()
@@ -377,8 +439,27 @@ func (s *RunTriggerService) ExecuteTriggers(ctx context.Context, task *models.Wo
 			continue
 		}
 
+		if managed[execution.RunTrigger.TargetWorkspaceID] {
+			execution.Status = models.TriggerStatusSkipped
+			execution.ErrorMessage = stackManagedTriggerMessage
+			s.db.Save(&execution)
+			log.Printf("[RunTrigger] Trigger execution %d skipped (target orchestrated by the same stack run)", execution.ID)
+			continue
+		}
+
+		// 检查触发条件
+		fire, changed, reason := s.evaluateTriggerCondition(execution.RunTrigger, changes)
+		execution.ChangedOutputs = changed
+		if !fire {
+			execution.Status = models.TriggerStatusSkipped
+			execution.ErrorMessage = reason
+			s.db.Save(&execution)
+			log.Printf("[RunTrigger] Trigger execution %d skipped (%s)", execution.ID, reason)
+			continue
+		}
+
 		// 创建目标 workspace 的任务
-		targetTask, err := s.createTriggeredTask(execution.RunTrigger.TargetWorkspaceID, task)
+		targetTask, err := s.createTriggeredTask(execution.RunTrigger.TargetWorkspaceID, task, changed)
 		if err != nil {
 			execution.Status = models.TriggerStatusFailed
 			execution.ErrorMessage = err.Error()
@@ -407,8 +488,22 @@ func (s *RunTriggerService) ExecuteTriggers(ctx context.Context, task *models.Wo
 	return nil
 }
 
+// stackManagedTriggerMessage 目标与源属于同一次 Stack 执行时跳过触发的原因
+const stackManagedTriggerMessage = "target workspace is orchestrated by the same stack run"
+
+// stackManagedTargets 返回与源任务同属一次 Stack 执行的 workspace，Stack 会按依赖顺序 apply 它们，不再单独触发
+func (s *RunTriggerService) stackManagedTargets(task *models.WorkspaceTask) map[string]bool {
+	peers, err := NewStackService(s.db).RunPeers(task.ID)
+	if err != nil {
+		log.Printf("[RunTrigger] Failed to check stack run membership of task %d: %v", task.ID, err)
+		return nil
+	}
+	return peers
+}
+
 // createTriggeredTask 创建被触发的任务
-func (s *RunTriggerService) createTriggeredTask(targetWorkspaceID string, sourceTask *models.WorkspaceTask) (*models.WorkspaceTask, error) {
+// changedOutputs 为触发条件关注且发生变化的上游 output，写入任务描述便于在 plan 中查看触发原因
+func (s *RunTriggerService) createTriggeredTask(targetWorkspaceID string, sourceTask *models.WorkspaceTask, changedOutputs []string) (*models.WorkspaceTask, error) {
 	// 获取目标 workspace
 	var workspace models.Workspace
 	if err := s.db.Where("workspace_id = ?", targetWorkspaceID).First(&workspace).Error; err != nil {
@@ -427,6 +522,9 @@ func (s *RunTriggerService) createTriggeredTask(targetWorkspaceID string, source
 
 	// 创建任务描述
 	description := fmt.Sprintf("Triggered by workspace %s (task #%d)", sourceTask.WorkspaceID, sourceTask.ID)
+	if len(changedOutputs) > 0 {
+		description += fmt.Sprintf("; changed outputs: %s", strings.Join(changedOutputs, ", "))
+	}
 
 	// 创建 plan_and_apply 任务
 	task := &models.WorkspaceTask{
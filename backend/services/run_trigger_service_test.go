@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"iac-platform/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func setupRunTriggerTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := setupTestDB(t)
+	statements := []string{
+		`CREATE TABLE run_triggers (
+			id INTEGER PRIMARY KEY AUTOINCREMENT, source_workspace_id TEXT NOT NULL, target_workspace_id TEXT NOT NULL,
+			enabled INTEGER DEFAULT 1, trigger_condition TEXT DEFAULT 'apply_success', output_names BLOB,
+			created_at DATETIME, updated_at DATETIME, created_by TEXT)`,
+		`CREATE TABLE task_trigger_executions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT, source_task_id INTEGER NOT NULL, run_trigger_id INTEGER NOT NULL,
+			target_task_id INTEGER, status TEXT DEFAULT 'pending', temporarily_disabled INTEGER DEFAULT 0,
+			disabled_by TEXT, disabled_at DATETIME, error_message TEXT, changed_outputs BLOB,
+			created_at DATETIME, updated_at DATETIME)`,
+		`CREATE TABLE workspace_state_versions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT, workspace_id TEXT NOT NULL, created_by TEXT, created_at DATETIME,
+			content BLOB NOT NULL, version INTEGER NOT NULL, checksum TEXT NOT NULL DEFAULT '', size_bytes INTEGER,
+			lineage TEXT, serial INTEGER, is_imported INTEGER DEFAULT 0, import_source TEXT, is_rollback INTEGER DEFAULT 0,
+			rollback_from_version INTEGER, description TEXT, task_id INTEGER, resource_count INTEGER DEFAULT 0)`,
+		`CREATE TABLE workspace_remote_data (
+			id INTEGER PRIMARY KEY AUTOINCREMENT, workspace_id TEXT NOT NULL, remote_data_id TEXT,
+			source_workspace_id TEXT NOT NULL, data_name TEXT NOT NULL, description TEXT,
+			created_by TEXT, created_at DATETIME, updated_at DATETIME)`,
+		`CREATE TABLE workspace_resources (
+			id INTEGER PRIMARY KEY AUTOINCREMENT, workspace_id TEXT NOT NULL, resource_id TEXT NOT NULL,
+			resource_type TEXT NOT NULL, resource_name TEXT NOT NULL, current_version_id INTEGER, is_active INTEGER DEFAULT 1,
+			description TEXT, created_by TEXT, created_at DATETIME, updated_at DATETIME, last_applied_at DATETIME,
+			manifest_deployment_id TEXT)`,
+		`CREATE TABLE resource_code_versions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT, resource_id INTEGER NOT NULL, version INTEGER NOT NULL,
+			is_latest INTEGER DEFAULT 0, tf_code BLOB NOT NULL, variables BLOB, change_summary TEXT, change_type TEXT,
+			diff_from_previous TEXT, state_version_id INTEGER, task_id INTEGER, created_by TEXT, created_at DATETIME)`,
+		// 目标 workspace 的 app 资源通过 Remote Data "net" 读取 subnet_id
+		`INSERT INTO workspace_remote_data (workspace_id, remote_data_id, source_workspace_id, data_name) VALUES ('ws-app', 'rd-1', 'ws-net', 'net')`,
+		`INSERT INTO workspace_resources (workspace_id, resource_id, resource_type, resource_name, current_version_id, is_active)
+			VALUES ('ws-app', 'aws_instance.app', 'aws_instance', 'app', 1, 1)`,
+	}
+	for _, stmt := range statements {
+		require.NoError(t, db.Exec(stmt).Error)
+	}
+
+	for _, id := range []string{"ws-net", "ws-all", "ws-vpc", "ws-app", "ws-any"} {
+		require.NoError(t, db.Exec(`INSERT INTO workspaces (workspace_id, name, execution_mode, provider_config) VALUES (?, ?, 'local', ?)`,
+			id, id, []byte(`{"aws":[{"region":"us-east-1"}]}`)).Error)
+	}
+	tfCode, err := json.Marshal(map[string]interface{}{
+		"resource": map[string]interface{}{
+			"aws_instance": map[string]interface{}{
+				"app": map[string]interface{}{"subnet_id": "${local.net.subnet_id}", "tags": map[string]interface{}{"Net": "${local.network}"}},
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.Exec(`INSERT INTO resource_code_versions (resource_id, version, is_latest, tf_code) VALUES (1, 1, 1, ?)`, tfCode).Error)
+	return db
+}
+
+func insertTriggerStateVersion(t *testing.T, db *gorm.DB, version int, taskID uint, outputs map[string]interface{}) {
+	t.Helper()
+	content, err := json.Marshal(map[string]interface{}{"version": 4, "outputs": outputs})
+	require.NoError(t, err)
+	require.NoError(t, db.Exec(`INSERT INTO workspace_state_versions (workspace_id, content, version, task_id) VALUES ('ws-net', ?, ?, ?)`,
+		content, version, taskID).Error)
+}
+
+func stateOutput(value interface{}) map[string]interface{} {
+	return map[string]interface{}{"value": value, "type": "string"}
+}
+
+func createAppliedNetTask(t *testing.T, db *gorm.DB) *models.WorkspaceTask {
+	t.Helper()
+	task := &models.WorkspaceTask{
+		WorkspaceID:   "ws-net",
+		TaskType:      models.TaskTypePlanAndApply,
+		Status:        models.TaskStatusApplied,
+		ExecutionMode: models.ExecutionModeLocal,
+	}
+	require.NoError(t, db.Create(task).Error)
+	return task
+}
+
+func TestNormalizeTriggerCondition(t *testing.T) {
+	condition, names, err := NormalizeTriggerCondition("", []string{"vpc_id"})
+	require.NoError(t, err)
+	assert.Equal(t, models.TriggerConditionApplySuccess, condition)
+	assert.Nil(t, names)
+
+	condition, names, err = NormalizeTriggerCondition(models.TriggerConditionOutputsChanged, []string{" vpc_id", "subnet_ids", "vpc_id", ""})
+	require.NoError(t, err)
+	assert.Equal(t, models.TriggerConditionOutputsChanged, condition)
+	assert.Equal(t, models.StringArray{"subnet_ids", "vpc_id"}, names)
+
+	_, _, err = NormalizeTriggerCondition("plan_success", nil)
+	assert.ErrorIs(t, err, ErrInvalidTriggerCondition)
+	_, _, err = NormalizeTriggerCondition(models.TriggerConditionOutputsChanged, []string{"bad name"})
+	assert.ErrorIs(t, err, ErrInvalidTriggerCondition)
+}
+
+func TestRunTriggerService_OutputsChangedCondition(t *testing.T) {
+	db := setupRunTriggerTestDB(t)
+	svc := NewRunTriggerService(db)
+
+	triggers := []models.RunTrigger{
+		{SourceWorkspaceID: "ws-net", TargetWorkspaceID: "ws-all", Enabled: true, TriggerCondition: models.TriggerConditionApplySuccess},
+		{SourceWorkspaceID: "ws-net", TargetWorkspaceID: "ws-vpc", Enabled: true, TriggerCondition: models.TriggerConditionOutputsChanged, OutputNames: models.StringArray{"vpc_id"}},
+		// 未指定 output：ws-app 通过 Remote Data 只读取 subnet_id；ws-any 未配置 Remote Data，任何 output 变化都触发
+		{SourceWorkspaceID: "ws-net", TargetWorkspaceID: "ws-app", Enabled: true, TriggerCondition: models.TriggerConditionOutputsChanged},
+		{SourceWorkspaceID: "ws-net", TargetWorkspaceID: "ws-any", Enabled: true, TriggerCondition: models.TriggerConditionOutputsChanged},
+	}
+	for i := range triggers {
+		require.NoError(t, db.Create(&triggers[i]).Error)
+	}
+
+	task := createAppliedNetTask(t, db)
+	insertTriggerStateVersion(t, db, 1, 0, map[string]interface{}{
+		"vpc_id": stateOutput("vpc-1"), "subnet_id": stateOutput("subnet-1"), "legacy": stateOutput("x"),
+	})
+	insertTriggerStateVersion(t, db, 2, task.ID, map[string]interface{}{
+		"vpc_id": stateOutput("vpc-1"), "subnet_id": stateOutput("subnet-2"),
+	})
+
+	require.NoError(t, svc.ExecuteTriggersCreateOnly(context.Background(), task))
+
+	executions, err := svc.GetTaskTriggerExecutions(task.ID)
+	require.NoError(t, err)
+	byTarget := make(map[string]models.TaskTriggerExecution)
+	for _, e := range executions {
+		byTarget[e.RunTrigger.TargetWorkspaceID] = e
+	}
+	require.Len(t, byTarget, 4)
+
+	all := byTarget["ws-all"]
+	assert.Equal(t, models.TriggerStatusTriggered, all.Status)
+	assert.Empty(t, all.ChangedOutputs)
+	require.NotNil(t, all.TargetTask)
+	assert.Equal(t, fmt.Sprintf("Triggered by workspace ws-net (task #%d)", task.ID), all.TargetTask.Description)
+
+	vpc := byTarget["ws-vpc"]
+	assert.Equal(t, models.TriggerStatusSkipped, vpc.Status)
+	assert.Nil(t, vpc.TargetTaskID)
+	assert.Equal(t, "trigger condition not met: none of the watched outputs changed (vpc_id)", vpc.ErrorMessage)
+
+	app := byTarget["ws-app"]
+	assert.Equal(t, models.TriggerStatusTriggered, app.Status)
+	assert.Equal(t, models.StringArray{"subnet_id"}, app.ChangedOutputs)
+	require.NotNil(t, app.TargetTask)
+	assert.Contains(t, app.TargetTask.Description, "; changed outputs: subnet_id")
+
+	anyOutput := byTarget["ws-any"]
+	assert.Equal(t, models.TriggerStatusTriggered, anyOutput.Status)
+	assert.Equal(t, models.StringArray{"legacy", "subnet_id"}, anyOutput.ChangedOutputs)
+
+	// 再次 apply 但 output 未变化（且未产生新 State 版本）：只有 apply_success 触发
+	second := createAppliedNetTask(t, db)
+	require.NoError(t, svc.ExecuteTriggersCreateOnly(context.Background(), second))
+	executions, err = svc.GetTaskTriggerExecutions(second.ID)
+	require.NoError(t, err)
+	require.Len(t, executions, 4)
+	for _, e := range executions {
+		if e.RunTrigger.TargetWorkspaceID == "ws-all" {
+			assert.Equal(t, models.TriggerStatusTriggered, e.Status)
+			continue
+		}
+		assert.Equal(t, models.TriggerStatusSkipped, e.Status, e.RunTrigger.TargetWorkspaceID)
+		assert.Contains(t, e.ErrorMessage, "recorded no new state version")
+	}
+}
+
+func TestRunTriggerService_ConsumedOutputs(t *testing.T) {
+	db := setupRunTriggerTestDB(t)
+	svc := NewRunTriggerService(db)
+
+	consumed, err := svc.consumedOutputs("ws-app", "ws-net")
+	require.NoError(t, err)
+	// local.network 与 local.net 不同名，不影响结果
+	assert.Equal(t, []string{"subnet_id"}, consumed)
+
+	consumed, err = svc.consumedOutputs("ws-any", "ws-net")
+	require.NoError(t, err)
+	assert.Nil(t, consumed, "no remote data: watch every output")
+
+	// 整体引用无法确定具体 output，关注全部
+	tfCode, err := json.Marshal(map[string]interface{}{"locals": map[string]interface{}{"all_net": "${local.net}"}})
+	require.NoError(t, err)
+	require.NoError(t, db.Exec(`UPDATE resource_code_versions SET tf_code = ? WHERE id = 1`, tfCode).Error)
+	consumed, err = svc.consumedOutputs("ws-app", "ws-net")
+	require.NoError(t, err)
+	assert.Nil(t, consumed)
+}
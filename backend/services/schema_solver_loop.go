@@ -19,17 +19,21 @@ type AIFeedbackLoop struct {
 	aiService     *AIFormService
 	configService *AIConfigService
 	maxRetries    int
+	// callAI 调用模型，默认使用 aiService；评估时替换为录制/回放的调用
+	callAI func(cfg *models.AIConfig, prompt string) (string, error)
 }
 
 // NewAIFeedbackLoop 创建 AI 反馈循环
 func NewAIFeedbackLoop(db *gorm.DB, moduleID uint) *AIFeedbackLoop {
-	return &AIFeedbackLoop{
+	loop := &AIFeedbackLoop{
 		db:            db,
 		solver:        NewSchemaSolver(db, moduleID),
 		aiService:     NewAIFormService(db),
 		configService: NewAIConfigService(db),
 		maxRetries:    3, // 最多重试 3 次
 	}
+	loop.callAI = loop.aiService.callAI
+	return loop
 }
 
 // IterationResult 迭代结果
@@ -106,7 +110,7 @@ func (loop *AIFeedbackLoop) ExecuteWithRetry(
 
 		// 调用 AI 重新生成参数
 		log.Printf("[AIFeedbackLoop] 迭代 %d: 调用 AI 修正参数", i+1)
-		aiResponse, err := loop.aiService.callAI(aiConfig, prompt)
+		aiResponse, err := loop.callAI(aiConfig, prompt)
 		if err != nil {
 			log.Printf("[AIFeedbackLoop] AI 调用失败: %v", err)
 			return &FeedbackLoopResult{
@@ -313,6 +317,13 @@ func (loop *AIFeedbackLoop) SetMaxRetries(maxRetries int) {
 	}
 }
 
+// SetAICaller 替换调用模型的函数
+func (loop *AIFeedbackLoop) SetAICaller(callAI func(cfg *models.AIConfig, prompt string) (string, error)) {
+	if callAI != nil {
+		loop.callAI = callAI
+	}
+}
+
 // GetSolver 获取 SchemaSolver
 func (loop *AIFeedbackLoop) GetSolver() *SchemaSolver {
 	return loop.solver
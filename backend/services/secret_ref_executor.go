@@ -0,0 +1,180 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"iac-platform/internal/models"
+	"iac-platform/internal/secretref"
+)
+
+// secretRefResolver 返回执行者侧的密文引用解析器。
+// 引用只在执行者（Agent 或本地执行器）上按其自身环境解析，解析值不回传平台。
+func (s *TerraformExecutor) secretRefResolver() *secretref.Resolver {
+	if s.secretResolver != nil {
+		return s.secretResolver
+	}
+	return secretref.NewResolverFromEnv()
+}
+
+// resolveVariableValue 返回变量用于执行的值，密文引用在此时解析
+func (s *TerraformExecutor) resolveVariableValue(v models.WorkspaceVariable) (string, error) {
+	if !v.IsSecretRef() {
+		return v.Value, nil
+	}
+	value, err := s.secretRefResolver().Resolve(context.Background(), v.Value)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve secret reference for variable %s: %w", v.Key, err)
+	}
+	return value, nil
+}
+
+// resolveProviderConfig 解析 Provider 配置（含模板字段）中的密文引用，原配置保持引用不变
+func (s *TerraformExecutor) resolveProviderConfig(providerConfig map[string]interface{}) (map[string]interface{}, error) {
+	resolved, _, err := s.secretRefResolver().ResolveTree(context.Background(), providerConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve secret reference in provider config: %w", err)
+	}
+	config, _ := resolved.(map[string]interface{})
+	return config, nil
+}
+
+// verifyEnvironmentSecretRefs 在执行前确认环境变量中的密文引用都能解析，
+// 避免 buildEnvironmentVariables 静默跳过后 terraform 以缺失凭据运行
+func (s *TerraformExecutor) verifyEnvironmentSecretRefs(workspaceID string) error {
+	envVars, err := s.dataAccessor.GetWorkspaceVariables(workspaceID, models.VariableTypeEnvironment)
+	if err != nil {
+		return nil
+	}
+	for _, v := range envVars {
+		if _, err := s.resolveVariableValue(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// redactSecretRefPlanJSON 去除 plan JSON 中密文引用的解析值，plan JSON 会上传并保存在平台。
+// 除 variables 外，解析值还会出现在 resource_changes、planned_values、prior_state
+// 以及 configuration 的常量表达式中，因此对整棵结构按明文替换；无法取得解析值时返回错误
+func (s *TerraformExecutor) redactSecretRefPlanJSON(planJSON map[string]interface{}, workspace *models.Workspace) error {
+	variables, err := s.dataAccessor.GetWorkspaceVariables(workspace.WorkspaceID, models.VariableTypeTerraform)
+	if err != nil {
+		return fmt.Errorf("failed to get variables for plan redaction: %w", err)
+	}
+
+	var secrets []string
+	planVars, _ := planJSON["variables"].(map[string]interface{})
+	for _, v := range variables {
+		if !v.IsSecretRef() {
+			continue
+		}
+		if entry, ok := planVars[v.Key].(map[string]interface{}); ok {
+			entry["value"] = secretref.MaskedValue
+		}
+		value, err := s.resolveVariableValue(v)
+		if err != nil {
+			return err
+		}
+		secrets = append(secrets, value)
+	}
+
+	if containsSecretRef(map[string]interface{}(workspace.ProviderConfig)) {
+		_, providerSecrets, err := s.secretRefResolver().ResolveTree(context.Background(), map[string]interface{}(workspace.ProviderConfig))
+		if err != nil {
+			return fmt.Errorf("failed to resolve secret reference in provider config: %w", err)
+		}
+		secrets = append(secrets, providerSecrets...)
+	}
+
+	if len(secrets) == 0 {
+		return nil
+	}
+	// 属性值可能是 JSON 编码后的字符串（如 policy），转义形式同样替换
+	for _, secret := range secrets {
+		if encoded, err := json.Marshal(secret); err == nil {
+			if escaped := string(encoded[1 : len(encoded)-1]); escaped != secret {
+				secrets = append(secrets, escaped)
+			}
+		}
+	}
+	maskSecretValues(planJSON, secrets)
+	return nil
+}
+
+// maskSecretValues 原地替换结构中包含明文的字符串
+func maskSecretValues(value interface{}, secrets []string) {
+	switch t := value.(type) {
+	case map[string]interface{}:
+		for k, item := range t {
+			if str, ok := item.(string); ok {
+				t[k] = secretref.Mask(str, secrets)
+				continue
+			}
+			maskSecretValues(item, secrets)
+		}
+	case []interface{}:
+		for i, item := range t {
+			if str, ok := item.(string); ok {
+				t[i] = secretref.Mask(str, secrets)
+				continue
+			}
+			maskSecretValues(item, secrets)
+		}
+	}
+}
+
+// quoteTFVarsString 将解析出的密文写成 tfvars 字符串字面量（转义引号、反斜杠和模板序列）
+func quoteTFVarsString(value string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		"\n", `\n`,
+		"\r", `\r`,
+		"\t", `\t`,
+		"${", "$${",
+		"%{", "%%{",
+	)
+	return `"` + replacer.Replace(value) + `"`
+}
+
+// planContainsResolvedSecrets 判断 plan 文件是否会包含密文引用的解析值。
+// 二进制 plan 中保存了变量值和 Provider 配置，这类 plan 只保留在执行者上，不上传平台；
+// 无法读取变量时按包含处理
+func (s *TerraformExecutor) planContainsResolvedSecrets(workspace *models.Workspace) bool {
+	variables, err := s.dataAccessor.GetWorkspaceVariables(workspace.WorkspaceID, models.VariableTypeTerraform)
+	if err != nil {
+		log.Printf("Warning: failed to get variables for plan upload check: %v", err)
+		return true
+	}
+	for _, v := range variables {
+		if v.IsSecretRef() {
+			return true
+		}
+	}
+	return containsSecretRef(map[string]interface{}(workspace.ProviderConfig))
+}
+
+// containsSecretRef 递归检查结构中是否有引用形式的字符串
+func containsSecretRef(value interface{}) bool {
+	switch t := value.(type) {
+	case string:
+		return secretref.IsReference(t)
+	case map[string]interface{}:
+		for _, item := range t {
+			if containsSecretRef(item) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, item := range t {
+			if containsSecretRef(item) {
+				return true
+			}
+		}
+	}
+	return false
+}
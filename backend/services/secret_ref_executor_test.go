@@ -0,0 +1,298 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"iac-platform/internal/models"
+	"iac-platform/internal/secretref"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// secretRefTestAccessor 只实现变量读取，其余方法不会被调用
+type secretRefTestAccessor struct {
+	DataAccessor
+	vars map[models.VariableType][]models.WorkspaceVariable
+}
+
+func (a *secretRefTestAccessor) GetWorkspaceVariables(workspaceID string, varType models.VariableType) ([]models.WorkspaceVariable, error) {
+	return a.vars[varType], nil
+}
+
+func newSecretRefTestExecutor(t *testing.T, vars map[models.VariableType][]models.WorkspaceVariable, env map[string]string) *TerraformExecutor {
+	t.Helper()
+	// Vault KV v2 兼容的本地替身
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "test-token" || r.URL.Path != "/v1/kv/data/team/db" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"data":     map[string]interface{}{"password": `p@ss"w${x}`},
+				"metadata": map[string]interface{}{"version": 1},
+			},
+		})
+	}))
+	t.Cleanup(vault.Close)
+
+	return &TerraformExecutor{
+		dataAccessor:  &secretRefTestAccessor{vars: vars},
+		signalManager: GetSignalManager(),
+		secretResolver: &secretref.Resolver{
+			VaultAddr:        vault.URL,
+			VaultToken:       "test-token",
+			AllowedEnvPrefix: secretref.DefaultAllowedEnvPrefix,
+			LookupEnv: func(k string) (string, bool) {
+				v, ok := env[k]
+				return v, ok
+			},
+		},
+	}
+}
+
+func TestSecretRefs_ResolvedAtExecution(t *testing.T) {
+	vars := map[models.VariableType][]models.WorkspaceVariable{
+		models.VariableTypeTerraform: {
+			{Key: "db_password", Value: "vault://kv/data/team/db#password", ValueFormat: models.ValueFormatSecretRef, VariableType: models.VariableTypeTerraform},
+			{Key: "region", Value: "us-east-1", ValueFormat: models.ValueFormatString, VariableType: models.VariableTypeTerraform},
+		},
+		models.VariableTypeEnvironment: {
+			{Key: "AWS_SECRET_ACCESS_KEY", Value: "env://IAC_SECRET_AWS", ValueFormat: models.ValueFormatSecretRef, VariableType: models.VariableTypeEnvironment},
+		},
+	}
+	s := newSecretRefTestExecutor(t, vars, map[string]string{"IAC_SECRET_AWS": "aws-secret"})
+	workspace := &models.Workspace{WorkspaceID: "ws-secretref"}
+	workDir := t.TempDir()
+
+	require.NoError(t, s.verifyEnvironmentSecretRefs(workspace.WorkspaceID))
+
+	// tfvars 中写入解析值，且按 HCL 字符串转义
+	require.NoError(t, s.generateVariablesTFVars(workspace, workDir))
+	tfvars, err := os.ReadFile(filepath.Join(workDir, "variables.tfvars"))
+	require.NoError(t, err)
+	assert.Contains(t, string(tfvars), `db_password = "p@ss\"w$${x}"`)
+	assert.Contains(t, string(tfvars), `region = "us-east-1"`)
+
+	// 引用变量在 variables.tf.json 中声明为 sensitive
+	require.NoError(t, s.generateVariablesTFJSON(workspace, workDir))
+	varsJSON, err := os.ReadFile(filepath.Join(workDir, "variables.tf.json"))
+	require.NoError(t, err)
+	var decl map[string]map[string]map[string]interface{}
+	require.NoError(t, json.Unmarshal(varsJSON, &decl))
+	assert.Equal(t, true, decl["variable"]["db_password"]["sensitive"])
+
+	// 日志脱敏
+	masked := s.maskSensitiveVariables(string(tfvars), workspace.WorkspaceID)
+	assert.NotContains(t, masked, "p@ss")
+	assert.Contains(t, masked, `db_password = "***SENSITIVE***"`)
+
+	// 环境变量
+	env := s.buildEnvironmentVariables(workspace)
+	assert.Contains(t, env, "AWS_SECRET_ACCESS_KEY=aws-secret")
+
+	// Provider 配置（含模板字段）中的引用
+	providerConfig := map[string]interface{}{
+		"provider": map[string]interface{}{
+			"aws": []interface{}{map[string]interface{}{"region": "us-east-1", "secret_key": "env://IAC_SECRET_AWS"}},
+		},
+	}
+	resolved, err := s.resolveProviderConfig(providerConfig)
+	require.NoError(t, err)
+	aws := resolved["provider"].(map[string]interface{})["aws"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "aws-secret", aws["secret_key"])
+
+	// plan JSON 上传前去除解析值
+	planJSON := map[string]interface{}{
+		"variables": map[string]interface{}{
+			"db_password": map[string]interface{}{"value": `p@ss"w${x}`},
+			"region":      map[string]interface{}{"value": "us-east-1"},
+		},
+	}
+	require.NoError(t, s.redactSecretRefPlanJSON(planJSON, workspace))
+	planVars := planJSON["variables"].(map[string]interface{})
+	assert.Equal(t, secretref.MaskedValue, planVars["db_password"].(map[string]interface{})["value"])
+	assert.Equal(t, "us-east-1", planVars["region"].(map[string]interface{})["value"])
+}
+
+func TestSecretRefs_PlanJSONMasksResolvedValuesInResources(t *testing.T) {
+	vars := map[models.VariableType][]models.WorkspaceVariable{
+		models.VariableTypeTerraform: {
+			{Key: "db_password", Value: "vault://kv/data/team/db#password", ValueFormat: models.ValueFormatSecretRef, VariableType: models.VariableTypeTerraform},
+		},
+	}
+	s := newSecretRefTestExecutor(t, vars, map[string]string{"IAC_SECRET_AWS": "aws-secret"})
+	workspace := &models.Workspace{
+		WorkspaceID: "ws-secretref",
+		ProviderConfig: models.JSONB{
+			"provider": map[string]interface{}{"aws": []interface{}{map[string]interface{}{"secret_key": "env://IAC_SECRET_AWS"}}},
+		},
+	}
+	secret := `p@ss"w${x}`
+
+	// 变量被用作资源属性，解析值会出现在 plan JSON 的各个部分
+	planJSON := map[string]interface{}{
+		"variables": map[string]interface{}{
+			"db_password": map[string]interface{}{"value": secret},
+		},
+		"resource_changes": []interface{}{
+			map[string]interface{}{
+				"address": "aws_db_instance.main",
+				"type":    "aws_db_instance",
+				"name":    "main",
+				"change": map[string]interface{}{
+					"actions": []interface{}{"update"},
+					"before":  map[string]interface{}{"password": "old", "identifier": "main"},
+					"after": map[string]interface{}{
+						"password":   secret,
+						"identifier": "main",
+						"policy":     `{"Condition":{"StringEquals":{"k":"p@ss\"w${x}"}}}`,
+					},
+				},
+			},
+		},
+		"planned_values": map[string]interface{}{
+			"root_module": map[string]interface{}{
+				"resources": []interface{}{
+					map[string]interface{}{"values": map[string]interface{}{"password": secret}},
+				},
+			},
+		},
+		"prior_state": map[string]interface{}{
+			"values": map[string]interface{}{"outputs": map[string]interface{}{"dsn": map[string]interface{}{"value": "postgres://admin:" + secret + "@db"}}},
+		},
+		"configuration": map[string]interface{}{
+			"provider_config": map[string]interface{}{
+				"aws": map[string]interface{}{
+					"expressions": map[string]interface{}{
+						"secret_key": map[string]interface{}{"constant_value": "aws-secret"},
+					},
+				},
+			},
+		},
+	}
+
+	require.NoError(t, s.redactSecretRefPlanJSON(planJSON, workspace))
+
+	data, err := json.Marshal(planJSON)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "p@ss")
+	assert.NotContains(t, string(data), "aws-secret")
+
+	// 保存到 workspace_task_resource_changes 的内容来自同一份 plan JSON
+	changes := s.parseResourceChangesFromPlanJSON(planJSON)
+	require.Len(t, changes, 1)
+	changeData, err := json.Marshal(changes)
+	require.NoError(t, err)
+	assert.NotContains(t, string(changeData), "p@ss")
+	assert.Contains(t, string(changeData), secretref.MaskedValue)
+	assert.Contains(t, string(changeData), `"identifier":"main"`)
+}
+
+func TestSecretRefs_UnresolvableFailsBeforeExecution(t *testing.T) {
+	vars := map[models.VariableType][]models.WorkspaceVariable{
+		models.VariableTypeTerraform: {
+			{Key: "db_password", Value: "vault://kv/data/team/missing#password", ValueFormat: models.ValueFormatSecretRef},
+		},
+		models.VariableTypeEnvironment: {
+			{Key: "TOKEN", Value: "env://IAC_SECRET_MISSING", ValueFormat: models.ValueFormatSecretRef},
+		},
+	}
+	s := newSecretRefTestExecutor(t, vars, nil)
+	workspace := &models.Workspace{WorkspaceID: "ws-secretref"}
+
+	err := s.verifyEnvironmentSecretRefs(workspace.WorkspaceID)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "TOKEN")
+
+	err = s.generateVariablesTFVars(workspace, t.TempDir())
+	require.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), "db_password"))
+
+	// 无法解析的环境变量不会以引用原文注入
+	for _, kv := range s.buildEnvironmentVariables(workspace) {
+		assert.False(t, strings.HasPrefix(kv, "TOKEN="))
+	}
+}
+
+func TestSecretRefs_PlanFileNotSavedToPlatform(t *testing.T) {
+	vars := map[models.VariableType][]models.WorkspaceVariable{
+		models.VariableTypeTerraform: {
+			{Key: "db_password", Value: "vault://kv/data/team/db#password", ValueFormat: models.ValueFormatSecretRef, VariableType: models.VariableTypeTerraform},
+		},
+	}
+	s := newSecretRefTestExecutor(t, vars, nil)
+	s.db = setupTestDB(t)
+	workspace := &models.Workspace{WorkspaceID: "ws-secretref"}
+	workDir := t.TempDir()
+
+	// 二进制 plan 中包含 tfvars 的解析值
+	require.NoError(t, s.generateVariablesTFVars(workspace, workDir))
+	tfvars, err := os.ReadFile(filepath.Join(workDir, "variables.tfvars"))
+	require.NoError(t, err)
+	planFile := filepath.Join(workDir, "plan.out")
+	require.NoError(t, os.WriteFile(planFile, append([]byte("tfplan\x00"), tfvars...), 0644))
+
+	task := &models.WorkspaceTask{WorkspaceID: workspace.WorkspaceID, TaskType: models.TaskTypePlanAndApply}
+	require.NoError(t, s.db.Exec(`INSERT INTO workspace_tasks (workspace_id, task_type, plan_data) VALUES (?, ?, ?)`,
+		task.WorkspaceID, task.TaskType, []byte("stale")).Error)
+	require.NoError(t, s.db.Raw(`SELECT last_insert_rowid()`).Scan(&task.ID).Error)
+
+	s.SavePlanDataWithLogging(task, workspace, planFile, nil, NewTerraformLogger(nil))
+
+	var saved models.WorkspaceTask
+	require.NoError(t, s.db.Select("id", "plan_data").First(&saved, task.ID).Error)
+	assert.Empty(t, saved.PlanData)
+	assert.Empty(t, task.PlanData)
+	assert.NotContains(t, string(saved.PlanData), "p@ss")
+
+	// 没有密文引用时 plan 文件照常保存
+	plain := newSecretRefTestExecutor(t, map[models.VariableType][]models.WorkspaceVariable{
+		models.VariableTypeTerraform: {{Key: "region", Value: "us-east-1", ValueFormat: models.ValueFormatString}},
+	}, nil)
+	plain.db = s.db
+	plain.SavePlanDataWithLogging(task, workspace, planFile, nil, NewTerraformLogger(nil))
+	require.NoError(t, s.db.Select("id", "plan_data").First(&saved, task.ID).Error)
+	assert.Equal(t, append([]byte("tfplan\x00"), tfvars...), saved.PlanData)
+
+	// Provider 配置中的引用同样会进入 plan 文件
+	workspace.ProviderConfig = models.JSONB{
+		"provider": map[string]interface{}{"aws": []interface{}{map[string]interface{}{"secret_key": "env://IAC_SECRET_AWS"}}},
+	}
+	assert.True(t, plain.planContainsResolvedSecrets(workspace))
+}
+
+func TestSecretRefs_ReplannedChangesMustMatchApprovedPlan(t *testing.T) {
+	s := newSecretRefTestExecutor(t, nil, nil)
+	resourceChange := func(address string, actions ...interface{}) interface{} {
+		return map[string]interface{}{"address": address, "change": map[string]interface{}{"actions": actions}}
+	}
+	approved := map[string]interface{}{"resource_changes": []interface{}{
+		resourceChange("aws_db_instance.main", "update"),
+		resourceChange("aws_vpc.main", "no-op"),
+	}}
+	planTask := &models.WorkspaceTask{ID: 7, ChangesChange: 1, PlanJSON: approved}
+
+	require.NoError(t, s.verifyReplannedChanges(planTask, approved))
+
+	drifted := map[string]interface{}{"resource_changes": []interface{}{
+		resourceChange("aws_db_instance.main", "delete", "create"),
+	}}
+	err := s.verifyReplannedChanges(planTask, drifted)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "plan #7")
+
+	// Agent 模式下只有变更数可比较
+	planTask.PlanJSON = nil
+	require.NoError(t, s.verifyReplannedChanges(planTask, map[string]interface{}{"resource_changes": []interface{}{
+		resourceChange("aws_db_instance.main", "update"),
+	}}))
+	assert.Error(t, s.verifyReplannedChanges(planTask, map[string]interface{}{}))
+}
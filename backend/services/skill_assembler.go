@@ -20,6 +20,7 @@ type SkillAssembler struct {
 	moduleSkillGen   *ModuleSkillGenerator
 	skillCache       map[string]*models.Skill // 简单的内存缓存
 	skillCacheExpiry time.Time
+	contentOverrides map[string]string // Skill 名称 -> 替换的内容，评估候选 Skill 时使用
 }
 
 // NewSkillAssembler 创建 SkillAssembler 实例
@@ -190,7 +191,7 @@ func (a *SkillAssembler) AssemblePrompt(
 	}
 
 	// 6. 按层级和优先级排序
-	sortedSkills := a.sortSkills(allSkills)
+	sortedSkills := a.applyContentOverrides(a.sortSkills(allSkills))
 
 	// 7. 打印组装前每个 Skill 的内容
 	log.Printf("[SkillAssembler] ========== 组装前 Skills 列表 ==========")
@@ -686,6 +687,31 @@ func (a *SkillAssembler) LogSkillUsage(
 	return a.db.Create(log).Error
 }
 
+// SetContentOverrides 组装时用指定内容替换同名 Skill 的内容，不修改数据库和缓存
+// 用于在保存前评估候选的 Skill 内容
+func (a *SkillAssembler) SetContentOverrides(overrides map[string]string) {
+	a.contentOverrides = overrides
+}
+
+// applyContentOverrides 返回替换了内容的 Skill 副本，缓存中的 Skill 不受影响
+func (a *SkillAssembler) applyContentOverrides(skills []*models.Skill) []*models.Skill {
+	if len(a.contentOverrides) == 0 {
+		return skills
+	}
+	result := make([]*models.Skill, len(skills))
+	for i, skill := range skills {
+		content, ok := a.contentOverrides[skill.Name]
+		if !ok {
+			result[i] = skill
+			continue
+		}
+		copied := *skill
+		copied.Content = content
+		result[i] = &copied
+	}
+	return result
+}
+
 // ClearCache 清除缓存
 func (a *SkillAssembler) ClearCache() {
 	a.skillCache = make(map[string]*models.Skill)
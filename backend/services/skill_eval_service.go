@@ -0,0 +1,901 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"iac-platform/internal/config"
+	"iac-platform/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrSkillEvalNoRecording 回放模式下没有与 Prompt 对应的录制响应
+var ErrSkillEvalNoRecording = errors.New("no recorded AI response for this prompt")
+
+// skillEvalScoreEpsilon 比较得分时忽略的浮点误差
+const skillEvalScoreEpsilon = 1e-9
+
+// generateSkillEvalRunID 生成评估运行 ID
+func generateSkillEvalRunID() string {
+	bytes := make([]byte, 8)
+	rand.Read(bytes)
+	return "ser-" + hex.EncodeToString(bytes)
+}
+
+// SkillContentHash 计算 Skill 内容的 SHA256，对比评估和修改 Skill 时用它匹配评估结论
+func SkillContentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// SkillEvalService Skill 评估服务
+// 运行评估套件：组装 Prompt -> 调用模型（或回放录制的响应）-> SchemaSolver 校验及反馈循环 -> 按用例期望打分
+type SkillEvalService struct {
+	db            *gorm.DB
+	configService *AIConfigService
+	// generator 复用配置生成的响应解析与默认 Skill 组合
+	generator *AICMDBSkillService
+	// callAI 调用模型，live 模式使用
+	callAI func(cfg *models.AIConfig, prompt string) (string, error)
+}
+
+// NewSkillEvalService 创建 Skill 评估服务
+func NewSkillEvalService(db *gorm.DB) *SkillEvalService {
+	return &SkillEvalService{
+		db:            db,
+		configService: NewAIConfigService(db),
+		generator:     &AICMDBSkillService{db: db},
+		callAI:        NewAIFormService(db).callAI,
+	}
+}
+
+// SkillEvalSuiteRequest 创建/更新评估套件请求
+type SkillEvalSuiteRequest struct {
+	Name           string                   `json:"name" binding:"required,max=255"`
+	Description    string                   `json:"description"`
+	Composition    *models.SkillComposition `json:"composition"`
+	AIConfigID     *uint                    `json:"ai_config_id"`
+	Mode           models.SkillEvalMode     `json:"mode" binding:"omitempty,oneof=live replay"`
+	GateSkillEdits bool                     `json:"gate_skill_edits"`
+	Tolerance      float64                  `json:"tolerance" binding:"min=0,max=1"`
+}
+
+// SkillEvalCaseRequest 创建/更新评估用例请求
+type SkillEvalCaseRequest struct {
+	Name          string                       `json:"name" binding:"required,max=255"`
+	UserIntent    string                       `json:"user_intent" binding:"required,max=2000"`
+	ModuleID      uint                         `json:"module_id" binding:"required"`
+	CurrentConfig map[string]interface{}       `json:"current_config"`
+	Expectations  models.SkillEvalExpectations `json:"expectations"`
+}
+
+// RunSkillEvalRequest 运行评估套件请求
+type RunSkillEvalRequest struct {
+	Mode models.SkillEvalMode `json:"mode" binding:"omitempty,oneof=live replay"` // 为空使用套件的默认模式
+}
+
+// CompareSkillEvalRequest 对比评估请求：用候选内容替换 Skill 后与 baseline 对比
+type CompareSkillEvalRequest struct {
+	SkillName string               `json:"skill_name" binding:"required"`
+	Content   string               `json:"content" binding:"required"`
+	Mode      models.SkillEvalMode `json:"mode" binding:"omitempty,oneof=live replay"`
+	// BaselineContent 可选，baseline 使用的内容；为空时使用数据库中当前的内容
+	BaselineContent *string `json:"baseline_content"`
+}
+
+// SkillEvalCaseDiff 对比评估中单个用例的差异
+type SkillEvalCaseDiff struct {
+	CaseID          uint    `json:"case_id"`
+	CaseName        string  `json:"case_name"`
+	BaselineStatus  string  `json:"baseline_status"`
+	CandidateStatus string  `json:"candidate_status"`
+	BaselineScore   float64 `json:"baseline_score"`
+	CandidateScore  float64 `json:"candidate_score"`
+	Regressed       bool    `json:"regressed"`
+}
+
+// SkillEvalComparison 两次运行的对比结论
+// 只有两边都得到结果（非 error）的用例参与得分比较；
+// candidate 出现 baseline 没有的 error（如回放模式下 Prompt 已变化）时，结论不确定
+type SkillEvalComparison struct {
+	BaselineRunID  string              `json:"baseline_run_id"`
+	CandidateRunID string              `json:"candidate_run_id"`
+	BaselineScore  float64             `json:"baseline_score"`  // 可比较用例的平均分
+	CandidateScore float64             `json:"candidate_score"` // 可比较用例的平均分
+	ScoreDelta     float64             `json:"score_delta"`
+	Regressed      *bool               `json:"regressed"` // nil 表示没有结论
+	Reasons        []string            `json:"reasons,omitempty"`
+	Cases          []SkillEvalCaseDiff `json:"cases"`
+}
+
+// SkillEvalRunDetail 运行详情，candidate 运行附带对比结论
+type SkillEvalRunDetail struct {
+	*models.SkillEvalRun
+	Comparison *SkillEvalComparison `json:"comparison,omitempty"`
+}
+
+// SkillEditGate 修改 Skill 内容前的评估检查结果
+type SkillEditGate struct {
+	Blocked  bool     `json:"blocked"`
+	Reasons  []string `json:"reasons,omitempty"` // 阻止修改的回归
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// ========== 套件与用例 ==========
+
+// ListSuites 获取评估套件列表
+func (s *SkillEvalService) ListSuites() ([]models.SkillEvalSuite, error) {
+	var suites []models.SkillEvalSuite
+	if err := s.db.Order("name ASC").Find(&suites).Error; err != nil {
+		return nil, err
+	}
+	return suites, nil
+}
+
+// GetSuite 获取评估套件及其用例
+func (s *SkillEvalService) GetSuite(id uint) (*models.SkillEvalSuite, error) {
+	var suite models.SkillEvalSuite
+	err := s.db.Preload("Cases", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		First(&suite, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &suite, nil
+}
+
+// CreateSuite 创建评估套件
+func (s *SkillEvalService) CreateSuite(req *SkillEvalSuiteRequest, userID string) (*models.SkillEvalSuite, error) {
+	suite := &models.SkillEvalSuite{CreatedBy: userID}
+	applySuiteRequest(suite, req)
+	if err := s.db.Create(suite).Error; err != nil {
+		return nil, err
+	}
+	return suite, nil
+}
+
+// UpdateSuite 更新评估套件
+func (s *SkillEvalService) UpdateSuite(id uint, req *SkillEvalSuiteRequest) (*models.SkillEvalSuite, error) {
+	var suite models.SkillEvalSuite
+	if err := s.db.First(&suite, id).Error; err != nil {
+		return nil, err
+	}
+	applySuiteRequest(&suite, req)
+	if err := s.db.Save(&suite).Error; err != nil {
+		return nil, err
+	}
+	return &suite, nil
+}
+
+func applySuiteRequest(suite *models.SkillEvalSuite, req *SkillEvalSuiteRequest) {
+	suite.Name = req.Name
+	suite.Description = req.Description
+	suite.Composition = req.Composition
+	suite.AIConfigID = req.AIConfigID
+	suite.Mode = req.Mode
+	if suite.Mode == "" {
+		suite.Mode = models.SkillEvalModeReplay
+	}
+	suite.GateSkillEdits = req.GateSkillEdits
+	suite.Tolerance = req.Tolerance
+}
+
+// DeleteSuite 删除评估套件及其用例、运行记录和录制的响应
+func (s *SkillEvalService) DeleteSuite(id uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var suite models.SkillEvalSuite
+		if err := tx.First(&suite, id).Error; err != nil {
+			return err
+		}
+		caseIDs := tx.Model(&models.SkillEvalCase{}).Select("id").Where("suite_id = ?", id)
+		if err := tx.Where("case_id IN (?)", caseIDs).Delete(&models.SkillEvalRecording{}).Error; err != nil {
+			return err
+		}
+		runIDs := tx.Model(&models.SkillEvalRun{}).Select("run_id").Where("suite_id = ?", id)
+		if err := tx.Where("run_id IN (?)", runIDs).Delete(&models.SkillEvalResult{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("suite_id = ?", id).Delete(&models.SkillEvalRun{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("suite_id = ?", id).Delete(&models.SkillEvalCase{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&suite).Error
+	})
+}
+
+// CreateCase 为套件添加用例
+func (s *SkillEvalService) CreateCase(suiteID uint, req *SkillEvalCaseRequest) (*models.SkillEvalCase, error) {
+	var suite models.SkillEvalSuite
+	if err := s.db.First(&suite, suiteID).Error; err != nil {
+		return nil, err
+	}
+	evalCase := &models.SkillEvalCase{SuiteID: suiteID}
+	applyCaseRequest(evalCase, req)
+	if err := s.db.Create(evalCase).Error; err != nil {
+		return nil, err
+	}
+	return evalCase, nil
+}
+
+// UpdateCase 更新用例，意图或 Module 变化后原有的录制不再匹配，一并删除
+func (s *SkillEvalService) UpdateCase(suiteID, caseID uint, req *SkillEvalCaseRequest) (*models.SkillEvalCase, error) {
+	var evalCase models.SkillEvalCase
+	if err := s.db.Where("id = ? AND suite_id = ?", caseID, suiteID).First(&evalCase).Error; err != nil {
+		return nil, err
+	}
+	inputChanged := evalCase.UserIntent != req.UserIntent || evalCase.ModuleID != req.ModuleID ||
+		!reflect.DeepEqual(evalCase.CurrentConfig, req.CurrentConfig)
+	applyCaseRequest(&evalCase, req)
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&evalCase).Error; err != nil {
+			return err
+		}
+		if inputChanged {
+			return tx.Where("case_id = ?", caseID).Delete(&models.SkillEvalRecording{}).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &evalCase, nil
+}
+
+func applyCaseRequest(evalCase *models.SkillEvalCase, req *SkillEvalCaseRequest) {
+	evalCase.Name = req.Name
+	evalCase.UserIntent = req.UserIntent
+	evalCase.ModuleID = req.ModuleID
+	evalCase.CurrentConfig = req.CurrentConfig
+	evalCase.Expectations = req.Expectations
+}
+
+// DeleteCase 删除用例及其录制的响应
+func (s *SkillEvalService) DeleteCase(suiteID, caseID uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND suite_id = ?", caseID, suiteID).Delete(&models.SkillEvalCase{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Where("case_id = ?", caseID).Delete(&models.SkillEvalRecording{}).Error
+	})
+}
+
+// ========== 运行 ==========
+
+// CreateRun 创建套件的评估运行，调用方负责调用 ExecuteRun 执行
+func (s *SkillEvalService) CreateRun(suiteID uint, req *RunSkillEvalRequest, userID string) (*models.SkillEvalRun, error) {
+	suite, err := s.GetSuite(suiteID)
+	if err != nil {
+		return nil, err
+	}
+	if len(suite.Cases) == 0 {
+		return nil, fmt.Errorf("评估套件 %s 没有用例", suite.Name)
+	}
+	run := s.newRun(suite, req.Mode, userID)
+	if err := s.db.Create(run).Error; err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// CreateComparison 创建对比评估：baseline 使用当前（或指定的）Skill 内容，candidate 使用候选内容
+// 调用方负责调用 ExecuteComparison 执行
+func (s *SkillEvalService) CreateComparison(suiteID uint, req *CompareSkillEvalRequest, userID string) (baseline, candidate *models.SkillEvalRun, err error) {
+	suite, err := s.GetSuite(suiteID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(suite.Cases) == 0 {
+		return nil, nil, fmt.Errorf("评估套件 %s 没有用例", suite.Name)
+	}
+	var count int64
+	if err := s.db.Model(&models.Skill{}).Where("name = ?", req.SkillName).Count(&count).Error; err != nil {
+		return nil, nil, err
+	}
+	if count == 0 {
+		return nil, nil, fmt.Errorf("Skill '%s' 不存在", req.SkillName)
+	}
+
+	baseline = s.newRun(suite, req.Mode, userID)
+	if req.BaselineContent != nil {
+		baseline.SkillOverrides = map[string]string{req.SkillName: *req.BaselineContent}
+		baseline.SkillName = req.SkillName
+		baseline.ContentHash = SkillContentHash(*req.BaselineContent)
+	}
+	candidate = s.newRun(suite, req.Mode, userID)
+	candidate.SkillOverrides = map[string]string{req.SkillName: req.Content}
+	candidate.SkillName = req.SkillName
+	candidate.ContentHash = SkillContentHash(req.Content)
+	candidate.BaselineRunID = baseline.RunID
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(baseline).Error; err != nil {
+			return err
+		}
+		return tx.Create(candidate).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return baseline, candidate, nil
+}
+
+func (s *SkillEvalService) newRun(suite *models.SkillEvalSuite, mode models.SkillEvalMode, userID string) *models.SkillEvalRun {
+	if mode == "" {
+		mode = suite.Mode
+	}
+	if mode == "" {
+		mode = models.SkillEvalModeReplay
+	}
+	return &models.SkillEvalRun{
+		RunID:     generateSkillEvalRunID(),
+		SuiteID:   suite.ID,
+		Mode:      mode,
+		Status:    models.SkillEvalRunStatusPending,
+		CreatedBy: userID,
+	}
+}
+
+// ListRuns 获取套件的运行记录（不含用例结果）
+func (s *SkillEvalService) ListRuns(suiteID uint, limit int) ([]models.SkillEvalRun, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	var runs []models.SkillEvalRun
+	err := s.db.Where("suite_id = ?", suiteID).Order("id DESC").Limit(limit).Find(&runs).Error
+	return runs, err
+}
+
+// GetRun 获取运行详情，candidate 运行附带与 baseline 的对比
+func (s *SkillEvalService) GetRun(runID string) (*SkillEvalRunDetail, error) {
+	run, err := s.loadRun(runID)
+	if err != nil {
+		return nil, err
+	}
+	detail := &SkillEvalRunDetail{SkillEvalRun: run}
+	if run.BaselineRunID != "" && run.Status == models.SkillEvalRunStatusCompleted {
+		baseline, err := s.loadRun(run.BaselineRunID)
+		if err != nil {
+			return nil, err
+		}
+		if baseline.Status == models.SkillEvalRunStatusCompleted {
+			var suite models.SkillEvalSuite
+			if err := s.db.First(&suite, run.SuiteID).Error; err != nil {
+				return nil, err
+			}
+			detail.Comparison = CompareSkillEvalRuns(baseline, run, suite.Tolerance)
+		}
+	}
+	return detail, nil
+}
+
+func (s *SkillEvalService) loadRun(runID string) (*models.SkillEvalRun, error) {
+	var run models.SkillEvalRun
+	err := s.db.Preload("Results", func(db *gorm.DB) *gorm.DB { return db.Order("case_id ASC") }).
+		Where("run_id = ?", runID).First(&run).Error
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// ExecuteComparison 依次执行 baseline 和 candidate，并把对比结论写入 candidate
+func (s *SkillEvalService) ExecuteComparison(baselineRunID, candidateRunID string) error {
+	if err := s.ExecuteRun(baselineRunID); err != nil {
+		return err
+	}
+	if err := s.ExecuteRun(candidateRunID); err != nil {
+		return err
+	}
+	detail, err := s.GetRun(candidateRunID)
+	if err != nil {
+		return err
+	}
+	if detail.Comparison == nil {
+		return nil
+	}
+	return s.db.Model(&models.SkillEvalRun{}).Where("run_id = ?", candidateRunID).Updates(map[string]interface{}{
+		"regressed":   detail.Comparison.Regressed,
+		"score_delta": detail.Comparison.ScoreDelta,
+	}).Error
+}
+
+// ExecuteRun 同步执行一次评估运行
+// 单个用例失败不影响其他用例；只有无法开始运行（如 live 模式没有 AI 配置）时运行记为 failed
+func (s *SkillEvalService) ExecuteRun(runID string) error {
+	var run models.SkillEvalRun
+	if err := s.db.Where("run_id = ?", runID).First(&run).Error; err != nil {
+		return err
+	}
+	suite, err := s.GetSuite(run.SuiteID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	run.Status = models.SkillEvalRunStatusRunning
+	run.StartedAt = &now
+	if err := s.db.Save(&run).Error; err != nil {
+		return err
+	}
+
+	aiConfig, err := s.resolveAIConfig(suite, run.Mode)
+	if err != nil {
+		return s.finishRun(&run, err)
+	}
+	run.AIModel = aiConfig.ModelID
+	composition := s.resolveComposition(suite, aiConfig)
+
+	assembler := NewSkillAssembler(s.db)
+	assembler.SetContentOverrides(run.SkillOverrides)
+
+	usedSkills := map[string]bool{}
+	var totalScore float64
+	for i := range suite.Cases {
+		evalCase := &suite.Cases[i]
+		result, used := s.evaluateCase(&run, evalCase, aiConfig, composition, assembler)
+		for _, name := range used {
+			usedSkills[name] = true
+		}
+		if err := s.db.Create(result).Error; err != nil {
+			return s.finishRun(&run, fmt.Errorf("保存用例 %s 的结果失败: %w", evalCase.Name, err))
+		}
+		run.TotalCount++
+		switch result.Status {
+		case "pass":
+			run.PassedCount++
+		case "fail":
+			run.FailedCount++
+		default:
+			run.ErroredCount++
+		}
+		totalScore += result.Score
+	}
+	if run.TotalCount > 0 {
+		run.Score = totalScore / float64(run.TotalCount)
+	}
+	run.UsedSkills = models.StringArray{}
+	for _, skill := range sortedKeys(usedSkills) {
+		run.UsedSkills = append(run.UsedSkills, skill)
+	}
+	return s.finishRun(&run, nil)
+}
+
+func (s *SkillEvalService) finishRun(run *models.SkillEvalRun, runErr error) error {
+	now := time.Now()
+	run.CompletedAt = &now
+	run.Status = models.SkillEvalRunStatusCompleted
+	if runErr != nil {
+		log.Printf("[SkillEval] Run %s failed: %v", run.RunID, runErr)
+		run.Status = models.SkillEvalRunStatusFailed
+		run.ErrorMessage = runErr.Error()
+	}
+	return s.db.Save(run).Error
+}
+
+// resolveAIConfig 获取运行使用的 AI 配置
+// replay 模式不调用模型，没有可用配置时使用空配置
+func (s *SkillEvalService) resolveAIConfig(suite *models.SkillEvalSuite, mode models.SkillEvalMode) (*models.AIConfig, error) {
+	var aiConfig *models.AIConfig
+	var err error
+	if suite.AIConfigID != nil {
+		var cfg models.AIConfig
+		if err = s.db.First(&cfg, *suite.AIConfigID).Error; err == nil {
+			aiConfig = &cfg
+		} else {
+			err = fmt.Errorf("AI 配置 %d 不存在: %w", *suite.AIConfigID, err)
+		}
+	} else {
+		aiConfig, err = s.configService.GetConfigForCapability("form_generation")
+	}
+	if err != nil || aiConfig == nil {
+		if mode == models.SkillEvalModeReplay {
+			return &models.AIConfig{}, nil
+		}
+		return nil, fmt.Errorf("live 模式需要 AI 配置: %v", err)
+	}
+	return aiConfig, nil
+}
+
+// resolveComposition 套件的 Skill 组合，与配置生成一致地回退到 AI 配置和默认组合
+func (s *SkillEvalService) resolveComposition(suite *models.SkillEvalSuite, aiConfig *models.AIConfig) *models.SkillComposition {
+	if suite.Composition != nil && (len(suite.Composition.FoundationSkills) > 0 || suite.Composition.TaskSkill != "") {
+		return suite.Composition
+	}
+	if len(aiConfig.SkillComposition.FoundationSkills) > 0 || aiConfig.SkillComposition.TaskSkill != "" {
+		return &aiConfig.SkillComposition
+	}
+	return s.generator.getDefaultSkillComposition()
+}
+
+// caseCaller 返回用例使用的模型调用：live 模式调用模型并录制，replay 模式按 Prompt 回放
+func (s *SkillEvalService) caseCaller(run *models.SkillEvalRun, caseID uint) func(cfg *models.AIConfig, prompt string) (string, error) {
+	return func(cfg *models.AIConfig, prompt string) (string, error) {
+		promptHash := SkillContentHash(prompt)
+		if run.Mode == models.SkillEvalModeReplay {
+			var recording models.SkillEvalRecording
+			err := s.db.Where("case_id = ? AND prompt_hash = ?", caseID, promptHash).First(&recording).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return "", ErrSkillEvalNoRecording
+			}
+			if err != nil {
+				return "", err
+			}
+			return recording.Response, nil
+		}
+
+		response, err := s.callAI(cfg, prompt)
+		if err != nil {
+			return "", err
+		}
+		recording := &models.SkillEvalRecording{
+			CaseID:     caseID,
+			PromptHash: promptHash,
+			Response:   response,
+			AIModel:    cfg.ModelID,
+		}
+		err = s.db.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "case_id"}, {Name: "prompt_hash"}},
+			DoUpdates: clause.AssignmentColumns([]string{"response", "ai_model", "created_at"}),
+		}).Create(recording).Error
+		if err != nil {
+			log.Printf("[SkillEval] Failed to record response for case %d: %v", caseID, err)
+		}
+		return response, nil
+	}
+}
+
+// evaluateCase 执行单个用例：与配置生成相同的 组装 -> 生成 -> SchemaSolver -> 反馈循环 流程，然后打分
+// 返回结果和组装 Prompt 用到的 Skill 名称
+func (s *SkillEvalService) evaluateCase(
+	run *models.SkillEvalRun,
+	evalCase *models.SkillEvalCase,
+	aiConfig *models.AIConfig,
+	composition *models.SkillComposition,
+	assembler *SkillAssembler,
+) (*models.SkillEvalResult, []string) {
+	result := &models.SkillEvalResult{
+		RunID:    run.RunID,
+		CaseID:   evalCase.ID,
+		CaseName: evalCase.Name,
+		Status:   "error",
+		Checks:   []models.SkillEvalCheck{},
+	}
+
+	mode := "new"
+	if len(evalCase.CurrentConfig) > 0 {
+		mode = "refine"
+	}
+	assembled, err := assembler.AssemblePrompt(composition, evalCase.ModuleID, &DynamicContext{
+		UserDescription: evalCase.UserIntent,
+		ModuleID:        evalCase.ModuleID,
+		CurrentConfig:   evalCase.CurrentConfig,
+		ExtraContext:    map[string]interface{}{"mode": mode},
+	})
+	if err != nil {
+		result.ErrorMessage = fmt.Sprintf("组装 Prompt 失败: %v", err)
+		return result, nil
+	}
+
+	callAI := s.caseCaller(run, evalCase.ID)
+	raw, err := callAI(aiConfig, assembled.Prompt)
+	if err != nil {
+		result.ErrorMessage = fmt.Sprintf("生成配置失败: %v", err)
+		return result, assembled.UsedSkillNames
+	}
+	response, err := s.generator.parseAIResponse(raw, evalCase.ModuleID)
+	if err != nil {
+		result.ErrorMessage = fmt.Sprintf("解析 AI 响应失败: %v", err)
+		return result, assembled.UsedSkillNames
+	}
+
+	// 没有返回配置属于生成质量问题，按失败打分
+	solverPassed := false
+	solverMessage := ""
+	if len(response.Config) == 0 {
+		solverMessage = fmt.Sprintf("AI 未返回配置（status=%s）: %s", response.Status, response.Message)
+	} else {
+		solverResult := NewSchemaSolver(s.db, evalCase.ModuleID).Solve(response.Config)
+		result.Params = solverResult.Params
+		solverPassed = solverResult.Success
+		result.SolverErrors = countSolverErrors(solverResult)
+
+		if !solverResult.Success && solverResult.NeedAIFix {
+			loop := NewAIFeedbackLoop(s.db, evalCase.ModuleID)
+			loop.SetMaxRetries(config.GetSchemaSolverMaxRetries())
+			loop.SetAICaller(callAI)
+			loopResult, _ := loop.ExecuteWithRetry(evalCase.UserIntent, response.Config, aiConfig)
+			if strings.Contains(loopResult.Error, ErrSkillEvalNoRecording.Error()) {
+				result.ErrorMessage = "SchemaSolver 反馈循环: " + loopResult.Error
+				return result, assembled.UsedSkillNames
+			}
+			result.Params = loopResult.FinalParams
+			solverPassed = loopResult.Success
+			for _, iteration := range loopResult.Iterations {
+				if iteration.AIResponse != nil {
+					result.Retries++
+				}
+			}
+			if n := len(loopResult.Iterations); n > 0 {
+				result.SolverErrors = countSolverErrors(loopResult.Iterations[n-1].Output)
+			}
+			solverMessage = loopResult.Error
+		} else if !solverResult.Success {
+			solverMessage = solverResult.AIInstructions
+		}
+	}
+
+	result.Checks = scoreSkillEvalCase(result.Params, solverPassed, solverMessage, &evalCase.Expectations)
+	passed := 0
+	for _, check := range result.Checks {
+		if check.Passed {
+			passed++
+		}
+	}
+	result.Score = float64(passed) / float64(len(result.Checks))
+	result.Status = "fail"
+	if passed == len(result.Checks) {
+		result.Status = "pass"
+	}
+	return result, assembled.UsedSkillNames
+}
+
+func countSolverErrors(result *SolverResult) int {
+	if result == nil {
+		return 0
+	}
+	count := 0
+	for _, feedback := range result.Feedbacks {
+		if feedback.Type == FeedbackTypeError {
+			count++
+		}
+	}
+	return count
+}
+
+// scoreSkillEvalCase 按用例期望检查生成结果：Solver 是否通过、必填字段、期望值和禁止字段各为一项
+func scoreSkillEvalCase(params map[string]interface{}, solverPassed bool, solverMessage string, exp *models.SkillEvalExpectations) []models.SkillEvalCheck {
+	checks := []models.SkillEvalCheck{{Name: "solver", Passed: solverPassed, Message: solverMessage}}
+
+	for _, field := range exp.RequiredFields {
+		value, ok := lookupParamPath(params, field)
+		check := models.SkillEvalCheck{Name: "required:" + field, Passed: ok && !isEmptyParam(value)}
+		if !check.Passed {
+			check.Message = "字段缺失或为空"
+		}
+		checks = append(checks, check)
+	}
+
+	for _, field := range sortedKeys(exp.ExpectedValues) {
+		expected := exp.ExpectedValues[field]
+		value, ok := lookupParamPath(params, field)
+		check := models.SkillEvalCheck{Name: "value:" + field, Passed: ok && jsonValuesEqual(value, expected)}
+		if !check.Passed {
+			actual, _ := json.Marshal(value)
+			want, _ := json.Marshal(expected)
+			if !ok {
+				actual = []byte("<missing>")
+			}
+			check.Message = fmt.Sprintf("期望 %s，实际 %s", want, actual)
+		}
+		checks = append(checks, check)
+	}
+
+	for _, field := range exp.ForbiddenFields {
+		_, ok := lookupParamPath(params, field)
+		check := models.SkillEvalCheck{Name: "forbidden:" + field, Passed: !ok}
+		if ok {
+			check.Message = "不应出现该字段"
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// lookupParamPath 按 a.b.c 路径读取嵌套参数
+func lookupParamPath(params map[string]interface{}, path string) (interface{}, bool) {
+	var current interface{} = params
+	for _, part := range strings.Split(path, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current, ok = m[part]
+		if !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func isEmptyParam(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// jsonValuesEqual 按 JSON 语义比较（数字类型不同但值相同视为相等）
+func jsonValuesEqual(a, b interface{}) bool {
+	normalize := func(v interface{}) interface{} {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return v
+		}
+		var out interface{}
+		if err := json.Unmarshal(data, &out); err != nil {
+			return v
+		}
+		return out
+	}
+	return reflect.DeepEqual(normalize(a), normalize(b))
+}
+
+// CompareSkillEvalRuns 对比 baseline 与 candidate
+// 可比较用例的平均分下降超过 tolerance，或有用例从 pass 变为 fail，视为回归
+func CompareSkillEvalRuns(baseline, candidate *models.SkillEvalRun, tolerance float64) *SkillEvalComparison {
+	comparison := &SkillEvalComparison{
+		BaselineRunID:  baseline.RunID,
+		CandidateRunID: candidate.RunID,
+		Cases:          []SkillEvalCaseDiff{},
+	}
+
+	candidateResults := make(map[uint]*models.SkillEvalResult, len(candidate.Results))
+	for i := range candidate.Results {
+		candidateResults[candidate.Results[i].CaseID] = &candidate.Results[i]
+	}
+
+	var baselineTotal, candidateTotal float64
+	comparable := 0
+	inconclusive := false
+	regressed := false
+	for i := range baseline.Results {
+		b := &baseline.Results[i]
+		c, ok := candidateResults[b.CaseID]
+		if !ok {
+			continue
+		}
+		diff := SkillEvalCaseDiff{
+			CaseID:          b.CaseID,
+			CaseName:        b.CaseName,
+			BaselineStatus:  b.Status,
+			CandidateStatus: c.Status,
+			BaselineScore:   b.Score,
+			CandidateScore:  c.Score,
+		}
+		switch {
+		case b.Status == "error" && c.Status == "error":
+		case c.Status == "error":
+			inconclusive = true
+			comparison.Reasons = append(comparison.Reasons, fmt.Sprintf("用例 %s 在候选版本中未得到结果", b.CaseName))
+		case b.Status == "error":
+		default:
+			comparable++
+			baselineTotal += b.Score
+			candidateTotal += c.Score
+			if b.Status == "pass" && c.Status != "pass" {
+				diff.Regressed = true
+				regressed = true
+				comparison.Reasons = append(comparison.Reasons, fmt.Sprintf("用例 %s 从通过变为失败", b.CaseName))
+			}
+		}
+		comparison.Cases = append(comparison.Cases, diff)
+	}
+
+	if comparable > 0 {
+		comparison.BaselineScore = baselineTotal / float64(comparable)
+		comparison.CandidateScore = candidateTotal / float64(comparable)
+		comparison.ScoreDelta = comparison.CandidateScore - comparison.BaselineScore
+		if comparison.ScoreDelta < -tolerance-skillEvalScoreEpsilon {
+			regressed = true
+			comparison.Reasons = append(comparison.Reasons, fmt.Sprintf("得分下降 %.1f%%（允许 %.1f%%）",
+				math.Abs(comparison.ScoreDelta)*100, tolerance*100))
+		}
+	} else {
+		inconclusive = true
+	}
+
+	switch {
+	case regressed:
+		comparison.Regressed = &regressed
+	case !inconclusive:
+		comparison.Regressed = &regressed
+	}
+	return comparison
+}
+
+// ========== 修改 Skill 的评估门禁 ==========
+
+// CheckSkillEdit 检查对 Skill 内容的修改
+// 只检查开启了 gate_skill_edits 且最近一次运行用到了该 Skill 的套件：
+// 对该内容的对比评估回归时阻止修改；没有评估或没有结论时给出警告
+func (s *SkillEvalService) CheckSkillEdit(skillName, content string) (*SkillEditGate, error) {
+	gate := &SkillEditGate{}
+	var suites []models.SkillEvalSuite
+	if err := s.db.Where("gate_skill_edits = ?", true).Order("name ASC").Find(&suites).Error; err != nil {
+		return nil, err
+	}
+
+	contentHash := SkillContentHash(content)
+	for _, suite := range suites {
+		var last models.SkillEvalRun
+		err := s.db.Where("suite_id = ? AND status = ?", suite.ID, models.SkillEvalRunStatusCompleted).
+			Order("id DESC").First(&last).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !containsString(last.UsedSkills, skillName) {
+			continue
+		}
+
+		var candidate models.SkillEvalRun
+		err = s.db.Where("suite_id = ? AND skill_name = ? AND content_hash = ? AND baseline_run_id <> '' AND status = ?",
+			suite.ID, skillName, contentHash, models.SkillEvalRunStatusCompleted).
+			Order("id DESC").First(&candidate).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			gate.Warnings = append(gate.Warnings, fmt.Sprintf("评估套件 %s 用到了 Skill %s，修改后的内容尚未评估", suite.Name, skillName))
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case candidate.Regressed == nil:
+			gate.Warnings = append(gate.Warnings, fmt.Sprintf("评估套件 %s 对修改后内容的评估没有结论（运行 %s）", suite.Name, candidate.RunID))
+		case *candidate.Regressed:
+			gate.Blocked = true
+			delta := 0.0
+			if candidate.ScoreDelta != nil {
+				delta = *candidate.ScoreDelta
+			}
+			gate.Reasons = append(gate.Reasons, fmt.Sprintf("评估套件 %s 出现回归（运行 %s，得分变化 %+.1f%%）",
+				suite.Name, candidate.RunID, delta*100))
+		}
+	}
+	return gate, nil
+}
+
+func containsString(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
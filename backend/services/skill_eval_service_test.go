@@ -0,0 +1,297 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"iac-platform/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+const skillEvalTestSchema = `{
+	"type": "object",
+	"required": ["name", "instance_type"],
+	"properties": {
+		"name": {"type": "string"},
+		"instance_type": {"type": "string", "enum": ["t3.micro", "t3.small"]},
+		"tags": {"type": "object"}
+	}
+}`
+
+func setupSkillEvalTest(t *testing.T) (*gorm.DB, *SkillEvalService) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(
+		&models.Skill{},
+		&models.AIConfig{},
+		&models.SkillEvalSuite{},
+		&models.SkillEvalCase{},
+		&models.SkillEvalRun{},
+		&models.SkillEvalResult{},
+		&models.SkillEvalRecording{},
+	))
+	require.NoError(t, db.Exec(`INSERT INTO schemas (module_id, status, openapi_schema) VALUES (1, 'active', ?)`,
+		skillEvalTestSchema).Error)
+
+	for _, skill := range []models.Skill{
+		{ID: "sk-foundation", Name: "output_format", DisplayName: "Output", Layer: models.SkillLayerFoundation,
+			Content: "Return JSON with a config object.", IsActive: true, SourceType: models.SkillSourceManual,
+			Metadata: models.SkillMetadata{Author: "test"}},
+		{ID: "sk-task", Name: "generate_config", DisplayName: "Generate", Layer: models.SkillLayerTask,
+			Content: "Generate module config for: {user_description}", IsActive: true, SourceType: models.SkillSourceManual,
+			Metadata: models.SkillMetadata{Author: "test"}},
+	} {
+		require.NoError(t, db.Create(&skill).Error)
+	}
+
+	require.NoError(t, db.Create(&models.AIConfig{
+		ID: 1, ServiceType: "openai", ModelID: "test-model", Enabled: true,
+		Capabilities:      models.StringArray{"form_generation"},
+		CapabilityPrompts: models.CapabilityPrompts{"form_generation": ""},
+	}).Error)
+
+	service := NewSkillEvalService(db)
+	service.callAI = func(cfg *models.AIConfig, prompt string) (string, error) {
+		return "", errors.New("unexpected AI call")
+	}
+	return db, service
+}
+
+func createSkillEvalSuite(t *testing.T, service *SkillEvalService, gate bool) *models.SkillEvalSuite {
+	aiConfigID := uint(1)
+	suite, err := service.CreateSuite(&SkillEvalSuiteRequest{
+		Name:       "ec2",
+		AIConfigID: &aiConfigID,
+		Composition: &models.SkillComposition{
+			FoundationSkills: []string{"output_format"},
+			TaskSkill:        "generate_config",
+		},
+		GateSkillEdits: gate,
+	}, "u-admin")
+	require.NoError(t, err)
+
+	_, err = service.CreateCase(suite.ID, &SkillEvalCaseRequest{
+		Name:       "small web server",
+		UserIntent: "a small web server called web-1",
+		ModuleID:   1,
+		Expectations: models.SkillEvalExpectations{
+			RequiredFields:  []string{"name"},
+			ExpectedValues:  map[string]interface{}{"instance_type": "t3.micro"},
+			ForbiddenFields: []string{"tags.Owner"},
+		},
+	})
+	require.NoError(t, err)
+	return suite
+}
+
+func runSkillEval(t *testing.T, service *SkillEvalService, suiteID uint, mode models.SkillEvalMode) *SkillEvalRunDetail {
+	run, err := service.CreateRun(suiteID, &RunSkillEvalRequest{Mode: mode}, "u-admin")
+	require.NoError(t, err)
+	require.NoError(t, service.ExecuteRun(run.RunID))
+	detail, err := service.GetRun(run.RunID)
+	require.NoError(t, err)
+	return detail
+}
+
+func TestSkillEval_ReplayWithoutRecordingErrors(t *testing.T) {
+	_, service := setupSkillEvalTest(t)
+	suite := createSkillEvalSuite(t, service, false)
+
+	detail := runSkillEval(t, service, suite.ID, models.SkillEvalModeReplay)
+
+	assert.Equal(t, models.SkillEvalRunStatusCompleted, detail.Status)
+	require.Len(t, detail.Results, 1)
+	assert.Equal(t, "error", detail.Results[0].Status)
+	assert.Contains(t, detail.Results[0].ErrorMessage, ErrSkillEvalNoRecording.Error())
+	assert.Equal(t, 1, detail.ErroredCount)
+}
+
+func TestSkillEval_LiveRecordsAndReplayReproduces(t *testing.T) {
+	db, service := setupSkillEvalTest(t)
+	suite := createSkillEvalSuite(t, service, false)
+
+	calls := 0
+	service.callAI = func(cfg *models.AIConfig, prompt string) (string, error) {
+		calls++
+		assert.Contains(t, prompt, "a small web server called web-1")
+		return `{"status":"complete","config":{"name":"web-1","instance_type":"t3.small"}}`, nil
+	}
+
+	live := runSkillEval(t, service, suite.ID, models.SkillEvalModeLive)
+	assert.Equal(t, 1, calls)
+	require.Len(t, live.Results, 1)
+	result := live.Results[0]
+	assert.Equal(t, "fail", result.Status)
+	assert.InDelta(t, 0.75, result.Score, 1e-9) // solver、required、forbidden 通过，期望值不符
+	assert.ElementsMatch(t, []string{"output_format", "generate_config"}, []string(live.UsedSkills))
+
+	var recordings int64
+	db.Model(&models.SkillEvalRecording{}).Count(&recordings)
+	assert.Equal(t, int64(1), recordings)
+
+	service.callAI = func(cfg *models.AIConfig, prompt string) (string, error) {
+		t.Fatal("replay must not call the model")
+		return "", nil
+	}
+	replay := runSkillEval(t, service, suite.ID, models.SkillEvalModeReplay)
+	require.Len(t, replay.Results, 1)
+	assert.Equal(t, result.Status, replay.Results[0].Status)
+	assert.InDelta(t, live.Score, replay.Score, 1e-9)
+}
+
+func TestSkillEval_SolverFeedbackLoopUsesRecordedCaller(t *testing.T) {
+	_, service := setupSkillEvalTest(t)
+	suite := createSkillEvalSuite(t, service, false)
+
+	service.callAI = func(cfg *models.AIConfig, prompt string) (string, error) {
+		if strings.Contains(prompt, "corrected_params") {
+			return `{"corrected_params":{"name":"web-1","instance_type":"t3.micro"}}`, nil
+		}
+		return `{"status":"complete","config":{"name":"web-1","instance_type":"m5.large"}}`, nil
+	}
+
+	detail := runSkillEval(t, service, suite.ID, models.SkillEvalModeLive)
+	require.Len(t, detail.Results, 1)
+	assert.Equal(t, "pass", detail.Results[0].Status, "checks: %+v", detail.Results[0].Checks)
+	assert.Equal(t, 1, detail.Results[0].Retries)
+	assert.Equal(t, "t3.micro", detail.Results[0].Params["instance_type"])
+}
+
+func TestSkillEval_ComparisonAndEditGate(t *testing.T) {
+	db, service := setupSkillEvalTest(t)
+	suite := createSkillEvalSuite(t, service, true)
+
+	// 候选内容让模型生成了错误的实例类型
+	const candidateContent = "Generate module config. Prefer larger instances."
+	service.callAI = func(cfg *models.AIConfig, prompt string) (string, error) {
+		if strings.Contains(prompt, "Prefer larger instances") {
+			return `{"status":"complete","config":{"name":"web-1","instance_type":"t3.small"}}`, nil
+		}
+		return `{"status":"complete","config":{"name":"web-1","instance_type":"t3.micro"}}`, nil
+	}
+
+	// 未评估的修改给出警告
+	gate, err := service.CheckSkillEdit("generate_config", candidateContent)
+	require.NoError(t, err)
+	assert.False(t, gate.Blocked)
+	assert.Empty(t, gate.Warnings, "suite has no completed runs yet")
+
+	runSkillEval(t, service, suite.ID, models.SkillEvalModeLive)
+	gate, err = service.CheckSkillEdit("generate_config", candidateContent)
+	require.NoError(t, err)
+	assert.False(t, gate.Blocked)
+	require.Len(t, gate.Warnings, 1)
+
+	// 不相关的 Skill 不受影响
+	gate, err = service.CheckSkillEdit("unrelated", "anything")
+	require.NoError(t, err)
+	assert.False(t, gate.Blocked)
+	assert.Empty(t, gate.Warnings)
+
+	baseline, candidate, err := service.CreateComparison(suite.ID, &CompareSkillEvalRequest{
+		SkillName: "generate_config",
+		Content:   candidateContent,
+		Mode:      models.SkillEvalModeLive,
+	}, "u-admin")
+	require.NoError(t, err)
+	require.NoError(t, service.ExecuteComparison(baseline.RunID, candidate.RunID))
+
+	detail, err := service.GetRun(candidate.RunID)
+	require.NoError(t, err)
+	require.NotNil(t, detail.Comparison)
+	require.NotNil(t, detail.Regressed)
+	assert.True(t, *detail.Regressed)
+	assert.InDelta(t, -0.25, *detail.ScoreDelta, 1e-9)
+	assert.True(t, detail.Comparison.Cases[0].Regressed)
+
+	gate, err = service.CheckSkillEdit("generate_config", candidateContent)
+	require.NoError(t, err)
+	assert.True(t, gate.Blocked)
+	require.Len(t, gate.Reasons, 1)
+	assert.Contains(t, gate.Reasons[0], candidate.RunID)
+
+	// 删除套件后门禁不再生效
+	require.NoError(t, service.DeleteSuite(suite.ID))
+	gate, err = service.CheckSkillEdit("generate_config", candidateContent)
+	require.NoError(t, err)
+	assert.False(t, gate.Blocked)
+	var count int64
+	db.Model(&models.SkillEvalResult{}).Count(&count)
+	assert.Zero(t, count)
+}
+
+func TestCompareSkillEvalRuns(t *testing.T) {
+	run := func(id string, results ...models.SkillEvalResult) *models.SkillEvalRun {
+		return &models.SkillEvalRun{RunID: id, Results: results}
+	}
+	result := func(caseID uint, status string, score float64) models.SkillEvalResult {
+		return models.SkillEvalResult{CaseID: caseID, CaseName: "case", Status: status, Score: score}
+	}
+
+	t.Run("within tolerance", func(t *testing.T) {
+		c := CompareSkillEvalRuns(
+			run("b", result(1, "fail", 0.8), result(2, "pass", 1)),
+			run("c", result(1, "fail", 0.6), result(2, "pass", 1)),
+			0.2,
+		)
+		require.NotNil(t, c.Regressed)
+		assert.False(t, *c.Regressed)
+		assert.InDelta(t, -0.1, c.ScoreDelta, 1e-9)
+	})
+
+	t.Run("pass to fail regresses regardless of tolerance", func(t *testing.T) {
+		c := CompareSkillEvalRuns(
+			run("b", result(1, "pass", 1), result(2, "fail", 0.5)),
+			run("c", result(1, "fail", 0.9), result(2, "pass", 1)),
+			0.5,
+		)
+		require.NotNil(t, c.Regressed)
+		assert.True(t, *c.Regressed)
+	})
+
+	t.Run("candidate errors are inconclusive", func(t *testing.T) {
+		c := CompareSkillEvalRuns(
+			run("b", result(1, "pass", 1)),
+			run("c", result(1, "error", 0)),
+			0,
+		)
+		assert.Nil(t, c.Regressed)
+		assert.NotEmpty(t, c.Reasons)
+	})
+
+	t.Run("improvement", func(t *testing.T) {
+		c := CompareSkillEvalRuns(
+			run("b", result(1, "fail", 0.5)),
+			run("c", result(1, "pass", 1)),
+			0,
+		)
+		require.NotNil(t, c.Regressed)
+		assert.False(t, *c.Regressed)
+		assert.InDelta(t, 0.5, c.ScoreDelta, 1e-9)
+	})
+}
+
+func TestSkillAssembler_ContentOverridesChangePrompt(t *testing.T) {
+	db, _ := setupSkillEvalTest(t)
+	composition := &models.SkillComposition{FoundationSkills: []string{"output_format"}, TaskSkill: "generate_config"}
+	ctx := &DynamicContext{UserDescription: "web", ModuleID: 1}
+
+	assembler := NewSkillAssembler(db)
+	original, err := assembler.AssemblePrompt(composition, 1, ctx)
+	require.NoError(t, err)
+
+	assembler.SetContentOverrides(map[string]string{"generate_config": "OVERRIDDEN TASK"})
+	overridden, err := assembler.AssemblePrompt(composition, 1, ctx)
+	require.NoError(t, err)
+
+	assert.Contains(t, overridden.Prompt, "OVERRIDDEN TASK")
+	assert.NotContains(t, overridden.Prompt, "Generate module config for: web")
+	assert.Contains(t, original.Prompt, "Generate module config for: web")
+
+	var stored models.Skill
+	require.NoError(t, db.Where("name = ?", "generate_config").First(&stored).Error)
+	assert.Equal(t, "Generate module config for: {user_description}", stored.Content)
+}
@@ -0,0 +1,1392 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"iac-platform/internal/infrastructure"
+	"iac-platform/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrStackNotFound    = errors.New("stack not found")
+	ErrStackRunNotFound = errors.New("stack run not found")
+	ErrStackInvalid     = errors.New("invalid stack")
+	ErrStackRunState    = errors.New("stack run is not in a valid state for this operation")
+)
+
+// stackTaskQueue 触发成员任务执行（TaskQueueManager 实现）
+type stackTaskQueue interface {
+	TryExecuteNextTask(workspaceID string) error
+	ExecuteConfirmedApply(workspaceID string, taskID uint) error
+}
+
+// StackService Stack 管理与协同执行服务
+// 执行（StackRun）按拓扑分层推进：上游成员 plan 完成后才 plan 下游，全部 plan 完成后等待统一审核，
+// 审核通过后按同样的顺序逐个确认 Apply；上游 apply 改变了下游读取的 output 时，下游重新 plan 并再次审核。
+// 任一成员 plan/apply 失败即停止并丢弃其余成员的计划。
+// 推进（Process）由 StackRunWorker 在 leader 上定期调用，所有状态保存在数据库中
+type StackService struct {
+	db           *gorm.DB
+	queueManager stackTaskQueue
+}
+
+// NewStackService 创建 Stack 服务
+func NewStackService(db *gorm.DB) *StackService {
+	return &StackService{db: db}
+}
+
+// SetQueueManager 设置任务队列管理器，未设置时任务由 pending tasks monitor 拾取
+func (s *StackService) SetQueueManager(qm stackTaskQueue) {
+	s.queueManager = qm
+}
+
+// ========== Stack 管理 ==========
+
+// List 列出全部 Stack（含成员）
+func (s *StackService) List() ([]models.Stack, error) {
+	var stacks []models.Stack
+	if err := s.db.Order("name").Find(&stacks).Error; err != nil {
+		return nil, err
+	}
+	for i := range stacks {
+		members, err := s.loadMembers(stacks[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		stacks[i].Members = members
+	}
+	return stacks, nil
+}
+
+// Get 获取 Stack 详情（含成员和当前依赖图）
+// 依赖图在读取时根据最新的远程数据和 Run Trigger 推导；成环时返回 graph_error 而不是报错，便于修正
+func (s *StackService) Get(stackID string) (*models.Stack, error) {
+	stack, err := s.load(stackID)
+	if err != nil {
+		return nil, err
+	}
+	members, err := s.loadMembers(stack.ID)
+	if err != nil {
+		return nil, err
+	}
+	stack.Members = members
+	graph, err := s.BuildGraph(members)
+	switch {
+	case errors.Is(err, ErrStackInvalid):
+		stack.GraphError = err.Error()
+	case err != nil:
+		return nil, err
+	default:
+		stack.Graph = graph
+	}
+	return stack, nil
+}
+
+// Create 创建 Stack
+func (s *StackService) Create(req *models.StackRequest, userID string) (*models.Stack, error) {
+	members, err := s.validateRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	id, err := infrastructure.GenerateStackID()
+	if err != nil {
+		return nil, err
+	}
+	stack := models.Stack{
+		ID:          id,
+		Name:        strings.TrimSpace(req.Name),
+		Description: req.Description,
+		CreatedBy:   userID,
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkStackNameAvailable(tx, stack.Name, ""); err != nil {
+			return err
+		}
+		if err := tx.Create(&stack).Error; err != nil {
+			return err
+		}
+		return replaceStackMembers(tx, stack.ID, members)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.Get(stack.ID)
+}
+
+// Update 更新 Stack 名称、描述和成员；进行中的执行使用发起时固化的成员和依赖，不受影响
+func (s *StackService) Update(stackID string, req *models.StackRequest, userID string) (*models.Stack, error) {
+	stack, err := s.load(stackID)
+	if err != nil {
+		return nil, err
+	}
+	members, err := s.validateRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		name := strings.TrimSpace(req.Name)
+		if err := checkStackNameAvailable(tx, name, stack.ID); err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Stack{}).Where("id = ?", stack.ID).Updates(map[string]interface{}{
+			"name":        name,
+			"description": req.Description,
+			"updated_by":  userID,
+		}).Error; err != nil {
+			return err
+		}
+		return replaceStackMembers(tx, stack.ID, members)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.Get(stack.ID)
+}
+
+// Delete 删除 Stack 及其执行记录；有未结束的执行时不允许删除
+func (s *StackService) Delete(stackID string) error {
+	stack, err := s.load(stackID)
+	if err != nil {
+		return err
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var active int64
+		if err := tx.Model(&models.StackRun{}).
+			Where("stack_id = ? AND status IN ?", stack.ID, activeStackRunStatuses).
+			Count(&active).Error; err != nil {
+			return err
+		}
+		if active > 0 {
+			return fmt.Errorf("%w: stack has an active run", ErrStackRunState)
+		}
+		runIDs := tx.Model(&models.StackRun{}).Select("id").Where("stack_id = ?", stack.ID)
+		if err := tx.Where("run_id IN (?)", runIDs).Delete(&models.StackRunMember{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("stack_id = ?", stack.ID).Delete(&models.StackRun{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("stack_id = ?", stack.ID).Delete(&models.StackMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Stack{}, "id = ?", stack.ID).Error
+	})
+}
+
+// BuildGraph 合并显式依赖与远程数据、Run Trigger 推导出的依赖（仅成员之间），并做拓扑分层
+// 远程数据：读取方依赖被读取方；Run Trigger：目标依赖源
+func (s *StackService) BuildGraph(members []models.StackMember) (*models.StackGraph, error) {
+	ids := make([]string, 0, len(members))
+	isMember := make(map[string]bool, len(members))
+	for _, m := range members {
+		ids = append(ids, m.WorkspaceID)
+		isMember[m.WorkspaceID] = true
+	}
+
+	graph := &models.StackGraph{Edges: []models.StackEdge{}}
+	seen := make(map[string]bool)
+	addEdge := func(edge models.StackEdge) {
+		key := edge.From + "|" + edge.To + "|" + edge.Type + "|" + edge.Detail
+		if edge.From == edge.To || !isMember[edge.From] || !isMember[edge.To] || seen[key] {
+			return
+		}
+		seen[key] = true
+		graph.Edges = append(graph.Edges, edge)
+	}
+
+	for _, m := range members {
+		for _, upstream := range m.DependsOn {
+			addEdge(models.StackEdge{From: m.WorkspaceID, To: upstream, Type: models.StackEdgeExplicit})
+		}
+	}
+	if len(ids) > 0 {
+		var remoteData []models.WorkspaceRemoteData
+		if err := s.db.Where("workspace_id IN ? AND source_workspace_id IN ?", ids, ids).Order("id").
+			Find(&remoteData).Error; err != nil {
+			return nil, fmt.Errorf("failed to load remote data: %w", err)
+		}
+		for _, rd := range remoteData {
+			addEdge(models.StackEdge{From: rd.WorkspaceID, To: rd.SourceWorkspaceID, Type: models.StackEdgeRemoteData, Detail: rd.DataName})
+		}
+
+		var triggers []models.RunTrigger
+		if err := s.db.Where("enabled = ? AND source_workspace_id IN ? AND target_workspace_id IN ?", true, ids, ids).Order("id").
+			Find(&triggers).Error; err != nil {
+			return nil, fmt.Errorf("failed to load run triggers: %w", err)
+		}
+		for _, trigger := range triggers {
+			addEdge(models.StackEdge{From: trigger.TargetWorkspaceID, To: trigger.SourceWorkspaceID, Type: models.StackEdgeRunTrigger})
+		}
+	}
+
+	layers, err := stackLayers(ids, graph.Edges)
+	if err != nil {
+		return nil, err
+	}
+	graph.Layers = layers
+	return graph, nil
+}
+
+// ========== 执行 ==========
+
+// CreateRun 发起执行：固化成员、分层和依赖，由 worker 开始 plan 第一层
+// 同一 Stack 同时只允许一个未结束的执行，同一 workspace 也不能同时属于两个未结束的执行
+func (s *StackService) CreateRun(stackID string, req *models.CreateStackRunRequest, userID string) (*models.StackRun, error) {
+	stack, err := s.Get(stackID)
+	if err != nil {
+		return nil, err
+	}
+	if stack.GraphError != "" {
+		return nil, fmt.Errorf("%w: %s", ErrStackInvalid, stack.GraphError)
+	}
+	if len(stack.Members) == 0 {
+		return nil, fmt.Errorf("%w: stack has no members", ErrStackInvalid)
+	}
+	id, err := infrastructure.GenerateStackRunID()
+	if err != nil {
+		return nil, err
+	}
+
+	upstreams := make(map[string][]string)
+	for _, edge := range stack.Graph.Edges {
+		upstreams[edge.From] = appendUnique(upstreams[edge.From], edge.To)
+	}
+	var members []models.StackRunMember
+	workspaceIDs := make([]string, 0, len(stack.Members))
+	for layer, wsIDs := range stack.Graph.Layers {
+		for _, wsID := range wsIDs {
+			dependsOn := upstreams[wsID]
+			sort.Strings(dependsOn)
+			members = append(members, models.StackRunMember{
+				WorkspaceID: wsID,
+				Layer:       layer,
+				DependsOn:   models.StringArray(dependsOn),
+				Status:      models.StackMemberStatusPending,
+			})
+			workspaceIDs = append(workspaceIDs, wsID)
+		}
+	}
+
+	run := models.StackRun{
+		ID:          id,
+		StackID:     stack.ID,
+		Status:      models.StackRunStatusPlanning,
+		Description: strings.TrimSpace(req.Description),
+		CreatedBy:   userID,
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		activeRuns := tx.Model(&models.StackRun{}).Select("id").Where("status IN ?", activeStackRunStatuses)
+		var active int64
+		if err := tx.Model(&models.StackRun{}).
+			Where("stack_id = ? AND status IN ?", stack.ID, activeStackRunStatuses).
+			Count(&active).Error; err != nil {
+			return err
+		}
+		if active > 0 {
+			return fmt.Errorf("%w: stack already has an active run", ErrStackRunState)
+		}
+		var busy []models.StackRunMember
+		if err := tx.Select("run_id, workspace_id").
+			Where("workspace_id IN ? AND run_id IN (?)", workspaceIDs, activeRuns).
+			Find(&busy).Error; err != nil {
+			return err
+		}
+		if len(busy) > 0 {
+			return fmt.Errorf("%w: workspace %s is part of active stack run %s", ErrStackRunState, busy[0].WorkspaceID, busy[0].RunID)
+		}
+		if err := tx.Create(&run).Error; err != nil {
+			return err
+		}
+		for i := range members {
+			members[i].RunID = run.ID
+		}
+		return tx.Create(&members).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetRun(stack.ID, run.ID)
+}
+
+// ListRuns 列出 Stack 的执行（带成员统计）
+func (s *StackService) ListRuns(stackID string) ([]models.StackRun, error) {
+	if _, err := s.load(stackID); err != nil {
+		return nil, err
+	}
+	var runs []models.StackRun
+	if err := s.db.Where("stack_id = ?", stackID).Order("created_at DESC").Find(&runs).Error; err != nil {
+		return nil, err
+	}
+	for i := range runs {
+		var members []models.StackRunMember
+		if err := s.db.Select("status, changes_add, changes_change, changes_destroy").
+			Where("run_id = ?", runs[i].ID).Find(&members).Error; err != nil {
+			return nil, err
+		}
+		runs[i].Summary = summarizeStackRunMembers(members)
+	}
+	return runs, nil
+}
+
+// GetRun 获取执行详情（含全部成员及其任务）
+func (s *StackService) GetRun(stackID, runID string) (*models.StackRun, error) {
+	run, err := s.loadRun(stackID, runID)
+	if err != nil {
+		return nil, err
+	}
+	var members []models.StackRunMember
+	if err := s.db.Where("run_id = ?", run.ID).Order("layer, id").Find(&members).Error; err != nil {
+		return nil, err
+	}
+	workspaceIDs := make([]string, 0, len(members))
+	for _, m := range members {
+		workspaceIDs = append(workspaceIDs, m.WorkspaceID)
+	}
+	names, err := s.workspaceNames(workspaceIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range members {
+		members[i].WorkspaceName = names[members[i].WorkspaceID]
+	}
+	run.Members = members
+	run.Summary = summarizeStackRunMembers(members)
+	return run, nil
+}
+
+// GetRunReview 统一审核视图：执行详情 + 每个成员计划中的资源变更
+func (s *StackService) GetRunReview(stackID, runID string) (*models.StackRun, error) {
+	run, err := s.GetRun(stackID, runID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range run.Members {
+		m := &run.Members[i]
+		if m.TaskID == nil {
+			continue
+		}
+		if err := s.db.Select("id, task_id, workspace_id, resource_address, resource_type, resource_name, module_address, action, apply_status, apply_error").
+			Where("task_id = ?", *m.TaskID).Order("resource_address").
+			Find(&m.Resources).Error; err != nil {
+			return nil, err
+		}
+	}
+	return run, nil
+}
+
+// ApproveRun 统一审核通过，开始按拓扑顺序 Apply
+func (s *StackService) ApproveRun(stackID, runID, userID string) (*models.StackRun, error) {
+	run, err := s.loadRun(stackID, runID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.transition(run, models.StackRunStatusAwaitingApproval, map[string]interface{}{
+		"status":      models.StackRunStatusApplying,
+		"approved_by": userID,
+		"approved_at": time.Now(),
+		"message":     "",
+	}); err != nil {
+		return nil, err
+	}
+	return s.GetRun(stackID, runID)
+}
+
+// CancelRun 取消执行：未开始的成员跳过，等待 Apply 的计划被丢弃（任务取消并解锁 workspace）；
+// 正在执行的 plan/apply 不会被中断，plan 结束后由 worker 丢弃
+func (s *StackService) CancelRun(stackID, runID, userID string) (*models.StackRun, error) {
+	run, err := s.loadRun(stackID, runID)
+	if err != nil {
+		return nil, err
+	}
+	if !isActiveStackRunStatus(run.Status) {
+		return nil, fmt.Errorf("%w: run is %s", ErrStackRunState, run.Status)
+	}
+	if err := s.transition(run, run.Status, map[string]interface{}{
+		"status":       models.StackRunStatusCancelled,
+		"message":      fmt.Sprintf("Cancelled by %s", userID),
+		"completed_at": time.Now(),
+	}); err != nil {
+		return nil, err
+	}
+	if err := s.stopMembers(run.ID, "stack run cancelled"); err != nil {
+		return nil, err
+	}
+	return s.GetRun(stackID, runID)
+}
+
+// ActiveRunForTask 返回任务所属的未结束执行；不属于任何未结束执行时返回 nil
+// 用于阻止在任务页面单独确认由 Stack 统一编排的 Apply
+func (s *StackService) ActiveRunForTask(taskID uint) (*models.StackRun, error) {
+	var run models.StackRun
+	err := s.db.Where("status IN ? AND id IN (?)", activeStackRunStatuses,
+		s.db.Model(&models.StackRunMember{}).Select("run_id").Where("task_id = ?", taskID)).
+		First(&run).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// RunPeers 返回与任务同属一次执行的其他成员 workspace；
+// 成员之间的 Run Trigger 由 Stack 按顺序编排，apply 后不再单独触发
+func (s *StackService) RunPeers(taskID uint) (map[string]bool, error) {
+	var workspaceIDs []string
+	if err := s.db.Model(&models.StackRunMember{}).
+		Where("run_id IN (?) AND (task_id IS NULL OR task_id <> ?)",
+			s.db.Model(&models.StackRunMember{}).Select("run_id").Where("task_id = ?", taskID), taskID).
+		Pluck("workspace_id", &workspaceIDs).Error; err != nil {
+		return nil, err
+	}
+	peers := make(map[string]bool, len(workspaceIDs))
+	for _, id := range workspaceIDs {
+		peers[id] = true
+	}
+	return peers, nil
+}
+
+// ProcessAll 推进所有需要处理的执行：未结束的执行，以及仍有任务在执行的已结束执行（需要丢弃结束后产生的计划）
+func (s *StackService) ProcessAll(ctx context.Context) {
+	var ids []string
+	if err := s.db.Model(&models.StackRun{}).
+		Where("status IN ? OR id IN (?)", activeStackRunStatuses,
+			s.db.Model(&models.StackRunMember{}).Select("run_id").Where("status IN ?", inFlightStackMemberStatuses)).
+		Order("created_at").
+		Pluck("id", &ids).Error; err != nil {
+		log.Printf("[StackRun] Failed to load runs: %v", err)
+		return
+	}
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := s.Process(id); err != nil {
+			log.Printf("[StackRun] Failed to process run %s: %v", id, err)
+		}
+	}
+}
+
+// Process 推进单个执行：刷新成员任务状态，失败时停止，否则按阶段启动可以开始的成员
+// 所有状态都从数据库读取，可以在任意时刻（包括服务重启后）重复调用
+func (s *StackService) Process(runID string) error {
+	var run models.StackRun
+	if err := s.db.Where("id = ?", runID).First(&run).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrStackRunNotFound
+		}
+		return err
+	}
+	var members []models.StackRunMember
+	if err := s.db.Where("run_id = ?", run.ID).Order("layer, id").Find(&members).Error; err != nil {
+		return err
+	}
+
+	for i := range members {
+		m := &members[i]
+		if m.TaskID == nil {
+			continue
+		}
+		switch m.Status {
+		case models.StackMemberStatusPlanning, models.StackMemberStatusPlanned, models.StackMemberStatusApplying:
+			if err := s.refreshMember(&run, m); err != nil {
+				log.Printf("[StackRun] Failed to refresh member %s of run %s: %v", m.WorkspaceID, run.ID, err)
+			}
+		}
+	}
+
+	if !isActiveStackRunStatus(run.Status) {
+		return nil
+	}
+	for i := range members {
+		if members[i].Status == models.StackMemberStatusFailed {
+			return s.failRun(&run, &members[i])
+		}
+	}
+
+	switch run.Status {
+	case models.StackRunStatusPlanning:
+		return s.advancePlanning(&run, members)
+	case models.StackRunStatusApplying:
+		return s.advanceApplying(&run, members)
+	}
+	return nil
+}
+
+// advancePlanning 上游全部 plan 完成的成员开始 plan；全部完成后等待审核，没有任何变更时直接完成
+func (s *StackService) advancePlanning(run *models.StackRun, members []models.StackRunMember) error {
+	done, changes := true, 0
+	for i := range members {
+		m := &members[i]
+		if m.Status == models.StackMemberStatusPending &&
+			upstreamsIn(m, members, models.StackMemberStatusPlanned, models.StackMemberStatusNoChanges) {
+			s.startPlan(run, m)
+		}
+		switch m.Status {
+		case models.StackMemberStatusFailed:
+			return s.failRun(run, m)
+		case models.StackMemberStatusPending, models.StackMemberStatusPlanning:
+			done = false
+		case models.StackMemberStatusPlanned:
+			changes++
+		}
+	}
+	if !done {
+		return nil
+	}
+	if changes == 0 {
+		log.Printf("[StackRun] Run %s completed without changes", run.ID)
+		return s.finishRun(run, models.StackRunStatusCompleted, "No changes in any workspace", nil)
+	}
+	log.Printf("[StackRun] Run %s planned, %d workspaces awaiting approval", run.ID, changes)
+	return s.advance(run, models.StackRunStatusPlanning, map[string]interface{}{
+		"status":  models.StackRunStatusAwaitingApproval,
+		"message": fmt.Sprintf("%d of %d workspaces have changes, approval required to apply", changes, len(members)),
+	})
+}
+
+// advanceApplying 上游全部 apply 完成（或无变更）的成员确认 Apply；全部完成后结束执行
+// 成员的计划基于上游 apply 前的 output，上游 apply 改变了它读取的 output 时先重新 plan，
+// 重新 plan 的计划在审核通过之后产生，需要再次审核
+func (s *StackService) advanceApplying(run *models.StackRun, members []models.StackRunMember) error {
+	done, replanning := true, false
+	var review []string
+	for i := range members {
+		m := &members[i]
+		ready := upstreamsIn(m, members, models.StackMemberStatusApplied, models.StackMemberStatusNoChanges)
+		if ready && (m.Status == models.StackMemberStatusPlanned || m.Status == models.StackMemberStatusNoChanges) {
+			reason, err := s.upstreamOutputsChanged(m, members)
+			if err != nil {
+				return err
+			}
+			if reason != "" {
+				s.replanMember(run, m, reason)
+			}
+		}
+		if ready && m.Status == models.StackMemberStatusPlanned {
+			if run.ApprovedAt != nil && m.StartedAt != nil && m.StartedAt.After(*run.ApprovedAt) {
+				review = append(review, m.WorkspaceID)
+			} else {
+				s.confirmApply(run, m)
+			}
+		}
+		switch m.Status {
+		case models.StackMemberStatusFailed:
+			return s.failRun(run, m)
+		case models.StackMemberStatusPlanning:
+			replanning = true
+			done = false
+		case models.StackMemberStatusApplied, models.StackMemberStatusNoChanges:
+		default:
+			done = false
+		}
+	}
+	if len(review) > 0 && !replanning {
+		log.Printf("[StackRun] Run %s re-planned %s after upstream outputs changed, approval required", run.ID, strings.Join(review, ", "))
+		return s.advance(run, models.StackRunStatusApplying, map[string]interface{}{
+			"status":  models.StackRunStatusAwaitingApproval,
+			"message": fmt.Sprintf("Upstream outputs changed, %d re-planned workspaces require approval: %s", len(review), strings.Join(review, ", ")),
+		})
+	}
+	if !done {
+		return nil
+	}
+	log.Printf("[StackRun] Run %s completed", run.ID)
+	return s.finishRun(run, models.StackRunStatusCompleted, "", nil)
+}
+
+// upstreamOutputsChanged 成员 plan 之后，直接上游的 apply 改变了成员读取的 output 时返回说明
+func (s *StackService) upstreamOutputsChanged(m *models.StackRunMember, members []models.StackRunMember) (string, error) {
+	for _, upstreamID := range m.DependsOn {
+		var upstream *models.StackRunMember
+		for i := range members {
+			if members[i].WorkspaceID == upstreamID {
+				upstream = &members[i]
+				break
+			}
+		}
+		if upstream == nil || upstream.Status != models.StackMemberStatusApplied || upstream.TaskID == nil || upstream.CompletedAt == nil {
+			continue
+		}
+		if m.StartedAt != nil && m.StartedAt.After(*upstream.CompletedAt) {
+			// 已基于 apply 后的 output 重新 plan
+			continue
+		}
+
+		changes := &sourceOutputChanges{db: s.db, task: &models.WorkspaceTask{ID: *upstream.TaskID, WorkspaceID: upstream.WorkspaceID}}
+		changed, _, err := changes.get()
+		if err != nil {
+			return "", fmt.Errorf("failed to compare outputs of %s: %w", upstream.WorkspaceID, err)
+		}
+		if len(changed) == 0 {
+			continue
+		}
+		consumed, err := s.consumedOutputs(m.WorkspaceID, upstream.WorkspaceID)
+		if err != nil {
+			return "", err
+		}
+		var names []string
+		for name := range changed {
+			if consumed == nil || consumed[name] {
+				names = append(names, name)
+			}
+		}
+		if len(names) > 0 {
+			sort.Strings(names)
+			return fmt.Sprintf("outputs of %s changed by apply: %s", upstream.WorkspaceID, strings.Join(names, ", ")), nil
+		}
+	}
+	return "", nil
+}
+
+// consumedOutputs 成员读取的上游 output，nil 表示全部
+// 远程数据和显式依赖读取全部 output；只通过指定了 output 的 outputs_changed 触发器连接时只关注这些 output
+func (s *StackService) consumedOutputs(workspaceID, upstreamID string) (map[string]bool, error) {
+	var remoteData int64
+	if err := s.db.Model(&models.WorkspaceRemoteData{}).
+		Where("workspace_id = ? AND source_workspace_id = ?", workspaceID, upstreamID).
+		Count(&remoteData).Error; err != nil {
+		return nil, err
+	}
+	if remoteData > 0 {
+		return nil, nil
+	}
+
+	var triggers []models.RunTrigger
+	if err := s.db.Where("enabled = ? AND source_workspace_id = ? AND target_workspace_id = ?", true, upstreamID, workspaceID).
+		Find(&triggers).Error; err != nil {
+		return nil, err
+	}
+	if len(triggers) == 0 {
+		return nil, nil
+	}
+	names := make(map[string]bool)
+	for _, trigger := range triggers {
+		if trigger.TriggerCondition != models.TriggerConditionOutputsChanged || len(trigger.OutputNames) == 0 {
+			return nil, nil
+		}
+		for _, name := range trigger.OutputNames {
+			names[name] = true
+		}
+	}
+	return names, nil
+}
+
+// replanMember 丢弃成员基于旧 output 的计划并重新 plan
+func (s *StackService) replanMember(run *models.StackRun, m *models.StackRunMember, reason string) {
+	if m.Status == models.StackMemberStatusPlanned && m.TaskID != nil {
+		discarded, err := s.discardPendingTask(*m.TaskID, "stack run re-plan: "+reason)
+		if err != nil {
+			log.Printf("[StackRun] Run %s failed to discard plan of workspace %s: %v", run.ID, m.WorkspaceID, err)
+			return
+		}
+		if !discarded {
+			return
+		}
+	}
+	log.Printf("[StackRun] Run %s re-planning workspace %s: %s", run.ID, m.WorkspaceID, reason)
+	m.ChangesAdd, m.ChangesChange, m.ChangesDestroy = 0, 0, 0
+	m.Error = ""
+	m.CompletedAt = nil
+	s.startPlan(run, m)
+}
+
+// startPlan 为成员创建 plan_and_apply 任务；plan 完成后任务停在 apply_pending，由 Stack 统一确认
+func (s *StackService) startPlan(run *models.StackRun, m *models.StackRunMember) {
+	now := time.Now()
+	m.StartedAt = &now
+	taskID, err := s.createTask(run, m.WorkspaceID)
+	if err != nil {
+		m.Status = models.StackMemberStatusFailed
+		m.Error = err.Error()
+		m.CompletedAt = &now
+		log.Printf("[StackRun] Run %s failed to start plan for workspace %s: %v", run.ID, m.WorkspaceID, err)
+	} else {
+		m.Status = models.StackMemberStatusPlanning
+		m.TaskID = &taskID
+	}
+	s.saveMember(run, m)
+}
+
+// createTask 创建 plan_and_apply 任务；workspace 被锁定时任务无法执行，直接失败而不是无限等待
+func (s *StackService) createTask(run *models.StackRun, workspaceID string) (uint, error) {
+	var workspace models.Workspace
+	if err := s.db.Select("id, workspace_id, execution_mode, is_locked, lock_reason").
+		Where("workspace_id = ?", workspaceID).First(&workspace).Error; err != nil {
+		return 0, fmt.Errorf("failed to get workspace: %w", err)
+	}
+	if workspace.IsLocked {
+		return 0, fmt.Errorf("workspace is locked: %s", workspace.LockReason)
+	}
+
+	description := fmt.Sprintf("Stack run %s", run.ID)
+	if run.Description != "" {
+		description += ": " + run.Description
+	}
+	createdBy := run.CreatedBy
+	task := &models.WorkspaceTask{
+		WorkspaceID:   workspace.WorkspaceID,
+		TaskType:      models.TaskTypePlanAndApply,
+		Status:        models.TaskStatusPending,
+		ExecutionMode: workspace.ExecutionMode,
+		CreatedBy:     &createdBy,
+		Stage:         "pending",
+		Description:   description,
+	}
+	if err := s.db.Create(task).Error; err != nil {
+		return 0, fmt.Errorf("failed to create task: %w", err)
+	}
+	if s.queueManager != nil {
+		go func() {
+			if err := s.queueManager.TryExecuteNextTask(workspace.WorkspaceID); err != nil {
+				log.Printf("[StackRun] Failed to trigger task execution for workspace %s: %v", workspace.WorkspaceID, err)
+			}
+		}()
+	}
+	return task.ID, nil
+}
+
+// confirmApply 以审核人身份确认成员任务的 Apply（与任务页面确认相同：成本预算检查、记录确认人、审计）
+// 变更冻结期间 apply 由 TaskQueueManager 挂起，成员保持 applying 直到冻结结束
+func (s *StackService) confirmApply(run *models.StackRun, m *models.StackRunMember) {
+	now := time.Now()
+	if err := s.confirmTask(run, *m.TaskID, now); err != nil {
+		m.Status = models.StackMemberStatusFailed
+		m.Error = err.Error()
+		m.CompletedAt = &now
+		log.Printf("[StackRun] Run %s failed to confirm apply for workspace %s: %v", run.ID, m.WorkspaceID, err)
+		s.saveMember(run, m)
+		return
+	}
+	m.Status = models.StackMemberStatusApplying
+	s.saveMember(run, m)
+
+	if s.queueManager != nil {
+		taskID, workspaceID := *m.TaskID, m.WorkspaceID
+		go func() {
+			if err := s.queueManager.ExecuteConfirmedApply(workspaceID, taskID); err != nil {
+				// pending tasks monitor 会重试已确认但未投递的 apply_pending 任务
+				log.Printf("[StackRun] Confirmed apply for task %d not started yet: %v", taskID, err)
+			}
+		}()
+	}
+}
+
+func (s *StackService) confirmTask(run *models.StackRun, taskID uint, now time.Time) error {
+	var task models.WorkspaceTask
+	if err := s.db.Select("id, workspace_id, status, apply_confirmed_by, changes_add, changes_change, changes_destroy").
+		Where("id = ?", taskID).First(&task).Error; err != nil {
+		return fmt.Errorf("failed to get task %d: %w", taskID, err)
+	}
+	if task.Status != models.TaskStatusApplyPending {
+		return fmt.Errorf("task %d is %s, expected apply_pending", task.ID, task.Status)
+	}
+	if err := NewCostEstimationService(s.db).CheckApplyAllowed(task.ID); err != nil {
+		return err
+	}
+	if task.ApplyConfirmedBy != nil {
+		return nil
+	}
+
+	approver := run.CreatedBy
+	if run.ApprovedBy != nil {
+		approver = *run.ApprovedBy
+	}
+	applyDescription := fmt.Sprintf("Stack run %s", run.ID)
+	if run.Description != "" {
+		applyDescription += ": " + run.Description
+	}
+	result := s.db.Model(&models.WorkspaceTask{}).
+		Where("id = ? AND status = ? AND apply_confirmed_by IS NULL", task.ID, models.TaskStatusApplyPending).
+		Updates(map[string]interface{}{
+			"apply_confirmed_by": approver,
+			"apply_confirmed_at": now,
+			"apply_description":  applyDescription,
+			"plan_task_id":       task.ID,
+			"stage":              "apply_pending",
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to confirm task %d: %w", task.ID, result.Error)
+	}
+
+	NewAuditEventService(s.db).Emit(&models.AuditEvent{
+		EventType:    models.AuditEventApplyConfirmed,
+		ActorType:    models.AuditActorUser,
+		ActorID:      approver,
+		ResourceType: "task",
+		ResourceID:   fmt.Sprintf("%d", task.ID),
+		WorkspaceID:  task.WorkspaceID,
+		TaskID:       &task.ID,
+		Message:      fmt.Sprintf("Apply confirmed for task #%d by stack run %s", task.ID, run.ID),
+		Details: models.JSONB{
+			"stack_id":          run.StackID,
+			"stack_run_id":      run.ID,
+			"apply_description": applyDescription,
+			"changes_add":       task.ChangesAdd,
+			"changes_change":    task.ChangesChange,
+			"changes_destroy":   task.ChangesDestroy,
+		},
+	})
+	return nil
+}
+
+// refreshMember 根据任务状态更新成员；执行已结束时新完成的计划直接丢弃
+func (s *StackService) refreshMember(run *models.StackRun, m *models.StackRunMember) error {
+	var task models.WorkspaceTask
+	if err := s.db.Select("id, status, error_message, changes_add, changes_change, changes_destroy").
+		Where("id = ?", *m.TaskID).First(&task).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		task.Status = models.TaskStatusFailed
+		task.ErrorMessage = "task not found"
+	}
+
+	now := time.Now()
+	switch task.Status {
+	case models.TaskStatusFailed, models.TaskStatusCancelled:
+		m.Status = models.StackMemberStatusFailed
+		m.Error = fmt.Sprintf("task %d %s", task.ID, task.Status)
+		if task.ErrorMessage != "" {
+			m.Error += ": " + task.ErrorMessage
+		}
+		m.CompletedAt = &now
+	case models.TaskStatusApplyPending:
+		if m.Status != models.StackMemberStatusPlanning {
+			return nil
+		}
+		m.ChangesAdd, m.ChangesChange, m.ChangesDestroy = task.ChangesAdd, task.ChangesChange, task.ChangesDestroy
+		if !isActiveStackRunStatus(run.Status) {
+			discarded, err := s.discardPendingTask(task.ID, fmt.Sprintf("Stack run %s %s", run.ID, run.Status))
+			if err != nil || !discarded {
+				return err
+			}
+			m.Status = models.StackMemberStatusSkipped
+			m.Error = "plan discarded: stack run " + run.Status
+			m.CompletedAt = &now
+		} else {
+			m.Status = models.StackMemberStatusPlanned
+		}
+	case models.TaskStatusPlannedAndFinished, models.TaskStatusSuccess:
+		if m.Status != models.StackMemberStatusPlanning {
+			return nil
+		}
+		m.Status = models.StackMemberStatusNoChanges
+		m.CompletedAt = &now
+	case models.TaskStatusApplied:
+		if m.Status != models.StackMemberStatusApplying {
+			return nil
+		}
+		m.Status = models.StackMemberStatusApplied
+		m.CompletedAt = &now
+	default:
+		return nil
+	}
+	s.saveMember(run, m)
+	return nil
+}
+
+// failRun 成员失败时停止执行：未开始的成员跳过，已完成 plan 的计划丢弃
+func (s *StackService) failRun(run *models.StackRun, failed *models.StackRunMember) error {
+	message := fmt.Sprintf("Workspace %s failed: %s", failed.WorkspaceID, failed.Error)
+	log.Printf("[StackRun] Run %s stopped: %s", run.ID, message)
+	return s.finishRun(run, models.StackRunStatusFailed, message, failed)
+}
+
+// finishRun 结束执行并记录一条审计事件
+func (s *StackService) finishRun(run *models.StackRun, status, message string, failed *models.StackRunMember) error {
+	if err := s.transition(run, run.Status, map[string]interface{}{
+		"status":       status,
+		"message":      message,
+		"completed_at": time.Now(),
+	}); err != nil {
+		return ignoreStackRunStateError(err)
+	}
+	if status != models.StackRunStatusCompleted {
+		if err := s.stopMembers(run.ID, "stack run stopped: "+message); err != nil {
+			return err
+		}
+	}
+
+	var members []models.StackRunMember
+	if err := s.db.Select("status, changes_add, changes_change, changes_destroy").Where("run_id = ?", run.ID).
+		Find(&members).Error; err != nil {
+		return err
+	}
+	ev := &models.AuditEvent{
+		EventType:    models.AuditEventStackRunFinished,
+		ActorType:    models.AuditActorSystem,
+		ActorID:      "stack-run-worker",
+		ResourceType: "stack_run",
+		ResourceID:   run.ID,
+		Outcome:      models.AuditOutcomeSuccess,
+		Message:      fmt.Sprintf("Stack run %s %s", run.ID, status),
+		Details: models.JSONB{
+			"stack_id": run.StackID,
+			"status":   status,
+			"message":  message,
+			"summary":  summarizeStackRunMembers(members),
+		},
+	}
+	if failed != nil {
+		ev.Outcome = models.AuditOutcomeFailure
+		ev.WorkspaceID = failed.WorkspaceID
+		ev.TaskID = failed.TaskID
+	}
+	NewAuditEventService(s.db).Emit(ev)
+	return nil
+}
+
+// stopMembers 跳过尚未开始的成员，丢弃尚未 Apply 的计划；正在执行的任务继续跟踪
+func (s *StackService) stopMembers(runID, reason string) error {
+	var members []models.StackRunMember
+	if err := s.db.Where("run_id = ? AND status IN ?", runID, []string{
+		models.StackMemberStatusPending, models.StackMemberStatusPlanning, models.StackMemberStatusPlanned,
+	}).Find(&members).Error; err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, m := range members {
+		if m.TaskID != nil {
+			discarded, err := s.discardPendingTask(*m.TaskID, reason)
+			if err != nil {
+				return err
+			}
+			if !discarded && m.Status == models.StackMemberStatusPlanning {
+				// plan 仍在执行，结束后由 refreshMember 丢弃
+				continue
+			}
+		}
+		if err := s.db.Model(&models.StackRunMember{}).Where("id = ?", m.ID).Updates(map[string]interface{}{
+			"status":       models.StackMemberStatusSkipped,
+			"error":        reason,
+			"completed_at": now,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// discardPendingTask 取消尚未执行或等待 Apply 的任务，并释放 plan 完成时加的 workspace 锁（与任务页面取消一致）
+// 任务已在执行时返回 false
+func (s *StackService) discardPendingTask(taskID uint, reason string) (bool, error) {
+	var task models.WorkspaceTask
+	if err := s.db.Select("id, workspace_id, status").Where("id = ?", taskID).First(&task).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return true, nil
+		}
+		return false, err
+	}
+	switch task.Status {
+	case models.TaskStatusPending, models.TaskStatusWaiting, models.TaskStatusApplyPending:
+	case models.TaskStatusRunning:
+		return false, nil
+	default:
+		return true, nil
+	}
+
+	now := time.Now()
+	result := s.db.Model(&models.WorkspaceTask{}).Where("id = ? AND status = ?", task.ID, task.Status).Updates(map[string]interface{}{
+		"status":        models.TaskStatusCancelled,
+		"error_message": reason,
+		"completed_at":  now,
+	})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		// 状态被并发修改（如刚开始执行），下次推进时再处理
+		return false, nil
+	}
+
+	var workspace models.Workspace
+	if err := s.db.Select("id, is_locked, lock_reason").Where("workspace_id = ?", task.WorkspaceID).First(&workspace).Error; err == nil &&
+		workspace.IsLocked && strings.Contains(workspace.LockReason, fmt.Sprintf("task #%d", task.ID)) {
+		if err := s.db.Model(&models.Workspace{}).Where("id = ?", workspace.ID).Updates(map[string]interface{}{
+			"is_locked":   false,
+			"locked_by":   nil,
+			"locked_at":   nil,
+			"lock_reason": "",
+		}).Error; err != nil {
+			log.Printf("[StackRun] Failed to unlock workspace %s: %v", task.WorkspaceID, err)
+		}
+	}
+	if s.queueManager != nil {
+		go func() {
+			if err := s.queueManager.TryExecuteNextTask(task.WorkspaceID); err != nil {
+				log.Printf("[StackRun] Failed to trigger task execution for workspace %s: %v", task.WorkspaceID, err)
+			}
+		}()
+	}
+	return true, nil
+}
+
+func (s *StackService) saveMember(run *models.StackRun, m *models.StackRunMember) {
+	if err := s.db.Model(&models.StackRunMember{}).Where("id = ?", m.ID).Updates(map[string]interface{}{
+		"status":          m.Status,
+		"task_id":         m.TaskID,
+		"changes_add":     m.ChangesAdd,
+		"changes_change":  m.ChangesChange,
+		"changes_destroy": m.ChangesDestroy,
+		"error":           m.Error,
+		"started_at":      m.StartedAt,
+		"completed_at":    m.CompletedAt,
+	}).Error; err != nil {
+		log.Printf("[StackRun] Failed to save member %s of run %s: %v", m.WorkspaceID, run.ID, err)
+	}
+}
+
+// advance 在执行仍处于 from 状态时更新（API 的取消优先）
+func (s *StackService) advance(run *models.StackRun, from string, updates map[string]interface{}) error {
+	return ignoreStackRunStateError(s.transition(run, from, updates))
+}
+
+// transition 仅当执行处于 from 状态时更新，成功后同步到 run
+func (s *StackService) transition(run *models.StackRun, from string, updates map[string]interface{}) error {
+	if run.Status != from {
+		return fmt.Errorf("%w: run is %s", ErrStackRunState, run.Status)
+	}
+	result := s.db.Model(&models.StackRun{}).Where("id = ? AND status = ?", run.ID, from).Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: run status changed concurrently", ErrStackRunState)
+	}
+	return s.db.Where("id = ?", run.ID).First(run).Error
+}
+
+func (s *StackService) load(stackID string) (*models.Stack, error) {
+	var stack models.Stack
+	if err := s.db.Where("id = ?", stackID).First(&stack).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrStackNotFound
+		}
+		return nil, err
+	}
+	return &stack, nil
+}
+
+func (s *StackService) loadRun(stackID, runID string) (*models.StackRun, error) {
+	var run models.StackRun
+	if err := s.db.Where("id = ? AND stack_id = ?", runID, stackID).First(&run).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrStackRunNotFound
+		}
+		return nil, err
+	}
+	return &run, nil
+}
+
+func (s *StackService) loadMembers(stackID string) ([]models.StackMember, error) {
+	var members []models.StackMember
+	if err := s.db.Where("stack_id = ?", stackID).Order("id").Find(&members).Error; err != nil {
+		return nil, err
+	}
+	workspaceIDs := make([]string, 0, len(members))
+	for _, m := range members {
+		workspaceIDs = append(workspaceIDs, m.WorkspaceID)
+	}
+	names, err := s.workspaceNames(workspaceIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range members {
+		members[i].WorkspaceName = names[members[i].WorkspaceID]
+	}
+	return members, nil
+}
+
+func (s *StackService) workspaceNames(workspaceIDs []string) (map[string]string, error) {
+	names := make(map[string]string, len(workspaceIDs))
+	if len(workspaceIDs) == 0 {
+		return names, nil
+	}
+	var workspaces []models.Workspace
+	if err := s.db.Select("workspace_id, name").Where("workspace_id IN ?", workspaceIDs).Find(&workspaces).Error; err != nil {
+		return nil, err
+	}
+	for _, ws := range workspaces {
+		names[ws.WorkspaceID] = ws.Name
+	}
+	return names, nil
+}
+
+// validateRequest 校验名称和成员（成员唯一、workspace 存在、显式依赖只能指向其他成员），并确认依赖图无环
+func (s *StackService) validateRequest(req *models.StackRequest) ([]models.StackMember, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > 100 {
+		return nil, fmt.Errorf("%w: name must be 1-100 characters", ErrStackInvalid)
+	}
+	if len(req.Members) == 0 {
+		return nil, fmt.Errorf("%w: at least one member workspace is required", ErrStackInvalid)
+	}
+
+	members := make([]models.StackMember, 0, len(req.Members))
+	isMember := make(map[string]bool, len(req.Members))
+	for _, m := range req.Members {
+		wsID := strings.TrimSpace(m.WorkspaceID)
+		if wsID == "" {
+			return nil, fmt.Errorf("%w: member workspace_id is required", ErrStackInvalid)
+		}
+		if isMember[wsID] {
+			return nil, fmt.Errorf("%w: workspace %s is listed twice", ErrStackInvalid, wsID)
+		}
+		isMember[wsID] = true
+		members = append(members, models.StackMember{WorkspaceID: wsID})
+	}
+	for i, m := range req.Members {
+		dependsOn := models.StringArray{}
+		for _, upstream := range m.DependsOn {
+			upstream = strings.TrimSpace(upstream)
+			switch {
+			case upstream == members[i].WorkspaceID:
+				return nil, fmt.Errorf("%w: workspace %s cannot depend on itself", ErrStackInvalid, upstream)
+			case !isMember[upstream]:
+				return nil, fmt.Errorf("%w: %s depends on %s which is not a member", ErrStackInvalid, members[i].WorkspaceID, upstream)
+			}
+			dependsOn = appendUnique(dependsOn, upstream)
+		}
+		members[i].DependsOn = dependsOn
+	}
+
+	var found []string
+	if err := s.db.Model(&models.Workspace{}).Where("workspace_id IN ?", sortedKeys(isMember)).
+		Pluck("workspace_id", &found).Error; err != nil {
+		return nil, err
+	}
+	if len(found) != len(isMember) {
+		exists := make(map[string]bool, len(found))
+		for _, id := range found {
+			exists[id] = true
+		}
+		var missing []string
+		for _, m := range members {
+			if !exists[m.WorkspaceID] {
+				missing = append(missing, m.WorkspaceID)
+			}
+		}
+		return nil, fmt.Errorf("%w: workspaces not found: %s", ErrStackInvalid, strings.Join(missing, ", "))
+	}
+
+	if _, err := s.BuildGraph(members); err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+func checkStackNameAvailable(tx *gorm.DB, name, excludeID string) error {
+	query := tx.Model(&models.Stack{}).Where("name = ?", name)
+	if excludeID != "" {
+		query = query.Where("id <> ?", excludeID)
+	}
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: a stack named %q already exists", ErrStackInvalid, name)
+	}
+	return nil
+}
+
+func replaceStackMembers(tx *gorm.DB, stackID string, members []models.StackMember) error {
+	if err := tx.Where("stack_id = ?", stackID).Delete(&models.StackMember{}).Error; err != nil {
+		return err
+	}
+	for i := range members {
+		members[i].ID = 0
+		members[i].StackID = stackID
+	}
+	return tx.Create(&members).Error
+}
+
+// stackLayers 按依赖做拓扑分层（From 依赖 To，To 在更早的层）；层内保持成员顺序，成环时返回错误
+func stackLayers(ids []string, edges []models.StackEdge) ([][]string, error) {
+	upstreams := make(map[string]map[string]bool, len(ids))
+	for _, edge := range edges {
+		if upstreams[edge.From] == nil {
+			upstreams[edge.From] = make(map[string]bool)
+		}
+		upstreams[edge.From][edge.To] = true
+	}
+
+	placed := make(map[string]bool, len(ids))
+	layers := [][]string{}
+	for len(placed) < len(ids) {
+		var layer []string
+		for _, id := range ids {
+			if placed[id] {
+				continue
+			}
+			ready := true
+			for upstream := range upstreams[id] {
+				if !placed[upstream] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				layer = append(layer, id)
+			}
+		}
+		if len(layer) == 0 {
+			var cycle []string
+			for _, id := range ids {
+				if !placed[id] {
+					cycle = append(cycle, id)
+				}
+			}
+			return nil, fmt.Errorf("%w: dependency cycle between %s", ErrStackInvalid, strings.Join(cycle, ", "))
+		}
+		for _, id := range layer {
+			placed[id] = true
+		}
+		layers = append(layers, layer)
+	}
+	return layers, nil
+}
+
+// upstreamsIn 成员的所有上游（含间接上游）都处于给定状态之一
+// 间接上游也要检查：中间成员无变更时，下游仍需等待更上游的成员完成
+func upstreamsIn(m *models.StackRunMember, members []models.StackRunMember, allowed ...string) bool {
+	byWorkspace := make(map[string]*models.StackRunMember, len(members))
+	for i := range members {
+		byWorkspace[members[i].WorkspaceID] = &members[i]
+	}
+	visited := make(map[string]bool)
+	queue := append([]string{}, m.DependsOn...)
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if visited[id] {
+			continue
+		}
+		visited[id] = true
+		upstream, ok := byWorkspace[id]
+		if !ok {
+			continue
+		}
+		ready := false
+		for _, status := range allowed {
+			if upstream.Status == status {
+				ready = true
+				break
+			}
+		}
+		if !ready {
+			return false
+		}
+		queue = append(queue, upstream.DependsOn...)
+	}
+	return true
+}
+
+func summarizeStackRunMembers(members []models.StackRunMember) *models.StackRunSummary {
+	summary := &models.StackRunSummary{Total: len(members)}
+	for _, m := range members {
+		switch m.Status {
+		case models.StackMemberStatusPending:
+			summary.Pending++
+		case models.StackMemberStatusPlanning:
+			summary.Planning++
+		case models.StackMemberStatusPlanned:
+			summary.Planned++
+		case models.StackMemberStatusNoChanges:
+			summary.NoChanges++
+		case models.StackMemberStatusApplying:
+			summary.Applying++
+		case models.StackMemberStatusApplied:
+			summary.Applied++
+		case models.StackMemberStatusFailed:
+			summary.Failed++
+		case models.StackMemberStatusSkipped:
+			summary.Skipped++
+		}
+		summary.ChangesAdd += m.ChangesAdd
+		summary.ChangesChange += m.ChangesChange
+		summary.ChangesDestroy += m.ChangesDestroy
+	}
+	return summary
+}
+
+func appendUnique(list []string, value string) []string {
+	for _, v := range list {
+		if v == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
+
+var activeStackRunStatuses = []string{
+	models.StackRunStatusPlanning,
+	models.StackRunStatusAwaitingApproval,
+	models.StackRunStatusApplying,
+}
+
+// inFlightStackMemberStatuses 任务可能仍在执行或等待 Apply 的成员状态
+var inFlightStackMemberStatuses = []string{
+	models.StackMemberStatusPlanning,
+	models.StackMemberStatusPlanned,
+	models.StackMemberStatusApplying,
+}
+
+func isActiveStackRunStatus(status string) bool {
+	for _, s := range activeStackRunStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// ignoreStackRunStateError worker 推进时执行状态被 API 并发修改属于正常情况
+func ignoreStackRunStateError(err error) error {
+	if errors.Is(err, ErrStackRunState) {
+		return nil
+	}
+	return err
+}
+
+// StackRunWorker 后台推进 Stack 执行（仅 leader 运行）
+type StackRunWorker struct {
+	service *StackService
+}
+
+// NewStackRunWorker 创建 Stack 执行 worker
+func NewStackRunWorker(db *gorm.DB, queueManager stackTaskQueue) *StackRunWorker {
+	service := NewStackService(db)
+	if queueManager != nil {
+		service.SetQueueManager(queueManager)
+	}
+	return &StackRunWorker{service: service}
+}
+
+// Start 立即处理一次（接管上一个 leader 未完成的执行），之后按间隔推进
+func (w *StackRunWorker) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Printf("[StackRun] Worker started (interval %v)", interval)
+	w.service.ProcessAll(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("[StackRun] Worker stopped")
+			return
+		case <-ticker.C:
+			w.service.ProcessAll(ctx)
+		}
+	}
+}
@@ -0,0 +1,310 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"iac-platform/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func setupStackTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := setupTestDB(t)
+	statements := []string{
+		`CREATE TABLE stacks (id TEXT PRIMARY KEY, name TEXT UNIQUE, description TEXT, created_by TEXT, updated_by TEXT,
+			created_at DATETIME, updated_at DATETIME)`,
+		`CREATE TABLE stack_members (id INTEGER PRIMARY KEY AUTOINCREMENT, stack_id TEXT, workspace_id TEXT, depends_on BLOB,
+			created_at DATETIME)`,
+		`CREATE TABLE stack_runs (id TEXT PRIMARY KEY, stack_id TEXT, status TEXT DEFAULT 'planning', description TEXT, message TEXT,
+			created_by TEXT, approved_by TEXT, approved_at DATETIME, completed_at DATETIME, created_at DATETIME, updated_at DATETIME)`,
+		`CREATE TABLE stack_run_members (id INTEGER PRIMARY KEY AUTOINCREMENT, run_id TEXT, workspace_id TEXT, layer INTEGER,
+			depends_on BLOB, status TEXT DEFAULT 'pending', task_id INTEGER, changes_add INTEGER DEFAULT 0,
+			changes_change INTEGER DEFAULT 0, changes_destroy INTEGER DEFAULT 0, error TEXT, started_at DATETIME, completed_at DATETIME)`,
+		`CREATE TABLE workspace_remote_data (
+			id INTEGER PRIMARY KEY AUTOINCREMENT, workspace_id TEXT NOT NULL, remote_data_id TEXT,
+			source_workspace_id TEXT NOT NULL, data_name TEXT NOT NULL, description TEXT,
+			created_by TEXT, created_at DATETIME, updated_at DATETIME)`,
+		`CREATE TABLE workspace_task_resource_changes (
+			id INTEGER PRIMARY KEY AUTOINCREMENT, task_id INTEGER NOT NULL, workspace_id TEXT NOT NULL,
+			created_at DATETIME, updated_at DATETIME, resource_address TEXT NOT NULL, resource_type TEXT NOT NULL,
+			resource_name TEXT NOT NULL, module_address TEXT, action TEXT NOT NULL, changes_before BLOB, changes_after BLOB,
+			apply_status TEXT, apply_started_at DATETIME, apply_completed_at DATETIME, apply_error TEXT,
+			resource_id TEXT, resource_attributes BLOB)`,
+		`CREATE TABLE run_triggers (
+			id INTEGER PRIMARY KEY AUTOINCREMENT, source_workspace_id TEXT NOT NULL, target_workspace_id TEXT NOT NULL,
+			enabled INTEGER DEFAULT 1, trigger_condition TEXT, created_at DATETIME, updated_at DATETIME, created_by TEXT)`,
+		`CREATE TABLE workspace_state_versions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT, workspace_id TEXT NOT NULL, created_by TEXT, created_at DATETIME,
+			content BLOB NOT NULL, version INTEGER NOT NULL, checksum TEXT NOT NULL DEFAULT '', size_bytes INTEGER,
+			lineage TEXT, serial INTEGER, is_imported INTEGER DEFAULT 0, import_source TEXT, is_rollback INTEGER DEFAULT 0,
+			rollback_from_version INTEGER, description TEXT, task_id INTEGER, resource_count INTEGER DEFAULT 0)`,
+		`INSERT INTO workspaces (workspace_id, name, execution_mode) VALUES
+			('ws-network', 'network', 'local'), ('ws-cluster', 'cluster', 'local'),
+			('ws-apps', 'apps', 'local'), ('ws-tools', 'tools', 'local')`,
+		// cluster 读取 network 的输出；cluster apply 后触发 apps
+		`INSERT INTO workspace_remote_data (workspace_id, remote_data_id, source_workspace_id, data_name) VALUES ('ws-cluster', 'rd-1', 'ws-network', 'network')`,
+		`INSERT INTO run_triggers (source_workspace_id, target_workspace_id, enabled) VALUES ('ws-cluster', 'ws-apps', 1), ('ws-apps', 'ws-network', 0)`,
+	}
+	for _, stmt := range statements {
+		require.NoError(t, db.Exec(stmt).Error)
+	}
+	return db
+}
+
+func createTestStack(t *testing.T, db *gorm.DB) *models.Stack {
+	t.Helper()
+	stack, err := NewStackService(db).Create(&models.StackRequest{
+		Name: "platform",
+		Members: []models.StackMemberRequest{
+			{WorkspaceID: "ws-apps"},
+			{WorkspaceID: "ws-cluster"},
+			{WorkspaceID: "ws-network"},
+			{WorkspaceID: "ws-tools", DependsOn: []string{"ws-network"}},
+		},
+	}, "u-1")
+	require.NoError(t, err)
+	return stack
+}
+
+func loadStackRunMembers(t *testing.T, db *gorm.DB, runID string) map[string]models.StackRunMember {
+	t.Helper()
+	var members []models.StackRunMember
+	require.NoError(t, db.Where("run_id = ?", runID).Find(&members).Error)
+	byWorkspace := make(map[string]models.StackRunMember, len(members))
+	for _, m := range members {
+		byWorkspace[m.WorkspaceID] = m
+	}
+	return byWorkspace
+}
+
+// setStackMemberTask 模拟成员任务的执行结果
+func setStackMemberTask(t *testing.T, db *gorm.DB, runID, workspaceID string, status models.TaskStatus, changes int) uint {
+	t.Helper()
+	m := loadStackRunMembers(t, db, runID)[workspaceID]
+	require.NotNil(t, m.TaskID, workspaceID)
+	require.NoError(t, db.Exec(`UPDATE workspace_tasks SET status = ?, changes_add = ? WHERE id = ?`, status, changes, *m.TaskID).Error)
+	return *m.TaskID
+}
+
+func insertStackStateVersion(t *testing.T, db *gorm.DB, workspaceID string, version int, taskID uint, outputs map[string]interface{}) {
+	t.Helper()
+	stateOutputs := make(map[string]interface{}, len(outputs))
+	for name, value := range outputs {
+		stateOutputs[name] = map[string]interface{}{"value": value, "type": "string"}
+	}
+	content, err := json.Marshal(map[string]interface{}{"version": 4, "outputs": stateOutputs})
+	require.NoError(t, err)
+	require.NoError(t, db.Exec(`INSERT INTO workspace_state_versions (workspace_id, content, version, task_id) VALUES (?, ?, ?, ?)`,
+		workspaceID, content, version, taskID).Error)
+}
+
+func TestStackService_Graph(t *testing.T) {
+	db := setupStackTestDB(t)
+	svc := NewStackService(db)
+	stack := createTestStack(t, db)
+
+	require.NotNil(t, stack.Graph)
+	assert.Equal(t, [][]string{{"ws-network"}, {"ws-cluster", "ws-tools"}, {"ws-apps"}}, stack.Graph.Layers)
+	assert.ElementsMatch(t, []models.StackEdge{
+		{From: "ws-tools", To: "ws-network", Type: models.StackEdgeExplicit},
+		{From: "ws-cluster", To: "ws-network", Type: models.StackEdgeRemoteData, Detail: "network"},
+		{From: "ws-apps", To: "ws-cluster", Type: models.StackEdgeRunTrigger},
+	}, stack.Graph.Edges)
+
+	tests := []struct {
+		name    string
+		members []models.StackMemberRequest
+	}{
+		{"unknown workspace", []models.StackMemberRequest{{WorkspaceID: "ws-missing"}}},
+		{"duplicate member", []models.StackMemberRequest{{WorkspaceID: "ws-apps"}, {WorkspaceID: "ws-apps"}}},
+		{"self dependency", []models.StackMemberRequest{{WorkspaceID: "ws-apps", DependsOn: []string{"ws-apps"}}}},
+		{"non-member dependency", []models.StackMemberRequest{{WorkspaceID: "ws-apps", DependsOn: []string{"ws-tools"}}}},
+		{"cycle", []models.StackMemberRequest{{WorkspaceID: "ws-network", DependsOn: []string{"ws-cluster"}}, {WorkspaceID: "ws-cluster"}}},
+	}
+	for _, tt := range tests {
+		_, err := svc.Create(&models.StackRequest{Name: "invalid", Members: tt.members}, "u-1")
+		assert.ErrorIs(t, err, ErrStackInvalid, tt.name)
+	}
+
+	// 之后新增的 Run Trigger 使依赖成环：Stack 仍可查看，但不能发起执行
+	require.NoError(t, db.Exec(`UPDATE run_triggers SET enabled = 1 WHERE source_workspace_id = 'ws-apps'`).Error)
+	stack, err := svc.Get(stack.ID)
+	require.NoError(t, err)
+	assert.Nil(t, stack.Graph)
+	assert.Contains(t, stack.GraphError, "dependency cycle")
+	_, err = svc.CreateRun(stack.ID, &models.CreateStackRunRequest{}, "u-1")
+	assert.ErrorIs(t, err, ErrStackInvalid)
+}
+
+func TestStackService_RunPlansReviewsAndAppliesInOrder(t *testing.T) {
+	db := setupStackTestDB(t)
+	svc := NewStackService(db)
+	stack := createTestStack(t, db)
+
+	run, err := svc.CreateRun(stack.ID, &models.CreateStackRunRequest{Description: "bump versions"}, "u-1")
+	require.NoError(t, err)
+	assert.Equal(t, models.StackRunStatusPlanning, run.Status)
+
+	_, err = svc.CreateRun(stack.ID, &models.CreateStackRunRequest{}, "u-1")
+	assert.ErrorIs(t, err, ErrStackRunState, "one active run per stack")
+
+	// 第一层先 plan
+	require.NoError(t, svc.Process(run.ID))
+	members := loadStackRunMembers(t, db, run.ID)
+	assert.Equal(t, models.StackMemberStatusPlanning, members["ws-network"].Status)
+	assert.Equal(t, models.StackMemberStatusPending, members["ws-cluster"].Status)
+	var task models.WorkspaceTask
+	require.NoError(t, db.First(&task, *members["ws-network"].TaskID).Error)
+	assert.Equal(t, models.TaskTypePlanAndApply, task.TaskType)
+	assert.Equal(t, "Stack run "+run.ID+": bump versions", task.Description)
+
+	networkTask := setStackMemberTask(t, db, run.ID, "ws-network", models.TaskStatusApplyPending, 2)
+	require.NoError(t, svc.Process(run.ID))
+	members = loadStackRunMembers(t, db, run.ID)
+	assert.Equal(t, models.StackMemberStatusPlanned, members["ws-network"].Status)
+	assert.Equal(t, 2, members["ws-network"].ChangesAdd)
+	assert.Equal(t, models.StackMemberStatusPlanning, members["ws-cluster"].Status)
+	assert.Equal(t, models.StackMemberStatusPlanning, members["ws-tools"].Status)
+
+	setStackMemberTask(t, db, run.ID, "ws-cluster", models.TaskStatusPlannedAndFinished, 0)
+	setStackMemberTask(t, db, run.ID, "ws-tools", models.TaskStatusPlannedAndFinished, 0)
+	require.NoError(t, svc.Process(run.ID))
+	appsTask := setStackMemberTask(t, db, run.ID, "ws-apps", models.TaskStatusApplyPending, 1)
+	require.NoError(t, svc.Process(run.ID))
+
+	review, err := svc.GetRunReview(stack.ID, run.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.StackRunStatusAwaitingApproval, review.Status)
+	assert.Equal(t, 2, review.Summary.Planned)
+	assert.Equal(t, 2, review.Summary.NoChanges)
+	assert.Equal(t, 3, review.Summary.ChangesAdd)
+
+	// 成员任务不能在任务页面单独确认；成员之间的 Run Trigger 由 Stack 编排
+	active, err := svc.ActiveRunForTask(appsTask)
+	require.NoError(t, err)
+	require.NotNil(t, active)
+	assert.Equal(t, run.ID, active.ID)
+	peers, err := svc.RunPeers(networkTask)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"ws-cluster": true, "ws-apps": true, "ws-tools": true}, peers)
+
+	_, err = svc.ApproveRun(stack.ID, run.ID, "u-2")
+	require.NoError(t, err)
+
+	// network 先 apply；apps 的直接上游 cluster 无变更，但仍要等待间接上游 network
+	require.NoError(t, svc.Process(run.ID))
+	members = loadStackRunMembers(t, db, run.ID)
+	assert.Equal(t, models.StackMemberStatusApplying, members["ws-network"].Status)
+	assert.Equal(t, models.StackMemberStatusPlanned, members["ws-apps"].Status)
+	require.NoError(t, db.First(&task, networkTask).Error)
+	require.NotNil(t, task.ApplyConfirmedBy)
+	assert.Equal(t, "u-2", *task.ApplyConfirmedBy)
+	assert.Equal(t, networkTask, *task.PlanTaskID)
+
+	// network 的 apply 改变了 output：cluster（远程数据）和 tools（显式依赖）的计划基于旧 output，
+	// 重新 plan；apps 等待 cluster，不能提前 apply
+	insertStackStateVersion(t, db, "ws-network", 1, 0, map[string]interface{}{"vpc_id": "vpc-old"})
+	insertStackStateVersion(t, db, "ws-network", 2, networkTask, map[string]interface{}{"vpc_id": "vpc-new"})
+	oldClusterTask := *members["ws-cluster"].TaskID
+	setStackMemberTask(t, db, run.ID, "ws-network", models.TaskStatusApplied, 2)
+	require.NoError(t, svc.Process(run.ID))
+	members = loadStackRunMembers(t, db, run.ID)
+	assert.Equal(t, models.StackMemberStatusApplied, members["ws-network"].Status)
+	assert.Equal(t, models.StackMemberStatusPlanning, members["ws-cluster"].Status)
+	assert.NotEqual(t, oldClusterTask, *members["ws-cluster"].TaskID)
+	assert.Equal(t, models.StackMemberStatusPlanning, members["ws-tools"].Status)
+	assert.Equal(t, models.StackMemberStatusPlanned, members["ws-apps"].Status)
+
+	// 重新 plan 的变更需要再次审核
+	setStackMemberTask(t, db, run.ID, "ws-cluster", models.TaskStatusApplyPending, 1)
+	setStackMemberTask(t, db, run.ID, "ws-tools", models.TaskStatusPlannedAndFinished, 0)
+	require.NoError(t, svc.Process(run.ID))
+	got, err := svc.GetRun(stack.ID, run.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.StackRunStatusAwaitingApproval, got.Status)
+	assert.Contains(t, got.Message, "ws-cluster")
+	members = loadStackRunMembers(t, db, run.ID)
+	assert.Equal(t, models.StackMemberStatusPlanned, members["ws-cluster"].Status)
+	assert.Equal(t, models.StackMemberStatusNoChanges, members["ws-tools"].Status)
+	assert.Equal(t, models.StackMemberStatusPlanned, members["ws-apps"].Status)
+
+	_, err = svc.ApproveRun(stack.ID, run.ID, "u-2")
+	require.NoError(t, err)
+	require.NoError(t, svc.Process(run.ID))
+	members = loadStackRunMembers(t, db, run.ID)
+	assert.Equal(t, models.StackMemberStatusApplying, members["ws-cluster"].Status)
+	assert.Equal(t, models.StackMemberStatusPlanned, members["ws-apps"].Status)
+
+	// cluster 的 apply 没有产生新的 State 版本，apps 直接 apply 已审核的计划
+	setStackMemberTask(t, db, run.ID, "ws-cluster", models.TaskStatusApplied, 1)
+	require.NoError(t, svc.Process(run.ID))
+	members = loadStackRunMembers(t, db, run.ID)
+	assert.Equal(t, models.StackMemberStatusApplied, members["ws-cluster"].Status)
+	assert.Equal(t, models.StackMemberStatusApplying, members["ws-apps"].Status)
+	assert.Equal(t, appsTask, *members["ws-apps"].TaskID)
+
+	setStackMemberTask(t, db, run.ID, "ws-apps", models.TaskStatusApplied, 1)
+	require.NoError(t, svc.Process(run.ID))
+	got, err = svc.GetRun(stack.ID, run.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.StackRunStatusCompleted, got.Status)
+	assert.NotNil(t, got.CompletedAt)
+
+	active, err = svc.ActiveRunForTask(appsTask)
+	require.NoError(t, err)
+	assert.Nil(t, active)
+}
+
+func TestStackService_RunStopsOnFailure(t *testing.T) {
+	db := setupStackTestDB(t)
+	svc := NewStackService(db)
+	stack := createTestStack(t, db)
+
+	run, err := svc.CreateRun(stack.ID, &models.CreateStackRunRequest{}, "u-1")
+	require.NoError(t, err)
+	require.NoError(t, svc.Process(run.ID))
+	networkTask := setStackMemberTask(t, db, run.ID, "ws-network", models.TaskStatusApplyPending, 1)
+	require.NoError(t, db.Exec(`UPDATE workspaces SET is_locked = 1, lock_reason = ? WHERE workspace_id = 'ws-network'`,
+		fmt.Sprintf("Locked for apply (task #%d). Do not modify resources/variables until apply completes.", networkTask)).Error)
+	require.NoError(t, svc.Process(run.ID))
+
+	// cluster plan 失败：执行停止，network 的计划被丢弃并解锁，apps 跳过；tools 的 plan 仍在执行，结束后丢弃
+	setStackMemberTask(t, db, run.ID, "ws-cluster", models.TaskStatusFailed, 0)
+	require.NoError(t, db.Exec(`UPDATE workspace_tasks SET status = ? WHERE id = ?`,
+		models.TaskStatusRunning, *loadStackRunMembers(t, db, run.ID)["ws-tools"].TaskID).Error)
+	require.NoError(t, svc.Process(run.ID))
+
+	got, err := svc.GetRun(stack.ID, run.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.StackRunStatusFailed, got.Status)
+	assert.Contains(t, got.Message, "ws-cluster")
+
+	members := loadStackRunMembers(t, db, run.ID)
+	assert.Equal(t, models.StackMemberStatusSkipped, members["ws-network"].Status)
+	assert.Equal(t, models.StackMemberStatusFailed, members["ws-cluster"].Status)
+	assert.Equal(t, models.StackMemberStatusSkipped, members["ws-apps"].Status)
+	assert.Equal(t, models.StackMemberStatusPlanning, members["ws-tools"].Status)
+
+	var task models.WorkspaceTask
+	require.NoError(t, db.First(&task, networkTask).Error)
+	assert.Equal(t, models.TaskStatusCancelled, task.Status)
+	var workspace models.Workspace
+	require.NoError(t, db.Where("workspace_id = ?", "ws-network").First(&workspace).Error)
+	assert.False(t, workspace.IsLocked)
+
+	setStackMemberTask(t, db, run.ID, "ws-tools", models.TaskStatusApplyPending, 1)
+	svc.ProcessAll(t.Context())
+	assert.Equal(t, models.StackMemberStatusSkipped, loadStackRunMembers(t, db, run.ID)["ws-tools"].Status)
+	var toolsTask models.WorkspaceTask
+	require.NoError(t, db.First(&toolsTask, *members["ws-tools"].TaskID).Error)
+	assert.Equal(t, models.TaskStatusCancelled, toolsTask.Status)
+
+	// 结束后可以重新发起
+	_, err = svc.CreateRun(stack.ID, &models.CreateStackRunRequest{}, "u-1")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,247 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"iac-platform/internal/models"
+)
+
+// 静态分析在 terraform plan 之前运行，对 GenerateConfigFilesWithLogging 生成的
+// *.tf.json 做检查。每个分析器的结果统一转换为 SARIF-like 结构并按任务保存；
+// mandatory 分析器失败（或自身执行出错）时与 mandatory Run Task 失败一样阻断 plan。
+
+const defaultStaticAnalyzerTimeout = 5 * time.Minute
+
+// StaticAnalysisConfig workspace 的静态分析配置（存储在 workspaces.static_analysis_config）
+type StaticAnalysisConfig struct {
+	Enabled   bool                   `json:"enabled"`
+	Analyzers []StaticAnalyzerConfig `json:"analyzers"`
+}
+
+// StaticAnalyzerConfig 单个分析器配置
+type StaticAnalyzerConfig struct {
+	Name             string                         `json:"name"`                      // builtin, tflint, trivy, checkov
+	EnforcementLevel models.RunTaskEnforcementLevel `json:"enforcement_level"`         // advisory（默认）或 mandatory
+	BlockingLevel    string                         `json:"blocking_level"`            // error（默认）、warning、note
+	Args             []string                       `json:"args,omitempty"`            // 追加给外部工具的参数
+	DisabledRules    []string                       `json:"disabled_rules,omitempty"`  // 忽略的规则 ID
+	TimeoutSeconds   int                            `json:"timeout_seconds,omitempty"` // 默认 300 秒
+}
+
+// StaticAnalyzer 分析器接口
+// Analyze 在 workDir 中检查生成的配置，返回 SARIF-like 结果；返回 error 表示分析器本身执行失败
+type StaticAnalyzer interface {
+	Analyze(ctx context.Context, workDir string, cfg StaticAnalyzerConfig) (*models.SARIFRun, error)
+}
+
+var (
+	staticAnalyzersMu sync.RWMutex
+	staticAnalyzers   = map[string]StaticAnalyzer{
+		"builtin": builtinStaticAnalyzer{},
+		"tflint":  tflintAnalyzer,
+		"trivy":   trivyAnalyzer,
+		"checkov": checkovAnalyzer,
+	}
+)
+
+// RegisterStaticAnalyzer 注册（或替换）分析器
+func RegisterStaticAnalyzer(name string, analyzer StaticAnalyzer) {
+	staticAnalyzersMu.Lock()
+	defer staticAnalyzersMu.Unlock()
+	staticAnalyzers[name] = analyzer
+}
+
+func getStaticAnalyzer(name string) (StaticAnalyzer, bool) {
+	staticAnalyzersMu.RLock()
+	defer staticAnalyzersMu.RUnlock()
+	analyzer, ok := staticAnalyzers[name]
+	return analyzer, ok
+}
+
+// ParseStaticAnalysisConfig 解析并校验 workspace 的静态分析配置
+// 配置为空时返回未启用的配置
+func ParseStaticAnalysisConfig(raw map[string]interface{}) (*StaticAnalysisConfig, error) {
+	cfg := &StaticAnalysisConfig{}
+	if len(raw) == 0 {
+		return cfg, nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid static analysis config: %w", err)
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("invalid static analysis config: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	for i := range cfg.Analyzers {
+		a := &cfg.Analyzers[i]
+		if _, ok := getStaticAnalyzer(a.Name); !ok {
+			return nil, fmt.Errorf("unknown static analyzer: %q", a.Name)
+		}
+		if seen[a.Name] {
+			return nil, fmt.Errorf("static analyzer %q configured more than once", a.Name)
+		}
+		seen[a.Name] = true
+
+		switch a.EnforcementLevel {
+		case "":
+			a.EnforcementLevel = models.RunTaskEnforcementAdvisory
+		case models.RunTaskEnforcementAdvisory, models.RunTaskEnforcementMandatory:
+		default:
+			return nil, fmt.Errorf("static analyzer %q: invalid enforcement_level %q", a.Name, a.EnforcementLevel)
+		}
+
+		if a.BlockingLevel == "" {
+			a.BlockingLevel = models.SARIFLevelError
+		}
+		if sarifLevelRank(a.BlockingLevel) == 0 {
+			return nil, fmt.Errorf("static analyzer %q: invalid blocking_level %q", a.Name, a.BlockingLevel)
+		}
+
+		if a.TimeoutSeconds < 0 || a.TimeoutSeconds > 3600 {
+			return nil, fmt.Errorf("static analyzer %q: timeout_seconds must be between 0 and 3600", a.Name)
+		}
+	}
+
+	return cfg, nil
+}
+
+// sarifLevelRank 返回级别的严重程度，未知级别返回 0
+func sarifLevelRank(level string) int {
+	switch level {
+	case models.SARIFLevelNote:
+		return 1
+	case models.SARIFLevelWarning:
+		return 2
+	case models.SARIFLevelError:
+		return 3
+	}
+	return 0
+}
+
+// RunStaticAnalysis 依次运行配置的分析器，每个分析器生成一条结果
+func RunStaticAnalysis(ctx context.Context, workDir string, task *models.WorkspaceTask, cfg *StaticAnalysisConfig) []models.TaskStaticAnalysisResult {
+	results := make([]models.TaskStaticAnalysisResult, 0, len(cfg.Analyzers))
+	for _, analyzerCfg := range cfg.Analyzers {
+		if ctx.Err() != nil {
+			break
+		}
+		results = append(results, runStaticAnalyzer(ctx, workDir, task, analyzerCfg))
+	}
+	return results
+}
+
+func runStaticAnalyzer(ctx context.Context, workDir string, task *models.WorkspaceTask, cfg StaticAnalyzerConfig) models.TaskStaticAnalysisResult {
+	result := models.TaskStaticAnalysisResult{
+		TaskID:           task.ID,
+		WorkspaceID:      task.WorkspaceID,
+		Analyzer:         cfg.Name,
+		EnforcementLevel: cfg.EnforcementLevel,
+		BlockingLevel:    cfg.BlockingLevel,
+		SARIF:            models.SARIFRun{Tool: models.SARIFTool{Driver: models.SARIFDriver{Name: cfg.Name}}},
+	}
+
+	timeout := defaultStaticAnalyzerTimeout
+	if cfg.TimeoutSeconds > 0 {
+		timeout = time.Duration(cfg.TimeoutSeconds) * time.Second
+	}
+	analyzeCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	var run *models.SARIFRun
+	analyzer, ok := getStaticAnalyzer(cfg.Name)
+	err := errors.New("analyzer not registered")
+	if ok {
+		run, err = analyzer.Analyze(analyzeCtx, workDir, cfg)
+	}
+	result.DurationMs = time.Since(start).Milliseconds()
+
+	if err != nil {
+		if errors.Is(analyzeCtx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %s", timeout)
+		}
+		result.Status = models.StaticAnalysisStatusErrored
+		result.Message = err.Error()
+		result.Blocking = cfg.EnforcementLevel == models.RunTaskEnforcementMandatory
+		return result
+	}
+
+	disabled := make(map[string]bool, len(cfg.DisabledRules))
+	for _, rule := range cfg.DisabledRules {
+		disabled[rule] = true
+	}
+	filtered := make([]models.SARIFResult, 0, len(run.Results))
+	for _, finding := range run.Results {
+		if disabled[finding.RuleID] {
+			continue
+		}
+		filtered = append(filtered, finding)
+	}
+	sort.SliceStable(filtered, func(i, j int) bool {
+		return sarifLevelRank(filtered[i].Level) > sarifLevelRank(filtered[j].Level)
+	})
+	run.Results = filtered
+	result.SARIF = *run
+
+	blockingRank := sarifLevelRank(cfg.BlockingLevel)
+	failing := 0
+	for _, finding := range filtered {
+		switch finding.Level {
+		case models.SARIFLevelError:
+			result.ErrorCount++
+		case models.SARIFLevelWarning:
+			result.WarningCount++
+		default:
+			result.NoteCount++
+		}
+		if sarifLevelRank(finding.Level) >= blockingRank {
+			failing++
+		}
+	}
+
+	if failing > 0 {
+		result.Status = models.StaticAnalysisStatusFailed
+		result.Message = fmt.Sprintf("%d finding(s) at or above %s level", failing, cfg.BlockingLevel)
+		result.Blocking = cfg.EnforcementLevel == models.RunTaskEnforcementMandatory
+	} else {
+		result.Status = models.StaticAnalysisStatusPassed
+		result.Message = fmt.Sprintf("%d finding(s) below %s level", len(filtered), cfg.BlockingLevel)
+	}
+	return result
+}
+
+// newSARIFResult 构造一条发现，file/line/address 可为空
+func newSARIFResult(ruleID, level, message, helpURI, file string, startLine, endLine int, address string) models.SARIFResult {
+	result := models.SARIFResult{
+		RuleID:  ruleID,
+		Level:   level,
+		Message: models.SARIFMessage{Text: message},
+		HelpURI: helpURI,
+	}
+
+	var location models.SARIFLocation
+	if file != "" {
+		location.PhysicalLocation = &models.SARIFPhysicalLocation{
+			ArtifactLocation: models.SARIFArtifactLocation{URI: file},
+		}
+		if startLine > 0 {
+			location.PhysicalLocation.Region = &models.SARIFRegion{StartLine: startLine, EndLine: endLine}
+		}
+	}
+	if address != "" {
+		location.LogicalLocations = []models.SARIFLogicalLocation{{FullyQualifiedName: address, Kind: "resource"}}
+	}
+	if location.PhysicalLocation != nil || location.LogicalLocations != nil {
+		result.Locations = []models.SARIFLocation{location}
+	}
+	return result
+}
@@ -0,0 +1,584 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"iac-platform/internal/models"
+)
+
+// ============================================================================
+// 外部工具分析器（tflint / trivy / checkov）
+// ============================================================================
+
+// commandAnalyzer 通过命令行调用外部工具并把其 JSON 输出转换为 SARIF-like 结果
+type commandAnalyzer struct {
+	binary         string
+	informationURI string
+	args           []string
+	parse          func(output []byte) ([]models.SARIFResult, error)
+}
+
+var (
+	tflintAnalyzer = &commandAnalyzer{
+		binary:         "tflint",
+		informationURI: "https://github.com/terraform-linters/tflint",
+		args:           []string{"--format=json", "--no-color"},
+		parse:          parseTFLintOutput,
+	}
+	trivyAnalyzer = &commandAnalyzer{
+		binary:         "trivy",
+		informationURI: "https://github.com/aquasecurity/trivy",
+		args:           []string{"config", "--format", "json", "--quiet", "--exit-code", "0", "."},
+		parse:          parseTrivyOutput,
+	}
+	checkovAnalyzer = &commandAnalyzer{
+		binary:         "checkov",
+		informationURI: "https://www.checkov.io",
+		args:           []string{"-d", ".", "--framework", "terraform_json", "--output", "json", "--quiet", "--compact"},
+		parse:          parseCheckovOutput,
+	}
+)
+
+// Analyze 运行外部工具
+// 这些工具在发现问题时通常以非零码退出，因此只要输出能被解析就视为执行成功
+func (a *commandAnalyzer) Analyze(ctx context.Context, workDir string, cfg StaticAnalyzerConfig) (*models.SARIFRun, error) {
+	binaryPath, err := exec.LookPath(a.binary)
+	if err != nil {
+		return nil, fmt.Errorf("%s not found on this agent: %w", a.binary, err)
+	}
+
+	args := append(append([]string{}, a.args...), cfg.Args...)
+	cmd := exec.CommandContext(ctx, binaryPath, args...)
+	cmd.Dir = workDir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	runErr := cmd.Run()
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	results, parseErr := a.parse(stdout.Bytes())
+	if parseErr != nil {
+		if runErr != nil {
+			return nil, fmt.Errorf("%s failed: %v: %s", a.binary, runErr, truncateString(strings.TrimSpace(stderr.String()), 500))
+		}
+		return nil, fmt.Errorf("failed to parse %s output: %w", a.binary, parseErr)
+	}
+
+	return &models.SARIFRun{
+		Tool: models.SARIFTool{Driver: models.SARIFDriver{
+			Name:           a.binary,
+			InformationURI: a.informationURI,
+		}},
+		Results: results,
+	}, nil
+}
+
+// parseTFLintOutput 转换 tflint --format=json 的输出
+func parseTFLintOutput(output []byte) ([]models.SARIFResult, error) {
+	type tflintRange struct {
+		Filename string `json:"filename"`
+		Start    struct {
+			Line int `json:"line"`
+		} `json:"start"`
+		End struct {
+			Line int `json:"line"`
+		} `json:"end"`
+	}
+	var report struct {
+		Issues []struct {
+			Rule struct {
+				Name     string `json:"name"`
+				Severity string `json:"severity"`
+				Link     string `json:"link"`
+			} `json:"rule"`
+			Message string      `json:"message"`
+			Range   tflintRange `json:"range"`
+		} `json:"issues"`
+		Errors []struct {
+			Message  string       `json:"message"`
+			Severity string       `json:"severity"`
+			Range    *tflintRange `json:"range"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(output, &report); err != nil {
+		return nil, err
+	}
+
+	results := make([]models.SARIFResult, 0, len(report.Issues)+len(report.Errors))
+	for _, issue := range report.Issues {
+		level := models.SARIFLevelNote
+		switch strings.ToLower(issue.Rule.Severity) {
+		case "error":
+			level = models.SARIFLevelError
+		case "warning":
+			level = models.SARIFLevelWarning
+		}
+		results = append(results, newSARIFResult(issue.Rule.Name, level, issue.Message, issue.Rule.Link,
+			issue.Range.Filename, issue.Range.Start.Line, issue.Range.End.Line, ""))
+	}
+	for _, e := range report.Errors {
+		file, start, end := "", 0, 0
+		if e.Range != nil {
+			file, start, end = e.Range.Filename, e.Range.Start.Line, e.Range.End.Line
+		}
+		results = append(results, newSARIFResult("tflint_error", models.SARIFLevelError, e.Message, "", file, start, end, ""))
+	}
+	return results, nil
+}
+
+// trivyCheckovLevel 将 CRITICAL/HIGH/MEDIUM/LOW 映射为 SARIF 级别
+func trivyCheckovLevel(severity string, fallback string) string {
+	switch strings.ToUpper(severity) {
+	case "CRITICAL", "HIGH":
+		return models.SARIFLevelError
+	case "MEDIUM":
+		return models.SARIFLevelWarning
+	case "LOW", "INFO", "UNKNOWN":
+		return models.SARIFLevelNote
+	}
+	return fallback
+}
+
+// parseTrivyOutput 转换 trivy config --format json 的输出（只保留 FAIL 的检查）
+func parseTrivyOutput(output []byte) ([]models.SARIFResult, error) {
+	var report struct {
+		Results []struct {
+			Target            string `json:"Target"`
+			Misconfigurations []struct {
+				ID            string `json:"ID"`
+				AVDID         string `json:"AVDID"`
+				Title         string `json:"Title"`
+				Message       string `json:"Message"`
+				Severity      string `json:"Severity"`
+				PrimaryURL    string `json:"PrimaryURL"`
+				Status        string `json:"Status"`
+				CauseMetadata struct {
+					Resource  string `json:"Resource"`
+					StartLine int    `json:"StartLine"`
+					EndLine   int    `json:"EndLine"`
+				} `json:"CauseMetadata"`
+			} `json:"Misconfigurations"`
+		} `json:"Results"`
+	}
+	if err := json.Unmarshal(output, &report); err != nil {
+		return nil, err
+	}
+
+	var results []models.SARIFResult
+	for _, target := range report.Results {
+		for _, m := range target.Misconfigurations {
+			if m.Status != "" && m.Status != "FAIL" {
+				continue
+			}
+			ruleID := m.AVDID
+			if ruleID == "" {
+				ruleID = m.ID
+			}
+			message := m.Title
+			if m.Message != "" {
+				message = m.Title + ": " + m.Message
+			}
+			results = append(results, newSARIFResult(ruleID, trivyCheckovLevel(m.Severity, models.SARIFLevelWarning), message,
+				m.PrimaryURL, target.Target, m.CauseMetadata.StartLine, m.CauseMetadata.EndLine, m.CauseMetadata.Resource))
+		}
+	}
+	return results, nil
+}
+
+// parseCheckovOutput 转换 checkov --output json 的输出
+// 单框架时输出为对象，多框架时为数组；没有 severity 的失败检查按 error 处理（与 checkov 默认的 hard-fail 一致）
+func parseCheckovOutput(output []byte) ([]models.SARIFResult, error) {
+	type checkovReport struct {
+		Results struct {
+			FailedChecks []struct {
+				CheckID       string `json:"check_id"`
+				CheckName     string `json:"check_name"`
+				FilePath      string `json:"file_path"`
+				FileLineRange []int  `json:"file_line_range"`
+				Resource      string `json:"resource"`
+				Severity      string `json:"severity"`
+				Guideline     string `json:"guideline"`
+			} `json:"failed_checks"`
+		} `json:"results"`
+	}
+
+	trimmed := bytes.TrimSpace(output)
+	var reports []checkovReport
+	switch {
+	case len(trimmed) > 0 && trimmed[0] == '[':
+		if err := json.Unmarshal(trimmed, &reports); err != nil {
+			return nil, err
+		}
+	default:
+		var report checkovReport
+		if err := json.Unmarshal(trimmed, &report); err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+
+	var results []models.SARIFResult
+	for _, report := range reports {
+		for _, check := range report.Results.FailedChecks {
+			start, end := 0, 0
+			if len(check.FileLineRange) == 2 {
+				start, end = check.FileLineRange[0], check.FileLineRange[1]
+			}
+			results = append(results, newSARIFResult(check.CheckID, trivyCheckovLevel(check.Severity, models.SARIFLevelError),
+				check.CheckName, check.Guideline, strings.TrimPrefix(check.FilePath, "/"), start, end, check.Resource))
+		}
+	}
+	return results, nil
+}
+
+// ============================================================================
+// 内置规则（直接检查生成的 *.tf.json，不依赖外部工具）
+// ============================================================================
+
+const builtinRulesHelpURI = "https://developer.hashicorp.com/terraform/language/syntax/json"
+
+type builtinStaticAnalyzer struct{}
+
+// tfJSONFile 一个已解析的 *.tf.json 文件
+type tfJSONFile struct {
+	name  string
+	body  map[string]interface{}
+	lines map[string]int
+}
+
+// line 返回 JSON 路径（如 module/vpc）所在行号，未知时返回 0
+func (f *tfJSONFile) line(path ...string) int {
+	return f.lines[strings.Join(path, "/")]
+}
+
+// builtinRule 内置规则：检查单个文件并返回发现
+type builtinRule struct {
+	id    string
+	check func(f *tfJSONFile) []models.SARIFResult
+}
+
+var builtinRules = []builtinRule{
+	{id: "TNV001", check: checkModuleSourceRequired},
+	{id: "TNV002", check: checkModuleVersionPinned},
+	{id: "TNV003", check: checkProviderHardcodedCredentials},
+	{id: "TNV004", check: checkModuleSecretLiterals},
+	{id: "TNV005", check: checkOpenIngressCIDR},
+	{id: "TNV006", check: checkCountAndForEach},
+}
+
+// Analyze 对工作目录下所有 *.tf.json 运行内置规则
+func (builtinStaticAnalyzer) Analyze(ctx context.Context, workDir string, cfg StaticAnalyzerConfig) (*models.SARIFRun, error) {
+	paths, err := filepath.Glob(filepath.Join(workDir, "*.tf.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	run := &models.SARIFRun{
+		Tool:    models.SARIFTool{Driver: models.SARIFDriver{Name: "builtin", InformationURI: builtinRulesHelpURI}},
+		Results: []models.SARIFResult{},
+	}
+	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		file := &tfJSONFile{name: filepath.Base(path)}
+		if err := json.Unmarshal(data, &file.body); err != nil {
+			run.Results = append(run.Results, newSARIFResult("TNV000", models.SARIFLevelError,
+				fmt.Sprintf("configuration is not valid JSON: %v", err), builtinRulesHelpURI, file.name, 0, 0, ""))
+			continue
+		}
+		file.lines = jsonLineIndex(data)
+		for _, rule := range builtinRules {
+			run.Results = append(run.Results, rule.check(file)...)
+		}
+	}
+	return run, nil
+}
+
+// jsonLineIndex 建立 JSON 路径到行号的索引，路径以 / 连接（数组元素使用下标）
+func jsonLineIndex(data []byte) map[string]int {
+	index := make(map[string]int)
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	lineAt := func(offset int64) int {
+		for offset < int64(len(data)) && strings.ContainsRune(" \t\r\n,:", rune(data[offset])) {
+			offset++
+		}
+		return bytes.Count(data[:offset], []byte("\n")) + 1
+	}
+
+	var walk func(path string) error
+	walk = func(path string) error {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		delim, ok := tok.(json.Delim)
+		if !ok {
+			return nil
+		}
+		switch delim {
+		case '{':
+			for dec.More() {
+				start := dec.InputOffset()
+				keyTok, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				key, _ := keyTok.(string)
+				child := key
+				if path != "" {
+					child = path + "/" + key
+				}
+				index[child] = lineAt(start)
+				if err := walk(child); err != nil {
+					return err
+				}
+			}
+		case '[':
+			for i := 0; dec.More(); i++ {
+				child := fmt.Sprintf("%s/%d", path, i)
+				index[child] = lineAt(dec.InputOffset())
+				if err := walk(child); err != nil {
+					return err
+				}
+			}
+		}
+		_, err = dec.Token()
+		return err
+	}
+
+	_ = walk("")
+	return index
+}
+
+// tfJSONBlocks 返回 JSON 配置中两级块（如 module.<name>、provider.<name>）的 body 列表
+// Terraform JSON 语法允许 body 为对象或对象数组，两种形式都处理；回调参数 path 为 body 的 JSON 路径
+func tfJSONBlocks(f *tfJSONFile, blockType string, fn func(name string, body map[string]interface{}, path []string)) {
+	blocks, ok := f.body[blockType].(map[string]interface{})
+	if !ok {
+		return
+	}
+	names := make([]string, 0, len(blocks))
+	for name := range blocks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		switch v := blocks[name].(type) {
+		case map[string]interface{}:
+			fn(name, v, []string{blockType, name})
+		case []interface{}:
+			for i, item := range v {
+				if body, ok := item.(map[string]interface{}); ok {
+					fn(name, body, []string{blockType, name, fmt.Sprint(i)})
+				}
+			}
+		}
+	}
+}
+
+// tfJSONResources 遍历 resource.<type>.<name> 块
+func tfJSONResources(f *tfJSONFile, fn func(address string, body map[string]interface{}, path []string)) {
+	types, ok := f.body["resource"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	typeNames := make([]string, 0, len(types))
+	for t := range types {
+		typeNames = append(typeNames, t)
+	}
+	sort.Strings(typeNames)
+
+	for _, resourceType := range typeNames {
+		inner := &tfJSONFile{name: f.name, body: map[string]interface{}{}, lines: f.lines}
+		inner.body[resourceType], _ = types[resourceType].(map[string]interface{})
+		tfJSONBlocks(inner, resourceType, func(name string, body map[string]interface{}, path []string) {
+			fn(resourceType+"."+name, body, append([]string{"resource"}, path...))
+		})
+	}
+}
+
+// isLiteralString 判断值是否为不含插值/引用的非空字符串字面量
+func isLiteralString(v interface{}) bool {
+	s, ok := v.(string)
+	return ok && s != "" && !strings.Contains(s, "${")
+}
+
+func checkModuleSourceRequired(f *tfJSONFile) []models.SARIFResult {
+	var results []models.SARIFResult
+	tfJSONBlocks(f, "module", func(name string, body map[string]interface{}, path []string) {
+		if source, ok := body["source"].(string); !ok || strings.TrimSpace(source) == "" {
+			results = append(results, newSARIFResult("TNV001", models.SARIFLevelError,
+				fmt.Sprintf("module %q has no source", name), builtinRulesHelpURI, f.name, f.line(path...), 0, "module."+name))
+		}
+	})
+	return results
+}
+
+var registryModuleSource = regexp.MustCompile(`^([a-zA-Z0-9.-]+/)?[a-zA-Z0-9_-]+/[a-zA-Z0-9_-]+/[a-zA-Z0-9_-]+(//.*)?$`)
+
+func checkModuleVersionPinned(f *tfJSONFile) []models.SARIFResult {
+	var results []models.SARIFResult
+	tfJSONBlocks(f, "module", func(name string, body map[string]interface{}, path []string) {
+		source, _ := body["source"].(string)
+		if source == "" || strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../") {
+			return
+		}
+		version, _ := body["version"].(string)
+
+		message := ""
+		switch {
+		case strings.HasPrefix(source, "git::") || strings.HasPrefix(source, "github.com/") || strings.HasSuffix(strings.SplitN(source, "?", 2)[0], ".git"):
+			if !strings.Contains(source, "ref=") {
+				message = fmt.Sprintf("module %q uses a git source without ?ref=, so every plan may pick up a different revision", name)
+			}
+		case registryModuleSource.MatchString(source):
+			if strings.TrimSpace(version) == "" {
+				message = fmt.Sprintf("module %q uses registry source %q without a version constraint", name, source)
+			}
+		}
+		if message != "" {
+			results = append(results, newSARIFResult("TNV002", models.SARIFLevelWarning, message,
+				"https://developer.hashicorp.com/terraform/language/modules/syntax#version", f.name, f.line(path...), 0, "module."+name))
+		}
+	})
+	return results
+}
+
+var providerCredentialKeys = []string{"access_key", "secret_key", "token", "password", "client_secret", "client_certificate_password"}
+
+func checkProviderHardcodedCredentials(f *tfJSONFile) []models.SARIFResult {
+	var results []models.SARIFResult
+	tfJSONBlocks(f, "provider", func(name string, body map[string]interface{}, path []string) {
+		for _, key := range providerCredentialKeys {
+			if isLiteralString(body[key]) {
+				results = append(results, newSARIFResult("TNV003", models.SARIFLevelError,
+					fmt.Sprintf("provider %q has a hard-coded %s; use workspace environment variables or a credential template instead", name, key),
+					builtinRulesHelpURI, f.name, f.line(append(path, key)...), 0, "provider."+name))
+			}
+		}
+	})
+	return results
+}
+
+var secretArgumentName = regexp.MustCompile(`(?i)(password|passwd|secret|private_key|api_key|access_token)$`)
+
+func checkModuleSecretLiterals(f *tfJSONFile) []models.SARIFResult {
+	var results []models.SARIFResult
+	tfJSONBlocks(f, "module", func(name string, body map[string]interface{}, path []string) {
+		keys := make([]string, 0, len(body))
+		for key := range body {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if secretArgumentName.MatchString(key) && isLiteralString(body[key]) {
+				results = append(results, newSARIFResult("TNV004", models.SARIFLevelWarning,
+					fmt.Sprintf("module %q argument %s is a literal value; pass secrets through sensitive variables", name, key),
+					builtinRulesHelpURI, f.name, f.line(append(path, key)...), 0, "module."+name))
+			}
+		}
+	})
+	return results
+}
+
+var ingressCIDRKeys = map[string]bool{
+	"cidr_blocks":         true,
+	"ipv6_cidr_blocks":    true,
+	"cidr_ipv4":           true,
+	"cidr_ipv6":           true,
+	"source_ranges":       true,
+	"ingress_cidr_blocks": true,
+}
+
+func checkOpenIngressCIDR(f *tfJSONFile) []models.SARIFResult {
+	var results []models.SARIFResult
+	report := func(address string, path []string) {
+		results = append(results, newSARIFResult("TNV005", models.SARIFLevelWarning,
+			fmt.Sprintf("%s allows traffic from any address (0.0.0.0/0 or ::/0)", address),
+			builtinRulesHelpURI, f.name, f.line(path...), 0, address))
+	}
+
+	var walk func(address string, v interface{}, path []string) bool
+	walk = func(address string, v interface{}, path []string) bool {
+		switch val := v.(type) {
+		case map[string]interface{}:
+			for key, child := range val {
+				childPath := append(append([]string{}, path...), key)
+				if ingressCIDRKeys[key] && containsOpenCIDR(child) {
+					report(address, childPath)
+					return true
+				}
+				if walk(address, child, childPath) {
+					return true
+				}
+			}
+		case []interface{}:
+			for i, child := range val {
+				if walk(address, child, append(append([]string{}, path...), fmt.Sprint(i))) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	tfJSONBlocks(f, "module", func(name string, body map[string]interface{}, path []string) {
+		walk("module."+name, body, path)
+	})
+	tfJSONResources(f, func(address string, body map[string]interface{}, path []string) {
+		walk(address, body, path)
+	})
+	return results
+}
+
+func containsOpenCIDR(v interface{}) bool {
+	switch val := v.(type) {
+	case string:
+		return val == "0.0.0.0/0" || val == "::/0"
+	case []interface{}:
+		for _, item := range val {
+			if containsOpenCIDR(item) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func checkCountAndForEach(f *tfJSONFile) []models.SARIFResult {
+	var results []models.SARIFResult
+	check := func(address string, body map[string]interface{}, path []string) {
+		_, hasCount := body["count"]
+		_, hasForEach := body["for_each"]
+		if hasCount && hasForEach {
+			results = append(results, newSARIFResult("TNV006", models.SARIFLevelError,
+				fmt.Sprintf("%s sets both count and for_each, which terraform rejects", address),
+				"https://developer.hashicorp.com/terraform/language/meta-arguments/for_each", f.name, f.line(path...), 0, address))
+		}
+	}
+	tfJSONBlocks(f, "module", func(name string, body map[string]interface{}, path []string) {
+		check("module."+name, body, path)
+	})
+	tfJSONResources(f, check)
+	return results
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"iac-platform/internal/models"
+)
+
+// runStaticAnalysisStage 在 plan 之前对生成的配置运行静态分析
+// 返回 error 表示需要终止任务：mandatory 分析器失败/出错，或配置无法解析
+func (s *TerraformExecutor) runStaticAnalysisStage(
+	ctx context.Context,
+	task *models.WorkspaceTask,
+	workspace *models.Workspace,
+	workDir string,
+	logger *TerraformLogger,
+) error {
+	// Drift Check 只做 refresh，不检查配置
+	if task.TaskType == models.TaskTypeDriftCheck {
+		return nil
+	}
+
+	cfg, err := ParseStaticAnalysisConfig(workspace.StaticAnalysisConfig)
+	if err != nil {
+		logger.StageBegin("static_analysis")
+		logger.Error("Invalid static analysis configuration: %v", err)
+		logger.StageEnd("static_analysis")
+		return err
+	}
+	if !cfg.Enabled || len(cfg.Analyzers) == 0 {
+		return nil
+	}
+
+	logger.StageBegin("static_analysis")
+	defer logger.StageEnd("static_analysis")
+	logger.Info("Running %d static analyzer(s) on generated configuration...", len(cfg.Analyzers))
+
+	results := RunStaticAnalysis(ctx, workDir, task, cfg)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	var blocked []string
+	for _, result := range results {
+		summary := fmt.Sprintf("%s [%s]: %s (%d errors, %d warnings, %d notes, %dms)",
+			result.Analyzer, result.EnforcementLevel, result.Status,
+			result.ErrorCount, result.WarningCount, result.NoteCount, result.DurationMs)
+		switch {
+		case result.Status == models.StaticAnalysisStatusPassed:
+			logger.Info("✓ %s", summary)
+		case result.Blocking:
+			logger.Error("✗ %s - %s", summary, result.Message)
+			blocked = append(blocked, result.Analyzer)
+		default:
+			logger.Warn("⚠ %s - %s (advisory, not blocking)", summary, result.Message)
+		}
+
+		for _, finding := range result.SARIF.Results {
+			line := fmt.Sprintf("  [%s] %s %s: %s", finding.Level, finding.RuleID, staticAnalysisLocation(finding), finding.Message.Text)
+			if finding.Level == models.SARIFLevelError {
+				logger.Warn("%s", line)
+			} else {
+				logger.Info("%s", line)
+			}
+		}
+	}
+
+	if err := s.saveStaticAnalysisResults(task.ID, results); err != nil {
+		logger.Warn("Failed to save static analysis results: %v", err)
+	}
+
+	if len(blocked) > 0 {
+		logger.Error("Static analysis blocked execution (mandatory analyzer failed: %s)", strings.Join(blocked, ", "))
+		return fmt.Errorf("static analysis failed (mandatory): %s", strings.Join(blocked, ", "))
+	}
+	logger.Info("✓ Static analysis completed")
+	return nil
+}
+
+// saveStaticAnalysisResults 保存静态分析结果（Local 模式写数据库，Agent 模式上报服务端）
+func (s *TerraformExecutor) saveStaticAnalysisResults(taskID uint, results []models.TaskStaticAnalysisResult) error {
+	if s.db != nil {
+		return NewStaticAnalysisService(s.db).SaveResults(taskID, results)
+	}
+	if remoteAccessor, ok := s.dataAccessor.(*RemoteDataAccessor); ok {
+		return remoteAccessor.apiClient.UploadStaticAnalysisResults(taskID, results)
+	}
+	return fmt.Errorf("no data accessor available to save static analysis results")
+}
+
+// staticAnalysisLocation 格式化发现位置，如 main.tf.json:12 (module.vpc)
+func staticAnalysisLocation(finding models.SARIFResult) string {
+	if len(finding.Locations) == 0 {
+		return "-"
+	}
+	loc := finding.Locations[0]
+	parts := make([]string, 0, 2)
+	if loc.PhysicalLocation != nil {
+		file := loc.PhysicalLocation.ArtifactLocation.URI
+		if loc.PhysicalLocation.Region != nil && loc.PhysicalLocation.Region.StartLine > 0 {
+			file = fmt.Sprintf("%s:%d", file, loc.PhysicalLocation.Region.StartLine)
+		}
+		parts = append(parts, file)
+	}
+	if len(loc.LogicalLocations) > 0 {
+		parts = append(parts, "("+loc.LogicalLocations[0].FullyQualifiedName+")")
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"fmt"
+
+	"iac-platform/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// StaticAnalysisService 静态分析结果存储服务
+type StaticAnalysisService struct {
+	db *gorm.DB
+}
+
+// NewStaticAnalysisService 创建静态分析结果存储服务
+func NewStaticAnalysisService(db *gorm.DB) *StaticAnalysisService {
+	return &StaticAnalysisService{db: db}
+}
+
+// SaveResults 保存任务的静态分析结果
+// 同一任务重复上报（如 Agent 重试）时替换旧结果
+func (s *StaticAnalysisService) SaveResults(taskID uint, results []models.TaskStaticAnalysisResult) error {
+	var task models.WorkspaceTask
+	if err := s.db.Select("id", "workspace_id").First(&task, taskID).Error; err != nil {
+		return fmt.Errorf("task not found: %w", err)
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("task_id = ?", taskID).Delete(&models.TaskStaticAnalysisResult{}).Error; err != nil {
+			return err
+		}
+		for i := range results {
+			result := results[i]
+			result.ID = 0
+			result.TaskID = task.ID
+			result.WorkspaceID = task.WorkspaceID
+			if err := tx.Create(&result).Error; err != nil {
+				return fmt.Errorf("failed to save %s result: %w", result.Analyzer, err)
+			}
+		}
+		return nil
+	})
+}
+
+// GetResultsForTask 获取任务的静态分析结果
+func (s *StaticAnalysisService) GetResultsForTask(taskID uint) ([]models.TaskStaticAnalysisResult, error) {
+	var results []models.TaskStaticAnalysisResult
+	err := s.db.Where("task_id = ?", taskID).Order("id ASC").Find(&results).Error
+	return results, err
+}
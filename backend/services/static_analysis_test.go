@@ -0,0 +1,229 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"iac-platform/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStaticAnalyzer struct {
+	run *models.SARIFRun
+	err error
+}
+
+func (f fakeStaticAnalyzer) Analyze(ctx context.Context, workDir string, cfg StaticAnalyzerConfig) (*models.SARIFRun, error) {
+	return f.run, f.err
+}
+
+func TestParseStaticAnalysisConfig(t *testing.T) {
+	cfg, err := ParseStaticAnalysisConfig(nil)
+	require.NoError(t, err)
+	assert.False(t, cfg.Enabled)
+
+	cfg, err = ParseStaticAnalysisConfig(map[string]interface{}{
+		"enabled":   true,
+		"analyzers": []interface{}{map[string]interface{}{"name": "builtin"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, cfg.Analyzers, 1)
+	assert.Equal(t, models.RunTaskEnforcementAdvisory, cfg.Analyzers[0].EnforcementLevel)
+	assert.Equal(t, models.SARIFLevelError, cfg.Analyzers[0].BlockingLevel)
+
+	invalid := []map[string]interface{}{
+		{"analyzers": []interface{}{map[string]interface{}{"name": "sonar"}}},
+		{"analyzers": []interface{}{map[string]interface{}{"name": "tflint"}, map[string]interface{}{"name": "tflint"}}},
+		{"analyzers": []interface{}{map[string]interface{}{"name": "trivy", "enforcement_level": "strict"}}},
+		{"analyzers": []interface{}{map[string]interface{}{"name": "checkov", "blocking_level": "critical"}}},
+	}
+	for _, raw := range invalid {
+		_, err := ParseStaticAnalysisConfig(raw)
+		assert.Error(t, err, "%v", raw)
+	}
+}
+
+func TestBuiltinStaticAnalyzer(t *testing.T) {
+	workDir := t.TempDir()
+	mainTF := `{
+  "module": {
+    "vpc": [
+      {
+        "source": "terraform-aws-modules/vpc/aws",
+        "ingress_cidr_blocks": ["10.0.0.0/8", "0.0.0.0/0"]
+      }
+    ],
+    "db": {
+      "source": "git::https://example.com/modules/rds.git",
+      "master_password": "hunter2",
+      "count": 1,
+      "for_each": "${var.dbs}"
+    },
+    "pinned": {
+      "source": "terraform-aws-modules/s3-bucket/aws",
+      "version": "4.1.0",
+      "admin_password": "${var.admin_password}"
+    }
+  }
+}`
+	providerTF := `{
+  "provider": {
+    "aws": [
+      {
+        "region": "us-east-1",
+        "access_key": "AKIAEXAMPLE",
+        "secret_key": "${var.secret_key}"
+      }
+    ]
+  }
+}`
+	require.NoError(t, os.WriteFile(filepath.Join(workDir, "main.tf.json"), []byte(mainTF), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(workDir, "provider.tf.json"), []byte(providerTF), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(workDir, "variables.tfvars"), []byte(`secret_key = "not scanned"`), 0644))
+
+	run, err := builtinStaticAnalyzer{}.Analyze(context.Background(), workDir, StaticAnalyzerConfig{Name: "builtin"})
+	require.NoError(t, err)
+
+	byRule := make(map[string][]models.SARIFResult)
+	for _, finding := range run.Results {
+		byRule[finding.RuleID] = append(byRule[finding.RuleID], finding)
+	}
+
+	require.Len(t, byRule["TNV002"], 2, "unpinned registry and git sources")
+	assert.Equal(t, "module.db", byRule["TNV002"][0].Locations[0].LogicalLocations[0].FullyQualifiedName)
+	assert.Equal(t, "module.vpc", byRule["TNV002"][1].Locations[0].LogicalLocations[0].FullyQualifiedName)
+	assert.Equal(t, 4, byRule["TNV002"][1].Locations[0].PhysicalLocation.Region.StartLine)
+
+	require.Len(t, byRule["TNV003"], 1, "interpolated secret_key is not reported")
+	assert.Equal(t, models.SARIFLevelError, byRule["TNV003"][0].Level)
+	assert.Equal(t, "provider.tf.json", byRule["TNV003"][0].Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	assert.Equal(t, 6, byRule["TNV003"][0].Locations[0].PhysicalLocation.Region.StartLine)
+	assert.NotContains(t, byRule["TNV003"][0].Message.Text, "AKIAEXAMPLE")
+
+	require.Len(t, byRule["TNV004"], 1)
+	assert.Equal(t, 11, byRule["TNV004"][0].Locations[0].PhysicalLocation.Region.StartLine)
+
+	require.Len(t, byRule["TNV005"], 1)
+	assert.Equal(t, 6, byRule["TNV005"][0].Locations[0].PhysicalLocation.Region.StartLine)
+
+	require.Len(t, byRule["TNV006"], 1)
+	assert.Empty(t, byRule["TNV001"])
+}
+
+func TestParseExternalAnalyzerOutput(t *testing.T) {
+	tflint := `{"issues":[{"rule":{"name":"terraform_unused_declarations","severity":"warning","link":"https://example.com/rule"},"message":"variable \"x\" is declared but not used","range":{"filename":"variables.tf.json","start":{"line":3,"column":5},"end":{"line":3,"column":8}}}],"errors":[]}`
+	results, err := parseTFLintOutput([]byte(tflint))
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "terraform_unused_declarations", results[0].RuleID)
+	assert.Equal(t, models.SARIFLevelWarning, results[0].Level)
+	assert.Equal(t, 3, results[0].Locations[0].PhysicalLocation.Region.StartLine)
+
+	trivy := `{"Results":[{"Target":"main.tf.json","Misconfigurations":[
+		{"ID":"AWS-0086","AVDID":"AVD-AWS-0086","Title":"S3 Access block should block public ACL","Message":"No public access block","Severity":"HIGH","Status":"FAIL","CauseMetadata":{"Resource":"module.logs","StartLine":10,"EndLine":20}},
+		{"ID":"AWS-0088","AVDID":"AVD-AWS-0088","Title":"Encryption","Severity":"LOW","Status":"PASS"}]}]}`
+	results, err = parseTrivyOutput([]byte(trivy))
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "AVD-AWS-0086", results[0].RuleID)
+	assert.Equal(t, models.SARIFLevelError, results[0].Level)
+	assert.Equal(t, "module.logs", results[0].Locations[0].LogicalLocations[0].FullyQualifiedName)
+
+	checkov := `[{"check_type":"terraform_json","results":{"failed_checks":[{"check_id":"CKV_AWS_18","check_name":"Ensure the S3 bucket has access logging enabled","file_path":"/main.tf.json","file_line_range":[5,9],"resource":"aws_s3_bucket.logs","severity":null,"guideline":"https://example.com/ckv18"}]}}]`
+	results, err = parseCheckovOutput([]byte(checkov))
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, models.SARIFLevelError, results[0].Level)
+	assert.Equal(t, "main.tf.json", results[0].Locations[0].PhysicalLocation.ArtifactLocation.URI)
+
+	_, err = parseCheckovOutput([]byte("checkov: command failed"))
+	assert.Error(t, err)
+}
+
+func TestRunStaticAnalysis_Enforcement(t *testing.T) {
+	RegisterStaticAnalyzer("fake-findings", fakeStaticAnalyzer{run: &models.SARIFRun{Results: []models.SARIFResult{
+		newSARIFResult("R1", models.SARIFLevelWarning, "warn", "", "main.tf.json", 1, 0, ""),
+		newSARIFResult("R2", models.SARIFLevelError, "err", "", "main.tf.json", 2, 0, ""),
+	}}})
+	RegisterStaticAnalyzer("fake-broken", fakeStaticAnalyzer{err: errors.New("tool crashed")})
+
+	task := &models.WorkspaceTask{ID: 7, WorkspaceID: "ws-1"}
+	cfg, err := ParseStaticAnalysisConfig(map[string]interface{}{
+		"enabled": true,
+		"analyzers": []interface{}{
+			map[string]interface{}{"name": "fake-findings", "enforcement_level": "mandatory", "disabled_rules": []interface{}{"R2"}},
+			map[string]interface{}{"name": "fake-broken", "enforcement_level": "advisory"},
+		},
+	})
+	require.NoError(t, err)
+
+	results := RunStaticAnalysis(context.Background(), t.TempDir(), task, cfg)
+	require.Len(t, results, 2)
+
+	// R2 被禁用，只剩 warning，低于默认 error 阻断级别
+	assert.Equal(t, models.StaticAnalysisStatusPassed, results[0].Status)
+	assert.False(t, results[0].Blocking)
+	assert.Equal(t, 1, results[0].WarningCount)
+	assert.Equal(t, 0, results[0].ErrorCount)
+
+	// advisory 分析器出错不阻断
+	assert.Equal(t, models.StaticAnalysisStatusErrored, results[1].Status)
+	assert.False(t, results[1].Blocking)
+	assert.Equal(t, "tool crashed", results[1].Message)
+
+	cfg.Analyzers[0].BlockingLevel = models.SARIFLevelWarning
+	cfg.Analyzers[1].EnforcementLevel = models.RunTaskEnforcementMandatory
+	results = RunStaticAnalysis(context.Background(), t.TempDir(), task, cfg)
+	assert.Equal(t, models.StaticAnalysisStatusFailed, results[0].Status)
+	assert.True(t, results[0].Blocking)
+	assert.True(t, results[1].Blocking, "mandatory analyzer that cannot run fails closed")
+}
+
+func TestStaticAnalysisService_SaveResultsReplaces(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.Exec(`CREATE TABLE task_static_analysis_results (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		task_id INTEGER NOT NULL,
+		workspace_id TEXT NOT NULL,
+		analyzer TEXT NOT NULL,
+		enforcement_level TEXT DEFAULT 'advisory',
+		blocking_level TEXT DEFAULT 'error',
+		status TEXT NOT NULL,
+		blocking INTEGER DEFAULT 0,
+		error_count INTEGER DEFAULT 0,
+		warning_count INTEGER DEFAULT 0,
+		note_count INTEGER DEFAULT 0,
+		sarif BLOB,
+		message TEXT,
+		duration_ms INTEGER DEFAULT 0,
+		created_at DATETIME
+	)`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO workspace_tasks (id, workspace_id, task_type, status) VALUES (1, 'ws-1', 'plan', 'running')`).Error)
+
+	svc := NewStaticAnalysisService(db)
+	result := models.TaskStaticAnalysisResult{
+		Analyzer: "builtin",
+		Status:   models.StaticAnalysisStatusFailed,
+		SARIF: models.SARIFRun{
+			Tool:    models.SARIFTool{Driver: models.SARIFDriver{Name: "builtin"}},
+			Results: []models.SARIFResult{newSARIFResult("TNV003", models.SARIFLevelError, "hard-coded access_key", "", "provider.tf.json", 6, 0, "provider.aws")},
+		},
+		ErrorCount: 1,
+	}
+	require.NoError(t, svc.SaveResults(1, []models.TaskStaticAnalysisResult{result}))
+	require.NoError(t, svc.SaveResults(1, []models.TaskStaticAnalysisResult{result}))
+
+	stored, err := svc.GetResultsForTask(1)
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	assert.Equal(t, "ws-1", stored[0].WorkspaceID)
+	require.Len(t, stored[0].SARIF.Results, 1)
+	assert.Equal(t, "TNV003", stored[0].SARIF.Results[0].RuleID)
+
+	assert.Error(t, svc.SaveResults(99, nil))
+}
@@ -0,0 +1,761 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"iac-platform/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// StateBackupDir State 保存前写入的本地备份目录
+var StateBackupDir = "/var/backup/states"
+
+// stateBackupPattern 备份文件名：ws_<workspace_id>_task_<task_id>_<unix>.tfstate
+var stateBackupPattern = regexp.MustCompile(`^ws_(.+)_task_(\d+)_(\d+)\.tfstate$`)
+
+// archivableTaskStatuses 可以归档的终态（apply_pending 的 Plan 数据仍需用于 Apply，不能归档）
+var archivableTaskStatuses = []models.TaskStatus{
+	models.TaskStatusSuccess,
+	models.TaskStatusApplied,
+	models.TaskStatusFailed,
+	models.TaskStatusCancelled,
+	models.TaskStatusPlannedAndFinished,
+}
+
+// artifactFileNames 各类产物在存储中的文件名
+var artifactFileNames = map[string]string{
+	models.TaskArtifactLogs:        "logs.jsonl.gz",
+	models.TaskArtifactPlanOutput:  "plan_output.txt.gz",
+	models.TaskArtifactApplyOutput: "apply_output.txt.gz",
+	models.TaskArtifactPlanData:    "plan.out.gz",
+	models.TaskArtifactPlanJSON:    "plan.json.gz",
+}
+
+// TaskArtifactService 任务产物归档、透明读取与保留策略
+// 归档：把日志、Plan/Apply 输出、Plan 文件和 Plan JSON 压缩后写入对象存储，再清空数据库中的对应数据
+// 读取：数据库中已清空的字段从对象存储加载，调用方无感知
+// 每个归档过的任务都有一条 logs 产物记录（即使日志为空），用来标记任务已归档/已清除
+type TaskArtifactService struct {
+	db        *gorm.DB
+	backupDir string
+
+	mu      sync.Mutex
+	storage ArtifactStorage
+}
+
+// NewTaskArtifactService 创建服务，存储按 system_configs 中的配置延迟初始化
+func NewTaskArtifactService(db *gorm.DB) *TaskArtifactService {
+	return &TaskArtifactService{db: db, backupDir: StateBackupDir}
+}
+
+// NewTaskArtifactServiceWithStorage 使用指定存储创建服务
+func NewTaskArtifactServiceWithStorage(db *gorm.DB, storage ArtifactStorage) *TaskArtifactService {
+	return &TaskArtifactService{db: db, backupDir: StateBackupDir, storage: storage}
+}
+
+// SetBackupDir 设置 State 备份目录
+func (s *TaskArtifactService) SetBackupDir(dir string) {
+	s.backupDir = dir
+}
+
+func (s *TaskArtifactService) getStorage() (ArtifactStorage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.storage != nil {
+		return s.storage, nil
+	}
+	cfg, err := LoadArtifactStorageConfig(s.db)
+	if err != nil {
+		return nil, err
+	}
+	storage, err := NewArtifactStorage(cfg)
+	if err != nil {
+		return nil, err
+	}
+	s.storage = storage
+	return storage, nil
+}
+
+// artifactKey 对象存储中的 key
+func artifactKey(workspaceID string, taskID uint, name string) string {
+	return fmt.Sprintf("tasks/%s/%d/%s", workspaceID, taskID, name)
+}
+
+// ============================================================================
+// 归档
+// ============================================================================
+
+// ArchiveTask 归档单个已结束任务的产物，返回写入存储的产物数
+// 已归档的任务直接返回 0；先上传对象再在事务中清空数据库，失败时数据库保持不变
+func (s *TaskArtifactService) ArchiveTask(ctx context.Context, taskID uint) (int, error) {
+	var task models.WorkspaceTask
+	if err := s.db.First(&task, taskID).Error; err != nil {
+		return 0, fmt.Errorf("task %d not found: %w", taskID, err)
+	}
+	if !isArchivableStatus(task.Status) {
+		return 0, fmt.Errorf("task %d is not finished (status %s)", taskID, task.Status)
+	}
+
+	var existing int64
+	s.db.Model(&models.TaskArtifact{}).
+		Where("task_id = ? AND kind = ?", taskID, models.TaskArtifactLogs).
+		Count(&existing)
+	if existing > 0 {
+		return 0, nil
+	}
+
+	storage, err := s.getStorage()
+	if err != nil {
+		return 0, err
+	}
+
+	var logs []models.TaskLog
+	if err := s.db.Where("task_id = ?", taskID).Order("created_at ASC, id ASC").Find(&logs).Error; err != nil {
+		return 0, fmt.Errorf("failed to load task logs: %w", err)
+	}
+	var logLines bytes.Buffer
+	for _, l := range logs {
+		line, _ := json.Marshal(l)
+		logLines.Write(line)
+		logLines.WriteByte('\n')
+	}
+
+	contents := map[string][]byte{models.TaskArtifactLogs: logLines.Bytes()}
+	if task.PlanOutput != "" {
+		contents[models.TaskArtifactPlanOutput] = []byte(task.PlanOutput)
+	}
+	if task.ApplyOutput != "" {
+		contents[models.TaskArtifactApplyOutput] = []byte(task.ApplyOutput)
+	}
+	if len(task.PlanData) > 0 {
+		contents[models.TaskArtifactPlanData] = task.PlanData
+	}
+	if task.PlanJSON != nil {
+		data, err := json.Marshal(task.PlanJSON)
+		if err != nil {
+			return 0, fmt.Errorf("failed to marshal plan json: %w", err)
+		}
+		contents[models.TaskArtifactPlanJSON] = data
+	}
+
+	now := time.Now()
+	artifacts := make([]models.TaskArtifact, 0, len(contents))
+	for _, kind := range []string{
+		models.TaskArtifactLogs,
+		models.TaskArtifactPlanOutput,
+		models.TaskArtifactApplyOutput,
+		models.TaskArtifactPlanData,
+		models.TaskArtifactPlanJSON,
+	} {
+		data, ok := contents[kind]
+		if !ok {
+			continue
+		}
+		artifact, err := s.putArtifact(ctx, storage, task.WorkspaceID, taskID, kind, artifactFileNames[kind], data)
+		if err != nil {
+			return 0, err
+		}
+		artifact.ArchivedAt = now
+		artifacts = append(artifacts, *artifact)
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&artifacts).Error; err != nil {
+			return fmt.Errorf("failed to record artifacts: %w", err)
+		}
+		if err := tx.Model(&models.WorkspaceTask{}).Where("id = ?", taskID).UpdateColumns(map[string]interface{}{
+			"plan_output":  "",
+			"apply_output": "",
+			"plan_data":    gorm.Expr("NULL"),
+			"plan_json":    gorm.Expr("NULL"),
+		}).Error; err != nil {
+			return fmt.Errorf("failed to clear archived task columns: %w", err)
+		}
+		if err := tx.Where("task_id = ?", taskID).Delete(&models.TaskLog{}).Error; err != nil {
+			return fmt.Errorf("failed to delete archived task logs: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	log.Printf("[Artifacts] Archived task %d (%d artifacts) to %s storage", taskID, len(artifacts), storage.Type())
+	return len(artifacts), nil
+}
+
+// putArtifact 压缩并写入存储，返回待记录的产物
+func (s *TaskArtifactService) putArtifact(ctx context.Context, storage ArtifactStorage, workspaceID string, taskID uint, kind, name string, data []byte) (*models.TaskArtifact, error) {
+	compressed, err := compressArtifact(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compress %s: %w", kind, err)
+	}
+	key := artifactKey(workspaceID, taskID, name)
+	if err := storage.Put(ctx, key, compressed); err != nil {
+		return nil, fmt.Errorf("failed to store %s: %w", kind, err)
+	}
+	return &models.TaskArtifact{
+		TaskID:      taskID,
+		WorkspaceID: workspaceID,
+		Kind:        kind,
+		StorageType: storage.Type(),
+		StorageKey:  key,
+		Compression: "gzip",
+		SizeBytes:   int64(len(data)),
+		StoredBytes: int64(len(compressed)),
+		Checksum:    artifactChecksum(data),
+	}, nil
+}
+
+// ArchiveStateBackup 归档一个本地 State 备份文件，成功后删除本地文件
+func (s *TaskArtifactService) ArchiveStateBackup(ctx context.Context, path string) error {
+	name := filepath.Base(path)
+	m := stateBackupPattern.FindStringSubmatch(name)
+	if m == nil {
+		return fmt.Errorf("unrecognized state backup file: %s", name)
+	}
+	taskID, _ := strconv.ParseUint(m[2], 10, 32)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read state backup: %w", err)
+	}
+	storage, err := s.getStorage()
+	if err != nil {
+		return err
+	}
+	artifact, err := s.putArtifact(ctx, storage, m[1], uint(taskID), models.TaskArtifactStateBackup, "state-backups/"+name+".gz", data)
+	if err != nil {
+		return err
+	}
+	artifact.Name = name
+	artifact.ArchivedAt = time.Now()
+	if err := s.db.Create(artifact).Error; err != nil {
+		return fmt.Errorf("failed to record state backup artifact: %w", err)
+	}
+	return os.Remove(path)
+}
+
+// ============================================================================
+// 透明读取
+// ============================================================================
+
+// loadArtifact 读取并解压产物，校验 checksum
+func (s *TaskArtifactService) loadArtifact(ctx context.Context, artifact *models.TaskArtifact) ([]byte, error) {
+	if artifact.PurgedAt != nil {
+		return nil, fmt.Errorf("%w: %s of task %d was purged by retention policy", ErrArtifactNotFound, artifact.Kind, artifact.TaskID)
+	}
+	storage, err := s.getStorage()
+	if err != nil {
+		return nil, err
+	}
+	if storage.Type() != artifact.StorageType {
+		return nil, fmt.Errorf("artifact %s is stored in %s storage but %s storage is configured",
+			artifact.StorageKey, artifact.StorageType, storage.Type())
+	}
+	compressed, err := storage.Get(ctx, artifact.StorageKey)
+	if err != nil {
+		return nil, err
+	}
+	data, err := decompressArtifact(compressed)
+	if err != nil {
+		return nil, err
+	}
+	if artifact.Checksum != "" && artifactChecksum(data) != artifact.Checksum {
+		return nil, fmt.Errorf("checksum mismatch for artifact %s", artifact.StorageKey)
+	}
+	return data, nil
+}
+
+// HydrateTask 把已归档的 PlanOutput/ApplyOutput/PlanData/PlanJSON 从对象存储加载回任务对象
+// kinds 为空时加载全部；未归档或已清除的任务保持原样
+func (s *TaskArtifactService) HydrateTask(ctx context.Context, task *models.WorkspaceTask, kinds ...string) error {
+	if len(kinds) == 0 {
+		kinds = []string{
+			models.TaskArtifactPlanOutput,
+			models.TaskArtifactApplyOutput,
+			models.TaskArtifactPlanData,
+			models.TaskArtifactPlanJSON,
+		}
+	}
+
+	var artifacts []models.TaskArtifact
+	if err := s.db.Where("task_id = ? AND kind IN ? AND purged_at IS NULL", task.ID, kinds).Find(&artifacts).Error; err != nil {
+		return fmt.Errorf("failed to load task artifacts: %w", err)
+	}
+
+	for i := range artifacts {
+		artifact := &artifacts[i]
+		switch artifact.Kind {
+		case models.TaskArtifactPlanOutput:
+			if task.PlanOutput != "" {
+				continue
+			}
+		case models.TaskArtifactApplyOutput:
+			if task.ApplyOutput != "" {
+				continue
+			}
+		case models.TaskArtifactPlanData:
+			if len(task.PlanData) > 0 {
+				continue
+			}
+		case models.TaskArtifactPlanJSON:
+			if task.PlanJSON != nil {
+				continue
+			}
+		}
+
+		data, err := s.loadArtifact(ctx, artifact)
+		if err != nil {
+			return err
+		}
+		switch artifact.Kind {
+		case models.TaskArtifactPlanOutput:
+			task.PlanOutput = string(data)
+		case models.TaskArtifactApplyOutput:
+			task.ApplyOutput = string(data)
+		case models.TaskArtifactPlanData:
+			task.PlanData = data
+		case models.TaskArtifactPlanJSON:
+			var planJSON models.JSONB
+			if err := json.Unmarshal(data, &planJSON); err != nil {
+				return fmt.Errorf("invalid archived plan json: %w", err)
+			}
+			task.PlanJSON = planJSON
+		}
+	}
+	return nil
+}
+
+// LoadTaskLogs 读取已归档的日志行；任务未归档时返回 nil
+func (s *TaskArtifactService) LoadTaskLogs(ctx context.Context, taskID uint) ([]models.TaskLog, error) {
+	var artifact models.TaskArtifact
+	err := s.db.Where("task_id = ? AND kind = ?", taskID, models.TaskArtifactLogs).First(&artifact).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load log artifact: %w", err)
+	}
+	if artifact.PurgedAt != nil {
+		return nil, nil
+	}
+
+	data, err := s.loadArtifact(ctx, &artifact)
+	if err != nil {
+		return nil, err
+	}
+
+	var logs []models.TaskLog
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var l models.TaskLog
+		if err := json.Unmarshal(scanner.Bytes(), &l); err != nil {
+			return nil, fmt.Errorf("invalid archived log line: %w", err)
+		}
+		logs = append(logs, l)
+	}
+	return logs, scanner.Err()
+}
+
+// ArtifactStatus 任务产物状态：""（在数据库中）、archived 或 purged
+func (s *TaskArtifactService) ArtifactStatus(taskID uint) string {
+	var artifact models.TaskArtifact
+	if err := s.db.Where("task_id = ? AND kind = ?", taskID, models.TaskArtifactLogs).First(&artifact).Error; err != nil {
+		return ""
+	}
+	if artifact.PurgedAt != nil {
+		return "purged"
+	}
+	return "archived"
+}
+
+// LoadStateBackup 读取已归档的 State 备份；name 为原始文件名，找不到同名备份时返回该任务最新的备份
+func (s *TaskArtifactService) LoadStateBackup(ctx context.Context, taskID uint, name string) ([]byte, error) {
+	var artifacts []models.TaskArtifact
+	if err := s.db.Where("task_id = ? AND kind = ? AND purged_at IS NULL", taskID, models.TaskArtifactStateBackup).
+		Order("id DESC").Find(&artifacts).Error; err != nil {
+		return nil, fmt.Errorf("failed to load state backup artifacts: %w", err)
+	}
+	if len(artifacts) == 0 {
+		return nil, ErrArtifactNotFound
+	}
+
+	selected := &artifacts[0]
+	for i := range artifacts {
+		if artifacts[i].Name == name {
+			selected = &artifacts[i]
+			break
+		}
+	}
+	return s.loadArtifact(ctx, selected)
+}
+
+// ============================================================================
+// 清除
+// ============================================================================
+
+// PurgeTask 彻底删除任务的产物：对象存储中的对象和数据库中仍保留的日志/输出
+// 记录保留为 purged 状态，读取时可以区分"已清除"和"从未产生"
+func (s *TaskArtifactService) PurgeTask(ctx context.Context, taskID uint) error {
+	var task models.WorkspaceTask
+	if err := s.db.Select("id", "workspace_id", "status").First(&task, taskID).Error; err != nil {
+		return fmt.Errorf("task %d not found: %w", taskID, err)
+	}
+	if !isArchivableStatus(task.Status) {
+		return fmt.Errorf("task %d is not finished (status %s)", taskID, task.Status)
+	}
+
+	var artifacts []models.TaskArtifact
+	if err := s.db.Where("task_id = ? AND purged_at IS NULL", taskID).Find(&artifacts).Error; err != nil {
+		return fmt.Errorf("failed to load task artifacts: %w", err)
+	}
+
+	if len(artifacts) > 0 {
+		storage, err := s.getStorage()
+		if err != nil {
+			return err
+		}
+		for _, artifact := range artifacts {
+			if artifact.StorageKey == "" {
+				continue
+			}
+			if artifact.StorageType != storage.Type() {
+				log.Printf("[Artifacts] Artifact %s is in %s storage, not reachable from the configured %s storage; marking purged",
+					artifact.StorageKey, artifact.StorageType, storage.Type())
+				continue
+			}
+			if err := storage.Delete(ctx, artifact.StorageKey); err != nil {
+				return fmt.Errorf("failed to delete artifact %s: %w", artifact.StorageKey, err)
+			}
+		}
+	}
+
+	now := time.Now()
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.TaskArtifact{}).Where("task_id = ? AND purged_at IS NULL", taskID).
+			Update("purged_at", now).Error; err != nil {
+			return err
+		}
+
+		var marker int64
+		tx.Model(&models.TaskArtifact{}).Where("task_id = ? AND kind = ?", taskID, models.TaskArtifactLogs).Count(&marker)
+		if marker == 0 {
+			if err := tx.Create(&models.TaskArtifact{
+				TaskID:      taskID,
+				WorkspaceID: task.WorkspaceID,
+				Kind:        models.TaskArtifactLogs,
+				StorageType: "",
+				ArchivedAt:  now,
+				PurgedAt:    &now,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&models.WorkspaceTask{}).Where("id = ?", taskID).UpdateColumns(map[string]interface{}{
+			"plan_output":  "",
+			"apply_output": "",
+			"plan_data":    gorm.Expr("NULL"),
+			"plan_json":    gorm.Expr("NULL"),
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Where("task_id = ?", taskID).Delete(&models.TaskLog{}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to purge task %d: %w", taskID, err)
+	}
+
+	log.Printf("[Artifacts] Purged artifacts of task %d", taskID)
+	return nil
+}
+
+// ============================================================================
+// 保留策略
+// ============================================================================
+
+// RetentionRunResult 一次保留策略执行的结果
+type RetentionRunResult struct {
+	ArchivedTasks        int `json:"archived_tasks"`
+	PurgedTasks          int `json:"purged_tasks"`
+	ArchivedStateBackups int `json:"archived_state_backups"`
+	PurgedStateBackups   int `json:"purged_state_backups"`
+}
+
+// retentionResolver 按工作空间所属组织查找生效的策略
+type retentionResolver struct {
+	policies map[uint]*models.ArtifactRetentionPolicy // org_id -> policy，0 为平台默认
+	orgOf    map[string]uint                          // workspace_id -> org_id
+}
+
+func (r *retentionResolver) policyFor(workspaceID string) *models.ArtifactRetentionPolicy {
+	if orgID, ok := r.orgOf[workspaceID]; ok {
+		if p, ok := r.policies[orgID]; ok {
+			if !p.Enabled {
+				return nil
+			}
+			return p
+		}
+	}
+	if p, ok := r.policies[0]; ok && p.Enabled {
+		return p
+	}
+	return nil
+}
+
+func (s *TaskArtifactService) loadRetentionResolver() (*retentionResolver, error) {
+	var policies []models.ArtifactRetentionPolicy
+	if err := s.db.Find(&policies).Error; err != nil {
+		return nil, fmt.Errorf("failed to load retention policies: %w", err)
+	}
+
+	r := &retentionResolver{
+		policies: make(map[uint]*models.ArtifactRetentionPolicy, len(policies)),
+		orgOf:    make(map[string]uint),
+	}
+	for i := range policies {
+		r.policies[policies[i].OrganizationID] = &policies[i]
+	}
+
+	var rows []struct {
+		WorkspaceID string
+		OrgID       uint
+	}
+	if err := s.db.Table("workspace_project_relations AS wpr").
+		Select("wpr.workspace_id, p.org_id").
+		Joins("JOIN projects p ON p.id = wpr.project_id").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to resolve workspace organizations: %w", err)
+	}
+	for _, row := range rows {
+		r.orgOf[row.WorkspaceID] = row.OrgID
+	}
+	return r, nil
+}
+
+// minDays 所有启用策略中某个天数的最小值（0 表示没有策略设置该项）
+func (r *retentionResolver) minDays(get func(*models.ArtifactRetentionPolicy) int) int {
+	min := 0
+	for _, p := range r.policies {
+		if !p.Enabled || get(p) <= 0 {
+			continue
+		}
+		if min == 0 || get(p) < min {
+			min = get(p)
+		}
+	}
+	return min
+}
+
+// ApplyRetention 按各组织策略归档和清除到期的任务产物，单次最多处理 limit 个任务
+func (s *TaskArtifactService) ApplyRetention(ctx context.Context, now time.Time, limit int) (*RetentionRunResult, error) {
+	resolver, err := s.loadRetentionResolver()
+	if err != nil {
+		return nil, err
+	}
+	result := &RetentionRunResult{}
+
+	// 先清除：已到清除期限的任务无需先归档
+	if days := resolver.minDays(func(p *models.ArtifactRetentionPolicy) int { return p.PurgeAfterDays }); days > 0 {
+		purgedMarker := s.db.Model(&models.TaskArtifact{}).Select("task_id").
+			Where("kind = ? AND purged_at IS NOT NULL", models.TaskArtifactLogs)
+		err := s.forEachExpiredTask(ctx, now.AddDate(0, 0, -days), purgedMarker, limit, func(task *models.WorkspaceTask, finishedAt time.Time) (bool, error) {
+			p := resolver.policyFor(task.WorkspaceID)
+			if p == nil || p.PurgeAfterDays <= 0 || finishedAt.After(now.AddDate(0, 0, -p.PurgeAfterDays)) {
+				return false, nil
+			}
+			if err := s.PurgeTask(ctx, task.ID); err != nil {
+				return false, err
+			}
+			result.PurgedTasks++
+			return true, nil
+		})
+		if err != nil {
+			return result, err
+		}
+	}
+
+	if days := resolver.minDays(func(p *models.ArtifactRetentionPolicy) int { return p.ArchiveAfterDays }); days > 0 {
+		archivedMarker := s.db.Model(&models.TaskArtifact{}).Select("task_id").
+			Where("kind = ?", models.TaskArtifactLogs)
+		err := s.forEachExpiredTask(ctx, now.AddDate(0, 0, -days), archivedMarker, limit, func(task *models.WorkspaceTask, finishedAt time.Time) (bool, error) {
+			p := resolver.policyFor(task.WorkspaceID)
+			if p == nil || p.ArchiveAfterDays <= 0 || finishedAt.After(now.AddDate(0, 0, -p.ArchiveAfterDays)) {
+				return false, nil
+			}
+			if _, err := s.ArchiveTask(ctx, task.ID); err != nil {
+				return false, err
+			}
+			result.ArchivedTasks++
+			return true, nil
+		})
+		if err != nil {
+			return result, err
+		}
+	}
+
+	s.applyStateBackupRetention(ctx, resolver, now, result)
+	return result, nil
+}
+
+// forEachExpiredTask 按 ID 游标遍历在 cutoff 之前结束、且不在 exclude 子查询中的任务
+// fn 返回 true 表示处理了该任务，处理数达到 limit 后停止
+func (s *TaskArtifactService) forEachExpiredTask(ctx context.Context, cutoff time.Time, exclude *gorm.DB, limit int,
+	fn func(task *models.WorkspaceTask, finishedAt time.Time) (bool, error)) error {
+
+	processed := 0
+	var lastID uint
+	for processed < limit {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		var tasks []models.WorkspaceTask
+		if err := s.db.Select("id", "workspace_id", "status", "created_at", "completed_at").
+			Where("id > ? AND status IN ?", lastID, archivableTaskStatuses).
+			Where("(completed_at IS NOT NULL AND completed_at < ?) OR (completed_at IS NULL AND created_at < ?)", cutoff, cutoff).
+			Where("id NOT IN (?)", exclude).
+			Order("id ASC").Limit(200).
+			Find(&tasks).Error; err != nil {
+			return fmt.Errorf("failed to list expired tasks: %w", err)
+		}
+		if len(tasks) == 0 {
+			return nil
+		}
+
+		for i := range tasks {
+			task := &tasks[i]
+			lastID = task.ID
+			finishedAt := task.CreatedAt
+			if task.CompletedAt != nil {
+				finishedAt = *task.CompletedAt
+			}
+			done, err := fn(task, finishedAt)
+			if err != nil {
+				// 单个任务失败不阻塞其他任务，下一轮重试
+				log.Printf("[Artifacts] Task %d: %v", task.ID, err)
+				continue
+			}
+			if done {
+				processed++
+				if processed >= limit {
+					return nil
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// applyStateBackupRetention 处理本地 State 备份文件：到清除期限的删除，到归档期限的移入对象存储
+func (s *TaskArtifactService) applyStateBackupRetention(ctx context.Context, resolver *retentionResolver, now time.Time, result *RetentionRunResult) {
+	entries, err := os.ReadDir(s.backupDir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("[Artifacts] Failed to read state backup dir: %v", err)
+		}
+		return
+	}
+
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return
+		}
+		m := stateBackupPattern.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || m == nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		p := resolver.policyFor(m[1])
+		if p == nil {
+			continue
+		}
+
+		path := filepath.Join(s.backupDir, entry.Name())
+		if p.PurgeAfterDays > 0 && info.ModTime().Before(now.AddDate(0, 0, -p.PurgeAfterDays)) {
+			if err := os.Remove(path); err == nil {
+				result.PurgedStateBackups++
+			}
+			continue
+		}
+		if p.ArchiveAfterDays > 0 && info.ModTime().Before(now.AddDate(0, 0, -p.ArchiveAfterDays)) {
+			if err := s.ArchiveStateBackup(ctx, path); err != nil {
+				log.Printf("[Artifacts] Failed to archive state backup %s: %v", entry.Name(), err)
+				continue
+			}
+			result.ArchivedStateBackups++
+		}
+	}
+}
+
+func isArchivableStatus(status models.TaskStatus) bool {
+	for _, s := range archivableTaskStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// ============================================================================
+// Worker
+// ============================================================================
+
+// ArtifactRetentionWorker 周期执行保留策略（仅 leader 运行）
+type ArtifactRetentionWorker struct {
+	service *TaskArtifactService
+}
+
+// NewArtifactRetentionWorker 创建保留策略 worker
+func NewArtifactRetentionWorker(db *gorm.DB) *ArtifactRetentionWorker {
+	return &ArtifactRetentionWorker{service: NewTaskArtifactService(db)}
+}
+
+// Start 按 interval 周期执行，直到 ctx 取消
+func (w *ArtifactRetentionWorker) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Printf("[Artifacts] Retention worker started (interval %v)", interval)
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("[Artifacts] Retention worker stopped")
+			return
+		case <-ticker.C:
+			// 每轮重新加载存储配置，配置修改后无需重启
+			w.service.mu.Lock()
+			w.service.storage = nil
+			w.service.mu.Unlock()
+
+			result, err := w.service.ApplyRetention(ctx, time.Now(), 500)
+			if err != nil {
+				log.Printf("[Artifacts] Retention run failed: %v", err)
+				continue
+			}
+			if result.ArchivedTasks+result.PurgedTasks+result.ArchivedStateBackups+result.PurgedStateBackups > 0 {
+				log.Printf("[Artifacts] Retention run: archived %d tasks, purged %d tasks, archived %d state backups, purged %d state backups",
+					result.ArchivedTasks, result.PurgedTasks, result.ArchivedStateBackups, result.PurgedStateBackups)
+			}
+		}
+	}
+}
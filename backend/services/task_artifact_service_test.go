@@ -0,0 +1,306 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"iac-platform/internal/crypto"
+	"iac-platform/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// s3StandIn 模拟 MinIO 的 path-style 对象接口，校验 SigV4 签名头
+type s3StandIn struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	authErr string
+}
+
+func (s *s3StandIn) handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKIDTEST/") || !strings.Contains(auth, "/us-east-1/s3/aws4_request") {
+			s.authErr = auth
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			sum := sha256.Sum256(body)
+			if r.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(sum[:]) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			s.objects[r.URL.Path] = body
+		case http.MethodGet:
+			data, ok := s.objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`<Error><Code>NoSuchKey</Code></Error>`))
+				return
+			}
+			w.Write(data)
+		case http.MethodDelete:
+			delete(s.objects, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+}
+
+func newS3TestStorage(t *testing.T) (*S3ArtifactStorage, *s3StandIn) {
+	standIn := &s3StandIn{objects: make(map[string][]byte)}
+	srv := httptest.NewServer(standIn.handler())
+	t.Cleanup(srv.Close)
+
+	// 密钥加密使用由 JWT_SECRET 派生的密钥
+	t.Setenv("JWT_SECRET", "artifact-storage-test-secret")
+	secret, err := crypto.EncryptValue("secret-test")
+	require.NoError(t, err)
+	storage, err := newS3ArtifactStorage(&models.ArtifactStorageConfig{
+		Type:                     models.ArtifactStorageS3,
+		Endpoint:                 srv.URL,
+		Bucket:                   "iac-artifacts",
+		Prefix:                   "prod",
+		UsePathStyle:             true,
+		AccessKeyID:              "AKIDTEST",
+		SecretAccessKeyEncrypted: secret,
+	})
+	require.NoError(t, err)
+	return storage, standIn
+}
+
+func setupArtifactTestDB(t *testing.T) *gorm.DB {
+	db := setupTestDB(t)
+	for _, stmt := range []string{
+		`CREATE TABLE task_logs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT, task_id INTEGER NOT NULL, phase TEXT, content TEXT,
+			level TEXT, checksum TEXT, log_offset INTEGER, created_at DATETIME)`,
+		`CREATE TABLE task_artifacts (
+			id INTEGER PRIMARY KEY AUTOINCREMENT, task_id INTEGER NOT NULL, workspace_id TEXT NOT NULL,
+			kind TEXT NOT NULL, name TEXT, storage_type TEXT, storage_key TEXT, compression TEXT,
+			size_bytes INTEGER DEFAULT 0, stored_bytes INTEGER DEFAULT 0, checksum TEXT,
+			archived_at DATETIME, purged_at DATETIME, created_at DATETIME)`,
+		`CREATE TABLE artifact_retention_policies (
+			id INTEGER PRIMARY KEY AUTOINCREMENT, organization_id INTEGER NOT NULL UNIQUE,
+			enabled INTEGER DEFAULT 1, archive_after_days INTEGER DEFAULT 0, purge_after_days INTEGER DEFAULT 0,
+			updated_by TEXT, created_at DATETIME, updated_at DATETIME)`,
+		`CREATE TABLE projects (id INTEGER PRIMARY KEY AUTOINCREMENT, org_id INTEGER, name TEXT)`,
+		`CREATE TABLE workspace_project_relations (id INTEGER PRIMARY KEY AUTOINCREMENT, workspace_id TEXT, project_id INTEGER, created_at DATETIME)`,
+	} {
+		require.NoError(t, db.Exec(stmt).Error)
+	}
+	return db
+}
+
+// createFinishedTask 创建一个带日志和输出的已结束任务
+func createFinishedTask(t *testing.T, db *gorm.DB, workspaceID string, completedAt time.Time) *models.WorkspaceTask {
+	task := &models.WorkspaceTask{
+		WorkspaceID:   workspaceID,
+		TaskType:      models.TaskTypePlanAndApply,
+		Status:        models.TaskStatusApplied,
+		ExecutionMode: models.ExecutionModeLocal,
+		PlanOutput:    "Plan: 1 to add, 0 to change, 0 to destroy.",
+		ApplyOutput:   "Apply complete! Resources: 1 added.",
+		PlanData:      []byte{0x50, 0x4b, 0x03, 0x04, 0x00},
+		PlanJSON:      models.JSONB{"format_version": "1.2", "resource_changes": []interface{}{}},
+		CompletedAt:   &completedAt,
+	}
+	require.NoError(t, db.Create(task).Error)
+
+	for i, content := range []string{"terraform init", "terraform plan", "terraform apply"} {
+		require.NoError(t, db.Create(&models.TaskLog{
+			TaskID:    task.ID,
+			Phase:     "apply",
+			Content:   content,
+			Level:     "info",
+			CreatedAt: completedAt.Add(time.Duration(i) * time.Second),
+		}).Error)
+	}
+	return task
+}
+
+func TestLocalArtifactStorage_RoundTrip(t *testing.T) {
+	root := t.TempDir()
+	storage := NewLocalArtifactStorage(root)
+	ctx := context.Background()
+
+	require.NoError(t, storage.Put(ctx, "tasks/ws-1/1/logs.jsonl.gz", []byte("data")))
+	data, err := storage.Get(ctx, "tasks/ws-1/1/logs.jsonl.gz")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("data"), data)
+
+	// key 中的 .. 不能逃出根目录
+	require.NoError(t, storage.Put(ctx, "../../escape", []byte("x")))
+	_, err = os.Stat(filepath.Join(root, "escape"))
+	assert.NoError(t, err)
+
+	require.NoError(t, storage.Delete(ctx, "tasks/ws-1/1/logs.jsonl.gz"))
+	require.NoError(t, storage.Delete(ctx, "tasks/ws-1/1/logs.jsonl.gz"))
+	_, err = storage.Get(ctx, "tasks/ws-1/1/logs.jsonl.gz")
+	assert.ErrorIs(t, err, ErrArtifactNotFound)
+}
+
+func TestS3ArtifactStorage_SignedRoundTrip(t *testing.T) {
+	storage, standIn := newS3TestStorage(t)
+	ctx := context.Background()
+
+	require.NoError(t, storage.Put(ctx, "tasks/ws-1/7/plan.out.gz", []byte("plan-bytes")))
+	assert.Empty(t, standIn.authErr)
+	assert.Contains(t, standIn.objects, "/iac-artifacts/prod/tasks/ws-1/7/plan.out.gz")
+
+	data, err := storage.Get(ctx, "tasks/ws-1/7/plan.out.gz")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("plan-bytes"), data)
+
+	require.NoError(t, storage.Delete(ctx, "tasks/ws-1/7/plan.out.gz"))
+	_, err = storage.Get(ctx, "tasks/ws-1/7/plan.out.gz")
+	assert.ErrorIs(t, err, ErrArtifactNotFound)
+}
+
+func TestTaskArtifactService_ArchiveAndReadBack(t *testing.T) {
+	db := setupArtifactTestDB(t)
+	storage, standIn := newS3TestStorage(t)
+	service := NewTaskArtifactServiceWithStorage(db, storage)
+	ctx := context.Background()
+
+	task := createFinishedTask(t, db, "ws-archive", time.Now().Add(-40*24*time.Hour))
+
+	count, err := service.ArchiveTask(ctx, task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 5, count)
+	assert.Len(t, standIn.objects, 5)
+
+	// 数据库中的大字段和日志行已清空
+	var stored models.WorkspaceTask
+	require.NoError(t, db.First(&stored, task.ID).Error)
+	assert.Empty(t, stored.PlanOutput)
+	assert.Empty(t, stored.ApplyOutput)
+	assert.Empty(t, stored.PlanData)
+	assert.Nil(t, stored.PlanJSON)
+	var logCount int64
+	db.Model(&models.TaskLog{}).Where("task_id = ?", task.ID).Count(&logCount)
+	assert.Zero(t, logCount)
+
+	// 透明读取
+	require.NoError(t, service.HydrateTask(ctx, &stored))
+	assert.Equal(t, task.PlanOutput, stored.PlanOutput)
+	assert.Equal(t, task.ApplyOutput, stored.ApplyOutput)
+	assert.Equal(t, task.PlanData, stored.PlanData)
+	assert.Equal(t, "1.2", stored.PlanJSON["format_version"])
+
+	logs, err := service.LoadTaskLogs(ctx, task.ID)
+	require.NoError(t, err)
+	require.Len(t, logs, 3)
+	assert.Equal(t, "terraform init", logs[0].Content)
+	assert.Equal(t, "terraform apply", logs[2].Content)
+	assert.Equal(t, "archived", service.ArtifactStatus(task.ID))
+
+	// 重复归档无副作用
+	count, err = service.ArchiveTask(ctx, task.ID)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestTaskArtifactService_DoesNotArchiveUnfinishedTask(t *testing.T) {
+	db := setupArtifactTestDB(t)
+	service := NewTaskArtifactServiceWithStorage(db, NewLocalArtifactStorage(t.TempDir()))
+
+	task := createFinishedTask(t, db, "ws-pending", time.Now().Add(-40*24*time.Hour))
+	require.NoError(t, db.Model(task).Update("status", models.TaskStatusApplyPending).Error)
+
+	_, err := service.ArchiveTask(context.Background(), task.ID)
+	assert.Error(t, err)
+
+	var stored models.WorkspaceTask
+	require.NoError(t, db.First(&stored, task.ID).Error)
+	assert.NotEmpty(t, stored.PlanData)
+}
+
+func TestTaskArtifactService_ApplyRetentionPerOrganization(t *testing.T) {
+	db := setupArtifactTestDB(t)
+	storage := NewLocalArtifactStorage(t.TempDir())
+	service := NewTaskArtifactServiceWithStorage(db, storage)
+	backupDir := t.TempDir()
+	service.SetBackupDir(backupDir)
+	ctx := context.Background()
+	now := time.Now()
+
+	// 组织 2 保留更久；ws-default 不属于任何组织，使用平台默认策略
+	require.NoError(t, db.Exec(`INSERT INTO projects (id, org_id, name) VALUES (1, 2, 'long-retention')`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO workspace_project_relations (workspace_id, project_id) VALUES ('ws-org2', 1)`).Error)
+	require.NoError(t, db.Create(&models.ArtifactRetentionPolicy{OrganizationID: 0, Enabled: true, ArchiveAfterDays: 7, PurgeAfterDays: 30}).Error)
+	require.NoError(t, db.Create(&models.ArtifactRetentionPolicy{OrganizationID: 2, Enabled: true, ArchiveAfterDays: 60, PurgeAfterDays: 365}).Error)
+
+	recent := createFinishedTask(t, db, "ws-default", now.Add(-2*24*time.Hour))
+	toArchive := createFinishedTask(t, db, "ws-default", now.Add(-10*24*time.Hour))
+	toPurge := createFinishedTask(t, db, "ws-default", now.Add(-45*24*time.Hour))
+	orgTask := createFinishedTask(t, db, "ws-org2", now.Add(-45*24*time.Hour))
+
+	// State 备份：一个到归档期限，一个到清除期限
+	archiveBackup := filepath.Join(backupDir, "ws_ws-default_task_9_100.tfstate")
+	purgeBackup := filepath.Join(backupDir, "ws_ws-default_task_8_100.tfstate")
+	require.NoError(t, os.WriteFile(archiveBackup, []byte(`{"serial":3}`), 0600))
+	require.NoError(t, os.WriteFile(purgeBackup, []byte(`{"serial":2}`), 0600))
+	require.NoError(t, os.Chtimes(archiveBackup, now.Add(-10*24*time.Hour), now.Add(-10*24*time.Hour)))
+	require.NoError(t, os.Chtimes(purgeBackup, now.Add(-45*24*time.Hour), now.Add(-45*24*time.Hour)))
+
+	result, err := service.ApplyRetention(ctx, now, 100)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.ArchivedTasks)
+	assert.Equal(t, 1, result.PurgedTasks)
+	assert.Equal(t, 1, result.ArchivedStateBackups)
+	assert.Equal(t, 1, result.PurgedStateBackups)
+
+	assert.Equal(t, "", service.ArtifactStatus(recent.ID))
+	assert.Equal(t, "archived", service.ArtifactStatus(toArchive.ID))
+	assert.Equal(t, "purged", service.ArtifactStatus(toPurge.ID))
+	assert.Equal(t, "", service.ArtifactStatus(orgTask.ID))
+
+	// 已清除的任务读取不到内容，数据库中也没有残留
+	logs, err := service.LoadTaskLogs(ctx, toPurge.ID)
+	require.NoError(t, err)
+	assert.Empty(t, logs)
+	var purged models.WorkspaceTask
+	require.NoError(t, db.First(&purged, toPurge.ID).Error)
+	assert.Empty(t, purged.PlanOutput)
+	assert.Empty(t, purged.PlanData)
+
+	// 归档的 State 备份可以按原文件名读回
+	_, err = os.Stat(archiveBackup)
+	assert.True(t, os.IsNotExist(err))
+	data, err := service.LoadStateBackup(ctx, 9, filepath.Base(archiveBackup))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"serial":3}`, string(data))
+
+	// 归档任务到期后清除对象
+	result, err = service.ApplyRetention(ctx, now.Add(25*24*time.Hour), 100)
+	require.NoError(t, err)
+	assert.Equal(t, "purged", service.ArtifactStatus(toArchive.ID))
+	var artifact models.TaskArtifact
+	require.NoError(t, db.Where("task_id = ? AND kind = ?", toArchive.ID, models.TaskArtifactPlanData).First(&artifact).Error)
+	_, err = storage.Get(ctx, artifact.StorageKey)
+	assert.ErrorIs(t, err, ErrArtifactNotFound)
+
+	// 再次执行无事可做
+	result, err = service.ApplyRetention(ctx, now.Add(25*24*time.Hour), 100)
+	require.NoError(t, err)
+	assert.Zero(t, result.ArchivedTasks+result.PurgedTasks)
+}
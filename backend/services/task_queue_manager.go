@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"hash/fnv"
 	"log"
@@ -173,14 +174,15 @@ func (m *TaskQueueManager) GetNextExecutableTask(workspaceID string) (*models.Wo
 		First(&planAndApplyTask).Error
 
 	if err == nil {
-		// 找到plan_and_apply pending任务,检查是否有running/pending/apply_pending的plan_and_apply任务阻塞它
+		// 找到plan_and_apply pending任务,检查是否有running/pending/apply_pending/state_pending_upload的plan_and_apply任务阻塞它
+		// state_pending_upload: 上一次Apply的State还在Agent spool中，基于旧State执行会产生错误的Plan
 		var otherBlockingCount int64
 		m.db.Model(&models.WorkspaceTask{}).
 			Where("workspace_id = ? AND task_type = ? AND id < ? AND status IN (?)",
 				workspaceID,
 				models.TaskTypePlanAndApply,
 				planAndApplyTask.ID,
-				[]models.TaskStatus{models.TaskStatusPending, models.TaskStatusRunning, models.TaskStatusApplyPending}).
+				[]models.TaskStatus{models.TaskStatusPending, models.TaskStatusRunning, models.TaskStatusApplyPending, models.TaskStatusStatePendingUpload}).
 			Count(&otherBlockingCount)
 
 		if otherBlockingCount > 0 {
@@ -213,10 +215,10 @@ func (m *TaskQueueManager) GetNextExecutableTask(workspaceID string) (*models.Wo
 		return nil, err
 	}
 
-	// 3. 获取drift_check任务（后台任务,可以并发执行）
+	// 3. 获取drift_check / test任务（后台任务,可以并发执行）
 	var driftCheckTask models.WorkspaceTask
-	err = m.db.Where("workspace_id = ? AND task_type = ? AND status = ?",
-		workspaceID, models.TaskTypeDriftCheck, models.TaskStatusPending).
+	err = m.db.Where("workspace_id = ? AND task_type IN ? AND status = ?",
+		workspaceID, []models.TaskType{models.TaskTypeDriftCheck, models.TaskTypeTest}, models.TaskStatusPending).
 		Order("created_at ASC").
 		First(&driftCheckTask).Error
 
@@ -230,7 +232,7 @@ func (m *TaskQueueManager) GetNextExecutableTask(workspaceID string) (*models.Wo
 		return nil, err
 	}
 
-	log.Printf("[TaskQueue] Found drift_check pending task %d for workspace %s (background task)", driftCheckTask.ID, workspaceID)
+	log.Printf("[TaskQueue] Found %s pending task %d for workspace %s (background task)", driftCheckTask.TaskType, driftCheckTask.ID, workspaceID)
 	return &driftCheckTask, nil
 }
 
@@ -931,8 +933,12 @@ func (m *TaskQueueManager) executeTask(task *models.WorkspaceTask, action string
 		// 发送任务开始执行通知（Local 模式，与 Agent 模式 pushTaskToAgent 对齐）
 		go m.sendTaskStartNotification(task, "plan")
 
-		// 执行Plan
-		err = m.executor.ExecutePlan(ctx, task)
+		// 执行Plan（test 任务执行 terraform test）
+		if task.TaskType == models.TaskTypeTest {
+			err = m.executor.ExecuteTest(ctx, task)
+		} else {
+			err = m.executor.ExecutePlan(ctx, task)
+		}
 
 		if err != nil {
 			// ExecutePlan已经通过saveTaskFailure保存了详细错误信息
@@ -1545,6 +1551,11 @@ func (m *TaskQueueManager) ExecuteConfirmedApply(workspaceID string, taskID uint
 		return fmt.Errorf("task %d has not been confirmed by user", taskID)
 	}
 
+	// 成本预算二次检查（mandatory 预算超出时禁止 apply）
+	if err := NewCostEstimationService(m.db).CheckApplyAllowed(taskID); err != nil {
+		return err
+	}
+
 	log.Printf("[TaskQueue] Task %d confirmed by %s at %v, proceeding with apply execution",
 		taskID, *task.ApplyConfirmedBy, task.ApplyConfirmedAt)
 
@@ -1554,6 +1565,12 @@ func (m *TaskQueueManager) ExecuteConfirmedApply(workspaceID string, taskID uint
 		return fmt.Errorf("workspace not found: %w", err)
 	}
 
+	// 变更冻结检查：冻结期间任务保持 apply_pending（stage = change_freeze），
+	// 冻结结束或例外被批准后由 pending tasks monitor 重新投递
+	if err := m.holdForChangeFreeze(&task, &workspace); err != nil {
+		return err
+	}
+
 	// Execute based on execution mode
 	if workspace.ExecutionMode == models.ExecutionModeK8s || workspace.ExecutionMode == models.ExecutionModeAgent {
 		return m.pushTaskToAgent(&task, &workspace)
@@ -1568,3 +1585,42 @@ func (m *TaskQueueManager) ExecuteConfirmedApply(workspaceID string, taskID uint
 	go m.executeTask(&task, "apply")
 	return nil
 }
+
+// holdForChangeFreeze 检查已确认的 apply 是否被变更冻结阻塞
+// 首次被阻塞时将 stage 标记为 change_freeze 并记录审计事件；放行时恢复 stage 为 apply_pending
+func (m *TaskQueueManager) holdForChangeFreeze(task *models.WorkspaceTask, workspace *models.Workspace) error {
+	err := NewChangeFreezeService(m.db).CheckApplyAllowed(task.ID, workspace)
+	if err == nil {
+		if task.Stage == ChangeFreezeStage {
+			m.db.Model(task).Update("stage", "apply_pending")
+			task.Stage = "apply_pending"
+			log.Printf("[TaskQueue] Task %d released from change freeze", task.ID)
+		}
+		return nil
+	}
+	if !errors.Is(err, ErrChangeFreezeActive) {
+		return err
+	}
+
+	if task.Stage != ChangeFreezeStage {
+		if updateErr := m.db.Model(task).Update("stage", ChangeFreezeStage).Error; updateErr != nil {
+			log.Printf("[TaskQueue] Failed to mark task %d as held by change freeze: %v", task.ID, updateErr)
+		}
+		task.Stage = ChangeFreezeStage
+		log.Printf("[TaskQueue] Apply for task %d held: %v", task.ID, err)
+
+		NewAuditEventService(m.db).Emit(&models.AuditEvent{
+			EventType:    models.AuditEventApplyBlockedByFreeze,
+			ActorType:    models.AuditActorSystem,
+			ActorID:      "task-queue",
+			ResourceType: "task",
+			ResourceID:   fmt.Sprintf("%d", task.ID),
+			WorkspaceID:  workspace.WorkspaceID,
+			TaskID:       &task.ID,
+			Outcome:      models.AuditOutcomeDenied,
+			Message:      fmt.Sprintf("Apply for task #%d held by change freeze", task.ID),
+			Details:      models.JSONB{"reason": strings.TrimPrefix(err.Error(), ErrChangeFreezeActive.Error()+": ")},
+		})
+	}
+	return err
+}
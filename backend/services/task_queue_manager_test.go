@@ -273,6 +273,62 @@ func setupTestDB(t *testing.T) *gorm.DB {
 	)`)
 	require.NoError(t, err)
 
+	// Task cost estimates — checked before executing confirmed applies
+	_, err = sqlDB.Exec(`CREATE TABLE task_cost_estimates (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		task_id INTEGER NOT NULL UNIQUE,
+		workspace_id TEXT NOT NULL,
+		currency TEXT DEFAULT 'USD',
+		monthly_before REAL DEFAULT 0,
+		monthly_after REAL DEFAULT 0,
+		monthly_delta REAL DEFAULT 0,
+		priced_count INTEGER DEFAULT 0,
+		free_count INTEGER DEFAULT 0,
+		unsupported_count INTEGER DEFAULT 0,
+		pricing_source TEXT,
+		pricing_region TEXT,
+		budget_status TEXT DEFAULT 'none',
+		budget_message TEXT,
+		enforcement_level TEXT DEFAULT 'advisory',
+		blocking INTEGER DEFAULT 0,
+		created_at DATETIME,
+		updated_at DATETIME
+	)`)
+	require.NoError(t, err)
+
+	// Change freeze calendar — checked before executing confirmed applies
+	_, err = sqlDB.Exec(`CREATE TABLE change_freeze_windows (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		description TEXT,
+		scope TEXT NOT NULL,
+		selector TEXT,
+		timezone TEXT DEFAULT 'UTC',
+		schedule TEXT,
+		enabled INTEGER DEFAULT 1,
+		created_by TEXT,
+		updated_by TEXT,
+		created_at DATETIME,
+		updated_at DATETIME
+	)`)
+	require.NoError(t, err)
+
+	_, err = sqlDB.Exec(`CREATE TABLE change_freeze_exceptions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		task_id INTEGER NOT NULL,
+		workspace_id TEXT NOT NULL,
+		window_ids TEXT,
+		reason TEXT NOT NULL,
+		status TEXT DEFAULT 'pending',
+		requested_by TEXT NOT NULL,
+		reviewed_by TEXT,
+		review_comment TEXT,
+		reviewed_at DATETIME,
+		created_at DATETIME,
+		updated_at DATETIME
+	)`)
+	require.NoError(t, err)
+
 	return db
 }
 
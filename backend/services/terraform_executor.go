@@ -8,6 +8,7 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -20,6 +21,7 @@ import (
 	"time"
 
 	"iac-platform/internal/models"
+	"iac-platform/internal/secretref"
 
 	"gorm.io/gorm"
 )
@@ -35,6 +37,9 @@ type TerraformExecutor struct {
 	cachedBinaryVersion string               // 缓存的版本号
 	runTaskExecutor     *RunTaskExecutor     // Run Task 执行器
 	notificationSender  *NotificationSender  // 通知发送器
+	secretResolver      *secretref.Resolver  // 外部密文引用解析器（按执行者环境配置）
+
+	remoteStateCredentials sync.Map // workDir -> *RemoteStateCredentials，只通过环境变量传给 terraform
 }
 
 // NewTerraformExecutor 创建Terraform执行器（向后兼容）
@@ -51,6 +56,7 @@ func NewTerraformExecutor(db *gorm.DB, streamManager *OutputStreamManager) *Terr
 		downloader:         NewTerraformDownloader(db), // 初始化下载器
 		runTaskExecutor:    nil,                        // 延迟初始化，需要 baseURL
 		notificationSender: NewNotificationSender(db, baseURL),
+		secretResolver:     secretref.NewResolverFromEnv(),
 	}
 }
 
@@ -106,11 +112,12 @@ func NewTerraformExecutorWithAccessor(accessor DataAccessor, streamManager *Outp
 	}
 
 	return &TerraformExecutor{
-		db:            nil, // Agent 模式下不需要直接访问数据库
-		dataAccessor:  accessor,
-		streamManager: streamManager,
-		signalManager: GetSignalManager(),
-		downloader:    downloader, // Agent 模式下也使用下载器
+		db:             nil, // Agent 模式下不需要直接访问数据库
+		dataAccessor:   accessor,
+		streamManager:  streamManager,
+		signalManager:  GetSignalManager(),
+		downloader:     downloader, // Agent 模式下也使用下载器
+		secretResolver: secretref.NewResolverFromEnv(),
 	}
 }
 
@@ -161,6 +168,7 @@ func (s *TerraformExecutor) PrepareWorkspace(task *models.WorkspaceTask) (string
 
 // CleanupWorkspace 清理工作目录
 func (s *TerraformExecutor) CleanupWorkspace(workDir string) error {
+	s.remoteStateCredentials.Delete(workDir)
 	if _, err := os.Stat(workDir); os.IsNotExist(err) {
 		return nil
 	}
@@ -198,7 +206,11 @@ func (s *TerraformExecutor) GenerateConfigFiles(
 	// 清理空的terraform块，避免Terraform尝试读取不存在的backend state
 	if workspace.ProviderConfig != nil && len(workspace.ProviderConfig) > 0 {
 		cleanedProviderConfig := s.cleanProviderConfig(workspace.ProviderConfig)
-		if err := s.writeJSONFile(workDir, "provider.tf.json", cleanedProviderConfig); err != nil {
+		resolvedProviderConfig, err := s.resolveProviderConfig(cleanedProviderConfig)
+		if err != nil {
+			return err
+		}
+		if err := s.writeJSONFile(workDir, "provider.tf.json", resolvedProviderConfig); err != nil {
 			return fmt.Errorf("failed to write provider.tf.json: %w", err)
 		}
 	}
@@ -251,7 +263,7 @@ func (s *TerraformExecutor) generateVariablesTFJSON(
 			varDef["description"] = v.Description
 		}
 
-		if v.Sensitive {
+		if v.Sensitive || v.IsSecretRef() {
 			varDef["sensitive"] = true
 		}
 
@@ -286,6 +298,16 @@ func (s *TerraformExecutor) generateVariablesTFVars(
 	}
 
 	for _, v := range workspaceVars {
+		// 密文引用在执行前解析，解析值只写入工作目录
+		if v.IsSecretRef() {
+			value, err := s.resolveVariableValue(v)
+			if err != nil {
+				return err
+			}
+			tfvars.WriteString(fmt.Sprintf("%s = %s\n", v.Key, quoteTFVarsString(value)))
+			continue
+		}
+
 		// 根据ValueFormat处理
 		if v.ValueFormat == models.ValueFormatHCL {
 			// HCL格式：需要判断是否为string类型
@@ -551,7 +573,7 @@ func (s *TerraformExecutor) TerraformInit(
 	cmd.Dir = workDir
 
 	// 设置环境变量
-	cmd.Env = s.buildEnvironmentVariables(workspace)
+	cmd.Env = append(s.buildEnvironmentVariables(workspace), s.remoteStateEnv(workDir)...)
 
 	// 添加插件缓存目录（如果创建成功）
 	if pluginCacheDir != "" {
@@ -611,7 +633,13 @@ func (s *TerraformExecutor) buildEnvironmentVariables(
 			if v.Key == "TF_CLI_ARGS" {
 				continue
 			}
-			env = append(env, fmt.Sprintf("%s=%s", v.Key, v.Value))
+			value, err := s.resolveVariableValue(v)
+			if err != nil {
+				// 执行前已由 verifyEnvironmentSecretRefs 校验，这里只记录引用错误
+				log.Printf("WARNING: Skipped environment variable %s: %v", v.Key, err)
+				continue
+			}
+			env = append(env, fmt.Sprintf("%s=%s", v.Key, value))
 			// 绝对禁止打印变量值 - 只打印变量名
 			log.Printf("DEBUG: Added environment variable: %s", v.Key)
 		}
@@ -907,6 +935,17 @@ func (s *TerraformExecutor) ExecutePlan(
 
 	logger.StageEnd("init")
 
+	// ========== 阶段2.4: Static Analysis ==========
+	if err := s.runStaticAnalysisStage(ctx, task, workspace, workDir, logger); err != nil {
+		if ctx.Err() == context.Canceled {
+			logger.Info("Task cancelled by user during static analysis")
+			s.saveTaskCancellation(task, logger, "plan")
+			return fmt.Errorf("task cancelled by user")
+		}
+		s.saveTaskFailure(task, logger, err, "plan")
+		return fmt.Errorf("static analysis blocked execution: %w", err)
+	}
+
 	// ========== 阶段2.5: Pre-Plan Run Tasks ==========
 	logger.StageBegin("pre_plan_run_tasks")
 	logger.Info("Executing pre-plan Run Tasks...")
@@ -998,7 +1037,7 @@ func (s *TerraformExecutor) ExecutePlan(
 
 	cmd := exec.CommandContext(ctx, terraformCmd, args...)
 	cmd.Dir = workDir
-	cmd.Env = s.buildEnvironmentVariables(workspace)
+	cmd.Env = append(s.buildEnvironmentVariables(workspace), s.remoteStateEnv(workDir)...)
 
 	// 使用Pipe实时捕获输出
 	stdoutPipe, err := cmd.StdoutPipe()
@@ -1134,7 +1173,7 @@ func (s *TerraformExecutor) ExecutePlan(
 	// 保存Plan数据到数据库
 	logger.Info("Saving plan data to database...")
 	log.Printf("[CRITICAL] About to call SavePlanDataWithLogging for task %d", task.ID)
-	s.SavePlanDataWithLogging(task, planFile, planJSON, logger)
+	s.SavePlanDataWithLogging(task, workspace, planFile, planJSON, logger)
 	log.Printf("[CRITICAL] SavePlanDataWithLogging completed for task %d", task.ID)
 
 	// 【新增】异步解析并存储资源变更（用于Structured Run Output）
@@ -1172,6 +1211,10 @@ func (s *TerraformExecutor) ExecutePlan(
 
 	logger.StageEnd("saving_plan")
 
+	// ========== 阶段4.4: Cost Estimation ==========
+	// 估算失败不影响 plan 结果；超出 mandatory 预算时在 apply 确认时阻断
+	s.runCostEstimationStage(ctx, task, planJSON, logger)
+
 	// ========== 阶段4.5: Post-Plan Run Tasks ==========
 	// 在 Plan 数据保存后执行 post_plan 阶段的 Run Tasks
 	// Run Task 需要访问 plan_json 来分析变更，所以必须在保存后执行
@@ -1445,6 +1488,13 @@ func (s *TerraformExecutor) GeneratePlanJSON(
 		return nil, fmt.Errorf("failed to parse plan JSON: %w", err)
 	}
 
+	// plan JSON 会保存到平台，去除密文引用的解析值
+	if workspace != nil {
+		if err := s.redactSecretRefPlanJSON(planJSON, workspace); err != nil {
+			return nil, err
+		}
+	}
+
 	return planJSON, nil
 }
 
@@ -2202,7 +2252,29 @@ func (s *TerraformExecutor) ExecuteApply(
 		}
 	}
 
-	if needRestorePlan {
+	if needRestorePlan && len(planTask.PlanData) == 0 && planTask.PlanHash != "" {
+		// plan 引用了密文，plan 文件未上传：优先复用执行者上保留的 plan，否则重新 plan
+		logger.StageBegin("restoring_plan")
+		if s.verifyPlanHash(workDir, planTask.PlanHash, logger) {
+			logger.Info("✓ Using plan file kept on the executor (plan references secrets)")
+		} else {
+			logger.Info("Plan file references secrets and was not uploaded, re-planning before apply...")
+			if err := s.replanForApply(ctx, planTask, workspace, workDir, planFile, logger); err != nil {
+				logger.LogError("restoring_plan", err, map[string]interface{}{
+					"plan_task_id": planTask.ID,
+				}, nil)
+				logger.StageEnd("restoring_plan")
+				if ctx.Err() == context.Canceled {
+					s.saveTaskCancellation(task, logger, "apply")
+					return fmt.Errorf("task cancelled by user")
+				}
+				s.saveTaskFailure(task, logger, err, "apply")
+				return err
+			}
+			logger.Info("✓ Re-planned changes match the approved plan")
+		}
+		logger.StageEnd("restoring_plan")
+	} else if needRestorePlan {
 		logger.StageBegin("restoring_plan")
 
 		logger.Info("Restoring plan file from plan task #%d...", planTask.ID)
@@ -2305,7 +2377,7 @@ func (s *TerraformExecutor) ExecuteApply(
 
 	cmd := exec.CommandContext(ctx, terraformCmd, args...)
 	cmd.Dir = workDir
-	cmd.Env = s.buildEnvironmentVariables(workspace)
+	cmd.Env = append(s.buildEnvironmentVariables(workspace), s.remoteStateEnv(workDir)...)
 
 	// 使用Pipe实时捕获输出
 	stdoutPipe, err := cmd.StdoutPipe()
@@ -2453,7 +2525,29 @@ func (s *TerraformExecutor) ExecuteApply(
 	s.saveTaskLog(task.ID, "apply", applyOutputBeforeState, "info")
 	log.Printf("Task %d apply output saved before state (%d bytes)", task.ID, len(applyOutputBeforeState))
 
-	if err := s.SaveNewStateVersionWithLogging(workspace, task, workDir, logger); err != nil {
+	if err := s.SaveNewStateVersionWithLogging(workspace, task, workDir, logger); errors.Is(err, ErrUploadPending) {
+		// Apply 已成功，State 在 Agent 本地 spool 中等待上传：任务不标记为失败
+		// 服务端收到 State 后将任务转为 applied，在此之前同一 Workspace 的后续 plan_and_apply 任务保持阻塞
+		logger.StageEnd("saving_state")
+
+		task.ApplyOutput = logger.GetFullOutput()
+		task.Status = models.TaskStatusStatePendingUpload
+		task.Stage = string(models.TaskStatusStatePendingUpload)
+		task.ErrorMessage = "Apply succeeded; state is kept in the agent spool and pending upload"
+		task.Duration += int(duration.Seconds())
+		if err := s.dataAccessor.UpdateTask(task); err != nil {
+			log.Printf("Task %d: status update pending: %v", task.ID, err)
+		}
+		s.saveTaskLog(task.ID, "apply", task.ApplyOutput, "warning")
+
+		stream.Broadcast(OutputMessage{
+			Type:      "completed",
+			Timestamp: time.Now(),
+		})
+
+		log.Printf("Task %d applied, state pending upload", task.ID)
+		return nil
+	} else if err != nil {
 		logger.LogError("saving_state", err, map[string]interface{}{
 			"workspace_id": workspace.WorkspaceID,
 			"task_id":      task.ID,
@@ -2642,6 +2736,89 @@ func (s *TerraformExecutor) RestorePlanFile(
 	return planFile, nil
 }
 
+// replanForApply 在 plan 文件未上传（引用了密文）且执行者上没有保留时重新 plan，
+// 新 plan 的资源变更必须与已审批的 plan 一致，否则拒绝 Apply
+func (s *TerraformExecutor) replanForApply(
+	ctx context.Context,
+	planTask *models.WorkspaceTask,
+	workspace *models.Workspace,
+	workDir string,
+	planFile string,
+	logger *TerraformLogger,
+) error {
+	if s.downloader == nil {
+		return fmt.Errorf("terraform downloader not initialized")
+	}
+	binaryPath, err := s.downloader.EnsureTerraformBinary(workspace.TerraformVersion)
+	if err != nil {
+		return fmt.Errorf("failed to ensure terraform binary for version %s: %w", workspace.TerraformVersion, err)
+	}
+
+	args := []string{"plan", "-out=" + planFile, "-no-color", "-var-file=variables.tfvars"}
+	args = append(args, s.getTFCLIArgs(workspace.WorkspaceID)...)
+	if targets, ok := planTask.Context["targets"].([]string); ok {
+		for _, target := range targets {
+			args = append(args, "-target="+target)
+		}
+	}
+
+	cmd := exec.CommandContext(ctx, binaryPath, args...)
+	cmd.Dir = workDir
+	cmd.Env = append(s.buildEnvironmentVariables(workspace), s.remoteStateEnv(workDir)...)
+	output, err := cmd.CombinedOutput()
+	for _, line := range strings.Split(strings.TrimRight(string(output), "\n"), "\n") {
+		logger.RawOutput(line)
+	}
+	if err != nil {
+		return fmt.Errorf("terraform plan failed: %w", err)
+	}
+
+	planJSON, err := s.GeneratePlanJSON(ctx, workDir, planFile, workspace)
+	if err != nil {
+		return err
+	}
+	return s.verifyReplannedChanges(planTask, planJSON)
+}
+
+// verifyReplannedChanges 比较重新 plan 的资源变更与已审批 plan 是否一致
+func (s *TerraformExecutor) verifyReplannedChanges(planTask *models.WorkspaceTask, planJSON map[string]interface{}) error {
+	add, change, destroy := s.parsePlanChanges(planJSON)
+	if add != planTask.ChangesAdd || change != planTask.ChangesChange || destroy != planTask.ChangesDestroy {
+		return fmt.Errorf("infrastructure changed since plan #%d was approved (planned %d to add, %d to change, %d to destroy; now %d, %d, %d), please run a new plan",
+			planTask.ID, planTask.ChangesAdd, planTask.ChangesChange, planTask.ChangesDestroy, add, change, destroy)
+	}
+	if planTask.PlanJSON == nil {
+		return nil
+	}
+
+	approved := s.planResourceActions(planTask.PlanJSON)
+	current := s.planResourceActions(planJSON)
+	for address, action := range approved {
+		if current[address] != action {
+			return fmt.Errorf("infrastructure changed since plan #%d was approved (%s: planned %s, now %q), please run a new plan",
+				planTask.ID, address, action, current[address])
+		}
+	}
+	for address := range current {
+		if _, ok := approved[address]; !ok {
+			return fmt.Errorf("infrastructure changed since plan #%d was approved (%s was not in the plan), please run a new plan",
+				planTask.ID, address)
+		}
+	}
+	return nil
+}
+
+// planResourceActions 返回 plan JSON 中每个资源地址的操作类型（忽略 no-op）
+func (s *TerraformExecutor) planResourceActions(planJSON map[string]interface{}) map[string]string {
+	actions := make(map[string]string)
+	for _, rc := range s.parseResourceChangesFromPlanJSON(planJSON) {
+		address, _ := rc["resource_address"].(string)
+		action, _ := rc["action"].(string)
+		actions[address] = action
+	}
+	return actions
+}
+
 // SaveNewStateVersion 保存新的State版本（带容错）
 func (s *TerraformExecutor) SaveNewStateVersion(
 	workspace *models.Workspace,
@@ -2657,7 +2834,7 @@ func (s *TerraformExecutor) SaveNewStateVersion(
 	}
 
 	// 2. 立即备份到文件系统（第一道保险）
-	backupDir := "/var/backup/states"
+	backupDir := StateBackupDir
 	os.MkdirAll(backupDir, 0700)
 	backupPath := filepath.Join(backupDir,
 		fmt.Sprintf("ws_%s_task_%d_%d.tfstate",
@@ -2683,6 +2860,12 @@ func (s *TerraformExecutor) SaveNewStateVersion(
 
 		log.Printf("Failed to save state (attempt %d/%d): %v", i+1, maxRetries, saveErr)
 
+		// State 已写入 Agent 本地 spool，由重连后的重放负责上传，无需继续重试
+		if errors.Is(saveErr, ErrUploadPending) {
+			log.Printf("State for task %d kept in agent spool, pending upload", task.ID)
+			return saveErr
+		}
+
 		if i < maxRetries-1 {
 			time.Sleep(time.Duration(i+1) * 2 * time.Second)
 		}
@@ -2725,16 +2908,15 @@ func (s *TerraformExecutor) SaveStateToDatabase(
 
 	checksum := s.calculateChecksum(stateData)
 
-	// 使用 DataAccessor 获取最大版本号
-	maxVersion, err := s.dataAccessor.GetMaxStateVersion(workspace.WorkspaceID)
-	if err != nil {
-		return fmt.Errorf("failed to get max state version: %w", err)
-	}
-
-	newVersion := maxVersion + 1
-
 	// Agent 模式不支持事务，需要顺序执行
 	if s.db != nil {
+		// 使用 DataAccessor 获取最大版本号
+		maxVersion, err := s.dataAccessor.GetMaxStateVersion(workspace.WorkspaceID)
+		if err != nil {
+			return fmt.Errorf("failed to get max state version: %w", err)
+		}
+		newVersion := maxVersion + 1
+
 		// Local 模式：使用事务
 		return s.db.Transaction(func(tx *gorm.DB) error {
 			stateVersion := &models.WorkspaceStateVersion{
@@ -2756,9 +2938,9 @@ func (s *TerraformExecutor) SaveStateToDatabase(
 		})
 	} else {
 		// Agent 模式：顺序执行（通过 DataAccessor）
+		// 版本号由服务端分配，这里不查询最大版本，服务端不可用时 State 仍能先写入本地 spool
 		stateVersion := &models.WorkspaceStateVersion{
 			WorkspaceID: workspace.WorkspaceID,
-			Version:     newVersion,
 			Content:     stateContent,
 			Checksum:    checksum,
 			SizeBytes:   len(stateData),
@@ -2999,6 +3181,13 @@ func (s *TerraformExecutor) mergeTFCode(target, source map[string]interface{}) {
 					continue
 				}
 			}
+			// 列表形式的块（如 import、moved）来自多个资源，需要追加而不是覆盖
+			if existingList, ok := existing.([]interface{}); ok {
+				if sourceList, ok := value.([]interface{}); ok {
+					target[key] = append(existingList, sourceList...)
+					continue
+				}
+			}
 		}
 		target[key] = value
 	}
@@ -3357,6 +3546,10 @@ func (s *TerraformExecutor) GenerateConfigFilesWithLogging(
 	workDir string,
 	logger *TerraformLogger,
 ) error {
+	if err := s.verifyEnvironmentSecretRefs(workspace.WorkspaceID); err != nil {
+		return err
+	}
+
 	logger.Debug("Aggregating TF code from resources...")
 
 	// 1. 生成 main.tf.json
@@ -3382,9 +3575,14 @@ func (s *TerraformExecutor) GenerateConfigFilesWithLogging(
 	// 清理空的terraform块，避免Terraform尝试读取不存在的backend state
 	if workspace.ProviderConfig != nil && len(workspace.ProviderConfig) > 0 {
 		cleanedProviderConfig := s.cleanProviderConfig(workspace.ProviderConfig)
-		if err := s.writeJSONFile(workDir, "provider.tf.json", cleanedProviderConfig); err != nil {
+		resolvedProviderConfig, err := s.resolveProviderConfig(cleanedProviderConfig)
+		if err != nil {
+			return err
+		}
+		if err := s.writeJSONFile(workDir, "provider.tf.json", resolvedProviderConfig); err != nil {
 			return fmt.Errorf("failed to write provider.tf.json: %w", err)
 		}
+		// 日志中只打印引用，不打印解析值
 		providerData, _ := json.MarshalIndent(cleanedProviderConfig, "", "  ")
 		logger.Info("✓ Generated provider.tf.json")
 
@@ -3407,7 +3605,7 @@ func (s *TerraformExecutor) GenerateConfigFilesWithLogging(
 	sensitiveCount := 0
 	if err == nil {
 		for _, v := range variables {
-			if v.Sensitive {
+			if v.Sensitive || v.IsSecretRef() {
 				sensitiveCount++
 			}
 		}
@@ -3428,18 +3626,12 @@ func (s *TerraformExecutor) GenerateConfigFilesWithLogging(
 	logger.Info("✓ Generated variables.tfvars (%d assignments, %d sensitive)", varCount, sensitiveCount)
 
 	// 只在TRACE级别打印完整内容（脱敏处理）
+	// maskSensitiveVariables 通过 DataAccessor 获取变量，Local 和 Agent 模式都需要脱敏（Agent 上有解析后的密文引用）
 	varsTFVarsData, _ := os.ReadFile(filepath.Join(workDir, "variables.tfvars"))
-	if s.db != nil {
-		maskedContent := s.maskSensitiveVariables(string(varsTFVarsData), workspace.WorkspaceID)
-		logger.Trace("========== variables.tfvars Content (sensitive values masked) ==========")
-		logger.Trace("%s", maskedContent)
-		logger.Trace("=========================================================================")
-	} else {
-		// Agent 模式下跳过脱敏（因为 maskSensitiveVariables 使用 s.db）
-		logger.Trace("========== variables.tfvars Content ==========")
-		logger.Trace("%s", string(varsTFVarsData))
-		logger.Trace("==============================================")
-	}
+	maskedContent := s.maskSensitiveVariables(string(varsTFVarsData), workspace.WorkspaceID)
+	logger.Trace("========== variables.tfvars Content (sensitive values masked) ==========")
+	logger.Trace("%s", maskedContent)
+	logger.Trace("=========================================================================")
 
 	// 5. 生成 outputs.tf.json（如果有配置outputs）
 	if err := s.generateOutputsTFJSONWithLogger(workspace, workDir, logger); err != nil {
@@ -3472,10 +3664,10 @@ func (s *TerraformExecutor) maskSensitiveVariables(content string, workspaceID s
 		return content
 	}
 
-	// 过滤出敏感变量
+	// 过滤出敏感变量（密文引用的解析值同样视为敏感）
 	var sensitiveVars []models.WorkspaceVariable
 	for _, v := range allVars {
-		if v.Sensitive {
+		if v.Sensitive || v.IsSecretRef() {
 			sensitiveVars = append(sensitiveVars, v)
 		}
 	}
@@ -3737,7 +3929,7 @@ plugin_cache_may_break_dependency_lock_file = true
 
 	cmd := exec.CommandContext(ctx, terraformCmd, args...)
 	cmd.Dir = workDir
-	cmd.Env = s.buildEnvironmentVariables(workspace)
+	cmd.Env = append(s.buildEnvironmentVariables(workspace), s.remoteStateEnv(workDir)...)
 
 	// 添加插件缓存目录（仅当不是使用全局缓存时才需要添加）
 	// 如果使用全局缓存，buildEnvironmentVariables 已经通过 os.Environ() 包含了 TF_PLUGIN_CACHE_DIR
@@ -3839,8 +4031,10 @@ plugin_cache_may_break_dependency_lock_file = true
 }
 
 // SavePlanDataWithLogging 保存Plan数据（带详细日志）
+// 引用了密文的 plan 文件包含解析值，只保存 plan JSON，plan 文件保留在执行者上，Apply 时复用或重新 plan
 func (s *TerraformExecutor) SavePlanDataWithLogging(
 	task *models.WorkspaceTask,
+	workspace *models.Workspace,
 	planFile string,
 	planJSON map[string]interface{},
 	logger *TerraformLogger,
@@ -3852,6 +4046,11 @@ func (s *TerraformExecutor) SavePlanDataWithLogging(
 	}
 
 	logger.Debug("Plan file size: %.1f KB", float64(len(planData))/1024)
+	keepPlanOnExecutor := s.planContainsResolvedSecrets(workspace)
+	if keepPlanOnExecutor {
+		logger.Info("Plan references secrets: plan file is kept on the executor and not uploaded")
+		planData = nil
+	}
 	log.Printf("[CRITICAL] SavePlanDataWithLogging called for task %d, planData size: %d, planJSON exists: %v",
 		task.ID, len(planData), planJSON != nil)
 
@@ -3877,7 +4076,7 @@ func (s *TerraformExecutor) SavePlanDataWithLogging(
 				task.ID, len(planData), planJSON != nil)
 			updates := map[string]interface{}{
 				"plan_data": planData,
-				"plan_json": planJSON,
+				"plan_json": models.JSONB(planJSON),
 			}
 			saveErr = s.db.Model(&models.WorkspaceTask{}).Where("id = ?", task.ID).Updates(updates).Error
 			log.Printf("[CRITICAL] Task %d: Updates result: error=%v", task.ID, saveErr)
@@ -3886,7 +4085,17 @@ func (s *TerraformExecutor) SavePlanDataWithLogging(
 			log.Printf("[CRITICAL] Task %d: Agent mode - uploading plan_data (len=%d) and plan_json to server", task.ID, len(planData))
 
 			// 上传 plan_data（Apply 需要用到）
-			saveErr = s.uploadPlanData(task.ID, planData)
+			if !keepPlanOnExecutor {
+				saveErr = s.uploadPlanData(task.ID, planData)
+			}
+			if errors.Is(saveErr, ErrUploadPending) {
+				// 已写入本地 spool，重连后上传；plan_json 同样先入 spool
+				logger.Warn("Server unreachable: plan data is kept in the agent spool and will be uploaded after reconnect")
+				if planJSON != nil {
+					s.uploadPlanJSON(task.ID, planJSON)
+				}
+				return
+			}
 			if saveErr != nil {
 				log.Printf("[CRITICAL] Task %d: Upload plan_data failed: error=%v", task.ID, saveErr)
 				continue
@@ -3918,7 +4127,7 @@ func (s *TerraformExecutor) SavePlanDataWithLogging(
 				if err := s.db.Select("id, plan_data, plan_json").First(&verifyTask, task.ID).Error; err == nil {
 					logger.Debug("Verification: plan_data size = %d bytes, plan_json exists = %v",
 						len(verifyTask.PlanData), verifyTask.PlanJSON != nil)
-					if len(verifyTask.PlanData) > 0 && verifyTask.PlanJSON != nil {
+					if (keepPlanOnExecutor || len(verifyTask.PlanData) > 0) && verifyTask.PlanJSON != nil {
 						logger.Info("✓ Verification passed: plan_data and plan_json saved successfully")
 					} else {
 						logger.Error("✗ Verification failed: plan_data size = %d, plan_json exists = %v",
@@ -3985,7 +4194,7 @@ func (s *TerraformExecutor) SaveNewStateVersionWithLogging(
 	logger.Info("✓ Checksum: %s", checksum[:16]+"...")
 
 	// 4. 立即备份到文件系统（第一道保险）
-	backupDir := "/var/backup/states"
+	backupDir := StateBackupDir
 	os.MkdirAll(backupDir, 0700)
 	backupPath := filepath.Join(backupDir,
 		fmt.Sprintf("ws_%s_task_%d_%d.tfstate",
@@ -4003,8 +4212,12 @@ func (s *TerraformExecutor) SaveNewStateVersionWithLogging(
 
 	maxVersion, err := s.dataAccessor.GetMaxStateVersion(workspace.WorkspaceID)
 	if err != nil {
-		logger.Error("Failed to get max state version: %v", err)
-		return fmt.Errorf("failed to get max state version: %w", err)
+		// Agent 模式下版本号由服务端分配，查询失败不阻塞 State 保存（服务端不可用时 State 先写入本地 spool）
+		if s.db != nil {
+			logger.Error("Failed to get max state version: %v", err)
+			return fmt.Errorf("failed to get max state version: %w", err)
+		}
+		logger.Warn("Failed to get max state version: %v", err)
 	}
 
 	newVersion := maxVersion + 1
@@ -4029,6 +4242,12 @@ func (s *TerraformExecutor) SaveNewStateVersionWithLogging(
 
 		logger.Warn("Failed to save state (attempt %d/%d): %v", i+1, maxRetries, saveErr)
 
+		if errors.Is(saveErr, ErrUploadPending) {
+			logger.Warn("Server unreachable: state is kept in the agent spool and will be uploaded after reconnect")
+			logger.Info("State backed up to: %s", backupPath)
+			return saveErr
+		}
+
 		if i < maxRetries-1 {
 			time.Sleep(time.Duration(i+1) * 2 * time.Second)
 		}
@@ -4187,8 +4406,8 @@ func (s *TerraformExecutor) uploadPlanData(taskID uint, planData []byte) error {
 		// 使用 base64 编码传输二进制数据
 		encodedData := base64.StdEncoding.EncodeToString(planData)
 
-		// 调用 API
-		return remoteAccessor.apiClient.UploadPlanData(taskID, encodedData)
+		// 调用 API（先写入本地 spool）
+		return remoteAccessor.apiClient.UploadPlanDataWithRetry(taskID, encodedData)
 	}
 
 	return fmt.Errorf("not in agent mode")
@@ -4198,8 +4417,8 @@ func (s *TerraformExecutor) uploadPlanData(taskID uint, planData []byte) error {
 func (s *TerraformExecutor) uploadPlanJSON(taskID uint, planJSON map[string]interface{}) error {
 	// 通过 dataAccessor 调用 API
 	if remoteAccessor, ok := s.dataAccessor.(*RemoteDataAccessor); ok {
-		// 调用 API
-		return remoteAccessor.apiClient.UploadPlanJSON(taskID, planJSON)
+		// 调用 API（先写入本地 spool）
+		return remoteAccessor.apiClient.UploadPlanJSONWithRetry(taskID, planJSON)
 	}
 
 	return fmt.Errorf("not in agent mode")
@@ -4702,8 +4921,13 @@ func (s *TerraformExecutor) generateRemoteDataTFJSON(
 		logLevel: LogLevelInfo,
 	}
 
-	// 生成 remote_data.tf.json
-	return generator.GenerateRemoteDataTFWithLogging(workspace.WorkspaceID, workDir, taskID, logger)
+	// 生成 remote_data.tf.json，凭证留给该目录下的 terraform 命令
+	creds, err := generator.GenerateRemoteDataTFWithLogging(workspace.WorkspaceID, workDir, taskID, logger)
+	if err != nil {
+		return err
+	}
+	s.setRemoteStateCredentials(workDir, creds)
+	return nil
 }
 
 // generateRemoteDataTFJSONWithLogging 生成remote_data.tf.json（带日志）
@@ -4722,87 +4946,77 @@ func (s *TerraformExecutor) generateRemoteDataTFJSONWithLogging(
 		// 创建 RemoteDataTFGenerator
 		generator := NewRemoteDataTFGenerator(s.db, baseURL)
 
-		// 生成 remote_data.tf.json
-		return generator.GenerateRemoteDataTFWithLogging(workspace.WorkspaceID, workDir, taskID, logger)
+		// 生成 remote_data.tf.json，凭证留给该目录下的 terraform 命令
+		creds, err := generator.GenerateRemoteDataTFWithLogging(workspace.WorkspaceID, workDir, taskID, logger)
+		if err != nil {
+			return err
+		}
+		s.setRemoteStateCredentials(workDir, creds)
+		return nil
 	}
 
-	// Agent 模式：从 RemoteDataAccessor 获取已生成好token的配置
+	// Agent 模式：从 RemoteDataAccessor 获取服务端签发的凭证和 remote data 配置
 	remoteAccessor, ok := s.dataAccessor.(*RemoteDataAccessor)
 	if !ok {
 		logger.Debug("Skipping remote_data.tf.json generation: not in Agent mode")
 		return nil
 	}
 
-	// 获取服务端已经准备好的 remote data 配置
 	remoteDataConfig := remoteAccessor.GetRemoteDataConfig()
 	if len(remoteDataConfig) == 0 {
 		logger.Debug("No remote data configured, skipping remote_data.tf.json generation")
 		return nil
 	}
 
-	logger.Info("Generating remote_data.tf.json with %d remote data references (Agent mode)...", len(remoteDataConfig))
+	creds := remoteAccessor.GetRemoteStateCredentials()
+	if creds == nil {
+		logger.Warn("Task data has no remote state credentials, skipping remote_data.tf.json generation")
+		return nil
+	}
 
-	// 构建TF配置
-	tfConfig := make(map[string]interface{})
-	dataBlocks := make(map[string]interface{})
-	localBlocks := make(map[string]interface{})
+	logger.Info("Generating remote_data.tf.json with %d remote data references (Agent mode)...", len(remoteDataConfig))
 
+	refs := make([]RemoteStateRef, 0, len(remoteDataConfig))
 	for _, rd := range remoteDataConfig {
-		dataName := getString(rd, "data_name")
-		token := getString(rd, "token")
-		url := getString(rd, "url")
-		sourceWorkspaceID := getString(rd, "source_workspace_id")
-
-		if dataName == "" || token == "" || url == "" {
+		ref := RemoteStateRef{
+			DataName:          getString(rd, "data_name"),
+			SourceWorkspaceID: getString(rd, "source_workspace_id"),
+		}
+		if ref.DataName == "" || ref.SourceWorkspaceID == "" {
 			logger.Warn("Invalid remote data config, skipping: %v", rd)
 			continue
 		}
-
-		// 生成data "http" block
-		dataBlockName := fmt.Sprintf("remote_%s", sanitizeNameForTF(dataName))
-
-		dataBlocks[dataBlockName] = []map[string]interface{}{
-			{
-				"url": url,
-				"request_headers": map[string]interface{}{
-					"Authorization": fmt.Sprintf("Bearer %s", token),
-				},
-			},
-		}
-
-		// 生成local block
-		localBlocks[dataName] = fmt.Sprintf("${jsondecode(data.http.%s.response_body).outputs}", dataBlockName)
-
-		logger.Info("✓ Added remote data reference: %s -> %s", dataName, sourceWorkspaceID)
+		refs = append(refs, ref)
 	}
 
 	// 只有当有有效的data blocks时才生成文件
-	if len(dataBlocks) == 0 {
+	if len(refs) == 0 {
 		logger.Warn("No valid remote data blocks generated")
 		return nil
 	}
 
-	// 构建完整的TF配置
-	tfConfig["data"] = map[string]interface{}{
-		"http": dataBlocks,
-	}
-
-	if len(localBlocks) > 0 {
-		tfConfig["locals"] = localBlocks
+	// 使用 Agent 访问平台的地址，terraform 与 Agent 走同一条网络路径
+	if err := WriteRemoteDataTFJSON(workDir, remoteAccessor.apiClient.baseURL, refs, logger); err != nil {
+		return err
 	}
+	s.setRemoteStateCredentials(workDir, creds)
+	return nil
+}
 
-	// 写入文件
-	filePath := filepath.Join(workDir, "remote_data.tf.json")
-	content, err := json.MarshalIndent(tfConfig, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal remote_data.tf.json: %w", err)
+// setRemoteStateCredentials 记录工作目录对应的 terraform_remote_state 凭证
+func (s *TerraformExecutor) setRemoteStateCredentials(workDir string, creds *RemoteStateCredentials) {
+	if creds == nil {
+		s.remoteStateCredentials.Delete(workDir)
+		return
 	}
+	s.remoteStateCredentials.Store(workDir, creds)
+}
 
-	if err := os.WriteFile(filePath, content, 0644); err != nil {
-		return fmt.Errorf("failed to write remote_data.tf.json: %w", err)
+// remoteStateEnv 返回工作目录对应的 terraform_remote_state 凭证环境变量（TF_HTTP_USERNAME/TF_HTTP_PASSWORD）
+func (s *TerraformExecutor) remoteStateEnv(workDir string) []string {
+	if creds, ok := s.remoteStateCredentials.Load(workDir); ok {
+		return creds.(*RemoteStateCredentials).Env()
 	}
-
-	logger.Info("✓ Generated remote_data.tf.json (%.1f KB)", float64(len(content))/1024)
 	return nil
 }
 
@@ -4928,22 +5142,6 @@ func removeTargetArgs(args []string) []string {
 	return result
 }
 
-// sanitizeNameForTF 清理名称，使其符合Terraform命名规范
-func sanitizeNameForTF(name string) string {
-	result := strings.Map(func(r rune) rune {
-		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
-			return r
-		}
-		return '_'
-	}, name)
-
-	if len(result) > 0 && result[0] >= '0' && result[0] <= '9' {
-		result = "_" + result
-	}
-
-	return result
-}
-
 // GenerateConfigFilesFromSnapshot 从快照数据生成配置文件
 func (s *TerraformExecutor) GenerateConfigFilesFromSnapshot(
 	workspace *models.Workspace,
@@ -4954,6 +5152,10 @@ func (s *TerraformExecutor) GenerateConfigFilesFromSnapshot(
 ) error {
 	logger.Debug("Generating config files from snapshot data...")
 
+	if err := s.verifyEnvironmentSecretRefs(workspace.WorkspaceID); err != nil {
+		return err
+	}
+
 	// 解析变量快照为实际变量值
 	snapshotVariables, err := s.ResolveVariableSnapshots(variableSnapshots, workspace.WorkspaceID)
 	if err != nil {
@@ -4978,7 +5180,11 @@ func (s *TerraformExecutor) GenerateConfigFilesFromSnapshot(
 	// 清理空的terraform块，避免Terraform尝试读取不存在的backend state
 	if workspace.ProviderConfig != nil && len(workspace.ProviderConfig) > 0 {
 		cleanedProviderConfig := s.cleanProviderConfig(workspace.ProviderConfig)
-		if err := s.writeJSONFile(workDir, "provider.tf.json", cleanedProviderConfig); err != nil {
+		resolvedProviderConfig, err := s.resolveProviderConfig(cleanedProviderConfig)
+		if err != nil {
+			return err
+		}
+		if err := s.writeJSONFile(workDir, "provider.tf.json", resolvedProviderConfig); err != nil {
 			return fmt.Errorf("failed to write provider.tf.json: %w", err)
 		}
 		logger.Info("✓ Generated provider.tf.json from snapshot")
@@ -4995,7 +5201,7 @@ func (s *TerraformExecutor) GenerateConfigFilesFromSnapshot(
 		if v.Description != "" {
 			varDef["description"] = v.Description
 		}
-		if v.Sensitive {
+		if v.Sensitive || v.IsSecretRef() {
 			varDef["sensitive"] = true
 		}
 		variablesDef[v.Key] = varDef
@@ -5019,10 +5225,19 @@ func (s *TerraformExecutor) GenerateConfigFilesFromSnapshot(
 	sensitiveCount := 0
 
 	for _, v := range snapshotVariables {
-		if v.Sensitive {
+		if v.Sensitive || v.IsSecretRef() {
 			sensitiveCount++
 		}
 
+		if v.IsSecretRef() {
+			value, err := s.resolveVariableValue(v)
+			if err != nil {
+				return err
+			}
+			tfvars.WriteString(fmt.Sprintf("%s = %s\n", v.Key, quoteTFVarsString(value)))
+			continue
+		}
+
 		// 根据ValueFormat处理
 		if v.ValueFormat == models.ValueFormatHCL {
 			trimmedValue := strings.TrimSpace(v.Value)
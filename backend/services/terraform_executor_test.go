@@ -612,3 +612,23 @@ func TestPlanApply_SnapshotPreservedAcrossPhases(t *testing.T) {
 func formatUint(id uint) string {
 	return fmt.Sprintf("%d", id)
 }
+
+func TestMergeTFCode_AppendsListBlocks(t *testing.T) {
+	executor := newTestExecutor(nil)
+	mainTF := map[string]interface{}{}
+
+	executor.mergeTFCode(mainTF, map[string]interface{}{
+		"resource": map[string]interface{}{"aws_s3_bucket": map[string]interface{}{"logs": map[string]interface{}{}}},
+		"import":   []interface{}{map[string]interface{}{"to": "aws_s3_bucket.logs", "id": "logs"}},
+	})
+	executor.mergeTFCode(mainTF, map[string]interface{}{
+		"resource": map[string]interface{}{"aws_instance": map[string]interface{}{"web": map[string]interface{}{}}},
+		"import":   []interface{}{map[string]interface{}{"to": "aws_instance.web", "id": "i-web"}},
+	})
+
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"to": "aws_s3_bucket.logs", "id": "logs"},
+		map[string]interface{}{"to": "aws_instance.web", "id": "i-web"},
+	}, mainTF["import"])
+	assert.Len(t, mainTF["resource"], 2)
+}
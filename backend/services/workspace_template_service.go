@@ -0,0 +1,1030 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"iac-platform/internal/crypto"
+	"iac-platform/internal/infrastructure"
+	"iac-platform/internal/models"
+	"iac-platform/internal/secretref"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrWorkspaceTemplateNotFound 模板不存在
+	ErrWorkspaceTemplateNotFound = errors.New("workspace template not found")
+	// ErrWorkspaceTemplateInvalid 模板内容或从模板创建的请求无效
+	ErrWorkspaceTemplateInvalid = errors.New("invalid workspace template")
+	// ErrWorkspaceTemplateInUse 模板仍被 workspace 引用
+	ErrWorkspaceTemplateInUse = errors.New("workspace template is in use")
+)
+
+// 模板同步差异的动作
+const (
+	TemplateChangeAdd           = "add"
+	TemplateChangeUpdate        = "update"
+	TemplateChangeRequiresInput = "requires_input"
+)
+
+// WorkspaceTemplateService 工作空间模板服务
+type WorkspaceTemplateService struct {
+	db *gorm.DB
+}
+
+// NewWorkspaceTemplateService 创建工作空间模板服务
+func NewWorkspaceTemplateService(db *gorm.DB) *WorkspaceTemplateService {
+	return &WorkspaceTemplateService{db: db}
+}
+
+// List 获取模板列表
+func (s *WorkspaceTemplateService) List(enabled *bool) ([]models.WorkspaceTemplate, error) {
+	var templates []models.WorkspaceTemplate
+	query := s.db.Model(&models.WorkspaceTemplate{})
+	if enabled != nil {
+		query = query.Where("enabled = ?", *enabled)
+	}
+	if err := query.Order("created_at DESC").Find(&templates).Error; err != nil {
+		return nil, fmt.Errorf("failed to query workspace templates: %w", err)
+	}
+	if templates == nil {
+		templates = []models.WorkspaceTemplate{}
+	}
+	return templates, nil
+}
+
+// Get 根据 template_id 获取模板
+func (s *WorkspaceTemplateService) Get(templateID string) (*models.WorkspaceTemplate, error) {
+	var template models.WorkspaceTemplate
+	err := s.db.Where("template_id = ?", templateID).First(&template).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrWorkspaceTemplateNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workspace template: %w", err)
+	}
+	return &template, nil
+}
+
+// Create 创建模板
+func (s *WorkspaceTemplateService) Create(req *models.CreateWorkspaceTemplateRequest, userID string) (*models.WorkspaceTemplate, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrWorkspaceTemplateInvalid)
+	}
+	spec := req.Spec
+	if err := s.prepareSpec(&spec, nil); err != nil {
+		return nil, err
+	}
+
+	var count int64
+	if err := s.db.Model(&models.WorkspaceTemplate{}).Where("name = ?", name).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("failed to check template name: %w", err)
+	}
+	if count > 0 {
+		return nil, fmt.Errorf("%w: template name '%s' already exists", ErrWorkspaceTemplateInvalid, name)
+	}
+
+	templateID, err := infrastructure.GenerateWorkspaceTemplateID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate template ID: %w", err)
+	}
+
+	template := &models.WorkspaceTemplate{
+		TemplateID:  templateID,
+		Name:        name,
+		Description: req.Description,
+		Version:     1,
+		Spec:        spec,
+		Enabled:     req.Enabled == nil || *req.Enabled,
+	}
+	if userID != "" {
+		template.CreatedBy = &userID
+	}
+	if err := s.db.Create(template).Error; err != nil {
+		return nil, fmt.Errorf("failed to create workspace template: %w", err)
+	}
+	return template, nil
+}
+
+// Update 更新模板；修改 spec 时版本号递增
+func (s *WorkspaceTemplateService) Update(templateID string, req *models.UpdateWorkspaceTemplateRequest) (*models.WorkspaceTemplate, error) {
+	template, err := s.Get(templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, fmt.Errorf("%w: name is required", ErrWorkspaceTemplateInvalid)
+		}
+		var count int64
+		if err := s.db.Model(&models.WorkspaceTemplate{}).
+			Where("name = ? AND template_id != ?", name, templateID).
+			Count(&count).Error; err != nil {
+			return nil, fmt.Errorf("failed to check template name: %w", err)
+		}
+		if count > 0 {
+			return nil, fmt.Errorf("%w: template name '%s' already exists", ErrWorkspaceTemplateInvalid, name)
+		}
+		template.Name = name
+	}
+	if req.Description != nil {
+		template.Description = *req.Description
+	}
+	if req.Enabled != nil {
+		template.Enabled = *req.Enabled
+	}
+	if req.Spec != nil {
+		spec := *req.Spec
+		if err := s.prepareSpec(&spec, &template.Spec); err != nil {
+			return nil, err
+		}
+		template.Spec = spec
+		template.Version++
+	}
+
+	if err := s.db.Save(template).Error; err != nil {
+		return nil, fmt.Errorf("failed to update workspace template: %w", err)
+	}
+	return template, nil
+}
+
+// Delete 删除模板（仍有关联 workspace 时拒绝删除）
+func (s *WorkspaceTemplateService) Delete(templateID string) error {
+	if _, err := s.Get(templateID); err != nil {
+		return err
+	}
+	var count int64
+	if err := s.db.Model(&models.Workspace{}).Where("template_id = ?", templateID).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check linked workspaces: %w", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: %d workspace(s) are linked to this template", ErrWorkspaceTemplateInUse, count)
+	}
+	return s.db.Where("template_id = ?", templateID).Delete(&models.WorkspaceTemplate{}).Error
+}
+
+// ListLinkedWorkspaces 获取关联该模板的 workspace
+func (s *WorkspaceTemplateService) ListLinkedWorkspaces(templateID string) ([]models.Workspace, error) {
+	var workspaces []models.Workspace
+	if err := s.db.Where("template_id = ?", templateID).Order("name").Find(&workspaces).Error; err != nil {
+		return nil, fmt.Errorf("failed to query linked workspaces: %w", err)
+	}
+	return workspaces, nil
+}
+
+// MaskWorkspaceTemplate 返回隐藏敏感变量默认值后的模板（用于 API 响应）
+func MaskWorkspaceTemplate(template models.WorkspaceTemplate) models.WorkspaceTemplate {
+	variables := make([]models.WorkspaceTemplateVariable, len(template.Spec.Variables))
+	for i, v := range template.Spec.Variables {
+		if v.Sensitive {
+			v.Value = ""
+		}
+		variables[i] = v
+	}
+	template.Spec.Variables = variables
+	return template
+}
+
+// prepareSpec 校验模板内容并填充默认值；敏感变量默认值加密保存
+// previous 为更新前的 spec，敏感变量未重新提交值时沿用原值
+func (s *WorkspaceTemplateService) prepareSpec(spec *models.WorkspaceTemplateSpec, previous *models.WorkspaceTemplateSpec) error {
+	invalid := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%w: %s", ErrWorkspaceTemplateInvalid, fmt.Sprintf(format, args...))
+	}
+
+	settings := spec.Settings
+	if settings.ExecutionMode != nil {
+		switch models.ExecutionMode(*settings.ExecutionMode) {
+		case models.ExecutionModeLocal, models.ExecutionModeAgent, models.ExecutionModeK8s:
+		default:
+			return invalid("execution_mode must be local, agent or k8s")
+		}
+	}
+	if settings.StaticAnalysisConfig != nil {
+		if _, err := ParseStaticAnalysisConfig(settings.StaticAnalysisConfig); err != nil {
+			return invalid("static_analysis_config: %v", err)
+		}
+	}
+	if settings.CostEstimationConfig != nil {
+		if _, err := ParseCostEstimationConfig(settings.CostEstimationConfig); err != nil {
+			return invalid("cost_estimation_config: %v", err)
+		}
+	}
+
+	seenRunTasks := map[string]bool{}
+	for i := range spec.RunTasks {
+		rt := &spec.RunTasks[i]
+		switch rt.Stage {
+		case models.RunTaskStagePrePlan, models.RunTaskStagePostPlan, models.RunTaskStagePreApply, models.RunTaskStagePostApply:
+		default:
+			return invalid("run task %s: invalid stage '%s'", rt.RunTaskID, rt.Stage)
+		}
+		if rt.EnforcementLevel == "" {
+			rt.EnforcementLevel = models.RunTaskEnforcementAdvisory
+		}
+		if rt.EnforcementLevel != models.RunTaskEnforcementAdvisory && rt.EnforcementLevel != models.RunTaskEnforcementMandatory {
+			return invalid("run task %s: invalid enforcement_level '%s'", rt.RunTaskID, rt.EnforcementLevel)
+		}
+		key := templateRunTaskKey(rt.RunTaskID, rt.Stage)
+		if seenRunTasks[key] {
+			return invalid("run task %s is attached twice at stage %s", rt.RunTaskID, rt.Stage)
+		}
+		seenRunTasks[key] = true
+		var count int64
+		if err := s.db.Model(&models.RunTask{}).Where("run_task_id = ?", rt.RunTaskID).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to check run task: %w", err)
+		}
+		if count == 0 {
+			return invalid("run task %s not found", rt.RunTaskID)
+		}
+	}
+
+	seenNotifications := map[string]bool{}
+	for i := range spec.Notifications {
+		n := &spec.Notifications[i]
+		if n.Events == "" {
+			n.Events = "task_completed,task_failed"
+		}
+		if seenNotifications[n.NotificationID] {
+			return invalid("notification %s is attached twice", n.NotificationID)
+		}
+		seenNotifications[n.NotificationID] = true
+		var count int64
+		if err := s.db.Model(&models.NotificationConfig{}).Where("notification_id = ?", n.NotificationID).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to check notification: %w", err)
+		}
+		if count == 0 {
+			return invalid("notification %s not found", n.NotificationID)
+		}
+	}
+
+	previousSecrets := map[string]string{}
+	if previous != nil {
+		for _, v := range previous.Variables {
+			if v.Sensitive && v.Value != "" {
+				previousSecrets[templateVariableKey(v.Key, v.VariableType)] = v.Value
+			}
+		}
+	}
+	seenVariables := map[string]bool{}
+	for i := range spec.Variables {
+		v := &spec.Variables[i]
+		if strings.TrimSpace(v.Key) == "" {
+			return invalid("variable key is required")
+		}
+		if v.VariableType == "" {
+			v.VariableType = models.VariableTypeTerraform
+		}
+		if v.VariableType != models.VariableTypeTerraform && v.VariableType != models.VariableTypeEnvironment {
+			return invalid("variable %s: invalid variable_type '%s'", v.Key, v.VariableType)
+		}
+		if v.ValueFormat == "" {
+			v.ValueFormat = models.ValueFormatString
+		}
+		if v.ValueFormat != models.ValueFormatString && v.ValueFormat != models.ValueFormatHCL && v.ValueFormat != models.ValueFormatSecretRef {
+			return invalid("variable %s: invalid value_format '%s'", v.Key, v.ValueFormat)
+		}
+		if v.ValueFormat == models.ValueFormatSecretRef {
+			if _, err := secretref.Parse(v.Value); err != nil {
+				return invalid("variable %s: %v", v.Key, err)
+			}
+		}
+		key := templateVariableKey(v.Key, v.VariableType)
+		if seenVariables[key] {
+			return invalid("variable %s is defined twice", v.Key)
+		}
+		seenVariables[key] = true
+		// 密文引用只保存引用本身，不加密
+		if v.Sensitive && v.ValueFormat != models.ValueFormatSecretRef {
+			if v.Value == "" {
+				v.Value = previousSecrets[key]
+			}
+			if v.Value != "" && !crypto.IsEncrypted(v.Value) {
+				encrypted, err := crypto.EncryptValue(v.Value)
+				if err != nil {
+					return fmt.Errorf("failed to encrypt variable %s: %w", v.Key, err)
+				}
+				v.Value = encrypted
+			}
+		}
+	}
+
+	seenResources := map[string]bool{}
+	for i := range spec.Resources {
+		r := &spec.Resources[i]
+		if strings.TrimSpace(r.Name) == "" {
+			return invalid("resource name is required")
+		}
+		if r.ModuleID == nil && r.ModuleSource == "" {
+			return invalid("resource %s: module_id or module_source is required", r.Name)
+		}
+		if r.ModuleID != nil {
+			var count int64
+			if err := s.db.Model(&models.Module{}).Where("id = ?", *r.ModuleID).Count(&count).Error; err != nil {
+				return fmt.Errorf("failed to check module: %w", err)
+			}
+			if count == 0 {
+				return invalid("resource %s: module %d not found", r.Name, *r.ModuleID)
+			}
+		} else if r.ResourceType == "" {
+			return invalid("resource %s: resource_type is required when module_id is not set", r.Name)
+		}
+		if seenResources[r.Name] {
+			return invalid("resource %s is defined twice", r.Name)
+		}
+		seenResources[r.Name] = true
+	}
+	return nil
+}
+
+// CreateWorkspaceFromTemplate 从模板创建 workspace
+// workspace、Run Tasks、通知、变量和初始资源在同一事务中创建，任一步失败全部回滚
+func (s *WorkspaceTemplateService) CreateWorkspaceFromTemplate(req *models.CreateWorkspaceFromTemplateRequest, userID string) (*models.Workspace, error) {
+	template, err := s.Get(req.TemplateID)
+	if err != nil {
+		return nil, err
+	}
+	if !template.Enabled {
+		return nil, fmt.Errorf("%w: template %s is disabled", ErrWorkspaceTemplateInvalid, template.Name)
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrWorkspaceTemplateInvalid)
+	}
+
+	// 必填变量在事务前检查，给出完整的缺失列表
+	var missing []string
+	for _, v := range template.Spec.Variables {
+		if v.Required && v.Value == "" && req.Variables[v.Key] == "" {
+			missing = append(missing, v.Key)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: required variables not provided: %s", ErrWorkspaceTemplateInvalid, strings.Join(missing, ", "))
+	}
+
+	workspace := &models.Workspace{
+		Name:             name,
+		Description:      req.Description,
+		ExecutionMode:    models.ExecutionModeLocal,
+		TerraformVersion: "latest",
+		Workdir:          "/workspace",
+		StateBackend:     "local",
+		RetryEnabled:     true,
+		MaxRetries:       3,
+		UIMode:           "console",
+		OutputsSharing:   "none",
+		State:            models.WorkspaceStateCreated,
+		TemplateID:       &template.TemplateID,
+		TemplateVersion:  template.Version,
+	}
+	if userID != "" {
+		workspace.CreatedBy = &userID
+	}
+	applyTemplateSettings(workspace, template.Spec.Settings)
+	applyTemplateSettings(workspace, req.Settings)
+
+	switch workspace.ExecutionMode {
+	case models.ExecutionModeLocal, models.ExecutionModeAgent, models.ExecutionModeK8s:
+	default:
+		return nil, fmt.Errorf("%w: invalid execution mode '%s'", ErrWorkspaceTemplateInvalid, workspace.ExecutionMode)
+	}
+	if workspace.ExecutionMode == models.ExecutionModeAgent && workspace.AgentPoolID == nil && workspace.CurrentPoolID == nil {
+		return nil, fmt.Errorf("%w: agent execution mode requires agent_pool_id", ErrWorkspaceTemplateInvalid)
+	}
+	if workspace.ExecutionMode == models.ExecutionModeK8s && workspace.K8sConfigID == nil {
+		return nil, fmt.Errorf("%w: k8s execution mode requires k8s_config_id", ErrWorkspaceTemplateInvalid)
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.Workspace{}).Where("name = ?", name).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to check workspace name: %w", err)
+		}
+		if count > 0 {
+			return fmt.Errorf("%w: workspace name '%s' already exists", ErrWorkspaceTemplateInvalid, name)
+		}
+
+		workspaceID, err := infrastructure.GenerateWorkspaceID()
+		if err != nil {
+			return fmt.Errorf("failed to generate workspace ID: %w", err)
+		}
+		workspace.WorkspaceID = workspaceID
+		if err := tx.Create(workspace).Error; err != nil {
+			return fmt.Errorf("failed to create workspace: %w", err)
+		}
+
+		for _, rt := range template.Spec.RunTasks {
+			if err := createTemplateRunTask(tx, workspace.WorkspaceID, rt, userID); err != nil {
+				return err
+			}
+		}
+		for _, n := range template.Spec.Notifications {
+			if err := createTemplateNotification(tx, workspace.WorkspaceID, n, userID); err != nil {
+				return err
+			}
+		}
+		for _, v := range template.Spec.Variables {
+			value := v.Value
+			if provided, ok := req.Variables[v.Key]; ok && provided != "" {
+				value = provided
+			}
+			if err := createTemplateVariable(tx, workspace.WorkspaceID, v, value, userID); err != nil {
+				return err
+			}
+		}
+		for _, r := range template.Spec.Resources {
+			if err := createTemplateResource(tx, workspace.WorkspaceID, r, userID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return workspace, nil
+}
+
+// PreviewPropagation 预览模板当前版本与关联 workspace 之间的差异
+// 同步只新增或更新模板管理的配置，不会删除 workspace 中已有的配置，也不会覆盖已有变量的值和资源代码
+func (s *WorkspaceTemplateService) PreviewPropagation(templateID string, workspaceIDs []string) ([]models.WorkspaceTemplatePreview, error) {
+	template, err := s.Get(templateID)
+	if err != nil {
+		return nil, err
+	}
+	workspaces, err := s.linkedWorkspaces(templateID, workspaceIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	previews := make([]models.WorkspaceTemplatePreview, 0, len(workspaces))
+	for i := range workspaces {
+		ws := &workspaces[i]
+		changes, err := s.diffWorkspace(s.db, template, ws, nil)
+		if err != nil {
+			return nil, err
+		}
+		previews = append(previews, models.WorkspaceTemplatePreview{
+			WorkspaceID:   ws.WorkspaceID,
+			WorkspaceName: ws.Name,
+			FromVersion:   ws.TemplateVersion,
+			ToVersion:     template.Version,
+			UpToDate:      len(changes) == 0 && ws.TemplateVersion == template.Version,
+			Changes:       changes,
+		})
+	}
+	return previews, nil
+}
+
+// Propagate 将模板当前版本同步到关联的 workspace（每个 workspace 独立事务）
+// 存在未提供值的必填变量时跳过该 workspace 并返回错误信息
+func (s *WorkspaceTemplateService) Propagate(templateID string, req *models.PropagateWorkspaceTemplateRequest, userID string) ([]models.WorkspaceTemplatePreview, error) {
+	template, err := s.Get(templateID)
+	if err != nil {
+		return nil, err
+	}
+	workspaces, err := s.linkedWorkspaces(templateID, req.WorkspaceIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]models.WorkspaceTemplatePreview, 0, len(workspaces))
+	for i := range workspaces {
+		ws := &workspaces[i]
+		result := models.WorkspaceTemplatePreview{
+			WorkspaceID:   ws.WorkspaceID,
+			WorkspaceName: ws.Name,
+			FromVersion:   ws.TemplateVersion,
+			ToVersion:     template.Version,
+		}
+
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			changes, err := s.diffWorkspace(tx, template, ws, req.Variables)
+			if err != nil {
+				return err
+			}
+			result.Changes = changes
+			for _, change := range changes {
+				if change.Action == TemplateChangeRequiresInput {
+					return fmt.Errorf("%w: variable %s requires a value", ErrWorkspaceTemplateInvalid, change.Key)
+				}
+			}
+			return s.applyChanges(tx, template, ws, changes, req.Variables, userID)
+		})
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Applied = true
+			result.UpToDate = true
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (s *WorkspaceTemplateService) linkedWorkspaces(templateID string, workspaceIDs []string) ([]models.Workspace, error) {
+	query := s.db.Where("template_id = ?", templateID)
+	if len(workspaceIDs) > 0 {
+		query = query.Where("workspace_id IN ?", workspaceIDs)
+	}
+	var workspaces []models.Workspace
+	if err := query.Order("name").Find(&workspaces).Error; err != nil {
+		return nil, fmt.Errorf("failed to query linked workspaces: %w", err)
+	}
+	return workspaces, nil
+}
+
+// diffWorkspace 计算模板与 workspace 之间的差异
+func (s *WorkspaceTemplateService) diffWorkspace(db *gorm.DB, template *models.WorkspaceTemplate, ws *models.Workspace, provided map[string]string) ([]models.WorkspaceTemplateChange, error) {
+	changes := []models.WorkspaceTemplateChange{}
+
+	// settings：只比较模板管理的字段
+	current := workspaceSettingsSnapshot(ws)
+	desiredWorkspace := *ws
+	applyTemplateSettings(&desiredWorkspace, template.Spec.Settings)
+	desired := workspaceSettingsSnapshot(&desiredWorkspace)
+	for _, key := range templateSettingKeys(template.Spec.Settings) {
+		if !reflect.DeepEqual(current[key], desired[key]) {
+			changes = append(changes, models.WorkspaceTemplateChange{
+				Section: "settings",
+				Key:     key,
+				Action:  TemplateChangeUpdate,
+				Current: current[key],
+				Desired: desired[key],
+			})
+		}
+	}
+
+	var runTasks []models.WorkspaceRunTask
+	if err := db.Where("workspace_id = ?", ws.WorkspaceID).Find(&runTasks).Error; err != nil {
+		return nil, fmt.Errorf("failed to query workspace run tasks: %w", err)
+	}
+	existingRunTasks := make(map[string]models.WorkspaceRunTask, len(runTasks))
+	for _, rt := range runTasks {
+		existingRunTasks[templateRunTaskKey(rt.RunTaskID, rt.Stage)] = rt
+	}
+	for _, rt := range template.Spec.RunTasks {
+		key := templateRunTaskKey(rt.RunTaskID, rt.Stage)
+		desired := map[string]interface{}{"enforcement_level": rt.EnforcementLevel, "enabled": templateEnabled(rt.Enabled)}
+		existing, ok := existingRunTasks[key]
+		if !ok {
+			changes = append(changes, models.WorkspaceTemplateChange{Section: "run_tasks", Key: key, Action: TemplateChangeAdd, Desired: desired})
+			continue
+		}
+		if existing.EnforcementLevel != rt.EnforcementLevel || existing.Enabled != templateEnabled(rt.Enabled) {
+			changes = append(changes, models.WorkspaceTemplateChange{
+				Section: "run_tasks",
+				Key:     key,
+				Action:  TemplateChangeUpdate,
+				Current: map[string]interface{}{"enforcement_level": existing.EnforcementLevel, "enabled": existing.Enabled},
+				Desired: desired,
+			})
+		}
+	}
+
+	var notifications []models.WorkspaceNotification
+	if err := db.Where("workspace_id = ?", ws.WorkspaceID).Find(&notifications).Error; err != nil {
+		return nil, fmt.Errorf("failed to query workspace notifications: %w", err)
+	}
+	existingNotifications := make(map[string]models.WorkspaceNotification, len(notifications))
+	for _, n := range notifications {
+		existingNotifications[n.NotificationID] = n
+	}
+	for _, n := range template.Spec.Notifications {
+		desired := map[string]interface{}{"events": n.Events, "enabled": templateEnabled(n.Enabled)}
+		existing, ok := existingNotifications[n.NotificationID]
+		if !ok {
+			changes = append(changes, models.WorkspaceTemplateChange{Section: "notifications", Key: n.NotificationID, Action: TemplateChangeAdd, Desired: desired})
+			continue
+		}
+		if existing.Events != n.Events || existing.Enabled != templateEnabled(n.Enabled) {
+			changes = append(changes, models.WorkspaceTemplateChange{
+				Section: "notifications",
+				Key:     n.NotificationID,
+				Action:  TemplateChangeUpdate,
+				Current: map[string]interface{}{"events": existing.Events, "enabled": existing.Enabled},
+				Desired: desired,
+			})
+		}
+	}
+
+	// variables：只新增缺失的变量，已有变量的值属于 workspace，不做覆盖
+	var variableKeys []struct {
+		Key          string
+		VariableType models.VariableType
+	}
+	if err := db.Model(&models.WorkspaceVariable{}).
+		Select("DISTINCT key, variable_type").
+		Where("workspace_id = ? AND is_deleted = ?", ws.WorkspaceID, false).
+		Scan(&variableKeys).Error; err != nil {
+		return nil, fmt.Errorf("failed to query workspace variables: %w", err)
+	}
+	existingVariables := make(map[string]bool, len(variableKeys))
+	for _, v := range variableKeys {
+		existingVariables[templateVariableKey(v.Key, v.VariableType)] = true
+	}
+	for _, v := range template.Spec.Variables {
+		if existingVariables[templateVariableKey(v.Key, v.VariableType)] {
+			continue
+		}
+		action := TemplateChangeAdd
+		if v.Required && v.Value == "" && provided[v.Key] == "" {
+			action = TemplateChangeRequiresInput
+		}
+		desired := map[string]interface{}{"variable_type": v.VariableType, "sensitive": v.Sensitive, "required": v.Required}
+		if !v.Sensitive {
+			desired["value"] = v.Value
+		}
+		changes = append(changes, models.WorkspaceTemplateChange{Section: "variables", Key: v.Key, Action: action, Desired: desired})
+	}
+
+	// resources：只新增缺失的资源，已有资源代码不做覆盖
+	for _, r := range template.Spec.Resources {
+		resourceType, _, err := templateResourceCode(db, r)
+		if err != nil {
+			return nil, err
+		}
+		resourceID := fmt.Sprintf("%s.%s", resourceType, r.Name)
+		var count int64
+		if err := db.Model(&models.WorkspaceResource{}).
+			Where("workspace_id = ? AND resource_id = ?", ws.WorkspaceID, resourceID).
+			Count(&count).Error; err != nil {
+			return nil, fmt.Errorf("failed to query workspace resources: %w", err)
+		}
+		if count == 0 {
+			changes = append(changes, models.WorkspaceTemplateChange{Section: "resources", Key: resourceID, Action: TemplateChangeAdd})
+		}
+	}
+
+	return changes, nil
+}
+
+// applyChanges 在事务中应用差异并更新 workspace 记录的模板版本
+func (s *WorkspaceTemplateService) applyChanges(tx *gorm.DB, template *models.WorkspaceTemplate, ws *models.Workspace, changes []models.WorkspaceTemplateChange, provided map[string]string, userID string) error {
+	var settingColumns []string
+	pending := map[string]bool{}
+	for _, change := range changes {
+		if change.Section == "settings" {
+			settingColumns = append(settingColumns, change.Key)
+			if change.Key == "provider_template_ids" && len(template.Spec.Settings.ProviderTemplateIDs) > 0 {
+				settingColumns = append(settingColumns, "provider_config", "provider_config_hash")
+			}
+			continue
+		}
+		pending[change.Section+"/"+change.Key] = true
+	}
+
+	applyTemplateSettings(ws, template.Spec.Settings)
+	ws.TemplateVersion = template.Version
+	columns := append(settingColumns, "template_version")
+	if err := tx.Model(ws).Select(columns).Updates(ws).Error; err != nil {
+		return fmt.Errorf("failed to update workspace settings: %w", err)
+	}
+
+	for _, rt := range template.Spec.RunTasks {
+		key := templateRunTaskKey(rt.RunTaskID, rt.Stage)
+		if !pending["run_tasks/"+key] {
+			continue
+		}
+		var existing models.WorkspaceRunTask
+		err := tx.Where("workspace_id = ? AND run_task_id = ? AND stage = ?", ws.WorkspaceID, rt.RunTaskID, rt.Stage).First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if err := createTemplateRunTask(tx, ws.WorkspaceID, rt, userID); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to query workspace run task: %w", err)
+		}
+		if err := tx.Model(&existing).Updates(map[string]interface{}{
+			"enforcement_level": rt.EnforcementLevel,
+			"enabled":           templateEnabled(rt.Enabled),
+		}).Error; err != nil {
+			return fmt.Errorf("failed to update workspace run task: %w", err)
+		}
+	}
+
+	for _, n := range template.Spec.Notifications {
+		if !pending["notifications/"+n.NotificationID] {
+			continue
+		}
+		var existing models.WorkspaceNotification
+		err := tx.Where("workspace_id = ? AND notification_id = ?", ws.WorkspaceID, n.NotificationID).First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if err := createTemplateNotification(tx, ws.WorkspaceID, n, userID); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to query workspace notification: %w", err)
+		}
+		if err := tx.Model(&existing).Updates(map[string]interface{}{
+			"events":  n.Events,
+			"enabled": templateEnabled(n.Enabled),
+		}).Error; err != nil {
+			return fmt.Errorf("failed to update workspace notification: %w", err)
+		}
+	}
+
+	for _, v := range template.Spec.Variables {
+		if !pending["variables/"+v.Key] {
+			continue
+		}
+		value := v.Value
+		if provided[v.Key] != "" {
+			value = provided[v.Key]
+		}
+		if err := createTemplateVariable(tx, ws.WorkspaceID, v, value, userID); err != nil {
+			return err
+		}
+	}
+
+	for _, r := range template.Spec.Resources {
+		resourceType, _, err := templateResourceCode(tx, r)
+		if err != nil {
+			return err
+		}
+		if !pending["resources/"+fmt.Sprintf("%s.%s", resourceType, r.Name)] {
+			continue
+		}
+		if err := createTemplateResource(tx, ws.WorkspaceID, r, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyTemplateSettings 将模板设置写入 workspace（只覆盖非空字段）
+func applyTemplateSettings(ws *models.Workspace, s models.WorkspaceTemplateSettings) {
+	if s.ExecutionMode != nil {
+		ws.ExecutionMode = models.ExecutionMode(*s.ExecutionMode)
+	}
+	if s.AgentPoolID != nil {
+		ws.AgentPoolID = s.AgentPoolID
+	}
+	if s.CurrentPoolID != nil {
+		ws.CurrentPoolID = s.CurrentPoolID
+	}
+	if s.K8sConfigID != nil {
+		ws.K8sConfigID = s.K8sConfigID
+	}
+	if s.TerraformVersion != nil {
+		ws.TerraformVersion = *s.TerraformVersion
+	}
+	if s.Workdir != nil {
+		ws.Workdir = *s.Workdir
+	}
+	if s.StateBackend != nil {
+		ws.StateBackend = *s.StateBackend
+	}
+	if s.AutoApply != nil {
+		ws.AutoApply = *s.AutoApply
+	}
+	if s.PlanOnly != nil {
+		ws.PlanOnly = *s.PlanOnly
+	}
+	if s.UIMode != nil {
+		ws.UIMode = *s.UIMode
+	}
+	if s.ShowUnchangedResources != nil {
+		ws.ShowUnchangedResources = *s.ShowUnchangedResources
+	}
+	if s.OutputsSharing != nil {
+		ws.OutputsSharing = *s.OutputsSharing
+	}
+	if s.RetryEnabled != nil {
+		ws.RetryEnabled = *s.RetryEnabled
+	}
+	if s.MaxRetries != nil {
+		ws.MaxRetries = *s.MaxRetries
+	}
+	if s.DriftCheckEnabled != nil {
+		ws.DriftCheckEnabled = *s.DriftCheckEnabled
+	}
+	if s.DriftCheckStartTime != nil {
+		ws.DriftCheckStartTime = *s.DriftCheckStartTime
+	}
+	if s.DriftCheckEndTime != nil {
+		ws.DriftCheckEndTime = *s.DriftCheckEndTime
+	}
+	if s.DriftCheckInterval != nil {
+		ws.DriftCheckInterval = *s.DriftCheckInterval
+	}
+	if s.ProviderTemplateIDs != nil {
+		ids := make([]interface{}, len(s.ProviderTemplateIDs))
+		for i, id := range s.ProviderTemplateIDs {
+			ids[i] = id
+		}
+		ws.ProviderTemplateIDs = models.JSONB{"_array": ids}
+		// 模板模式下清空 provider_config，读取时动态解析
+		if len(s.ProviderTemplateIDs) > 0 {
+			ws.ProviderConfig = nil
+			ws.ProviderConfigHash = ""
+		}
+	}
+	if s.ProviderOverrides != nil {
+		ws.ProviderOverrides = models.JSONB(s.ProviderOverrides)
+	}
+	if s.Tags != nil {
+		ws.Tags = models.JSONB(s.Tags)
+	}
+	if s.NotifySettings != nil {
+		ws.NotifySettings = models.JSONB(s.NotifySettings)
+	}
+	if s.StaticAnalysisConfig != nil {
+		ws.StaticAnalysisConfig = models.JSONB(s.StaticAnalysisConfig)
+	}
+	if s.CostEstimationConfig != nil {
+		ws.CostEstimationConfig = models.JSONB(s.CostEstimationConfig)
+	}
+}
+
+// templateSettingKeys 模板管理的设置字段（json 名称即数据库列名）
+func templateSettingKeys(s models.WorkspaceTemplateSettings) []string {
+	raw, _ := json.Marshal(s)
+	var fields map[string]interface{}
+	_ = json.Unmarshal(raw, &fields)
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// workspaceSettingsSnapshot 以 JSON 形式读取 workspace 字段，便于与模板设置比较
+func workspaceSettingsSnapshot(ws *models.Workspace) map[string]interface{} {
+	raw, _ := json.Marshal(ws)
+	var snapshot map[string]interface{}
+	_ = json.Unmarshal(raw, &snapshot)
+	return snapshot
+}
+
+func createTemplateRunTask(tx *gorm.DB, workspaceID string, rt models.WorkspaceTemplateRunTask, userID string) error {
+	id, err := infrastructure.GenerateWorkspaceRunTaskID()
+	if err != nil {
+		return fmt.Errorf("failed to generate workspace run task ID: %w", err)
+	}
+	wrt := &models.WorkspaceRunTask{
+		WorkspaceRunTaskID: id,
+		WorkspaceID:        workspaceID,
+		RunTaskID:          rt.RunTaskID,
+		Stage:              rt.Stage,
+		EnforcementLevel:   rt.EnforcementLevel,
+		Enabled:            templateEnabled(rt.Enabled),
+	}
+	if userID != "" {
+		wrt.CreatedBy = &userID
+	}
+	// Enabled 默认值为 true，显式写入 false
+	if err := tx.Select("*").Omit("id").Create(wrt).Error; err != nil {
+		return fmt.Errorf("failed to attach run task %s: %w", rt.RunTaskID, err)
+	}
+	return nil
+}
+
+func createTemplateNotification(tx *gorm.DB, workspaceID string, n models.WorkspaceTemplateNotification, userID string) error {
+	wn := &models.WorkspaceNotification{
+		WorkspaceNotificationID: fmt.Sprintf("wn-%s-%s-%d", workspaceID, n.NotificationID, time.Now().Unix()),
+		WorkspaceID:             workspaceID,
+		NotificationID:          n.NotificationID,
+		Events:                  n.Events,
+		Enabled:                 templateEnabled(n.Enabled),
+	}
+	if userID != "" {
+		wn.CreatedBy = &userID
+	}
+	if err := tx.Select("*").Omit("id").Create(wn).Error; err != nil {
+		return fmt.Errorf("failed to attach notification %s: %w", n.NotificationID, err)
+	}
+	return nil
+}
+
+func createTemplateVariable(tx *gorm.DB, workspaceID string, v models.WorkspaceTemplateVariable, value string, userID string) error {
+	// 敏感变量默认值在模板中已加密，BeforeCreate 不会重复加密
+	variable := &models.WorkspaceVariable{
+		WorkspaceID:  workspaceID,
+		Key:          v.Key,
+		Version:      1,
+		Value:        value,
+		VariableType: v.VariableType,
+		ValueFormat:  v.ValueFormat,
+		Sensitive:    v.Sensitive,
+		Description:  v.Description,
+	}
+	if err := validateSecretRef(variable); err != nil {
+		return fmt.Errorf("%w: %v", ErrWorkspaceTemplateInvalid, err)
+	}
+	if userID != "" {
+		variable.CreatedBy = &userID
+	}
+	if err := tx.Create(variable).Error; err != nil {
+		return fmt.Errorf("failed to create variable %s: %w", v.Key, err)
+	}
+	return nil
+}
+
+func createTemplateResource(tx *gorm.DB, workspaceID string, r models.WorkspaceTemplateResource, userID string) error {
+	resourceType, tfCode, err := templateResourceCode(tx, r)
+	if err != nil {
+		return err
+	}
+	resource := &models.WorkspaceResource{
+		WorkspaceID:  workspaceID,
+		ResourceID:   fmt.Sprintf("%s.%s", resourceType, r.Name),
+		ResourceType: resourceType,
+		ResourceName: r.Name,
+		IsActive:     true,
+		Description:  r.Description,
+	}
+	if userID != "" {
+		resource.CreatedBy = &userID
+	}
+	// 模板资源不带 tags，交给数据库默认值
+	if err := tx.Omit("Tags").Create(resource).Error; err != nil {
+		return fmt.Errorf("failed to create resource %s: %w", resource.ResourceID, err)
+	}
+
+	version := &models.ResourceCodeVersion{
+		ResourceID:    resource.ID,
+		Version:       1,
+		IsLatest:      true,
+		TFCode:        tfCode,
+		ChangeType:    "create",
+		ChangeSummary: "Created from workspace template",
+		CreatedBy:     resource.CreatedBy,
+	}
+	if err := tx.Create(version).Error; err != nil {
+		return fmt.Errorf("failed to create resource version for %s: %w", resource.ResourceID, err)
+	}
+	return tx.Model(resource).Update("current_version_id", version.ID).Error
+}
+
+// templateResourceCode 生成资源类型和 module 代码，格式与前端添加资源一致：
+// {"module": {"<type>_<name>": [{"source": ..., "version": ..., ...config}]}}
+func templateResourceCode(db *gorm.DB, r models.WorkspaceTemplateResource) (string, models.JSONB, error) {
+	resourceType := r.ResourceType
+	source := r.ModuleSource
+	version := r.ModuleVersion
+
+	if r.ModuleID != nil {
+		var module models.Module
+		if err := db.First(&module, *r.ModuleID).Error; err != nil {
+			return "", nil, fmt.Errorf("failed to load module %d for resource %s: %w", *r.ModuleID, r.Name, err)
+		}
+		if resourceType == "" {
+			resourceType = fmt.Sprintf("%s_%s", module.Provider, module.Name)
+		}
+		if source == "" {
+			source = module.ModuleSource
+			if source == "" {
+				source = module.Source
+			}
+		}
+		if version == "" {
+			version = module.Version
+		}
+	}
+
+	block := map[string]interface{}{}
+	for k, v := range r.Config {
+		block[k] = v
+	}
+	block["source"] = source
+	if version != "" {
+		block["version"] = version
+	}
+	tfCode := models.JSONB{
+		"module": map[string]interface{}{
+			fmt.Sprintf("%s_%s", resourceType, r.Name): []interface{}{block},
+		},
+	}
+	return resourceType, tfCode, nil
+}
+
+func templateEnabled(enabled *bool) bool {
+	return enabled == nil || *enabled
+}
+
+func templateRunTaskKey(runTaskID string, stage models.RunTaskStage) string {
+	return fmt.Sprintf("%s@%s", runTaskID, stage)
+}
+
+func templateVariableKey(key string, variableType models.VariableType) string {
+	if variableType == "" {
+		variableType = models.VariableTypeTerraform
+	}
+	return fmt.Sprintf("%s/%s", variableType, key)
+}
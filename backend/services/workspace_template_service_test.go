@@ -0,0 +1,426 @@
+package services
+
+import (
+	"testing"
+
+	"iac-platform/internal/crypto"
+	"iac-platform/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupWorkspaceTemplateTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	// 敏感变量加密密钥由 JWT_SECRET 派生
+	t.Setenv("JWT_SECRET", "workspace-template-test-secret")
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+
+	// JSONB 字段使用 BLOB，保证读回为 []byte（JSONB.Scan 需要）
+	statements := []string{
+		`CREATE TABLE workspaces (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			workspace_id TEXT UNIQUE NOT NULL,
+			name TEXT NOT NULL UNIQUE,
+			description TEXT DEFAULT '',
+			created_by TEXT,
+			execution_mode TEXT DEFAULT 'local',
+			agent_id INTEGER,
+			auto_apply INTEGER DEFAULT 0,
+			plan_only INTEGER DEFAULT 0,
+			terraform_version TEXT DEFAULT 'latest',
+			workdir TEXT DEFAULT '/workspace',
+			state_backend TEXT DEFAULT 'local',
+			state_config BLOB,
+			is_locked INTEGER DEFAULT 0,
+			locked_by TEXT,
+			locked_at DATETIME,
+			lock_reason TEXT DEFAULT '',
+			tf_code BLOB,
+			tf_state BLOB,
+			provider_config BLOB,
+			provider_template_ids BLOB,
+			provider_overrides BLOB,
+			provider_config_hash TEXT DEFAULT '',
+			last_init_hash TEXT DEFAULT '',
+			last_init_terraform_version TEXT DEFAULT '',
+			terraform_lock_hcl TEXT DEFAULT '',
+			init_config BLOB,
+			retry_enabled INTEGER DEFAULT 1,
+			max_retries INTEGER DEFAULT 3,
+			notify_settings BLOB,
+			log_config BLOB,
+			state TEXT DEFAULT 'created',
+			tags BLOB,
+			system_variables BLOB,
+			static_analysis_config BLOB,
+			cost_estimation_config BLOB,
+			template_id TEXT,
+			template_version INTEGER DEFAULT 0,
+			resource_count INTEGER DEFAULT 0,
+			last_plan_at DATETIME,
+			last_apply_at DATETIME,
+			drift_count INTEGER DEFAULT 0,
+			last_drift_check DATETIME,
+			current_code_version_id INTEGER,
+			workspace_execution_mode TEXT DEFAULT 'plan_and_apply',
+			ui_mode TEXT DEFAULT 'console',
+			show_unchanged_resources INTEGER DEFAULT 0,
+			outputs_sharing TEXT DEFAULT 'none',
+			drift_check_enabled INTEGER DEFAULT 1,
+			drift_check_start_time TEXT DEFAULT '07:00:00',
+			drift_check_end_time TEXT DEFAULT '22:00:00',
+			drift_check_interval INTEGER DEFAULT 1440,
+			cmdb_sync_status TEXT DEFAULT 'idle',
+			cmdb_sync_triggered_by TEXT,
+			cmdb_sync_started_at DATETIME,
+			cmdb_sync_completed_at DATETIME,
+			agent_pool_id INTEGER,
+			current_pool_id TEXT,
+			k8s_config_id INTEGER,
+			created_at DATETIME,
+			updated_at DATETIME
+		)`,
+		`CREATE TABLE workspace_templates (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			template_id TEXT UNIQUE NOT NULL,
+			name TEXT UNIQUE NOT NULL,
+			description TEXT,
+			version INTEGER NOT NULL DEFAULT 1,
+			spec BLOB,
+			enabled INTEGER DEFAULT 1,
+			created_by TEXT,
+			created_at DATETIME,
+			updated_at DATETIME
+		)`,
+		`CREATE TABLE run_tasks (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			run_task_id TEXT UNIQUE NOT NULL,
+			name TEXT NOT NULL
+		)`,
+		`CREATE TABLE notification_configs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			notification_id TEXT UNIQUE NOT NULL,
+			name TEXT NOT NULL
+		)`,
+		`CREATE TABLE modules (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			provider TEXT NOT NULL,
+			source TEXT NOT NULL,
+			module_source TEXT,
+			version TEXT NOT NULL,
+			description TEXT,
+			status TEXT DEFAULT 'active',
+			default_version_id TEXT,
+			vcs_provider_id INTEGER,
+			repository_url TEXT,
+			branch TEXT DEFAULT 'main',
+			path TEXT DEFAULT '/',
+			module_files BLOB,
+			ai_prompts TEXT DEFAULT '[]',
+			sync_status TEXT DEFAULT 'pending',
+			require_passing_test INTEGER DEFAULT 0,
+			last_sync_at DATETIME,
+			created_by TEXT,
+			created_at DATETIME,
+			updated_at DATETIME
+		)`,
+		`CREATE TABLE workspace_run_tasks (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			workspace_run_task_id TEXT UNIQUE NOT NULL,
+			workspace_id TEXT NOT NULL,
+			run_task_id TEXT NOT NULL,
+			created_by TEXT,
+			created_at DATETIME,
+			updated_at DATETIME,
+			stage TEXT NOT NULL,
+			enforcement_level TEXT DEFAULT 'advisory',
+			enabled INTEGER DEFAULT 1
+		)`,
+		`CREATE TABLE workspace_notifications (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			workspace_notification_id TEXT UNIQUE NOT NULL,
+			workspace_id TEXT NOT NULL,
+			notification_id TEXT NOT NULL,
+			created_by TEXT,
+			created_at DATETIME,
+			updated_at DATETIME,
+			events TEXT NOT NULL DEFAULT 'task_completed,task_failed',
+			enabled INTEGER DEFAULT 1
+		)`,
+		`CREATE TABLE workspace_variables (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			variable_id TEXT UNIQUE NOT NULL,
+			workspace_id TEXT NOT NULL,
+			key TEXT NOT NULL,
+			version INTEGER NOT NULL DEFAULT 1,
+			value TEXT,
+			variable_type TEXT NOT NULL DEFAULT 'terraform',
+			value_format TEXT NOT NULL DEFAULT 'string',
+			sensitive INTEGER DEFAULT 0,
+			description TEXT,
+			is_deleted INTEGER DEFAULT 0,
+			created_at DATETIME,
+			updated_at DATETIME,
+			created_by TEXT
+		)`,
+		`CREATE TABLE workspace_resources (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			workspace_id TEXT NOT NULL,
+			resource_id TEXT NOT NULL,
+			resource_type TEXT NOT NULL,
+			resource_name TEXT NOT NULL,
+			current_version_id INTEGER,
+			is_active INTEGER DEFAULT 1,
+			description TEXT,
+			tags BLOB,
+			created_by TEXT,
+			created_at DATETIME,
+			updated_at DATETIME,
+			last_applied_at DATETIME,
+			manifest_deployment_id TEXT
+		)`,
+		`CREATE TABLE resource_code_versions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			resource_id INTEGER NOT NULL,
+			version INTEGER NOT NULL,
+			is_latest INTEGER DEFAULT 0,
+			tf_code BLOB NOT NULL,
+			variables BLOB,
+			change_summary TEXT,
+			change_type TEXT,
+			diff_from_previous TEXT,
+			state_version_id INTEGER,
+			task_id INTEGER,
+			created_by TEXT,
+			created_at DATETIME
+		)`,
+		`INSERT INTO run_tasks (run_task_id, name) VALUES ('rt-security-scan', 'security-scan')`,
+		`INSERT INTO notification_configs (notification_id, name) VALUES ('notif-lark-ops', 'lark-ops')`,
+		`INSERT INTO modules (id, name, provider, source, module_source, version)
+			VALUES (1, 's3', 'aws', 'terraform-aws-modules/s3-bucket/aws', 'git::https://example.com/s3.git', '4.1.0')`,
+	}
+	for _, stmt := range statements {
+		_, err := sqlDB.Exec(stmt)
+		require.NoError(t, err)
+	}
+	return db
+}
+
+func workspaceTemplateTestSpec() models.WorkspaceTemplateSpec {
+	executionMode := "local"
+	autoApply := true
+	moduleID := uint(1)
+	return models.WorkspaceTemplateSpec{
+		Settings: models.WorkspaceTemplateSettings{
+			ExecutionMode: &executionMode,
+			AutoApply:     &autoApply,
+			Tags:          map[string]interface{}{"team": "platform"},
+		},
+		RunTasks: []models.WorkspaceTemplateRunTask{
+			{RunTaskID: "rt-security-scan", Stage: models.RunTaskStagePostPlan},
+		},
+		Notifications: []models.WorkspaceTemplateNotification{
+			{NotificationID: "notif-lark-ops"},
+		},
+		Variables: []models.WorkspaceTemplateVariable{
+			{Key: "region", Value: "us-east-1"},
+			{Key: "db_password", Sensitive: true, Required: true},
+		},
+		Resources: []models.WorkspaceTemplateResource{
+			{Name: "logs", ModuleID: &moduleID, Config: map[string]interface{}{"bucket": "logs"}},
+		},
+	}
+}
+
+func TestWorkspaceTemplateService_CreateWorkspaceFromTemplate(t *testing.T) {
+	db := setupWorkspaceTemplateTestDB(t)
+	svc := NewWorkspaceTemplateService(db)
+
+	template, err := svc.Create(&models.CreateWorkspaceTemplateRequest{
+		Name: "golden-app",
+		Spec: workspaceTemplateTestSpec(),
+	}, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, template.Version)
+	assert.Equal(t, models.RunTaskEnforcementAdvisory, template.Spec.RunTasks[0].EnforcementLevel)
+	assert.Equal(t, "task_completed,task_failed", template.Spec.Notifications[0].Events)
+
+	// 缺少必填变量时不创建任何内容
+	_, err = svc.CreateWorkspaceFromTemplate(&models.CreateWorkspaceFromTemplateRequest{
+		TemplateID: template.TemplateID,
+		Name:       "app-prod",
+	}, "user-1")
+	require.ErrorIs(t, err, ErrWorkspaceTemplateInvalid)
+	assert.Contains(t, err.Error(), "db_password")
+	var count int64
+	db.Model(&models.Workspace{}).Count(&count)
+	assert.Zero(t, count)
+
+	ws, err := svc.CreateWorkspaceFromTemplate(&models.CreateWorkspaceFromTemplateRequest{
+		TemplateID: template.TemplateID,
+		Name:       "app-prod",
+		Variables:  map[string]string{"db_password": "s3cret"},
+	}, "user-1")
+	require.NoError(t, err)
+	require.NotNil(t, ws.TemplateID)
+	assert.Equal(t, template.TemplateID, *ws.TemplateID)
+	assert.Equal(t, 1, ws.TemplateVersion)
+
+	var stored models.Workspace
+	require.NoError(t, db.Where("workspace_id = ?", ws.WorkspaceID).First(&stored).Error)
+	assert.True(t, stored.AutoApply)
+	assert.Equal(t, models.ExecutionModeLocal, stored.ExecutionMode)
+	assert.Equal(t, "platform", stored.Tags["team"])
+
+	var runTasks []models.WorkspaceRunTask
+	require.NoError(t, db.Where("workspace_id = ?", ws.WorkspaceID).Find(&runTasks).Error)
+	require.Len(t, runTasks, 1)
+	assert.Equal(t, models.RunTaskStagePostPlan, runTasks[0].Stage)
+	assert.True(t, runTasks[0].Enabled)
+
+	db.Model(&models.WorkspaceNotification{}).Where("workspace_id = ?", ws.WorkspaceID).Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	var password models.WorkspaceVariable
+	require.NoError(t, db.Where("workspace_id = ? AND key = ?", ws.WorkspaceID, "db_password").First(&password).Error)
+	assert.Equal(t, "s3cret", password.Value)
+	var rawValue string
+	require.NoError(t, db.Raw("SELECT value FROM workspace_variables WHERE id = ?", password.ID).Scan(&rawValue).Error)
+	assert.True(t, crypto.IsEncrypted(rawValue))
+
+	var resource models.WorkspaceResource
+	require.NoError(t, db.Preload("CurrentVersion").Where("workspace_id = ?", ws.WorkspaceID).First(&resource).Error)
+	assert.Equal(t, "aws_s3.logs", resource.ResourceID)
+	require.NotNil(t, resource.CurrentVersion)
+	moduleBlock := resource.CurrentVersion.TFCode["module"].(map[string]interface{})["aws_s3_logs"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "git::https://example.com/s3.git", moduleBlock["source"])
+	assert.Equal(t, "4.1.0", moduleBlock["version"])
+	assert.Equal(t, "logs", moduleBlock["bucket"])
+
+	// 模板仍被引用时不能删除
+	require.ErrorIs(t, svc.Delete(template.TemplateID), ErrWorkspaceTemplateInUse)
+}
+
+func TestWorkspaceTemplateService_CreateRollsBackOnFailure(t *testing.T) {
+	db := setupWorkspaceTemplateTestDB(t)
+	svc := NewWorkspaceTemplateService(db)
+
+	template, err := svc.Create(&models.CreateWorkspaceTemplateRequest{
+		Name: "golden-app",
+		Spec: workspaceTemplateTestSpec(),
+	}, "user-1")
+	require.NoError(t, err)
+
+	// 模块在模板创建后被删除，资源创建失败时整个事务回滚
+	require.NoError(t, db.Exec("DELETE FROM modules").Error)
+	_, err = svc.CreateWorkspaceFromTemplate(&models.CreateWorkspaceFromTemplateRequest{
+		TemplateID: template.TemplateID,
+		Name:       "app-prod",
+		Variables:  map[string]string{"db_password": "s3cret"},
+	}, "user-1")
+	require.Error(t, err)
+
+	for _, table := range []string{"workspaces", "workspace_run_tasks", "workspace_notifications", "workspace_variables", "workspace_resources"} {
+		var count int64
+		require.NoError(t, db.Table(table).Count(&count).Error)
+		assert.Zero(t, count, table)
+	}
+}
+
+func TestWorkspaceTemplateService_PreviewAndPropagate(t *testing.T) {
+	db := setupWorkspaceTemplateTestDB(t)
+	svc := NewWorkspaceTemplateService(db)
+
+	template, err := svc.Create(&models.CreateWorkspaceTemplateRequest{
+		Name: "golden-app",
+		Spec: workspaceTemplateTestSpec(),
+	}, "user-1")
+	require.NoError(t, err)
+	ws, err := svc.CreateWorkspaceFromTemplate(&models.CreateWorkspaceFromTemplateRequest{
+		TemplateID: template.TemplateID,
+		Name:       "app-prod",
+		Variables:  map[string]string{"db_password": "s3cret"},
+	}, "user-1")
+	require.NoError(t, err)
+
+	previews, err := svc.PreviewPropagation(template.TemplateID, nil)
+	require.NoError(t, err)
+	require.Len(t, previews, 1)
+	assert.True(t, previews[0].UpToDate)
+	assert.Empty(t, previews[0].Changes)
+
+	// 修改模板：关闭 auto_apply、Run Task 改为 mandatory、新增必填变量
+	spec := workspaceTemplateTestSpec()
+	autoApply := false
+	spec.Settings.AutoApply = &autoApply
+	spec.RunTasks[0].EnforcementLevel = models.RunTaskEnforcementMandatory
+	spec.Variables[1].Value = ""
+	spec.Variables = append(spec.Variables, models.WorkspaceTemplateVariable{Key: "owner", Required: true})
+	template, err = svc.Update(template.TemplateID, &models.UpdateWorkspaceTemplateRequest{Spec: &spec})
+	require.NoError(t, err)
+	assert.Equal(t, 2, template.Version)
+
+	previews, err = svc.PreviewPropagation(template.TemplateID, []string{ws.WorkspaceID})
+	require.NoError(t, err)
+	require.Len(t, previews, 1)
+	assert.False(t, previews[0].UpToDate)
+	assert.Equal(t, 1, previews[0].FromVersion)
+	assert.Equal(t, 2, previews[0].ToVersion)
+	actions := map[string]string{}
+	for _, change := range previews[0].Changes {
+		actions[change.Section+"/"+change.Key] = change.Action
+	}
+	assert.Equal(t, map[string]string{
+		"settings/auto_apply":                  TemplateChangeUpdate,
+		"run_tasks/rt-security-scan@post_plan": TemplateChangeUpdate,
+		"variables/owner":                      TemplateChangeRequiresInput,
+	}, actions)
+
+	// 缺少新增必填变量的值时不应用
+	results, err := svc.Propagate(template.TemplateID, &models.PropagateWorkspaceTemplateRequest{}, "user-1")
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.False(t, results[0].Applied)
+	assert.Contains(t, results[0].Error, "owner")
+	var stored models.Workspace
+	require.NoError(t, db.Where("workspace_id = ?", ws.WorkspaceID).First(&stored).Error)
+	assert.True(t, stored.AutoApply)
+	assert.Equal(t, 1, stored.TemplateVersion)
+
+	results, err = svc.Propagate(template.TemplateID, &models.PropagateWorkspaceTemplateRequest{
+		Variables: map[string]string{"owner": "team-a"},
+	}, "user-1")
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.True(t, results[0].Applied, results[0].Error)
+
+	require.NoError(t, db.Where("workspace_id = ?", ws.WorkspaceID).First(&stored).Error)
+	assert.False(t, stored.AutoApply)
+	assert.Equal(t, 2, stored.TemplateVersion)
+	var runTask models.WorkspaceRunTask
+	require.NoError(t, db.Where("workspace_id = ?", ws.WorkspaceID).First(&runTask).Error)
+	assert.Equal(t, models.RunTaskEnforcementMandatory, runTask.EnforcementLevel)
+	var owner models.WorkspaceVariable
+	require.NoError(t, db.Where("workspace_id = ? AND key = ?", ws.WorkspaceID, "owner").First(&owner).Error)
+	assert.Equal(t, "team-a", owner.Value)
+
+	// 敏感变量更新时未重新提交值，沿用原加密默认值；已有变量值不被覆盖
+	var password models.WorkspaceVariable
+	require.NoError(t, db.Where("workspace_id = ? AND key = ?", ws.WorkspaceID, "db_password").First(&password).Error)
+	assert.Equal(t, "s3cret", password.Value)
+
+	previews, err = svc.PreviewPropagation(template.TemplateID, nil)
+	require.NoError(t, err)
+	assert.True(t, previews[0].UpToDate)
+}
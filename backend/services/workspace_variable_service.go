@@ -5,6 +5,8 @@ import (
 	"iac-platform/internal/crypto"
 	"iac-platform/internal/infrastructure"
 	"iac-platform/internal/models"
+	"iac-platform/internal/secretref"
+	"strings"
 
 	"gorm.io/gorm"
 )
@@ -24,6 +26,18 @@ func NewWorkspaceVariableService(db *gorm.DB) *WorkspaceVariableService {
 	return &WorkspaceVariableService{db: db}
 }
 
+// validateSecretRef 校验密文引用格式（引用的解析值在执行时一律按敏感处理）
+func validateSecretRef(variable *models.WorkspaceVariable) error {
+	if !variable.IsSecretRef() {
+		return nil
+	}
+	if _, err := secretref.Parse(variable.Value); err != nil {
+		return fmt.Errorf("变量 %s 的密文引用无效: %w", variable.Key, err)
+	}
+	variable.Value = strings.TrimSpace(variable.Value)
+	return nil
+}
+
 // CreateVariable 创建变量
 func (s *WorkspaceVariableService) CreateVariable(variable *models.WorkspaceVariable) error {
 	// 检查workspace是否存在（使用workspace_id字段）
@@ -62,8 +76,12 @@ func (s *WorkspaceVariableService) CreateVariable(variable *models.WorkspaceVari
 	variable.Version = maxVersion + 1
 	variable.IsDeleted = false
 
-	// 手动处理加密
-	if variable.Sensitive && variable.Value != "" && !crypto.IsEncrypted(variable.Value) {
+	if err := validateSecretRef(variable); err != nil {
+		return err
+	}
+
+	// 手动处理加密（密文引用只保存引用本身，不加密）
+	if variable.Sensitive && !variable.IsSecretRef() && variable.Value != "" && !crypto.IsEncrypted(variable.Value) {
 		encrypted, err := crypto.EncryptValue(variable.Value)
 		if err != nil {
 			return fmt.Errorf("加密失败: %w", err)
@@ -237,8 +255,12 @@ func (s *WorkspaceVariableService) UpdateVariable(id uint, expectedVersion int,
 		Scan(&maxVersion)
 	newVersion.Version = maxVersion + 1
 
+	if err := validateSecretRef(&newVersion); err != nil {
+		return nil, err
+	}
+
 	// 手动处理加密（因为要使用原生 SQL）
-	if newVersion.Sensitive && newVersion.Value != "" && !crypto.IsEncrypted(newVersion.Value) {
+	if newVersion.Sensitive && !newVersion.IsSecretRef() && newVersion.Value != "" && !crypto.IsEncrypted(newVersion.Value) {
 		encrypted, err := crypto.EncryptValue(newVersion.Value)
 		if err != nil {
 			return nil, fmt.Errorf("加密失败: %w", err)
@@ -337,7 +359,7 @@ func (s *WorkspaceVariableService) DeleteVariable(id uint) error {
 	}
 
 	// 手动处理加密
-	if deleteVersion.Sensitive && deleteVersion.Value != "" && !crypto.IsEncrypted(deleteVersion.Value) {
+	if deleteVersion.Sensitive && !deleteVersion.IsSecretRef() && deleteVersion.Value != "" && !crypto.IsEncrypted(deleteVersion.Value) {
 		encrypted, err := crypto.EncryptValue(deleteVersion.Value)
 		if err != nil {
 			return fmt.Errorf("加密失败: %w", err)
@@ -444,6 +466,12 @@ func (s *WorkspaceVariableService) BulkCreateVariables(variables []*models.Works
 		}
 	}
 
+	for _, variable := range variables {
+		if err := validateSecretRef(variable); err != nil {
+			return err
+		}
+	}
+
 	// 批量创建
 	if err := s.db.Create(&variables).Error; err != nil {
 		return fmt.Errorf("批量创建变量失败: %w", err)
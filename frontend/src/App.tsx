@@ -71,6 +71,9 @@ import SSOConfig from './pages/admin/SSOConfig';
 import MFASetup from './pages/MFASetup';
 import MFAVerify from './pages/MFAVerify';
 import MFAConfig from './pages/admin/MFAConfig';
+import ChangeFreezeCalendar from './pages/admin/ChangeFreezeCalendar';
+import Stacks from './pages/Stacks';
+import StackDetail from './pages/StackDetail';
 import './App.css';
 
 console.log('App component loaded');
@@ -155,6 +158,8 @@ const App: FC = () => {
                 <Route path="workspaces/create" element={<CreateWorkspace />} />
                 <Route path="workspaces/:id/edit" element={<EditWorkspace />} />
                 <Route path="workspaces/:id/resources" element={<WorkspaceResources />} />
+                <Route path="stacks" element={<Stacks />} />
+                <Route path="stacks/:stackId" element={<StackDetail />} />
                 <Route path="test-form" element={<TestDynamicForm />} />
                 <Route path="modules/:moduleId/schemas" element={<SchemaManagement />} />
                 <Route path="modules/:moduleId/schemas/:schemaId/edit" element={<SchemaEditorPage />} />
@@ -177,6 +182,7 @@ const App: FC = () => {
                 <Route path="global/settings/notifications/:notificationId/edit" element={<NotificationForm />} />
                 <Route path="global/settings/platform-config" element={<PlatformConfig />} />
                 <Route path="global/settings/mfa" element={<MFAConfig />} />
+                <Route path="global/settings/change-freezes" element={<ChangeFreezeCalendar />} />
                 <Route path="global/settings/sso" element={<SSOConfig />} />
                 <Route path="admin/manifests" element={<ManifestManagement />} />
                 <Route path="admin/manifests/new" element={<ManifestCreate />} />
@@ -0,0 +1,325 @@
+import React, { useState, useEffect, useCallback } from 'react';
+import { useToast } from '../contexts/ToastContext';
+import cmdbService from '../services/cmdb';
+import type {
+  ReconciliationFilter,
+  ReconciliationReport,
+  ReconciliationResource,
+  ReconciliationImportResult,
+} from '../services/cmdb';
+import styles from './CMDBReconciliationTab.module.css';
+
+interface CMDBReconciliationTabProps {
+  workspaces: { workspace_id: string; name: string }[];
+}
+
+// 资源位置（账号/区域）
+const formatLocation = (resource: { account_id?: string; region?: string }): string => {
+  return [resource.account_id, resource.region].filter(Boolean).join(' / ') || '-';
+};
+
+const CMDBReconciliationTab: React.FC<CMDBReconciliationTabProps> = ({ workspaces }) => {
+  const toast = useToast();
+  const [filter, setFilter] = useState<ReconciliationFilter>({});
+  const [report, setReport] = useState<ReconciliationReport | null>(null);
+  const [loading, setLoading] = useState(true);
+
+  // 导入状态
+  const [selected, setSelected] = useState<Set<number>>(new Set());
+  const [targetWorkspace, setTargetWorkspace] = useState('');
+  const [importing, setImporting] = useState(false);
+  const [importResult, setImportResult] = useState<ReconciliationImportResult | null>(null);
+
+  const loadReport = useCallback(async () => {
+    try {
+      setLoading(true);
+      const data = await cmdbService.getReconciliationReport(filter);
+      setReport(data);
+      setSelected(new Set());
+      setImportResult(null);
+    } catch (error) {
+      console.error('Failed to load reconciliation report:', error);
+      toast.error('加载对账报告失败');
+    } finally {
+      setLoading(false);
+    }
+    // eslint-disable-next-line react-hooks/exhaustive-deps
+  }, [filter]);
+
+  useEffect(() => {
+    loadReport();
+    // 只在首次加载时自动拉取，过滤条件变化后由用户手动刷新
+    // eslint-disable-next-line react-hooks/exhaustive-deps
+  }, []);
+
+  const toggleSelected = (id: number) => {
+    setSelected(prev => {
+      const next = new Set(prev);
+      if (next.has(id)) {
+        next.delete(id);
+      } else {
+        next.add(id);
+      }
+      return next;
+    });
+  };
+
+  const handleImport = async (dryRun: boolean) => {
+    if (!targetWorkspace || selected.size === 0) return;
+    try {
+      setImporting(true);
+      const result = await cmdbService.importUnmanagedResources({
+        workspace_id: targetWorkspace,
+        items: Array.from(selected).map(id => ({ resource_index_id: id })),
+        dry_run: dryRun,
+      });
+      setImportResult(result);
+      if (!dryRun && result.created.length > 0) {
+        toast.success(`已导入 ${result.created.length} 个资源，请在 Workspace 中执行 Plan 完成导入`);
+        setSelected(new Set());
+      }
+      if (result.errors && result.errors.length > 0) {
+        toast.error(`${result.errors.length} 个资源无法导入`);
+      }
+    } catch (error: any) {
+      console.error('Failed to import resources:', error);
+      toast.error(error?.response?.data?.error || '导入资源失败');
+    } finally {
+      setImporting(false);
+    }
+  };
+
+  const renderResourceTable = (resources: ReconciliationResource[], selectable: boolean) => (
+    <table className={styles.table}>
+      <thead>
+        <tr>
+          {selectable && <th></th>}
+          <th>Type</th>
+          <th>{selectable ? 'Cloud Resource' : 'Terraform Address'}</th>
+          <th>{selectable ? 'Source' : 'Workspace'}</th>
+          <th>Account / Region</th>
+        </tr>
+      </thead>
+      <tbody>
+        {resources.map(resource => (
+          <tr key={resource.resource_index_id}>
+            {selectable && (
+              <td>
+                <input
+                  type="checkbox"
+                  checked={selected.has(resource.resource_index_id)}
+                  onChange={() => toggleSelected(resource.resource_index_id)}
+                />
+              </td>
+            )}
+            <td className={styles.mono}>{resource.resource_type}</td>
+            <td className={styles.mono}>
+              {selectable
+                ? resource.cloud_resource_name || resource.cloud_resource_id || resource.cloud_resource_arn
+                : resource.terraform_address}
+            </td>
+            <td>
+              {selectable
+                ? resource.external_source_id
+                : resource.workspace_name || resource.workspace_id}
+            </td>
+            <td>{formatLocation(resource)}</td>
+          </tr>
+        ))}
+      </tbody>
+    </table>
+  );
+
+  return (
+    <div className={styles.container}>
+      {/* 过滤条件 */}
+      <div className={styles.filters}>
+        {([
+          ['cloud_provider', 'Provider'],
+          ['account_id', 'Account'],
+          ['region', 'Region'],
+          ['resource_type', 'Resource Type'],
+        ] as [keyof ReconciliationFilter, string][]).map(([key, label]) => (
+          <div key={key} className={styles.filterGroup}>
+            <label className={styles.label}>{label}</label>
+            <input
+              className={styles.input}
+              value={filter[key] || ''}
+              onChange={e => setFilter({ ...filter, [key]: e.target.value })}
+            />
+          </div>
+        ))}
+        <button className={styles.primaryButton} onClick={loadReport} disabled={loading}>
+          {loading ? 'Loading...' : 'Refresh'}
+        </button>
+      </div>
+
+      {loading && !report && <div className={styles.loading}>Loading reconciliation report...</div>}
+
+      {report && (
+        <>
+          {/* 汇总 */}
+          <div className={styles.summary}>
+            <div className={styles.summaryCard}>
+              <div className={styles.summaryValue}>{report.summary.external_resources}</div>
+              <div className={styles.summaryLabel}>External resources</div>
+            </div>
+            <div className={styles.summaryCard}>
+              <div className={styles.summaryValue}>{report.summary.managed}</div>
+              <div className={styles.summaryLabel}>Managed by Terraform</div>
+            </div>
+            <div className={styles.summaryCard}>
+              <div className={`${styles.summaryValue} ${styles.warning}`}>{report.summary.unmanaged}</div>
+              <div className={styles.summaryLabel}>Unmanaged</div>
+            </div>
+            <div className={styles.summaryCard}>
+              <div className={`${styles.summaryValue} ${styles.danger}`}>{report.summary.multi_managed}</div>
+              <div className={styles.summaryLabel}>Managed by multiple workspaces</div>
+            </div>
+            <div className={styles.summaryCard}>
+              <div className={`${styles.summaryValue} ${styles.warning}`}>{report.summary.missing_externally}</div>
+              <div className={styles.summaryLabel}>Missing from inventory</div>
+            </div>
+          </div>
+
+          {/* 分组统计 */}
+          <div className={styles.section}>
+            <h3 className={styles.sectionTitle}>By account / region / type</h3>
+            {report.groups.length === 0 ? (
+              <div className={styles.emptyState}>No discrepancies found</div>
+            ) : (
+              <table className={styles.table}>
+                <thead>
+                  <tr>
+                    <th>Account</th>
+                    <th>Region</th>
+                    <th>Type</th>
+                    <th>Unmanaged</th>
+                    <th>Multi-managed</th>
+                    <th>Missing</th>
+                  </tr>
+                </thead>
+                <tbody>
+                  {report.groups.map(group => (
+                    <tr key={`${group.account_id}|${group.region}|${group.resource_type}`}>
+                      <td>{group.account_id || '-'}</td>
+                      <td>{group.region || '-'}</td>
+                      <td className={styles.mono}>{group.resource_type}</td>
+                      <td>{group.unmanaged}</td>
+                      <td>{group.multi_managed}</td>
+                      <td>{group.missing_externally}</td>
+                    </tr>
+                  ))}
+                </tbody>
+              </table>
+            )}
+          </div>
+
+          {/* 未被管理的资源 */}
+          <div className={styles.section}>
+            <div className={styles.sectionHeader}>
+              <h3 className={styles.sectionTitle}>Unmanaged resources ({report.unmanaged.length})</h3>
+            </div>
+            {report.unmanaged.length === 0 ? (
+              <div className={styles.emptyState}>Every external resource is managed by a workspace</div>
+            ) : (
+              <>
+                <div className={styles.importPanel}>
+                  <div className={styles.filterGroup}>
+                    <label className={styles.label}>Import into workspace</label>
+                    <select
+                      className={styles.select}
+                      value={targetWorkspace}
+                      onChange={e => setTargetWorkspace(e.target.value)}
+                    >
+                      <option value="">Select workspace</option>
+                      {workspaces.map(ws => (
+                        <option key={ws.workspace_id} value={ws.workspace_id}>
+                          {ws.name}
+                        </option>
+                      ))}
+                    </select>
+                  </div>
+                  <button
+                    className={styles.actionButton}
+                    disabled={importing || !targetWorkspace || selected.size === 0}
+                    onClick={() => handleImport(true)}
+                  >
+                    Preview
+                  </button>
+                  <button
+                    className={styles.primaryButton}
+                    disabled={importing || !targetWorkspace || selected.size === 0}
+                    onClick={() => handleImport(false)}
+                  >
+                    Import {selected.size > 0 ? `(${selected.size})` : ''}
+                  </button>
+                </div>
+                {importResult?.errors && importResult.errors.length > 0 && (
+                  <ul className={styles.errorList}>
+                    {importResult.errors.map(err => (
+                      <li key={err.resource_index_id}>
+                        #{err.resource_index_id}: {err.error}
+                      </li>
+                    ))}
+                  </ul>
+                )}
+                {importResult && Object.keys(importResult.tf_code || {}).length > 0 && (
+                  <pre className={styles.preview}>{JSON.stringify(importResult.tf_code, null, 2)}</pre>
+                )}
+                {renderResourceTable(report.unmanaged, true)}
+              </>
+            )}
+          </div>
+
+          {/* 多 workspace 管理 */}
+          <div className={styles.section}>
+            <h3 className={styles.sectionTitle}>Managed by multiple workspaces ({report.multi_managed.length})</h3>
+            {report.multi_managed.length === 0 ? (
+              <div className={styles.emptyState}>No resource is managed by more than one workspace</div>
+            ) : (
+              <table className={styles.table}>
+                <thead>
+                  <tr>
+                    <th>Type</th>
+                    <th>Cloud Resource</th>
+                    <th>Terraform Addresses</th>
+                    <th>Account / Region</th>
+                  </tr>
+                </thead>
+                <tbody>
+                  {report.multi_managed.map(item => (
+                    <tr key={`${item.resource_type}|${item.cloud_resource_arn || item.cloud_resource_id}`}>
+                      <td className={styles.mono}>{item.resource_type}</td>
+                      <td className={styles.mono}>{item.cloud_resource_arn || item.cloud_resource_id}</td>
+                      <td>
+                        {item.instances.map(instance => (
+                          <div key={instance.resource_index_id} className={styles.mono}>
+                            {instance.workspace_name || instance.workspace_id}: {instance.terraform_address}
+                          </div>
+                        ))}
+                      </td>
+                      <td>{formatLocation(item)}</td>
+                    </tr>
+                  ))}
+                </tbody>
+              </table>
+            )}
+          </div>
+
+          {/* 外部清单中缺失 */}
+          <div className={styles.section}>
+            <h3 className={styles.sectionTitle}>Missing from external inventory ({report.missing_externally.length})</h3>
+            {report.missing_externally.length === 0 ? (
+              <div className={styles.emptyState}>All Terraform-managed resources are present in the external inventory</div>
+            ) : (
+              renderResourceTable(report.missing_externally, false)
+            )}
+          </div>
+        </>
+      )}
+    </div>
+  );
+};
+
+export default CMDBReconciliationTab;
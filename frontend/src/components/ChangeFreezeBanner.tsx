@@ -0,0 +1,134 @@
+import React, { useEffect, useState } from 'react';
+import { useToast } from '../hooks/useToast';
+import {
+  getTaskChangeFreeze,
+  requestChangeFreezeException,
+  type TaskChangeFreezeStatus,
+} from '../services/changeFreeze';
+import styles from './ChangeFreezeBanner.module.css';
+
+interface ChangeFreezeBannerProps {
+  workspaceId: string;
+  taskId: number;
+  taskType: string;
+  taskStatus: string;
+  canRequestException: boolean;
+}
+
+// 仅在等待 apply 的 plan_and_apply 任务上展示冻结状态
+const WATCHED_STATUSES = ['plan_completed', 'apply_pending'];
+
+const ChangeFreezeBanner: React.FC<ChangeFreezeBannerProps> = ({
+  workspaceId,
+  taskId,
+  taskType,
+  taskStatus,
+  canRequestException,
+}) => {
+  const { showToast } = useToast();
+  const [status, setStatus] = useState<TaskChangeFreezeStatus | null>(null);
+  const [showForm, setShowForm] = useState(false);
+  const [reason, setReason] = useState('');
+  const [submitting, setSubmitting] = useState(false);
+
+  const watched = taskType === 'plan_and_apply' && WATCHED_STATUSES.includes(taskStatus);
+
+  useEffect(() => {
+    if (!watched) {
+      setStatus(null);
+      return;
+    }
+    fetchStatus();
+    const interval = setInterval(fetchStatus, 10000);
+    return () => clearInterval(interval);
+  }, [workspaceId, taskId, watched]);
+
+  const fetchStatus = async () => {
+    try {
+      setStatus(await getTaskChangeFreeze(workspaceId, taskId));
+    } catch (err) {
+      console.error('Failed to fetch change freeze status:', err);
+    }
+  };
+
+  const handleSubmit = async () => {
+    try {
+      setSubmitting(true);
+      await requestChangeFreezeException(workspaceId, taskId, reason.trim());
+      showToast('Exception requested; an administrator must approve it', 'success');
+      setShowForm(false);
+      setReason('');
+      fetchStatus();
+    } catch (error: any) {
+      showToast(error.response?.data?.error || 'Failed to request exception', 'error');
+    } finally {
+      setSubmitting(false);
+    }
+  };
+
+  if (!status || (status.active.length === 0 && !status.exception)) {
+    return null;
+  }
+
+  const exception = status.exception;
+  const bypassed = exception?.status === 'approved';
+
+  return (
+    <div className={bypassed ? styles.bannerInfo : styles.bannerWarning}>
+      <div className={styles.title}>
+        {status.active.length > 0 ? 'Change freeze in effect' : 'Change freeze exception'}
+      </div>
+      {status.active.map(f => (
+        <div key={f.window_id} className={styles.freeze}>
+          <strong>{f.name}</strong> until {new Date(f.until).toLocaleString()}
+          {f.description && <span className={styles.muted}> — {f.description}</span>}
+        </div>
+      ))}
+      {status.active.length > 0 && !bypassed && (
+        <div className={styles.muted}>
+          Confirmed applies are held and will run automatically once the freeze ends or an exception is approved.
+        </div>
+      )}
+
+      {exception && (
+        <div className={styles.exception}>
+          Exception <span className={styles[`status_${exception.status}`]}>{exception.status}</span>
+          {' '}requested by {exception.requested_by}: {exception.reason}
+          {exception.reviewed_by && (
+            <div className={styles.muted}>
+              Reviewed by {exception.reviewed_by}{exception.review_comment ? `: ${exception.review_comment}` : ''}
+            </div>
+          )}
+        </div>
+      )}
+
+      {status.active.length > 0 && canRequestException && (!exception || exception.status === 'rejected') && (
+        showForm ? (
+          <div className={styles.form}>
+            <textarea
+              className={styles.textarea}
+              value={reason}
+              onChange={e => setReason(e.target.value)}
+              placeholder="Why must this run be applied during the freeze?"
+              rows={3}
+            />
+            <div className={styles.actions}>
+              <button className={styles.secondaryButton} onClick={() => setShowForm(false)} disabled={submitting}>
+                Cancel
+              </button>
+              <button className={styles.primaryButton} onClick={handleSubmit} disabled={submitting || !reason.trim()}>
+                {submitting ? 'Submitting...' : 'Request Exception'}
+              </button>
+            </div>
+          </div>
+        ) : (
+          <button className={styles.linkButton} onClick={() => setShowForm(true)}>
+            Request an exception for this run
+          </button>
+        )
+      )}
+    </div>
+  );
+};
+
+export default ChangeFreezeBanner;
@@ -118,6 +118,7 @@ const Layout: React.FC = () => {
     { path: '/modules', label: 'Modules', icon: '', requireAdmin: false, requireModulesPermission: true },
     { path: '/admin/manifests', label: 'Manifests', icon: '', requireGlobalSettingsPermission: true },
     { path: '/workspaces', label: 'Workspaces', icon: '', requireWorkspacesPermission: true },
+    { path: '/stacks', label: 'Stacks', icon: '', requireWorkspacesPermission: true },
     { path: '/cmdb', label: 'CMDB', icon: '' },
     { path: '/iam/organizations', label: 'IAM', icon: '', requireIAMPermission: true },
     {
@@ -132,6 +133,7 @@ const Layout: React.FC = () => {
         { path: '/global/settings/agent-pools', label: 'Agent Pools', icon: '' },
         { path: '/global/settings/run-tasks', label: 'Run Tasks', icon: '' },
         { path: '/global/settings/notifications', label: 'Notifications', icon: '' },
+        { path: '/global/settings/change-freezes', label: 'Change Freezes', icon: '' },
         { path: '/global/settings/platform-config', label: 'Platform Config', icon: '' },
         { path: '/global/settings/mfa', label: 'MFA Security', icon: '' },
         { path: '/global/settings/sso', label: 'SSO Config', icon: '' },
@@ -83,6 +83,18 @@ interface ResourceChangesResponse {
   actions?: ActionResource[];
 }
 
+// 删除/替换资源的影响分析（来自CMDB资源关系图）
+interface TaskImpactReport {
+  changes: { resource_address: string; action: string }[];
+  impact: {
+    resources: { id: string; workspace_id: string; workspace_name?: string; terraform_address: string; depth: number; depends_on: string; attribute?: string }[];
+    workspaces: { workspace_id: string; workspace_name?: string; depth: number; reasons: string[] }[];
+  };
+  // 无权读取的 workspace 中受影响的资源/workspace 数量
+  hidden_resources?: number;
+  hidden_workspaces?: number;
+}
+
 interface Props {
   task: Task;
   workspaceId: number | string;
@@ -105,6 +117,7 @@ const StructuredRunOutput: React.FC<Props> = ({ task, workspaceId, workspace, mo
   const [actionInvocations, setActionInvocations] = useState<ActionInvocation[]>([]);
   const [actions, setActions] = useState<ActionResource[]>([]);
   const [summary, setSummary] = useState({ add: 0, change: 0, destroy: 0 });
+  const [impact, setImpact] = useState<TaskImpactReport | null>(null);
   const [loading, setLoading] = useState(false);
   
   // 使用 WebSocket 获取实时阶段信息（仅在任务运行时）
@@ -161,6 +174,20 @@ const StructuredRunOutput: React.FC<Props> = ({ task, workspaceId, workspace, mo
     }
   }, [task.id, task.status, workspaceId]); // 添加workspaceId依赖
 
+  // 计划中有删除/替换时加载影响分析
+  useEffect(() => {
+    if (mode === 'apply' || summary.destroy === 0) {
+      setImpact(null);
+      return;
+    }
+    api.get(`/workspaces/${workspaceId}/tasks/${task.id}/impact`)
+      .then((report: any) => setImpact(report as TaskImpactReport))
+      .catch((err) => {
+        console.error('Failed to load impact analysis:', err);
+        setImpact(null);
+      });
+  }, [task.id, workspaceId, summary.destroy, mode]);
+
   // Apply 完成后，从 state 获取实际的 output 值
   useEffect(() => {
     if (task.status === 'applied') {
@@ -821,6 +848,40 @@ const StructuredRunOutput: React.FC<Props> = ({ task, workspaceId, workspace, mo
         );
       })()}
 
+      {/* 删除/替换资源的影响分析 */}
+      {impact && (impact.impact.resources.length > 0 || impact.impact.workspaces.length > 0 ||
+        !!impact.hidden_resources || !!impact.hidden_workspaces) && (
+        <div className={styles.impactPanel}>
+          <div className={styles.impactTitle}>
+            Impact: {impact.impact.resources.length} dependent resource(s)
+            {impact.impact.workspaces.length > 0 && ` in ${impact.impact.workspaces.length} other workspace(s)`}
+          </div>
+          <ul className={styles.impactList}>
+            {impact.impact.resources.map((res) => (
+              <li key={res.id}>
+                <code>{res.terraform_address}</code>
+                {res.workspace_id !== workspaceId && ` (${res.workspace_name || res.workspace_id})`}
+                {res.depends_on && <>{' → '}<code>{res.depends_on}</code></>}
+                {res.attribute && <span className={styles.impactMeta}> via {res.attribute}</span>}
+              </li>
+            ))}
+            {impact.impact.workspaces
+              .filter((ws) => !ws.reasons.includes('reference'))
+              .map((ws) => (
+                <li key={ws.workspace_id}>
+                  Workspace <strong>{ws.workspace_name || ws.workspace_id}</strong>
+                  <span className={styles.impactMeta}> ({ws.reasons.join(', ')})</span>
+                </li>
+              ))}
+            {(!!impact.hidden_resources || !!impact.hidden_workspaces) && (
+              <li className={styles.impactMeta}>
+                {impact.hidden_resources || 0} resource(s) in {impact.hidden_workspaces || 0} workspace(s) you cannot access are also affected
+              </li>
+            )}
+          </ul>
+        </div>
+      )}
+
       {/* 阶段Tab - 仅在未指定 mode 时显示 */}
       {!mode && (
         <div className={styles.stageTabs}>
@@ -13,6 +13,7 @@ interface TriggerExecution {
   disabled_by?: string;
   disabled_at?: string;
   error_message?: string;
+  changed_outputs?: string[] | null;
   run_trigger?: {
     id: number;
     target_workspace_id: string;
@@ -34,6 +35,7 @@ interface RunTrigger {
   target_workspace_id: string;
   enabled: boolean;
   trigger_condition: string;
+  output_names?: string[] | null;
   target_workspace?: {
     workspace_id: string;
     name: string;
@@ -250,6 +252,12 @@ const TaskTriggerExecutions: React.FC<Props> = ({ workspaceId, taskId, taskStatu
                     <span className={`${styles.statusTag} ${getStatusClass(execution)}`}>
                       {getStatusText(execution)}
                     </span>
+                    {execution.changed_outputs && execution.changed_outputs.length > 0 && (
+                      <div className={styles.detail}>Changed outputs: {execution.changed_outputs.join(', ')}</div>
+                    )}
+                    {execution.error_message && (
+                      <div className={styles.detail}>{execution.error_message}</div>
+                    )}
                   </td>
                   <td>
                     {execution.target_task_id ? (
@@ -299,8 +307,17 @@ const TaskTriggerExecutions: React.FC<Props> = ({ workspaceId, taskId, taskStatu
                     <span className={styles.conditionTag}>
                       {trigger.trigger_condition === 'apply_success' 
                         ? 'After Apply Success' 
-                        : trigger.trigger_condition}
+                        : trigger.trigger_condition === 'outputs_changed'
+                          ? 'When Outputs Change'
+                          : trigger.trigger_condition}
                     </span>
+                    {trigger.trigger_condition === 'outputs_changed' && (
+                      <div className={styles.detail}>
+                        {trigger.output_names && trigger.output_names.length > 0
+                          ? trigger.output_names.join(', ')
+                          : 'Outputs read through remote data'}
+                      </div>
+                    )}
                   </td>
                   <td>
                     <span className={`${styles.statusTag} ${styles.statusPending}`}>
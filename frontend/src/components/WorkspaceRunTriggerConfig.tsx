@@ -27,6 +27,7 @@ interface RunTrigger {
   target_workspace_id: string;
   enabled: boolean;
   trigger_condition: string;
+  output_names?: string[] | null;
   created_at: string;
   source_workspace?: {
     workspace_id: string;
@@ -56,6 +57,8 @@ const WorkspaceRunTriggerConfig: React.FC<Props> = ({ workspaceId, workspaceAuto
   const [showAddForm, setShowAddForm] = useState(false);
   const [selectedSource, setSelectedSource] = useState<string | undefined>(undefined);
   const [addEnabled, setAddEnabled] = useState(true);
+  const [addCondition, setAddCondition] = useState<'apply_success' | 'outputs_changed'>('apply_success');
+  const [addOutputNames, setAddOutputNames] = useState<string[]>([]);
   const [submitting, setSubmitting] = useState(false);
   
   // 删除确认对话框状态
@@ -126,6 +129,8 @@ const WorkspaceRunTriggerConfig: React.FC<Props> = ({ workspaceId, workspaceAuto
         body: JSON.stringify({
           source_workspace_id: selectedSource,
           enabled: addEnabled,
+          trigger_condition: addCondition,
+          output_names: addCondition === 'outputs_changed' ? addOutputNames : [],
         }),
       });
 
@@ -136,9 +141,7 @@ const WorkspaceRunTriggerConfig: React.FC<Props> = ({ workspaceId, workspaceAuto
         if (data.warning) {
           warning(data.warning);
         }
-        setShowAddForm(false);
-        setSelectedSource(undefined);
-        setAddEnabled(true);
+        resetAddForm();
         fetchTriggers();
         fetchAvailableSources();
       } else {
@@ -151,6 +154,14 @@ const WorkspaceRunTriggerConfig: React.FC<Props> = ({ workspaceId, workspaceAuto
     }
   };
 
+  const resetAddForm = () => {
+    setShowAddForm(false);
+    setSelectedSource(undefined);
+    setAddEnabled(true);
+    setAddCondition('apply_success');
+    setAddOutputNames([]);
+  };
+
   const handleDeleteClick = (trigger: RunTrigger) => {
     setDeletingTrigger(trigger);
     setDeleteDialogOpen(true);
@@ -216,11 +227,24 @@ const WorkspaceRunTriggerConfig: React.FC<Props> = ({ workspaceId, workspaceAuto
       title: 'Trigger Condition',
       dataIndex: 'trigger_condition',
       key: 'trigger_condition',
-      render: (condition: string) => (
-        <Tag color="blue">
-          {condition === 'apply_success' ? 'After Apply Success' : condition}
-        </Tag>
-      ),
+      render: (condition: string, record: RunTrigger) => {
+        if (condition !== 'outputs_changed') {
+          return (
+            <Tag color="blue">
+              {condition === 'apply_success' ? 'After Apply Success' : condition}
+            </Tag>
+          );
+        }
+        const names = record.output_names || [];
+        return (
+          <Space direction="vertical" size={4}>
+            <Tag color="purple">When Outputs Change</Tag>
+            <span style={{ fontSize: 12, color: '#666' }}>
+              {names.length > 0 ? names.join(', ') : 'Outputs read through remote data'}
+            </span>
+          </Space>
+        );
+      },
     },
     {
       title: 'Status',
@@ -268,6 +292,7 @@ const WorkspaceRunTriggerConfig: React.FC<Props> = ({ workspaceId, workspaceAuto
         <p style={{ color: '#666', marginBottom: 16 }}>
           Configure which workspaces are allowed to trigger this workspace.
           When a source workspace's apply completes successfully, it will automatically start a Plan+Apply task in this workspace.
+          Use "When Outputs Change" to trigger only when the source apply changed outputs this workspace depends on.
         </p>
 
         {/* Auto Apply 警告 */}
@@ -327,6 +352,37 @@ const WorkspaceRunTriggerConfig: React.FC<Props> = ({ workspaceId, workspaceAuto
               />
             )}
 
+            <div style={{ marginBottom: 16 }}>
+              <label style={{ display: 'block', marginBottom: 8, fontWeight: 500 }}>
+                Trigger Condition
+              </label>
+              <Select
+                style={{ width: '100%' }}
+                value={addCondition}
+                onChange={(value) => setAddCondition(value)}
+                options={[
+                  { value: 'apply_success', label: 'After Apply Success' },
+                  { value: 'outputs_changed', label: 'When Outputs Change' },
+                ]}
+              />
+              {addCondition === 'outputs_changed' && (
+                <>
+                  <Select
+                    mode="tags"
+                    style={{ width: '100%', marginTop: 8 }}
+                    placeholder="Output names, e.g. vpc_id"
+                    value={addOutputNames}
+                    onChange={(values) => setAddOutputNames(values)}
+                    tokenSeparators={[',', ' ']}
+                  />
+                  <p style={{ marginTop: 6, fontSize: 12, color: '#999' }}>
+                    Outputs are compared with the previous state version of the source workspace.
+                    Leave empty to watch the outputs this workspace reads through remote data.
+                  </p>
+                </>
+              )}
+            </div>
+
             <div style={{ marginBottom: 16 }}>
               <label style={{ display: 'block', marginBottom: 8, fontWeight: 500 }}>
                 Enabled
@@ -340,13 +396,7 @@ const WorkspaceRunTriggerConfig: React.FC<Props> = ({ workspaceId, workspaceAuto
             </div>
 
             <Space>
-              <Button
-                onClick={() => {
-                  setShowAddForm(false);
-                  setSelectedSource(undefined);
-                  setAddEnabled(true);
-                }}
-              >
+              <Button onClick={resetAddForm}>
                 Cancel
               </Button>
               <Button
@@ -17,6 +17,7 @@ import type {
   ExternalSourceResponse,
 } from '../services/cmdb';
 import ExternalSourcesTab from '../components/ExternalSourcesTab';
+import CMDBReconciliationTab from '../components/CMDBReconciliationTab';
 import styles from './CMDB.module.css';
 
 // Copy to clipboard helper
@@ -718,12 +719,12 @@ const CMDB: React.FC = () => {
   const toast = useToast();
 
   // Get initial state from URL
-  const initialTab = (searchParams.get('tab') as 'tree' | 'search' | 'external') || 'tree';
+  const initialTab = (searchParams.get('tab') as 'tree' | 'search' | 'external' | 'reconciliation') || 'tree';
   const initialQuery = searchParams.get('q') || '';
   const initialType = searchParams.get('type') || '';
 
   // State
-  const [activeTab, setActiveTab] = useState<'tree' | 'search' | 'external'>(initialTab);
+  const [activeTab, setActiveTab] = useState<'tree' | 'search' | 'external' | 'reconciliation'>(initialTab);
   const [stats, setStats] = useState<CMDBStats | null>(null);
   const [statsLoading, setStatsLoading] = useState(true);
 
@@ -761,7 +762,7 @@ const CMDB: React.FC = () => {
   const [warmupProgress, setWarmupProgress] = useState<WarmupProgress | null>(null);
 
   // Update URL when tab changes
-  const handleTabChange = (tab: 'tree' | 'search' | 'external') => {
+  const handleTabChange = (tab: 'tree' | 'search' | 'external' | 'reconciliation') => {
     setActiveTab(tab);
     const newParams = new URLSearchParams(searchParams);
     newParams.set('tab', tab);
@@ -1262,6 +1263,14 @@ const CMDB: React.FC = () => {
             External Sources
           </button>
         )}
+        {isAdmin && (
+          <button
+            className={`${styles.tab} ${activeTab === 'reconciliation' ? styles.tabActive : ''}`}
+            onClick={() => handleTabChange('reconciliation')}
+          >
+            Reconciliation
+          </button>
+        )}
       </div>
 
       {/* Resource tree tab */}
@@ -1544,6 +1553,13 @@ const CMDB: React.FC = () => {
           <ExternalSourcesTab />
         </div>
       )}
+
+      {/* Reconciliation tab */}
+      {activeTab === 'reconciliation' && isAdmin && (
+        <div className={styles.treeSection}>
+          <CMDBReconciliationTab workspaces={workspaces} />
+        </div>
+      )}
     </div>
   );
 };
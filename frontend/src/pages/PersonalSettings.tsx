@@ -3,6 +3,7 @@ import { useNavigate, useSearchParams } from 'react-router-dom';
 import api from '../services/api';
 import { getMFAStatus, getMFAConfig } from '../services/mfaService';
 import type { MFAStatus, MFAConfig } from '../services/mfaService';
+import { parseScopeList } from '../services/iam';
 import styles from './PersonalSettings.module.css';
 
 interface UserToken {
@@ -42,6 +43,9 @@ const PersonalSettings: React.FC = () => {
   const [showCreateToken, setShowCreateToken] = useState(false);
   const [tokenName, setTokenName] = useState('');
   const [expiresInDays, setExpiresInDays] = useState(90);
+  const [tokenReadOnly, setTokenReadOnly] = useState(true);
+  const [tokenWorkspaces, setTokenWorkspaces] = useState('');
+  const [tokenIPAllowlist, setTokenIPAllowlist] = useState('');
   const [createdToken, setCreatedToken] = useState<TokenCreateResponse | null>(null);
   const [tokenMessage, setTokenMessage] = useState('');
   const [tokenError, setTokenError] = useState('');
@@ -144,9 +148,16 @@ const PersonalSettings: React.FC = () => {
       const response = await api.post('/user/tokens', {
         token_name: tokenName,
         expires_in_days: expiresInDays,
+        scopes: {
+          read_only: tokenReadOnly,
+          workspace_ids: parseScopeList(tokenWorkspaces),
+          ip_allowlist: parseScopeList(tokenIPAllowlist),
+        },
       });
       setCreatedToken(response.data);
       setTokenName('');
+      setTokenWorkspaces('');
+      setTokenIPAllowlist('');
       setShowCreateToken(false);
       loadTokens();
     } catch (error: any) {
@@ -356,10 +367,44 @@ const PersonalSettings: React.FC = () => {
                       <option value={90}>90天</option>
                       <option value={180}>180天</option>
                       <option value={365}>365天</option>
-                      <option value={0}>永不过期</option>
                     </select>
                   </div>
 
+                  <div className={styles.formGroup}>
+                    <label>
+                      <input
+                        type="checkbox"
+                        checked={tokenReadOnly}
+                        onChange={(e) => setTokenReadOnly(e.target.checked)}
+                      />{' '}
+                      只读Token
+                    </label>
+                  </div>
+
+                  <div className={styles.formGroup}>
+                    <label htmlFor="tokenWorkspaces">限定工作空间（可选）</label>
+                    <input
+                      type="text"
+                      id="tokenWorkspaces"
+                      value={tokenWorkspaces}
+                      onChange={(e) => setTokenWorkspaces(e.target.value)}
+                      placeholder="ws-xxx, ws-yyy；留空表示不限定"
+                      className={styles.input}
+                    />
+                  </div>
+
+                  <div className={styles.formGroup}>
+                    <label htmlFor="tokenIPAllowlist">IP白名单（可选）</label>
+                    <input
+                      type="text"
+                      id="tokenIPAllowlist"
+                      value={tokenIPAllowlist}
+                      onChange={(e) => setTokenIPAllowlist(e.target.value)}
+                      placeholder="10.0.0.0/8, 192.168.1.10；留空表示不限制"
+                      className={styles.input}
+                    />
+                  </div>
+
                   <div className={styles.formActions}>
                     <button
                       type="button"
@@ -92,6 +92,24 @@ const SSOCallback: React.FC = () => {
         const response: any = await ssoService.callback(providerKey, code, state);
         const data = response.data || response;
 
+        // CLI 浏览器登录：把结果转交给 CLI 在本机监听的回调地址
+        if (data.cli_redirect_url) {
+          localStorage.removeItem('sso_provider');
+          // 回调地址自带 CLI 生成的 state 参数，需要原样保留
+          const target = new URL(data.cli_redirect_url);
+          const params = target.searchParams;
+          if (data.mfa_required) {
+            params.set('mfa_required', 'true');
+            params.set('mfa_token', data.mfa_token);
+          } else if (data.mfa_setup_required) {
+            params.set('mfa_setup_required', 'true');
+          } else if (data.token) {
+            params.set('token', data.token);
+          }
+          window.location.href = target.toString();
+          return;
+        }
+
         // 检查是否需要 MFA 验证
         if (data.mfa_required) {
           localStorage.removeItem('sso_provider');
@@ -174,7 +174,11 @@ const SkillDetail = () => {
       setSkill(updated);
       resetFormData(updated);
       setIsEditing(false);
-      setMessage({ type: 'success', text: '保存成功' });
+      if (updated.eval_warnings?.length) {
+        setMessage({ type: 'success', text: `保存成功（评估提示：${updated.eval_warnings.join('；')}）` });
+      } else {
+        setMessage({ type: 'success', text: '保存成功' });
+      }
     } catch (err: any) {
       setMessage({ type: 'error', text: err.response?.data?.error || '保存失败' });
     } finally {
@@ -0,0 +1,319 @@
+import React, { useEffect, useState } from 'react';
+import { Link, useParams } from 'react-router-dom';
+import { useToast } from '../hooks/useToast';
+import {
+  ACTIVE_STACK_RUN_STATUSES,
+  approveStackRun,
+  cancelStackRun,
+  createStackRun,
+  getStack,
+  getStackRunReview,
+  listStackRuns,
+  type Stack,
+  type StackEdge,
+  type StackMemberStatus,
+  type StackRun,
+  type StackRunStatus,
+} from '../services/stacks';
+import styles from './Stacks.module.css';
+
+const runBadge = (status: StackRunStatus) => {
+  switch (status) {
+    case 'completed':
+      return styles.badgeActive;
+    case 'failed':
+      return styles.badgeDanger;
+    case 'awaiting_approval':
+      return styles.badgeWarning;
+    case 'cancelled':
+      return styles.badgeMuted;
+    default:
+      return styles.badgeInfo;
+  }
+};
+
+const memberBadge = (status: StackMemberStatus) => {
+  switch (status) {
+    case 'applied':
+    case 'no_changes':
+      return styles.badgeActive;
+    case 'failed':
+      return styles.badgeDanger;
+    case 'planned':
+      return styles.badgeWarning;
+    case 'planning':
+    case 'applying':
+      return styles.badgeInfo;
+    default:
+      return styles.badgeMuted;
+  }
+};
+
+const EDGE_LABELS: Record<StackEdge['type'], string> = {
+  explicit: 'declared',
+  remote_data: 'remote data',
+  run_trigger: 'run trigger',
+};
+
+const StackDetail: React.FC = () => {
+  const { stackId } = useParams<{ stackId: string }>();
+  const { showToast } = useToast();
+  const [stack, setStack] = useState<Stack | null>(null);
+  const [runs, setRuns] = useState<StackRun[]>([]);
+  const [selectedRunId, setSelectedRunId] = useState<string | null>(null);
+  const [review, setReview] = useState<StackRun | null>(null);
+  const [loading, setLoading] = useState(true);
+  const [busy, setBusy] = useState(false);
+
+  useEffect(() => {
+    loadData();
+  }, [stackId]);
+
+  // 轮询选中执行的审核视图，跟踪各成员进度
+  useEffect(() => {
+    if (!stackId || !selectedRunId) {
+      setReview(null);
+      return;
+    }
+    loadReview(selectedRunId);
+    const timer = setInterval(() => loadReview(selectedRunId), 5000);
+    return () => clearInterval(timer);
+  }, [stackId, selectedRunId]);
+
+  const loadData = async () => {
+    if (!stackId) return;
+    try {
+      setLoading(true);
+      const [s, r] = await Promise.all([getStack(stackId), listStackRuns(stackId)]);
+      setStack(s);
+      setRuns(r);
+      if (r.length > 0) setSelectedRunId(current => current || r[0].id);
+    } catch (error: any) {
+      showToast(error.response?.data?.error || 'Failed to load stack', 'error');
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  const loadReview = async (runId: string) => {
+    if (!stackId) return;
+    try {
+      const run = await getStackRunReview(stackId, runId);
+      setReview(run);
+      setRuns(prev => prev.map(r => (r.id === run.id ? { ...r, status: run.status, summary: run.summary } : r)));
+    } catch (error: any) {
+      showToast(error.response?.data?.error || 'Failed to load run', 'error');
+    }
+  };
+
+  const workspaceName = (id: string) =>
+    stack?.members?.find(m => m.workspace_id === id)?.workspace_name || id;
+
+  const handleStartRun = async () => {
+    if (!stackId) return;
+    const description = prompt('Run description (written to every workspace run)');
+    if (description === null) return;
+    try {
+      setBusy(true);
+      const run = await createStackRun(stackId, description);
+      showToast('Stack run started', 'success');
+      setRuns(prev => [run, ...prev]);
+      setSelectedRunId(run.id);
+    } catch (error: any) {
+      showToast(error.response?.data?.error || 'Failed to start stack run', 'error');
+    } finally {
+      setBusy(false);
+    }
+  };
+
+  const handleApprove = async () => {
+    if (!stackId || !review) return;
+    if (!confirm('Approve all plans? Workspaces will be applied in dependency order.')) return;
+    try {
+      setBusy(true);
+      await approveStackRun(stackId, review.id);
+      showToast('Stack run approved', 'success');
+      loadReview(review.id);
+    } catch (error: any) {
+      showToast(error.response?.data?.error || 'Failed to approve stack run', 'error');
+    } finally {
+      setBusy(false);
+    }
+  };
+
+  const handleCancel = async () => {
+    if (!stackId || !review) return;
+    if (!confirm('Cancel this run? Plans waiting for apply are discarded; running plans and applies finish first.')) return;
+    try {
+      setBusy(true);
+      await cancelStackRun(stackId, review.id);
+      showToast('Stack run cancelled', 'success');
+      loadReview(review.id);
+    } catch (error: any) {
+      showToast(error.response?.data?.error || 'Failed to cancel stack run', 'error');
+    } finally {
+      setBusy(false);
+    }
+  };
+
+  if (loading) {
+    return <div className={styles.container}><div className={styles.loading}>Loading...</div></div>;
+  }
+  if (!stack) {
+    return <div className={styles.container}><div className={styles.empty}>Stack not found</div></div>;
+  }
+
+  const hasActiveRun = runs.some(r => ACTIVE_STACK_RUN_STATUSES.includes(r.status));
+
+  return (
+    <div className={styles.container}>
+      <Link to="/stacks" className={styles.backLink}>← Stacks</Link>
+      <div className={styles.header}>
+        <div>
+          <h1 className={styles.title}>{stack.name}</h1>
+          {stack.description && <p className={styles.description}>{stack.description}</p>}
+        </div>
+        <button className={styles.primaryButton} onClick={handleStartRun}
+          disabled={busy || hasActiveRun || !!stack.graph_error || !stack.members?.length}>
+          Plan Stack
+        </button>
+      </div>
+
+      <div className={styles.card}>
+        <h2 className={styles.sectionTitle}>Dependency Graph</h2>
+        {stack.graph_error && <div className={styles.graphError}>{stack.graph_error}</div>}
+        {stack.graph && (
+          <>
+            <div className={styles.layers}>
+              {stack.graph.layers.map((layer, i) => (
+                <div key={i} className={styles.layer}>
+                  <div className={styles.layerTitle}>Layer {i + 1}</div>
+                  {layer.map(id => (
+                    <div key={id} className={styles.node}>
+                      <a href={`/workspaces/${id}`}>{workspaceName(id)}</a>
+                    </div>
+                  ))}
+                </div>
+              ))}
+            </div>
+            {stack.graph.edges.length > 0 && (
+              <ul className={styles.edgeList}>
+                {stack.graph.edges.map(e => (
+                  <li key={`${e.from}-${e.to}-${e.type}`}>
+                    {workspaceName(e.from)} depends on {workspaceName(e.to)} ({EDGE_LABELS[e.type]}{e.detail ? `: ${e.detail}` : ''})
+                  </li>
+                ))}
+              </ul>
+            )}
+          </>
+        )}
+      </div>
+
+      {review && (
+        <div className={styles.card}>
+          <h2 className={styles.sectionTitle}>
+            Run {review.id} <span className={runBadge(review.status)}>{review.status.replace('_', ' ')}</span>
+          </h2>
+          {review.description && <p className={styles.description}>{review.description}</p>}
+          {review.message && <div className={styles.graphError}>{review.message}</div>}
+          {review.summary && (
+            <div className={styles.runSummary}>
+              <span>{review.summary.total} workspaces</span>
+              <span className={styles.add}>+{review.summary.changes_add} to add</span>
+              <span className={styles.change}>~{review.summary.changes_change} to change</span>
+              <span className={styles.destroy}>-{review.summary.changes_destroy} to destroy</span>
+              {review.approved_by && <span className={styles.muted}>Approved by {review.approved_by}</span>}
+            </div>
+          )}
+          <table className={styles.table}>
+            <thead>
+              <tr>
+                <th>Layer</th>
+                <th>Workspace</th>
+                <th>Status</th>
+                <th>Changes</th>
+                <th>Run</th>
+              </tr>
+            </thead>
+            <tbody>
+              {(review.members || []).map(m => (
+                <tr key={m.id}>
+                  <td>{m.layer + 1}</td>
+                  <td>
+                    <div className={styles.name}>{m.workspace_name || m.workspace_id}</div>
+                    {m.depends_on && m.depends_on.length > 0 && (
+                      <div className={styles.muted}>after {m.depends_on.map(workspaceName).join(', ')}</div>
+                    )}
+                  </td>
+                  <td>
+                    <span className={memberBadge(m.status)}>{m.status.replace('_', ' ')}</span>
+                    {m.error && <div className={styles.muted}>{m.error}</div>}
+                  </td>
+                  <td>
+                    <span className={styles.add}>+{m.changes_add}</span>{' '}
+                    <span className={styles.change}>~{m.changes_change}</span>{' '}
+                    <span className={styles.destroy}>-{m.changes_destroy}</span>
+                    {m.resources && m.resources.length > 0 && (
+                      <ul className={styles.resources}>
+                        {m.resources.map(r => <li key={r.id}>{r.action} {r.resource_address}</li>)}
+                      </ul>
+                    )}
+                  </td>
+                  <td>
+                    {m.task_id && <a href={`/workspaces/${m.workspace_id}/tasks/${m.task_id}`}>#{m.task_id}</a>}
+                  </td>
+                </tr>
+              ))}
+            </tbody>
+          </table>
+          {ACTIVE_STACK_RUN_STATUSES.includes(review.status) && (
+            <div className={styles.actions}>
+              <button className={styles.secondaryButton} onClick={handleCancel} disabled={busy}>Cancel Run</button>
+              {review.status === 'awaiting_approval' && (
+                <button className={styles.primaryButton} onClick={handleApprove} disabled={busy}>Approve and Apply</button>
+              )}
+            </div>
+          )}
+        </div>
+      )}
+
+      <div className={styles.card}>
+        <h2 className={styles.sectionTitle}>Runs</h2>
+        {runs.length === 0 ? (
+          <div className={styles.empty}>No runs yet</div>
+        ) : (
+          <table className={styles.table}>
+            <thead>
+              <tr>
+                <th>Run</th>
+                <th>Status</th>
+                <th>Started</th>
+                <th></th>
+              </tr>
+            </thead>
+            <tbody>
+              {runs.map(r => (
+                <tr key={r.id} className={r.id === selectedRunId ? styles.selectedRow : undefined}>
+                  <td>
+                    <div className={styles.name}>{r.id}</div>
+                    {r.description && <div className={styles.muted}>{r.description}</div>}
+                  </td>
+                  <td><span className={runBadge(r.status)}>{r.status.replace('_', ' ')}</span></td>
+                  <td>
+                    <div>{r.created_by}</div>
+                    <div className={styles.muted}>{new Date(r.created_at).toLocaleString()}</div>
+                  </td>
+                  <td className={styles.rowActions}>
+                    <button className={styles.linkButton} onClick={() => setSelectedRunId(r.id)}>View</button>
+                  </td>
+                </tr>
+              ))}
+            </tbody>
+          </table>
+        )}
+      </div>
+    </div>
+  );
+};
+
+export default StackDetail;
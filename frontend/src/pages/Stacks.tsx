@@ -0,0 +1,239 @@
+import React, { useEffect, useState } from 'react';
+import { Link } from 'react-router-dom';
+import { useToast } from '../hooks/useToast';
+import { workspaceService, type Workspace } from '../services/workspaces';
+import {
+  createStack,
+  deleteStack,
+  listStacks,
+  updateStack,
+  type Stack,
+  type StackRequest,
+} from '../services/stacks';
+import styles from './Stacks.module.css';
+
+const emptyForm = (): StackRequest => ({ name: '', description: '', members: [] });
+
+const Stacks: React.FC = () => {
+  const { showToast } = useToast();
+  const [stacks, setStacks] = useState<Stack[]>([]);
+  const [workspaces, setWorkspaces] = useState<Workspace[]>([]);
+  const [loading, setLoading] = useState(true);
+  const [editingId, setEditingId] = useState<string | null>(null);
+  const [showForm, setShowForm] = useState(false);
+  const [form, setForm] = useState<StackRequest>(emptyForm());
+  const [saving, setSaving] = useState(false);
+
+  useEffect(() => {
+    loadData();
+    workspaceService.getWorkspaces()
+      .then(response => {
+        const data: any = response.data;
+        const items = data?.items || data || [];
+        setWorkspaces(Array.isArray(items) ? items : []);
+      })
+      .catch(() => setWorkspaces([]));
+  }, []);
+
+  const loadData = async () => {
+    try {
+      setLoading(true);
+      setStacks(await listStacks());
+    } catch (error: any) {
+      showToast(error.response?.data?.error || 'Failed to load stacks', 'error');
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  const workspaceName = (id: string) => workspaces.find(w => w.workspace_id === id)?.name || id;
+
+  const openCreate = () => {
+    setEditingId(null);
+    setForm(emptyForm());
+    setShowForm(true);
+  };
+
+  const openEdit = (s: Stack) => {
+    setEditingId(s.id);
+    setForm({
+      name: s.name,
+      description: s.description,
+      members: (s.members || []).map(m => ({ workspace_id: m.workspace_id, depends_on: m.depends_on || [] })),
+    });
+    setShowForm(true);
+  };
+
+  const setMember = (index: number, workspaceId: string) => {
+    const previous = form.members[index].workspace_id;
+    setForm({
+      ...form,
+      members: form.members.map((m, i) => i === index
+        ? { workspace_id: workspaceId, depends_on: m.depends_on }
+        : { ...m, depends_on: (m.depends_on || []).filter(d => d !== previous) }),
+    });
+  };
+
+  const removeMember = (index: number) => {
+    const removed = form.members[index].workspace_id;
+    setForm({
+      ...form,
+      members: form.members
+        .filter((_, i) => i !== index)
+        .map(m => ({ ...m, depends_on: (m.depends_on || []).filter(d => d !== removed) })),
+    });
+  };
+
+  const toggleDependency = (index: number, upstream: string) => {
+    setForm({
+      ...form,
+      members: form.members.map((m, i) => {
+        if (i !== index) return m;
+        const deps = m.depends_on || [];
+        return { ...m, depends_on: deps.includes(upstream) ? deps.filter(d => d !== upstream) : [...deps, upstream] };
+      }),
+    });
+  };
+
+  const handleSave = async () => {
+    const req: StackRequest = { ...form, members: form.members.filter(m => m.workspace_id) };
+    try {
+      setSaving(true);
+      if (editingId) {
+        await updateStack(editingId, req);
+      } else {
+        await createStack(req);
+      }
+      showToast('Stack saved', 'success');
+      setShowForm(false);
+      loadData();
+    } catch (error: any) {
+      showToast(error.response?.data?.error || 'Failed to save stack', 'error');
+    } finally {
+      setSaving(false);
+    }
+  };
+
+  const handleDelete = async (s: Stack) => {
+    if (!confirm(`Delete stack "${s.name}" and its run history? Workspaces are not affected.`)) return;
+    try {
+      await deleteStack(s.id);
+      showToast('Stack deleted', 'success');
+      loadData();
+    } catch (error: any) {
+      showToast(error.response?.data?.error || 'Failed to delete stack', 'error');
+    }
+  };
+
+  if (loading) {
+    return <div className={styles.container}><div className={styles.loading}>Loading...</div></div>;
+  }
+
+  const selected = form.members.map(m => m.workspace_id).filter(Boolean);
+
+  return (
+    <div className={styles.container}>
+      <div className={styles.header}>
+        <div>
+          <h1 className={styles.title}>Stacks</h1>
+          <p className={styles.description}>
+            A stack plans its workspaces in dependency order, waits for one review of all plans, then applies
+            them in order and stops on the first failure. Dependencies from remote data and run triggers between
+            members are added automatically.
+          </p>
+        </div>
+        <button className={styles.primaryButton} onClick={openCreate}>New Stack</button>
+      </div>
+
+      {showForm && (
+        <div className={styles.card}>
+          <h2 className={styles.sectionTitle}>{editingId ? 'Edit Stack' : 'New Stack'}</h2>
+          <div className={styles.formRow}>
+            <div className={styles.formGroup}>
+              <label className={styles.label}>Name</label>
+              <input className={styles.input} value={form.name}
+                onChange={e => setForm({ ...form, name: e.target.value })} placeholder="platform" />
+            </div>
+            <div className={styles.formGroup}>
+              <label className={styles.label}>Description</label>
+              <input className={styles.input} value={form.description}
+                onChange={e => setForm({ ...form, description: e.target.value })} />
+            </div>
+          </div>
+
+          <label className={styles.label}>Members</label>
+          {form.members.map((m, index) => (
+            <div key={index} className={styles.formGroup}>
+              <div className={styles.memberRow}>
+                <select className={styles.input} value={m.workspace_id}
+                  onChange={e => setMember(index, e.target.value)}>
+                  <option value="">Select workspace</option>
+                  {workspaces
+                    .filter(w => w.workspace_id && (w.workspace_id === m.workspace_id || !selected.includes(w.workspace_id)))
+                    .map(w => <option key={w.workspace_id} value={w.workspace_id}>{w.name}</option>)}
+                </select>
+                <button className={styles.linkDanger} onClick={() => removeMember(index)}>Remove</button>
+              </div>
+              {m.workspace_id && selected.length > 1 && (
+                <div className={styles.chips}>
+                  <span className={styles.muted}>Also depends on:</span>
+                  {selected.filter(id => id !== m.workspace_id).map(id => (
+                    <label key={id} className={styles.chip}>
+                      <input type="checkbox" checked={(m.depends_on || []).includes(id)}
+                        onChange={() => toggleDependency(index, id)} />
+                      {workspaceName(id)}
+                    </label>
+                  ))}
+                </div>
+              )}
+            </div>
+          ))}
+          <button className={styles.secondaryButton}
+            onClick={() => setForm({ ...form, members: [...form.members, { workspace_id: '', depends_on: [] }] })}>
+            Add Workspace
+          </button>
+
+          <div className={styles.actions}>
+            <button className={styles.secondaryButton} onClick={() => setShowForm(false)} disabled={saving}>Cancel</button>
+            <button className={styles.primaryButton} onClick={handleSave} disabled={saving || !form.name}>
+              {saving ? 'Saving...' : 'Save'}
+            </button>
+          </div>
+        </div>
+      )}
+
+      <div className={styles.card}>
+        {stacks.length === 0 ? (
+          <div className={styles.empty}>No stacks configured</div>
+        ) : (
+          <table className={styles.table}>
+            <thead>
+              <tr>
+                <th>Name</th>
+                <th>Workspaces</th>
+                <th></th>
+              </tr>
+            </thead>
+            <tbody>
+              {stacks.map(s => (
+                <tr key={s.id}>
+                  <td>
+                    <Link to={`/stacks/${s.id}`} className={styles.name}>{s.name}</Link>
+                    {s.description && <div className={styles.muted}>{s.description}</div>}
+                  </td>
+                  <td>{(s.members || []).map(m => m.workspace_name || m.workspace_id).join(', ')}</td>
+                  <td className={styles.rowActions}>
+                    <button className={styles.linkButton} onClick={() => openEdit(s)}>Edit</button>
+                    <button className={styles.linkDanger} onClick={() => handleDelete(s)}>Delete</button>
+                  </td>
+                </tr>
+              ))}
+            </tbody>
+          </table>
+        )}
+      </div>
+    </div>
+  );
+};
+
+export default Stacks;
@@ -9,6 +9,7 @@ import AIErrorAnalysis from '../components/AIErrorAnalysis';
 import TaskTimeline from '../components/TaskTimeline';
 import SmartLogViewer from '../components/SmartLogViewer';
 import WorkspaceSidebar from '../components/WorkspaceSidebar';
+import ChangeFreezeBanner from '../components/ChangeFreezeBanner';
 import { useToast } from '../hooks/useToast';
 import api from '../services/api';
 import styles from './TaskDetail.module.css';
@@ -486,6 +487,11 @@ const TaskDetail: React.FC = () => {
               {task.status === 'planned_and_finished' && (
                 <span className={styles.statusTagSuccess}>Planned and Finished</span>
               )}
+              {task.status === 'state_pending_upload' && (
+                <span className={styles.statusTagWarning} title="Apply succeeded; the state is kept on the agent and will be uploaded when the server is reachable">
+                  State Pending Upload
+                </span>
+              )}
               {(task.status === 'success' || task.status === 'applied') && (
                 <span className={styles.statusTagSuccess}>
                   {task.task_type === 'plan' ? 'Planned' : 'Applied'}
@@ -501,6 +507,14 @@ const TaskDetail: React.FC = () => {
           </div>
         </div>
 
+        <ChangeFreezeBanner
+          workspaceId={workspaceId!}
+          taskId={task.id}
+          taskType={task.task_type}
+          taskStatus={task.status}
+          canRequestException={canConfirmApply}
+        />
+
         {/* Summary Stats with View Mode Toggle */}
         <div className={styles.statsRow}>
           <div className={styles.statsCards}>
@@ -672,7 +686,7 @@ const TaskDetail: React.FC = () => {
                 </button>
               )}
               
-              {(task.status !== 'success' && task.status !== 'applied' && task.status !== 'failed' && task.status !== 'cancelled' && task.status !== 'planned_and_finished') && canCancelTask && (
+              {(task.status !== 'success' && task.status !== 'applied' && task.status !== 'failed' && task.status !== 'cancelled' && task.status !== 'planned_and_finished' && task.status !== 'state_pending_upload') && canCancelTask && (
                 <button
                   className={styles.cancelButton}
                   onClick={() => handleActionWithComment('cancel')}
@@ -13,7 +13,7 @@ interface Variable {
   version: number;  // 版本号
   description: string;
   variable_type: 'terraform' | 'environment';
-  value_format: 'string' | 'hcl';
+  value_format: 'string' | 'hcl' | 'secret_ref';
   sensitive: boolean;
   // is_deleted 不从API返回（内部实现细节）
   created_at: string;
@@ -40,7 +40,8 @@ const VariablesTab: React.FC<VariablesTabProps> = ({ workspaceId }) => {
     variable_type: 'terraform' as 'terraform' | 'environment',
     sensitive: false,
     hcl: false,
-    value_format: 'string' as 'string' | 'hcl'
+    secret_ref: false,
+    value_format: 'string' as 'string' | 'hcl' | 'secret_ref'
   });
 
   useEffect(() => {
@@ -71,6 +72,7 @@ const VariablesTab: React.FC<VariablesTabProps> = ({ workspaceId }) => {
       variable_type: variable.variable_type,
       sensitive: variable.sensitive,
       hcl: variable.value_format === 'hcl',
+      secret_ref: variable.value_format === 'secret_ref',
       value_format: variable.value_format
     });
   };
@@ -119,7 +121,7 @@ const VariablesTab: React.FC<VariablesTabProps> = ({ workspaceId }) => {
         description: formData.description,
         variable_type: formData.variable_type,
         sensitive: formData.sensitive,
-        value_format: formData.hcl ? 'hcl' : 'string'
+        value_format: formData.secret_ref ? 'secret_ref' : formData.hcl ? 'hcl' : 'string'
       };
       
       if (editingId) {
@@ -173,6 +175,7 @@ const VariablesTab: React.FC<VariablesTabProps> = ({ workspaceId }) => {
         variable_type: 'terraform',
         sensitive: false,
         hcl: false,
+        secret_ref: false,
         value_format: 'string'
       });
       fetchVariables();
@@ -192,6 +195,7 @@ const VariablesTab: React.FC<VariablesTabProps> = ({ workspaceId }) => {
       variable_type: 'terraform',
       sensitive: false,
       hcl: false,
+      secret_ref: false,
       value_format: 'string'
     });
   };
@@ -277,7 +281,7 @@ const VariablesTab: React.FC<VariablesTabProps> = ({ workspaceId }) => {
                         value={formData.value}
                         onChange={(e) => setFormData({ ...formData, value: e.target.value })}
                         className={styles.formInput}
-                        placeholder="value"
+                        placeholder={formData.secret_ref ? 'vault://kv/data/team/db#password' : 'value'}
                         required
                       />
                     </div>
@@ -287,11 +291,19 @@ const VariablesTab: React.FC<VariablesTabProps> = ({ workspaceId }) => {
                           <input
                             type="checkbox"
                             checked={formData.hcl}
-                            onChange={(e) => setFormData({ ...formData, hcl: e.target.checked })}
+                            onChange={(e) => setFormData({ ...formData, hcl: e.target.checked, secret_ref: false })}
                           />
                           <span>HCL</span>
                         </label>
                       )}
+                      <label className={styles.checkboxLabel} title="Value is a reference such as vault://kv/data/team/db#password, env://IAC_SECRET_NAME or file://path, resolved by the agent at run time">
+                        <input
+                          type="checkbox"
+                          checked={formData.secret_ref}
+                          onChange={(e) => setFormData({ ...formData, secret_ref: e.target.checked, hcl: false })}
+                        />
+                        <span>Secret ref</span>
+                      </label>
                       <label className={styles.checkboxLabel}>
                         <input
                           type="checkbox"
@@ -337,6 +349,9 @@ const VariablesTab: React.FC<VariablesTabProps> = ({ workspaceId }) => {
                     {variable.value_format === 'hcl' && (
                       <span className={styles.hclBadge}>HCL</span>
                     )}
+                    {variable.value_format === 'secret_ref' && (
+                      <span className={styles.hclBadge}>Secret ref</span>
+                    )}
                     {variable.sensitive && (
                       <span className={styles.sensitiveBadge}>Sensitive</span>
                     )}
@@ -348,7 +363,7 @@ const VariablesTab: React.FC<VariablesTabProps> = ({ workspaceId }) => {
                   )}
                 </div>
                 <div className={styles.variableValue}>
-                  {variable.sensitive ? '-- sensitive value --' : variable.value}
+                  {variable.sensitive && variable.value_format !== 'secret_ref' ? '-- sensitive value --' : variable.value}
                 </div>
                 <div className={styles.variableCategory}>
                   {variable.variable_type === 'terraform' ? 'Terraform' : 'Environment'}
@@ -430,7 +445,7 @@ const VariablesTab: React.FC<VariablesTabProps> = ({ workspaceId }) => {
                   value={formData.value}
                   onChange={(e) => setFormData({ ...formData, value: e.target.value })}
                   className={styles.formInput}
-                  placeholder="value"
+                  placeholder={formData.secret_ref ? 'vault://kv/data/team/db#password' : 'value'}
                   required
                 />
               </div>
@@ -440,11 +455,19 @@ const VariablesTab: React.FC<VariablesTabProps> = ({ workspaceId }) => {
                     <input
                       type="checkbox"
                       checked={formData.hcl}
-                      onChange={(e) => setFormData({ ...formData, hcl: e.target.checked })}
+                      onChange={(e) => setFormData({ ...formData, hcl: e.target.checked, secret_ref: false })}
                     />
                     <span>HCL</span>
                   </label>
                 )}
+                <label className={styles.checkboxLabel} title="Value is a reference such as vault://kv/data/team/db#password, env://IAC_SECRET_NAME or file://path, resolved by the agent at run time">
+                  <input
+                    type="checkbox"
+                    checked={formData.secret_ref}
+                    onChange={(e) => setFormData({ ...formData, secret_ref: e.target.checked, hcl: false })}
+                  />
+                  <span>Secret ref</span>
+                </label>
                 <label className={styles.checkboxLabel}>
                   <input
                     type="checkbox"
@@ -1334,6 +1334,9 @@ const RunsTab: React.FC<{ workspaceId: string; globalLatestRun: any }> = ({ work
     } else if (run.status === 'planned_and_finished') {
       // Plan完成，无需Apply（无变更）
       return 'Planned and Finished';
+    } else if (run.status === 'state_pending_upload') {
+      // Apply完成，State仍在Agent本地spool中等待上传
+      return 'State Pending Upload';
     } else if (run.status === 'success' || run.status === 'applied') {
       // 根据任务类型和stage判断最终状态
       if (run.task_type === 'plan' || (run.task_type === 'plan_and_apply' && run.status === 'success')) {
@@ -8,6 +8,44 @@ import SecretsManager from '../../components/SecretsManager';
 import AgentMetricsBar from '../../components/AgentMetricsBar';
 import styles from './AgentPoolDetail.module.css';
 
+// Pod template fields edited as a JSON document (native K8s schema for nested objects)
+const POD_TEMPLATE_KEYS = [
+  'node_selector',
+  'tolerations',
+  'affinity',
+  'automount_service_account_token',
+  'volumes',
+  'volume_mounts',
+  'pod_security_context',
+  'security_context',
+  'pod_annotations',
+  'pod_labels',
+  'priority_class_name',
+  'image_pull_secrets',
+  'pod_overrides',
+] as const;
+
+const POD_TEMPLATE_EXAMPLE = `{
+  "node_selector": { "node-pool": "iac" },
+  "tolerations": [{ "key": "dedicated", "operator": "Equal", "value": "iac", "effect": "NoSchedule" }],
+  "pod_annotations": { "eks.amazonaws.com/role-arn": "arn:aws:iam::123456789012:role/iac-agent" },
+  "volumes": [{ "name": "ca-bundle", "configMap": { "name": "corp-ca" } }],
+  "volume_mounts": [{ "name": "ca-bundle", "mountPath": "/etc/ssl/corp", "readOnly": true }],
+  "pod_security_context": { "runAsNonRoot": true, "runAsUser": 1000 },
+  "image_pull_secrets": ["registry-creds"],
+  "pod_overrides": { "spec": { "dnsPolicy": "ClusterFirst" } }
+}`;
+
+const extractPodTemplate = (config: Record<string, unknown>): Record<string, unknown> => {
+  const template: Record<string, unknown> = {};
+  POD_TEMPLATE_KEYS.forEach((key) => {
+    if (config[key] !== undefined && config[key] !== null) {
+      template[key] = config[key];
+    }
+  });
+  return template;
+};
+
 interface AgentMetrics {
   agent_id: string;
   agent_name: string;
@@ -66,6 +104,9 @@ const AgentPoolDetail: React.FC = () => {
   const [envPairs, setEnvPairs] = useState<Array<{ key: string; value: string }>>([
     { key: '', value: '' }
   ]);
+  // Saved pod template customization; kept in every config save so freeze schedule edits do not drop it
+  const [podTemplate, setPodTemplate] = useState<Record<string, unknown>>({});
+  const [podTemplateText, setPodTemplateText] = useState('');
   
   // Freeze Schedule states
   const [showFreezeSchedule, setShowFreezeSchedule] = useState(false);
@@ -284,7 +325,7 @@ const AgentPoolDetail: React.FC = () => {
         image: config.image || '',
         image_pull_policy: config.image_pull_policy || 'Always',
         namespace: config.namespace || 'terraform',
-        service_account: config.service_account || '',
+        service_account: config.service_account_name || '',
         command: config.command || [],
         args: config.args || [],
         min_replicas: config.min_replicas || 1,
@@ -294,6 +335,10 @@ const AgentPoolDetail: React.FC = () => {
         env: envVars,
       });
       setEnvPairs(pairs);
+
+      const template = extractPodTemplate(config);
+      setPodTemplate(template);
+      setPodTemplateText(Object.keys(template).length > 0 ? JSON.stringify(template, null, 2) : '');
       
       // Load freeze schedules
       setFreezeSchedules(config.freeze_schedules || []);
@@ -308,6 +353,20 @@ const AgentPoolDetail: React.FC = () => {
   const handleSaveK8sConfig = async () => {
     if (!poolId) return;
 
+    let templateToSave: Record<string, unknown> = {};
+    if (podTemplateText.trim()) {
+      try {
+        const parsed = JSON.parse(podTemplateText);
+        if (typeof parsed !== 'object' || parsed === null || Array.isArray(parsed)) {
+          throw new Error('not an object');
+        }
+        templateToSave = extractPodTemplate(parsed);
+      } catch {
+        showToast('Pod template must be a valid JSON object', 'error');
+        return;
+      }
+    }
+
     // Convert envPairs to env object
     const env: Record<string, string> = {};
     envPairs.forEach(pair => {
@@ -331,7 +390,9 @@ const AgentPoolDetail: React.FC = () => {
       },
       min_replicas: k8sConfig.min_replicas,
       max_replicas: k8sConfig.max_replicas,
-      freeze_schedules: freezeSchedules
+      freeze_schedules: freezeSchedules,
+      service_account_name: k8sConfig.service_account || undefined,
+      ...templateToSave,
     };
 
     try {
@@ -411,7 +472,9 @@ const AgentPoolDetail: React.FC = () => {
       },
       min_replicas: k8sConfig.min_replicas,
       max_replicas: k8sConfig.max_replicas,
-      freeze_schedules: updatedSchedules
+      freeze_schedules: updatedSchedules,
+      service_account_name: k8sConfig.service_account || undefined,
+      ...podTemplate,
     };
 
     try {
@@ -474,7 +537,9 @@ const AgentPoolDetail: React.FC = () => {
       },
       min_replicas: k8sConfig.min_replicas,
       max_replicas: k8sConfig.max_replicas,
-      freeze_schedules: updatedSchedules
+      freeze_schedules: updatedSchedules,
+      service_account_name: k8sConfig.service_account || undefined,
+      ...podTemplate,
     };
 
     try {
@@ -523,6 +588,9 @@ const AgentPoolDetail: React.FC = () => {
     if (!pool) return '';
     
     const saLine = k8sConfig.service_account ? `  serviceAccountName: ${k8sConfig.service_account}\n` : '';
+    const templateNote = podTemplateText.trim()
+      ? '\n# Pod template customization (node selector, tolerations, volumes, overrides, ...) is applied on top of this spec'
+      : '';
     
     // Use current envPairs (not saved k8sConfig.env) for preview
     const customEnvVars = envPairs
@@ -567,7 +635,7 @@ ${saLine}  restartPolicy: Never  # One-time execution pod
     resources:
       limits:
         memory: "${k8sConfig.memory_limit}"
-        cpu: "${k8sConfig.cpu_limit}"`;
+        cpu: "${k8sConfig.cpu_limit}"${templateNote}`;
   };
 
   const handleCopyYaml = () => {
@@ -1403,6 +1471,24 @@ ${saLine}  restartPolicy: Never  # One-time execution pod
                     Custom environment variables for the agent container
                   </small>
                 </div>
+
+                <div className={styles.formGroup}>
+                  <label htmlFor="podTemplate">Pod Template (JSON)</label>
+                  <textarea
+                    id="podTemplate"
+                    value={podTemplateText}
+                    onChange={(e) => setPodTemplateText(e.target.value)}
+                    placeholder={POD_TEMPLATE_EXAMPLE}
+                    rows={12}
+                    spellCheck={false}
+                    className={`${styles.input} ${styles.codeInput}`}
+                  />
+                  <small className={styles.helpText}>
+                    Node selector, tolerations, affinity, volumes and mounts, security contexts, pod labels/annotations,
+                    priority class and image pull secrets. Nested objects use the Kubernetes schema;
+                    pod_overrides is a strategic merge patch applied last. Idle pods are rebuilt after saving.
+                  </small>
+                </div>
               </div>
             ) : (
               <div className={styles.k8sConfigDisplay}>
@@ -1426,6 +1512,12 @@ ${saLine}  restartPolicy: Never  # One-time execution pod
                         <span>{k8sConfig.service_account}</span>
                       </div>
                     )}
+                    {Object.keys(podTemplate).length > 0 && (
+                      <div className={styles.configItem}>
+                        <label>Pod Template:</label>
+                        <span>{Object.keys(podTemplate).join(', ')}</span>
+                      </div>
+                    )}
                     <div className={styles.configItem}>
                       <label>Replica Limits:</label>
                       <span>Min: {k8sConfig.min_replicas}, Max: {k8sConfig.max_replicas}</span>
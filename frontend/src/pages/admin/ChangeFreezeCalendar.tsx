@@ -0,0 +1,421 @@
+import React, { useEffect, useState } from 'react';
+import { useToast } from '../../hooks/useToast';
+import { getProjects, type Project } from '../../services/projects';
+import {
+  approveChangeFreezeException,
+  createChangeFreezeWindow,
+  deleteChangeFreezeWindow,
+  listChangeFreezeExceptions,
+  listChangeFreezeWindows,
+  rejectChangeFreezeException,
+  updateChangeFreezeWindow,
+  type ChangeFreezeException,
+  type ChangeFreezeWindow,
+  type ChangeFreezeWindowRequest,
+} from '../../services/changeFreeze';
+import styles from './ChangeFreezeCalendar.module.css';
+
+const WEEKDAYS = [
+  { value: 1, label: 'Mon' },
+  { value: 2, label: 'Tue' },
+  { value: 3, label: 'Wed' },
+  { value: 4, label: 'Thu' },
+  { value: 5, label: 'Fri' },
+  { value: 6, label: 'Sat' },
+  { value: 7, label: 'Sun' },
+];
+
+const emptyForm = (): ChangeFreezeWindowRequest => ({
+  name: '',
+  description: '',
+  scope: 'organization',
+  selector: {},
+  timezone: Intl.DateTimeFormat().resolvedOptions().timeZone || 'UTC',
+  schedule: { type: 'once', starts_at: '', ends_at: '' },
+  enabled: true,
+});
+
+// tags 以 key=value 逗号分隔的形式编辑
+const tagsToText = (tags?: Record<string, string>) =>
+  Object.entries(tags || {}).map(([k, v]) => `${k}=${v}`).join(', ');
+
+const textToTags = (text: string): Record<string, string> | undefined => {
+  const tags: Record<string, string> = {};
+  text.split(',').map(s => s.trim()).filter(Boolean).forEach(pair => {
+    const idx = pair.indexOf('=');
+    if (idx > 0) tags[pair.slice(0, idx).trim()] = pair.slice(idx + 1).trim();
+  });
+  return Object.keys(tags).length > 0 ? tags : undefined;
+};
+
+const describeSchedule = (w: ChangeFreezeWindow) => {
+  const s = w.schedule;
+  if (s.type === 'once') {
+    return `${s.starts_at?.replace('T', ' ')} → ${s.ends_at?.replace('T', ' ')}`;
+  }
+  const days = (s.weekdays || []).map(d => WEEKDAYS.find(x => x.value === d)?.label).join(', ');
+  const bounds = s.starts_at || s.ends_at
+    ? ` (${s.starts_at?.slice(0, 10) || '…'} – ${s.ends_at?.slice(0, 10) || '…'})`
+    : '';
+  return `${days} ${s.from_time}–${s.to_time}${bounds}`;
+};
+
+const ChangeFreezeCalendar: React.FC = () => {
+  const { showToast } = useToast();
+  const [windows, setWindows] = useState<ChangeFreezeWindow[]>([]);
+  const [exceptions, setExceptions] = useState<ChangeFreezeException[]>([]);
+  const [projects, setProjects] = useState<Project[]>([]);
+  const [loading, setLoading] = useState(true);
+  const [editingId, setEditingId] = useState<string | null>(null);
+  const [showForm, setShowForm] = useState(false);
+  const [form, setForm] = useState<ChangeFreezeWindowRequest>(emptyForm());
+  const [tagsText, setTagsText] = useState('');
+  const [saving, setSaving] = useState(false);
+
+  useEffect(() => {
+    loadData();
+    getProjects().then(setProjects).catch(() => setProjects([]));
+  }, []);
+
+  const loadData = async () => {
+    try {
+      setLoading(true);
+      const [w, e] = await Promise.all([listChangeFreezeWindows(), listChangeFreezeExceptions()]);
+      setWindows(w);
+      setExceptions(e);
+    } catch (error: any) {
+      showToast(error.response?.data?.error || 'Failed to load change freezes', 'error');
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  const openCreate = () => {
+    setEditingId(null);
+    setForm(emptyForm());
+    setTagsText('');
+    setShowForm(true);
+  };
+
+  const openEdit = (w: ChangeFreezeWindow) => {
+    setEditingId(w.id);
+    setForm({
+      name: w.name,
+      description: w.description,
+      scope: w.scope,
+      selector: w.selector || {},
+      timezone: w.timezone,
+      schedule: w.schedule,
+      enabled: w.enabled,
+    });
+    setTagsText(tagsToText(w.selector?.tags));
+    setShowForm(true);
+  };
+
+  const setSchedule = (patch: Partial<ChangeFreezeWindowRequest['schedule']>) =>
+    setForm({ ...form, schedule: { ...form.schedule, ...patch } });
+
+  const toggleWeekday = (day: number) => {
+    const days = form.schedule.weekdays || [];
+    setSchedule({ weekdays: days.includes(day) ? days.filter(d => d !== day) : [...days, day].sort() });
+  };
+
+  const toggleProject = (id: number) => {
+    const ids = form.selector.project_ids || [];
+    setForm({
+      ...form,
+      selector: { ...form.selector, project_ids: ids.includes(id) ? ids.filter(x => x !== id) : [...ids, id] },
+    });
+  };
+
+  const handleSave = async () => {
+    const req: ChangeFreezeWindowRequest = {
+      ...form,
+      selector: {
+        project_ids: form.scope === 'project' ? form.selector.project_ids : undefined,
+        tags: textToTags(tagsText),
+      },
+    };
+    try {
+      setSaving(true);
+      if (editingId) {
+        await updateChangeFreezeWindow(editingId, req);
+      } else {
+        await createChangeFreezeWindow(req);
+      }
+      showToast('Change freeze saved', 'success');
+      setShowForm(false);
+      loadData();
+    } catch (error: any) {
+      showToast(error.response?.data?.error || 'Failed to save change freeze', 'error');
+    } finally {
+      setSaving(false);
+    }
+  };
+
+  const handleDelete = async (w: ChangeFreezeWindow) => {
+    if (!confirm(`Delete change freeze "${w.name}"? Applies held by it will resume.`)) return;
+    try {
+      await deleteChangeFreezeWindow(w.id);
+      showToast('Change freeze deleted', 'success');
+      loadData();
+    } catch (error: any) {
+      showToast(error.response?.data?.error || 'Failed to delete change freeze', 'error');
+    }
+  };
+
+  const handleReview = async (e: ChangeFreezeException, approve: boolean) => {
+    const comment = prompt(approve ? 'Approval comment (optional)' : 'Rejection reason (optional)');
+    if (comment === null) return;
+    try {
+      if (approve) {
+        await approveChangeFreezeException(e.id, comment);
+      } else {
+        await rejectChangeFreezeException(e.id, comment);
+      }
+      showToast(approve ? 'Exception approved; the apply will resume shortly' : 'Exception rejected', 'success');
+      loadData();
+    } catch (error: any) {
+      showToast(error.response?.data?.error || 'Failed to review exception', 'error');
+    }
+  };
+
+  const projectName = (id: number) => {
+    const p = projects.find(x => x.id === id);
+    return p ? p.display_name || p.name : `#${id}`;
+  };
+
+  if (loading) {
+    return <div className={styles.container}><div className={styles.loading}>Loading...</div></div>;
+  }
+
+  return (
+    <div className={styles.container}>
+      <div className={styles.header}>
+        <div>
+          <h1 className={styles.title}>Change Freezes</h1>
+          <p className={styles.description}>
+            While a freeze is active, plans still run but applies in matching workspaces are held in the queue
+            until the freeze ends or a per-run exception is approved.
+          </p>
+        </div>
+        <button className={styles.primaryButton} onClick={openCreate}>New Freeze</button>
+      </div>
+
+      {showForm && (
+        <div className={styles.card}>
+          <h2 className={styles.sectionTitle}>{editingId ? 'Edit Freeze' : 'New Freeze'}</h2>
+          <div className={styles.formRow}>
+            <div className={styles.formGroup}>
+              <label className={styles.label}>Name</label>
+              <input className={styles.input} value={form.name}
+                onChange={e => setForm({ ...form, name: e.target.value })} placeholder="Year-end freeze" />
+            </div>
+            <div className={styles.formGroup}>
+              <label className={styles.label}>Timezone</label>
+              <input className={styles.input} value={form.timezone}
+                onChange={e => setForm({ ...form, timezone: e.target.value })} placeholder="Asia/Shanghai" />
+            </div>
+          </div>
+          <div className={styles.formGroup}>
+            <label className={styles.label}>Reason (shown to users when an apply is held)</label>
+            <input className={styles.input} value={form.description}
+              onChange={e => setForm({ ...form, description: e.target.value })} />
+          </div>
+
+          <div className={styles.formRow}>
+            <div className={styles.formGroup}>
+              <label className={styles.label}>Scope</label>
+              <select className={styles.input} value={form.scope}
+                onChange={e => setForm({ ...form, scope: e.target.value as ChangeFreezeWindowRequest['scope'] })}>
+                <option value="organization">Organization (all workspaces)</option>
+                <option value="project">Projects</option>
+              </select>
+            </div>
+            <div className={styles.formGroup}>
+              <label className={styles.label}>Workspace tags (optional)</label>
+              <input className={styles.input} value={tagsText}
+                onChange={e => setTagsText(e.target.value)} placeholder="env=prod, tier=1" />
+            </div>
+          </div>
+          {form.scope === 'project' && (
+            <div className={styles.formGroup}>
+              <label className={styles.label}>Projects</label>
+              <div className={styles.chips}>
+                {projects.map(p => (
+                  <label key={p.id} className={styles.chip}>
+                    <input type="checkbox" checked={(form.selector.project_ids || []).includes(p.id)}
+                      onChange={() => toggleProject(p.id)} />
+                    {p.display_name || p.name}
+                  </label>
+                ))}
+              </div>
+            </div>
+          )}
+
+          <div className={styles.formGroup}>
+            <label className={styles.label}>Schedule</label>
+            <div className={styles.chips}>
+              <label className={styles.chip}>
+                <input type="radio" checked={form.schedule.type === 'once'}
+                  onChange={() => setSchedule({ type: 'once' })} />
+                Date range
+              </label>
+              <label className={styles.chip}>
+                <input type="radio" checked={form.schedule.type === 'weekly'}
+                  onChange={() => setSchedule({ type: 'weekly', weekdays: form.schedule.weekdays || [5], from_time: form.schedule.from_time || '18:00', to_time: form.schedule.to_time || '08:00' })} />
+                Weekly
+              </label>
+            </div>
+          </div>
+          {form.schedule.type === 'weekly' && (
+            <>
+              <div className={styles.chips}>
+                {WEEKDAYS.map(d => (
+                  <label key={d.value} className={styles.chip}>
+                    <input type="checkbox" checked={(form.schedule.weekdays || []).includes(d.value)}
+                      onChange={() => toggleWeekday(d.value)} />
+                    {d.label}
+                  </label>
+                ))}
+              </div>
+              <div className={styles.formRow}>
+                <div className={styles.formGroup}>
+                  <label className={styles.label}>From</label>
+                  <input type="time" className={styles.input} value={form.schedule.from_time || ''}
+                    onChange={e => setSchedule({ from_time: e.target.value })} />
+                </div>
+                <div className={styles.formGroup}>
+                  <label className={styles.label}>To (earlier than From spans midnight)</label>
+                  <input type="time" className={styles.input} value={form.schedule.to_time || ''}
+                    onChange={e => setSchedule({ to_time: e.target.value })} />
+                </div>
+              </div>
+            </>
+          )}
+          <div className={styles.formRow}>
+            <div className={styles.formGroup}>
+              <label className={styles.label}>{form.schedule.type === 'once' ? 'Starts' : 'Effective from (optional)'}</label>
+              <input type="datetime-local" className={styles.input} value={form.schedule.starts_at || ''}
+                onChange={e => setSchedule({ starts_at: e.target.value })} />
+            </div>
+            <div className={styles.formGroup}>
+              <label className={styles.label}>{form.schedule.type === 'once' ? 'Ends' : 'Effective until (optional)'}</label>
+              <input type="datetime-local" className={styles.input} value={form.schedule.ends_at || ''}
+                onChange={e => setSchedule({ ends_at: e.target.value })} />
+            </div>
+          </div>
+
+          <label className={styles.chip}>
+            <input type="checkbox" checked={form.enabled}
+              onChange={e => setForm({ ...form, enabled: e.target.checked })} />
+            Enabled
+          </label>
+
+          <div className={styles.actions}>
+            <button className={styles.secondaryButton} onClick={() => setShowForm(false)} disabled={saving}>Cancel</button>
+            <button className={styles.primaryButton} onClick={handleSave} disabled={saving || !form.name}>
+              {saving ? 'Saving...' : 'Save'}
+            </button>
+          </div>
+        </div>
+      )}
+
+      <div className={styles.card}>
+        <h2 className={styles.sectionTitle}>Calendar</h2>
+        {windows.length === 0 ? (
+          <div className={styles.empty}>No change freezes configured</div>
+        ) : (
+          <table className={styles.table}>
+            <thead>
+              <tr>
+                <th>Name</th>
+                <th>Scope</th>
+                <th>Schedule</th>
+                <th>Timezone</th>
+                <th>Status</th>
+                <th></th>
+              </tr>
+            </thead>
+            <tbody>
+              {windows.map(w => (
+                <tr key={w.id}>
+                  <td>
+                    <div className={styles.name}>{w.name}</div>
+                    {w.description && <div className={styles.muted}>{w.description}</div>}
+                  </td>
+                  <td>
+                    {w.scope === 'project'
+                      ? (w.selector?.project_ids || []).map(projectName).join(', ')
+                      : 'Organization'}
+                    {w.selector?.tags && <div className={styles.muted}>{tagsToText(w.selector.tags)}</div>}
+                  </td>
+                  <td>{describeSchedule(w)}</td>
+                  <td>{w.timezone}</td>
+                  <td>
+                    <span className={w.enabled ? styles.badgeActive : styles.badgeMuted}>
+                      {w.enabled ? 'Enabled' : 'Disabled'}
+                    </span>
+                  </td>
+                  <td className={styles.rowActions}>
+                    <button className={styles.linkButton} onClick={() => openEdit(w)}>Edit</button>
+                    <button className={styles.linkDanger} onClick={() => handleDelete(w)}>Delete</button>
+                  </td>
+                </tr>
+              ))}
+            </tbody>
+          </table>
+        )}
+      </div>
+
+      <div className={styles.card}>
+        <h2 className={styles.sectionTitle}>Exception Requests</h2>
+        {exceptions.length === 0 ? (
+          <div className={styles.empty}>No exception requests</div>
+        ) : (
+          <table className={styles.table}>
+            <thead>
+              <tr>
+                <th>Run</th>
+                <th>Reason</th>
+                <th>Requested</th>
+                <th>Status</th>
+                <th></th>
+              </tr>
+            </thead>
+            <tbody>
+              {exceptions.map(e => (
+                <tr key={e.id}>
+                  <td>
+                    <a href={`/workspaces/${e.workspace_id}/tasks/${e.task_id}`}>{e.workspace_id} #{e.task_id}</a>
+                  </td>
+                  <td>{e.reason}</td>
+                  <td>
+                    <div>{e.requested_by}</div>
+                    <div className={styles.muted}>{new Date(e.created_at).toLocaleString()}</div>
+                  </td>
+                  <td>
+                    <span className={e.status === 'approved' ? styles.badgeActive : e.status === 'rejected' ? styles.badgeDanger : styles.badgeWarning}>
+                      {e.status}
+                    </span>
+                    {e.reviewed_by && <div className={styles.muted}>by {e.reviewed_by}{e.review_comment ? `: ${e.review_comment}` : ''}</div>}
+                  </td>
+                  <td className={styles.rowActions}>
+                    {e.status === 'pending' && (
+                      <>
+                        <button className={styles.linkButton} onClick={() => handleReview(e, true)}>Approve</button>
+                        <button className={styles.linkDanger} onClick={() => handleReview(e, false)}>Reject</button>
+                      </>
+                    )}
+                  </td>
+                </tr>
+              ))}
+            </tbody>
+          </table>
+        )}
+      </div>
+    </div>
+  );
+};
+
+export default ChangeFreezeCalendar;
@@ -1,7 +1,7 @@
 import React, { useState, useEffect } from 'react';
 import { useParams, useNavigate } from 'react-router-dom';
 import { useToast } from '../../contexts/ToastContext';
-import { iamService } from '../../services/iam';
+import { iamService, parseScopeList } from '../../services/iam';
 import type { Team, TeamMember, PermissionGrant, PermissionDefinition, Organization, Project, PermissionLevel, ScopeType } from '../../services/iam';
 import { workspaceService } from '../../services/workspaces';
 import ConfirmDialog from '../../components/ConfirmDialog';
@@ -63,6 +63,9 @@ const TeamDetail: React.FC = () => {
   const [tokenNameError, setTokenNameError] = useState('');
   const [expiresIn, setExpiresIn] = useState<string>('30'); // 默认30天
   const [customDays, setCustomDays] = useState<number>(30);
+  const [tokenReadOnly, setTokenReadOnly] = useState(true);
+  const [tokenWorkspaces, setTokenWorkspaces] = useState('');
+  const [tokenIPAllowlist, setTokenIPAllowlist] = useState('');
   const [createdToken, setCreatedToken] = useState<string>('');
   
   // 成员添加表单
@@ -297,18 +300,19 @@ const TeamDetail: React.FC = () => {
       return;
     }
 
-    // 计算过期天数
-    let expiresInDays = 0;
-    if (expiresIn === 'custom') {
-      expiresInDays = customDays;
-    } else if (expiresIn !== 'never') {
-      expiresInDays = parseInt(expiresIn);
-    }
+    // 计算过期天数（必须设置过期时间）
+    const expiresInDays = expiresIn === 'custom' ? customDays : parseInt(expiresIn);
 
     try {
-      const response = await iamService.createTeamToken(id!, tokenName.trim(), expiresInDays);
+      const response = await iamService.createTeamToken(id!, tokenName.trim(), expiresInDays, {
+        read_only: tokenReadOnly,
+        workspace_ids: parseScopeList(tokenWorkspaces),
+        ip_allowlist: parseScopeList(tokenIPAllowlist),
+      });
       setCreatedToken(response.token.token);
       setTokenName('');
+      setTokenWorkspaces('');
+      setTokenIPAllowlist('');
       setTokenNameError('');
       setExpiresIn('30');
       setShowTokenForm(false);
@@ -962,7 +966,6 @@ const TeamDetail: React.FC = () => {
                   <option value="30">30天</option>
                   <option value="90">90天</option>
                   <option value="180">180天</option>
-                  <option value="never">永不过期</option>
                   <option value="custom">自定义</option>
                 </select>
                 {expiresIn === 'custom' && (
@@ -976,6 +979,31 @@ const TeamDetail: React.FC = () => {
                   />
                 )}
               </div>
+              <div className={styles.formField}>
+                <label className={styles.formLabel}>作用域</label>
+                <label>
+                  <input
+                    type="checkbox"
+                    checked={tokenReadOnly}
+                    onChange={(e) => setTokenReadOnly(e.target.checked)}
+                  />{' '}
+                  只读
+                </label>
+                <input
+                  type="text"
+                  className={styles.input}
+                  value={tokenWorkspaces}
+                  onChange={(e) => setTokenWorkspaces(e.target.value)}
+                  placeholder="限定工作空间：ws-xxx, ws-yyy"
+                />
+                <input
+                  type="text"
+                  className={styles.input}
+                  value={tokenIPAllowlist}
+                  onChange={(e) => setTokenIPAllowlist(e.target.value)}
+                  placeholder="IP白名单：10.0.0.0/8"
+                />
+              </div>
               <div className={styles.formActions}>
                 <button
                   type="button"
@@ -0,0 +1,107 @@
+import api from './api';
+
+// 冻结窗口
+export type ChangeFreezeScope = 'organization' | 'project';
+export type ChangeFreezeType = 'once' | 'weekly';
+
+export interface ChangeFreezeSelector {
+  project_ids?: number[];
+  tags?: Record<string, string>;
+}
+
+// 时间格式: YYYY-MM-DDTHH:MM（按窗口时区解释），weekdays 1=周一 ... 7=周日
+export interface ChangeFreezeSchedule {
+  type: ChangeFreezeType;
+  starts_at?: string;
+  ends_at?: string;
+  weekdays?: number[];
+  from_time?: string;
+  to_time?: string;
+}
+
+export interface ChangeFreezeWindow {
+  id: string;
+  name: string;
+  description: string;
+  scope: ChangeFreezeScope;
+  selector: ChangeFreezeSelector;
+  timezone: string;
+  schedule: ChangeFreezeSchedule;
+  enabled: boolean;
+  created_by: string;
+  updated_by: string;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface ChangeFreezeWindowRequest {
+  name: string;
+  description?: string;
+  scope: ChangeFreezeScope;
+  selector: ChangeFreezeSelector;
+  timezone: string;
+  schedule: ChangeFreezeSchedule;
+  enabled: boolean;
+}
+
+export interface ActiveChangeFreeze {
+  window_id: string;
+  name: string;
+  description: string;
+  scope: ChangeFreezeScope;
+  timezone: string;
+  until: string;
+}
+
+// 单次运行例外
+export type ChangeFreezeExceptionStatus = 'pending' | 'approved' | 'rejected';
+
+export interface ChangeFreezeException {
+  id: number;
+  task_id: number;
+  workspace_id: string;
+  window_ids: string;
+  reason: string;
+  status: ChangeFreezeExceptionStatus;
+  requested_by: string;
+  reviewed_by?: string;
+  review_comment: string;
+  reviewed_at?: string;
+  created_at: string;
+}
+
+export interface TaskChangeFreezeStatus {
+  active: ActiveChangeFreeze[];
+  exception: ChangeFreezeException | null;
+}
+
+export const listChangeFreezeWindows = async (): Promise<ChangeFreezeWindow[]> => {
+  const data: any = await api.get('/global/settings/change-freezes');
+  return data.items || [];
+};
+
+export const createChangeFreezeWindow = (req: ChangeFreezeWindowRequest): Promise<ChangeFreezeWindow> =>
+  api.post('/global/settings/change-freezes', req);
+
+export const updateChangeFreezeWindow = (id: string, req: ChangeFreezeWindowRequest): Promise<ChangeFreezeWindow> =>
+  api.put(`/global/settings/change-freezes/${id}`, req);
+
+export const deleteChangeFreezeWindow = (id: string) =>
+  api.delete(`/global/settings/change-freezes/${id}`);
+
+export const listChangeFreezeExceptions = async (status?: ChangeFreezeExceptionStatus): Promise<ChangeFreezeException[]> => {
+  const data: any = await api.get('/global/settings/change-freeze-exceptions', { params: status ? { status } : {} });
+  return data.items || [];
+};
+
+export const approveChangeFreezeException = (id: number, comment: string): Promise<ChangeFreezeException> =>
+  api.post(`/global/settings/change-freeze-exceptions/${id}/approve`, { comment });
+
+export const rejectChangeFreezeException = (id: number, comment: string): Promise<ChangeFreezeException> =>
+  api.post(`/global/settings/change-freeze-exceptions/${id}/reject`, { comment });
+
+export const getTaskChangeFreeze = (workspaceId: string, taskId: number | string): Promise<TaskChangeFreezeStatus> =>
+  api.get(`/workspaces/${workspaceId}/tasks/${taskId}/change-freeze`);
+
+export const requestChangeFreezeException = (workspaceId: string, taskId: number | string, reason: string): Promise<ChangeFreezeException> =>
+  api.post(`/workspaces/${workspaceId}/tasks/${taskId}/change-freeze/exception`, { reason });
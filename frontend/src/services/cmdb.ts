@@ -210,8 +210,171 @@ export const cmdbService = {
     if (limit) params.append('limit', limit.toString());
     return api.get(`/cmdb/suggestions?${params.toString()}`);
   },
+
+  // 获取资源属性变更时间线（field 可选，只返回修改了该字段的变更）
+  getResourceHistory: async (
+    workspaceId: string,
+    query: { address?: string; cloud_resource_id?: string; field?: string }
+  ): Promise<{ entries: ResourceHistoryEntry[]; total: number }> => {
+    const params = new URLSearchParams();
+    if (query.address) params.append('address', query.address);
+    if (query.cloud_resource_id) params.append('cloud_resource_id', query.cloud_resource_id);
+    if (query.field) params.append('field', query.field);
+    return api.get(`/cmdb/workspaces/${workspaceId}/resources/history?${params.toString()}`);
+  },
+
+  // 获取资源在某个时间点（或State版本）的属性
+  getResourceAsOf: async (
+    workspaceId: string,
+    query: { address?: string; cloud_resource_id?: string; at?: string; state_version_id?: number }
+  ): Promise<ResourceVersionView> => {
+    const params = new URLSearchParams();
+    if (query.address) params.append('address', query.address);
+    if (query.cloud_resource_id) params.append('cloud_resource_id', query.cloud_resource_id);
+    if (query.at) params.append('at', query.at);
+    if (query.state_version_id) params.append('state_version_id', query.state_version_id.toString());
+    return api.get(`/cmdb/workspaces/${workspaceId}/resources/as-of?${params.toString()}`);
+  },
+
+  // 获取外部CMDB与Terraform状态的对账报告
+  getReconciliationReport: async (
+    filter?: ReconciliationFilter
+  ): Promise<ReconciliationReport> => {
+    const params = new URLSearchParams();
+    if (filter?.cloud_provider) params.append('cloud_provider', filter.cloud_provider);
+    if (filter?.account_id) params.append('account_id', filter.account_id);
+    if (filter?.region) params.append('region', filter.region);
+    if (filter?.resource_type) params.append('resource_type', filter.resource_type);
+    const query = params.toString();
+    return api.get(`/cmdb/reconciliation${query ? `?${query}` : ''}`);
+  },
+
+  // 将未被管理的外部资源导入到Workspace
+  importUnmanagedResources: async (
+    request: ReconciliationImportRequest
+  ): Promise<ReconciliationImportResult> => {
+    return api.post('/cmdb/reconciliation/import', request);
+  },
 };
 
+// 资源属性变化（路径以点号分隔，如 tags.Name）
+export interface AttributeChange {
+  path: string;
+  old?: unknown;
+  new?: unknown;
+}
+
+// 资源变更时间线条目
+export interface ResourceHistoryEntry {
+  workspace_id: string;
+  terraform_address: string;
+  cloud_resource_id?: string;
+  resource_type: string;
+  state_version_id: number;
+  task_id?: number;
+  changed_at: string;
+  change_type: 'create' | 'update' | 'delete';
+  changes?: AttributeChange[];
+}
+
+// 资源在某个时间点的属性版本
+export interface ResourceVersionView {
+  workspace_id: string;
+  terraform_address: string;
+  cloud_resource_id?: string;
+  resource_type: string;
+  valid_from_state_version_id: number;
+  valid_to_state_version_id?: number;
+  valid_from: string;
+  valid_to?: string;
+  task_id?: number;
+  attributes: Record<string, unknown>;
+}
+
+// 对账过滤条件
+export interface ReconciliationFilter {
+  cloud_provider?: string;
+  account_id?: string;
+  region?: string;
+  resource_type?: string;
+}
+
+// 对账报告中的资源
+export interface ReconciliationResource {
+  resource_index_id: number;
+  source_type: 'terraform' | 'external';
+  workspace_id?: string;
+  workspace_name?: string;
+  terraform_address?: string;
+  external_source_id?: string;
+  resource_type: string;
+  cloud_resource_id?: string;
+  cloud_resource_name?: string;
+  cloud_resource_arn?: string;
+  cloud_provider?: string;
+  account_id?: string;
+  region?: string;
+  importable?: boolean;
+}
+
+// 被多个Workspace同时管理的资源
+export interface MultiManagedResource {
+  cloud_resource_id?: string;
+  cloud_resource_arn?: string;
+  resource_type: string;
+  account_id?: string;
+  region?: string;
+  workspaces: string[];
+  instances: ReconciliationResource[];
+}
+
+// 按账号/区域/类型分组的统计
+export interface ReconciliationGroup {
+  account_id: string;
+  region: string;
+  resource_type: string;
+  unmanaged: number;
+  multi_managed: number;
+  missing_externally: number;
+}
+
+export interface ReconciliationReport {
+  generated_at: string;
+  filter: ReconciliationFilter;
+  summary: {
+    external_resources: number;
+    terraform_resources: number;
+    managed: number;
+    unmanaged: number;
+    multi_managed: number;
+    missing_externally: number;
+  };
+  groups: ReconciliationGroup[];
+  unmanaged: ReconciliationResource[];
+  multi_managed: MultiManagedResource[];
+  missing_externally: ReconciliationResource[];
+}
+
+export interface ReconciliationImportItem {
+  resource_index_id: number;
+  resource_type?: string;
+  resource_name?: string;
+  import_id?: string;
+}
+
+export interface ReconciliationImportRequest {
+  workspace_id: string;
+  items: ReconciliationImportItem[];
+  dry_run?: boolean;
+}
+
+export interface ReconciliationImportResult {
+  workspace_id: string;
+  tf_code: Record<string, unknown>;
+  created: string[];
+  errors?: { resource_index_id: number; error: string }[];
+}
+
 // Workspace资源数量
 export interface WorkspaceResourceCount {
   workspace_id: string;
@@ -246,10 +409,54 @@ export interface FieldMapping {
   attributes?: string;
 }
 
+// 外部数据源类型
+export type ExternalSourceType = 'http' | 'file' | 'sql';
+
+// HTTP 分页配置
+export interface PaginationConfig {
+  strategy: 'none' | 'offset' | 'page' | 'cursor' | 'link_header';
+  page_size?: number;
+  limit_param?: string;
+  offset_param?: string;
+  page_param?: string;
+  start_page?: number;
+  cursor_param?: string;
+  next_cursor_path?: string;
+  max_pages?: number;
+}
+
+// 文件导入配置
+export interface FileSourceConfig {
+  format?: '' | 'csv' | 'json' | 'jsonl';
+  path?: string;
+  object_key?: string;
+  file_name?: string;
+  delimiter?: string;
+}
+
+// SQL 查询配置（dsn 只在创建/更新时提交，不会返回）
+export interface SQLSourceConfig {
+  driver: string;
+  query: string;
+  max_rows?: number;
+  dsn?: string;
+  has_dsn?: boolean;
+}
+
+// 数据源类型相关配置
+export interface ExternalSourceConfig {
+  pagination?: PaginationConfig;
+  rate_limit_per_second?: number;
+  file?: FileSourceConfig;
+  sql?: SQLSourceConfig;
+}
+
 // 创建外部数据源请求
 export interface CreateExternalSourceRequest {
   name: string;
   description?: string;
+  source_type?: ExternalSourceType;
+  source_config?: ExternalSourceConfig;
   api_endpoint: string;
   http_method?: 'GET' | 'POST';
   request_body?: string;
@@ -269,6 +476,8 @@ export interface CreateExternalSourceRequest {
 export interface UpdateExternalSourceRequest {
   name?: string;
   description?: string;
+  source_type?: ExternalSourceType;
+  source_config?: ExternalSourceConfig;
   api_endpoint?: string;
   http_method?: 'GET' | 'POST';
   request_body?: string;
@@ -290,6 +499,8 @@ export interface ExternalSourceResponse {
   source_id: string;
   name: string;
   description?: string;
+  source_type: ExternalSourceType;
+  source_config?: ExternalSourceConfig;
   api_endpoint: string;
   http_method: string;
   request_body?: string;
@@ -385,6 +596,15 @@ export const externalSourceService = {
     return api.post(`/cmdb/external-sources/${sourceId}/test`);
   },
 
+  // 上传文件数据源的导入文件
+  uploadSourceFile: async (sourceId: string, file: File): Promise<ExternalSourceResponse> => {
+    const formData = new FormData();
+    formData.append('file', file);
+    return api.post(`/cmdb/external-sources/${sourceId}/upload`, formData, {
+      headers: { 'Content-Type': 'multipart/form-data' },
+    });
+  },
+
   // 获取同步日志
   getSyncLogs: async (sourceId: string, limit?: number): Promise<SyncLogListResponse> => {
     const params = limit ? `?limit=${limit}` : '';
@@ -355,11 +355,12 @@ export const iamService = {
 
   // ==================== 团队Token管理 ====================
 
-  // 创建团队Token
-  createTeamToken: async (teamId: string, tokenName: string, expiresInDays: number): Promise<{ message: string; token: any }> => {
+  // 创建团队Token（必须指定过期时间和作用域）
+  createTeamToken: async (teamId: string, tokenName: string, expiresInDays: number, scopes: TokenScope): Promise<{ message: string; token: any }> => {
     return await api.post(`/iam/teams/${teamId}/tokens`, { 
       token_name: tokenName,
-      expires_in_days: expiresInDays 
+      expires_in_days: expiresInDays,
+      scopes
     });
   },
 
@@ -510,3 +511,16 @@ export const iamService = {
     return await api.put('/iam/audit/config', config);
   },
 };
+
+// API Token 作用域，Token 的实际权限为持有者权限与作用域的交集
+export interface TokenScope {
+  read_only: boolean;
+  workspace_ids?: string[];
+  project_ids?: number[];
+  resource_types?: string[];
+  ip_allowlist?: string[];
+}
+
+// 将逗号或换行分隔的输入解析为列表
+export const parseScopeList = (value: string): string[] =>
+  value.split(/[,\n]/).map(v => v.trim()).filter(Boolean);
@@ -33,6 +33,7 @@ export interface Skill {
   created_by?: string;
   created_at: string;
   updated_at: string;
+  eval_warnings?: string[];  // 更新内容时评估门禁的提示（未评估或评估无结论）
 }
 
 // Skill 列表响应
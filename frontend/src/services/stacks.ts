@@ -0,0 +1,143 @@
+import api from './api';
+
+// Stack: 按依赖顺序协同 plan/apply 的一组 workspace
+export type StackEdgeType = 'explicit' | 'remote_data' | 'run_trigger';
+
+export interface StackEdge {
+  from: string;
+  to: string;
+  type: StackEdgeType;
+  detail?: string;
+}
+
+export interface StackGraph {
+  edges: StackEdge[];
+  layers: string[][];
+}
+
+export interface StackMember {
+  id: number;
+  stack_id: string;
+  workspace_id: string;
+  workspace_name?: string;
+  depends_on: string[] | null;
+  created_at: string;
+}
+
+export interface Stack {
+  id: string;
+  name: string;
+  description: string;
+  created_by: string;
+  updated_by?: string;
+  created_at: string;
+  updated_at: string;
+  members?: StackMember[];
+  graph?: StackGraph;
+  graph_error?: string;
+}
+
+export interface StackMemberRequest {
+  workspace_id: string;
+  depends_on?: string[];
+}
+
+export interface StackRequest {
+  name: string;
+  description?: string;
+  members: StackMemberRequest[];
+}
+
+// 协同执行
+export type StackRunStatus = 'planning' | 'awaiting_approval' | 'applying' | 'completed' | 'failed' | 'cancelled';
+export type StackMemberStatus =
+  | 'pending' | 'planning' | 'planned' | 'no_changes' | 'applying' | 'applied' | 'failed' | 'skipped';
+
+export interface StackRunSummary {
+  total: number;
+  pending: number;
+  planning: number;
+  planned: number;
+  no_changes: number;
+  applying: number;
+  applied: number;
+  failed: number;
+  skipped: number;
+  changes_add: number;
+  changes_change: number;
+  changes_destroy: number;
+}
+
+export interface StackResourceChange {
+  id: number;
+  resource_address: string;
+  resource_type: string;
+  action: string;
+  apply_status: string;
+}
+
+export interface StackRunMember {
+  id: number;
+  run_id: string;
+  workspace_id: string;
+  workspace_name?: string;
+  layer: number;
+  depends_on: string[] | null;
+  status: StackMemberStatus;
+  task_id?: number;
+  changes_add: number;
+  changes_change: number;
+  changes_destroy: number;
+  error: string;
+  started_at?: string;
+  completed_at?: string;
+  resources?: StackResourceChange[];
+}
+
+export interface StackRun {
+  id: string;
+  stack_id: string;
+  status: StackRunStatus;
+  description: string;
+  message: string;
+  created_by: string;
+  approved_by?: string;
+  approved_at?: string;
+  completed_at?: string;
+  created_at: string;
+  updated_at: string;
+  summary?: StackRunSummary;
+  members?: StackRunMember[];
+}
+
+export const ACTIVE_STACK_RUN_STATUSES: StackRunStatus[] = ['planning', 'awaiting_approval', 'applying'];
+
+export const listStacks = async (): Promise<Stack[]> => {
+  const data: any = await api.get('/stacks');
+  return data.items || [];
+};
+
+export const getStack = (id: string): Promise<Stack> => api.get(`/stacks/${id}`);
+
+export const createStack = (req: StackRequest): Promise<Stack> => api.post('/stacks', req);
+
+export const updateStack = (id: string, req: StackRequest): Promise<Stack> => api.put(`/stacks/${id}`, req);
+
+export const deleteStack = (id: string) => api.delete(`/stacks/${id}`);
+
+export const listStackRuns = async (stackId: string): Promise<StackRun[]> => {
+  const data: any = await api.get(`/stacks/${stackId}/runs`);
+  return data.items || [];
+};
+
+export const createStackRun = (stackId: string, description: string): Promise<StackRun> =>
+  api.post(`/stacks/${stackId}/runs`, { description });
+
+export const getStackRunReview = (stackId: string, runId: string): Promise<StackRun> =>
+  api.get(`/stacks/${stackId}/runs/${runId}/review`);
+
+export const approveStackRun = (stackId: string, runId: string): Promise<StackRun> =>
+  api.post(`/stacks/${stackId}/runs/${runId}/approve`);
+
+export const cancelStackRun = (stackId: string, runId: string): Promise<StackRun> =>
+  api.post(`/stacks/${stackId}/runs/${runId}/cancel`);